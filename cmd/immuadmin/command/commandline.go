@@ -37,6 +37,8 @@ type Commandline interface {
 	stats(cmd *cobra.Command)
 	serverConfig(cmd *cobra.Command)
 	database(cmd *cobra.Command)
+	replication(cmd *cobra.Command)
+	job(cmd *cobra.Command)
 	ConfigChain(post func(cmd *cobra.Command, args []string) error) func(cmd *cobra.Command, args []string) (err error)
 }
 
@@ -91,6 +93,8 @@ func (cl *commandline) Register(rootCmd *cobra.Command) *cobra.Command {
 	cl.stats(rootCmd)
 	cl.serverConfig(rootCmd)
 	cl.database(rootCmd)
+	cl.replication(rootCmd)
+	cl.job(rootCmd)
 	return rootCmd
 }
 
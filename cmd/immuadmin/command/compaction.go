@@ -0,0 +1,66 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package immuadmin
+
+import (
+	"errors"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/spf13/cobra"
+)
+
+func (cl *commandline) compaction() *cobra.Command {
+	ccmd := &cobra.Command{
+		Use:               "compaction",
+		Short:             "Manage index compaction jobs",
+		PersistentPostRun: cl.disconnect,
+		ValidArgs:         []string{"status"},
+	}
+
+	csc := &cobra.Command{
+		Use:               "status",
+		Short:             "Show the progress of an index compaction job, the latest one is shown when no job id is provided",
+		Example:           "status [job_id]",
+		PersistentPreRunE: cl.ConfigChain(cl.connect),
+		PersistentPostRun: cl.disconnect,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) > 0 {
+				job, err := cl.immuClient.GetJob(cl.context, args[0])
+				if err != nil {
+					return err
+				}
+
+				return printJob(cmd, job)
+			}
+
+			jobs, err := cl.immuClient.ListJobs(cl.context, schema.JobKindCompaction)
+			if err != nil {
+				return err
+			}
+
+			if len(jobs.Jobs) == 0 {
+				return errors.New("no index compaction job found")
+			}
+
+			return printJob(cmd, jobs.Jobs[0])
+		},
+		Args: cobra.MaximumNArgs(1),
+	}
+
+	ccmd.AddCommand(csc)
+
+	return ccmd
+}
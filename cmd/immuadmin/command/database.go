@@ -18,15 +18,27 @@ package immuadmin
 
 import (
 	"fmt"
+	"io"
+	"math"
 	"strings"
+	"time"
 
 	c "github.com/codenotary/immudb/cmd/helper"
 	"github.com/codenotary/immudb/pkg/api/schema"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
-	"google.golang.org/protobuf/types/known/emptypb"
 )
 
+type databaseListOutput struct {
+	Databases []databaseOutput `json:"databases" yaml:"databases"`
+}
+
+type databaseOutput struct {
+	Name        string `json:"name" yaml:"name"`
+	Current     bool   `json:"current" yaml:"current"`
+	Maintenance bool   `json:"maintenance" yaml:"maintenance"`
+}
+
 func addDbUpdateFlags(c *cobra.Command) {
 	c.Flags().Bool("exclude-commit-time", false,
 		"do not include server-side timestamps in commit checksums, useful when reproducibility is a desired feature")
@@ -37,6 +49,14 @@ func addDbUpdateFlags(c *cobra.Command) {
 	c.Flags().String("replication-follower-username", "", "set username used for replication")
 	c.Flags().String("replication-follower-password", "", "set password used for replication")
 	c.Flags().Uint32("write-tx-header-version", 1, "set write tx header version (use 0 for compatibility with immudb 1.1, 1 for immudb 1.2+)")
+	c.Flags().String("value-compression", "none", "set the algorithm used to compress values (none, zstd or s2), it can be changed at any time")
+	c.Flags().Uint32("value-compression-threshold", 512, "set the minimum length of the values to be compressed")
+	c.Flags().Bool("anonymous-reads", false, "allow reading the database without logging in, writes still require authentication")
+	c.Flags().Bool("maintenance-mode", false, "make the database temporarily read-only, writes are rejected until maintenance mode is switched off")
+	c.Flags().Uint64("max-disk-usage", 0, "set the disk space in bytes the database may take before writes are rejected (0 means unlimited)")
+	c.Flags().Bool("reverse-reference-index", false, "write a reverse entry along with each reference so the references to a key can be resolved")
+	c.Flags().Duration("scrub-interval", 0, "set the time between background scrubs verifying every committed transaction (0 disables them), applied once the database is reloaded")
+	c.Flags().Duration("delay-during-scrub", 10*time.Millisecond, "set the pause taken after each batch of scrubbed transactions, applied once the database is reloaded")
 }
 
 func (cl *commandline) database(cmd *cobra.Command) {
@@ -46,7 +66,7 @@ func (cl *commandline) database(cmd *cobra.Command) {
 		Aliases: []string{"d"},
 		//PersistentPreRunE: cl.ConfigChain(cl.connect),
 		PersistentPostRun: cl.disconnect,
-		ValidArgs:         []string{"list", "create", "update", "use", "clean"},
+		ValidArgs:         []string{"list", "create", "update", "use", "clean", "compaction"},
 	}
 
 	ccd := &cobra.Command{
@@ -60,21 +80,40 @@ func (cl *commandline) database(cmd *cobra.Command) {
 			if err != nil {
 				return err
 			}
-			c.PrintTable(
-				cmd.OutOrStdout(),
-				[]string{"Database Name"},
-				len(resp.Databases),
-				func(i int) []string {
-					row := make([]string, 1)
-					if cl.options.CurrentDatabase == resp.Databases[i].DatabaseName {
-						row[0] += fmt.Sprintf("*")
-					}
-					row[0] += fmt.Sprintf("%s", resp.Databases[i].DatabaseName)
-					return row
-				},
-				fmt.Sprintf("%d database(s)", len(resp.Databases)),
-			)
-			return nil
+
+			inMaintenance := make(map[string]bool, len(resp.InMaintenance))
+			for _, db := range resp.InMaintenance {
+				inMaintenance[db] = true
+			}
+
+			out := databaseListOutput{Databases: make([]databaseOutput, len(resp.Databases))}
+			for i, db := range resp.Databases {
+				out.Databases[i] = databaseOutput{
+					Name:        db.DatabaseName,
+					Current:     cl.options.CurrentDatabase == db.DatabaseName,
+					Maintenance: inMaintenance[db.DatabaseName],
+				}
+			}
+
+			return printOutput(cmd, out, func(w io.Writer) {
+				c.PrintTable(
+					w,
+					[]string{"Database Name"},
+					len(resp.Databases),
+					func(i int) []string {
+						row := make([]string, 1)
+						if cl.options.CurrentDatabase == resp.Databases[i].DatabaseName {
+							row[0] += fmt.Sprintf("*")
+						}
+						row[0] += fmt.Sprintf("%s", resp.Databases[i].DatabaseName)
+						if inMaintenance[resp.Databases[i].DatabaseName] {
+							row[0] += " (maintenance)"
+						}
+						return row
+					},
+					fmt.Sprintf("%d database(s)", len(resp.Databases)),
+				)
+			})
 		},
 		Args: cobra.ExactArgs(0),
 	}
@@ -91,6 +130,11 @@ func (cl *commandline) database(cmd *cobra.Command) {
 				return err
 			}
 
+			settings.Template, err = cmd.Flags().GetString("template")
+			if err != nil {
+				return err
+			}
+
 			_, err = cl.immuClient.CreateDatabaseV2(cl.context, settings)
 			if err != nil {
 				return err
@@ -103,6 +147,7 @@ func (cl *commandline) database(cmd *cobra.Command) {
 		Args: cobra.ExactArgs(1),
 	}
 	addDbUpdateFlags(cc)
+	cc.Flags().String("template", "", "name of the server-side template the database settings are based on")
 
 	cu := &cobra.Command{
 		Use:               "update",
@@ -116,13 +161,21 @@ func (cl *commandline) database(cmd *cobra.Command) {
 				return err
 			}
 
-			if _, err := cl.immuClient.UpdateDatabaseV2(cl.context, settings); err != nil {
+			res, err := cl.immuClient.UpdateDatabaseV2(cl.context, settings)
+			if err != nil {
 				return err
 			}
 
 			fmt.Fprintf(cmd.OutOrStdout(),
 				"database '%s' {%s} successfully updated\n",
 				args[0], databaseSettingsStr(settings))
+
+			if len(res.GetReloadRequired()) > 0 {
+				fmt.Fprintf(cmd.OutOrStdout(),
+					"database '%s' must be reloaded to apply: %s\n",
+					args[0], strings.Join(res.GetReloadRequired(), ", "))
+			}
+
 			return nil
 		},
 		Args: cobra.ExactArgs(1),
@@ -188,11 +241,28 @@ func (cl *commandline) database(cmd *cobra.Command) {
 	ccc := &cobra.Command{
 		Use:               "compact command",
 		Short:             "Compact database index",
-		Example:           "compact",
+		Long:              "Start the compaction of the database index in background. Use --wait to wait for it to finish.",
+		Example:           "compact --wait",
 		PersistentPreRunE: cl.ConfigChain(cl.connect),
 		PersistentPostRun: cl.disconnect,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			err := cl.immuClient.CompactIndex(cl.context, &emptypb.Empty{})
+			wait, err := cmd.Flags().GetBool("wait")
+			if err != nil {
+				return err
+			}
+
+			job, err := cl.immuClient.CompactIndexAsync(cl.context)
+			if err != nil {
+				return err
+			}
+
+			if !wait {
+				fmt.Fprintf(cmd.OutOrStdout(), "Index compaction started with job id %s\n", job.Id)
+				fmt.Fprintf(cmd.OutOrStdout(), "Use 'immuadmin job get %s' to follow its progress\n", job.Id)
+				return nil
+			}
+
+			_, err = cl.waitForJob(cmd, job)
 			if err != nil {
 				return err
 			}
@@ -202,13 +272,20 @@ func (cl *commandline) database(cmd *cobra.Command) {
 		},
 		Args: cobra.ExactArgs(0),
 	}
+	ccc.Flags().Bool("wait", false, "wait for the compaction to finish, reporting its progress")
 
 	ccmd.AddCommand(fcc)
 	ccmd.AddCommand(ccc)
+	ccmd.AddCommand(cl.compaction())
 	ccmd.AddCommand(ccu)
 	ccmd.AddCommand(ccd)
 	ccmd.AddCommand(cc)
 	ccmd.AddCommand(cu)
+	ccmd.AddCommand(cl.cloneDatabase())
+	ccmd.AddCommand(cl.databaseDump()...)
+	ccmd.AddCommand(cl.settingsHistory()...)
+	ccmd.AddCommand(cl.keyspaceStats())
+	ccmd.AddCommand(cl.scrub()...)
 	cmd.AddCommand(ccmd)
 }
 
@@ -249,6 +326,31 @@ func prepareDatabaseSettings(db string, flags *pflag.FlagSet) (*schema.DatabaseS
 		return nil, nil
 	}
 
+	condUInt64 := func(name string) (*schema.ConditionalUint64, error) {
+		if flags.Changed(name) {
+			val, err := flags.GetUint64(name)
+			if err != nil {
+				return nil, err
+			}
+			return &schema.ConditionalUint64{Value: val}, nil
+		}
+		return nil, nil
+	}
+
+	condDuration := func(name string) (*time.Duration, error) {
+		if flags.Changed(name) {
+			val, err := flags.GetDuration(name)
+			if err != nil {
+				return nil, err
+			}
+			if val < 0 {
+				return nil, fmt.Errorf("invalid value for --%s: %s", name, val)
+			}
+			return &val, nil
+		}
+		return nil, nil
+	}
+
 	ret := &schema.DatabaseSettingsV2{
 		DatabaseName:        db,
 		ReplicationSettings: &schema.ReplicationSettings{},
@@ -294,12 +396,65 @@ func prepareDatabaseSettings(db string, flags *pflag.FlagSet) (*schema.DatabaseS
 		return nil, err
 	}
 
+	ret.ValueCompression, err = condString("value-compression")
+	if err != nil {
+		return nil, err
+	}
+
+	ret.ValueCompressionThreshold, err = condUInt32("value-compression-threshold")
+	if err != nil {
+		return nil, err
+	}
+
+	ret.AnonymousReads, err = condBool("anonymous-reads")
+	if err != nil {
+		return nil, err
+	}
+
+	ret.MaintenanceMode, err = condBool("maintenance-mode")
+	if err != nil {
+		return nil, err
+	}
+
+	ret.MaxDiskUsage, err = condUInt64("max-disk-usage")
+	if err != nil {
+		return nil, err
+	}
+
+	ret.ReverseReferenceIndex, err = condBool("reverse-reference-index")
+	if err != nil {
+		return nil, err
+	}
+
+	scrubInterval, err := condDuration("scrub-interval")
+	if err != nil {
+		return nil, err
+	}
+	if scrubInterval != nil {
+		ret.ScrubInterval = &schema.ConditionalUint64{Value: uint64(scrubInterval.Milliseconds())}
+	}
+
+	delayDuringScrub, err := condDuration("delay-during-scrub")
+	if err != nil {
+		return nil, err
+	}
+	if delayDuringScrub != nil {
+		if delayDuringScrub.Milliseconds() > math.MaxUint32 {
+			return nil, fmt.Errorf("invalid value for --delay-during-scrub: %s", delayDuringScrub)
+		}
+		ret.DelayDuringScrub = &schema.ConditionalUint32{Value: uint32(delayDuringScrub.Milliseconds())}
+	}
+
 	return ret, nil
 }
 
 func databaseSettingsStr(settings *schema.DatabaseSettingsV2) string {
 	propertiesStr := []string{}
 
+	if settings.Template != "" {
+		propertiesStr = append(propertiesStr, fmt.Sprintf("template: %s", settings.Template))
+	}
+
 	if settings.ReplicationSettings != nil {
 		propertiesStr = append(propertiesStr, fmt.Sprintf("replica: %v", settings.ReplicationSettings.Replica.GetValue()))
 	}
@@ -312,5 +467,37 @@ func databaseSettingsStr(settings *schema.DatabaseSettingsV2) string {
 		propertiesStr = append(propertiesStr, fmt.Sprintf("write-tx-header-version: %d", settings.WriteTxHeaderVersion.GetValue()))
 	}
 
+	if settings.ValueCompression != nil {
+		propertiesStr = append(propertiesStr, fmt.Sprintf("value-compression: %s", settings.ValueCompression.GetValue()))
+	}
+
+	if settings.ValueCompressionThreshold != nil {
+		propertiesStr = append(propertiesStr, fmt.Sprintf("value-compression-threshold: %d", settings.ValueCompressionThreshold.GetValue()))
+	}
+
+	if settings.AnonymousReads != nil {
+		propertiesStr = append(propertiesStr, fmt.Sprintf("anonymous-reads: %v", settings.AnonymousReads.GetValue()))
+	}
+
+	if settings.MaintenanceMode != nil {
+		propertiesStr = append(propertiesStr, fmt.Sprintf("maintenance-mode: %v", settings.MaintenanceMode.GetValue()))
+	}
+
+	if settings.MaxDiskUsage != nil {
+		propertiesStr = append(propertiesStr, fmt.Sprintf("max-disk-usage: %d", settings.MaxDiskUsage.GetValue()))
+	}
+
+	if settings.ReverseReferenceIndex != nil {
+		propertiesStr = append(propertiesStr, fmt.Sprintf("reverse-reference-index: %v", settings.ReverseReferenceIndex.GetValue()))
+	}
+
+	if settings.ScrubInterval != nil {
+		propertiesStr = append(propertiesStr, fmt.Sprintf("scrub-interval: %s", time.Duration(settings.ScrubInterval.GetValue())*time.Millisecond))
+	}
+
+	if settings.DelayDuringScrub != nil {
+		propertiesStr = append(propertiesStr, fmt.Sprintf("delay-during-scrub: %s", time.Duration(settings.DelayDuringScrub.GetValue())*time.Millisecond))
+	}
+
 	return strings.Join(propertiesStr, ", ")
 }
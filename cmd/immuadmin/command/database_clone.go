@@ -0,0 +1,58 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package immuadmin
+
+import (
+	"fmt"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/spf13/cobra"
+)
+
+func (cl *commandline) cloneDatabase() *cobra.Command {
+	ccl := &cobra.Command{
+		Use:               "clone",
+		Short:             "Create a read-only database holding the state of another database up to a given transaction",
+		Example:           "clone {source_database_name} {database_name} --as-of-tx 1000",
+		PersistentPreRunE: cl.ConfigChain(cl.connect),
+		PersistentPostRun: cl.disconnect,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			asOfTx, err := cmd.Flags().GetUint64("as-of-tx")
+			if err != nil {
+				return err
+			}
+
+			res, err := cl.immuClient.CloneDatabase(cl.context, &schema.CloneDatabaseRequest{
+				SourceDatabase: args[0],
+				DatabaseName:   args[1],
+				AsOfTx:         asOfTx,
+			})
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(),
+				"database '%s' successfully cloned into database '%s' up to tx %d\n",
+				args[0], res.Settings.DatabaseName, res.TxId)
+			return nil
+		},
+		Args: cobra.ExactArgs(2),
+	}
+	ccl.Flags().Uint64("as-of-tx", 0, "last transaction included in the clone, the latest one when not set")
+
+	return ccl
+}
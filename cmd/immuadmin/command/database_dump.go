@@ -0,0 +1,448 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package immuadmin
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/auth"
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// A database dump starts with the dump prefix and format version, followed by records made of
+// a record type byte, the big endian uint32 length of the record and its content:
+//
+//	settings record: database settings as json, as accepted by the REST API
+//	user record:     json holding a user and its permission on the database
+//	tx record:       big endian uint64 tx id, alh of the tx and the tx as exported for replication
+//
+// The settings record always comes first, transactions are kept in order.
+const (
+	dumpPrefix            = "IMMUDUMP"
+	latestDumpFileVersion = 1
+)
+
+const (
+	dumpSettingsRecord byte = iota + 1
+	dumpUserRecord
+	dumpTxRecord
+)
+
+var ErrMalformedDump = errors.New("malformed database dump")
+
+type dumpUser struct {
+	User       string `json:"user"`
+	Permission uint32 `json:"permission"`
+	Active     bool   `json:"active"`
+}
+
+func (cl *commandline) databaseDump() []*cobra.Command {
+	cex := &cobra.Command{
+		Use:               "export",
+		Short:             "Export the database into a portable dump, which can be imported into any immudb server",
+		Example:           "export {database_name} --output mydb.dump --users",
+		PersistentPreRunE: cl.ConfigChain(cl.connect),
+		PersistentPostRun: cl.disconnect,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			output, err := cmd.Flags().GetString("output")
+			if err != nil {
+				return err
+			}
+
+			users, err := cmd.Flags().GetBool("users")
+			if err != nil {
+				return err
+			}
+
+			w := io.Writer(cmd.OutOrStdout())
+
+			if output != "-" {
+				f, err := os.OpenFile(output, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+
+				w = f
+			}
+
+			lastTx, err := cl.exportDatabase(w, args[0], users)
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cmd.ErrOrStderr(), "database '%s' successfully exported up to tx %d\n", args[0], lastTx)
+			return nil
+		},
+		Args: cobra.ExactArgs(1),
+	}
+	cex.Flags().StringP("output", "o", "-", "output file, \"-\" for stdout")
+	cex.Flags().Bool("users", false, "include the users with permissions on the database")
+
+	cim := &cobra.Command{
+		Use:               "import",
+		Short:             "Create a database from a dump made with the export command",
+		Example:           "import {database_name} --input mydb.dump",
+		PersistentPreRunE: cl.ConfigChain(cl.connect),
+		PersistentPostRun: cl.disconnect,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			input, err := cmd.Flags().GetString("input")
+			if err != nil {
+				return err
+			}
+
+			usersPassword, err := cmd.Flags().GetString("users-password")
+			if err != nil {
+				return err
+			}
+
+			r := cmd.InOrStdin()
+
+			if input != "-" {
+				f, err := os.Open(input)
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+
+				r = f
+			}
+
+			lastTx, err := cl.importDatabase(cmd, r, args[0], usersPassword)
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "database '%s' successfully imported up to tx %d\n", args[0], lastTx)
+			return nil
+		},
+		Args: cobra.ExactArgs(1),
+	}
+	cim.Flags().StringP("input", "i", "-", "input file, \"-\" for stdin")
+	cim.Flags().String("users-password", "", "password of the dumped users not found in the server, they are not created when not set")
+
+	return []*cobra.Command{cex, cim}
+}
+
+func (cl *commandline) exportDatabase(w io.Writer, db string, users bool) (uint64, error) {
+	udr, err := cl.immuClient.UseDatabase(cl.context, &schema.Database{DatabaseName: db})
+	if err != nil {
+		return 0, err
+	}
+	cl.context = metadata.NewOutgoingContext(cl.context, metadata.Pairs("authorization", udr.GetToken()))
+
+	settings, err := cl.immuClient.GetDatabaseSettingsV2(cl.context)
+	if err != nil {
+		return 0, err
+	}
+
+	// name, template and replication settings belong to the source server
+	settings.DatabaseName = ""
+	settings.Template = ""
+	settings.ReplicationSettings = nil
+
+	serializedSettings, err := protojson.Marshal(settings)
+	if err != nil {
+		return 0, err
+	}
+
+	header := make([]byte, len(dumpPrefix)+4)
+	copy(header, dumpPrefix)
+	binary.BigEndian.PutUint32(header[len(dumpPrefix):], latestDumpFileVersion)
+
+	_, err = w.Write(header)
+	if err != nil {
+		return 0, err
+	}
+
+	err = writeDumpRecord(w, dumpSettingsRecord, serializedSettings)
+	if err != nil {
+		return 0, err
+	}
+
+	if users {
+		err = cl.exportDatabaseUsers(w, db)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	state, err := cl.immuClient.CurrentState(cl.context)
+	if err != nil {
+		return 0, err
+	}
+
+	for tx := uint64(1); tx <= state.TxId; tx++ {
+		alh, content, err := cl.exportTx(tx)
+		if err != nil {
+			return 0, err
+		}
+
+		record := make([]byte, 8+sha256.Size+len(content))
+		binary.BigEndian.PutUint64(record, tx)
+		copy(record[8:], alh[:])
+		copy(record[8+sha256.Size:], content)
+
+		err = writeDumpRecord(w, dumpTxRecord, record)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return state.TxId, nil
+}
+
+func (cl *commandline) exportDatabaseUsers(w io.Writer, db string) error {
+	userList, err := cl.immuClient.ListUsers(cl.context)
+	if err != nil {
+		return err
+	}
+
+	for _, u := range userList.Users {
+		for _, p := range u.Permissions {
+			if p.Database != db || p.Permission == auth.PermissionSysAdmin {
+				continue
+			}
+
+			serializedUser, err := json.Marshal(&dumpUser{
+				User:       string(u.User),
+				Permission: p.Permission,
+				Active:     u.Active,
+			})
+			if err != nil {
+				return err
+			}
+
+			err = writeDumpRecord(w, dumpUserRecord, serializedUser)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (cl *commandline) importDatabase(cmd *cobra.Command, r io.Reader, db string, usersPassword string) (uint64, error) {
+	header := make([]byte, len(dumpPrefix)+4)
+
+	_, err := io.ReadFull(r, header)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrMalformedDump, err)
+	}
+
+	if !bytes.Equal(header[:len(dumpPrefix)], []byte(dumpPrefix)) {
+		return 0, ErrMalformedDump
+	}
+
+	version := binary.BigEndian.Uint32(header[len(dumpPrefix):])
+	if version > latestDumpFileVersion {
+		return 0, fmt.Errorf("%w: unsupported dump version %d", ErrMalformedDump, version)
+	}
+
+	recordType, content, err := readDumpRecord(r)
+	if err != nil {
+		return 0, err
+	}
+
+	if recordType != dumpSettingsRecord {
+		return 0, fmt.Errorf("%w: database settings not found", ErrMalformedDump)
+	}
+
+	settings := &schema.DatabaseSettingsV2{}
+
+	err = protojson.Unmarshal(content, settings)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrMalformedDump, err)
+	}
+
+	// the database is created as a replica, so transactions can be replicated into it
+	settings.DatabaseName = db
+	settings.ReplicationSettings = &schema.ReplicationSettings{
+		Replica: &schema.ConditionalBool{Value: true},
+	}
+
+	_, err = cl.immuClient.CreateDatabaseV2(cl.context, settings)
+	if err != nil {
+		return 0, err
+	}
+
+	sysCtx := cl.context
+
+	udr, err := cl.immuClient.UseDatabase(cl.context, &schema.Database{DatabaseName: db})
+	if err != nil {
+		return 0, err
+	}
+	cl.context = metadata.NewOutgoingContext(cl.context, metadata.Pairs("authorization", udr.GetToken()))
+
+	lastTx, err := cl.importDatabaseRecords(cmd, r, db, usersPassword)
+	if err != nil {
+		return 0, fmt.Errorf("database '%s' was partially imported: %w", db, err)
+	}
+
+	_, err = cl.immuClient.UpdateDatabaseV2(sysCtx, &schema.DatabaseSettingsV2{
+		DatabaseName: db,
+		ReplicationSettings: &schema.ReplicationSettings{
+			Replica: &schema.ConditionalBool{Value: false},
+		},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("cannot switch off replica mode for db: %w", err)
+	}
+
+	return lastTx, nil
+}
+
+func (cl *commandline) importDatabaseRecords(cmd *cobra.Command, r io.Reader, db string, usersPassword string) (uint64, error) {
+	var existingUsers map[string]bool
+
+	lastTx := uint64(0)
+
+	for {
+		recordType, content, err := readDumpRecord(r)
+		if errors.Is(err, io.EOF) {
+			return lastTx, nil
+		}
+		if err != nil {
+			return lastTx, err
+		}
+
+		switch recordType {
+		case dumpUserRecord:
+			if existingUsers == nil {
+				existingUsers, err = cl.existingUsers()
+				if err != nil {
+					return lastTx, err
+				}
+			}
+
+			err = cl.importDatabaseUser(cmd, content, db, usersPassword, existingUsers)
+			if err != nil {
+				return lastTx, err
+			}
+		case dumpTxRecord:
+			if len(content) < 8+sha256.Size {
+				return lastTx, ErrMalformedDump
+			}
+
+			tx := binary.BigEndian.Uint64(content)
+			if tx != lastTx+1 {
+				return lastTx, ErrTxWrongOrder
+			}
+
+			err = cl.restoreTx(content[8:8+sha256.Size], content[8+sha256.Size:])
+			if err != nil {
+				return lastTx, err
+			}
+
+			lastTx = tx
+		default:
+			return lastTx, fmt.Errorf("%w: unexpected record type %d", ErrMalformedDump, recordType)
+		}
+	}
+}
+
+func (cl *commandline) existingUsers() (map[string]bool, error) {
+	userList, err := cl.immuClient.ListUsers(cl.context)
+	if err != nil {
+		return nil, err
+	}
+
+	users := make(map[string]bool, len(userList.Users))
+	for _, u := range userList.Users {
+		users[string(u.User)] = true
+	}
+
+	return users, nil
+}
+
+func (cl *commandline) importDatabaseUser(cmd *cobra.Command, content []byte, db string, usersPassword string, existingUsers map[string]bool) error {
+	var u dumpUser
+
+	err := json.Unmarshal(content, &u)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrMalformedDump, err)
+	}
+
+	if existingUsers[u.User] {
+		return cl.immuClient.ChangePermission(cl.context, schema.PermissionAction_GRANT, u.User, db, u.Permission)
+	}
+
+	if usersPassword == "" {
+		fmt.Fprintf(cmd.ErrOrStderr(), "user '%s' not found, its permission on database '%s' was not imported\n", u.User, db)
+		return nil
+	}
+
+	err = cl.immuClient.CreateUser(cl.context, []byte(u.User), []byte(usersPassword), u.Permission, db)
+	if err != nil {
+		return err
+	}
+
+	existingUsers[u.User] = true
+
+	if !u.Active {
+		return cl.immuClient.SetActiveUser(cl.context, &schema.SetActiveUserRequest{Username: u.User, Active: false})
+	}
+
+	return nil
+}
+
+func writeDumpRecord(w io.Writer, recordType byte, content []byte) error {
+	header := make([]byte, 1+4)
+	header[0] = recordType
+	binary.BigEndian.PutUint32(header[1:], uint32(len(content)))
+
+	_, err := w.Write(header)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(content)
+	return err
+}
+
+// readDumpRecord returns io.EOF only when there are no more records
+func readDumpRecord(r io.Reader) (byte, []byte, error) {
+	header := make([]byte, 1+4)
+
+	_, err := io.ReadFull(r, header)
+	if errors.Is(err, io.EOF) {
+		return 0, nil, io.EOF
+	}
+	if err != nil {
+		return 0, nil, fmt.Errorf("%w: %v", ErrMalformedDump, err)
+	}
+
+	content := make([]byte, binary.BigEndian.Uint32(header[1:]))
+
+	_, err = io.ReadFull(r, content)
+	if err != nil {
+		return 0, nil, fmt.Errorf("%w: %v", ErrMalformedDump, err)
+	}
+
+	return header[0], content, nil
+}
@@ -0,0 +1,150 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package immuadmin
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/auth"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestDatabaseExportImport(t *testing.T) {
+	cl := getCmdline()
+	require.NotNil(t, cl)
+
+	ctx := cl.context
+
+	_, err := cl.immuClient.CreateDatabaseV2(ctx, &schema.DatabaseSettingsV2{
+		DatabaseName:   "dumpsrc",
+		MaxConcurrency: &schema.ConditionalUint32{Value: 20},
+	})
+	require.NoError(t, err)
+
+	err = cl.immuClient.CreateUser(ctx, []byte("dumpuser"), []byte("Pass1234!"), auth.PermissionRW, "dumpsrc")
+	require.NoError(t, err)
+
+	udr, err := cl.immuClient.UseDatabase(ctx, &schema.Database{DatabaseName: "dumpsrc"})
+	require.NoError(t, err)
+
+	srcCtx := metadata.NewOutgoingContext(ctx, metadata.Pairs("authorization", udr.GetToken()))
+
+	for i := 0; i < 5; i++ {
+		_, err = cl.immuClient.Set(srcCtx, []byte(fmt.Sprintf("key%d", i)), []byte(fmt.Sprintf("value%d", i)))
+		require.NoError(t, err)
+	}
+
+	srcState, err := cl.immuClient.CurrentState(srcCtx)
+	require.NoError(t, err)
+
+	cmd, _ := cl.NewCmd()
+	cl.database(cmd)
+
+	// disable connects/disconnects, cmd already contains connected immudb client
+	for _, name := range []string{"export", "import"} {
+		c, _, err := cmd.Find([]string{"database", name})
+		require.NoError(t, err)
+
+		c.PersistentPreRunE = nil
+		c.PersistentPostRun = func(cmd *cobra.Command, args []string) {}
+	}
+
+	dump := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+
+	cmd.SetOut(dump)
+	cmd.SetErr(stderr)
+	cmd.SetArgs([]string{"database", "export", "dumpsrc", "--users"})
+
+	err = cmd.Execute()
+	require.NoError(t, err)
+	require.Contains(t, stderr.String(), fmt.Sprintf("database 'dumpsrc' successfully exported up to tx %d", srcState.TxId))
+	require.True(t, bytes.HasPrefix(dump.Bytes(), []byte(dumpPrefix)))
+
+	out := &bytes.Buffer{}
+
+	cmd.SetIn(bytes.NewReader(dump.Bytes()))
+	cmd.SetOut(out)
+	cmd.SetArgs([]string{"database", "import", "dumpdst"})
+
+	err = cmd.Execute()
+	require.NoError(t, err)
+	require.Contains(t, out.String(), fmt.Sprintf("database 'dumpdst' successfully imported up to tx %d", srcState.TxId))
+
+	udr, err = cl.immuClient.UseDatabase(ctx, &schema.Database{DatabaseName: "dumpdst"})
+	require.NoError(t, err)
+
+	dstCtx := metadata.NewOutgoingContext(ctx, metadata.Pairs("authorization", udr.GetToken()))
+
+	dstState, err := cl.immuClient.CurrentState(dstCtx)
+	require.NoError(t, err)
+	require.Equal(t, srcState.TxId, dstState.TxId)
+	require.Equal(t, srcState.TxHash, dstState.TxHash)
+
+	entry, err := cl.immuClient.Get(dstCtx, []byte("key4"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("value4"), entry.Value)
+
+	settings, err := cl.immuClient.GetDatabaseSettingsV2(dstCtx)
+	require.NoError(t, err)
+	require.Equal(t, uint32(20), settings.MaxConcurrency.Value)
+	require.False(t, settings.ReplicationSettings.Replica.Value)
+
+	// imported databases accept writes
+	_, err = cl.immuClient.Set(dstCtx, []byte("key5"), []byte("value5"))
+	require.NoError(t, err)
+
+	users, err := cl.immuClient.ListUsers(ctx)
+	require.NoError(t, err)
+
+	var permission *schema.Permission
+
+	for _, u := range users.Users {
+		if string(u.User) != "dumpuser" {
+			continue
+		}
+
+		for _, p := range u.Permissions {
+			if p.Database == "dumpdst" {
+				permission = p
+			}
+		}
+	}
+	require.NotNil(t, permission)
+	require.Equal(t, uint32(auth.PermissionRW), permission.Permission)
+
+	t.Run("malformed dumps should not be imported", func(t *testing.T) {
+		cmd.SetIn(bytes.NewReader([]byte("IMMUBACKUP")))
+		cmd.SetArgs([]string{"database", "import", "dumpdst2"})
+
+		err = cmd.Execute()
+		require.ErrorIs(t, err, ErrMalformedDump)
+
+		malformed := append([]byte(nil), dump.Bytes()...)
+		malformed[len(dumpPrefix)+3] = latestDumpFileVersion + 1
+
+		cmd.SetIn(bytes.NewReader(malformed))
+
+		err = cmd.Execute()
+		require.ErrorIs(t, err, ErrMalformedDump)
+	})
+}
@@ -19,6 +19,7 @@ package immuadmin
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
 	"encoding/binary"
 	"errors"
 	"fmt"
@@ -249,12 +250,26 @@ func (cl *commandlineHotBck) runHotBackup(output io.Writer, startTx uint64, prog
 }
 
 func (cl *commandlineHotBck) backupTx(tx uint64, output io.Writer) error {
+	alh, content, err := cl.exportTx(tx)
+	if err != nil {
+		return err
+	}
+
+	err = outputTx(tx, output, alh[:], content)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// exportTx returns the alh of the transaction along with its content, as exported for replication
+func (cl *commandline) exportTx(tx uint64) (alh [sha256.Size]byte, content []byte, err error) {
 	stream, err := cl.immuClient.ExportTx(cl.context, &schema.ExportTxRequest{Tx: tx})
 	if err != nil {
-		return fmt.Errorf("failed to export transaction: %w", err)
+		return alh, nil, fmt.Errorf("failed to export transaction: %w", err)
 	}
 
-	var content []byte
 	for {
 		var chunk *schema.Chunk
 		chunk, err = stream.Recv()
@@ -268,23 +283,18 @@ func (cl *commandlineHotBck) backupTx(tx uint64, output io.Writer) error {
 	}
 
 	if err != nil {
-		return fmt.Errorf("cannot process transaction data: %w", err)
+		return alh, nil, fmt.Errorf("cannot process transaction data: %w", err)
 	}
 	err = stream.CloseSend()
 	if err != nil {
-		return fmt.Errorf("CloseSend returned %v", err)
+		return alh, nil, fmt.Errorf("CloseSend returned %v", err)
 	}
 	txn, err := cl.immuClient.TxByID(cl.context, tx)
 	if err != nil {
-		return err
-	}
-	alh := schema.TxHeaderFromProto(txn.Header).Alh()
-	err = outputTx(tx, output, alh[:], content)
-	if err != nil {
-		return err
+		return alh, nil, err
 	}
 
-	return nil
+	return schema.TxHeaderFromProto(txn.Header).Alh(), content, nil
 }
 
 func outputTx(tx uint64, output io.Writer, checksum []byte, content []byte) error {
@@ -601,7 +611,7 @@ func (cl *commandlineHotBck) runHotRestore(input io.Reader, progress bool, first
 	return nil
 }
 
-func (cl *commandlineHotBck) restoreTx(checksum, payload []byte) error {
+func (cl *commandline) restoreTx(checksum, payload []byte) error {
 	maxPayload := uint32(cl.options.MaxRecvMsgSize)
 
 	stream, err := cl.immuClient.ReplicateTx(cl.context)
@@ -23,6 +23,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"google.golang.org/grpc"
@@ -222,16 +223,24 @@ func TestBackup(t *testing.T) {
 	cmds[1].PersistentPreRunE = nil
 	cmds[1].PersistentPostRun = nil
 
+	dir, err := ioutil.TempDir("", "hot_backup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	fullBackup := filepath.Join(dir, "full.backup")
+	partialBackup := filepath.Join(dir, "1-5.backup")
+
 	// restore (1-10)
 	cmd.SetArgs([]string{"hot-restore", "test1", "-i", "testdata/1-10.backup"})
-	err := cmd.Execute()
+	err = cmd.Execute()
 	if err != nil {
 		t.Fatal(err)
 	}
 
 	// full backup (1-10)
-	os.Remove("full.backup")
-	cmd.SetArgs([]string{"hot-backup", "test1", "-o", "full.backup"})
+	cmd.SetArgs([]string{"hot-backup", "test1", "-o", fullBackup})
 	err = cmd.Execute()
 	if err != nil {
 		t.Fatal(err)
@@ -243,8 +252,7 @@ func TestBackup(t *testing.T) {
 	assert.Contains(t, string(out), "Backing up transactions from 1 to 10")
 
 	// partial backup (5-10)
-	os.Remove("1-5.backup")
-	cmd.SetArgs([]string{"hot-backup", "test1", "--start-tx", "5", "-o", "1-5.backup"})
+	cmd.SetArgs([]string{"hot-backup", "test1", "--start-tx", "5", "-o", partialBackup})
 	err = cmd.Execute()
 	if err != nil {
 		t.Fatal(err)
@@ -263,7 +271,7 @@ func TestBackup(t *testing.T) {
 	}
 
 	// append txn 11 to file - require --append flag, should fail
-	cmd.SetArgs([]string{"hot-backup", "test1", "--start-tx", "1", "-o", "full.backup"})
+	cmd.SetArgs([]string{"hot-backup", "test1", "--start-tx", "1", "-o", fullBackup})
 	err = cmd.Execute()
 	if err == nil {
 		t.Fatal(ErrExpectedFailure)
@@ -275,7 +283,7 @@ func TestBackup(t *testing.T) {
 	assert.Contains(t, string(out), "Error: file already exists, use --append option to append new data to file")
 
 	// append txn 11 to file with --append flag
-	cmd.SetArgs([]string{"hot-backup", "test1", "--append", "-o", "full.backup"})
+	cmd.SetArgs([]string{"hot-backup", "test1", "--append", "-o", fullBackup})
 	err = cmd.Execute()
 	if err != nil {
 		t.Fatal(err)
@@ -294,7 +302,7 @@ func TestBackup(t *testing.T) {
 	}
 
 	// append txn 12-14 to file
-	cmd.SetArgs([]string{"hot-backup", "test1", "--append", "-o", "full.backup"})
+	cmd.SetArgs([]string{"hot-backup", "test1", "--append", "-o", fullBackup})
 	err = cmd.Execute()
 	if err != nil {
 		t.Fatal(err)
@@ -320,7 +328,7 @@ func TestBackup(t *testing.T) {
 	}
 
 	// append txn 15 to file from second DB, should fail because txn 14 in DB and file differ
-	cmd.SetArgs([]string{"hot-backup", "test2", "--append", "-o", "full.backup"})
+	cmd.SetArgs([]string{"hot-backup", "test2", "--append", "-o", fullBackup})
 	err = cmd.Execute()
 	if err == nil {
 		t.Fatal(ErrExpectedFailure)
@@ -0,0 +1,207 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package immuadmin
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/spf13/cobra"
+)
+
+const jobPollInterval = time.Second
+
+type jobListOutput struct {
+	Jobs []jobOutput `json:"jobs" yaml:"jobs"`
+}
+
+type jobOutput struct {
+	ID         string  `json:"id" yaml:"id"`
+	Kind       string  `json:"kind" yaml:"kind"`
+	Database   string  `json:"database" yaml:"database"`
+	Status     string  `json:"status" yaml:"status"`
+	Progress   float32 `json:"progress" yaml:"progress"`
+	Error      string  `json:"error,omitempty" yaml:"error,omitempty"`
+	StartedAt  int64   `json:"startedAt" yaml:"startedAt"`
+	FinishedAt int64   `json:"finishedAt,omitempty" yaml:"finishedAt,omitempty"`
+	Cancelable bool    `json:"cancelable" yaml:"cancelable"`
+}
+
+func newJobOutput(job *schema.Job) jobOutput {
+	return jobOutput{
+		ID:         job.Id,
+		Kind:       job.Kind,
+		Database:   job.Database,
+		Status:     job.Status,
+		Progress:   job.Progress,
+		Error:      job.Error,
+		StartedAt:  job.StartedAt,
+		FinishedAt: job.FinishedAt,
+		Cancelable: job.Cancelable,
+	}
+}
+
+func (cl *commandline) job(cmd *cobra.Command) {
+	ccmd := &cobra.Command{
+		Use:               "job",
+		Short:             "Manage the jobs run in background on the selected database, e.g. index compaction",
+		Aliases:           []string{"j"},
+		PersistentPostRun: cl.disconnect,
+		ValidArgs:         []string{"list", "get", "cancel"},
+	}
+
+	clc := &cobra.Command{
+		Use:               "list",
+		Short:             "List the jobs of the selected database, newest first",
+		Aliases:           []string{"l"},
+		Example:           "list --kind compaction",
+		PersistentPreRunE: cl.ConfigChain(cl.connect),
+		PersistentPostRun: cl.disconnect,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			kind, err := cmd.Flags().GetString("kind")
+			if err != nil {
+				return err
+			}
+
+			jobs, err := cl.immuClient.ListJobs(cl.context, kind)
+			if err != nil {
+				return err
+			}
+
+			out := jobListOutput{Jobs: make([]jobOutput, len(jobs.Jobs))}
+			for i, job := range jobs.Jobs {
+				out.Jobs[i] = newJobOutput(job)
+			}
+
+			return printOutput(cmd, out, func(w io.Writer) {
+				if len(jobs.Jobs) == 0 {
+					fmt.Fprintf(w, "No jobs found\n")
+					return
+				}
+
+				tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+				fmt.Fprintf(tw, "ID\tKIND\tSTATUS\tPROGRESS\tSTARTED AT\n")
+				for _, job := range jobs.Jobs {
+					fmt.Fprintf(tw, "%s\t%s\t%s\t%.1f%%\t%s\n", job.Id, job.Kind, job.Status, job.Progress, time.Unix(job.StartedAt, 0))
+				}
+				tw.Flush()
+			})
+		},
+		Args: cobra.ExactArgs(0),
+	}
+	clc.Flags().String("kind", "", "only list jobs of the given kind, e.g. "+schema.JobKindCompaction)
+
+	cgc := &cobra.Command{
+		Use:               "get",
+		Short:             "Show the state of a job",
+		Example:           "get {job_id}",
+		PersistentPreRunE: cl.ConfigChain(cl.connect),
+		PersistentPostRun: cl.disconnect,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			job, err := cl.immuClient.GetJob(cl.context, args[0])
+			if err != nil {
+				return err
+			}
+
+			return printJob(cmd, job)
+		},
+		Args: cobra.ExactArgs(1),
+	}
+
+	ccc := &cobra.Command{
+		Use:               "cancel",
+		Short:             "Cancel a running job",
+		Example:           "cancel {job_id} --wait",
+		PersistentPreRunE: cl.ConfigChain(cl.connect),
+		PersistentPostRun: cl.disconnect,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			wait, err := cmd.Flags().GetBool("wait")
+			if err != nil {
+				return err
+			}
+
+			job, err := cl.immuClient.CancelJob(cl.context, args[0])
+			if err != nil {
+				return err
+			}
+
+			if !wait {
+				fmt.Fprintf(cmd.OutOrStdout(), "Cancelation of job %s requested\n", job.Id)
+				return nil
+			}
+
+			job, err = cl.waitForJob(cmd, job)
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "Job %s %s\n", job.Id, job.Status)
+			return nil
+		},
+		Args: cobra.ExactArgs(1),
+	}
+	ccc.Flags().Bool("wait", false, "wait for the job to stop")
+
+	ccmd.AddCommand(clc)
+	ccmd.AddCommand(cgc)
+	ccmd.AddCommand(ccc)
+	cmd.AddCommand(ccmd)
+}
+
+func printJob(cmd *cobra.Command, job *schema.Job) error {
+	return printOutput(cmd, newJobOutput(job), func(w io.Writer) {
+		fmt.Fprintf(w, "job id:\t\t%s\n", job.Id)
+		fmt.Fprintf(w, "kind:\t\t%s\n", job.Kind)
+		fmt.Fprintf(w, "database:\t%s\n", job.Database)
+		fmt.Fprintf(w, "status:\t\t%s\n", job.Status)
+		fmt.Fprintf(w, "progress:\t%.1f%%\n", job.Progress)
+		fmt.Fprintf(w, "cancelable:\t%t\n", job.Cancelable)
+		fmt.Fprintf(w, "started at:\t%s\n", time.Unix(job.StartedAt, 0))
+		if job.FinishedAt > 0 {
+			fmt.Fprintf(w, "finished at:\t%s\n", time.Unix(job.FinishedAt, 0))
+		}
+		if job.Error != "" {
+			fmt.Fprintf(w, "error:\t\t%s\n", job.Error)
+		}
+	})
+}
+
+// waitForJob waits until the job is finished, reporting its progress
+func (cl *commandline) waitForJob(cmd *cobra.Command, job *schema.Job) (*schema.Job, error) {
+	out := cmd.OutOrStdout()
+
+	reported := false
+
+	job, err := cl.immuClient.WaitForJob(cl.context, job.Id, jobPollInterval, func(job *schema.Job) {
+		fmt.Fprintf(out, "\rRunning %s job on database '%s'... %.1f%%", job.Kind, job.Database, job.Progress)
+		reported = true
+	})
+	if reported {
+		fmt.Fprintln(out)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if job.Status == schema.JobStatusFailed {
+		return nil, fmt.Errorf("%s job failed: %s", job.Kind, job.Error)
+	}
+
+	return job, nil
+}
@@ -0,0 +1,121 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package immuadmin
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/database"
+	"github.com/spf13/cobra"
+)
+
+func (cl *commandline) keyspaceStats() *cobra.Command {
+	ckc := &cobra.Command{
+		Use:               "keyspace",
+		Short:             "Show the keys of a database by prefix, the distribution of key and value sizes and the keys with most revisions",
+		Example:           "keyspace {database_name} --prefix-length 4 --outliers 20",
+		PersistentPreRunE: cl.ConfigChain(cl.connect),
+		PersistentPostRun: cl.disconnect,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			prefixLength, err := cmd.Flags().GetUint32("prefix-length")
+			if err != nil {
+				return err
+			}
+
+			outliers, err := cmd.Flags().GetUint32("outliers")
+			if err != nil {
+				return err
+			}
+
+			// the whole index is walked, so it may take a while on large databases
+			stats, err := cl.immuClient.KeyspaceStats(cl.context, &schema.KeyspaceStatsRequest{
+				Database:     args[0],
+				PrefixLength: prefixLength,
+				Outliers:     outliers,
+			})
+			if err != nil {
+				return err
+			}
+
+			return printOutput(cmd, stats, func(w io.Writer) {
+				printKeyspaceStats(w, args[0], stats)
+			})
+		},
+		Args: cobra.ExactArgs(1),
+	}
+	ckc.Flags().Uint32("prefix-length", database.DefaultKeyspacePrefixLength, "number of leading bytes keys are grouped by")
+	ckc.Flags().Uint32("outliers", database.DefaultKeyspaceOutliers, "number of keys with most revisions to show")
+
+	return ckc
+}
+
+func printKeyspaceStats(w io.Writer, db string, stats *schema.KeyspaceStatsResponse) {
+	fmt.Fprintf(w, "Database '%s' at tx %d\n\n", db, stats.TxId)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "KEYSPACE\tKEYS\tKEY BYTES\tVALUE BYTES\n")
+	for _, ks := range stats.Keyspaces {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\n", ks.Name, ks.Keys, ks.KeyBytes, ks.ValueBytes)
+	}
+	tw.Flush()
+
+	fmt.Fprintf(w, "\n")
+
+	tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "PREFIX\tKEYS\tKEY BYTES\tVALUE BYTES\n")
+	for _, p := range stats.Prefixes {
+		fmt.Fprintf(tw, "%q\t%d\t%d\t%d\n", p.Prefix, p.Keys, p.KeyBytes, p.ValueBytes)
+	}
+	if p := stats.OtherPrefixes; p != nil {
+		fmt.Fprintf(tw, "(other)\t%d\t%d\t%d\n", p.Keys, p.KeyBytes, p.ValueBytes)
+	}
+	tw.Flush()
+
+	for _, d := range []struct {
+		name string
+		dist *schema.SizeDistribution
+	}{{"Key sizes", stats.KeySizes}, {"Value sizes", stats.ValueSizes}} {
+		if d.dist == nil || d.dist.Count == 0 {
+			continue
+		}
+
+		fmt.Fprintf(w, "\n%s: min %d, mean %.1f, max %d\n", d.name, d.dist.Min, float64(d.dist.Sum)/float64(d.dist.Count), d.dist.Max)
+
+		tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(tw, "UP TO\tKEYS\n")
+		for _, b := range d.dist.Buckets {
+			if b.Count > 0 {
+				fmt.Fprintf(tw, "%d\t%d\n", b.UpperBound, b.Count)
+			}
+		}
+		tw.Flush()
+	}
+
+	if len(stats.RevisionOutliers) > 0 {
+		fmt.Fprintf(w, "\n")
+
+		tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(tw, "KEY\tREVISIONS\n")
+		for _, o := range stats.RevisionOutliers {
+			fmt.Fprintf(tw, "%q\t%d\n", o.Key, o.Revisions)
+		}
+		tw.Flush()
+	}
+}
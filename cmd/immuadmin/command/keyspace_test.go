@@ -0,0 +1,83 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package immuadmin
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestKeyspaceStats(t *testing.T) {
+	cl := getCmdline()
+	require.NotNil(t, cl)
+
+	ur, err := cl.immuClient.UseDatabase(cl.context, &schema.Database{DatabaseName: "defaultdb"})
+	require.NoError(t, err)
+
+	cl.context = metadata.NewOutgoingContext(context.Background(), metadata.Pairs("authorization", ur.GetToken()))
+
+	_, err = cl.immuClient.Set(cl.context, []byte("user1"), []byte("value1"))
+	require.NoError(t, err)
+
+	cmd := &cobra.Command{}
+	ckc := cl.keyspaceStats()
+	// disable connects/disconnects, cl already contains connected immudb client
+	ckc.PersistentPreRunE = nil
+	ckc.PersistentPostRun = nil
+	cmd.AddCommand(ckc)
+
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetErr(&out)
+
+	cmd.SetArgs([]string{"keyspace", "defaultdb", "--prefix-length", "4"})
+	err = cmd.Execute()
+	require.NoError(t, err)
+
+	require.Contains(t, out.String(), "Database 'defaultdb' at tx")
+	require.Contains(t, out.String(), `"user"`)
+
+	cmd.SetArgs([]string{"keyspace", "nonexistent"})
+	err = cmd.Execute()
+	require.Error(t, err)
+}
+
+func TestPrintKeyspaceStats(t *testing.T) {
+	stats := &schema.KeyspaceStatsResponse{
+		TxId:             10,
+		Keyspaces:        []*schema.KeyspaceUsage{{Name: "kv", Keys: 3}},
+		Prefixes:         []*schema.KeyspaceUsage{{Prefix: []byte("user"), Keys: 3}},
+		KeySizes:         &schema.SizeDistribution{Count: 3, Min: 5, Max: 6, Sum: 16, Buckets: []*schema.SizeBucket{{UpperBound: 8, Count: 3}}},
+		ValueSizes:       &schema.SizeDistribution{},
+		RevisionOutliers: []*schema.KeyRevisions{{Key: []byte("user1"), Revisions: 7}},
+	}
+
+	var out bytes.Buffer
+	printKeyspaceStats(&out, "db1", stats)
+
+	require.Contains(t, out.String(), "Database 'db1' at tx 10")
+	require.Contains(t, out.String(), `"user"`)
+	require.Contains(t, out.String(), "Key sizes: min 5, mean 5.3, max 6")
+	require.NotContains(t, out.String(), "Value sizes")
+	require.Contains(t, out.String(), `"user1"  7`)
+}
@@ -0,0 +1,84 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package immuadmin
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+	outputYAML  = "yaml"
+)
+
+func addOutputFlag(cmd *cobra.Command) {
+	cmd.PersistentFlags().String("output", outputTable, "output format: table, json or yaml")
+}
+
+// outputFormat returns the format selected with the --output flag, commands
+// that do not inherit the flag always print tables
+func outputFormat(cmd *cobra.Command) (string, error) {
+	if cmd.Flags().Lookup("output") == nil {
+		return outputTable, nil
+	}
+
+	format, err := cmd.Flags().GetString("output")
+	if err != nil {
+		return "", err
+	}
+
+	switch format {
+	case outputTable, outputJSON, outputYAML:
+		return format, nil
+	}
+
+	return "", fmt.Errorf("invalid output format '%s', expected table, json or yaml", format)
+}
+
+// printOutput encodes v in the format selected with the --output flag,
+// printTable is used to render the human readable output
+func printOutput(cmd *cobra.Command, v interface{}, printTable func(w io.Writer)) error {
+	format, err := outputFormat(cmd)
+	if err != nil {
+		return err
+	}
+
+	w := cmd.OutOrStdout()
+
+	switch format {
+	case outputJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	case outputYAML:
+		out, err := yaml.Marshal(v)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(out)
+		return err
+	}
+
+	printTable(w)
+	return nil
+}
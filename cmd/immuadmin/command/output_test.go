@@ -0,0 +1,61 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package immuadmin
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrintOutput(t *testing.T) {
+	out := databaseListOutput{Databases: []databaseOutput{{Name: "defaultdb", Current: true}}}
+	printTable := func(w io.Writer) { fmt.Fprint(w, "table") }
+
+	cmd := &cobra.Command{}
+	b := &bytes.Buffer{}
+	cmd.SetOut(b)
+
+	// commands not inheriting the flag print tables
+	require.NoError(t, printOutput(cmd, out, printTable))
+	require.Equal(t, "table", b.String())
+
+	addOutputFlag(cmd)
+
+	for _, tc := range []struct {
+		format   string
+		expected string
+	}{
+		{"table", "table"},
+		{"json", "{\n  \"databases\": [\n    {\n      \"name\": \"defaultdb\",\n      \"current\": true,\n      \"maintenance\": false\n    }\n  ]\n}\n"},
+		{"yaml", "databases:\n- name: defaultdb\n  current: true\n  maintenance: false\n"},
+	} {
+		t.Run(tc.format, func(t *testing.T) {
+			b.Reset()
+			require.NoError(t, cmd.ParseFlags([]string{"--output", tc.format}))
+			require.NoError(t, printOutput(cmd, out, printTable))
+			require.Equal(t, tc.expected, b.String())
+		})
+	}
+
+	require.NoError(t, cmd.ParseFlags([]string{"--output", "xml"}))
+	require.Error(t, printOutput(cmd, out, printTable))
+}
@@ -0,0 +1,180 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package immuadmin
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	c "github.com/codenotary/immudb/cmd/helper"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/spf13/cobra"
+)
+
+type replicationStatusOutput struct {
+	Database       string `json:"database" yaml:"database"`
+	Running        bool   `json:"running" yaml:"running"`
+	Paused         bool   `json:"paused" yaml:"paused"`
+	Connected      bool   `json:"connected" yaml:"connected"`
+	LastTx         uint64 `json:"lastTx" yaml:"lastTx"`
+	MasterTx       uint64 `json:"masterTx" yaml:"masterTx"`
+	Lag            uint64 `json:"lag" yaml:"lag"`
+	FailedAttempts uint32 `json:"failedAttempts" yaml:"failedAttempts"`
+	LastError      string `json:"lastError,omitempty" yaml:"lastError,omitempty"`
+}
+
+func newReplicationStatusOutput(st *schema.ReplicationStatusResponse) replicationStatusOutput {
+	return replicationStatusOutput{
+		Database:       st.Database,
+		Running:        st.Running,
+		Paused:         st.Paused,
+		Connected:      st.Connected,
+		LastTx:         st.LastTx,
+		MasterTx:       st.MasterTx,
+		Lag:            st.Lag,
+		FailedAttempts: st.FailedAttempts,
+		LastError:      st.LastError,
+	}
+}
+
+func (cl *commandline) replication(cmd *cobra.Command) {
+	ccmd := &cobra.Command{
+		Use:               "replication",
+		Short:             "Issue all replication commands",
+		Aliases:           []string{"r"},
+		PersistentPostRun: cl.disconnect,
+		ValidArgs:         []string{"status", "pause", "resume", "reset"},
+	}
+
+	csc := &cobra.Command{
+		Use:               "status",
+		Short:             "Show the progress of the replication of a replica database",
+		Example:           "status {database_name}",
+		PersistentPreRunE: cl.ConfigChain(cl.connect),
+		PersistentPostRun: cl.disconnect,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			st, err := cl.immuClient.ReplicationStatus(cl.context, args[0])
+			if err != nil {
+				return err
+			}
+
+			return printOutput(cmd, newReplicationStatusOutput(st), func(w io.Writer) {
+				state := "stopped"
+				if st.Paused {
+					state = "paused"
+				} else if st.Running {
+					state = "running"
+				}
+
+				fmt.Fprintf(w, "database:\t\t%s\n", st.Database)
+				fmt.Fprintf(w, "state:\t\t\t%s\n", state)
+				fmt.Fprintf(w, "connected:\t\t%t\n", st.Connected)
+				fmt.Fprintf(w, "last tx:\t\t%d\n", st.LastTx)
+				if st.Connected {
+					fmt.Fprintf(w, "master tx:\t\t%d\n", st.MasterTx)
+					fmt.Fprintf(w, "lag:\t\t\t%d\n", st.Lag)
+				}
+				fmt.Fprintf(w, "failed attempts:\t%d\n", st.FailedAttempts)
+				if st.LastError != "" {
+					fmt.Fprintf(w, "last error:\t\t%s\n", st.LastError)
+				}
+			})
+		},
+		Args: cobra.ExactArgs(1),
+	}
+
+	cpc := &cobra.Command{
+		Use:               "pause",
+		Short:             "Pause the replication of a replica database, e.g. during maintenance windows",
+		Example:           "pause {database_name}",
+		PersistentPreRunE: cl.ConfigChain(cl.connect),
+		PersistentPostRun: cl.disconnect,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := cl.immuClient.PauseReplication(cl.context, args[0])
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "Replication of database '%s' successfully paused\n", args[0])
+			return nil
+		},
+		Args: cobra.ExactArgs(1),
+	}
+
+	crc := &cobra.Command{
+		Use:               "resume",
+		Short:             "Resume the replication of a paused replica database",
+		Example:           "resume {database_name}",
+		PersistentPreRunE: cl.ConfigChain(cl.connect),
+		PersistentPostRun: cl.disconnect,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := cl.immuClient.ResumeReplication(cl.context, args[0])
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "Replication of database '%s' successfully resumed\n", args[0])
+			return nil
+		},
+		Args: cobra.ExactArgs(1),
+	}
+
+	crsc := &cobra.Command{
+		Use:               "reset",
+		Short:             "Discard all the data of a replica database and replicate it again from scratch",
+		Example:           "reset {database_name}",
+		PersistentPreRunE: cl.ConfigChain(cl.connect),
+		PersistentPostRun: cl.disconnect,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			yes, err := cmd.Flags().GetBool("yes")
+			if err != nil {
+				return err
+			}
+
+			if !yes {
+				fmt.Fprintf(cmd.OutOrStdout(), "All the data of replica database '%s' will be discarded. Are you sure you want to proceed? [y/N]: ", args[0])
+
+				answer, err := bufio.NewReader(cmd.InOrStdin()).ReadString('\n')
+				if err != nil && err != io.EOF {
+					return err
+				}
+
+				if answer = strings.ToLower(strings.TrimSpace(answer)); answer != "y" && answer != "yes" {
+					c.QuitToStdErr("Canceled")
+				}
+			}
+
+			err = cl.immuClient.ResetReplication(cl.context, args[0])
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "Replica database '%s' successfully reset, replication restarted from the first transaction\n", args[0])
+			return nil
+		},
+		Args: cobra.ExactArgs(1),
+	}
+	crsc.Flags().BoolP("yes", "y", false, "do not ask for confirmation")
+
+	ccmd.AddCommand(csc)
+	ccmd.AddCommand(cpc)
+	ccmd.AddCommand(crc)
+	ccmd.AddCommand(crsc)
+	cmd.AddCommand(ccmd)
+}
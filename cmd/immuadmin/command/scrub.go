@@ -0,0 +1,134 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package immuadmin
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/spf13/cobra"
+)
+
+func (cl *commandline) scrub() []*cobra.Command {
+	csc := &cobra.Command{
+		Use:               "scrub",
+		Short:             "Verify every committed transaction of the selected database",
+		Long:              "Start verifying every committed transaction of the selected database in background. Use --wait to wait for it to finish and show the corrupted transactions found.",
+		Example:           "scrub --wait",
+		PersistentPreRunE: cl.ConfigChain(cl.connect),
+		PersistentPostRun: cl.disconnect,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			wait, err := cmd.Flags().GetBool("wait")
+			if err != nil {
+				return err
+			}
+
+			job, err := cl.immuClient.ScrubAsync(cl.context)
+			if err != nil {
+				return err
+			}
+
+			if !wait {
+				fmt.Fprintf(cmd.OutOrStdout(), "Scrub started with job id %s\n", job.Id)
+				fmt.Fprintf(cmd.OutOrStdout(), "Use 'immuadmin database scrub-status' to see its outcome\n")
+				return nil
+			}
+
+			// corrupted transactions make the job fail, they're shown before reporting it
+			_, jobErr := cl.waitForJob(cmd, job)
+
+			status, err := cl.immuClient.ScrubStatus(cl.context)
+			if err != nil {
+				return err
+			}
+
+			err = printOutput(cmd, status, func(w io.Writer) {
+				printScrubStatus(w, status)
+			})
+			if err != nil {
+				return err
+			}
+
+			return jobErr
+		},
+		Args: cobra.ExactArgs(0),
+	}
+	csc.Flags().Bool("wait", false, "wait for the scrub to finish, reporting its progress")
+
+	css := &cobra.Command{
+		Use:               "scrub-status",
+		Short:             "Show the status of the ongoing scrub of the selected database or the last one",
+		Example:           "scrub-status",
+		PersistentPreRunE: cl.ConfigChain(cl.connect),
+		PersistentPostRun: cl.disconnect,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			status, err := cl.immuClient.ScrubStatus(cl.context)
+			if err != nil {
+				return err
+			}
+
+			return printOutput(cmd, status, func(w io.Writer) {
+				printScrubStatus(w, status)
+			})
+		},
+		Args: cobra.ExactArgs(0),
+	}
+
+	return []*cobra.Command{csc, css}
+}
+
+func printScrubStatus(w io.Writer, status *schema.ScrubStatusResponse) {
+	if status.StartedAt == 0 {
+		fmt.Fprintf(w, "Database '%s' has not been scrubbed since it was loaded\n", status.Database)
+		return
+	}
+
+	startedAt := time.Unix(status.StartedAt, 0).Format(time.RFC3339)
+
+	if status.Running {
+		fmt.Fprintf(w, "Scrub of database '%s' started at %s: %d of %d transactions verified\n",
+			status.Database, startedAt, status.ScrubbedTxs, status.TargetTxId)
+	} else {
+		fmt.Fprintf(w, "Scrub of database '%s' started at %s and finished at %s: %d of %d transactions verified\n",
+			status.Database, startedAt, time.Unix(status.FinishedAt, 0).Format(time.RFC3339), status.ScrubbedTxs, status.TargetTxId)
+	}
+
+	if status.Error != "" {
+		fmt.Fprintf(w, "Scrub could not be completed: %s\n", status.Error)
+	}
+
+	if status.MismatchCount == 0 {
+		fmt.Fprintf(w, "No corrupted transactions found\n")
+		return
+	}
+
+	fmt.Fprintf(w, "%d corrupted transaction(s) found\n\n", status.MismatchCount)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "TX\tREASON\n")
+	for _, m := range status.Mismatches {
+		fmt.Fprintf(tw, "%d\t%s\n", m.TxId, m.Reason)
+	}
+	tw.Flush()
+
+	if status.MismatchCount > uint64(len(status.Mismatches)) {
+		fmt.Fprintf(w, "(%d more not shown)\n", status.MismatchCount-uint64(len(status.Mismatches)))
+	}
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package immuadmin
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestScrub(t *testing.T) {
+	cl := getCmdline()
+	require.NotNil(t, cl)
+
+	ur, err := cl.immuClient.UseDatabase(cl.context, &schema.Database{DatabaseName: "defaultdb"})
+	require.NoError(t, err)
+
+	cl.context = metadata.NewOutgoingContext(context.Background(), metadata.Pairs("authorization", ur.GetToken()))
+
+	_, err = cl.immuClient.Set(cl.context, []byte("key1"), []byte("value1"))
+	require.NoError(t, err)
+
+	cmd := &cobra.Command{}
+	for _, c := range cl.scrub() {
+		// disable connects/disconnects, cl already contains connected immudb client
+		c.PersistentPreRunE = nil
+		c.PersistentPostRun = nil
+		cmd.AddCommand(c)
+	}
+
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetErr(&out)
+
+	cmd.SetArgs([]string{"scrub-status"})
+	err = cmd.Execute()
+	require.NoError(t, err)
+	require.Contains(t, out.String(), "Database 'defaultdb' has not been scrubbed")
+
+	out.Reset()
+
+	cmd.SetArgs([]string{"scrub", "--wait"})
+	err = cmd.Execute()
+	require.NoError(t, err)
+	require.Contains(t, out.String(), "Scrub of database 'defaultdb' started at")
+	require.Contains(t, out.String(), "No corrupted transactions found")
+}
+
+func TestPrintScrubStatus(t *testing.T) {
+	status := &schema.ScrubStatusResponse{
+		Database:      "db1",
+		StartedAt:     time.Now().Unix(),
+		FinishedAt:    time.Now().Unix(),
+		ScrubbedTxs:   10,
+		TargetTxId:    10,
+		Mismatches:    []*schema.ScrubMismatch{{TxId: 3, Reason: "corrupted value"}},
+		MismatchCount: 2,
+	}
+
+	var out bytes.Buffer
+	printScrubStatus(&out, status)
+
+	require.Contains(t, out.String(), "10 of 10 transactions verified")
+	require.Contains(t, out.String(), "2 corrupted transaction(s) found")
+	require.Contains(t, out.String(), "corrupted value")
+	require.Contains(t, out.String(), "(1 more not shown)")
+}
+
+func TestPrepareDatabaseSettingsScrub(t *testing.T) {
+	cmd := &cobra.Command{}
+	addDbUpdateFlags(cmd)
+
+	err := cmd.Flags().Parse([]string{"--scrub-interval", "24h", "--delay-during-scrub", "50ms"})
+	require.NoError(t, err)
+
+	settings, err := prepareDatabaseSettings("db1", cmd.Flags())
+	require.NoError(t, err)
+	require.Equal(t, uint64((24 * time.Hour).Milliseconds()), settings.ScrubInterval.GetValue())
+	require.Equal(t, uint32(50), settings.DelayDuringScrub.GetValue())
+
+	cmd = &cobra.Command{}
+	addDbUpdateFlags(cmd)
+
+	err = cmd.Flags().Parse([]string{"--scrub-interval", "-1s"})
+	require.NoError(t, err)
+
+	_, err = prepareDatabaseSettings("db1", cmd.Flags())
+	require.Error(t, err)
+}
@@ -0,0 +1,99 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package immuadmin
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/spf13/cobra"
+)
+
+func (cl *commandline) settingsHistory() []*cobra.Command {
+	chc := &cobra.Command{
+		Use:               "history",
+		Short:             "Show the versions of the database settings, with the changes made on each of them",
+		Example:           "history {database_name}",
+		PersistentPreRunE: cl.ConfigChain(cl.connect),
+		PersistentPostRun: cl.disconnect,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			limit, err := cmd.Flags().GetUint32("limit")
+			if err != nil {
+				return err
+			}
+
+			history, err := cl.immuClient.GetDatabaseSettingsHistory(cl.context, &schema.DatabaseSettingsHistoryRequest{
+				Database: args[0],
+				Limit:    limit,
+				Desc:     true,
+			})
+			if err != nil {
+				return err
+			}
+
+			for _, v := range history.Versions {
+				fmt.Fprintf(cmd.OutOrStdout(), "version %d updated by '%s' at %s\n",
+					v.Version, v.UpdatedBy, time.Unix(v.UpdatedAt, 0).Format(time.RFC3339))
+
+				for _, c := range v.Changes {
+					fmt.Fprintf(cmd.OutOrStdout(), "\t%s: %s -> %s\n", c.Setting, c.OldValue, c.NewValue)
+				}
+			}
+
+			return nil
+		},
+		Args: cobra.ExactArgs(1),
+	}
+	chc.Flags().Uint32("limit", 10, "max number of versions to show, latest versions are shown first")
+
+	crc := &cobra.Command{
+		Use:               "rollback",
+		Short:             "Set the database settings back to a previous version",
+		Example:           "rollback {database_name} {version}",
+		PersistentPreRunE: cl.ConfigChain(cl.connect),
+		PersistentPostRun: cl.disconnect,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			version, err := strconv.ParseUint(args[1], 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid settings version '%s'", args[1])
+			}
+
+			res, err := cl.immuClient.RollbackDatabaseSettings(cl.context, args[0], version)
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(),
+				"database '%s' settings successfully rolled back to version %d\n",
+				args[0], version)
+
+			if len(res.ReloadRequired) > 0 {
+				fmt.Fprintf(cmd.OutOrStdout(),
+					"database '%s' must be reloaded to apply: %s\n",
+					args[0], strings.Join(res.ReloadRequired, ", "))
+			}
+
+			return nil
+		},
+		Args: cobra.ExactArgs(2),
+	}
+
+	return []*cobra.Command{chc, crc}
+}
@@ -18,12 +18,18 @@ package immuadmin
 
 import (
 	"fmt"
+	"io"
 
 	c "github.com/codenotary/immudb/cmd/helper"
 	"github.com/codenotary/immudb/cmd/immuadmin/command/stats"
 	"github.com/spf13/cobra"
 )
 
+type statusOutput struct {
+	Status  string `json:"status" yaml:"status"`
+	Message string `json:"message" yaml:"message"`
+}
+
 func (cl *commandline) status(cmd *cobra.Command) {
 	ccmd := &cobra.Command{
 		Use:               "status",
@@ -36,7 +42,12 @@ func (cl *commandline) status(cmd *cobra.Command) {
 			if err := cl.immuClient.HealthCheck(ctx); err != nil {
 				c.QuitWithUserError(err)
 			}
-			fmt.Fprintf(cmd.OutOrStdout(), "OK - server is reachable and responding to queries\n")
+			err := printOutput(cmd, statusOutput{Status: "OK", Message: "server is reachable and responding to queries"}, func(w io.Writer) {
+				fmt.Fprintf(w, "OK - server is reachable and responding to queries\n")
+			})
+			if err != nil {
+				c.QuitToStdErr(err)
+			}
 			return nil
 		},
 		Args: cobra.NoArgs,
@@ -57,6 +68,20 @@ func (cl *commandline) stats(cmd *cobra.Command) {
 				c.QuitToStdErr(err)
 			}
 			options := cl.immuClient.GetOptions()
+			format, err := outputFormat(cmd)
+			if err != nil {
+				c.QuitToStdErr(err)
+			}
+			if format != outputTable {
+				summary, err := stats.LoadSummary(options.Address)
+				if err != nil {
+					c.QuitToStdErr(err)
+				}
+				if err := printOutput(cmd, summary, nil); err != nil {
+					c.QuitToStdErr(err)
+				}
+				return nil
+			}
 			if raw {
 				if err := stats.ShowMetricsRaw(cmd.OutOrStderr(), options.Address); err != nil {
 					c.QuitToStdErr(err)
@@ -43,3 +43,14 @@ func TestShowMetricsAsText(t *testing.T) {
 	var sw strings.Builder
 	require.NoError(t, ShowMetricsAsText(&sw, testServer.URL))
 }
+
+func TestLoadSummary(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		res.Write(statstest.StatsResponse)
+	}))
+	defer testServer.Close()
+	s, err := LoadSummary(testServer.URL)
+	require.NoError(t, err)
+	require.Greater(t, s.UptimeHours, 0.0)
+	require.NotEmpty(t, s.RPCDurations)
+}
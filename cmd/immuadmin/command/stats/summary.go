@@ -0,0 +1,88 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stats
+
+import (
+	"sort"
+	"time"
+)
+
+// Summary holds the statistics shown as text, in a form suitable to be encoded
+type Summary struct {
+	Database         string            `json:"database" yaml:"database"`
+	UptimeHours      float64           `json:"uptimeHours" yaml:"uptimeHours"`
+	Entries          uint64            `json:"entries" yaml:"entries"`
+	SizeBytes        uint64            `json:"sizeBytes" yaml:"sizeBytes"`
+	Clients          []ClientSummary   `json:"clients" yaml:"clients"`
+	RPCDurations     []DurationSummary `json:"rpcDurations,omitempty" yaml:"rpcDurations,omitempty"`
+	MemoryInUseBytes uint64            `json:"memoryInUseBytes" yaml:"memoryInUseBytes"`
+}
+
+// ClientSummary holds the number of queries issued by a client
+type ClientSummary struct {
+	IP          string     `json:"ip" yaml:"ip"`
+	Queries     uint64     `json:"queries" yaml:"queries"`
+	LastQueryAt *time.Time `json:"lastQueryAt,omitempty" yaml:"lastQueryAt,omitempty"`
+}
+
+// DurationSummary holds the number of calls and the average duration of an RPC method
+type DurationSummary struct {
+	Method              string  `json:"method" yaml:"method"`
+	Calls               uint64  `json:"calls" yaml:"calls"`
+	AvgDurationMicrosec float64 `json:"avgDurationMicrosec" yaml:"avgDurationMicrosec"`
+}
+
+// LoadSummary fetches the metrics exposed by the server and summarizes them
+func LoadSummary(serverAddress string) (*Summary, error) {
+	loader := newMetricsLoader(metricsURL(serverAddress))
+	ms, err := loader.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	db := ms.dbWithMostEntries()
+
+	s := &Summary{
+		Database:         db.name,
+		UptimeHours:      ms.uptimeHours,
+		Entries:          db.nbEntries,
+		SizeBytes:        db.totalBytes,
+		Clients:          make([]ClientSummary, 0, len(ms.nbRPCsPerClient)),
+		MemoryInUseBytes: ms.memstats.heapInUseBytes + ms.memstats.stackInUseBytes,
+	}
+
+	for ip, queries := range ms.nbRPCsPerClient {
+		c := ClientSummary{IP: ip, Queries: queries}
+		if lastMsgAt, ok := ms.lastMsgAtPerClient[ip]; ok {
+			t := time.Unix(int64(lastMsgAt), 0)
+			c.LastQueryAt = &t
+		}
+		s.Clients = append(s.Clients, c)
+	}
+	sort.Slice(s.Clients, func(i, j int) bool { return s.Clients[i].IP < s.Clients[j].IP })
+
+	for _, rd := range ms.durationRPCsByMethod {
+		s.RPCDurations = append(s.RPCDurations, DurationSummary{
+			Method:              rd.method,
+			Calls:               rd.counter,
+			AvgDurationMicrosec: rd.avgDuration * 1000_000,
+		})
+	}
+	sort.Slice(s.RPCDurations, func(i, j int) bool { return s.RPCDurations[i].Method < s.RPCDurations[j].Method })
+
+	return s, nil
+}
@@ -22,6 +22,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 
 	c "github.com/codenotary/immudb/cmd/helper"
 	"github.com/codenotary/immudb/pkg/api/schema"
@@ -43,11 +44,17 @@ func (cl *commandline) user(cmd *cobra.Command) {
 		Short: "List all users",
 
 		RunE: func(cmd *cobra.Command, args []string) error {
-			resp, err := cl.userList(args)
+			userlist, err := cl.immuClient.ListUsers(cl.context)
+			if err != nil {
+				c.QuitToStdErr(err)
+			}
+			users := userlist.GetUsers()
+			err = printOutput(cmd, newUserListOutput(users), func(w io.Writer) {
+				fmt.Fprint(w, userListTable(users))
+			})
 			if err != nil {
 				c.QuitToStdErr(err)
 			}
-			fmt.Fprint(cmd.OutOrStdout(), resp)
 			return nil
 		},
 		Args: cobra.MaximumNArgs(0),
@@ -58,6 +65,7 @@ func (cl *commandline) user(cmd *cobra.Command) {
 		Long:  "Create a new user inside a database with permissions",
 		Example: `immuadmin user create user1 read mydb
 immuadmin user create user1 readwrite mydb
+immuadmin user create user1 restrictedread mydb
 immuadmin user create user1 admin mydb`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			resp, err := cl.userCreate(args)
@@ -115,7 +123,7 @@ immuadmin user create user1 admin mydb`,
 		Args: cobra.ExactArgs(1),
 	}
 	userPermission := &cobra.Command{
-		Use:     "permission [grant|revoke] {username} [read|readwrite|admin] {database}",
+		Use:     "permission [grant|revoke] {username} [read|readwrite|restrictedread|admin] {database}",
 		Short:   "Set user permission",
 		Example: "immuadmin user permission grant user1 readwrite mydb",
 		RunE: func(cmd *cobra.Command, args []string) (err error) {
@@ -161,7 +169,47 @@ func (cl *commandline) userList(args []string) (string, error) {
 	if err != nil {
 		return "", err
 	}
-	users := userlist.GetUsers()
+	return userListTable(userlist.GetUsers()), nil
+}
+
+type userListOutput struct {
+	Users []userOutput `json:"users" yaml:"users"`
+}
+
+type userOutput struct {
+	User        string             `json:"user" yaml:"user"`
+	Active      bool               `json:"active" yaml:"active"`
+	Permissions []permissionOutput `json:"permissions" yaml:"permissions"`
+	CreatedBy   string             `json:"createdBy" yaml:"createdBy"`
+	CreatedAt   string             `json:"createdAt" yaml:"createdAt"`
+}
+
+type permissionOutput struct {
+	Database   string `json:"database" yaml:"database"`
+	Permission string `json:"permission" yaml:"permission"`
+}
+
+func newUserListOutput(users []*schema.User) userListOutput {
+	out := userListOutput{Users: make([]userOutput, len(users))}
+	for i, user := range users {
+		out.Users[i] = userOutput{
+			User:        string(user.GetUser()),
+			Active:      user.GetActive(),
+			Permissions: make([]permissionOutput, len(user.GetPermissions())),
+			CreatedBy:   user.Createdby,
+			CreatedAt:   user.Createdat,
+		}
+		for j, permission := range user.GetPermissions() {
+			out.Users[i].Permissions[j] = permissionOutput{
+				Database:   permission.Database,
+				Permission: permissionToString(permission.Permission),
+			}
+		}
+	}
+	return out
+}
+
+func userListTable(users []*schema.User) string {
 	usersAndPermissions := make([][]string, 0, len(users))
 	maxColWidths := make([]int, 6)
 	for _, user := range users {
@@ -205,7 +253,7 @@ func (cl *commandline) userList(args []string) (string, error) {
 		fmt.Sprintf("%d user(s)", len(users)),
 	)
 	w.Flush()
-	return b.String(), nil
+	return b.String()
 }
 
 func updateMaxLen(maxs []int, strs []string) {
@@ -226,6 +274,8 @@ func permissionToString(permission uint32) string {
 		return fmt.Sprintf("Read")
 	case auth.PermissionRW:
 		return fmt.Sprintf("Read/Write")
+	case auth.PermissionRestrictedR:
+		return fmt.Sprintf("Restricted Read")
 	default:
 		return fmt.Sprintf("unknown: %d", permission)
 	}
@@ -357,9 +407,11 @@ func permissionFromString(permissionStr string) (uint32, error) {
 		permission = auth.PermissionAdmin
 	case "readwrite":
 		permission = auth.PermissionRW
+	case "restrictedread":
+		permission = auth.PermissionRestrictedR
 	default:
 		return 0, fmt.Errorf(
-			"Permission %s not recognized: allowed permissions are read, readwrite, admin",
+			"Permission %s not recognized: allowed permissions are read, readwrite, restrictedread, admin",
 			permissionStr)
 	}
 	return permission, nil
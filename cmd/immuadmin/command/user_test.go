@@ -382,7 +382,7 @@ func TestUserCreateErrors(t *testing.T) {
 	require.Equal(
 		t,
 		fmt.Errorf(
-			"Permission %s not recognized: allowed permissions are read, readwrite, admin",
+			"Permission %s not recognized: allowed permissions are read, readwrite, restrictedread, admin",
 			args[1]), err)
 
 	args[1] = permission
@@ -679,7 +679,7 @@ func TestUserPermissionErrors(t *testing.T) {
 	require.Equal(
 		t,
 		fmt.Errorf(
-			"Permission %s not recognized: allowed permissions are read, readwrite, admin",
+			"Permission %s not recognized: allowed permissions are read, readwrite, restrictedread, admin",
 			args[2]),
 		err)
 
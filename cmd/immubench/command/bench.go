@@ -0,0 +1,270 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package immubench
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	kvWorkload  = "kv"
+	sqlWorkload = "sql"
+)
+
+// errConflict is returned by sessions when a write conflicts with a concurrent transaction, the write is then retried
+var errConflict = errors.New("transaction conflict")
+
+// populateBatchSize is the number of keys written per transaction while populating the key space
+const populateBatchSize = 100
+
+type benchOptions struct {
+	workload    string
+	readRatio   int // percentage of reads
+	valueSize   int
+	keys        int
+	concurrency int
+	duration    time.Duration
+	operations  int // 0 runs for the whole duration
+	seed        int64
+}
+
+func defaultBenchOptions() *benchOptions {
+	return &benchOptions{
+		workload:    kvWorkload,
+		readRatio:   50,
+		valueSize:   256,
+		keys:        10_000,
+		concurrency: 8,
+		duration:    10 * time.Second,
+		seed:        1,
+	}
+}
+
+func (opts *benchOptions) validate() error {
+	if opts.workload != kvWorkload && opts.workload != sqlWorkload {
+		return fmt.Errorf("invalid workload '%s', it must be one of: %s, %s", opts.workload, kvWorkload, sqlWorkload)
+	}
+	if opts.readRatio < 0 || opts.readRatio > 100 {
+		return fmt.Errorf("invalid read ratio %d, it must be a percentage between 0 and 100", opts.readRatio)
+	}
+	if opts.valueSize <= 0 {
+		return fmt.Errorf("invalid value size %d, it must be greater than 0", opts.valueSize)
+	}
+	if opts.keys <= 0 {
+		return fmt.Errorf("invalid number of keys %d, it must be greater than 0", opts.keys)
+	}
+	if opts.concurrency <= 0 {
+		return fmt.Errorf("invalid concurrency %d, it must be greater than 0", opts.concurrency)
+	}
+	if opts.duration <= 0 {
+		return fmt.Errorf("invalid duration %s, it must be greater than 0", opts.duration)
+	}
+	if opts.operations < 0 {
+		return fmt.Errorf("invalid number of operations %d, it must not be negative", opts.operations)
+	}
+	return nil
+}
+
+// driver opens the sessions the workload is run through
+type driver interface {
+	fmt.Stringer
+	session(ctx context.Context) (session, error)
+	close() error
+}
+
+// session runs the operations of a single worker, keys are identified by their number
+type session interface {
+	// setup prepares the workload, creating the benchmark table if needed. It's called once per run
+	setup(ctx context.Context) error
+	populate(ctx context.Context, keys []int, value []byte) error
+	read(ctx context.Context, key int) error
+	write(ctx context.Context, key int, value []byte) error
+	close() error
+}
+
+func benchKey(key int) []byte {
+	return []byte(fmt.Sprintf("bench:%010d", key))
+}
+
+const (
+	createBenchTableStmt = "CREATE TABLE IF NOT EXISTS bench (k INTEGER, v BLOB, PRIMARY KEY k)"
+	selectBenchRowStmt   = "SELECT v FROM bench WHERE k = @k"
+	upsertBenchRowStmt   = "UPSERT INTO bench (k, v) VALUES (@k, @v)"
+)
+
+// populateStmt returns a statement upserting the rows of the given keys
+func populateStmt(keys []int, value []byte) (string, map[string]interface{}) {
+	rows := make([]string, len(keys))
+	params := make(map[string]interface{}, len(keys)+1)
+
+	for i, k := range keys {
+		rows[i] = fmt.Sprintf("(@k%d, @v)", i)
+		params[fmt.Sprintf("k%d", i)] = k
+	}
+	params["v"] = value
+
+	return "UPSERT INTO bench (k, v) VALUES " + strings.Join(rows, ", "), params
+}
+
+// run populates the key space and runs the workload, reporting the latencies of every operation
+func run(ctx context.Context, drv driver, opts *benchOptions) (*report, error) {
+	err := populate(ctx, drv, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, opts.duration)
+	defer cancel()
+
+	var wg sync.WaitGroup
+
+	samples := make([]*workerSamples, opts.concurrency)
+	errs := make([]error, opts.concurrency)
+
+	var operations int64
+
+	startedAt := time.Now()
+
+	for w := 0; w < opts.concurrency; w++ {
+		wg.Add(1)
+
+		go func(w int) {
+			defer wg.Done()
+
+			samples[w], errs[w] = runWorker(ctx, drv, opts, opts.seed+int64(w), &operations)
+			if errs[w] != nil {
+				// the first failure ends the run
+				cancel()
+			}
+		}(w)
+	}
+
+	wg.Wait()
+
+	elapsed := time.Since(startedAt)
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return newReport(samples, elapsed), nil
+}
+
+func populate(ctx context.Context, drv driver, opts *benchOptions) error {
+	s, err := drv.session(ctx)
+	if err != nil {
+		return err
+	}
+	defer s.close()
+
+	err = s.setup(ctx)
+	if err != nil {
+		return err
+	}
+
+	rnd := rand.New(rand.NewSource(opts.seed))
+
+	value := make([]byte, opts.valueSize)
+	rnd.Read(value)
+
+	keys := make([]int, 0, populateBatchSize)
+
+	for k := 0; k < opts.keys; k++ {
+		keys = append(keys, k)
+
+		if len(keys) == populateBatchSize || k == opts.keys-1 {
+			err = s.populate(ctx, keys, value)
+			if err != nil {
+				return fmt.Errorf("populating the key space: %w", err)
+			}
+
+			keys = keys[:0]
+		}
+	}
+
+	return nil
+}
+
+func runWorker(ctx context.Context, drv driver, opts *benchOptions, seed int64, operations *int64) (*workerSamples, error) {
+	s, err := drv.session(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer s.close()
+
+	rnd := rand.New(rand.NewSource(seed))
+
+	value := make([]byte, opts.valueSize)
+
+	samples := &workerSamples{}
+
+	for ctx.Err() == nil {
+		if opts.operations > 0 && atomic.AddInt64(operations, 1) > int64(opts.operations) {
+			break
+		}
+
+		key := rnd.Intn(opts.keys)
+		isRead := rnd.Intn(100) < opts.readRatio
+
+		if !isRead {
+			rnd.Read(value)
+		}
+
+		startedAt := time.Now()
+
+		for {
+			if isRead {
+				err = s.read(ctx, key)
+			} else {
+				err = s.write(ctx, key, value)
+			}
+
+			if err != errConflict {
+				break
+			}
+
+			samples.conflicts++
+		}
+
+		latency := time.Since(startedAt)
+
+		if err != nil {
+			if ctx.Err() != nil {
+				// the operation was interrupted by the end of the run
+				break
+			}
+			return nil, err
+		}
+
+		if isRead {
+			samples.reads = append(samples.reads, latency)
+		} else {
+			samples.writes = append(samples.writes, latency)
+		}
+	}
+
+	return samples, nil
+}
@@ -0,0 +1,38 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package immubench
+
+import (
+	"os"
+
+	c "github.com/codenotary/immudb/cmd/helper"
+	"github.com/codenotary/immudb/cmd/version"
+	"github.com/spf13/cobra"
+)
+
+// NewCmd creates a new immubench command
+func NewCmd(onError func(err error)) *cobra.Command {
+	cmd := &cobra.Command{}
+	Init(cmd,
+		&commandline{
+			out:     os.Stdout,
+			config:  c.Config{Name: "immubench"},
+			onError: onError,
+		})
+	cmd.AddCommand(version.VersionCmd())
+	return cmd
+}
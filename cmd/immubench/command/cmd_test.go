@@ -0,0 +1,121 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package immubench
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	c "github.com/codenotary/immudb/cmd/helper"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPercentile(t *testing.T) {
+	latencies := make([]time.Duration, 1000)
+	for i := range latencies {
+		latencies[i] = time.Duration(i+1) * time.Millisecond
+	}
+
+	require.Equal(t, 1*time.Millisecond, percentile(latencies, 0))
+	require.Equal(t, 500*time.Millisecond, percentile(latencies, 50))
+	require.Equal(t, 990*time.Millisecond, percentile(latencies, 99))
+	require.Equal(t, 999*time.Millisecond, percentile(latencies, 99.9))
+	require.Equal(t, 1000*time.Millisecond, percentile(latencies, 100))
+
+	require.Equal(t, 7*time.Millisecond, percentile([]time.Duration{7 * time.Millisecond}, 99))
+}
+
+func TestBenchOptions(t *testing.T) {
+	require.NoError(t, defaultBenchOptions().validate())
+
+	for _, invalid := range []func(opts *benchOptions){
+		func(opts *benchOptions) { opts.workload = "unknown" },
+		func(opts *benchOptions) { opts.readRatio = 101 },
+		func(opts *benchOptions) { opts.valueSize = 0 },
+		func(opts *benchOptions) { opts.keys = 0 },
+		func(opts *benchOptions) { opts.concurrency = 0 },
+		func(opts *benchOptions) { opts.duration = 0 },
+		func(opts *benchOptions) { opts.operations = -1 },
+	} {
+		opts := defaultBenchOptions()
+		invalid(opts)
+		require.Error(t, opts.validate())
+	}
+}
+
+func TestEmbeddedBench(t *testing.T) {
+	for _, workload := range []string{kvWorkload, sqlWorkload} {
+		t.Run(workload, func(t *testing.T) {
+			opts := defaultBenchOptions()
+			opts.workload = workload
+			opts.keys = 250
+			opts.concurrency = 4
+			opts.operations = 200
+
+			dir, err := ioutil.TempDir("", "immubench_"+workload)
+			require.NoError(t, err)
+			defer os.RemoveAll(dir)
+
+			drv, err := openEmbeddedDriver(dir, opts)
+			require.NoError(t, err)
+			defer drv.close()
+
+			report, err := run(context.Background(), drv, opts)
+			require.NoError(t, err)
+			require.Equal(t, 200, report.total.count)
+			require.Equal(t, report.total.count, report.reads.count+report.writes.count)
+			require.NotZero(t, report.reads.count)
+			require.NotZero(t, report.writes.count)
+			require.LessOrEqual(t, report.total.p50, report.total.p99)
+			require.LessOrEqual(t, report.total.p99, report.total.max)
+
+			var out bytes.Buffer
+			report.print(&out)
+			require.Contains(t, out.String(), "200 operations completed")
+		})
+	}
+}
+
+func TestImmubenchCommand(t *testing.T) {
+	dir, err := ioutil.TempDir("", "immubench")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	var out bytes.Buffer
+
+	cmd := &cobra.Command{}
+	Init(cmd, &commandline{
+		out:     &out,
+		config:  c.Config{Name: "immubench"},
+		onError: func(err error) { require.NoError(t, err) },
+	})
+
+	cmd.SetArgs([]string{"--embedded", dir, "--keys", "100", "--operations", "50", "--concurrency", "2"})
+	err = cmd.Execute()
+	require.NoError(t, err)
+	require.Contains(t, out.String(), "Running kv workload against embedded store")
+	require.Contains(t, out.String(), "50 operations completed")
+
+	cmd.SetArgs([]string{"--embedded", dir, "--workload", "unknown"})
+	err = cmd.Execute()
+	require.Error(t, err)
+}
@@ -0,0 +1,194 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package immubench
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/codenotary/immudb/embedded/sql"
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/logger"
+)
+
+const embeddedSQLDatabase = "bench"
+
+// embeddedDriver runs the workload directly over a store, sessions share the store and SQL engine
+type embeddedDriver struct {
+	dir      string
+	workload string
+	st       *store.ImmuStore
+	engine   *sql.Engine
+}
+
+func openEmbeddedDriver(dir string, opts *benchOptions) (*embeddedDriver, error) {
+	stOpts := store.DefaultOptions().
+		WithLog(logger.NewSimpleLoggerWithLevel("immubench", os.Stderr, logger.LogError))
+	if opts.valueSize > stOpts.MaxValueLen {
+		stOpts.WithMaxValueLen(opts.valueSize)
+	}
+
+	st, err := store.Open(dir, stOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	drv := &embeddedDriver{
+		dir:      dir,
+		workload: opts.workload,
+		st:       st,
+	}
+
+	if opts.workload == sqlWorkload {
+		drv.engine, err = sql.Open(st, sql.DefaultOptions().WithPrefix([]byte("sql")))
+		if err != nil {
+			st.Close()
+			return nil, err
+		}
+	}
+
+	return drv, nil
+}
+
+func (d *embeddedDriver) String() string {
+	return fmt.Sprintf("embedded store at %s", d.dir)
+}
+
+func (d *embeddedDriver) session(ctx context.Context) (session, error) {
+	if d.workload == sqlWorkload {
+		return &embeddedSQLSession{engine: d.engine}, nil
+	}
+	return &embeddedKVSession{st: d.st}, nil
+}
+
+func (d *embeddedDriver) close() error {
+	return d.st.Close()
+}
+
+type embeddedKVSession struct {
+	st *store.ImmuStore
+}
+
+func (s *embeddedKVSession) setup(ctx context.Context) error {
+	return nil
+}
+
+func (s *embeddedKVSession) populate(ctx context.Context, keys []int, value []byte) error {
+	tx, err := s.st.NewWriteOnlyTx()
+	if err != nil {
+		return err
+	}
+
+	for _, k := range keys {
+		err = tx.Set(benchKey(k), nil, value)
+		if err != nil {
+			tx.Cancel()
+			return err
+		}
+	}
+
+	hdr, err := tx.Commit()
+	if err != nil {
+		return err
+	}
+
+	// populated keys must be readable once the run starts
+	return s.st.WaitForIndexingUpto(hdr.ID, nil)
+}
+
+func (s *embeddedKVSession) read(ctx context.Context, key int) error {
+	valRef, err := s.st.Get(benchKey(key))
+	if err != nil {
+		return err
+	}
+
+	_, err = valRef.Resolve()
+	return err
+}
+
+func (s *embeddedKVSession) write(ctx context.Context, key int, value []byte) error {
+	tx, err := s.st.NewWriteOnlyTx()
+	if err != nil {
+		return err
+	}
+
+	err = tx.Set(benchKey(key), nil, value)
+	if err != nil {
+		tx.Cancel()
+		return err
+	}
+
+	_, err = tx.Commit()
+	return err
+}
+
+func (s *embeddedKVSession) close() error {
+	return nil
+}
+
+type embeddedSQLSession struct {
+	engine *sql.Engine
+}
+
+func (s *embeddedSQLSession) setup(ctx context.Context) error {
+	_, _, err := s.engine.ExecContext(ctx, "CREATE DATABASE "+embeddedSQLDatabase, nil, nil)
+	if err != nil && !errors.Is(err, sql.ErrDatabaseAlreadyExists) {
+		return err
+	}
+
+	err = s.engine.SetDefaultDatabase(embeddedSQLDatabase)
+	if err != nil {
+		return err
+	}
+
+	_, _, err = s.engine.ExecContext(ctx, createBenchTableStmt, nil, nil)
+	return err
+}
+
+func (s *embeddedSQLSession) populate(ctx context.Context, keys []int, value []byte) error {
+	stmt, params := populateStmt(keys, value)
+
+	_, _, err := s.engine.ExecContext(ctx, stmt, params, nil)
+	return err
+}
+
+func (s *embeddedSQLSession) read(ctx context.Context, key int) error {
+	rows, err := s.engine.QueryRows(ctx, selectBenchRowStmt, map[string]interface{}{"k": key}, nil)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+	}
+
+	return rows.Err()
+}
+
+func (s *embeddedSQLSession) write(ctx context.Context, key int, value []byte) error {
+	_, _, err := s.engine.ExecContext(ctx, upsertBenchRowStmt, map[string]interface{}{"k": key, "v": value}, nil)
+	if errors.Is(err, store.ErrTxReadConflict) {
+		return errConflict
+	}
+	return err
+}
+
+func (s *embeddedSQLSession) close() error {
+	return nil
+}
@@ -0,0 +1,147 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package immubench
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/codenotary/immudb/cmd/docs/man"
+	c "github.com/codenotary/immudb/cmd/helper"
+	"github.com/codenotary/immudb/pkg/auth"
+	"github.com/codenotary/immudb/pkg/client"
+	"github.com/codenotary/immudb/pkg/server"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+type commandline struct {
+	out     io.Writer
+	config  c.Config
+	onError func(err error)
+}
+
+// Init initializes the command
+func Init(cmd *cobra.Command, cl *commandline) {
+	if err := cl.configureFlags(cmd); err != nil {
+		cl.onError(err)
+		return
+	}
+
+	cmd.Use = "immubench"
+	cmd.Short = "Run reproducible KV and SQL workloads against immudb and report throughput and latency percentiles"
+	cmd.Long = fmt.Sprintf(`Run reproducible KV and SQL workloads against immudb and report throughput and latency percentiles.
+
+The key space is populated before the run, then each worker performs reads and writes
+of random keys in the configured proportion until the duration elapses or the number
+of operations is reached. Workers generate their keys and values from the seed, so runs
+with the same seed perform the same sequence of operations on each worker.
+
+Workloads run against an immudb server unless an embedded store directory is given.
+  Environment variables:
+    IMMUBENCH_IMMUDB_ADDRESS=127.0.0.1
+    IMMUBENCH_IMMUDB_PORT=3322
+    IMMUBENCH_DATABASE=%s
+    IMMUBENCH_USER=%s`,
+		server.DefaultDBName, auth.SysAdminUsername)
+	cmd.Example = `  immubench --workload kv --read-ratio 90 --concurrency 16 --duration 30s
+  immubench --workload sql --value-size 1024 --operations 100000 --database some-database
+  immubench --embedded ./benchdata --workload kv --read-ratio 0`
+	cmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		return cl.config.LoadConfig(cmd)
+	}
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		opts := benchOptionsFromConfig()
+		if err := opts.validate(); err != nil {
+			return err
+		}
+
+		drv, err := cl.driver(opts)
+		if err != nil {
+			return err
+		}
+		defer drv.close()
+
+		fmt.Fprintf(cl.out, "Running %s workload against %s: %d workers, %d%% reads, %d keys, values of %d bytes\n",
+			opts.workload, drv, opts.concurrency, opts.readRatio, opts.keys, opts.valueSize)
+
+		report, err := run(context.Background(), drv, opts)
+		if err != nil {
+			return err
+		}
+
+		report.print(cl.out)
+
+		return nil
+	}
+	cmd.Args = cobra.NoArgs
+	cmd.SilenceUsage = true
+	cmd.DisableAutoGenTag = true
+	cmd.AddCommand(man.Generate(cmd, "immubench", "./cmd/docs/man/immubench"))
+}
+
+func benchOptionsFromConfig() *benchOptions {
+	return &benchOptions{
+		workload:    viper.GetString("workload"),
+		readRatio:   viper.GetInt("read-ratio"),
+		valueSize:   viper.GetInt("value-size"),
+		keys:        viper.GetInt("keys"),
+		concurrency: viper.GetInt("concurrency"),
+		duration:    viper.GetDuration("duration"),
+		operations:  viper.GetInt("operations"),
+		seed:        viper.GetInt64("seed"),
+	}
+}
+
+func (cl *commandline) driver(opts *benchOptions) (driver, error) {
+	if dir := viper.GetString("embedded"); dir != "" {
+		return openEmbeddedDriver(dir, opts)
+	}
+
+	return newServerDriver(
+		client.DefaultOptions().
+			WithAddress(viper.GetString("immudb-address")).
+			WithPort(viper.GetInt("immudb-port")),
+		viper.GetString("user"),
+		viper.GetString("password"),
+		viper.GetString("database"),
+		opts,
+	), nil
+}
+
+func (cl *commandline) configureFlags(cmd *cobra.Command) error {
+	defaults := defaultBenchOptions()
+
+	cmd.PersistentFlags().IntP("immudb-port", "p", client.DefaultOptions().Port, "immudb port number")
+	cmd.PersistentFlags().StringP("immudb-address", "a", client.DefaultOptions().Address, "immudb host address")
+	cmd.PersistentFlags().StringP("database", "d", server.DefaultDBName, "database to run the workload against")
+	cmd.PersistentFlags().StringP("user", "u", auth.SysAdminUsername, "database user")
+	cmd.PersistentFlags().String("password", auth.SysAdminPassword, "database user password")
+	cmd.PersistentFlags().String("embedded", "", "run against an embedded store in the given directory instead of a server")
+	cmd.PersistentFlags().StringP("workload", "w", defaults.workload, "workload to run: kv or sql")
+	cmd.PersistentFlags().Int("read-ratio", defaults.readRatio, "percentage of read operations, the remaining ones are writes")
+	cmd.PersistentFlags().Int("value-size", defaults.valueSize, "size in bytes of the written values")
+	cmd.PersistentFlags().Int("keys", defaults.keys, "number of distinct keys, populated before the run")
+	cmd.PersistentFlags().IntP("concurrency", "c", defaults.concurrency, "number of concurrent workers")
+	cmd.PersistentFlags().Duration("duration", defaults.duration, "duration of the run")
+	cmd.PersistentFlags().Int("operations", defaults.operations, "number of operations after which the run ends, 0 to run for the whole duration")
+	cmd.PersistentFlags().Int64("seed", defaults.seed, "seed of the generated keys and values")
+	cmd.PersistentFlags().StringVar(&cl.config.CfgFn, "config", "", "config file (default path are configs or $HOME. Default filename is immubench.toml)")
+
+	return viper.BindPFlags(cmd.PersistentFlags())
+}
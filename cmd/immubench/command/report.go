@@ -0,0 +1,151 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package immubench
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	c "github.com/codenotary/immudb/cmd/helper"
+)
+
+// workerSamples holds the latencies of the operations performed by a worker
+type workerSamples struct {
+	reads     []time.Duration
+	writes    []time.Duration
+	conflicts int // retried writes, their latency includes the retries
+}
+
+type report struct {
+	elapsed   time.Duration
+	conflicts int
+	reads     *opStats
+	writes    *opStats
+	total     *opStats
+}
+
+// opStats summarizes the latencies of a kind of operation
+type opStats struct {
+	count      int
+	throughput float64 // operations per second
+	mean       time.Duration
+	p50        time.Duration
+	p90        time.Duration
+	p99        time.Duration
+	p999       time.Duration
+	max        time.Duration
+}
+
+func newReport(samples []*workerSamples, elapsed time.Duration) *report {
+	var reads, writes []time.Duration
+	var conflicts int
+
+	for _, s := range samples {
+		reads = append(reads, s.reads...)
+		writes = append(writes, s.writes...)
+		conflicts += s.conflicts
+	}
+
+	total := make([]time.Duration, 0, len(reads)+len(writes))
+	total = append(total, reads...)
+	total = append(total, writes...)
+
+	return &report{
+		elapsed:   elapsed,
+		conflicts: conflicts,
+		reads:     newOpStats(reads, elapsed),
+		writes:    newOpStats(writes, elapsed),
+		total:     newOpStats(total, elapsed),
+	}
+}
+
+func newOpStats(latencies []time.Duration, elapsed time.Duration) *opStats {
+	stats := &opStats{count: len(latencies)}
+
+	if len(latencies) == 0 {
+		return stats
+	}
+
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+
+	var sum time.Duration
+	for _, l := range latencies {
+		sum += l
+	}
+
+	stats.throughput = float64(len(latencies)) / elapsed.Seconds()
+	stats.mean = sum / time.Duration(len(latencies))
+	stats.p50 = percentile(latencies, 50)
+	stats.p90 = percentile(latencies, 90)
+	stats.p99 = percentile(latencies, 99)
+	stats.p999 = percentile(latencies, 99.9)
+	stats.max = latencies[len(latencies)-1]
+
+	return stats
+}
+
+// percentile returns the nearest-rank percentile p of the sorted latencies
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(p/100*float64(len(sorted))+0.5) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	if rank >= len(sorted) {
+		rank = len(sorted) - 1
+	}
+	return sorted[rank]
+}
+
+func (r *report) print(w io.Writer) {
+	rows := []struct {
+		op    string
+		stats *opStats
+	}{
+		{"read", r.reads},
+		{"write", r.writes},
+		{"total", r.total},
+	}
+
+	c.PrintTable(
+		w,
+		[]string{"OPERATION", "COUNT", "OPS/S", "MEAN", "P50", "P90", "P99", "P99.9", "MAX"},
+		len(rows),
+		func(i int) []string {
+			s := rows[i].stats
+			return []string{
+				rows[i].op,
+				strconv.Itoa(s.count),
+				fmt.Sprintf("%.1f", s.throughput),
+				formatLatency(s.mean),
+				formatLatency(s.p50),
+				formatLatency(s.p90),
+				formatLatency(s.p99),
+				formatLatency(s.p999),
+				formatLatency(s.max),
+			}
+		},
+		fmt.Sprintf("%d operations completed in %s, %d conflicting writes retried",
+			r.total.count, r.elapsed.Round(time.Millisecond), r.conflicts),
+	)
+}
+
+func formatLatency(d time.Duration) string {
+	return d.Round(time.Microsecond).String()
+}
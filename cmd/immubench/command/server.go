@@ -0,0 +1,134 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package immubench
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/client"
+	immuErrors "github.com/codenotary/immudb/pkg/client/errors"
+)
+
+// serverDriver runs the workload against an immudb server, each session opens its own client session
+type serverDriver struct {
+	opts     *client.Options
+	user     string
+	password string
+	database string
+	workload string
+}
+
+func newServerDriver(opts *client.Options, user, password, database string, benchOpts *benchOptions) *serverDriver {
+	return &serverDriver{
+		opts:     opts,
+		user:     user,
+		password: password,
+		database: database,
+		workload: benchOpts.workload,
+	}
+}
+
+func (d *serverDriver) String() string {
+	return fmt.Sprintf("database %s at %s:%d", d.database, d.opts.Address, d.opts.Port)
+}
+
+func (d *serverDriver) session(ctx context.Context) (session, error) {
+	cli := client.NewClient().WithOptions(d.opts)
+
+	err := cli.OpenSession(ctx, []byte(d.user), []byte(d.password), d.database)
+	if err != nil {
+		return nil, err
+	}
+
+	if d.workload == sqlWorkload {
+		return &serverSQLSession{cli: cli}, nil
+	}
+	return &serverKVSession{cli: cli}, nil
+}
+
+func (d *serverDriver) close() error {
+	return nil
+}
+
+type serverKVSession struct {
+	cli client.ImmuClient
+}
+
+func (s *serverKVSession) setup(ctx context.Context) error {
+	return nil
+}
+
+func (s *serverKVSession) populate(ctx context.Context, keys []int, value []byte) error {
+	req := &schema.SetRequest{KVs: make([]*schema.KeyValue, len(keys))}
+
+	for i, k := range keys {
+		req.KVs[i] = &schema.KeyValue{Key: benchKey(k), Value: value}
+	}
+
+	_, err := s.cli.SetAll(ctx, req)
+	return err
+}
+
+func (s *serverKVSession) read(ctx context.Context, key int) error {
+	_, err := s.cli.Get(ctx, benchKey(key))
+	return err
+}
+
+func (s *serverKVSession) write(ctx context.Context, key int, value []byte) error {
+	_, err := s.cli.Set(ctx, benchKey(key), value)
+	return err
+}
+
+func (s *serverKVSession) close() error {
+	return s.cli.CloseSession(context.Background())
+}
+
+type serverSQLSession struct {
+	cli client.ImmuClient
+}
+
+func (s *serverSQLSession) setup(ctx context.Context) error {
+	_, err := s.cli.SQLExec(ctx, createBenchTableStmt, nil)
+	return err
+}
+
+func (s *serverSQLSession) populate(ctx context.Context, keys []int, value []byte) error {
+	stmt, params := populateStmt(keys, value)
+
+	_, err := s.cli.SQLExec(ctx, stmt, params)
+	return err
+}
+
+func (s *serverSQLSession) read(ctx context.Context, key int) error {
+	_, err := s.cli.SQLQuery(ctx, selectBenchRowStmt, map[string]interface{}{"k": key}, false)
+	return err
+}
+
+func (s *serverSQLSession) write(ctx context.Context, key int, value []byte) error {
+	_, err := s.cli.SQLExec(ctx, upsertBenchRowStmt, map[string]interface{}{"k": key, "v": value})
+	if errors.Is(err, immuErrors.ErrTxReadConflict) {
+		return errConflict
+	}
+	return err
+}
+
+func (s *serverSQLSession) close() error {
+	return s.cli.CloseSession(context.Background())
+}
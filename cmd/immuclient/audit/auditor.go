@@ -27,6 +27,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/codenotary/immudb/pkg/alert"
 	"github.com/codenotary/immudb/pkg/signer"
 
 	"github.com/codenotary/immudb/pkg/auth"
@@ -86,15 +87,7 @@ func (cAgent *auditAgent) InitAgent() (AuditAgent, error) {
 	if err != nil {
 		return nil, err
 	}
-	auditDatabasesStr := viper.GetString("audit-databases")
-	auditDatabasesArr := strings.Split(auditDatabasesStr, ",")
-	var auditDatabases []string
-	for _, dbPrefix := range auditDatabasesArr {
-		dbPrefix = strings.TrimSpace(dbPrefix)
-		if len(dbPrefix) > 0 {
-			auditDatabases = append(auditDatabases, dbPrefix)
-		}
-	}
+	auditDatabases := splitList(viper.GetString("audit-databases"))
 	auditNotificationURL := viper.GetString("audit-notification-url")
 	auditNotificationUsername := viper.GetString("audit-notification-username")
 	auditNotificationPassword := viper.GetString("audit-notification-password")
@@ -108,6 +101,19 @@ func (cAgent *auditAgent) InitAgent() (AuditAgent, error) {
 		"%s:%d",
 		viper.GetString("audit-monitoring-host"), viper.GetInt("audit-monitoring-port"))
 
+	alertSinks, err := alert.DefaultOptions().
+		WithWebhookURL(viper.GetString("audit-alert-webhook-url")).
+		WithSMTPAddress(viper.GetString("audit-alert-smtp-address")).
+		WithSMTPUsername(viper.GetString("audit-alert-smtp-username")).
+		WithSMTPPassword(viper.GetString("audit-alert-smtp-password")).
+		WithSMTPFrom(viper.GetString("audit-alert-smtp-from")).
+		WithSMTPTo(splitList(viper.GetString("audit-alert-smtp-to"))).
+		WithPagerDutyRoutingKey(viper.GetString("audit-alert-pagerduty-routing-key")).
+		Sinks()
+	if err != nil {
+		return nil, err
+	}
+
 	var pk *ecdsa.PublicKey
 	if cliOpts.ServerSigningPubKey != "" {
 		pk, err = signer.ParsePublicKeyFile(cliOpts.ServerSigningPubKey)
@@ -134,9 +140,22 @@ func (cAgent *auditAgent) InitAgent() (AuditAgent, error) {
 		cache.NewHistoryFileCache(filepath.Join(os.TempDir(), "auditor")),
 		cAgent.metrics.updateMetrics,
 		cAgent.logger,
-		&auditMonitoringHTTPAddr)
+		&auditMonitoringHTTPAddr,
+		alertSinks...)
 	if err != nil {
 		return nil, err
 	}
 	return cAgent, nil
 }
+
+// splitList returns the non-empty trimmed items of a comma-separated list
+func splitList(list string) []string {
+	var items []string
+	for _, item := range strings.Split(list, ",") {
+		item = strings.TrimSpace(item)
+		if len(item) > 0 {
+			items = append(items, item)
+		}
+	}
+	return items
+}
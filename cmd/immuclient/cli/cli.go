@@ -36,6 +36,8 @@ type cli struct {
 	helpMessage  string
 	valueOnly    bool
 	isLoggedin   bool
+	historyFile  string
+	catalog      catalog
 }
 
 // Cli ...
@@ -49,6 +51,7 @@ func Init(immucl immuc.Client) Cli {
 	cli := new(cli)
 	cli.immucl = immucl
 	cli.valueOnly = viper.GetBool("value-only")
+	cli.historyFile = defaultHistoryFile()
 	cli.commands = make(map[string]*command)
 	cli.commandsList = make([]*command, 0)
 	cli.initCommands()
@@ -95,15 +98,28 @@ func (cli *cli) helpInit() {
 		str.WriteString("\n")
 	}
 	str.WriteString("\n")
+	str.WriteString("SQL statements (e.g. SELECT, INSERT, CREATE) can be typed directly, spanning multiple lines until terminated by ';'\n")
+	str.WriteString(`Meta-commands: \d [table], \dt, \l, \c databasename, \?, \q` + "\n")
 	cli.helpMessage = str.String()
 }
 
 func (cli *cli) Run() {
 	l := liner.NewLiner()
-	l.SetCompleter(cli.completer)
+	l.SetWordCompleter(cli.wordCompleter)
+	l.SetTabCompletionStyle(liner.TabPrints)
+	cli.readHistory(l)
 	defer l.Close()
+	defer cli.writeHistory(l)
+
+	// SQL statements typed directly may span multiple lines until terminated by ';'
+	var stmt strings.Builder
+
 	for {
-		line, err := l.Prompt("immuclient>")
+		prompt := "immuclient>"
+		if stmt.Len() > 0 {
+			prompt = "         ->"
+		}
+		line, err := l.Prompt(prompt)
 		if err == liner.ErrInvalidPrompt {
 			if len(line) == 0 {
 				break
@@ -116,12 +132,25 @@ func (cli *cli) Run() {
 		if err != nil {
 			fmt.Fprintln(os.Stderr, err)
 		}
+		line = strings.TrimSpace(line)
+		if stmt.Len() > 0 || cli.isSQLStatement(line) {
+			stmt.WriteString(line)
+			if !strings.HasSuffix(line, ";") {
+				stmt.WriteString("\n")
+				continue
+			}
+			sqlStmt := stmt.String()
+			stmt.Reset()
+			l.AppendHistory(strings.ReplaceAll(sqlStmt, "\n", " "))
+			cli.runSQLStatement(sqlStmt)
+			continue
+		}
 		l.AppendHistory(line)
-		line = strings.TrimSuffix(line, "\n")
 		arrCommandStr := strings.Fields(line)
 		if len(arrCommandStr) == 0 {
 			continue
 		}
+		arrCommandStr, _ = translateMetaCommand(arrCommandStr)
 		passed := cli.checkCommand(arrCommandStr, l)
 		if passed {
 			cli.runCommand(arrCommandStr)
@@ -132,12 +161,31 @@ func (cli *cli) Run() {
 	}
 }
 
+// isSQLStatement reports if the line starts a SQL statement instead of a shell command
+func (cli *cli) isSQLStatement(line string) bool {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return false
+	}
+	_, isCommand := cli.commands[fields[0]]
+	return !isCommand && sqlStmtPrefixes[strings.ToUpper(fields[0])]
+}
+
+func (cli *cli) runSQLStatement(sqlStmt string) {
+	if strings.EqualFold(strings.Fields(sqlStmt)[0], "SELECT") {
+		cli.runCommand([]string{"query", sqlStmt})
+		return
+	}
+	cli.runCommand([]string{"exec", sqlStmt})
+}
+
 func (cli *cli) checkCommand(arrCommandStr []string, l *liner.State) bool {
 	if arrCommandStr[0] == "exit" || arrCommandStr[0] == "quit" {
 		if cli.isLoggedin {
 			logoutmsg, _ := cli.logout(nil)
 			fmt.Println(logoutmsg)
 		}
+		cli.writeHistory(l)
 		l.Close()
 		os.Exit(0)
 	}
@@ -233,6 +281,10 @@ func (cli *cli) runCommand(arrCommandStr []string) {
 	if valOnly {
 		cli.immucl.SetValueOnly(false)
 	}
+	switch command.name {
+	case "exec", "use", "login", "logout":
+		cli.invalidateCatalog()
+	}
 	if err != nil {
 		fmt.Fprintf(os.Stdout, "ERROR: %s \n", err.Error())
 		return
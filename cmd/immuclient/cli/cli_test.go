@@ -325,3 +325,17 @@ func TestImmuClient_BackupAndRestoreUX(t *testing.T) {
 
 	require.Equal(t, client.ErrServerStateIsOlder, err)
 }
+
+func TestIsSQLStatement(t *testing.T) {
+	cli := new(cli)
+	cli.commands = make(map[string]*command, 0)
+	cli.commandsList = make([]*command, 0)
+	cli.initCommands()
+
+	require.True(t, cli.isSQLStatement("SELECT * FROM table1"))
+	require.True(t, cli.isSQLStatement("create table table1 (id INTEGER, PRIMARY KEY id);"))
+	require.True(t, cli.isSQLStatement("DELETE FROM table1;"))
+	require.False(t, cli.isSQLStatement("delete key1"))
+	require.False(t, cli.isSQLStatement("set key1 value1"))
+	require.False(t, cli.isSQLStatement("  "))
+}
@@ -0,0 +1,200 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cli
+
+import (
+	"context"
+	"strings"
+
+	"github.com/codenotary/immudb/embedded/sql"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/client"
+)
+
+var sqlKeywords = sql.Keywords()
+
+// sqlStmtPrefixes are the keywords starting a statement typed directly in the shell
+var sqlStmtPrefixes = map[string]bool{
+	"SELECT":   true,
+	"INSERT":   true,
+	"UPSERT":   true,
+	"UPDATE":   true,
+	"DELETE":   true,
+	"CREATE":   true,
+	"ALTER":    true,
+	"BEGIN":    true,
+	"COMMIT":   true,
+	"ROLLBACK": true,
+	"USE":      true,
+}
+
+// catalog caches the tables and columns of the current database, used for completion
+type catalog struct {
+	tables  []string
+	columns map[string][]string
+	loaded  bool
+}
+
+func (cli *cli) invalidateCatalog() {
+	cli.catalog = catalog{}
+}
+
+func (cli *cli) catalogTables() []string {
+	if !cli.catalog.loaded {
+		cli.catalog.columns = make(map[string][]string)
+		cli.catalog.tables = cli.fetchFirstColumn(func(ic client.ImmuClient) (*schema.SQLQueryResult, error) {
+			return ic.ListTables(context.Background())
+		})
+		cli.catalog.loaded = true
+	}
+	return cli.catalog.tables
+}
+
+func (cli *cli) catalogColumns(table string) []string {
+	found := false
+	for _, t := range cli.catalogTables() {
+		if strings.EqualFold(t, table) {
+			table = t
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil
+	}
+
+	cols, ok := cli.catalog.columns[table]
+	if !ok {
+		cols = cli.fetchFirstColumn(func(ic client.ImmuClient) (*schema.SQLQueryResult, error) {
+			return ic.DescribeTable(context.Background(), table)
+		})
+		cli.catalog.columns[table] = cols
+	}
+	return cols
+}
+
+func (cli *cli) fetchFirstColumn(f func(ic client.ImmuClient) (*schema.SQLQueryResult, error)) []string {
+	if cli.immucl == nil {
+		return nil
+	}
+
+	res, err := cli.immucl.Execute(func(ic client.ImmuClient) (interface{}, error) {
+		return f(ic)
+	})
+	if err != nil {
+		return nil
+	}
+
+	values := make([]string, 0)
+	for _, row := range res.(*schema.SQLQueryResult).Rows {
+		if len(row.Values) > 0 {
+			values = append(values, row.Values[0].GetS())
+		}
+	}
+	return values
+}
+
+// wordCompleter completes the word under the cursor with commands, SQL keywords, tables and columns
+func (cli *cli) wordCompleter(line string, pos int) (head string, completions []string, tail string) {
+	if pos > len(line) {
+		pos = len(line)
+	}
+
+	start := strings.LastIndexAny(line[:pos], " \t(,=") + 1
+	head, word, tail := line[:start], line[start:pos], line[pos:]
+
+	fields := strings.Fields(head)
+
+	// first word: commands, meta-commands or the beginning of a SQL statement
+	if len(fields) == 0 {
+		completions = cli.completer(word)
+		for _, m := range metaCommandNames {
+			if strings.HasPrefix(m, word) {
+				completions = append(completions, m)
+			}
+		}
+		for _, kw := range sqlKeywords {
+			if sqlStmtPrefixes[kw] {
+				completions = append(completions, matchKeyword(kw, word)...)
+			}
+		}
+		return head, completions, tail
+	}
+
+	switch fields[0] {
+	case "describe", `\d`:
+		return head, matchPrefix(cli.catalogTables(), word), tail
+	}
+
+	if !cli.isSQL(fields[0]) {
+		return head, nil, tail
+	}
+
+	// qualified column name
+	if i := strings.LastIndex(word, "."); i > 0 {
+		table, colPrefix := word[:i], word[i+1:]
+		for _, c := range matchPrefix(cli.catalogColumns(table), colPrefix) {
+			completions = append(completions, table+"."+c)
+		}
+		return head, completions, tail
+	}
+
+	for _, kw := range sqlKeywords {
+		completions = append(completions, matchKeyword(kw, word)...)
+	}
+
+	tables := cli.catalogTables()
+	completions = append(completions, matchPrefix(tables, word)...)
+
+	// columns of the tables referenced in the statement
+	referenced := make(map[string]bool)
+	for _, f := range strings.FieldsFunc(line, func(r rune) bool { return strings.ContainsRune(" \t(),=;", r) }) {
+		for _, t := range tables {
+			if strings.EqualFold(f, t) && !referenced[t] {
+				referenced[t] = true
+				completions = append(completions, matchPrefix(cli.catalogColumns(t), word)...)
+			}
+		}
+	}
+
+	return head, completions, tail
+}
+
+func (cli *cli) isSQL(firstWord string) bool {
+	return firstWord == "exec" || firstWord == "query" || cli.isSQLStatement(firstWord)
+}
+
+// matchKeyword returns the keyword if it starts with the typed word, using the case of the word
+func matchKeyword(kw, word string) []string {
+	if !strings.HasPrefix(kw, strings.ToUpper(word)) {
+		return nil
+	}
+	if word != "" && word == strings.ToLower(word) {
+		return []string{strings.ToLower(kw)}
+	}
+	return []string{kw}
+}
+
+func matchPrefix(candidates []string, prefix string) []string {
+	matches := make([]string, 0)
+	for _, c := range candidates {
+		if strings.HasPrefix(strings.ToLower(c), strings.ToLower(prefix)) {
+			matches = append(matches, c)
+		}
+	}
+	return matches
+}
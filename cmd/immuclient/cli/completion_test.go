@@ -0,0 +1,155 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cli
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/codenotary/immudb/cmd/cmdtest"
+	test "github.com/codenotary/immudb/cmd/immuclient/immuclienttest"
+	"github.com/codenotary/immudb/pkg/client/tokenservice"
+	"github.com/codenotary/immudb/pkg/server"
+	"github.com/codenotary/immudb/pkg/server/servertest"
+	"github.com/stretchr/testify/require"
+)
+
+func newCompletionTestCli() *cli {
+	cli := new(cli)
+	cli.commands = make(map[string]*command)
+	cli.commandsList = make([]*command, 0)
+	cli.initCommands()
+	cli.catalog = catalog{
+		tables: []string{"customers", "orders"},
+		columns: map[string][]string{
+			"customers": {"id", "name", "country"},
+			"orders":    {"id", "customer_id", "amount"},
+		},
+		loaded: true,
+	}
+	return cli
+}
+
+func TestWordCompleter(t *testing.T) {
+	cli := newCompletionTestCli()
+
+	t.Run("first word", func(t *testing.T) {
+		head, c, tail := cli.wordCompleter("safe", 4)
+		require.Empty(t, head)
+		require.Empty(t, tail)
+		require.Len(t, c, 4)
+
+		_, c, _ = cli.wordCompleter("sel", 3)
+		require.Equal(t, []string{"select"}, c)
+
+		_, c, _ = cli.wordCompleter("UPD", 3)
+		require.Equal(t, []string{"UPDATE"}, c)
+
+		_, c, _ = cli.wordCompleter(`\d`, 2)
+		require.Equal(t, []string{`\d`, `\dt`}, c)
+	})
+
+	t.Run("tables", func(t *testing.T) {
+		head, c, _ := cli.wordCompleter("describe cu", 11)
+		require.Equal(t, "describe ", head)
+		require.Equal(t, []string{"customers"}, c)
+
+		_, c, _ = cli.wordCompleter(`\d o`, 4)
+		require.Equal(t, []string{"orders"}, c)
+
+		_, c, _ = cli.wordCompleter("SELECT * FROM ord", 17)
+		require.Contains(t, c, "orders")
+		require.Contains(t, c, "order")
+	})
+
+	t.Run("columns of referenced tables", func(t *testing.T) {
+		line := "SELECT c FROM customers"
+		_, c, tail := cli.wordCompleter(line, 8)
+		require.Equal(t, " FROM customers", tail)
+		require.Contains(t, c, "country")
+		require.Contains(t, c, "cast")
+		require.NotContains(t, c, "customer_id")
+
+		_, c, _ = cli.wordCompleter("query SELECT orders.cu", 22)
+		require.Equal(t, []string{"orders.customer_id"}, c)
+
+		_, c, _ = cli.wordCompleter("SELECT unknown.", 15)
+		require.Empty(t, c)
+	})
+
+	t.Run("no completion for key-value commands", func(t *testing.T) {
+		_, c, _ := cli.wordCompleter("set cu", 6)
+		require.Empty(t, c)
+
+		_, c, _ = cli.wordCompleter("delete cu", 9)
+		require.Empty(t, c)
+	})
+
+	t.Run("catalog is reloaded after invalidation", func(t *testing.T) {
+		cli.invalidateCatalog()
+
+		// not connected
+		require.Empty(t, cli.catalogTables())
+		require.Empty(t, cli.catalogColumns("customers"))
+	})
+}
+
+func TestCatalogFromServer(t *testing.T) {
+	cli := new(cli)
+	cli.commands = make(map[string]*command)
+	cli.commandsList = make([]*command, 0)
+	cli.initCommands()
+	cli.helpInit()
+
+	options := server.DefaultOptions().WithAuth(true)
+	bs := servertest.NewBufconnServer(options)
+
+	bs.Start()
+	defer bs.Stop()
+
+	defer os.RemoveAll(options.Dir)
+	defer os.Remove(".state-")
+
+	tkf := cmdtest.RandString()
+	ts := tokenservice.NewFileTokenService().WithTokenFileName(tkf)
+	ic := test.NewClientTest(&test.PasswordReader{
+		Pass: []string{"immudb"},
+	}, ts)
+	ic.Connect(bs.Dialer)
+	ic.Login("immudb")
+
+	cli.immucl = ic.Imc
+
+	require.Empty(t, cli.catalogTables())
+
+	msg := test.CaptureStdout(func() {
+		cli.runSQLStatement("CREATE TABLE customers (id INTEGER, name VARCHAR, PRIMARY KEY id);")
+	})
+	require.Contains(t, msg, "Updated rows")
+
+	require.Equal(t, []string{"customers"}, cli.catalogTables())
+	require.Equal(t, []string{"id", "name"}, cli.catalogColumns("CUSTOMERS"))
+
+	_, c, _ := cli.wordCompleter("SELECT na FROM customers", 9)
+	require.Equal(t, []string{"name"}, c)
+
+	msg = test.CaptureStdout(func() {
+		cli.runSQLStatement("SELECT id, name\nFROM customers;")
+	})
+	require.True(t, strings.Contains(msg, "CUSTOMERS ID"), msg)
+}
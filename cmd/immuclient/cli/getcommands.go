@@ -24,6 +24,10 @@ func (cli *cli) getKey(args []string) (string, error) {
 	return cli.immucl.Get(args)
 }
 
+func (cli *cli) getAll(args []string) (string, error) {
+	return cli.immucl.GetAll(args)
+}
+
 func (cli *cli) safeGetKey(args []string) (string, error) {
 	return cli.immucl.VerifiedGet(args)
 }
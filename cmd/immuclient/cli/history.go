@@ -0,0 +1,64 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cli
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/peterh/liner"
+)
+
+const historyFileName = ".immuclient_history"
+
+func defaultHistoryFile() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, historyFileName)
+}
+
+// readHistory loads the commands typed in previous sessions, if any
+func (cli *cli) readHistory(l *liner.State) {
+	if cli.historyFile == "" {
+		return
+	}
+
+	f, err := os.Open(cli.historyFile)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	l.ReadHistory(f)
+}
+
+// writeHistory persists the typed commands so they are available in later sessions
+func (cli *cli) writeHistory(l *liner.State) {
+	if cli.historyFile == "" || l == nil {
+		return
+	}
+
+	f, err := os.OpenFile(cli.historyFile, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	l.WriteHistory(f)
+}
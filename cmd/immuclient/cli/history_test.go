@@ -0,0 +1,67 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cli
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/peterh/liner"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistoryFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "immuclient_history")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	cli := new(cli)
+	cli.historyFile = filepath.Join(dir, historyFileName)
+
+	l := liner.NewLiner()
+	l.AppendHistory("get key1")
+	l.AppendHistory("SELECT * FROM table1;")
+	cli.writeHistory(l)
+	l.Close()
+
+	l = liner.NewLiner()
+	defer l.Close()
+	cli.readHistory(l)
+
+	f, err := ioutil.TempFile(dir, "history")
+	require.NoError(t, err)
+	defer f.Close()
+
+	n, err := l.WriteHistory(f)
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+}
+
+func TestHistoryFileNotSet(t *testing.T) {
+	cli := new(cli)
+
+	l := liner.NewLiner()
+	defer l.Close()
+
+	cli.readHistory(l)
+	cli.writeHistory(l)
+	cli.writeHistory(nil)
+
+	require.NotEmpty(t, defaultHistoryFile())
+}
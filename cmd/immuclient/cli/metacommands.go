@@ -0,0 +1,42 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cli
+
+// metaCommandNames are the psql-like shortcuts accepted by the shell
+var metaCommandNames = []string{`\d`, `\dt`, `\l`, `\c`, `\?`, `\q`}
+
+// translateMetaCommand maps a meta-command to the equivalent shell command
+func translateMetaCommand(args []string) ([]string, bool) {
+	switch args[0] {
+	case `\d`:
+		if len(args) > 1 {
+			return append([]string{"describe"}, args[1:]...), true
+		}
+		return []string{"tables"}, true
+	case `\dt`:
+		return []string{"tables"}, true
+	case `\l`:
+		return []string{"databases"}, true
+	case `\c`:
+		return append([]string{"use"}, args[1:]...), true
+	case `\?`:
+		return []string{"help"}, true
+	case `\q`:
+		return []string{"exit"}, true
+	}
+	return args, false
+}
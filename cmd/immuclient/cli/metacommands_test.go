@@ -0,0 +1,44 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTranslateMetaCommand(t *testing.T) {
+	for _, c := range []struct {
+		in  []string
+		out []string
+		ok  bool
+	}{
+		{[]string{`\d`}, []string{"tables"}, true},
+		{[]string{`\d`, "table1"}, []string{"describe", "table1"}, true},
+		{[]string{`\dt`}, []string{"tables"}, true},
+		{[]string{`\l`}, []string{"databases"}, true},
+		{[]string{`\c`, "db1"}, []string{"use", "db1"}, true},
+		{[]string{`\?`}, []string{"help"}, true},
+		{[]string{`\q`}, []string{"exit"}, true},
+		{[]string{"get", "key"}, []string{"get", "key"}, false},
+	} {
+		out, ok := translateMetaCommand(c.in)
+		require.Equal(t, c.ok, ok)
+		require.Equal(t, c.out, out)
+	}
+}
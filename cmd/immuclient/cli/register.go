@@ -29,15 +29,18 @@ func (cli *cli) initCommands() {
 	cli.Register(&command{"login", "Login using the specified username and password", cli.login, []string{"username"}, false})
 	cli.Register(&command{"logout", "", cli.logout, nil, false})
 	cli.Register(&command{"use", "Select database", cli.UseDatabase, []string{"databasename"}, false})
+	cli.Register(&command{"databases", "List databases", cli.databaseList, nil, false})
 
 	// Get commands
 	cli.Register(&command{"safeget", "Get and verify item having the specified key", cli.safeGetKey, []string{"key"}, false})
 	cli.Register(&command{"get", "Get item having the specified key", cli.getKey, []string{"key"}, false})
 	cli.Register(&command{"gettx", "Return a tx by id", cli.getTxByID, []string{"id"}, false})
+	cli.Register(&command{"getall", "Get all the items having the specified keys", cli.getAll, []string{"key"}, true})
 
 	// Set commands
 	cli.Register(&command{"set", "Add new item having the specified key and value", cli.set, []string{"key", "value"}, false})
 	cli.Register(&command{"safeset", "Add and verify new item having the specified key and value", cli.safeset, []string{"key", "value"}, false})
+	cli.Register(&command{"setall", "Atomically execute the operations listed in a json file", cli.setAll, []string{"file"}, false})
 	cli.Register(&command{"safezadd", "Add and verify new key with score to a new or existing sorted set", cli.safeZAdd, []string{"setname", "score", "key"}, false})
 	cli.Register(&command{"zadd", "Add new key with score to a new or existing sorted set", cli.zAdd, []string{"setname", "score", "key"}, false})
 
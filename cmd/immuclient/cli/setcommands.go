@@ -24,6 +24,10 @@ func (cli *cli) safeset(args []string) (string, error) {
 	return cli.immucl.VerifiedSet(args)
 }
 
+func (cli *cli) setAll(args []string) (string, error) {
+	return cli.immucl.SetAll(args)
+}
+
 func (cli *cli) deleteKey(args []string) (string, error) {
 	return cli.immucl.DeleteKey(args)
 }
@@ -39,3 +43,7 @@ func (cli *cli) safeZAdd(args []string) (string, error) {
 func (cli *cli) UseDatabase(args []string) (string, error) {
 	return cli.immucl.UseDatabase(args)
 }
+
+func (cli *cli) databaseList(args []string) (string, error) {
+	return cli.immucl.DatabaseList(args)
+}
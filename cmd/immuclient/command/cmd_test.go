@@ -24,8 +24,8 @@ import (
 
 func TestNew(t *testing.T) {
 	cmd := NewCommand()
-	if len(cmd.Commands()) != 30 {
-		t.Fatalf("error initialising command expected %d, got %d", 30, len(cmd.Commands()))
+	if len(cmd.Commands()) != 35 {
+		t.Fatalf("error initialising command expected %d, got %d", 35, len(cmd.Commands()))
 	}
 	cmd.SetArgs([]string{"--help"})
 
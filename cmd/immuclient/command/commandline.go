@@ -48,9 +48,11 @@ func (cl *commandline) Register(rootCmd *cobra.Command) *cobra.Command {
 	cl.safegetTxByID(rootCmd)
 	cl.getKey(rootCmd)
 	cl.safeGetKey(rootCmd)
+	cl.getAll(rootCmd)
 	// set operations
 	cl.set(rootCmd)
 	cl.safeset(rootCmd)
+	cl.setAll(rootCmd)
 	cl.deleteKey(rootCmd)
 	cl.zAdd(rootCmd)
 	cl.safeZAdd(rootCmd)
@@ -63,6 +65,7 @@ func (cl *commandline) Register(rootCmd *cobra.Command) *cobra.Command {
 	cl.safereference(rootCmd)
 	// misc
 	cl.consistency(rootCmd)
+	cl.verifyProof(rootCmd)
 	cl.history(rootCmd)
 	cl.status(rootCmd)
 	cl.auditmode(rootCmd)
@@ -73,6 +76,8 @@ func (cl *commandline) Register(rootCmd *cobra.Command) *cobra.Command {
 	cl.sqlQuery(rootCmd)
 	cl.listTables(rootCmd)
 	cl.describeTable(rootCmd)
+	cl.importFile(rootCmd)
+	cl.exportFile(rootCmd)
 
 	return rootCmd
 }
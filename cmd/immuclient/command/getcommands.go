@@ -17,6 +17,10 @@ limitations under the License.
 package immuclient
 
 import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+
 	"github.com/spf13/cobra"
 )
 
@@ -99,3 +103,49 @@ func (cl *commandline) safeGetKey(cmd *cobra.Command) {
 	}
 	cmd.AddCommand(ccmd)
 }
+
+func (cl *commandline) getAll(cmd *cobra.Command) {
+	ccmd := &cobra.Command{
+		Use:               "getall [key...]",
+		Short:             "Get all the items having the specified keys with a single request",
+		Example:           "getall k1 k2\ngetall -f keys.json",
+		PersistentPreRunE: cl.ConfigChain(cl.connect),
+		PersistentPostRun: cl.disconnect,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			file, err := cmd.Flags().GetString("file")
+			if err != nil {
+				cl.quit(err)
+			}
+			if file != "" {
+				keys, err := readKeysFile(file)
+				if err != nil {
+					cl.quit(err)
+				}
+				args = append(args, keys...)
+			}
+			if len(args) == 0 {
+				cl.quit(errors.New("at least one key is required"))
+			}
+			resp, err := cl.immucl.GetAll(args)
+			if err != nil {
+				cl.quit(err)
+			}
+			fprintln(cmd.OutOrStdout(), resp)
+			return nil
+		},
+	}
+	ccmd.Flags().StringP("file", "f", "", "json file holding an array of keys")
+	cmd.AddCommand(ccmd)
+}
+
+// readKeysFile reads a json array of keys
+func readKeysFile(file string) ([]string, error) {
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []string
+	err = json.Unmarshal(content, &keys)
+	return keys, err
+}
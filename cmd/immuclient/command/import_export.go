@@ -0,0 +1,129 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package immuclient
+
+import (
+	"io"
+	"os"
+
+	"github.com/codenotary/immudb/cmd/immuclient/immuc"
+	"github.com/spf13/cobra"
+)
+
+func (cl *commandline) importFile(cmd *cobra.Command) {
+	ccmd := &cobra.Command{
+		Use:               "import <csv|json> <file>",
+		Short:             "Import rows from a csv or json file into a table",
+		Example:           "import csv --table customers customers.csv\nimport json --table customers --errors-file rejected.json customers.json",
+		PersistentPreRunE: cl.ConfigChain(cl.connect),
+		PersistentPostRun: cl.disconnect,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts := &immuc.ImportOptions{Format: args[0]}
+
+			var err error
+			opts.Table, err = cmd.Flags().GetString("table")
+			if err != nil {
+				cl.quit(err)
+			}
+			opts.BatchSize, err = cmd.Flags().GetInt("batch-size")
+			if err != nil {
+				cl.quit(err)
+			}
+			opts.ErrorsFile, err = cmd.Flags().GetString("errors-file")
+			if err != nil {
+				cl.quit(err)
+			}
+			progress, err := cmd.Flags().GetBool("progress-bar")
+			if err != nil {
+				cl.quit(err)
+			}
+			if progress {
+				opts.Progress = cmd.ErrOrStderr()
+			}
+
+			resp, err := cl.immucl.Import(args[1], opts)
+			if err != nil {
+				cl.quit(err)
+			}
+			if progress {
+				fprintln(cmd.ErrOrStderr(), "")
+			}
+			fprintln(cmd.OutOrStdout(), resp)
+			return nil
+		},
+		Args: cobra.ExactArgs(2),
+	}
+	ccmd.Flags().String("table", "", "table the rows are inserted into")
+	ccmd.Flags().Int("batch-size", immuc.DefaultImportBatchSize, "number of rows inserted by each statement")
+	ccmd.Flags().String("errors-file", "", "file the rejected rows are written to")
+	ccmd.Flags().Bool("progress-bar", false, "show progress indicator")
+	ccmd.MarkFlagRequired("table")
+	cmd.AddCommand(ccmd)
+}
+
+func (cl *commandline) exportFile(cmd *cobra.Command) {
+	ccmd := &cobra.Command{
+		Use:               "export <csv|json>",
+		Short:             "Export the rows of a table or the result of a query to csv or json",
+		Example:           "export csv --table customers -o customers.csv\nexport json --query \"SELECT id, name FROM customers WHERE active\"",
+		PersistentPreRunE: cl.ConfigChain(cl.connect),
+		PersistentPostRun: cl.disconnect,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts := &immuc.ExportOptions{Format: args[0]}
+
+			var err error
+			opts.Table, err = cmd.Flags().GetString("table")
+			if err != nil {
+				cl.quit(err)
+			}
+			opts.Query, err = cmd.Flags().GetString("query")
+			if err != nil {
+				cl.quit(err)
+			}
+			output, err := cmd.Flags().GetString("output")
+			if err != nil {
+				cl.quit(err)
+			}
+
+			var w io.Writer = cmd.OutOrStdout()
+
+			if output != "-" {
+				f, err := os.Create(output)
+				if err != nil {
+					cl.quit(err)
+				}
+				defer f.Close()
+
+				w = f
+			}
+
+			resp, err := cl.immucl.Export(w, opts)
+			if err != nil {
+				cl.quit(err)
+			}
+			if output != "-" {
+				fprintln(cmd.OutOrStdout(), resp)
+			}
+			return nil
+		},
+		Args: cobra.ExactArgs(1),
+	}
+	ccmd.Flags().String("table", "", "table to export")
+	ccmd.Flags().String("query", "", "query whose result is exported")
+	ccmd.Flags().StringP("output", "o", "-", "output file, \"-\" for stdout")
+	cmd.AddCommand(ccmd)
+}
@@ -53,6 +53,13 @@ func (cl *commandline) configureFlags(cmd *cobra.Command) error {
 	cmd.PersistentFlags().String("audit-notification-url", "", "If set, auditor will send a POST request at this URL with audit result details.")
 	cmd.PersistentFlags().String("audit-notification-username", "", "Username used to authenticate when publishing audit result to 'audit-notification-url'.")
 	cmd.PersistentFlags().String("audit-notification-password", "", "Password used to authenticate when publishing audit result to 'audit-notification-url'.")
+	cmd.PersistentFlags().String("audit-alert-webhook-url", "", "If set, auditor will send a POST request at this URL with the conflicting states and consistency proof when tampering is detected.")
+	cmd.PersistentFlags().String("audit-alert-smtp-address", "", "If set, auditor will mail an alert through this SMTP server (host:port) when tampering is detected.")
+	cmd.PersistentFlags().String("audit-alert-smtp-username", "", "Username used to authenticate to 'audit-alert-smtp-address'.")
+	cmd.PersistentFlags().String("audit-alert-smtp-password", "", "Password used to authenticate to 'audit-alert-smtp-address'.")
+	cmd.PersistentFlags().String("audit-alert-smtp-from", "", "Sender address of the alert mails.")
+	cmd.PersistentFlags().String("audit-alert-smtp-to", "", "Comma-separated list of recipient addresses of the alert mails.")
+	cmd.PersistentFlags().String("audit-alert-pagerduty-routing-key", "", "If set, auditor will trigger a PagerDuty incident on the service with this integration key when tampering is detected.")
 	cmd.PersistentFlags().String("audit-monitoring-host", "0.0.0.0", "Host for the monitoring HTTP server when running in audit mode (serves endpoints like metrics, health and version).")
 	cmd.PersistentFlags().Int("audit-monitoring-port", 9477, "Port for the monitoring HTTP server when running in audit mode (serves endpoints like metrics, health and version).")
 	cmd.PersistentFlags().String("server-signing-pub-key", "", "Path to the public key to verify signatures when presents")
@@ -78,6 +85,13 @@ func (cl *commandline) configureFlags(cmd *cobra.Command) error {
 	viper.BindPFlag("audit-notification-url", cmd.PersistentFlags().Lookup("audit-notification-url"))
 	viper.BindPFlag("audit-notification-username", cmd.PersistentFlags().Lookup("audit-notification-username"))
 	viper.BindPFlag("audit-notification-password", cmd.PersistentFlags().Lookup("audit-notification-password"))
+	viper.BindPFlag("audit-alert-webhook-url", cmd.PersistentFlags().Lookup("audit-alert-webhook-url"))
+	viper.BindPFlag("audit-alert-smtp-address", cmd.PersistentFlags().Lookup("audit-alert-smtp-address"))
+	viper.BindPFlag("audit-alert-smtp-username", cmd.PersistentFlags().Lookup("audit-alert-smtp-username"))
+	viper.BindPFlag("audit-alert-smtp-password", cmd.PersistentFlags().Lookup("audit-alert-smtp-password"))
+	viper.BindPFlag("audit-alert-smtp-from", cmd.PersistentFlags().Lookup("audit-alert-smtp-from"))
+	viper.BindPFlag("audit-alert-smtp-to", cmd.PersistentFlags().Lookup("audit-alert-smtp-to"))
+	viper.BindPFlag("audit-alert-pagerduty-routing-key", cmd.PersistentFlags().Lookup("audit-alert-pagerduty-routing-key"))
 	viper.BindPFlag("audit-monitoring-host", cmd.PersistentFlags().Lookup("audit-monitoring-host"))
 	viper.BindPFlag("audit-monitoring-port", cmd.PersistentFlags().Lookup("audit-monitoring-port"))
 	viper.BindPFlag("server-signing-pub-key", cmd.PersistentFlags().Lookup("server-signing-pub-key"))
@@ -102,6 +116,13 @@ func (cl *commandline) configureFlags(cmd *cobra.Command) error {
 	viper.SetDefault("audit-notification-url", "")
 	viper.SetDefault("audit-notification-username", "")
 	viper.SetDefault("audit-notification-password", "")
+	viper.SetDefault("audit-alert-webhook-url", "")
+	viper.SetDefault("audit-alert-smtp-address", "")
+	viper.SetDefault("audit-alert-smtp-username", "")
+	viper.SetDefault("audit-alert-smtp-password", "")
+	viper.SetDefault("audit-alert-smtp-from", "")
+	viper.SetDefault("audit-alert-smtp-to", "")
+	viper.SetDefault("audit-alert-pagerduty-routing-key", "")
 	viper.SetDefault("audit-monitoring-host", "0.0.0.0")
 	viper.SetDefault("audit-monitoring-port", 9477)
 	viper.SetDefault("server-signing-pub-key", "")
@@ -61,6 +61,32 @@ func (cl *commandline) safeset(cmd *cobra.Command) {
 	cmd.AddCommand(ccmd)
 }
 
+func (cl *commandline) setAll(cmd *cobra.Command) {
+	ccmd := &cobra.Command{
+		Use:               "setall -f batch.json",
+		Short:             "Atomically set values, references and sorted set entries listed in a json file within a single transaction",
+		Example:           "setall -f batch.json\n\nbatch.json:\n[\n  {\"key\": \"k1\", \"value\": \"v1\"},\n  {\"key\": \"r1\", \"referencedKey\": \"k1\"},\n  {\"key\": \"k1\", \"set\": \"s1\", \"score\": 1.5}\n]",
+		PersistentPreRunE: cl.ConfigChain(cl.connect),
+		PersistentPostRun: cl.disconnect,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			file, err := cmd.Flags().GetString("file")
+			if err != nil {
+				cl.quit(err)
+			}
+			resp, err := cl.immucl.SetAll([]string{file})
+			if err != nil {
+				cl.quit(err)
+			}
+			fprintln(cmd.OutOrStdout(), resp)
+			return nil
+		},
+		Args: cobra.NoArgs,
+	}
+	ccmd.Flags().StringP("file", "f", "", "json file holding the list of operations")
+	ccmd.MarkFlagRequired("file")
+	cmd.AddCommand(ccmd)
+}
+
 func (cl *commandline) deleteKey(cmd *cobra.Command) {
 	ccmd := &cobra.Command{
 		Use:               "delete key value",
@@ -19,7 +19,9 @@ package immuclient
 import (
 	"errors"
 
+	"github.com/codenotary/immudb/cmd/immuclient/immuc"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
 func (cl *commandline) consistency(cmd *cobra.Command) {
@@ -42,3 +44,34 @@ func (cl *commandline) consistency(cmd *cobra.Command) {
 	}
 	cmd.AddCommand(ccmd)
 }
+
+func (cl *commandline) verifyProof(cmd *cobra.Command) {
+	ccmd := &cobra.Command{
+		Use:     "verify-proof",
+		Short:   "Verify an exported proof bundle offline against a previously saved signed root",
+		Example: "verify-proof --bundle proof.json --root trusted_root.json\nverify-proof --bundle proof.json --root trusted_root.json --server-signing-pub-key server.pub",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			bundleFile, err := cmd.Flags().GetString("bundle")
+			if err != nil {
+				cl.quit(err)
+			}
+			rootFile, err := cmd.Flags().GetString("root")
+			if err != nil {
+				cl.quit(err)
+			}
+
+			resp, err := immuc.VerifyProof(bundleFile, rootFile, viper.GetString("server-signing-pub-key"))
+			if err != nil {
+				cl.quit(err)
+			}
+			fprintln(cmd.OutOrStdout(), resp)
+			return nil
+		},
+		Args: cobra.ExactArgs(0),
+	}
+	ccmd.Flags().String("bundle", "", "proof bundle file, either binary or json encoded")
+	ccmd.Flags().String("root", "", "trusted signed root file, either in the exported format or json encoded")
+	ccmd.MarkFlagRequired("bundle")
+	ccmd.MarkFlagRequired("root")
+	cmd.AddCommand(ccmd)
+}
@@ -0,0 +1,165 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package immuc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/client"
+)
+
+var errEmptyBatch = errors.New("batch file does not contain any operation")
+
+// batchOp is an entry of a batch file, it sets a value by default,
+// a reference when referencedKey is provided or adds the key to a
+// sorted set when set is provided
+type batchOp struct {
+	Key           string  `json:"key"`
+	Value         string  `json:"value"`
+	ReferencedKey string  `json:"referencedKey"`
+	Set           string  `json:"set"`
+	Score         float64 `json:"score"`
+}
+
+func (op *batchOp) toProto() (*schema.Op, error) {
+	if op.Key == "" {
+		return nil, errors.New("missing key in batch operation")
+	}
+	if op.ReferencedKey != "" && op.Set != "" {
+		return nil, fmt.Errorf("invalid batch operation for key '%s': referencedKey and set can not be used together", op.Key)
+	}
+
+	if op.ReferencedKey != "" {
+		return &schema.Op{Operation: &schema.Op_Ref{Ref: &schema.ReferenceRequest{
+			Key:           []byte(op.Key),
+			ReferencedKey: []byte(op.ReferencedKey),
+		}}}, nil
+	}
+
+	if op.Set != "" {
+		return &schema.Op{Operation: &schema.Op_ZAdd{ZAdd: &schema.ZAddRequest{
+			Set:   []byte(op.Set),
+			Score: op.Score,
+			Key:   []byte(op.Key),
+		}}}, nil
+	}
+
+	return &schema.Op{Operation: &schema.Op_Kv{Kv: &schema.KeyValue{
+		Key:   []byte(op.Key),
+		Value: []byte(op.Value),
+	}}}, nil
+}
+
+// SetAll atomically executes all the operations of the json file args[0] within a single transaction.
+// The transaction is then fetched along with its proofs so it gets verified against the local state.
+func (i *immuc) SetAll(args []string) (string, error) {
+	content, err := ioutil.ReadFile(args[0])
+	if err != nil {
+		return "", err
+	}
+
+	var ops []*batchOp
+
+	err = json.Unmarshal(content, &ops)
+	if err != nil {
+		return "", fmt.Errorf("invalid batch file %s: %v", args[0], err)
+	}
+	if len(ops) == 0 {
+		return "", errEmptyBatch
+	}
+
+	req := &schema.ExecAllRequest{Operations: make([]*schema.Op, len(ops))}
+
+	for j, op := range ops {
+		req.Operations[j], err = op.toProto()
+		if err != nil {
+			return "", err
+		}
+	}
+
+	ctx := context.Background()
+	response, err := i.Execute(func(immuClient client.ImmuClient) (interface{}, error) {
+		return immuClient.ExecAll(ctx, req)
+	})
+	if err != nil {
+		if msg, ok := rpcErrorMessage(err); ok {
+			return msg, nil
+		}
+		return "", err
+	}
+
+	txhdr := response.(*schema.TxHeader)
+	tx, err := i.Execute(func(immuClient client.ImmuClient) (interface{}, error) {
+		return immuClient.VerifiedTxByID(ctx, txhdr.Id)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return PrintTx(tx.(*schema.Tx), true), nil
+}
+
+// GetAll fetches all the keys with a single request, keys not found are reported in place of their entry
+func (i *immuc) GetAll(args []string) (string, error) {
+	keys := make([][]byte, len(args))
+	for j, key := range args {
+		keys[j] = []byte(key)
+	}
+
+	ctx := context.Background()
+	response, err := i.Execute(func(immuClient client.ImmuClient) (interface{}, error) {
+		return immuClient.GetAll(ctx, keys)
+	})
+	if err != nil {
+		if msg, ok := rpcErrorMessage(err); ok {
+			return msg, nil
+		}
+		return "", err
+	}
+
+	entries := make(map[string]*schema.Entry)
+	for _, entry := range response.(*schema.Entries).Entries {
+		if entry.ReferencedBy != nil {
+			entries[string(entry.ReferencedBy.Key)] = entry
+		} else {
+			entries[string(entry.Key)] = entry
+		}
+	}
+
+	str := strings.Builder{}
+	for j, key := range args {
+		if j > 0 && !i.valueOnly {
+			str.WriteString("\n")
+		}
+
+		entry, ok := entries[key]
+		if !ok {
+			str.WriteString(fmt.Sprintf("key not found: %v \n", key))
+			continue
+		}
+
+		str.WriteString(PrintKV(entry.Key, entry.Metadata, entry.Value, entry.Tx, false, i.valueOnly))
+	}
+
+	return str.String(), nil
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package immuc_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/codenotary/immudb/cmd/cmdtest"
+	"github.com/codenotary/immudb/pkg/client/tokenservice"
+	"github.com/stretchr/testify/require"
+
+	test "github.com/codenotary/immudb/cmd/immuclient/immuclienttest"
+	"github.com/codenotary/immudb/pkg/server"
+	"github.com/codenotary/immudb/pkg/server/servertest"
+)
+
+func TestSetAllGetAll(t *testing.T) {
+	options := server.DefaultOptions().WithAuth(true)
+	bs := servertest.NewBufconnServer(options)
+
+	bs.Start()
+	defer bs.Stop()
+
+	defer os.RemoveAll(options.Dir)
+	defer os.Remove(".state-")
+	tkf := cmdtest.RandString()
+	ts := tokenservice.NewFileTokenService().WithTokenFileName(tkf)
+	ic := test.NewClientTest(&test.PasswordReader{
+		Pass: []string{"immudb"},
+	}, ts)
+	ic.
+		Connect(bs.Dialer)
+	ic.Login("immudb")
+
+	dir, err := ioutil.TempDir("", "batch")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	_, err = ic.Imc.SetAll([]string{filepath.Join(dir, "missing.json")})
+	require.Error(t, err)
+
+	emptyFile := filepath.Join(dir, "empty.json")
+	err = ioutil.WriteFile(emptyFile, []byte(`[]`), 0644)
+	require.NoError(t, err)
+
+	_, err = ic.Imc.SetAll([]string{emptyFile})
+	require.Error(t, err)
+
+	invalidFile := filepath.Join(dir, "invalid.json")
+	err = ioutil.WriteFile(invalidFile, []byte(`[{"key": "k1", "referencedKey": "k2", "set": "s1"}]`), 0644)
+	require.NoError(t, err)
+
+	_, err = ic.Imc.SetAll([]string{invalidFile})
+	require.Error(t, err)
+
+	batchFile := filepath.Join(dir, "batch.json")
+	err = ioutil.WriteFile(batchFile, []byte(`[
+		{"key": "k1", "value": "v1"},
+		{"key": "k2", "value": "v2"},
+		{"key": "r1", "referencedKey": "k1"},
+		{"key": "k1", "set": "s1", "score": 1.5}
+	]`), 0644)
+	require.NoError(t, err)
+
+	msg, err := ic.Imc.SetAll([]string{batchFile})
+	require.NoError(t, err)
+	require.Contains(t, msg, "entries:	4")
+	require.Contains(t, msg, "verified:	true")
+
+	msg, err = ic.Imc.GetAll([]string{"k1", "k2", "r1", "k3"})
+	require.NoError(t, err)
+	require.Contains(t, msg, "key:		k1 \nvalue:		v1")
+	require.Contains(t, msg, "key:		k2 \nvalue:		v2")
+	require.Contains(t, msg, "key not found: k3")
+	require.NotContains(t, msg, "key not found: r1")
+}
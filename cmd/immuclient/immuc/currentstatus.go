@@ -18,7 +18,6 @@ package immuc
 
 import (
 	"context"
-	"strings"
 
 	"github.com/codenotary/immudb/pkg/api/schema"
 	"github.com/codenotary/immudb/pkg/client"
@@ -30,9 +29,8 @@ func (i *immuc) DatabaseHealth(args []string) (string, error) {
 		return immuClient.Health(ctx)
 	})
 	if err != nil {
-		rpcerrors := strings.SplitAfter(err.Error(), "=")
-		if len(rpcerrors) > 1 {
-			return rpcerrors[len(rpcerrors)-1], nil
+		if msg, ok := rpcErrorMessage(err); ok {
+			return msg, nil
 		}
 		return "", err
 	}
@@ -45,9 +43,8 @@ func (i *immuc) CurrentState(args []string) (string, error) {
 		return immuClient.CurrentState(ctx)
 	})
 	if err != nil {
-		rpcerrors := strings.SplitAfter(err.Error(), "=")
-		if len(rpcerrors) > 1 {
-			return rpcerrors[len(rpcerrors)-1], nil
+		if msg, ok := rpcErrorMessage(err); ok {
+			return msg, nil
 		}
 		return "", err
 	}
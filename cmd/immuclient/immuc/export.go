@@ -0,0 +1,177 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package immuc
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/codenotary/immudb/embedded/sql"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/client"
+)
+
+// ExportOptions holds the settings used to dump a table or the result of a query
+type ExportOptions struct {
+	Format string // csv or json
+	Table  string // all the rows of the table are exported when Query is empty
+	Query  string
+}
+
+// Export writes the rows of a table or the result of a query to w.
+// CSV output starts with a header naming the columns, NULL values are written as empty fields.
+// JSON output is an array of objects keyed by column name, blobs are hex encoded.
+func (i *immuc) Export(w io.Writer, opts *ExportOptions) (string, error) {
+	if opts == nil || (opts.Table == "" && opts.Query == "") || (opts.Table != "" && opts.Query != "") {
+		return "", client.ErrIllegalArguments
+	}
+
+	format := strings.ToLower(opts.Format)
+	if format != "csv" && format != "json" {
+		return "", ErrUnsupportedFormat
+	}
+
+	query := opts.Query
+	if query == "" {
+		query = fmt.Sprintf("SELECT * FROM %s", opts.Table)
+	}
+
+	resp, err := i.Execute(func(immuClient client.ImmuClient) (interface{}, error) {
+		return immuClient.SQLQuery(context.Background(), query, nil, true)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	res := resp.(*schema.SQLQueryResult)
+
+	cols := make([]string, len(res.Columns))
+	for i, col := range res.Columns {
+		cols[i] = exportColumnName(col.Name)
+	}
+
+	if format == "csv" {
+		err = exportCSV(w, cols, res.Rows)
+	} else {
+		err = exportJSON(w, cols, res.Rows)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("Exported rows: %d", len(res.Rows)), nil
+}
+
+// exportColumnName strips database and table from selectors such as "(defaultdb.mytable.id)"
+func exportColumnName(name string) string {
+	name = strings.TrimSuffix(strings.TrimPrefix(name, "("), ")")
+
+	return name[strings.LastIndexAny(name, ". ")+1:]
+}
+
+func exportCSV(w io.Writer, cols []string, rows []*schema.Row) error {
+	cw := csv.NewWriter(w)
+
+	err := cw.Write(cols)
+	if err != nil {
+		return err
+	}
+
+	record := make([]string, len(cols))
+
+	for _, row := range rows {
+		for i, v := range row.Values {
+			record[i] = string(schema.RenderValueAsByte(v.Value))
+		}
+
+		err = cw.Write(record)
+		if err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+func exportJSON(w io.Writer, cols []string, rows []*schema.Row) error {
+	bw := bufio.NewWriter(w)
+
+	bw.WriteString("[")
+
+	for r, row := range rows {
+		if r > 0 {
+			bw.WriteString(",")
+		}
+
+		// objects are written field by field to keep the order of the columns
+		bw.WriteString("\n  {")
+
+		for i, v := range row.Values {
+			if i > 0 {
+				bw.WriteString(", ")
+			}
+
+			name, err := json.Marshal(cols[i])
+			if err != nil {
+				return err
+			}
+
+			value, err := json.Marshal(exportValue(v))
+			if err != nil {
+				return err
+			}
+
+			bw.Write(name)
+			bw.WriteString(": ")
+			bw.Write(value)
+		}
+
+		bw.WriteString("}")
+	}
+
+	if len(rows) > 0 {
+		bw.WriteString("\n")
+	}
+
+	bw.WriteString("]\n")
+
+	return bw.Flush()
+}
+
+func exportValue(v *schema.SQLValue) interface{} {
+	switch tv := v.Value.(type) {
+	case *schema.SQLValue_N:
+		return tv.N
+	case *schema.SQLValue_S:
+		return tv.S
+	case *schema.SQLValue_B:
+		return tv.B
+	case *schema.SQLValue_Bs:
+		return hex.EncodeToString(tv.Bs)
+	case *schema.SQLValue_Ts:
+		return sql.TimeFromInt64(tv.Ts).Format(timestampLayout)
+	}
+
+	return nil
+}
@@ -21,10 +21,10 @@ import (
 	"errors"
 	"fmt"
 	"strconv"
-	"strings"
 
 	"github.com/codenotary/immudb/pkg/api/schema"
 	"github.com/codenotary/immudb/pkg/client"
+	immuErrors "github.com/codenotary/immudb/pkg/client/errors"
 )
 
 var (
@@ -45,12 +45,11 @@ func (i *immuc) GetTxByID(args []string) (string, error) {
 		return immuClient.TxByID(ctx, id)
 	})
 	if err != nil {
-		if strings.Contains(err.Error(), "NotFound") {
+		if errors.Is(err, immuErrors.ErrTxNotFound) {
 			return fmt.Sprintf("no item exists in id:%v", id), nil
 		}
-		rpcerrors := strings.SplitAfter(err.Error(), "=")
-		if len(rpcerrors) > 1 {
-			return rpcerrors[len(rpcerrors)-1], nil
+		if msg, ok := rpcErrorMessage(err); ok {
+			return msg, nil
 		}
 		return "", err
 	}
@@ -71,12 +70,11 @@ func (i *immuc) VerifiedGetTxByID(args []string) (string, error) {
 		return immuClient.VerifiedTxByID(ctx, id)
 	})
 	if err != nil {
-		if strings.Contains(err.Error(), "NotFound") {
+		if errors.Is(err, immuErrors.ErrTxNotFound) {
 			return fmt.Sprintf("no item exists in id:%v", id), nil
 		}
-		rpcerrors := strings.SplitAfter(err.Error(), "=")
-		if len(rpcerrors) > 1 {
-			return rpcerrors[len(rpcerrors)-1], nil
+		if msg, ok := rpcErrorMessage(err); ok {
+			return msg, nil
 		}
 		return "", err
 	}
@@ -90,12 +88,11 @@ func (i *immuc) Get(args []string) (string, error) {
 		return immuClient.Get(ctx, key)
 	})
 	if err != nil {
-		if strings.Contains(err.Error(), "NotFound") {
+		if errors.Is(err, immuErrors.ErrKeyNotFound) {
 			return fmt.Sprintf("key not found: %v ", string(key)), nil
 		}
-		rpcerrors := strings.SplitAfter(err.Error(), "=")
-		if len(rpcerrors) > 1 {
-			return rpcerrors[len(rpcerrors)-1], nil
+		if msg, ok := rpcErrorMessage(err); ok {
+			return msg, nil
 		}
 		return "", err
 	}
@@ -111,12 +108,11 @@ func (i *immuc) VerifiedGet(args []string) (string, error) {
 		return immuClient.VerifiedGet(ctx, key)
 	})
 	if err != nil {
-		if strings.Contains(err.Error(), "NotFound") {
+		if errors.Is(err, immuErrors.ErrKeyNotFound) {
 			return fmt.Sprintf("key not found: %v ", string(key)), nil
 		}
-		rpcerrors := strings.SplitAfter(err.Error(), "=")
-		if len(rpcerrors) > 1 {
-			return rpcerrors[len(rpcerrors)-1], nil
+		if msg, ok := rpcErrorMessage(err); ok {
+			return msg, nil
 		}
 		return "", err
 	}
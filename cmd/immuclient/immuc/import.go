@@ -0,0 +1,533 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package immuc
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/codenotary/immudb/embedded/sql"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/client"
+	"github.com/schollz/progressbar/v2"
+)
+
+// DefaultImportBatchSize is the number of rows inserted by each statement when importing files
+const DefaultImportBatchSize = 100
+
+const timestampLayout = "2006-01-02 15:04:05.999999"
+
+var ErrUnsupportedFormat = errors.New("unsupported format, expected csv or json")
+
+// ImportOptions holds the settings used to bulk load a file into a table
+type ImportOptions struct {
+	Format     string // csv or json
+	Table      string
+	BatchSize  int
+	ErrorsFile string    // rejected rows are written to this file when set
+	Progress   io.Writer // progress bar destination, disabled when nil
+}
+
+// importRow holds the values of a row keyed by column name, along with the original record
+type importRow struct {
+	cols   []string
+	values map[string]interface{}
+	record interface{}
+}
+
+type importRowReader interface {
+	Read() (*importRow, error)
+}
+
+type rejectedRowWriter interface {
+	Write(row *importRow, err error) error
+	Flush() error
+}
+
+// Import bulk loads a csv or json file into a table using multi-row inserts.
+// CSV files must start with a header naming the columns, empty fields are imported as NULL.
+// JSON files must contain an array of objects keyed by column name.
+func (i *immuc) Import(fileName string, opts *ImportOptions) (string, error) {
+	if opts == nil || opts.Table == "" {
+		return "", client.ErrIllegalArguments
+	}
+
+	batchSize := opts.BatchSize
+	if batchSize < 1 {
+		batchSize = DefaultImportBatchSize
+	}
+
+	colTypes, err := i.tableColumnTypes(opts.Table)
+	if err != nil {
+		return "", err
+	}
+
+	f, err := os.Open(fileName)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+
+	if opts.Progress != nil {
+		fi, err := f.Stat()
+		if err != nil {
+			return "", err
+		}
+
+		bar := progressbar.NewOptions64(
+			fi.Size(),
+			progressbar.OptionSetWriter(opts.Progress),
+			progressbar.OptionSetBytes64(fi.Size()),
+			progressbar.OptionSetDescription("importing"),
+		)
+		defer bar.Finish()
+
+		r = io.TeeReader(f, bar)
+	}
+
+	var rows importRowReader
+	var rejected rejectedRowWriter
+
+	var errFile *os.File
+
+	if opts.ErrorsFile != "" {
+		errFile, err = os.Create(opts.ErrorsFile)
+		if err != nil {
+			return "", err
+		}
+		defer errFile.Close()
+	}
+
+	switch strings.ToLower(opts.Format) {
+	case "csv":
+		{
+			rows, err = newCSVRowReader(r)
+			if err != nil {
+				return "", err
+			}
+			if errFile != nil {
+				rejected = newCSVRejectedRowWriter(errFile, rows.(*csvRowReader).header)
+			}
+		}
+	case "json":
+		{
+			rows, err = newJSONRowReader(r)
+			if err != nil {
+				return "", err
+			}
+			if errFile != nil {
+				rejected = newJSONRejectedRowWriter(errFile)
+			}
+		}
+	default:
+		return "", ErrUnsupportedFormat
+	}
+
+	imp := &importer{
+		immuc:     i,
+		table:     opts.Table,
+		colTypes:  colTypes,
+		batchSize: batchSize,
+		rejected:  rejected,
+	}
+
+	err = imp.run(rows)
+
+	if rejected != nil {
+		if ferr := rejected.Flush(); err == nil {
+			err = ferr
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("Imported rows: %d, rejected rows: %d", imp.imported, imp.rejectedCount), nil
+}
+
+// tableColumnTypes returns the type of each column of the table, keyed by lower-cased column name
+func (i *immuc) tableColumnTypes(table string) (map[string]string, error) {
+	resp, err := i.Execute(func(immuClient client.ImmuClient) (interface{}, error) {
+		return immuClient.DescribeTable(context.Background(), table)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	colTypes := make(map[string]string)
+
+	for _, row := range resp.(*schema.SQLQueryResult).Rows {
+		colType := row.Values[1].GetS()
+		if i := strings.Index(colType, "["); i > 0 {
+			colType = colType[:i]
+		}
+		colTypes[strings.ToLower(row.Values[0].GetS())] = colType
+	}
+
+	return colTypes, nil
+}
+
+type importer struct {
+	immuc     *immuc
+	table     string
+	colTypes  map[string]string
+	batchSize int
+	rejected  rejectedRowWriter
+
+	imported      int
+	rejectedCount int
+}
+
+func (imp *importer) run(rows importRowReader) error {
+	batch := make([]*importRow, 0, imp.batchSize)
+	var batchCols []string
+
+	for {
+		row, err := rows.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		err = imp.convert(row)
+		if err != nil {
+			err = imp.reject(row, err)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		// rows inserted by a single statement must assign the same columns
+		if len(batch) == imp.batchSize || (len(batch) > 0 && !sameColumns(batchCols, row.cols)) {
+			err = imp.insert(batch)
+			if err != nil {
+				return err
+			}
+			batch = batch[:0]
+		}
+
+		if len(batch) == 0 {
+			batchCols = row.cols
+		}
+
+		batch = append(batch, row)
+	}
+
+	if len(batch) > 0 {
+		return imp.insert(batch)
+	}
+
+	return nil
+}
+
+// convert replaces the values read from the file with values of the type of each column
+func (imp *importer) convert(row *importRow) error {
+	for _, col := range row.cols {
+		colType, ok := imp.colTypes[strings.ToLower(col)]
+		if !ok {
+			return fmt.Errorf("column '%s' does not exist in table '%s'", col, imp.table)
+		}
+
+		v, err := convertValue(row.values[col], colType)
+		if err != nil {
+			return fmt.Errorf("invalid value for column '%s': %v", col, err)
+		}
+
+		row.values[col] = v
+	}
+
+	return nil
+}
+
+// insert adds all the rows with a single statement, rows are inserted one by one
+// if the statement fails so that only the offending rows get rejected
+func (imp *importer) insert(batch []*importRow) error {
+	err := imp.exec(batch)
+	if err == nil {
+		imp.imported += len(batch)
+		return nil
+	}
+
+	if len(batch) == 1 {
+		return imp.reject(batch[0], err)
+	}
+
+	for _, row := range batch {
+		err = imp.insert([]*importRow{row})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (imp *importer) exec(batch []*importRow) error {
+	cols := batch[0].cols
+
+	stmt := strings.Builder{}
+	stmt.WriteString(fmt.Sprintf("INSERT INTO %s(%s) VALUES ", imp.table, strings.Join(cols, ", ")))
+
+	params := make(map[string]interface{}, len(batch)*len(cols))
+
+	for r, row := range batch {
+		if r > 0 {
+			stmt.WriteString(", ")
+		}
+
+		stmt.WriteString("(")
+
+		for c, col := range cols {
+			if c > 0 {
+				stmt.WriteString(", ")
+			}
+
+			param := fmt.Sprintf("r%dc%d", r, c)
+			stmt.WriteString("@" + param)
+
+			params[param] = row.values[col]
+		}
+
+		stmt.WriteString(")")
+	}
+
+	_, err := imp.immuc.Execute(func(immuClient client.ImmuClient) (interface{}, error) {
+		return immuClient.SQLExec(context.Background(), stmt.String(), params)
+	})
+
+	return err
+}
+
+func (imp *importer) reject(row *importRow, err error) error {
+	imp.rejectedCount++
+
+	if imp.rejected == nil {
+		return nil
+	}
+
+	return imp.rejected.Write(row, err)
+}
+
+func sameColumns(cols1, cols2 []string) bool {
+	if len(cols1) != len(cols2) {
+		return false
+	}
+	for i := range cols1 {
+		if cols1[i] != cols2[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func convertValue(v interface{}, colType string) (interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	switch colType {
+	case sql.IntegerType:
+		switch tv := v.(type) {
+		case string:
+			return strconv.ParseInt(tv, 10, 64)
+		case json.Number:
+			return tv.Int64()
+		}
+	case sql.BooleanType:
+		switch tv := v.(type) {
+		case string:
+			return strconv.ParseBool(tv)
+		case bool:
+			return tv, nil
+		}
+	case sql.VarcharType:
+		switch tv := v.(type) {
+		case string:
+			return tv, nil
+		case json.Number:
+			return tv.String(), nil
+		}
+	case sql.BLOBType:
+		if tv, ok := v.(string); ok {
+			return hex.DecodeString(tv)
+		}
+	case sql.TimestampType:
+		if tv, ok := v.(string); ok {
+			t, err := time.Parse(timestampLayout, tv)
+			if err != nil {
+				return time.Parse(time.RFC3339Nano, tv)
+			}
+			return t, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%v can not be converted to %s", v, colType)
+}
+
+type csvRowReader struct {
+	r      *csv.Reader
+	header []string
+}
+
+func newCSVRowReader(r io.Reader) (*csvRowReader, error) {
+	cr := csv.NewReader(r)
+
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, errors.New("missing csv header")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range header {
+		header[i] = strings.TrimSpace(header[i])
+	}
+
+	return &csvRowReader{r: cr, header: header}, nil
+}
+
+func (cr *csvRowReader) Read() (*importRow, error) {
+	record, err := cr.r.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]interface{}, len(record))
+
+	for i, col := range cr.header {
+		if record[i] == "" {
+			values[col] = nil
+		} else {
+			values[col] = record[i]
+		}
+	}
+
+	return &importRow{cols: cr.header, values: values, record: record}, nil
+}
+
+type jsonRowReader struct {
+	dec *json.Decoder
+}
+
+func newJSONRowReader(r io.Reader) (*jsonRowReader, error) {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+
+	t, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	if d, ok := t.(json.Delim); !ok || d != '[' {
+		return nil, errors.New("json file must contain an array of objects")
+	}
+
+	return &jsonRowReader{dec: dec}, nil
+}
+
+func (jr *jsonRowReader) Read() (*importRow, error) {
+	if !jr.dec.More() {
+		return nil, io.EOF
+	}
+
+	var obj map[string]interface{}
+
+	err := jr.dec.Decode(&obj)
+	if err != nil {
+		return nil, err
+	}
+
+	cols := make([]string, 0, len(obj))
+	values := make(map[string]interface{}, len(obj))
+
+	for col, v := range obj {
+		cols = append(cols, col)
+		values[col] = v
+	}
+
+	// columns are sorted so rows assigning the same columns can be inserted together
+	sort.Strings(cols)
+
+	return &importRow{cols: cols, values: values, record: obj}, nil
+}
+
+// csvRejectedRowWriter writes rejected records followed by the error found
+type csvRejectedRowWriter struct {
+	w             *csv.Writer
+	header        []string
+	headerWritten bool
+}
+
+func newCSVRejectedRowWriter(w io.Writer, header []string) *csvRejectedRowWriter {
+	return &csvRejectedRowWriter{w: csv.NewWriter(w), header: header}
+}
+
+func (cw *csvRejectedRowWriter) Write(row *importRow, err error) error {
+	if !cw.headerWritten {
+		werr := cw.w.Write(append(append([]string{}, cw.header...), "error"))
+		if werr != nil {
+			return werr
+		}
+		cw.headerWritten = true
+	}
+
+	return cw.w.Write(append(append([]string{}, row.record.([]string)...), err.Error()))
+}
+
+func (cw *csvRejectedRowWriter) Flush() error {
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+// jsonRejectedRowWriter writes a json object per line holding each rejected row and the error found
+type jsonRejectedRowWriter struct {
+	enc *json.Encoder
+}
+
+func newJSONRejectedRowWriter(w io.Writer) *jsonRejectedRowWriter {
+	return &jsonRejectedRowWriter{enc: json.NewEncoder(w)}
+}
+
+func (jw *jsonRejectedRowWriter) Write(row *importRow, err error) error {
+	return jw.enc.Encode(struct {
+		Row   interface{} `json:"row"`
+		Error string      `json:"error"`
+	}{
+		Row:   row.record,
+		Error: err.Error(),
+	})
+}
+
+func (jw *jsonRejectedRowWriter) Flush() error {
+	return nil
+}
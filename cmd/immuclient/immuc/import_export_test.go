@@ -0,0 +1,134 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package immuc_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/codenotary/immudb/cmd/cmdtest"
+	"github.com/codenotary/immudb/cmd/immuclient/immuc"
+	"github.com/codenotary/immudb/pkg/client/tokenservice"
+	"github.com/stretchr/testify/require"
+
+	test "github.com/codenotary/immudb/cmd/immuclient/immuclienttest"
+	"github.com/codenotary/immudb/pkg/server"
+	"github.com/codenotary/immudb/pkg/server/servertest"
+)
+
+func TestImportExport(t *testing.T) {
+	options := server.DefaultOptions().WithAuth(true)
+	bs := servertest.NewBufconnServer(options)
+
+	bs.Start()
+	defer bs.Stop()
+
+	defer os.RemoveAll(options.Dir)
+	defer os.Remove(".state-")
+	tkf := cmdtest.RandString()
+	ts := tokenservice.NewFileTokenService().WithTokenFileName(tkf)
+	ic := test.NewClientTest(&test.PasswordReader{
+		Pass: []string{"immudb"},
+	}, ts)
+	ic.
+		Connect(bs.Dialer)
+	ic.Login("immudb")
+
+	_, err := ic.Imc.SQLExec([]string{"CREATE TABLE customers(id INTEGER, name VARCHAR, active BOOLEAN, data BLOB, PRIMARY KEY id)"})
+	require.NoError(t, err)
+
+	dir, err := ioutil.TempDir("", "import_export")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	csvFile := filepath.Join(dir, "customers.csv")
+	err = ioutil.WriteFile(csvFile, []byte("id,name,active,data\n1,Alice,true,0a0b\n2,Bob,,\nthree,Carol,false,\n4,Dave,false,\n"), 0644)
+	require.NoError(t, err)
+
+	_, err = ic.Imc.Import(csvFile, nil)
+	require.Error(t, err)
+
+	_, err = ic.Imc.Import(csvFile, &immuc.ImportOptions{Format: "xml", Table: "customers"})
+	require.Equal(t, immuc.ErrUnsupportedFormat, err)
+
+	errorsFile := filepath.Join(dir, "rejected.csv")
+
+	msg, err := ic.Imc.Import(csvFile, &immuc.ImportOptions{
+		Format:     "csv",
+		Table:      "customers",
+		BatchSize:  2,
+		ErrorsFile: errorsFile,
+		Progress:   ioutil.Discard,
+	})
+	require.NoError(t, err)
+	require.Equal(t, "Imported rows: 3, rejected rows: 1", msg)
+
+	rejected, err := ioutil.ReadFile(errorsFile)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(string(rejected), "id,name,active,data,error\nthree,Carol,false,,"))
+
+	// id 4 is already present, so the batch is retried row by row
+	jsonFile := filepath.Join(dir, "customers.json")
+	err = ioutil.WriteFile(jsonFile, []byte(`[{"id": 4, "name": "Dave"}, {"id": 5, "name": "Eve", "active": true}, {"id": 6}]`), 0644)
+	require.NoError(t, err)
+
+	errorsFile = filepath.Join(dir, "rejected.json")
+
+	msg, err = ic.Imc.Import(jsonFile, &immuc.ImportOptions{
+		Format:     "json",
+		Table:      "customers",
+		ErrorsFile: errorsFile,
+	})
+	require.NoError(t, err)
+	require.Equal(t, "Imported rows: 2, rejected rows: 1", msg)
+
+	rejected, err = ioutil.ReadFile(errorsFile)
+	require.NoError(t, err)
+	require.Contains(t, string(rejected), `"row":{"id":4,"name":"Dave"}`)
+
+	_, err = ic.Imc.Export(ioutil.Discard, &immuc.ExportOptions{Format: "csv"})
+	require.Error(t, err)
+
+	_, err = ic.Imc.Export(ioutil.Discard, &immuc.ExportOptions{Format: "xml", Table: "customers"})
+	require.Equal(t, immuc.ErrUnsupportedFormat, err)
+
+	var buf bytes.Buffer
+
+	msg, err = ic.Imc.Export(&buf, &immuc.ExportOptions{Format: "csv", Table: "customers"})
+	require.NoError(t, err)
+	require.Equal(t, "Exported rows: 5", msg)
+	require.Equal(t, "id,name,active,data\n1,Alice,true,0a0b\n2,Bob,,\n4,Dave,false,\n5,Eve,true,\n6,,,\n", buf.String())
+
+	buf.Reset()
+
+	msg, err = ic.Imc.Export(&buf, &immuc.ExportOptions{Format: "json", Query: "SELECT id, name FROM customers WHERE id < 3"})
+	require.NoError(t, err)
+	require.Equal(t, "Exported rows: 2", msg)
+
+	var rows []map[string]interface{}
+	err = json.Unmarshal(buf.Bytes(), &rows)
+	require.NoError(t, err)
+	require.Equal(t, []map[string]interface{}{
+		{"id": float64(1), "name": "Alice"},
+		{"id": float64(2), "name": "Bob"},
+	}, rows)
+}
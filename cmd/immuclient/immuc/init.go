@@ -18,6 +18,7 @@ package immuc
 import (
 	"errors"
 	"fmt"
+	"io"
 	"strings"
 
 	"github.com/codenotary/immudb/pkg/client/tokenservice"
@@ -59,6 +60,8 @@ type Client interface {
 	Count(args []string) (string, error)
 	Set(args []string) (string, error)
 	VerifiedSet(args []string) (string, error)
+	SetAll(args []string) (string, error)
+	GetAll(args []string) (string, error)
 	DeleteKey(args []string) (string, error)
 	ZAdd(args []string) (string, error)
 	VerifiedZAdd(args []string) (string, error)
@@ -76,6 +79,8 @@ type Client interface {
 	SQLQuery(args []string) (string, error)
 	ListTables() (string, error)
 	DescribeTable(args []string) (string, error)
+	Import(fileName string, opts *ImportOptions) (string, error)
+	Export(w io.Writer, opts *ExportOptions) (string, error)
 
 	WithFileTokenService(tkns tokenservice.TokenService) Client
 }
@@ -112,8 +112,10 @@ func (i *immuc) UserCreate(args []string) (string, error) {
 		userpermission = auth.PermissionAdmin
 	case "readwrite":
 		userpermission = auth.PermissionRW
+	case "restrictedread":
+		userpermission = auth.PermissionRestrictedR
 	default:
-		return "permission value not recognized. Allowed permissions are read, readwrite, admin", nil
+		return "permission value not recognized. Allowed permissions are read, readwrite, restrictedread, admin", nil
 	}
 	_, err = i.Execute(func(immuClient client.ImmuClient) (interface{}, error) {
 		return nil, immuClient.CreateUser(
@@ -148,6 +150,8 @@ func (i *immuc) UserList(args []string) (string, error) {
 				ris += "Read\n"
 			case auth.PermissionRW:
 				ris += "Read/Write\n"
+			case auth.PermissionRestrictedR:
+				ris += "Restricted Read\n"
 			default:
 				return "permission value not recognized. Allowed permissions are read, write, admin", nil
 			}
@@ -231,8 +235,10 @@ func (i *immuc) SetUserPermission(args []string) (string, error) {
 		userpermission = auth.PermissionAdmin
 	case "readwrite":
 		userpermission = auth.PermissionRW
+	case "restrictedread":
+		userpermission = auth.PermissionRestrictedR
 	default:
-		return "permission value not recognized. Allowed permissions are read, readwrite, admin", nil
+		return "permission value not recognized. Allowed permissions are read, readwrite, restrictedread, admin", nil
 	}
 
 	dbname := args[3]
@@ -290,7 +290,7 @@ func TestLoginAndUserCommandsErrors(t *testing.T) {
 	args[2] = "default"
 	resp, err = ic.SetUserPermission(args)
 	require.NoError(t, err)
-	require.Equal(t, "permission value not recognized. Allowed permissions are read, readwrite, admin", resp)
+	require.Equal(t, "permission value not recognized. Allowed permissions are read, readwrite, restrictedread, admin", resp)
 
 	args[2] = "readwrite"
 	errChangePermission := errors.New("change permission error")
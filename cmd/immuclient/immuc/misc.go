@@ -33,9 +33,8 @@ func (i *immuc) History(args []string) (string, error) {
 		})
 	})
 	if err != nil {
-		rpcerrors := strings.SplitAfter(err.Error(), "=")
-		if len(rpcerrors) > 1 {
-			return rpcerrors[len(rpcerrors)-1], nil
+		if msg, ok := rpcErrorMessage(err); ok {
+			return msg, nil
 		}
 		return "", err
 	}
@@ -64,10 +63,8 @@ func (i *immuc) HealthCheck(args []string) (string, error) {
 	if _, err := i.Execute(func(immuClient client.ImmuClient) (interface{}, error) {
 		return nil, immuClient.HealthCheck(ctx)
 	}); err != nil {
-		rpcerrors := strings.SplitAfter(err.Error(), "=")
-
-		if len(rpcerrors) > 1 {
-			return rpcerrors[len(rpcerrors)-1], nil
+		if msg, ok := rpcErrorMessage(err); ok {
+			return msg, nil
 		}
 
 		return "", err
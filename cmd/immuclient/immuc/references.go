@@ -23,7 +23,6 @@ import (
 	"io"
 	"io/ioutil"
 	"os"
-	"strings"
 
 	"github.com/codenotary/immudb/pkg/api/schema"
 	"github.com/codenotary/immudb/pkg/client"
@@ -55,9 +54,8 @@ func (i *immuc) SetReference(args []string) (string, error) {
 		return immuClient.SetReference(ctx, key, referencedKey)
 	})
 	if err != nil {
-		rpcerrors := strings.SplitAfter(err.Error(), "=")
-		if len(rpcerrors) > 1 {
-			return rpcerrors[len(rpcerrors)-1], nil
+		if msg, ok := rpcErrorMessage(err); ok {
+			return msg, nil
 		}
 		return "", err
 	}
@@ -97,9 +95,8 @@ func (i *immuc) VerifiedSetReference(args []string) (string, error) {
 		return immuClient.VerifiedSetReference(ctx, key, referencedKey)
 	})
 	if err != nil {
-		rpcerrors := strings.SplitAfter(err.Error(), "=")
-		if len(rpcerrors) > 1 {
-			return rpcerrors[len(rpcerrors)-1], nil
+		if msg, ok := rpcErrorMessage(err); ok {
+			return msg, nil
 		}
 		return "", err
 	}
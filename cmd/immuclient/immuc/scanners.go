@@ -32,10 +32,8 @@ func (i *immuc) ZScan(args []string) (string, error) {
 		return immuClient.ZScan(ctx, &schema.ZScanRequest{Set: set, NoWait: true})
 	})
 	if err != nil {
-		rpcerrors := strings.SplitAfter(err.Error(), "=")
-
-		if len(rpcerrors) > 1 {
-			return rpcerrors[len(rpcerrors)-1], nil
+		if msg, ok := rpcErrorMessage(err); ok {
+			return msg, nil
 		}
 
 		return "", err
@@ -68,9 +66,8 @@ func (i *immuc) Scan(args []string) (res string, err error) {
 		return immuClient.Scan(ctx, &schema.ScanRequest{Prefix: prefix, NoWait: true})
 	})
 	if err != nil {
-		rpcerrors := strings.SplitAfter(err.Error(), "=")
-		if len(rpcerrors) > 1 {
-			return rpcerrors[len(rpcerrors)-1], nil
+		if msg, ok := rpcErrorMessage(err); ok {
+			return msg, nil
 		}
 		return "", err
 	}
@@ -20,16 +20,17 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
 	"strconv"
-	"strings"
 	"time"
 
 	"github.com/codenotary/immudb/pkg/api/schema"
 	"github.com/codenotary/immudb/pkg/client"
+	immuErrors "github.com/codenotary/immudb/pkg/client/errors"
 )
 
 func (i *immuc) Set(args []string) (string, error) {
@@ -133,12 +134,11 @@ func (i *immuc) DeleteKey(args []string) (string, error) {
 		return immuClient.Delete(ctx, &schema.DeleteKeysRequest{Keys: [][]byte{key}})
 	})
 	if err != nil {
-		if strings.Contains(err.Error(), "NotFound") {
+		if errors.Is(err, immuErrors.ErrKeyNotFound) {
 			return fmt.Sprintf("key not found: %v ", string(key)), nil
 		}
-		rpcerrors := strings.SplitAfter(err.Error(), "=")
-		if len(rpcerrors) > 1 {
-			return rpcerrors[len(rpcerrors)-1], nil
+		if msg, ok := rpcErrorMessage(err); ok {
+			return msg, nil
 		}
 		return "", err
 	}
@@ -264,7 +264,7 @@ func (i *immuc) DatabaseList(args []string) (string, error) {
 		if i.options.CurrentDatabase == val.DatabaseName {
 			dbList += "*"
 		}
-		dbList += fmt.Sprintf("%s", val.DatabaseName)
+		dbList += fmt.Sprintf("%s\n", val.DatabaseName)
 	}
 
 	return dbList, nil
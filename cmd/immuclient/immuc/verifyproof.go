@@ -0,0 +1,101 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package immuc
+
+import (
+	"crypto/ecdsa"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/client"
+	"github.com/codenotary/immudb/pkg/signer"
+)
+
+// VerifyProof validates a proof bundle against a previously saved signed root without connecting to
+// any server. The root may be encoded in the compact exported format or as JSON, its signature is
+// checked against the given public key file or, when none is provided, against its embedded key.
+func VerifyProof(bundleFile, rootFile, pubKeyFile string) (string, error) {
+	data, err := ioutil.ReadFile(bundleFile)
+	if err != nil {
+		return "", err
+	}
+
+	bundle, err := schema.ParseProofBundle(data)
+	if err != nil {
+		return "", err
+	}
+
+	data, err = ioutil.ReadFile(rootFile)
+	if err != nil {
+		return "", err
+	}
+
+	root, err := schema.ParseRoot(data)
+	if err != nil {
+		return "", err
+	}
+
+	var key *ecdsa.PublicKey
+
+	if pubKeyFile != "" {
+		key, err = signer.ParsePublicKeyFile(pubKeyFile)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	err = client.VerifyProofBundleWithRoot(bundle, root, key)
+	if err != nil {
+		return "", err
+	}
+
+	return PrintProofBundle(bundle, root), nil
+}
+
+// PrintProofBundle ...
+func PrintProofBundle(bundle *schema.ProofBundle, root *schema.ImmutableState) string {
+	str := strings.Builder{}
+	str.WriteString(fmt.Sprintf("db:		%s\n", bundle.State.Db))
+	str.WriteString(fmt.Sprintf("type:		%s\n", bundle.Type))
+
+	switch bundle.Type {
+	case schema.ProofType_TX_INCLUSION:
+		str.WriteString(fmt.Sprintf("tx:		%d\n", bundle.VerifiableTx.Tx.Header.Id))
+	case schema.ProofType_ENTRY_INCLUSION:
+		str.WriteString(fmt.Sprintf("tx:		%d\n", bundle.VerifiableTx.Tx.Header.Id))
+		if bundle.Entry.ReferencedBy != nil {
+			str.WriteString(fmt.Sprintf("key:		%s\n", bundle.Entry.ReferencedBy.Key))
+			str.WriteString(fmt.Sprintf("referenced key:	%s\n", bundle.Entry.Key))
+		} else {
+			str.WriteString(fmt.Sprintf("key:		%s\n", bundle.Entry.Key))
+		}
+		str.WriteString(fmt.Sprintf("value:		%s\n", bundle.Entry.Value))
+	case schema.ProofType_CONSISTENCY:
+		str.WriteString(fmt.Sprintf("source tx:	%d\n", bundle.SourceState.TxId))
+		str.WriteString(fmt.Sprintf("source hash:	%x\n", bundle.SourceState.TxHash))
+	}
+
+	str.WriteString(fmt.Sprintf("state tx:	%d\n", bundle.State.TxId))
+	str.WriteString(fmt.Sprintf("state hash:	%x\n", bundle.State.TxHash))
+	str.WriteString(fmt.Sprintf("root tx:	%d\n", root.TxId))
+	str.WriteString(fmt.Sprintf("root hash:	%x\n", root.TxHash))
+	str.WriteString(fmt.Sprintf("verified:	%t\n", true))
+
+	return str.String()
+}
@@ -0,0 +1,100 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package immuc_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/codenotary/immudb/cmd/immuclient/immuc"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/client"
+	"github.com/codenotary/immudb/pkg/client/tokenservice"
+	"github.com/codenotary/immudb/pkg/server"
+	"github.com/codenotary/immudb/pkg/server/servertest"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+func TestVerifyProof(t *testing.T) {
+	options := server.DefaultOptions().WithAuth(true).WithSigningKey("./../../../test/signer/ec1.key")
+	bs := servertest.NewBufconnServer(options)
+
+	err := bs.Start()
+	require.NoError(t, err)
+	defer bs.Stop()
+
+	defer os.RemoveAll(options.Dir)
+	defer os.Remove(".state-")
+
+	opts := client.DefaultOptions().WithDialOptions([]grpc.DialOption{grpc.WithContextDialer(bs.Dialer), grpc.WithInsecure()})
+	cli, err := client.NewImmuClient(opts)
+	require.NoError(t, err)
+	cli.WithTokenService(tokenservice.NewInmemoryTokenService())
+
+	lr, err := cli.Login(context.TODO(), []byte(`immudb`), []byte(`immudb`))
+	require.NoError(t, err)
+	ctx := metadata.NewOutgoingContext(context.Background(), metadata.Pairs("authorization", lr.Token))
+
+	_, err = cli.Set(ctx, []byte("key"), []byte("val"))
+	require.NoError(t, err)
+
+	root, err := cli.CurrentState(ctx)
+	require.NoError(t, err)
+
+	bundle, err := cli.ExportProof(ctx, &client.ProofRequest{Type: schema.ProofType_ENTRY_INCLUSION, Key: []byte("key")})
+	require.NoError(t, err)
+
+	dir, err := ioutil.TempDir("", "verify_proof")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	bundleFile := filepath.Join(dir, "proof.json")
+	rootFile := filepath.Join(dir, "trusted_root.json")
+
+	js, err := bundle.ToJSON()
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(bundleFile, js, 0644))
+
+	js, err = protojson.Marshal(root)
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(rootFile, js, 0644))
+
+	msg, err := immuc.VerifyProof(bundleFile, rootFile, "")
+	require.NoError(t, err)
+	require.Contains(t, msg, "verified:	true")
+
+	_, err = immuc.VerifyProof(bundleFile, rootFile, "./../../../test/signer/ec1.pub")
+	require.NoError(t, err)
+
+	_, err = immuc.VerifyProof(bundleFile, rootFile, "./../../../test/signer/ec3.pub")
+	require.ErrorIs(t, err, schema.ErrInvalidRootSignature)
+
+	_, err = immuc.VerifyProof(filepath.Join(dir, "missing.json"), rootFile, "")
+	require.Error(t, err)
+
+	_, err = immuc.VerifyProof(rootFile, rootFile, "")
+	require.ErrorIs(t, err, schema.ErrInvalidProofBundle)
+
+	_, err = immuc.VerifyProof(bundleFile, bundleFile, "")
+	require.ErrorIs(t, err, schema.ErrInvalidExportedRoot)
+}
@@ -66,6 +66,9 @@ func newCommand(immudbServer server.ImmuServerIf) (*cobra.Command, error) {
 
 	cmd.AddCommand(man.Generate(cmd, "immudb", "./cmd/docs/man/immudb"))
 	cmd.AddCommand(version.VersionCmd())
+	cmd.AddCommand(cl.configCmd(cmd))
+	cmd.AddCommand(cl.inspectCmd())
+	cmd.AddCommand(cl.repairCmd())
 
 	scl := service.NewCommandLine()
 	scl.Register(cmd)
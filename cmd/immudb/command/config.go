@@ -0,0 +1,410 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package immudb
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/codenotary/immudb/pkg/server"
+	"github.com/codenotary/immudb/pkg/signer"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// legacyConfigKeys are still accepted in config files but no longer have any effect
+var legacyConfigKeys = map[string]struct{}{
+	"network":           {},
+	"dbname":            {},
+	"consistency-check": {},
+	"replica":           {},
+}
+
+// secretConfigKeys are masked when the effective configuration is rendered
+var secretConfigKeys = map[string]struct{}{
+	"admin-password":                {},
+	"replication-follower-password": {},
+	"s3-secret-key":                 {},
+}
+
+// configError reports an invalid value of a configuration key
+type configError struct {
+	key string
+	msg string
+}
+
+func (e *configError) Error() string {
+	return fmt.Sprintf("%s: %s", e.key, e.msg)
+}
+
+// configErrors collects all the problems found in a configuration
+type configErrors []error
+
+func (errs configErrors) Error() string {
+	var sb strings.Builder
+
+	sb.WriteString("invalid configuration:")
+	for _, err := range errs {
+		sb.WriteString("\n  ")
+		sb.WriteString(err.Error())
+	}
+
+	return sb.String()
+}
+
+func (cl *Commandline) configCmd(rootCmd *cobra.Command) *cobra.Command {
+	ccmd := &cobra.Command{
+		Use:   "config",
+		Short: "Inspect the immudb configuration",
+	}
+
+	ccc := &cobra.Command{
+		Use:   "check",
+		Short: "Validate the configuration and print the effective values",
+		Long: `Validate the configuration resulting from the config file, IMMUDB_* environment variables
+and flags, without starting the server.
+
+The effective value of each setting is printed along with its source. Invalid values,
+unknown keys and out of range settings are reported, and the command exits with a non-zero code.`,
+		Example:       "immudb config check --config ./configs/immudb.toml --port 3323",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return checkConfig(cmd.OutOrStdout(), cmd.ErrOrStderr(), cmd.Flags(), viper.ConfigFileUsed())
+		},
+		Args: cobra.NoArgs,
+	}
+	// flags are shared with the root command so that values are bound to the same viper keys
+	ccc.Flags().AddFlagSet(rootCmd.Flags())
+
+	ccmd.AddCommand(ccc)
+
+	return ccmd
+}
+
+// serverFlags returns a fresh copy of the flags accepted by the server, used as the configuration schema
+func serverFlags() *pflag.FlagSet {
+	cmd := &cobra.Command{}
+	(&Commandline{}).setupFlags(cmd, server.DefaultOptions())
+	return cmd.Flags()
+}
+
+func envVarName(key string) string {
+	return "IMMUDB_" + strings.ToUpper(strings.Replace(key, "-", "_", -1))
+}
+
+func checkConfig(out, errOut io.Writer, flags *pflag.FlagSet, configFile string) error {
+	schema := serverFlags()
+
+	knownKeys := make(map[string]string)
+	schema.VisitAll(func(f *pflag.Flag) {
+		knownKeys[strings.ToLower(f.Name)] = f.Name
+	})
+
+	var errs configErrors
+	var warnings []string
+
+	fileKeys := make(map[string]struct{})
+
+	if configFile != "" {
+		fileCfg := viper.New()
+		fileCfg.SetConfigFile(configFile)
+
+		err := fileCfg.ReadInConfig()
+		if err != nil {
+			return err
+		}
+
+		keys := fileCfg.AllKeys()
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			name, known := knownKeys[key]
+			if !known {
+				if _, legacy := legacyConfigKeys[key]; legacy {
+					warnings = append(warnings, fmt.Sprintf("%s: key is no longer used and will be ignored", key))
+				} else {
+					errs = append(errs, &configError{key: key, msg: fmt.Sprintf("unknown key in config file '%s'", configFile)})
+				}
+				continue
+			}
+
+			fileKeys[name] = struct{}{}
+
+			err := schema.Set(name, fmt.Sprint(fileCfg.Get(key)))
+			if err != nil {
+				errs = append(errs, &configError{key: name, msg: fmt.Sprintf("invalid value in config file: %v", err)})
+			}
+		}
+	}
+
+	schema.VisitAll(func(f *pflag.Flag) {
+		value, ok := os.LookupEnv(envVarName(f.Name))
+		if !ok {
+			return
+		}
+
+		err := schema.Set(f.Name, value)
+		if err != nil {
+			errs = append(errs, &configError{key: f.Name, msg: fmt.Sprintf("invalid value in %s: %v", envVarName(f.Name), err)})
+		}
+	})
+
+	options, err := parseOptions()
+	if err != nil {
+		errs = append(errs, err)
+	} else {
+		errs = append(errs, validateOptions(options)...)
+	}
+
+	if configFile == "" {
+		fmt.Fprintf(out, "# effective configuration, no config file used\n")
+	} else {
+		fmt.Fprintf(out, "# effective configuration, config file: %s\n", configFile)
+	}
+
+	schema.VisitAll(func(f *pflag.Flag) {
+		source := "default"
+		if flags.Changed(f.Name) {
+			source = "flag"
+		} else if _, ok := os.LookupEnv(envVarName(f.Name)); ok {
+			source = "env " + envVarName(f.Name)
+		} else if _, ok := fileKeys[f.Name]; ok {
+			source = "config file"
+		}
+
+		fmt.Fprintf(out, "%s = %s # %s\n", f.Name, renderConfigValue(f), source)
+	})
+
+	for _, w := range warnings {
+		fmt.Fprintf(errOut, "warning: %s\n", w)
+	}
+
+	if len(errs) > 0 {
+		fmt.Fprintln(errOut, errs.Error())
+		return fmt.Errorf("%d configuration error(s) found", len(errs))
+	}
+
+	fmt.Fprintf(out, "# configuration is valid\n")
+
+	return nil
+}
+
+func renderConfigValue(f *pflag.Flag) string {
+	if _, secret := secretConfigKeys[f.Name]; secret {
+		if viper.GetString(f.Name) == "" {
+			return `""`
+		}
+		return `"********"`
+	}
+
+	switch f.Value.Type() {
+	case "bool":
+		return fmt.Sprint(viper.GetBool(f.Name))
+	case "int":
+		return fmt.Sprint(viper.GetInt(f.Name))
+	case "duration":
+		return fmt.Sprintf("%q", viper.GetDuration(f.Name).String())
+	default:
+		return fmt.Sprintf("%q", viper.GetString(f.Name))
+	}
+}
+
+func validatePort(key string, port int) error {
+	if port < 1 || port > 65535 {
+		return &configError{key: key, msg: fmt.Sprintf("must be between 1 and 65535, got %d", port)}
+	}
+	return nil
+}
+
+// validateOptions checks server options against their allowed ranges, reporting every invalid setting
+// by its configuration key
+func validateOptions(opts *server.Options) configErrors {
+	var errs configErrors
+
+	check := func(err error) {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	notEmpty := func(key, value, condition string) {
+		if value == "" {
+			check(&configError{key: key, msg: "must not be empty" + condition})
+		}
+	}
+
+	notEmpty("dir", opts.Dir, "")
+	notEmpty("address", opts.Address, "")
+	notEmpty("admin-password", opts.AdminPassword, "")
+
+	check(validatePort("port", opts.Port))
+
+	if opts.MaxRecvMsgSize <= 0 {
+		check(&configError{key: "max-recv-msg-size", msg: fmt.Sprintf("must be greater than 0, got %d", opts.MaxRecvMsgSize)})
+	}
+
+	if opts.TokenExpiryTimeMin <= 0 {
+		check(&configError{key: "token-expiry-time", msg: fmt.Sprintf("must be greater than 0, got %d", opts.TokenExpiryTimeMin)})
+	}
+
+	if opts.WebServer {
+		check(validatePort("web-server-port", opts.WebServerPort))
+
+		if opts.WebServerPort == opts.Port {
+			check(&configError{key: "web-server-port", msg: fmt.Sprintf("must be different from port %d", opts.Port)})
+		}
+	}
+
+	if opts.PgsqlServer {
+		check(validatePort("pgsql-server-port", opts.PgsqlServerPort))
+
+		if opts.PgsqlServerPort == opts.Port {
+			check(&configError{key: "pgsql-server-port", msg: fmt.Sprintf("must be different from port %d", opts.Port)})
+		}
+		if opts.WebServer && opts.PgsqlServerPort == opts.WebServerPort {
+			check(&configError{key: "pgsql-server-port", msg: fmt.Sprintf("must be different from web-server-port %d", opts.WebServerPort)})
+		}
+	}
+
+	if opts.ReplicationOptions != nil {
+		notEmpty("replication-master-address", opts.ReplicationOptions.MasterAddress, " when replication is enabled")
+		notEmpty("replication-follower-username", opts.ReplicationOptions.FollowerUsername, " when replication is enabled")
+		check(validatePort("replication-master-port", opts.ReplicationOptions.MasterPort))
+	}
+
+	if opts.RemoteStorageOptions != nil && opts.RemoteStorageOptions.S3Storage {
+		notEmpty("s3-endpoint", opts.RemoteStorageOptions.S3Endpoint, " when s3 storage is enabled")
+		notEmpty("s3-bucket-name", opts.RemoteStorageOptions.S3BucketName, " when s3 storage is enabled")
+	}
+
+	if opts.ACMEOptions != nil && len(opts.ACMEOptions.Domains) > 0 {
+		notEmpty("acme-cache-dir", opts.ACMEOptions.CacheDir, " when acme is enabled")
+
+		if opts.TLSConfig != nil && opts.TLSConfig.GetCertificate != nil {
+			check(&configError{key: "acme-domains", msg: "must be empty when a certificate is configured"})
+		}
+	}
+
+	if opts.NetworkRulesOptions != nil {
+		networks := func(key string, networks []string) {
+			if _, err := server.ParseNetworks(networks); err != nil {
+				check(&configError{key: key, msg: err.Error()})
+			}
+		}
+
+		networks("allowed-networks", opts.NetworkRulesOptions.AllowedNetworks)
+		networks("denied-networks", opts.NetworkRulesOptions.DeniedNetworks)
+
+		dbs := make([]string, 0, len(opts.NetworkRulesOptions.DatabaseAllowedNetworks))
+		for db := range opts.NetworkRulesOptions.DatabaseAllowedNetworks {
+			dbs = append(dbs, db)
+		}
+		sort.Strings(dbs)
+
+		for _, db := range dbs {
+			networks("database-allowed-networks", opts.NetworkRulesOptions.DatabaseAllowedNetworks[db])
+		}
+	}
+
+	for _, origin := range opts.GrpcWebAllowedOrigins {
+		u, err := url.Parse(origin)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || strings.Trim(u.Path, "/") != "" || u.RawQuery != "" {
+			check(&configError{key: "grpc-web-allowed-origins", msg: fmt.Sprintf("'%s' is not an origin like https://app.example.com", origin)})
+		}
+	}
+
+	if len(opts.ClientCertUsers) > 0 && opts.TLSConfig == nil {
+		check(&configError{key: "client-cert-users", msg: "must be empty when tls is not enabled"})
+	}
+
+	if opts.SessionsOptions != nil {
+		durations := []struct {
+			key   string
+			value int64
+		}{
+			{"max-session-inactivity-time", int64(opts.SessionsOptions.MaxSessionInactivityTime)},
+			{"max-session-age-time", int64(opts.SessionsOptions.MaxSessionAgeTime)},
+			{"session-timeout", int64(opts.SessionsOptions.Timeout)},
+		}
+
+		for _, d := range durations {
+			if d.value < 0 {
+				check(&configError{key: d.key, msg: "must not be negative"})
+			}
+		}
+
+		if opts.SessionsOptions.SessionGuardCheckInterval <= 0 {
+			check(&configError{key: "sessions-guard-check-interval", msg: "must be greater than 0"})
+		}
+	}
+
+	if opts.SigningKey != "" {
+		_, err := os.Stat(opts.SigningKey)
+		if err != nil {
+			check(&configError{key: "signingKey", msg: fmt.Sprintf("signing key can not be read: %v", err)})
+		}
+	}
+
+	for _, k := range opts.RetiredSigningKeys {
+		_, err := signer.ParsePublicKeyFile(k)
+		if err != nil {
+			check(&configError{key: "retired-signing-keys", msg: fmt.Sprintf("public key '%s' can not be read: %v", k, err)})
+		}
+	}
+
+	if opts.StateSigningInterval < 0 {
+		check(&configError{key: "state-signing-interval", msg: "must not be negative"})
+	}
+
+	if opts.SigningKey == "" && (len(opts.RetiredSigningKeys) > 0 || opts.StateSigningInterval > 0) {
+		check(&configError{key: "signingKey", msg: "must not be empty when retired-signing-keys or state-signing-interval are set"})
+	}
+
+	if opts.DatabaseSettingsFile != "" {
+		_, err := server.ReadDatabaseSettingsFile(opts.DatabaseSettingsFile)
+		if err != nil {
+			check(&configError{key: "database-settings-file", msg: fmt.Sprintf("database settings can not be read: %v", err)})
+		}
+	}
+
+	if opts.TenantsFile != "" {
+		_, err := server.ReadTenantsFile(opts.TenantsFile)
+		if err != nil {
+			check(&configError{key: "tenants-file", msg: fmt.Sprintf("tenants can not be read: %v", err)})
+		}
+	}
+
+	if opts.AutoCompactionInterval < 0 {
+		check(&configError{key: "auto-compaction-interval", msg: "must not be negative"})
+	}
+
+	if opts.MultiDBTxRetryInterval < 0 {
+		check(&configError{key: "multidb-tx-retry-interval", msg: "must not be negative"})
+	}
+
+	if opts.MemoryBudget < 0 {
+		check(&configError{key: "memory-budget", msg: "must not be negative"})
+	}
+
+	return errs
+}
@@ -0,0 +1,163 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package immudb
+
+import (
+	"bytes"
+	"crypto/tls"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/codenotary/immudb/cmd/helper"
+	"github.com/codenotary/immudb/pkg/server"
+	"github.com/codenotary/immudb/pkg/server/sessions"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateOptions(t *testing.T) {
+	require.Empty(t, validateOptions(server.DefaultOptions()))
+
+	opts := server.DefaultOptions().
+		WithPort(0).
+		WithMaxRecvMsgSize(-1).
+		WithWebServer(true).
+		WithWebServerPort(5432).
+		WithPgsqlServer(true).
+		WithPgsqlServerPort(5432).
+		WithReplicationOptions(&server.ReplicationOptions{MasterPort: 3322}).
+		WithTLS(&tls.Config{GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) { return nil, nil }}).
+		WithACMEOptions(server.DefaultACMEOptions().WithDomains([]string{"immudb.io"}).WithCacheDir("")).
+		WithNetworkRulesOptions((&server.NetworkRulesOptions{}).
+			WithAllowedNetworks([]string{"10.0.0.0/8"}).
+			WithDeniedNetworks([]string{"10.0.0.0/33"}).
+			WithDatabaseAllowedNetworks(map[string][]string{"db1": {"192.168.1.1"}, "db2": {"invalid"}})).
+		WithGrpcWebAllowedOrigins([]string{"https://app.example.com", "app.example.com"}).
+		WithSessionOptions(sessions.DefaultOptions().WithTimeout(-time.Second)).
+		WithSigningKey("./unexistent.key").
+		WithRetiredSigningKeys([]string{"./unexistent.pub"}).
+		WithStateSigningInterval(-time.Second).
+		WithDatabaseSettingsFile("./unexistent.json").
+		WithTenantsFile("./unexistent.json").
+		WithAutoCompactionInterval(-time.Second).
+		WithMemoryBudget(-1)
+
+	errs := validateOptions(opts)
+
+	var keys []string
+	for _, err := range errs {
+		keys = append(keys, err.(*configError).key)
+	}
+
+	require.Equal(t, []string{
+		"port",
+		"max-recv-msg-size",
+		"pgsql-server-port",
+		"replication-master-address",
+		"replication-follower-username",
+		"acme-cache-dir",
+		"acme-domains",
+		"denied-networks",
+		"database-allowed-networks",
+		"grpc-web-allowed-origins",
+		"session-timeout",
+		"signingKey",
+		"retired-signing-keys",
+		"state-signing-interval",
+		"database-settings-file",
+		"tenants-file",
+		"auto-compaction-interval",
+		"memory-budget",
+	}, keys)
+
+	require.Contains(t, errs.Error(), "port: must be between 1 and 65535, got 0")
+
+	errs = validateOptions(server.DefaultOptions().WithStateSigningInterval(time.Minute))
+	require.Len(t, errs, 1)
+	require.Equal(t, "signingKey", errs[0].(*configError).key)
+}
+
+func newConfigCheckCmd(t *testing.T) *cobra.Command {
+	viper.Reset()
+
+	cl := Commandline{config: helper.Config{Name: "immudb"}}
+
+	cmd, err := cl.NewRootCmd(server.DefaultServer())
+	require.NoError(t, err)
+
+	cmd.AddCommand(cl.configCmd(cmd))
+
+	return cmd
+}
+
+func TestConfigCheck(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config_check")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	cfgFile := filepath.Join(dir, "immudb.toml")
+
+	t.Run("valid configuration", func(t *testing.T) {
+		err := ioutil.WriteFile(cfgFile, []byte("port = 3323\ndbname = \"immudb\"\nadmin-password = \"mypassword\"\n"), 0644)
+		require.NoError(t, err)
+
+		cmd := newConfigCheckCmd(t)
+
+		var out, errOut bytes.Buffer
+		cmd.SetOut(&out)
+		cmd.SetErr(&errOut)
+		cmd.SetArgs([]string{"config", "check", "--config", cfgFile, "--pgsql-server-port", "5433"})
+
+		err = cmd.Execute()
+		require.NoError(t, err)
+
+		require.Contains(t, out.String(), "port = 3323 # config file")
+		require.Contains(t, out.String(), "pgsql-server-port = 5433 # flag")
+		require.Contains(t, out.String(), "admin-password = \"********\" # config file")
+		require.Contains(t, out.String(), "# configuration is valid")
+		require.NotContains(t, out.String(), "mypassword")
+		require.Contains(t, errOut.String(), "warning: dbname: key is no longer used")
+	})
+
+	t.Run("invalid configuration", func(t *testing.T) {
+		err := ioutil.WriteFile(cfgFile, []byte("prot = 3323\nmax-recv-msg-size = \"big\"\n"), 0644)
+		require.NoError(t, err)
+
+		os.Setenv("IMMUDB_TOKEN_EXPIRY_TIME", "0")
+		defer os.Unsetenv("IMMUDB_TOKEN_EXPIRY_TIME")
+
+		cmd := newConfigCheckCmd(t)
+
+		var out, errOut bytes.Buffer
+		cmd.SetOut(&out)
+		cmd.SetErr(&errOut)
+		cmd.SetArgs([]string{"config", "check", "--config", cfgFile, "--port", "70000"})
+
+		err = cmd.Execute()
+		require.Error(t, err)
+
+		require.Contains(t, out.String(), "token-expiry-time = 0 # env IMMUDB_TOKEN_EXPIRY_TIME")
+		require.Contains(t, errOut.String(), "prot: unknown key in config file")
+		require.Contains(t, errOut.String(), "max-recv-msg-size: invalid value in config file")
+		require.Contains(t, errOut.String(), "port: must be between 1 and 65535, got 70000")
+		require.Contains(t, errOut.String(), "token-expiry-time: must be greater than 0, got 0")
+	})
+}
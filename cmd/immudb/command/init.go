@@ -28,7 +28,7 @@ import (
 func (cl *Commandline) setupFlags(cmd *cobra.Command, options *server.Options) {
 	cmd.Flags().String("dir", options.Dir, "data folder")
 	cmd.Flags().IntP("port", "p", options.Port, "port number")
-	cmd.Flags().StringP("address", "a", options.Address, "bind address")
+	cmd.Flags().StringP("address", "a", options.Address, "bind address, or unix:///path/to/immudb.sock to listen on a unix socket")
 	cmd.Flags().Bool("replication-enabled", false, "set systemdb and defaultdb as replica")
 	cmd.Flags().String("replication-master-address", "", "master address (if replica=true)")
 	cmd.Flags().Int("replication-master-port", 3322, "master port (if replica=true)")
@@ -41,14 +41,44 @@ func (cl *Commandline) setupFlags(cmd *cobra.Command, options *server.Options) {
 	cmd.Flags().BoolP("auth", "s", false, "enable auth")
 	cmd.Flags().Int("max-recv-msg-size", options.MaxRecvMsgSize, "max message size in bytes the server can receive")
 	cmd.Flags().Bool("no-histograms", false, "disable collection of histogram metrics like query durations")
+	cmd.Flags().String("metrics-username", "", "username required through basic auth to read metrics (metrics are not protected when empty)")
+	cmd.Flags().String("metrics-password", "", "password required through basic auth to read metrics")
+	cmd.Flags().String("metrics-client-ca", "", "certificate authority of the clients allowed to read metrics, it enables mutual tls on the metrics server with the server certificate")
+	cmd.Flags().StringSlice("metrics-groups", nil, "groups of metrics exported among server, store, index, replication, sql and runtime (default is all of them)")
+	cmd.Flags().Bool("metrics-aggregate-databases", false, "sum the metrics of all databases into a single series, to bound the number of series on servers with many databases")
+	cmd.Flags().Duration("slow-query-threshold", 0, "duration after which sql statements and scans are logged as slow, together with their plan and redacted parameters (0 disables the slow query log)")
+	cmd.Flags().Bool("slow-query-recording", false, "record slow queries in the system database besides logging them")
+	cmd.Flags().Duration("max-request-duration", 0, "max execution time of unary requests, longer ones are canceled (0 means unlimited)")
+	cmd.Flags().Int("max-result-rows", 0, "max number of rows of a sql result or entries of a scan, larger results are rejected with the count of rows read so far (0 means unlimited)")
+	cmd.Flags().Int("max-result-bytes", 0, "max size in bytes of a sql result or a scan, larger results are rejected with the count of rows read so far (0 means unlimited)")
 	cmd.Flags().BoolP(c.DetachedFlag, c.DetachedShortFlag, options.Detached, "run immudb in background")
 	cmd.Flags().String("certificate", "", "server certificate file path")
 	cmd.Flags().String("pkey", "", "server private key path")
 	cmd.Flags().String("clientcas", "", "clients certificates list. Aka certificate authority")
+	cmd.Flags().StringSlice("allowed-networks", nil, "networks clients are allowed to connect from, in CIDR notation (default is any network). E.g. 10.0.0.0/8,192.168.1.10")
+	cmd.Flags().StringSlice("denied-networks", nil, "networks clients are not allowed to connect from, in CIDR notation. Denied networks take precedence over allowed ones")
+	cmd.Flags().StringToString("database-allowed-networks", nil, "networks clients are allowed to access a database from, separated by spaces. E.g. db1=\"10.0.0.0/8 192.168.1.0/24\",db2=10.1.0.0/16")
+	cmd.Flags().StringToString("client-cert-users", nil, "users clients are authenticated as by their verified certificate, without a password. E.g. app1.example.com=app1,spiffe://example.com/app2=app2 maps the certificate common name or subject alternative name to the user")
+	cmd.Flags().StringSlice("acme-domains", nil, "domains the server certificate is obtained and renewed for through ACME e.g. from Let's Encrypt, instead of being read from the certificate file")
+	cmd.Flags().String("acme-email", "", "contact email of the ACME account, notified about certificate problems")
+	cmd.Flags().String("acme-cache-dir", server.DefaultACMEOptions().CacheDir, "directory where certificates obtained through ACME are kept across restarts")
+	cmd.Flags().String("acme-http-challenge-address", server.DefaultACMEOptions().HTTPChallengeAddress, "address where ACME http-01 challenges are answered, it must be reachable on port 80 of the domains (empty to rely on tls-alpn-01 challenges only)")
+	cmd.Flags().String("acme-directory-url", "", "directory URL of the ACME CA (default is the Let's Encrypt production directory)")
 	cmd.Flags().Bool("devmode", options.DevMode, "enable dev mode: accept remote connections without auth")
 	cmd.Flags().String("admin-password", options.AdminPassword, "admin password (default is 'immudb') as plain-text or base64 encoded (must be prefixed with 'enc:' if it is encoded)")
 	cmd.Flags().Bool("maintenance", options.GetMaintenance(), "override the authentication flag")
 	cmd.Flags().String("signingKey", options.SigningKey, "signature private key path. If a valid one is provided, it enables the cryptographic signature of the root. E.g. \"./../test/signer/ec3.key\"")
+	cmd.Flags().StringSlice("retired-signing-keys", nil, "public key paths of the signing keys used before the current one, served to clients to verify states signed before a key rotation")
+	cmd.Flags().Duration("state-signing-interval", options.StateSigningInterval, "interval at which the current state of every database is signed in background (0 disables periodic signing)")
+	cmd.Flags().String("database-settings-file", options.DatabaseSettingsFile, "json file with the default settings and the named templates of the databases created on this server")
+	cmd.Flags().String("tenants-file", options.TenantsFile, "json file with the tenants owning databases and users on this server and their quotas (max databases, max disk usage in bytes and max concurrent sessions)")
+	cmd.Flags().Duration("auto-compaction-interval", options.AutoCompactionInterval, "interval at which the indexes of the databases with auto compaction enabled are checked (0 disables auto compaction)")
+	cmd.Flags().Duration("multidb-tx-retry-interval", options.MultiDBTxRetryInterval, "interval at which the pending parts of multi-database transactions are retried (0 means they're only retried on start)")
+	cmd.Flags().Int64("memory-budget", options.MemoryBudget, "memory in bytes the caches of all the databases may take, divided among them by activity (0 means each database takes the caches set in its settings)")
+	cmd.Flags().Bool("scripting", options.Scripting, "enable server-side scripts reading and conditionally writing keys within a single transaction")
+	cmd.Flags().Bool("grpc-reflection", options.GrpcReflection, "enable the gRPC server reflection service, used by tools like grpcurl to discover the API")
+	cmd.Flags().Bool("grpc-web", options.GrpcWeb, "serve the gRPC API through the gRPC-Web protocol on the web server, so browsers can call it without a proxy")
+	cmd.Flags().StringSlice("grpc-web-allowed-origins", nil, "origins, other than the one of the web server, browsers may call the gRPC API from through gRPC-Web (default is none). E.g. https://app.example.com")
 	cmd.Flags().Bool("synced", true, "synced mode prevents data lost under unexpected crashes but affects performance")
 	cmd.Flags().Int("token-expiry-time", options.TokenExpiryTimeMin, "client authentication token expiration time. Minutes")
 	cmd.Flags().Bool("web-server", options.WebServer, "enable or disable web/console server")
@@ -80,14 +110,44 @@ func setupDefaults(options *server.Options) {
 	viper.SetDefault("auth", options.GetAuth())
 	viper.SetDefault("max-recv-msg-size", options.MaxRecvMsgSize)
 	viper.SetDefault("no-histograms", options.NoHistograms)
+	viper.SetDefault("metrics-username", "")
+	viper.SetDefault("metrics-password", "")
+	viper.SetDefault("metrics-client-ca", "")
+	viper.SetDefault("metrics-groups", []string{})
+	viper.SetDefault("metrics-aggregate-databases", false)
+	viper.SetDefault("slow-query-threshold", 0)
+	viper.SetDefault("slow-query-recording", false)
+	viper.SetDefault("max-request-duration", 0)
+	viper.SetDefault("max-result-rows", 0)
+	viper.SetDefault("max-result-bytes", 0)
 	viper.SetDefault("detached", options.Detached)
 	viper.SetDefault("certificate", "")
 	viper.SetDefault("pkey", "")
 	viper.SetDefault("clientcas", "")
+	viper.SetDefault("allowed-networks", []string{})
+	viper.SetDefault("denied-networks", []string{})
+	viper.SetDefault("database-allowed-networks", map[string]string{})
+	viper.SetDefault("client-cert-users", map[string]string{})
+	viper.SetDefault("acme-domains", []string{})
+	viper.SetDefault("acme-email", "")
+	viper.SetDefault("acme-cache-dir", server.DefaultACMEOptions().CacheDir)
+	viper.SetDefault("acme-http-challenge-address", server.DefaultACMEOptions().HTTPChallengeAddress)
+	viper.SetDefault("acme-directory-url", "")
 	viper.SetDefault("devmode", options.DevMode)
 	viper.SetDefault("admin-password", options.AdminPassword)
 	viper.SetDefault("maintenance", options.GetMaintenance())
+	viper.SetDefault("database-settings-file", options.DatabaseSettingsFile)
+	viper.SetDefault("tenants-file", options.TenantsFile)
+	viper.SetDefault("scripting", options.Scripting)
+	viper.SetDefault("grpc-reflection", options.GrpcReflection)
+	viper.SetDefault("grpc-web", options.GrpcWeb)
+	viper.SetDefault("grpc-web-allowed-origins", []string{})
 	viper.SetDefault("synced", true)
+	viper.SetDefault("retired-signing-keys", []string{})
+	viper.SetDefault("state-signing-interval", options.StateSigningInterval)
+	viper.SetDefault("auto-compaction-interval", options.AutoCompactionInterval)
+	viper.SetDefault("multidb-tx-retry-interval", options.MultiDBTxRetryInterval)
+	viper.SetDefault("memory-budget", options.MemoryBudget)
 	viper.SetDefault("token-expiry-time", options.TokenExpiryTimeMin)
 	viper.SetDefault("web-server", options.WebServer)
 	viper.SetDefault("web-server-port", options.WebServerPort)
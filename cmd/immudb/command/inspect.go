@@ -0,0 +1,397 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package immudb
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/embedded/tbtree"
+	"github.com/codenotary/immudb/pkg/database"
+	"github.com/codenotary/immudb/pkg/logger"
+	"github.com/codenotary/immudb/pkg/server"
+	"github.com/spf13/cobra"
+)
+
+// secretDBSettings are masked when database settings are printed
+var secretDBSettings = map[string]struct{}{
+	"followerPassword": {},
+}
+
+func (cl *Commandline) inspectCmd() *cobra.Command {
+	icmd := &cobra.Command{
+		Use:   "inspect [database...]",
+		Short: "Inspect an immudb data directory without running the server",
+		Long: `Open the databases of an immudb data directory in read-only mode and print the last
+committed and indexed transactions, the database settings and the latest transaction headers.
+
+The commit log of each database is verified by reading every transaction, checking the
+accumulative linear hash chain and the hash of every value. The command exits with a non-zero
+code if any inconsistency is found.
+
+The server must not be running on the same data directory.`,
+		Example:       "immudb inspect --dir ./data defaultdb --txs 5",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dir, err := cmd.Flags().GetString("dir")
+			if err != nil {
+				return err
+			}
+			txs, err := cmd.Flags().GetInt("txs")
+			if err != nil {
+				return err
+			}
+			verify, err := cmd.Flags().GetBool("verify")
+			if err != nil {
+				return err
+			}
+
+			return inspectDataDir(cmd.OutOrStdout(), cmd.ErrOrStderr(), dir, args, txs, verify)
+		},
+	}
+	icmd.Flags().String("dir", server.DefaultOptions().Dir, "data folder")
+	icmd.Flags().Int("txs", 10, "number of latest transaction headers to print per database")
+	icmd.Flags().Bool("verify", true, "verify the integrity of the commit log")
+
+	return icmd
+}
+
+// dataDirDatabases lists the databases found in a data directory, system and default databases first
+func dataDirDatabases(dir string) ([]string, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var dbs []string
+
+	for _, f := range files {
+		if !f.IsDir() {
+			continue
+		}
+
+		// every database holds a commit log
+		_, err := os.Stat(filepath.Join(dir, f.Name(), "commit"))
+		if err != nil {
+			continue
+		}
+
+		dbs = append(dbs, f.Name())
+	}
+
+	rank := func(db string) int {
+		switch db {
+		case server.SystemDBName:
+			return 0
+		case server.DefaultDBName:
+			return 1
+		}
+		return 2
+	}
+
+	sort.SliceStable(dbs, func(i, j int) bool {
+		return rank(dbs[i]) < rank(dbs[j])
+	})
+
+	return dbs, nil
+}
+
+func openReadOnlyStore(dir string, errOut io.Writer) (*store.ImmuStore, error) {
+	opts := store.DefaultOptions().
+		WithReadOnly(true).
+		WithLog(logger.NewSimpleLoggerWithLevel("immudb ", errOut, logger.LogError))
+
+	return store.Open(dir, opts)
+}
+
+// indexedTx returns the last tx indexed by the database, as stored in its index
+func indexedTx(dir string, errOut io.Writer) (uint64, error) {
+	indexPath := filepath.Join(dir, "index")
+
+	_, err := os.Stat(indexPath)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	index, err := tbtree.Open(indexPath, tbtree.DefaultOptions().
+		WithReadOnly(true).
+		WithLog(logger.NewSimpleLoggerWithLevel("immudb ", errOut, logger.LogError)))
+	if err != nil {
+		return 0, err
+	}
+	defer index.Close()
+
+	return index.Ts(), nil
+}
+
+func inspectDataDir(out, errOut io.Writer, dir string, databases []string, txs int, verify bool) error {
+	if txs < 0 {
+		return fmt.Errorf("invalid number of transactions: %d", txs)
+	}
+
+	all, err := dataDirDatabases(dir)
+	if err != nil {
+		return err
+	}
+	if len(all) == 0 {
+		return fmt.Errorf("no databases found in data directory '%s'", dir)
+	}
+
+	if len(databases) == 0 {
+		databases = all
+	} else {
+		for _, db := range databases {
+			found := false
+			for _, d := range all {
+				if d == db {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return fmt.Errorf("database '%s' not found in data directory '%s'", db, dir)
+			}
+		}
+	}
+
+	settings := make(map[string]map[string]interface{})
+
+	sysDB, err := openReadOnlyStore(filepath.Join(dir, server.SystemDBName), errOut)
+	if err == nil {
+		for _, db := range databases {
+			s, err := readDBSettings(sysDB, db)
+			if err != nil {
+				fmt.Fprintf(errOut, "warning: settings of database '%s' could not be read: %v\n", db, err)
+				continue
+			}
+			settings[db] = s
+		}
+
+		sysDB.Close()
+	} else {
+		fmt.Fprintf(errOut, "warning: database settings could not be read: %v\n", err)
+	}
+
+	failed := 0
+
+	for i, db := range databases {
+		if i > 0 {
+			fmt.Fprintln(out)
+		}
+
+		err := inspectDatabase(out, errOut, filepath.Join(dir, db), db, settings[db], txs, verify)
+		if err != nil {
+			fmt.Fprintf(out, "  error:        %v\n", err)
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d database(s) failed inspection", failed)
+	}
+
+	return nil
+}
+
+// readDBSettings reads the settings of a database as stored in the system database
+func readDBSettings(sysDB *store.ImmuStore, db string) (map[string]interface{}, error) {
+	key := make([]byte, 1+len(db))
+	key[0] = server.KeyPrefixDBSettings
+	copy(key[1:], db)
+
+	// read-only stores build their own index from the commit log
+	committedTxID, _ := sysDB.Alh()
+
+	err := sysDB.WaitForIndexingUpto(committedTxID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	valRef, err := sysDB.Get(database.EncodeKey(key))
+	if errors.Is(err, store.ErrKeyNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	val, err := valRef.Resolve()
+	if err != nil {
+		return nil, err
+	}
+
+	var s map[string]interface{}
+
+	err = json.Unmarshal(database.TrimPrefix(val), &s)
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func inspectDatabase(out, errOut io.Writer, dir, name string, settings map[string]interface{}, txs int, verify bool) error {
+	fmt.Fprintf(out, "database:       %s\n", name)
+	fmt.Fprintf(out, "  dir:          %s\n", dir)
+
+	st, err := openReadOnlyStore(dir, errOut)
+	if err != nil {
+		return err
+	}
+	defer st.Close()
+
+	committedTxID, committedAlh := st.Alh()
+
+	indexedTxID, err := indexedTx(dir, errOut)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "  committed tx: %d\n", committedTxID)
+	fmt.Fprintf(out, "  indexed tx:   %d\n", indexedTxID)
+	fmt.Fprintf(out, "  alh:          %x\n", committedAlh)
+
+	if settings == nil {
+		fmt.Fprintf(out, "  settings:     not found\n")
+	} else {
+		fmt.Fprintf(out, "  settings:\n")
+		printDBSettings(out, settings)
+	}
+
+	tx := st.NewTxHolder()
+
+	if txs > 0 && committedTxID > 0 {
+		fmt.Fprintf(out, "  latest txs:\n")
+
+		from := uint64(1)
+		if committedTxID > uint64(txs) {
+			from = committedTxID - uint64(txs) + 1
+		}
+
+		for id := committedTxID; id >= from; id-- {
+			err := st.ReadTx(id, tx)
+			if err != nil {
+				return fmt.Errorf("tx %d could not be read: %w", id, err)
+			}
+
+			hdr := tx.Header()
+			alh := hdr.Alh()
+
+			fmt.Fprintf(out, "    tx %d: ts=%s version=%d entries=%d eh=%x alh=%x\n",
+				hdr.ID,
+				time.Unix(hdr.Ts, 0).UTC().Format(time.RFC3339),
+				hdr.Version,
+				hdr.NEntries,
+				hdr.Eh,
+				alh,
+			)
+		}
+	}
+
+	if !verify {
+		return nil
+	}
+
+	err = verifyCommitLog(st, tx)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "  integrity:    ok (%d txs verified)\n", committedTxID)
+
+	return nil
+}
+
+func printDBSettings(out io.Writer, settings map[string]interface{}) {
+	keys := make([]string, 0, len(settings))
+	for k := range settings {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		v := settings[k]
+
+		if _, secret := secretDBSettings[k]; secret && v != "" {
+			v = "********"
+		}
+
+		b, err := json.Marshal(v)
+		if err != nil {
+			b = []byte(fmt.Sprint(v))
+		}
+
+		fmt.Fprintf(out, "    %s = %s\n", k, b)
+	}
+}
+
+// verifyCommitLog reads every committed transaction checking the accumulative linear hash chain up to
+// the last committed one and that every value matches its hash
+func verifyCommitLog(st *store.ImmuStore, tx *store.Tx) error {
+	committedTxID, committedAlh := st.Alh()
+
+	alh := sha256.Sum256(nil)
+
+	for id := uint64(1); id <= committedTxID; id++ {
+		// alh of each transaction is checked against the stored one while reading it
+		err := st.ReadTx(id, tx)
+		if err != nil {
+			return fmt.Errorf("commit log verification failed at tx %d: %w", id, err)
+		}
+
+		hdr := tx.Header()
+
+		if hdr.ID != id {
+			return fmt.Errorf("commit log verification failed at tx %d: unexpected tx id %d", id, hdr.ID)
+		}
+
+		if hdr.PrevAlh != alh {
+			return fmt.Errorf("commit log verification failed at tx %d: previous alh mismatch", id)
+		}
+
+		for _, e := range tx.Entries() {
+			_, err := st.ReadValue(e)
+			if errors.Is(err, store.ErrExpiredEntry) {
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("commit log verification failed at tx %d: value of key %q: %w", id, e.Key(), err)
+			}
+		}
+
+		alh = hdr.Alh()
+	}
+
+	if alh != committedAlh {
+		return fmt.Errorf("commit log verification failed: alh mismatch at tx %d", committedTxID)
+	}
+
+	return nil
+}
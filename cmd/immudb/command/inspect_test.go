@@ -0,0 +1,122 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package immudb
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/database"
+	"github.com/codenotary/immudb/pkg/server"
+	"github.com/stretchr/testify/require"
+)
+
+func setupInspectDataDir(t *testing.T, dir string) {
+	sysDB, err := store.Open(filepath.Join(dir, server.SystemDBName), store.DefaultOptions())
+	require.NoError(t, err)
+
+	settingsKey := append([]byte{server.KeyPrefixDBSettings}, []byte(server.DefaultDBName)...)
+	settings := []byte(`{"database":"defaultdb","replica":true,"followerPassword":"follower-pwd","maxKeyLen":1024}`)
+
+	tx, err := sysDB.NewWriteOnlyTx()
+	require.NoError(t, err)
+	spec := database.EncodeEntrySpec(settingsKey, nil, settings)
+	require.NoError(t, tx.Set(spec.Key, nil, spec.Value))
+	_, err = tx.Commit()
+	require.NoError(t, err)
+	require.NoError(t, sysDB.Close())
+
+	defaultDB, err := store.Open(filepath.Join(dir, server.DefaultDBName), store.DefaultOptions())
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		tx, err := defaultDB.NewWriteOnlyTx()
+		require.NoError(t, err)
+		require.NoError(t, tx.Set([]byte(fmt.Sprintf("key%d", i)), nil, []byte(fmt.Sprintf("value%d", i))))
+		_, err = tx.Commit()
+		require.NoError(t, err)
+	}
+	require.NoError(t, defaultDB.Close())
+}
+
+func TestInspectDataDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "inspect")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	setupInspectDataDir(t, dir)
+
+	var out, errOut bytes.Buffer
+
+	err = inspectDataDir(&out, &errOut, dir, nil, 2, true)
+	require.NoError(t, err)
+
+	output := out.String()
+	require.Contains(t, output, "database:       systemdb")
+	require.Contains(t, output, "database:       defaultdb")
+	require.Contains(t, output, "committed tx: 3")
+	require.Contains(t, output, "    tx 3: ")
+	require.Contains(t, output, "    tx 2: ")
+	require.NotContains(t, output[strings.Index(output, "database:       defaultdb"):], "    tx 1: ")
+	require.Contains(t, output, "    maxKeyLen = 1024")
+	require.Contains(t, output, `    followerPassword = "********"`)
+	require.NotContains(t, output, "follower-pwd")
+	require.Contains(t, output, "integrity:    ok (3 txs verified)")
+
+	t.Run("unknown database", func(t *testing.T) {
+		err := inspectDataDir(&out, &errOut, dir, []string{"unknowndb"}, 0, true)
+		require.Error(t, err)
+	})
+
+	t.Run("empty data directory", func(t *testing.T) {
+		emptyDir, err := ioutil.TempDir("", "inspect_empty")
+		require.NoError(t, err)
+		defer os.RemoveAll(emptyDir)
+
+		err = inspectDataDir(&out, &errOut, emptyDir, nil, 0, true)
+		require.Error(t, err)
+	})
+
+	t.Run("corrupted value log", func(t *testing.T) {
+		vLogFiles, err := filepath.Glob(filepath.Join(dir, server.DefaultDBName, "val_0", "*.val"))
+		require.NoError(t, err)
+		require.NotEmpty(t, vLogFiles)
+
+		b, err := ioutil.ReadFile(vLogFiles[0])
+		require.NoError(t, err)
+		b = bytes.Replace(b, []byte("value1"), []byte("VALUE1"), 1)
+		require.NoError(t, ioutil.WriteFile(vLogFiles[0], b, 0644))
+
+		out.Reset()
+
+		err = inspectDataDir(&out, &errOut, dir, []string{server.DefaultDBName}, 0, true)
+		require.Error(t, err)
+		require.Contains(t, out.String(), "commit log verification failed at tx 2")
+
+		out.Reset()
+
+		err = inspectDataDir(&out, &errOut, dir, []string{server.DefaultDBName}, 0, false)
+		require.NoError(t, err)
+		require.NotContains(t, out.String(), "integrity")
+	})
+}
@@ -56,6 +56,16 @@ func parseOptions() (options *server.Options, err error) {
 	adminPassword := viper.GetString("admin-password")
 	maintenance := viper.GetBool("maintenance")
 	signingKey := viper.GetString("signingKey")
+	retiredSigningKeys := viper.GetStringSlice("retired-signing-keys")
+	stateSigningInterval := viper.GetDuration("state-signing-interval")
+	databaseSettingsFile := viper.GetString("database-settings-file")
+	tenantsFile := viper.GetString("tenants-file")
+	autoCompactionInterval := viper.GetDuration("auto-compaction-interval")
+	multiDBTxRetryInterval := viper.GetDuration("multidb-tx-retry-interval")
+	memoryBudget := viper.GetInt64("memory-budget")
+	scripting := viper.GetBool("scripting")
+	grpcReflection := viper.GetBool("grpc-reflection")
+	grpcWeb := viper.GetBool("grpc-web")
 	synced := viper.GetBool("synced")
 	tokenExpTime := viper.GetInt("token-expiry-time")
 
@@ -88,6 +98,25 @@ func parseOptions() (options *server.Options, err error) {
 		WithMaxSessionAgeTime(viper.GetDuration("max-session-age-time")).
 		WithTimeout(viper.GetDuration("session-timeout"))
 
+	acmeOptions := server.DefaultACMEOptions().
+		WithDomains(viper.GetStringSlice("acme-domains")).
+		WithEmail(viper.GetString("acme-email")).
+		WithCacheDir(viper.GetString("acme-cache-dir")).
+		WithHTTPChallengeAddress(viper.GetString("acme-http-challenge-address")).
+		WithDirectoryURL(viper.GetString("acme-directory-url"))
+
+	metricsOptions := (&server.MetricsOptions{}).
+		WithUsername(viper.GetString("metrics-username")).
+		WithPassword(viper.GetString("metrics-password")).
+		WithClientCAFile(viper.GetString("metrics-client-ca")).
+		WithGroups(viper.GetStringSlice("metrics-groups")).
+		WithAggregateDatabases(viper.GetBool("metrics-aggregate-databases"))
+
+	networkRulesOptions := (&server.NetworkRulesOptions{}).
+		WithAllowedNetworks(viper.GetStringSlice("allowed-networks")).
+		WithDeniedNetworks(viper.GetStringSlice("denied-networks")).
+		WithDatabaseAllowedNetworks(viper.GetStringMapStringSlice("database-allowed-networks"))
+
 	tlsConfig, err := setUpTLS(pkey, certificate, clientcas, mtls)
 	if err != nil {
 		return options, err
@@ -110,6 +139,17 @@ func parseOptions() (options *server.Options, err error) {
 		WithAdminPassword(adminPassword).
 		WithMaintenance(maintenance).
 		WithSigningKey(signingKey).
+		WithRetiredSigningKeys(retiredSigningKeys).
+		WithStateSigningInterval(stateSigningInterval).
+		WithDatabaseSettingsFile(databaseSettingsFile).
+		WithTenantsFile(tenantsFile).
+		WithAutoCompactionInterval(autoCompactionInterval).
+		WithMultiDBTxRetryInterval(multiDBTxRetryInterval).
+		WithMemoryBudget(memoryBudget).
+		WithScripting(scripting).
+		WithGrpcReflection(grpcReflection).
+		WithGrpcWeb(grpcWeb).
+		WithGrpcWebAllowedOrigins(viper.GetStringSlice("grpc-web-allowed-origins")).
 		WithSynced(synced).
 		WithRemoteStorageOptions(remoteStorageOptions).
 		WithTokenExpiryTime(tokenExpTime).
@@ -117,7 +157,16 @@ func parseOptions() (options *server.Options, err error) {
 		WithWebServerPort(webServerPort).
 		WithPgsqlServer(pgsqlServer).
 		WithPgsqlServerPort(pgsqlServerPort).
-		WithSessionOptions(sessionOptions)
+		WithSessionOptions(sessionOptions).
+		WithACMEOptions(acmeOptions).
+		WithClientCertUsers(viper.GetStringMapString("client-cert-users")).
+		WithNetworkRulesOptions(networkRulesOptions).
+		WithMetricsOptions(metricsOptions).
+		WithSlowQueryThreshold(viper.GetDuration("slow-query-threshold")).
+		WithSlowQueryRecording(viper.GetBool("slow-query-recording")).
+		WithMaxRequestDuration(viper.GetDuration("max-request-duration")).
+		WithMaxResultRows(viper.GetInt("max-result-rows")).
+		WithMaxResultBytes(viper.GetInt("max-result-bytes"))
 
 	return options, nil
 }
@@ -0,0 +1,161 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package immudb
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/logger"
+	"github.com/spf13/cobra"
+)
+
+func (cl *Commandline) repairCmd() *cobra.Command {
+	rcmd := &cobra.Command{
+		Use:   "repair <dir> [database...]",
+		Short: "Repair an immudb data directory after a crash",
+		Long: `Detect and discard partially written data at the tail of the transaction, commit and value
+logs of the databases of an immudb data directory, so that they can be opened again after a crash.
+
+Commits are discarded, starting from the last one, until one whose transaction and values are
+fully written is found. The index is discarded when it contains discarded transactions, it is
+rebuilt when the server starts. Every discarded piece of data is reported.
+
+The server must not be running on the same data directory.`,
+		Example:       "immudb repair ./data defaultdb --dry-run",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dryRun, err := cmd.Flags().GetBool("dry-run")
+			if err != nil {
+				return err
+			}
+
+			return repairDataDir(cmd.OutOrStdout(), cmd.ErrOrStderr(), args[0], args[1:], dryRun)
+		},
+		Args: cobra.MinimumNArgs(1),
+	}
+	rcmd.Flags().Bool("dry-run", false, "report the data to be discarded without modifying the data directory")
+
+	return rcmd
+}
+
+func repairDataDir(out, errOut io.Writer, dir string, databases []string, dryRun bool) error {
+	// a single database directory may be repaired as well
+	_, err := os.Stat(filepath.Join(dir, "commit"))
+	if err == nil {
+		if len(databases) > 0 {
+			return fmt.Errorf("'%s' is a database directory, databases can not be specified", dir)
+		}
+
+		return repairDatabase(out, errOut, dir, filepath.Base(dir), dryRun)
+	}
+
+	all, err := dataDirDatabases(dir)
+	if err != nil {
+		return err
+	}
+	if len(all) == 0 {
+		return fmt.Errorf("no databases found in data directory '%s'", dir)
+	}
+
+	if len(databases) == 0 {
+		databases = all
+	} else {
+		for _, db := range databases {
+			found := false
+			for _, d := range all {
+				if d == db {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return fmt.Errorf("database '%s' not found in data directory '%s'", db, dir)
+			}
+		}
+	}
+
+	failed := 0
+
+	for i, db := range databases {
+		if i > 0 {
+			fmt.Fprintln(out)
+		}
+
+		err := repairDatabase(out, errOut, filepath.Join(dir, db), db, dryRun)
+		if err != nil {
+			fmt.Fprintf(out, "  error:        %v\n", err)
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d database(s) could not be repaired", failed)
+	}
+
+	return nil
+}
+
+func repairDatabase(out, errOut io.Writer, dir, name string, dryRun bool) error {
+	fmt.Fprintf(out, "database:       %s\n", name)
+	fmt.Fprintf(out, "  dir:          %s\n", dir)
+
+	opts := store.DefaultOptions().
+		WithReadOnly(dryRun).
+		WithLog(logger.NewSimpleLoggerWithLevel("immudb ", errOut, logger.LogError))
+
+	report, err := store.Repair(dir, opts)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "  committed tx: %d\n", report.CommittedTxID)
+
+	if !report.Repaired() {
+		fmt.Fprintf(out, "  nothing to repair\n")
+		return nil
+	}
+
+	discard := "discarded"
+	if dryRun {
+		discard = "to be discarded"
+	}
+
+	fmt.Fprintf(out, "  commit log:   %d bytes %s, %d commit(s) of partially written txs\n", report.DiscardedCommitLogBytes, discard, report.DiscardedTxs)
+	fmt.Fprintf(out, "  tx log:       %d bytes %s\n", report.DiscardedTxLogBytes, discard)
+
+	vLogs := make([]string, 0, len(report.DiscardedValueLogBytes))
+	for vLog := range report.DiscardedValueLogBytes {
+		vLogs = append(vLogs, vLog)
+	}
+	sort.Strings(vLogs)
+
+	for _, vLog := range vLogs {
+		fmt.Fprintf(out, "  %-13s %d bytes %s\n", vLog+":", report.DiscardedValueLogBytes[vLog], discard)
+	}
+
+	if report.IndexDiscarded {
+		fmt.Fprintf(out, "  index:        %s, it will be rebuilt on startup\n", discard)
+	}
+
+	return nil
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package immudb
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/server"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepairDataDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "repair")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	setupInspectDataDir(t, dir)
+
+	var out, errOut bytes.Buffer
+
+	err = repairDataDir(&out, &errOut, dir, nil, false)
+	require.NoError(t, err)
+	require.Contains(t, out.String(), "database:       systemdb")
+	require.Contains(t, out.String(), "nothing to repair")
+
+	txFile := filepath.Join(dir, server.DefaultDBName, "tx", "00000000.tx")
+	stat, err := os.Stat(txFile)
+	require.NoError(t, err)
+	require.NoError(t, os.Truncate(txFile, stat.Size()-1))
+
+	out.Reset()
+
+	err = repairDataDir(&out, &errOut, dir, []string{server.DefaultDBName}, true)
+	require.NoError(t, err)
+	require.Contains(t, out.String(), "committed tx: 2")
+	require.Contains(t, out.String(), "1 commit(s) of partially written txs")
+	require.Contains(t, out.String(), "to be discarded")
+
+	out.Reset()
+
+	err = repairDataDir(&out, &errOut, filepath.Join(dir, server.DefaultDBName), nil, false)
+	require.NoError(t, err)
+	require.Contains(t, out.String(), "database:       defaultdb")
+	require.Contains(t, out.String(), "index:        discarded, it will be rebuilt on startup")
+
+	// the index is rebuilt when the database is opened
+	st, err := store.Open(filepath.Join(dir, server.DefaultDBName), store.DefaultOptions())
+	require.NoError(t, err)
+	require.NoError(t, st.WaitForIndexingUpto(2, nil))
+	require.NoError(t, st.Close())
+
+	out.Reset()
+
+	err = inspectDataDir(&out, &errOut, dir, []string{server.DefaultDBName}, 0, true)
+	require.NoError(t, err)
+	require.Contains(t, out.String(), "integrity:    ok (2 txs verified)")
+
+	err = repairDataDir(&out, &errOut, dir, []string{"unknowndb"}, false)
+	require.Error(t, err)
+
+	err = repairDataDir(&out, &errOut, filepath.Join(dir, server.DefaultDBName), []string{"unknowndb"}, false)
+	require.Error(t, err)
+}
@@ -17,12 +17,13 @@ limitations under the License.
 package immudb
 
 import (
+	"os"
+
 	c "github.com/codenotary/immudb/cmd/helper"
 	"github.com/codenotary/immudb/pkg/logger"
 	"github.com/codenotary/immudb/pkg/server"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
-	daem "github.com/takama/daemon"
 )
 
 func (cl *Commandline) NewRootCmd(immudbServer server.ImmuServerIf) (*cobra.Command, error) {
@@ -92,11 +93,16 @@ func (cl *Commandline) Immudb(immudbServer server.ImmuServerIf) func(*cobra.Comm
 		if options, err = parseOptions(); err != nil {
 			return err
 		}
+		if errs := validateOptions(options); len(errs) > 0 {
+			return errs
+		}
 		immudbServer := immudbServer.WithOptions(options)
+		log := logger.NewSimpleLogger("immudb ", os.Stderr)
 		if options.Logfile != "" {
 			if flogger, file, err := logger.NewFileLogger("immudb ", options.Logfile); err == nil {
 				defer file.Close()
 				immudbServer.WithLogger(flogger)
+				log = flogger
 			} else {
 				c.QuitToStdErr(err)
 			}
@@ -107,21 +113,6 @@ func (cl *Commandline) Immudb(immudbServer server.ImmuServerIf) func(*cobra.Comm
 			}
 		}
 
-		var d daem.Daemon
-		if d, err = daem.New("immudb", "immudb", "immudb"); err != nil {
-			c.QuitToStdErr(err)
-		}
-
-		if err = immudbServer.Initialize(); err != nil {
-			return err
-		}
-
-		service := server.Service{
-			ImmuServerIf: immudbServer,
-		}
-
-		d.Run(service)
-
-		return nil
+		return runService(immudbServer, log)
 	}
 }
@@ -0,0 +1,52 @@
+// +build !windows
+
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package immudb
+
+import (
+	c "github.com/codenotary/immudb/cmd/helper"
+	"github.com/codenotary/immudb/cmd/sservice"
+	"github.com/codenotary/immudb/pkg/logger"
+	"github.com/codenotary/immudb/pkg/server"
+	daem "github.com/takama/daemon"
+)
+
+// runService initializes the server and runs it until it's stopped. Readiness and
+// watchdog pings are notified to systemd when immudb runs as a Type=notify unit
+func runService(immudbServer server.ImmuServerIf, log logger.Logger) error {
+	d, err := daem.New("immudb", "immudb", "immudb")
+	if err != nil {
+		c.QuitToStdErr(err)
+	}
+
+	if err = immudbServer.Initialize(); err != nil {
+		return err
+	}
+
+	service := server.Service{
+		ImmuServerIf: immudbServer,
+	}
+
+	if notifier := sservice.NewSystemdNotifier(log); notifier != nil {
+		service.Notifier = notifier
+	}
+
+	d.Run(service)
+
+	return nil
+}
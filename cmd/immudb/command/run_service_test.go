@@ -0,0 +1,77 @@
+// +build linux darwin
+
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package immudb
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/codenotary/immudb/cmd/immudb/command/service/servicetest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImmudbNotifiesSystemd(t *testing.T) {
+	dir, err := ioutil.TempDir("", "immudb_notify")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	socket := filepath.Join(dir, "notify.sock")
+
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socket, Net: "unixgram"})
+	require.NoError(t, err)
+	defer conn.Close()
+
+	os.Setenv("NOTIFY_SOCKET", socket)
+	defer os.Unsetenv("NOTIFY_SOCKET")
+
+	started := false
+
+	s := servicetest.NewDefaultImmuServerMock()
+	s.StartF = func() error {
+		started = true
+		return nil
+	}
+
+	// an explicit config file keeps the command independent from configurations set by other tests
+	cfgFile := filepath.Join(dir, "immudb.toml")
+	require.NoError(t, ioutil.WriteFile(cfgFile, nil, 0644))
+
+	cl := Commandline{}
+	cmd, err := cl.NewRootCmd(s)
+	require.NoError(t, err)
+
+	cmd.SetArgs([]string{"--config", cfgFile})
+
+	err = cmd.Execute()
+	require.NoError(t, err)
+	require.True(t, started)
+
+	for _, expected := range []string{"READY=1", "STOPPING=1"} {
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+
+		b := make([]byte, 256)
+		n, err := conn.Read(b)
+		require.NoError(t, err)
+		require.Equal(t, expected, string(b[:n]))
+	}
+}
@@ -0,0 +1,140 @@
+// +build windows
+
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package immudb
+
+import (
+	"time"
+
+	"github.com/codenotary/immudb/pkg/logger"
+	"github.com/codenotary/immudb/pkg/server"
+	"golang.org/x/sys/windows/svc"
+)
+
+const windowsServiceName = "immudb"
+
+// startPendingInterval is how often the service control manager is told the service
+// is still starting, opening databases may take longer than it waits by default
+const startPendingInterval = 2 * time.Second
+const stopWaitHint = 30 * time.Second
+
+// runService runs the server as a native Windows service when started by the service
+// control manager, otherwise it's initialized and run until it's stopped
+func runService(immudbServer server.ImmuServerIf, log logger.Logger) error {
+	isService, err := svc.IsWindowsService()
+	if err != nil {
+		return err
+	}
+
+	if !isService {
+		if err = immudbServer.Initialize(); err != nil {
+			return err
+		}
+
+		server.Service{ImmuServerIf: immudbServer}.Run()
+
+		return nil
+	}
+
+	return svc.Run(windowsServiceName, &windowsService{
+		server: immudbServer,
+		log:    log,
+	})
+}
+
+// windowsService reports the server state to the service control manager: the service is
+// running once the server is initialized and stopped once the server is
+type windowsService struct {
+	server server.ImmuServerIf
+	log    logger.Logger
+}
+
+func (ws *windowsService) Execute(args []string, r <-chan svc.ChangeRequest, changes chan<- svc.Status) (bool, uint32) {
+	const accepted = svc.AcceptStop | svc.AcceptShutdown
+
+	checkpoint := uint32(0)
+	startPending := func() svc.Status {
+		checkpoint++
+		return svc.Status{
+			State:      svc.StartPending,
+			CheckPoint: checkpoint,
+			WaitHint:   uint32(2 * startPendingInterval / time.Millisecond),
+		}
+	}
+
+	changes <- startPending()
+
+	initialized := make(chan error, 1)
+	go func() {
+		initialized <- ws.server.Initialize()
+	}()
+
+	ticker := time.NewTicker(startPendingInterval)
+	defer ticker.Stop()
+
+initializing:
+	for {
+		select {
+		case err := <-initialized:
+			if err != nil {
+				ws.log.Errorf("immudb service failed to start: %v", err)
+				return true, 1
+			}
+			break initializing
+		case <-ticker.C:
+			changes <- startPending()
+		case c := <-r:
+			if c.Cmd == svc.Interrogate {
+				changes <- c.CurrentStatus
+			}
+		}
+	}
+
+	stopped := make(chan error, 1)
+	go func() {
+		stopped <- ws.server.Start()
+	}()
+
+	changes <- svc.Status{State: svc.Running, Accepts: accepted}
+
+	for {
+		select {
+		case err := <-stopped:
+			if err != nil {
+				ws.log.Errorf("immudb service stopped due to error: %v", err)
+				return true, 1
+			}
+			return false, 0
+		case c := <-r:
+			switch c.Cmd {
+			case svc.Interrogate:
+				changes <- c.CurrentStatus
+			case svc.Stop, svc.Shutdown:
+				changes <- svc.Status{State: svc.StopPending, WaitHint: uint32(stopWaitHint / time.Millisecond)}
+
+				if err := ws.server.Stop(); err != nil {
+					ws.log.Errorf("immudb service stopped with error: %v", err)
+				}
+
+				<-stopped
+
+				return false, 0
+			}
+		}
+	}
+}
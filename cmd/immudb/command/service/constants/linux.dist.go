@@ -31,6 +31,8 @@ Requires={{.Dependencies}}
 After={{.Dependencies}}
 
 [Service]
+Type=notify
+WatchdogSec=60
 PIDFile=/var/lib/immudb/{{.Name}}.pid
 ExecStartPre=/bin/rm -f /var/lib/immudb/{{.Name}}.pid
 ExecStart={{.Path}} {{.Args}}
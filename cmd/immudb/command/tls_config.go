@@ -22,19 +22,24 @@ import (
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"os"
+
+	"github.com/codenotary/immudb/pkg/logger"
+	"github.com/codenotary/immudb/pkg/server"
 )
 
 func setUpTLS(pkey, cert, ca string, mtls bool) (*tls.Config, error) {
 	var c *tls.Config
 
 	if cert != "" && pkey != "" {
-		certs, err := tls.LoadX509KeyPair(cert, pkey)
+		// the certificate is reloaded when renewed, without restarting the server
+		reloader, err := server.NewCertReloader(cert, pkey, logger.NewSimpleLogger("immudb ", os.Stderr))
 		if err != nil {
 			return nil, errors.New(fmt.Sprintf("failed to read client certificate or private key: %v", err))
 		}
 		c = &tls.Config{
-			Certificates: []tls.Certificate{certs},
-			ClientAuth:   tls.VerifyClientCertIfGiven,
+			GetCertificate: reloader.GetCertificate,
+			ClientAuth:     tls.VerifyClientCertIfGiven,
 		}
 	}
 
@@ -0,0 +1,122 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sservice
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/logger"
+)
+
+// SystemdNotifier reports the service state to systemd when running as a Type=notify unit,
+// see https://www.freedesktop.org/software/systemd/man/sd_notify.html
+type SystemdNotifier struct {
+	socket           string
+	watchdogInterval time.Duration
+	log              logger.Logger
+
+	mutex    sync.Mutex
+	stopping bool
+	done     chan struct{}
+}
+
+// NewSystemdNotifier returns nil when the process was not started by systemd with notify access
+func NewSystemdNotifier(log logger.Logger) *SystemdNotifier {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return nil
+	}
+
+	return &SystemdNotifier{
+		socket:           socket,
+		watchdogInterval: watchdogInterval(),
+		log:              log,
+		done:             make(chan struct{}),
+	}
+}
+
+// watchdogInterval returns the interval systemd expects keep-alive pings within, 0 when the watchdog is disabled
+func watchdogInterval() time.Duration {
+	usec, err := strconv.ParseInt(os.Getenv("WATCHDOG_USEC"), 10, 64)
+	if err != nil || usec <= 0 {
+		return 0
+	}
+
+	// the watchdog may be meant for another process e.g. when immudb is spawned by a wrapper script
+	if pid := os.Getenv("WATCHDOG_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return 0
+	}
+
+	return time.Duration(usec) * time.Microsecond
+}
+
+// Ready reports the service is up, watchdog pings are sent from now on when the watchdog is enabled
+func (n *SystemdNotifier) Ready() {
+	n.notify("READY=1")
+
+	if n.watchdogInterval > 0 {
+		go n.watchdog()
+	}
+}
+
+// Stopping reports the service is shutting down and stops the watchdog pings
+func (n *SystemdNotifier) Stopping() {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	if n.stopping {
+		return
+	}
+
+	n.stopping = true
+	close(n.done)
+
+	n.notify("STOPPING=1")
+}
+
+func (n *SystemdNotifier) watchdog() {
+	// pings are sent twice per interval, as recommended by systemd, so a late one doesn't kill the service
+	ticker := time.NewTicker(n.watchdogInterval / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-n.done:
+			return
+		case <-ticker.C:
+			n.notify("WATCHDOG=1")
+		}
+	}
+}
+
+func (n *SystemdNotifier) notify(state string) {
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: n.socket, Net: "unixgram"})
+	if err != nil {
+		n.log.Warningf("unable to notify '%s' to systemd: %v", state, err)
+		return
+	}
+	defer conn.Close()
+
+	_, err = conn.Write([]byte(state))
+	if err != nil {
+		n.log.Warningf("unable to notify '%s' to systemd: %v", state, err)
+	}
+}
@@ -0,0 +1,130 @@
+// +build linux darwin
+
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sservice
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/logger"
+	"github.com/stretchr/testify/require"
+)
+
+func listenNotifySocket(t *testing.T) (*net.UnixConn, func()) {
+	dir, err := ioutil.TempDir("", "notify")
+	require.NoError(t, err)
+
+	socket := filepath.Join(dir, "notify.sock")
+
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socket, Net: "unixgram"})
+	require.NoError(t, err)
+
+	os.Setenv("NOTIFY_SOCKET", socket)
+
+	return conn, func() {
+		os.Unsetenv("NOTIFY_SOCKET")
+		conn.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func readNotification(t *testing.T, conn *net.UnixConn) string {
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+
+	b := make([]byte, 256)
+	n, err := conn.Read(b)
+	require.NoError(t, err)
+
+	return string(b[:n])
+}
+
+func TestSystemdNotifierNotStartedBySystemd(t *testing.T) {
+	os.Unsetenv("NOTIFY_SOCKET")
+	require.Nil(t, NewSystemdNotifier(logger.NewSimpleLogger("immudb ", os.Stderr)))
+}
+
+func TestSystemdNotifier(t *testing.T) {
+	conn, cleanup := listenNotifySocket(t)
+	defer cleanup()
+
+	os.Setenv("WATCHDOG_USEC", "100000")
+	defer os.Unsetenv("WATCHDOG_USEC")
+
+	n := NewSystemdNotifier(logger.NewSimpleLogger("immudb ", os.Stderr))
+	require.NotNil(t, n)
+	require.Equal(t, 100*time.Millisecond, n.watchdogInterval)
+
+	n.Ready()
+	require.Equal(t, "READY=1", readNotification(t, conn))
+	require.Equal(t, "WATCHDOG=1", readNotification(t, conn))
+	require.Equal(t, "WATCHDOG=1", readNotification(t, conn))
+
+	n.Stopping()
+	n.Stopping()
+
+	// pings sent before stopping may still be queued
+	for {
+		state := readNotification(t, conn)
+		if state != "WATCHDOG=1" {
+			require.Equal(t, "STOPPING=1", state)
+			break
+		}
+	}
+
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(200*time.Millisecond)))
+	_, err := conn.Read(make([]byte, 256))
+	require.Error(t, err)
+}
+
+func TestSystemdWatchdogInterval(t *testing.T) {
+	defer os.Unsetenv("WATCHDOG_USEC")
+	defer os.Unsetenv("WATCHDOG_PID")
+
+	os.Unsetenv("WATCHDOG_USEC")
+	require.Zero(t, watchdogInterval())
+
+	os.Setenv("WATCHDOG_USEC", "invalid")
+	require.Zero(t, watchdogInterval())
+
+	os.Setenv("WATCHDOG_USEC", "30000000")
+	require.Equal(t, 30*time.Second, watchdogInterval())
+
+	os.Setenv("WATCHDOG_PID", strconv.Itoa(os.Getpid()))
+	require.Equal(t, 30*time.Second, watchdogInterval())
+
+	os.Setenv("WATCHDOG_PID", strconv.Itoa(os.Getpid()+1))
+	require.Zero(t, watchdogInterval())
+}
+
+func TestSystemdNotifierUnreachableSocket(t *testing.T) {
+	os.Setenv("NOTIFY_SOCKET", "/nonexistent/notify.sock")
+	defer os.Unsetenv("NOTIFY_SOCKET")
+
+	n := NewSystemdNotifier(logger.NewSimpleLogger("immudb ", os.Stderr))
+	require.NotNil(t, n)
+
+	// failures are only logged, the service keeps running
+	n.Ready()
+	n.Stopping()
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package ahtree
+
+import "crypto/sha256"
+
+// Accumulator computes the root of a tree as data is appended to it, without storing the tree.
+// Only the roots of the largest perfect subtrees are kept, so memory is logarithmic in the tree size
+type Accumulator struct {
+	size uint64
+
+	// roots of the perfect subtrees from left to right, along with their heights
+	roots   [][sha256.Size]byte
+	heights []int
+}
+
+func NewAccumulator() *Accumulator {
+	return &Accumulator{}
+}
+
+func (a *Accumulator) Size() uint64 {
+	return a.size
+}
+
+// Append adds a new leaf, the same way AHtree.Append does
+func (a *Accumulator) Append(d []byte) {
+	b := make([]byte, 1+len(d))
+	b[0] = LeafPrefix
+	copy(b[1:], d)
+
+	h := sha256.Sum256(b)
+	height := 0
+
+	for len(a.roots) > 0 && a.heights[len(a.heights)-1] == height {
+		h = nodeHash(a.roots[len(a.roots)-1], h)
+		height++
+
+		a.roots = a.roots[:len(a.roots)-1]
+		a.heights = a.heights[:len(a.heights)-1]
+	}
+
+	a.roots = append(a.roots, h)
+	a.heights = append(a.heights, height)
+
+	a.size++
+}
+
+// Root returns the same root AHtree.Root returns after the same data is appended to it
+func (a *Accumulator) Root() (root [sha256.Size]byte, err error) {
+	if a.size == 0 {
+		return root, ErrEmptyTree
+	}
+
+	root = a.roots[len(a.roots)-1]
+
+	for i := len(a.roots) - 2; i >= 0; i-- {
+		root = nodeHash(a.roots[i], root)
+	}
+
+	return root, nil
+}
+
+func nodeHash(l, r [sha256.Size]byte) [sha256.Size]byte {
+	b := [1 + sha256.Size*2]byte{NodePrefix}
+	copy(b[1:], l[:])
+	copy(b[1+sha256.Size:], r[:])
+
+	return sha256.Sum256(b[:])
+}
@@ -0,0 +1,52 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package ahtree
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccumulator(t *testing.T) {
+	tree, err := Open("ahtree_accumulator_test", DefaultOptions().WithSynced(false))
+	require.NoError(t, err)
+	defer os.RemoveAll("ahtree_accumulator_test")
+	defer tree.Close()
+
+	acc := NewAccumulator()
+
+	_, err = acc.Root()
+	require.ErrorIs(t, err, ErrEmptyTree)
+
+	for i := 1; i <= 300; i++ {
+		p := []byte{byte(i), byte(i >> 8)}
+
+		_, _, err := tree.Append(p)
+		require.NoError(t, err)
+
+		acc.Append(p)
+		require.Equal(t, uint64(i), acc.Size())
+
+		_, expectedRoot, err := tree.Root()
+		require.NoError(t, err)
+
+		root, err := acc.Root()
+		require.NoError(t, err)
+		require.Equal(t, expectedRoot, root)
+	}
+}
@@ -39,6 +39,9 @@ var ErrEmptyTree = errors.New("empty tree")
 var ErrReadOnly = errors.New("cannot append when opened in read-only mode")
 var ErrUnexistentData = errors.New("attempt to read unexistent data")
 var ErrCannotResetToLargerSize = errors.New("can not reset the tree to a larger size")
+var ErrorCorruptedCheckpoint = errors.New("checkpoint log is corrupted")
+var ErrPrunedData = errors.New("data was pruned")
+var ErrPruningUnsupported = errors.New("pruning is not supported without a checkpoint log")
 
 const LeafPrefix = byte(0)
 const NodePrefix = byte(1)
@@ -59,9 +62,18 @@ type AHtree struct {
 	dLog appendable.Appendable
 	cLog appendable.Appendable
 
-	pLogSize int64
-	dLogSize int64
-	cLogSize int64
+	// optional, holds the checkpoints of the tree
+	cpLog appendable.Appendable
+
+	pLogSize  int64
+	dLogSize  int64
+	cLogSize  int64
+	cpLogSize int64
+
+	// data and digests appended before the checkpoint were pruned,
+	// only the digests of the subtrees covering them are kept in cpNodes
+	checkpoint uint64
+	cpNodes    map[uint64][sha256.Size]byte
 
 	pCache *cache.LRUCache
 	dCache *cache.LRUCache
@@ -81,7 +93,7 @@ func Open(path string, opts *Options) (*AHtree, error) {
 
 	finfo, err := os.Stat(path)
 	if err != nil {
-		if !os.IsNotExist(err) {
+		if !os.IsNotExist(err) || opts.readOnly {
 			return nil, err
 		}
 
@@ -129,10 +141,27 @@ func Open(path string, opts *Options) (*AHtree, error) {
 		return nil, err
 	}
 
-	return OpenWith(pLog, dLog, cLog, opts)
+	appendableOpts.WithFileExt("cp")
+	cpLog, err := appFactory(path, "checkpoint", appendableOpts)
+	if os.IsNotExist(err) && opts.readOnly {
+		// trees created before checkpoints were introduced were never pruned
+		cpLog, err = nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return OpenWithCheckpoints(pLog, dLog, cLog, cpLog, opts)
 }
 
+// OpenWith opens a tree without a checkpoint log, such tree can not be pruned
 func OpenWith(pLog, dLog, cLog appendable.Appendable, opts *Options) (*AHtree, error) {
+	return OpenWithCheckpoints(pLog, dLog, cLog, nil, opts)
+}
+
+// OpenWithCheckpoints opens a tree which can be pruned, checkpoints are kept in cpLog.
+// cpLog may be nil, in which case the tree is opened as with OpenWith
+func OpenWithCheckpoints(pLog, dLog, cLog, cpLog appendable.Appendable, opts *Options) (*AHtree, error) {
 	if !validOptions(opts) || pLog == nil || dLog == nil || cLog == nil {
 		return nil, ErrIllegalArguments
 	}
@@ -165,6 +194,7 @@ func OpenWith(pLog, dLog, cLog appendable.Appendable, opts *Options) (*AHtree, e
 		pLog:     pLog,
 		dLog:     dLog,
 		cLog:     cLog,
+		cpLog:    cpLog,
 		pLogSize: 0,
 		dLogSize: 0,
 		cLogSize: cLogSize,
@@ -173,6 +203,17 @@ func OpenWith(pLog, dLog, cLog appendable.Appendable, opts *Options) (*AHtree, e
 		readOnly: opts.readOnly,
 	}
 
+	if cpLog != nil {
+		err = t.loadCheckpoint()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if t.checkpoint > t.size() {
+		return nil, ErrorCorruptedCheckpoint
+	}
+
 	if cLogSize == 0 {
 		return t, nil
 	}
@@ -186,7 +227,7 @@ func OpenWith(pLog, dLog, cLog appendable.Appendable, opts *Options) (*AHtree, e
 	pOff := binary.BigEndian.Uint64(b[:])
 	pSize := binary.BigEndian.Uint32(b[offsetSize:])
 
-	t.pLogSize = int64(pOff) + int64(pSize)
+	t.pLogSize = int64(pOff) + szSize + int64(pSize)
 
 	pLogFileSize, err := pLog.Size()
 	if err != nil {
@@ -377,6 +418,10 @@ func (t *AHtree) ResetSize(newSize uint64) error {
 		return nil
 	}
 
+	if newSize < t.checkpoint {
+		return ErrPrunedData
+	}
+
 	cLogSize := int64(newSize * cLogEntrySize)
 	pLogSize := int64(0)
 	dLogSize := int64(0)
@@ -391,7 +436,7 @@ func (t *AHtree) ResetSize(newSize uint64) error {
 		pOff := binary.BigEndian.Uint64(b[:])
 		pSize := binary.BigEndian.Uint32(b[offsetSize:])
 
-		pLogSize = int64(pOff) + int64(pSize)
+		pLogSize = int64(pOff) + szSize + int64(pSize)
 
 		pLogFileSize, err := t.pLog.Size()
 		if err != nil {
@@ -434,6 +479,14 @@ func (t *AHtree) node(n uint64, l int) (h [sha256.Size]byte, err error) {
 }
 
 func (t *AHtree) nodeAt(i uint64) (h [sha256.Size]byte, err error) {
+	if t.checkpoint > 1 && i < nodesUntil(t.checkpoint) {
+		h, ok := t.cpNodes[i]
+		if !ok {
+			return h, ErrPrunedData
+		}
+		return h, nil
+	}
+
 	v, err := t.dCache.Get(i)
 
 	if err == nil {
@@ -511,6 +564,10 @@ func (t *AHtree) InclusionProof(i, j uint64) (p [][sha256.Size]byte, err error)
 		return nil, ErrUnexistentData
 	}
 
+	if i < t.checkpoint {
+		return nil, ErrPrunedData
+	}
+
 	return t.inclusionProof(i, j, bits.Len64(j-1))
 }
 
@@ -565,6 +622,10 @@ func (t *AHtree) ConsistencyProof(i, j uint64) (p [][sha256.Size]byte, err error
 		return nil, ErrUnexistentData
 	}
 
+	if i < t.checkpoint {
+		return nil, ErrPrunedData
+	}
+
 	return t.consistencyProof(i, j, bits.Len64(j-1))
 }
 
@@ -659,6 +720,10 @@ func (t *AHtree) DataAt(n uint64) ([]byte, error) {
 		return nil, ErrUnexistentData
 	}
 
+	if n < t.checkpoint {
+		return nil, ErrPrunedData
+	}
+
 	v, err := t.pCache.Get(n)
 
 	if err == nil {
@@ -732,6 +797,11 @@ func (t *AHtree) rootAt(n uint64) (r [sha256.Size]byte, err error) {
 		return
 	}
 
+	if n < t.checkpoint {
+		err = ErrPrunedData
+		return
+	}
+
 	return t.nodeAt(nodesUntil(n) + uint64(levelsAt(n)))
 }
 
@@ -790,5 +860,10 @@ func (t *AHtree) Close() error {
 	err = t.cLog.Close()
 	merrors.Append(err)
 
+	if t.cpLog != nil {
+		err = t.cpLog.Close()
+		merrors.Append(err)
+	}
+
 	return merrors.Reduce()
 }
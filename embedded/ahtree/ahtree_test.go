@@ -298,7 +298,7 @@ func TestEdgeCases(t *testing.T) {
 			return cLogEntrySize, nil
 		}
 		pLog.SizeFn = func() (int64, error) {
-			return 8, nil
+			return szSize + 8, nil
 		}
 		dLog.SizeFn = func() (int64, error) {
 			return 0, nil
@@ -744,6 +744,47 @@ func TestReOpenningImmudbStore(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestAppendAfterReopening(t *testing.T) {
+	defer os.RemoveAll("ahtree_test")
+
+	tree, err := Open("ahtree_test", DefaultOptions().WithSynced(false))
+	require.NoError(t, err)
+
+	_, _, err = tree.Append([]byte("first"))
+	require.NoError(t, err)
+
+	err = tree.Close()
+	require.NoError(t, err)
+
+	tree, err = Open("ahtree_test", DefaultOptions().WithSynced(false))
+	require.NoError(t, err)
+
+	// appending after reopening must not overwrite previously appended data
+	_, _, err = tree.Append([]byte("second"))
+	require.NoError(t, err)
+
+	err = tree.ResetSize(1)
+	require.NoError(t, err)
+
+	_, _, err = tree.Append([]byte("third"))
+	require.NoError(t, err)
+
+	err = tree.Close()
+	require.NoError(t, err)
+
+	tree, err = Open("ahtree_test", DefaultOptions().WithSynced(false))
+	require.NoError(t, err)
+
+	for i, expected := range []string{"first", "third"} {
+		p, err := tree.DataAt(uint64(i + 1))
+		require.NoError(t, err)
+		require.Equal(t, []byte(expected), p)
+	}
+
+	err = tree.Close()
+	require.NoError(t, err)
+}
+
 func TestReset(t *testing.T) {
 	path, err := ioutil.TempDir("", "ahtree_test_reset")
 	require.NoError(t, err)
@@ -878,7 +919,7 @@ func TestResetCornerCases(t *testing.T) {
 
 	t.Run("should fail on cLog read error", func(t *testing.T) {
 		injectedErr := errors.New("injected error")
-		pLog := appendableFromBuffer(nil)
+		pLog := appendableFromBuffer(make([]byte, szSize))
 		dLog := appendableFromBuffer(make([]byte, 3*sha256.Size))
 		cLog := appendableFromBuffer(make([]byte, 12*2))
 		cLog.ReadAtFn = func(bs []byte, off int64) (int, error) {
@@ -900,7 +941,7 @@ func TestResetCornerCases(t *testing.T) {
 
 	t.Run("should fail on getting pLogSize", func(t *testing.T) {
 		injectedErr := errors.New("injected error")
-		pLog := appendableFromBuffer(nil)
+		pLog := appendableFromBuffer(make([]byte, szSize))
 		dLog := appendableFromBuffer(make([]byte, 3*sha256.Size))
 		cLog := appendableFromBuffer(make([]byte, 12*2))
 		tree, err := OpenWith(pLog, dLog, cLog, DefaultOptions())
@@ -916,7 +957,7 @@ func TestResetCornerCases(t *testing.T) {
 	})
 
 	t.Run("should fail on corrupted older cLog entries", func(t *testing.T) {
-		pLog := appendableFromBuffer(nil)
+		pLog := appendableFromBuffer(make([]byte, szSize))
 		dLog := appendableFromBuffer(make([]byte, 3*sha256.Size))
 		cLog := appendableFromBuffer([]byte{
 			1, 1, 1, 1, 1, 1, 1, 1, 0, 0, 0, 0, // Corrupted entry, offset way outside pLog size
@@ -934,7 +975,7 @@ func TestResetCornerCases(t *testing.T) {
 
 	t.Run("should fail on dLog size error", func(t *testing.T) {
 		injectedErr := errors.New("injected error")
-		pLog := appendableFromBuffer(nil)
+		pLog := appendableFromBuffer(make([]byte, szSize))
 		dLog := appendableFromBuffer(make([]byte, 3*sha256.Size))
 		cLog := appendableFromBuffer(make([]byte, 2*12))
 		tree, err := OpenWith(pLog, dLog, cLog, DefaultOptions())
@@ -950,7 +991,7 @@ func TestResetCornerCases(t *testing.T) {
 	})
 
 	t.Run("should fail on incorrect dlog size", func(t *testing.T) {
-		pLog := appendableFromBuffer(nil)
+		pLog := appendableFromBuffer(make([]byte, szSize))
 		dLog := appendableFromBuffer(make([]byte, 3*sha256.Size))
 		cLog := appendableFromBuffer(make([]byte, 2*12))
 		tree, err := OpenWith(pLog, dLog, cLog, DefaultOptions())
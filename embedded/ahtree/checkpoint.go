@@ -0,0 +1,211 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package ahtree
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"math/bits"
+)
+
+// a checkpoint entry is made of its size and the number of nodes, followed by the index and digest of each node
+const cpHeaderSize = 8 + 4
+const cpNodeSize = 8 + sha256.Size
+
+// Prune checkpoints the tree at size n, discarding the data and digests appended before the n-th leaf.
+// Only the digests of the perfect subtrees covering the first n-1 leaves are kept, which is enough to
+// provide roots and proofs from size n onwards, including consistency proofs from the checkpoint itself.
+// Pruning never goes back, and space is reclaimed as whole files get unused
+func (t *AHtree) Prune(n uint64) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.closed {
+		return ErrAlreadyClosed
+	}
+
+	if t.readOnly {
+		return ErrReadOnly
+	}
+
+	if t.cpLog == nil {
+		return ErrPruningUnsupported
+	}
+
+	if n == 0 || n > t.size() {
+		return ErrIllegalArguments
+	}
+
+	if n == 1 || n <= t.checkpoint {
+		return nil
+	}
+
+	// roots of the perfect subtrees covering the first n-1 leaves, from left to right
+	var cpNodes []uint64
+	k := uint64(0)
+
+	for l := bits.Len64(n-1) - 1; l >= 0; l-- {
+		if (n-1)&(1<<l) == 0 {
+			continue
+		}
+
+		k += 1 << l
+		cpNodes = append(cpNodes, nodesUntil(k)+uint64(l))
+	}
+
+	entry := make([]byte, cpHeaderSize+len(cpNodes)*cpNodeSize)
+	binary.BigEndian.PutUint64(entry, n)
+	binary.BigEndian.PutUint32(entry[8:], uint32(len(cpNodes)))
+
+	nodes := make(map[uint64][sha256.Size]byte, len(cpNodes))
+
+	for i, ni := range cpNodes {
+		h, err := t.nodeAt(ni)
+		if err != nil {
+			return err
+		}
+
+		nodes[ni] = h
+
+		off := cpHeaderSize + i*cpNodeSize
+		binary.BigEndian.PutUint64(entry[off:], ni)
+		copy(entry[off+8:], h[:])
+	}
+
+	var b [cLogEntrySize]byte
+	_, err := t.cLog.ReadAt(b[:], int64((n-1)*cLogEntrySize))
+	if err != nil {
+		return err
+	}
+
+	pOff := binary.BigEndian.Uint64(b[:])
+
+	// the tree must be durable up to the checkpoint before anything is discarded
+	err = t.pLog.Sync()
+	if err != nil {
+		return err
+	}
+
+	err = t.dLog.Sync()
+	if err != nil {
+		return err
+	}
+
+	err = t.cLog.Sync()
+	if err != nil {
+		return err
+	}
+
+	// will overwrite a partially written checkpoint
+	err = t.cpLog.SetOffset(t.cpLogSize)
+	if err != nil {
+		return err
+	}
+
+	_, _, err = t.cpLog.Append(entry)
+	if err != nil {
+		return err
+	}
+
+	err = t.cpLog.Flush()
+	if err != nil {
+		return err
+	}
+
+	err = t.cpLog.Sync()
+	if err != nil {
+		return err
+	}
+
+	t.cpLogSize += int64(len(entry))
+	t.checkpoint = n
+	t.cpNodes = nodes
+
+	err = t.pLog.DiscardUpto(int64(pOff))
+	if err != nil {
+		return err
+	}
+
+	return t.dLog.DiscardUpto(int64(nodesUntil(n) * sha256.Size))
+}
+
+// Checkpoint returns the size at which the tree was last pruned, 0 if it was never pruned.
+// Data, roots and proofs are available from this size onwards
+func (t *AHtree) Checkpoint() uint64 {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	return t.checkpoint
+}
+
+// loadCheckpoint reads the last checkpoint, a partially written one is ignored
+func (t *AHtree) loadCheckpoint() error {
+	cpLogSize, err := t.cpLog.Size()
+	if err != nil {
+		return err
+	}
+
+	var off int64
+
+	for off+cpHeaderSize <= cpLogSize {
+		var hdr [cpHeaderSize]byte
+		_, err = t.cpLog.ReadAt(hdr[:], off)
+		if err != nil {
+			return err
+		}
+
+		n := binary.BigEndian.Uint64(hdr[:])
+		count := binary.BigEndian.Uint32(hdr[8:])
+
+		entrySize := cpHeaderSize + int64(count)*cpNodeSize
+
+		if off+entrySize > cpLogSize {
+			break
+		}
+
+		if n <= t.checkpoint {
+			return ErrorCorruptedCheckpoint
+		}
+
+		nodes := make(map[uint64][sha256.Size]byte, count)
+
+		if count > 0 {
+			b := make([]byte, int64(count)*cpNodeSize)
+			_, err = t.cpLog.ReadAt(b, off+cpHeaderSize)
+			if err != nil {
+				return err
+			}
+
+			for i := 0; i < int(count); i++ {
+				var h [sha256.Size]byte
+
+				ni := binary.BigEndian.Uint64(b[i*cpNodeSize:])
+				copy(h[:], b[i*cpNodeSize+8:])
+
+				nodes[ni] = h
+			}
+		}
+
+		t.checkpoint = n
+		t.cpNodes = nodes
+
+		off += entrySize
+	}
+
+	t.cpLogSize = off
+
+	return nil
+}
@@ -0,0 +1,199 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package ahtree
+
+import (
+	"crypto/sha256"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/codenotary/immudb/embedded/appendable/multiapp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrune(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ahtree_prune_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	opts := DefaultOptions().WithSynced(false).WithFileSize(256)
+
+	tree, err := Open(dir, opts)
+	require.NoError(t, err)
+
+	N := uint64(200)
+
+	roots := make([][sha256.Size]byte, N+1)
+
+	for i := uint64(1); i <= N; i++ {
+		_, roots[i], err = tree.Append([]byte{byte(i)})
+		require.NoError(t, err)
+	}
+
+	require.Zero(t, tree.Checkpoint())
+
+	countFiles := func(subPath string) int {
+		files, err := ioutil.ReadDir(filepath.Join(dir, subPath))
+		require.NoError(t, err)
+		return len(files)
+	}
+
+	dataFiles := countFiles("data")
+	treeFiles := countFiles("tree")
+
+	checkProofs := func(tree *AHtree, cp uint64) {
+		for i := cp; i <= N; i++ {
+			leaf, err := tree.DataAt(i)
+			require.NoError(t, err)
+			require.Equal(t, []byte{byte(i)}, leaf)
+
+			root, err := tree.RootAt(i)
+			require.NoError(t, err)
+			require.Equal(t, roots[i], root)
+
+			h := sha256.Sum256([]byte{LeafPrefix, byte(i)})
+
+			for j := i; j <= N; j++ {
+				iproof, err := tree.InclusionProof(i, j)
+				require.NoError(t, err)
+				require.True(t, VerifyInclusion(iproof, i, j, h, roots[j]))
+
+				cproof, err := tree.ConsistencyProof(i, j)
+				require.NoError(t, err)
+				require.True(t, VerifyConsistency(cproof, i, j, roots[i], roots[j]))
+			}
+		}
+
+		_, err := tree.DataAt(cp - 1)
+		require.ErrorIs(t, err, ErrPrunedData)
+
+		_, err = tree.RootAt(cp - 1)
+		require.ErrorIs(t, err, ErrPrunedData)
+
+		_, err = tree.InclusionProof(cp-1, N)
+		require.ErrorIs(t, err, ErrPrunedData)
+
+		_, err = tree.ConsistencyProof(cp-1, N)
+		require.ErrorIs(t, err, ErrPrunedData)
+	}
+
+	for _, cp := range []uint64{37, 64, 129} {
+		err = tree.Prune(cp)
+		require.NoError(t, err)
+		require.Equal(t, cp, tree.Checkpoint())
+
+		checkProofs(tree, cp)
+	}
+
+	require.Less(t, countFiles("data"), dataFiles)
+	require.Less(t, countFiles("tree"), treeFiles)
+
+	// pruning never goes back
+	err = tree.Prune(100)
+	require.NoError(t, err)
+	require.Equal(t, uint64(129), tree.Checkpoint())
+
+	err = tree.ResetSize(128)
+	require.ErrorIs(t, err, ErrPrunedData)
+
+	err = tree.Close()
+	require.NoError(t, err)
+
+	tree, err = Open(dir, opts.WithReadOnly(true))
+	require.NoError(t, err)
+	require.Equal(t, uint64(129), tree.Checkpoint())
+
+	checkProofs(tree, 129)
+
+	err = tree.Prune(150)
+	require.ErrorIs(t, err, ErrReadOnly)
+
+	err = tree.Close()
+	require.NoError(t, err)
+
+	t.Run("a partially written checkpoint is ignored", func(t *testing.T) {
+		appOpts := multiapp.DefaultOptions().WithFileExt("cp")
+
+		cpLog, err := multiapp.Open(filepath.Join(dir, "checkpoint"), appOpts)
+		require.NoError(t, err)
+
+		_, _, err = cpLog.Append([]byte{0, 0, 0, 0, 0, 0, 0, 1, 0, 0, 0, 1})
+		require.NoError(t, err)
+
+		err = cpLog.Close()
+		require.NoError(t, err)
+
+		tree, err = Open(dir, opts.WithReadOnly(false))
+		require.NoError(t, err)
+		require.Equal(t, uint64(129), tree.Checkpoint())
+
+		_, _, err = tree.Append([]byte{byte(N)})
+		require.NoError(t, err)
+
+		err = tree.Prune(N)
+		require.NoError(t, err)
+
+		err = tree.Close()
+		require.NoError(t, err)
+
+		tree, err = Open(dir, opts)
+		require.NoError(t, err)
+		require.Equal(t, N, tree.Checkpoint())
+
+		err = tree.Close()
+		require.NoError(t, err)
+	})
+}
+
+func TestPruneEdgeCases(t *testing.T) {
+	tree, err := OpenWith(appendableFromBuffer(nil), appendableFromBuffer(nil), appendableFromBuffer(nil), DefaultOptions())
+	require.NoError(t, err)
+
+	err = tree.Prune(1)
+	require.ErrorIs(t, err, ErrPruningUnsupported)
+
+	dir, err := ioutil.TempDir("", "ahtree_prune_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	tree, err = Open(dir, DefaultOptions().WithSynced(false))
+	require.NoError(t, err)
+
+	err = tree.Prune(1)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, _, err = tree.Append([]byte{1})
+	require.NoError(t, err)
+
+	err = tree.Prune(0)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	err = tree.Prune(2)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	// nothing to prune
+	err = tree.Prune(1)
+	require.NoError(t, err)
+	require.Zero(t, tree.Checkpoint())
+
+	err = tree.Close()
+	require.NoError(t, err)
+
+	err = tree.Prune(1)
+	require.ErrorIs(t, err, ErrAlreadyClosed)
+}
@@ -0,0 +1,206 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package faulty provides an appendable injecting faults into the calls to a wrapped appendable:
+// short writes, flush and sync failures and latency, drawn from a deterministic seed.
+// It's meant to exercise error handling and crash recovery paths in tests.
+package faulty
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/codenotary/immudb/embedded/appendable"
+)
+
+var ErrIllegalArguments = errors.New("illegal arguments")
+var ErrInjectedFault = errors.New("injected fault")
+var ErrShortWrite = fmt.Errorf("%w: short write", ErrInjectedFault)
+var ErrFlushFailure = fmt.Errorf("%w: flush failure", ErrInjectedFault)
+var ErrSyncFailure = fmt.Errorf("%w: sync failure", ErrInjectedFault)
+var ErrCrashed = errors.New("appendable crashed")
+
+// Faults counts the faults injected by an appendable
+type Faults struct {
+	ShortWrites   int
+	FlushFailures int
+	SyncFailures  int
+	Delays        int
+}
+
+// Appendable wraps an appendable injecting faults into appends, flushes and syncs.
+// Reads are never faulted.
+type Appendable struct {
+	appendable.Appendable
+
+	opts *Options
+
+	mutex   sync.Mutex
+	rnd     *rand.Rand
+	faults  Faults
+	crashed bool
+}
+
+func Wrap(app appendable.Appendable, opts *Options) (*Appendable, error) {
+	if app == nil || !opts.Valid() {
+		return nil, ErrIllegalArguments
+	}
+
+	return &Appendable{
+		Appendable: app,
+		opts:       opts,
+		rnd:        rand.New(rand.NewSource(opts.seed)),
+	}, nil
+}
+
+// Crash makes every later write fail, as if the process died at this point
+func (a *Appendable) Crash() {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	a.crashed = true
+}
+
+func (a *Appendable) Crashed() bool {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	return a.crashed
+}
+
+func (a *Appendable) Faults() Faults {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	return a.faults
+}
+
+// hit draws whether a fault with probability p is injected, the mutex must be held
+func (a *Appendable) hit(p float64) bool {
+	return p > 0 && a.rnd.Float64() < p
+}
+
+// delay sleeps for a random duration when latency is injected, the mutex must be held
+// so calls are delayed in order
+func (a *Appendable) delay() {
+	if !a.hit(a.opts.latencyProbability) || a.opts.maxLatency == 0 {
+		return
+	}
+
+	a.faults.Delays++
+
+	time.Sleep(time.Duration(a.rnd.Int63n(int64(a.opts.maxLatency))))
+}
+
+// fault records an injected fault, crashing the appendable when configured
+func (a *Appendable) fault(err error) error {
+	if a.opts.crashOnFault {
+		a.crashed = true
+	}
+	return err
+}
+
+func (a *Appendable) SetOffset(off int64) error {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if a.crashed {
+		return ErrCrashed
+	}
+
+	return a.Appendable.SetOffset(off)
+}
+
+func (a *Appendable) DiscardUpto(off int64) error {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if a.crashed {
+		return ErrCrashed
+	}
+
+	return a.Appendable.DiscardUpto(off)
+}
+
+// Append may write only a prefix of bs, returning ErrShortWrite along with the number of bytes written
+func (a *Appendable) Append(bs []byte) (off int64, n int, err error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if a.crashed {
+		return 0, 0, ErrCrashed
+	}
+
+	a.delay()
+
+	if len(bs) > 0 && a.hit(a.opts.shortWriteProbability) {
+		a.faults.ShortWrites++
+
+		short := a.rnd.Intn(len(bs))
+
+		off = a.Appendable.Offset()
+
+		if short > 0 {
+			off, n, err = a.Appendable.Append(bs[:short])
+			if err != nil {
+				return off, n, err
+			}
+		}
+
+		return off, n, a.fault(ErrShortWrite)
+	}
+
+	return a.Appendable.Append(bs)
+}
+
+func (a *Appendable) Flush() error {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if a.crashed {
+		return ErrCrashed
+	}
+
+	a.delay()
+
+	if a.hit(a.opts.flushFailureProbability) {
+		a.faults.FlushFailures++
+		return a.fault(ErrFlushFailure)
+	}
+
+	return a.Appendable.Flush()
+}
+
+func (a *Appendable) Sync() error {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if a.crashed {
+		return ErrCrashed
+	}
+
+	a.delay()
+
+	if a.hit(a.opts.syncFailureProbability) {
+		a.faults.SyncFailures++
+		return a.fault(ErrSyncFailure)
+	}
+
+	return a.Appendable.Sync()
+}
@@ -0,0 +1,163 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package faulty
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/codenotary/immudb/embedded/appendable/multiapp"
+	"github.com/stretchr/testify/require"
+)
+
+func openFaulty(t *testing.T, opts *Options) (*Appendable, func()) {
+	dir, err := ioutil.TempDir("", "faulty")
+	require.NoError(t, err)
+
+	app, err := multiapp.Open(dir, multiapp.DefaultOptions())
+	require.NoError(t, err)
+
+	fapp, err := Wrap(app, opts)
+	require.NoError(t, err)
+
+	return fapp, func() {
+		app.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestOptions(t *testing.T) {
+	require.False(t, (*Options)(nil).Valid())
+	require.True(t, DefaultOptions().Valid())
+	require.False(t, DefaultOptions().WithShortWriteProbability(1.1).Valid())
+	require.False(t, DefaultOptions().WithFlushFailureProbability(-0.1).Valid())
+	require.False(t, DefaultOptions().WithSyncFailureProbability(2).Valid())
+	require.False(t, DefaultOptions().WithLatency(0.5, -time.Second).Valid())
+
+	_, err := Wrap(nil, DefaultOptions())
+	require.ErrorIs(t, err, ErrIllegalArguments)
+}
+
+func TestNoFaults(t *testing.T) {
+	app, cleanup := openFaulty(t, DefaultOptions())
+	defer cleanup()
+
+	for i := 0; i < 100; i++ {
+		_, n, err := app.Append([]byte("immudb"))
+		require.NoError(t, err)
+		require.Equal(t, 6, n)
+
+		require.NoError(t, app.Flush())
+		require.NoError(t, app.Sync())
+	}
+
+	require.Equal(t, Faults{}, app.Faults())
+
+	b := make([]byte, 6)
+	_, err := app.ReadAt(b, 6*99)
+	require.NoError(t, err)
+	require.Equal(t, []byte("immudb"), b)
+}
+
+func TestDeterministicFaults(t *testing.T) {
+	opts := DefaultOptions().
+		WithSeed(7).
+		WithShortWriteProbability(0.2).
+		WithFlushFailureProbability(0.1).
+		WithSyncFailureProbability(0.1)
+
+	run := func() ([]error, Faults) {
+		app, cleanup := openFaulty(t, opts)
+		defer cleanup()
+
+		var errs []error
+
+		for i := 0; i < 100; i++ {
+			_, _, err := app.Append([]byte("immudb"))
+			errs = append(errs, err)
+			errs = append(errs, app.Flush())
+			errs = append(errs, app.Sync())
+		}
+
+		return errs, app.Faults()
+	}
+
+	errs1, faults1 := run()
+	errs2, faults2 := run()
+
+	require.Equal(t, errs1, errs2)
+	require.Equal(t, faults1, faults2)
+
+	require.NotZero(t, faults1.ShortWrites)
+	require.NotZero(t, faults1.FlushFailures)
+	require.NotZero(t, faults1.SyncFailures)
+
+	require.Contains(t, errs1, ErrShortWrite)
+	require.Contains(t, errs1, ErrFlushFailure)
+	require.Contains(t, errs1, ErrSyncFailure)
+}
+
+func TestShortWrite(t *testing.T) {
+	app, cleanup := openFaulty(t, DefaultOptions().WithShortWriteProbability(1))
+	defer cleanup()
+
+	_, n, err := app.Append([]byte("immudb"))
+	require.ErrorIs(t, err, ErrShortWrite)
+	require.ErrorIs(t, err, ErrInjectedFault)
+	require.Less(t, n, 6)
+	require.Equal(t, int64(n), app.Offset())
+}
+
+func TestCrashOnFault(t *testing.T) {
+	app, cleanup := openFaulty(t, DefaultOptions().WithSyncFailureProbability(1).WithCrashOnFault(true))
+	defer cleanup()
+
+	_, _, err := app.Append([]byte("immudb"))
+	require.NoError(t, err)
+
+	require.False(t, app.Crashed())
+
+	err = app.Sync()
+	require.ErrorIs(t, err, ErrSyncFailure)
+	require.True(t, app.Crashed())
+
+	_, _, err = app.Append([]byte("immudb"))
+	require.ErrorIs(t, err, ErrCrashed)
+
+	require.ErrorIs(t, app.Flush(), ErrCrashed)
+	require.ErrorIs(t, app.Sync(), ErrCrashed)
+	require.ErrorIs(t, app.SetOffset(0), ErrCrashed)
+	require.ErrorIs(t, app.DiscardUpto(0), ErrCrashed)
+
+	require.Equal(t, int64(6), app.Offset())
+}
+
+func TestLatency(t *testing.T) {
+	app, cleanup := openFaulty(t, DefaultOptions().WithLatency(1, time.Millisecond))
+	defer cleanup()
+
+	for i := 0; i < 10; i++ {
+		_, _, err := app.Append([]byte("immudb"))
+		require.NoError(t, err)
+	}
+
+	require.Equal(t, 10, app.Faults().Delays)
+
+	app.Crash()
+	require.True(t, app.Crashed())
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package faulty
+
+import "time"
+
+// Options defines the faults injected by a faulty appendable, every probability is
+// checked on each call to the affected operation. No fault is injected by default.
+type Options struct {
+	seed int64
+
+	shortWriteProbability   float64
+	flushFailureProbability float64
+	syncFailureProbability  float64
+
+	latencyProbability float64
+	maxLatency         time.Duration
+
+	crashOnFault bool
+}
+
+func DefaultOptions() *Options {
+	return &Options{}
+}
+
+func (opts *Options) Valid() bool {
+	return opts != nil &&
+		validProbability(opts.shortWriteProbability) &&
+		validProbability(opts.flushFailureProbability) &&
+		validProbability(opts.syncFailureProbability) &&
+		validProbability(opts.latencyProbability) &&
+		opts.maxLatency >= 0
+}
+
+func validProbability(p float64) bool {
+	return p >= 0 && p <= 1
+}
+
+// WithSeed sets the seed faults are drawn from, appendables with the same seed and options
+// inject the same faults when receiving the same sequence of calls
+func (opts *Options) WithSeed(seed int64) *Options {
+	opts.seed = seed
+	return opts
+}
+
+// WithShortWriteProbability sets the probability of an append writing only part of the data
+func (opts *Options) WithShortWriteProbability(p float64) *Options {
+	opts.shortWriteProbability = p
+	return opts
+}
+
+// WithFlushFailureProbability sets the probability of a flush failing without flushing buffered data
+func (opts *Options) WithFlushFailureProbability(p float64) *Options {
+	opts.flushFailureProbability = p
+	return opts
+}
+
+// WithSyncFailureProbability sets the probability of a sync failing without syncing data
+func (opts *Options) WithSyncFailureProbability(p float64) *Options {
+	opts.syncFailureProbability = p
+	return opts
+}
+
+// WithLatency sets the probability of appends, flushes and syncs being delayed up to maxLatency
+func (opts *Options) WithLatency(p float64, maxLatency time.Duration) *Options {
+	opts.latencyProbability = p
+	opts.maxLatency = maxLatency
+	return opts
+}
+
+// WithCrashOnFault makes the appendable crash on the first injected fault, as a process
+// dying while writing: every later write fails, leaving data as it was at that point
+func (opts *Options) WithCrashOnFault(crashOnFault bool) *Options {
+	opts.crashOnFault = crashOnFault
+	return opts
+}
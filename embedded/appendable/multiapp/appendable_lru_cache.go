@@ -54,3 +54,18 @@ func (c appendableLRUCache) Apply(fun func(k int64, v appendable.Appendable) err
 		return fun(k.(int64), v.(appendable.Appendable))
 	})
 }
+
+func (c appendableLRUCache) Evict() (int64, appendable.Appendable, error) {
+	k, v, err := c.cache.Evict()
+	rkey, _ := k.(int64)
+	rvalue, _ := v.(appendable.Appendable)
+	return rkey, rvalue, err
+}
+
+func (c appendableLRUCache) Resize(size int) {
+	c.cache.Resize(size)
+}
+
+func (c appendableLRUCache) EntriesCount() int {
+	return c.cache.EntriesCount()
+}
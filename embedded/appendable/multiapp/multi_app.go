@@ -104,6 +104,11 @@ type MultiFileAppendable struct {
 	fileExt         string
 	readBufferSize  int
 	writeBufferSize int
+	offHeapBuffers  bool
+	mmapReads       bool
+	preallocateSize int
+	directIO        bool
+	checksums       bool
 
 	closed bool
 
@@ -149,8 +154,16 @@ func OpenWithHooks(path string, hooks MultiFileAppendableHooks, opts *Options) (
 		WithCompresionLevel(opts.compressionLevel).
 		WithReadBufferSize(opts.readBufferSize).
 		WithWriteBufferSize(opts.writeBufferSize).
+		WithOffHeapWriteBuffer(opts.offHeapBuffers).
+		WithMmapReads(opts.mmapReads).
+		WithDirectIO(opts.directIO).
+		WithChecksums(opts.checksums).
 		WithMetadata(m.Bytes())
 
+	if opts.preallocateFiles {
+		appendableOpts.WithPreallocateSize(opts.fileSize)
+	}
+
 	currApp, currAppID, err := hooks.OpenInitialAppendable(opts, appendableOpts)
 	if err != nil {
 		return nil, err
@@ -163,6 +176,11 @@ func OpenWithHooks(path string, hooks MultiFileAppendableHooks, opts *Options) (
 
 	fileSize, _ := appendable.NewMetadata(currApp.Metadata()).GetInt(metaFileSize)
 
+	var preallocateSize int
+	if opts.preallocateFiles {
+		preallocateSize = fileSize
+	}
+
 	return &MultiFileAppendable{
 		appendables:     appendableLRUCache{cache: cache},
 		currAppID:       currAppID,
@@ -175,6 +193,11 @@ func OpenWithHooks(path string, hooks MultiFileAppendableHooks, opts *Options) (
 		fileExt:         opts.fileExt,
 		readBufferSize:  opts.readBufferSize,
 		writeBufferSize: opts.writeBufferSize,
+		offHeapBuffers:  opts.offHeapBuffers,
+		mmapReads:       opts.mmapReads,
+		preallocateSize: preallocateSize,
+		directIO:        opts.directIO,
+		checksums:       opts.checksums,
 		closed:          false,
 		hooks:           hooks,
 	}, nil
@@ -355,6 +378,11 @@ func (mf *MultiFileAppendable) openAppendable(appname string, activeChunk bool)
 		WithFileMode(mf.fileMode).
 		WithReadBufferSize(mf.readBufferSize).
 		WithWriteBufferSize(mf.writeBufferSize).
+		WithOffHeapWriteBuffer(mf.offHeapBuffers).
+		WithMmapReads(mf.mmapReads).
+		WithPreallocateSize(mf.preallocateSize).
+		WithDirectIO(mf.directIO).
+		WithChecksums(mf.checksums).
 		WithCompressionFormat(mf.currApp.CompressionFormat()).
 		WithCompresionLevel(mf.currApp.CompressionLevel()).
 		WithMetadata(mf.currApp.Metadata())
@@ -414,6 +442,68 @@ func (mf *MultiFileAppendable) SetOffset(off int64) error {
 	return mf.currApp.SetOffset(off % int64(mf.fileSize))
 }
 
+// Truncate discards all the data beyond the given offset, chunks placed after it are removed
+func (mf *MultiFileAppendable) Truncate(off int64) error {
+	mf.mutex.Lock()
+	defer mf.mutex.Unlock()
+
+	if mf.closed {
+		return ErrAlreadyClosed
+	}
+
+	if mf.readOnly {
+		return ErrReadOnly
+	}
+
+	if off < 0 || off > mf.offset() {
+		return fmt.Errorf("%w: truncate beyond existent data boundaries", ErrIllegalArguments)
+	}
+
+	appID := appendableID(off, mf.fileSize)
+
+	if mf.currAppID != appID {
+		err := mf.currApp.Close()
+		if err != nil {
+			return err
+		}
+
+		for id := mf.currAppID; id >= appID; id-- {
+			app, err := mf.appendables.Pop(id)
+			if err == nil {
+				err = app.Close()
+			}
+			if err != nil && err != cache.ErrKeyNotFound {
+				return err
+			}
+
+			if id == appID {
+				break
+			}
+
+			appFile := filepath.Join(mf.path, appendableName(id, mf.fileExt))
+			err = os.Remove(appFile)
+			if err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+
+		app, err := mf.openAppendable(appendableName(appID, mf.fileExt), true)
+		if err != nil {
+			return err
+		}
+
+		mf.currAppID = appID
+		mf.currApp = app
+	}
+
+	app, ok := mf.currApp.(*singleapp.AppendableFile)
+	if !ok {
+		return fmt.Errorf("%w: chunk can not be truncated", ErrIllegalArguments)
+	}
+
+	return app.Truncate(off % int64(mf.fileSize))
+}
+
 func (mf *MultiFileAppendable) DiscardUpto(off int64) error {
 	mf.mutex.Lock()
 	defer mf.mutex.Unlock()
@@ -609,6 +699,38 @@ func (mf *MultiFileAppendable) Close() error {
 	return mf.currApp.Close()
 }
 
+// SetMaxOpenedFiles changes the number of files kept opened, closing the least recently used ones if needed
+func (mf *MultiFileAppendable) SetMaxOpenedFiles(maxOpenedFiles int) error {
+	if maxOpenedFiles < 1 {
+		return ErrIllegalArguments
+	}
+
+	mf.mutex.Lock()
+	defer mf.mutex.Unlock()
+
+	if mf.closed {
+		return ErrAlreadyClosed
+	}
+
+	for mf.appendables.EntriesCount() > maxOpenedFiles {
+		_, ejectedApp, err := mf.appendables.Evict()
+		if err != nil {
+			return err
+		}
+
+		metricsCacheEvicted.Inc()
+
+		err = ejectedApp.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	mf.appendables.Resize(maxOpenedFiles)
+
+	return nil
+}
+
 func (mf *MultiFileAppendable) CurrApp() (appendable.Appendable, int64) {
 	mf.mutex.Lock()
 	defer mf.mutex.Unlock()
@@ -368,3 +368,135 @@ func TestMultiAppDiscard(t *testing.T) {
 	err = a.Close()
 	require.NoError(t, err)
 }
+
+func TestMultiAppTruncate(t *testing.T) {
+	a, err := Open("testdata_truncate", DefaultOptions().WithFileSize(2))
+	defer os.RemoveAll("testdata_truncate")
+	require.NoError(t, err)
+
+	err = a.Truncate(1)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, _, err = a.Append([]byte{1, 2, 3})
+	require.NoError(t, err)
+
+	_, _, err = a.Append([]byte{4, 5, 6})
+	require.NoError(t, err)
+
+	err = a.Flush()
+	require.NoError(t, err)
+
+	// read from a previous chunk so to have it cached
+	bs := make([]byte, 1)
+	_, err = a.ReadAt(bs, 3)
+	require.NoError(t, err)
+
+	err = a.Truncate(3)
+	require.NoError(t, err)
+	require.Equal(t, int64(3), a.Offset())
+
+	sz, err := a.Size()
+	require.NoError(t, err)
+	require.Equal(t, int64(3), sz)
+
+	_, err = os.Stat(filepath.Join("testdata_truncate", appendableName(2, "aof")))
+	require.True(t, os.IsNotExist(err))
+
+	off, _, err := a.Append([]byte{7})
+	require.NoError(t, err)
+	require.Equal(t, int64(3), off)
+
+	err = a.Close()
+	require.NoError(t, err)
+
+	err = a.Truncate(0)
+	require.ErrorIs(t, err, ErrAlreadyClosed)
+
+	a, err = Open("testdata_truncate", DefaultOptions().WithReadOnly(true))
+	require.NoError(t, err)
+
+	bs = make([]byte, 4)
+	_, err = a.ReadAt(bs, 0)
+	require.NoError(t, err)
+	require.Equal(t, []byte{1, 2, 3, 7}, bs)
+
+	err = a.Truncate(0)
+	require.ErrorIs(t, err, ErrReadOnly)
+
+	err = a.Close()
+	require.NoError(t, err)
+}
+
+func TestMultiAppSetMaxOpenedFiles(t *testing.T) {
+	a, err := Open("testdata", DefaultOptions().WithFileSize(1).WithMaxOpenedFiles(5))
+	defer os.RemoveAll("testdata")
+	require.NoError(t, err)
+
+	_, n, err := a.Append([]byte{0, 1, 2, 3, 4, 5, 6, 7})
+	require.NoError(t, err)
+
+	err = a.Flush()
+	require.NoError(t, err)
+
+	b := make([]byte, n)
+	_, err = a.ReadAt(b, 0)
+	require.NoError(t, err)
+	require.Equal(t, 5, a.appendables.EntriesCount())
+
+	err = a.SetMaxOpenedFiles(0)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	err = a.SetMaxOpenedFiles(2)
+	require.NoError(t, err)
+	require.Equal(t, 2, a.appendables.EntriesCount())
+
+	_, err = a.ReadAt(b, 0)
+	require.NoError(t, err)
+	require.Equal(t, []byte{0, 1, 2, 3, 4, 5, 6, 7}, b)
+	require.Equal(t, 2, a.appendables.EntriesCount())
+
+	err = a.Close()
+	require.NoError(t, err)
+
+	err = a.SetMaxOpenedFiles(2)
+	require.ErrorIs(t, err, ErrAlreadyClosed)
+}
+
+func TestMultiAppPreallocatedFilesWithDirectIO(t *testing.T) {
+	opts := DefaultOptions().
+		WithFileSize(10000).
+		WithPreallocateFiles(true).
+		WithDirectIO(true)
+
+	a, err := Open("testdata_direct", opts)
+	defer os.RemoveAll("testdata_direct")
+	require.NoError(t, err)
+
+	data := make([]byte, 25000)
+	for i := range data {
+		data[i] = byte(i)
+	}
+
+	for i := 0; i < len(data); i += 1000 {
+		_, _, err = a.Append(data[i : i+1000])
+		require.NoError(t, err)
+	}
+
+	err = a.Close()
+	require.NoError(t, err)
+
+	a, err = Open("testdata_direct", opts)
+	require.NoError(t, err)
+
+	sz, err := a.Size()
+	require.NoError(t, err)
+	require.Equal(t, int64(len(data)), sz)
+
+	bs := make([]byte, len(data))
+	_, err = a.ReadAt(bs, 0)
+	require.NoError(t, err)
+	require.Equal(t, data, bs)
+
+	err = a.Close()
+	require.NoError(t, err)
+}
@@ -41,6 +41,11 @@ type Options struct {
 	compressionLevel  int
 	readBufferSize    int
 	writeBufferSize   int
+	offHeapBuffers    bool
+	mmapReads         bool
+	preallocateFiles  bool
+	directIO          bool
+	checksums         bool
 }
 
 func DefaultOptions() *Options {
@@ -122,6 +127,36 @@ func (opts *Options) WithWriteBufferSize(size int) *Options {
 	return opts
 }
 
+// WithOffHeapWriteBuffers makes the write buffers of the files to be allocated outside of the Go heap, when supported
+func (opts *Options) WithOffHeapWriteBuffers(offHeapBuffers bool) *Options {
+	opts.offHeapBuffers = offHeapBuffers
+	return opts
+}
+
+// WithMmapReads makes reads to be served from memory mappings of the (uncompressed) files, when supported
+func (opts *Options) WithMmapReads(mmapReads bool) *Options {
+	opts.mmapReads = mmapReads
+	return opts
+}
+
+// WithPreallocateFiles makes disk space to be allocated for the whole file size when a new file is created
+func (opts *Options) WithPreallocateFiles(preallocateFiles bool) *Options {
+	opts.preallocateFiles = preallocateFiles
+	return opts
+}
+
+// WithDirectIO makes appended data to be written bypassing the page cache, when supported
+func (opts *Options) WithDirectIO(directIO bool) *Options {
+	opts.directIO = directIO
+	return opts
+}
+
+// WithChecksums makes new files to be written with per-block checksums, verified when data is read
+func (opts *Options) WithChecksums(checksums bool) *Options {
+	opts.checksums = checksums
+	return opts
+}
+
 func (opt *Options) GetFileExt() string {
 	return opt.fileExt
 }
@@ -137,3 +172,23 @@ func (opts *Options) GetReadBufferSize() int {
 func (opts *Options) GetWriteBufferSize() int {
 	return opts.writeBufferSize
 }
+
+func (opts *Options) GetOffHeapWriteBuffers() bool {
+	return opts.offHeapBuffers
+}
+
+func (opts *Options) GetMmapReads() bool {
+	return opts.mmapReads
+}
+
+func (opts *Options) GetPreallocateFiles() bool {
+	return opts.preallocateFiles
+}
+
+func (opts *Options) GetDirectIO() bool {
+	return opts.directIO
+}
+
+func (opts *Options) GetChecksums() bool {
+	return opts.checksums
+}
@@ -49,6 +49,11 @@ func TestValidOptions(t *testing.T) {
 
 	require.Equal(t, DefaultReadBufferSize+1, opts.WithReadBufferSize(DefaultReadBufferSize+1).GetReadBufferSize())
 	require.Equal(t, DefaultWriteBufferSize+2, opts.WithWriteBufferSize(DefaultWriteBufferSize+2).GetWriteBufferSize())
+	require.True(t, opts.WithOffHeapWriteBuffers(true).GetOffHeapWriteBuffers())
+	require.True(t, opts.WithMmapReads(true).GetMmapReads())
+	require.True(t, opts.WithPreallocateFiles(true).GetPreallocateFiles())
+	require.True(t, opts.WithDirectIO(true).GetDirectIO())
+	require.True(t, opts.WithChecksums(true).GetChecksums())
 
 	require.True(t, opts.Valid())
 
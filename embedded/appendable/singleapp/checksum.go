@@ -0,0 +1,341 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package singleapp
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+)
+
+// DefaultChecksumBlockSize is the amount of data covered by each checksum
+const DefaultChecksumBlockSize = 4096
+
+// checksumsVersion is the version of the file format including checksums,
+// files without a version in their metadata are written without checksums
+const checksumsVersion = 2
+
+const checksumSize = 4
+
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+var crc32Table = crc32.MakeTable(crc32.Castagnoli)
+
+// ChecksumError is returned when data read from a file does not match its checksum,
+// it identifies the block of data found to be corrupted and it wraps ErrChecksumMismatch
+type ChecksumError struct {
+	FileName string
+	// Offset is the offset of the corrupted block as seen by the readers of the appendable
+	Offset int64
+}
+
+func (e *ChecksumError) Error() string {
+	return fmt.Sprintf("%s at '%s' (block at offset %d)", ErrChecksumMismatch.Error(), e.FileName, e.Offset)
+}
+
+func (e *ChecksumError) Unwrap() error {
+	return ErrChecksumMismatch
+}
+
+// Files with checksums store data in blocks of checksumBlockSize bytes, each one followed by the checksum
+// of its data. The checksum of a block is written once the block is complete, so data is only ever appended
+// and bytes already synced are never written again, thus a crash can only leave the last block invalid.
+// Data of the last block is not covered by a checksum until the block is complete.
+
+// physicalOffset returns the offset in the file where data at the given offset is stored
+func (aof *AppendableFile) physicalOffset(off int64) int64 {
+	blockSize := int64(aof.checksumBlockSize)
+	return aof.baseOffset + off/blockSize*(blockSize+checksumSize) + off%blockSize
+}
+
+// dataSize returns the amount of data stored in a file of the given size,
+// an incomplete checksum at the end of the file is not considered
+func (aof *AppendableFile) dataSize(fileSize int64) int64 {
+	blockSize := int64(aof.checksumBlockSize)
+
+	size := fileSize - aof.baseOffset
+	if size <= 0 {
+		return 0
+	}
+
+	blocks := size / (blockSize + checksumSize)
+	rem := size % (blockSize + checksumSize)
+
+	if rem > blockSize {
+		return blocks*blockSize + blockSize
+	}
+
+	return blocks*blockSize + rem
+}
+
+// readBlock returns the data of a block once its checksum is verified
+func (aof *AppendableFile) readBlock(block int64) ([]byte, error) {
+	buf := make([]byte, aof.checksumBlockSize+checksumSize)
+
+	n, err := aof.f.ReadAt(buf, aof.physicalOffset(block*int64(aof.checksumBlockSize)))
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	if n == 0 {
+		return nil, io.EOF
+	}
+
+	if n < len(buf) {
+		// the last block, its checksum is not written yet
+		return buf[:minInt(n, aof.checksumBlockSize)], nil
+	}
+
+	data := buf[:aof.checksumBlockSize]
+
+	if crc32.Checksum(data, crc32Table) != binary.BigEndian.Uint32(buf[aof.checksumBlockSize:]) {
+		return nil, &ChecksumError{FileName: aof.f.Name(), Offset: block * int64(aof.checksumBlockSize)}
+	}
+
+	return data, nil
+}
+
+func (aof *AppendableFile) readChecksummedAt(bs []byte, off int64) (n int, err error) {
+	if off < 0 {
+		return 0, ErrIllegalArguments
+	}
+
+	blockSize := int64(aof.checksumBlockSize)
+
+	for n < len(bs) {
+		dOff := off + int64(n)
+
+		data, err := aof.readBlock(dOff / blockSize)
+		if err != nil {
+			return n, err
+		}
+
+		start := int(dOff % blockSize)
+		if start >= len(data) {
+			return n, io.EOF
+		}
+
+		n += copy(bs[n:], data[start:])
+	}
+
+	return n, nil
+}
+
+// loadTail makes data to be appended from the given offset, the data of the incomplete block
+// is loaded as its checksum is written once the block is completed
+func (aof *AppendableFile) loadTail(off int64) error {
+	blockSize := int64(aof.checksumBlockSize)
+
+	stat, err := aof.f.Stat()
+	if err != nil {
+		return err
+	}
+
+	if off%blockSize == 0 && off > 0 && stat.Size() < aof.physicalOffset(off) {
+		// the checksum of the last block was torn by a crash while the block was being completed,
+		// it's written again as it was never synced
+		err = aof.writeChecksumOf(off - blockSize)
+		if err != nil {
+			return err
+		}
+	}
+
+	block := make([]byte, off%blockSize, blockSize)
+
+	if len(block) > 0 {
+		err = aof.readTail(block, off-off%blockSize, stat.Size())
+		if err != nil {
+			return err
+		}
+	}
+
+	aof.w.(*checksumWriter).reset(aof.physicalOffset(off-off%blockSize), block)
+	aof.tailLoaded = true
+
+	return nil
+}
+
+// readTail reads the data of the block data is appended to. The checksum of the last block of the file is not
+// verified: it may have been left invalid by a crash while the block was being completed, but the data read from it
+// was synced before and it's never written again, the rest of the block is discarded
+func (aof *AppendableFile) readTail(block []byte, off int64, fileSize int64) error {
+	buf := make([]byte, aof.checksumBlockSize+checksumSize)
+
+	physicalOff := aof.physicalOffset(off)
+
+	n, err := aof.f.ReadAt(buf, physicalOff)
+	if err != nil && err != io.EOF {
+		return err
+	}
+
+	if n < len(block) {
+		return fmt.Errorf("%w: offset beyond existent data boundaries", ErrIllegalArguments)
+	}
+
+	if n == len(buf) && physicalOff+int64(n) < fileSize &&
+		crc32.Checksum(buf[:aof.checksumBlockSize], crc32Table) != binary.BigEndian.Uint32(buf[aof.checksumBlockSize:]) {
+		return &ChecksumError{FileName: aof.f.Name(), Offset: off}
+	}
+
+	copy(block, buf)
+
+	return nil
+}
+
+// writeChecksumOf writes the checksum of the complete block at the given offset
+func (aof *AppendableFile) writeChecksumOf(off int64) error {
+	data := make([]byte, aof.checksumBlockSize)
+
+	_, err := aof.f.ReadAt(data, aof.physicalOffset(off))
+	if err != nil {
+		return err
+	}
+
+	checksumOff := aof.physicalOffset(off) + int64(aof.checksumBlockSize)
+
+	err = aof.f.Truncate(checksumOff)
+	if err != nil {
+		return err
+	}
+
+	_, err = aof.f.WriteAt(appendChecksum(nil, data), checksumOff)
+
+	return err
+}
+
+// discardFrom discards the data beyond the given offset
+func (aof *AppendableFile) discardFrom(off int64) error {
+	err := aof.w.Flush()
+	if err != nil {
+		return err
+	}
+
+	stat, err := aof.f.Stat()
+	if err != nil {
+		return err
+	}
+
+	if off > aof.dataSize(stat.Size()) {
+		return fmt.Errorf("%w: offset beyond existent data boundaries", ErrIllegalArguments)
+	}
+
+	err = aof.loadTail(off)
+	if err != nil {
+		return err
+	}
+
+	if aof.physicalOffset(off) >= stat.Size() {
+		return nil
+	}
+
+	return aof.f.Truncate(aof.physicalOffset(off))
+}
+
+// checksumWriter appends data in blocks followed by their checksums
+type checksumWriter struct {
+	f         *os.File
+	blockSize int
+
+	// data and checksums to be written at bufOff
+	buf     []byte
+	bufSize int
+	bufOff  int64
+
+	// data of the incomplete block, its first flushed bytes are already written
+	block   []byte
+	flushed int
+}
+
+func newChecksumWriter(f *os.File, blockSize, bufSize int, off int64) *checksumWriter {
+	return &checksumWriter{
+		f:         f,
+		blockSize: blockSize,
+		buf:       make([]byte, 0, bufSize+blockSize+checksumSize),
+		bufSize:   bufSize,
+		bufOff:    off,
+		block:     make([]byte, 0, blockSize),
+	}
+}
+
+// reset makes data to be appended to the block written at off, which already holds the given data
+func (w *checksumWriter) reset(off int64, block []byte) {
+	w.buf = w.buf[:0]
+	w.bufOff = off + int64(len(block))
+	w.block = append(w.block[:0], block...)
+	w.flushed = len(block)
+}
+
+func (w *checksumWriter) Write(bs []byte) (n int, err error) {
+	for n < len(bs) {
+		c := minInt(w.blockSize-len(w.block), len(bs)-n)
+
+		w.block = append(w.block, bs[n:n+c]...)
+		n += c
+
+		if len(w.block) < w.blockSize {
+			break
+		}
+
+		w.buf = append(w.buf, w.block[w.flushed:]...)
+		w.buf = appendChecksum(w.buf, w.block)
+		w.block = w.block[:0]
+		w.flushed = 0
+
+		if len(w.buf) >= w.bufSize {
+			_, err = w.f.WriteAt(w.buf, w.bufOff)
+			if err != nil {
+				return n, err
+			}
+
+			w.bufOff += int64(len(w.buf))
+			w.buf = w.buf[:0]
+		}
+	}
+
+	return n, nil
+}
+
+func (w *checksumWriter) Flush() error {
+	if len(w.buf) == 0 && len(w.block) == w.flushed {
+		return nil
+	}
+
+	completed := len(w.buf)
+
+	w.buf = append(w.buf, w.block[w.flushed:]...)
+
+	_, err := w.f.WriteAt(w.buf, w.bufOff)
+	if err != nil {
+		w.buf = w.buf[:completed]
+		return err
+	}
+
+	w.bufOff += int64(len(w.buf))
+	w.buf = w.buf[:0]
+	w.flushed = len(w.block)
+
+	return nil
+}
+
+func appendChecksum(buf, block []byte) []byte {
+	var checksum [checksumSize]byte
+	binary.BigEndian.PutUint32(checksum[:], crc32.Checksum(block, crc32Table))
+
+	return append(buf, checksum[:]...)
+}
@@ -0,0 +1,384 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package singleapp
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/codenotary/immudb/embedded/appendable"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testData(size int) []byte {
+	data := make([]byte, size)
+	for i := range data {
+		data[i] = byte(i % 251)
+	}
+	return data
+}
+
+func TestSingleAppChecksums(t *testing.T) {
+	opts := DefaultOptions().
+		WithChecksums(true).
+		WithWriteBufferSize(1000)
+
+	a, err := Open("testdata_checksums.aof", opts)
+	defer os.Remove("testdata_checksums.aof")
+	require.NoError(t, err)
+
+	data := testData(3*DefaultChecksumBlockSize + 100)
+
+	for i := 0; i < len(data); i += 900 {
+		end := minInt(i+900, len(data))
+
+		off, n, err := a.Append(data[i:end])
+		require.NoError(t, err)
+		require.Equal(t, int64(i), off)
+		require.Equal(t, end-i, n)
+
+		err = a.Flush()
+		require.NoError(t, err)
+
+		sz, err := a.Size()
+		require.NoError(t, err)
+		require.Equal(t, int64(end), sz)
+	}
+
+	bs := make([]byte, 200)
+	_, err = a.ReadAt(bs, DefaultChecksumBlockSize-100)
+	require.NoError(t, err)
+	require.Equal(t, data[DefaultChecksumBlockSize-100:DefaultChecksumBlockSize+100], bs)
+
+	n, err := a.ReadAt(bs, int64(len(data))-100)
+	require.ErrorIs(t, err, io.EOF)
+	require.Equal(t, 100, n)
+	require.Equal(t, data[len(data)-100:], bs[:n])
+
+	err = a.Close()
+	require.NoError(t, err)
+
+	// checksums are kept for files created with them
+	a, err = Open("testdata_checksums.aof", DefaultOptions())
+	require.NoError(t, err)
+	require.Equal(t, int64(len(data)), a.Offset())
+
+	_, _, err = a.Append([]byte{1, 2, 3})
+	require.NoError(t, err)
+
+	data = append(data, 1, 2, 3)
+
+	err = a.Flush()
+	require.NoError(t, err)
+
+	bs = make([]byte, len(data))
+	_, err = a.ReadAt(bs, 0)
+	require.NoError(t, err)
+	require.Equal(t, data, bs)
+
+	t.Run("data beyond the offset should be discarded", func(t *testing.T) {
+		err = a.Truncate(int64(2*DefaultChecksumBlockSize + 10))
+		require.NoError(t, err)
+
+		data = data[:2*DefaultChecksumBlockSize+10]
+
+		sz, err := a.Size()
+		require.NoError(t, err)
+		require.Equal(t, int64(len(data)), sz)
+
+		err = a.SetOffset(int64(len(data)) + 1)
+		require.ErrorIs(t, err, ErrIllegalArguments)
+
+		err = a.SetOffset(int64(DefaultChecksumBlockSize))
+		require.NoError(t, err)
+
+		data = data[:DefaultChecksumBlockSize]
+
+		_, _, err = a.Append([]byte{4, 5, 6})
+		require.NoError(t, err)
+
+		data = append(data, 4, 5, 6)
+
+		err = a.Close()
+		require.NoError(t, err)
+
+		a, err = Open("testdata_checksums.aof", DefaultOptions())
+		require.NoError(t, err)
+		require.Equal(t, int64(len(data)), a.Offset())
+
+		bs := make([]byte, len(data))
+		_, err = a.ReadAt(bs, 0)
+		require.NoError(t, err)
+		require.Equal(t, data, bs)
+
+		err = a.Close()
+		require.NoError(t, err)
+	})
+
+	t.Run("corrupted data should be detected", func(t *testing.T) {
+		f, err := os.OpenFile("testdata_checksums.aof", os.O_RDWR, 0644)
+		require.NoError(t, err)
+
+		stat, err := f.Stat()
+		require.NoError(t, err)
+
+		// corrupts the last complete block, the data of the incomplete one follows its checksum
+		_, err = f.WriteAt([]byte{0}, stat.Size()-int64(len(data)%DefaultChecksumBlockSize)-checksumSize-1)
+		require.NoError(t, err)
+
+		err = f.Close()
+		require.NoError(t, err)
+
+		a, err = Open("testdata_checksums.aof", DefaultOptions().WithReadOnly(true))
+		require.NoError(t, err)
+
+		bs := make([]byte, 3)
+		_, err = a.ReadAt(bs, int64(len(data))-3)
+		require.NoError(t, err)
+		require.Equal(t, data[len(data)-3:], bs)
+
+		bs = make([]byte, 10)
+		_, err = a.ReadAt(bs, int64(len(data))-10)
+		require.ErrorIs(t, err, ErrChecksumMismatch)
+
+		var checksumErr *ChecksumError
+		require.True(t, errors.As(err, &checksumErr))
+		require.Equal(t, "testdata_checksums.aof", checksumErr.FileName)
+		require.Equal(t, int64(0), checksumErr.Offset)
+
+		err = a.Close()
+		require.NoError(t, err)
+	})
+}
+
+func TestSingleAppChecksumsTornTail(t *testing.T) {
+	fileName := "testdata_checksums_torn.aof"
+	defer os.Remove(fileName)
+
+	data := testData(2*DefaultChecksumBlockSize + 100)
+
+	synced := DefaultChecksumBlockSize - 10
+
+	// appends data up to synced, then the rest of the data is appended without being synced
+	write := func(t *testing.T) []byte {
+		os.Remove(fileName)
+
+		a, err := Open(fileName, DefaultOptions().WithChecksums(true))
+		require.NoError(t, err)
+
+		_, _, err = a.Append(data[:synced])
+		require.NoError(t, err)
+
+		err = a.Sync()
+		require.NoError(t, err)
+
+		syncedBs, err := ioutil.ReadFile(fileName)
+		require.NoError(t, err)
+
+		_, _, err = a.Append(data[synced:DefaultChecksumBlockSize])
+		require.NoError(t, err)
+
+		err = a.Flush()
+		require.NoError(t, err)
+
+		err = a.Close()
+		require.NoError(t, err)
+
+		bs, err := ioutil.ReadFile(fileName)
+		require.NoError(t, err)
+		require.Equal(t, syncedBs, bs[:len(syncedBs)], "synced bytes should never be written again")
+
+		return bs
+	}
+
+	requireData := func(t *testing.T, expected []byte) {
+		a, err := Open(fileName, DefaultOptions())
+		require.NoError(t, err)
+		require.Equal(t, int64(len(expected)), a.Offset())
+
+		bs := make([]byte, len(expected))
+		_, err = a.ReadAt(bs, 0)
+		require.NoError(t, err)
+		require.Equal(t, expected, bs)
+
+		err = a.Close()
+		require.NoError(t, err)
+	}
+
+	t.Run("a torn checksum of the last block should be written again", func(t *testing.T) {
+		bs := write(t)
+
+		err := os.Truncate(fileName, int64(len(bs)-2))
+		require.NoError(t, err)
+
+		a, err := Open(fileName, DefaultOptions())
+		require.NoError(t, err)
+		require.Equal(t, int64(DefaultChecksumBlockSize), a.Offset())
+
+		_, _, err = a.Append(data[DefaultChecksumBlockSize:])
+		require.NoError(t, err)
+
+		err = a.Close()
+		require.NoError(t, err)
+
+		requireData(t, data)
+	})
+
+	t.Run("the invalid last block should be discarded up to the synced data", func(t *testing.T) {
+		bs := write(t)
+
+		// data written after the sync was torn while its checksum was written
+		bs[len(bs)-checksumSize-5] ^= 1
+
+		err := ioutil.WriteFile(fileName, bs, 0644)
+		require.NoError(t, err)
+
+		a, err := Open(fileName, DefaultOptions())
+		require.NoError(t, err)
+
+		_, err = a.ReadAt(make([]byte, 10), 0)
+		require.ErrorIs(t, err, ErrChecksumMismatch)
+
+		err = a.SetOffset(int64(synced))
+		require.NoError(t, err)
+
+		_, _, err = a.Append(data[synced:])
+		require.NoError(t, err)
+
+		err = a.Close()
+		require.NoError(t, err)
+
+		requireData(t, data)
+	})
+
+	t.Run("invalid blocks other than the last one should not be discarded", func(t *testing.T) {
+		a, err := Open(fileName, DefaultOptions())
+		require.NoError(t, err)
+
+		err = a.Close()
+		require.NoError(t, err)
+
+		f, err := os.OpenFile(fileName, os.O_RDWR, 0644)
+		require.NoError(t, err)
+
+		_, err = f.WriteAt([]byte{0}, a.physicalOffset(20))
+		require.NoError(t, err)
+
+		err = f.Close()
+		require.NoError(t, err)
+
+		a, err = Open(fileName, DefaultOptions())
+		require.NoError(t, err)
+
+		err = a.SetOffset(10)
+		require.ErrorIs(t, err, ErrChecksumMismatch)
+
+		err = a.Close()
+		require.NoError(t, err)
+	})
+}
+
+func TestSingleAppChecksumsWithCompression(t *testing.T) {
+	opts := DefaultOptions().
+		WithChecksums(true).
+		WithCompressionFormat(appendable.ZLibCompression)
+
+	a, err := Open("testdata_checksums_compressed.aof", opts)
+	defer os.Remove("testdata_checksums_compressed.aof")
+	require.NoError(t, err)
+
+	var offs []int64
+
+	for i := 0; i < 10; i++ {
+		off, _, err := a.Append(testData(1000 + i))
+		require.NoError(t, err)
+
+		offs = append(offs, off)
+	}
+
+	err = a.Flush()
+	require.NoError(t, err)
+
+	for i, off := range offs {
+		bs := make([]byte, 1000+i)
+		_, err = a.ReadAt(bs, off)
+		require.NoError(t, err)
+		require.Equal(t, testData(1000+i), bs)
+	}
+
+	err = a.Close()
+	require.NoError(t, err)
+}
+
+func TestSingleAppWithoutChecksums(t *testing.T) {
+	a, err := Open("testdata_no_checksums.aof", DefaultOptions())
+	defer os.Remove("testdata_no_checksums.aof")
+	require.NoError(t, err)
+
+	_, _, err = a.Append(testData(100))
+	require.NoError(t, err)
+
+	err = a.Close()
+	require.NoError(t, err)
+
+	// files written without checksums keep being read as before
+	a, err = Open("testdata_no_checksums.aof", DefaultOptions().WithChecksums(true))
+	require.NoError(t, err)
+	require.Zero(t, a.checksumBlockSize)
+
+	sz, err := a.Size()
+	require.NoError(t, err)
+	require.Equal(t, int64(100), sz)
+
+	err = a.Close()
+	require.NoError(t, err)
+}
+
+func TestSingleAppUnsupportedVersion(t *testing.T) {
+	f, err := ioutil.TempFile(".", "singleapp_test_")
+	require.NoError(t, err)
+
+	defer os.Remove(f.Name())
+
+	m := appendable.NewMetadata(nil)
+	m.PutInt(metaCompressionFormat, appendable.NoCompression)
+	m.PutInt(metaCompressionLevel, appendable.DefaultCompression)
+	m.Put(metaWrappedMeta, nil)
+	m.PutInt(metaVersion, checksumsVersion+1)
+
+	mBs := m.Bytes()
+	mLenBs := make([]byte, 4)
+	binary.BigEndian.PutUint32(mLenBs, uint32(len(mBs)))
+
+	w := bufio.NewWriter(f)
+	_, err = w.Write(mLenBs)
+	require.NoError(t, err)
+
+	_, err = w.Write(mBs)
+	require.NoError(t, err)
+
+	err = w.Flush()
+	require.NoError(t, err)
+
+	_, err = Open(f.Name(), DefaultOptions())
+	require.ErrorIs(t, err, ErrCorruptedMetadata)
+}
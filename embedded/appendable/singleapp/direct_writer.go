@@ -0,0 +1,191 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package singleapp
+
+import (
+	"os"
+	"unsafe"
+)
+
+// directIOBlockSize is the alignment of buffers, offsets and sizes written with direct I/O
+const directIOBlockSize = 4096
+
+// directWriter writes appended data bypassing the page cache.
+// Data is buffered into blocks aligned as required by direct I/O, the last incomplete
+// block is padded when flushed and it's kept in the buffer to be written again as data gets appended.
+type directWriter struct {
+	f  *os.File // opened for direct I/O
+	rf *os.File // used to read data back when the write offset changes
+
+	buf []byte
+	n   int
+
+	off  int64 // offset of the first byte in buf, always block aligned
+	size int64 // size of the file
+}
+
+func newDirectWriter(fileName string, rf *os.File, fileMode os.FileMode, bufSize int, off int64) (*directWriter, error) {
+	f, err := openDirect(fileName, fileMode)
+	if err != nil {
+		return nil, err
+	}
+
+	if bufSize%directIOBlockSize != 0 {
+		bufSize += directIOBlockSize - bufSize%directIOBlockSize
+	}
+
+	w := &directWriter{
+		f:   f,
+		rf:  rf,
+		buf: alignedBuffer(bufSize),
+	}
+
+	err = w.reset(off)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return w, nil
+}
+
+// alignedBuffer returns a buffer whose address is block aligned
+func alignedBuffer(size int) []byte {
+	buf := make([]byte, size+directIOBlockSize)
+
+	var off int
+
+	rem := int(uintptr(unsafe.Pointer(&buf[0])) & uintptr(directIOBlockSize-1))
+	if rem > 0 {
+		off = directIOBlockSize - rem
+	}
+
+	return buf[off : off+size]
+}
+
+func (w *directWriter) Write(bs []byte) (n int, err error) {
+	for n < len(bs) {
+		c := copy(w.buf[w.n:], bs[n:])
+		w.n += c
+		n += c
+
+		if w.n < len(w.buf) {
+			break
+		}
+
+		_, err = w.f.WriteAt(w.buf, w.off)
+		if err != nil {
+			w.n -= c
+			return n - c, err
+		}
+
+		w.off += int64(len(w.buf))
+		w.n = 0
+
+		if w.off > w.size {
+			w.size = w.off
+		}
+	}
+
+	return n, nil
+}
+
+func (w *directWriter) Flush() error {
+	if w.n == 0 {
+		return nil
+	}
+
+	end := w.off + int64(w.n)
+
+	padded := w.n
+	if padded%directIOBlockSize != 0 {
+		padded += directIOBlockSize - padded%directIOBlockSize
+	}
+
+	for i := w.n; i < padded; i++ {
+		w.buf[i] = 0
+	}
+
+	// existing data beyond the written one must not be overwritten by the padding
+	if w.size > end {
+		_, err := w.rf.ReadAt(w.buf[w.n:minInt(padded, int(w.size-w.off))], end)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err := w.f.WriteAt(w.buf[:padded], w.off)
+	if err != nil {
+		return err
+	}
+
+	if w.off+int64(padded) > w.size {
+		if end < w.size {
+			end = w.size
+		}
+
+		// padding is not part of the file
+		if padded > w.n {
+			err = w.f.Truncate(end)
+			if err != nil {
+				return err
+			}
+		}
+
+		w.size = end
+	}
+
+	full := w.n - w.n%directIOBlockSize
+
+	copy(w.buf, w.buf[full:w.n])
+
+	w.off += int64(full)
+	w.n -= full
+
+	return nil
+}
+
+// reset moves the writing position to the given offset, buffered data is discarded
+func (w *directWriter) reset(off int64) error {
+	stat, err := w.rf.Stat()
+	if err != nil {
+		return err
+	}
+
+	if off < 0 || off > stat.Size() {
+		return ErrIllegalArguments
+	}
+
+	blockOff := off - off%directIOBlockSize
+	n := int(off - blockOff)
+
+	if n > 0 {
+		_, err = w.rf.ReadAt(w.buf[:n], blockOff)
+		if err != nil {
+			return err
+		}
+	}
+
+	w.size = stat.Size()
+	w.off = blockOff
+	w.n = n
+
+	return nil
+}
+
+func (w *directWriter) Close() error {
+	return w.f.Close()
+}
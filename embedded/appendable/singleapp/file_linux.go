@@ -0,0 +1,48 @@
+// +build linux
+
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package singleapp
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// fallocFlKeepSize allocates disk space without changing the size of the file
+const fallocFlKeepSize = 0x01
+
+func openDirect(fileName string, fileMode os.FileMode) (*os.File, error) {
+	f, err := os.OpenFile(fileName, os.O_WRONLY|syscall.O_DIRECT, fileMode)
+	if errors.Is(err, syscall.EINVAL) {
+		// the filesystem does not support direct I/O e.g. tmpfs
+		return nil, errDirectIOUnsupported
+	}
+
+	return f, err
+}
+
+func preallocate(f *os.File, size int64) error {
+	err := syscall.Fallocate(int(f.Fd()), fallocFlKeepSize, 0, size)
+	if errors.Is(err, syscall.EOPNOTSUPP) {
+		// preallocation is just an optimization
+		return nil
+	}
+
+	return err
+}
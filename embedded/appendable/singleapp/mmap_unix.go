@@ -0,0 +1,47 @@
+// +build linux darwin freebsd
+
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package singleapp
+
+import (
+	"os"
+	"strconv"
+	"syscall"
+)
+
+func mmap(f *os.File, size int64) ([]byte, error) {
+	// files may not fit into the address space of 32-bit platforms
+	if strconv.IntSize < 64 {
+		return nil, errMmapUnsupported
+	}
+
+	return syscall.Mmap(int(f.Fd()), 0, int(size), syscall.PROT_READ, syscall.MAP_SHARED)
+}
+
+func munmap(b []byte) error {
+	return syscall.Munmap(b)
+}
+
+// allocOffHeap returns a buffer allocated outside of the Go heap, which is not scanned by the garbage collector
+func allocOffHeap(size int) ([]byte, error) {
+	return syscall.Mmap(-1, 0, size, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_ANON|syscall.MAP_PRIVATE)
+}
+
+func freeOffHeap(b []byte) error {
+	return syscall.Munmap(b)
+}
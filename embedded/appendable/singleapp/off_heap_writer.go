@@ -0,0 +1,98 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package singleapp
+
+import "io"
+
+// offHeapWriter buffers appended data as bufio.Writer does but into a buffer allocated outside of the Go heap,
+// so large buffers neither add to the heap size that drives garbage collection nor have to be scanned.
+// The buffer must be released by closing the writer.
+type offHeapWriter struct {
+	w   io.Writer
+	buf []byte
+	n   int
+}
+
+func newOffHeapWriter(w io.Writer, bufSize int) (*offHeapWriter, error) {
+	buf, err := allocOffHeap(bufSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return &offHeapWriter{w: w, buf: buf}, nil
+}
+
+func (w *offHeapWriter) Write(bs []byte) (n int, err error) {
+	for len(bs) > len(w.buf)-w.n {
+		var c int
+
+		if w.n == 0 {
+			// large writes bypass the buffer when it's empty
+			c, err = w.w.Write(bs)
+		} else {
+			c = copy(w.buf[w.n:], bs)
+			w.n += c
+			err = w.Flush()
+		}
+
+		n += c
+		bs = bs[c:]
+
+		if err != nil {
+			return n, err
+		}
+	}
+
+	c := copy(w.buf[w.n:], bs)
+	w.n += c
+
+	return n + c, nil
+}
+
+func (w *offHeapWriter) Flush() error {
+	if w.n == 0 {
+		return nil
+	}
+
+	c, err := w.w.Write(w.buf[:w.n])
+	if c < w.n && err == nil {
+		err = io.ErrShortWrite
+	}
+	if err != nil {
+		// data not yet written is kept to be written by the next flush
+		if c > 0 && c < w.n {
+			copy(w.buf, w.buf[c:w.n])
+		}
+		w.n -= c
+		return err
+	}
+
+	w.n = 0
+
+	return nil
+}
+
+// Close releases the buffer, buffered data must be flushed beforehand
+func (w *offHeapWriter) Close() error {
+	if w.buf == nil {
+		return nil
+	}
+
+	err := freeOffHeap(w.buf)
+	w.buf = nil
+
+	return err
+}
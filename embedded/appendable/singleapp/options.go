@@ -35,8 +35,16 @@ type Options struct {
 	compressionFormat int
 	compressionLevel  int
 
-	readBufferSize  int
-	writeBufferSize int
+	readBufferSize     int
+	writeBufferSize    int
+	offHeapWriteBuffer bool
+
+	mmapReads bool
+
+	preallocateSize int
+	directIO        bool
+
+	checksums bool
 
 	metadata []byte
 }
@@ -56,7 +64,8 @@ func DefaultOptions() *Options {
 func (opts *Options) Valid() bool {
 	return opts != nil &&
 		opts.readBufferSize > 0 &&
-		opts.writeBufferSize > 0
+		opts.writeBufferSize > 0 &&
+		opts.preallocateSize >= 0
 }
 
 func (opts *Options) WithReadOnly(readOnly bool) *Options {
@@ -114,3 +123,61 @@ func (opts *Options) WithWriteBufferSize(size int) *Options {
 	opts.writeBufferSize = size
 	return opts
 }
+
+// WithMmapReads makes reads to be served from a memory mapping of the file, when it's not compressed
+// and memory mapping is supported by the platform, otherwise reads fall back to regular file reads
+// WithOffHeapWriteBuffer makes the write buffer to be allocated outside of the Go heap, when supported,
+// so large buffers do not lengthen garbage collection pauses. Files with checksums or written with direct I/O
+// keep using their own buffers
+func (opts *Options) WithOffHeapWriteBuffer(offHeapWriteBuffer bool) *Options {
+	opts.offHeapWriteBuffer = offHeapWriteBuffer
+	return opts
+}
+
+func (opts *Options) GetOffHeapWriteBuffer() bool {
+	return opts.offHeapWriteBuffer
+}
+
+func (opts *Options) WithMmapReads(mmapReads bool) *Options {
+	opts.mmapReads = mmapReads
+	return opts
+}
+
+func (opts *Options) GetMmapReads() bool {
+	return opts.mmapReads
+}
+
+// WithPreallocateSize makes disk space to be allocated for the given number of bytes when the file is created,
+// reducing filesystem fragmentation. The size of the file is not changed. Zero means no preallocation
+func (opts *Options) WithPreallocateSize(size int) *Options {
+	opts.preallocateSize = size
+	return opts
+}
+
+func (opts *Options) GetPreallocateSize() int {
+	return opts.preallocateSize
+}
+
+// WithDirectIO makes appended data to be written bypassing the page cache (O_DIRECT), when supported
+// by the platform and the filesystem, otherwise data is written as usual
+func (opts *Options) WithDirectIO(directIO bool) *Options {
+	opts.directIO = directIO
+	return opts
+}
+
+func (opts *Options) GetDirectIO() bool {
+	return opts.directIO
+}
+
+// WithChecksums makes new files to be written with a checksum for each block of data, verified when data is read.
+// The checksum of a block is written once the block is complete, so data of the last block is not verified.
+// Files with checksums can not be read by previous versions. Memory mapped reads and direct I/O are not used
+// for files with checksums
+func (opts *Options) WithChecksums(checksums bool) *Options {
+	opts.checksums = checksums
+	return opts
+}
+
+func (opts *Options) GetChecksums() bool {
+	return opts.checksums
+}
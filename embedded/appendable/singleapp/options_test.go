@@ -46,6 +46,12 @@ func TestValidOptions(t *testing.T) {
 	require.Equal(t, DefaultReadBufferSize+1, opts.WithReadBufferSize(DefaultReadBufferSize+1).GetReadBufferSize())
 	require.Equal(t, DefaultWriteBufferSize+2, opts.WithWriteBufferSize(DefaultWriteBufferSize+2).GetWriteBufferSize())
 
+	require.True(t, opts.WithOffHeapWriteBuffer(true).GetOffHeapWriteBuffer())
+	require.True(t, opts.WithMmapReads(true).GetMmapReads())
+	require.Equal(t, 1024, opts.WithPreallocateSize(1024).GetPreallocateSize())
+	require.True(t, opts.WithDirectIO(true).GetDirectIO())
+	require.True(t, opts.WithChecksums(true).GetChecksums())
+
 	require.True(t, opts.Valid())
 
 	require.True(t, opts.WithReadOnly(true).readOnly)
@@ -38,12 +38,23 @@ var ErrAlreadyClosed = errors.New("single-file appendable already closed")
 var ErrReadOnly = errors.New("cannot append when opened in read-only mode")
 var ErrCorruptedMetadata = errors.New("corrupted metadata")
 
+var errMmapUnsupported = errors.New("memory mapping is not supported")
+var errDirectIOUnsupported = errors.New("direct I/O is not supported")
+
 const (
 	metaCompressionFormat = "COMPRESSION_FORMAT"
 	metaCompressionLevel  = "COMPRESSION_LEVEL"
 	metaWrappedMeta       = "WRAPPED_METADATA"
+	metaVersion           = "VERSION"
+	metaChecksumBlockSize = "CHECKSUM_BLOCK_SIZE"
 )
 
+// appendableWriter buffers the data appended to the file
+type appendableWriter interface {
+	io.Writer
+	Flush() error
+}
+
 type AppendableFile struct {
 	f *os.File
 
@@ -52,15 +63,22 @@ type AppendableFile struct {
 
 	metadata []byte
 
+	// checksumBlockSize is zero when the file is written without checksums
+	checksumBlockSize int
+	tailLoaded        bool
+
 	readBufferSize  int
 	writeBufferSize int
 
 	readOnly bool
 	synced   bool
 
+	mmapReads bool
+	mmapped   []byte
+
 	closed bool
 
-	w *bufio.Writer
+	w appendableWriter
 
 	baseOffset int64
 	offset     int64
@@ -96,6 +114,7 @@ func Open(fileName string, opts *Options) (*AppendableFile, error) {
 	var metadata []byte
 	var compressionFormat int
 	var compressionLevel int
+	var checksumBlockSize int
 	var baseOffset int64
 
 	if notExist {
@@ -104,6 +123,13 @@ func Open(fileName string, opts *Options) (*AppendableFile, error) {
 		m.PutInt(metaCompressionLevel, opts.compressionLevel)
 		m.Put(metaWrappedMeta, opts.metadata)
 
+		if opts.checksums {
+			m.PutInt(metaVersion, checksumsVersion)
+			m.PutInt(metaChecksumBlockSize, DefaultChecksumBlockSize)
+
+			checksumBlockSize = DefaultChecksumBlockSize
+		}
+
 		mBs := m.Bytes()
 		mLenBs := make([]byte, 4)
 		binary.BigEndian.PutUint32(mLenBs, uint32(len(mBs)))
@@ -125,6 +151,14 @@ func Open(fileName string, opts *Options) (*AppendableFile, error) {
 			return nil, err
 		}
 
+		if opts.preallocateSize > 0 {
+			err = preallocate(f, int64(opts.preallocateSize))
+			if err != nil {
+				f.Close()
+				return nil, err
+			}
+		}
+
 		compressionFormat = opts.compressionFormat
 		compressionLevel = opts.compressionLevel
 		metadata = opts.metadata
@@ -164,6 +198,18 @@ func Open(fileName string, opts *Options) (*AppendableFile, error) {
 			return nil, ErrCorruptedMetadata
 		}
 
+		version, ok := m.GetInt(metaVersion)
+		if ok {
+			if version != checksumsVersion {
+				return nil, fmt.Errorf("%w: unsupported file version %d", ErrCorruptedMetadata, version)
+			}
+
+			checksumBlockSize, ok = m.GetInt(metaChecksumBlockSize)
+			if !ok || checksumBlockSize <= 0 {
+				return nil, ErrCorruptedMetadata
+			}
+		}
+
 		baseOffset = int64(4 + len(mBs))
 	}
 
@@ -172,25 +218,58 @@ func Open(fileName string, opts *Options) (*AppendableFile, error) {
 		return nil, err
 	}
 
-	var w *bufio.Writer
-	if !opts.readOnly {
+	var w appendableWriter
+	if !opts.readOnly && checksumBlockSize > 0 {
+		w = newChecksumWriter(f, checksumBlockSize, opts.writeBufferSize, baseOffset)
+	} else if !opts.readOnly && opts.offHeapWriteBuffer {
+		ow, err := newOffHeapWriter(f, opts.writeBufferSize)
+		if err == nil {
+			w = ow
+		} else if errors.Is(err, errMmapUnsupported) {
+			w = bufio.NewWriterSize(f, opts.writeBufferSize)
+		} else {
+			f.Close()
+			return nil, err
+		}
+	} else if !opts.readOnly {
 		w = bufio.NewWriterSize(f, opts.writeBufferSize)
 	}
 
-	return &AppendableFile{
+	if !opts.readOnly && opts.directIO && checksumBlockSize == 0 {
+		dw, err := newDirectWriter(fileName, f, opts.fileMode, opts.writeBufferSize, off)
+		if err == nil {
+			if ow, ok := w.(*offHeapWriter); ok {
+				ow.Close()
+			}
+			w = dw
+		} else if !errors.Is(err, errDirectIOUnsupported) {
+			f.Close()
+			return nil, err
+		}
+	}
+
+	aof := &AppendableFile{
 		f:                 f,
 		compressionFormat: compressionFormat,
 		compressionLevel:  compressionLevel,
+		checksumBlockSize: checksumBlockSize,
 		readBufferSize:    opts.readBufferSize,
 		writeBufferSize:   opts.writeBufferSize,
 		metadata:          metadata,
 		readOnly:          opts.readOnly,
 		synced:            opts.synced,
+		mmapReads:         opts.mmapReads && compressionFormat == appendable.NoCompression && checksumBlockSize == 0,
 		w:                 w,
 		baseOffset:        baseOffset,
 		offset:            off - baseOffset,
 		closed:            false,
-	}, nil
+	}
+
+	if checksumBlockSize > 0 {
+		aof.offset = aof.dataSize(off)
+	}
+
+	return aof, nil
 }
 
 func (aof *AppendableFile) Copy(dstPath string) error {
@@ -249,6 +328,11 @@ func (aof *AppendableFile) Size() (int64, error) {
 	if err != nil {
 		return 0, err
 	}
+
+	if aof.checksumBlockSize > 0 {
+		return aof.dataSize(stat.Size()), nil
+	}
+
 	return stat.Size() - aof.baseOffset, nil
 }
 
@@ -267,15 +351,110 @@ func (aof *AppendableFile) SetOffset(off int64) error {
 		return ErrAlreadyClosed
 	}
 
+	// data beyond the offset is discarded as blocks are written along with their checksums
+	if aof.checksumBlockSize > 0 && !aof.readOnly {
+		err := aof.discardFrom(off)
+		if err != nil {
+			return err
+		}
+
+		aof.offset = off
+		return nil
+	}
+
 	_, err := aof.f.Seek(off+aof.baseOffset, io.SeekStart)
 	if err != nil {
 		return err
 	}
 
+	dw, ok := aof.w.(*directWriter)
+	if ok {
+		// data buffered for direct I/O is written before moving to the new offset
+		err = dw.Flush()
+		if err != nil {
+			return err
+		}
+
+		err = dw.reset(off + aof.baseOffset)
+		if err != nil {
+			return err
+		}
+	}
+
 	aof.offset = off
 	return nil
 }
 
+// Truncate discards all the data beyond the given offset, shrinking the underlying file
+func (aof *AppendableFile) Truncate(off int64) error {
+	aof.mutex.Lock()
+	defer aof.mutex.Unlock()
+
+	if aof.closed {
+		return ErrAlreadyClosed
+	}
+
+	if aof.readOnly {
+		return ErrReadOnly
+	}
+
+	if off < 0 || off > aof.offset {
+		return fmt.Errorf("%w: truncate beyond existent data boundaries", ErrIllegalArguments)
+	}
+
+	if aof.checksumBlockSize > 0 {
+		err := aof.discardFrom(off)
+		if err != nil {
+			return err
+		}
+
+		aof.offset = off
+
+		if aof.synced {
+			return aof.f.Sync()
+		}
+
+		return nil
+	}
+
+	err := aof.w.Flush()
+	if err != nil {
+		return err
+	}
+
+	// accessing a mapped region beyond the end of the file is not allowed
+	err = aof.unmap()
+	if err != nil {
+		return err
+	}
+
+	err = aof.f.Truncate(off + aof.baseOffset)
+	if err != nil {
+		return err
+	}
+
+	_, err = aof.f.Seek(off+aof.baseOffset, io.SeekStart)
+	if err != nil {
+		return err
+	}
+
+	dw, ok := aof.w.(*directWriter)
+	if ok {
+		err = dw.reset(off + aof.baseOffset)
+		if err != nil {
+			return err
+		}
+	}
+
+	aof.offset = off
+
+	if aof.synced {
+		return aof.f.Sync()
+	}
+
+	return nil
+}
+
 func (aof *AppendableFile) DiscardUpto(off int64) error {
 	aof.mutex.Lock()
 	defer aof.mutex.Unlock()
@@ -335,6 +514,13 @@ func (aof *AppendableFile) Append(bs []byte) (off int64, n int, err error) {
 		return 0, 0, ErrIllegalArguments
 	}
 
+	if aof.checksumBlockSize > 0 && !aof.tailLoaded {
+		err = aof.loadTail(aof.offset)
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+
 	off = aof.offset
 
 	if aof.compressionFormat == appendable.NoCompression {
@@ -390,19 +576,71 @@ func (aof *AppendableFile) ReadAt(bs []byte, off int64) (n int, err error) {
 		return 0, ErrIllegalArguments
 	}
 
+	if aof.compressionFormat == appendable.NoCompression && aof.checksumBlockSize > 0 {
+		return aof.readChecksummedAt(bs, off)
+	}
+
 	if aof.compressionFormat == appendable.NoCompression {
+		if aof.mmapReads {
+			return aof.readAtMmapped(bs, off+aof.baseOffset)
+		}
+
 		return aof.f.ReadAt(bs, off+aof.baseOffset)
 	}
 
-	cOff, err := aof.f.Seek(0, io.SeekCurrent)
+	cBs, err := aof.readCompressed(off)
 	if err != nil {
 		return 0, err
 	}
+
+	r, err := aof.reader(bytes.NewReader(cBs))
+	if err != nil {
+		return 0, err
+	}
+	defer r.Close()
+
+	var buf bytes.Buffer
+	buf.ReadFrom(r)
+	rbs := buf.Bytes()
+
+	n = minInt(len(rbs), len(bs))
+
+	copy(bs, rbs[:n])
+
+	if n < len(bs) {
+		err = io.EOF
+	}
+
+	return
+}
+
+// readCompressed returns the compressed data appended at the given offset
+func (aof *AppendableFile) readCompressed(off int64) ([]byte, error) {
+	if aof.checksumBlockSize > 0 {
+		clenBs := make([]byte, 4)
+		_, err := aof.readChecksummedAt(clenBs, off)
+		if err != nil {
+			return nil, err
+		}
+
+		cBs := make([]byte, binary.BigEndian.Uint32(clenBs))
+		_, err = aof.readChecksummedAt(cBs, off+4)
+		if err != nil {
+			return nil, err
+		}
+
+		return cBs, nil
+	}
+
+	cOff, err := aof.f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
 	defer aof.f.Seek(cOff, io.SeekStart)
 
 	_, err = aof.f.Seek(off+aof.baseOffset, io.SeekStart)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 
 	br := bufio.NewReaderSize(aof.f, aof.readBufferSize)
@@ -410,28 +648,41 @@ func (aof *AppendableFile) ReadAt(bs []byte, off int64) (n int, err error) {
 	clenBs := make([]byte, 4)
 	_, err = br.Read(clenBs)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 
 	cBs := make([]byte, binary.BigEndian.Uint32(clenBs))
 	_, err = io.ReadFull(br, cBs)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 
-	r, err := aof.reader(bytes.NewReader(cBs))
-	if err != nil {
-		return 0, err
+	return cBs, nil
+}
+
+// readAtMmapped reads from the memory mapped file, which is mapped again when the read
+// goes beyond the mapped region e.g. after new data was flushed into the file
+func (aof *AppendableFile) readAtMmapped(bs []byte, off int64) (n int, err error) {
+	if off < 0 {
+		return 0, ErrIllegalArguments
 	}
-	defer r.Close()
 
-	var buf bytes.Buffer
-	buf.ReadFrom(r)
-	rbs := buf.Bytes()
+	if off+int64(len(bs)) > int64(len(aof.mmapped)) {
+		err = aof.remap()
+		if errors.Is(err, errMmapUnsupported) {
+			aof.mmapReads = false
+			return aof.f.ReadAt(bs, off)
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
 
-	n = minInt(len(rbs), len(bs))
+	if off >= int64(len(aof.mmapped)) {
+		return 0, io.EOF
+	}
 
-	copy(bs, rbs[:n])
+	n = copy(bs, aof.mmapped[off:])
 
 	if n < len(bs) {
 		err = io.EOF
@@ -440,6 +691,47 @@ func (aof *AppendableFile) ReadAt(bs []byte, off int64) (n int, err error) {
 	return
 }
 
+func (aof *AppendableFile) remap() error {
+	stat, err := aof.f.Stat()
+	if err != nil {
+		return err
+	}
+
+	if stat.Size() <= int64(len(aof.mmapped)) {
+		return nil
+	}
+
+	mmapped, err := mmap(aof.f, stat.Size())
+	if err != nil {
+		return err
+	}
+
+	err = aof.unmap()
+	if err != nil {
+		munmap(mmapped)
+		return err
+	}
+
+	aof.mmapped = mmapped
+
+	return nil
+}
+
+func (aof *AppendableFile) unmap() error {
+	if aof.mmapped == nil {
+		return nil
+	}
+
+	err := munmap(aof.mmapped)
+	if err != nil {
+		return err
+	}
+
+	aof.mmapped = nil
+
+	return nil
+}
+
 func (aof *AppendableFile) Flush() error {
 	aof.mutex.Lock()
 	defer aof.mutex.Unlock()
@@ -502,6 +794,20 @@ func (aof *AppendableFile) Close() error {
 		}
 	}
 
+	err := aof.unmap()
+	if err != nil {
+		return err
+	}
+
+	// direct I/O and off-heap writers hold resources which must be released
+	c, ok := aof.w.(io.Closer)
+	if ok {
+		err = c.Close()
+		if err != nil {
+			return err
+		}
+	}
+
 	aof.closed = true
 
 	return aof.f.Close()
@@ -17,6 +17,7 @@ package singleapp
 
 import (
 	"bufio"
+	"bytes"
 	"encoding/binary"
 	"io"
 	"io/ioutil"
@@ -424,3 +425,297 @@ func TestSingleAppDiscard(t *testing.T) {
 	err = app.Close()
 	require.NoError(t, err)
 }
+
+func TestSingleAppTruncate(t *testing.T) {
+	app, err := Open("testdata_truncate.aof", DefaultOptions())
+	require.NoError(t, err)
+
+	defer os.RemoveAll("testdata_truncate.aof")
+
+	err = app.Truncate(1)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, _, err = app.Append([]byte{1, 2, 3})
+	require.NoError(t, err)
+
+	off, _, err := app.Append([]byte{4, 5, 6})
+	require.NoError(t, err)
+
+	err = app.Truncate(off)
+	require.NoError(t, err)
+	require.Equal(t, off, app.Offset())
+
+	sz, err := app.Size()
+	require.NoError(t, err)
+	require.Equal(t, off, sz)
+
+	off1, _, err := app.Append([]byte{7})
+	require.NoError(t, err)
+	require.Equal(t, off, off1)
+
+	err = app.Close()
+	require.NoError(t, err)
+
+	err = app.Truncate(0)
+	require.ErrorIs(t, err, ErrAlreadyClosed)
+
+	app, err = Open("testdata_truncate.aof", DefaultOptions().WithReadOnly(true))
+	require.NoError(t, err)
+
+	bs := make([]byte, 4)
+	_, err = app.ReadAt(bs, 0)
+	require.NoError(t, err)
+	require.Equal(t, []byte{1, 2, 3, 7}, bs)
+
+	err = app.Truncate(0)
+	require.ErrorIs(t, err, ErrReadOnly)
+
+	err = app.Close()
+	require.NoError(t, err)
+}
+
+func TestSingleAppMmapReads(t *testing.T) {
+	a, err := Open("testdata_mmap.aof", DefaultOptions().WithMmapReads(true))
+	defer os.Remove("testdata_mmap.aof")
+	require.NoError(t, err)
+
+	_, err = a.ReadAt(make([]byte, 1), 0)
+	require.ErrorIs(t, err, io.EOF)
+
+	_, _, err = a.Append([]byte{1, 2, 3})
+	require.NoError(t, err)
+
+	err = a.Flush()
+	require.NoError(t, err)
+
+	bs := make([]byte, 3)
+	n, err := a.ReadAt(bs, 0)
+	require.NoError(t, err)
+	require.Equal(t, 3, n)
+	require.Equal(t, []byte{1, 2, 3}, bs)
+
+	_, _, err = a.Append([]byte{4, 5, 6})
+	require.NoError(t, err)
+
+	err = a.Flush()
+	require.NoError(t, err)
+
+	// reading appended data requires the file to be mapped again
+	n, err = a.ReadAt(bs, 3)
+	require.NoError(t, err)
+	require.Equal(t, 3, n)
+	require.Equal(t, []byte{4, 5, 6}, bs)
+
+	n, err = a.ReadAt(bs, 4)
+	require.ErrorIs(t, err, io.EOF)
+	require.Equal(t, 2, n)
+	require.Equal(t, []byte{5, 6}, bs[:n])
+
+	err = a.Truncate(2)
+	require.NoError(t, err)
+
+	n, err = a.ReadAt(bs, 0)
+	require.ErrorIs(t, err, io.EOF)
+	require.Equal(t, 2, n)
+	require.Equal(t, []byte{1, 2}, bs[:n])
+
+	err = a.Close()
+	require.NoError(t, err)
+
+	_, err = a.ReadAt(bs, 0)
+	require.ErrorIs(t, err, ErrAlreadyClosed)
+}
+
+func TestSingleAppOffHeapWriteBuffer(t *testing.T) {
+	a, err := Open("testdata_off_heap.aof", DefaultOptions().WithOffHeapWriteBuffer(true).WithWriteBufferSize(8))
+	defer os.Remove("testdata_off_heap.aof")
+	require.NoError(t, err)
+
+	// appends smaller and larger than the buffer
+	for i := 1; i < 10; i++ {
+		_, _, err = a.Append(bytes.Repeat([]byte{byte(i)}, i*2))
+		require.NoError(t, err)
+	}
+
+	err = a.Flush()
+	require.NoError(t, err)
+
+	off := int64(0)
+	for i := 1; i < 10; i++ {
+		bs := make([]byte, i*2)
+		_, err = a.ReadAt(bs, off)
+		require.NoError(t, err)
+		require.Equal(t, bytes.Repeat([]byte{byte(i)}, i*2), bs)
+
+		off += int64(len(bs))
+	}
+
+	err = a.Truncate(3)
+	require.NoError(t, err)
+
+	_, _, err = a.Append([]byte{7, 8, 9})
+	require.NoError(t, err)
+
+	err = a.Close()
+	require.NoError(t, err)
+
+	a, err = Open("testdata_off_heap.aof", DefaultOptions().WithReadOnly(true))
+	require.NoError(t, err)
+
+	bs := make([]byte, 6)
+	_, err = a.ReadAt(bs, 0)
+	require.NoError(t, err)
+	require.Equal(t, []byte{1, 1, 2, 7, 8, 9}, bs)
+
+	err = a.Close()
+	require.NoError(t, err)
+}
+
+func TestSingleAppMmapReadsWithCompression(t *testing.T) {
+	opts := DefaultOptions().
+		WithMmapReads(true).
+		WithCompressionFormat(appendable.FlateCompression)
+
+	a, err := Open("testdata_mmap_compressed.aof", opts)
+	defer os.Remove("testdata_mmap_compressed.aof")
+	require.NoError(t, err)
+
+	// compressed files are not memory mapped
+	require.False(t, a.mmapReads)
+
+	off, _, err := a.Append([]byte{1, 2, 3})
+	require.NoError(t, err)
+
+	err = a.Flush()
+	require.NoError(t, err)
+
+	bs := make([]byte, 3)
+	_, err = a.ReadAt(bs, off)
+	require.NoError(t, err)
+	require.Equal(t, []byte{1, 2, 3}, bs)
+
+	err = a.Close()
+	require.NoError(t, err)
+}
+
+func TestSingleAppDirectIO(t *testing.T) {
+	opts := DefaultOptions().
+		WithDirectIO(true).
+		WithWriteBufferSize(100)
+
+	a, err := Open("testdata_direct.aof", opts)
+	defer os.Remove("testdata_direct.aof")
+	require.NoError(t, err)
+
+	var data []byte
+
+	for i := 0; i < 100; i++ {
+		bs := make([]byte, 97)
+		for j := range bs {
+			bs[j] = byte(i)
+		}
+
+		off, n, err := a.Append(bs)
+		require.NoError(t, err)
+		require.Equal(t, int64(len(data)), off)
+		require.Equal(t, len(bs), n)
+
+		data = append(data, bs...)
+
+		if i%10 == 0 {
+			err = a.Flush()
+			require.NoError(t, err)
+
+			// padding written to align blocks must not be part of the file
+			sz, err := a.Size()
+			require.NoError(t, err)
+			require.Equal(t, int64(len(data)), sz)
+		}
+	}
+
+	err = a.Flush()
+	require.NoError(t, err)
+
+	bs := make([]byte, len(data))
+	_, err = a.ReadAt(bs, 0)
+	require.NoError(t, err)
+	require.Equal(t, data, bs)
+
+	err = a.SetOffset(100)
+	require.NoError(t, err)
+
+	_, _, err = a.Append([]byte{1, 2, 3})
+	require.NoError(t, err)
+
+	err = a.Flush()
+	require.NoError(t, err)
+
+	copy(data[100:], []byte{1, 2, 3})
+
+	// data beyond the written one is kept
+	_, err = a.ReadAt(bs, 0)
+	require.NoError(t, err)
+	require.Equal(t, data, bs)
+
+	err = a.SetOffset(int64(len(data)) + 1)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	err = a.SetOffset(int64(len(data)))
+	require.NoError(t, err)
+
+	err = a.Truncate(5000)
+	require.NoError(t, err)
+
+	data = data[:5000]
+
+	_, _, err = a.Append([]byte{4, 5, 6})
+	require.NoError(t, err)
+
+	data = append(data, 4, 5, 6)
+
+	err = a.Close()
+	require.NoError(t, err)
+
+	a, err = Open("testdata_direct.aof", opts)
+	require.NoError(t, err)
+
+	sz, err := a.Size()
+	require.NoError(t, err)
+	require.Equal(t, int64(len(data)), sz)
+
+	bs = make([]byte, len(data))
+	_, err = a.ReadAt(bs, 0)
+	require.NoError(t, err)
+	require.Equal(t, data, bs)
+
+	err = a.Close()
+	require.NoError(t, err)
+}
+
+func TestSingleAppPreallocation(t *testing.T) {
+	a, err := Open("testdata_preallocated.aof", DefaultOptions().WithPreallocateSize(1<<20))
+	defer os.Remove("testdata_preallocated.aof")
+	require.NoError(t, err)
+
+	// preallocation does not change the size of the file
+	sz, err := a.Size()
+	require.NoError(t, err)
+	require.Equal(t, int64(0), sz)
+
+	_, _, err = a.Append([]byte{1, 2, 3})
+	require.NoError(t, err)
+
+	err = a.Close()
+	require.NoError(t, err)
+
+	a, err = Open("testdata_preallocated.aof", DefaultOptions())
+	require.NoError(t, err)
+
+	require.Equal(t, int64(3), a.Offset())
+
+	err = a.Close()
+	require.NoError(t, err)
+
+	_, err = Open("testdata_preallocated.aof", DefaultOptions().WithPreallocateSize(-1))
+	require.ErrorIs(t, err, ErrIllegalArguments)
+}
@@ -0,0 +1,294 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cache
+
+import (
+	"container/list"
+	"fmt"
+	"sync"
+)
+
+// ARCCache is an Adaptive Replacement Cache (Megiddo and Modha, 2003).
+//
+// Cached entries are kept in two LRU lists: t1 holds the entries used once since they were put
+// and t2 the ones used at least twice. The keys of the entries recently evicted from them are
+// remembered in the ghost lists b1 and b2, and every time an evicted key is put again the target
+// size of t1 is adapted, favouring recency when it's found in b1 and frequency when found in b2.
+// Thus, a scan only replaces entries of t1 and the entries frequently used are kept in t2.
+type ARCCache struct {
+	data map[interface{}]*arcEntry
+
+	t1 *list.List
+	t2 *list.List
+	b1 *list.List
+	b2 *list.List
+
+	size int
+	p    int // target size of t1
+
+	mutex sync.Mutex
+}
+
+type arcEntry struct {
+	value interface{}
+	in    *list.List // the list holding the entry, values of evicted entries are not kept
+	order *list.Element
+}
+
+func NewARCCache(size int) (*ARCCache, error) {
+	if size < 1 {
+		return nil, ErrIllegalArguments
+	}
+
+	return &ARCCache{
+		data: make(map[interface{}]*arcEntry, size),
+		t1:   list.New(),
+		t2:   list.New(),
+		b1:   list.New(),
+		b2:   list.New(),
+		size: size,
+	}, nil
+}
+
+func (c *ARCCache) cached(e *arcEntry) bool {
+	return e.in == c.t1 || e.in == c.t2
+}
+
+func (c *ARCCache) moveToBack(key interface{}, e *arcEntry, l *list.List) {
+	e.in.Remove(e.order)
+	e.in = l
+	e.order = l.PushBack(key)
+}
+
+func (c *ARCCache) removeFront(l *list.List) {
+	key := l.Remove(l.Front())
+	delete(c.data, key)
+}
+
+// replace evicts an entry from t1 or t2 according to the target size of t1,
+// the key of the evicted entry is remembered in the corresponding ghost list
+func (c *ARCCache) replace(inB2 bool) (rkey interface{}, rvalue interface{}, err error) {
+	from, to := c.t2, c.b2
+
+	if c.t2.Len() == 0 || (c.t1.Len() > 0 && (c.t1.Len() > c.p || (inB2 && c.t1.Len() == c.p))) {
+		from, to = c.t1, c.b1
+	}
+
+	if from.Len() == 0 {
+		return nil, nil, fmt.Errorf("%w: evict requested in an empty cache", ErrIllegalState)
+	}
+
+	rkey = from.Front().Value
+
+	e := c.data[rkey]
+	rvalue = e.value
+
+	e.value = nil
+	c.moveToBack(rkey, e, to)
+
+	return rkey, rvalue, nil
+}
+
+func (c *ARCCache) Resize(size int) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for size < c.t1.Len()+c.t2.Len() {
+		c.replace(false)
+	}
+
+	c.size = size
+
+	if c.p > size {
+		c.p = size
+	}
+
+	c.trimGhosts()
+}
+
+// trimGhosts keeps the number of remembered keys bounded by the size of the cache
+func (c *ARCCache) trimGhosts() {
+	for c.b1.Len() > 0 && c.t1.Len()+c.b1.Len() > c.size {
+		c.removeFront(c.b1)
+	}
+
+	for c.b2.Len() > 0 && c.t1.Len()+c.t2.Len()+c.b1.Len()+c.b2.Len() > 2*c.size {
+		c.removeFront(c.b2)
+	}
+}
+
+func (c *ARCCache) Put(key interface{}, value interface{}) (rkey interface{}, rvalue interface{}, err error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if key == nil || value == nil {
+		return nil, nil, ErrIllegalArguments
+	}
+
+	e, ok := c.data[key]
+
+	if ok && c.cached(e) {
+		e.value = value
+		c.moveToBack(key, e, c.t2)
+		return nil, nil, nil
+	}
+
+	if ok {
+		// the key was recently evicted, so it's put back as frequently used
+		// and the target size of t1 is adapted to the list it was found in
+		inB2 := e.in == c.b2
+
+		if inB2 {
+			delta := 1
+			if c.b1.Len() > c.b2.Len() {
+				delta = c.b1.Len() / c.b2.Len()
+			}
+
+			c.p -= delta
+			if c.p < 0 {
+				c.p = 0
+			}
+		} else {
+			delta := 1
+			if c.b2.Len() > c.b1.Len() {
+				delta = c.b2.Len() / c.b1.Len()
+			}
+
+			c.p += delta
+			if c.p > c.size {
+				c.p = c.size
+			}
+		}
+
+		if c.t1.Len()+c.t2.Len() >= c.size {
+			rkey, rvalue, err = c.replace(inB2)
+		}
+
+		e.value = value
+		c.moveToBack(key, e, c.t2)
+
+		return rkey, rvalue, err
+	}
+
+	if c.t1.Len()+c.t2.Len() >= c.size {
+		rkey, rvalue, err = c.replace(false)
+	}
+
+	c.data[key] = &arcEntry{
+		value: value,
+		in:    c.t1,
+		order: c.t1.PushBack(key),
+	}
+
+	c.trimGhosts()
+
+	return rkey, rvalue, err
+}
+
+// Evict removes an entry from the cache, chosen as it would be to make room for a new one
+func (c *ARCCache) Evict() (rkey interface{}, rvalue interface{}, err error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.replace(false)
+}
+
+func (c *ARCCache) Get(key interface{}) (interface{}, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if key == nil {
+		return nil, ErrIllegalArguments
+	}
+
+	e, ok := c.data[key]
+	if !ok || !c.cached(e) {
+		return nil, ErrKeyNotFound
+	}
+
+	c.moveToBack(key, e, c.t2)
+
+	return e.value, nil
+}
+
+func (c *ARCCache) Pop(key interface{}) (interface{}, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if key == nil {
+		return nil, ErrIllegalArguments
+	}
+
+	e, ok := c.data[key]
+	if !ok || !c.cached(e) {
+		return nil, ErrKeyNotFound
+	}
+
+	e.in.Remove(e.order)
+	delete(c.data, key)
+
+	return e.value, nil
+}
+
+func (c *ARCCache) Replace(k interface{}, v interface{}) (interface{}, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if k == nil {
+		return nil, ErrIllegalArguments
+	}
+
+	e, ok := c.data[k]
+	if !ok || !c.cached(e) {
+		return nil, ErrKeyNotFound
+	}
+
+	oldV := e.value
+	e.value = v
+
+	return oldV, nil
+}
+
+func (c *ARCCache) Size() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.size
+}
+
+func (c *ARCCache) EntriesCount() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.t1.Len() + c.t2.Len()
+}
+
+func (c *ARCCache) Apply(fun func(k interface{}, v interface{}) error) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for k, e := range c.data {
+		if !c.cached(e) {
+			continue
+		}
+
+		err := fun(k, e.value)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
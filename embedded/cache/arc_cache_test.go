@@ -0,0 +1,284 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cache
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewCache(t *testing.T) {
+	_, err := NewCache(-1, 10)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = NewCache(ARCPolicy, 0)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	lru, err := NewCache(LRUPolicy, 10)
+	require.NoError(t, err)
+	require.IsType(t, &LRUCache{}, lru)
+
+	arc, err := NewCache(ARCPolicy, 10)
+	require.NoError(t, err)
+	require.IsType(t, &ARCCache{}, arc)
+
+	require.True(t, ValidPolicy(LRUPolicy))
+	require.True(t, ValidPolicy(ARCPolicy))
+	require.False(t, ValidPolicy(ARCPolicy+1))
+}
+
+func TestARCCache(t *testing.T) {
+	cacheSize := 10
+	cache, err := NewARCCache(cacheSize)
+	require.NoError(t, err)
+	require.Equal(t, cacheSize, cache.Size())
+
+	_, err = cache.Get(nil)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, _, err = cache.Put(nil, nil)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	for i := 0; i < cacheSize; i++ {
+		rkey, _, err := cache.Put(i, 10*i)
+		require.NoError(t, err)
+		require.Nil(t, rkey)
+	}
+
+	// entries read twice are kept while new ones get evicted
+	for i := 0; i < cacheSize/2; i++ {
+		v, err := cache.Get(i)
+		require.NoError(t, err)
+		require.Equal(t, 10*i, v)
+	}
+
+	for i := cacheSize; i < 3*cacheSize; i++ {
+		rkey, rvalue, err := cache.Put(i, 10*i)
+		require.NoError(t, err)
+		require.NotNil(t, rkey)
+		require.GreaterOrEqual(t, rkey.(int), cacheSize/2)
+		require.Equal(t, 10*rkey.(int), rvalue)
+	}
+
+	require.Equal(t, cacheSize, cache.EntriesCount())
+
+	for i := 0; i < cacheSize/2; i++ {
+		v, err := cache.Get(i)
+		require.NoError(t, err)
+		require.Equal(t, 10*i, v)
+	}
+
+	for i := cacheSize / 2; i < 2*cacheSize; i++ {
+		_, err := cache.Get(i)
+		require.ErrorIs(t, err, ErrKeyNotFound)
+	}
+
+	// putting an existing key updates its value
+	rkey, _, err := cache.Put(0, 1)
+	require.NoError(t, err)
+	require.Nil(t, rkey)
+
+	v, err := cache.Get(0)
+	require.NoError(t, err)
+	require.Equal(t, 1, v)
+}
+
+func TestARCCacheAdaptation(t *testing.T) {
+	cacheSize := 4
+	cache, err := NewARCCache(cacheSize)
+	require.NoError(t, err)
+
+	for i := 0; i < cacheSize; i++ {
+		_, _, err = cache.Put(i, i)
+		require.NoError(t, err)
+	}
+
+	for i := 0; i < cacheSize/2; i++ {
+		_, err = cache.Get(i)
+		require.NoError(t, err)
+	}
+
+	for i := cacheSize; i < 2*cacheSize; i++ {
+		_, _, err = cache.Put(i, i)
+		require.NoError(t, err)
+	}
+
+	// keys recently evicted from t1 make t1 to grow
+	_, _, err = cache.Put(cacheSize, cacheSize)
+	require.NoError(t, err)
+	require.Equal(t, 1, cache.p)
+
+	_, err = cache.Get(cacheSize)
+	require.NoError(t, err)
+	require.Equal(t, cacheSize, cache.EntriesCount())
+
+	// and keys recently evicted from t2 make t1 to shrink
+	for i := 0; i < cacheSize; i++ {
+		_, _, err = cache.Put(2*cacheSize+i, i)
+		require.NoError(t, err)
+
+		_, err = cache.Get(2*cacheSize + i)
+		require.NoError(t, err)
+	}
+
+	require.Positive(t, cache.b2.Len())
+
+	ghost := cache.b2.Back().Value
+
+	_, _, err = cache.Put(ghost, 0)
+	require.NoError(t, err)
+	require.Equal(t, 0, cache.p)
+
+	for i := 0; i < 2*cacheSize; i++ {
+		_, err = cache.Get(ghost)
+		require.NoError(t, err)
+
+		_, _, err = cache.Put(100+i, i)
+		require.NoError(t, err)
+	}
+
+	require.LessOrEqual(t, cache.t1.Len()+cache.b1.Len(), cacheSize)
+	require.LessOrEqual(t, cache.t1.Len()+cache.t2.Len()+cache.b1.Len()+cache.b2.Len(), 2*cacheSize)
+	require.Equal(t, len(cache.data), cache.t1.Len()+cache.t2.Len()+cache.b1.Len()+cache.b2.Len())
+}
+
+func TestARCCacheApply(t *testing.T) {
+	cacheSize := 10
+	cache, err := NewARCCache(cacheSize)
+	require.NoError(t, err)
+
+	for i := 0; i < 2*cacheSize; i++ {
+		_, _, err = cache.Put(i, 10*i)
+		require.NoError(t, err)
+	}
+
+	c := 0
+	err = cache.Apply(func(k, v interface{}) error {
+		require.Equal(t, 10*k.(int), v)
+		c++
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, cacheSize, c)
+
+	err = cache.Apply(func(k, v interface{}) error {
+		return errors.New("expected error")
+	})
+	require.Error(t, err)
+}
+
+func TestARCCachePopAndReplace(t *testing.T) {
+	cacheSize := 10
+	cache, err := NewARCCache(cacheSize)
+	require.NoError(t, err)
+
+	for i := 0; i < cacheSize+1; i++ {
+		_, _, err = cache.Put(i, 10*i)
+		require.NoError(t, err)
+	}
+
+	val, err := cache.Pop(5)
+	require.NoError(t, err)
+	require.Equal(t, 50, val)
+	require.Equal(t, cacheSize-1, cache.EntriesCount())
+
+	_, err = cache.Pop(5)
+	require.ErrorIs(t, err, ErrKeyNotFound)
+
+	// evicted entries can neither be popped nor replaced
+	_, err = cache.Pop(0)
+	require.ErrorIs(t, err, ErrKeyNotFound)
+
+	_, err = cache.Replace(0, 1)
+	require.ErrorIs(t, err, ErrKeyNotFound)
+
+	_, err = cache.Pop(nil)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	val, err = cache.Replace(6, 9999)
+	require.NoError(t, err)
+	require.Equal(t, 60, val)
+
+	val, err = cache.Get(6)
+	require.NoError(t, err)
+	require.Equal(t, 9999, val)
+
+	_, err = cache.Replace(nil, 1)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+}
+
+func TestARCCacheResizing(t *testing.T) {
+	initialCacheSize := 10
+	cache, err := NewARCCache(initialCacheSize)
+	require.NoError(t, err)
+
+	for i := 0; i < initialCacheSize; i++ {
+		rkey, _, err := cache.Put(i, i)
+		require.NoError(t, err)
+		require.Nil(t, rkey)
+	}
+
+	largerCacheSize := 20
+	cache.Resize(largerCacheSize)
+	require.Equal(t, largerCacheSize, cache.Size())
+
+	for i := initialCacheSize; i < largerCacheSize; i++ {
+		rkey, _, err := cache.Put(i, i)
+		require.NoError(t, err)
+		require.Nil(t, rkey)
+	}
+
+	cache.Resize(initialCacheSize)
+	require.Equal(t, initialCacheSize, cache.Size())
+	require.Equal(t, initialCacheSize, cache.EntriesCount())
+
+	for i := 0; i < initialCacheSize; i++ {
+		_, err = cache.Get(i)
+		require.ErrorIs(t, err, ErrKeyNotFound)
+	}
+
+	for i := initialCacheSize; i < largerCacheSize; i++ {
+		_, err = cache.Get(i)
+		require.NoError(t, err)
+	}
+}
+
+func TestARCCacheEvict(t *testing.T) {
+	cache, err := NewARCCache(5)
+	require.NoError(t, err)
+
+	_, _, err = cache.Evict()
+	require.ErrorIs(t, err, ErrIllegalState)
+
+	for i := 0; i < 5; i++ {
+		_, _, err = cache.Put(i, i*10)
+		require.NoError(t, err)
+	}
+
+	_, err = cache.Get(0)
+	require.NoError(t, err)
+
+	rkey, rvalue, err := cache.Evict()
+	require.NoError(t, err)
+	require.Equal(t, 1, rkey)
+	require.Equal(t, 10, rvalue)
+	require.Equal(t, 4, cache.EntriesCount())
+
+	_, err = cache.Get(1)
+	require.ErrorIs(t, err, ErrKeyNotFound)
+}
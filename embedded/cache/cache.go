@@ -0,0 +1,54 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cache
+
+// Cache holds up to Size entries, evicting some of them as new ones are put according to its replacement policy
+type Cache interface {
+	Put(key interface{}, value interface{}) (rkey interface{}, rvalue interface{}, err error)
+	Get(key interface{}) (interface{}, error)
+	Pop(key interface{}) (interface{}, error)
+	Replace(key interface{}, value interface{}) (interface{}, error)
+	Evict() (rkey interface{}, rvalue interface{}, err error)
+	Resize(size int)
+	Size() int
+	EntriesCount() int
+	Apply(fun func(k interface{}, v interface{}) error) error
+}
+
+// Replacement policies
+const (
+	// LRUPolicy evicts the least recently used entry
+	LRUPolicy = iota
+	// ARCPolicy balances recency and frequency of use, entries read only once
+	// (e.g. during a scan) don't evict the ones being read repeatedly
+	ARCPolicy
+)
+
+func ValidPolicy(policy int) bool {
+	return policy >= LRUPolicy && policy <= ARCPolicy
+}
+
+// NewCache creates a cache of the given size with the given replacement policy
+func NewCache(policy int, size int) (Cache, error) {
+	switch policy {
+	case LRUPolicy:
+		return NewLRUCache(size)
+	case ARCPolicy:
+		return NewARCCache(size)
+	}
+
+	return nil, ErrIllegalArguments
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cache
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const hitRatioCacheSize = 1_000
+
+// scanWorkload reads a set of hot keys, following a zipf distribution, interleaved with
+// scans reading a range of keys only once, as index lookups interleaved with full scans do
+func scanWorkload(rnd *rand.Rand, n int) []int {
+	zipf := rand.NewZipf(rnd, 1.1, 1, hitRatioCacheSize-1)
+
+	keys := make([]int, 0, n)
+	nextScanKey := hitRatioCacheSize
+
+	for len(keys) < n {
+		for i := 0; i < 5_000 && len(keys) < n; i++ {
+			keys = append(keys, int(zipf.Uint64()))
+		}
+
+		for i := 0; i < 2*hitRatioCacheSize && len(keys) < n; i++ {
+			keys = append(keys, nextScanKey)
+			nextScanKey++
+		}
+	}
+
+	return keys
+}
+
+// hitRatio replays the keys reading them from the cache and putting them on misses
+func hitRatio(t testing.TB, policy int, keys []int) float64 {
+	c, err := NewCache(policy, hitRatioCacheSize)
+	require.NoError(t, err)
+
+	hits := 0
+
+	for _, k := range keys {
+		_, err := c.Get(k)
+		if err == nil {
+			hits++
+			continue
+		}
+		require.ErrorIs(t, err, ErrKeyNotFound)
+
+		_, _, err = c.Put(k, k)
+		require.NoError(t, err)
+	}
+
+	return float64(hits) / float64(len(keys))
+}
+
+func TestARCHitRatioUnderScans(t *testing.T) {
+	keys := scanWorkload(rand.New(rand.NewSource(0)), 100_000)
+
+	lruHitRatio := hitRatio(t, LRUPolicy, keys)
+	arcHitRatio := hitRatio(t, ARCPolicy, keys)
+
+	require.Greater(t, arcHitRatio, lruHitRatio)
+}
+
+func BenchmarkHitRatio(b *testing.B) {
+	for _, policy := range []struct {
+		name   string
+		policy int
+	}{
+		{"lru", LRUPolicy},
+		{"arc", ARCPolicy},
+	} {
+		b.Run(policy.name, func(b *testing.B) {
+			keys := scanWorkload(rand.New(rand.NewSource(0)), b.N)
+
+			b.ResetTimer()
+
+			ratio := hitRatio(b, policy.policy, keys)
+
+			b.ReportMetric(ratio, "hit-ratio")
+		})
+	}
+}
@@ -91,6 +91,14 @@ func (c *LRUCache) Put(key interface{}, value interface{}) (rkey interface{}, rv
 	return nil, nil, nil
 }
 
+// Evict removes the least recently used entry from the cache
+func (c *LRUCache) Evict() (rkey interface{}, rvalue interface{}, err error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.evict()
+}
+
 func (c *LRUCache) evict() (rkey interface{}, rvalue interface{}, err error) {
 	if c.lruList.Len() == 0 {
 		return nil, nil, fmt.Errorf("%w: evict requested in an empty cache", ErrIllegalState)
@@ -212,3 +212,28 @@ func TestCacheResizing(t *testing.T) {
 		require.NoError(t, err)
 	}
 }
+
+func TestEvict(t *testing.T) {
+	cache, err := NewLRUCache(5)
+	require.NoError(t, err)
+
+	_, _, err = cache.Evict()
+	require.ErrorIs(t, err, ErrIllegalState)
+
+	for i := 0; i < 5; i++ {
+		_, _, err = cache.Put(i, i*10)
+		require.NoError(t, err)
+	}
+
+	_, err = cache.Get(0)
+	require.NoError(t, err)
+
+	rkey, rvalue, err := cache.Evict()
+	require.NoError(t, err)
+	require.Equal(t, 1, rkey)
+	require.Equal(t, 10, rvalue)
+	require.Equal(t, 4, cache.EntriesCount())
+
+	_, err = cache.Get(1)
+	require.ErrorIs(t, err, ErrKeyNotFound)
+}
@@ -0,0 +1,49 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+Package script runs Lua scripts to read and conditionally write keys within a single transaction,
+so read-modify-write patterns are run next to the data.
+
+Scripts are Lua 5.1 chunks run by gopher-lua, parameters are available through the params table:
+
+	local balance = tonumber(get("acc1"))
+	if balance < params.amount then
+		error("insufficient funds")
+	end
+	set("acc1", tostring(balance - params.amount))
+	set("acc2", tostring(tonumber(get("acc2")) + params.amount))
+	return balance - params.amount
+
+Each run is bounded by Limits: the length of every string, the total number of bytes of the strings
+produced, the number of instructions executed and the time spent, the script is aborted as soon as
+any of them is exceeded.
+
+Strings are byte strings, so keys and values are read and written as they are stored. Parameters may be
+nil, booleans, integers, strings and bytes, numbers are 64-bit floats so integers are exact up to 2^53.
+
+Besides the base, string, table and math libraries, excluding the functions able to reach outside
+the script, catch errors or build arbitrarily large strings in a single call, the following functions
+are available:
+
+	get(key)        value of the key, nil when the key does not exist
+	exists(key)     whether the key exists
+	set(key, value) sets the value of the key, values must be strings
+	del(key)        deletes the key, returns whether it existed
+
+Errors, raised by error() included, abort the script and nothing it wrote is kept.
+*/
+package script
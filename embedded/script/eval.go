@@ -0,0 +1,308 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package script
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+	"unsafe"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+// libs are the functions of the standard libraries available to scripts, those able to reach outside
+// the script, catch errors or build arbitrarily large strings in a single call are left out
+var libs = []struct {
+	name  string
+	open  lua.LGFunction
+	funcs []string
+}{
+	{lua.BaseLibName, lua.OpenBase, []string{
+		"assert", "error", "getmetatable", "ipairs", "next", "pairs", "rawequal", "rawget", "rawset",
+		"select", "setmetatable", "tonumber", "tostring", "type", "unpack", "_G", "_VERSION",
+	}},
+	{lua.StringLibName, lua.OpenString, []string{"__index", "byte", "char", "len", "lower", "reverse", "sub", "upper"}},
+	{lua.TabLibName, lua.OpenTable, []string{"getn", "insert", "maxn", "remove", "sort"}},
+	{lua.MathLibName, lua.OpenMath, []string{
+		"abs", "acos", "asin", "atan", "atan2", "ceil", "cos", "cosh", "deg", "exp", "floor", "fmod", "frexp",
+		"huge", "ldexp", "log", "log10", "max", "min", "mod", "modf", "pi", "pow", "rad", "sin", "sinh", "sqrt",
+		"tan", "tanh",
+	}},
+}
+
+func openLibs(L *lua.LState) error {
+	for _, lib := range libs {
+		err := L.CallByParam(lua.P{Fn: L.NewFunction(lib.open), NRet: 1}, lua.LString(lib.name))
+		if err != nil {
+			return err
+		}
+
+		tb, ok := L.Get(-1).(*lua.LTable)
+		if !ok {
+			return fmt.Errorf("%w: library '%s' could not be opened", ErrIllegalArguments, lib.name)
+		}
+		L.Pop(1)
+
+		allowed := make(map[string]struct{}, len(lib.funcs))
+		for _, f := range lib.funcs {
+			allowed[f] = struct{}{}
+		}
+
+		var names []lua.LValue
+
+		tb.ForEach(func(k, _ lua.LValue) {
+			_, ok := allowed[k.String()]
+			if !ok {
+				names = append(names, k)
+			}
+		})
+
+		for _, k := range names {
+			tb.RawSet(k, lua.LNil)
+		}
+	}
+
+	return nil
+}
+
+// evaluator is set as the context of the Lua state, whose Done method is called before each instruction
+// is executed, so steps, time and the strings held by the running function are accounted for there
+type evaluator struct {
+	L  *lua.LState
+	kv KV
+
+	limits   *Limits
+	deadline time.Time
+	steps    int
+	memory   int
+	// seen holds the strings already accounted for by the address of their bytes,
+	// so values moved around are accounted for only once
+	seen map[uintptr]struct{}
+
+	err  error
+	done chan struct{}
+}
+
+func newEvaluator(L *lua.LState, kv KV, limits *Limits) *evaluator {
+	return &evaluator{
+		L:        L,
+		kv:       kv,
+		limits:   limits,
+		deadline: time.Now().Add(limits.Timeout),
+		seen:     make(map[uintptr]struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+func (e *evaluator) register() {
+	e.L.SetGlobal("get", e.L.NewFunction(e.get))
+	e.L.SetGlobal("exists", e.L.NewFunction(e.exists))
+	e.L.SetGlobal("set", e.L.NewFunction(e.set))
+	e.L.SetGlobal("del", e.L.NewFunction(e.del))
+}
+
+func (e *evaluator) Deadline() (time.Time, bool) {
+	return e.deadline, true
+}
+
+func (e *evaluator) Done() <-chan struct{} {
+	if e.err == nil {
+		e.err = e.step()
+		if e.err != nil {
+			close(e.done)
+		}
+	}
+
+	return e.done
+}
+
+func (e *evaluator) Err() error {
+	return e.err
+}
+
+func (e *evaluator) Value(key interface{}) interface{} {
+	return nil
+}
+
+// step accounts for the execution of an instruction
+func (e *evaluator) step() error {
+	e.steps++
+
+	if e.steps > e.limits.MaxSteps {
+		return fmt.Errorf("%w: %d", ErrMaxStepsExceeded, e.limits.MaxSteps)
+	}
+
+	if time.Now().After(e.deadline) {
+		return fmt.Errorf("%w after %s", ErrTimeout, e.limits.Timeout)
+	}
+
+	// strings are built into the registers of the running function
+	for i := 1; i <= e.L.GetTop(); i++ {
+		s, ok := e.L.Get(i).(lua.LString)
+		if !ok {
+			continue
+		}
+
+		err := e.alloc(string(s))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// alloc accounts for a string, unless it was already accounted for
+func (e *evaluator) alloc(s string) error {
+	if len(s) == 0 {
+		return nil
+	}
+
+	if len(s) > e.limits.MaxValueLen {
+		return fmt.Errorf("%w: %d bytes", ErrMaxValueLenExceeded, e.limits.MaxValueLen)
+	}
+
+	addr := (*reflect.StringHeader)(unsafe.Pointer(&s)).Data
+
+	_, seen := e.seen[addr]
+	if seen {
+		return nil
+	}
+
+	if e.memory+len(s) > e.limits.MaxMemory {
+		return fmt.Errorf("%w: %d bytes", ErrMaxMemoryExceeded, e.limits.MaxMemory)
+	}
+
+	e.memory += len(s)
+	e.seen[addr] = struct{}{}
+
+	return nil
+}
+
+// raise aborts the script with err, which is returned as it is by unwrap
+func (e *evaluator) raise(err error) int {
+	ud := e.L.NewUserData()
+	ud.Value = fmt.Errorf("%s %w", e.L.Where(1), err)
+
+	e.L.Error(ud, 1)
+
+	return 0
+}
+
+// unwrap returns the error the script was aborted with
+func (e *evaluator) unwrap(err error) error {
+	if e.err != nil {
+		return e.err
+	}
+
+	var apiErr *lua.ApiError
+	if !errors.As(err, &apiErr) {
+		return err
+	}
+
+	if ud, ok := apiErr.Object.(*lua.LUserData); ok {
+		if err, ok := ud.Value.(error); ok {
+			return err
+		}
+	}
+
+	return fmt.Errorf("%w: %s", ErrScriptFailed, apiErr.Object.String())
+}
+
+func (e *evaluator) keyArg(n int) []byte {
+	key, ok := e.L.Get(n).(lua.LString)
+	if !ok || len(key) == 0 {
+		e.raise(fmt.Errorf("%w: keys must be non-empty strings", ErrTypeMismatch))
+	}
+
+	return []byte(key)
+}
+
+func (e *evaluator) read(key []byte) (lua.LValue, error) {
+	val, err := e.kv.Get(key)
+	if errors.Is(err, ErrKeyNotFound) {
+		return lua.LNil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s := string(val)
+
+	// values read are new ones
+	err = e.alloc(s)
+	if err != nil {
+		return nil, err
+	}
+
+	return lua.LString(s), nil
+}
+
+func (e *evaluator) get(L *lua.LState) int {
+	val, err := e.read(e.keyArg(1))
+	if err != nil {
+		return e.raise(err)
+	}
+
+	L.Push(val)
+
+	return 1
+}
+
+func (e *evaluator) exists(L *lua.LState) int {
+	val, err := e.read(e.keyArg(1))
+	if err != nil {
+		return e.raise(err)
+	}
+
+	L.Push(lua.LBool(val != lua.LNil))
+
+	return 1
+}
+
+func (e *evaluator) set(L *lua.LState) int {
+	key := e.keyArg(1)
+
+	val, ok := L.Get(2).(lua.LString)
+	if !ok {
+		return e.raise(fmt.Errorf("%w: values must be strings", ErrTypeMismatch))
+	}
+
+	err := e.kv.Set(key, []byte(val))
+	if err != nil {
+		return e.raise(err)
+	}
+
+	return 0
+}
+
+func (e *evaluator) del(L *lua.LState) int {
+	err := e.kv.Delete(e.keyArg(1))
+	if errors.Is(err, ErrKeyNotFound) {
+		L.Push(lua.LFalse)
+		return 1
+	}
+	if err != nil {
+		return e.raise(err)
+	}
+
+	L.Push(lua.LTrue)
+
+	return 1
+}
@@ -0,0 +1,223 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package script
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	lua "github.com/yuin/gopher-lua"
+	"github.com/yuin/gopher-lua/parse"
+)
+
+var ErrIllegalArguments = errors.New("illegal arguments")
+var ErrSyntax = errors.New("syntax error")
+var ErrTypeMismatch = errors.New("type mismatch")
+var ErrScriptFailed = errors.New("script failed")
+var ErrKeyNotFound = errors.New("key not found")
+var ErrMaxScriptLenExceeded = errors.New("max script length exceeded")
+var ErrMaxValueLenExceeded = errors.New("max value length exceeded")
+var ErrMaxMemoryExceeded = errors.New("max memory exceeded")
+var ErrMaxStepsExceeded = errors.New("max steps exceeded")
+var ErrTimeout = errors.New("script timed out")
+
+const MaxScriptLen = 64 * 1024
+
+const DefaultMaxValueLen = 32 * 1024 * 1024
+const DefaultMaxMemory = 64 * 1024 * 1024
+const DefaultMaxSteps = 100_000
+const DefaultTimeout = 5 * time.Second
+
+// maxExactInt is the largest integer a Lua number holds without loss of precision
+const maxExactInt = 1 << 53
+
+const callStackSize = 64
+const registrySize = 16 * 1024
+
+const chunkName = "script"
+
+// Limits bound the resources a script may use each time it's run
+type Limits struct {
+	// MaxValueLen is the max length of any string
+	MaxValueLen int
+	// MaxMemory is the max number of bytes of all the strings produced by the script
+	MaxMemory int
+	// MaxSteps is the max number of instructions executed
+	MaxSteps int
+	// Timeout is the max time the script may run for, reads and writes made through the KV included
+	Timeout time.Duration
+}
+
+func DefaultLimits() *Limits {
+	return &Limits{
+		MaxValueLen: DefaultMaxValueLen,
+		MaxMemory:   DefaultMaxMemory,
+		MaxSteps:    DefaultMaxSteps,
+		Timeout:     DefaultTimeout,
+	}
+}
+
+func (l *Limits) Validate() error {
+	if l == nil || l.MaxValueLen <= 0 || l.MaxMemory <= 0 || l.MaxSteps <= 0 || l.Timeout <= 0 {
+		return ErrIllegalArguments
+	}
+
+	return nil
+}
+
+// KV is the key-value access granted to a script, implementations are expected to keep
+// every read and write within the same transaction
+type KV interface {
+	// Get returns ErrKeyNotFound, possibly wrapped, when the key does not exist
+	Get(key []byte) ([]byte, error)
+	Set(key, value []byte) error
+	// Delete returns ErrKeyNotFound, possibly wrapped, when the key does not exist
+	Delete(key []byte) error
+}
+
+// Script is a compiled script, it can be run any number of times
+type Script struct {
+	proto *lua.FunctionProto
+}
+
+// Compile parses and compiles the source of a script
+func Compile(src string) (*Script, error) {
+	if len(src) == 0 {
+		return nil, ErrIllegalArguments
+	}
+
+	if len(src) > MaxScriptLen {
+		return nil, ErrMaxScriptLenExceeded
+	}
+
+	chunk, err := parse.Parse(strings.NewReader(src), chunkName)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrSyntax, err)
+	}
+
+	proto, err := lua.Compile(chunk, chunkName)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrSyntax, err)
+	}
+
+	return &Script{proto: proto}, nil
+}
+
+// Run evaluates the script over kv within the default limits, see RunWithLimits
+func (s *Script) Run(kv KV, params map[string]interface{}) (interface{}, error) {
+	return s.RunWithLimits(kv, params, DefaultLimits())
+}
+
+// RunWithLimits evaluates the script over kv, params are available through the params table within the script
+// and must be nil, bool, int64, string or []byte values. The first value returned by the script is returned
+// as nil, bool, int64 or []byte, nil when no value is returned.
+// The script is aborted as soon as any of the limits is exceeded.
+// Writes are made through kv as the script is evaluated, discarding them when an error is returned is
+// up to the caller
+func (s *Script) RunWithLimits(kv KV, params map[string]interface{}, limits *Limits) (interface{}, error) {
+	if kv == nil {
+		return nil, ErrIllegalArguments
+	}
+
+	err := limits.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	L := lua.NewState(lua.Options{
+		SkipOpenLibs:  true,
+		CallStackSize: callStackSize,
+		RegistrySize:  registrySize,
+	})
+	defer L.Close()
+
+	err = openLibs(L)
+	if err != nil {
+		return nil, err
+	}
+
+	luaParams := L.NewTable()
+
+	for name, v := range params {
+		lv, err := luaValue(v)
+		if err != nil {
+			return nil, fmt.Errorf("%w: parameter '%s'", err, name)
+		}
+
+		luaParams.RawSetString(name, lv)
+	}
+
+	L.SetGlobal("params", luaParams)
+
+	e := newEvaluator(L, kv, limits)
+	e.register()
+
+	L.SetContext(e)
+
+	L.Push(L.NewFunctionFromProto(s.proto))
+
+	err = L.PCall(0, 1, nil)
+	if err != nil {
+		return nil, e.unwrap(err)
+	}
+
+	return goValue(L.Get(-1))
+}
+
+// luaValue converts the value of a parameter
+func luaValue(v interface{}) (lua.LValue, error) {
+	switch tv := v.(type) {
+	case nil:
+		return lua.LNil, nil
+	case bool:
+		return lua.LBool(tv), nil
+	case int64:
+		if tv > maxExactInt || tv < -maxExactInt {
+			return nil, fmt.Errorf("%w: integers must be within +/-2^53", ErrIllegalArguments)
+		}
+		return lua.LNumber(tv), nil
+	case string:
+		return lua.LString(tv), nil
+	case []byte:
+		return lua.LString(tv), nil
+	}
+
+	return nil, fmt.Errorf("%w: unsupported type", ErrIllegalArguments)
+}
+
+// goValue converts the value returned by a script
+func goValue(lv lua.LValue) (interface{}, error) {
+	switch tv := lv.(type) {
+	case *lua.LNilType:
+		return nil, nil
+	case lua.LBool:
+		return bool(tv), nil
+	case lua.LNumber:
+		n := float64(tv)
+		if n != math.Trunc(n) || n > maxExactInt || n < -maxExactInt {
+			return nil, fmt.Errorf("%w: returned number %v is not an integer", ErrTypeMismatch, n)
+		}
+		return int64(n), nil
+	case lua.LString:
+		return []byte(tv), nil
+	}
+
+	return nil, fmt.Errorf("%w: returned value of type %s", ErrTypeMismatch, lv.Type())
+}
@@ -0,0 +1,310 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package script
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type mapKV map[string][]byte
+
+func (kv mapKV) Get(key []byte) ([]byte, error) {
+	v, ok := kv[string(key)]
+	if !ok {
+		return nil, ErrKeyNotFound
+	}
+	return v, nil
+}
+
+func (kv mapKV) Set(key, value []byte) error {
+	kv[string(key)] = value
+	return nil
+}
+
+func (kv mapKV) Delete(key []byte) error {
+	_, ok := kv[string(key)]
+	if !ok {
+		return ErrKeyNotFound
+	}
+	delete(kv, string(key))
+	return nil
+}
+
+func run(t *testing.T, kv KV, src string, params map[string]interface{}) (interface{}, error) {
+	s, err := Compile(src)
+	require.NoError(t, err)
+
+	return s.Run(kv, params)
+}
+
+func TestCompile(t *testing.T) {
+	_, err := Compile("")
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = Compile(strings.Repeat(" ", MaxScriptLen+1))
+	require.ErrorIs(t, err, ErrMaxScriptLenExceeded)
+
+	for _, src := range []string{
+		"local = 1",
+		"local if = 1",
+		"return 1 +",
+		"return (1",
+		"if true then return 1",
+		"return 'unterminated",
+		"return @p",
+		"x",
+		"break",
+	} {
+		_, err = Compile(src)
+		require.ErrorIs(t, err, ErrSyntax, src)
+	}
+
+	s, err := Compile(`
+		-- comments are skipped
+		local a = 1; local b = 'it\'s'
+		if a > 0 then return b elseif a < 0 then return "neg" else return "zero" end
+	`)
+	require.NoError(t, err)
+
+	_, err = s.Run(nil, nil)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = s.Run(mapKV{}, map[string]interface{}{"p": 1})
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = s.Run(mapKV{}, map[string]interface{}{"p": int64(1) << 54})
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	v, err := s.Run(mapKV{}, nil)
+	require.NoError(t, err)
+	require.Equal(t, []byte("it's"), v)
+}
+
+func TestEval(t *testing.T) {
+	kv := mapKV{"k1": []byte("v1"), "n": []byte("10")}
+
+	for _, tc := range []struct {
+		src      string
+		expected interface{}
+	}{
+		{"return 1 + 2 * 3 - -4", int64(11)},
+		{"return (1 + 2) * 3 % 4", int64(1)},
+		{"return 'a' .. \"b\"", []byte("ab")},
+		{"return get('k1') .. 'x'", []byte("v1x")},
+		{"return get('k1') == 'v1' and get('missing') == nil", true},
+		{"return not (1 < 2) or 'a' >= 'b'", false},
+		{"return tonumber(get('n')) + tonumber('5')", int64(15)},
+		{"return tostring(true) .. tostring(7) .. ('x'):upper()", []byte("true7X")},
+		{"return #'abc' + string.len(get('k1'))", int64(5)},
+		{"return exists('k1') and not exists('k2')", true},
+		{"local n = 0; for i = 1, 10 do n = n + i end; return n", int64(55)},
+		{"local t = {}; table.insert(t, 'a'); return #t", int64(1)},
+		{"return math.max(1, 2)", int64(2)},
+		{"return params.p + 1", int64(42)},
+		{"return params.b, 1", []byte("b")},
+		{"local x = 1 + 1", nil},
+		{"if false then return 1 end", nil},
+	} {
+		v, err := run(t, kv, tc.src, map[string]interface{}{"p": int64(41), "b": []byte("b")})
+		require.NoError(t, err, tc.src)
+		require.Equal(t, tc.expected, v, tc.src)
+	}
+
+	for _, tc := range []struct {
+		src string
+		err error
+	}{
+		{"return x + 1", ErrScriptFailed},
+		{"return params.missing + 1", ErrScriptFailed},
+		{"return 1 + {}", ErrScriptFailed},
+		{"return 1 / 2", ErrTypeMismatch},
+		{"return {}", ErrTypeMismatch},
+		{"print(1)", ErrScriptFailed},
+		{"return string.format('%d', 1)", ErrScriptFailed},
+		{"return string.rep('x', 10)", ErrScriptFailed},
+		{"return table.concat({'a', 'b'})", ErrScriptFailed},
+		{"return os.time()", ErrScriptFailed},
+		{"return require('os')", ErrScriptFailed},
+		{"return load('return 1')", ErrScriptFailed},
+		{"return pcall(error, 'caught')", ErrScriptFailed},
+		{"get('')", ErrTypeMismatch},
+		{"get(1)", ErrTypeMismatch},
+		{"set('k', 1)", ErrTypeMismatch},
+		{"error('aborted')", ErrScriptFailed},
+	} {
+		_, err := run(t, kv, tc.src, nil)
+		require.ErrorIs(t, err, tc.err, tc.src)
+	}
+
+	_, err := run(t, errKV{}, "set('k', 'v')", nil)
+	require.ErrorIs(t, err, errKVFailure)
+	require.Contains(t, err.Error(), "script:1:")
+}
+
+func TestConditionalWrites(t *testing.T) {
+	kv := mapKV{"acc1": []byte("100"), "acc2": []byte("0")}
+
+	s, err := Compile(`
+		local balance = tonumber(get("acc1"))
+		if balance < params.amount then
+			error("insufficient funds")
+		end
+		set("acc1", tostring(balance - params.amount))
+		set("acc2", tostring(tonumber(get("acc2")) + params.amount))
+		return balance - params.amount
+	`)
+	require.NoError(t, err)
+
+	v, err := s.Run(kv, map[string]interface{}{"amount": int64(60)})
+	require.NoError(t, err)
+	require.Equal(t, int64(40), v)
+	require.Equal(t, []byte("40"), kv["acc1"])
+	require.Equal(t, []byte("60"), kv["acc2"])
+
+	_, err = s.Run(kv, map[string]interface{}{"amount": int64(60)})
+	require.ErrorIs(t, err, ErrScriptFailed)
+	require.Contains(t, err.Error(), "insufficient funds")
+	require.Equal(t, []byte("40"), kv["acc1"])
+
+	// writes guarded by short-circuited operators are not made
+	_, err = run(t, kv, "local _ = exists('acc3') and del('acc3'); local _ = not exists('acc1') or del('acc1')", nil)
+	require.NoError(t, err)
+	require.NotContains(t, kv, "acc1")
+
+	v, err = run(t, kv, "return del('acc1')", nil)
+	require.NoError(t, err)
+	require.Equal(t, false, v)
+}
+
+func TestLimits(t *testing.T) {
+	s, err := Compile("return 1")
+	require.NoError(t, err)
+
+	_, err = s.RunWithLimits(mapKV{}, nil, nil)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = s.RunWithLimits(mapKV{}, nil, &Limits{})
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	// values doubling on each iteration would take 2GB
+	src := `local a = "xxxxxxxx"; for i = 1, 28 do a = a .. a end`
+
+	_, err = run(t, mapKV{}, src, nil)
+	require.ErrorIs(t, err, ErrMaxValueLenExceeded)
+
+	s, err = Compile(src)
+	require.NoError(t, err)
+
+	limits := DefaultLimits()
+	limits.MaxValueLen = 1024
+	limits.MaxMemory = 512
+
+	_, err = s.RunWithLimits(mapKV{}, nil, limits)
+	require.ErrorIs(t, err, ErrMaxMemoryExceeded)
+
+	limits = DefaultLimits()
+	limits.MaxValueLen = 1024
+
+	_, err = s.RunWithLimits(mapKV{}, nil, limits)
+	require.ErrorIs(t, err, ErrMaxValueLenExceeded)
+
+	// strings kept in tables are accounted for as well
+	s, err = Compile(`local t = {}; for i = 1, 100 do t[i] = "x" .. i end`)
+	require.NoError(t, err)
+
+	limits = DefaultLimits()
+	limits.MaxMemory = 100
+
+	_, err = s.RunWithLimits(mapKV{}, nil, limits)
+	require.ErrorIs(t, err, ErrMaxMemoryExceeded)
+
+	// values read from the kv are accounted for as well
+	s, err = Compile("get('k1'); get('k1'); return 1")
+	require.NoError(t, err)
+
+	limits = DefaultLimits()
+	limits.MaxMemory = 150
+
+	_, err = s.RunWithLimits(mapKV{"k1": make([]byte, 100)}, nil, limits)
+	require.ErrorIs(t, err, ErrMaxMemoryExceeded)
+
+	limits.MaxMemory = 250
+
+	v, err := s.RunWithLimits(mapKV{"k1": make([]byte, 100)}, nil, limits)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), v)
+
+	s, err = Compile("while true do end")
+	require.NoError(t, err)
+
+	limits = DefaultLimits()
+	limits.MaxSteps = 10
+
+	_, err = s.RunWithLimits(mapKV{}, nil, limits)
+	require.ErrorIs(t, err, ErrMaxStepsExceeded)
+
+	// errors raised by exceeding a limit can't be caught
+	s, err = Compile("local f = function() while true do end end; for i = 1, 10 do f() end")
+	require.NoError(t, err)
+
+	_, err = s.RunWithLimits(mapKV{}, nil, limits)
+	require.ErrorIs(t, err, ErrMaxStepsExceeded)
+
+	s, err = Compile("local n = 0; for i = 1, 10 do n = n + i end")
+	require.NoError(t, err)
+
+	limits.MaxSteps = 50
+
+	_, err = s.RunWithLimits(mapKV{}, nil, limits)
+	require.NoError(t, err)
+
+	limits = DefaultLimits()
+	limits.Timeout = time.Millisecond
+
+	_, err = s.RunWithLimits(slowKV{mapKV{}}, nil, limits)
+	require.NoError(t, err)
+
+	s, err = Compile("get('k1'); get('k1')")
+	require.NoError(t, err)
+
+	_, err = s.RunWithLimits(slowKV{mapKV{}}, nil, limits)
+	require.ErrorIs(t, err, ErrTimeout)
+}
+
+type slowKV struct {
+	mapKV
+}
+
+func (kv slowKV) Get(key []byte) ([]byte, error) {
+	time.Sleep(10 * time.Millisecond)
+	return kv.mapKV.Get(key)
+}
+
+var errKVFailure = errors.New("kv failure")
+
+type errKV struct {
+	mapKV
+}
+
+func (kv errKV) Set(key, value []byte) error {
+	return errKVFailure
+}
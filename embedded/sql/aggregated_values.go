@@ -16,6 +16,8 @@ limitations under the License.
 
 package sql
 
+import "fmt"
+
 type AggregatedValue interface {
 	TypedValue
 	updateWith(val TypedValue) error
@@ -108,6 +110,10 @@ func (v *CountValue) selectorRanges(table *Table, asTable string, params map[str
 	return nil
 }
 
+func (v *CountValue) String() string {
+	return fmt.Sprintf("%d", v.c)
+}
+
 type SumValue struct {
 	s   int64
 	sel string
@@ -198,6 +204,10 @@ func (v *SumValue) selectorRanges(table *Table, asTable string, params map[strin
 	return nil
 }
 
+func (v *SumValue) String() string {
+	return fmt.Sprintf("%d", v.s)
+}
+
 type MinValue struct {
 	val TypedValue
 	sel string
@@ -291,6 +301,14 @@ func (v *MinValue) selectorRanges(table *Table, asTable string, params map[strin
 	return nil
 }
 
+func (v *MinValue) String() string {
+	if v.val == nil {
+		return "NULL"
+	}
+
+	return v.val.String()
+}
+
 type MaxValue struct {
 	val TypedValue
 	sel string
@@ -384,6 +402,14 @@ func (v *MaxValue) selectorRanges(table *Table, asTable string, params map[strin
 	return nil
 }
 
+func (v *MaxValue) String() string {
+	if v.val == nil {
+		return "NULL"
+	}
+
+	return v.val.String()
+}
+
 type AVGValue struct {
 	s   int64
 	c   int64
@@ -477,3 +503,11 @@ func (v *AVGValue) isConstant() bool {
 func (v *AVGValue) selectorRanges(table *Table, asTable string, params map[string]interface{}, rangesByColID map[uint32]*typedValueRange) error {
 	return nil
 }
+
+func (v *AVGValue) String() string {
+	if v.c == 0 {
+		return "NULL"
+	}
+
+	return fmt.Sprintf("%d", v.s/v.c)
+}
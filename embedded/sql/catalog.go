@@ -26,6 +26,9 @@ type Database struct {
 	name         string
 	tablesByID   map[uint32]*Table
 	tablesByName map[string]*Table
+	viewsByName  map[string]*View
+
+	proceduresByName map[string]*Procedure
 }
 
 type Table struct {
@@ -40,6 +43,9 @@ type Table struct {
 	primaryIndex    *Index
 	autoIncrementPK bool
 	maxPK           int64
+	policies        map[string]*Policy
+	stats           *TableStats // nil until the table is analyzed
+	checks          []ValueExp
 }
 
 type Index struct {
@@ -58,6 +64,7 @@ type Column struct {
 	maxLen        int
 	autoIncrement bool
 	notNull       bool
+	defaultValue  ValueExp
 }
 
 func newCatalog() *Catalog {
@@ -84,6 +91,9 @@ func (c *Catalog) newDatabase(id uint32, name string) (*Database, error) {
 		name:         name,
 		tablesByID:   map[uint32]*Table{},
 		tablesByName: map[string]*Table{},
+		viewsByName:  map[string]*View{},
+
+		proceduresByName: map[string]*Procedure{},
 	}
 
 	c.dbsByID[db.id] = db
@@ -241,11 +251,24 @@ func (i *Index) IncludesCol(colID uint32) bool {
 	return ok
 }
 
-func (i *Index) sortableUsing(colID uint32, rangesByColID map[uint32]*typedValueRange) bool {
-	// all columns before colID must be fixedValues otherwise the index can not be used
+// sortableUsing returns true when the index entries are sorted by the given columns,
+// index columns preceding or in between them must be fixed by unitary ranges
+func (i *Index) sortableUsing(colIDs []uint32, rangesByColID map[uint32]*typedValueRange) bool {
+	if len(colIDs) == 0 {
+		return false
+	}
+
+	sorted := 0
+
 	for _, col := range i.cols {
-		if col.id == colID {
-			return true
+		if col.id == colIDs[sorted] {
+			sorted++
+
+			if sorted == len(colIDs) {
+				return true
+			}
+
+			continue
 		}
 
 		colRange, ok := rangesByColID[col.id]
@@ -255,9 +278,24 @@ func (i *Index) sortableUsing(colID uint32, rangesByColID map[uint32]*typedValue
 
 		return false
 	}
+
 	return false
 }
 
+// containsInOrder returns true when the given columns are indexed in the same order,
+// even if there are other columns in between
+func (i *Index) containsInOrder(colIDs []uint32) bool {
+	j := 0
+
+	for _, col := range i.cols {
+		if j < len(colIDs) && col.id == colIDs[j] {
+			j++
+		}
+	}
+
+	return j == len(colIDs)
+}
+
 func (i *Index) prefix() string {
 	if i.IsPrimary() {
 		return PIndexPrefix
@@ -280,6 +318,10 @@ func (db *Database) newTable(name string, colsSpec []*ColSpec) (table *Table, er
 		return nil, ErrTableAlreadyExists
 	}
 
+	if db.ExistView(name) {
+		return nil, ErrViewAlreadyExists
+	}
+
 	id := len(db.tablesByID) + 1
 
 	table = &Table{
@@ -291,6 +333,7 @@ func (db *Database) newTable(name string, colsSpec []*ColSpec) (table *Table, er
 		colsByName:     make(map[string]*Column),
 		indexes:        make(map[string]*Index),
 		indexesByColID: make(map[uint32][]*Index),
+		policies:       make(map[string]*Policy),
 	}
 
 	for i, cs := range colsSpec {
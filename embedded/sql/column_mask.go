@@ -0,0 +1,67 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sql
+
+import "time"
+
+// ColumnMask transforms the values read from table columns before queries evaluate them,
+// so filters, projections and aggregations are all computed over the transformed values.
+// value is nil for NULL values, the returned value must be of the type of the column or nil.
+type ColumnMask func(table *Table, col *Column, value interface{}) (interface{}, error)
+
+// SetColumnMask makes the values read from tables within the tx to be transformed by mask
+func (sqlTx *SQLTx) SetColumnMask(mask ColumnMask) {
+	sqlTx.colMask = mask
+}
+
+func (sqlTx *SQLTx) maskValue(table *Table, col *Column, val TypedValue) (TypedValue, error) {
+	var value interface{}
+
+	if !val.IsNull() {
+		value = val.Value()
+	}
+
+	masked, err := sqlTx.colMask(table, col, value)
+	if err != nil {
+		return nil, err
+	}
+
+	var mval TypedValue
+
+	switch v := masked.(type) {
+	case nil:
+		return &NullValue{t: col.colType}, nil
+	case int64:
+		mval = &Number{val: v}
+	case string:
+		mval = &Varchar{val: v}
+	case bool:
+		mval = &Bool{val: v}
+	case []byte:
+		mval = &Blob{val: v}
+	case time.Time:
+		mval = &Timestamp{val: v}
+	default:
+		return nil, ErrInvalidValue
+	}
+
+	if mval.Type() != col.colType {
+		return nil, ErrInvalidTypes
+	}
+
+	return mval, nil
+}
@@ -0,0 +1,246 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package sql
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/codenotary/immudb/embedded/store"
+)
+
+// DefaultValue returns the SQL representation of the value the column takes when not specified on insertion,
+// it's empty when the column has no default value
+func (c *Column) DefaultValue() string {
+	if c.defaultValue == nil {
+		return ""
+	}
+
+	return c.defaultValue.String()
+}
+
+// Checks returns the SQL representation of the conditions rows must satisfy to be inserted or updated
+func (t *Table) Checks() []string {
+	checks := make([]string, len(t.checks))
+
+	for i, check := range t.checks {
+		checks[i] = check.String()
+	}
+
+	return checks
+}
+
+// colDescriptors returns the descriptors of the columns of the table, by selector
+func (t *Table) colDescriptors() map[string]ColDescriptor {
+	cols := make(map[string]ColDescriptor, len(t.cols))
+
+	for _, c := range t.cols {
+		colDescriptor := ColDescriptor{
+			Database: t.db.name,
+			Table:    t.name,
+			Column:   c.colName,
+			Type:     c.colType,
+		}
+
+		cols[colDescriptor.Selector()] = colDescriptor
+	}
+
+	return cols
+}
+
+// setDefaultValue validates the default value of the column, which can not reference columns nor parameters,
+// and is evaluated for each row e.g. NOW() or RANDOM_UUID()
+func (t *Table) setDefaultValue(col *Column, exp ValueExp) error {
+	if col.autoIncrement {
+		return fmt.Errorf("%w: auto incremental column '%s' can not have a default value", ErrIllegalArguments, col.colName)
+	}
+
+	params := make(map[string]SQLValueType)
+
+	err := exp.requiresType(col.colType, make(map[string]ColDescriptor), params, t.db.name, t.name)
+	if err != nil {
+		return err
+	}
+
+	if len(params) > 0 {
+		return fmt.Errorf("%w: default value of column '%s'", ErrUnsupportedParameter, col.colName)
+	}
+
+	col.defaultValue = exp
+
+	return nil
+}
+
+// newCheck validates the condition rows of the table must satisfy, which can not reference parameters
+func (t *Table) newCheck(exp ValueExp) error {
+	params := make(map[string]SQLValueType)
+
+	err := exp.requiresType(BooleanType, t.colDescriptors(), params, t.db.name, t.name)
+	if err != nil {
+		return err
+	}
+
+	if len(params) > 0 {
+		return fmt.Errorf("%w: check %s", ErrUnsupportedParameter, exp.String())
+	}
+
+	t.checks = append(t.checks, exp)
+
+	return nil
+}
+
+// defaultValueFor returns the value of a column not specified on insertion
+func (tx *SQLTx) defaultValueFor(col *Column) (TypedValue, error) {
+	if col.defaultValue == nil {
+		return &NullValue{t: col.colType}, nil
+	}
+
+	return col.defaultValue.reduce(tx.catalog, nil, col.table.db.name, col.table.name)
+}
+
+// validateChecks returns an error when the row does not satisfy any of the checks of the table,
+// checks evaluated to NULL are satisfied
+func (tx *SQLTx) validateChecks(table *Table, valuesByColID map[uint32]TypedValue) error {
+	if len(table.checks) == 0 {
+		return nil
+	}
+
+	row := &Row{Values: make(map[string]TypedValue, len(table.cols))}
+
+	for _, col := range table.cols {
+		val, ok := valuesByColID[col.id]
+		if !ok {
+			val = &NullValue{t: col.colType}
+		}
+
+		row.Values[EncodeSelector("", table.db.name, table.name, col.colName)] = val
+	}
+
+	for _, check := range table.checks {
+		r, err := check.reduce(tx.catalog, row, table.db.name, table.name)
+		if err != nil {
+			return err
+		}
+
+		if r.IsNull() {
+			continue
+		}
+
+		if !r.Value().(bool) {
+			return fmt.Errorf("%w: %s", ErrCheckConstraintViolation, check.String())
+		}
+	}
+
+	return nil
+}
+
+// persistConstraints stores the default values and checks of a newly created table
+func (tx *SQLTx) persistConstraints(table *Table) error {
+	for _, col := range table.cols {
+		if col.defaultValue == nil {
+			continue
+		}
+
+		// v={default value expression}
+		mappedKey := mapKey(tx.sqlPrefix(), catalogDefaultPrefix, EncodeID(table.db.id), EncodeID(table.id), EncodeID(col.id))
+
+		err := tx.set(mappedKey, nil, []byte(col.defaultValue.String()))
+		if err != nil {
+			return err
+		}
+	}
+
+	for i, check := range table.checks {
+		// v={check expression}
+		mappedKey := mapKey(tx.sqlPrefix(), catalogCheckPrefix, EncodeID(table.db.id), EncodeID(table.id), EncodeID(uint32(i+1)))
+
+		err := tx.set(mappedKey, nil, []byte(check.String()))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (table *Table) loadConstraints(sqlPrefix []byte, tx *store.OngoingTx) error {
+	defaultsPrefix := mapKey(sqlPrefix, catalogDefaultPrefix, EncodeID(table.db.id), EncodeID(table.id))
+
+	err := loadExps(tx, defaultsPrefix, func(id uint32, exp ValueExp) error {
+		col, err := table.GetColumnByID(id)
+		if err != nil {
+			return ErrCorruptedData
+		}
+
+		return table.setDefaultValue(col, exp)
+	})
+	if err != nil {
+		return err
+	}
+
+	checksPrefix := mapKey(sqlPrefix, catalogCheckPrefix, EncodeID(table.db.id), EncodeID(table.id))
+
+	return loadExps(tx, checksPrefix, func(id uint32, exp ValueExp) error {
+		if int(id) != len(table.checks)+1 {
+			return ErrCorruptedData
+		}
+
+		return table.newCheck(exp)
+	})
+}
+
+// loadExps reads the expressions stored under prefix, keyed by id
+func loadExps(tx *store.OngoingTx, prefix []byte, fn func(id uint32, exp ValueExp) error) error {
+	reader, err := tx.NewKeyReader(&store.KeyReaderSpec{
+		Prefix: prefix,
+		Filter: store.IgnoreDeleted,
+	})
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	for {
+		mkey, vref, err := reader.Read()
+		if err == store.ErrNoMoreEntries {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		if len(mkey) != len(prefix)+EncIDLen {
+			return ErrCorruptedData
+		}
+
+		v, err := vref.Resolve()
+		if err != nil {
+			return err
+		}
+
+		exp, err := parseExp(string(v))
+		if err != nil {
+			return err
+		}
+
+		err = fn(binary.BigEndian.Uint32(mkey[len(prefix):]), exp)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
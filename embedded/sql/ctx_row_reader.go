@@ -0,0 +1,86 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sql
+
+import "context"
+
+// ctxRowReader stops reading rows once the provided context is done
+type ctxRowReader struct {
+	rowReader RowReader
+
+	ctx context.Context
+}
+
+func newCtxRowReader(ctx context.Context, rowReader RowReader) *ctxRowReader {
+	return &ctxRowReader{
+		rowReader: rowReader,
+		ctx:       ctx,
+	}
+}
+
+func (cr *ctxRowReader) onClose(callback func()) {
+	cr.rowReader.onClose(callback)
+}
+
+func (cr *ctxRowReader) Tx() *SQLTx {
+	return cr.rowReader.Tx()
+}
+
+func (cr *ctxRowReader) Database() *Database {
+	return cr.rowReader.Database()
+}
+
+func (cr *ctxRowReader) TableAlias() string {
+	return cr.rowReader.TableAlias()
+}
+
+func (cr *ctxRowReader) SetParameters(params map[string]interface{}) error {
+	return cr.rowReader.SetParameters(params)
+}
+
+func (cr *ctxRowReader) OrderBy() []ColDescriptor {
+	return cr.rowReader.OrderBy()
+}
+
+func (cr *ctxRowReader) ScanSpecs() *ScanSpecs {
+	return cr.rowReader.ScanSpecs()
+}
+
+func (cr *ctxRowReader) Columns() ([]ColDescriptor, error) {
+	return cr.rowReader.Columns()
+}
+
+func (cr *ctxRowReader) colsBySelector() (map[string]ColDescriptor, error) {
+	return cr.rowReader.colsBySelector()
+}
+
+func (cr *ctxRowReader) InferParameters(params map[string]SQLValueType) error {
+	return cr.rowReader.InferParameters(params)
+}
+
+func (cr *ctxRowReader) Read() (*Row, error) {
+	err := cr.ctx.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return cr.rowReader.Read()
+}
+
+func (cr *ctxRowReader) Close() error {
+	return cr.rowReader.Close()
+}
@@ -0,0 +1,170 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+Package sql provides a verifiable SQL engine built on top of the embedded store.
+
+The engine doesn't depend on any server component and can be used directly
+by Go applications over their own store instance:
+
+	st, err := store.Open("data", store.DefaultOptions())
+	if err != nil {
+		return err
+	}
+	defer st.Close()
+
+	engine, err := sql.Open(st, sql.DefaultOptions().WithPrefix([]byte("sql")))
+	if err != nil {
+		return err
+	}
+
+	_, _, err = engine.ExecContext(ctx, "CREATE DATABASE db1", nil, nil)
+	if err != nil {
+		return err
+	}
+
+	err = engine.SetDefaultDatabase("db1")
+	if err != nil {
+		return err
+	}
+
+	_, _, err = engine.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS customers (id INTEGER AUTO_INCREMENT, name VARCHAR, PRIMARY KEY id);
+		INSERT INTO customers (name) VALUES (@name);`,
+		map[string]interface{}{"name": sql.NewVarchar("John")}, nil)
+	if err != nil {
+		return err
+	}
+
+	rows, err := engine.QueryRows(ctx, "SELECT id, name FROM customers", nil, nil)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int64
+		var name string
+
+		err = rows.Scan(&id, &name)
+		if err != nil {
+			return err
+		}
+		...
+	}
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+Parameters may be given as Go values or as typed values built with NewInteger,
+NewVarchar, NewBool, NewBlob, NewTimestamp or NewNull, the latter being the way
+to pass a NULL of a given type. Rows can be scanned into Go values, NULL values
+only into *TypedValue or *interface{} destinations. Engine.QueryContext returns
+the underlying RowReader for callers needing full control over the rows read.
+
+Catalog and table data are stored under the key prefix configured with
+Options.WithPrefix, keeping SQL data apart from any other key the application
+writes into the same store.
+
+Statements are executed within implicitly created transactions unless an
+interactive transaction obtained with Engine.NewTx (or BEGIN TRANSACTION) is
+provided, in which case it must be finished with SQLTx.Commit or SQLTx.Cancel.
+
+Prefixing a SELECT with EXPLAIN makes it return its plan, one row per operator
+with the indexes scanned, the join strategy and the estimated number of rows.
+EXPLAIN ANALYZE also runs the query and reports the rows actually returned by
+each operator along with the time spent reading them.
+
+ANALYZE TABLE collects the row count and the distinct, null, min and max values
+of the indexed columns of a table (ANALYZE alone analyzes all of them). Queries
+over analyzed tables not requiring sorted rows scan the index estimated to read
+the fewest rows, and single inner joins read first from the cheapest table.
+Statistics are refreshed once enough rows are modified, see Options.WithAutoAnalyzeRatio.
+
+Large tables are better paginated by keyset than by OFFSET, since skipped rows
+still have to be scanned. Rows can be compared as tuples in lexicographical order,
+so the next page starts right after the last row read, seeking the index on the
+ordering columns instead of scanning it from the beginning:
+
+	SELECT id, a, b FROM t WHERE (a, b) > (@a, @b) ORDER BY a, b LIMIT 100
+
+OFFSET is skipped by the index scan itself, without reading the skipped rows,
+as long as every scanned row is returned i.e. there are no conditions, joins,
+grouping or distinct clauses.
+
+Columns may declare a DEFAULT value, evaluated for each inserted row not
+specifying it, e.g. NOW() or RANDOM_UUID(), and tables may declare CHECK
+conditions, either next to a column or after the primary key, that every
+inserted or updated row must satisfy:
+
+	CREATE TABLE orders (
+		id VARCHAR[36] DEFAULT RANDOM_UUID(),
+		qty INTEGER NOT NULL DEFAULT 1 CHECK (qty > 0),
+		price INTEGER,
+		discount INTEGER DEFAULT 0,
+		PRIMARY KEY id,
+		CHECK (discount <= price)
+	)
+
+INSERT fails when a row with the same primary key already exists, either at
+execution time or, if inserted by a concurrent transaction, when committing.
+UPSERT overwrites existing rows instead, while ON CONFLICT specifies how INSERT
+handles them: DO NOTHING skips them and DO UPDATE updates them, referencing the
+values proposed for insertion as EXCLUDED.col:
+
+	INSERT INTO stock(sku, qty) VALUES ('a', 10), ('b', 5)
+	ON CONFLICT DO UPDATE SET qty = qty + EXCLUDED.qty
+
+Builtin functions can be called from selectors, conditions and default values:
+SUBSTRING, CONCAT, LOWER, UPPER and LENGTH over strings, DATE_TRUNC and EXTRACT
+over timestamps, SHA256 and UUID (or RANDOM_UUID) along with NOW. They return
+NULL when any argument is NULL, except CONCAT which skips NULL arguments.
+Selected function calls are named colN unless aliased:
+
+	SELECT UPPER(name) AS uname, EXTRACT(YEAR FROM ts) FROM events WHERE LENGTH(name) > 3
+
+Views are named queries read from as tables. They are not materialized but
+expanded into subqueries when planning the queries reading from them, thus the
+policies of the underlying tables still apply. View definitions are kept in the
+catalog, so previous ones remain in its history after being dropped:
+
+	CREATE VIEW adults AS SELECT id, name FROM people WHERE age >= 18
+
+Procedures are sequences of INSERT, UPSERT, UPDATE and DELETE statements over
+typed parameters, kept in the catalog and executed with CALL within the
+transaction of the CALL statement, so their changes are committed at once.
+IF blocks may check the rows affected by the previous statement with
+ROW_COUNT(), and RAISE discards the changes of the whole transaction:
+
+	CREATE PROCEDURE transfer(src INTEGER, dst INTEGER, amount INTEGER) AS BEGIN
+		UPDATE accounts SET balance = balance - @amount WHERE id = @src AND balance >= @amount;
+		IF ROW_COUNT() = 0 THEN
+			RAISE 'insufficient funds';
+		END IF;
+		UPDATE accounts SET balance = balance + @amount WHERE id = @dst;
+	END;
+
+	CALL transfer(1, 2, 100)
+
+HISTORY OF reads every committed revision of the rows of a table, oldest first,
+along with the id (_tx_id), time (_tx_ts) and user (_tx_user) of the transaction
+each one was committed in, see SQLTx.SetUser. Deleted rows are read with their
+last values and _deleted set to TRUE:
+
+	SELECT balance, _tx_id, _tx_ts, _tx_user FROM HISTORY OF accounts WHERE id = @id
+*/
+package sql
@@ -17,6 +17,7 @@ package sql
 
 import (
 	"bytes"
+	"context"
 	"encoding/binary"
 	"errors"
 	"fmt"
@@ -53,7 +54,7 @@ var ErrInvalidNumberOfValues = errors.New("invalid number of values provided")
 var ErrInvalidValue = errors.New("invalid value provided")
 var ErrInferredMultipleTypes = errors.New("inferred multiple types")
 var ErrExpectingDQLStmt = errors.New("illegal statement. DQL statement expected")
-var ErrLimitedOrderBy = errors.New("order is limit to one indexed column")
+var ErrLimitedOrderBy = errors.New("order is limited to indexed columns sorted in the same direction")
 var ErrLimitedGroupBy = errors.New("group by requires ordering by the grouping column")
 var ErrIllegalMappedKey = errors.New("error illegal mapped key")
 var ErrCorruptedData = store.ErrCorruptedData
@@ -80,6 +81,14 @@ var ErrTooManyRows = errors.New("too many rows")
 var ErrAlreadyClosed = store.ErrAlreadyClosed
 var ErrAmbiguousSelector = errors.New("ambiguous selector")
 var ErrUnsupportedCast = errors.New("unsupported cast")
+var ErrPolicyAlreadyExists = errors.New("policy already exists")
+var ErrPolicyDoesNotExist = errors.New("policy does not exist")
+var ErrCheckConstraintViolation = errors.New("check constraint violation")
+var ErrViewAlreadyExists = errors.New("view already exists")
+var ErrViewDoesNotExist = errors.New("view does not exist")
+var ErrProcedureAlreadyExists = errors.New("procedure already exists")
+var ErrProcedureDoesNotExist = errors.New("procedure does not exist")
+var ErrProcedureRaised = errors.New("procedure raised an error")
 
 var maxKeyLen = 256
 
@@ -88,6 +97,9 @@ const EncLenLen = 4
 
 const MaxNumberOfColumnsInIndex = 8
 
+// Engine executes SQL statements over an embedded store.ImmuStore.
+// The catalog and all table data are kept in the same store, under the key prefix set with Options.WithPrefix,
+// so several engines (or other key-value data) may share a single store as long as their prefixes don't overlap.
 type Engine struct {
 	store *store.ImmuStore
 
@@ -98,6 +110,10 @@ type Engine struct {
 	defaultDatabase string
 
 	mutex sync.RWMutex
+
+	autoAnalyzeRatio float64
+	modifiedRows     map[tableID]int64 // rows modified since the last analysis of the table
+	statsMutex       sync.Mutex
 }
 
 //SQLTx (no-thread safe) represents an interactive or incremental transaction with support of RYOW
@@ -111,26 +127,42 @@ type SQLTx struct {
 
 	explicitClose bool
 
+	distinctRowsLimit int
+
 	updatedRows      int
 	lastInsertedPKs  map[string]int64 // last inserted PK by table name
 	firstInsertedPKs map[string]int64 // first inserted PK by table name
 
 	txHeader *store.TxHeader // header is set once tx is committed
 
+	colMask ColumnMask
+
+	sessionAttrs map[string]interface{} // policies are enforced when set
+
+	modifiedRows   map[tableID]int64
+	analyzedTables map[tableID]struct{}
+
+	savepoints []*savepoint
+	aborted    bool // set when a statement fails within a tx holding savepoints, until it's rolled back to one of them
+
 	committed bool
 	closed    bool
 }
 
+// NewEngine returns an engine operating over the provided store.
+// The store is owned by the caller, who is responsible for closing it once the engine is no longer used.
 func NewEngine(store *store.ImmuStore, opts *Options) (*Engine, error) {
 	if store == nil || !ValidOpts(opts) {
 		return nil, ErrIllegalArguments
 	}
 
 	e := &Engine{
-		store:         store,
-		prefix:        make([]byte, len(opts.prefix)),
-		distinctLimit: opts.distinctLimit,
-		autocommit:    opts.autocommit,
+		store:            store,
+		prefix:           make([]byte, len(opts.prefix)),
+		distinctLimit:    opts.distinctLimit,
+		autocommit:       opts.autocommit,
+		autoAnalyzeRatio: opts.autoAnalyzeRatio,
+		modifiedRows:     make(map[tableID]int64),
 	}
 
 	copy(e.prefix, opts.prefix)
@@ -141,6 +173,13 @@ func NewEngine(store *store.ImmuStore, opts *Options) (*Engine, error) {
 	return e, nil
 }
 
+// Open returns an engine operating over the provided store, it's the entry point of Go applications embedding the engine.
+// It's equivalent to NewEngine, the store is owned by the caller who is responsible for closing it.
+func Open(store *store.ImmuStore, opts *Options) (*Engine, error) {
+	return NewEngine(store, opts)
+}
+
+// SetDefaultDatabase sets the database used by transactions not specifying one with USE DATABASE
 func (e *Engine) SetDefaultDatabase(dbName string) error {
 	tx, err := e.newTx(false)
 	if err != nil {
@@ -161,6 +200,21 @@ func (e *Engine) SetDefaultDatabase(dbName string) error {
 	return nil
 }
 
+// SetDistinctLimit sets the maximum number of rows a DISTINCT clause may hold in memory,
+// it's applied to the transactions started afterwards
+func (e *Engine) SetDistinctLimit(distinctLimit int) error {
+	if distinctLimit < 1 {
+		return ErrIllegalArguments
+	}
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	e.distinctLimit = distinctLimit
+
+	return nil
+}
+
 func (e *Engine) DefaultDatabase() string {
 	e.mutex.RLock()
 	defer e.mutex.RUnlock()
@@ -168,6 +222,20 @@ func (e *Engine) DefaultDatabase() string {
 	return e.defaultDatabase
 }
 
+// NewTx begins an interactive transaction, it must be either committed or cancelled by the caller
+func (e *Engine) NewTx(ctx context.Context) (*SQLTx, error) {
+	if ctx == nil {
+		return nil, ErrIllegalArguments
+	}
+
+	err := ctx.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return e.newTx(true)
+}
+
 func (e *Engine) newTx(explicitClose bool) (*SQLTx, error) {
 	e.mutex.RLock()
 	defer e.mutex.RUnlock()
@@ -196,13 +264,14 @@ func (e *Engine) newTx(explicitClose bool) (*SQLTx, error) {
 	}
 
 	return &SQLTx{
-		engine:           e,
-		tx:               tx,
-		catalog:          catalog,
-		currentDB:        currentDB,
-		lastInsertedPKs:  make(map[string]int64),
-		firstInsertedPKs: make(map[string]int64),
-		explicitClose:    explicitClose,
+		engine:            e,
+		tx:                tx,
+		catalog:           catalog,
+		currentDB:         currentDB,
+		lastInsertedPKs:   make(map[string]int64),
+		firstInsertedPKs:  make(map[string]int64),
+		explicitClose:     explicitClose,
+		distinctRowsLimit: e.distinctLimit,
 	}, nil
 }
 
@@ -242,7 +311,7 @@ func (sqlTx *SQLTx) sqlPrefix() []byte {
 }
 
 func (sqlTx *SQLTx) distinctLimit() int {
-	return sqlTx.engine.distinctLimit
+	return sqlTx.distinctRowsLimit
 }
 
 func (sqlTx *SQLTx) newKeyReader(rSpec *store.KeyReaderSpec) (*store.KeyReader, error) {
@@ -257,6 +326,34 @@ func (sqlTx *SQLTx) set(key []byte, metadata *store.KVMetadata, value []byte) er
 	return sqlTx.tx.Set(key, metadata, value)
 }
 
+// Set writes a raw entry within the transaction, so key-value entries get committed atomically along with SQL changes
+func (sqlTx *SQLTx) Set(key []byte, metadata *store.KVMetadata, value []byte) error {
+	if sqlTx.closed {
+		return ErrAlreadyClosed
+	}
+
+	return sqlTx.tx.Set(key, metadata, value)
+}
+
+// addPrecondition requires the key to exist, or not, when the transaction is committed,
+// keys already written within the transaction are skipped as they are not committed yet
+func (sqlTx *SQLTx) addPrecondition(key []byte, mustExist bool) error {
+	valRef, err := sqlTx.tx.GetWith(key)
+	if err != nil && !errors.Is(err, store.ErrKeyNotFound) {
+		return err
+	}
+
+	if err == nil && valRef.Tx() == 0 {
+		return nil
+	}
+
+	if mustExist {
+		return sqlTx.tx.AddPrecondition(&store.PreconditionKeyMustExist{Key: key})
+	}
+
+	return sqlTx.tx.AddPrecondition(&store.PreconditionKeyMustNotExist{Key: key})
+}
+
 func (sqlTx *SQLTx) existKeyWith(prefix, neq []byte) (bool, error) {
 	return sqlTx.tx.ExistKeyWith(prefix, neq)
 }
@@ -271,11 +368,32 @@ func (sqlTx *SQLTx) Cancel() error {
 	return sqlTx.tx.Cancel()
 }
 
+// Commit persists all changes made within the transaction
+func (sqlTx *SQLTx) Commit(ctx context.Context) error {
+	if ctx == nil {
+		return ErrIllegalArguments
+	}
+
+	err := ctx.Err()
+	if err != nil {
+		sqlTx.Cancel()
+		return err
+	}
+
+	return sqlTx.commit()
+}
+
 func (sqlTx *SQLTx) commit() error {
 	if sqlTx.closed {
 		return ErrAlreadyClosed
 	}
 
+	// committing an aborted transaction rolls it back
+	if sqlTx.aborted {
+		sqlTx.Cancel()
+		return ErrTxAborted
+	}
+
 	sqlTx.committed = true
 	sqlTx.closed = true
 
@@ -286,6 +404,8 @@ func (sqlTx *SQLTx) commit() error {
 
 	sqlTx.txHeader = hdr
 
+	sqlTx.engine.trackCommittedTx(sqlTx)
+
 	return nil
 }
 
@@ -333,6 +453,16 @@ func (c *Catalog) load(sqlPrefix []byte, tx *store.OngoingTx) error {
 		if err != nil {
 			return err
 		}
+
+		err = db.loadViews(sqlPrefix, tx)
+		if err != nil {
+			return err
+		}
+
+		err = db.loadProcedures(sqlPrefix, tx)
+		if err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -392,6 +522,21 @@ func (db *Database) loadTables(sqlPrefix []byte, tx *store.OngoingTx) error {
 			return err
 		}
 
+		err = table.loadConstraints(sqlPrefix, tx)
+		if err != nil {
+			return err
+		}
+
+		err = table.loadPolicies(sqlPrefix, tx)
+		if err != nil {
+			return err
+		}
+
+		err = table.loadStats(sqlPrefix, tx)
+		if err != nil {
+			return err
+		}
+
 		if table.autoIncrementPK {
 			encMaxPK, err := loadMaxPK(sqlPrefix, tx, table)
 			if err == store.ErrNoMoreEntries {
@@ -1088,16 +1233,26 @@ func normalizeParams(params map[string]interface{}) (map[string]interface{}, err
 }
 
 func (e *Engine) Exec(sql string, params map[string]interface{}, tx *SQLTx) (ntx *SQLTx, committedTxs []*SQLTx, err error) {
+	return e.ExecContext(context.Background(), sql, params, tx)
+}
+
+// ExecContext parses and executes the provided statements, cancelling any ongoing transaction when ctx is done.
+// Statements are executed within tx when provided, otherwise transactions are implicitly created and committed.
+func (e *Engine) ExecContext(ctx context.Context, sql string, params map[string]interface{}, tx *SQLTx) (ntx *SQLTx, committedTxs []*SQLTx, err error) {
 	stmts, err := Parse(strings.NewReader(sql))
 	if err != nil {
 		return nil, nil, err
 	}
 
-	return e.ExecPreparedStmts(stmts, params, tx)
+	return e.ExecPreparedStmtsContext(ctx, stmts, params, tx)
 }
 
 func (e *Engine) ExecPreparedStmts(stmts []SQLStmt, params map[string]interface{}, tx *SQLTx) (ntx *SQLTx, committedTxs []*SQLTx, err error) {
-	if len(stmts) == 0 {
+	return e.ExecPreparedStmtsContext(context.Background(), stmts, params, tx)
+}
+
+func (e *Engine) ExecPreparedStmtsContext(ctx context.Context, stmts []SQLStmt, params map[string]interface{}, tx *SQLTx) (ntx *SQLTx, committedTxs []*SQLTx, err error) {
+	if ctx == nil || len(stmts) == 0 {
 		return nil, nil, ErrIllegalArguments
 	}
 
@@ -1114,6 +1269,14 @@ func (e *Engine) ExecPreparedStmts(stmts []SQLStmt, params map[string]interface{
 			return nil, nil, ErrIllegalArguments
 		}
 
+		err = ctx.Err()
+		if err != nil {
+			if currTx != nil && !currTx.closed {
+				currTx.Cancel()
+			}
+			return nil, committedTxs, err
+		}
+
 		if currTx == nil || currTx.closed {
 			// begin tx with implicit commit
 			currTx, err = e.newTx(false)
@@ -1122,8 +1285,18 @@ func (e *Engine) ExecPreparedStmts(stmts []SQLStmt, params map[string]interface{
 			}
 		}
 
+		if currTx.aborted && !isTxControlStmt(stmt) {
+			return currTx, committedTxs, ErrTxAborted
+		}
+
 		ntx, err := stmt.execAt(currTx, nparams)
 		if err != nil {
+			// a transaction of the caller holding savepoints is kept open, so it can be rolled back to any of them
+			if currTx == tx && len(currTx.savepoints) > 0 && !currTx.closed {
+				currTx.aborted = true
+				return currTx, committedTxs, err
+			}
+
 			currTx.Cancel()
 			return nil, committedTxs, err
 		}
@@ -1157,6 +1330,12 @@ func (e *Engine) ExecPreparedStmts(stmts []SQLStmt, params map[string]interface{
 }
 
 func (e *Engine) Query(sql string, params map[string]interface{}, tx *SQLTx) (RowReader, error) {
+	return e.QueryContext(context.Background(), sql, params, tx)
+}
+
+// QueryContext parses and resolves a single SELECT statement.
+// The returned reader fails with ctx.Err() once ctx is done and it must be closed by the caller.
+func (e *Engine) QueryContext(ctx context.Context, sql string, params map[string]interface{}, tx *SQLTx) (RowReader, error) {
 	stmts, err := Parse(strings.NewReader(sql))
 	if err != nil {
 		return nil, err
@@ -1170,14 +1349,27 @@ func (e *Engine) Query(sql string, params map[string]interface{}, tx *SQLTx) (Ro
 		return nil, ErrExpectingDQLStmt
 	}
 
-	return e.QueryPreparedStmt(stmt, params, tx)
+	return e.QueryPreparedStmtContext(ctx, stmt, params, tx)
 }
 
 func (e *Engine) QueryPreparedStmt(stmt *SelectStmt, params map[string]interface{}, tx *SQLTx) (rowReader RowReader, err error) {
-	if stmt == nil {
+	return e.QueryPreparedStmtContext(context.Background(), stmt, params, tx)
+}
+
+func (e *Engine) QueryPreparedStmtContext(ctx context.Context, stmt *SelectStmt, params map[string]interface{}, tx *SQLTx) (rowReader RowReader, err error) {
+	if ctx == nil || stmt == nil {
 		return nil, ErrIllegalArguments
 	}
 
+	err = ctx.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	if tx != nil && tx.aborted {
+		return nil, ErrTxAborted
+	}
+
 	qtx := tx
 
 	if qtx == nil {
@@ -1209,6 +1401,10 @@ func (e *Engine) QueryPreparedStmt(stmt *SelectStmt, params map[string]interface
 		})
 	}
 
+	if ctx.Done() != nil {
+		return newCtxRowReader(ctx, r), nil
+	}
+
 	return r, nil
 }
 
@@ -16,6 +16,8 @@ limitations under the License.
 package sql
 
 import (
+	"context"
+	"crypto/sha256"
 	"encoding/hex"
 	"errors"
 	"fmt"
@@ -639,6 +641,151 @@ func TestInsertIntoEdgeCases(t *testing.T) {
 	})
 }
 
+func TestInsertOnConflict(t *testing.T) {
+	st, err := store.Open("sqldata_on_conflict", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_on_conflict")
+	defer st.Close()
+
+	engine, err := NewEngine(st, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec("CREATE DATABASE db1", nil, nil)
+	require.NoError(t, err)
+
+	err = engine.SetDefaultDatabase("db1")
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec(`
+		CREATE TABLE stock (
+			sku VARCHAR[16],
+			qty INTEGER NOT NULL,
+			note VARCHAR,
+			PRIMARY KEY sku
+		)`, nil, nil)
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec("CREATE INDEX ON stock(qty)", nil, nil)
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec("INSERT INTO stock(sku, qty, note) VALUES ('a', 1, 'first'), ('b', 2, 'second')", nil, nil)
+	require.NoError(t, err)
+
+	qtyOf := func(t *testing.T, sku string) (int64, TypedValue) {
+		r, err := engine.Query("SELECT qty, note FROM stock WHERE sku = @sku", map[string]interface{}{"sku": sku}, nil)
+		require.NoError(t, err)
+		defer r.Close()
+
+		row, err := r.Read()
+		require.NoError(t, err)
+
+		return row.Values[EncodeSelector("", "db1", "stock", "qty")].Value().(int64),
+			row.Values[EncodeSelector("", "db1", "stock", "note")]
+	}
+
+	t.Run("strict insertion should fail on duplicated rows", func(t *testing.T) {
+		_, _, err = engine.Exec("INSERT INTO stock(sku, qty) VALUES ('c', 3), ('a', 10)", nil, nil)
+		require.ErrorIs(t, err, store.ErrKeyAlreadyExists)
+
+		_, _, err = engine.Exec("INSERT INTO stock(sku, qty) VALUES ('c', 3), ('c', 10)", nil, nil)
+		require.ErrorIs(t, err, store.ErrKeyAlreadyExists)
+
+		r, err := engine.Query("SELECT COUNT(*) FROM stock", nil, nil)
+		require.NoError(t, err)
+
+		row, err := r.Read()
+		require.NoError(t, err)
+		require.Equal(t, int64(2), row.Values[EncodeSelector("", "db1", "stock", "col0")].Value())
+
+		require.NoError(t, r.Close())
+	})
+
+	t.Run("conflicting rows should be skipped", func(t *testing.T) {
+		_, ctxs, err := engine.Exec("INSERT INTO stock(sku, qty) VALUES ('a', 10), ('c', 3), ('b', 20) ON CONFLICT DO NOTHING", nil, nil)
+		require.NoError(t, err)
+		require.Len(t, ctxs, 1)
+		require.Equal(t, 1, ctxs[0].UpdatedRows())
+
+		qty, note := qtyOf(t, "a")
+		require.Equal(t, int64(1), qty)
+		require.Equal(t, "first", note.Value())
+
+		qty, _ = qtyOf(t, "c")
+		require.Equal(t, int64(3), qty)
+	})
+
+	t.Run("conflicting rows should be updated", func(t *testing.T) {
+		_, ctxs, err := engine.Exec(`
+			INSERT INTO stock(sku, qty) VALUES ('a', 10), ('d', 4), ('d', 40)
+			ON CONFLICT DO UPDATE SET qty = qty + EXCLUDED.qty, note = EXCLUDED.note`, nil, nil)
+		require.NoError(t, err)
+		require.Len(t, ctxs, 1)
+		require.Equal(t, 3, ctxs[0].UpdatedRows())
+
+		qty, note := qtyOf(t, "a")
+		require.Equal(t, int64(11), qty)
+		require.True(t, note.IsNull())
+
+		qty, _ = qtyOf(t, "d")
+		require.Equal(t, int64(44), qty)
+
+		// secondary index entries are updated as well
+		r, err := engine.Query("SELECT sku FROM stock WHERE qty = 11", nil, nil)
+		require.NoError(t, err)
+
+		row, err := r.Read()
+		require.NoError(t, err)
+		require.Equal(t, "a", row.Values[EncodeSelector("", "db1", "stock", "sku")].Value())
+
+		_, err = r.Read()
+		require.ErrorIs(t, err, ErrNoMoreRows)
+
+		require.NoError(t, r.Close())
+
+		_, _, err = engine.Exec("INSERT INTO stock(sku, qty) VALUES (@sku, @qty) ON CONFLICT DO UPDATE SET qty = @qty * 2",
+			map[string]interface{}{"sku": "b", "qty": 5}, nil)
+		require.NoError(t, err)
+
+		qty, _ = qtyOf(t, "b")
+		require.Equal(t, int64(10), qty)
+	})
+
+	t.Run("invalid updates of conflicting rows should fail", func(t *testing.T) {
+		_, _, err = engine.Exec("INSERT INTO stock(sku, qty) VALUES ('a', 1) ON CONFLICT DO UPDATE SET sku = 'z'", nil, nil)
+		require.ErrorIs(t, err, ErrPKCanNotBeUpdated)
+
+		_, _, err = engine.Exec("INSERT INTO stock(sku, qty) VALUES ('a', 1) ON CONFLICT DO UPDATE SET qty = 1, qty = 2", nil, nil)
+		require.ErrorIs(t, err, ErrDuplicatedColumn)
+
+		_, _, err = engine.Exec("INSERT INTO stock(sku, qty) VALUES ('a', 1) ON CONFLICT DO UPDATE SET amount = 1", nil, nil)
+		require.ErrorIs(t, err, ErrColumnDoesNotExist)
+
+		_, _, err = engine.Exec("INSERT INTO stock(sku, qty) VALUES ('a', 1) ON CONFLICT DO UPDATE SET qty = EXCLUDED.note", nil, nil)
+		require.ErrorIs(t, err, ErrInvalidTypes)
+
+		_, _, err = engine.Exec("INSERT INTO stock(sku, qty) VALUES ('a', 1) ON CONFLICT DO UPDATE SET qty = NULL", nil, nil)
+		require.ErrorIs(t, err, ErrNotNullableColumnCannotBeNull)
+
+		params, err := engine.InferParameters("INSERT INTO stock(sku, qty) VALUES (@sku, 1) ON CONFLICT DO UPDATE SET note = @note", nil)
+		require.NoError(t, err)
+		require.Equal(t, map[string]SQLValueType{"sku": VarcharType, "note": VarcharType}, params)
+	})
+
+	t.Run("rows inserted by concurrent transactions should not be overwritten", func(t *testing.T) {
+		tx1, _, err := engine.Exec("BEGIN TRANSACTION; INSERT INTO stock(sku, qty) VALUES ('e', 5);", nil, nil)
+		require.NoError(t, err)
+
+		_, _, err = engine.Exec("INSERT INTO stock(sku, qty) VALUES ('e', 50)", nil, nil)
+		require.NoError(t, err)
+
+		_, _, err = engine.Exec("COMMIT;", nil, tx1)
+		require.Error(t, err)
+
+		qty, _ := qtyOf(t, "e")
+		require.Equal(t, int64(50), qty)
+	})
+}
+
 func TestAutoIncrementPK(t *testing.T) {
 	st, err := store.Open("sqldata_auto_inc", store.DefaultOptions())
 	require.NoError(t, err)
@@ -4409,3 +4556,1762 @@ func TestIndexingNullableColumns(t *testing.T) {
 		)
 	})
 }
+
+func TestEngineWithContext(t *testing.T) {
+	st, err := store.Open("sqldata_ctx", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_ctx")
+	defer st.Close()
+
+	engine, err := NewEngine(st, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	_, _, err = engine.ExecContext(ctx, "CREATE DATABASE db1", nil, nil)
+	require.NoError(t, err)
+
+	err = engine.SetDefaultDatabase("db1")
+	require.NoError(t, err)
+
+	_, _, err = engine.ExecContext(ctx, "CREATE TABLE table1 (id INTEGER AUTO_INCREMENT, title VARCHAR, PRIMARY KEY id)", nil, nil)
+	require.NoError(t, err)
+
+	t.Run("nil context is rejected", func(t *testing.T) {
+		//lint:ignore SA1012 nil context is being tested on purpose
+		_, _, err := engine.ExecContext(nil, "INSERT INTO table1(title) VALUES ('title0')", nil, nil)
+		require.ErrorIs(t, err, ErrIllegalArguments)
+
+		//lint:ignore SA1012 nil context is being tested on purpose
+		_, err = engine.QueryContext(nil, "SELECT * FROM table1", nil, nil)
+		require.ErrorIs(t, err, ErrIllegalArguments)
+
+		//lint:ignore SA1012 nil context is being tested on purpose
+		_, err = engine.NewTx(nil)
+		require.ErrorIs(t, err, ErrIllegalArguments)
+	})
+
+	t.Run("cancelled context prevents execution", func(t *testing.T) {
+		cctx, cancel := context.WithCancel(ctx)
+		cancel()
+
+		_, _, err := engine.ExecContext(cctx, "INSERT INTO table1(title) VALUES ('title0')", nil, nil)
+		require.ErrorIs(t, err, context.Canceled)
+
+		_, err = engine.QueryContext(cctx, "SELECT * FROM table1", nil, nil)
+		require.ErrorIs(t, err, context.Canceled)
+
+		_, err = engine.NewTx(cctx)
+		require.ErrorIs(t, err, context.Canceled)
+
+		r, err := engine.QueryContext(ctx, "SELECT * FROM table1", nil, nil)
+		require.NoError(t, err)
+
+		_, err = r.Read()
+		require.ErrorIs(t, err, ErrNoMoreRows)
+
+		err = r.Close()
+		require.NoError(t, err)
+	})
+
+	t.Run("interactive transaction", func(t *testing.T) {
+		tx, err := engine.NewTx(ctx)
+		require.NoError(t, err)
+
+		ntx, committedTxs, err := engine.ExecContext(ctx, "INSERT INTO table1(title) VALUES (@title)", map[string]interface{}{"title": "title1"}, tx)
+		require.NoError(t, err)
+		require.Equal(t, tx, ntx)
+		require.Empty(t, committedTxs)
+
+		err = tx.Commit(ctx)
+		require.NoError(t, err)
+		require.True(t, tx.Closed())
+		require.NotNil(t, tx.TxHeader())
+
+		err = tx.Commit(ctx)
+		require.ErrorIs(t, err, ErrAlreadyClosed)
+
+		tx, err = engine.NewTx(ctx)
+		require.NoError(t, err)
+
+		_, _, err = engine.ExecContext(ctx, "INSERT INTO table1(title) VALUES ('title2')", nil, tx)
+		require.NoError(t, err)
+
+		cctx, cancel := context.WithCancel(ctx)
+		cancel()
+
+		err = tx.Commit(cctx)
+		require.ErrorIs(t, err, context.Canceled)
+		require.True(t, tx.Closed())
+	})
+
+	t.Run("reader stops once context is done", func(t *testing.T) {
+		cctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		r, err := engine.QueryContext(cctx, "SELECT id, title FROM table1", nil, nil)
+		require.NoError(t, err)
+		defer r.Close()
+
+		row, err := r.Read()
+		require.NoError(t, err)
+		require.Equal(t, "title1", row.Values[EncodeSelector("", "db1", "table1", "title")].Value())
+
+		cancel()
+
+		_, err = r.Read()
+		require.ErrorIs(t, err, context.Canceled)
+	})
+}
+
+func TestColumnMask(t *testing.T) {
+	st, err := store.Open("sqldata_colmask", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_colmask")
+	defer st.Close()
+
+	engine, err := NewEngine(st, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec("CREATE DATABASE db1", nil, nil)
+	require.NoError(t, err)
+
+	err = engine.SetDefaultDatabase("db1")
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec(`
+		CREATE TABLE people (id INTEGER AUTO_INCREMENT, name VARCHAR, ssn VARCHAR, age INTEGER, PRIMARY KEY id);
+		INSERT INTO people(name, ssn, age) VALUES ('alice', '123-45-6789', 30), ('bob', '987-65-4321', 40);
+	`, nil, nil)
+	require.NoError(t, err)
+
+	query := func(mask ColumnMask, sql string) ([]*Row, error) {
+		tx, err := engine.NewTx(context.Background())
+		require.NoError(t, err)
+		defer tx.Cancel()
+
+		tx.SetColumnMask(mask)
+
+		r, err := engine.Query(sql, nil, tx)
+		require.NoError(t, err)
+		defer r.Close()
+
+		var rows []*Row
+
+		for {
+			row, err := r.Read()
+			if err == ErrNoMoreRows {
+				return rows, nil
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			rows = append(rows, row)
+		}
+	}
+
+	maskSSN := func(table *Table, col *Column, value interface{}) (interface{}, error) {
+		if table.Name() != "people" {
+			return value, nil
+		}
+
+		switch col.Name() {
+		case "ssn":
+			return "***", nil
+		case "age":
+			return nil, nil
+		}
+
+		return value, nil
+	}
+
+	t.Run("masked values should be returned", func(t *testing.T) {
+		rows, err := query(maskSSN, "SELECT name, ssn AS s, age FROM people AS p")
+		require.NoError(t, err)
+		require.Len(t, rows, 2)
+
+		require.Equal(t, "alice", rows[0].Values[EncodeSelector("", "db1", "p", "name")].Value())
+		require.Equal(t, "***", rows[0].Values[EncodeSelector("", "db1", "p", "s")].Value())
+		require.True(t, rows[0].Values[EncodeSelector("", "db1", "p", "age")].IsNull())
+	})
+
+	t.Run("queries should be evaluated over masked values", func(t *testing.T) {
+		rows, err := query(maskSSN, "SELECT name FROM people WHERE ssn = '123-45-6789'")
+		require.NoError(t, err)
+		require.Empty(t, rows)
+
+		rows, err = query(maskSSN, "SELECT COUNT(*) AS c FROM people WHERE age > 10")
+		require.NoError(t, err)
+		require.Len(t, rows, 1)
+		require.Equal(t, int64(0), rows[0].Values[EncodeSelector("", "db1", "people", "c")].Value())
+	})
+
+	t.Run("values of a different type should be rejected", func(t *testing.T) {
+		_, err := query(func(table *Table, col *Column, value interface{}) (interface{}, error) {
+			return "masked", nil
+		}, "SELECT * FROM people")
+		require.ErrorIs(t, err, ErrInvalidTypes)
+
+		_, err = query(func(table *Table, col *Column, value interface{}) (interface{}, error) {
+			return 1, nil
+		}, "SELECT * FROM people")
+		require.ErrorIs(t, err, ErrInvalidValue)
+	})
+
+	t.Run("values should be kept as stored without mask", func(t *testing.T) {
+		rows, err := query(nil, "SELECT ssn FROM people WHERE age > 35")
+		require.NoError(t, err)
+		require.Len(t, rows, 1)
+		require.Equal(t, "987-65-4321", rows[0].Values[EncodeSelector("", "db1", "people", "ssn")].Value())
+	})
+}
+
+func TestPolicies(t *testing.T) {
+	st, err := store.Open("sqldata_policies", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_policies")
+	defer st.Close()
+
+	engine, err := NewEngine(st, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec("CREATE POLICY p1 ON orders USING (tenant = @tenant)", nil, nil)
+	require.ErrorIs(t, err, ErrNoDatabaseSelected)
+
+	_, _, err = engine.Exec("CREATE DATABASE db1", nil, nil)
+	require.NoError(t, err)
+
+	err = engine.SetDefaultDatabase("db1")
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec(`
+		CREATE TABLE orders (id INTEGER AUTO_INCREMENT, tenant VARCHAR, owner VARCHAR, amount INTEGER, PRIMARY KEY id);
+		INSERT INTO orders(tenant, owner, amount) VALUES ('t1', 'alice', 10), ('t1', 'bob', 20), ('t2', 'carol', 30);
+	`, nil, nil)
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec("CREATE POLICY p1 ON orders1 USING (tenant = @tenant)", nil, nil)
+	require.ErrorIs(t, err, ErrTableDoesNotExist)
+
+	_, _, err = engine.Exec("CREATE POLICY p1 ON orders USING (amount + 1)", nil, nil)
+	require.ErrorIs(t, err, ErrInvalidTypes)
+
+	_, _, err = engine.Exec("CREATE POLICY p1 ON orders USING (title = @tenant)", nil, nil)
+	require.ErrorIs(t, err, ErrColumnDoesNotExist)
+
+	_, _, err = engine.Exec("CREATE POLICY p1 ON orders USING (tenant = @tenant)", nil, nil)
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec("CREATE POLICY p1 ON orders USING (owner = @user)", nil, nil)
+	require.ErrorIs(t, err, ErrPolicyAlreadyExists)
+
+	_, _, err = engine.Exec("DROP POLICY p2 ON orders", nil, nil)
+	require.ErrorIs(t, err, ErrPolicyDoesNotExist)
+
+	query := func(engine *Engine, attrs map[string]interface{}, sql string) ([]*Row, error) {
+		tx, err := engine.NewTx(context.Background())
+		require.NoError(t, err)
+		defer tx.Cancel()
+
+		err = tx.SetSessionAttributes(attrs)
+		require.NoError(t, err)
+
+		r, err := engine.Query(sql, nil, tx)
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+
+		var rows []*Row
+
+		for {
+			row, err := r.Read()
+			if err == ErrNoMoreRows {
+				return rows, nil
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			rows = append(rows, row)
+		}
+	}
+
+	t.Run("rows should be filtered by policies", func(t *testing.T) {
+		rows, err := query(engine, map[string]interface{}{"tenant": "t1"}, "SELECT id FROM orders")
+		require.NoError(t, err)
+		require.Len(t, rows, 2)
+
+		rows, err = query(engine, map[string]interface{}{"tenant": "t2"}, "SELECT COUNT(*) AS c FROM orders WHERE amount > 10")
+		require.NoError(t, err)
+		require.Len(t, rows, 1)
+		require.Equal(t, int64(1), rows[0].Values[EncodeSelector("", "db1", "orders", "c")].Value())
+
+		rows, err = query(engine, map[string]interface{}{"tenant": "t2"}, "SELECT o1.id FROM orders AS o1 INNER JOIN orders AS o2 ON o1.id = o2.id")
+		require.NoError(t, err)
+		require.Len(t, rows, 1)
+	})
+
+	t.Run("rows satisfying any policy should be returned", func(t *testing.T) {
+		_, _, err = engine.Exec("CREATE POLICY p2 ON orders USING (owner = @user AND amount >= 20)", nil, nil)
+		require.NoError(t, err)
+
+		rows, err := query(engine, map[string]interface{}{"tenant": "t2", "user": "bob"}, "SELECT id FROM orders")
+		require.NoError(t, err)
+		require.Len(t, rows, 2)
+	})
+
+	t.Run("missing session attributes should be rejected", func(t *testing.T) {
+		_, err := query(engine, map[string]interface{}{"tenant": "t1"}, "SELECT id FROM orders")
+		require.ErrorIs(t, err, ErrMissingParameter)
+	})
+
+	t.Run("policies should not be enforced without session attributes", func(t *testing.T) {
+		rows, err := query(engine, nil, "SELECT id FROM orders")
+		require.NoError(t, err)
+		require.Len(t, rows, 3)
+	})
+
+	t.Run("policies should be loaded when reopening the engine", func(t *testing.T) {
+		engine, err := NewEngine(st, DefaultOptions().WithPrefix(sqlPrefix))
+		require.NoError(t, err)
+
+		err = engine.SetDefaultDatabase("db1")
+		require.NoError(t, err)
+
+		rows, err := query(engine, map[string]interface{}{"tenant": "t1", "user": "carol"}, "SELECT id FROM orders")
+		require.NoError(t, err)
+		require.Len(t, rows, 3)
+
+		catalog, err := engine.Catalog(nil)
+		require.NoError(t, err)
+
+		db, err := catalog.GetDatabaseByName("db1")
+		require.NoError(t, err)
+
+		table, err := db.GetTableByName("orders")
+		require.NoError(t, err)
+
+		policies := table.Policies()
+		require.Len(t, policies, 2)
+		require.Equal(t, "p1", policies[0].Name())
+		require.Equal(t, "(tenant = @tenant)", policies[0].Predicate())
+		require.Equal(t, "((owner = @user) AND (amount >= 20))", policies[1].Predicate())
+	})
+
+	t.Run("dropped policies should not be enforced", func(t *testing.T) {
+		_, _, err = engine.Exec("DROP POLICY p2 ON orders", nil, nil)
+		require.NoError(t, err)
+
+		rows, err := query(engine, map[string]interface{}{"tenant": "t2"}, "SELECT id FROM orders")
+		require.NoError(t, err)
+		require.Len(t, rows, 1)
+
+		engine, err := NewEngine(st, DefaultOptions().WithPrefix(sqlPrefix))
+		require.NoError(t, err)
+
+		err = engine.SetDefaultDatabase("db1")
+		require.NoError(t, err)
+
+		rows, err = query(engine, map[string]interface{}{"tenant": "t2"}, "SELECT id FROM orders")
+		require.NoError(t, err)
+		require.Len(t, rows, 1)
+	})
+}
+
+func TestExplain(t *testing.T) {
+	st, err := store.Open("sqldata_explain", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_explain")
+	defer st.Close()
+
+	engine, err := NewEngine(st, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec("CREATE DATABASE db1", nil, nil)
+	require.NoError(t, err)
+
+	err = engine.SetDefaultDatabase("db1")
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec(`
+		CREATE TABLE customers (id INTEGER AUTO_INCREMENT, name VARCHAR, age INTEGER, PRIMARY KEY id);
+		CREATE INDEX ON customers(age);
+		CREATE TABLE orders (id INTEGER AUTO_INCREMENT, customer_id INTEGER, amount INTEGER, PRIMARY KEY id);
+		INSERT INTO customers(name, age) VALUES ('alice', 10), ('bob', 20), ('carol', 30);
+		INSERT INTO orders(customer_id, amount) VALUES (1, 10), (2, 20), (3, 30), (1, 40);
+	`, nil, nil)
+	require.NoError(t, err)
+
+	explain := func(sql string, params map[string]interface{}) ([]ColDescriptor, [][]TypedValue) {
+		r, err := engine.Query(sql, nil, nil)
+		require.NoError(t, err)
+		defer r.Close()
+
+		err = r.SetParameters(params)
+		require.NoError(t, err)
+
+		cols, err := r.Columns()
+		require.NoError(t, err)
+
+		var rows [][]TypedValue
+
+		for {
+			row, err := r.Read()
+			if err == ErrNoMoreRows {
+				return cols, rows
+			}
+			require.NoError(t, err)
+
+			values := make([]TypedValue, len(cols))
+			for i, c := range cols {
+				values[i] = row.Values[c.Selector()]
+			}
+
+			rows = append(rows, values)
+		}
+	}
+
+	t.Run("plan should describe index usage and scan direction", func(t *testing.T) {
+		cols, rows := explain("EXPLAIN SELECT id, name AS n FROM customers WHERE age >= 20 ORDER BY age DESC LIMIT 1", nil)
+		require.Len(t, cols, 3)
+		require.Equal(t, "operator", cols[0].Column)
+		require.Equal(t, "details", cols[1].Column)
+		require.Equal(t, "est_rows", cols[2].Column)
+
+		require.Len(t, rows, 4)
+
+		require.Equal(t, "Limit", rows[0][0].Value())
+		require.Equal(t, int64(1), rows[0][2].Value())
+
+		require.Equal(t, "  Project", rows[1][0].Value())
+		require.Equal(t, "id, name AS n", rows[1][1].Value())
+
+		require.Equal(t, "    Filter", rows[2][0].Value())
+		require.Equal(t, "(age >= 20)", rows[2][1].Value())
+
+		require.Equal(t, "      IndexRangeScan", rows[3][0].Value())
+		require.Equal(t, "customers, index: (age) DESC, range: age >= 20", rows[3][1].Value())
+		require.Equal(t, int64(3), rows[3][2].Value())
+	})
+
+	t.Run("plan should describe joins", func(t *testing.T) {
+		_, rows := explain("EXPLAIN SELECT amount FROM orders INNER JOIN customers AS c ON c.id = orders.customer_id", nil)
+		require.Len(t, rows, 4)
+
+		require.Equal(t, "  NestedLoopJoin", rows[1][0].Value())
+		require.Equal(t, "JOIN customers AS c ON (c.id = orders.customer_id)", rows[1][1].Value())
+		require.True(t, rows[1][2].IsNull())
+
+		require.Equal(t, "    Scan", rows[2][0].Value())
+		require.Equal(t, "orders, index: (id) ASC", rows[2][1].Value())
+
+		require.Equal(t, "    Lookup", rows[3][0].Value())
+		require.Equal(t, "customers AS c, per outer row", rows[3][1].Value())
+	})
+
+	t.Run("analyzed plan should report actual row counts", func(t *testing.T) {
+		cols, rows := explain("EXPLAIN ANALYZE SELECT COUNT(*) AS total FROM orders WHERE amount > @lowest", map[string]interface{}{"lowest": 15})
+		require.Len(t, cols, 5)
+		require.Equal(t, "rows", cols[3].Column)
+		require.Equal(t, "time_us", cols[4].Column)
+
+		require.Len(t, rows, 4)
+
+		expected := []struct {
+			op   string
+			rows int64
+		}{
+			{"Project", 1},
+			{"  Aggregate", 1},
+			{"    Filter", 3},
+			{"      Scan", 4},
+		}
+
+		for i, e := range expected {
+			require.Equal(t, e.op, rows[i][0].Value())
+			require.Equal(t, e.rows, rows[i][3].Value())
+			require.GreaterOrEqual(t, rows[i][4].Value(), int64(0))
+		}
+	})
+
+	t.Run("analyzed plan should include subqueries", func(t *testing.T) {
+		_, rows := explain("EXPLAIN ANALYZE SELECT DISTINCT name FROM (SELECT * FROM customers WHERE id = 2) AS sub", nil)
+		require.Len(t, rows, 6)
+
+		require.Equal(t, "    Subquery", rows[2][0].Value())
+		require.Equal(t, "sub", rows[2][1].Value())
+
+		require.Equal(t, "          IndexRangeScan", rows[5][0].Value())
+		require.Equal(t, "customers, index: (id) ASC, range: id = 2", rows[5][1].Value())
+		require.Equal(t, int64(1), rows[5][2].Value())
+		require.Equal(t, int64(1), rows[5][3].Value())
+	})
+}
+
+func TestAnalyzeTable(t *testing.T) {
+	st, err := store.Open("sqldata_analyze", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_analyze")
+	defer st.Close()
+
+	engine, err := NewEngine(st, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec("CREATE DATABASE db1", nil, nil)
+	require.NoError(t, err)
+
+	err = engine.SetDefaultDatabase("db1")
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec(`
+		CREATE TABLE users (id INTEGER AUTO_INCREMENT, country VARCHAR[16], age INTEGER, PRIMARY KEY id);
+		CREATE INDEX ON users(country);
+		CREATE INDEX ON users(age);
+		CREATE TABLE orders (id INTEGER AUTO_INCREMENT, user_id INTEGER, amount INTEGER, PRIMARY KEY id);
+		CREATE INDEX ON orders(user_id);
+	`, nil, nil)
+	require.NoError(t, err)
+
+	for i := 0; i < 200; i++ {
+		country := "ES"
+		if i%2 == 1 {
+			country = "US"
+		}
+
+		_, _, err = engine.Exec(
+			"INSERT INTO users(country, age) VALUES (@country, @age); INSERT INTO orders(user_id, amount) VALUES (@user, @amount)",
+			map[string]interface{}{"country": country, "age": i, "user": i + 1, "amount": i * 10},
+			nil,
+		)
+		require.NoError(t, err)
+	}
+
+	explain := func(sql string) [][]TypedValue {
+		r, err := engine.Query("EXPLAIN "+sql, nil, nil)
+		require.NoError(t, err)
+		defer r.Close()
+
+		cols, err := r.Columns()
+		require.NoError(t, err)
+
+		var rows [][]TypedValue
+
+		for {
+			row, err := r.Read()
+			if err == ErrNoMoreRows {
+				return rows
+			}
+			require.NoError(t, err)
+
+			values := make([]TypedValue, len(cols))
+			for i, c := range cols {
+				values[i] = row.Values[c.Selector()]
+			}
+
+			rows = append(rows, values)
+		}
+	}
+
+	queryInts := func(sql string) [][]int64 {
+		r, err := engine.Query(sql, nil, nil)
+		require.NoError(t, err)
+		defer r.Close()
+
+		cols, err := r.Columns()
+		require.NoError(t, err)
+
+		var rows [][]int64
+
+		for {
+			row, err := r.Read()
+			if err == ErrNoMoreRows {
+				return rows
+			}
+			require.NoError(t, err)
+
+			values := make([]int64, len(cols))
+			for i, c := range cols {
+				values[i] = row.Values[c.Selector()].Value().(int64)
+			}
+
+			rows = append(rows, values)
+		}
+	}
+
+	usersStats := func() *TableStats {
+		catalog, err := engine.Catalog(nil)
+		require.NoError(t, err)
+
+		table, err := catalog.GetTableByName("db1", "users")
+		require.NoError(t, err)
+
+		return table.Stats()
+	}
+
+	t.Run("tables should not have statistics before being analyzed", func(t *testing.T) {
+		require.Nil(t, usersStats())
+
+		rows := explain("SELECT id FROM users WHERE age = 10")
+		require.Equal(t, "users, index: (id) ASC", rows[2][1].Value())
+	})
+
+	t.Run("analyzing a nonexistent table should fail", func(t *testing.T) {
+		_, _, err = engine.Exec("ANALYZE TABLE nonexistent", nil, nil)
+		require.ErrorIs(t, err, ErrTableDoesNotExist)
+	})
+
+	_, _, err = engine.Exec("ANALYZE TABLE users", nil, nil)
+	require.NoError(t, err)
+
+	t.Run("statistics should be kept for indexed columns", func(t *testing.T) {
+		stats := usersStats()
+		require.NotNil(t, stats)
+		require.Equal(t, int64(200), stats.RowCount())
+
+		catalog, err := engine.Catalog(nil)
+		require.NoError(t, err)
+
+		table, err := catalog.GetTableByName("db1", "users")
+		require.NoError(t, err)
+
+		age, err := table.GetColumnByName("age")
+		require.NoError(t, err)
+
+		ageStats, ok := stats.ColumnStats(age)
+		require.True(t, ok)
+		require.Equal(t, int64(200), ageStats.Distinct())
+		require.Equal(t, int64(0), ageStats.Nulls())
+		require.Equal(t, int64(0), ageStats.Min().Value())
+		require.Equal(t, int64(199), ageStats.Max().Value())
+
+		country, err := table.GetColumnByName("country")
+		require.NoError(t, err)
+
+		countryStats, ok := stats.ColumnStats(country)
+		require.True(t, ok)
+		require.Equal(t, int64(2), countryStats.Distinct())
+		require.Equal(t, "ES", countryStats.Min().Value())
+		require.Equal(t, "US", countryStats.Max().Value())
+
+		orders, err := catalog.GetTableByName("db1", "orders")
+		require.NoError(t, err)
+		require.Nil(t, orders.Stats())
+	})
+
+	t.Run("the most selective index should be used when rows are not required to be sorted", func(t *testing.T) {
+		rows := explain("SELECT id FROM users WHERE country = 'ES' AND age = 10")
+		require.Equal(t, "    IndexRangeScan", rows[2][0].Value())
+		require.Equal(t, "users, index: (age) ASC, range: age = 10", rows[2][1].Value())
+		require.Equal(t, int64(1), rows[2][2].Value())
+
+		require.Equal(t, [][]int64{{11}}, queryInts("SELECT id FROM users WHERE country = 'ES' AND age = 10"))
+
+		rows = explain("SELECT id FROM users WHERE country = 'US' AND age >= 150")
+		require.Equal(t, "users, index: (age) ASC, range: age >= 150", rows[2][1].Value())
+		require.Equal(t, int64(50), rows[2][2].Value())
+
+		rows = explain("SELECT id FROM users WHERE country = 'US' AND age >= 10")
+		require.Equal(t, "users, index: (country) ASC, range: country = 'US'", rows[2][1].Value())
+		require.Equal(t, int64(100), rows[2][2].Value())
+
+		require.Len(t, queryInts("SELECT id FROM users WHERE country = 'US' AND age >= 10"), 95)
+
+		rows = explain("SELECT id FROM users WHERE country = 'US' ORDER BY age")
+		require.Equal(t, "users, index: (age) ASC", rows[2][1].Value())
+	})
+
+	_, _, err = engine.Exec("ANALYZE", nil, nil)
+	require.NoError(t, err)
+
+	t.Run("joins should read first from the table fewer rows are estimated to be read from", func(t *testing.T) {
+		rows := explain("SELECT u.id, o.amount FROM orders AS o INNER JOIN users AS u ON u.id = o.user_id WHERE u.age = 10")
+		require.Equal(t, "      IndexRangeScan", rows[3][0].Value())
+		require.Equal(t, "users AS u, index: (age) ASC, range: age = 10", rows[3][1].Value())
+		require.Equal(t, "orders AS o, per outer row", rows[4][1].Value())
+
+		require.Equal(t, [][]int64{{11, 100}}, queryInts("SELECT u.id, o.amount FROM orders AS o INNER JOIN users AS u ON u.id = o.user_id WHERE u.age = 10"))
+
+		// unqualified columns would be resolved against a different table
+		rows = explain("SELECT id, o.amount FROM orders AS o INNER JOIN users AS u ON u.id = o.user_id WHERE u.age = 10")
+		require.Equal(t, "orders AS o, index: (id) ASC", rows[3][1].Value())
+
+		require.Equal(t, [][]int64{{11, 100}}, queryInts("SELECT id, o.amount FROM orders AS o INNER JOIN users AS u ON u.id = o.user_id WHERE u.age = 10"))
+	})
+
+	t.Run("statistics should be refreshed once enough rows are modified", func(t *testing.T) {
+		_, _, err = engine.Exec("INSERT INTO users(country, age) VALUES ('FR', 500)", nil, nil)
+		require.NoError(t, err)
+		require.Equal(t, int64(200), usersStats().RowCount())
+
+		_, _, err = engine.Exec("DELETE FROM users WHERE age < 69", nil, nil)
+		require.NoError(t, err)
+		require.Equal(t, int64(132), usersStats().RowCount())
+	})
+
+	t.Run("statistics should not be refreshed when disabled", func(t *testing.T) {
+		engine, err := NewEngine(st, DefaultOptions().WithPrefix(sqlPrefix).WithAutoAnalyzeRatio(0))
+		require.NoError(t, err)
+
+		err = engine.SetDefaultDatabase("db1")
+		require.NoError(t, err)
+
+		_, _, err = engine.Exec("DELETE FROM users WHERE age < 200", nil, nil)
+		require.NoError(t, err)
+
+		catalog, err := engine.Catalog(nil)
+		require.NoError(t, err)
+
+		table, err := catalog.GetTableByName("db1", "users")
+		require.NoError(t, err)
+		require.Equal(t, int64(132), table.Stats().RowCount())
+	})
+}
+
+func TestPagination(t *testing.T) {
+	st, err := store.Open("sqldata_pagination", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_pagination")
+	defer st.Close()
+
+	engine, err := NewEngine(st, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec("CREATE DATABASE db1", nil, nil)
+	require.NoError(t, err)
+
+	err = engine.SetDefaultDatabase("db1")
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec(`
+		CREATE TABLE table1 (id INTEGER AUTO_INCREMENT, a INTEGER, b INTEGER, PRIMARY KEY id);
+		CREATE INDEX ON table1(a, b);
+	`, nil, nil)
+	require.NoError(t, err)
+
+	for i := 0; i < 30; i++ {
+		_, _, err = engine.Exec("INSERT INTO table1(a, b) VALUES (@a, @b)", map[string]interface{}{"a": i / 10, "b": i % 10}, nil)
+		require.NoError(t, err)
+	}
+
+	query := func(sql string, params map[string]interface{}) [][]TypedValue {
+		r, err := engine.Query(sql, params, nil)
+		require.NoError(t, err)
+		defer r.Close()
+
+		cols, err := r.Columns()
+		require.NoError(t, err)
+
+		var rows [][]TypedValue
+
+		for {
+			row, err := r.Read()
+			if err == ErrNoMoreRows {
+				return rows
+			}
+			require.NoError(t, err)
+
+			values := make([]TypedValue, len(cols))
+			for i, c := range cols {
+				values[i] = row.Values[c.Selector()]
+			}
+
+			rows = append(rows, values)
+		}
+	}
+
+	t.Run("offset should skip rows", func(t *testing.T) {
+		rows := query("SELECT id FROM table1 ORDER BY id LIMIT 5 OFFSET 10", nil)
+		require.Len(t, rows, 5)
+		require.Equal(t, int64(11), rows[0][0].Value())
+		require.Equal(t, int64(15), rows[4][0].Value())
+
+		rows = query("SELECT id FROM table1 OFFSET 28", nil)
+		require.Len(t, rows, 2)
+		require.Equal(t, int64(29), rows[0][0].Value())
+
+		rows = query("SELECT id FROM table1 LIMIT 5 OFFSET 30", nil)
+		require.Empty(t, rows)
+
+		rows = query("SELECT id FROM table1 WHERE a = 1 ORDER BY id DESC LIMIT 2 OFFSET 3", nil)
+		require.Len(t, rows, 2)
+		require.Equal(t, int64(17), rows[0][0].Value())
+		require.Equal(t, int64(16), rows[1][0].Value())
+	})
+
+	t.Run("offset should be skipped by the index scan when every scanned row is returned", func(t *testing.T) {
+		rows := query("EXPLAIN SELECT id FROM table1 ORDER BY id LIMIT 5 OFFSET 10", nil)
+		require.Equal(t, "table1, index: (id) ASC, offset: 10", rows[2][1].Value())
+		require.Equal(t, int64(20), rows[2][2].Value())
+		require.Equal(t, "5", rows[0][1].Value())
+
+		rows = query("EXPLAIN SELECT id FROM table1 WHERE a = 1 LIMIT 5 OFFSET 10", nil)
+		require.Equal(t, "Limit", rows[0][0].Value())
+		require.Equal(t, "5, offset: 10", rows[0][1].Value())
+		require.Equal(t, "table1, index: (id) ASC", rows[3][1].Value())
+	})
+
+	t.Run("rows should be sorted by multiple indexed columns", func(t *testing.T) {
+		rows := query("SELECT a, b FROM table1 ORDER BY a DESC, b DESC LIMIT 2", nil)
+		require.Len(t, rows, 2)
+		require.Equal(t, []interface{}{int64(2), int64(9)}, []interface{}{rows[0][0].Value(), rows[0][1].Value()})
+		require.Equal(t, []interface{}{int64(2), int64(8)}, []interface{}{rows[1][0].Value(), rows[1][1].Value()})
+
+		_, err = engine.Query("SELECT a, b FROM table1 ORDER BY a, b DESC", nil, nil)
+		require.ErrorIs(t, err, ErrLimitedOrderBy)
+
+		_, err = engine.Query("SELECT a, b FROM table1 ORDER BY b, a", nil, nil)
+		require.ErrorIs(t, err, ErrLimitedOrderBy)
+	})
+
+	t.Run("keyset pagination should resume from the last row read", func(t *testing.T) {
+		for _, desc := range []bool{false, true} {
+			order, cmp := "", ">"
+			if desc {
+				order, cmp = " DESC", "<"
+			}
+
+			rows := query(fmt.Sprintf("SELECT id, a, b FROM table1 ORDER BY a%s, b%s LIMIT 7", order, order), nil)
+
+			for len(rows) < 30 {
+				last := rows[len(rows)-1]
+
+				page := query(
+					fmt.Sprintf("SELECT id, a, b FROM table1 WHERE (a, b) %s (@a, @b) ORDER BY a%s, b%s LIMIT 7", cmp, order, order),
+					map[string]interface{}{"a": last[1].Value(), "b": last[2].Value()},
+				)
+				require.NotEmpty(t, page)
+
+				rows = append(rows, page...)
+			}
+
+			require.Len(t, rows, 30)
+
+			for i, row := range rows {
+				id := int64(i + 1)
+				if desc {
+					id = int64(30 - i)
+				}
+
+				require.Equal(t, id, row[0].Value())
+			}
+		}
+
+		rows := query("EXPLAIN SELECT id FROM table1 WHERE (a, b) > (@a, @b) ORDER BY a, b LIMIT 7", map[string]interface{}{"a": 1, "b": 4})
+		require.Equal(t, "table1, index: (a, b) ASC, range: a >= 1", rows[3][1].Value())
+
+		rows = query("EXPLAIN SELECT id FROM table1 WHERE (a, b) < (@a, @b) ORDER BY a DESC, b DESC LIMIT 7", map[string]interface{}{"a": 1, "b": 4})
+		require.Equal(t, "table1, index: (a, b) DESC, range: a <= 1", rows[3][1].Value())
+	})
+
+	t.Run("tuples of different sizes should not be compared", func(t *testing.T) {
+		_, err = engine.Query("SELECT id FROM table1 WHERE (a, b) > (1, 2, 3)", nil, nil)
+		require.ErrorIs(t, err, ErrInvalidNumberOfValues)
+	})
+
+	t.Run("ranges over the same value should keep inclusive bounds", func(t *testing.T) {
+		require.Len(t, query("SELECT id FROM table1 WHERE a >= 1 AND a > 0", nil), 20)
+		require.Len(t, query("SELECT id FROM table1 WHERE a < 1 OR a <= 1", nil), 20)
+	})
+}
+
+func TestDefaultValuesAndChecks(t *testing.T) {
+	st, err := store.Open("sqldata_constraints", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_constraints")
+	defer st.Close()
+
+	engine, err := NewEngine(st, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec("CREATE DATABASE db1", nil, nil)
+	require.NoError(t, err)
+
+	err = engine.SetDefaultDatabase("db1")
+	require.NoError(t, err)
+
+	t.Run("invalid default values and checks should be rejected", func(t *testing.T) {
+		_, _, err = engine.Exec("CREATE TABLE invalid (id INTEGER AUTO_INCREMENT DEFAULT 1, PRIMARY KEY id)", nil, nil)
+		require.ErrorIs(t, err, ErrIllegalArguments)
+
+		_, _, err = engine.Exec("CREATE TABLE invalid (id INTEGER, name VARCHAR DEFAULT 1, PRIMARY KEY id)", nil, nil)
+		require.ErrorIs(t, err, ErrInvalidTypes)
+
+		_, _, err = engine.Exec("CREATE TABLE invalid (id INTEGER, qty INTEGER DEFAULT id, PRIMARY KEY id)", nil, nil)
+		require.ErrorIs(t, err, ErrColumnDoesNotExist)
+
+		_, _, err = engine.Exec("CREATE TABLE invalid (id INTEGER, qty INTEGER DEFAULT @qty, PRIMARY KEY id)", nil, nil)
+		require.ErrorIs(t, err, ErrUnsupportedParameter)
+
+		_, _, err = engine.Exec("CREATE TABLE invalid (id INTEGER, qty INTEGER CHECK (qty + 1), PRIMARY KEY id)", nil, nil)
+		require.ErrorIs(t, err, ErrInvalidTypes)
+
+		_, _, err = engine.Exec("CREATE TABLE invalid (id INTEGER, PRIMARY KEY id, CHECK (amount > 0))", nil, nil)
+		require.ErrorIs(t, err, ErrColumnDoesNotExist)
+
+		_, _, err = engine.Exec("CREATE TABLE invalid (id INTEGER, qty INTEGER DEFAULT UNKNOWN(), PRIMARY KEY id)", nil, nil)
+		require.ErrorIs(t, err, ErrIllegalArguments)
+
+		catalog, err := engine.Catalog(nil)
+		require.NoError(t, err)
+		require.False(t, catalog.dbsByName["db1"].ExistTable("invalid"))
+	})
+
+	_, _, err = engine.Exec(`
+		CREATE TABLE orders (
+			id VARCHAR[36] DEFAULT RANDOM_UUID(),
+			created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+			status VARCHAR DEFAULT 'pending',
+			qty INTEGER NOT NULL DEFAULT 1 CHECK (qty > 0),
+			price INTEGER,
+			discount INTEGER DEFAULT 0,
+			PRIMARY KEY id,
+			CHECK (discount <= price)
+		)`, nil, nil)
+	require.NoError(t, err)
+
+	t.Run("default values should be assigned to unspecified columns", func(t *testing.T) {
+		_, _, err = engine.Exec("INSERT INTO orders(price) VALUES (10), (20)", nil, nil)
+		require.NoError(t, err)
+
+		r, err := engine.Query("SELECT id, created_at, status, qty, discount FROM orders", nil, nil)
+		require.NoError(t, err)
+		defer r.Close()
+
+		var ids []string
+
+		for {
+			row, err := r.Read()
+			if err == ErrNoMoreRows {
+				break
+			}
+			require.NoError(t, err)
+
+			id := row.Values[EncodeSelector("", "db1", "orders", "id")].Value().(string)
+			require.Len(t, id, 36)
+			ids = append(ids, id)
+
+			createdAt := row.Values[EncodeSelector("", "db1", "orders", "created_at")].Value().(time.Time)
+			require.WithinDuration(t, time.Now(), createdAt, time.Minute)
+
+			require.Equal(t, "pending", row.Values[EncodeSelector("", "db1", "orders", "status")].Value())
+			require.Equal(t, int64(1), row.Values[EncodeSelector("", "db1", "orders", "qty")].Value())
+			require.Equal(t, int64(0), row.Values[EncodeSelector("", "db1", "orders", "discount")].Value())
+		}
+
+		require.Len(t, ids, 2)
+		require.NotEqual(t, ids[0], ids[1])
+	})
+
+	t.Run("explicit null values should not be replaced by default values", func(t *testing.T) {
+		_, _, err = engine.Exec("INSERT INTO orders(id, status, price) VALUES ('o1', NULL, 10)", nil, nil)
+		require.NoError(t, err)
+
+		r, err := engine.QueryPreparedStmt(&SelectStmt{
+			selectors: []Selector{&ColSelector{col: "status"}},
+			ds:        &tableRef{table: "orders"},
+			where:     &CmpBoolExp{op: EQ, left: &ColSelector{col: "id"}, right: &Varchar{val: "o1"}},
+		}, nil, nil)
+		require.NoError(t, err)
+
+		row, err := r.Read()
+		require.NoError(t, err)
+		require.True(t, row.Values[EncodeSelector("", "db1", "orders", "status")].IsNull())
+
+		require.NoError(t, r.Close())
+
+		_, _, err = engine.Exec("INSERT INTO orders(id, qty, price) VALUES ('o2', NULL, 10)", nil, nil)
+		require.ErrorIs(t, err, ErrNotNullableColumnCannotBeNull)
+	})
+
+	t.Run("rows not satisfying checks should not be inserted nor updated", func(t *testing.T) {
+		_, _, err = engine.Exec("INSERT INTO orders(id, qty, price) VALUES ('o3', 0, 10)", nil, nil)
+		require.ErrorIs(t, err, ErrCheckConstraintViolation)
+		require.Contains(t, err.Error(), "(qty > 0)")
+
+		_, _, err = engine.Exec("INSERT INTO orders(id, price, discount) VALUES ('o3', 10, 11)", nil, nil)
+		require.ErrorIs(t, err, ErrCheckConstraintViolation)
+		require.Contains(t, err.Error(), "(discount <= price)")
+
+		_, _, err = engine.Exec("UPDATE orders SET discount = 20 WHERE id = 'o1'", nil, nil)
+		require.ErrorIs(t, err, ErrCheckConstraintViolation)
+
+		_, _, err = engine.Exec("UPSERT INTO orders(id, qty, price) VALUES ('o1', -1, 10)", nil, nil)
+		require.ErrorIs(t, err, ErrCheckConstraintViolation)
+
+		_, _, err = engine.Exec("INSERT INTO orders(id, price, discount) VALUES ('o3', 10, 5)", nil, nil)
+		require.NoError(t, err)
+
+		_, _, err = engine.Exec("UPDATE orders SET discount = 5 WHERE id = 'o1'", nil, nil)
+		require.NoError(t, err)
+	})
+
+	t.Run("default values and checks should be loaded with the catalog", func(t *testing.T) {
+		engine, err := NewEngine(st, DefaultOptions().WithPrefix(sqlPrefix))
+		require.NoError(t, err)
+
+		err = engine.SetDefaultDatabase("db1")
+		require.NoError(t, err)
+
+		catalog, err := engine.Catalog(nil)
+		require.NoError(t, err)
+
+		table, err := catalog.GetTableByName("db1", "orders")
+		require.NoError(t, err)
+		require.Equal(t, []string{"(qty > 0)", "(discount <= price)"}, table.Checks())
+
+		col, err := table.GetColumnByName("created_at")
+		require.NoError(t, err)
+		require.Equal(t, "NOW()", col.DefaultValue())
+
+		col, err = table.GetColumnByName("price")
+		require.NoError(t, err)
+		require.Empty(t, col.DefaultValue())
+
+		_, _, err = engine.Exec("INSERT INTO orders(id, qty) VALUES ('o4', -1)", nil, nil)
+		require.ErrorIs(t, err, ErrCheckConstraintViolation)
+
+		_, _, err = engine.Exec("INSERT INTO orders(id, price) VALUES ('o4', 10)", nil, nil)
+		require.NoError(t, err)
+	})
+}
+
+func TestBuiltinFunctions(t *testing.T) {
+	st, err := store.Open("sqldata_functions", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_functions")
+	defer st.Close()
+
+	engine, err := NewEngine(st, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec("CREATE DATABASE db1", nil, nil)
+	require.NoError(t, err)
+
+	err = engine.SetDefaultDatabase("db1")
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec(`
+		CREATE TABLE events (
+			id INTEGER AUTO_INCREMENT,
+			code VARCHAR[36] DEFAULT UUID(),
+			name VARCHAR,
+			ts TIMESTAMP,
+			day TIMESTAMP DEFAULT DATE_TRUNC('day', NOW()),
+			PRIMARY KEY id
+		)`, nil, nil)
+	require.NoError(t, err)
+
+	ts := time.Date(2021, time.December, 8, 13, 46, 23, 0, time.UTC)
+
+	_, _, err = engine.Exec("INSERT INTO events(name, ts) VALUES (@name, @ts), (NULL, @ts)", map[string]interface{}{"name": "Würzburg", "ts": ts}, nil)
+	require.NoError(t, err)
+
+	t.Run("functions should be usable as selectors", func(t *testing.T) {
+		r, err := engine.Query(`
+			SELECT
+				UPPER(name) AS uname, LOWER(name), LENGTH(name), SUBSTRING(name, 2, 3), SUBSTRING(name, 6),
+				CONCAT(name, '#', id, ' ', ts), SHA256(name),
+				DATE_TRUNC('week', ts), DATE_TRUNC('quarter', ts),
+				EXTRACT(YEAR FROM ts), EXTRACT(DOW FROM ts), EXTRACT(EPOCH FROM ts),
+				LENGTH(code), day
+			FROM events
+			WHERE id = 1`, nil, nil)
+		require.NoError(t, err)
+		defer r.Close()
+
+		cols, err := r.Columns()
+		require.NoError(t, err)
+		require.Len(t, cols, 14)
+		require.Equal(t, "uname", cols[0].Column)
+		require.Equal(t, VarcharType, cols[0].Type)
+		require.Equal(t, "col1", cols[1].Column)
+		require.Equal(t, IntegerType, cols[2].Type)
+		require.Equal(t, BLOBType, cols[6].Type)
+		require.Equal(t, TimestampType, cols[7].Type)
+		require.Equal(t, IntegerType, cols[9].Type)
+
+		row, err := r.Read()
+		require.NoError(t, err)
+
+		valueOf := func(col string) interface{} {
+			return row.Values[EncodeSelector("", "db1", "events", col)].Value()
+		}
+
+		sum := sha256.Sum256([]byte("Würzburg"))
+
+		require.Equal(t, "WÜRZBURG", valueOf("uname"))
+		require.Equal(t, "würzburg", valueOf("col1"))
+		require.Equal(t, int64(8), valueOf("col2"))
+		require.Equal(t, "ürz", valueOf("col3"))
+		require.Equal(t, "urg", valueOf("col4"))
+		require.Equal(t, "Würzburg#1 2021-12-08 13:46:23", valueOf("col5"))
+		require.Equal(t, sum[:], valueOf("col6"))
+		require.Equal(t, time.Date(2021, time.December, 6, 0, 0, 0, 0, time.UTC), valueOf("col7"))
+		require.Equal(t, time.Date(2021, time.October, 1, 0, 0, 0, 0, time.UTC), valueOf("col8"))
+		require.Equal(t, int64(2021), valueOf("col9"))
+		require.Equal(t, int64(3), valueOf("col10"))
+		require.Equal(t, ts.Unix(), valueOf("col11"))
+		require.Equal(t, int64(36), valueOf("col12"))
+
+		now := time.Now().UTC()
+		require.Equal(t, time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC), valueOf("day"))
+	})
+
+	t.Run("functions should return NULL on NULL arguments", func(t *testing.T) {
+		r, err := engine.Query("SELECT UPPER(name), CONCAT(name, 'x', NULL), SUBSTRING(name, @start) FROM events WHERE id = 2", map[string]interface{}{"start": 1}, nil)
+		require.NoError(t, err)
+		defer r.Close()
+
+		row, err := r.Read()
+		require.NoError(t, err)
+		require.True(t, row.Values[EncodeSelector("", "db1", "events", "col0")].IsNull())
+		require.Equal(t, "x", row.Values[EncodeSelector("", "db1", "events", "col1")].Value())
+		require.True(t, row.Values[EncodeSelector("", "db1", "events", "col2")].IsNull())
+	})
+
+	t.Run("functions should be usable in conditions and sub-queries", func(t *testing.T) {
+		r, err := engine.Query(`
+			SELECT id, n
+			FROM (SELECT id, LOWER(name) AS n FROM events WHERE EXTRACT(MONTH FROM ts) = 12 AND LENGTH(name) > @len)
+			WHERE n = 'würzburg'`, map[string]interface{}{"len": 3}, nil)
+		require.NoError(t, err)
+		defer r.Close()
+
+		row, err := r.Read()
+		require.NoError(t, err)
+		require.Equal(t, int64(1), row.Values[EncodeSelector("", "db1", "events", "id")].Value())
+
+		_, err = r.Read()
+		require.ErrorIs(t, err, ErrNoMoreRows)
+	})
+
+	t.Run("parameters of functions should be inferred", func(t *testing.T) {
+		params, err := engine.InferParameters("SELECT UPPER(@s), SHA256(@b) FROM events WHERE DATE_TRUNC(@field, ts) > @ts", nil)
+		require.NoError(t, err)
+		require.Equal(t, map[string]SQLValueType{"s": VarcharType, "b": VarcharType, "field": VarcharType, "ts": TimestampType}, params)
+	})
+
+	t.Run("invalid function calls should fail", func(t *testing.T) {
+		for q, expectedErr := range map[string]error{
+			"SELECT UNKNOWN(name) FROM events":              ErrIllegalArguments,
+			"SELECT UPPER(name, name) FROM events":          ErrIllegalArguments,
+			"SELECT SUBSTRING(name) FROM events":            ErrIllegalArguments,
+			"SELECT DATE_TRUNC('decade', ts) FROM events":   ErrIllegalArguments,
+			"SELECT EXTRACT(CENTURY FROM ts) FROM events":   ErrIllegalArguments,
+			"SELECT SUBSTRING(name, 1, -1) FROM events":     ErrIllegalArguments,
+			"SELECT id FROM events WHERE UPPER(id) = 'A'":   ErrInvalidTypes,
+			"SELECT id FROM events WHERE LENGTH(ts) > 1":    ErrInvalidTypes,
+			"SELECT id FROM events WHERE UPPER(nope) = 'A'": ErrColumnDoesNotExist,
+		} {
+			r, err := engine.Query(q, nil, nil)
+			require.NoError(t, err)
+
+			_, err = r.Read()
+			require.ErrorIs(t, err, expectedErr, q)
+
+			require.NoError(t, r.Close())
+		}
+
+		_, _, err = engine.Exec("CREATE TABLE invalid (id INTEGER, name VARCHAR DEFAULT UPPER(1), PRIMARY KEY id)", nil, nil)
+		require.ErrorIs(t, err, ErrInvalidTypes)
+	})
+}
+
+func TestViews(t *testing.T) {
+	st, err := store.Open("sqldata_views", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_views")
+	defer st.Close()
+
+	engine, err := NewEngine(st, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec("CREATE VIEW v1 AS SELECT id FROM people", nil, nil)
+	require.ErrorIs(t, err, ErrNoDatabaseSelected)
+
+	_, _, err = engine.Exec("CREATE DATABASE db1", nil, nil)
+	require.NoError(t, err)
+
+	err = engine.SetDefaultDatabase("db1")
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec(`
+		CREATE TABLE people (id INTEGER AUTO_INCREMENT, name VARCHAR, age INTEGER, tenant VARCHAR, PRIMARY KEY id);
+		CREATE TABLE pets (id INTEGER AUTO_INCREMENT, owner INTEGER, kind VARCHAR, PRIMARY KEY id);
+		INSERT INTO people(name, age, tenant) VALUES ('alice', 30, 't1'), ('bob', 12, 't1'), ('carol', 45, 't2');
+		INSERT INTO pets(owner, kind) VALUES (1, 'cat'), (2, 'dog'), (3, 'cat');
+	`, nil, nil)
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec("CREATE VIEW adults AS SELECT id, name FROM people1", nil, nil)
+	require.ErrorIs(t, err, ErrTableDoesNotExist)
+
+	_, _, err = engine.Exec("CREATE VIEW adults AS SELECT id, title FROM people", nil, nil)
+	require.ErrorIs(t, err, ErrColumnDoesNotExist)
+
+	_, _, err = engine.Exec("CREATE VIEW adults AS SELECT id, name FROM people WHERE age >= @age", nil, nil)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, _, err = engine.Exec("CREATE VIEW adults AS SELECT id, name, tenant FROM people WHERE age >= 18", nil, nil)
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec("CREATE VIEW adults AS SELECT id FROM people", nil, nil)
+	require.ErrorIs(t, err, ErrViewAlreadyExists)
+
+	_, _, err = engine.Exec("CREATE VIEW IF NOT EXISTS adults AS SELECT id FROM people", nil, nil)
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec("CREATE VIEW people AS SELECT id FROM pets", nil, nil)
+	require.ErrorIs(t, err, ErrTableAlreadyExists)
+
+	_, _, err = engine.Exec("CREATE TABLE adults (id INTEGER, PRIMARY KEY id)", nil, nil)
+	require.ErrorIs(t, err, ErrViewAlreadyExists)
+
+	_, _, err = engine.Exec("CREATE VIEW cat_owners AS SELECT a.id, a.name FROM adults AS a INNER JOIN pets ON pets.owner = a.id WHERE pets.kind = 'cat'", nil, nil)
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec("INSERT INTO adults(name) VALUES ('dave')", nil, nil)
+	require.ErrorIs(t, err, ErrTableDoesNotExist)
+
+	_, err = engine.Query("SELECT id FROM adults BEFORE TX 1", nil, nil)
+	require.ErrorIs(t, err, ErrNoSupported)
+
+	query := func(engine *Engine, attrs map[string]interface{}, sql string) ([]*Row, error) {
+		tx, err := engine.NewTx(context.Background())
+		require.NoError(t, err)
+		defer tx.Cancel()
+
+		err = tx.SetSessionAttributes(attrs)
+		require.NoError(t, err)
+
+		r, err := engine.Query(sql, nil, tx)
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+
+		var rows []*Row
+
+		for {
+			row, err := r.Read()
+			if err == ErrNoMoreRows {
+				return rows, nil
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			rows = append(rows, row)
+		}
+	}
+
+	t.Run("views should be read as tables", func(t *testing.T) {
+		rows, err := query(engine, nil, "SELECT id, name FROM adults")
+		require.NoError(t, err)
+		require.Len(t, rows, 2)
+		require.Equal(t, "alice", rows[0].Values[EncodeSelector("", "db1", "adults", "name")].Value())
+		require.Equal(t, "carol", rows[1].Values[EncodeSelector("", "db1", "adults", "name")].Value())
+
+		rows, err = query(engine, nil, "SELECT a.name FROM adults AS a WHERE a.id > 1")
+		require.NoError(t, err)
+		require.Len(t, rows, 1)
+		require.Equal(t, "carol", rows[0].Values[EncodeSelector("", "db1", "a", "name")].Value())
+
+		rows, err = query(engine, nil, "SELECT COUNT(*) AS c FROM adults")
+		require.NoError(t, err)
+		require.Len(t, rows, 1)
+		require.Equal(t, int64(2), rows[0].Values[EncodeSelector("", "db1", "adults", "c")].Value())
+	})
+
+	t.Run("views should be joined and nested", func(t *testing.T) {
+		rows, err := query(engine, nil, "SELECT pets.kind FROM pets INNER JOIN adults ON adults.id = pets.owner")
+		require.NoError(t, err)
+		require.Len(t, rows, 2)
+
+		rows, err = query(engine, nil, "SELECT name FROM cat_owners")
+		require.NoError(t, err)
+		require.Len(t, rows, 2)
+	})
+
+	t.Run("views should be filtered by the policies of their tables", func(t *testing.T) {
+		_, _, err = engine.Exec("CREATE POLICY tenancy ON people USING (tenant = @tenant)", nil, nil)
+		require.NoError(t, err)
+
+		rows, err := query(engine, map[string]interface{}{"tenant": "t2"}, "SELECT name FROM adults")
+		require.NoError(t, err)
+		require.Len(t, rows, 1)
+		require.Equal(t, "carol", rows[0].Values[EncodeSelector("", "db1", "adults", "name")].Value())
+	})
+
+	t.Run("views should be loaded when reopening the engine", func(t *testing.T) {
+		engine, err := NewEngine(st, DefaultOptions().WithPrefix(sqlPrefix))
+		require.NoError(t, err)
+
+		err = engine.SetDefaultDatabase("db1")
+		require.NoError(t, err)
+
+		rows, err := query(engine, nil, "SELECT name FROM cat_owners")
+		require.NoError(t, err)
+		require.Len(t, rows, 2)
+
+		catalog, err := engine.Catalog(nil)
+		require.NoError(t, err)
+
+		db, err := catalog.GetDatabaseByName("db1")
+		require.NoError(t, err)
+
+		views := db.GetViews()
+		require.Len(t, views, 2)
+		require.Equal(t, "adults", views[0].Name())
+		require.Equal(t, "SELECT id, name, tenant FROM people WHERE (age >= 18)", views[0].Query())
+		require.Equal(t, "cat_owners", views[1].Name())
+	})
+
+	t.Run("dropped views should not be readable", func(t *testing.T) {
+		_, _, err = engine.Exec("DROP VIEW adults1", nil, nil)
+		require.ErrorIs(t, err, ErrViewDoesNotExist)
+
+		_, _, err = engine.Exec("DROP VIEW cat_owners", nil, nil)
+		require.NoError(t, err)
+
+		_, err := query(engine, nil, "SELECT name FROM cat_owners")
+		require.ErrorIs(t, err, ErrTableDoesNotExist)
+
+		_, _, err = engine.Exec("CREATE VIEW cat_owners AS SELECT owner FROM pets WHERE kind = 'cat'", nil, nil)
+		require.NoError(t, err)
+
+		rows, err := query(engine, nil, "SELECT owner FROM cat_owners")
+		require.NoError(t, err)
+		require.Len(t, rows, 2)
+	})
+}
+
+func TestProcedures(t *testing.T) {
+	st, err := store.Open("sqldata_procedures", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_procedures")
+	defer st.Close()
+
+	engine, err := NewEngine(st, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec("CREATE PROCEDURE p1() AS BEGIN DELETE FROM accounts; END", nil, nil)
+	require.ErrorIs(t, err, ErrNoDatabaseSelected)
+
+	_, _, err = engine.Exec("CREATE DATABASE db1", nil, nil)
+	require.NoError(t, err)
+
+	err = engine.SetDefaultDatabase("db1")
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec(`
+		CREATE TABLE accounts (id INTEGER, balance INTEGER, PRIMARY KEY id);
+		CREATE TABLE movements (id INTEGER AUTO_INCREMENT, src INTEGER, dst INTEGER, amount INTEGER, PRIMARY KEY id);
+		INSERT INTO accounts(id, balance) VALUES (1, 100), (2, 0);
+	`, nil, nil)
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec("CREATE PROCEDURE p1() AS BEGIN DELETE FROM accounts1; END", nil, nil)
+	require.ErrorIs(t, err, ErrTableDoesNotExist)
+
+	_, _, err = engine.Exec("CREATE PROCEDURE p1(acc INTEGER) AS BEGIN DELETE FROM accounts WHERE id = @id; END", nil, nil)
+	require.ErrorIs(t, err, ErrMissingParameter)
+
+	_, _, err = engine.Exec("CREATE PROCEDURE p1(acc INTEGER, acc VARCHAR) AS BEGIN DELETE FROM accounts WHERE id = @acc; END", nil, nil)
+	require.ErrorIs(t, err, ErrDuplicatedParameters)
+
+	_, _, err = engine.Exec("CREATE PROCEDURE p1(acc VARCHAR) AS BEGIN DELETE FROM accounts WHERE id = @acc; END", nil, nil)
+	require.ErrorIs(t, err, ErrInvalidTypes)
+
+	_, _, err = engine.Exec(`
+		CREATE PROCEDURE transfer(src INTEGER, dst INTEGER, amount INTEGER) AS BEGIN
+			UPDATE accounts SET balance = balance - @amount WHERE id = @src AND balance >= @amount;
+			IF ROW_COUNT() = 0 THEN
+				RAISE 'insufficient funds';
+			END IF;
+			UPDATE accounts SET balance = balance + @amount WHERE id = @dst;
+			IF ROW_COUNT() = 0 THEN
+				INSERT INTO accounts(id, balance) VALUES (@dst, @amount);
+			END IF;
+			INSERT INTO movements(src, dst, amount) VALUES (@src, @dst, @amount);
+		END
+	`, nil, nil)
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec("CREATE PROCEDURE transfer() AS BEGIN DELETE FROM movements; END", nil, nil)
+	require.ErrorIs(t, err, ErrProcedureAlreadyExists)
+
+	_, _, err = engine.Exec("CREATE PROCEDURE IF NOT EXISTS transfer() AS BEGIN DELETE FROM movements; END", nil, nil)
+	require.NoError(t, err)
+
+	balance := func(engine *Engine, id int) interface{} {
+		r, err := engine.Query("SELECT balance FROM accounts WHERE id = @id", map[string]interface{}{"id": id}, nil)
+		require.NoError(t, err)
+		defer r.Close()
+
+		row, err := r.Read()
+		if err == ErrNoMoreRows {
+			return nil
+		}
+		require.NoError(t, err)
+
+		return row.Values[EncodeSelector("", "db1", "accounts", "balance")].Value()
+	}
+
+	t.Run("procedures should be called with the declared arguments", func(t *testing.T) {
+		_, _, err := engine.Exec("CALL transfer1(1, 2, 10)", nil, nil)
+		require.ErrorIs(t, err, ErrProcedureDoesNotExist)
+
+		_, _, err = engine.Exec("CALL transfer(1, 2)", nil, nil)
+		require.ErrorIs(t, err, ErrInvalidNumberOfValues)
+
+		_, _, err = engine.Exec("CALL transfer(1, 2, 'ten')", nil, nil)
+		require.ErrorIs(t, err, ErrInvalidTypes)
+
+		_, _, err = engine.Exec("CALL transfer(1, 2, @amount)", nil, nil)
+		require.ErrorIs(t, err, ErrMissingParameter)
+
+		params, err := engine.InferParameters("CALL transfer(1, 2, @amount)", nil)
+		require.NoError(t, err)
+		require.Equal(t, map[string]SQLValueType{"amount": IntegerType}, params)
+	})
+
+	t.Run("statements of procedures should be executed within a single transaction", func(t *testing.T) {
+		_, txs, err := engine.Exec("CALL transfer(1, 2, @amount)", map[string]interface{}{"amount": 60}, nil)
+		require.NoError(t, err)
+		require.Len(t, txs, 1)
+		require.Equal(t, 3, txs[0].UpdatedRows())
+
+		require.Equal(t, int64(40), balance(engine, 1))
+		require.Equal(t, int64(60), balance(engine, 2))
+
+		_, _, err = engine.Exec("CALL transfer(1, 3, 30)", nil, nil)
+		require.NoError(t, err)
+
+		require.Equal(t, int64(10), balance(engine, 1))
+		require.Equal(t, int64(30), balance(engine, 3))
+	})
+
+	t.Run("changes should be discarded when a procedure raises an error", func(t *testing.T) {
+		tx, _, err := engine.Exec("BEGIN TRANSACTION; INSERT INTO movements(src, dst, amount) VALUES (0, 0, 0);", nil, nil)
+		require.NoError(t, err)
+
+		_, _, err = engine.Exec("CALL transfer(1, 2, 20)", nil, tx)
+		require.ErrorIs(t, err, ErrProcedureRaised)
+		require.Contains(t, err.Error(), "insufficient funds")
+
+		r, err := engine.Query("SELECT COUNT(*) AS c FROM movements", nil, nil)
+		require.NoError(t, err)
+		defer r.Close()
+
+		row, err := r.Read()
+		require.NoError(t, err)
+		require.Equal(t, int64(2), row.Values[EncodeSelector("", "db1", "movements", "c")].Value())
+
+		require.Equal(t, int64(10), balance(engine, 1))
+	})
+
+	t.Run("ROW_COUNT() should only be used within procedures", func(t *testing.T) {
+		r, err := engine.Query("SELECT ROW_COUNT() FROM accounts", nil, nil)
+		require.NoError(t, err)
+		defer r.Close()
+
+		_, err = r.Read()
+		require.ErrorIs(t, err, ErrIllegalArguments)
+	})
+
+	t.Run("procedures should be loaded when reopening the engine", func(t *testing.T) {
+		engine, err := NewEngine(st, DefaultOptions().WithPrefix(sqlPrefix))
+		require.NoError(t, err)
+
+		err = engine.SetDefaultDatabase("db1")
+		require.NoError(t, err)
+
+		_, _, err = engine.Exec("CALL transfer(3, 4, 5)", nil, nil)
+		require.NoError(t, err)
+
+		require.Equal(t, int64(25), balance(engine, 3))
+		require.Equal(t, int64(5), balance(engine, 4))
+
+		catalog, err := engine.Catalog(nil)
+		require.NoError(t, err)
+
+		db, err := catalog.GetDatabaseByName("db1")
+		require.NoError(t, err)
+
+		procs := db.GetProcedures()
+		require.Len(t, procs, 1)
+		require.Equal(t, "transfer", procs[0].Name())
+		require.Len(t, procs[0].Params(), 3)
+		require.Equal(t, "amount", procs[0].Params()[2].Name())
+		require.Equal(t, IntegerType, procs[0].Params()[2].Type())
+		require.Equal(t, "CREATE PROCEDURE transfer(src INTEGER, dst INTEGER, amount INTEGER) AS BEGIN "+
+			"UPDATE accounts SET balance = (balance - @amount) WHERE ((id = @src) AND (balance >= @amount)); "+
+			"IF (ROW_COUNT() = 0) THEN RAISE 'insufficient funds'; END IF; "+
+			"UPDATE accounts SET balance = (balance + @amount) WHERE (id = @dst); "+
+			"IF (ROW_COUNT() = 0) THEN INSERT INTO accounts(id, balance) VALUES (@dst, @amount); END IF; "+
+			"INSERT INTO movements(src, dst, amount) VALUES (@src, @dst, @amount); END", procs[0].Definition())
+	})
+
+	t.Run("dropped procedures should not be callable", func(t *testing.T) {
+		_, _, err = engine.Exec("DROP PROCEDURE transfer1", nil, nil)
+		require.ErrorIs(t, err, ErrProcedureDoesNotExist)
+
+		_, _, err = engine.Exec("DROP PROCEDURE transfer", nil, nil)
+		require.NoError(t, err)
+
+		_, _, err = engine.Exec("CALL transfer(3, 4, 5)", nil, nil)
+		require.ErrorIs(t, err, ErrProcedureDoesNotExist)
+	})
+}
+
+func TestHistoryOf(t *testing.T) {
+	st, err := store.Open("sqldata_history", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_history")
+	defer st.Close()
+
+	engine, err := NewEngine(st, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec(`
+		CREATE DATABASE db1;
+		USE DATABASE db1;
+		CREATE TABLE accounts (id INTEGER, owner VARCHAR[32], balance INTEGER, PRIMARY KEY id);
+		CREATE INDEX ON accounts(owner);
+		INSERT INTO accounts(id, owner, balance) VALUES (1, 'alice', 100), (2, 'bob', 50);
+	`, nil, nil)
+	require.NoError(t, err)
+
+	err = engine.SetDefaultDatabase("db1")
+	require.NoError(t, err)
+
+	tx, err := engine.NewTx(context.Background())
+	require.NoError(t, err)
+
+	err = tx.SetUser("carol")
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec("UPDATE accounts SET balance = balance - 30 WHERE id = 1", nil, tx)
+	require.NoError(t, err)
+
+	err = tx.Commit(context.Background())
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec("DELETE FROM accounts WHERE id = 2", nil, nil)
+	require.NoError(t, err)
+
+	readRows := func(sql string, params map[string]interface{}) []*Row {
+		r, err := engine.Query(sql, params, nil)
+		require.NoError(t, err)
+		defer r.Close()
+
+		var rows []*Row
+
+		for {
+			row, err := r.Read()
+			if err == ErrNoMoreRows {
+				return rows
+			}
+			require.NoError(t, err)
+
+			rows = append(rows, row)
+		}
+	}
+
+	sel := func(col string) string {
+		return EncodeSelector("", "db1", "accounts", col)
+	}
+
+	t.Run("every revision of a row should be read", func(t *testing.T) {
+		rows := readRows("SELECT id, balance, _tx_id, _tx_ts, _tx_user, _deleted FROM HISTORY OF accounts WHERE id = @id", map[string]interface{}{"id": 1})
+		require.Len(t, rows, 2)
+
+		require.Equal(t, int64(100), rows[0].Values[sel("balance")].Value())
+		require.Nil(t, rows[0].Values[sel("_tx_user")].Value())
+		require.False(t, rows[0].Values[sel("_deleted")].Value().(bool))
+
+		require.Equal(t, int64(70), rows[1].Values[sel("balance")].Value())
+		require.Equal(t, "carol", rows[1].Values[sel("_tx_user")].Value())
+		require.Greater(t, rows[1].Values[sel("_tx_id")].Value(), rows[0].Values[sel("_tx_id")].Value())
+		require.False(t, rows[1].Values[sel("_tx_ts")].Value().(time.Time).IsZero())
+
+		cols, err := func() ([]ColDescriptor, error) {
+			r, err := engine.Query("SELECT * FROM HISTORY OF accounts", nil, nil)
+			require.NoError(t, err)
+			defer r.Close()
+
+			return r.Columns()
+		}()
+		require.NoError(t, err)
+		require.Len(t, cols, 7)
+		require.Equal(t, "_deleted", cols[6].Column)
+	})
+
+	t.Run("deleted rows should be read with their last values", func(t *testing.T) {
+		rows := readRows("SELECT h.owner, h._deleted FROM HISTORY OF accounts AS h WHERE h.id = 2", nil)
+		require.Len(t, rows, 2)
+
+		require.Equal(t, "bob", rows[1].Values[EncodeSelector("", "db1", "h", "owner")].Value())
+		require.True(t, rows[1].Values[EncodeSelector("", "db1", "h", "_deleted")].Value().(bool))
+
+		rows = readRows("SELECT id FROM accounts", nil)
+		require.Len(t, rows, 1)
+	})
+
+	t.Run("the history should be read by primary key", func(t *testing.T) {
+		rows := readRows("SELECT id FROM HISTORY OF accounts WHERE owner = 'bob'", nil)
+		require.Len(t, rows, 2)
+
+		_, err := engine.Query("SELECT id FROM HISTORY OF accounts ORDER BY owner", nil, nil)
+		require.ErrorIs(t, err, ErrLimitedOrderBy)
+	})
+
+	t.Run("revisions committed after the snapshot should not be read", func(t *testing.T) {
+		tx, err := engine.NewTx(context.Background())
+		require.NoError(t, err)
+		defer tx.Cancel()
+
+		_, _, err = engine.Exec("UPSERT INTO accounts(id, owner, balance) VALUES (1, 'alice', 10)", nil, nil)
+		require.NoError(t, err)
+
+		r, err := engine.Query("SELECT id FROM HISTORY OF accounts WHERE id = 1", nil, tx)
+		require.NoError(t, err)
+		defer r.Close()
+
+		for i := 0; i < 2; i++ {
+			_, err = r.Read()
+			require.NoError(t, err)
+		}
+
+		_, err = r.Read()
+		require.ErrorIs(t, err, ErrNoMoreRows)
+	})
+
+	t.Run("users exceeding the maximum length should be rejected", func(t *testing.T) {
+		tx, err := engine.NewTx(context.Background())
+		require.NoError(t, err)
+		defer tx.Cancel()
+
+		err = tx.SetUser(strings.Repeat("u", store.MaxTxMetadataUserLen+1))
+		require.ErrorIs(t, err, store.ErrIllegalArguments)
+	})
+}
+
+func TestSavepoints(t *testing.T) {
+	st, err := store.Open("sqldata_savepoints", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_savepoints")
+	defer st.Close()
+
+	engine, err := NewEngine(st, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec(`
+		CREATE DATABASE db1;
+		USE DATABASE db1;
+		CREATE TABLE items (id INTEGER AUTO_INCREMENT, name VARCHAR, PRIMARY KEY id);
+	`, nil, nil)
+	require.NoError(t, err)
+
+	err = engine.SetDefaultDatabase("db1")
+	require.NoError(t, err)
+
+	names := func(tx *SQLTx) []string {
+		r, err := engine.Query("SELECT name FROM items", nil, tx)
+		require.NoError(t, err)
+		defer r.Close()
+
+		var names []string
+
+		for {
+			row, err := r.Read()
+			if err == ErrNoMoreRows {
+				break
+			}
+			require.NoError(t, err)
+
+			names = append(names, row.Values[EncodeSelector("", "db1", "items", "name")].Value().(string))
+		}
+
+		return names
+	}
+
+	_, _, err = engine.Exec("SAVEPOINT sp1", nil, nil)
+	require.ErrorIs(t, err, ErrNoOngoingTx)
+
+	t.Run("changes made after a savepoint should be discarded when rolling back to it", func(t *testing.T) {
+		tx, _, err := engine.Exec(`
+			BEGIN TRANSACTION;
+			INSERT INTO items(name) VALUES ('a');
+			SAVEPOINT sp1;
+			INSERT INTO items(name) VALUES ('b');
+			CREATE TABLE items2 (id INTEGER, PRIMARY KEY id);
+			ROLLBACK TO SAVEPOINT sp1;
+		`, nil, nil)
+		require.NoError(t, err)
+		require.Equal(t, []string{"a"}, names(tx))
+
+		_, err = engine.Query("SELECT id FROM items2", nil, tx)
+		require.ErrorIs(t, err, ErrTableDoesNotExist)
+
+		tx, txs, err := engine.Exec("INSERT INTO items(name) VALUES ('c'); COMMIT;", nil, tx)
+		require.NoError(t, err)
+		require.Nil(t, tx)
+		require.Len(t, txs, 1)
+		require.Equal(t, 2, txs[0].UpdatedRows())
+		require.Equal(t, int64(2), txs[0].LastInsertedPKs()["items"])
+
+		require.Equal(t, []string{"a", "c"}, names(nil))
+	})
+
+	t.Run("transactions should be aborted until rolled back to a savepoint when a statement fails", func(t *testing.T) {
+		tx, _, err := engine.Exec(`
+			BEGIN TRANSACTION;
+			INSERT INTO items(id, name) VALUES (10, 'd');
+			SAVEPOINT sp1;
+		`, nil, nil)
+		require.NoError(t, err)
+
+		tx, _, err = engine.Exec("INSERT INTO items(id, name) VALUES (11, 'e'), (1, 'f')", nil, tx)
+		require.Error(t, err)
+		require.NotNil(t, tx)
+
+		tx, _, err = engine.Exec("INSERT INTO items(id, name) VALUES (12, 'g')", nil, tx)
+		require.ErrorIs(t, err, ErrTxAborted)
+
+		_, err = engine.Query("SELECT name FROM items", nil, tx)
+		require.ErrorIs(t, err, ErrTxAborted)
+
+		tx, _, err = engine.Exec("ROLLBACK TO sp1; INSERT INTO items(id, name) VALUES (12, 'g'); RELEASE SAVEPOINT sp1;", nil, tx)
+		require.NoError(t, err)
+
+		_, _, err = engine.Exec("COMMIT", nil, tx)
+		require.NoError(t, err)
+
+		require.Equal(t, []string{"a", "c", "d", "g"}, names(nil))
+	})
+
+	t.Run("aborted transactions should not be committed", func(t *testing.T) {
+		tx, _, err := engine.Exec("BEGIN TRANSACTION; INSERT INTO items(name) VALUES ('h'); SAVEPOINT sp1;", nil, nil)
+		require.NoError(t, err)
+
+		tx, _, err = engine.Exec("INSERT INTO items(id, name) VALUES (1, 'i')", nil, tx)
+		require.Error(t, err)
+
+		_, _, err = engine.Exec("COMMIT", nil, tx)
+		require.ErrorIs(t, err, ErrTxAborted)
+
+		require.Equal(t, []string{"a", "c", "d", "g"}, names(nil))
+	})
+
+	t.Run("released savepoints should not be rolled back to", func(t *testing.T) {
+		tx, _, err := engine.Exec("BEGIN TRANSACTION; SAVEPOINT sp1; SAVEPOINT sp2; RELEASE sp1;", nil, nil)
+		require.NoError(t, err)
+
+		_, _, err = engine.Exec("ROLLBACK TO sp2", nil, tx)
+		require.ErrorIs(t, err, ErrSavepointDoesNotExist)
+	})
+}
@@ -0,0 +1,470 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package sql
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+const explainTableAlias = "explain"
+
+// columns of the rows EXPLAIN resolves to, one row per operator of the query plan
+const (
+	explainOperatorCol = "operator"
+	explainDetailsCol  = "details"
+	explainEstRowsCol  = "est_rows"
+	explainRowsCol     = "rows"
+	explainTimeCol     = "time_us"
+)
+
+// planOperator describes a step of the query execution, children are the operators it reads rows from
+type planOperator struct {
+	name     string
+	details  string
+	estRows  int64 // negative when unknown
+	children []*planOperator
+
+	// set when the query is analyzed
+	stats *analyzedRowReader
+}
+
+type queryPlan struct {
+	analyze bool
+	root    *planOperator
+}
+
+// add records op as the root of the plan, reading rows from the former root.
+// When the query is analyzed, the rows returned by rowReader are counted and timed.
+// It's a no-op on a nil plan, so queries not being explained are resolved as usual.
+func (p *queryPlan) add(rowReader RowReader, op *planOperator) RowReader {
+	if p == nil {
+		return rowReader
+	}
+
+	if p.root != nil {
+		op.children = append([]*planOperator{p.root}, op.children...)
+	}
+
+	p.root = op
+
+	if !p.analyze {
+		return rowReader
+	}
+
+	op.stats = &analyzedRowReader{RowReader: rowReader}
+
+	return op.stats
+}
+
+// estRows returns the estimated number of rows read from the root of the plan
+func (p *queryPlan) estRows() int64 {
+	if p == nil || p.root == nil {
+		return -1
+	}
+
+	return p.root.estRows
+}
+
+func scanOperator(tx *SQLTx, ds DataSource, scanSpecs *ScanSpecs) *planOperator {
+	tableRef, ok := ds.(*tableRef)
+	if !ok || scanSpecs == nil {
+		return &planOperator{name: "Scan", details: ds.Alias(), estRows: -1}
+	}
+
+	details := []string{tableRef.String()}
+
+	var idxCols []string
+	for _, col := range scanSpecs.index.cols {
+		idxCols = append(idxCols, col.colName)
+	}
+
+	order := "ASC"
+	if scanSpecs.descOrder {
+		order = "DESC"
+	}
+
+	details = append(details, fmt.Sprintf("index: (%s) %s", strings.Join(idxCols, ", "), order))
+
+	var ranges []string
+	for _, col := range scanSpecs.index.cols {
+		colRange, ok := scanSpecs.rangesByColID[col.id]
+		if !ok {
+			break
+		}
+
+		ranges = append(ranges, colRange.string(col.colName))
+	}
+
+	if len(ranges) > 0 {
+		details = append(details, "range: "+strings.Join(ranges, " AND "))
+	}
+
+	if scanSpecs.offset > 0 {
+		details = append(details, fmt.Sprintf("offset: %d", scanSpecs.offset))
+	}
+
+	estRows := int64(-1)
+
+	table, err := tableRef.referencedTable(tx)
+	if err == nil {
+		if tx.sessionAttrs != nil && len(table.policies) > 0 {
+			var policies []string
+			for _, p := range table.Policies() {
+				policies = append(policies, p.name)
+			}
+
+			details = append(details, "policies: "+strings.Join(policies, ", "))
+		}
+
+		estRows = table.estimatedRows(scanSpecs)
+
+		if estRows >= 0 {
+			estRows -= int64(scanSpecs.offset)
+
+			if estRows < 0 {
+				estRows = 0
+			}
+		}
+	}
+
+	name := "Scan"
+	if len(ranges) > 0 {
+		name = "IndexRangeScan"
+	}
+
+	return &planOperator{name: name, details: strings.Join(details, ", "), estRows: estRows}
+}
+
+// estimatedRows returns an upper bound of the number of rows the scan reads,
+// a negative value is returned when it can not be estimated
+func (t *Table) estimatedRows(scanSpecs *ScanSpecs) int64 {
+	if scanSpecs.index.IsUnique() {
+		unitary := true
+
+		for _, col := range scanSpecs.index.cols {
+			colRange, ok := scanSpecs.rangesByColID[col.id]
+			if !ok || !colRange.unitary() {
+				unitary = false
+				break
+			}
+		}
+
+		if unitary {
+			return 1
+		}
+	}
+
+	if t.stats != nil {
+		return int64(math.Ceil(t.stats.estimateRows(scanSpecs.index, scanSpecs.rangesByColID)))
+	}
+
+	// rows are never assigned a pk greater than the last assigned one
+	if t.autoIncrementPK {
+		return t.maxPK
+	}
+
+	return -1
+}
+
+func (r *typedValueRange) string(col string) string {
+	if r.unitary() {
+		return fmt.Sprintf("%s = %s", col, r.lRange.val.String())
+	}
+
+	var conds []string
+
+	if r.lRange != nil {
+		op := ">"
+		if r.lRange.inclusive {
+			op = ">="
+		}
+
+		conds = append(conds, fmt.Sprintf("%s %s %s", col, op, r.lRange.val.String()))
+	}
+
+	if r.hRange != nil {
+		op := "<"
+		if r.hRange.inclusive {
+			op = "<="
+		}
+
+		conds = append(conds, fmt.Sprintf("%s %s %s", col, op, r.hRange.val.String()))
+	}
+
+	return strings.Join(conds, " AND ")
+}
+
+// joinOperator describes the nested loop joins, for each row read the joint data sources are queried
+// using the join condition bound to the values of the row
+func joinOperator(joins []*JoinSpec) *planOperator {
+	op := &planOperator{name: "NestedLoopJoin", estRows: -1}
+
+	var details []string
+
+	for _, join := range joins {
+		details = append(details, join.String())
+
+		op.children = append(op.children, &planOperator{
+			name:    "Lookup",
+			details: fmt.Sprintf("%s, per outer row", dataSourceString(join.ds)),
+			estRows: -1,
+		})
+	}
+
+	op.details = strings.Join(details, " ")
+
+	return op
+}
+
+func aggregateOperator(groupBy []*ColSelector, estRows int64) *planOperator {
+	if len(groupBy) == 0 {
+		return &planOperator{name: "Aggregate", estRows: 1}
+	}
+
+	var cols []string
+	for _, col := range groupBy {
+		cols = append(cols, col.String())
+	}
+
+	return &planOperator{name: "Aggregate", details: "group by: " + strings.Join(cols, ", "), estRows: estRows}
+}
+
+func projectOperator(selectors []Selector, estRows int64) *planOperator {
+	if len(selectors) == 0 {
+		return &planOperator{name: "Project", details: "*", estRows: estRows}
+	}
+
+	var sels []string
+	for _, sel := range selectors {
+		sels = append(sels, selectorString(sel))
+	}
+
+	return &planOperator{name: "Project", details: strings.Join(sels, ", "), estRows: estRows}
+}
+
+func limitOperator(limit, offset int, estRows int64) *planOperator {
+	if estRows >= 0 {
+		estRows -= int64(offset)
+
+		if estRows < 0 {
+			estRows = 0
+		}
+	}
+
+	if limit > 0 && (estRows < 0 || estRows > int64(limit)) {
+		estRows = int64(limit)
+	}
+
+	var details []string
+
+	if limit > 0 {
+		details = append(details, fmt.Sprintf("%d", limit))
+	}
+
+	if offset > 0 {
+		details = append(details, fmt.Sprintf("offset: %d", offset))
+	}
+
+	return &planOperator{name: "Limit", details: strings.Join(details, ", "), estRows: estRows}
+}
+
+// analyzedRowReader counts the rows read and the time spent reading them, including the time spent by the readers it reads from
+type analyzedRowReader struct {
+	RowReader
+
+	rows    int64
+	elapsed time.Duration
+}
+
+func (r *analyzedRowReader) Read() (*Row, error) {
+	start := time.Now()
+
+	row, err := r.RowReader.Read()
+
+	r.elapsed += time.Since(start)
+
+	if err == nil {
+		r.rows++
+	}
+
+	return row, err
+}
+
+// explainRowReader returns a row per operator of the plan of a query, in depth-first order.
+// Analyzed queries are fully read on the first read, so their parameters can still be set beforehand.
+type explainRowReader struct {
+	rowReader RowReader
+	plan      *queryPlan
+
+	colsByPos []ColDescriptor
+	colsBySel map[string]ColDescriptor
+
+	rows []*Row
+	read bool
+}
+
+func newExplainRowReader(rowReader RowReader, plan *queryPlan) (*explainRowReader, error) {
+	if rowReader == nil || plan == nil || plan.root == nil {
+		return nil, ErrIllegalArguments
+	}
+
+	cols := []ColDescriptor{
+		{Column: explainOperatorCol, Type: VarcharType},
+		{Column: explainDetailsCol, Type: VarcharType},
+		{Column: explainEstRowsCol, Type: IntegerType},
+	}
+
+	if plan.analyze {
+		cols = append(cols,
+			ColDescriptor{Column: explainRowsCol, Type: IntegerType},
+			ColDescriptor{Column: explainTimeCol, Type: IntegerType},
+		)
+	}
+
+	colsBySel := make(map[string]ColDescriptor, len(cols))
+
+	for i := range cols {
+		cols[i].Database = rowReader.Database().name
+		cols[i].Table = explainTableAlias
+
+		colsBySel[cols[i].Selector()] = cols[i]
+	}
+
+	return &explainRowReader{
+		rowReader: rowReader,
+		plan:      plan,
+		colsByPos: cols,
+		colsBySel: colsBySel,
+	}, nil
+}
+
+func (er *explainRowReader) onClose(callback func()) {
+	er.rowReader.onClose(callback)
+}
+
+func (er *explainRowReader) Tx() *SQLTx {
+	return er.rowReader.Tx()
+}
+
+func (er *explainRowReader) Database() *Database {
+	return er.rowReader.Database()
+}
+
+func (er *explainRowReader) TableAlias() string {
+	return explainTableAlias
+}
+
+func (er *explainRowReader) SetParameters(params map[string]interface{}) error {
+	return er.rowReader.SetParameters(params)
+}
+
+func (er *explainRowReader) OrderBy() []ColDescriptor {
+	return nil
+}
+
+func (er *explainRowReader) ScanSpecs() *ScanSpecs {
+	return nil
+}
+
+func (er *explainRowReader) Columns() ([]ColDescriptor, error) {
+	return er.colsByPos, nil
+}
+
+func (er *explainRowReader) colsBySelector() (map[string]ColDescriptor, error) {
+	return er.colsBySel, nil
+}
+
+func (er *explainRowReader) InferParameters(params map[string]SQLValueType) error {
+	return er.rowReader.InferParameters(params)
+}
+
+func (er *explainRowReader) Read() (*Row, error) {
+	if !er.read {
+		err := er.explain()
+		if err != nil {
+			return nil, err
+		}
+
+		er.read = true
+	}
+
+	if len(er.rows) == 0 {
+		return nil, ErrNoMoreRows
+	}
+
+	row := er.rows[0]
+	er.rows = er.rows[1:]
+
+	return row, nil
+}
+
+func (er *explainRowReader) explain() error {
+	if er.plan.analyze {
+		for {
+			_, err := er.rowReader.Read()
+			if err == ErrNoMoreRows {
+				break
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	er.appendRows(er.plan.root, 0)
+
+	return nil
+}
+
+func (er *explainRowReader) appendRows(op *planOperator, depth int) {
+	values := []TypedValue{
+		&Varchar{val: strings.Repeat("  ", depth) + op.name},
+		&Varchar{val: op.details},
+		&NullValue{t: IntegerType},
+	}
+
+	if op.estRows >= 0 {
+		values[2] = &Number{val: op.estRows}
+	}
+
+	if er.plan.analyze {
+		// operators without stats are only resolved while reading e.g. the lookups of the joins
+		if op.stats == nil {
+			values = append(values, &NullValue{t: IntegerType}, &NullValue{t: IntegerType})
+		} else {
+			values = append(values, &Number{val: op.stats.rows}, &Number{val: op.stats.elapsed.Microseconds()})
+		}
+	}
+
+	row := &Row{Values: make(map[string]TypedValue, len(values))}
+
+	for i, v := range values {
+		row.Values[er.colsByPos[i].Selector()] = v
+	}
+
+	er.rows = append(er.rows, row)
+
+	for _, child := range op.children {
+		er.appendRows(child, depth+1)
+	}
+}
+
+func (er *explainRowReader) Close() error {
+	return er.rowReader.Close()
+}
@@ -0,0 +1,466 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package sql
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// builtinFn describes a function callable from SQL statements
+type builtinFn struct {
+	// argTypes holds the types accepted by each argument, the last ones are accepted by
+	// any further argument of variadic functions
+	argTypes [][]SQLValueType
+	minArgs  int
+	variadic bool
+
+	retType SQLValueType
+
+	// results of functions not null-safe are NULL when any argument is NULL
+	nullSafe bool
+	// volatile functions may return a different value on each call
+	volatile bool
+
+	apply func(args []TypedValue) (TypedValue, error)
+}
+
+var builtinFns = map[string]*builtinFn{
+	"NOW": {
+		retType:  TimestampType,
+		volatile: true,
+		apply: func(args []TypedValue) (TypedValue, error) {
+			return &Timestamp{val: time.Now().UTC()}, nil
+		},
+	},
+	"RANDOM_UUID": {
+		retType:  VarcharType,
+		volatile: true,
+		apply:    randomUUID,
+	},
+	"UUID": {
+		retType:  VarcharType,
+		volatile: true,
+		apply:    randomUUID,
+	},
+	"SUBSTRING": {
+		argTypes: [][]SQLValueType{{VarcharType}, {IntegerType}, {IntegerType}},
+		minArgs:  2,
+		retType:  VarcharType,
+		apply:    substring,
+	},
+	"CONCAT": {
+		argTypes: [][]SQLValueType{{VarcharType, IntegerType, BooleanType, TimestampType}},
+		minArgs:  1,
+		variadic: true,
+		retType:  VarcharType,
+		nullSafe: true,
+		apply:    concat,
+	},
+	"LOWER": {
+		argTypes: [][]SQLValueType{{VarcharType}},
+		minArgs:  1,
+		retType:  VarcharType,
+		apply: func(args []TypedValue) (TypedValue, error) {
+			return &Varchar{val: strings.ToLower(args[0].Value().(string))}, nil
+		},
+	},
+	"UPPER": {
+		argTypes: [][]SQLValueType{{VarcharType}},
+		minArgs:  1,
+		retType:  VarcharType,
+		apply: func(args []TypedValue) (TypedValue, error) {
+			return &Varchar{val: strings.ToUpper(args[0].Value().(string))}, nil
+		},
+	},
+	"LENGTH": {
+		argTypes: [][]SQLValueType{{VarcharType, BLOBType}},
+		minArgs:  1,
+		retType:  IntegerType,
+		apply:    length,
+	},
+	"DATE_TRUNC": {
+		argTypes: [][]SQLValueType{{VarcharType}, {TimestampType}},
+		minArgs:  2,
+		retType:  TimestampType,
+		apply:    dateTrunc,
+	},
+	"EXTRACT": {
+		argTypes: [][]SQLValueType{{VarcharType}, {TimestampType}},
+		minArgs:  2,
+		retType:  IntegerType,
+		apply:    extract,
+	},
+	"ROW_COUNT": {
+		retType:  IntegerType,
+		volatile: true,
+		apply: func(args []TypedValue) (TypedValue, error) {
+			return nil, fmt.Errorf("%w: ROW_COUNT() can only be used within procedures", ErrIllegalArguments)
+		},
+	},
+	"SHA256": {
+		argTypes: [][]SQLValueType{{VarcharType, BLOBType}},
+		minArgs:  1,
+		retType:  BLOBType,
+		apply:    sha256Sum,
+	},
+}
+
+func (fn *builtinFn) argTypesAt(i int) []SQLValueType {
+	if i < len(fn.argTypes) {
+		return fn.argTypes[i]
+	}
+
+	return fn.argTypes[len(fn.argTypes)-1]
+}
+
+func acceptedType(t SQLValueType, types []SQLValueType) bool {
+	for _, at := range types {
+		if t == at {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SysFn is a call to a builtin function e.g. NOW(), UPPER(name) or DATE_TRUNC('day', ts),
+// it can be used as an expression or as a selector
+type SysFn struct {
+	fn     string
+	params []ValueExp
+	as     string
+}
+
+func (v *SysFn) builtin() (*builtinFn, error) {
+	fn, ok := builtinFns[strings.ToUpper(v.fn)]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown function %s", ErrIllegalArguments, v.fn)
+	}
+
+	if len(v.params) < fn.minArgs || (!fn.variadic && len(v.params) > len(fn.argTypes)) {
+		return nil, fmt.Errorf("%w: invalid number of arguments for function %s", ErrIllegalArguments, strings.ToUpper(v.fn))
+	}
+
+	return fn, nil
+}
+
+func (v *SysFn) inferType(cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) (SQLValueType, error) {
+	fn, err := v.builtin()
+	if err != nil {
+		return AnyType, err
+	}
+
+	for i, param := range v.params {
+		types := fn.argTypesAt(i)
+
+		t, err := param.inferType(cols, params, implicitDB, implicitTable)
+		if err != nil {
+			return AnyType, err
+		}
+
+		if t == AnyType {
+			// parameters and nulls take the first type accepted by the argument
+			err = param.requiresType(types[0], cols, params, implicitDB, implicitTable)
+			if err != nil {
+				return AnyType, err
+			}
+
+			continue
+		}
+
+		if !acceptedType(t, types) {
+			return AnyType, fmt.Errorf("%w: %v can not be used as argument %d of function %s", ErrInvalidTypes, t, i+1, strings.ToUpper(v.fn))
+		}
+	}
+
+	return fn.retType, nil
+}
+
+func (v *SysFn) requiresType(t SQLValueType, cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) error {
+	ft, err := v.inferType(cols, params, implicitDB, implicitTable)
+	if err != nil {
+		return err
+	}
+
+	if t != ft {
+		return fmt.Errorf("%w: %v can not be interpreted as type %v", ErrInvalidTypes, ft, t)
+	}
+
+	return nil
+}
+
+func (v *SysFn) substitute(params map[string]interface{}) (ValueExp, error) {
+	rowCount, ok := params[rowCountParam]
+	if ok && len(v.params) == 0 && strings.EqualFold(v.fn, "ROW_COUNT") {
+		return &Number{val: rowCount.(int64)}, nil
+	}
+
+	if len(v.params) == 0 {
+		return v, nil
+	}
+
+	sparams := make([]ValueExp, len(v.params))
+
+	for i, param := range v.params {
+		sparam, err := param.substitute(params)
+		if err != nil {
+			return nil, err
+		}
+
+		sparams[i] = sparam
+	}
+
+	return &SysFn{fn: v.fn, params: sparams, as: v.as}, nil
+}
+
+func (v *SysFn) reduce(catalog *Catalog, row *Row, implicitDB, implicitTable string) (TypedValue, error) {
+	fn, err := v.builtin()
+	if err != nil {
+		return nil, err
+	}
+
+	args := make([]TypedValue, len(v.params))
+
+	for i, param := range v.params {
+		arg, err := param.reduce(catalog, row, implicitDB, implicitTable)
+		if err != nil {
+			return nil, err
+		}
+
+		if arg.IsNull() {
+			if !fn.nullSafe {
+				return &NullValue{t: fn.retType}, nil
+			}
+		} else if !acceptedType(arg.Type(), fn.argTypesAt(i)) {
+			return nil, fmt.Errorf("%w: %v can not be used as argument %d of function %s", ErrInvalidTypes, arg.Type(), i+1, strings.ToUpper(v.fn))
+		}
+
+		args[i] = arg
+	}
+
+	return fn.apply(args)
+}
+
+func (v *SysFn) reduceSelectors(row *Row, implicitDB, implicitTable string) ValueExp {
+	if len(v.params) == 0 {
+		return v
+	}
+
+	params := make([]ValueExp, len(v.params))
+
+	for i, param := range v.params {
+		params[i] = param.reduceSelectors(row, implicitDB, implicitTable)
+	}
+
+	return &SysFn{fn: v.fn, params: params, as: v.as}
+}
+
+func (v *SysFn) isConstant() bool {
+	fn, err := v.builtin()
+	if err != nil || fn.volatile {
+		return false
+	}
+
+	for _, param := range v.params {
+		if !param.isConstant() {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (v *SysFn) selectorRanges(table *Table, asTable string, params map[string]interface{}, rangesByColID map[uint32]*typedValueRange) error {
+	return nil
+}
+
+func (v *SysFn) String() string {
+	return strings.ToUpper(v.fn) + valuesString(v.params)
+}
+
+func (v *SysFn) resolve(implicitDB, implicitTable string) (aggFn, db, table, col string) {
+	return "", implicitDB, implicitTable, v.String()
+}
+
+func (v *SysFn) alias() string {
+	return v.as
+}
+
+func (v *SysFn) setAlias(alias string) {
+	v.as = alias
+}
+
+func randomUUID(args []TypedValue) (TypedValue, error) {
+	return &Varchar{val: uuid.New().String()}, nil
+}
+
+// substring returns the characters of the string starting at the given position, counting from 1
+func substring(args []TypedValue) (TypedValue, error) {
+	s := []rune(args[0].Value().(string))
+
+	from := args[1].Value().(int64)
+	to := int64(len(s)) + 1
+
+	if len(args) > 2 {
+		l := args[2].Value().(int64)
+		if l < 0 {
+			return nil, fmt.Errorf("%w: negative substring length", ErrIllegalArguments)
+		}
+
+		to = from + l
+	}
+
+	if from < 1 {
+		from = 1
+	}
+
+	if to > int64(len(s))+1 {
+		to = int64(len(s)) + 1
+	}
+
+	if to <= from {
+		return &Varchar{val: ""}, nil
+	}
+
+	return &Varchar{val: string(s[from-1 : to-1])}, nil
+}
+
+// concat joins the string representation of the values, NULL values are ignored
+func concat(args []TypedValue) (TypedValue, error) {
+	var sb strings.Builder
+
+	for _, arg := range args {
+		if arg.IsNull() {
+			continue
+		}
+
+		switch v := arg.Value().(type) {
+		case string:
+			sb.WriteString(v)
+		case int64:
+			sb.WriteString(strconv.FormatInt(v, 10))
+		case bool:
+			sb.WriteString(strconv.FormatBool(v))
+		case time.Time:
+			sb.WriteString(v.Format("2006-01-02 15:04:05.999999"))
+		}
+	}
+
+	return &Varchar{val: sb.String()}, nil
+}
+
+// length returns the number of characters of strings and the number of bytes of blobs
+func length(args []TypedValue) (TypedValue, error) {
+	switch v := args[0].Value().(type) {
+	case string:
+		return &Number{val: int64(utf8.RuneCountInString(v))}, nil
+	case []byte:
+		return &Number{val: int64(len(v))}, nil
+	}
+
+	return nil, ErrInvalidValue
+}
+
+func sha256Sum(args []TypedValue) (TypedValue, error) {
+	var h [sha256.Size]byte
+
+	switch v := args[0].Value().(type) {
+	case string:
+		h = sha256.Sum256([]byte(v))
+	case []byte:
+		h = sha256.Sum256(v)
+	default:
+		return nil, ErrInvalidValue
+	}
+
+	return &Blob{val: h[:]}, nil
+}
+
+// dateTrunc truncates the timestamp to the given precision, weeks start on monday
+func dateTrunc(args []TypedValue) (TypedValue, error) {
+	field := args[0].Value().(string)
+	t := args[1].Value().(time.Time).UTC()
+
+	y, m, d := t.Date()
+
+	switch strings.ToLower(field) {
+	case "second":
+		t = t.Truncate(time.Second)
+	case "minute":
+		t = t.Truncate(time.Minute)
+	case "hour":
+		t = time.Date(y, m, d, t.Hour(), 0, 0, 0, time.UTC)
+	case "day":
+		t = time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	case "week":
+		t = time.Date(y, m, d-(int(t.Weekday())+6)%7, 0, 0, 0, 0, time.UTC)
+	case "month":
+		t = time.Date(y, m, 1, 0, 0, 0, 0, time.UTC)
+	case "quarter":
+		t = time.Date(y, m-(m-1)%3, 1, 0, 0, 0, 0, time.UTC)
+	case "year":
+		t = time.Date(y, time.January, 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return nil, fmt.Errorf("%w: unsupported timestamp field '%s'", ErrIllegalArguments, field)
+	}
+
+	return &Timestamp{val: t}, nil
+}
+
+// extract returns the given field of the timestamp, days of the week are numbered from sunday (0)
+// and weeks follow ISO 8601
+func extract(args []TypedValue) (TypedValue, error) {
+	field := args[0].Value().(string)
+	t := args[1].Value().(time.Time).UTC()
+
+	var v int
+
+	switch strings.ToLower(field) {
+	case "year":
+		v = t.Year()
+	case "quarter":
+		v = (int(t.Month())-1)/3 + 1
+	case "month":
+		v = int(t.Month())
+	case "week":
+		_, v = t.ISOWeek()
+	case "day":
+		v = t.Day()
+	case "dow":
+		v = int(t.Weekday())
+	case "doy":
+		v = t.YearDay()
+	case "hour":
+		v = t.Hour()
+	case "minute":
+		v = t.Minute()
+	case "second":
+		v = t.Second()
+	case "epoch":
+		return &Number{val: t.Unix()}, nil
+	default:
+		return nil, fmt.Errorf("%w: unsupported timestamp field '%s'", ErrIllegalArguments, field)
+	}
+
+	return &Number{val: int64(v)}, nil
+}
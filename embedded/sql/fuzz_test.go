@@ -0,0 +1,48 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package sql
+
+import (
+	"testing"
+)
+
+func FuzzParse(f *testing.F) {
+	for _, sql := range []string{
+		"CREATE DATABASE db1",
+		"USE DATABASE db1",
+		"CREATE TABLE IF NOT EXISTS table1 (id INTEGER AUTO_INCREMENT, title VARCHAR[50] NOT NULL, active BOOLEAN, payload BLOB, ts TIMESTAMP, PRIMARY KEY id)",
+		"CREATE UNIQUE INDEX ON table1(title, active)",
+		"ALTER TABLE table1 ADD COLUMN amount INTEGER",
+		"INSERT INTO table1 (title, active, payload) VALUES ('title1', true, x'AED0393F'), (@title, NOT false, NULL)",
+		"INSERT INTO table1 (id, title) VALUES (1, 'title1') ON CONFLICT DO UPDATE SET title = 'title2'",
+		"UPDATE table1 SET title = 'updated' WHERE id >= 10 AND NOT active",
+		"DELETE FROM table1 WHERE title LIKE '^t.*' LIMIT 5",
+		"SELECT DISTINCT id, title AS t FROM table1 AS t1 INNER JOIN table2 ON t1.id = table2.fkid WHERE id IN (1, 2, 3) ORDER BY title DESC LIMIT 10 OFFSET 2",
+		"SELECT COUNT(*), SUM(amount), MAX(ts) FROM table1 GROUP BY active HAVING COUNT(*) > 1",
+		"SELECT * FROM table1 BEFORE TX 10 AS t WHERE ts < NOW()",
+		"SELECT id FROM (SELECT id FROM table1) WHERE id = CAST('1' AS INTEGER)",
+		"BEGIN TRANSACTION; INSERT INTO table1 (id) VALUES (-1); COMMIT;",
+		"USE SNAPSHOT SINCE TX 10; SELECT * FROM HISTORY OF table1",
+		"",
+	} {
+		f.Add(sql)
+	}
+
+	f.Fuzz(func(t *testing.T, sql string) {
+		// errors are expected, malformed statements must not make the parser panic
+		ParseString(sql)
+	})
+}
@@ -0,0 +1,304 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package sql
+
+import (
+	"time"
+
+	"github.com/codenotary/immudb/embedded/store"
+)
+
+// columns added to the rows read from the history of a table
+const (
+	historyTxIDCol    = "_tx_id"
+	historyTxTsCol    = "_tx_ts"
+	historyTxUserCol  = "_tx_user"
+	historyDeletedCol = "_deleted"
+)
+
+const historyBatchSize = 100
+
+// SetUser attributes the changes made within the transaction to the given user,
+// who is recorded in the metadata of the committed transaction and read from table histories as _tx_user
+func (sqlTx *SQLTx) SetUser(user string) error {
+	md := store.NewTxMetadata()
+
+	err := md.SetUser(user)
+	if err != nil {
+		return err
+	}
+
+	sqlTx.tx.WithMetadata(md)
+
+	return nil
+}
+
+// historyRowReader reads every committed revision of the rows of a table, oldest first.
+// Revisions are read as rows along with the id, time and user of the transaction they were committed in,
+// deleted rows are read with the values they had before being deleted.
+type historyRowReader struct {
+	tx              *SQLTx
+	table           *Table
+	tableAlias      string
+	colsByPos       []ColDescriptor
+	colsBySel       map[string]ColDescriptor
+	scanSpecs       *ScanSpecs
+	reader          *store.KeyReader
+	txHolder        *store.Tx
+	key             []byte
+	revisions       []uint64
+	values          map[string]TypedValue // values of the last revision read
+	onCloseCallback func()
+}
+
+func newHistoryRowReader(tx *SQLTx, table *Table, tableAlias string, scanSpecs *ScanSpecs) (*historyRowReader, error) {
+	if table == nil || scanSpecs == nil || !scanSpecs.index.IsPrimary() {
+		return nil, ErrIllegalArguments
+	}
+
+	rSpec, err := keyReaderSpecFrom(tx.engine.prefix, table, scanSpecs)
+	if err != nil {
+		return nil, err
+	}
+
+	// deleted rows have a history as well
+	rSpec.Filter = nil
+
+	r, err := tx.newKeyReader(rSpec)
+	if err != nil {
+		return nil, err
+	}
+
+	if tableAlias == "" {
+		tableAlias = table.name
+	}
+
+	cols := make([]ColDescriptor, 0, len(table.Cols())+4)
+
+	for _, c := range table.Cols() {
+		cols = append(cols, ColDescriptor{Database: table.db.name, Table: tableAlias, Column: c.colName, Type: c.colType})
+	}
+
+	cols = append(cols,
+		ColDescriptor{Database: table.db.name, Table: tableAlias, Column: historyTxIDCol, Type: IntegerType},
+		ColDescriptor{Database: table.db.name, Table: tableAlias, Column: historyTxTsCol, Type: TimestampType},
+		ColDescriptor{Database: table.db.name, Table: tableAlias, Column: historyTxUserCol, Type: VarcharType},
+		ColDescriptor{Database: table.db.name, Table: tableAlias, Column: historyDeletedCol, Type: BooleanType},
+	)
+
+	colsBySel := make(map[string]ColDescriptor, len(cols))
+
+	for _, c := range cols {
+		colsBySel[c.Selector()] = c
+	}
+
+	return &historyRowReader{
+		tx:         tx,
+		table:      table,
+		tableAlias: tableAlias,
+		colsByPos:  cols,
+		colsBySel:  colsBySel,
+		scanSpecs:  scanSpecs,
+		reader:     r,
+		txHolder:   tx.engine.store.NewTxHolder(),
+	}, nil
+}
+
+func (r *historyRowReader) onClose(callback func()) {
+	r.onCloseCallback = callback
+}
+
+func (r *historyRowReader) Tx() *SQLTx {
+	return r.tx
+}
+
+func (r *historyRowReader) Database() *Database {
+	return r.table.db
+}
+
+func (r *historyRowReader) TableAlias() string {
+	return r.tableAlias
+}
+
+func (r *historyRowReader) OrderBy() []ColDescriptor {
+	cols := make([]ColDescriptor, len(r.table.primaryIndex.cols))
+
+	for i, col := range r.table.primaryIndex.cols {
+		cols[i] = ColDescriptor{
+			Database: r.table.db.name,
+			Table:    r.tableAlias,
+			Column:   col.colName,
+			Type:     col.colType,
+		}
+	}
+
+	return cols
+}
+
+func (r *historyRowReader) ScanSpecs() *ScanSpecs {
+	return r.scanSpecs
+}
+
+func (r *historyRowReader) Columns() ([]ColDescriptor, error) {
+	ret := make([]ColDescriptor, len(r.colsByPos))
+	copy(ret, r.colsByPos)
+	return ret, nil
+}
+
+func (r *historyRowReader) colsBySelector() (map[string]ColDescriptor, error) {
+	ret := make(map[string]ColDescriptor, len(r.colsBySel))
+	for sel := range r.colsBySel {
+		ret[sel] = r.colsBySel[sel]
+	}
+	return ret, nil
+}
+
+func (r *historyRowReader) InferParameters(params map[string]SQLValueType) error {
+	return nil
+}
+
+func (r *historyRowReader) SetParameters(params map[string]interface{}) error {
+	return nil
+}
+
+// nextKey positions the reader at the next row with committed revisions
+func (r *historyRowReader) nextKey() error {
+	for {
+		mkey, vref, err := r.reader.Read()
+		if err != nil {
+			return err
+		}
+
+		// revisions committed after the snapshot of the transaction are not read
+		revisions, err := r.committedRevisions(mkey, vref.Tx())
+		if err != nil {
+			return err
+		}
+
+		if len(revisions) > 0 {
+			r.key = mkey
+			r.revisions = revisions
+			r.values = nil
+			return nil
+		}
+	}
+}
+
+// committedRevisions returns the ids of the transactions the key was committed in up to lastTx, oldest first.
+// All of them are returned when lastTx is zero i.e. the key was written within the ongoing transaction.
+func (r *historyRowReader) committedRevisions(key []byte, lastTx uint64) ([]uint64, error) {
+	var revisions []uint64
+
+	for offset := uint64(0); ; {
+		txs, err := r.tx.engine.store.History(key, offset, true, historyBatchSize)
+		if err == store.ErrKeyNotFound || err == store.ErrNoMoreEntries {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		for _, txID := range txs {
+			if lastTx == 0 || txID <= lastTx {
+				revisions = append(revisions, txID)
+			}
+		}
+
+		offset += uint64(len(txs))
+	}
+
+	for i, j := 0, len(revisions)-1; i < j; i, j = i+1, j-1 {
+		revisions[i], revisions[j] = revisions[j], revisions[i]
+	}
+
+	return revisions, nil
+}
+
+func (r *historyRowReader) Read() (*Row, error) {
+	if len(r.revisions) == 0 {
+		err := r.nextKey()
+		if err == store.ErrNoMoreEntries {
+			return nil, ErrNoMoreRows
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	txID := r.revisions[0]
+	r.revisions = r.revisions[1:]
+
+	err := r.tx.engine.store.ReadTx(txID, r.txHolder)
+	if err != nil {
+		return nil, err
+	}
+
+	entry, err := r.txHolder.EntryOf(r.key)
+	if err != nil {
+		return nil, err
+	}
+
+	deleted := entry.Metadata() != nil && entry.Metadata().Deleted()
+
+	if !deleted {
+		v, err := r.tx.engine.store.ReadValue(entry)
+		if err != nil {
+			return nil, err
+		}
+
+		r.values, err = r.tx.decodeRow(r.table, r.tableAlias, v)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if r.values == nil {
+		r.values = make(map[string]TypedValue, len(r.table.Cols()))
+
+		for _, col := range r.table.Cols() {
+			r.values[EncodeSelector("", r.table.db.name, r.tableAlias, col.colName)] = &NullValue{t: col.colType}
+		}
+	}
+
+	hdr := r.txHolder.Header()
+
+	values := make(map[string]TypedValue, len(r.colsByPos))
+
+	for sel, val := range r.values {
+		values[sel] = val
+	}
+
+	var user TypedValue = &NullValue{t: VarcharType}
+
+	if hdr.Metadata != nil && hdr.Metadata.User() != "" {
+		user = &Varchar{val: hdr.Metadata.User()}
+	}
+
+	values[EncodeSelector("", r.table.db.name, r.tableAlias, historyTxIDCol)] = &Number{val: int64(hdr.ID)}
+	values[EncodeSelector("", r.table.db.name, r.tableAlias, historyTxTsCol)] = &Timestamp{val: time.Unix(hdr.Ts, 0).UTC()}
+	values[EncodeSelector("", r.table.db.name, r.tableAlias, historyTxUserCol)] = user
+	values[EncodeSelector("", r.table.db.name, r.tableAlias, historyDeletedCol)] = &Bool{val: deleted}
+
+	return &Row{Values: values}, nil
+}
+
+func (r *historyRowReader) Close() error {
+	if r.onCloseCallback != nil {
+		defer r.onCloseCallback()
+	}
+
+	return r.reader.Close()
+}
@@ -18,14 +18,22 @@ package sql
 type limitRowReader struct {
 	rowReader RowReader
 
-	limit int
-	read  int
+	limit  int // no limit when zero
+	offset int
+
+	read    int
+	skipped int
 }
 
-func newLimitRowReader(rowReader RowReader, limit int) (*limitRowReader, error) {
+func newLimitRowReader(rowReader RowReader, limit, offset int) (*limitRowReader, error) {
+	if limit < 0 || offset < 0 {
+		return nil, ErrIllegalArguments
+	}
+
 	return &limitRowReader{
 		rowReader: rowReader,
 		limit:     limit,
+		offset:    offset,
 	}, nil
 }
 
@@ -70,10 +78,19 @@ func (lr *limitRowReader) InferParameters(params map[string]SQLValueType) error
 }
 
 func (lr *limitRowReader) Read() (*Row, error) {
-	if lr.read >= lr.limit {
+	if lr.limit > 0 && lr.read >= lr.limit {
 		return nil, ErrNoMoreRows
 	}
 
+	for lr.skipped < lr.offset {
+		_, err := lr.rowReader.Read()
+		if err != nil {
+			return nil, err
+		}
+
+		lr.skipped++
+	}
+
 	row, err := lr.rowReader.Read()
 	if err != nil {
 		return nil, err
@@ -24,7 +24,7 @@ import (
 func TestLimitRowReader(t *testing.T) {
 	dummyr := &dummyRowReader{failReturningColumns: false}
 
-	rowReader, err := newLimitRowReader(dummyr, 1)
+	rowReader, err := newLimitRowReader(dummyr, 1, 0)
 	require.NoError(t, err)
 
 	require.Equal(t, dummyr.Database(), rowReader.Database())
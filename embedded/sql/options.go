@@ -16,16 +16,22 @@ limitations under the License.
 package sql
 
 var defultDistinctLimit = 1 << 20 // ~ 1mi rows
+var defaultAutoAnalyzeRatio = 0.1
 
+// Options holds the engine settings, values are copied when the engine is created
 type Options struct {
 	prefix        []byte
 	distinctLimit int
 	autocommit    bool
+
+	autoAnalyzeRatio float64
 }
 
+// DefaultOptions returns the options used when no customization is needed
 func DefaultOptions() *Options {
 	return &Options{
-		distinctLimit: defultDistinctLimit,
+		distinctLimit:    defultDistinctLimit,
+		autoAnalyzeRatio: defaultAutoAnalyzeRatio,
 	}
 }
 
@@ -33,17 +39,27 @@ func ValidOpts(opts *Options) bool {
 	return opts != nil && opts.distinctLimit > 0
 }
 
+// WithPrefix sets the prefix prepended to every key written by the engine, including the catalog
 func (opts *Options) WithPrefix(prefix []byte) *Options {
 	opts.prefix = prefix
 	return opts
 }
 
+// WithDistinctLimit sets the maximum number of rows a DISTINCT clause may hold in memory
 func (opts *Options) WithDistinctLimit(distinctLimit int) *Options {
 	opts.distinctLimit = distinctLimit
 	return opts
 }
 
+// WithAutocommit makes each statement be committed as soon as it is executed, unless running within an interactive transaction
 func (opts *Options) WithAutocommit(autocommit bool) *Options {
 	opts.autocommit = autocommit
 	return opts
 }
+
+// WithAutoAnalyzeRatio sets the ratio of the rows of an analyzed table to be modified before its statistics are refreshed.
+// Statistics are only refreshed by ANALYZE statements when it's zero.
+func (opts *Options) WithAutoAnalyzeRatio(ratio float64) *Options {
+	opts.autoAnalyzeRatio = ratio
+	return opts
+}
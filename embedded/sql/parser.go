@@ -22,6 +22,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"sort"
 	"strconv"
 	"strings"
 )
@@ -59,6 +60,8 @@ var reservedWords = map[string]int{
 	"TRANSACTION":    TRANSACTION,
 	"COMMIT":         COMMIT,
 	"ROLLBACK":       ROLLBACK,
+	"SAVEPOINT":      SAVEPOINT,
+	"RELEASE":        RELEASE,
 	"SELECT":         SELECT,
 	"DISTINCT":       DISTINCT,
 	"FROM":           FROM,
@@ -83,6 +86,23 @@ var reservedWords = map[string]int{
 	"IF":             IF,
 	"IS":             IS,
 	"CAST":           CAST,
+	"DROP":           DROP,
+	"POLICY":         POLICY,
+	"USING":          USING,
+	"VIEW":           VIEW,
+	"PROCEDURE":      PROCEDURE,
+	"CALL":           CALL,
+	"THEN":           THEN,
+	"ELSE":           ELSE,
+	"END":            END,
+	"RAISE":          RAISE,
+	"HISTORY":        HISTORY,
+	"OF":             OF,
+	"EXPLAIN":        EXPLAIN,
+	"ANALYZE":        ANALYZE,
+	"OFFSET":         OFFSET,
+	"DEFAULT":        DEFAULT,
+	"CHECK":          CHECK,
 }
 
 var joinTypes = map[string]JoinType{
@@ -127,6 +147,31 @@ var logicOps = map[string]LogicOperator{
 	"OR":  OR,
 }
 
+// Keywords returns the reserved words, join types, value types and aggregate functions recognized by the parser
+func Keywords() []string {
+	keywords := make([]string, 0, len(reservedWords)+len(joinTypes)+len(types)+len(aggregateFns)+len(boolValues))
+
+	for k := range reservedWords {
+		keywords = append(keywords, k)
+	}
+	for k := range joinTypes {
+		keywords = append(keywords, k)
+	}
+	for k := range types {
+		keywords = append(keywords, k)
+	}
+	for k := range aggregateFns {
+		keywords = append(keywords, k)
+	}
+	for k := range boolValues {
+		keywords = append(keywords, k)
+	}
+
+	sort.Strings(keywords)
+
+	return keywords
+}
+
 var ErrEitherNamedOrUnnamedParams = errors.New("either named or unnamed params")
 var ErrEitherPosOrNonPosParams = errors.New("either positional or non-positional named params")
 var ErrInvalidPositionalParameter = errors.New("invalid positional parameter")
@@ -145,6 +190,10 @@ type lexer struct {
 	namedParamsType positionalParamType
 	paramsCount     int
 	result          []SQLStmt
+
+	// set when parsing a single expression instead of statements
+	parsingExp bool
+	expResult  ValueExp
 }
 
 type aheadByteReader struct {
@@ -192,6 +241,24 @@ func Parse(r io.ByteReader) ([]SQLStmt, error) {
 	return lexer.result, lexer.err
 }
 
+// parseExp parses a single expression, as stored in the catalog e.g. column default values
+func parseExp(exp string) (ValueExp, error) {
+	lexer := newLexer(strings.NewReader(exp))
+	lexer.parsingExp = true
+
+	yyParse(lexer)
+
+	if lexer.err != nil {
+		return nil, lexer.err
+	}
+
+	if lexer.expResult == nil {
+		return nil, ErrIllegalArguments
+	}
+
+	return lexer.expResult, nil
+}
+
 func newLexer(r io.ByteReader) *lexer {
 	return &lexer{
 		r:   newAheadByteReader(r),
@@ -203,6 +270,12 @@ func (l *lexer) Lex(lval *yySymType) int {
 	var ch byte
 	var err error
 
+	// expressions are parsed as if they were preceded by a token which can not be written
+	if l.parsingExp {
+		l.parsingExp = false
+		return EXPRESSION
+	}
+
 	for {
 		ch, err = l.r.ReadByte()
 		if err == io.EOF {
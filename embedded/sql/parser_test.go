@@ -19,6 +19,7 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"sort"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -43,7 +44,7 @@ func TestCreateDatabaseStmt(t *testing.T) {
 		{
 			input:          "CREATE db1",
 			expectedOutput: nil,
-			expectedError:  errors.New("syntax error: unexpected IDENTIFIER, expecting DATABASE or TABLE or UNIQUE or INDEX at position 10"),
+			expectedError:  errors.New("syntax error: unexpected IDENTIFIER at position 10"),
 		},
 	}
 
@@ -143,6 +144,28 @@ func TestCreateTableStmt(t *testing.T) {
 				}},
 			expectedError: nil,
 		},
+		{
+			input: "CREATE TABLE table1 (id VARCHAR[36] DEFAULT RANDOM_UUID(), ts TIMESTAMP NOT NULL DEFAULT NOW(), qty INTEGER DEFAULT 1 CHECK (qty > 0), PRIMARY KEY id, CHECK (ts < NOW()))",
+			expectedOutput: []SQLStmt{
+				&CreateTableStmt{
+					table: "table1",
+					colsSpec: []*ColSpec{
+						{colName: "id", colType: VarcharType, maxLen: 36, defaultValue: &SysFn{fn: "random_uuid"}},
+						{colName: "ts", colType: TimestampType, notNull: true, defaultValue: &SysFn{fn: "now"}},
+						{
+							colName:      "qty",
+							colType:      IntegerType,
+							defaultValue: &Number{val: 1},
+							check:        &CmpBoolExp{op: GT, left: &ColSelector{col: "qty"}, right: &Number{val: 0}},
+						},
+					},
+					pkColNames: []string{"id"},
+					checks: []ValueExp{
+						&CmpBoolExp{op: LT, left: &ColSelector{col: "ts"}, right: &SysFn{fn: "now"}},
+					},
+				}},
+			expectedError: nil,
+		},
 		{
 			input: "CREATE TABLE xtable1 (xid INTEGER, PRIMARY KEY xid)",
 			expectedOutput: []SQLStmt{
@@ -185,7 +208,7 @@ func TestCreateTableStmt(t *testing.T) {
 		{
 			input:          "CREATE table1",
 			expectedOutput: nil,
-			expectedError:  errors.New("syntax error: unexpected IDENTIFIER, expecting DATABASE or TABLE or UNIQUE or INDEX at position 13"),
+			expectedError:  errors.New("syntax error: unexpected IDENTIFIER at position 13"),
 		},
 		{
 			input:          "CREATE TABLE table1",
@@ -288,6 +311,320 @@ func TestAlterTableStmt(t *testing.T) {
 	}
 }
 
+func TestPolicyStmts(t *testing.T) {
+	testCases := []struct {
+		input          string
+		expectedOutput []SQLStmt
+		expectedError  error
+	}{
+		{
+			input: "CREATE POLICY tenancy ON table1 USING (tenant = @tenant)",
+			expectedOutput: []SQLStmt{
+				&CreatePolicyStmt{
+					name:  "tenancy",
+					table: "table1",
+					predicate: &CmpBoolExp{
+						op:    EQ,
+						left:  &ColSelector{col: "tenant"},
+						right: &Param{id: "tenant"},
+					},
+				}},
+			expectedError: nil,
+		},
+		{
+			input: "DROP POLICY tenancy ON table1",
+			expectedOutput: []SQLStmt{
+				&DropPolicyStmt{
+					name:  "tenancy",
+					table: "table1",
+				}},
+			expectedError: nil,
+		},
+		{
+			input:          "CREATE POLICY tenancy ON table1 USING tenant = @tenant",
+			expectedOutput: nil,
+			expectedError:  errors.New("syntax error: unexpected IDENTIFIER, expecting '(' at position 44"),
+		},
+	}
+
+	for i, tc := range testCases {
+		res, err := ParseString(tc.input)
+		require.Equal(t, tc.expectedError, err, fmt.Sprintf("failed on iteration %d", i))
+
+		if tc.expectedError == nil {
+			require.Equal(t, tc.expectedOutput, res, fmt.Sprintf("failed on iteration %d", i))
+		}
+	}
+}
+
+func TestViewStmts(t *testing.T) {
+	testCases := []struct {
+		input          string
+		expectedOutput []SQLStmt
+		expectedError  error
+	}{
+		{
+			input: "CREATE VIEW adults AS SELECT id, name FROM people WHERE age >= 18",
+			expectedOutput: []SQLStmt{
+				&CreateViewStmt{
+					name: "adults",
+					query: &SelectStmt{
+						selectors: []Selector{
+							&ColSelector{col: "id"},
+							&ColSelector{col: "name"},
+						},
+						ds: &tableRef{table: "people"},
+						where: &CmpBoolExp{
+							op:    GE,
+							left:  &ColSelector{col: "age"},
+							right: &Number{val: 18},
+						},
+					},
+				}},
+			expectedError: nil,
+		},
+		{
+			input: "CREATE VIEW IF NOT EXISTS adults AS SELECT * FROM people",
+			expectedOutput: []SQLStmt{
+				&CreateViewStmt{
+					name:        "adults",
+					ifNotExists: true,
+					query: &SelectStmt{
+						ds: &tableRef{table: "people"},
+					},
+				}},
+			expectedError: nil,
+		},
+		{
+			input: "DROP VIEW adults",
+			expectedOutput: []SQLStmt{
+				&DropViewStmt{name: "adults"},
+			},
+			expectedError: nil,
+		},
+		{
+			input:          "CREATE VIEW adults SELECT * FROM people",
+			expectedOutput: nil,
+			expectedError:  errors.New("syntax error: unexpected SELECT, expecting AS at position 25"),
+		},
+	}
+
+	for i, tc := range testCases {
+		res, err := ParseString(tc.input)
+		require.Equal(t, tc.expectedError, err, fmt.Sprintf("failed on iteration %d", i))
+
+		if tc.expectedError == nil {
+			require.Equal(t, tc.expectedOutput, res, fmt.Sprintf("failed on iteration %d", i))
+		}
+	}
+}
+
+func TestProcedureStmts(t *testing.T) {
+	testCases := []struct {
+		input          string
+		expectedOutput []SQLStmt
+		expectedError  error
+	}{
+		{
+			input: `CREATE PROCEDURE debit(acc INTEGER, amount INTEGER) AS BEGIN
+				UPDATE accounts SET balance = balance - @amount WHERE id = @acc AND balance >= @amount;
+				IF ROW_COUNT() = 0 THEN
+					RAISE 'insufficient funds';
+				ELSE
+					INSERT INTO movements(acc, amount) VALUES (@acc, @amount);
+				END IF;
+			END`,
+			expectedOutput: []SQLStmt{
+				&CreateProcedureStmt{
+					name: "debit",
+					params: []*ProcParam{
+						{name: "acc", paramType: IntegerType},
+						{name: "amount", paramType: IntegerType},
+					},
+					body: []SQLStmt{
+						&UpdateStmt{
+							tableRef: &tableRef{table: "accounts"},
+							updates: []*colUpdate{
+								{col: "balance", op: EQ, val: &NumExp{op: SUBSOP, left: &ColSelector{col: "balance"}, right: &Param{id: "amount"}}},
+							},
+							where: &BinBoolExp{
+								op:    AND,
+								left:  &CmpBoolExp{op: EQ, left: &ColSelector{col: "id"}, right: &Param{id: "acc"}},
+								right: &CmpBoolExp{op: GE, left: &ColSelector{col: "balance"}, right: &Param{id: "amount"}},
+							},
+						},
+						&ProcIfStmt{
+							cond: &CmpBoolExp{op: EQ, left: &SysFn{fn: "row_count"}, right: &Number{val: 0}},
+							then: []SQLStmt{&ProcRaiseStmt{msg: "insufficient funds"}},
+							elseStmts: []SQLStmt{
+								&UpsertIntoStmt{
+									isInsert: true,
+									tableRef: &tableRef{table: "movements"},
+									cols:     []string{"acc", "amount"},
+									rows:     []*RowSpec{{Values: []ValueExp{&Param{id: "acc"}, &Param{id: "amount"}}}},
+								},
+							},
+						},
+					},
+				}},
+			expectedError: nil,
+		},
+		{
+			input: "CREATE PROCEDURE IF NOT EXISTS cleanup() AS BEGIN DELETE FROM movements; END",
+			expectedOutput: []SQLStmt{
+				&CreateProcedureStmt{
+					name:        "cleanup",
+					ifNotExists: true,
+					body: []SQLStmt{
+						&DeleteFromStmt{tableRef: &tableRef{table: "movements"}},
+					},
+				}},
+			expectedError: nil,
+		},
+		{
+			input: "CALL debit(1, @amount); DROP PROCEDURE debit",
+			expectedOutput: []SQLStmt{
+				&CallStmt{name: "debit", args: []ValueExp{&Number{val: 1}, &Param{id: "amount"}}},
+				&DropProcedureStmt{name: "debit"},
+			},
+			expectedError: nil,
+		},
+		{
+			input:          "CREATE PROCEDURE cleanup() AS BEGIN DELETE FROM movements END",
+			expectedOutput: nil,
+			expectedError:  errors.New("syntax error: unexpected END, expecting STMT_SEPARATOR at position 61"),
+		},
+		{
+			input:          "CREATE PROCEDURE nested() AS BEGIN CALL cleanup(); END",
+			expectedOutput: nil,
+			expectedError:  errors.New("syntax error: unexpected CALL at position 39"),
+		},
+	}
+
+	for i, tc := range testCases {
+		res, err := ParseString(tc.input)
+		require.Equal(t, tc.expectedError, err, fmt.Sprintf("failed on iteration %d", i))
+
+		if tc.expectedError == nil {
+			require.Equal(t, tc.expectedOutput, res, fmt.Sprintf("failed on iteration %d", i))
+		}
+	}
+}
+
+func TestExplainStmt(t *testing.T) {
+	testCases := []struct {
+		input          string
+		expectedOutput []SQLStmt
+		expectedError  error
+	}{
+		{
+			input: "EXPLAIN SELECT id FROM table1",
+			expectedOutput: []SQLStmt{
+				&SelectStmt{
+					selectors: []Selector{&ColSelector{col: "id"}},
+					ds:        &tableRef{table: "table1"},
+					explain:   true,
+				}},
+			expectedError: nil,
+		},
+		{
+			input: "EXPLAIN ANALYZE SELECT id FROM table1",
+			expectedOutput: []SQLStmt{
+				&SelectStmt{
+					selectors: []Selector{&ColSelector{col: "id"}},
+					ds:        &tableRef{table: "table1"},
+					explain:   true,
+					analyze:   true,
+				}},
+			expectedError: nil,
+		},
+		{
+			input:          "EXPLAIN DELETE FROM table1",
+			expectedOutput: nil,
+			expectedError:  errors.New("syntax error: unexpected DELETE, expecting SELECT at position 14"),
+		},
+	}
+
+	for i, tc := range testCases {
+		res, err := ParseString(tc.input)
+		require.Equal(t, tc.expectedError, err, fmt.Sprintf("failed on iteration %d", i))
+
+		if tc.expectedError == nil {
+			require.Equal(t, tc.expectedOutput, res, fmt.Sprintf("failed on iteration %d", i))
+		}
+	}
+}
+
+func TestAnalyzeStmt(t *testing.T) {
+	testCases := []struct {
+		input          string
+		expectedOutput []SQLStmt
+		expectedError  error
+	}{
+		{
+			input:          "ANALYZE",
+			expectedOutput: []SQLStmt{&AnalyzeTableStmt{}},
+			expectedError:  nil,
+		},
+		{
+			input:          "ANALYZE TABLE table1",
+			expectedOutput: []SQLStmt{&AnalyzeTableStmt{table: "table1"}},
+			expectedError:  nil,
+		},
+		{
+			input: "ANALYZE TABLE table1; EXPLAIN ANALYZE SELECT id FROM table1",
+			expectedOutput: []SQLStmt{
+				&AnalyzeTableStmt{table: "table1"},
+				&SelectStmt{
+					selectors: []Selector{&ColSelector{col: "id"}},
+					ds:        &tableRef{table: "table1"},
+					explain:   true,
+					analyze:   true,
+				}},
+			expectedError: nil,
+		},
+		{
+			input:          "ANALYZE TABLE",
+			expectedOutput: nil,
+			expectedError:  errors.New("syntax error: unexpected $end, expecting IDENTIFIER at position 14"),
+		},
+	}
+
+	for i, tc := range testCases {
+		res, err := ParseString(tc.input)
+		require.Equal(t, tc.expectedError, err, fmt.Sprintf("failed on iteration %d", i))
+
+		if tc.expectedError == nil {
+			require.Equal(t, tc.expectedOutput, res, fmt.Sprintf("failed on iteration %d", i))
+		}
+	}
+}
+
+func TestExpStringRoundTrip(t *testing.T) {
+	exps := []string{
+		"(((a + 1) * b) >= $1)",
+		"((NOT (name LIKE '^a''b')) OR (t1.active = TRUE))",
+		"((id IN (1, 2, 3)) AND (payload != x'aed0'))",
+		"(CAST('2021-12-08 13:46:23' AS TIMESTAMP) < NOW())",
+		"((tenant = @tenant) OR (owner = NULL))",
+		"(((a, b, c) <= (1, @b, 3)) AND (d = 2))",
+		"((LOWER(SUBSTRING(name, 1, 3)) = 'abc') AND (LENGTH(CONCAT(a, @b)) > 2))",
+		"(EXTRACT(YEAR FROM DATE_TRUNC('day', ts)) = 2021)",
+	}
+
+	for _, e := range exps {
+		stmts, err := ParseString(fmt.Sprintf("CREATE POLICY p ON t USING %s", e))
+		require.NoError(t, err, e)
+		require.Len(t, stmts, 1)
+
+		stmt := stmts[0].(*CreatePolicyStmt)
+
+		reparsed, err := ParseString(stmt.String())
+		require.NoError(t, err)
+		require.Equal(t, stmts, reparsed)
+	}
+}
+
 func TestInsertIntoStmt(t *testing.T) {
 	decodedBLOB, err := hex.DecodeString("AED0393F")
 	require.NoError(t, err)
@@ -297,6 +634,54 @@ func TestInsertIntoStmt(t *testing.T) {
 		expectedOutput []SQLStmt
 		expectedError  error
 	}{
+		{
+			input: "INSERT INTO table1(id, title) VALUES (1, 'title1'), (2, 'title2') ON CONFLICT DO NOTHING",
+			expectedOutput: []SQLStmt{
+				&UpsertIntoStmt{
+					isInsert: true,
+					tableRef: &tableRef{table: "table1"},
+					cols:     []string{"id", "title"},
+					rows: []*RowSpec{
+						{Values: []ValueExp{&Number{val: 1}, &Varchar{val: "title1"}}},
+						{Values: []ValueExp{&Number{val: 2}, &Varchar{val: "title2"}}},
+					},
+					onConflict: &OnConflictDo{},
+				},
+			},
+			expectedError: nil,
+		},
+		{
+			input: "INSERT INTO table1(id, qty) VALUES (1, 10) ON CONFLICT DO UPDATE SET qty = qty + excluded.qty",
+			expectedOutput: []SQLStmt{
+				&UpsertIntoStmt{
+					isInsert: true,
+					tableRef: &tableRef{table: "table1"},
+					cols:     []string{"id", "qty"},
+					rows: []*RowSpec{
+						{Values: []ValueExp{&Number{val: 1}, &Number{val: 10}}},
+					},
+					onConflict: &OnConflictDo{
+						updates: []*colUpdate{
+							{
+								col: "qty",
+								op:  EQ,
+								val: &NumExp{
+									op:    ADDOP,
+									left:  &ColSelector{col: "qty"},
+									right: &ColSelector{table: "excluded", col: "qty"},
+								},
+							},
+						},
+					},
+				},
+			},
+			expectedError: nil,
+		},
+		{
+			input:          "INSERT INTO table1(id, qty) VALUES (1, 10) ON CONFLICT DO UPDATE",
+			expectedOutput: nil,
+			expectedError:  errors.New("syntax error: unexpected $end, expecting SET at position 65"),
+		},
 		{
 			input: "UPSERT INTO table1(id, time, title, active, compressed, payload, note) VALUES (2, now(), 'un''titled row', TRUE, false, x'AED0393F', @param1)",
 			expectedOutput: []SQLStmt{
@@ -664,6 +1049,20 @@ func TestTxStmt(t *testing.T) {
 			},
 			expectedError: nil,
 		},
+		{
+			input: "BEGIN TRANSACTION; SAVEPOINT sp1; DELETE FROM table1; ROLLBACK TO SAVEPOINT sp1; ROLLBACK TO sp1; RELEASE SAVEPOINT sp1; RELEASE sp1; COMMIT;",
+			expectedOutput: []SQLStmt{
+				&BeginTransactionStmt{},
+				&SavepointStmt{name: "sp1"},
+				&DeleteFromStmt{tableRef: &tableRef{table: "table1"}},
+				&RollbackToSavepointStmt{name: "sp1"},
+				&RollbackToSavepointStmt{name: "sp1"},
+				&ReleaseSavepointStmt{name: "sp1"},
+				&ReleaseSavepointStmt{name: "sp1"},
+				&CommitStmt{},
+			},
+			expectedError: nil,
+		},
 	}
 
 	for i, tc := range testCases {
@@ -710,6 +1109,24 @@ func TestSelectStmt(t *testing.T) {
 				}},
 			expectedError: nil,
 		},
+		{
+			input: "SELECT id, _tx_id FROM HISTORY OF db1.table1 AS t1 WHERE id = @id",
+			expectedOutput: []SQLStmt{
+				&SelectStmt{
+					distinct: false,
+					selectors: []Selector{
+						&ColSelector{col: "id"},
+						&ColSelector{col: "_tx_id"},
+					},
+					ds: &tableRef{db: "db1", table: "table1", history: true, as: "t1"},
+					where: &CmpBoolExp{
+						op:    EQ,
+						left:  &ColSelector{col: "id"},
+						right: &Param{id: "id"},
+					},
+				}},
+			expectedError: nil,
+		},
 		{
 			input: "SELECT t1.id, title FROM db1.table1 t1",
 			expectedOutput: []SQLStmt{
@@ -981,6 +1398,26 @@ func TestSelectStmt(t *testing.T) {
 				}},
 			expectedError: nil,
 		},
+		{
+			input: "SELECT id FROM table1 WHERE (a, b) > (@a, @b) ORDER BY a, b LIMIT 10 OFFSET 20",
+			expectedOutput: []SQLStmt{
+				&SelectStmt{
+					selectors: []Selector{&ColSelector{col: "id"}},
+					ds:        &tableRef{table: "table1"},
+					where: &TupleCmpExp{
+						op:    GT,
+						left:  []ValueExp{&ColSelector{col: "a"}, &ColSelector{col: "b"}},
+						right: []ValueExp{&Param{id: "a"}, &Param{id: "b"}},
+					},
+					orderBy: []*OrdCol{
+						{sel: &ColSelector{col: "a"}},
+						{sel: &ColSelector{col: "b"}},
+					},
+					limit:  10,
+					offset: 20,
+				}},
+			expectedError: nil,
+		},
 		{
 			input: "SELECT id, name, time FROM table1 WHERE time >= '20210101 00:00:00.000' AND time < '20210211 00:00:00.000'",
 			expectedOutput: []SQLStmt{
@@ -1075,6 +1512,65 @@ func TestAggFnStmt(t *testing.T) {
 	}
 }
 
+func TestFnCallStmt(t *testing.T) {
+	testCases := []struct {
+		input          string
+		expectedOutput []SQLStmt
+		expectedError  error
+	}{
+		{
+			input: "SELECT id, UPPER(name) AS uname, EXTRACT(YEAR FROM ts) FROM table1 WHERE LENGTH(name) > 3",
+			expectedOutput: []SQLStmt{
+				&SelectStmt{
+					selectors: []Selector{
+						&ColSelector{col: "id"},
+						&SysFn{fn: "upper", params: []ValueExp{&ColSelector{col: "name"}}, as: "uname"},
+						&SysFn{fn: "extract", params: []ValueExp{&Varchar{val: "year"}, &ColSelector{col: "ts"}}},
+					},
+					ds: &tableRef{table: "table1"},
+					where: &CmpBoolExp{
+						op:    GT,
+						left:  &SysFn{fn: "length", params: []ValueExp{&ColSelector{col: "name"}}},
+						right: &Number{val: 3},
+					},
+				}},
+			expectedError: nil,
+		},
+		{
+			input: "SELECT SHA256(CONCAT(a, '-', @b)), uuid() FROM table1",
+			expectedOutput: []SQLStmt{
+				&SelectStmt{
+					selectors: []Selector{
+						&SysFn{fn: "sha256", params: []ValueExp{
+							&SysFn{fn: "concat", params: []ValueExp{
+								&ColSelector{col: "a"},
+								&Varchar{val: "-"},
+								&Param{id: "b"},
+							}},
+						}},
+						&SysFn{fn: "uuid"},
+					},
+					ds: &tableRef{table: "table1"},
+				}},
+			expectedError: nil,
+		},
+		{
+			input:          "SELECT EXTRACT(YEAR FROM) FROM table1",
+			expectedOutput: nil,
+			expectedError:  errors.New("syntax error: unexpected ')' at position 25"),
+		},
+	}
+
+	for i, tc := range testCases {
+		res, err := ParseString(tc.input)
+		require.Equal(t, tc.expectedError, err, fmt.Sprintf("failed on iteration %d", i))
+
+		if tc.expectedError == nil {
+			require.Equal(t, tc.expectedOutput, res, fmt.Sprintf("failed on iteration %d", i))
+		}
+	}
+}
+
 func TestExpressions(t *testing.T) {
 	testCases := []struct {
 		input          string
@@ -1471,3 +1967,14 @@ func TestMultiLineStmts(t *testing.T) {
 		}
 	}
 }
+
+func TestKeywords(t *testing.T) {
+	keywords := Keywords()
+
+	require.True(t, sort.StringsAreSorted(keywords))
+	require.Contains(t, keywords, "SELECT")
+	require.Contains(t, keywords, "INNER")
+	require.Contains(t, keywords, "VARCHAR")
+	require.Contains(t, keywords, "COUNT")
+	require.Contains(t, keywords, "TRUE")
+}
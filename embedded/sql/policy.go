@@ -0,0 +1,269 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package sql
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/codenotary/immudb/embedded/store"
+)
+
+// Policy is a row-level security predicate over a table.
+// Predicates reference session attributes as named parameters e.g. CREATE POLICY p ON t USING (tenant = @tenant),
+// tables read within txs with session attributes only yield the rows satisfying at least one of their policies.
+type Policy struct {
+	table     *Table
+	name      string
+	predicate ValueExp
+}
+
+func (p *Policy) Name() string {
+	return p.name
+}
+
+func (p *Policy) Table() *Table {
+	return p.table
+}
+
+// Predicate returns the SQL representation of the policy predicate
+func (p *Policy) Predicate() string {
+	return p.predicate.String()
+}
+
+// Policies returns the policies of the table sorted by name
+func (t *Table) Policies() []*Policy {
+	policies := make([]*Policy, 0, len(t.policies))
+
+	for _, p := range t.policies {
+		policies = append(policies, p)
+	}
+
+	sort.Slice(policies, func(i, j int) bool {
+		return policies[i].name < policies[j].name
+	})
+
+	return policies
+}
+
+func (t *Table) newPolicy(name string, predicate ValueExp) (*Policy, error) {
+	if name == "" || predicate == nil {
+		return nil, ErrIllegalArguments
+	}
+
+	_, exists := t.policies[name]
+	if exists {
+		return nil, ErrPolicyAlreadyExists
+	}
+
+	policy := &Policy{
+		table:     t,
+		name:      name,
+		predicate: predicate,
+	}
+
+	t.policies[name] = policy
+
+	return policy, nil
+}
+
+// policyCondition returns the condition rows must satisfy to be visible with the given session attributes,
+// it's nil when the table has no policies
+func (t *Table) policyCondition(attrs map[string]interface{}) (ValueExp, error) {
+	var cond ValueExp
+
+	for _, p := range t.Policies() {
+		pred, err := p.predicate.substitute(attrs)
+		if err != nil {
+			return nil, fmt.Errorf("%w: policy '%s' on table '%s'", err, p.name, t.name)
+		}
+
+		if cond == nil {
+			cond = pred
+			continue
+		}
+
+		cond = &BinBoolExp{op: OR, left: cond, right: pred}
+	}
+
+	return cond, nil
+}
+
+// SetSessionAttributes sets the session attributes policy predicates are evaluated with.
+// Policies are only enforced within txs with session attributes, a nil attrs disables them.
+func (sqlTx *SQLTx) SetSessionAttributes(attrs map[string]interface{}) error {
+	if attrs == nil {
+		sqlTx.sessionAttrs = nil
+		return nil
+	}
+
+	nattrs, err := normalizeParams(attrs)
+	if err != nil {
+		return err
+	}
+
+	sqlTx.sessionAttrs = nattrs
+
+	return nil
+}
+
+func (sqlTx *SQLTx) policyFilteredReader(table *Table, rowReader RowReader) (RowReader, error) {
+	if sqlTx.sessionAttrs == nil {
+		return rowReader, nil
+	}
+
+	cond, err := table.policyCondition(sqlTx.sessionAttrs)
+	if err != nil {
+		return nil, err
+	}
+
+	if cond == nil {
+		return rowReader, nil
+	}
+
+	return newConditionalRowReader(rowReader, cond, nil)
+}
+
+type CreatePolicyStmt struct {
+	name      string
+	table     string
+	predicate ValueExp
+}
+
+func (stmt *CreatePolicyStmt) inferParameters(tx *SQLTx, params map[string]SQLValueType) error {
+	return nil
+}
+
+func (stmt *CreatePolicyStmt) execAt(tx *SQLTx, params map[string]interface{}) (*SQLTx, error) {
+	if tx.currentDB == nil {
+		return nil, ErrNoDatabaseSelected
+	}
+
+	table, err := tx.currentDB.GetTableByName(stmt.table)
+	if err != nil {
+		return nil, err
+	}
+
+	// named parameters are bound to session attributes when the policy is enforced
+	err = stmt.predicate.requiresType(BooleanType, table.colDescriptors(), make(map[string]SQLValueType), table.db.name, table.name)
+	if err != nil {
+		return nil, err
+	}
+
+	policy, err := table.newPolicy(stmt.name, stmt.predicate)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.set(policyKey(tx.sqlPrefix(), policy), nil, []byte(stmt.String()))
+	if err != nil {
+		return nil, err
+	}
+
+	return tx, nil
+}
+
+func (stmt *CreatePolicyStmt) String() string {
+	return fmt.Sprintf("CREATE POLICY %s ON %s USING (%s)", stmt.name, stmt.table, stmt.predicate.String())
+}
+
+type DropPolicyStmt struct {
+	name  string
+	table string
+}
+
+func (stmt *DropPolicyStmt) inferParameters(tx *SQLTx, params map[string]SQLValueType) error {
+	return nil
+}
+
+func (stmt *DropPolicyStmt) execAt(tx *SQLTx, params map[string]interface{}) (*SQLTx, error) {
+	if tx.currentDB == nil {
+		return nil, ErrNoDatabaseSelected
+	}
+
+	table, err := tx.currentDB.GetTableByName(stmt.table)
+	if err != nil {
+		return nil, err
+	}
+
+	policy, exists := table.policies[stmt.name]
+	if !exists {
+		return nil, ErrPolicyDoesNotExist
+	}
+
+	md := store.NewKVMetadata()
+
+	md.AsDeleted(true)
+
+	err = tx.set(policyKey(tx.sqlPrefix(), policy), md, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	delete(table.policies, policy.name)
+
+	return tx, nil
+}
+
+func policyKey(sqlPrefix []byte, policy *Policy) []byte {
+	return mapKey(sqlPrefix, catalogPolicyPrefix, EncodeID(policy.table.db.id), EncodeID(policy.table.id), []byte(policy.name))
+}
+
+func (table *Table) loadPolicies(sqlPrefix []byte, tx *store.OngoingTx) error {
+	initialKey := mapKey(sqlPrefix, catalogPolicyPrefix, EncodeID(table.db.id), EncodeID(table.id))
+
+	policyReader, err := tx.NewKeyReader(&store.KeyReaderSpec{
+		Prefix: initialKey,
+		Filter: store.IgnoreDeleted,
+	})
+	if err != nil {
+		return err
+	}
+	defer policyReader.Close()
+
+	for {
+		mkey, vref, err := policyReader.Read()
+		if err == store.ErrNoMoreEntries {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		v, err := vref.Resolve()
+		if err != nil {
+			return err
+		}
+
+		// v={CREATE POLICY statement}
+		stmts, err := ParseString(string(v))
+		if err != nil {
+			return err
+		}
+
+		stmt, ok := stmts[0].(*CreatePolicyStmt)
+		if !ok || len(stmts) != 1 || stmt.table != table.name || string(mkey[len(initialKey):]) != stmt.name {
+			return ErrCorruptedData
+		}
+
+		_, err = table.newPolicy(stmt.name, stmt.predicate)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,496 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package sql
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/codenotary/immudb/embedded/store"
+)
+
+// rowCountParam holds the number of rows affected by the last statement run by a procedure,
+// it can not be referenced as a named parameter as it's not an identifier
+const rowCountParam = "#row_count"
+
+// Procedure is a named sequence of parameterized statements executed with CALL.
+// All the statements of a procedure are executed within the transaction of the CALL statement,
+// so they are either committed or discarded at once.
+type Procedure struct {
+	db     *Database
+	name   string
+	params []*ProcParam
+	body   []SQLStmt
+}
+
+// ProcParam is a parameter of a procedure, referenced as a named parameter from its statements
+type ProcParam struct {
+	name      string
+	paramType SQLValueType
+}
+
+func (p *ProcParam) Name() string {
+	return p.name
+}
+
+func (p *ProcParam) Type() SQLValueType {
+	return p.paramType
+}
+
+func (p *Procedure) Name() string {
+	return p.name
+}
+
+func (p *Procedure) Database() *Database {
+	return p.db
+}
+
+func (p *Procedure) Params() []*ProcParam {
+	return p.params
+}
+
+// Definition returns the SQL representation of the procedure
+func (p *Procedure) Definition() string {
+	stmt := &CreateProcedureStmt{name: p.name, params: p.params, body: p.body}
+	return stmt.String()
+}
+
+func (db *Database) ExistProcedure(name string) bool {
+	_, exists := db.proceduresByName[name]
+	return exists
+}
+
+// GetProcedures returns the procedures of the database sorted by name
+func (db *Database) GetProcedures() []*Procedure {
+	procs := make([]*Procedure, 0, len(db.proceduresByName))
+
+	for _, p := range db.proceduresByName {
+		procs = append(procs, p)
+	}
+
+	sort.Slice(procs, func(i, j int) bool {
+		return procs[i].name < procs[j].name
+	})
+
+	return procs
+}
+
+func (db *Database) GetProcedureByName(name string) (*Procedure, error) {
+	proc, exists := db.proceduresByName[name]
+	if !exists {
+		return nil, ErrProcedureDoesNotExist
+	}
+	return proc, nil
+}
+
+func (db *Database) newProcedure(name string, params []*ProcParam, body []SQLStmt) (*Procedure, error) {
+	if name == "" || len(body) == 0 {
+		return nil, ErrIllegalArguments
+	}
+
+	if db.ExistProcedure(name) {
+		return nil, ErrProcedureAlreadyExists
+	}
+
+	proc := &Procedure{
+		db:     db,
+		name:   name,
+		params: params,
+		body:   body,
+	}
+
+	db.proceduresByName[name] = proc
+
+	return proc, nil
+}
+
+type CreateProcedureStmt struct {
+	name        string
+	ifNotExists bool
+	params      []*ProcParam
+	body        []SQLStmt
+}
+
+func (stmt *CreateProcedureStmt) inferParameters(tx *SQLTx, params map[string]SQLValueType) error {
+	return nil
+}
+
+func (stmt *CreateProcedureStmt) execAt(tx *SQLTx, params map[string]interface{}) (*SQLTx, error) {
+	if tx.currentDB == nil {
+		return nil, ErrNoDatabaseSelected
+	}
+
+	if stmt.ifNotExists && tx.currentDB.ExistProcedure(stmt.name) {
+		return tx, nil
+	}
+
+	err := stmt.validateBody(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	proc, err := tx.currentDB.newProcedure(stmt.name, stmt.params, stmt.body)
+	if err != nil {
+		return nil, err
+	}
+
+	definition := &CreateProcedureStmt{name: proc.name, params: proc.params, body: proc.body}
+
+	err = tx.set(procedureKey(tx.sqlPrefix(), proc), nil, []byte(definition.String()))
+	if err != nil {
+		return nil, err
+	}
+
+	return tx, nil
+}
+
+// validateBody checks the statements of the procedure are valid over the current catalog
+// and only reference the parameters of the procedure, with the declared types
+func (stmt *CreateProcedureStmt) validateBody(tx *SQLTx) error {
+	params := make(map[string]SQLValueType, len(stmt.params))
+
+	for _, p := range stmt.params {
+		_, duplicated := params[p.name]
+		if duplicated {
+			return fmt.Errorf("%w: parameter '%s' of procedure '%s'", ErrDuplicatedParameters, p.name, stmt.name)
+		}
+
+		params[p.name] = p.paramType
+	}
+
+	for _, s := range stmt.body {
+		err := s.inferParameters(tx, params)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(params) > len(stmt.params) {
+		for name := range params {
+			if !stmt.declares(name) {
+				return fmt.Errorf("%w: '%s' is not a parameter of procedure '%s'", ErrMissingParameter, name, stmt.name)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (stmt *CreateProcedureStmt) declares(param string) bool {
+	for _, p := range stmt.params {
+		if p.name == param {
+			return true
+		}
+	}
+	return false
+}
+
+func (stmt *CreateProcedureStmt) String() string {
+	var sb strings.Builder
+
+	sb.WriteString("CREATE PROCEDURE ")
+
+	if stmt.ifNotExists {
+		sb.WriteString("IF NOT EXISTS ")
+	}
+
+	params := make([]string, len(stmt.params))
+	for i, p := range stmt.params {
+		params[i] = p.name + " " + p.paramType
+	}
+
+	sb.WriteString(stmt.name + "(" + strings.Join(params, ", ") + ") AS BEGIN " + procStmtsString(stmt.body) + "END")
+
+	return sb.String()
+}
+
+func procStmtsString(stmts []SQLStmt) string {
+	var sb strings.Builder
+
+	for _, s := range stmts {
+		sb.WriteString(fmt.Sprintf("%s; ", s))
+	}
+
+	return sb.String()
+}
+
+type DropProcedureStmt struct {
+	name string
+}
+
+func (stmt *DropProcedureStmt) inferParameters(tx *SQLTx, params map[string]SQLValueType) error {
+	return nil
+}
+
+func (stmt *DropProcedureStmt) execAt(tx *SQLTx, params map[string]interface{}) (*SQLTx, error) {
+	if tx.currentDB == nil {
+		return nil, ErrNoDatabaseSelected
+	}
+
+	proc, err := tx.currentDB.GetProcedureByName(stmt.name)
+	if err != nil {
+		return nil, err
+	}
+
+	md := store.NewKVMetadata()
+
+	md.AsDeleted(true)
+
+	err = tx.set(procedureKey(tx.sqlPrefix(), proc), md, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	delete(tx.currentDB.proceduresByName, proc.name)
+
+	return tx, nil
+}
+
+// CallStmt executes the statements of a procedure with the provided arguments
+type CallStmt struct {
+	name string
+	args []ValueExp
+}
+
+func (stmt *CallStmt) procedure(tx *SQLTx) (*Procedure, error) {
+	if tx.currentDB == nil {
+		return nil, ErrNoDatabaseSelected
+	}
+
+	proc, err := tx.currentDB.GetProcedureByName(stmt.name)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(stmt.args) != len(proc.params) {
+		return nil, fmt.Errorf("%w: procedure '%s' expects %d argument(s)", ErrInvalidNumberOfValues, proc.name, len(proc.params))
+	}
+
+	return proc, nil
+}
+
+func (stmt *CallStmt) inferParameters(tx *SQLTx, params map[string]SQLValueType) error {
+	proc, err := stmt.procedure(tx)
+	if err != nil {
+		return err
+	}
+
+	for i, arg := range stmt.args {
+		err = arg.requiresType(proc.params[i].paramType, map[string]ColDescriptor{}, params, tx.currentDB.name, "")
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (stmt *CallStmt) execAt(tx *SQLTx, params map[string]interface{}) (*SQLTx, error) {
+	proc, err := stmt.procedure(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	procParams := make(map[string]interface{}, len(proc.params)+1)
+
+	for i, arg := range stmt.args {
+		p := proc.params[i]
+
+		sval, err := arg.substitute(params)
+		if err != nil {
+			return nil, err
+		}
+
+		val, err := sval.reduce(tx.catalog, nil, tx.currentDB.name, "")
+		if err != nil {
+			return nil, err
+		}
+
+		if !val.IsNull() {
+			err = val.requiresType(p.paramType, map[string]ColDescriptor{}, nil, tx.currentDB.name, "")
+			if err != nil {
+				return nil, fmt.Errorf("%w: argument '%s' of procedure '%s'", err, p.name, proc.name)
+			}
+		}
+
+		procParams[p.name] = val
+	}
+
+	procParams[rowCountParam] = int64(0)
+
+	return execProcStmts(tx, proc.body, procParams)
+}
+
+func (stmt *CallStmt) String() string {
+	return "CALL " + stmt.name + valuesString(stmt.args)
+}
+
+// execProcStmts executes the statements of a procedure, setting the number of rows affected by each one
+// so it can be checked by the following ones with ROW_COUNT()
+func execProcStmts(tx *SQLTx, stmts []SQLStmt, params map[string]interface{}) (*SQLTx, error) {
+	for _, stmt := range stmts {
+		updatedRows := tx.updatedRows
+
+		_, err := stmt.execAt(tx, params)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, isIf := stmt.(*ProcIfStmt); !isIf {
+			params[rowCountParam] = int64(tx.updatedRows - updatedRows)
+		}
+	}
+
+	return tx, nil
+}
+
+// ProcIfStmt executes the statements of one of its branches depending on its condition,
+// it's only allowed within procedures
+type ProcIfStmt struct {
+	cond      ValueExp
+	then      []SQLStmt
+	elseStmts []SQLStmt
+}
+
+func (stmt *ProcIfStmt) inferParameters(tx *SQLTx, params map[string]SQLValueType) error {
+	err := stmt.cond.requiresType(BooleanType, map[string]ColDescriptor{}, params, tx.currentDB.name, "")
+	if err != nil {
+		return err
+	}
+
+	for _, s := range stmt.then {
+		err = s.inferParameters(tx, params)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, s := range stmt.elseStmts {
+		err = s.inferParameters(tx, params)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (stmt *ProcIfStmt) execAt(tx *SQLTx, params map[string]interface{}) (*SQLTx, error) {
+	cond, err := stmt.cond.substitute(params)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := cond.reduce(tx.catalog, nil, tx.currentDB.name, "")
+	if err != nil {
+		return nil, err
+	}
+
+	satisfied := false
+
+	if !r.IsNull() {
+		b, ok := r.(*Bool)
+		if !ok {
+			return nil, ErrInvalidCondition
+		}
+
+		satisfied = b.val
+	}
+
+	if satisfied {
+		return execProcStmts(tx, stmt.then, params)
+	}
+
+	return execProcStmts(tx, stmt.elseStmts, params)
+}
+
+func (stmt *ProcIfStmt) String() string {
+	s := "IF " + stmt.cond.String() + " THEN " + procStmtsString(stmt.then)
+
+	if len(stmt.elseStmts) > 0 {
+		s += "ELSE " + procStmtsString(stmt.elseStmts)
+	}
+
+	return s + "END IF"
+}
+
+// ProcRaiseStmt aborts the execution of a procedure, discarding the changes made by the ongoing transaction
+type ProcRaiseStmt struct {
+	msg string
+}
+
+func (stmt *ProcRaiseStmt) inferParameters(tx *SQLTx, params map[string]SQLValueType) error {
+	return nil
+}
+
+func (stmt *ProcRaiseStmt) execAt(tx *SQLTx, params map[string]interface{}) (*SQLTx, error) {
+	return nil, fmt.Errorf("%w: %s", ErrProcedureRaised, stmt.msg)
+}
+
+func (stmt *ProcRaiseStmt) String() string {
+	return "RAISE " + (&Varchar{val: stmt.msg}).String()
+}
+
+func procedureKey(sqlPrefix []byte, proc *Procedure) []byte {
+	return mapKey(sqlPrefix, catalogProcPrefix, EncodeID(proc.db.id), []byte(proc.name))
+}
+
+func (db *Database) loadProcedures(sqlPrefix []byte, tx *store.OngoingTx) error {
+	initialKey := mapKey(sqlPrefix, catalogProcPrefix, EncodeID(db.id))
+
+	procReader, err := tx.NewKeyReader(&store.KeyReaderSpec{
+		Prefix: initialKey,
+		Filter: store.IgnoreDeleted,
+	})
+	if err != nil {
+		return err
+	}
+	defer procReader.Close()
+
+	for {
+		mkey, vref, err := procReader.Read()
+		if err == store.ErrNoMoreEntries {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		v, err := vref.Resolve()
+		if err != nil {
+			return err
+		}
+
+		// v={CREATE PROCEDURE statement}
+		stmts, err := ParseString(string(v))
+		if err != nil {
+			return err
+		}
+
+		stmt, ok := stmts[0].(*CreateProcedureStmt)
+		if !ok || len(stmts) != 1 || string(mkey[len(initialKey):]) != stmt.name {
+			return ErrCorruptedData
+		}
+
+		_, err = db.newProcedure(stmt.name, stmt.params, stmt.body)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -23,9 +23,11 @@ type projectedRowReader struct {
 	tableAlias string
 
 	selectors []Selector
+
+	params map[string]interface{}
 }
 
-func newProjectedRowReader(rowReader RowReader, tableAlias string, selectors []Selector) (*projectedRowReader, error) {
+func newProjectedRowReader(rowReader RowReader, tableAlias string, selectors []Selector, params map[string]interface{}) (*projectedRowReader, error) {
 	// case: SELECT *
 	if len(selectors) == 0 {
 		cols, err := rowReader.Columns()
@@ -47,6 +49,7 @@ func newProjectedRowReader(rowReader RowReader, tableAlias string, selectors []S
 		rowReader:  rowReader,
 		tableAlias: tableAlias,
 		selectors:  selectors,
+		params:     params,
 	}, nil
 }
 
@@ -98,7 +101,7 @@ func (pr *projectedRowReader) Columns() ([]ColDescriptor, error) {
 			col = sel.alias()
 		}
 
-		if aggFn != "" {
+		if aggFn != "" || isFnSelector(sel) {
 			aggFn = ""
 			col = sel.alias()
 			if col == "" {
@@ -132,11 +135,24 @@ func (pr *projectedRowReader) colsBySelector() (map[string]ColDescriptor, error)
 	for i, sel := range pr.selectors {
 		aggFn, db, table, col := sel.resolve(pr.rowReader.Database().Name(), pr.rowReader.TableAlias())
 
-		encSel := EncodeSelector(aggFn, db, table, col)
+		var colDesc ColDescriptor
+
+		if isFnSelector(sel) {
+			t, err := sel.inferType(dsColDescriptors, make(map[string]SQLValueType), db, table)
+			if err != nil {
+				return nil, err
+			}
+
+			colDesc.Type = t
+		} else {
+			encSel := EncodeSelector(aggFn, db, table, col)
 
-		colDesc, ok := dsColDescriptors[encSel]
-		if !ok {
-			return nil, ErrColumnDoesNotExist
+			desc, ok := dsColDescriptors[encSel]
+			if !ok {
+				return nil, ErrColumnDoesNotExist
+			}
+
+			colDesc = desc
 		}
 
 		if pr.tableAlias != "" {
@@ -148,7 +164,7 @@ func (pr *projectedRowReader) colsBySelector() (map[string]ColDescriptor, error)
 			col = sel.alias()
 		}
 
-		if aggFn != "" {
+		if aggFn != "" || isFnSelector(sel) {
 			aggFn = ""
 			col = sel.alias()
 			if col == "" {
@@ -171,11 +187,39 @@ func (pr *projectedRowReader) colsBySelector() (map[string]ColDescriptor, error)
 }
 
 func (pr *projectedRowReader) InferParameters(params map[string]SQLValueType) error {
-	return pr.rowReader.InferParameters(params)
+	err := pr.rowReader.InferParameters(params)
+	if err != nil {
+		return err
+	}
+
+	cols, err := pr.rowReader.colsBySelector()
+	if err != nil {
+		return err
+	}
+
+	for _, sel := range pr.selectors {
+		if !isFnSelector(sel) {
+			continue
+		}
+
+		_, err = sel.inferType(cols, params, pr.rowReader.Database().Name(), pr.rowReader.TableAlias())
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 func (pr *projectedRowReader) SetParameters(params map[string]interface{}) error {
-	return pr.rowReader.SetParameters(params)
+	err := pr.rowReader.SetParameters(params)
+	if err != nil {
+		return err
+	}
+
+	pr.params, err = normalizeParams(params)
+
+	return err
 }
 
 func (pr *projectedRowReader) Read() (*Row, error) {
@@ -191,11 +235,26 @@ func (pr *projectedRowReader) Read() (*Row, error) {
 	for i, sel := range pr.selectors {
 		aggFn, db, table, col := sel.resolve(pr.rowReader.Database().Name(), pr.rowReader.TableAlias())
 
-		encSel := EncodeSelector(aggFn, db, table, col)
+		var val TypedValue
 
-		val, ok := row.Values[encSel]
-		if !ok {
-			return nil, ErrColumnDoesNotExist
+		if isFnSelector(sel) {
+			// values of function selectors are computed from the row read
+			fn, err := sel.substitute(pr.params)
+			if err != nil {
+				return nil, err
+			}
+
+			val, err = fn.reduce(pr.Tx().catalog, row, db, table)
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			v, ok := row.Values[EncodeSelector(aggFn, db, table, col)]
+			if !ok {
+				return nil, ErrColumnDoesNotExist
+			}
+
+			val = v
 		}
 
 		if pr.tableAlias != "" {
@@ -207,7 +266,7 @@ func (pr *projectedRowReader) Read() (*Row, error) {
 			col = sel.alias()
 		}
 
-		if aggFn != "" {
+		if aggFn != "" || isFnSelector(sel) {
 			aggFn = ""
 			col = sel.alias()
 			if col == "" {
@@ -221,6 +280,12 @@ func (pr *projectedRowReader) Read() (*Row, error) {
 	return prow, nil
 }
 
+// isFnSelector returns true when the selector is a function call, which is not read but computed
+func isFnSelector(sel Selector) bool {
+	_, isFn := sel.(*SysFn)
+	return isFn
+}
+
 func (pr *projectedRowReader) Close() error {
 	return pr.rowReader.Close()
 }
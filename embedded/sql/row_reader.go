@@ -107,6 +107,7 @@ type rawRowReader struct {
 	colsBySel       map[string]ColDescriptor
 	scanSpecs       *ScanSpecs
 	reader          *store.KeyReader
+	skipped         int
 	onCloseCallback func()
 }
 
@@ -297,15 +298,27 @@ func (r *rawRowReader) SetParameters(params map[string]interface{}) error {
 	return nil
 }
 
-func (r *rawRowReader) Read() (row *Row, err error) {
-	var mkey []byte
-	var vref store.ValueRef
-
+func (r *rawRowReader) readEntry() (mkey []byte, vref store.ValueRef, err error) {
 	if r.asBefore > 0 {
 		mkey, vref, _, err = r.reader.ReadAsBefore(r.asBefore)
-	} else {
-		mkey, vref, err = r.reader.Read()
+		return mkey, vref, err
+	}
+
+	return r.reader.Read()
+}
+
+func (r *rawRowReader) Read() (row *Row, err error) {
+	// skipped entries are not resolved into rows
+	for r.skipped < r.scanSpecs.offset {
+		_, _, err = r.readEntry()
+		if err != nil {
+			return nil, err
+		}
+
+		r.skipped++
 	}
+
+	mkey, vref, err := r.readEntry()
 	if err != nil {
 		return nil, err
 	}
@@ -346,10 +359,20 @@ func (r *rawRowReader) Read() (row *Row, err error) {
 		}
 	}
 
-	values := make(map[string]TypedValue, len(r.table.Cols()))
+	values, err := r.tx.decodeRow(r.table, r.tableAlias, v)
+	if err != nil {
+		return nil, err
+	}
 
-	for _, col := range r.table.Cols() {
-		values[EncodeSelector("", r.table.db.name, r.tableAlias, col.colName)] = &NullValue{t: col.colType}
+	return &Row{Values: values}, nil
+}
+
+// decodeRow returns the column values of the encoded row, selected as columns of the table aliased as tableAlias
+func (sqlTx *SQLTx) decodeRow(table *Table, tableAlias string, v []byte) (map[string]TypedValue, error) {
+	values := make(map[string]TypedValue, len(table.Cols()))
+
+	for _, col := range table.Cols() {
+		values[EncodeSelector("", table.db.name, tableAlias, col.colName)] = &NullValue{t: col.colType}
 	}
 
 	if len(v) < EncLenLen {
@@ -369,7 +392,7 @@ func (r *rawRowReader) Read() (row *Row, err error) {
 		colID := binary.BigEndian.Uint32(v[voff:])
 		voff += EncIDLen
 
-		col, err := r.table.GetColumnByID(colID)
+		col, err := table.GetColumnByID(colID)
 		if err != nil {
 			return nil, ErrCorruptedData
 		}
@@ -380,14 +403,22 @@ func (r *rawRowReader) Read() (row *Row, err error) {
 		}
 
 		voff += n
-		values[EncodeSelector("", r.table.db.name, r.tableAlias, col.colName)] = val
+
+		if sqlTx.colMask != nil {
+			val, err = sqlTx.maskValue(table, col, val)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		values[EncodeSelector("", table.db.name, tableAlias, col.colName)] = val
 	}
 
 	if len(v)-voff > 0 {
 		return nil, ErrCorruptedData
 	}
 
-	return &Row{Values: values}, nil
+	return values, nil
 }
 
 func (r *rawRowReader) Close() error {
@@ -0,0 +1,200 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package sql
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Rows iterates over the rows returned by a query, values are read by position as in:
+//
+//	rows, err := engine.QueryRows(ctx, "SELECT id, name FROM customers WHERE id > @id", map[string]interface{}{"id": sql.NewInteger(10)}, nil)
+//	if err != nil {
+//		return err
+//	}
+//	defer rows.Close()
+//
+//	for rows.Next() {
+//		var id int64
+//		var name string
+//
+//		err := rows.Scan(&id, &name)
+//		...
+//	}
+//
+//	return rows.Err()
+type Rows struct {
+	reader RowReader
+	cols   []ColDescriptor
+	row    *Row
+	err    error
+	closed bool
+}
+
+// QueryRows parses and resolves a single SELECT statement, see QueryContext.
+// The returned rows must be closed by the caller.
+func (e *Engine) QueryRows(ctx context.Context, sql string, params map[string]interface{}, tx *SQLTx) (*Rows, error) {
+	r, err := e.QueryContext(ctx, sql, params, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewRows(r)
+}
+
+// NewRows returns an iterator over the rows read by the provided reader, which is closed along with it
+func NewRows(reader RowReader) (*Rows, error) {
+	if reader == nil {
+		return nil, ErrIllegalArguments
+	}
+
+	cols, err := reader.Columns()
+	if err != nil {
+		reader.Close()
+		return nil, err
+	}
+
+	return &Rows{
+		reader: reader,
+		cols:   cols,
+	}, nil
+}
+
+// Columns returns the columns of the rows, in the order their values are read
+func (r *Rows) Columns() []ColDescriptor {
+	cols := make([]ColDescriptor, len(r.cols))
+	copy(cols, r.cols)
+	return cols
+}
+
+// Next reads the next row, it returns false once there are no more rows or reading them failed, see Err
+func (r *Rows) Next() bool {
+	if r.closed || r.err != nil {
+		return false
+	}
+
+	row, err := r.reader.Read()
+	if err == ErrNoMoreRows {
+		r.row = nil
+		return false
+	}
+	if err != nil {
+		r.row = nil
+		r.err = err
+		return false
+	}
+
+	r.row = row
+
+	return true
+}
+
+// Err returns the error rows failed to be read with, if any
+func (r *Rows) Err() error {
+	return r.err
+}
+
+// Values returns the values of the current row
+func (r *Rows) Values() ([]TypedValue, error) {
+	if r.row == nil {
+		return nil, ErrNoMoreRows
+	}
+
+	values := make([]TypedValue, len(r.cols))
+
+	for i, col := range r.cols {
+		values[i] = r.row.Values[col.Selector()]
+	}
+
+	return values, nil
+}
+
+// Scan copies the values of the current row into dest, which must have a pointer per column.
+// Values can be scanned into pointers to TypedValue or interface{}, and non-NULL values into pointers to the Go type
+// of the column: int64 (or int) for INTEGER, string for VARCHAR, bool for BOOLEAN, []byte for BLOB and time.Time for TIMESTAMP.
+func (r *Rows) Scan(dest ...interface{}) error {
+	values, err := r.Values()
+	if err != nil {
+		return err
+	}
+
+	if len(dest) != len(values) {
+		return fmt.Errorf("%w: %d columns but %d destinations", ErrInvalidNumberOfValues, len(values), len(dest))
+	}
+
+	for i, v := range values {
+		err := scanValue(v, dest[i])
+		if err != nil {
+			return fmt.Errorf("%w: column '%s'", err, r.cols[i].Column)
+		}
+	}
+
+	return nil
+}
+
+func scanValue(v TypedValue, dest interface{}) error {
+	switch d := dest.(type) {
+	case *TypedValue:
+		*d = v
+		return nil
+	case *interface{}:
+		*d = v.Value()
+		return nil
+	}
+
+	if v.IsNull() {
+		return fmt.Errorf("%w: NULL can not be scanned into %T", ErrInvalidValue, dest)
+	}
+
+	var ok bool
+
+	switch d := dest.(type) {
+	case *int64:
+		*d, ok = v.Value().(int64)
+	case *int:
+		var i int64
+		i, ok = v.Value().(int64)
+		*d = int(i)
+	case *string:
+		*d, ok = v.Value().(string)
+	case *bool:
+		*d, ok = v.Value().(bool)
+	case *[]byte:
+		*d, ok = v.Value().([]byte)
+	case *time.Time:
+		*d, ok = v.Value().(time.Time)
+	}
+
+	if !ok {
+		return fmt.Errorf("%w: %s value can not be scanned into %T", ErrInvalidTypes, v.Type(), dest)
+	}
+
+	return nil
+}
+
+// Close closes the underlying reader, releasing the transaction it was implicitly created within
+func (r *Rows) Close() error {
+	if r.closed {
+		return ErrAlreadyClosed
+	}
+
+	r.closed = true
+	r.row = nil
+
+	return r.reader.Close()
+}
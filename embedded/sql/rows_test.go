@@ -0,0 +1,143 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package sql
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRows(t *testing.T) {
+	st, err := store.Open("sqldata_rows", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_rows")
+	defer st.Close()
+
+	engine, err := Open(st, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	_, _, err = engine.ExecContext(ctx, `
+		CREATE DATABASE db1;
+		USE DATABASE db1;
+		CREATE TABLE customers (id INTEGER, name VARCHAR, active BOOLEAN, photo BLOB, joined TIMESTAMP, PRIMARY KEY id);
+	`, nil, nil)
+	require.NoError(t, err)
+
+	err = engine.SetDefaultDatabase("db1")
+	require.NoError(t, err)
+
+	since := time.Date(2021, 12, 8, 13, 46, 23, 0, time.UTC)
+
+	_, _, err = engine.ExecContext(ctx,
+		"INSERT INTO customers(id, name, active, photo, joined) VALUES (@id, @name, @active, @photo, @joined), (2, 'bob', @inactive, NULL, NULL)",
+		map[string]interface{}{
+			"id":       NewInteger(1),
+			"name":     NewVarchar("alice"),
+			"active":   NewBool(true),
+			"photo":    NewBlob([]byte{1, 2}),
+			"joined":   NewTimestamp(since),
+			"inactive": NewNull(BooleanType),
+		}, nil)
+	require.NoError(t, err)
+
+	_, err = engine.QueryRows(ctx, "INSERT INTO customers(id) VALUES (3)", nil, nil)
+	require.ErrorIs(t, err, ErrExpectingDQLStmt)
+
+	_, err = NewRows(nil)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	rows, err := engine.QueryRows(ctx, "SELECT id, name, active, photo, joined FROM customers WHERE id >= @id", map[string]interface{}{"id": NewInteger(1)}, nil)
+	require.NoError(t, err)
+
+	cols := rows.Columns()
+	require.Len(t, cols, 5)
+	require.Equal(t, "name", cols[1].Column)
+	require.Equal(t, VarcharType, cols[1].Type)
+
+	_, err = rows.Values()
+	require.ErrorIs(t, err, ErrNoMoreRows)
+
+	require.True(t, rows.Next())
+
+	var id int
+	var name string
+	var active bool
+	var photo []byte
+	var ts time.Time
+
+	err = rows.Scan(&id, &name, &active, &photo, &ts)
+	require.NoError(t, err)
+	require.Equal(t, 1, id)
+	require.Equal(t, "alice", name)
+	require.True(t, active)
+	require.Equal(t, []byte{1, 2}, photo)
+	require.Equal(t, since, ts)
+
+	err = rows.Scan(&id, &name)
+	require.ErrorIs(t, err, ErrInvalidNumberOfValues)
+
+	err = rows.Scan(&name, &id, &active, &photo, &ts)
+	require.ErrorIs(t, err, ErrInvalidTypes)
+
+	require.True(t, rows.Next())
+
+	var nullable interface{}
+	var typedPhoto TypedValue
+
+	err = rows.Scan(&id, &name, &nullable, &typedPhoto, &ts)
+	require.ErrorIs(t, err, ErrInvalidValue)
+
+	var typedTs TypedValue
+
+	err = rows.Scan(&id, &name, &nullable, &typedPhoto, &typedTs)
+	require.NoError(t, err)
+	require.Equal(t, 2, id)
+	require.Nil(t, nullable)
+	require.True(t, typedPhoto.IsNull())
+	require.Equal(t, BLOBType, typedPhoto.Type())
+	require.True(t, typedTs.IsNull())
+
+	require.False(t, rows.Next())
+	require.NoError(t, rows.Err())
+
+	err = rows.Close()
+	require.NoError(t, err)
+
+	require.False(t, rows.Next())
+
+	err = rows.Close()
+	require.ErrorIs(t, err, ErrAlreadyClosed)
+
+	t.Run("rows should fail once the context is done", func(t *testing.T) {
+		cctx, cancel := context.WithCancel(ctx)
+
+		rows, err := engine.QueryRows(cctx, "SELECT id FROM customers", nil, nil)
+		require.NoError(t, err)
+		defer rows.Close()
+
+		cancel()
+
+		require.False(t, rows.Next())
+		require.ErrorIs(t, rows.Err(), context.Canceled)
+	})
+}
@@ -0,0 +1,185 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sql
+
+import (
+	"errors"
+
+	"github.com/codenotary/immudb/embedded/store"
+)
+
+var ErrSavepointDoesNotExist = errors.New("savepoint does not exist")
+var ErrTxAborted = errors.New("current transaction is aborted, statements are ignored until rolled back")
+
+// savepoint holds the state of an explicit transaction it can be rolled back to
+type savepoint struct {
+	name string
+
+	txSavepoint *store.Savepoint
+
+	currentDB string
+
+	updatedRows      int
+	lastInsertedPKs  map[string]int64
+	firstInsertedPKs map[string]int64
+
+	modifiedRows   map[tableID]int64
+	analyzedTables map[tableID]struct{}
+}
+
+func (sqlTx *SQLTx) newSavepoint(name string) error {
+	if !sqlTx.explicitClose {
+		return ErrNoOngoingTx
+	}
+
+	txSavepoint, err := sqlTx.tx.Savepoint()
+	if err != nil {
+		return err
+	}
+
+	sp := &savepoint{
+		name:        name,
+		txSavepoint: txSavepoint,
+		updatedRows: sqlTx.updatedRows,
+	}
+
+	if sqlTx.currentDB != nil {
+		sp.currentDB = sqlTx.currentDB.name
+	}
+
+	sp.lastInsertedPKs, sp.firstInsertedPKs, sp.modifiedRows, sp.analyzedTables = copyTxCounters(
+		sqlTx.lastInsertedPKs,
+		sqlTx.firstInsertedPKs,
+		sqlTx.modifiedRows,
+		sqlTx.analyzedTables,
+	)
+
+	sqlTx.savepoints = append(sqlTx.savepoints, sp)
+
+	return nil
+}
+
+// savepointIndex returns the position of the most recent savepoint named as given
+func (sqlTx *SQLTx) savepointIndex(name string) (int, error) {
+	if !sqlTx.explicitClose {
+		return -1, ErrNoOngoingTx
+	}
+
+	for i := len(sqlTx.savepoints) - 1; i >= 0; i-- {
+		if sqlTx.savepoints[i].name == name {
+			return i, nil
+		}
+	}
+
+	return -1, ErrSavepointDoesNotExist
+}
+
+// rollbackToSavepoint discards the changes made since the savepoint was established, the savepoint itself is kept
+func (sqlTx *SQLTx) rollbackToSavepoint(name string) error {
+	i, err := sqlTx.savepointIndex(name)
+	if err != nil {
+		return err
+	}
+
+	sp := sqlTx.savepoints[i]
+
+	err = sqlTx.tx.RollbackTo(sp.txSavepoint)
+	if err != nil {
+		return err
+	}
+
+	// the in-memory catalog may have been changed by DDL statements,
+	// so it's loaded again from the entries of the transaction
+	catalog := newCatalog()
+
+	err = catalog.load(sqlTx.sqlPrefix(), sqlTx.tx)
+	if err != nil {
+		return err
+	}
+
+	sqlTx.catalog = catalog
+	sqlTx.currentDB = nil
+
+	if sp.currentDB != "" {
+		sqlTx.currentDB, err = catalog.GetDatabaseByName(sp.currentDB)
+		if err != nil {
+			return err
+		}
+	}
+
+	sqlTx.updatedRows = sp.updatedRows
+
+	sqlTx.lastInsertedPKs, sqlTx.firstInsertedPKs, sqlTx.modifiedRows, sqlTx.analyzedTables = copyTxCounters(
+		sp.lastInsertedPKs,
+		sp.firstInsertedPKs,
+		sp.modifiedRows,
+		sp.analyzedTables,
+	)
+
+	sqlTx.savepoints = sqlTx.savepoints[:i+1]
+	sqlTx.aborted = false
+
+	return nil
+}
+
+// releaseSavepoint forgets the savepoint and the ones established after it, changes made since then are kept
+func (sqlTx *SQLTx) releaseSavepoint(name string) error {
+	i, err := sqlTx.savepointIndex(name)
+	if err != nil {
+		return err
+	}
+
+	sqlTx.savepoints = sqlTx.savepoints[:i]
+
+	return nil
+}
+
+func copyTxCounters(
+	lastInsertedPKs map[string]int64,
+	firstInsertedPKs map[string]int64,
+	modifiedRows map[tableID]int64,
+	analyzedTables map[tableID]struct{},
+) (map[string]int64, map[string]int64, map[tableID]int64, map[tableID]struct{}) {
+
+	lastPKs := make(map[string]int64, len(lastInsertedPKs))
+	for t, pk := range lastInsertedPKs {
+		lastPKs[t] = pk
+	}
+
+	firstPKs := make(map[string]int64, len(firstInsertedPKs))
+	for t, pk := range firstInsertedPKs {
+		firstPKs[t] = pk
+	}
+
+	var modified map[tableID]int64
+	if modifiedRows != nil {
+		modified = make(map[tableID]int64, len(modifiedRows))
+		for id, n := range modifiedRows {
+			modified[id] = n
+		}
+	}
+
+	var analyzed map[tableID]struct{}
+	if analyzedTables != nil {
+		analyzed = make(map[tableID]struct{}, len(analyzedTables))
+		for id := range analyzedTables {
+			analyzed[id] = struct{}{}
+		}
+	}
+
+	return lastPKs, firstPKs, modified, analyzed
+}
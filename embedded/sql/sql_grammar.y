@@ -22,6 +22,10 @@ import "fmt"
 func setResult(l yyLexer, stmts []SQLStmt) {
     l.(*lexer).result = stmts
 }
+
+func setExpResult(l yyLexer, exp ValueExp) {
+    l.(*lexer).expResult = exp
+}
 %}
 
 %union{
@@ -62,14 +66,21 @@ func setResult(l yyLexer, stmts []SQLStmt) {
     update *colUpdate
     updates []*colUpdate
     onConflict *OnConflictDo
+    procParam *ProcParam
+    procParams []*ProcParam
 }
 
 %token CREATE USE DATABASE SNAPSHOT SINCE UP TO TABLE UNIQUE INDEX ON ALTER ADD COLUMN PRIMARY KEY
-%token BEGIN TRANSACTION COMMIT ROLLBACK
+%token DROP POLICY USING VIEW
+%token PROCEDURE CALL THEN ELSE END RAISE
+%token HISTORY OF
+%token EXPLAIN ANALYZE
+%token BEGIN TRANSACTION COMMIT ROLLBACK SAVEPOINT RELEASE
 %token INSERT UPSERT INTO VALUES DELETE UPDATE SET CONFLICT DO NOTHING
-%token SELECT DISTINCT FROM BEFORE TX JOIN HAVING WHERE GROUP BY LIMIT ORDER ASC DESC AS
+%token SELECT DISTINCT FROM BEFORE TX JOIN HAVING WHERE GROUP BY LIMIT OFFSET ORDER ASC DESC AS
 %token NOT LIKE IF EXISTS IN IS
-%token AUTO_INCREMENT NULL NPARAM CAST
+%token AUTO_INCREMENT NULL NPARAM CAST DEFAULT CHECK
+%token EXPRESSION
 %token <pparam> PPARAM
 %token <joinType> JOINTYPE
 %token <logicOp> LOP
@@ -95,17 +106,17 @@ func setResult(l yyLexer, stmts []SQLStmt) {
 %right STMT_SEPARATOR
 %left IS
 
-%type <stmts> sql sqlstmts
-%type <stmt> sqlstmt ddlstmt dqlstmt dmlstmt
+%type <stmts> sql sqlstmts procstmts opt_else
+%type <stmt> sqlstmt ddlstmt dqlstmt dmlstmt explainstmt callstmt procstmt
 %type <colsSpec> colsSpec
 %type <colSpec> colSpec
 %type <ids> ids one_or_more_ids opt_ids
 %type <cols> cols
 %type <rows> rows
 %type <row> row
-%type <values> values opt_values
+%type <values> values opt_values opt_checks
 %type <value> val
-%type <sel> selector
+%type <sel> selector fnCall
 %type <sels> opt_selectors selectors
 %type <col> col
 %type <distinct> opt_distinct
@@ -115,18 +126,20 @@ func setResult(l yyLexer, stmts []SQLStmt) {
 %type <joins> opt_joins joins
 %type <join> join
 %type <joinType> opt_join_type
-%type <exp> exp opt_where opt_having boundexp
+%type <exp> exp opt_where opt_having boundexp opt_default opt_check
 %type <binExp> binExp
 %type <cols> opt_groupby
-%type <number> opt_limit opt_max_len
+%type <number> opt_limit opt_offset opt_max_len
 %type <id> opt_as
 %type <ordcols> ordcols opt_orderby
 %type <opt_ord> opt_ord
 %type <ids> opt_indexon
-%type <boolean> opt_if_not_exists opt_auto_increment opt_not_null opt_not
+%type <boolean> opt_if_not_exists opt_auto_increment opt_not_null opt_not opt_analyze
 %type <update> update
 %type <updates> updates
 %type <onConflict> opt_on_conflict
+%type <procParam> procParam
+%type <procParams> opt_procParams procParams
 
 %start sql
 
@@ -137,6 +150,12 @@ sql: sqlstmts
     $$ = $1
     setResult(yylex, $1)
 }
+|
+    EXPRESSION exp
+{
+    $$ = nil
+    setExpResult(yylex, $2)
+}
 
 sqlstmts:
     sqlstmt opt_separator
@@ -151,7 +170,34 @@ sqlstmts:
 
 opt_separator: {} | STMT_SEPARATOR
 
-sqlstmt: ddlstmt | dmlstmt | dqlstmt
+sqlstmt: ddlstmt | dmlstmt | dqlstmt | explainstmt | callstmt
+
+explainstmt:
+    EXPLAIN opt_analyze dqlstmt
+    {
+        stmt := $3.(*SelectStmt)
+        stmt.explain = true
+        stmt.analyze = $2
+        $$ = stmt
+    }
+
+opt_savepoint:
+    {
+    }
+|
+    SAVEPOINT
+    {
+    }
+
+opt_analyze:
+    {
+        $$ = false
+    }
+|
+    ANALYZE
+    {
+        $$ = true
+    }
 
 ddlstmt:
     BEGIN TRANSACTION
@@ -168,6 +214,21 @@ ddlstmt:
     {
         $$ = &RollbackStmt{}
     }
+|
+    SAVEPOINT IDENTIFIER
+    {
+        $$ = &SavepointStmt{name: $2}
+    }
+|
+    ROLLBACK TO opt_savepoint IDENTIFIER
+    {
+        $$ = &RollbackToSavepointStmt{name: $4}
+    }
+|
+    RELEASE opt_savepoint IDENTIFIER
+    {
+        $$ = &ReleaseSavepointStmt{name: $3}
+    }
 |
     CREATE DATABASE IDENTIFIER
     {
@@ -184,9 +245,9 @@ ddlstmt:
         $$ = &UseSnapshotStmt{sinceTx: $3, asBefore: $4}
     }
 |
-    CREATE TABLE opt_if_not_exists IDENTIFIER '(' colsSpec ',' PRIMARY KEY one_or_more_ids ')'
+    CREATE TABLE opt_if_not_exists IDENTIFIER '(' colsSpec ',' PRIMARY KEY one_or_more_ids opt_checks ')'
     {
-        $$ = &CreateTableStmt{ifNotExists: $3, table: $4, colsSpec: $6, pkColNames: $10}
+        $$ = &CreateTableStmt{ifNotExists: $3, table: $4, colsSpec: $6, pkColNames: $10, checks: $11}
     }
 |
     CREATE INDEX opt_if_not_exists ON IDENTIFIER '(' ids ')'
@@ -203,6 +264,116 @@ ddlstmt:
     {
         $$ = &AddColumnStmt{table: $3, colSpec: $6}
     }
+|
+    CREATE POLICY IDENTIFIER ON IDENTIFIER USING '(' exp ')'
+    {
+        $$ = &CreatePolicyStmt{name: $3, table: $5, predicate: $8}
+    }
+|
+    DROP POLICY IDENTIFIER ON IDENTIFIER
+    {
+        $$ = &DropPolicyStmt{name: $3, table: $5}
+    }
+|
+    CREATE VIEW opt_if_not_exists IDENTIFIER AS dqlstmt
+    {
+        $$ = &CreateViewStmt{ifNotExists: $3, name: $4, query: $6.(*SelectStmt)}
+    }
+|
+    DROP VIEW IDENTIFIER
+    {
+        $$ = &DropViewStmt{name: $3}
+    }
+|
+    CREATE PROCEDURE opt_if_not_exists IDENTIFIER '(' opt_procParams ')' AS BEGIN procstmts END
+    {
+        $$ = &CreateProcedureStmt{ifNotExists: $3, name: $4, params: $6, body: $10}
+    }
+|
+    DROP PROCEDURE IDENTIFIER
+    {
+        $$ = &DropProcedureStmt{name: $3}
+    }
+|
+    ANALYZE
+    {
+        $$ = &AnalyzeTableStmt{}
+    }
+|
+    ANALYZE TABLE IDENTIFIER
+    {
+        $$ = &AnalyzeTableStmt{table: $3}
+    }
+
+opt_procParams:
+    {
+        $$ = nil
+    }
+|
+    procParams
+    {
+        $$ = $1
+    }
+
+procParams:
+    procParam
+    {
+        $$ = []*ProcParam{$1}
+    }
+|
+    procParams ',' procParam
+    {
+        $$ = append($1, $3)
+    }
+
+procParam:
+    IDENTIFIER TYPE
+    {
+        $$ = &ProcParam{name: $1, paramType: $2}
+    }
+
+procstmts:
+    procstmt STMT_SEPARATOR
+    {
+        $$ = []SQLStmt{$1}
+    }
+|
+    procstmt STMT_SEPARATOR procstmts
+    {
+        $$ = append([]SQLStmt{$1}, $3...)
+    }
+
+procstmt:
+    dmlstmt
+    {
+        $$ = $1
+    }
+|
+    IF exp THEN procstmts opt_else END IF
+    {
+        $$ = &ProcIfStmt{cond: $2, then: $4, elseStmts: $5}
+    }
+|
+    RAISE VARCHAR
+    {
+        $$ = &ProcRaiseStmt{msg: $2}
+    }
+
+opt_else:
+    {
+        $$ = nil
+    }
+|
+    ELSE procstmts
+    {
+        $$ = $2
+    }
+
+callstmt:
+    CALL IDENTIFIER '(' opt_values ')'
+    {
+        $$ = &CallStmt{name: $2, args: $4}
+    }
 
 opt_since:
     {
@@ -265,6 +436,11 @@ opt_on_conflict:
     {
         $$ = &OnConflictDo{}
     }
+|
+    ON CONFLICT DO UPDATE SET updates
+    {
+        $$ = &OnConflictDo{updates: $6}
+    }
 
 updates:
     update
@@ -378,11 +554,6 @@ val:
     {
         $$ = &Cast{val: $3, t: $5}
     }
-|
-    IDENTIFIER '(' ')'
-    {
-        $$ = &SysFn{fn: $1}
-    }
 |
     NPARAM IDENTIFIER
     {
@@ -411,9 +582,39 @@ colsSpec:
     }
 
 colSpec:
-    IDENTIFIER TYPE opt_max_len opt_not_null opt_auto_increment
+    IDENTIFIER TYPE opt_max_len opt_not_null opt_auto_increment opt_default opt_check
+    {
+        $$ = &ColSpec{colName: $1, colType: $2, maxLen: int($3), notNull: $4, autoIncrement: $5, defaultValue: $6, check: $7}
+    }
+
+opt_default:
+    {
+        $$ = nil
+    }
+|
+    DEFAULT exp
+    {
+        $$ = $2
+    }
+
+opt_check:
+    {
+        $$ = nil
+    }
+|
+    CHECK '(' exp ')'
+    {
+        $$ = $3
+    }
+
+opt_checks:
     {
-        $$ = &ColSpec{colName: $1, colType: $2, maxLen: int($3), notNull: $4, autoIncrement: $5}
+        $$ = nil
+    }
+|
+    opt_checks ',' CHECK '(' exp ')'
+    {
+        $$ = append($1, $5)
     }
 
 opt_max_len:
@@ -452,7 +653,7 @@ opt_not_null:
     }
 
 dqlstmt:
-    SELECT opt_distinct opt_selectors FROM ds opt_indexon opt_joins opt_where opt_groupby opt_having opt_orderby opt_limit
+    SELECT opt_distinct opt_selectors FROM ds opt_indexon opt_joins opt_where opt_groupby opt_having opt_orderby opt_limit opt_offset
     {
         $$ = &SelectStmt{
                 distinct: $2,
@@ -465,6 +666,7 @@ dqlstmt:
                 having: $10,
                 orderBy: $11,
                 limit: int($12),
+                offset: int($13),
             }
     }
 
@@ -517,6 +719,23 @@ selector:
     {
         $$ = &AggColSelector{aggFn: $1, db: $3.db, table: $3.table, col: $3.col}
     }
+|
+    fnCall
+    {
+        $$ = $1
+    }
+
+fnCall:
+    IDENTIFIER '(' opt_values ')'
+    {
+        $$ = &SysFn{fn: $1, params: $3}
+    }
+|
+    IDENTIFIER '(' IDENTIFIER FROM exp ')'
+    {
+        // e.g. EXTRACT(YEAR FROM ts)
+        $$ = &SysFn{fn: $1, params: []ValueExp{&Varchar{val: $3}, $5}}
+    }
 
 col:
     IDENTIFIER
@@ -541,6 +760,13 @@ ds:
         $1.as = $3
         $$ = $1
     }
+|
+    HISTORY OF tableRef opt_as
+    {
+        $3.history = true
+        $3.as = $4
+        $$ = $3
+    }
 |
     '(' dqlstmt ')' opt_as
     {
@@ -646,6 +872,16 @@ opt_limit:
         $$ = $2
     }
 
+opt_offset:
+    {
+        $$ = 0
+    }
+|
+    OFFSET NUMBER
+    {
+        $$ = $2
+    }
+
 opt_orderby:
     {
         $$ = nil
@@ -747,6 +983,11 @@ exp:
     {
         $$ = &InListExp{val: $1, notIn: $2, values: $5}
     }
+|
+    '(' exp ',' values ')' CMPOP '(' exp ',' values ')'
+    {
+        $$ = &TupleCmpExp{op: $6, left: append([]ValueExp{$2}, $4...), right: append([]ValueExp{$8}, $10...)}
+    }
 
 boundexp:
     selector
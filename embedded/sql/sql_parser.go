@@ -9,6 +9,10 @@ func setResult(l yyLexer, stmts []SQLStmt) {
 	l.(*lexer).result = stmts
 }
 
+func setExpResult(l yyLexer, exp ValueExp) {
+	l.(*lexer).expResult = exp
+}
+
 type yySymType struct {
 	yys        int
 	stmts      []SQLStmt
@@ -48,6 +52,8 @@ type yySymType struct {
 	update     *colUpdate
 	updates    []*colUpdate
 	onConflict *OnConflictDo
+	procParam  *ProcParam
+	procParams []*ProcParam
 }
 
 const CREATE = 57346
@@ -66,58 +72,78 @@ const ADD = 57358
 const COLUMN = 57359
 const PRIMARY = 57360
 const KEY = 57361
-const BEGIN = 57362
-const TRANSACTION = 57363
-const COMMIT = 57364
-const ROLLBACK = 57365
-const INSERT = 57366
-const UPSERT = 57367
-const INTO = 57368
-const VALUES = 57369
-const DELETE = 57370
-const UPDATE = 57371
-const SET = 57372
-const CONFLICT = 57373
-const DO = 57374
-const NOTHING = 57375
-const SELECT = 57376
-const DISTINCT = 57377
-const FROM = 57378
-const BEFORE = 57379
-const TX = 57380
-const JOIN = 57381
-const HAVING = 57382
-const WHERE = 57383
-const GROUP = 57384
-const BY = 57385
-const LIMIT = 57386
-const ORDER = 57387
-const ASC = 57388
-const DESC = 57389
-const AS = 57390
-const NOT = 57391
-const LIKE = 57392
-const IF = 57393
-const EXISTS = 57394
-const IN = 57395
-const IS = 57396
-const AUTO_INCREMENT = 57397
-const NULL = 57398
-const NPARAM = 57399
-const CAST = 57400
-const PPARAM = 57401
-const JOINTYPE = 57402
-const LOP = 57403
-const CMPOP = 57404
-const IDENTIFIER = 57405
-const TYPE = 57406
-const NUMBER = 57407
-const VARCHAR = 57408
-const BOOLEAN = 57409
-const BLOB = 57410
-const AGGREGATE_FUNC = 57411
-const ERROR = 57412
-const STMT_SEPARATOR = 57413
+const DROP = 57362
+const POLICY = 57363
+const USING = 57364
+const VIEW = 57365
+const PROCEDURE = 57366
+const CALL = 57367
+const THEN = 57368
+const ELSE = 57369
+const END = 57370
+const RAISE = 57371
+const HISTORY = 57372
+const OF = 57373
+const EXPLAIN = 57374
+const ANALYZE = 57375
+const BEGIN = 57376
+const TRANSACTION = 57377
+const COMMIT = 57378
+const ROLLBACK = 57379
+const SAVEPOINT = 57380
+const RELEASE = 57381
+const INSERT = 57382
+const UPSERT = 57383
+const INTO = 57384
+const VALUES = 57385
+const DELETE = 57386
+const UPDATE = 57387
+const SET = 57388
+const CONFLICT = 57389
+const DO = 57390
+const NOTHING = 57391
+const SELECT = 57392
+const DISTINCT = 57393
+const FROM = 57394
+const BEFORE = 57395
+const TX = 57396
+const JOIN = 57397
+const HAVING = 57398
+const WHERE = 57399
+const GROUP = 57400
+const BY = 57401
+const LIMIT = 57402
+const OFFSET = 57403
+const ORDER = 57404
+const ASC = 57405
+const DESC = 57406
+const AS = 57407
+const NOT = 57408
+const LIKE = 57409
+const IF = 57410
+const EXISTS = 57411
+const IN = 57412
+const IS = 57413
+const AUTO_INCREMENT = 57414
+const NULL = 57415
+const NPARAM = 57416
+const CAST = 57417
+const DEFAULT = 57418
+const CHECK = 57419
+const EXPRESSION = 57420
+const PPARAM = 57421
+const JOINTYPE = 57422
+const LOP = 57423
+const CMPOP = 57424
+const IDENTIFIER = 57425
+const TYPE = 57426
+const NUMBER = 57427
+const VARCHAR = 57428
+const BOOLEAN = 57429
+const BLOB = 57430
+const AGGREGATE_FUNC = 57431
+const ERROR = 57432
+const STMT_SEPARATOR = 57433
 
 var yyToknames = [...]string{
 	"$end",
@@ -139,10 +165,26 @@ var yyToknames = [...]string{
 	"COLUMN",
 	"PRIMARY",
 	"KEY",
+	"DROP",
+	"POLICY",
+	"USING",
+	"VIEW",
+	"PROCEDURE",
+	"CALL",
+	"THEN",
+	"ELSE",
+	"END",
+	"RAISE",
+	"HISTORY",
+	"OF",
+	"EXPLAIN",
+	"ANALYZE",
 	"BEGIN",
 	"TRANSACTION",
 	"COMMIT",
 	"ROLLBACK",
+	"SAVEPOINT",
+	"RELEASE",
 	"INSERT",
 	"UPSERT",
 	"INTO",
@@ -164,6 +206,7 @@ var yyToknames = [...]string{
 	"GROUP",
 	"BY",
 	"LIMIT",
+	"OFFSET",
 	"ORDER",
 	"ASC",
 	"DESC",
@@ -178,6 +221,9 @@ var yyToknames = [...]string{
 	"NULL",
 	"NPARAM",
 	"CAST",
+	"DEFAULT",
+	"CHECK",
+	"EXPRESSION",
 	"PPARAM",
 	"JOINTYPE",
 	"LOP",
@@ -213,193 +259,258 @@ var yyExca = [...]int{
 	-1, 1,
 	1, -1,
 	-2, 0,
-	-1, 94,
-	50, 123,
-	53, 123,
-	-2, 112,
-	-1, 154,
-	39, 90,
-	-2, 85,
-	-1, 187,
-	39, 90,
-	-2, 87,
+	-1, 28,
+	67, 168,
+	70, 168,
+	-2, 156,
+	-1, 245,
+	55, 132,
+	-2, 127,
+	-1, 271,
+	55, 132,
+	-2, 129,
 }
 
 const yyPrivate = 57344
 
-const yyLast = 333
+const yyLast = 532
 
 var yyAct = [...]int{
-	226, 269, 54, 132, 91, 200, 203, 114, 225, 6,
-	75, 186, 67, 199, 123, 61, 70, 88, 17, 238,
-	242, 130, 130, 130, 196, 251, 130, 246, 245, 243,
-	220, 197, 244, 96, 131, 241, 98, 209, 191, 204,
-	110, 108, 106, 109, 183, 32, 159, 107, 158, 102,
-	103, 104, 105, 55, 205, 201, 96, 97, 129, 98,
-	116, 208, 101, 110, 108, 106, 109, 164, 148, 93,
-	107, 141, 102, 103, 104, 105, 55, 146, 139, 140,
-	97, 120, 111, 125, 90, 101, 141, 80, 78, 135,
-	136, 138, 137, 139, 140, 19, 181, 144, 145, 66,
-	65, 128, 147, 141, 135, 136, 138, 137, 219, 79,
-	160, 149, 79, 49, 153, 223, 151, 268, 263, 154,
-	168, 210, 242, 138, 137, 99, 156, 141, 157, 152,
-	222, 155, 161, 141, 139, 140, 170, 171, 172, 173,
-	174, 175, 141, 163, 68, 135, 136, 138, 137, 182,
-	140, 135, 136, 138, 137, 184, 180, 56, 56, 53,
-	135, 136, 138, 137, 55, 56, 190, 130, 119, 51,
-	74, 55, 222, 127, 112, 85, 194, 230, 77, 207,
-	193, 202, 198, 162, 56, 89, 192, 166, 71, 150,
-	124, 126, 121, 76, 118, 82, 72, 57, 211, 212,
-	117, 32, 214, 44, 41, 36, 113, 189, 218, 177,
-	237, 206, 236, 81, 38, 217, 176, 229, 228, 141,
-	143, 233, 234, 227, 178, 124, 58, 179, 239, 270,
-	271, 255, 133, 262, 249, 232, 68, 248, 250, 213,
-	84, 63, 62, 253, 73, 30, 37, 34, 17, 256,
-	260, 252, 258, 240, 48, 167, 165, 29, 261, 115,
-	264, 10, 11, 28, 20, 266, 267, 215, 86, 64,
-	39, 272, 12, 2, 273, 259, 31, 7, 169, 8,
-	9, 13, 14, 83, 59, 15, 16, 60, 45, 46,
-	47, 17, 21, 35, 134, 40, 27, 22, 24, 23,
-	43, 25, 26, 92, 18, 221, 69, 142, 216, 235,
-	254, 265, 195, 231, 95, 94, 247, 188, 187, 185,
-	42, 33, 52, 50, 100, 224, 257, 87, 122, 5,
-	4, 3, 1,
+	147, 380, 36, 322, 27, 166, 239, 203, 288, 291,
+	146, 209, 200, 144, 172, 270, 164, 287, 232, 210,
+	224, 7, 157, 167, 34, 24, 321, 352, 186, 237,
+	237, 88, 89, 211, 91, 351, 350, 330, 305, 237,
+	281, 30, 329, 72, 32, 186, 276, 282, 46, 44,
+	43, 237, 260, 251, 45, 85, 292, 253, 47, 238,
+	39, 40, 41, 42, 37, 101, 84, 186, 31, 185,
+	250, 293, 373, 33, 340, 218, 79, 80, 82, 81,
+	127, 128, 129, 130, 131, 132, 73, 236, 215, 116,
+	117, 118, 184, 182, 143, 141, 181, 30, 125, 124,
+	32, 212, 49, 179, 46, 44, 43, 96, 365, 95,
+	45, 289, 137, 96, 47, 95, 39, 40, 41, 42,
+	37, 277, 259, 103, 31, 30, 106, 107, 32, 33,
+	258, 226, 46, 44, 43, 194, 177, 188, 45, 178,
+	175, 163, 145, 162, 39, 40, 41, 42, 37, 180,
+	126, 85, 31, 93, 92, 90, 187, 33, 85, 96,
+	120, 83, 84, 165, 207, 379, 205, 142, 362, 309,
+	153, 138, 79, 80, 82, 81, 202, 310, 140, 139,
+	85, 82, 81, 206, 261, 255, 220, 221, 237, 217,
+	83, 84, 186, 171, 342, 378, 213, 207, 304, 85,
+	216, 79, 80, 82, 81, 47, 47, 278, 388, 244,
+	266, 37, 37, 262, 242, 229, 122, 245, 235, 85,
+	79, 80, 82, 81, 234, 196, 174, 256, 249, 83,
+	84, 243, 142, 246, 248, 85, 219, 168, 201, 257,
+	79, 80, 82, 81, 173, 83, 84, 384, 233, 268,
+	73, 264, 225, 227, 309, 222, 79, 80, 82, 81,
+	284, 274, 214, 306, 198, 192, 190, 275, 169, 156,
+	155, 283, 225, 150, 85, 149, 279, 148, 299, 357,
+	286, 115, 114, 290, 83, 84, 294, 295, 113, 112,
+	297, 298, 111, 108, 105, 79, 80, 82, 81, 100,
+	99, 94, 254, 311, 78, 52, 312, 252, 315, 208,
+	317, 273, 364, 354, 338, 303, 320, 176, 327, 319,
+	189, 134, 302, 334, 85, 85, 341, 335, 133, 285,
+	389, 102, 85, 346, 83, 84, 348, 135, 151, 355,
+	136, 87, 83, 84, 356, 79, 80, 82, 81, 193,
+	363, 360, 316, 79, 80, 82, 81, 381, 382, 345,
+	369, 366, 240, 314, 371, 372, 374, 361, 333, 183,
+	165, 332, 296, 377, 383, 85, 195, 159, 158, 170,
+	386, 85, 387, 71, 75, 83, 84, 53, 24, 390,
+	359, 83, 84, 328, 358, 2, 79, 80, 82, 81,
+	15, 16, 79, 80, 82, 81, 324, 6, 326, 343,
+	367, 17, 119, 265, 263, 70, 18, 69, 54, 20,
+	21, 26, 50, 22, 23, 307, 77, 385, 25, 19,
+	10, 247, 11, 12, 13, 14, 20, 21, 339, 98,
+	22, 23, 376, 15, 16, 97, 24, 325, 65, 197,
+	66, 67, 228, 300, 17, 241, 6, 160, 349, 18,
+	267, 191, 161, 154, 26, 152, 104, 68, 64, 51,
+	110, 25, 19, 10, 3, 11, 12, 13, 14, 20,
+	21, 55, 204, 22, 23, 48, 56, 58, 57, 24,
+	62, 63, 231, 230, 308, 76, 59, 86, 60, 61,
+	301, 318, 344, 370, 280, 368, 313, 29, 353, 337,
+	28, 331, 272, 271, 269, 109, 74, 123, 121, 38,
+	35, 336, 347, 199, 223, 323, 9, 8, 5, 4,
+	375, 1,
 }
 
 var yyPact = [...]int{
-	257, -1000, -1000, 18, -1000, -1000, -1000, 243, -1000, -1000,
-	286, 295, 285, 237, 231, 209, 138, 212, -1000, 257,
-	-1000, 142, 163, 163, 282, 141, 292, 140, 138, 138,
-	138, 224, 37, 95, -1000, -1000, -1000, 134, 177, 270,
-	163, -1000, 205, 203, 253, 22, 21, 195, 125, 133,
-	208, -1000, 99, 130, -1000, 10, 36, 9, 161, 132,
-	269, -1000, 202, 110, 251, 122, 122, 298, 7, 103,
-	-1000, 144, -1000, -18, 102, -1000, -1000, 131, 94, 129,
-	127, -1000, 5, 128, 108, -1000, 127, -21, 96, -1000,
-	-45, 188, 281, 32, 171, -1000, 7, 7, -1, -1000,
-	-1000, 7, -1000, -1000, -1000, -1000, -10, 33, 126, -1000,
-	-1000, 298, 125, 7, 298, 205, 214, 130, -1000, -31,
-	-33, 34, 61, -1000, 119, 122, -11, -1000, -1000, 229,
-	124, 228, -1000, 55, 264, 7, 7, 7, 7, 7,
-	7, 160, 174, -1000, 88, 49, 214, 17, 7, -35,
-	-1000, 188, -1000, 32, 147, 130, -41, -1000, -1000, -1000,
-	123, 162, -56, -48, 122, -23, -1000, -23, -1000, -24,
-	49, 49, 165, 165, 88, 79, -1000, 155, 7, -17,
-	-42, -1000, 73, -1000, -1000, 195, -1000, 147, 200, -1000,
-	-1000, 130, -1000, 248, -1000, 159, 43, -1000, -49, 101,
-	-1000, 7, 59, -1000, -1000, 122, -1000, 88, -16, -1000,
-	113, 193, -1000, -18, -1000, -24, 157, -1000, 154, -62,
-	-1000, -1000, -23, 222, -44, 51, 32, -50, -47, -51,
-	-52, 197, 191, 298, -54, -1000, -1000, -1000, -1000, -1000,
-	219, -1000, 7, -1000, -1000, -1000, -1000, 186, 7, 121,
-	261, -1000, 217, 32, 188, 190, 32, 47, -1000, 7,
-	-1000, -1000, 121, 121, 32, 46, 183, -1000, 121, -1000,
-	-1000, -1000, 183, -1000,
+	396, -1000, -1000, 31, 5, -1000, -1000, -1000, -1000, -1000,
+	387, -1000, 459, 222, 380, 475, 484, 457, 427, 456,
+	375, 373, 331, 167, 333, 393, 221, 310, 275, -1000,
+	31, 31, 57, 31, -1000, -1000, -1000, 56, -1000, -1000,
+	-1000, -1000, -1000, 55, 218, -1000, -1000, 11, -1000, 439,
+	-1000, 380, -1000, 217, -1000, 216, 263, 263, 453, 211,
+	263, 263, 210, 462, 209, 206, 205, 199, 198, 167,
+	167, 167, 366, 64, 122, -1000, 338, -1000, 52, 31,
+	31, 31, 31, 31, 31, 255, 270, -1000, -16, 87,
+	338, 80, 84, 31, -1000, 59, 194, -1000, 192, -1000,
+	-1000, 190, 272, 451, 263, 449, 187, 186, -1000, 325,
+	323, 441, 448, -1000, -1000, -1000, 45, 43, 313, 154,
+	185, 327, -1000, 102, 161, -1000, 31, 87, 87, 254,
+	254, -16, 128, -1000, 244, 31, 41, 4, 31, -1000,
+	-3, -6, 63, 304, -7, 17, 101, 310, 60, -1000,
+	39, 251, 183, 447, 182, 284, 37, -1000, 322, 140,
+	432, 181, 155, 155, 477, 31, 106, -1000, 227, -1000,
+	3, 123, -1000, -1000, 179, -11, -1000, -16, -25, -1000,
+	-24, -1000, -1000, 152, -1000, 31, 31, 172, 169, -1000,
+	33, 170, 430, 338, 165, 139, -1000, 169, -1000, -12,
+	97, -1000, -40, 302, 442, 310, 477, 154, 31, 477,
+	325, 400, 338, 161, -1000, -1000, -29, -46, 225, -42,
+	203, 310, -1000, 94, -1000, 143, 155, 32, 24, -1000,
+	-47, 93, -1000, 129, -1000, -1000, 371, 168, 370, -1000,
+	125, 446, 302, -1000, 310, 231, 161, 167, -53, -1000,
+	-1000, -1000, 23, -1000, -1000, 189, -60, -52, 155, 31,
+	264, 165, -1000, 13, -1000, 13, -1000, -27, -1000, 313,
+	-1000, 231, 317, -1000, -1000, 161, 161, 31, 434, -1000,
+	249, 113, -1000, -61, 164, 391, -1000, 163, -1000, 31,
+	78, -1000, -1000, 155, 305, -1000, 3, -1000, -1000, 261,
+	-27, 247, -1000, 243, -75, -1000, -1000, 379, -1000, 13,
+	346, -57, -62, 315, 309, 477, 31, -1000, 238, -1000,
+	-1000, -1000, 410, -23, -1000, 31, 108, -1000, 361, -1000,
+	-1000, 297, 31, 149, 444, -63, -64, 236, 31, -1000,
+	379, 253, -1000, 345, 302, 308, 310, 77, -1000, 31,
+	-1000, -1000, 235, -1000, 10, 310, -1000, 379, -1000, 364,
+	299, 149, 149, 310, -26, 31, 415, 154, -1000, 110,
+	74, 294, -1000, 31, 148, 399, 379, 73, -1000, 149,
+	-1000, -1000, -1000, 109, -1000, 262, -1000, 294, -1000, -1000,
+	-1000,
 }
 
 var yyPgo = [...]int{
-	0, 332, 273, 331, 330, 9, 329, 328, 14, 17,
-	6, 327, 326, 13, 5, 8, 325, 324, 125, 323,
-	322, 2, 321, 7, 259, 320, 15, 319, 11, 318,
-	317, 0, 12, 316, 315, 314, 313, 3, 312, 10,
-	311, 310, 1, 4, 246, 309, 308, 307, 16, 306,
-	305, 304,
+	0, 531, 395, 3, 530, 529, 528, 21, 406, 527,
+	526, 525, 524, 20, 12, 9, 523, 522, 17, 8,
+	10, 13, 521, 520, 24, 519, 518, 517, 2, 516,
+	11, 19, 515, 22, 514, 15, 513, 512, 0, 16,
+	511, 510, 509, 508, 507, 506, 6, 505, 504, 14,
+	503, 502, 1, 7, 65, 501, 500, 497, 495, 23,
+	5, 494, 18, 493, 492, 485, 387,
 }
 
 var yyR1 = [...]int{
-	0, 1, 2, 2, 51, 51, 3, 3, 3, 4,
-	4, 4, 4, 4, 4, 4, 4, 4, 4, 25,
-	25, 44, 44, 10, 10, 6, 6, 6, 6, 50,
-	50, 49, 49, 48, 11, 11, 13, 13, 14, 9,
-	9, 12, 12, 16, 16, 15, 15, 17, 17, 17,
-	17, 17, 17, 17, 17, 17, 7, 7, 8, 38,
-	38, 45, 45, 46, 46, 46, 5, 22, 22, 19,
-	19, 20, 20, 18, 18, 18, 21, 21, 21, 23,
-	23, 24, 24, 26, 26, 27, 27, 28, 28, 29,
-	30, 30, 32, 32, 36, 36, 33, 33, 37, 37,
-	41, 41, 43, 43, 40, 40, 42, 42, 42, 39,
-	39, 39, 31, 31, 31, 31, 31, 31, 31, 31,
-	34, 34, 34, 47, 47, 35, 35, 35, 35, 35,
-	35, 35, 35,
+	0, 1, 1, 2, 2, 65, 65, 5, 5, 5,
+	5, 5, 9, 66, 66, 58, 58, 6, 6, 6,
+	6, 6, 6, 6, 6, 6, 6, 6, 6, 6,
+	6, 6, 6, 6, 6, 6, 6, 6, 63, 63,
+	64, 64, 62, 3, 3, 11, 11, 11, 4, 4,
+	10, 32, 32, 54, 54, 15, 15, 8, 8, 8,
+	8, 61, 61, 61, 60, 60, 59, 16, 16, 18,
+	18, 19, 14, 14, 17, 17, 21, 21, 20, 20,
+	23, 23, 23, 23, 23, 23, 23, 23, 12, 12,
+	13, 42, 42, 43, 43, 22, 22, 48, 48, 55,
+	55, 56, 56, 56, 7, 29, 29, 26, 26, 27,
+	27, 24, 24, 24, 24, 25, 25, 28, 28, 28,
+	30, 30, 30, 31, 31, 33, 33, 34, 34, 35,
+	35, 36, 37, 37, 39, 39, 45, 45, 40, 40,
+	46, 46, 47, 47, 51, 51, 53, 53, 50, 50,
+	52, 52, 52, 49, 49, 49, 38, 38, 38, 38,
+	38, 38, 38, 38, 38, 41, 41, 41, 57, 57,
+	44, 44, 44, 44, 44, 44, 44, 44,
 }
 
 var yyR2 = [...]int{
-	0, 1, 2, 3, 0, 1, 1, 1, 1, 2,
-	1, 1, 3, 3, 4, 11, 8, 9, 6, 0,
-	3, 0, 3, 1, 3, 9, 8, 6, 7, 0,
-	4, 1, 3, 3, 0, 1, 1, 3, 3, 1,
-	3, 1, 3, 0, 1, 1, 3, 1, 1, 1,
-	1, 6, 3, 2, 1, 1, 1, 3, 5, 0,
-	3, 0, 1, 0, 1, 2, 12, 0, 1, 1,
-	1, 2, 4, 1, 4, 4, 1, 3, 5, 3,
-	4, 1, 3, 0, 3, 0, 1, 1, 2, 6,
-	0, 1, 0, 2, 0, 3, 0, 2, 0, 2,
-	0, 3, 0, 4, 2, 4, 0, 1, 1, 0,
-	1, 2, 1, 1, 2, 2, 4, 4, 6, 6,
-	1, 1, 3, 0, 1, 3, 3, 3, 3, 3,
-	3, 3, 4,
+	0, 1, 2, 2, 3, 0, 1, 1, 1, 1,
+	1, 1, 3, 0, 1, 0, 1, 2, 1, 1,
+	2, 4, 3, 3, 3, 4, 12, 8, 9, 6,
+	9, 5, 6, 3, 11, 3, 1, 3, 0, 1,
+	1, 3, 2, 2, 3, 1, 7, 2, 0, 2,
+	5, 0, 3, 0, 3, 1, 3, 9, 8, 6,
+	7, 0, 4, 6, 1, 3, 3, 0, 1, 1,
+	3, 3, 1, 3, 1, 3, 0, 1, 1, 3,
+	1, 1, 1, 1, 6, 2, 1, 1, 1, 3,
+	7, 0, 2, 0, 4, 0, 6, 0, 3, 0,
+	1, 0, 1, 2, 13, 0, 1, 1, 1, 2,
+	4, 1, 4, 4, 1, 4, 6, 1, 3, 5,
+	3, 4, 4, 1, 3, 0, 3, 0, 1, 1,
+	2, 6, 0, 1, 0, 2, 0, 3, 0, 2,
+	0, 2, 0, 2, 0, 3, 0, 4, 2, 4,
+	0, 1, 1, 0, 1, 2, 1, 1, 2, 2,
+	4, 4, 6, 6, 11, 1, 1, 3, 0, 1,
+	3, 3, 3, 3, 3, 3, 3, 4,
 }
 
 var yyChk = [...]int{
-	-1000, -1, -2, -3, -4, -6, -5, 20, 22, 23,
-	4, 5, 15, 24, 25, 28, 29, 34, -51, 77,
-	21, 6, 11, 13, 12, 6, 7, 11, 26, 26,
-	36, -24, 63, -22, 35, -2, 63, -44, 51, -44,
-	13, 63, -25, 8, 63, -24, -24, -24, 30, 76,
-	-19, 74, -20, -18, -21, 69, 63, 63, 49, 14,
-	-44, -26, 37, 38, 16, 78, 78, -32, 41, -49,
-	-48, 63, 63, 36, 71, -39, 63, 48, 78, 76,
-	78, 52, 63, 14, 38, 65, 17, -11, -9, 63,
-	-9, -43, 5, -31, -34, -35, 49, 73, 52, -18,
-	-17, 78, 65, 66, 67, 68, 58, 63, 57, 59,
-	56, -32, 71, 62, -23, -24, 78, -18, 63, 74,
-	-21, 63, -7, -8, 63, 78, 63, 65, -8, 79,
-	71, 79, -37, 44, 13, 72, 73, 75, 74, 61,
-	62, 54, -47, 49, -31, -31, 78, -31, 78, 78,
-	63, -43, -48, -31, -43, -26, -5, -39, 79, 79,
-	76, 71, 64, -9, 78, 27, 63, 27, 65, 14,
-	-31, -31, -31, -31, -31, -31, 56, 49, 50, 53,
-	-5, 79, -31, 79, -37, -27, -28, -29, -30, 60,
-	-39, 79, 63, 18, -8, -38, 80, 79, -9, -13,
-	-14, 78, -13, -10, 63, 78, 56, -31, 78, 79,
-	48, -32, -28, 39, -39, 19, -46, 56, 49, 65,
-	79, -50, 71, 14, -16, -15, -31, -9, -5, -15,
-	64, -36, 42, -23, -10, -45, 55, 56, 81, -14,
-	31, 79, 71, 79, 79, 79, 79, -33, 40, 43,
-	-43, 79, 32, -31, -41, 45, -31, -12, -21, 14,
-	33, -37, 43, 71, -31, -40, -21, -21, 71, -42,
-	46, 47, -21, -42,
+	-1000, -1, -2, 78, -5, -6, -8, -7, -9, -10,
+	34, 36, 37, 38, 39, 4, 5, 15, 20, 33,
+	40, 41, 44, 45, 50, 32, 25, -38, -41, -44,
+	66, 93, 69, 98, -24, -23, -28, 89, -25, 85,
+	86, 87, 88, 75, 74, 79, 73, 83, -65, 97,
+	35, 10, 83, -66, 38, 6, 11, 13, 12, 21,
+	23, 24, 6, 7, 11, 21, 23, 24, 11, 42,
+	42, 52, -31, 83, -29, 51, -58, 33, 83, 92,
+	93, 95, 94, 81, 82, 71, -57, 66, -38, -38,
+	98, -38, 98, 98, 83, 98, 96, -2, -66, 83,
+	83, -54, 68, -54, 13, 83, -54, -54, 83, -32,
+	8, 83, 83, 83, 83, 83, -31, -31, -31, 46,
+	96, -26, 94, -27, -24, -7, 98, -38, -38, -38,
+	-38, -38, -38, 73, 66, 67, 70, -7, 91, 99,
+	94, -28, 83, -38, -21, 83, -20, -38, 83, 83,
+	83, 66, 14, -54, 14, 83, 83, -33, 53, 54,
+	16, 14, 98, 98, -39, 57, -60, -59, 83, 83,
+	52, 91, -49, 83, 65, -21, 73, -38, 98, 99,
+	-20, 99, 99, 65, 99, 52, 91, 96, 98, 69,
+	83, 14, 83, 65, 98, 54, 85, 17, 83, -16,
+	-14, 83, -14, -53, 5, -38, -39, 91, 82, -30,
+	-31, 30, 98, -24, 83, 99, -7, -20, 99, 84,
+	-38, -38, 83, -12, -13, 83, 98, 83, 22, -7,
+	-63, -64, -62, 83, 85, -13, 99, 91, 99, -46,
+	60, 13, -53, -59, -38, -53, -33, 31, -7, -49,
+	99, 99, 82, 99, 99, 91, 84, -14, 98, 98,
+	99, 91, 84, 43, 83, 43, 85, 14, -46, -34,
+	-35, -36, -37, 80, -49, -31, 99, 98, 18, -13,
+	-48, 100, 99, -14, -38, 65, -62, -18, -19, 98,
+	-18, -15, 83, 98, -39, -35, 55, -49, -49, -38,
+	19, -56, 73, 66, 85, 99, 99, 34, -61, 91,
+	14, -21, -14, -45, 58, -30, 91, -15, -55, 72,
+	73, 101, -3, -11, -8, 68, 29, -19, 47, 99,
+	99, -40, 56, 59, -53, -20, -22, -42, 76, 28,
+	97, -38, 86, 48, -51, 62, -38, -17, -28, 14,
+	99, 99, 91, -43, 77, -38, -3, 26, 49, 45,
+	-46, 59, 91, -38, 77, 98, -3, 46, -47, 61,
+	-50, -28, -28, 98, -38, -4, 27, -60, 85, 91,
+	-52, 63, 64, -38, 99, 28, -3, -28, 99, 68,
+	-52,
 }
 
 var yyDef = [...]int{
-	0, -2, 1, 4, 6, 7, 8, 0, 10, 11,
-	0, 0, 0, 0, 0, 0, 0, 67, 2, 5,
-	9, 0, 21, 21, 0, 0, 19, 0, 0, 0,
-	0, 0, 81, 0, 68, 3, 12, 0, 0, 0,
-	21, 13, 83, 0, 0, 0, 0, 92, 0, 0,
-	0, 69, 70, 109, 73, 0, 76, 0, 0, 0,
-	0, 14, 0, 0, 0, 34, 0, 102, 0, 92,
-	31, 0, 82, 0, 0, 71, 110, 0, 0, 0,
-	0, 22, 0, 0, 0, 20, 0, 0, 35, 39,
-	0, 98, 0, 93, -2, 113, 0, 0, 0, 120,
-	121, 0, 47, 48, 49, 50, 0, 76, 0, 54,
-	55, 102, 0, 0, 102, 83, 0, 109, 111, 0,
-	0, 77, 0, 56, 0, 0, 0, 84, 18, 0,
-	0, 0, 27, 0, 0, 0, 0, 0, 0, 0,
-	0, 0, 0, 124, 114, 115, 0, 0, 0, 0,
-	53, 98, 32, 33, -2, 109, 0, 72, 74, 75,
-	0, 0, 59, 0, 0, 0, 40, 0, 99, 0,
-	125, 126, 127, 128, 129, 130, 131, 0, 0, 0,
-	0, 122, 0, 52, 28, 92, 86, -2, 0, 91,
-	79, 109, 78, 0, 57, 63, 0, 16, 0, 29,
-	36, 43, 26, 103, 23, 0, 132, 116, 0, 117,
-	0, 94, 88, 0, 80, 0, 61, 64, 0, 0,
-	17, 25, 0, 0, 0, 44, 45, 0, 0, 0,
-	0, 96, 0, 102, 0, 58, 62, 65, 60, 37,
-	0, 38, 0, 24, 118, 119, 51, 100, 0, 0,
-	0, 15, 0, 46, 98, 0, 97, 95, 41, 0,
-	30, 66, 0, 0, 89, 101, 106, 42, 0, 104,
-	107, 108, 106, 105,
+	0, -2, 1, 0, 5, 7, 8, 9, 10, 11,
+	0, 18, 19, 0, 13, 0, 0, 0, 0, 36,
+	0, 0, 0, 0, 105, 15, 0, 2, -2, 157,
+	0, 0, 0, 0, 165, 166, 111, 0, 114, 80,
+	81, 82, 83, 0, 0, 86, 87, 117, 3, 6,
+	17, 13, 20, 0, 14, 0, 53, 53, 0, 0,
+	53, 53, 0, 51, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 123, 0, 106, 0, 16, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 169, 158, 159,
+	0, 0, 0, 0, 85, 76, 0, 4, 0, 22,
+	23, 0, 0, 0, 53, 0, 0, 0, 24, 125,
+	0, 0, 0, 33, 35, 37, 0, 0, 134, 0,
+	0, 0, 107, 108, 153, 12, 76, 170, 171, 172,
+	173, 174, 175, 176, 0, 0, 0, 0, 0, 167,
+	0, 0, 117, 0, 0, 117, 77, 78, 118, 21,
+	0, 0, 0, 0, 0, 0, 0, 25, 0, 0,
+	0, 0, 67, 0, 146, 0, 134, 64, 0, 124,
+	0, 0, 109, 154, 0, 0, 177, 160, 0, 161,
+	0, 112, 113, 0, 115, 0, 0, 0, 0, 54,
+	0, 0, 0, 0, 38, 0, 52, 0, 31, 0,
+	68, 72, 0, 140, 0, 135, 146, 0, 0, 146,
+	125, 0, 0, 153, 155, 50, 0, 0, 0, 0,
+	0, 79, 119, 0, 88, 0, 0, 0, 0, 32,
+	0, 39, 40, 0, 126, 29, 0, 0, 0, 59,
+	0, 0, 140, 65, 66, -2, 153, 0, 0, 110,
+	162, 163, 0, 84, 116, 0, 97, 0, 0, 0,
+	0, 0, 42, 0, 73, 0, 141, 0, 60, 134,
+	128, -2, 0, 133, 120, 153, 153, 0, 0, 89,
+	101, 0, 27, 0, 0, 0, 41, 61, 69, 76,
+	58, 147, 55, 0, 136, 130, 0, 121, 122, 0,
+	0, 99, 102, 0, 0, 28, 30, 0, 57, 0,
+	0, 0, 0, 138, 0, 146, 0, 95, 91, 100,
+	103, 98, 0, 0, 45, 0, 0, 70, 0, 71,
+	56, 144, 0, 0, 0, 0, 0, 93, 0, 34,
+	43, 0, 47, 0, 140, 0, 139, 137, 74, 0,
+	164, 26, 0, 90, 0, 92, 44, 0, 62, 0,
+	142, 0, 0, 131, 0, 0, 48, 0, 104, 0,
+	145, 150, 75, 0, 0, 0, 0, 63, 143, 0,
+	148, 151, 152, 0, 94, 0, 49, 150, 96, 46,
+	149,
 }
 
 var yyTok1 = [...]int{
@@ -407,12 +518,12 @@ var yyTok1 = [...]int{
 	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
 	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
 	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
-	78, 79, 74, 72, 71, 73, 76, 75, 3, 3,
+	98, 99, 94, 92, 91, 93, 96, 95, 3, 3,
 	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
 	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
 	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
 	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
-	3, 80, 3, 81,
+	3, 100, 3, 101,
 }
 
 var yyTok2 = [...]int{
@@ -422,7 +533,9 @@ var yyTok2 = [...]int{
 	32, 33, 34, 35, 36, 37, 38, 39, 40, 41,
 	42, 43, 44, 45, 46, 47, 48, 49, 50, 51,
 	52, 53, 54, 55, 56, 57, 58, 59, 60, 61,
-	62, 63, 64, 65, 66, 67, 68, 69, 70, 77,
+	62, 63, 64, 65, 66, 67, 68, 69, 70, 71,
+	72, 73, 74, 75, 76, 77, 78, 79, 80, 81,
+	82, 83, 84, 85, 86, 87, 88, 89, 90, 97,
 }
 
 var yyTok3 = [...]int{
@@ -773,304 +886,486 @@ yydefault:
 	case 2:
 		yyDollar = yyS[yypt-2 : yypt+1]
 		{
-			yyVAL.stmts = []SQLStmt{yyDollar[1].stmt}
+			yyVAL.stmts = nil
+			setExpResult(yylex, yyDollar[2].exp)
 		}
 	case 3:
+		yyDollar = yyS[yypt-2 : yypt+1]
+		{
+			yyVAL.stmts = []SQLStmt{yyDollar[1].stmt}
+		}
+	case 4:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.stmts = append([]SQLStmt{yyDollar[1].stmt}, yyDollar[3].stmts...)
 		}
-	case 4:
+	case 5:
+		yyDollar = yyS[yypt-0 : yypt+1]
+		{
+		}
+	case 12:
+		yyDollar = yyS[yypt-3 : yypt+1]
+		{
+			stmt := yyDollar[3].stmt.(*SelectStmt)
+			stmt.explain = true
+			stmt.analyze = yyDollar[2].boolean
+			yyVAL.stmt = stmt
+		}
+	case 13:
+		yyDollar = yyS[yypt-0 : yypt+1]
+		{
+		}
+	case 14:
+		yyDollar = yyS[yypt-1 : yypt+1]
+		{
+		}
+	case 15:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
+			yyVAL.boolean = false
 		}
-	case 9:
+	case 16:
+		yyDollar = yyS[yypt-1 : yypt+1]
+		{
+			yyVAL.boolean = true
+		}
+	case 17:
 		yyDollar = yyS[yypt-2 : yypt+1]
 		{
 			yyVAL.stmt = &BeginTransactionStmt{}
 		}
-	case 10:
+	case 18:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.stmt = &CommitStmt{}
 		}
-	case 11:
+	case 19:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.stmt = &RollbackStmt{}
 		}
-	case 12:
+	case 20:
+		yyDollar = yyS[yypt-2 : yypt+1]
+		{
+			yyVAL.stmt = &SavepointStmt{name: yyDollar[2].id}
+		}
+	case 21:
+		yyDollar = yyS[yypt-4 : yypt+1]
+		{
+			yyVAL.stmt = &RollbackToSavepointStmt{name: yyDollar[4].id}
+		}
+	case 22:
+		yyDollar = yyS[yypt-3 : yypt+1]
+		{
+			yyVAL.stmt = &ReleaseSavepointStmt{name: yyDollar[3].id}
+		}
+	case 23:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.stmt = &CreateDatabaseStmt{DB: yyDollar[3].id}
 		}
-	case 13:
+	case 24:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.stmt = &UseDatabaseStmt{DB: yyDollar[3].id}
 		}
-	case 14:
+	case 25:
 		yyDollar = yyS[yypt-4 : yypt+1]
 		{
 			yyVAL.stmt = &UseSnapshotStmt{sinceTx: yyDollar[3].number, asBefore: yyDollar[4].number}
 		}
-	case 15:
-		yyDollar = yyS[yypt-11 : yypt+1]
+	case 26:
+		yyDollar = yyS[yypt-12 : yypt+1]
 		{
-			yyVAL.stmt = &CreateTableStmt{ifNotExists: yyDollar[3].boolean, table: yyDollar[4].id, colsSpec: yyDollar[6].colsSpec, pkColNames: yyDollar[10].ids}
+			yyVAL.stmt = &CreateTableStmt{ifNotExists: yyDollar[3].boolean, table: yyDollar[4].id, colsSpec: yyDollar[6].colsSpec, pkColNames: yyDollar[10].ids, checks: yyDollar[11].values}
 		}
-	case 16:
+	case 27:
 		yyDollar = yyS[yypt-8 : yypt+1]
 		{
 			yyVAL.stmt = &CreateIndexStmt{ifNotExists: yyDollar[3].boolean, table: yyDollar[5].id, cols: yyDollar[7].ids}
 		}
-	case 17:
+	case 28:
 		yyDollar = yyS[yypt-9 : yypt+1]
 		{
 			yyVAL.stmt = &CreateIndexStmt{unique: true, ifNotExists: yyDollar[4].boolean, table: yyDollar[6].id, cols: yyDollar[8].ids}
 		}
-	case 18:
+	case 29:
 		yyDollar = yyS[yypt-6 : yypt+1]
 		{
 			yyVAL.stmt = &AddColumnStmt{table: yyDollar[3].id, colSpec: yyDollar[6].colSpec}
 		}
-	case 19:
+	case 30:
+		yyDollar = yyS[yypt-9 : yypt+1]
+		{
+			yyVAL.stmt = &CreatePolicyStmt{name: yyDollar[3].id, table: yyDollar[5].id, predicate: yyDollar[8].exp}
+		}
+	case 31:
+		yyDollar = yyS[yypt-5 : yypt+1]
+		{
+			yyVAL.stmt = &DropPolicyStmt{name: yyDollar[3].id, table: yyDollar[5].id}
+		}
+	case 32:
+		yyDollar = yyS[yypt-6 : yypt+1]
+		{
+			yyVAL.stmt = &CreateViewStmt{ifNotExists: yyDollar[3].boolean, name: yyDollar[4].id, query: yyDollar[6].stmt.(*SelectStmt)}
+		}
+	case 33:
+		yyDollar = yyS[yypt-3 : yypt+1]
+		{
+			yyVAL.stmt = &DropViewStmt{name: yyDollar[3].id}
+		}
+	case 34:
+		yyDollar = yyS[yypt-11 : yypt+1]
+		{
+			yyVAL.stmt = &CreateProcedureStmt{ifNotExists: yyDollar[3].boolean, name: yyDollar[4].id, params: yyDollar[6].procParams, body: yyDollar[10].stmts}
+		}
+	case 35:
+		yyDollar = yyS[yypt-3 : yypt+1]
+		{
+			yyVAL.stmt = &DropProcedureStmt{name: yyDollar[3].id}
+		}
+	case 36:
+		yyDollar = yyS[yypt-1 : yypt+1]
+		{
+			yyVAL.stmt = &AnalyzeTableStmt{}
+		}
+	case 37:
+		yyDollar = yyS[yypt-3 : yypt+1]
+		{
+			yyVAL.stmt = &AnalyzeTableStmt{table: yyDollar[3].id}
+		}
+	case 38:
+		yyDollar = yyS[yypt-0 : yypt+1]
+		{
+			yyVAL.procParams = nil
+		}
+	case 39:
+		yyDollar = yyS[yypt-1 : yypt+1]
+		{
+			yyVAL.procParams = yyDollar[1].procParams
+		}
+	case 40:
+		yyDollar = yyS[yypt-1 : yypt+1]
+		{
+			yyVAL.procParams = []*ProcParam{yyDollar[1].procParam}
+		}
+	case 41:
+		yyDollar = yyS[yypt-3 : yypt+1]
+		{
+			yyVAL.procParams = append(yyDollar[1].procParams, yyDollar[3].procParam)
+		}
+	case 42:
+		yyDollar = yyS[yypt-2 : yypt+1]
+		{
+			yyVAL.procParam = &ProcParam{name: yyDollar[1].id, paramType: yyDollar[2].sqlType}
+		}
+	case 43:
+		yyDollar = yyS[yypt-2 : yypt+1]
+		{
+			yyVAL.stmts = []SQLStmt{yyDollar[1].stmt}
+		}
+	case 44:
+		yyDollar = yyS[yypt-3 : yypt+1]
+		{
+			yyVAL.stmts = append([]SQLStmt{yyDollar[1].stmt}, yyDollar[3].stmts...)
+		}
+	case 45:
+		yyDollar = yyS[yypt-1 : yypt+1]
+		{
+			yyVAL.stmt = yyDollar[1].stmt
+		}
+	case 46:
+		yyDollar = yyS[yypt-7 : yypt+1]
+		{
+			yyVAL.stmt = &ProcIfStmt{cond: yyDollar[2].exp, then: yyDollar[4].stmts, elseStmts: yyDollar[5].stmts}
+		}
+	case 47:
+		yyDollar = yyS[yypt-2 : yypt+1]
+		{
+			yyVAL.stmt = &ProcRaiseStmt{msg: yyDollar[2].str}
+		}
+	case 48:
+		yyDollar = yyS[yypt-0 : yypt+1]
+		{
+			yyVAL.stmts = nil
+		}
+	case 49:
+		yyDollar = yyS[yypt-2 : yypt+1]
+		{
+			yyVAL.stmts = yyDollar[2].stmts
+		}
+	case 50:
+		yyDollar = yyS[yypt-5 : yypt+1]
+		{
+			yyVAL.stmt = &CallStmt{name: yyDollar[2].id, args: yyDollar[4].values}
+		}
+	case 51:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.number = 0
 		}
-	case 20:
+	case 52:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.number = yyDollar[3].number
 		}
-	case 21:
+	case 53:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.boolean = false
 		}
-	case 22:
+	case 54:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.boolean = true
 		}
-	case 23:
+	case 55:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.ids = []string{yyDollar[1].id}
 		}
-	case 24:
+	case 56:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.ids = yyDollar[2].ids
 		}
-	case 25:
+	case 57:
 		yyDollar = yyS[yypt-9 : yypt+1]
 		{
 			yyVAL.stmt = &UpsertIntoStmt{isInsert: true, tableRef: yyDollar[3].tableRef, cols: yyDollar[5].ids, rows: yyDollar[8].rows, onConflict: yyDollar[9].onConflict}
 		}
-	case 26:
+	case 58:
 		yyDollar = yyS[yypt-8 : yypt+1]
 		{
 			yyVAL.stmt = &UpsertIntoStmt{tableRef: yyDollar[3].tableRef, cols: yyDollar[5].ids, rows: yyDollar[8].rows}
 		}
-	case 27:
+	case 59:
 		yyDollar = yyS[yypt-6 : yypt+1]
 		{
 			yyVAL.stmt = &DeleteFromStmt{tableRef: yyDollar[3].tableRef, where: yyDollar[4].exp, indexOn: yyDollar[5].ids, limit: int(yyDollar[6].number)}
 		}
-	case 28:
+	case 60:
 		yyDollar = yyS[yypt-7 : yypt+1]
 		{
 			yyVAL.stmt = &UpdateStmt{tableRef: yyDollar[2].tableRef, updates: yyDollar[4].updates, where: yyDollar[5].exp, indexOn: yyDollar[6].ids, limit: int(yyDollar[7].number)}
 		}
-	case 29:
+	case 61:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.onConflict = nil
 		}
-	case 30:
+	case 62:
 		yyDollar = yyS[yypt-4 : yypt+1]
 		{
 			yyVAL.onConflict = &OnConflictDo{}
 		}
-	case 31:
+	case 63:
+		yyDollar = yyS[yypt-6 : yypt+1]
+		{
+			yyVAL.onConflict = &OnConflictDo{updates: yyDollar[6].updates}
+		}
+	case 64:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.updates = []*colUpdate{yyDollar[1].update}
 		}
-	case 32:
+	case 65:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.updates = append(yyDollar[1].updates, yyDollar[3].update)
 		}
-	case 33:
+	case 66:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.update = &colUpdate{col: yyDollar[1].id, op: yyDollar[2].cmpOp, val: yyDollar[3].exp}
 		}
-	case 34:
+	case 67:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.ids = nil
 		}
-	case 35:
+	case 68:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.ids = yyDollar[1].ids
 		}
-	case 36:
+	case 69:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.rows = []*RowSpec{yyDollar[1].row}
 		}
-	case 37:
+	case 70:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.rows = append(yyDollar[1].rows, yyDollar[3].row)
 		}
-	case 38:
+	case 71:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.row = &RowSpec{Values: yyDollar[2].values}
 		}
-	case 39:
+	case 72:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.ids = []string{yyDollar[1].id}
 		}
-	case 40:
+	case 73:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.ids = append(yyDollar[1].ids, yyDollar[3].id)
 		}
-	case 41:
+	case 74:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.cols = []*ColSelector{yyDollar[1].col}
 		}
-	case 42:
+	case 75:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.cols = append(yyDollar[1].cols, yyDollar[3].col)
 		}
-	case 43:
+	case 76:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.values = nil
 		}
-	case 44:
+	case 77:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.values = yyDollar[1].values
 		}
-	case 45:
+	case 78:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.values = []ValueExp{yyDollar[1].exp}
 		}
-	case 46:
+	case 79:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.values = append(yyDollar[1].values, yyDollar[3].exp)
 		}
-	case 47:
+	case 80:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.value = &Number{val: int64(yyDollar[1].number)}
 		}
-	case 48:
+	case 81:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.value = &Varchar{val: yyDollar[1].str}
 		}
-	case 49:
+	case 82:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.value = &Bool{val: yyDollar[1].boolean}
 		}
-	case 50:
+	case 83:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.value = &Blob{val: yyDollar[1].blob}
 		}
-	case 51:
+	case 84:
 		yyDollar = yyS[yypt-6 : yypt+1]
 		{
 			yyVAL.value = &Cast{val: yyDollar[3].exp, t: yyDollar[5].sqlType}
 		}
-	case 52:
-		yyDollar = yyS[yypt-3 : yypt+1]
-		{
-			yyVAL.value = &SysFn{fn: yyDollar[1].id}
-		}
-	case 53:
+	case 85:
 		yyDollar = yyS[yypt-2 : yypt+1]
 		{
 			yyVAL.value = &Param{id: yyDollar[2].id}
 		}
-	case 54:
+	case 86:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.value = &Param{id: fmt.Sprintf("param%d", yyDollar[1].pparam), pos: yyDollar[1].pparam}
 		}
-	case 55:
+	case 87:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.value = &NullValue{t: AnyType}
 		}
-	case 56:
+	case 88:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.colsSpec = []*ColSpec{yyDollar[1].colSpec}
 		}
-	case 57:
+	case 89:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.colsSpec = append(yyDollar[1].colsSpec, yyDollar[3].colSpec)
 		}
-	case 58:
-		yyDollar = yyS[yypt-5 : yypt+1]
+	case 90:
+		yyDollar = yyS[yypt-7 : yypt+1]
 		{
-			yyVAL.colSpec = &ColSpec{colName: yyDollar[1].id, colType: yyDollar[2].sqlType, maxLen: int(yyDollar[3].number), notNull: yyDollar[4].boolean, autoIncrement: yyDollar[5].boolean}
+			yyVAL.colSpec = &ColSpec{colName: yyDollar[1].id, colType: yyDollar[2].sqlType, maxLen: int(yyDollar[3].number), notNull: yyDollar[4].boolean, autoIncrement: yyDollar[5].boolean, defaultValue: yyDollar[6].exp, check: yyDollar[7].exp}
 		}
-	case 59:
+	case 91:
+		yyDollar = yyS[yypt-0 : yypt+1]
+		{
+			yyVAL.exp = nil
+		}
+	case 92:
+		yyDollar = yyS[yypt-2 : yypt+1]
+		{
+			yyVAL.exp = yyDollar[2].exp
+		}
+	case 93:
+		yyDollar = yyS[yypt-0 : yypt+1]
+		{
+			yyVAL.exp = nil
+		}
+	case 94:
+		yyDollar = yyS[yypt-4 : yypt+1]
+		{
+			yyVAL.exp = yyDollar[3].exp
+		}
+	case 95:
+		yyDollar = yyS[yypt-0 : yypt+1]
+		{
+			yyVAL.values = nil
+		}
+	case 96:
+		yyDollar = yyS[yypt-6 : yypt+1]
+		{
+			yyVAL.values = append(yyDollar[1].values, yyDollar[5].exp)
+		}
+	case 97:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.number = 0
 		}
-	case 60:
+	case 98:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.number = yyDollar[2].number
 		}
-	case 61:
+	case 99:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.boolean = false
 		}
-	case 62:
+	case 100:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.boolean = true
 		}
-	case 63:
+	case 101:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.boolean = false
 		}
-	case 64:
+	case 102:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.boolean = false
 		}
-	case 65:
+	case 103:
 		yyDollar = yyS[yypt-2 : yypt+1]
 		{
 			yyVAL.boolean = true
 		}
-	case 66:
-		yyDollar = yyS[yypt-12 : yypt+1]
+	case 104:
+		yyDollar = yyS[yypt-13 : yypt+1]
 		{
 			yyVAL.stmt = &SelectStmt{
 				distinct:  yyDollar[2].distinct,
@@ -1083,339 +1378,378 @@ yydefault:
 				having:    yyDollar[10].exp,
 				orderBy:   yyDollar[11].ordcols,
 				limit:     int(yyDollar[12].number),
+				offset:    int(yyDollar[13].number),
 			}
 		}
-	case 67:
+	case 105:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.distinct = false
 		}
-	case 68:
+	case 106:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.distinct = true
 		}
-	case 69:
+	case 107:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.sels = nil
 		}
-	case 70:
+	case 108:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.sels = yyDollar[1].sels
 		}
-	case 71:
+	case 109:
 		yyDollar = yyS[yypt-2 : yypt+1]
 		{
 			yyDollar[1].sel.setAlias(yyDollar[2].id)
 			yyVAL.sels = []Selector{yyDollar[1].sel}
 		}
-	case 72:
+	case 110:
 		yyDollar = yyS[yypt-4 : yypt+1]
 		{
 			yyDollar[3].sel.setAlias(yyDollar[4].id)
 			yyVAL.sels = append(yyDollar[1].sels, yyDollar[3].sel)
 		}
-	case 73:
+	case 111:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.sel = yyDollar[1].col
 		}
-	case 74:
+	case 112:
 		yyDollar = yyS[yypt-4 : yypt+1]
 		{
 			yyVAL.sel = &AggColSelector{aggFn: yyDollar[1].aggFn, col: "*"}
 		}
-	case 75:
+	case 113:
 		yyDollar = yyS[yypt-4 : yypt+1]
 		{
 			yyVAL.sel = &AggColSelector{aggFn: yyDollar[1].aggFn, db: yyDollar[3].col.db, table: yyDollar[3].col.table, col: yyDollar[3].col.col}
 		}
-	case 76:
+	case 114:
+		yyDollar = yyS[yypt-1 : yypt+1]
+		{
+			yyVAL.sel = yyDollar[1].sel
+		}
+	case 115:
+		yyDollar = yyS[yypt-4 : yypt+1]
+		{
+			yyVAL.sel = &SysFn{fn: yyDollar[1].id, params: yyDollar[3].values}
+		}
+	case 116:
+		yyDollar = yyS[yypt-6 : yypt+1]
+		{
+			// e.g. EXTRACT(YEAR FROM ts)
+			yyVAL.sel = &SysFn{fn: yyDollar[1].id, params: []ValueExp{&Varchar{val: yyDollar[3].id}, yyDollar[5].exp}}
+		}
+	case 117:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.col = &ColSelector{col: yyDollar[1].id}
 		}
-	case 77:
+	case 118:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.col = &ColSelector{table: yyDollar[1].id, col: yyDollar[3].id}
 		}
-	case 78:
+	case 119:
 		yyDollar = yyS[yypt-5 : yypt+1]
 		{
 			yyVAL.col = &ColSelector{db: yyDollar[1].id, table: yyDollar[3].id, col: yyDollar[5].id}
 		}
-	case 79:
+	case 120:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyDollar[1].tableRef.asBefore = yyDollar[2].number
 			yyDollar[1].tableRef.as = yyDollar[3].id
 			yyVAL.ds = yyDollar[1].tableRef
 		}
-	case 80:
+	case 121:
+		yyDollar = yyS[yypt-4 : yypt+1]
+		{
+			yyDollar[3].tableRef.history = true
+			yyDollar[3].tableRef.as = yyDollar[4].id
+			yyVAL.ds = yyDollar[3].tableRef
+		}
+	case 122:
 		yyDollar = yyS[yypt-4 : yypt+1]
 		{
 			yyDollar[2].stmt.(*SelectStmt).as = yyDollar[4].id
 			yyVAL.ds = yyDollar[2].stmt.(DataSource)
 		}
-	case 81:
+	case 123:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.tableRef = &tableRef{table: yyDollar[1].id}
 		}
-	case 82:
+	case 124:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.tableRef = &tableRef{db: yyDollar[1].id, table: yyDollar[3].id}
 		}
-	case 83:
+	case 125:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.number = 0
 		}
-	case 84:
+	case 126:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.number = yyDollar[3].number
 		}
-	case 85:
+	case 127:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.joins = nil
 		}
-	case 86:
+	case 128:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.joins = yyDollar[1].joins
 		}
-	case 87:
+	case 129:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.joins = []*JoinSpec{yyDollar[1].join}
 		}
-	case 88:
+	case 130:
 		yyDollar = yyS[yypt-2 : yypt+1]
 		{
 			yyVAL.joins = append([]*JoinSpec{yyDollar[1].join}, yyDollar[2].joins...)
 		}
-	case 89:
+	case 131:
 		yyDollar = yyS[yypt-6 : yypt+1]
 		{
 			yyVAL.join = &JoinSpec{joinType: yyDollar[1].joinType, ds: yyDollar[3].ds, indexOn: yyDollar[4].ids, cond: yyDollar[6].exp}
 		}
-	case 90:
+	case 132:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.joinType = InnerJoin
 		}
-	case 91:
+	case 133:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.joinType = yyDollar[1].joinType
 		}
-	case 92:
+	case 134:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.exp = nil
 		}
-	case 93:
+	case 135:
 		yyDollar = yyS[yypt-2 : yypt+1]
 		{
 			yyVAL.exp = yyDollar[2].exp
 		}
-	case 94:
+	case 136:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.cols = nil
 		}
-	case 95:
+	case 137:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.cols = yyDollar[3].cols
 		}
-	case 96:
+	case 138:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.exp = nil
 		}
-	case 97:
+	case 139:
 		yyDollar = yyS[yypt-2 : yypt+1]
 		{
 			yyVAL.exp = yyDollar[2].exp
 		}
-	case 98:
+	case 140:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.number = 0
 		}
-	case 99:
+	case 141:
 		yyDollar = yyS[yypt-2 : yypt+1]
 		{
 			yyVAL.number = yyDollar[2].number
 		}
-	case 100:
+	case 142:
+		yyDollar = yyS[yypt-0 : yypt+1]
+		{
+			yyVAL.number = 0
+		}
+	case 143:
+		yyDollar = yyS[yypt-2 : yypt+1]
+		{
+			yyVAL.number = yyDollar[2].number
+		}
+	case 144:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.ordcols = nil
 		}
-	case 101:
+	case 145:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.ordcols = yyDollar[3].ordcols
 		}
-	case 102:
+	case 146:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.ids = nil
 		}
-	case 103:
+	case 147:
 		yyDollar = yyS[yypt-4 : yypt+1]
 		{
 			yyVAL.ids = yyDollar[4].ids
 		}
-	case 104:
+	case 148:
 		yyDollar = yyS[yypt-2 : yypt+1]
 		{
 			yyVAL.ordcols = []*OrdCol{{sel: yyDollar[1].col, descOrder: yyDollar[2].opt_ord}}
 		}
-	case 105:
+	case 149:
 		yyDollar = yyS[yypt-4 : yypt+1]
 		{
 			yyVAL.ordcols = append(yyDollar[1].ordcols, &OrdCol{sel: yyDollar[3].col, descOrder: yyDollar[4].opt_ord})
 		}
-	case 106:
+	case 150:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.opt_ord = false
 		}
-	case 107:
+	case 151:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.opt_ord = false
 		}
-	case 108:
+	case 152:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.opt_ord = true
 		}
-	case 109:
+	case 153:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.id = ""
 		}
-	case 110:
+	case 154:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.id = yyDollar[1].id
 		}
-	case 111:
+	case 155:
 		yyDollar = yyS[yypt-2 : yypt+1]
 		{
 			yyVAL.id = yyDollar[2].id
 		}
-	case 112:
+	case 156:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.exp = yyDollar[1].exp
 		}
-	case 113:
+	case 157:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.exp = yyDollar[1].binExp
 		}
-	case 114:
+	case 158:
 		yyDollar = yyS[yypt-2 : yypt+1]
 		{
 			yyVAL.exp = &NotBoolExp{exp: yyDollar[2].exp}
 		}
-	case 115:
+	case 159:
 		yyDollar = yyS[yypt-2 : yypt+1]
 		{
 			yyVAL.exp = &NumExp{left: &Number{val: 0}, op: SUBSOP, right: yyDollar[2].exp}
 		}
-	case 116:
+	case 160:
 		yyDollar = yyS[yypt-4 : yypt+1]
 		{
 			yyVAL.exp = &LikeBoolExp{val: yyDollar[1].exp, notLike: yyDollar[2].boolean, pattern: yyDollar[4].exp}
 		}
-	case 117:
+	case 161:
 		yyDollar = yyS[yypt-4 : yypt+1]
 		{
 			yyVAL.exp = &ExistsBoolExp{q: (yyDollar[3].stmt).(*SelectStmt)}
 		}
-	case 118:
+	case 162:
 		yyDollar = yyS[yypt-6 : yypt+1]
 		{
 			yyVAL.exp = &InSubQueryExp{val: yyDollar[1].exp, notIn: yyDollar[2].boolean, q: yyDollar[5].stmt.(*SelectStmt)}
 		}
-	case 119:
+	case 163:
 		yyDollar = yyS[yypt-6 : yypt+1]
 		{
 			yyVAL.exp = &InListExp{val: yyDollar[1].exp, notIn: yyDollar[2].boolean, values: yyDollar[5].values}
 		}
-	case 120:
+	case 164:
+		yyDollar = yyS[yypt-11 : yypt+1]
+		{
+			yyVAL.exp = &TupleCmpExp{op: yyDollar[6].cmpOp, left: append([]ValueExp{yyDollar[2].exp}, yyDollar[4].values...), right: append([]ValueExp{yyDollar[8].exp}, yyDollar[10].values...)}
+		}
+	case 165:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.exp = yyDollar[1].sel
 		}
-	case 121:
+	case 166:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.exp = yyDollar[1].value
 		}
-	case 122:
+	case 167:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.exp = yyDollar[2].exp
 		}
-	case 123:
+	case 168:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.boolean = false
 		}
-	case 124:
+	case 169:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.boolean = true
 		}
-	case 125:
+	case 170:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.binExp = &NumExp{left: yyDollar[1].exp, op: ADDOP, right: yyDollar[3].exp}
 		}
-	case 126:
+	case 171:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.binExp = &NumExp{left: yyDollar[1].exp, op: SUBSOP, right: yyDollar[3].exp}
 		}
-	case 127:
+	case 172:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.binExp = &NumExp{left: yyDollar[1].exp, op: DIVOP, right: yyDollar[3].exp}
 		}
-	case 128:
+	case 173:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.binExp = &NumExp{left: yyDollar[1].exp, op: MULTOP, right: yyDollar[3].exp}
 		}
-	case 129:
+	case 174:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.binExp = &BinBoolExp{left: yyDollar[1].exp, op: yyDollar[2].logicOp, right: yyDollar[3].exp}
 		}
-	case 130:
+	case 175:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.binExp = &CmpBoolExp{left: yyDollar[1].exp, op: yyDollar[2].cmpOp, right: yyDollar[3].exp}
 		}
-	case 131:
+	case 176:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.binExp = &CmpBoolExp{left: yyDollar[1].exp, op: EQ, right: &NullValue{t: AnyType}}
 		}
-	case 132:
+	case 177:
 		yyDollar = yyS[yypt-4 : yypt+1]
 		{
 			yyVAL.binExp = &CmpBoolExp{left: yyDollar[1].exp, op: NE, right: &NullValue{t: AnyType}}
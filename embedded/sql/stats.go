@@ -0,0 +1,703 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package sql
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"time"
+
+	"github.com/codenotary/immudb/embedded/store"
+)
+
+// minAutoAnalyzeRows is the number of rows to be modified, on top of the configured ratio of the analyzed ones,
+// before the statistics of a table are automatically refreshed
+const minAutoAnalyzeRows = 50
+
+// selectivity of the ranges over columns whose values can not be interpolated
+const defaultRangeSelectivity = 1.0 / 3
+
+// TableStats are the statistics the planner estimates the rows read by the queries with.
+// They're collected by ANALYZE and only kept for indexed columns.
+type TableStats struct {
+	rowCount int64
+	colStats map[uint32]*ColumnStats
+}
+
+type ColumnStats struct {
+	distinct int64
+	nulls    int64
+	min      TypedValue // nil when all the values are null
+	max      TypedValue
+}
+
+// Stats returns the statistics collected by the last analysis of the table, nil if it has never been analyzed
+func (t *Table) Stats() *TableStats {
+	return t.stats
+}
+
+func (s *TableStats) RowCount() int64 {
+	return s.rowCount
+}
+
+// ColumnStats returns the statistics of an indexed column
+func (s *TableStats) ColumnStats(col *Column) (*ColumnStats, bool) {
+	cs, ok := s.colStats[col.id]
+	return cs, ok
+}
+
+func (cs *ColumnStats) Distinct() int64 {
+	return cs.distinct
+}
+
+func (cs *ColumnStats) Nulls() int64 {
+	return cs.nulls
+}
+
+func (cs *ColumnStats) Min() TypedValue {
+	return cs.min
+}
+
+func (cs *ColumnStats) Max() TypedValue {
+	return cs.max
+}
+
+// estimateRows estimates the rows read when scanning the index over the given ranges,
+// assuming values are evenly distributed and columns independent of each other
+func (s *TableStats) estimateRows(index *Index, rangesByColID map[uint32]*typedValueRange) float64 {
+	rows := float64(s.rowCount)
+
+	for _, col := range index.cols {
+		colRange, ok := rangesByColID[col.id]
+		if !ok {
+			break
+		}
+
+		cs, ok := s.colStats[col.id]
+		if !ok {
+			break
+		}
+
+		if colRange.unitary() {
+			rows *= cs.equalitySelectivity(s.rowCount)
+			continue
+		}
+
+		// further columns do not narrow down the scanned range
+		rows *= cs.rangeSelectivity(colRange, s.rowCount)
+		break
+	}
+
+	return rows
+}
+
+func (cs *ColumnStats) equalitySelectivity(rowCount int64) float64 {
+	if cs.distinct == 0 || rowCount == 0 {
+		return 0
+	}
+
+	return float64(rowCount-cs.nulls) / float64(rowCount) / float64(cs.distinct)
+}
+
+func (cs *ColumnStats) rangeSelectivity(colRange *typedValueRange, rowCount int64) float64 {
+	if cs.min == nil || rowCount == 0 {
+		return 0
+	}
+
+	min, minOk := interpolable(cs.min)
+	max, maxOk := interpolable(cs.max)
+	if !minOk || !maxOk {
+		return defaultRangeSelectivity
+	}
+
+	lo, hi := min, max
+
+	if colRange.lRange != nil {
+		v, ok := interpolable(colRange.lRange.val)
+		if !ok {
+			return defaultRangeSelectivity
+		}
+
+		lo = math.Max(lo, v)
+	}
+
+	if colRange.hRange != nil {
+		v, ok := interpolable(colRange.hRange.val)
+		if !ok {
+			return defaultRangeSelectivity
+		}
+
+		hi = math.Min(hi, v)
+	}
+
+	if hi < lo {
+		return 0
+	}
+
+	nonNulls := float64(rowCount-cs.nulls) / float64(rowCount)
+
+	if max == min {
+		return nonNulls
+	}
+
+	// at least one distinct value is assumed to be in range
+	return nonNulls * math.Max((hi-lo)/(max-min), 1/float64(cs.distinct))
+}
+
+func interpolable(v TypedValue) (float64, bool) {
+	switch v.Type() {
+	case IntegerType:
+		return float64(v.Value().(int64)), true
+	case TimestampType:
+		return float64(TimeToInt64(v.Value().(time.Time))), true
+	}
+
+	return 0, false
+}
+
+// cheapestIndex returns the index the fewest rows are estimated to be read from,
+// the primary one is preferred when the estimations are the same
+func (t *Table) cheapestIndex(rangesByColID map[uint32]*typedValueRange) *Index {
+	cheapest := t.primaryIndex
+	minRows := t.stats.estimateRows(t.primaryIndex, rangesByColID)
+
+	for _, index := range t.indexes {
+		if index.IsPrimary() {
+			continue
+		}
+
+		rows := t.stats.estimateRows(index, rangesByColID)
+		if rows < minRows || (rows == minRows && cheapest != t.primaryIndex && index.id < cheapest.id) {
+			cheapest = index
+			minRows = rows
+		}
+	}
+
+	return cheapest
+}
+
+type AnalyzeTableStmt struct {
+	table string // all the tables of the database are analyzed when empty
+}
+
+func (stmt *AnalyzeTableStmt) inferParameters(tx *SQLTx, params map[string]SQLValueType) error {
+	return nil
+}
+
+func (stmt *AnalyzeTableStmt) execAt(tx *SQLTx, params map[string]interface{}) (*SQLTx, error) {
+	if tx.currentDB == nil {
+		return nil, ErrNoDatabaseSelected
+	}
+
+	tables := tx.currentDB.GetTables()
+
+	if stmt.table != "" {
+		table, err := tx.currentDB.GetTableByName(stmt.table)
+		if err != nil {
+			return nil, err
+		}
+
+		tables = []*Table{table}
+	}
+
+	for _, table := range tables {
+		err := tx.analyze(table)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return tx, nil
+}
+
+// analyze collects the statistics of the table reading all its rows,
+// the ones of the indexed columns are stored along with the catalog
+func (tx *SQLTx) analyze(table *Table) error {
+	stats := &TableStats{colStats: make(map[uint32]*ColumnStats)}
+
+	distinctValues := make(map[uint32]map[string]struct{})
+
+	for _, index := range table.indexes {
+		for _, col := range index.cols {
+			stats.colStats[col.id] = &ColumnStats{}
+			distinctValues[col.id] = make(map[string]struct{})
+		}
+	}
+
+	rowReader, err := newRawRowReader(tx, table, 0, table.name, &ScanSpecs{index: table.primaryIndex})
+	if err != nil {
+		return err
+	}
+	defer rowReader.Close()
+
+	for {
+		row, err := rowReader.Read()
+		if err == ErrNoMoreRows {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		stats.rowCount++
+
+		for colID, cs := range stats.colStats {
+			col := table.colsByID[colID]
+
+			v, ok := row.Values[EncodeSelector("", table.db.name, table.name, col.colName)]
+			if !ok || v.IsNull() {
+				cs.nulls++
+				continue
+			}
+
+			if cs.min == nil {
+				cs.min = v
+				cs.max = v
+			}
+
+			if cmp, err := v.Compare(cs.min); err == nil && cmp < 0 {
+				cs.min = v
+			}
+
+			if cmp, err := v.Compare(cs.max); err == nil && cmp > 0 {
+				cs.max = v
+			}
+
+			// distinct values are only counted up to the limit of DISTINCT clauses
+			if len(distinctValues[colID]) >= tx.distinctLimit() {
+				continue
+			}
+
+			encVal, err := EncodeValue(v.Value(), col.colType, 0)
+			if err != nil {
+				return err
+			}
+
+			distinctValues[colID][string(encVal)] = struct{}{}
+		}
+	}
+
+	for colID, cs := range stats.colStats {
+		cs.distinct = int64(len(distinctValues[colID]))
+	}
+
+	encStats, err := encodeTableStats(table, stats)
+	if err != nil {
+		return err
+	}
+
+	err = tx.set(mapKey(tx.sqlPrefix(), catalogStatsPrefix, EncodeID(table.db.id), EncodeID(table.id)), nil, encStats)
+	if err != nil {
+		return err
+	}
+
+	table.stats = stats
+
+	if tx.analyzedTables == nil {
+		tx.analyzedTables = make(map[tableID]struct{})
+	}
+
+	tx.analyzedTables[tableID{db: table.db.id, table: table.id}] = struct{}{}
+
+	return nil
+}
+
+// encodeTableStats encodes the statistics of the indexed columns as
+// {rowCount}{colCount}({colID}{distinct}{nulls}{hasValues}[{min}{max}])*
+func encodeTableStats(table *Table, stats *TableStats) ([]byte, error) {
+	var b bytes.Buffer
+
+	var n [8]byte
+
+	binary.BigEndian.PutUint64(n[:], uint64(stats.rowCount))
+	b.Write(n[:])
+
+	b.Write(EncodeID(uint32(len(stats.colStats))))
+
+	for _, col := range table.cols {
+		cs, ok := stats.colStats[col.id]
+		if !ok {
+			continue
+		}
+
+		b.Write(EncodeID(col.id))
+
+		binary.BigEndian.PutUint64(n[:], uint64(cs.distinct))
+		b.Write(n[:])
+
+		binary.BigEndian.PutUint64(n[:], uint64(cs.nulls))
+		b.Write(n[:])
+
+		if cs.min == nil {
+			b.WriteByte(0)
+			continue
+		}
+
+		b.WriteByte(1)
+
+		for _, v := range []TypedValue{cs.min, cs.max} {
+			encVal, err := EncodeValue(v.Value(), col.colType, 0)
+			if err != nil {
+				return nil, err
+			}
+
+			b.Write(encVal)
+		}
+	}
+
+	return b.Bytes(), nil
+}
+
+func decodeTableStats(table *Table, b []byte) (*TableStats, error) {
+	if len(b) < 8+EncIDLen {
+		return nil, ErrCorruptedData
+	}
+
+	stats := &TableStats{
+		rowCount: int64(binary.BigEndian.Uint64(b)),
+		colStats: make(map[uint32]*ColumnStats),
+	}
+
+	colCount := binary.BigEndian.Uint32(b[8:])
+	off := 8 + EncIDLen
+
+	for i := 0; i < int(colCount); i++ {
+		if len(b) < off+EncIDLen+8+8+1 {
+			return nil, ErrCorruptedData
+		}
+
+		col, err := table.GetColumnByID(binary.BigEndian.Uint32(b[off:]))
+		if err != nil {
+			return nil, ErrCorruptedData
+		}
+		off += EncIDLen
+
+		cs := &ColumnStats{
+			distinct: int64(binary.BigEndian.Uint64(b[off:])),
+			nulls:    int64(binary.BigEndian.Uint64(b[off+8:])),
+		}
+		off += 16
+
+		hasValues := b[off] == 1
+		off++
+
+		if hasValues {
+			min, n, err := DecodeValue(b[off:], col.colType)
+			if err != nil {
+				return nil, err
+			}
+			off += n
+
+			max, n, err := DecodeValue(b[off:], col.colType)
+			if err != nil {
+				return nil, err
+			}
+			off += n
+
+			cs.min = min
+			cs.max = max
+		}
+
+		stats.colStats[col.id] = cs
+	}
+
+	if off != len(b) {
+		return nil, ErrCorruptedData
+	}
+
+	return stats, nil
+}
+
+func (table *Table) loadStats(sqlPrefix []byte, tx *store.OngoingTx) error {
+	vref, err := tx.Get(mapKey(sqlPrefix, catalogStatsPrefix, EncodeID(table.db.id), EncodeID(table.id)))
+	if err == store.ErrKeyNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	v, err := vref.Resolve()
+	if err != nil {
+		return err
+	}
+
+	stats, err := decodeTableStats(table, v)
+	if err != nil {
+		return err
+	}
+
+	table.stats = stats
+
+	return nil
+}
+
+type tableID struct {
+	db    uint32
+	table uint32
+}
+
+func (tx *SQLTx) trackModifiedRow(table *Table) {
+	if tx.modifiedRows == nil {
+		tx.modifiedRows = make(map[tableID]int64)
+	}
+
+	tx.modifiedRows[tableID{db: table.db.id, table: table.id}]++
+}
+
+// trackCommittedTx accumulates the rows modified by a committed tx,
+// the tables analyzed before and modified enough since then are analyzed again
+func (e *Engine) trackCommittedTx(tx *SQLTx) {
+	if e.autoAnalyzeRatio <= 0 || (len(tx.modifiedRows) == 0 && len(tx.analyzedTables) == 0) {
+		return
+	}
+
+	var outdated []tableID
+
+	e.statsMutex.Lock()
+
+	for id := range tx.analyzedTables {
+		delete(e.modifiedRows, id)
+	}
+
+	for id, n := range tx.modifiedRows {
+		if _, analyzed := tx.analyzedTables[id]; analyzed {
+			continue
+		}
+
+		db, err := tx.catalog.GetDatabaseByID(id.db)
+		if err != nil {
+			continue
+		}
+
+		table, err := db.GetTableByID(id.table)
+		if err != nil || table.stats == nil {
+			continue
+		}
+
+		e.modifiedRows[id] += n
+
+		if float64(e.modifiedRows[id]) >= minAutoAnalyzeRows+e.autoAnalyzeRatio*float64(table.stats.rowCount) {
+			outdated = append(outdated, id)
+			delete(e.modifiedRows, id)
+		}
+	}
+
+	e.statsMutex.Unlock()
+
+	for _, id := range outdated {
+		// statistics are just hints for the planner, they'll be refreshed on further modifications if this fails
+		e.autoAnalyze(id)
+	}
+}
+
+func (e *Engine) autoAnalyze(id tableID) error {
+	tx, err := e.newTx(false)
+	if err != nil {
+		return err
+	}
+	defer tx.Cancel()
+
+	db, err := tx.catalog.GetDatabaseByID(id.db)
+	if err != nil {
+		return err
+	}
+
+	table, err := db.GetTableByID(id.table)
+	if err != nil {
+		return err
+	}
+
+	err = tx.analyze(table)
+	if err != nil {
+		return err
+	}
+
+	return tx.commit()
+}
+
+// reorderedJoin returns an equivalent statement reading first from the joint table, when it's estimated to read fewer rows.
+// Only single inner joins between analyzed tables are reordered, as long as the order of the resulting rows is not relevant
+// and the selected columns are qualified, so they're not resolved differently once the tables are swapped.
+func (stmt *SelectStmt) reorderedJoin(tx *SQLTx, params map[string]interface{}) (*SelectStmt, error) {
+	if len(stmt.joins) != 1 || len(stmt.indexOn) > 0 || len(stmt.orderBy) > 0 || len(stmt.groupBy) > 0 || len(stmt.selectors) == 0 {
+		return nil, nil
+	}
+
+	join := stmt.joins[0]
+	if join.joinType != InnerJoin || len(join.indexOn) > 0 {
+		return nil, nil
+	}
+
+	for _, sel := range stmt.selectors {
+		colSel, ok := sel.(*ColSelector)
+		if !ok || colSel.table == "" {
+			return nil, nil
+		}
+	}
+
+	outer, ok := stmt.ds.(*tableRef)
+	if !ok {
+		return nil, nil
+	}
+
+	inner, ok := join.ds.(*tableRef)
+	if !ok {
+		return nil, nil
+	}
+
+	outerTable, err := outer.referencedTable(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	innerTable, err := inner.referencedTable(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	if outerTable.stats == nil || innerTable.stats == nil || outer.Alias() == inner.Alias() {
+		return nil, nil
+	}
+
+	// conditions are only resolved the same way in both orders when all their columns are qualified
+	cols := make(map[string]ColDescriptor)
+
+	for _, t := range []struct {
+		table *Table
+		alias string
+	}{{outerTable, outer.Alias()}, {innerTable, inner.Alias()}} {
+		for _, col := range t.table.cols {
+			desc := ColDescriptor{Database: t.table.db.name, Table: t.alias, Column: col.colName, Type: col.colType}
+			cols[desc.Selector()] = desc
+		}
+	}
+
+	for _, exp := range []ValueExp{stmt.where, join.cond} {
+		if exp == nil {
+			continue
+		}
+
+		_, err := exp.inferType(cols, make(map[string]SQLValueType), outerTable.db.name, "")
+		if err != nil {
+			return nil, nil
+		}
+	}
+
+	cost, err := stmt.joinCost(outerTable, outer.Alias(), innerTable, inner.Alias(), join.cond, params)
+	if err != nil {
+		return nil, err
+	}
+
+	swappedCost, err := stmt.joinCost(innerTable, inner.Alias(), outerTable, outer.Alias(), join.cond, params)
+	if err != nil {
+		return nil, err
+	}
+
+	if swappedCost >= cost {
+		return nil, nil
+	}
+
+	reordered := *stmt
+	reordered.ds = inner
+	reordered.joins = []*JoinSpec{{joinType: InnerJoin, ds: outer, cond: join.cond}}
+
+	return &reordered, nil
+}
+
+// joinCost estimates the rows read when scanning the outer table and looking up the inner one for each of its rows
+func (stmt *SelectStmt) joinCost(outerTable *Table, outerAlias string, innerTable *Table, innerAlias string, cond ValueExp, params map[string]interface{}) (float64, error) {
+	outerRanges := make(map[uint32]*typedValueRange)
+
+	if stmt.where != nil {
+		err := stmt.where.selectorRanges(outerTable, outerAlias, params, outerRanges)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	outerRows := outerTable.stats.estimateRows(outerTable.cheapestIndex(outerRanges), outerRanges)
+
+	innerRanges := make(map[uint32]*typedValueRange)
+
+	err := cond.selectorRanges(innerTable, innerAlias, params, innerRanges)
+	if err != nil {
+		return 0, err
+	}
+
+	// columns matching the ones of the outer rows are looked up by a single value
+	for _, col := range joinedColumns(cond, innerTable, innerAlias, outerAlias) {
+		innerRanges[col.id] = &typedValueRange{
+			lRange: &typedValueSemiRange{val: &Number{}, inclusive: true},
+			hRange: &typedValueSemiRange{val: &Number{}, inclusive: true},
+		}
+	}
+
+	lookupRows := innerTable.stats.estimateRows(innerTable.cheapestIndex(innerRanges), innerRanges)
+
+	// each lookup costs at least one read, even when no row is found
+	return outerRows * (1 + lookupRows), nil
+}
+
+// joinedColumns returns the columns of the table compared for equality with columns of the joint one
+func joinedColumns(cond ValueExp, table *Table, alias, jointAlias string) []*Column {
+	switch exp := cond.(type) {
+	case *BinBoolExp:
+		if exp.op != AND {
+			return nil
+		}
+
+		return append(joinedColumns(exp.left, table, alias, jointAlias), joinedColumns(exp.right, table, alias, jointAlias)...)
+	case *CmpBoolExp:
+		if exp.op != EQ {
+			return nil
+		}
+
+		left, lok := exp.left.(*ColSelector)
+		right, rok := exp.right.(*ColSelector)
+		if !lok || !rok {
+			return nil
+		}
+
+		if right.table == alias {
+			left, right = right, left
+		}
+
+		if left.table != alias || right.table != jointAlias {
+			return nil
+		}
+
+		col, err := table.GetColumnByName(left.col)
+		if err != nil {
+			return nil
+		}
+
+		return []*Column{col}
+	}
+
+	return nil
+}
+
+// aliasedRowReader keeps the alias of a statement whose data sources were reordered
+type aliasedRowReader struct {
+	RowReader
+	alias string
+}
+
+func (ar *aliasedRowReader) TableAlias() string {
+	return ar.alias
+}
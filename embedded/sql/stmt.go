@@ -19,6 +19,7 @@ package sql
 import (
 	"bytes"
 	"encoding/binary"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"regexp"
@@ -33,6 +34,12 @@ const (
 	catalogTablePrefix    = "CTL.TABLE."    // (key=CTL.TABLE.{dbID}{tableID}, value={tableNAME})
 	catalogColumnPrefix   = "CTL.COLUMN."   // (key=CTL.COLUMN.{dbID}{tableID}{colID}{colTYPE}, value={(auto_incremental | nullable){maxLen}{colNAME}})
 	catalogIndexPrefix    = "CTL.INDEX."    // (key=CTL.INDEX.{dbID}{tableID}{indexID}, value={unique {colID1}(ASC|DESC)...{colIDN}(ASC|DESC)})
+	catalogPolicyPrefix   = "CTL.POLICY."   // (key=CTL.POLICY.{dbID}{tableID}{policyNAME}, value={CREATE POLICY statement})
+	catalogDefaultPrefix  = "CTL.DEFAULT."  // (key=CTL.DEFAULT.{dbID}{tableID}{colID}, value={default value expression})
+	catalogCheckPrefix    = "CTL.CHECK."    // (key=CTL.CHECK.{dbID}{tableID}{checkID}, value={check expression})
+	catalogStatsPrefix    = "CTL.STATS."    // (key=CTL.STATS.{dbID}{tableID}, value={rowCount {colCount} ({colID}{distinct}{nulls}{hasValues}({min}{max})?)*})
+	catalogViewPrefix     = "CTL.VIEW."     // (key=CTL.VIEW.{dbID}{viewNAME}, value={CREATE VIEW statement})
+	catalogProcPrefix     = "CTL.PROC."     // (key=CTL.PROC.{dbID}{procNAME}, value={CREATE PROCEDURE statement})
 	PIndexPrefix          = "R."            // (key=R.{dbID}{tableID}{0}({null}({pkVal}{padding}{pkValLen})?)+, value={count (colID valLen val)+})
 	SIndexPrefix          = "E."            // (key=E.{dbID}{tableID}{indexID}({null}({val}{padding}{valLen})?)+({pkVal}{padding}{pkValLen})+, value={})
 	UIndexPrefix          = "N."            // (key=N.{dbID}{tableID}{indexID}({null}({val}{padding}{valLen})?)+, value={({pkVal}{padding}{pkValLen})+})
@@ -173,6 +180,51 @@ func (stmt *RollbackStmt) execAt(tx *SQLTx, params map[string]interface{}) (*SQL
 	return nil, tx.Cancel()
 }
 
+type SavepointStmt struct {
+	name string
+}
+
+func (stmt *SavepointStmt) inferParameters(tx *SQLTx, params map[string]SQLValueType) error {
+	return nil
+}
+
+func (stmt *SavepointStmt) execAt(tx *SQLTx, params map[string]interface{}) (*SQLTx, error) {
+	return tx, tx.newSavepoint(stmt.name)
+}
+
+type RollbackToSavepointStmt struct {
+	name string
+}
+
+func (stmt *RollbackToSavepointStmt) inferParameters(tx *SQLTx, params map[string]SQLValueType) error {
+	return nil
+}
+
+func (stmt *RollbackToSavepointStmt) execAt(tx *SQLTx, params map[string]interface{}) (*SQLTx, error) {
+	return tx, tx.rollbackToSavepoint(stmt.name)
+}
+
+type ReleaseSavepointStmt struct {
+	name string
+}
+
+func (stmt *ReleaseSavepointStmt) inferParameters(tx *SQLTx, params map[string]SQLValueType) error {
+	return nil
+}
+
+func (stmt *ReleaseSavepointStmt) execAt(tx *SQLTx, params map[string]interface{}) (*SQLTx, error) {
+	return tx, tx.releaseSavepoint(stmt.name)
+}
+
+// isTxControlStmt returns whether stmt is accepted on aborted transactions
+func isTxControlStmt(stmt SQLStmt) bool {
+	switch stmt.(type) {
+	case *CommitStmt, *RollbackStmt, *RollbackToSavepointStmt:
+		return true
+	}
+	return false
+}
+
 type CreateDatabaseStmt struct {
 	DB string
 }
@@ -227,6 +279,7 @@ type CreateTableStmt struct {
 	ifNotExists bool
 	colsSpec    []*ColSpec
 	pkColNames  []string
+	checks      []ValueExp
 }
 
 func (stmt *CreateTableStmt) inferParameters(tx *SQLTx, params map[string]SQLValueType) error {
@@ -288,6 +341,34 @@ func (stmt *CreateTableStmt) execAt(tx *SQLTx, params map[string]interface{}) (*
 		}
 	}
 
+	for i, cs := range stmt.colsSpec {
+		if cs.defaultValue != nil {
+			err = table.setDefaultValue(table.cols[i], cs.defaultValue)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if cs.check != nil {
+			err = table.newCheck(cs.check)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for _, check := range stmt.checks {
+		err = table.newCheck(check)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = tx.persistConstraints(table)
+	if err != nil {
+		return nil, err
+	}
+
 	mappedKey := mapKey(tx.sqlPrefix(), catalogTablePrefix, EncodeID(tx.currentDB.id), EncodeID(table.id))
 
 	err = tx.set(mappedKey, nil, []byte(table.name))
@@ -304,6 +385,8 @@ type ColSpec struct {
 	maxLen        int
 	autoIncrement bool
 	notNull       bool
+	defaultValue  ValueExp
+	check         ValueExp
 }
 
 type CreateIndexStmt struct {
@@ -419,9 +502,16 @@ type RowSpec struct {
 	Values []ValueExp
 }
 
+// OnConflictDo specifies how rows conflicting with existing ones are inserted,
+// they are skipped unless updates are specified
 type OnConflictDo struct {
+	updates []*colUpdate
 }
 
+// excludedTable is the name used to reference the values proposed for insertion from the updates
+// of conflicting rows e.g. ON CONFLICT DO UPDATE SET qty = EXCLUDED.qty
+const excludedTable = "excluded"
+
 func (stmt *UpsertIntoStmt) inferParameters(tx *SQLTx, params map[string]SQLValueType) error {
 	if tx.currentDB == nil {
 		return ErrNoDatabaseSelected
@@ -450,9 +540,108 @@ func (stmt *UpsertIntoStmt) inferParameters(tx *SQLTx, params map[string]SQLValu
 		}
 	}
 
+	if stmt.onConflict == nil {
+		return nil
+	}
+
+	table, err := stmt.tableRef.referencedTable(tx)
+	if err != nil {
+		return err
+	}
+
+	cols := stmt.onConflict.colDescriptors(table)
+
+	for _, update := range stmt.onConflict.updates {
+		col, err := table.GetColumnByName(update.col)
+		if err != nil {
+			return err
+		}
+
+		err = update.val.requiresType(col.colType, cols, params, table.db.name, table.name)
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
+// colDescriptors returns the descriptors of the columns of the conflicting row and of the row proposed for insertion
+func (oc *OnConflictDo) colDescriptors(table *Table) map[string]ColDescriptor {
+	cols := table.colDescriptors()
+
+	for _, c := range table.cols {
+		colDescriptor := ColDescriptor{
+			Database: table.db.name,
+			Table:    excludedTable,
+			Column:   c.colName,
+			Type:     c.colType,
+		}
+
+		cols[colDescriptor.Selector()] = colDescriptor
+	}
+
+	return cols
+}
+
+// updatedValues returns the values of the conflicting row once updated,
+// values proposed for insertion are referenced as EXCLUDED.col
+func (oc *OnConflictDo) updatedValues(tx *SQLTx, table *Table, proposedValuesByColID map[uint32]TypedValue, params map[string]interface{}) (map[uint32]TypedValue, error) {
+	currRow, err := tx.fetchPKRow(table, proposedValuesByColID)
+	if err != nil {
+		return nil, err
+	}
+
+	row := &Row{Values: make(map[string]TypedValue, 2*len(table.cols))}
+	valuesByColID := make(map[uint32]TypedValue, len(table.cols))
+
+	for _, col := range table.cols {
+		encSel := EncodeSelector("", table.db.name, table.name, col.colName)
+
+		row.Values[encSel] = currRow.Values[encSel]
+		valuesByColID[col.id] = currRow.Values[encSel]
+
+		proposedVal, ok := proposedValuesByColID[col.id]
+		if !ok {
+			proposedVal = &NullValue{t: col.colType}
+		}
+
+		row.Values[EncodeSelector("", table.db.name, excludedTable, col.colName)] = proposedVal
+	}
+
+	cols := oc.colDescriptors(table)
+
+	for _, update := range oc.updates {
+		col, err := table.GetColumnByName(update.col)
+		if err != nil {
+			return nil, err
+		}
+
+		sval, err := update.val.substitute(params)
+		if err != nil {
+			return nil, err
+		}
+
+		rval, err := sval.reduce(tx.catalog, row, table.db.name, table.name)
+		if err != nil {
+			return nil, err
+		}
+
+		err = rval.requiresType(col.colType, cols, nil, table.db.name, table.name)
+		if err != nil {
+			return nil, err
+		}
+
+		if rval.IsNull() && col.notNull {
+			return nil, fmt.Errorf("%w (%s)", ErrNotNullableColumnCannotBeNull, col.colName)
+		}
+
+		valuesByColID[col.id] = rval
+	}
+
+	return valuesByColID, nil
+}
+
 func (stmt *UpsertIntoStmt) validate(table *Table) (map[uint32]int, error) {
 	selPosByColID := make(map[uint32]int, len(stmt.cols))
 
@@ -473,6 +662,67 @@ func (stmt *UpsertIntoStmt) validate(table *Table) (map[uint32]int, error) {
 	return selPosByColID, nil
 }
 
+func (stmt *UpsertIntoStmt) String() string {
+	var sb strings.Builder
+
+	if stmt.isInsert {
+		sb.WriteString("INSERT INTO ")
+	} else {
+		sb.WriteString("UPSERT INTO ")
+	}
+
+	sb.WriteString(stmt.tableRef.String() + "(" + strings.Join(stmt.cols, ", ") + ") VALUES ")
+
+	for i, row := range stmt.rows {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+
+		sb.WriteString(valuesString(row.Values))
+	}
+
+	if stmt.onConflict != nil {
+		sb.WriteString(" ON CONFLICT DO ")
+
+		if len(stmt.onConflict.updates) == 0 {
+			sb.WriteString("NOTHING")
+		} else {
+			sb.WriteString("UPDATE SET " + colUpdatesString(stmt.onConflict.updates))
+		}
+	}
+
+	return sb.String()
+}
+
+func colUpdatesString(updates []*colUpdate) string {
+	strs := make([]string, len(updates))
+
+	for i, update := range updates {
+		strs[i] = update.col + " " + cmpOperatorString(update.op) + " " + update.val.String()
+	}
+
+	return strings.Join(strs, ", ")
+}
+
+// filterClausesString renders the WHERE, USE INDEX ON and LIMIT clauses shared by UPDATE and DELETE statements
+func filterClausesString(where ValueExp, indexOn []string, limit int) string {
+	var sb strings.Builder
+
+	if where != nil {
+		sb.WriteString(" WHERE " + where.String())
+	}
+
+	if len(indexOn) > 0 {
+		sb.WriteString(" USE INDEX ON (" + strings.Join(indexOn, ", ") + ")")
+	}
+
+	if limit > 0 {
+		sb.WriteString(fmt.Sprintf(" LIMIT %d", limit))
+	}
+
+	return sb.String()
+}
+
 func (stmt *UpsertIntoStmt) execAt(tx *SQLTx, params map[string]interface{}) (*SQLTx, error) {
 	if tx.currentDB == nil {
 		return nil, ErrNoDatabaseSelected
@@ -488,6 +738,13 @@ func (stmt *UpsertIntoStmt) execAt(tx *SQLTx, params map[string]interface{}) (*S
 		return nil, err
 	}
 
+	if stmt.onConflict != nil {
+		err = validateColUpdates(table, stmt.onConflict.updates)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	for _, row := range stmt.rows {
 		if len(row.Values) != len(stmt.cols) {
 			return nil, ErrInvalidNumberOfValues
@@ -499,14 +756,28 @@ func (stmt *UpsertIntoStmt) execAt(tx *SQLTx, params map[string]interface{}) (*S
 
 		for colID, col := range table.colsByID {
 			colPos, specified := selPosByColID[colID]
-			if !specified {
-				// TODO: Default values
-				if col.notNull && !col.autoIncrement {
-					return nil, fmt.Errorf("%w (%s)", ErrNotNullableColumnCannotBeNull, col.colName)
+			if !specified && !col.autoIncrement {
+				rval, err := tx.defaultValueFor(col)
+				if err != nil {
+					return nil, err
+				}
+
+				if rval.IsNull() {
+					if col.notNull {
+						return nil, fmt.Errorf("%w (%s)", ErrNotNullableColumnCannotBeNull, col.colName)
+					}
+
+					continue
 				}
 
+				valuesByColID[colID] = rval
+
+				continue
+			}
+
+			if !specified {
 				// inject auto-incremental pk value
-				if stmt.isInsert && table.autoIncrementPK {
+				if stmt.isInsert {
 					// current implementation assumes only PK can be set as autoincremental
 					table.maxPK++
 
@@ -583,9 +854,36 @@ func (stmt *UpsertIntoStmt) execAt(tx *SQLTx, params map[string]interface{}) (*S
 				return nil, store.ErrKeyAlreadyExists
 			}
 
-			if err == nil && stmt.onConflict != nil {
-				// TODO: conflict resolution may be extended. Currently only supports "ON CONFLICT DO NOTHING"
-				return tx, nil
+			if err == nil && len(stmt.onConflict.updates) == 0 {
+				// ON CONFLICT DO NOTHING
+				continue
+			}
+
+			if err == nil {
+				// ON CONFLICT DO UPDATE
+				valuesByColID, err = stmt.onConflict.updatedValues(tx, table, valuesByColID, params)
+				if err != nil {
+					return nil, err
+				}
+
+				// the row must not be deleted by other transactions before this one is committed
+				err = tx.addPrecondition(mkey, true)
+				if err != nil {
+					return nil, err
+				}
+
+				err = tx.doUpsert(pkEncVals, valuesByColID, table, true)
+				if err != nil {
+					return nil, err
+				}
+
+				continue
+			}
+
+			// the row must not be inserted by other transactions before this one is committed
+			err = tx.addPrecondition(mkey, false)
+			if err != nil {
+				return nil, err
 			}
 		}
 
@@ -599,6 +897,11 @@ func (stmt *UpsertIntoStmt) execAt(tx *SQLTx, params map[string]interface{}) (*S
 }
 
 func (tx *SQLTx) doUpsert(pkEncVals []byte, valuesByColID map[uint32]TypedValue, table *Table, reuseIndex bool) error {
+	err := tx.validateChecks(table, valuesByColID)
+	if err != nil {
+		return err
+	}
+
 	var reusableIndexEntries map[uint32]struct{}
 
 	if reuseIndex && len(table.indexes) > 1 {
@@ -638,7 +941,7 @@ func (tx *SQLTx) doUpsert(pkEncVals []byte, valuesByColID map[uint32]TypedValue,
 	b := make([]byte, EncLenLen)
 	binary.BigEndian.PutUint32(b, uint32(encodedVals))
 
-	_, err := valbuf.Write(b)
+	_, err = valbuf.Write(b)
 	if err != nil {
 		return err
 	}
@@ -738,6 +1041,7 @@ func (tx *SQLTx) doUpsert(pkEncVals []byte, valuesByColID map[uint32]TypedValue,
 	}
 
 	tx.updatedRows++
+	tx.trackModifiedRow(table)
 
 	return nil
 }
@@ -901,13 +1205,15 @@ func (stmt *UpdateStmt) inferParameters(tx *SQLTx, params map[string]SQLValueTyp
 		return err
 	}
 
+	cols := table.colDescriptors()
+
 	for _, update := range stmt.updates {
 		col, err := table.GetColumnByName(update.col)
 		if err != nil {
 			return err
 		}
 
-		err = update.val.requiresType(col.colType, make(map[string]ColDescriptor), params, tx.currentDB.name, table.name)
+		err = update.val.requiresType(col.colType, cols, params, tx.currentDB.name, table.name)
 		if err != nil {
 			return err
 		}
@@ -917,9 +1223,13 @@ func (stmt *UpdateStmt) inferParameters(tx *SQLTx, params map[string]SQLValueTyp
 }
 
 func (stmt *UpdateStmt) validate(table *Table) error {
-	colIDs := make(map[uint32]struct{}, len(stmt.updates))
+	return validateColUpdates(table, stmt.updates)
+}
 
-	for _, update := range stmt.updates {
+func validateColUpdates(table *Table, updates []*colUpdate) error {
+	colIDs := make(map[uint32]struct{}, len(updates))
+
+	for _, update := range updates {
 		if update.op != EQ {
 			return ErrIllegalArguments
 		}
@@ -944,6 +1254,11 @@ func (stmt *UpdateStmt) validate(table *Table) error {
 	return nil
 }
 
+func (stmt *UpdateStmt) String() string {
+	return "UPDATE " + stmt.tableRef.String() + " SET " + colUpdatesString(stmt.updates) +
+		filterClausesString(stmt.where, stmt.indexOn, stmt.limit)
+}
+
 func (stmt *UpdateStmt) execAt(tx *SQLTx, params map[string]interface{}) (*SQLTx, error) {
 	if tx.currentDB == nil {
 		return nil, ErrNoDatabaseSelected
@@ -1049,6 +1364,10 @@ func (stmt *DeleteFromStmt) inferParameters(tx *SQLTx, params map[string]SQLValu
 	return selectStmt.inferParameters(tx, params)
 }
 
+func (stmt *DeleteFromStmt) String() string {
+	return "DELETE FROM " + stmt.tableRef.String() + filterClausesString(stmt.where, stmt.indexOn, stmt.limit)
+}
+
 func (stmt *DeleteFromStmt) execAt(tx *SQLTx, params map[string]interface{}) (*SQLTx, error) {
 	if tx.currentDB == nil {
 		return nil, ErrNoDatabaseSelected
@@ -1096,6 +1415,7 @@ func (stmt *DeleteFromStmt) execAt(tx *SQLTx, params map[string]interface{}) (*S
 		}
 
 		tx.updatedRows++
+		tx.trackModifiedRow(table)
 	}
 
 	return tx, nil
@@ -1156,6 +1476,7 @@ type ValueExp interface {
 	reduceSelectors(row *Row, implicitDB, implicitTable string) ValueExp
 	isConstant() bool
 	selectorRanges(table *Table, asTable string, params map[string]interface{}, rangesByColID map[uint32]*typedValueRange) error
+	String() string
 }
 
 type typedValueRange struct {
@@ -1182,7 +1503,7 @@ func (r *typedValueRange) refineWith(refiningRange *typedValueRange) error {
 	if r.lRange == nil {
 		r.lRange = refiningRange.lRange
 	} else if r.lRange != nil && refiningRange.lRange != nil {
-		maxRange, err := maxSemiRange(r.lRange, refiningRange.lRange)
+		maxRange, err := maxSemiRange(r.lRange, refiningRange.lRange, true)
 		if err != nil {
 			return err
 		}
@@ -1192,7 +1513,7 @@ func (r *typedValueRange) refineWith(refiningRange *typedValueRange) error {
 	if r.hRange == nil {
 		r.hRange = refiningRange.hRange
 	} else if r.hRange != nil && refiningRange.hRange != nil {
-		minRange, err := minSemiRange(r.hRange, refiningRange.hRange)
+		minRange, err := minSemiRange(r.hRange, refiningRange.hRange, true)
 		if err != nil {
 			return err
 		}
@@ -1206,7 +1527,7 @@ func (r *typedValueRange) extendWith(extendingRange *typedValueRange) error {
 	if r.lRange == nil || extendingRange.lRange == nil {
 		r.lRange = nil
 	} else {
-		minRange, err := minSemiRange(r.lRange, extendingRange.lRange)
+		minRange, err := minSemiRange(r.lRange, extendingRange.lRange, false)
 		if err != nil {
 			return err
 		}
@@ -1216,7 +1537,7 @@ func (r *typedValueRange) extendWith(extendingRange *typedValueRange) error {
 	if r.hRange == nil || extendingRange.hRange == nil {
 		r.hRange = nil
 	} else {
-		maxRange, err := maxSemiRange(r.hRange, extendingRange.hRange)
+		maxRange, err := maxSemiRange(r.hRange, extendingRange.hRange, false)
 		if err != nil {
 			return err
 		}
@@ -1226,38 +1547,46 @@ func (r *typedValueRange) extendWith(extendingRange *typedValueRange) error {
 	return nil
 }
 
-func maxSemiRange(or1, or2 *typedValueSemiRange) (*typedValueSemiRange, error) {
+// maxSemiRange returns the semi range bounded by the greatest value, when both are bounded by the same value
+// it's inclusive if both are (intersecting) or any of them is (extending)
+func maxSemiRange(or1, or2 *typedValueSemiRange, intersecting bool) (*typedValueSemiRange, error) {
 	r, err := or1.val.Compare(or2.val)
 	if err != nil {
 		return nil, err
 	}
 
-	maxVal := or1.val
-	if r < 0 {
-		maxVal = or2.val
-	}
-
-	return &typedValueSemiRange{
-		val:       maxVal,
-		inclusive: or1.inclusive && or2.inclusive,
-	}, nil
+	return chooseSemiRange(or1, or2, r >= 0, r == 0, intersecting), nil
 }
 
-func minSemiRange(or1, or2 *typedValueSemiRange) (*typedValueSemiRange, error) {
+// minSemiRange returns the semi range bounded by the lowest value, when both are bounded by the same value
+// it's inclusive if both are (intersecting) or any of them is (extending)
+func minSemiRange(or1, or2 *typedValueSemiRange, intersecting bool) (*typedValueSemiRange, error) {
 	r, err := or1.val.Compare(or2.val)
 	if err != nil {
 		return nil, err
 	}
 
-	minVal := or1.val
-	if r > 0 {
-		minVal = or2.val
+	return chooseSemiRange(or1, or2, r <= 0, r == 0, intersecting), nil
+}
+
+func chooseSemiRange(or1, or2 *typedValueSemiRange, first, equal, intersecting bool) *typedValueSemiRange {
+	if !equal {
+		if first {
+			return or1
+		}
+
+		return or2
+	}
+
+	inclusive := or1.inclusive || or2.inclusive
+	if intersecting {
+		inclusive = or1.inclusive && or2.inclusive
 	}
 
 	return &typedValueSemiRange{
-		val:       minVal,
-		inclusive: or1.inclusive || or2.inclusive,
-	}, nil
+		val:       or1.val,
+		inclusive: inclusive,
+	}
 }
 
 type TypedValue interface {
@@ -1334,6 +1663,10 @@ func (v *NullValue) selectorRanges(table *Table, asTable string, params map[stri
 	return nil
 }
 
+func (v *NullValue) String() string {
+	return "NULL"
+}
+
 type Number struct {
 	val int64
 }
@@ -1378,6 +1711,10 @@ func (v *Number) selectorRanges(table *Table, asTable string, params map[string]
 	return nil
 }
 
+func (v *Number) String() string {
+	return fmt.Sprintf("%d", v.val)
+}
+
 func (v *Number) Value() interface{} {
 	return v.val
 }
@@ -1448,6 +1785,10 @@ func (v *Timestamp) selectorRanges(table *Table, asTable string, params map[stri
 	return nil
 }
 
+func (v *Timestamp) String() string {
+	return fmt.Sprintf("CAST('%s' AS %s)", v.val.Format("2006-01-02 15:04:05.999999"), TimestampType)
+}
+
 func (v *Timestamp) Value() interface{} {
 	return v.val
 }
@@ -1518,6 +1859,10 @@ func (v *Varchar) selectorRanges(table *Table, asTable string, params map[string
 	return nil
 }
 
+func (v *Varchar) String() string {
+	return "'" + strings.ReplaceAll(v.val, "'", "''") + "'"
+}
+
 func (v *Varchar) Value() interface{} {
 	return v.val
 }
@@ -1580,6 +1925,14 @@ func (v *Bool) selectorRanges(table *Table, asTable string, params map[string]in
 	return nil
 }
 
+func (v *Bool) String() string {
+	if v.val {
+		return "TRUE"
+	}
+
+	return "FALSE"
+}
+
 func (v *Bool) Value() interface{} {
 	return v.val
 }
@@ -1650,6 +2003,10 @@ func (v *Blob) selectorRanges(table *Table, asTable string, params map[string]in
 	return nil
 }
 
+func (v *Blob) String() string {
+	return "x'" + hex.EncodeToString(v.val) + "'"
+}
+
 func (v *Blob) Value() interface{} {
 	return v.val
 }
@@ -1668,54 +2025,6 @@ func (v *Blob) Compare(val TypedValue) (int, error) {
 	return bytes.Compare(v.val, rval), nil
 }
 
-type SysFn struct {
-	fn string
-}
-
-func (v *SysFn) inferType(cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) (SQLValueType, error) {
-	if strings.ToUpper(v.fn) == "NOW" {
-		return TimestampType, nil
-	}
-
-	return AnyType, fmt.Errorf("%w: unkown function %s", ErrIllegalArguments, v.fn)
-}
-
-func (v *SysFn) requiresType(t SQLValueType, cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) error {
-	if strings.ToUpper(v.fn) == "NOW" {
-		if t != TimestampType {
-			return fmt.Errorf("%w: %v can not be interpreted as type %v", ErrInvalidTypes, TimestampType, t)
-		}
-
-		return nil
-	}
-
-	return fmt.Errorf("%w: unkown function %s", ErrIllegalArguments, v.fn)
-}
-
-func (v *SysFn) substitute(params map[string]interface{}) (ValueExp, error) {
-	return v, nil
-}
-
-func (v *SysFn) reduce(catalog *Catalog, row *Row, implicitDB, implicitTable string) (TypedValue, error) {
-	if strings.ToUpper(v.fn) == "NOW" {
-		return &Timestamp{val: time.Now().UTC()}, nil
-	}
-
-	return nil, fmt.Errorf("%w: unkown function %s", ErrIllegalArguments, v.fn)
-}
-
-func (v *SysFn) reduceSelectors(row *Row, implicitDB, implicitTable string) ValueExp {
-	return v
-}
-
-func (v *SysFn) isConstant() bool {
-	return false
-}
-
-func (v *SysFn) selectorRanges(table *Table, asTable string, params map[string]interface{}, rangesByColID map[uint32]*typedValueRange) error {
-	return nil
-}
-
 type Cast struct {
 	val ValueExp
 	t   SQLValueType
@@ -1843,6 +2152,10 @@ func (c *Cast) selectorRanges(table *Table, asTable string, params map[string]in
 	return nil
 }
 
+func (c *Cast) String() string {
+	return fmt.Sprintf("CAST(%s AS %s)", c.val.String(), c.t)
+}
+
 type Param struct {
 	id  string
 	pos int
@@ -1880,6 +2193,10 @@ func (p *Param) substitute(params map[string]interface{}) (ValueExp, error) {
 	}
 
 	switch v := val.(type) {
+	case TypedValue:
+		{
+			return v, nil
+		}
 	case bool:
 		{
 			return &Bool{val: v}, nil
@@ -1933,6 +2250,14 @@ func (v *Param) selectorRanges(table *Table, asTable string, params map[string]i
 	return nil
 }
 
+func (v *Param) String() string {
+	if v.pos > 0 {
+		return fmt.Sprintf("$%d", v.pos)
+	}
+
+	return "@" + v.id
+}
+
 type Comparison int
 
 const (
@@ -1959,20 +2284,29 @@ type SelectStmt struct {
 	groupBy   []*ColSelector
 	having    ValueExp
 	limit     int
+	offset    int
 	orderBy   []*OrdCol
 	as        string
+	// explained queries resolve to their plan, which is annotated with actual row counts and timings when analyzed
+	explain bool
+	analyze bool
 }
 
 type ScanSpecs struct {
 	index         *Index
 	rangesByColID map[uint32]*typedValueRange
 	descOrder     bool
+	offset        int // leading index entries skipped without reading their rows
 }
 
 func (stmt *SelectStmt) Limit() int {
 	return stmt.limit
 }
 
+func (stmt *SelectStmt) Offset() int {
+	return stmt.offset
+}
+
 func (stmt *SelectStmt) inferParameters(tx *SQLTx, params map[string]SQLValueType) error {
 	_, err := stmt.execAt(tx, nil)
 	if err != nil {
@@ -1994,6 +2328,11 @@ func (stmt *SelectStmt) execAt(tx *SQLTx, params map[string]interface{}) (*SQLTx
 		return nil, ErrNoDatabaseSelected
 	}
 
+	stmt, err := stmt.expandViews(tx)
+	if err != nil {
+		return nil, err
+	}
+
 	if stmt.groupBy == nil && stmt.having != nil {
 		return nil, ErrHavingClauseRequiresGroupClause
 	}
@@ -2002,10 +2341,6 @@ func (stmt *SelectStmt) execAt(tx *SQLTx, params map[string]interface{}) (*SQLTx
 		return nil, ErrLimitedGroupBy
 	}
 
-	if len(stmt.orderBy) > 1 {
-		return nil, ErrLimitedOrderBy
-	}
-
 	if len(stmt.orderBy) > 0 {
 		tableRef, ok := stmt.ds.(*tableRef)
 		if !ok {
@@ -2017,14 +2352,23 @@ func (stmt *SelectStmt) execAt(tx *SQLTx, params map[string]interface{}) (*SQLTx
 			return nil, err
 		}
 
-		col, err := table.GetColumnByName(stmt.orderBy[0].sel.col)
+		colIDs, err := stmt.orderByColIDs(table)
 		if err != nil {
 			return nil, err
 		}
 
-		_, indexed := table.indexesByColID[col.id]
-		if !indexed {
-			return nil, ErrLimitedOrderBy
+		// rows can only be sorted by the columns of an index, all in the same direction
+		indexed := false
+
+		for _, idx := range table.indexesByColID[colIDs[0]] {
+			if idx.containsInOrder(colIDs) {
+				indexed = true
+				break
+			}
+		}
+
+		if !indexed {
+			return nil, ErrLimitedOrderBy
 		}
 	}
 
@@ -2032,21 +2376,93 @@ func (stmt *SelectStmt) execAt(tx *SQLTx, params map[string]interface{}) (*SQLTx
 }
 
 func (stmt *SelectStmt) Resolve(tx *SQLTx, params map[string]interface{}, _ *ScanSpecs) (rowReader RowReader, err error) {
-	scanSpecs, err := stmt.genScanSpecs(tx, params)
+	if !stmt.explain {
+		return stmt.resolve(tx, params, nil)
+	}
+
+	plan := &queryPlan{analyze: stmt.analyze}
+
+	rowReader, err = stmt.resolve(tx, params, plan)
 	if err != nil {
 		return nil, err
 	}
 
-	rowReader, err = stmt.ds.Resolve(tx, params, scanSpecs)
+	return newExplainRowReader(rowReader, plan)
+}
+
+// resolve builds the row reader of the query, recording its operators in plan when provided
+func (stmt *SelectStmt) resolve(tx *SQLTx, params map[string]interface{}, plan *queryPlan) (rowReader RowReader, err error) {
+	stmt, err = stmt.expandViews(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	reordered, err := stmt.reorderedJoin(tx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	if reordered != nil {
+		rowReader, err = reordered.resolve(tx, params, plan)
+		if err != nil {
+			return nil, err
+		}
+
+		// the statement is still referenced by its original data source
+		return &aliasedRowReader{RowReader: rowReader, alias: stmt.Alias()}, nil
+	}
+
+	scanSpecs, err := stmt.genScanSpecs(tx, params)
 	if err != nil {
 		return nil, err
 	}
 
+	// rows to be skipped by the limit reader, unless skipped while scanning the index
+	offset := stmt.offset
+
+	subq, isSubq := stmt.ds.(*SelectStmt)
+
+	if isSubq && plan != nil {
+		subplan := &queryPlan{analyze: plan.analyze}
+
+		rowReader, err = subq.resolve(tx, params, subplan)
+		if err != nil {
+			return nil, err
+		}
+
+		rowReader = plan.add(rowReader, &planOperator{
+			name:     "Subquery",
+			details:  subq.Alias(),
+			estRows:  subplan.root.estRows,
+			children: []*planOperator{subplan.root},
+		})
+	} else {
+		offsetPushedDown, err := stmt.pushDownOffset(tx, scanSpecs)
+		if err != nil {
+			return nil, err
+		}
+
+		if offsetPushedDown {
+			offset = 0
+		}
+
+		rowReader, err = stmt.ds.Resolve(tx, params, scanSpecs)
+		if err != nil {
+			return nil, err
+		}
+
+		if plan != nil {
+			rowReader = plan.add(rowReader, scanOperator(tx, stmt.ds, scanSpecs))
+		}
+	}
+
 	if stmt.joins != nil {
 		rowReader, err = newJointRowReader(rowReader, stmt.joins, params)
 		if err != nil {
 			return nil, err
 		}
+
+		rowReader = plan.add(rowReader, joinOperator(stmt.joins))
 	}
 
 	if stmt.where != nil {
@@ -2054,6 +2470,8 @@ func (stmt *SelectStmt) Resolve(tx *SQLTx, params map[string]interface{}, _ *Sca
 		if err != nil {
 			return nil, err
 		}
+
+		rowReader = plan.add(rowReader, &planOperator{name: "Filter", details: stmt.where.String(), estRows: plan.estRows()})
 	}
 
 	containsAggregations := false
@@ -2075,33 +2493,85 @@ func (stmt *SelectStmt) Resolve(tx *SQLTx, params map[string]interface{}, _ *Sca
 			return nil, err
 		}
 
+		rowReader = plan.add(rowReader, aggregateOperator(groupBy, plan.estRows()))
+
 		if stmt.having != nil {
 			rowReader, err = newConditionalRowReader(rowReader, stmt.having, params)
 			if err != nil {
 				return nil, err
 			}
+
+			rowReader = plan.add(rowReader, &planOperator{name: "Filter", details: stmt.having.String(), estRows: plan.estRows()})
 		}
 	}
 
-	rowReader, err = newProjectedRowReader(rowReader, stmt.as, stmt.selectors)
+	rowReader, err = newProjectedRowReader(rowReader, stmt.as, stmt.selectors, params)
 	if err != nil {
 		return nil, err
 	}
 
+	rowReader = plan.add(rowReader, projectOperator(stmt.selectors, plan.estRows()))
+
 	if stmt.distinct {
 		rowReader, err = newDistinctRowReader(rowReader)
 		if err != nil {
 			return nil, err
 		}
+
+		rowReader = plan.add(rowReader, &planOperator{name: "Distinct", estRows: plan.estRows()})
 	}
 
-	if stmt.limit > 0 {
-		return newLimitRowReader(rowReader, stmt.limit)
+	if stmt.limit > 0 || offset > 0 {
+		rowReader, err = newLimitRowReader(rowReader, stmt.limit, offset)
+		if err != nil {
+			return nil, err
+		}
+
+		rowReader = plan.add(rowReader, limitOperator(stmt.limit, offset, plan.estRows()))
 	}
 
 	return rowReader, nil
 }
 
+// pushDownOffset makes the index scan skip the rows to be skipped by the offset clause, when every scanned row is returned.
+// Skipped index entries are not resolved into rows.
+func (stmt *SelectStmt) pushDownOffset(tx *SQLTx, scanSpecs *ScanSpecs) (bool, error) {
+	if stmt.offset == 0 || scanSpecs == nil || stmt.joins != nil || stmt.where != nil || stmt.distinct || stmt.groupBy != nil {
+		return false, nil
+	}
+
+	for _, sel := range stmt.selectors {
+		_, isAgg := sel.(*AggColSelector)
+		if isAgg {
+			return false, nil
+		}
+	}
+
+	tableRef, ok := stmt.ds.(*tableRef)
+	if !ok {
+		return false, nil
+	}
+
+	table, err := tableRef.referencedTable(tx)
+	if err != nil {
+		return false, err
+	}
+
+	// rows not satisfying the policies of the table are filtered out after being scanned
+	if tx.sessionAttrs != nil && len(table.policies) > 0 {
+		return false, nil
+	}
+
+	// several rows are read from each scanned entry
+	if tableRef.history {
+		return false, nil
+	}
+
+	scanSpecs.offset = stmt.offset
+
+	return true, nil
+}
+
 func (stmt *SelectStmt) Alias() string {
 	if stmt.as == "" {
 		return stmt.ds.Alias()
@@ -2110,6 +2580,130 @@ func (stmt *SelectStmt) Alias() string {
 	return stmt.as
 }
 
+func (stmt *SelectStmt) String() string {
+	var sb strings.Builder
+
+	if stmt.explain {
+		sb.WriteString("EXPLAIN ")
+
+		if stmt.analyze {
+			sb.WriteString("ANALYZE ")
+		}
+	}
+
+	sb.WriteString("SELECT ")
+
+	if stmt.distinct {
+		sb.WriteString("DISTINCT ")
+	}
+
+	if len(stmt.selectors) == 0 {
+		sb.WriteString("*")
+	}
+
+	for i, sel := range stmt.selectors {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+
+		sb.WriteString(selectorString(sel))
+	}
+
+	sb.WriteString(" FROM " + dataSourceString(stmt.ds))
+
+	if len(stmt.indexOn) > 0 {
+		sb.WriteString(" USE INDEX ON (" + strings.Join(stmt.indexOn, ", ") + ")")
+	}
+
+	for _, join := range stmt.joins {
+		sb.WriteString(" " + join.String())
+	}
+
+	if stmt.where != nil {
+		sb.WriteString(" WHERE " + stmt.where.String())
+	}
+
+	if len(stmt.groupBy) > 0 {
+		cols := make([]string, len(stmt.groupBy))
+		for i, col := range stmt.groupBy {
+			cols[i] = col.String()
+		}
+
+		sb.WriteString(" GROUP BY " + strings.Join(cols, ", "))
+	}
+
+	if stmt.having != nil {
+		sb.WriteString(" HAVING " + stmt.having.String())
+	}
+
+	if len(stmt.orderBy) > 0 {
+		cols := make([]string, len(stmt.orderBy))
+		for i, col := range stmt.orderBy {
+			cols[i] = col.sel.String()
+
+			if col.descOrder {
+				cols[i] += " DESC"
+			}
+		}
+
+		sb.WriteString(" ORDER BY " + strings.Join(cols, ", "))
+	}
+
+	if stmt.limit > 0 {
+		sb.WriteString(fmt.Sprintf(" LIMIT %d", stmt.limit))
+	}
+
+	if stmt.offset > 0 {
+		sb.WriteString(fmt.Sprintf(" OFFSET %d", stmt.offset))
+	}
+
+	return sb.String()
+}
+
+// selectorString renders the selector along with its alias, unless it's the default one
+func selectorString(sel Selector) string {
+	if sel.alias() == "" || sel.alias() == sel.String() {
+		return sel.String()
+	}
+
+	return sel.String() + " AS " + sel.alias()
+}
+
+func dataSourceString(ds DataSource) string {
+	switch ds := ds.(type) {
+	case *tableRef:
+		return ds.String()
+	case *SelectStmt:
+		if ds.as == "" {
+			return "(" + ds.String() + ")"
+		}
+
+		return "(" + ds.String() + ") AS " + ds.as
+	}
+
+	return ""
+}
+
+// orderByColIDs returns the ids of the ordering columns, which must be sorted in the same direction
+func (stmt *SelectStmt) orderByColIDs(table *Table) ([]uint32, error) {
+	colIDs := make([]uint32, len(stmt.orderBy))
+
+	for i, ordCol := range stmt.orderBy {
+		if ordCol.descOrder != stmt.orderBy[0].descOrder {
+			return nil, ErrLimitedOrderBy
+		}
+
+		col, err := table.GetColumnByName(ordCol.sel.col)
+		if err != nil {
+			return nil, err
+		}
+
+		colIDs[i] = col.id
+	}
+
+	return colIDs, nil
+}
+
 func (stmt *SelectStmt) genScanSpecs(tx *SQLTx, params map[string]interface{}) (*ScanSpecs, error) {
 	tableRef, isTableRef := stmt.ds.(*tableRef)
 	if !isTableRef {
@@ -2155,21 +2749,24 @@ func (stmt *SelectStmt) genScanSpecs(tx *SQLTx, params map[string]interface{}) (
 	var descOrder bool
 
 	if stmt.orderBy == nil {
-		if preferredIndex == nil {
-			sortingIndex = table.primaryIndex
-		} else {
+		if preferredIndex != nil {
 			sortingIndex = preferredIndex
+		} else if table.stats != nil {
+			// rows are not required to be sorted, thus the index the fewest rows are estimated to be read from is used
+			sortingIndex = table.cheapestIndex(rangesByColID)
+		} else {
+			sortingIndex = table.primaryIndex
 		}
 	}
 
 	if len(stmt.orderBy) > 0 {
-		col, err := table.GetColumnByName(stmt.orderBy[0].sel.col)
+		colIDs, err := stmt.orderByColIDs(table)
 		if err != nil {
 			return nil, err
 		}
 
-		for _, idx := range table.indexesByColID[col.id] {
-			if idx.sortableUsing(col.id, rangesByColID) {
+		for _, idx := range table.indexesByColID[colIDs[0]] {
+			if idx.sortableUsing(colIDs, rangesByColID) {
 				if preferredIndex == nil || idx.id == preferredIndex.id {
 					sortingIndex = idx
 					break
@@ -2180,6 +2777,19 @@ func (stmt *SelectStmt) genScanSpecs(tx *SQLTx, params map[string]interface{}) (
 		descOrder = stmt.orderBy[0].descOrder
 	}
 
+	// the history of the rows is read by primary key
+	if tableRef.history && sortingIndex != nil && !sortingIndex.IsPrimary() {
+		if len(stmt.orderBy) > 0 {
+			return nil, ErrLimitedOrderBy
+		}
+
+		if preferredIndex != nil {
+			return nil, ErrNoAvailableIndex
+		}
+
+		sortingIndex = table.primaryIndex
+	}
+
 	if sortingIndex == nil {
 		return nil, ErrNoAvailableIndex
 	}
@@ -2195,27 +2805,26 @@ type tableRef struct {
 	db       string
 	table    string
 	asBefore uint64
+	history  bool // every revision of the rows is read, see historyRowReader
 	as       string
 }
 
-func (stmt *tableRef) referencedTable(tx *SQLTx) (*Table, error) {
-	var db *Database
-
+func (stmt *tableRef) referencedDatabase(tx *SQLTx) (*Database, error) {
 	if stmt.db != "" {
-		rdb, err := tx.catalog.GetDatabaseByName(stmt.db)
-		if err != nil {
-			return nil, err
-		}
+		return tx.catalog.GetDatabaseByName(stmt.db)
+	}
 
-		db = rdb
+	if tx.currentDB == nil {
+		return nil, ErrNoDatabaseSelected
 	}
 
-	if db == nil {
-		if tx.currentDB == nil {
-			return nil, ErrNoDatabaseSelected
-		}
+	return tx.currentDB, nil
+}
 
-		db = tx.currentDB
+func (stmt *tableRef) referencedTable(tx *SQLTx) (*Table, error) {
+	db, err := stmt.referencedDatabase(tx)
+	if err != nil {
+		return nil, err
 	}
 
 	table, err := db.GetTableByName(stmt.table)
@@ -2235,12 +2844,35 @@ func (stmt *tableRef) Resolve(tx *SQLTx, params map[string]interface{}, scanSpec
 		return nil, ErrIllegalArguments
 	}
 
+	ds, err := expandedDataSource(tx, stmt)
+	if err != nil {
+		return nil, err
+	}
+
+	if ds != stmt {
+		return ds.Resolve(tx, params, nil)
+	}
+
 	table, err := stmt.referencedTable(tx)
 	if err != nil {
 		return nil, err
 	}
 
-	return newRawRowReader(tx, table, stmt.asBefore, stmt.as, scanSpecs)
+	if stmt.history {
+		rowReader, err := newHistoryRowReader(tx, table, stmt.as, scanSpecs)
+		if err != nil {
+			return nil, err
+		}
+
+		return tx.policyFilteredReader(table, rowReader)
+	}
+
+	rowReader, err := newRawRowReader(tx, table, stmt.asBefore, stmt.as, scanSpecs)
+	if err != nil {
+		return nil, err
+	}
+
+	return tx.policyFilteredReader(table, rowReader)
 }
 
 func (stmt *tableRef) Alias() string {
@@ -2250,6 +2882,24 @@ func (stmt *tableRef) Alias() string {
 	return stmt.as
 }
 
+func (stmt *tableRef) String() string {
+	s := qualifiedName(stmt.db, stmt.table)
+
+	if stmt.history {
+		s = "HISTORY OF " + s
+	}
+
+	if stmt.asBefore > 0 {
+		s += fmt.Sprintf(" BEFORE TX %d", stmt.asBefore)
+	}
+
+	if stmt.as != "" {
+		s += " AS " + stmt.as
+	}
+
+	return s
+}
+
 type JoinSpec struct {
 	joinType JoinType
 	ds       DataSource
@@ -2257,6 +2907,27 @@ type JoinSpec struct {
 	indexOn  []string
 }
 
+func (join *JoinSpec) String() string {
+	var sb strings.Builder
+
+	switch join.joinType {
+	case LeftJoin:
+		sb.WriteString("LEFT ")
+	case RightJoin:
+		sb.WriteString("RIGHT ")
+	}
+
+	sb.WriteString("JOIN " + dataSourceString(join.ds))
+
+	if len(join.indexOn) > 0 {
+		sb.WriteString(" USE INDEX ON (" + strings.Join(join.indexOn, ", ") + ")")
+	}
+
+	sb.WriteString(" ON " + join.cond.String())
+
+	return sb.String()
+}
+
 type OrdCol struct {
 	sel       *ColSelector
 	descOrder bool
@@ -2368,6 +3039,10 @@ func (sel *ColSelector) selectorRanges(table *Table, asTable string, params map[
 	return nil
 }
 
+func (sel *ColSelector) String() string {
+	return qualifiedName(sel.db, sel.table, sel.col)
+}
+
 type AggColSelector struct {
 	aggFn AggregateFn
 	db    string
@@ -2380,6 +3055,19 @@ func EncodeSelector(aggFn, db, table, col string) string {
 	return aggFn + "(" + db + "." + table + "." + col + ")"
 }
 
+// qualifiedName joins the non-empty parts of a name with dots, e.g. db.table.col
+func qualifiedName(parts ...string) string {
+	nonEmpty := make([]string, 0, len(parts))
+
+	for _, p := range parts {
+		if p != "" {
+			nonEmpty = append(nonEmpty, p)
+		}
+	}
+
+	return strings.Join(nonEmpty, ".")
+}
+
 func (sel *AggColSelector) resolve(implicitDB, implicitTable string) (aggFn, db, table, col string) {
 	db = implicitDB
 	if sel.db != "" {
@@ -2462,6 +3150,23 @@ func (sel *AggColSelector) selectorRanges(table *Table, asTable string, params m
 	return nil
 }
 
+func (sel *AggColSelector) String() string {
+	return sel.aggFn + "(" + qualifiedName(sel.db, sel.table, sel.col) + ")"
+}
+
+func numOperatorString(op NumOperator) string {
+	switch op {
+	case ADDOP:
+		return "+"
+	case SUBSOP:
+		return "-"
+	case DIVOP:
+		return "/"
+	}
+
+	return "*"
+}
+
 type NumExp struct {
 	op          NumOperator
 	left, right ValueExp
@@ -2579,6 +3284,10 @@ func (bexp *NumExp) selectorRanges(table *Table, asTable string, params map[stri
 	return nil
 }
 
+func (bexp *NumExp) String() string {
+	return "(" + bexp.left.String() + " " + numOperatorString(bexp.op) + " " + bexp.right.String() + ")"
+}
+
 type NotBoolExp struct {
 	exp ValueExp
 }
@@ -2639,6 +3348,10 @@ func (bexp *NotBoolExp) selectorRanges(table *Table, asTable string, params map[
 	return nil
 }
 
+func (bexp *NotBoolExp) String() string {
+	return "(NOT " + bexp.exp.String() + ")"
+}
+
 type LikeBoolExp struct {
 	val     ValueExp
 	notLike bool
@@ -2740,6 +3453,32 @@ func (bexp *LikeBoolExp) selectorRanges(table *Table, asTable string, params map
 	return nil
 }
 
+func (bexp *LikeBoolExp) String() string {
+	op := " LIKE "
+	if bexp.notLike {
+		op = " NOT LIKE "
+	}
+
+	return "(" + bexp.val.String() + op + bexp.pattern.String() + ")"
+}
+
+func cmpOperatorString(op CmpOperator) string {
+	switch op {
+	case EQ:
+		return "="
+	case NE:
+		return "!="
+	case LT:
+		return "<"
+	case LE:
+		return "<="
+	case GT:
+		return ">"
+	}
+
+	return ">="
+}
+
 type CmpBoolExp struct {
 	op          CmpOperator
 	left, right ValueExp
@@ -2886,6 +3625,10 @@ func (bexp *CmpBoolExp) selectorRanges(table *Table, asTable string, params map[
 	return updateRangeFor(column.id, rval, bexp.op, rangesByColID)
 }
 
+func (bexp *CmpBoolExp) String() string {
+	return "(" + bexp.left.String() + " " + cmpOperatorString(bexp.op) + " " + bexp.right.String() + ")"
+}
+
 func updateRangeFor(colID uint32, val TypedValue, cmp CmpOperator, rangesByColID map[uint32]*typedValueRange) error {
 	currRange, ranged := rangesByColID[colID]
 	var newRange *typedValueRange
@@ -2970,6 +3713,162 @@ func cmpSatisfiesOp(cmp int, op CmpOperator) bool {
 	return false
 }
 
+// TupleCmpExp compares rows of values in lexicographical order e.g. (a, b) > (@a, @b) holds when a > @a OR (a = @a AND b > @b),
+// which allows paginating over multi-column indexes resuming from the last row read
+type TupleCmpExp struct {
+	op          CmpOperator
+	left, right []ValueExp
+}
+
+// expanded returns the equivalent expression comparing the values one by one
+func (bexp *TupleCmpExp) expanded() (ValueExp, error) {
+	if len(bexp.left) == 0 || len(bexp.left) != len(bexp.right) {
+		return nil, fmt.Errorf("%w: rows of different sizes can not be compared", ErrInvalidNumberOfValues)
+	}
+
+	var strictOp CmpOperator
+
+	switch bexp.op {
+	case EQ, NE:
+		var exp ValueExp
+		logicOp := AND
+		if bexp.op == NE {
+			logicOp = OR
+		}
+
+		for i := range bexp.left {
+			cmp := &CmpBoolExp{op: bexp.op, left: bexp.left[i], right: bexp.right[i]}
+
+			if exp == nil {
+				exp = cmp
+				continue
+			}
+
+			exp = &BinBoolExp{op: logicOp, left: exp, right: cmp}
+		}
+
+		return exp, nil
+	case LT, LE:
+		strictOp = LT
+	default:
+		strictOp = GT
+	}
+
+	last := len(bexp.left) - 1
+
+	var exp ValueExp = &CmpBoolExp{op: bexp.op, left: bexp.left[last], right: bexp.right[last]}
+
+	for i := last - 1; i >= 0; i-- {
+		exp = &BinBoolExp{
+			op:   OR,
+			left: &CmpBoolExp{op: strictOp, left: bexp.left[i], right: bexp.right[i]},
+			right: &BinBoolExp{
+				op:    AND,
+				left:  &CmpBoolExp{op: EQ, left: bexp.left[i], right: bexp.right[i]},
+				right: exp,
+			},
+		}
+	}
+
+	return exp, nil
+}
+
+func (bexp *TupleCmpExp) inferType(cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) (SQLValueType, error) {
+	exp, err := bexp.expanded()
+	if err != nil {
+		return AnyType, err
+	}
+
+	return exp.inferType(cols, params, implicitDB, implicitTable)
+}
+
+func (bexp *TupleCmpExp) requiresType(t SQLValueType, cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) error {
+	exp, err := bexp.expanded()
+	if err != nil {
+		return err
+	}
+
+	return exp.requiresType(t, cols, params, implicitDB, implicitTable)
+}
+
+func (bexp *TupleCmpExp) substitute(params map[string]interface{}) (ValueExp, error) {
+	left := make([]ValueExp, len(bexp.left))
+	right := make([]ValueExp, len(bexp.right))
+
+	for i, v := range bexp.left {
+		sv, err := v.substitute(params)
+		if err != nil {
+			return nil, err
+		}
+
+		left[i] = sv
+	}
+
+	for i, v := range bexp.right {
+		sv, err := v.substitute(params)
+		if err != nil {
+			return nil, err
+		}
+
+		right[i] = sv
+	}
+
+	return &TupleCmpExp{op: bexp.op, left: left, right: right}, nil
+}
+
+func (bexp *TupleCmpExp) reduce(catalog *Catalog, row *Row, implicitDB, implicitTable string) (TypedValue, error) {
+	exp, err := bexp.expanded()
+	if err != nil {
+		return nil, err
+	}
+
+	return exp.reduce(catalog, row, implicitDB, implicitTable)
+}
+
+func (bexp *TupleCmpExp) reduceSelectors(row *Row, implicitDB, implicitTable string) ValueExp {
+	left := make([]ValueExp, len(bexp.left))
+	right := make([]ValueExp, len(bexp.right))
+
+	for i, v := range bexp.left {
+		left[i] = v.reduceSelectors(row, implicitDB, implicitTable)
+	}
+
+	for i, v := range bexp.right {
+		right[i] = v.reduceSelectors(row, implicitDB, implicitTable)
+	}
+
+	return &TupleCmpExp{op: bexp.op, left: left, right: right}
+}
+
+func (bexp *TupleCmpExp) isConstant() bool {
+	return false
+}
+
+// selectorRanges of the expanded expression bound the first column of the rows,
+// thus scans over an index on the compared columns start from the given row
+func (bexp *TupleCmpExp) selectorRanges(table *Table, asTable string, params map[string]interface{}, rangesByColID map[uint32]*typedValueRange) error {
+	exp, err := bexp.expanded()
+	if err != nil {
+		return err
+	}
+
+	return exp.selectorRanges(table, asTable, params, rangesByColID)
+}
+
+func (bexp *TupleCmpExp) String() string {
+	return "(" + valuesString(bexp.left) + " " + cmpOperatorString(bexp.op) + " " + valuesString(bexp.right) + ")"
+}
+
+func valuesString(values []ValueExp) string {
+	strs := make([]string, len(values))
+
+	for i, v := range values {
+		strs[i] = v.String()
+	}
+
+	return "(" + strings.Join(strs, ", ") + ")"
+}
+
 type BinBoolExp struct {
 	op          LogicOperator
 	left, right ValueExp
@@ -3111,6 +4010,15 @@ func (bexp *BinBoolExp) selectorRanges(table *Table, asTable string, params map[
 	return nil
 }
 
+func (bexp *BinBoolExp) String() string {
+	op := "AND"
+	if bexp.op == OR {
+		op = "OR"
+	}
+
+	return "(" + bexp.left.String() + " " + op + " " + bexp.right.String() + ")"
+}
+
 type ExistsBoolExp struct {
 	q *SelectStmt
 }
@@ -3143,6 +4051,10 @@ func (bexp *ExistsBoolExp) selectorRanges(table *Table, asTable string, params m
 	return nil
 }
 
+func (bexp *ExistsBoolExp) String() string {
+	return "(EXISTS (" + bexp.q.String() + "))"
+}
+
 type InSubQueryExp struct {
 	val   ValueExp
 	notIn bool
@@ -3177,6 +4089,15 @@ func (bexp *InSubQueryExp) selectorRanges(table *Table, asTable string, params m
 	return nil
 }
 
+func (bexp *InSubQueryExp) String() string {
+	op := " IN "
+	if bexp.notIn {
+		op = " NOT IN "
+	}
+
+	return "(" + bexp.val.String() + op + "(" + bexp.q.String() + "))"
+}
+
 // TODO: once InSubQueryExp is supported, this struct may become obsolete by creating a ListDataSource struct
 type InListExp struct {
 	val    ValueExp
@@ -3284,3 +4205,12 @@ func (bexp *InListExp) selectorRanges(table *Table, asTable string, params map[s
 	// TODO: may be determiined by smallest and bigggest value in the list
 	return nil
 }
+
+func (bexp *InListExp) String() string {
+	op := " IN "
+	if bexp.notIn {
+		op = " NOT IN "
+	}
+
+	return "(" + bexp.val.String() + op + valuesString(bexp.values) + ")"
+}
@@ -0,0 +1,45 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package sql
+
+import "time"
+
+// NewInteger returns an INTEGER value. Typed values can be provided as parameters instead of the Go values
+// they would be inferred from, so the type of a parameter doesn't depend on the Go type used by the caller.
+func NewInteger(v int64) TypedValue {
+	return &Number{val: v}
+}
+
+func NewVarchar(v string) TypedValue {
+	return &Varchar{val: v}
+}
+
+func NewBool(v bool) TypedValue {
+	return &Bool{val: v}
+}
+
+func NewBlob(v []byte) TypedValue {
+	return &Blob{val: v}
+}
+
+func NewTimestamp(v time.Time) TypedValue {
+	return &Timestamp{val: v}
+}
+
+// NewNull returns a NULL value of the given type
+func NewNull(t SQLValueType) TypedValue {
+	return &NullValue{t: t}
+}
@@ -0,0 +1,346 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package sql
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/codenotary/immudb/embedded/store"
+)
+
+// View is a named query which can be read from as a table.
+// Views are not materialized, queries reading from a view are planned as if its query were written as a subquery.
+type View struct {
+	db    *Database
+	name  string
+	query *SelectStmt
+}
+
+func (v *View) Name() string {
+	return v.name
+}
+
+func (v *View) Database() *Database {
+	return v.db
+}
+
+// Query returns the SQL representation of the view query
+func (v *View) Query() string {
+	return v.query.String()
+}
+
+func (db *Database) ExistView(name string) bool {
+	_, exists := db.viewsByName[name]
+	return exists
+}
+
+// GetViews returns the views of the database sorted by name
+func (db *Database) GetViews() []*View {
+	views := make([]*View, 0, len(db.viewsByName))
+
+	for _, v := range db.viewsByName {
+		views = append(views, v)
+	}
+
+	sort.Slice(views, func(i, j int) bool {
+		return views[i].name < views[j].name
+	})
+
+	return views
+}
+
+func (db *Database) GetViewByName(name string) (*View, error) {
+	view, exists := db.viewsByName[name]
+	if !exists {
+		return nil, ErrViewDoesNotExist
+	}
+	return view, nil
+}
+
+func (db *Database) newView(name string, query *SelectStmt) (*View, error) {
+	if name == "" || query == nil {
+		return nil, ErrIllegalArguments
+	}
+
+	if db.ExistTable(name) {
+		return nil, ErrTableAlreadyExists
+	}
+
+	if db.ExistView(name) {
+		return nil, ErrViewAlreadyExists
+	}
+
+	view := &View{
+		db:    db,
+		name:  name,
+		query: query,
+	}
+
+	db.viewsByName[name] = view
+
+	return view, nil
+}
+
+// referencedView returns the view the data source reads from, it's nil when reading from a table
+func (stmt *tableRef) referencedView(tx *SQLTx) (*View, error) {
+	db, err := stmt.referencedDatabase(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	view, exists := db.viewsByName[stmt.table]
+	if !exists {
+		return nil, nil
+	}
+
+	if stmt.asBefore > 0 {
+		return nil, fmt.Errorf("%w: BEFORE TX on view '%s'", ErrNoSupported, view.name)
+	}
+
+	if stmt.history {
+		return nil, fmt.Errorf("%w: HISTORY OF view '%s'", ErrNoSupported, view.name)
+	}
+
+	return view, nil
+}
+
+// expandedDataSource replaces a reference to a view with the view query, aliased as the reference
+func expandedDataSource(tx *SQLTx, ds DataSource) (DataSource, error) {
+	ref, ok := ds.(*tableRef)
+	if !ok {
+		return ds, nil
+	}
+
+	view, err := ref.referencedView(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	if view == nil {
+		return ds, nil
+	}
+
+	query := *view.query
+	query.as = ref.Alias()
+
+	return &query, nil
+}
+
+// expandViews returns the statement with the views it reads from replaced by their queries,
+// views referenced by view queries are expanded when those are resolved
+func (stmt *SelectStmt) expandViews(tx *SQLTx) (*SelectStmt, error) {
+	ds, err := expandedDataSource(tx, stmt.ds)
+	if err != nil {
+		return nil, err
+	}
+
+	expanded := ds != stmt.ds
+
+	joins := make([]*JoinSpec, len(stmt.joins))
+
+	for i, join := range stmt.joins {
+		jds, err := expandedDataSource(tx, join.ds)
+		if err != nil {
+			return nil, err
+		}
+
+		if jds == join.ds {
+			joins[i] = join
+			continue
+		}
+
+		ejoin := *join
+		ejoin.ds = jds
+
+		joins[i] = &ejoin
+		expanded = true
+	}
+
+	if !expanded {
+		return stmt, nil
+	}
+
+	estmt := *stmt
+	estmt.ds = ds
+
+	if stmt.joins != nil {
+		estmt.joins = joins
+	}
+
+	return &estmt, nil
+}
+
+type CreateViewStmt struct {
+	name        string
+	ifNotExists bool
+	query       *SelectStmt
+}
+
+func (stmt *CreateViewStmt) inferParameters(tx *SQLTx, params map[string]SQLValueType) error {
+	return nil
+}
+
+func (stmt *CreateViewStmt) execAt(tx *SQLTx, params map[string]interface{}) (*SQLTx, error) {
+	if tx.currentDB == nil {
+		return nil, ErrNoDatabaseSelected
+	}
+
+	if stmt.ifNotExists && tx.currentDB.ExistView(stmt.name) {
+		return tx, nil
+	}
+
+	err := stmt.validateQuery(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	view, err := tx.currentDB.newView(stmt.name, stmt.query)
+	if err != nil {
+		return nil, err
+	}
+
+	definition := &CreateViewStmt{name: view.name, query: view.query}
+
+	err = tx.set(viewKey(tx.sqlPrefix(), view), nil, []byte(definition.String()))
+	if err != nil {
+		return nil, err
+	}
+
+	return tx, nil
+}
+
+// validateQuery checks the query is valid over the current catalog and fully defined i.e. without parameters
+func (stmt *CreateViewStmt) validateQuery(tx *SQLTx) error {
+	_, err := stmt.query.execAt(tx, nil)
+	if err != nil {
+		return err
+	}
+
+	rowReader, err := stmt.query.Resolve(tx, nil, nil)
+	if err != nil {
+		return err
+	}
+	defer rowReader.Close()
+
+	_, err = rowReader.Columns()
+	if err != nil {
+		return err
+	}
+
+	params := make(map[string]SQLValueType)
+
+	err = rowReader.InferParameters(params)
+	if err != nil {
+		return err
+	}
+
+	if len(params) > 0 {
+		return fmt.Errorf("%w: view '%s' can not have parameters", ErrIllegalArguments, stmt.name)
+	}
+
+	return nil
+}
+
+func (stmt *CreateViewStmt) String() string {
+	if stmt.ifNotExists {
+		return fmt.Sprintf("CREATE VIEW IF NOT EXISTS %s AS %s", stmt.name, stmt.query.String())
+	}
+
+	return fmt.Sprintf("CREATE VIEW %s AS %s", stmt.name, stmt.query.String())
+}
+
+type DropViewStmt struct {
+	name string
+}
+
+func (stmt *DropViewStmt) inferParameters(tx *SQLTx, params map[string]SQLValueType) error {
+	return nil
+}
+
+func (stmt *DropViewStmt) execAt(tx *SQLTx, params map[string]interface{}) (*SQLTx, error) {
+	if tx.currentDB == nil {
+		return nil, ErrNoDatabaseSelected
+	}
+
+	view, err := tx.currentDB.GetViewByName(stmt.name)
+	if err != nil {
+		return nil, err
+	}
+
+	md := store.NewKVMetadata()
+
+	md.AsDeleted(true)
+
+	err = tx.set(viewKey(tx.sqlPrefix(), view), md, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	delete(tx.currentDB.viewsByName, view.name)
+
+	return tx, nil
+}
+
+func viewKey(sqlPrefix []byte, view *View) []byte {
+	return mapKey(sqlPrefix, catalogViewPrefix, EncodeID(view.db.id), []byte(view.name))
+}
+
+func (db *Database) loadViews(sqlPrefix []byte, tx *store.OngoingTx) error {
+	initialKey := mapKey(sqlPrefix, catalogViewPrefix, EncodeID(db.id))
+
+	viewReader, err := tx.NewKeyReader(&store.KeyReaderSpec{
+		Prefix: initialKey,
+		Filter: store.IgnoreDeleted,
+	})
+	if err != nil {
+		return err
+	}
+	defer viewReader.Close()
+
+	for {
+		mkey, vref, err := viewReader.Read()
+		if err == store.ErrNoMoreEntries {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		v, err := vref.Resolve()
+		if err != nil {
+			return err
+		}
+
+		// v={CREATE VIEW statement}
+		stmts, err := ParseString(string(v))
+		if err != nil {
+			return err
+		}
+
+		stmt, ok := stmts[0].(*CreateViewStmt)
+		if !ok || len(stmts) != 1 || string(mkey[len(initialKey):]) != stmt.name {
+			return ErrCorruptedData
+		}
+
+		_, err = db.newView(stmt.name, stmt.query)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,64 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var errAffinityUnsupported = errors.New("CPU affinity is not supported")
+
+// parseCPUList parses lists of CPUs as in /sys/devices/system/node/node*/cpulist, e.g. "0-3,8,10-11"
+func parseCPUList(list string) ([]int, error) {
+	var cpus []int
+
+	list = strings.TrimSpace(list)
+	if list == "" {
+		return nil, nil
+	}
+
+	for _, r := range strings.Split(list, ",") {
+		bounds := strings.SplitN(r, "-", 2)
+
+		first, err := strconv.Atoi(bounds[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid CPU list '%s': %w", list, err)
+		}
+
+		last := first
+
+		if len(bounds) == 2 {
+			last, err = strconv.Atoi(bounds[1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid CPU list '%s': %w", list, err)
+			}
+		}
+
+		if first < 0 || last < first {
+			return nil, fmt.Errorf("invalid CPU list '%s': invalid range '%s'", list, r)
+		}
+
+		for cpu := first; cpu <= last; cpu++ {
+			cpus = append(cpus, cpu)
+		}
+	}
+
+	return cpus, nil
+}
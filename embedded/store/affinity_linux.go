@@ -0,0 +1,106 @@
+// +build linux
+
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+const numaNodesPath = "/sys/devices/system/node"
+
+// numaNodeCPUs returns the CPUs of each NUMA node the process is allowed to run on.
+// All the allowed CPUs are returned as a single node if the NUMA topology is not available
+func numaNodeCPUs() ([][]int, error) {
+	var allowed unix.CPUSet
+
+	err := unix.SchedGetaffinity(0, &allowed)
+	if err != nil {
+		return nil, err
+	}
+
+	var allowedCPUs []int
+
+	for cpu := 0; cpu < len(allowed)*64; cpu++ {
+		if allowed.IsSet(cpu) {
+			allowedCPUs = append(allowedCPUs, cpu)
+		}
+	}
+
+	paths, _ := filepath.Glob(filepath.Join(numaNodesPath, "node*", "cpulist"))
+
+	nodeIDs := make(map[string]int, len(paths))
+
+	for _, path := range paths {
+		nodeIDs[path], _ = strconv.Atoi(strings.TrimPrefix(filepath.Base(filepath.Dir(path)), "node"))
+	}
+
+	sort.Slice(paths, func(i, j int) bool {
+		return nodeIDs[paths[i]] < nodeIDs[paths[j]]
+	})
+
+	var nodes [][]int
+
+	for _, path := range paths {
+		list, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		cpus, err := parseCPUList(string(list))
+		if err != nil {
+			return nil, err
+		}
+
+		var nodeCPUs []int
+
+		for _, cpu := range cpus {
+			if allowed.IsSet(cpu) {
+				nodeCPUs = append(nodeCPUs, cpu)
+			}
+		}
+
+		// nodes without allowed CPUs, e.g. memory-only ones, are skipped
+		if len(nodeCPUs) > 0 {
+			nodes = append(nodes, nodeCPUs)
+		}
+	}
+
+	if len(nodes) == 0 {
+		return [][]int{allowedCPUs}, nil
+	}
+
+	return nodes, nil
+}
+
+// setThreadAffinity pins the calling thread to the given CPUs, it must be locked to the goroutine
+func setThreadAffinity(cpus []int) error {
+	var set unix.CPUSet
+
+	for _, cpu := range cpus {
+		set.Set(cpu)
+	}
+
+	return unix.SchedSetaffinity(0, &set)
+}
@@ -0,0 +1,166 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package store
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+
+	"github.com/codenotary/immudb/embedded/ahtree"
+	"github.com/codenotary/immudb/embedded/htree"
+)
+
+// AuditStep is a step of an audit path, the digest it is applied to is hashed as sha256(Prefix + digest + Suffix)
+type AuditStep struct {
+	Prefix []byte
+	Suffix []byte
+}
+
+// EvalAuditPath applies the steps of path in order, starting from digest
+func EvalAuditPath(path []*AuditStep, digest [sha256.Size]byte) [sha256.Size]byte {
+	for _, step := range path {
+		b := make([]byte, 0, len(step.Prefix)+sha256.Size+len(step.Suffix))
+		b = append(b, step.Prefix...)
+		b = append(b, digest[:]...)
+		b = append(b, step.Suffix...)
+
+		digest = sha256.Sum256(b)
+	}
+
+	return digest
+}
+
+// AuditPath returns the digest of the entry of key in tx, along with the steps leading from it to the alh of targetTx.
+// The path goes through the tree of the entries of tx and its header, then either through the binary linking tree
+// targetTx is linked to, when it includes tx, or through the txs in between. Unlike dual proofs, the path is verified
+// by just evaluating it, so verifiers need no knowledge of how txs are linked
+func (s *ImmuStore) AuditPath(tx *Tx, key []byte, targetTx *Tx) (entryDigest [sha256.Size]byte, path []*AuditStep, err error) {
+	if tx == nil || targetTx == nil {
+		return entryDigest, nil, ErrIllegalArguments
+	}
+
+	if tx.header.ID > targetTx.header.ID {
+		return entryDigest, nil, ErrSourceTxNewerThanTargetTx
+	}
+
+	entry, err := tx.EntryOf(key)
+	if err != nil {
+		return entryDigest, nil, err
+	}
+
+	entryDigestFn, err := tx.TxEntryDigest()
+	if err != nil {
+		return entryDigest, nil, err
+	}
+
+	entryDigest, err = entryDigestFn(entry)
+	if err != nil {
+		return entryDigest, nil, err
+	}
+
+	iproof, err := tx.Proof(key)
+	if err != nil {
+		return entryDigest, nil, err
+	}
+
+	path = append(path, &AuditStep{Prefix: []byte{htree.LeafPrefix}})
+
+	// same traversal as htree.VerifyInclusion
+	i := iproof.Leaf
+	r := iproof.Width - 1
+
+	for _, t := range iproof.Terms {
+		path = append(path, nodeStep(htree.NodePrefix, t, i%2 == 0 && i != r))
+
+		i /= 2
+		r /= 2
+	}
+
+	// eH is followed by blTxID and blRoot
+	path = append(path, headerSteps(tx.header, txIDSize+sha256.Size)...)
+
+	if tx.header.ID == targetTx.header.ID {
+		return entryDigest, path, nil
+	}
+
+	if tx.header.ID <= targetTx.header.BlTxID {
+		binInclusionProof, err := s.aht.InclusionProof(tx.header.ID, targetTx.header.BlTxID) // must match targetTx.BlRoot
+		if err != nil {
+			return entryDigest, nil, err
+		}
+
+		path = append(path, &AuditStep{Prefix: []byte{ahtree.LeafPrefix}})
+
+		// same traversal as ahtree.EvalInclusion
+		i1 := tx.header.ID - 1
+		j1 := targetTx.header.BlTxID - 1
+
+		for _, h := range binInclusionProof {
+			path = append(path, nodeStep(ahtree.NodePrefix, h, i1%2 == 0 && i1 != j1))
+
+			i1 >>= 1
+			j1 >>= 1
+		}
+
+		// blRoot is the last record of the header
+		path = append(path, headerSteps(targetTx.header, 0)...)
+
+		return entryDigest, path, nil
+	}
+
+	lproof, err := s.LinearProof(tx.header.ID, targetTx.header.ID)
+	if err != nil {
+		return entryDigest, nil, err
+	}
+
+	for i, innerHash := range lproof.Terms[1:] {
+		// hash(txID + prevAlh + innerHash)
+		prefix := make([]byte, txIDSize)
+		binary.BigEndian.PutUint64(prefix, tx.header.ID+uint64(i)+1)
+
+		path = append(path, &AuditStep{Prefix: prefix, Suffix: append([]byte(nil), innerHash[:]...)})
+	}
+
+	return entryDigest, path, nil
+}
+
+// nodeStep hashes a digest along with its sibling in a tree, on the left of it if left is true
+func nodeStep(nodePrefix byte, sibling [sha256.Size]byte, left bool) *AuditStep {
+	if left {
+		return &AuditStep{Prefix: []byte{nodePrefix}, Suffix: append([]byte(nil), sibling[:]...)}
+	}
+
+	return &AuditStep{Prefix: append([]byte{nodePrefix}, sibling[:]...)}
+}
+
+// headerSteps hash a digest found in the header up to its alh, trailing is the number of bytes following
+// the digest in the preimage of the inner hash
+func headerSteps(hdr *TxHeader, trailing int) []*AuditStep {
+	var b [innerHashMaxLen]byte
+	n := hdr.innerHashPreimage(b[:])
+
+	off := n - trailing - sha256.Size
+
+	// hash(txID + prevAlh + innerHash)
+	alhPrefix := make([]byte, txIDSize+sha256.Size)
+	binary.BigEndian.PutUint64(alhPrefix, hdr.ID)
+	copy(alhPrefix[txIDSize:], hdr.PrevAlh[:])
+
+	return []*AuditStep{
+		{Prefix: append([]byte(nil), b[:off]...), Suffix: append([]byte(nil), b[off+sha256.Size:n]...)},
+		{Prefix: alhPrefix},
+	}
+}
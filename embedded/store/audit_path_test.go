@@ -0,0 +1,108 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package store
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestImmudbStoreAuditPath(t *testing.T) {
+	dir, err := ioutil.TempDir("", "store_audit_path")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// short linear proofs, so both binary linking and linear paths are taken
+	immuStore, err := Open(dir, DefaultOptions().WithSynced(false).WithMaxLinearProofLen(4))
+	require.NoError(t, err)
+	defer immuStore.Close()
+
+	txCount := 30
+	eCount := 5
+
+	for i := 0; i < txCount; i++ {
+		tx, err := immuStore.NewWriteOnlyTx()
+		require.NoError(t, err)
+
+		if i%2 == 0 {
+			tx.WithMetadata(NewTxMetadata())
+		}
+
+		for j := 0; j < eCount; j++ {
+			err = tx.Set([]byte(fmt.Sprintf("key%d_%d", i, j)), nil, []byte(fmt.Sprintf("value%d_%d", i, j)))
+			require.NoError(t, err)
+		}
+
+		_, err = tx.Commit()
+		require.NoError(t, err)
+	}
+
+	tx := immuStore.NewTxHolder()
+	targetTx := immuStore.NewTxHolder()
+
+	for txID := uint64(1); txID <= uint64(txCount); txID++ {
+		err = immuStore.ReadTx(txID, tx)
+		require.NoError(t, err)
+
+		for targetTxID := txID; targetTxID <= uint64(txCount); targetTxID++ {
+			err = immuStore.ReadTx(targetTxID, targetTx)
+			require.NoError(t, err)
+
+			for j := 0; j < eCount; j++ {
+				key := []byte(fmt.Sprintf("key%d_%d", txID-1, j))
+
+				entryDigest, path, err := immuStore.AuditPath(tx, key, targetTx)
+				if err == ErrLinearProofMaxLenExceeded {
+					// targetTx was committed before tx got linked to the binary linking tree
+					require.Greater(t, txID, targetTx.header.BlTxID)
+					continue
+				}
+				require.NoError(t, err)
+				require.Equal(t, targetTx.header.Alh(), EvalAuditPath(path, entryDigest))
+
+				iproof, err := tx.Proof(key)
+				require.NoError(t, err)
+				require.True(t, VerifyInclusion(iproof, entryDigest, tx.header.Eh))
+			}
+		}
+	}
+
+	err = immuStore.ReadTx(2, tx)
+	require.NoError(t, err)
+
+	err = immuStore.ReadTx(1, targetTx)
+	require.NoError(t, err)
+
+	_, _, err = immuStore.AuditPath(nil, []byte("key1_0"), targetTx)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, _, err = immuStore.AuditPath(tx, []byte("key1_0"), targetTx)
+	require.ErrorIs(t, err, ErrSourceTxNewerThanTargetTx)
+
+	_, _, err = immuStore.AuditPath(targetTx, []byte("key1_0"), tx)
+	require.ErrorIs(t, err, ErrKeyNotFound)
+
+	// a tampered path does not lead to the alh of the target tx
+	entryDigest, path, err := immuStore.AuditPath(targetTx, []byte("key0_0"), tx)
+	require.NoError(t, err)
+
+	path[len(path)/2].Prefix[0] ^= 1
+	require.NotEqual(t, tx.header.Alh(), EvalAuditPath(path, entryDigest))
+}
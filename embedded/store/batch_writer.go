@@ -0,0 +1,280 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package store
+
+import (
+	"sync"
+	"time"
+)
+
+const DefaultBatchFlushInterval = 10 * time.Millisecond
+
+// BatchCallback is called once the entry it was written with is committed, or failed to be
+type BatchCallback func(hdr *TxHeader, err error)
+
+type BatchWriterOptions struct {
+	// MaxBatchEntries is the maximum number of entries committed in a single transaction,
+	// the store limit is used when zero
+	MaxBatchEntries int
+
+	// FlushInterval is the maximum time written entries wait to be committed
+	FlushInterval time.Duration
+}
+
+func DefaultBatchWriterOptions() *BatchWriterOptions {
+	return &BatchWriterOptions{
+		FlushInterval: DefaultBatchFlushInterval,
+	}
+}
+
+func (opts *BatchWriterOptions) WithMaxBatchEntries(maxBatchEntries int) *BatchWriterOptions {
+	opts.MaxBatchEntries = maxBatchEntries
+	return opts
+}
+
+func (opts *BatchWriterOptions) WithFlushInterval(flushInterval time.Duration) *BatchWriterOptions {
+	opts.FlushInterval = flushInterval
+	return opts
+}
+
+// BatchWriter accumulates the entries written by concurrent callers and commits them
+// in as few transactions as possible: a transaction is committed once it holds
+// MaxBatchEntries entries or when the flush interval elapses.
+// Entries are committed in the order they were written, an entry updating a key already
+// written in the pending batch is committed in a later transaction so every write is kept as a revision.
+type BatchWriter struct {
+	st              *ImmuStore
+	maxBatchEntries int
+	flushInterval   time.Duration
+
+	mutex   sync.Mutex
+	pending []*batchEntry
+	closed  bool
+
+	// commitMutex ensures batches are committed in the order their entries were written
+	commitMutex sync.Mutex
+
+	flushC chan struct{}
+	doneC  chan struct{}
+	wg     sync.WaitGroup
+}
+
+type batchEntry struct {
+	key      []byte
+	md       *KVMetadata
+	value    []byte
+	callback BatchCallback
+}
+
+// NewBatchWriter returns a writer committing into the store until it's closed
+func (s *ImmuStore) NewBatchWriter(opts *BatchWriterOptions) (*BatchWriter, error) {
+	if opts == nil || opts.MaxBatchEntries < 0 || opts.MaxBatchEntries > s.maxTxEntries || opts.FlushInterval <= 0 {
+		return nil, ErrIllegalArguments
+	}
+
+	maxBatchEntries := opts.MaxBatchEntries
+	if maxBatchEntries == 0 {
+		maxBatchEntries = s.maxTxEntries
+	}
+
+	w := &BatchWriter{
+		st:              s,
+		maxBatchEntries: maxBatchEntries,
+		flushInterval:   opts.FlushInterval,
+		flushC:          make(chan struct{}, 1),
+		doneC:           make(chan struct{}),
+	}
+
+	w.wg.Add(1)
+	go w.flushPeriodically()
+
+	return w, nil
+}
+
+// Write adds an entry to the pending batch, callback (which may be nil) is called once it's committed.
+// Key and value must not be modified until then.
+func (w *BatchWriter) Write(key []byte, md *KVMetadata, value []byte, callback BatchCallback) error {
+	if len(key) == 0 {
+		return ErrNullKey
+	}
+
+	if len(key) > w.st.maxKeyLen {
+		return ErrorMaxKeyLenExceeded
+	}
+
+	if len(value) > w.st.maxStorableValueLen() {
+		return ErrorMaxValueLenExceeded
+	}
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.closed {
+		return ErrAlreadyClosed
+	}
+
+	w.pending = append(w.pending, &batchEntry{
+		key:      key,
+		md:       md,
+		value:    value,
+		callback: callback,
+	})
+
+	if len(w.pending) >= w.maxBatchEntries {
+		select {
+		case w.flushC <- struct{}{}:
+		default:
+		}
+	}
+
+	return nil
+}
+
+func (w *BatchWriter) flushPeriodically() {
+	defer w.wg.Done()
+
+	ticker := time.NewTicker(w.flushInterval)
+	defer ticker.Stop()
+
+	// errors are reported to the callbacks of the entries
+	for {
+		select {
+		case <-w.doneC:
+			return
+		case <-ticker.C:
+			w.flush(false)
+		case <-w.flushC:
+			w.flush(true)
+		}
+	}
+}
+
+// Flush commits the pending entries, returning the first error found while committing them
+func (w *BatchWriter) Flush() error {
+	return w.flush(false)
+}
+
+// flush commits the pending entries, when onlyFullBatches is set the entries not filling
+// a whole batch are kept pending until more entries are written or the flush interval elapses
+func (w *BatchWriter) flush(onlyFullBatches bool) error {
+	w.commitMutex.Lock()
+	defer w.commitMutex.Unlock()
+
+	w.mutex.Lock()
+	pending := w.pending
+	w.pending = nil
+	w.mutex.Unlock()
+
+	batches := w.batchesOf(pending)
+
+	if onlyFullBatches && len(batches) > 0 && len(batches[len(batches)-1]) < w.maxBatchEntries {
+		last := batches[len(batches)-1]
+		batches = batches[:len(batches)-1]
+
+		w.mutex.Lock()
+		w.pending = append(last, w.pending...)
+		w.mutex.Unlock()
+	}
+
+	var firstErr error
+
+	for _, batch := range batches {
+		err := w.commit(batch)
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
+
+// batchesOf splits the entries into batches of up to maxBatchEntries entries with no repeated keys
+func (w *BatchWriter) batchesOf(entries []*batchEntry) [][]*batchEntry {
+	var batches [][]*batchEntry
+
+	var batch []*batchEntry
+	var batchKeys map[string]struct{}
+
+	for _, e := range entries {
+		_, isKeyUpdate := batchKeys[string(e.key)]
+
+		if batch == nil || len(batch) == w.maxBatchEntries || isKeyUpdate {
+			if batch != nil {
+				batches = append(batches, batch)
+			}
+
+			batch = make([]*batchEntry, 0, w.maxBatchEntries)
+			batchKeys = make(map[string]struct{}, w.maxBatchEntries)
+		}
+
+		batch = append(batch, e)
+		batchKeys[string(e.key)] = struct{}{}
+	}
+
+	if batch != nil {
+		batches = append(batches, batch)
+	}
+
+	return batches
+}
+
+func (w *BatchWriter) commit(batch []*batchEntry) error {
+	hdr, err := w.commitTx(batch)
+
+	for _, e := range batch {
+		if e.callback != nil {
+			e.callback(hdr, err)
+		}
+	}
+
+	return err
+}
+
+func (w *BatchWriter) commitTx(batch []*batchEntry) (*TxHeader, error) {
+	tx, err := w.st.NewWriteOnlyTx()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, e := range batch {
+		err = tx.Set(e.key, e.md, e.value)
+		if err != nil {
+			tx.Cancel()
+			return nil, err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Close commits the pending entries, no more entries can be written afterwards
+func (w *BatchWriter) Close() error {
+	w.mutex.Lock()
+
+	if w.closed {
+		w.mutex.Unlock()
+		return ErrAlreadyClosed
+	}
+
+	w.closed = true
+	close(w.doneC)
+
+	w.mutex.Unlock()
+
+	w.wg.Wait()
+
+	return w.Flush()
+}
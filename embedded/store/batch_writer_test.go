@@ -0,0 +1,160 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package store
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatchWriter(t *testing.T) {
+	immuStore, err := Open("data_batch_writer", DefaultOptions().WithSynced(false).WithMaxTxEntries(100))
+	require.NoError(t, err)
+	defer os.RemoveAll("data_batch_writer")
+	defer immuStore.Close()
+
+	_, err = immuStore.NewBatchWriter(nil)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = immuStore.NewBatchWriter(DefaultBatchWriterOptions().WithMaxBatchEntries(101))
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = immuStore.NewBatchWriter(DefaultBatchWriterOptions().WithFlushInterval(0))
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	t.Run("concurrent writes should be committed in batches", func(t *testing.T) {
+		w, err := immuStore.NewBatchWriter(DefaultBatchWriterOptions().WithFlushInterval(time.Hour))
+		require.NoError(t, err)
+
+		workers := 10
+		entries := 50
+
+		var mutex sync.Mutex
+		txIDs := make(map[uint64]int)
+
+		var wg sync.WaitGroup
+		wg.Add(workers * entries)
+
+		var writers sync.WaitGroup
+		writers.Add(workers)
+
+		for i := 0; i < workers; i++ {
+			go func(i int) {
+				defer writers.Done()
+
+				for j := 0; j < entries; j++ {
+					err := w.Write([]byte(fmt.Sprintf("key_%d_%d", i, j)), nil, []byte(fmt.Sprintf("value_%d_%d", i, j)), func(hdr *TxHeader, err error) {
+						defer wg.Done()
+
+						require.NoError(t, err)
+
+						mutex.Lock()
+						txIDs[hdr.ID]++
+						mutex.Unlock()
+					})
+					require.NoError(t, err)
+				}
+			}(i)
+		}
+
+		writers.Wait()
+
+		// the flush interval is too long, the entries are committed either when batches are full or when closing
+		err = w.Close()
+		require.NoError(t, err)
+
+		wg.Wait()
+
+		require.Len(t, txIDs, workers*entries/100)
+
+		for _, n := range txIDs {
+			require.Equal(t, 100, n)
+		}
+
+		err = w.Write([]byte("key"), nil, nil, nil)
+		require.ErrorIs(t, err, ErrAlreadyClosed)
+
+		err = w.Close()
+		require.ErrorIs(t, err, ErrAlreadyClosed)
+
+		valRef, err := immuStore.Get([]byte("key_3_7"))
+		require.NoError(t, err)
+
+		val, err := valRef.Resolve()
+		require.NoError(t, err)
+		require.Equal(t, []byte("value_3_7"), val)
+	})
+
+	t.Run("updates to a key should be committed in separate transactions", func(t *testing.T) {
+		w, err := immuStore.NewBatchWriter(DefaultBatchWriterOptions().WithFlushInterval(time.Hour))
+		require.NoError(t, err)
+
+		var hdrs []*TxHeader
+
+		for i := 0; i < 3; i++ {
+			err = w.Write([]byte("counter"), nil, []byte{byte(i)}, func(hdr *TxHeader, err error) {
+				require.NoError(t, err)
+				hdrs = append(hdrs, hdr)
+			})
+			require.NoError(t, err)
+		}
+
+		err = w.Flush()
+		require.NoError(t, err)
+
+		require.Len(t, hdrs, 3)
+		require.Less(t, hdrs[0].ID, hdrs[1].ID)
+		require.Less(t, hdrs[1].ID, hdrs[2].ID)
+
+		err = w.Close()
+		require.NoError(t, err)
+	})
+
+	t.Run("pending entries should be committed once the flush interval elapses", func(t *testing.T) {
+		w, err := immuStore.NewBatchWriter(DefaultBatchWriterOptions().WithMaxBatchEntries(10))
+		require.NoError(t, err)
+		defer w.Close()
+
+		err = w.Write(nil, nil, nil, nil)
+		require.ErrorIs(t, err, ErrNullKey)
+
+		err = w.Write([]byte("key"), nil, make([]byte, immuStore.maxValueLen+1), nil)
+		require.ErrorIs(t, err, ErrorMaxValueLenExceeded)
+
+		err = w.Write(make([]byte, immuStore.maxKeyLen+1), nil, nil, nil)
+		require.ErrorIs(t, err, ErrorMaxKeyLenExceeded)
+
+		committed := make(chan *TxHeader, 1)
+
+		err = w.Write([]byte("key"), nil, []byte("value"), func(hdr *TxHeader, err error) {
+			require.NoError(t, err)
+			committed <- hdr
+		})
+		require.NoError(t, err)
+
+		select {
+		case hdr := <-committed:
+			require.Equal(t, 1, hdr.NEntries)
+		case <-time.After(5 * time.Second):
+			require.Fail(t, "entry not committed")
+		}
+	})
+}
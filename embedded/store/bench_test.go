@@ -0,0 +1,217 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"runtime"
+	"sort"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/logger"
+)
+
+// Benchmarks run by 'make bench-store', they report allocations and GC cycles per commit
+
+func openBenchStore(b *testing.B, opts *Options) *ImmuStore {
+	dir, err := ioutil.TempDir("", "store_bench")
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { os.RemoveAll(dir) })
+
+	immuStore, err := Open(dir, opts.WithLog(logger.NewSimpleLogger("immudb ", ioutil.Discard)))
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { immuStore.Close() })
+
+	return immuStore
+}
+
+func benchCommit(b *testing.B, immuStore *ImmuStore, n uint64, eCount int, kv []byte) {
+	tx, err := immuStore.NewWriteOnlyTx()
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	for j := 0; j < eCount; j++ {
+		k := make([]byte, 16)
+		binary.BigEndian.PutUint64(k, n)
+		binary.BigEndian.PutUint64(k[8:], uint64(j))
+
+		err = tx.Set(k, nil, kv)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	_, err = tx.Commit()
+	if err != nil {
+		b.Fatal(err)
+	}
+}
+
+func reportGCCycles(b *testing.B, before *runtime.MemStats) {
+	var after runtime.MemStats
+	runtime.ReadMemStats(&after)
+
+	b.ReportMetric(float64(after.NumGC-before.NumGC)/float64(b.N), "gc/op")
+}
+
+func BenchmarkStoreCommit(b *testing.B) {
+	for _, eCount := range []int{1, 10, 100} {
+		b.Run(fmt.Sprintf("entries_%d", eCount), func(b *testing.B) {
+			opts := DefaultOptions().
+				WithSynced(false).
+				WithMaxConcurrency(1)
+
+			immuStore := openBenchStore(b, opts)
+
+			value := make([]byte, 32)
+
+			var before runtime.MemStats
+			runtime.ReadMemStats(&before)
+
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				benchCommit(b, immuStore, uint64(i), eCount, value)
+			}
+
+			b.StopTimer()
+			reportGCCycles(b, &before)
+		})
+	}
+}
+
+func BenchmarkStoreConcurrentCommit(b *testing.B) {
+	opts := DefaultOptions().
+		WithSynced(false).
+		WithMaxConcurrency(runtime.GOMAXPROCS(0))
+
+	immuStore := openBenchStore(b, opts)
+
+	value := make([]byte, 32)
+
+	var n uint64
+
+	var before runtime.MemStats
+	runtime.ReadMemStats(&before)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			benchCommit(b, immuStore, atomic.AddUint64(&n, 1), 10, value)
+		}
+	})
+
+	b.StopTimer()
+	reportGCCycles(b, &before)
+}
+
+func BenchmarkStoreCommitWithValueDedup(b *testing.B) {
+	opts := DefaultOptions().
+		WithSynced(false).
+		WithMaxConcurrency(1).
+		WithValueDedup(true)
+
+	immuStore := openBenchStore(b, opts)
+
+	value := make([]byte, 32)
+
+	var before runtime.MemStats
+	runtime.ReadMemStats(&before)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		benchCommit(b, immuStore, uint64(i), 10, value)
+	}
+
+	b.StopTimer()
+	reportGCCycles(b, &before)
+}
+
+// BenchmarkStoreCommitLatency compares the p99 commit latency with large index flush buffers
+// allocated in and outside of the Go heap, while the index is flushed as commits are made
+func BenchmarkStoreCommitLatency(b *testing.B) {
+	for _, offHeap := range []bool{false, true} {
+		b.Run(fmt.Sprintf("off_heap_flush_buffers_%v", offHeap), func(b *testing.B) {
+			opts := DefaultOptions().
+				WithSynced(false).
+				WithMaxConcurrency(1).
+				WithIndexOptions(DefaultIndexOptions().
+					WithFlushThld(1000).
+					WithFlushBufferSize(64 * 1024 * 1024).
+					WithOffHeapFlushBuffers(offHeap))
+
+			immuStore := openBenchStore(b, opts)
+
+			value := make([]byte, 32)
+			latencies := make([]time.Duration, b.N)
+
+			var before runtime.MemStats
+			runtime.ReadMemStats(&before)
+
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				start := time.Now()
+				benchCommit(b, immuStore, uint64(i), 10, value)
+				latencies[i] = time.Since(start)
+			}
+
+			b.StopTimer()
+			reportGCCycles(b, &before)
+
+			sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+			b.ReportMetric(float64(latencies[b.N*99/100].Nanoseconds()), "p99-ns/op")
+		})
+	}
+}
+
+func BenchmarkTxBuildHashTree(b *testing.B) {
+	tx := newTx(100, DefaultMaxKeyLen)
+	tx.header.Version = 1
+
+	for i, e := range tx.Entries() {
+		var k [8]byte
+		binary.BigEndian.PutUint64(k[:], uint64(i))
+		e.setKey(k[:])
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		err := tx.BuildHashTree()
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"crypto/sha256"
+	"sync"
+)
+
+// Buffers used while building and committing transactions are shared through pools
+// so to reduce garbage generation at high commit rates.
+// Buffers taken from a pool must not be retained once released.
+
+var entryBufPool = sync.Pool{
+	New: func() interface{} {
+		b := make([]byte, 0, sszSize+sszSize+DefaultMaxKeyLen+sha256.Size)
+		return &b
+	},
+}
+
+// fetchEntryBuf returns a buffer with length n, used to calculate entry digests
+func fetchEntryBuf(n int) *[]byte {
+	b := entryBufPool.Get().(*[]byte)
+
+	if cap(*b) < n {
+		*b = make([]byte, n)
+	}
+
+	*b = (*b)[:n]
+
+	return b
+}
+
+func releaseEntryBuf(b *[]byte) {
+	entryBufPool.Put(b)
+}
+
+var digestsPool = sync.Pool{
+	New: func() interface{} {
+		return &[][sha256.Size]byte{}
+	},
+}
+
+// fetchDigests returns a slice with length n, used to build the hash tree of a tx
+func fetchDigests(n int) *[][sha256.Size]byte {
+	d := digestsPool.Get().(*[][sha256.Size]byte)
+
+	if cap(*d) < n {
+		*d = make([][sha256.Size]byte, n)
+	}
+
+	*d = (*d)[:n]
+
+	return d
+}
+
+func releaseDigests(d *[][sha256.Size]byte) {
+	digestsPool.Put(d)
+}
+
+var keySetPool = sync.Pool{
+	New: func() interface{} {
+		return make(map[string]struct{})
+	},
+}
+
+// fetchKeySet returns an empty set, used to detect duplicated keys within a tx
+func fetchKeySet() map[string]struct{} {
+	return keySetPool.Get().(map[string]struct{})
+}
+
+func releaseKeySet(m map[string]struct{}) {
+	for k := range m {
+		delete(m, k)
+	}
+
+	keySetPool.Put(m)
+}
+
+// appendBuffers holds what is needed to append the values of a tx
+type appendBuffers struct {
+	offsets  []int64
+	lens     []int
+	values   [][]byte      // compressed values, nil when values are stored as they are
+	mds      []*KVMetadata // metadata the entries are committed with
+	appended map[[sha256.Size]byte]dedupValue
+	donec    chan appendableResult
+}
+
+var appendBuffersPool = sync.Pool{
+	New: func() interface{} {
+		return &appendBuffers{
+			appended: make(map[[sha256.Size]byte]dedupValue),
+			donec:    make(chan appendableResult),
+		}
+	},
+}
+
+// fetchAppendBuffers returns buffers with room for n values,
+// the result of appending the values is sent through its channel
+func fetchAppendBuffers(n int) *appendBuffers {
+	b := appendBuffersPool.Get().(*appendBuffers)
+
+	if cap(b.offsets) < n {
+		b.offsets = make([]int64, n)
+		b.lens = make([]int, n)
+		b.values = make([][]byte, n)
+		b.mds = make([]*KVMetadata, n)
+	}
+
+	b.offsets = b.offsets[:n]
+	b.lens = b.lens[:n]
+	b.values = b.values[:n]
+	b.mds = b.mds[:n]
+
+	for i := 0; i < n; i++ {
+		b.offsets[i] = 0
+		b.lens[i] = 0
+	}
+
+	return b
+}
+
+// releaseAppendBuffers must be called once the result of appending the values was received
+func releaseAppendBuffers(b *appendBuffers) {
+	for i := range b.values {
+		b.values[i] = nil
+		b.mds[i] = nil
+	}
+
+	for h := range b.appended {
+		delete(b.appended, h)
+	}
+
+	appendBuffersPool.Put(b)
+}
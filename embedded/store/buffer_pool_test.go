@@ -0,0 +1,68 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBufferPools(t *testing.T) {
+	t.Run("entry buffers grow as needed", func(t *testing.T) {
+		b := fetchEntryBuf(10)
+		require.Len(t, *b, 10)
+		releaseEntryBuf(b)
+
+		b = fetchEntryBuf(10_000)
+		require.Len(t, *b, 10_000)
+		releaseEntryBuf(b)
+	})
+
+	t.Run("digests grow as needed", func(t *testing.T) {
+		d := fetchDigests(1)
+		require.Len(t, *d, 1)
+		releaseDigests(d)
+
+		d = fetchDigests(100)
+		require.Len(t, *d, 100)
+		releaseDigests(d)
+	})
+
+	t.Run("key sets are released empty", func(t *testing.T) {
+		m := fetchKeySet()
+		m["key1"] = struct{}{}
+		releaseKeySet(m)
+		require.Empty(t, m)
+	})
+
+	t.Run("append buffers are reset", func(t *testing.T) {
+		b := fetchAppendBuffers(2)
+		b.offsets[1] = 10
+		b.lens[1] = 5
+		b.appended[sha256.Sum256(nil)] = dedupValue{off: 10, vLen: 5}
+		releaseAppendBuffers(b)
+		require.Empty(t, b.appended)
+
+		b = fetchAppendBuffers(2)
+		require.Equal(t, []int64{0, 0}, b.offsets)
+		require.Equal(t, []int{0, 0}, b.lens)
+		require.Empty(t, b.appended)
+		releaseAppendBuffers(b)
+	})
+}
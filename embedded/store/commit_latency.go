@@ -0,0 +1,96 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package store
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// commitLatencySamples is the number of the latest commits kept to compute the commit latency
+const commitLatencySamples = 1024
+
+// commitLatencyWindow is the period of time during which commits are taken into account
+const commitLatencyWindow = time.Minute
+
+type latencySample struct {
+	at       time.Time
+	duration time.Duration
+}
+
+// latencyTracker keeps the duration of the latest operations in a circular buffer
+type latencyTracker struct {
+	samples []latencySample
+	next    int
+	mutex   sync.Mutex
+}
+
+func newLatencyTracker(size int) *latencyTracker {
+	return &latencyTracker{
+		samples: make([]latencySample, size),
+	}
+}
+
+func (lt *latencyTracker) add(at time.Time, duration time.Duration) {
+	lt.mutex.Lock()
+	defer lt.mutex.Unlock()
+
+	lt.samples[lt.next] = latencySample{at: at, duration: duration}
+	lt.next = (lt.next + 1) % len(lt.samples)
+}
+
+// percentile returns the p-th percentile of the durations of the operations done since the given time,
+// zero is returned when there are no such operations
+func (lt *latencyTracker) percentile(p float64, since time.Time) time.Duration {
+	lt.mutex.Lock()
+
+	durations := make([]time.Duration, 0, len(lt.samples))
+
+	for _, s := range lt.samples {
+		if !s.at.IsZero() && !s.at.Before(since) {
+			durations = append(durations, s.duration)
+		}
+	}
+
+	lt.mutex.Unlock()
+
+	if len(durations) == 0 {
+		return 0
+	}
+
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+
+	i := int(p*float64(len(durations))/100+0.5) - 1
+	if i < 0 {
+		i = 0
+	}
+	if i >= len(durations) {
+		i = len(durations) - 1
+	}
+
+	return durations[i]
+}
+
+// CommitLatency returns the p-th percentile (0 < p <= 100) of the duration of the transactions
+// committed during the last minute, zero is returned when no transaction was committed
+func (s *ImmuStore) CommitLatency(p float64) (time.Duration, error) {
+	if p <= 0 || p > 100 {
+		return 0, ErrIllegalArguments
+	}
+
+	return s.commitLatencies.percentile(p, time.Now().Add(-commitLatencyWindow)), nil
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package store
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLatencyTracker(t *testing.T) {
+	lt := newLatencyTracker(10)
+
+	now := time.Now()
+
+	require.Zero(t, lt.percentile(95, now.Add(-time.Minute)))
+
+	for i := 1; i <= 20; i++ {
+		lt.add(now, time.Duration(i)*time.Millisecond)
+	}
+
+	// only the latest 10 samples are kept
+	require.Equal(t, 11*time.Millisecond, lt.percentile(1, now))
+	require.Equal(t, 15*time.Millisecond, lt.percentile(50, now))
+	require.Equal(t, 20*time.Millisecond, lt.percentile(95, now))
+	require.Equal(t, 20*time.Millisecond, lt.percentile(100, now))
+
+	// samples taken before the given time are not considered
+	lt.add(now.Add(time.Second), time.Millisecond)
+	require.Equal(t, time.Millisecond, lt.percentile(95, now.Add(time.Second)))
+}
+
+func TestImmudbStoreCommitLatency(t *testing.T) {
+	dir, err := ioutil.TempDir("", "store_commit_latency")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	immuStore, err := Open(dir, DefaultOptions())
+	require.NoError(t, err)
+	defer immuStore.Close()
+
+	_, err = immuStore.CommitLatency(0)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = immuStore.CommitLatency(101)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	latency, err := immuStore.CommitLatency(95)
+	require.NoError(t, err)
+	require.Zero(t, latency)
+
+	tx, err := immuStore.NewWriteOnlyTx()
+	require.NoError(t, err)
+
+	err = tx.Set([]byte("key"), nil, []byte("value"))
+	require.NoError(t, err)
+
+	_, err = tx.Commit()
+	require.NoError(t, err)
+
+	latency, err = immuStore.CommitLatency(95)
+	require.NoError(t, err)
+	require.Positive(t, latency)
+}
@@ -0,0 +1,427 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package store
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// stages of the commit of a transaction
+const (
+	commitStageValues    = "values"     // values written into a value log
+	commitStageSerialize = "serialize"  // entries hashed and, when the commit pipeline is enabled, serialized
+	commitStageTxLog     = "tx_log"     // transaction serialized into the transaction log
+	commitStageCommitLog = "commit_log" // transaction synced and made visible through the commit log
+)
+
+var (
+	metricsCommitStageDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "immudb_commit_stage_duration_seconds",
+		Help:    "Duration of each stage of the commit of transactions: values, serialize, tx_log and commit_log",
+		Buckets: prometheus.ExponentialBuckets(0.00005, 2, 16),
+	}, []string{"db", "stage"})
+
+	metricsCommitSyncBatchSize = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "immudb_commit_sync_batch_size",
+		Help:    "Number of transactions synced together by the commit pipeline",
+		Buckets: prometheus.ExponentialBuckets(1, 2, 10),
+	}, []string{"db"})
+
+	metricsCommitAppendBatchSize = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "immudb_commit_append_batch_size",
+		Help:    "Number of transactions whose values are synced together by an appender of the commit pipeline",
+		Buckets: prometheus.ExponentialBuckets(1, 2, 10),
+	}, []string{"db"})
+)
+
+func (s *ImmuStore) observeCommitStage(stage string, startedAt time.Time) {
+	metricsCommitStageDuration.WithLabelValues(filepath.Base(s.path), stage).Observe(time.Since(startedAt).Seconds())
+}
+
+// pendingCommit is a transaction already written into the transaction log waiting to be synced
+type pendingCommit struct {
+	txID   uint64
+	alh    [sha256.Size]byte
+	txOff  int64
+	txSize int
+	sync   bool
+
+	// synced is closed once the transaction is committed, or failed to be with err
+	synced chan struct{}
+	err    error
+}
+
+// valueAppend is the request to append the values of a transaction, the result is sent through the channel of the buffers
+type valueAppend struct {
+	entries []*EntrySpec
+	buffers *appendBuffers
+}
+
+// valueAppender appends values into the value log it owns, syncing together the values queued while it was busy
+type valueAppender struct {
+	vLogID  byte
+	appendC chan *valueAppend
+	doneC   chan struct{}
+}
+
+// commitPipeline splits the commit of transactions into stages run concurrently for different transactions:
+//   - values: each value log is written by its own appender, values being queued to the least busy one.
+//     Appenders may be pinned to the CPUs of the NUMA nodes of the host, spread across nodes
+//   - serialize: entries are hashed and serialized, by a bounded number of commits at once
+//   - tx_log: transactions are written into the transaction log in order, while holding the store mutex
+//   - commit_log: transactions written while a batch of them was being synced are synced together
+//     into the transaction and commit logs and made visible
+type commitPipeline struct {
+	st *ImmuStore
+
+	appenders    []*valueAppender
+	appendMutex  sync.RWMutex
+	appendClosed bool
+
+	serializers chan struct{}
+
+	pendingC chan *pendingCommit
+	doneC    chan struct{}
+
+	// last is the last transaction sent through the pipeline, it's guarded by the store mutex
+	last *pendingCommit
+
+	errMutex sync.Mutex
+	err      error
+}
+
+func newCommitPipeline(st *ImmuStore, opts *Options) *commitPipeline {
+	appenders := opts.CommitPipelineAppenders
+	if appenders == 0 {
+		appenders = len(st.vLogs)
+	}
+
+	serializers := opts.CommitPipelineSerializers
+	if serializers == 0 {
+		serializers = opts.MaxConcurrency
+	}
+
+	p := &commitPipeline{
+		st:          st,
+		appenders:   make([]*valueAppender, appenders),
+		serializers: make(chan struct{}, serializers),
+		pendingC:    make(chan *pendingCommit, opts.CommitPipelineDepth),
+		doneC:       make(chan struct{}),
+	}
+
+	var nodes [][]int
+
+	if opts.CommitPipelineAffinity {
+		var err error
+
+		nodes, err = numaNodeCPUs()
+		if err != nil {
+			st.log.Warningf("Appenders of the commit pipeline at '%s' are not pinned to CPUs: %v", st.path, err)
+		}
+	}
+
+	for i := range p.appenders {
+		a := &valueAppender{
+			vLogID:  byte(i + 1),
+			appendC: make(chan *valueAppend, opts.CommitPipelineDepth),
+			doneC:   make(chan struct{}),
+		}
+
+		var cpus []int
+		if len(nodes) > 0 {
+			cpus = nodes[i%len(nodes)]
+		}
+
+		p.appenders[i] = a
+
+		go p.runAppender(a, cpus)
+	}
+
+	go p.syncPending()
+
+	return p
+}
+
+// appendValues queues the values of the entries to the appender with the fewest queued transactions
+func (p *commitPipeline) appendValues(entries []*EntrySpec, buffers *appendBuffers) error {
+	p.appendMutex.RLock()
+	defer p.appendMutex.RUnlock()
+
+	if p.appendClosed {
+		return ErrAlreadyClosed
+	}
+
+	a := p.appenders[0]
+
+	for _, other := range p.appenders[1:] {
+		if len(other.appendC) < len(a.appendC) {
+			a = other
+		}
+	}
+
+	a.appendC <- &valueAppend{entries: entries, buffers: buffers}
+
+	return nil
+}
+
+func (p *commitPipeline) runAppender(a *valueAppender, cpus []int) {
+	defer close(a.doneC)
+
+	if len(cpus) > 0 {
+		// the thread is discarded once the appender is done
+		runtime.LockOSThread()
+
+		err := setThreadAffinity(cpus)
+		if err != nil {
+			p.st.log.Warningf("Appender of value log %d at '%s' is not pinned to CPUs %v: %v", a.vLogID, p.st.path, cpus, err)
+		}
+	}
+
+	for va := range a.appendC {
+		batch := []*valueAppend{va}
+
+		// values queued while the previous batch was appended are synced together
+	queued:
+		for {
+			select {
+			case va, ok := <-a.appendC:
+				if !ok {
+					break queued
+				}
+				batch = append(batch, va)
+			default:
+				break queued
+			}
+		}
+
+		p.st.appendBatch(a.vLogID, batch)
+	}
+}
+
+// serialize runs f once one of the serializers of the pipeline is available
+func (p *commitPipeline) serialize(f func() error) error {
+	p.serializers <- struct{}{}
+	defer func() { <-p.serializers }()
+
+	startedAt := time.Now()
+
+	err := f()
+	if err == nil {
+		p.st.observeCommitStage(commitStageSerialize, startedAt)
+	}
+
+	return err
+}
+
+// send queues a transaction to be synced, it blocks while the pipeline is full.
+// It must be called with the store mutex held, so transactions are queued in order
+func (p *commitPipeline) send(pc *pendingCommit) {
+	p.last = pc
+	p.pendingC <- pc
+}
+
+// waitSynced waits until all the transactions sent through the pipeline are committed.
+// It must be called with the store mutex held
+func (p *commitPipeline) waitSynced() error {
+	if p.last == nil {
+		return nil
+	}
+
+	<-p.last.synced
+
+	return p.last.err
+}
+
+func (p *commitPipeline) failure() error {
+	p.errMutex.Lock()
+	defer p.errMutex.Unlock()
+
+	return p.err
+}
+
+func (p *commitPipeline) syncPending() {
+	defer close(p.doneC)
+
+	for pc := range p.pendingC {
+		batch := []*pendingCommit{pc}
+
+		// transactions queued while the previous batch was synced are synced together
+	queued:
+		for {
+			select {
+			case pc, ok := <-p.pendingC:
+				if !ok {
+					break queued
+				}
+				batch = append(batch, pc)
+			default:
+				break queued
+			}
+		}
+
+		err := p.failure()
+		if err == nil {
+			err = p.st.syncCommits(batch)
+		}
+
+		if err != nil {
+			p.errMutex.Lock()
+			if p.err == nil {
+				// transactions written after a failed sync can not be committed
+				p.err = fmt.Errorf("commit pipeline: %w", err)
+			}
+			err = p.err
+			p.errMutex.Unlock()
+		}
+
+		for _, pc := range batch {
+			pc.err = err
+			close(pc.synced)
+		}
+	}
+}
+
+// close waits until the queued values are appended and the queued transactions are synced,
+// the store mutex must be held so no more transactions are queued
+func (p *commitPipeline) close() {
+	p.appendMutex.Lock()
+	p.appendClosed = true
+	for _, a := range p.appenders {
+		close(a.appendC)
+	}
+	p.appendMutex.Unlock()
+
+	for _, a := range p.appenders {
+		<-a.doneC
+	}
+
+	close(p.pendingC)
+	<-p.doneC
+}
+
+// appendBatch appends the values of a batch of transactions into a vLog, which is flushed once for all of them
+func (s *ImmuStore) appendBatch(vLogID byte, batch []*valueAppend) {
+	startedAt := time.Now()
+
+	vLog := s.fetchVLog(vLogID)
+	defer s.releaseVLog(vLogID)
+
+	errs := make([]error, len(batch))
+
+	for i, va := range batch {
+		errs[i] = s.appendValues(vLogID, vLog, va.entries, va.buffers)
+	}
+
+	err := s.flushVLog(vLog)
+
+	if err == nil {
+		s.observeCommitStage(commitStageValues, startedAt)
+		metricsCommitAppendBatchSize.WithLabelValues(filepath.Base(s.path)).Observe(float64(len(batch)))
+	}
+
+	for i, va := range batch {
+		if errs[i] == nil {
+			errs[i] = err
+		}
+
+		if errs[i] != nil {
+			va.buffers.donec <- appendableResult{nil, nil, errs[i]}
+			continue
+		}
+
+		va.buffers.donec <- appendableResult{va.buffers.offsets, va.buffers.lens, nil}
+	}
+}
+
+// syncCommits syncs the transaction log, appends the transactions to the commit log and makes them visible
+func (s *ImmuStore) syncCommits(batch []*pendingCommit) error {
+	startedAt := time.Now()
+
+	sync := false
+
+	for _, pc := range batch {
+		sync = sync || pc.sync
+	}
+
+	if sync {
+		err := s.txLog.Sync()
+		if err != nil {
+			return err
+		}
+	}
+
+	committedTxID, _, _ := s.commitState()
+
+	// will overwrite partially written and uncommitted data
+	err := s.cLog.SetOffset(int64(committedTxID * cLogEntrySize))
+	if err != nil {
+		return err
+	}
+
+	for _, pc := range batch {
+		var cb [cLogEntrySize]byte
+		binary.BigEndian.PutUint64(cb[:], uint64(pc.txOff))
+		binary.BigEndian.PutUint32(cb[offsetSize:], uint32(pc.txSize))
+		_, _, err = s.cLog.Append(cb[:])
+		if err != nil {
+			return err
+		}
+	}
+
+	err = s.cLog.Flush()
+	if err != nil {
+		return err
+	}
+
+	if sync {
+		err = s.cLog.Sync()
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, pc := range batch {
+		committedTxID = s.advanceCommitState(pc.alh, int64(pc.txSize))
+	}
+
+	s.wHub.DoneUpto(committedTxID)
+
+	s.observeCommitStage(commitStageCommitLog, startedAt)
+	metricsCommitSyncBatchSize.WithLabelValues(filepath.Base(s.path)).Observe(float64(len(batch)))
+
+	return nil
+}
+
+// waitCommitted waits until a transaction sent through the commit pipeline is committed,
+// transactions committed synchronously are represented by a nil pendingCommit
+func waitCommitted(pc *pendingCommit) error {
+	if pc == nil {
+		return nil
+	}
+
+	<-pc.synced
+
+	return pc.err
+}
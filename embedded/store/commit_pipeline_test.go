@@ -0,0 +1,225 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package store
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommitPipeline(t *testing.T) {
+	dir := "data_commit_pipeline"
+	defer os.RemoveAll(dir)
+
+	_, err := Open(dir, DefaultOptions().WithCommitPipelineDepth(-1))
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = Open(dir, DefaultOptions().WithCommitPipelineDepth(4).WithCommitPipelineAppenders(-1))
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = Open(dir, DefaultOptions().WithCommitPipelineDepth(4).WithCommitPipelineAppenders(DefaultMaxIOConcurrency+1))
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = Open(dir, DefaultOptions().WithCommitPipelineDepth(4).WithCommitPipelineSerializers(-1))
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	immuStore, err := Open(dir, DefaultOptions().WithCommitPipelineDepth(4))
+	require.NoError(t, err)
+
+	writers := 10
+	txsPerWriter := 20
+
+	var wg sync.WaitGroup
+	wg.Add(writers)
+
+	var mutex sync.Mutex
+	committed := make(map[uint64]string)
+
+	for i := 0; i < writers; i++ {
+		go func(i int) {
+			defer wg.Done()
+
+			for j := 0; j < txsPerWriter; j++ {
+				tx, err := immuStore.NewWriteOnlyTx()
+				require.NoError(t, err)
+
+				key := fmt.Sprintf("key_%d_%d", i, j)
+
+				err = tx.Set([]byte(key), nil, []byte(key))
+				require.NoError(t, err)
+
+				hdr, err := tx.Commit()
+				require.NoError(t, err)
+
+				// transactions are visible once committed
+				require.LessOrEqual(t, hdr.ID, immuStore.TxCount())
+
+				mutex.Lock()
+				committed[hdr.ID] = key
+				mutex.Unlock()
+			}
+		}(i)
+	}
+
+	wg.Wait()
+
+	require.Len(t, committed, writers*txsPerWriter)
+	require.Equal(t, uint64(writers*txsPerWriter), immuStore.TxCount())
+
+	require.Positive(t, testutil.CollectAndCount(metricsCommitSyncBatchSize))
+	require.Positive(t, testutil.CollectAndCount(metricsCommitStageDuration))
+
+	t.Run("preconditions should be checked against pending transactions", func(t *testing.T) {
+		tx, err := immuStore.NewWriteOnlyTx()
+		require.NoError(t, err)
+
+		err = tx.Set([]byte("key_0_0"), nil, []byte("updated"))
+		require.NoError(t, err)
+
+		err = tx.AddPrecondition(&PreconditionKeyMustNotExist{Key: []byte("key_0_0")})
+		require.NoError(t, err)
+
+		_, err = tx.Commit()
+		require.ErrorIs(t, err, ErrPreconditionFailed)
+	})
+
+	err = immuStore.Close()
+	require.NoError(t, err)
+
+	// transactions committed through the pipeline are readable once reopened without it
+	immuStore, err = Open(dir, DefaultOptions())
+	require.NoError(t, err)
+	defer immuStore.Close()
+
+	require.Equal(t, uint64(writers*txsPerWriter), immuStore.TxCount())
+
+	tx := immuStore.NewTxHolder()
+
+	for txID, key := range committed {
+		err = immuStore.ReadTx(txID, tx)
+		require.NoError(t, err)
+
+		_, err = tx.EntryOf([]byte(key))
+		require.NoError(t, err)
+	}
+
+	_, err = immuStore.LinearProof(1, uint64(writers*txsPerWriter))
+	require.NoError(t, err)
+}
+
+func TestCommitPipelineStages(t *testing.T) {
+	dir := "data_commit_pipeline_stages"
+	defer os.RemoveAll(dir)
+
+	opts := DefaultOptions().
+		WithMaxIOConcurrency(4).
+		WithCommitPipelineDepth(4).
+		WithCommitPipelineAppenders(2).
+		WithCommitPipelineSerializers(1).
+		WithCommitPipelineAffinity(true)
+
+	immuStore, err := Open(dir, opts)
+	require.NoError(t, err)
+
+	require.Len(t, immuStore.commitPipeline.appenders, 2)
+	require.Equal(t, 1, cap(immuStore.commitPipeline.serializers))
+
+	writers := 8
+	txsPerWriter := 20
+
+	var wg sync.WaitGroup
+	wg.Add(writers)
+
+	for i := 0; i < writers; i++ {
+		go func(i int) {
+			defer wg.Done()
+
+			for j := 0; j < txsPerWriter; j++ {
+				tx, err := immuStore.NewWriteOnlyTx()
+				require.NoError(t, err)
+
+				key := fmt.Sprintf("key_%d_%d", i, j)
+
+				err = tx.Set([]byte(key), nil, []byte("value_"+key))
+				require.NoError(t, err)
+
+				_, err = tx.Commit()
+				require.NoError(t, err)
+			}
+		}(i)
+	}
+
+	wg.Wait()
+
+	require.Equal(t, uint64(writers*txsPerWriter), immuStore.TxCount())
+
+	require.Positive(t, testutil.CollectAndCount(metricsCommitAppendBatchSize))
+
+	var serializeStage dto.Metric
+
+	err = metricsCommitStageDuration.WithLabelValues(dir, commitStageSerialize).(prometheus.Histogram).Write(&serializeStage)
+	require.NoError(t, err)
+	require.Positive(t, serializeStage.GetHistogram().GetSampleCount())
+
+	err = immuStore.Close()
+	require.NoError(t, err)
+
+	err = immuStore.commitPipeline.appendValues(nil, nil)
+	require.ErrorIs(t, err, ErrAlreadyClosed)
+
+	// values appended by appenders are read from the value logs they were written to
+	immuStore, err = Open(dir, DefaultOptions().WithMaxIOConcurrency(4))
+	require.NoError(t, err)
+	defer immuStore.Close()
+
+	err = immuStore.WaitForIndexingUpto(uint64(writers*txsPerWriter), nil)
+	require.NoError(t, err)
+
+	for i := 0; i < writers; i++ {
+		for j := 0; j < txsPerWriter; j++ {
+			key := fmt.Sprintf("key_%d_%d", i, j)
+
+			valRef, err := immuStore.Get([]byte(key))
+			require.NoError(t, err)
+
+			val, err := valRef.Resolve()
+			require.NoError(t, err)
+			require.Equal(t, []byte("value_"+key), val)
+		}
+	}
+}
+
+func TestParseCPUList(t *testing.T) {
+	cpus, err := parseCPUList("0-3,8,10-11\n")
+	require.NoError(t, err)
+	require.Equal(t, []int{0, 1, 2, 3, 8, 10, 11}, cpus)
+
+	cpus, err = parseCPUList("")
+	require.NoError(t, err)
+	require.Empty(t, cpus)
+
+	for _, list := range []string{"a", "0-b", "3-1", "-1", "0,,1"} {
+		_, err = parseCPUList(list)
+		require.Error(t, err, list)
+	}
+}
@@ -0,0 +1,164 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/codenotary/immudb/embedded/appendable"
+	"github.com/codenotary/immudb/embedded/appendable/faulty"
+	"github.com/codenotary/immudb/embedded/appendable/multiapp"
+	"github.com/stretchr/testify/require"
+)
+
+// crashTx is a transaction acknowledged before the crash, it must survive the recovery
+type crashTx struct {
+	id  uint64
+	kvs map[string][]byte
+}
+
+// runUntilCrash commits transactions into a store whose logs inject faults drawn from seed,
+// the first injected fault crashes every log. It returns the acknowledged transactions.
+func runUntilCrash(t *testing.T, dir string, seed int64, maxTxs int) []*crashTx {
+	var apps []*faulty.Appendable
+
+	crashAll := func() {
+		for _, app := range apps {
+			app.Crash()
+		}
+	}
+
+	opts := DefaultOptions().
+		WithMaxConcurrency(1).
+		WithMaxIOConcurrency(1).
+		WithAppFactory(func(rootPath, subPath string, opts *multiapp.Options) (appendable.Appendable, error) {
+			app, err := multiapp.Open(filepath.Join(rootPath, subPath), opts)
+			if err != nil {
+				return nil, err
+			}
+
+			// faults are only injected into the logs, derived data is rebuilt from them
+			if subPath != "tx" && subPath != "commit" && !strings.HasPrefix(subPath, "val_") {
+				return app, nil
+			}
+
+			fapp, err := faulty.Wrap(app, faulty.DefaultOptions().
+				WithSeed(seed*10+int64(len(apps))).
+				WithShortWriteProbability(0.002).
+				WithFlushFailureProbability(0.002).
+				WithSyncFailureProbability(0.002).
+				WithCrashOnFault(true))
+			if err != nil {
+				return nil, err
+			}
+
+			apps = append(apps, fapp)
+
+			return fapp, nil
+		})
+
+	immuStore, err := Open(dir, opts)
+	require.NoError(t, err)
+
+	var acked []*crashTx
+
+	for i := 0; i < maxTxs; i++ {
+		kvs := map[string][]byte{
+			fmt.Sprintf("key%d", i%7):   []byte(fmt.Sprintf("value%d", i)),
+			fmt.Sprintf("other%d", i):   []byte(strings.Repeat("v", i%300)),
+			fmt.Sprintf("third%d", i%3): []byte(fmt.Sprintf("revision%d", i)),
+		}
+
+		tx, err := immuStore.NewWriteOnlyTx()
+		require.NoError(t, err)
+
+		for k, v := range kvs {
+			err = tx.Set([]byte(k), nil, v)
+			require.NoError(t, err)
+		}
+
+		hdr, err := tx.Commit()
+		if err != nil {
+			break
+		}
+
+		acked = append(acked, &crashTx{id: hdr.ID, kvs: kvs})
+	}
+
+	// the process dies, nothing else gets written into the logs
+	crashAll()
+
+	immuStore.Close()
+
+	return acked
+}
+
+func TestCrashRecovery(t *testing.T) {
+	for seed := int64(1); seed <= 20; seed++ {
+		t.Run(fmt.Sprintf("seed %d", seed), func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "crash_recovery")
+			require.NoError(t, err)
+			defer os.RemoveAll(dir)
+
+			acked := runUntilCrash(t, dir, seed, 200)
+
+			_, err = Repair(dir, DefaultOptions())
+			require.NoError(t, err)
+
+			immuStore, err := Open(dir, DefaultOptions().WithMaxConcurrency(1).WithMaxIOConcurrency(1))
+			require.NoError(t, err)
+			defer immuStore.Close()
+
+			// every acknowledged transaction survives the crash
+			require.GreaterOrEqual(t, immuStore.TxCount(), uint64(len(acked)))
+
+			txHolder := immuStore.NewTxHolder()
+
+			for _, atx := range acked {
+				err = immuStore.ReadTx(atx.id, txHolder)
+				require.NoError(t, err)
+				require.Len(t, txHolder.Entries(), len(atx.kvs))
+
+				for _, e := range txHolder.Entries() {
+					val, err := immuStore.ReadValue(e)
+					require.NoError(t, err)
+					require.Equal(t, atx.kvs[string(e.Key())], val)
+				}
+			}
+
+			status, err := immuStore.Scrub()
+			require.NoError(t, err)
+			require.Zero(t, status.MismatchCount)
+
+			// the recovered store accepts new commits
+			tx, err := immuStore.NewWriteOnlyTx()
+			require.NoError(t, err)
+
+			err = tx.Set([]byte("after crash"), nil, []byte("value"))
+			require.NoError(t, err)
+
+			hdr, err := tx.Commit()
+			require.NoError(t, err)
+			require.Equal(t, immuStore.TxCount(), hdr.ID)
+		})
+	}
+}
@@ -0,0 +1,48 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package store
+
+import (
+	"path/filepath"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	metricsKeySize = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "immudb_key_size_bytes",
+		Help:    "Size of the keys of committed entries, as stored including internal prefixes",
+		Buckets: prometheus.ExponentialBuckets(8, 2, 10),
+	}, []string{"db"})
+
+	metricsValueSize = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "immudb_value_size_bytes",
+		Help:    "Size of the values of committed entries, as stored including internal prefixes",
+		Buckets: prometheus.ExponentialBuckets(16, 4, 10),
+	}, []string{"db"})
+)
+
+// observeEntrySizes records the sizes of the entries of a committed transaction
+func (s *ImmuStore) observeEntrySizes(entries []*EntrySpec) {
+	keySize := metricsKeySize.WithLabelValues(filepath.Base(s.path))
+	valueSize := metricsValueSize.WithLabelValues(filepath.Base(s.path))
+
+	for _, e := range entries {
+		keySize.Observe(float64(len(e.Key)))
+		valueSize.Observe(float64(len(e.Value)))
+	}
+}
@@ -0,0 +1,156 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package store
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/codenotary/immudb/embedded/appendable"
+	"github.com/stretchr/testify/require"
+)
+
+const fuzzMaxTxEntries = 8
+const fuzzMaxKeyLen = 32
+
+// fuzzSeedStore opens a store holding a few committed transactions,
+// its logs and exported transactions are used as seed corpus
+func fuzzSeedStore(f *testing.F) *ImmuStore {
+	dir, err := ioutil.TempDir("", "fuzz_store")
+	require.NoError(f, err)
+	f.Cleanup(func() { os.RemoveAll(dir) })
+
+	opts := DefaultOptions().
+		WithMaxTxEntries(fuzzMaxTxEntries).
+		WithMaxKeyLen(fuzzMaxKeyLen).
+		WithMaxConcurrency(1)
+
+	immuStore, err := Open(dir, opts)
+	require.NoError(f, err)
+	f.Cleanup(func() { immuStore.Close() })
+
+	for i := 0; i < 3; i++ {
+		tx, err := immuStore.NewWriteOnlyTx()
+		require.NoError(f, err)
+
+		if i == 1 {
+			txmd := NewTxMetadata()
+			require.NoError(f, txmd.SetUser("fuzz"))
+
+			tx.WithMetadata(txmd)
+		}
+
+		for j := 0; j <= i; j++ {
+			md := NewKVMetadata()
+			if j == 1 {
+				require.NoError(f, md.AsDeleted(true))
+			}
+
+			err = tx.Set([]byte(fmt.Sprintf("key%d", j)), md, []byte(fmt.Sprintf("value%d_%d", i, j)))
+			require.NoError(f, err)
+		}
+
+		_, err = tx.Commit()
+		require.NoError(f, err)
+	}
+
+	return immuStore
+}
+
+func FuzzTxHeaderReadFrom(f *testing.F) {
+	immuStore := fuzzSeedStore(f)
+
+	txHolder := immuStore.NewTxHolder()
+
+	for id := uint64(1); id <= immuStore.TxCount(); id++ {
+		require.NoError(f, immuStore.ReadTx(id, txHolder))
+
+		hdrBs, err := txHolder.Header().Bytes()
+		require.NoError(f, err)
+
+		f.Add(hdrBs)
+	}
+
+	f.Fuzz(func(t *testing.T, b []byte) {
+		hdr := &TxHeader{}
+
+		err := hdr.ReadFrom(b)
+		if err != nil {
+			return
+		}
+
+		// a successfully decoded header must be serializable
+		_, err = hdr.Bytes()
+		require.NoError(t, err)
+	})
+}
+
+func FuzzTxReadFrom(f *testing.F) {
+	immuStore := fuzzSeedStore(f)
+
+	txLogSize, err := immuStore.txLog.Size()
+	require.NoError(f, err)
+
+	txLogBs := make([]byte, txLogSize)
+	_, err = immuStore.txLog.ReadAt(txLogBs, 0)
+	require.NoError(f, err)
+
+	f.Add(txLogBs)
+
+	f.Fuzz(func(t *testing.T, b []byte) {
+		r := appendable.NewReaderFrom(bytes.NewReader(b), 0, len(b))
+		tx := newTx(fuzzMaxTxEntries, fuzzMaxKeyLen)
+
+		err := tx.readFrom(r)
+		if err != nil {
+			return
+		}
+
+		require.LessOrEqual(t, len(tx.Entries()), fuzzMaxTxEntries)
+	})
+}
+
+func FuzzReplicateTx(f *testing.F) {
+	immuStore := fuzzSeedStore(f)
+
+	txHolder := immuStore.NewTxHolder()
+
+	for id := uint64(1); id <= immuStore.TxCount(); id++ {
+		etx, err := immuStore.ExportTx(id, txHolder)
+		require.NoError(f, err)
+
+		f.Add(etx)
+	}
+
+	dir, err := ioutil.TempDir("", "fuzz_replica")
+	require.NoError(f, err)
+	f.Cleanup(func() { os.RemoveAll(dir) })
+
+	replicaStore, err := Open(dir, DefaultOptions().
+		WithMaxTxEntries(fuzzMaxTxEntries).
+		WithMaxKeyLen(fuzzMaxKeyLen).
+		WithMaxConcurrency(1))
+	require.NoError(f, err)
+	f.Cleanup(func() { replicaStore.Close() })
+
+	f.Fuzz(func(t *testing.T, etx []byte) {
+		// errors are expected, malformed input must not make the replica panic
+		replicaStore.ReplicateTx(etx, false)
+	})
+}
@@ -19,7 +19,6 @@ import (
 	"bytes"
 	"container/list"
 	"crypto/sha256"
-	"encoding/base64"
 	"encoding/binary"
 	"errors"
 	"fmt"
@@ -75,6 +74,9 @@ var ErrSourceTxNewerThanTargetTx = errors.New("source tx is newer than target tx
 var ErrLinearProofMaxLenExceeded = errors.New("max linear proof length limit exceeded")
 
 var ErrCompactionUnsupported = errors.New("compaction is unsupported when remote storage is used")
+var ErrReadOnlyStore = errors.New("store is read-only")
+var ErrIncompleteDerivedData = errors.New("index, binary linking tree or time index do not cover all the committed txs")
+var ErrBinaryLinkingPruned = ahtree.ErrPrunedData
 
 var ErrMetadataUnsupported = errors.New(
 	"metadata is unsupported when in 1.1 compatibility mode, " +
@@ -127,8 +129,17 @@ type ImmuStore struct {
 	committedTxID      uint64
 	committedAlh       [sha256.Size]byte
 	committedTxLogSize int64
+
+	// precommitted state is ahead of the committed one while transactions
+	// written into the transaction log are waiting to be synced by the commit pipeline
+	precommittedTxID      uint64
+	precommittedAlh       [sha256.Size]byte
+	precommittedTxLogSize int64
+
 	commitStateRWMutex sync.RWMutex
 
+	commitPipeline *commitPipeline
+
 	readOnly          bool
 	synced            bool
 	maxConcurrency    int
@@ -137,6 +148,11 @@ type ImmuStore struct {
 	maxKeyLen         int
 	maxValueLen       int
 	maxLinearProofLen int
+	replayConcurrency int
+
+	binaryLinkingHorizon uint64
+
+	maxChunkedValueLen int
 
 	maxTxSize int
 
@@ -144,6 +160,24 @@ type ImmuStore struct {
 
 	timeFunc TimeFunc
 
+	valueCompression     int
+	valueCompressionThld int
+
+	// read-only replicas keep index and binary linking tree apart from the data
+	replicaDataPath       string
+	replicaTmpDataPath    bool
+	replicaFollowInterval time.Duration
+	replicaDone           chan struct{}
+
+	// stores on a read-only filesystem use their derived data in place and are never written
+	readOnlyFilesystem bool
+
+	scrubInterval    time.Duration
+	delayDuringScrub time.Duration
+	scrubDone        chan struct{}
+	scrubStatus      ScrubStatus
+	scrubMutex       sync.Mutex
+
 	_txs     *list.List // pre-allocated txs
 	_txsLock sync.Mutex
 
@@ -155,6 +189,8 @@ type ImmuStore struct {
 	blBuffer chan ([sha256.Size]byte)
 	blErr    error
 
+	timeIndex *timeIndex
+
 	wHub *watchers.WatchersHub
 
 	indexer *indexer
@@ -165,6 +201,12 @@ type ImmuStore struct {
 	mutex sync.Mutex
 
 	compactionDisabled bool
+
+	valueDedup *valueDedup
+
+	truncation *truncation
+
+	commitLatencies *latencyTracker
 }
 
 type refVLog struct {
@@ -179,7 +221,7 @@ func Open(path string, opts *Options) (*ImmuStore, error) {
 
 	finfo, err := os.Stat(path)
 	if err != nil {
-		if !os.IsNotExist(err) {
+		if !os.IsNotExist(err) || opts.ReadOnly {
 			return nil, err
 		}
 
@@ -203,6 +245,7 @@ func Open(path string, opts *Options) (*ImmuStore, error) {
 		WithSynced(opts.Synced).
 		WithFileSize(opts.FileSize).
 		WithFileMode(opts.FileMode).
+		WithChecksums(opts.Checksums).
 		WithMetadata(metadata.Bytes())
 
 	appFactory := opts.appFactory
@@ -213,6 +256,9 @@ func Open(path string, opts *Options) (*ImmuStore, error) {
 		}
 	}
 
+	// transaction and commit logs are explicitly synced by the commit pipeline
+	appendableOpts.WithSynced(opts.Synced && opts.CommitPipelineDepth == 0)
+
 	appendableOpts.WithFileExt("tx")
 	appendableOpts.WithCompressionFormat(appendable.NoCompression)
 	appendableOpts.WithMaxOpenedFiles(opts.TxLogMaxOpenedFiles)
@@ -237,6 +283,8 @@ func Open(path string, opts *Options) (*ImmuStore, error) {
 		appendableOpts.WithCompressionFormat(opts.CompressionFormat)
 		appendableOpts.WithCompresionLevel(opts.CompressionLevel)
 		appendableOpts.WithMaxOpenedFiles(opts.VLogMaxOpenedFiles)
+		appendableOpts.WithPreallocateFiles(opts.VLogPreallocateFiles)
+		appendableOpts.WithDirectIO(opts.VLogDirectIO)
 		vLog, err := appFactory(path, fmt.Sprintf("val_%d", i), appendableOpts)
 		if err != nil {
 			return nil, err
@@ -248,7 +296,7 @@ func Open(path string, opts *Options) (*ImmuStore, error) {
 }
 
 func OpenWith(path string, vLogs []appendable.Appendable, txLog, cLog appendable.Appendable, opts *Options) (*ImmuStore, error) {
-	if !validOptions(opts) || len(vLogs) == 0 || txLog == nil || cLog == nil {
+	if !validOptions(opts) || len(vLogs) == 0 || opts.CommitPipelineAppenders > len(vLogs) || txLog == nil || cLog == nil {
 		return nil, ErrIllegalArguments
 	}
 
@@ -275,6 +323,12 @@ func OpenWith(path string, vLogs []appendable.Appendable, txLog, cLog appendable
 
 	}
 
+	maxValueLen = maxInt(maxValueLen, opts.MaxValueLen)
+
+	if opts.MaxChunkedValueLen > maxChunkedValueLenFor(maxValueLen) {
+		return nil, fmt.Errorf("%w: max chunked value length can not exceed %d", ErrIllegalArguments, maxChunkedValueLenFor(maxValueLen))
+	}
+
 	cLogSize, err := cLog.Size()
 	if err != nil {
 		return nil, fmt.Errorf("corrupted commit log: could not get size: %w", err)
@@ -349,17 +403,28 @@ func OpenWith(path string, vLogs []appendable.Appendable, txLog, cLog appendable
 		vLogsMap[byte(i)] = &refVLog{vLog: vLog, unlockedRef: e}
 	}
 
-	ahtPath := filepath.Join(path, ahtDirname)
+	// derived data is kept along with the data unless the store is a read-only replica
+	derivedDataPath := path
+	replicaTmpDataPath := false
+
+	if opts.ReadOnly && !opts.ReadOnlyFilesystem {
+		derivedDataPath, replicaTmpDataPath, err = replicaDataPath(opts)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	ahtPath := filepath.Join(derivedDataPath, ahtDirname)
 
 	ahtOpts := ahtree.DefaultOptions().
-		WithReadOnly(opts.ReadOnly).
+		WithReadOnly(opts.ReadOnlyFilesystem).
 		WithFileMode(opts.FileMode).
 		WithFileSize(fileSize).
 		WithSynced(opts.Synced) // built from derived data, but temporarily to reduce chances of data inconsistencies
 
 	if opts.appFactory != nil {
 		ahtOpts.WithAppFactory(func(rootPath, subPath string, appOpts *multiapp.Options) (appendable.Appendable, error) {
-			return opts.appFactory(path, filepath.Join(ahtDirname, subPath), appOpts)
+			return opts.appFactory(derivedDataPath, filepath.Join(ahtDirname, subPath), appOpts)
 		})
 	}
 
@@ -368,6 +433,11 @@ func OpenWith(path string, vLogs []appendable.Appendable, txLog, cLog appendable
 		return nil, fmt.Errorf("could not open aht: %w", err)
 	}
 
+	timeIndex, err := openTimeIndex(derivedDataPath, opts, fileSize)
+	if err != nil {
+		return nil, fmt.Errorf("could not open time index: %w", err)
+	}
+
 	kvs := make([]*tbtree.KV, maxTxEntries)
 	for i := range kvs {
 		// vLen + vOff + vHash + txmdLen + txmd + kvmdLen + kvmd
@@ -375,8 +445,9 @@ func OpenWith(path string, vLogs []appendable.Appendable, txLog, cLog appendable
 		kvs[i] = &tbtree.KV{K: make([]byte, maxKeyLen), V: make([]byte, elen)}
 	}
 
+	// read-only replicas link txs as they are followed, so to provide the same proofs as the writer
 	var blBuffer chan ([sha256.Size]byte)
-	if opts.MaxLinearProofLen > 0 {
+	if opts.MaxLinearProofLen > 0 && !opts.ReadOnly {
 		blBuffer = make(chan [sha256.Size]byte, opts.MaxLinearProofLen)
 	}
 
@@ -398,14 +469,25 @@ func OpenWith(path string, vLogs []appendable.Appendable, txLog, cLog appendable
 		committedTxID:      committedTxID,
 		committedAlh:       committedAlh,
 
+		precommittedTxLogSize: committedTxLogSize,
+		precommittedTxID:      committedTxID,
+		precommittedAlh:       committedAlh,
+
+		commitLatencies: newLatencyTracker(commitLatencySamples),
+
 		readOnly:          opts.ReadOnly,
 		synced:            opts.Synced,
 		maxConcurrency:    opts.MaxConcurrency,
 		maxIOConcurrency:  opts.MaxIOConcurrency,
 		maxTxEntries:      maxTxEntries,
 		maxKeyLen:         maxKeyLen,
-		maxValueLen:       maxInt(maxValueLen, opts.MaxValueLen),
+		maxValueLen:       maxValueLen,
 		maxLinearProofLen: opts.MaxLinearProofLen,
+		replayConcurrency: opts.ReplayConcurrency,
+
+		binaryLinkingHorizon: uint64(opts.BinaryLinkingHorizon),
+
+		maxChunkedValueLen: opts.MaxChunkedValueLen,
 
 		maxTxSize: maxTxSize,
 
@@ -413,9 +495,24 @@ func OpenWith(path string, vLogs []appendable.Appendable, txLog, cLog appendable
 
 		timeFunc: opts.TimeFunc,
 
+		valueCompression:     opts.ValueCompression,
+		valueCompressionThld: opts.ValueCompressionThld,
+
+		replicaDataPath:       derivedDataPath,
+		replicaTmpDataPath:    replicaTmpDataPath,
+		replicaFollowInterval: opts.ReplicaFollowInterval,
+
+		readOnlyFilesystem: opts.ReadOnlyFilesystem,
+
+		scrubInterval:    opts.ScrubInterval,
+		delayDuringScrub: opts.DelayDuringScrub,
+		scrubDone:        make(chan struct{}),
+
 		aht:      aht,
 		blBuffer: blBuffer,
 
+		timeIndex: timeIndex,
+
 		wHub: watchers.New(0, 1+opts.MaxWaitees),
 
 		_kvs:  kvs,
@@ -425,17 +522,30 @@ func OpenWith(path string, vLogs []appendable.Appendable, txLog, cLog appendable
 		compactionDisabled: opts.CompactionDisabled,
 	}
 
+	if opts.ValueDedup {
+		store.valueDedup, err = openValueDedup(derivedDataPath, opts, fileSize)
+		if err != nil {
+			return nil, fmt.Errorf("could not open value de-duplication references: %w", err)
+		}
+	}
+
+	store.truncation, err = openTruncation(path, opts.FileMode)
+	if err != nil {
+		return nil, fmt.Errorf("could not load truncation: %w", err)
+	}
+
 	err = store.wHub.DoneUpto(committedTxID)
 	if err != nil {
 		return nil, err
 	}
 
 	indexOpts := tbtree.DefaultOptions().
-		WithReadOnly(opts.ReadOnly).
+		WithReadOnly(opts.ReadOnlyFilesystem).
 		WithFileMode(opts.FileMode).
 		WithLog(opts.log).
 		WithFileSize(fileSize).
 		WithCacheSize(opts.IndexOpts.CacheSize).
+		WithCachePolicy(opts.IndexOpts.CachePolicy).
 		WithFlushThld(opts.IndexOpts.FlushThld).
 		WithSyncThld(opts.IndexOpts.SyncThld).
 		WithFlushBufferSize(opts.IndexOpts.FlushBufferSize).
@@ -447,21 +557,33 @@ func OpenWith(path string, vLogs []appendable.Appendable, txLog, cLog appendable
 		WithCommitLogMaxOpenedFiles(opts.IndexOpts.CommitLogMaxOpenedFiles).
 		WithRenewSnapRootAfter(opts.IndexOpts.RenewSnapRootAfter).
 		WithCompactionThld(opts.IndexOpts.CompactionThld).
-		WithDelayDuringCompaction(opts.IndexOpts.DelayDuringCompaction)
+		WithDelayDuringCompaction(opts.IndexOpts.DelayDuringCompaction).
+		WithMmapReads(opts.IndexOpts.MmapReads).
+		WithOffHeapFlushBuffers(opts.IndexOpts.OffHeapFlushBuffers)
 
 	if opts.appFactory != nil {
 		indexOpts.WithAppFactory(func(rootPath, subPath string, appOpts *multiapp.Options) (appendable.Appendable, error) {
-			return opts.appFactory(store.path, filepath.Join(indexDirname, subPath), appOpts)
+			return opts.appFactory(derivedDataPath, filepath.Join(indexDirname, subPath), appOpts)
 		})
 	}
 
-	indexPath := filepath.Join(store.path, indexDirname)
+	indexPath := filepath.Join(derivedDataPath, indexDirname)
 
 	store.indexer, err = newIndexer(indexPath, store, indexOpts, opts.MaxWaitees)
 	if err != nil {
 		return nil, fmt.Errorf("could not open indexer: %w", err)
 	}
 
+	// derived data can not be amended on a read-only filesystem
+	if store.readOnlyFilesystem &&
+		(store.aht.Size() != store.committedTxID ||
+			store.timeIndex.size != store.committedTxID ||
+			store.indexer.Ts() != store.committedTxID) {
+		store.Close()
+		return nil, fmt.Errorf("%w: index at tx %d, binary linking at tx %d and time index at tx %d but last committed tx is %d",
+			ErrIncompleteDerivedData, store.indexer.Ts(), store.aht.Size(), store.timeIndex.size, store.committedTxID)
+	}
+
 	if store.aht.Size() > store.committedTxID {
 		err = store.aht.ResetSize(store.committedTxID)
 		if err != nil {
@@ -470,6 +592,14 @@ func OpenWith(path string, vLogs []appendable.Appendable, txLog, cLog appendable
 		}
 	}
 
+	if store.timeIndex.size > store.committedTxID {
+		err = store.timeIndex.resetSize(store.committedTxID)
+		if err != nil {
+			store.Close()
+			return nil, fmt.Errorf("corrupted commit log: can not truncate time index: %w", err)
+		}
+	}
+
 	if store.indexer.Ts() > store.committedTxID {
 		store.Close()
 		return nil, fmt.Errorf("corrupted commit log: index size is too large: %w", ErrCorruptedCLog)
@@ -481,11 +611,47 @@ func OpenWith(path string, vLogs []appendable.Appendable, txLog, cLog appendable
 		return nil, fmt.Errorf("binary linking failed: %w", err)
 	}
 
+	err = store.syncTimeIndex()
+	if err != nil {
+		store.Close()
+		return nil, fmt.Errorf("time indexing failed: %w", err)
+	}
+
+	err = store.checkIntegrity(opts.IntegrityCheck)
+	if err != nil {
+		store.Close()
+		return nil, fmt.Errorf("integrity check failed: %w", err)
+	}
+
+	if store.valueDedup != nil {
+		err = store.loadValueDedup()
+		if err != nil {
+			store.Close()
+			return nil, fmt.Errorf("could not load value de-duplication references: %w", err)
+		}
+
+		store.valueDedup.discardTruncated(store.truncation)
+	}
+
 	if store.blBuffer != nil {
 		store.blDone = make(chan struct{})
 		go store.binaryLinking()
 	}
 
+	// there is no writer to follow on a read-only filesystem
+	if store.readOnly && !store.readOnlyFilesystem {
+		store.replicaDone = make(chan struct{})
+		go store.followCommitLog()
+	}
+
+	if store.scrubInterval > 0 {
+		go store.scrubPeriodically()
+	}
+
+	if !store.readOnly && opts.CommitPipelineDepth > 0 {
+		store.commitPipeline = newCommitPipeline(store, opts)
+	}
+
 	return store, nil
 }
 
@@ -579,6 +745,99 @@ func (s *ImmuStore) UseTimeFunc(timeFunc TimeFunc) error {
 	return nil
 }
 
+// UpdateOptions applies the options which can be changed while the store is opened:
+// sync mode, tx header version, value compression, time function, cache sizes, max number of opened files
+// and the indexing thresholds. Any other option keeps the value the store was opened with.
+func (s *ImmuStore) UpdateOptions(opts *Options) error {
+	if !validOptions(opts) || opts.TxLogCacheSize < 1 {
+		return ErrIllegalArguments
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.closed {
+		return ErrAlreadyClosed
+	}
+
+	setMaxOpenedFiles := func(app appendable.Appendable, maxOpenedFiles int) error {
+		mapp, ok := app.(interface{ SetMaxOpenedFiles(int) error })
+		if !ok {
+			return nil
+		}
+		return mapp.SetMaxOpenedFiles(maxOpenedFiles)
+	}
+
+	for _, vLog := range s.vLogs {
+		err := setMaxOpenedFiles(vLog.vLog, opts.VLogMaxOpenedFiles)
+		if err != nil {
+			return err
+		}
+	}
+
+	err := setMaxOpenedFiles(s.txLog, opts.TxLogMaxOpenedFiles)
+	if err != nil {
+		return err
+	}
+
+	err = setMaxOpenedFiles(s.cLog, opts.CommitLogMaxOpenedFiles)
+	if err != nil {
+		return err
+	}
+
+	err = s.indexer.UpdateOptions(tbtree.DefaultOptions().
+		WithLog(s.log).
+		WithCacheSize(opts.IndexOpts.CacheSize).
+		WithCachePolicy(opts.IndexOpts.CachePolicy).
+		WithFlushThld(opts.IndexOpts.FlushThld).
+		WithSyncThld(opts.IndexOpts.SyncThld).
+		WithFlushBufferSize(opts.IndexOpts.FlushBufferSize).
+		WithCleanupPercentage(opts.IndexOpts.CleanupPercentage).
+		WithMaxActiveSnapshots(opts.IndexOpts.MaxActiveSnapshots).
+		WithNodesLogMaxOpenedFiles(opts.IndexOpts.NodesLogMaxOpenedFiles).
+		WithHistoryLogMaxOpenedFiles(opts.IndexOpts.HistoryLogMaxOpenedFiles).
+		WithCommitLogMaxOpenedFiles(opts.IndexOpts.CommitLogMaxOpenedFiles).
+		WithRenewSnapRootAfter(opts.IndexOpts.RenewSnapRootAfter).
+		WithCompactionThld(opts.IndexOpts.CompactionThld).
+		WithDelayDuringCompaction(opts.IndexOpts.DelayDuringCompaction))
+	if err != nil {
+		return err
+	}
+
+	s.txLogCache.Resize(opts.TxLogCacheSize)
+
+	s.synced = opts.Synced
+	s.timeFunc = opts.TimeFunc
+	s.writeTxHeaderVersion = opts.WriteTxHeaderVersion
+	s.valueCompression = opts.ValueCompression
+	s.valueCompressionThld = opts.ValueCompressionThld
+
+	return nil
+}
+
+// SetCacheSizes resizes the index nodes cache and the tx log cache, e.g. to keep the memory they take within a budget
+func (s *ImmuStore) SetCacheSizes(indexCacheSize int, txLogCacheSize int) error {
+	if indexCacheSize < 1 || txLogCacheSize < 1 {
+		return ErrIllegalArguments
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.closed {
+		return ErrAlreadyClosed
+	}
+
+	err := s.indexer.SetCacheSize(indexCacheSize)
+	if err != nil {
+		return err
+	}
+
+	s.txLogCache.Resize(txLogCacheSize)
+
+	return nil
+}
+
 func (s *ImmuStore) NewTxHolder() *Tx {
 	return newTx(s.maxTxEntries, s.maxKeyLen)
 }
@@ -615,6 +874,9 @@ func (s *ImmuStore) binaryLinking() {
 		case alh := <-s.blBuffer:
 			{
 				_, _, err := s.aht.Append(alh[:])
+				if err == nil {
+					err = s.pruneBinaryLinking()
+				}
 				if err != nil {
 					s.SetBlErr(err)
 					s.log.Errorf("Binary linking at '%s' stopped due to error: %v", s.path, err)
@@ -648,40 +910,93 @@ func (s *ImmuStore) BlInfo() (uint64, error) {
 	return s.aht.Size(), s.blErr
 }
 
-func (s *ImmuStore) syncBinaryLinking() error {
-	if s.aht.Size() == s.committedTxID {
-		s.log.Infof("Binary Linking up to date at '%s'", s.path)
+// pruneBinaryLinking keeps between binaryLinkingHorizon and twice as many of the latest txs in the binary
+// linking tree, so the cost of pruning is amortized over many txs
+func (s *ImmuStore) pruneBinaryLinking() error {
+	if s.binaryLinkingHorizon == 0 || s.readOnly {
 		return nil
 	}
 
-	s.log.Infof("Syncing Binary Linking at '%s'...", s.path)
+	size := s.aht.Size()
 
-	tx, err := s.fetchAllocTx()
-	if err != nil {
-		return err
+	if size < 2*s.binaryLinkingHorizon {
+		return nil
 	}
-	defer s.releaseAllocTx(tx)
 
-	txReader, err := s.NewTxReader(s.aht.Size()+1, false, tx)
+	checkpoint := size - s.binaryLinkingHorizon + 1
+
+	if checkpoint < s.aht.Checkpoint()+s.binaryLinkingHorizon {
+		return nil
+	}
+
+	err := s.aht.Prune(checkpoint)
 	if err != nil {
-		return err
+		return fmt.Errorf("binary linking could not be pruned: %w", err)
 	}
 
-	for {
-		tx, err := txReader.Read()
-		if err == ErrNoMoreEntries {
-			break
-		}
+	s.log.Infof("Binary linking at '%s' pruned up to tx: %d", s.path, checkpoint-1)
+
+	return nil
+}
+
+// BinaryLinkingCheckpoint returns the oldest tx still in the binary linking tree,
+// 0 if the tree was never pruned. Dual proofs can be built from txs linked to it or to later ones
+func (s *ImmuStore) BinaryLinkingCheckpoint() uint64 {
+	return s.aht.Checkpoint()
+}
+
+func (s *ImmuStore) syncBinaryLinking() error {
+	if s.aht.Size() == s.committedTxID {
+		s.log.Infof("Binary Linking up to date at '%s'", s.path)
+		return nil
+	}
+
+	s.log.Infof("Syncing Binary Linking at '%s' {txs=%d, workers=%d}...", s.path, s.committedTxID-s.aht.Size(), s.replayConcurrency)
+
+	batches := make(chan *alhBatch, 1)
+
+	done := make(chan struct{})
+	defer close(done)
+
+	// the first tx to be linked must follow the last one already in the tree
+	prevAlh := sha256.Sum256(nil)
+
+	if s.aht.Size() > 0 {
+		link, err := s.readTxLink(s.NewTxHolder(), s.aht.Size())
 		if err != nil {
 			return err
 		}
 
-		alh := tx.header.Alh()
-		s.aht.Append(alh[:])
+		prevAlh = link.alh
+	}
+
+	go s.readAlhs(s.aht.Size()+1, s.committedTxID, batches, done)
 
-		if tx.header.ID%1000 == 0 {
-			s.log.Infof("Binary linking at '%s' in progress: processing tx: %d", s.path, tx.header.ID)
+	for batch := range batches {
+		if batch.err != nil {
+			return batch.err
 		}
+
+		for i, link := range batch.links {
+			err := checkTxLink(link, batch.firstTxID+uint64(i), prevAlh)
+			if err != nil {
+				return err
+			}
+
+			_, _, err = s.aht.Append(link.alh[:])
+			if err != nil {
+				return err
+			}
+
+			prevAlh = link.alh
+		}
+
+		err := s.pruneBinaryLinking()
+		if err != nil {
+			return err
+		}
+
+		s.log.Infof("Binary linking at '%s' in progress: processed up to tx: %d", s.path, s.aht.Size())
 	}
 
 	s.log.Infof("Binary Linking up to date at '%s'", s.path)
@@ -698,13 +1013,29 @@ func (s *ImmuStore) WaitForIndexingUpto(txID uint64, cancellation <-chan struct{
 }
 
 func (s *ImmuStore) CompactIndex() error {
+	if s.readOnlyFilesystem {
+		return ErrReadOnlyStore
+	}
 	if s.compactionDisabled {
 		return ErrCompactionUnsupported
 	}
 	return s.indexer.CompactIndex()
 }
 
+// IndexStats returns an estimation of the space taken by the index
+func (s *ImmuStore) IndexStats() (*tbtree.Stats, error) {
+	return s.indexer.Stats()
+}
+
+// IndexCompactionProgress returns the percentage of the index already dumped by an ongoing compaction
+func (s *ImmuStore) IndexCompactionProgress() float32 {
+	return s.indexer.CompactionProgress()
+}
+
 func (s *ImmuStore) FlushIndex(cleanupPercentage float32, synced bool) error {
+	if s.readOnlyFilesystem {
+		return ErrReadOnlyStore
+	}
 	return s.indexer.FlushIndex(cleanupPercentage, synced)
 }
 
@@ -757,6 +1088,16 @@ func (s *ImmuStore) MaxValueLen() int {
 	return s.maxValueLen
 }
 
+// MaxChunkedValueLen is the length of the largest value which can be stored in chunks, zero when disabled
+func (s *ImmuStore) MaxChunkedValueLen() int {
+	return s.maxChunkedValueLen
+}
+
+// maxStorableValueLen is the length of the largest value which can be stored, either as a whole or in chunks
+func (s *ImmuStore) maxStorableValueLen() int {
+	return maxInt(s.maxValueLen, s.maxChunkedValueLen)
+}
+
 func (s *ImmuStore) MaxLinearProofLen() int {
 	return s.maxLinearProofLen
 }
@@ -829,43 +1170,127 @@ func (s *ImmuStore) releaseVLog(vLogID byte) {
 
 type appendableResult struct {
 	offsets []int64
+	lens    []int // length of the values as recorded in tx entries, it's the length of the manifest of chunked values
 	err     error
 }
 
-func (s *ImmuStore) appendData(entries []*EntrySpec, donec chan<- appendableResult) {
-	offsets := make([]int64, len(entries))
+// appendDataAsync writes the values of the entries in background, by the appenders of the commit pipeline
+// when it's enabled. The result is sent through the channel of the buffers unless an error is returned
+func (s *ImmuStore) appendDataAsync(entries []*EntrySpec, buffers *appendBuffers) error {
+	if s.commitPipeline != nil {
+		return s.commitPipeline.appendValues(entries, buffers)
+	}
+
+	go s.appendData(entries, buffers)
+
+	return nil
+}
+
+func (s *ImmuStore) appendData(entries []*EntrySpec, buffers *appendBuffers) {
+	startedAt := time.Now()
 
 	vLogID, vLog := s.fetchAnyVLog()
 	defer s.releaseVLog(vLogID)
 
+	err := s.appendValues(vLogID, vLog, entries, buffers)
+	if err == nil {
+		err = s.flushVLog(vLog)
+	}
+	if err != nil {
+		buffers.donec <- appendableResult{nil, nil, err}
+		return
+	}
+
+	s.observeCommitStage(commitStageValues, startedAt)
+
+	buffers.donec <- appendableResult{buffers.offsets, buffers.lens, nil}
+}
+
+// appendValues appends the values of the entries into the given vLog, where they are written is kept in the buffers.
+// Values are only durable once the vLog is flushed
+func (s *ImmuStore) appendValues(vLogID byte, vLog appendable.Appendable, entries []*EntrySpec, buffers *appendBuffers) error {
+	offsets := buffers.offsets
+	lens := buffers.lens
+
+	// values appended by this tx, so duplicated values within the tx are stored once as well
+	appended := buffers.appended
+
 	for i := 0; i < len(offsets); i++ {
 		if len(entries[i].Value) == 0 {
 			continue
 		}
 
-		voff, _, err := vLog.Append(entries[i].Value)
+		algorithm := valueCompression(buffers.mds[i])
+
+		var hVal [sha256.Size]byte
+
+		if s.valueDedup != nil {
+			hVal = sha256.Sum256(entries[i].Value)
+
+			v, found := s.valueDedup.lookup(hVal)
+			if !found {
+				v, found = appended[hVal]
+			}
+
+			// values are read as recorded in the metadata of the entries
+			if found && v.compression == algorithm {
+				offsets[i] = v.off
+				lens[i] = v.vLen
+				continue
+			}
+		}
+
+		val := buffers.values[i]
+		var err error
+
+		if val == nil {
+			if algorithm != NoValueCompression {
+				val, err = compressValue(algorithm, entries[i].Value)
+				if err == nil && len(val)-lszSize > s.maxValueLen {
+					err = fmt.Errorf("%w: compressed value is longer than %d bytes", ErrIllegalArguments, s.maxValueLen)
+				}
+			} else if len(entries[i].Value) > s.maxValueLen {
+				// the manifest is appended after the chunks as any other value
+				val, err = appendChunks(vLog, vLogID, entries[i].Value, s.maxValueLen)
+			} else {
+				val = entries[i].Value
+			}
+		}
 		if err != nil {
-			donec <- appendableResult{nil, err}
-			return
+			return err
+		}
+
+		voff, _, err := vLog.Append(val)
+		if err != nil {
+			return err
 		}
 		offsets[i] = encodeOffset(voff, vLogID)
+		lens[i] = len(val)
+
+		if algorithm != NoValueCompression {
+			// tx entries keep the length of the uncompressed value
+			lens[i] = len(entries[i].Value)
+		}
+
+		if s.valueDedup != nil {
+			appended[hVal] = dedupValue{off: offsets[i], vLen: lens[i], compression: algorithm}
+		}
 	}
 
+	return nil
+}
+
+func (s *ImmuStore) flushVLog(vLog appendable.Appendable) error {
 	err := vLog.Flush()
 	if err != nil {
-		donec <- appendableResult{nil, err}
-		return
+		return err
 	}
 
 	if s.synced {
-		err = vLog.Sync()
-		if err != nil {
-			donec <- appendableResult{nil, err}
-			return
-		}
+		return vLog.Sync()
 	}
 
-	donec <- appendableResult{offsets, nil}
+	return nil
 }
 
 func (s *ImmuStore) NewWriteOnlyTx() (*OngoingTx, error) {
@@ -881,6 +1306,12 @@ func (s *ImmuStore) commit(otx *OngoingTx, expectedHeader *TxHeader, waitForInde
 		return nil, ErrIllegalArguments
 	}
 
+	if s.readOnly {
+		return nil, ErrReadOnlyStore
+	}
+
+	startedAt := time.Now()
+
 	err := s.validateEntries(otx.entries)
 	if err != nil {
 		return nil, err
@@ -893,10 +1324,16 @@ func (s *ImmuStore) commit(otx *OngoingTx, expectedHeader *TxHeader, waitForInde
 		return nil, ErrAlreadyClosed
 	}
 
-	if !otx.IsWriteOnly() && otx.snap.Ts() <= s.committedTxID {
+	if !otx.IsWriteOnly() && otx.snapTxID < s.precommittedTxID {
 		s.mutex.Unlock()
 		return nil, ErrTxReadConflict
 	}
+
+	err = s.checkPreconditions(otx.preconditions)
+	if err != nil {
+		s.mutex.Unlock()
+		return nil, err
+	}
 	s.mutex.Unlock()
 
 	var ts int64
@@ -914,7 +1351,7 @@ func (s *ImmuStore) commit(otx *OngoingTx, expectedHeader *TxHeader, waitForInde
 
 		//TxHeader is validated against current store
 
-		currTxID, currAlh := s.Alh()
+		currTxID, currAlh, _ := s.precommitState()
 
 		var blRoot [sha256.Size]byte
 
@@ -944,8 +1381,21 @@ func (s *ImmuStore) commit(otx *OngoingTx, expectedHeader *TxHeader, waitForInde
 
 	}
 
-	appendableCh := make(chan appendableResult)
-	go s.appendData(otx.entries, appendableCh)
+	buffers := fetchAppendBuffers(len(otx.entries))
+	defer releaseAppendBuffers(buffers)
+
+	// compression is decided in advance as it's recorded in the metadata of the entries
+	err = s.compressValues(otx.entries, version, buffers)
+	if err != nil {
+		return nil, err
+	}
+
+	appendableCh := buffers.donec
+
+	err = s.appendDataAsync(otx.entries, buffers)
+	if err != nil {
+		return nil, err
+	}
 
 	tx, err := s.fetchAllocTx()
 	if err != nil {
@@ -959,15 +1409,7 @@ func (s *ImmuStore) commit(otx *OngoingTx, expectedHeader *TxHeader, waitForInde
 
 	tx.header.NEntries = len(otx.entries)
 
-	for i, e := range otx.entries {
-		txe := tx.entries[i]
-		txe.setKey(e.Key)
-		txe.md = e.Metadata
-		txe.vLen = len(e.Value)
-		txe.hVal = sha256.Sum256(e.Value)
-	}
-
-	err = tx.BuildHashTree()
+	err = s.hashTx(tx, otx.entries, buffers.mds)
 	if err != nil {
 		<-appendableCh // wait for data to be written
 		return nil, err
@@ -985,6 +1427,11 @@ func (s *ImmuStore) commit(otx *OngoingTx, expectedHeader *TxHeader, waitForInde
 		return nil, err
 	}
 
+	entriesbs, err := s.serializeTxEntries(tx, r)
+	if err != nil {
+		return nil, err
+	}
+
 	s.mutex.Lock()
 
 	if s.closed {
@@ -992,44 +1439,179 @@ func (s *ImmuStore) commit(otx *OngoingTx, expectedHeader *TxHeader, waitForInde
 		return nil, ErrAlreadyClosed
 	}
 
-	if !otx.IsWriteOnly() && otx.snap.Ts() <= s.committedTxID {
+	if !otx.IsWriteOnly() && otx.snapTxID < s.precommittedTxID {
 		s.mutex.Unlock()
 		return nil, ErrTxReadConflict
 	}
 
+	err = s.truncation.checkOffsets(r.offsets)
+	if err != nil {
+		s.mutex.Unlock()
+		return nil, err
+	}
+
+	// preconditions are checked once again as other txs may have been committed in between
+	err = s.checkPreconditions(otx.preconditions)
+	if err != nil {
+		s.mutex.Unlock()
+		return nil, err
+	}
+
+	pc, err := s.performCommit(tx, ts, blTxID, entriesbs)
+	if err != nil {
+		s.mutex.Unlock()
+		return nil, err
+	}
+
+	s.mutex.Unlock()
+
+	err = waitCommitted(pc)
+	if err != nil {
+		return nil, err
+	}
+
+	s.observeEntrySizes(otx.entries)
+
+	if waitForIndexing {
+		err = s.WaitForIndexingUpto(tx.header.ID, nil)
+		if err != nil {
+			return tx.Header(), err
+		}
+	}
+
+	s.commitLatencies.add(startedAt, time.Since(startedAt))
+
+	return tx.Header(), nil
+}
+
+// hashTx computes the digests of the values of tx along with its hash tree,
+// bounded by the serializers of the commit pipeline when it's enabled
+func (s *ImmuStore) hashTx(tx *Tx, entries []*EntrySpec, mds []*KVMetadata) error {
+	return s.serializeStage(func() error {
+		for i, e := range entries {
+			txe := tx.entries[i]
+			txe.setKey(e.Key)
+			txe.md = mds[i]
+			txe.vLen = len(e.Value)
+			txe.hVal = sha256.Sum256(e.Value)
+		}
+
+		return tx.BuildHashTree()
+	})
+}
+
+// serializeTxEntries sets where the values of tx were written. When the commit pipeline is enabled, its entries
+// are serialized as well, so only the header is left to be serialized once the transaction is written in order
+func (s *ImmuStore) serializeTxEntries(tx *Tx, r appendableResult) ([]byte, error) {
 	for i := 0; i < tx.header.NEntries; i++ {
 		tx.entries[i].vOff = r.offsets[i]
+		tx.entries[i].vLen = r.lens[i]
+	}
+
+	if s.commitPipeline == nil {
+		return nil, nil
+	}
+
+	var entriesbs []byte
+
+	err := s.serializeStage(func() error {
+		entriesbs = make([]byte, txEntriesSize(tx))
+		writeTxEntries(tx, entriesbs)
+		return nil
+	})
+
+	return entriesbs, err
+}
+
+func (s *ImmuStore) serializeStage(f func() error) error {
+	if s.commitPipeline != nil {
+		return s.commitPipeline.serialize(f)
 	}
 
-	err = s.performCommit(tx, ts, blTxID)
-	if err != nil {
-		s.mutex.Unlock()
-		return nil, err
+	startedAt := time.Now()
+
+	err := f()
+	if err == nil {
+		s.observeCommitStage(commitStageSerialize, startedAt)
 	}
 
-	s.mutex.Unlock()
+	return err
+}
 
-	if waitForIndexing {
-		err = s.WaitForIndexingUpto(tx.header.ID, nil)
-		if err != nil {
-			return tx.Header(), err
+// txEntriesSize returns the size of the entries of tx as serialized into the transaction log
+func txEntriesSize(tx *Tx) int {
+	size := 0
+
+	for i := 0; i < tx.header.NEntries; i++ {
+		txe := tx.entries[i]
+
+		if txe.md != nil {
+			size += len(txe.md.Bytes())
 		}
+
+		size += sszSize + sszSize + txe.kLen + lszSize + offsetSize + sha256.Size
 	}
 
-	return tx.Header(), nil
+	return size
 }
 
-func (s *ImmuStore) performCommit(tx *Tx, ts int64, blTxID uint64) error {
+// writeTxEntries serializes the entries of tx into b as written into the transaction log,
+// returning the number of bytes written. b must have room for all of them
+func writeTxEntries(tx *Tx, b []byte) int {
+	n := 0
+
+	for i := 0; i < tx.header.NEntries; i++ {
+		txe := tx.entries[i]
+
+		// md is stored before key to ensure backward compatibility
+		var kvmdbs []byte
+
+		if txe.md != nil {
+			kvmdbs = txe.md.Bytes()
+		}
+
+		binary.BigEndian.PutUint16(b[n:], uint16(len(kvmdbs)))
+		n += sszSize
+		copy(b[n:], kvmdbs)
+		n += len(kvmdbs)
+		binary.BigEndian.PutUint16(b[n:], uint16(txe.kLen))
+		n += sszSize
+		copy(b[n:], txe.k[:txe.kLen])
+		n += txe.kLen
+		binary.BigEndian.PutUint32(b[n:], uint32(txe.vLen))
+		n += lszSize
+		binary.BigEndian.PutUint64(b[n:], uint64(txe.vOff))
+		n += offsetSize
+		copy(b[n:], txe.hVal[:])
+		n += sha256.Size
+	}
+
+	return n
+}
+
+// performCommit writes the transaction into the transaction and commit logs. When the commit pipeline is enabled
+// the transaction is only written into the transaction log, the returned pendingCommit being synced by the pipeline.
+// Entries already serialized by serializeTxEntries are written as they are
+func (s *ImmuStore) performCommit(tx *Tx, ts int64, blTxID uint64, entriesbs []byte) (*pendingCommit, error) {
 	if s.blErr != nil {
-		return s.blErr
+		return nil, s.blErr
+	}
+
+	if s.commitPipeline != nil {
+		err := s.commitPipeline.failure()
+		if err != nil {
+			return nil, err
+		}
 	}
 
+	startedAt := time.Now()
+
 	// will overwrite partially written and uncommitted data
-	committedTxID, committedAlh, committedTxLogSize := s.commitState()
+	committedTxID, committedAlh, committedTxLogSize := s.precommitState()
 
 	err := s.txLog.SetOffset(committedTxLogSize)
 	if err != nil {
-		return fmt.Errorf("commit log: could not set offset: %w", err)
+		return nil, fmt.Errorf("commit log: could not set offset: %w", err)
 	}
 
 	tx.header.ID = committedTxID + 1
@@ -1040,13 +1622,13 @@ func (s *ImmuStore) performCommit(tx *Tx, ts int64, blTxID uint64) error {
 	if blTxID > 0 {
 		blRoot, err := s.aht.RootAt(blTxID)
 		if err != nil && err != ahtree.ErrEmptyTree {
-			return err
+			return nil, err
 		}
 		tx.header.BlRoot = blRoot
 	}
 
 	if tx.header.ID <= tx.header.BlTxID {
-		return ErrUnexpectedLinkingError
+		return nil, ErrUnexpectedLinkingError
 	}
 
 	tx.header.PrevAlh = committedAlh
@@ -1097,31 +1679,11 @@ func (s *ImmuStore) performCommit(tx *Tx, ts int64, blTxID uint64) error {
 		}
 	}
 
-	for i := 0; i < tx.header.NEntries; i++ {
-		txe := tx.entries[i]
-
+	if entriesbs == nil {
 		// tx serialization using pre-allocated buffer
-		// md is stored before key to ensure backward compatibility
-		var kvmdbs []byte
-
-		if txe.md != nil {
-			kvmdbs = txe.md.Bytes()
-		}
-
-		binary.BigEndian.PutUint16(s._txbs[txSize:], uint16(len(kvmdbs)))
-		txSize += sszSize
-		copy(s._txbs[txSize:], kvmdbs)
-		txSize += len(kvmdbs)
-		binary.BigEndian.PutUint16(s._txbs[txSize:], uint16(txe.kLen))
-		txSize += sszSize
-		copy(s._txbs[txSize:], txe.k[:txe.kLen])
-		txSize += txe.kLen
-		binary.BigEndian.PutUint32(s._txbs[txSize:], uint32(txe.vLen))
-		txSize += lszSize
-		binary.BigEndian.PutUint64(s._txbs[txSize:], uint64(txe.vOff))
-		txSize += offsetSize
-		copy(s._txbs[txSize:], txe.hVal[:])
-		txSize += sha256.Size
+		txSize += writeTxEntries(tx, s._txbs[txSize:])
+	} else {
+		txSize += copy(s._txbs[txSize:], entriesbs)
 	}
 
 	// tx serialization using pre-allocated buffer
@@ -1135,36 +1697,77 @@ func (s *ImmuStore) performCommit(tx *Tx, ts int64, blTxID uint64) error {
 
 	txOff, _, err := s.txLog.Append(txbs)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	_, _, err = s.txLogCache.Put(tx.header.ID, txbs)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	err = s.txLog.Flush()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	if s.blBuffer == nil {
 		err = s.aht.ResetSize(committedTxID)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		_, _, err := s.aht.Append(alh[:])
 		if err != nil {
-			return err
+			return nil, err
+		}
+		err = s.pruneBinaryLinking()
+		if err != nil {
+			return nil, err
 		}
 	} else {
 		s.blBuffer <- alh
 	}
 
+	err = s.timeIndex.track(tx.header.ID, tx.header.Ts)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.valueDedup != nil {
+		err = s.valueDedup.persist(tx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	s.observeCommitStage(commitStageTxLog, startedAt)
+
+	if s.commitPipeline != nil {
+		pc := &pendingCommit{
+			txID:   tx.header.ID,
+			alh:    alh,
+			txOff:  txOff,
+			txSize: txSize,
+			sync:   s.synced,
+			synced: make(chan struct{}),
+		}
+
+		s.advancePrecommitState(alh, int64(txSize))
+
+		if s.valueDedup != nil {
+			s.valueDedup.track(tx)
+		}
+
+		s.commitPipeline.send(pc)
+
+		return pc, nil
+	}
+
+	startedAt = time.Now()
+
 	// will overwrite partially written and uncommitted data
 	err = s.cLog.SetOffset(int64(committedTxID * cLogEntrySize))
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	var cb [cLogEntrySize]byte
@@ -1172,20 +1775,28 @@ func (s *ImmuStore) performCommit(tx *Tx, ts int64, blTxID uint64) error {
 	binary.BigEndian.PutUint32(cb[offsetSize:], uint32(txSize))
 	_, _, err = s.cLog.Append(cb[:])
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	err = s.cLog.Flush()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	committedTxID = s.advanceCommitState(alh, int64(txSize))
+
+	if s.valueDedup != nil {
+		s.valueDedup.track(tx)
+	}
+
 	s.wHub.DoneUpto(committedTxID)
 
-	return nil
+	s.observeCommitStage(commitStageCommitLog, startedAt)
+
+	return nil, nil
 }
 
+// advanceCommitState makes the next transaction visible, it's already precommitted when synced by the commit pipeline
 func (s *ImmuStore) advanceCommitState(txAlh [sha256.Size]byte, txSize int64) uint64 {
 	s.commitStateRWMutex.Lock()
 	defer s.commitStateRWMutex.Unlock()
@@ -1194,9 +1805,34 @@ func (s *ImmuStore) advanceCommitState(txAlh [sha256.Size]byte, txSize int64) ui
 	s.committedAlh = txAlh
 	s.committedTxLogSize += txSize
 
+	if s.precommittedTxID < s.committedTxID {
+		s.precommittedTxID = s.committedTxID
+		s.precommittedAlh = s.committedAlh
+		s.precommittedTxLogSize = s.committedTxLogSize
+	}
+
 	return s.committedTxID
 }
 
+func (s *ImmuStore) advancePrecommitState(txAlh [sha256.Size]byte, txSize int64) uint64 {
+	s.commitStateRWMutex.Lock()
+	defer s.commitStateRWMutex.Unlock()
+
+	s.precommittedTxID++
+	s.precommittedAlh = txAlh
+	s.precommittedTxLogSize += txSize
+
+	return s.precommittedTxID
+}
+
+// precommitState is the state transactions are written after, including those not yet synced by the commit pipeline
+func (s *ImmuStore) precommitState() (txID uint64, txAlh [sha256.Size]byte, txLogSize int64) {
+	s.commitStateRWMutex.RLock()
+	defer s.commitStateRWMutex.RUnlock()
+
+	return s.precommittedTxID, s.precommittedAlh, s.precommittedTxLogSize
+}
+
 func (s *ImmuStore) commitState() (txID uint64, txAlh [sha256.Size]byte, clogSize int64) {
 	s.commitStateRWMutex.RLock()
 	defer s.commitStateRWMutex.RUnlock()
@@ -1242,6 +1878,10 @@ func (s *ImmuStore) commitWith(callback func(txID uint64, index KeyIndex) ([]*En
 		return nil, ErrIllegalArguments
 	}
 
+	if s.readOnly {
+		return nil, ErrReadOnlyStore
+	}
+
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
@@ -1249,6 +1889,14 @@ func (s *ImmuStore) commitWith(callback func(txID uint64, index KeyIndex) ([]*En
 		return nil, ErrAlreadyClosed
 	}
 
+	if s.commitPipeline != nil {
+		// the callback reads the latest committed state
+		err := s.commitPipeline.waitSynced()
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	s.indexer.Pause()
 	defer s.indexer.Resume()
 
@@ -1265,8 +1913,20 @@ func (s *ImmuStore) commitWith(callback func(txID uint64, index KeyIndex) ([]*En
 		return nil, err
 	}
 
-	appendableCh := make(chan appendableResult)
-	go s.appendData(entries, appendableCh)
+	buffers := fetchAppendBuffers(len(entries))
+	defer releaseAppendBuffers(buffers)
+
+	err = s.compressValues(entries, s.writeTxHeaderVersion, buffers)
+	if err != nil {
+		return nil, err
+	}
+
+	appendableCh := buffers.donec
+
+	err = s.appendDataAsync(entries, buffers)
+	if err != nil {
+		return nil, err
+	}
 
 	tx, err := s.fetchAllocTx()
 	if err != nil {
@@ -1278,15 +1938,7 @@ func (s *ImmuStore) commitWith(callback func(txID uint64, index KeyIndex) ([]*En
 	tx.header.Version = s.writeTxHeaderVersion
 	tx.header.NEntries = len(entries)
 
-	for i, e := range entries {
-		txe := tx.entries[i]
-		txe.setKey(e.Key)
-		txe.md = e.Metadata
-		txe.vLen = len(e.Value)
-		txe.hVal = sha256.Sum256(e.Value)
-	}
-
-	err = tx.BuildHashTree()
+	err = s.hashTx(tx, entries, buffers.mds)
 	if err != nil {
 		<-appendableCh // wait for data to be writen
 		return nil, err
@@ -1298,11 +1950,17 @@ func (s *ImmuStore) commitWith(callback func(txID uint64, index KeyIndex) ([]*En
 		return nil, err
 	}
 
-	for i := 0; i < tx.header.NEntries; i++ {
-		tx.entries[i].vOff = r.offsets[i]
+	entriesbs, err := s.serializeTxEntries(tx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	pc, err := s.performCommit(tx, s.timeFunc().Unix(), s.aht.Size(), entriesbs)
+	if err != nil {
+		return nil, err
 	}
 
-	err = s.performCommit(tx, s.timeFunc().Unix(), s.aht.Size())
+	err = waitCommitted(pc)
 	if err != nil {
 		return nil, err
 	}
@@ -1527,7 +2185,7 @@ func (s *ImmuStore) ExportTx(txID uint64, tx *Tx) ([]byte, error) {
 	valBs := make([]byte, s.maxValueLen)
 
 	for _, e := range tx.Entries() {
-		_, err = s.readValueAt(valBs[:e.vLen], e.vOff, e.hVal)
+		val, err := s.readValueAt(valBs[:e.vLen], e.vOff, e.hVal, e.md)
 		if err != nil {
 			return nil, err
 		}
@@ -1567,14 +2225,14 @@ func (s *ImmuStore) ExportTx(txID uint64, tx *Tx) ([]byte, error) {
 		}
 
 		// vLen
-		binary.BigEndian.PutUint32(blen[:], uint32(e.vLen))
+		binary.BigEndian.PutUint32(blen[:], uint32(len(val)))
 		_, err = buf.Write(blen[:])
 		if err != nil {
 			return nil, err
 		}
 
 		// val
-		_, err = buf.Write(valBs[:e.vLen])
+		_, err = buf.Write(val)
 		if err != nil {
 			return nil, err
 		}
@@ -1623,6 +2281,10 @@ func (s *ImmuStore) ReplicateTx(exportedTx []byte, waitForIndexing bool) (*TxHea
 		kLen := int(binary.BigEndian.Uint16(exportedTx[i:]))
 		i += sszSize
 
+		if len(exportedTx) < i+kLen+sszSize+lszSize {
+			return nil, ErrIllegalArguments
+		}
+
 		key := make([]byte, kLen)
 		copy(key, exportedTx[i:])
 		i += kLen
@@ -1630,7 +2292,7 @@ func (s *ImmuStore) ReplicateTx(exportedTx []byte, waitForIndexing bool) (*TxHea
 		mdLen := int(binary.BigEndian.Uint16(exportedTx[i:]))
 		i += sszSize
 
-		if len(exportedTx) < i+mdLen {
+		if len(exportedTx) < i+mdLen+lszSize {
 			return nil, ErrIllegalArguments
 		}
 
@@ -1717,37 +2379,187 @@ func (s *ImmuStore) ReadValue(entry *TxEntry) ([]byte, error) {
 		return nil, ErrExpiredEntry
 	}
 
-	b := make([]byte, entry.vLen)
+	return s.readValueAt(make([]byte, entry.vLen), entry.vOff, entry.hVal, entry.md)
+}
+
+// ReadValues returns the values associated to the given entries, in the same order.
+// Values are read concurrently using up to MaxIOConcurrency workers so reads spread across the value logs.
+// Values of expired entries are returned as nil
+func (s *ImmuStore) ReadValues(entries []*TxEntry) ([][]byte, error) {
+	values := make([][]byte, len(entries))
 
-	_, err := s.readValueAt(b, entry.vOff, entry.hVal)
+	err := s.readConcurrently(len(entries), func(i int) error {
+		v, err := s.ReadValue(entries[i])
+		if err == ErrExpiredEntry {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		values[i] = v
+
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	return b, nil
+	return values, nil
 }
 
-func (s *ImmuStore) readValueAt(b []byte, off int64, hvalue [sha256.Size]byte) (int, error) {
-	vLogID, offset := decodeOffset(off)
+// ResolveValues resolves the given value references, in the same order.
+// As with ReadValues, values are read concurrently using up to MaxIOConcurrency workers
+func (s *ImmuStore) ResolveValues(valRefs []ValueRef) ([][]byte, error) {
+	values := make([][]byte, len(valRefs))
 
-	if vLogID > 0 {
-		vLog := s.fetchVLog(vLogID)
-		defer s.releaseVLog(vLogID)
+	err := s.readConcurrently(len(valRefs), func(i int) (err error) {
+		values[i], err = valRefs[i].Resolve()
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return values, nil
+}
+
+// readConcurrently calls read for every index in [0, n) from a bounded pool of workers,
+// the first error stops the remaining reads and is returned
+func (s *ImmuStore) readConcurrently(n int, read func(i int) error) error {
+	workers := s.maxIOConcurrency
+	if workers > n {
+		workers = n
+	}
 
-		n, err := vLog.ReadAt(b, offset)
-		if err == multiapp.ErrAlreadyClosed || err == singleapp.ErrAlreadyClosed {
-			return n, ErrAlreadyClosed
+	if workers <= 1 {
+		for i := 0; i < n; i++ {
+			err := read(i)
+			if err != nil {
+				return err
+			}
 		}
-		if err != nil {
-			return n, err
+		return nil
+	}
+
+	var wg sync.WaitGroup
+	var errOnce sync.Once
+	var readErr error
+
+	next := make(chan int)
+	donec := make(chan struct{})
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for i := range next {
+				err := read(i)
+				if err != nil {
+					errOnce.Do(func() {
+						readErr = err
+						close(donec)
+					})
+					return
+				}
+			}
+		}()
+	}
+
+feed:
+	for i := 0; i < n; i++ {
+		select {
+		case next <- i:
+		case <-donec:
+			break feed
 		}
 	}
 
-	if hvalue != sha256.Sum256(b) {
-		return len(b), ErrCorruptedData
+	close(next)
+	wg.Wait()
+
+	return readErr
+}
+
+// readValueAt reads the value of an entry stored at the given offset into b, which must be as long as
+// the value recorded in the entry. The metadata of the entry tells if the value was stored compressed.
+// The returned value is b itself unless the value was stored as chunks
+func (s *ImmuStore) readValueAt(b []byte, off int64, hvalue [sha256.Size]byte, md *KVMetadata) ([]byte, error) {
+	algorithm := valueCompression(md)
+
+	if algorithm != NoValueCompression && len(b) > 0 {
+		return s.readCompressedValueAt(b, off, hvalue, algorithm)
+	}
+
+	err := s.readStoredValueAt(b, off)
+	if err != nil {
+		return nil, err
+	}
+
+	if hvalue == sha256.Sum256(b) {
+		return b, nil
+	}
+
+	if isChunkManifest(b) {
+		return readChunkedValue(b, hvalue, s.maxValueLen, s.readStoredValueAt)
+	}
+
+	return nil, ErrCorruptedData
+}
+
+// readCompressedValueAt reads the length of the compressed value and then its payload, decompressing it into b
+func (s *ImmuStore) readCompressedValueAt(b []byte, off int64, hvalue [sha256.Size]byte, algorithm int) ([]byte, error) {
+	var hdr [lszSize]byte
+
+	err := s.readStoredValueAt(hdr[:], off)
+	if err != nil {
+		return nil, err
+	}
+
+	cLen := int(binary.BigEndian.Uint32(hdr[:]))
+	if cLen > s.maxValueLen {
+		return nil, ErrCorruptedData
+	}
+
+	vLogID, offset := decodeOffset(off)
+
+	payload := make([]byte, cLen)
+
+	err = s.readStoredValueAt(payload, encodeOffset(offset+lszSize, vLogID))
+	if err != nil {
+		return nil, err
+	}
+
+	return decompressValue(b, payload, hvalue, algorithm)
+}
+
+// readStoredValueAt reads the bytes stored at the given offset, empty values are not stored in any vLog
+func (s *ImmuStore) readStoredValueAt(b []byte, off int64) error {
+	vLogID, offset := decodeOffset(off)
+
+	if vLogID == 0 {
+		return nil
+	}
+
+	if s.truncation.truncated(vLogID, offset) {
+		return ErrValueTruncated
+	}
+
+	return s.readVLogAt(vLogID, b, offset)
+}
+
+func (s *ImmuStore) readVLogAt(vLogID byte, b []byte, offset int64) error {
+	vLog := s.fetchVLog(vLogID)
+	defer s.releaseVLog(vLogID)
+
+	_, err := vLog.ReadAt(b, offset)
+	if err == multiapp.ErrAlreadyClosed || err == singleapp.ErrAlreadyClosed {
+		return ErrAlreadyClosed
 	}
 
-	return len(b), nil
+	return err
 }
 
 func (s *ImmuStore) validateEntries(entries []*EntrySpec) error {
@@ -1758,7 +2570,8 @@ func (s *ImmuStore) validateEntries(entries []*EntrySpec) error {
 		return ErrorMaxTxEntriesLimitExceeded
 	}
 
-	m := make(map[string]struct{}, len(entries))
+	m := fetchKeySet()
+	defer releaseKeySet(m)
 
 	for _, kv := range entries {
 		if kv.Key == nil {
@@ -1768,15 +2581,14 @@ func (s *ImmuStore) validateEntries(entries []*EntrySpec) error {
 		if len(kv.Key) > s.maxKeyLen {
 			return ErrorMaxKeyLenExceeded
 		}
-		if len(kv.Value) > s.maxValueLen {
+		if len(kv.Value) > s.maxStorableValueLen() {
 			return ErrorMaxValueLenExceeded
 		}
 
-		b64k := base64.StdEncoding.EncodeToString(kv.Key)
-		if _, ok := m[b64k]; ok {
+		if _, ok := m[string(kv.Key)]; ok {
 			return ErrDuplicatedKey
 		}
-		m[b64k] = struct{}{}
+		m[string(kv.Key)] = struct{}{}
 	}
 	return nil
 }
@@ -1789,29 +2601,49 @@ func (s *ImmuStore) Sync() error {
 		return ErrAlreadyClosed
 	}
 
-	for i := range s.vLogs {
-		vLog := s.fetchVLog(i + 1)
-		defer s.releaseVLog(i + 1)
+	// nothing is ever written on a read-only filesystem
+	if s.readOnlyFilesystem {
+		return nil
+	}
+
+	// data of read-only replicas is synced by the writer
+	if !s.readOnly {
+		for i := range s.vLogs {
+			vLog := s.fetchVLog(i + 1)
+			defer s.releaseVLog(i + 1)
+
+			err := vLog.Sync()
+			if err != nil {
+				return err
+			}
+		}
 
-		err := vLog.Sync()
+		err := s.txLog.Sync()
+		if err != nil {
+			return err
+		}
+
+		err = s.cLog.Sync()
 		if err != nil {
 			return err
 		}
 	}
 
-	err := s.txLog.Sync()
+	err := s.aht.Sync()
 	if err != nil {
 		return err
 	}
 
-	err = s.cLog.Sync()
+	err = s.timeIndex.Sync()
 	if err != nil {
 		return err
 	}
 
-	err = s.aht.Sync()
-	if err != nil {
-		return err
+	if s.valueDedup != nil {
+		err = s.valueDedup.Sync()
+		if err != nil {
+			return err
+		}
 	}
 
 	return s.indexer.Sync()
@@ -1827,6 +2659,19 @@ func (s *ImmuStore) Close() error {
 
 	s.closed = true
 
+	if s.replicaDone != nil {
+		// following may be waiting for the lock, it will find the store closed
+		close(s.replicaDone)
+	}
+
+	// an ongoing scrub stops once it finds the store closed
+	close(s.scrubDone)
+
+	if s.commitPipeline != nil {
+		// transactions already written are synced before closing the logs
+		s.commitPipeline.close()
+	}
+
 	merr := multierr.NewMultiErr()
 
 	for i := range s.vLogs {
@@ -1843,6 +2688,15 @@ func (s *ImmuStore) Close() error {
 		s.blDone <- struct{}{}
 		s.log.Infof("Binary linking gracefully stopped at '%s'", s.path)
 		close(s.blBuffer)
+
+		// buffered txs are linked as well, so the binary linking tree is complete once closed
+		for alh := range s.blBuffer {
+			_, _, err := s.aht.Append(alh[:])
+			if err != nil {
+				merr.Append(err)
+				break
+			}
+		}
 	}
 
 	err := s.wHub.Close()
@@ -1860,6 +2714,19 @@ func (s *ImmuStore) Close() error {
 	err = s.aht.Close()
 	merr.Append(err)
 
+	err = s.timeIndex.Close()
+	merr.Append(err)
+
+	if s.valueDedup != nil {
+		err = s.valueDedup.Close()
+		merr.Append(err)
+	}
+
+	if s.replicaTmpDataPath {
+		err = os.RemoveAll(s.replicaDataPath)
+		merr.Append(err)
+	}
+
 	return merr.Reduce()
 }
 
@@ -1886,6 +2753,13 @@ func maxInt(a, b int) int {
 	return a
 }
 
+func minUint64(a, b uint64) uint64 {
+	if a <= b {
+		return a
+	}
+	return b
+}
+
 func maxUint64(a, b uint64) uint64 {
 	if a <= b {
 		return b
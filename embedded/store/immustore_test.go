@@ -34,6 +34,7 @@ import (
 	"github.com/codenotary/immudb/embedded/appendable"
 	"github.com/codenotary/immudb/embedded/appendable/mocked"
 	"github.com/codenotary/immudb/embedded/appendable/multiapp"
+	"github.com/codenotary/immudb/embedded/appendable/singleapp"
 	"github.com/codenotary/immudb/embedded/htree"
 	"github.com/codenotary/immudb/embedded/tbtree"
 
@@ -253,6 +254,123 @@ func TestImmudbStoreSettings(t *testing.T) {
 	require.Equal(t, DefaultOptions().MaxLinearProofLen, immuStore.MaxLinearProofLen())
 }
 
+func TestImmudbStoreUpdateOptions(t *testing.T) {
+	dir, err := ioutil.TempDir("", "store_update_options")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	immuStore, err := Open(dir, DefaultOptions().WithFileSize(256))
+	require.NoError(t, err)
+
+	commit := func(i int) {
+		tx, err := immuStore.NewWriteOnlyTx()
+		require.NoError(t, err)
+
+		err = tx.Set([]byte(fmt.Sprintf("key%d", i)), nil, []byte(fmt.Sprintf("value%d", i)))
+		require.NoError(t, err)
+
+		_, err = tx.Commit()
+		require.NoError(t, err)
+	}
+
+	for i := 0; i < 20; i++ {
+		commit(i)
+	}
+
+	err = immuStore.UpdateOptions(nil)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	err = immuStore.UpdateOptions(DefaultOptions().WithTxLogCacheSize(0))
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	opts := DefaultOptions().
+		WithSynced(false).
+		WithMaxConcurrency(1).
+		WithTxLogCacheSize(1).
+		WithVLogMaxOpenedFiles(1).
+		WithTxLogMaxOpenedFiles(1).
+		WithValueCompression(ZstdValueCompression).
+		WithValueCompressionThld(0).
+		WithIndexOptions(DefaultIndexOptions().WithCacheSize(10).WithFlushThld(10))
+
+	err = immuStore.UpdateOptions(opts)
+	require.NoError(t, err)
+
+	require.False(t, immuStore.Synced())
+	require.Equal(t, DefaultOptions().MaxConcurrency, immuStore.MaxConcurrency())
+	require.Equal(t, 1, immuStore.txLogCache.Size())
+	require.Equal(t, ZstdValueCompression, immuStore.valueCompression)
+
+	for i := 20; i < 40; i++ {
+		commit(i)
+	}
+
+	err = immuStore.WaitForIndexingUpto(40, nil)
+	require.NoError(t, err)
+
+	for i := 0; i < 40; i++ {
+		valRef, err := immuStore.Get([]byte(fmt.Sprintf("key%d", i)))
+		require.NoError(t, err)
+
+		val, err := valRef.Resolve()
+		require.NoError(t, err)
+		require.Equal(t, []byte(fmt.Sprintf("value%d", i)), val)
+
+		tx := immuStore.NewTxHolder()
+		err = immuStore.ReadTx(uint64(i+1), tx)
+		require.NoError(t, err)
+	}
+
+	err = immuStore.Close()
+	require.NoError(t, err)
+
+	err = immuStore.UpdateOptions(opts)
+	require.ErrorIs(t, err, ErrAlreadyClosed)
+}
+
+func TestImmudbStoreSetCacheSizes(t *testing.T) {
+	dir, err := ioutil.TempDir("", "store_set_cache_sizes")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	immuStore, err := Open(dir, DefaultOptions())
+	require.NoError(t, err)
+
+	err = immuStore.SetCacheSizes(0, 1)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	err = immuStore.SetCacheSizes(tbtree.MinCacheSize, 0)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	for i := 0; i < 10; i++ {
+		tx, err := immuStore.NewWriteOnlyTx()
+		require.NoError(t, err)
+
+		err = tx.Set([]byte(fmt.Sprintf("key%d", i)), nil, []byte(fmt.Sprintf("value%d", i)))
+		require.NoError(t, err)
+
+		_, err = tx.Commit()
+		require.NoError(t, err)
+	}
+
+	err = immuStore.SetCacheSizes(tbtree.MinCacheSize, 2)
+	require.NoError(t, err)
+
+	require.Equal(t, 2, immuStore.txLogCache.Size())
+
+	for i := 0; i < 10; i++ {
+		tx := immuStore.NewTxHolder()
+		err = immuStore.ReadTx(uint64(i+1), tx)
+		require.NoError(t, err)
+	}
+
+	err = immuStore.Close()
+	require.NoError(t, err)
+
+	err = immuStore.SetCacheSizes(tbtree.MinCacheSize, 2)
+	require.ErrorIs(t, err, ErrAlreadyClosed)
+}
+
 func TestImmudbStoreEdgeCases(t *testing.T) {
 	defer os.RemoveAll("edge_cases")
 
@@ -1159,6 +1277,83 @@ func TestImmudbStoreRWTransactions(t *testing.T) {
 	})
 }
 
+func TestImmudbStoreTxSavepoints(t *testing.T) {
+	immuStore, err := Open("data_tx_savepoints", DefaultOptions().WithSynced(false))
+	require.NoError(t, err)
+	defer os.RemoveAll("data_tx_savepoints")
+	defer immuStore.Close()
+
+	tx, err := immuStore.NewTx()
+	require.NoError(t, err)
+
+	err = tx.RollbackTo(nil)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	err = tx.Set([]byte("key1"), nil, []byte("value1"))
+	require.NoError(t, err)
+
+	sp, err := tx.Savepoint()
+	require.NoError(t, err)
+
+	err = tx.Set([]byte("key1"), nil, []byte("value1_1"))
+	require.NoError(t, err)
+
+	err = tx.Set([]byte("key2"), nil, []byte("value2"))
+	require.NoError(t, err)
+
+	err = tx.AddPrecondition(&PreconditionKeyMustExist{Key: []byte("key3")})
+	require.NoError(t, err)
+
+	err = tx.RollbackTo(sp)
+	require.NoError(t, err)
+
+	valRef, err := tx.Get([]byte("key1"))
+	require.NoError(t, err)
+
+	val, err := valRef.Resolve()
+	require.NoError(t, err)
+	require.Equal(t, []byte("value1"), val)
+
+	_, err = tx.Get([]byte("key2"))
+	require.ErrorIs(t, err, ErrKeyNotFound)
+
+	hdr, err := tx.Commit()
+	require.NoError(t, err)
+	require.Equal(t, 1, hdr.NEntries)
+
+	_, err = tx.Savepoint()
+	require.ErrorIs(t, err, ErrAlreadyClosed)
+
+	err = tx.RollbackTo(sp)
+	require.ErrorIs(t, err, ErrAlreadyClosed)
+
+	t.Run("conflicts should be detected since the start of the transaction", func(t *testing.T) {
+		tx, err := immuStore.NewTx()
+		require.NoError(t, err)
+
+		sp, err := tx.Savepoint()
+		require.NoError(t, err)
+
+		otherTx, err := immuStore.NewWriteOnlyTx()
+		require.NoError(t, err)
+
+		err = otherTx.Set([]byte("key1"), nil, []byte("value1_2"))
+		require.NoError(t, err)
+
+		_, err = otherTx.Commit()
+		require.NoError(t, err)
+
+		err = tx.RollbackTo(sp)
+		require.NoError(t, err)
+
+		err = tx.Set([]byte("key2"), nil, []byte("value2"))
+		require.NoError(t, err)
+
+		_, err = tx.Commit()
+		require.ErrorIs(t, err, ErrTxReadConflict)
+	})
+}
+
 func TestImmudbStoreKVMetadata(t *testing.T) {
 	opts := DefaultOptions().WithSynced(false).WithMaxConcurrency(1)
 	immuStore, _ := Open("data_kv_metadata", opts)
@@ -1228,6 +1423,107 @@ func TestImmudbStoreKVMetadata(t *testing.T) {
 	require.Equal(t, []byte{1, 1, 1}, v)
 }
 
+func TestImmudbStoreConcurrentValueReads(t *testing.T) {
+	opts := DefaultOptions().WithSynced(false).WithMaxConcurrency(1).WithMaxIOConcurrency(4)
+	immuStore, err := Open("data_concurrent_value_reads", opts)
+	require.NoError(t, err)
+	defer os.RemoveAll("data_concurrent_value_reads")
+
+	defer immuStore.Close()
+
+	txCount := 8
+	entriesPerTx := 16
+
+	for i := 0; i < txCount; i++ {
+		tx, err := immuStore.NewWriteOnlyTx()
+		require.NoError(t, err)
+
+		for j := 0; j < entriesPerTx; j++ {
+			err = tx.Set([]byte(fmt.Sprintf("key_%d_%d", i, j)), nil, []byte(fmt.Sprintf("value_%d_%d", i, j)))
+			require.NoError(t, err)
+		}
+
+		md := NewKVMetadata()
+		err = md.ExpiresAt(time.Now().Add(-1 * time.Second))
+		require.NoError(t, err)
+
+		err = tx.Set([]byte(fmt.Sprintf("expired_%d", i)), md, []byte("expired"))
+		require.NoError(t, err)
+
+		_, err = tx.Commit()
+		require.NoError(t, err)
+	}
+
+	t.Run("read tx values", func(t *testing.T) {
+		txHolder := immuStore.NewTxHolder()
+
+		for i := 0; i < txCount; i++ {
+			err := immuStore.ReadTx(uint64(i+1), txHolder)
+			require.NoError(t, err)
+
+			values, err := immuStore.ReadValues(txHolder.Entries())
+			require.NoError(t, err)
+			require.Len(t, values, entriesPerTx+1)
+
+			for j, e := range txHolder.Entries() {
+				if bytes.HasPrefix(e.Key(), []byte("expired_")) {
+					require.Nil(t, values[j])
+					continue
+				}
+
+				v, err := immuStore.ReadValue(e)
+				require.NoError(t, err)
+				require.Equal(t, v, values[j])
+			}
+		}
+	})
+
+	t.Run("resolve indexed values", func(t *testing.T) {
+		err := immuStore.WaitForIndexingUpto(uint64(txCount), nil)
+		require.NoError(t, err)
+
+		var valRefs []ValueRef
+
+		for i := 0; i < txCount; i++ {
+			for j := 0; j < entriesPerTx; j++ {
+				valRef, err := immuStore.Get([]byte(fmt.Sprintf("key_%d_%d", i, j)))
+				require.NoError(t, err)
+
+				valRefs = append(valRefs, valRef)
+			}
+		}
+
+		values, err := immuStore.ResolveValues(valRefs)
+		require.NoError(t, err)
+		require.Len(t, values, len(valRefs))
+
+		for i := 0; i < txCount; i++ {
+			for j := 0; j < entriesPerTx; j++ {
+				require.Equal(t, []byte(fmt.Sprintf("value_%d_%d", i, j)), values[i*entriesPerTx+j])
+			}
+		}
+	})
+
+	t.Run("empty reads", func(t *testing.T) {
+		values, err := immuStore.ReadValues(nil)
+		require.NoError(t, err)
+		require.Empty(t, values)
+	})
+
+	t.Run("failing reads", func(t *testing.T) {
+		txHolder := immuStore.NewTxHolder()
+
+		err := immuStore.ReadTx(1, txHolder)
+		require.NoError(t, err)
+
+		entries := append([]*TxEntry{}, txHolder.Entries()...)
+		entries[entriesPerTx/2] = nil
+
+		_, err = immuStore.ReadValues(entries)
+		require.ErrorIs(t, err, ErrIllegalArguments)
+	})
+}
+
 func TestImmudbStoreNonIndexableEntries(t *testing.T) {
 	opts := DefaultOptions().WithSynced(false).WithMaxConcurrency(1)
 	immuStore, _ := Open("data_kv_metadata_non_indexable", opts)
@@ -1293,6 +1589,64 @@ func TestImmudbStoreNonIndexableEntries(t *testing.T) {
 	val, err = valRef.Resolve()
 	require.NoError(t, err)
 	require.Equal(t, []byte("indexedValue1"), val)
+
+	t.Run("non-indexable entries should not be readable by key within the transaction", func(t *testing.T) {
+		tx, err := immuStore.NewTx()
+		require.NoError(t, err)
+
+		err = tx.Set([]byte("nonIndexedKey2"), md, []byte("nonIndexedValue2"))
+		require.NoError(t, err)
+
+		_, err = tx.Get([]byte("nonIndexedKey2"))
+		require.ErrorIs(t, err, ErrKeyNotFound)
+
+		// the indexed value of the key is kept
+		err = tx.Set([]byte("indexedKey1"), md, []byte("nonIndexedValue"))
+		require.NoError(t, err)
+
+		valRef, err := tx.Get([]byte("indexedKey1"))
+		require.NoError(t, err)
+
+		val, err := valRef.Resolve()
+		require.NoError(t, err)
+		require.Equal(t, []byte("indexedValue1"), val)
+
+		// the entry can become indexable but not the other way around
+		err = tx.Set([]byte("nonIndexedKey2"), nil, []byte("indexedValue2"))
+		require.NoError(t, err)
+
+		err = tx.Set([]byte("nonIndexedKey2"), md, []byte("nonIndexedValue2"))
+		require.ErrorIs(t, err, ErrIllegalArguments)
+
+		hdr, err := tx.Commit()
+		require.NoError(t, err)
+
+		valRef, err = immuStore.Get([]byte("indexedKey1"))
+		require.NoError(t, err)
+		require.Less(t, valRef.Tx(), hdr.ID)
+
+		valRef, err = immuStore.Get([]byte("nonIndexedKey2"))
+		require.NoError(t, err)
+		require.Equal(t, hdr.ID, valRef.Tx())
+
+		// non-indexable entries are readable through their transaction
+		txHolder := immuStore.NewTxHolder()
+
+		err = immuStore.ReadTx(hdr.ID, txHolder)
+		require.NoError(t, err)
+
+		for _, e := range txHolder.Entries() {
+			if string(e.Key()) != "indexedKey1" {
+				continue
+			}
+
+			require.True(t, e.Metadata().NonIndexable())
+
+			val, err := immuStore.ReadValue(e)
+			require.NoError(t, err)
+			require.Equal(t, []byte("nonIndexedValue"), val)
+		}
+	})
 }
 
 func TestImmudbStoreCommitWith(t *testing.T) {
@@ -1539,7 +1893,7 @@ func TestImmudbStoreInclusionProof(t *testing.T) {
 			require.Equal(t, j, ki)
 
 			value := make([]byte, txEntries[j].vLen)
-			_, err = immuStore.readValueAt(value, txEntries[j].VOff(), txEntries[j].HVal())
+			_, err = immuStore.readValueAt(value, txEntries[j].VOff(), txEntries[j].HVal(), txEntries[j].Metadata())
 			require.NoError(t, err)
 
 			k := make([]byte, 8)
@@ -1920,6 +2274,83 @@ func TestImmudbStoreConsistencyProofReopened(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestImmudbStoreBinaryLinkingPruning(t *testing.T) {
+	dir, err := ioutil.TempDir("", "store_bl_pruning")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	_, err = Open(dir, DefaultOptions().WithMaxLinearProofLen(10).WithBinaryLinkingHorizon(10))
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	opts := DefaultOptions().
+		WithSynced(false).
+		WithMaxLinearProofLen(5).
+		WithBinaryLinkingHorizon(10).
+		WithIntegrityCheck(FullIntegrityCheck)
+
+	immuStore, err := Open(dir, opts)
+	require.NoError(t, err)
+
+	txCount := 50
+
+	for i := 0; i < txCount; i++ {
+		tx, err := immuStore.NewWriteOnlyTx()
+		require.NoError(t, err)
+
+		err = tx.Set([]byte(fmt.Sprintf("key%d", i)), nil, []byte(fmt.Sprintf("value%d", i)))
+		require.NoError(t, err)
+
+		_, err = tx.Commit()
+		require.NoError(t, err)
+	}
+
+	require.Eventually(t, func() bool {
+		blTxID, err := immuStore.BlInfo()
+		require.NoError(t, err)
+		return blTxID == uint64(txCount)
+	}, 10*time.Second, 10*time.Millisecond)
+
+	// between 10 and 20 txs are kept
+	checkpoint := immuStore.BinaryLinkingCheckpoint()
+	require.Greater(t, checkpoint, uint64(txCount-20))
+	require.LessOrEqual(t, checkpoint, uint64(txCount-10+1))
+
+	err = immuStore.Close()
+	require.NoError(t, err)
+
+	immuStore, err = Open(dir, opts)
+	require.NoError(t, err)
+	defer immuStore.Close()
+
+	require.Equal(t, checkpoint, immuStore.BinaryLinkingCheckpoint())
+
+	sourceTx := immuStore.NewTxHolder()
+	targetTx := immuStore.NewTxHolder()
+
+	err = immuStore.ReadTx(uint64(txCount), targetTx)
+	require.NoError(t, err)
+
+	for txID := uint64(1); txID <= uint64(txCount); txID++ {
+		err = immuStore.ReadTx(txID, sourceTx)
+		require.NoError(t, err)
+
+		dproof, err := immuStore.DualProof(sourceTx, targetTx)
+
+		if txID < checkpoint || sourceTx.header.BlTxID < checkpoint {
+			require.ErrorIs(t, err, ErrBinaryLinkingPruned)
+			continue
+		}
+
+		require.NoError(t, err)
+		require.True(t, VerifyDualProof(dproof, txID, uint64(txCount), sourceTx.header.Alh(), targetTx.header.Alh()))
+	}
+
+	status, err := immuStore.Scrub()
+	require.NoError(t, err)
+	require.Equal(t, uint64(txCount), status.ScrubbedTxs)
+	require.Zero(t, status.MismatchCount)
+}
+
 func TestReOpenningImmudbStore(t *testing.T) {
 	defer os.RemoveAll("data_reopenning")
 
@@ -2102,7 +2533,7 @@ func TestUncommittedTxOverwriting(t *testing.T) {
 			require.NoError(t, err)
 
 			value := make([]byte, txe.vLen)
-			_, err = immuStore.readValueAt(value, txe.vOff, txe.hVal)
+			_, err = immuStore.readValueAt(value, txe.vOff, txe.hVal, txe.md)
 			require.NoError(t, err)
 
 			e := &EntrySpec{Key: txe.key(), Value: value}
@@ -2402,3 +2833,144 @@ func TestImmudbStoreTruncatedCommitLog(t *testing.T) {
 	err = immuStore.Close()
 	require.NoError(t, err)
 }
+
+func TestImmudbStoreIndexStats(t *testing.T) {
+	dir, err := ioutil.TempDir("", "store_index_stats")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	immuStore, err := Open(dir, DefaultOptions())
+	require.NoError(t, err)
+	defer immuStore.Close()
+
+	for i := 0; i < 2; i++ {
+		tx, err := immuStore.NewWriteOnlyTx()
+		require.NoError(t, err)
+
+		err = tx.Set([]byte("key"), nil, []byte(fmt.Sprintf("value%d", i)))
+		require.NoError(t, err)
+
+		_, err = tx.Commit()
+		require.NoError(t, err)
+
+		err = immuStore.FlushIndex(0, false)
+		require.NoError(t, err)
+	}
+
+	stats, err := immuStore.IndexStats()
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), stats.SnapshotCount)
+	require.Equal(t, 1, stats.NodesLogFiles)
+	require.Positive(t, stats.StaleNodeSize)
+	require.Positive(t, stats.GarbageRatio())
+}
+
+func TestImmudbStoreVLogDirectIO(t *testing.T) {
+	dir, err := ioutil.TempDir("", "store_vlog_direct_io")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	opts := DefaultOptions().
+		WithFileSize(64 * 1024).
+		WithVLogPreallocateFiles(true).
+		WithVLogDirectIO(true)
+
+	immuStore, err := Open(dir, opts)
+	require.NoError(t, err)
+
+	value := func(i int) []byte {
+		return bytes.Repeat([]byte{byte(i)}, 1000+i)
+	}
+
+	for i := 0; i < 200; i++ {
+		tx, err := immuStore.NewWriteOnlyTx()
+		require.NoError(t, err)
+
+		err = tx.Set([]byte(fmt.Sprintf("key%d", i)), nil, value(i))
+		require.NoError(t, err)
+
+		_, err = tx.Commit()
+		require.NoError(t, err)
+	}
+
+	err = immuStore.Close()
+	require.NoError(t, err)
+
+	immuStore, err = Open(dir, opts)
+	require.NoError(t, err)
+	defer immuStore.Close()
+
+	for i := 0; i < 200; i++ {
+		valRef, err := immuStore.Get([]byte(fmt.Sprintf("key%d", i)))
+		require.NoError(t, err)
+
+		val, err := valRef.Resolve()
+		require.NoError(t, err)
+		require.Equal(t, value(i), val)
+	}
+}
+
+func TestImmudbStoreChecksums(t *testing.T) {
+	dir, err := ioutil.TempDir("", "store_checksums")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	opts := DefaultOptions().WithChecksums(true)
+
+	immuStore, err := Open(dir, opts)
+	require.NoError(t, err)
+
+	for i := 0; i < 10; i++ {
+		tx, err := immuStore.NewWriteOnlyTx()
+		require.NoError(t, err)
+
+		err = tx.Set([]byte(fmt.Sprintf("key%d", i)), nil, checksumsTestValue(i))
+		require.NoError(t, err)
+
+		_, err = tx.Commit()
+		require.NoError(t, err)
+	}
+
+	err = immuStore.Close()
+	require.NoError(t, err)
+
+	immuStore, err = Open(dir, opts)
+	require.NoError(t, err)
+
+	for i := 0; i < 10; i++ {
+		valRef, err := immuStore.Get([]byte(fmt.Sprintf("key%d", i)))
+		require.NoError(t, err)
+
+		val, err := valRef.Resolve()
+		require.NoError(t, err)
+		require.Equal(t, checksumsTestValue(i), val)
+	}
+
+	err = immuStore.Close()
+	require.NoError(t, err)
+
+	// corrupts the first value, placed in the first block, which is complete
+	f, err := os.OpenFile(filepath.Join(dir, "val_0", "00000000.val"), os.O_RDWR, 0644)
+	require.NoError(t, err)
+
+	_, err = f.WriteAt([]byte("X"), 500)
+	require.NoError(t, err)
+
+	err = f.Close()
+	require.NoError(t, err)
+
+	immuStore, err = Open(dir, opts)
+	require.NoError(t, err)
+	defer immuStore.Close()
+
+	valRef, err := immuStore.Get([]byte("key0"))
+	require.NoError(t, err)
+
+	_, err = valRef.Resolve()
+	require.ErrorIs(t, err, singleapp.ErrChecksumMismatch)
+}
+
+// checksumsTestValue returns values spanning several checksum blocks when written one after the other
+func checksumsTestValue(i int) []byte {
+	return []byte(fmt.Sprintf("value%d%s", i, strings.Repeat("x", 1000)))
+}
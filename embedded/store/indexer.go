@@ -237,6 +237,24 @@ func (idx *indexer) CompactIndex() (err error) {
 	return idx.restartIndex()
 }
 
+func (idx *indexer) CompactionProgress() float32 {
+	idx.mutex.Lock()
+	defer idx.mutex.Unlock()
+
+	return idx.index.CompactionProgress()
+}
+
+func (idx *indexer) Stats() (*tbtree.Stats, error) {
+	idx.mutex.Lock()
+	defer idx.mutex.Unlock()
+
+	if idx.closed {
+		return nil, ErrAlreadyClosed
+	}
+
+	return idx.index.Stats()
+}
+
 func (idx *indexer) FlushIndex(cleanupPercentage float32, synced bool) (err error) {
 	idx.compactionMutex.Lock()
 	defer idx.compactionMutex.Unlock()
@@ -300,6 +318,28 @@ func (idx *indexer) restartIndex() error {
 	return err
 }
 
+func (idx *indexer) UpdateOptions(opts *tbtree.Options) error {
+	idx.mutex.Lock()
+	defer idx.mutex.Unlock()
+
+	if idx.closed {
+		return ErrAlreadyClosed
+	}
+
+	return idx.index.UpdateOptions(opts)
+}
+
+func (idx *indexer) SetCacheSize(size int) error {
+	idx.mutex.Lock()
+	defer idx.mutex.Unlock()
+
+	if idx.closed {
+		return ErrAlreadyClosed
+	}
+
+	return idx.index.SetCacheSize(size)
+}
+
 func (idx *indexer) Resume() {
 	idx.stateCond.L.Lock()
 	idx.state = running
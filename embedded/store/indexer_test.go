@@ -79,6 +79,10 @@ func TestClosedIndexerFailures(t *testing.T) {
 
 	err = indexer.CompactIndex()
 	require.Equal(t, ErrAlreadyClosed, err)
+
+	stats, err := indexer.Stats()
+	require.Nil(t, stats)
+	require.Equal(t, ErrAlreadyClosed, err)
 }
 
 func TestMaxIndexWaitees(t *testing.T) {
@@ -0,0 +1,172 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package store
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"time"
+
+	"github.com/codenotary/immudb/embedded/ahtree"
+	"github.com/codenotary/immudb/embedded/appendable"
+)
+
+// IntegrityCheckLevel is the verification made when the store is opened
+type IntegrityCheckLevel int
+
+const (
+	// FastIntegrityCheck only reads back the last committed tx
+	FastIntegrityCheck IntegrityCheckLevel = iota
+	// MetadataIntegrityCheck reads back every committed tx, verifying it's placed right after the previous one
+	// in the transaction log and linked to it
+	MetadataIntegrityCheck
+	// FullIntegrityCheck also recomputes the binary linking tree over all the committed txs, verifying the
+	// stored one and the binary linking root of every tx
+	FullIntegrityCheck
+)
+
+// integrityCheckProgressInterval is the time between the progress messages logged while checking integrity
+const integrityCheckProgressInterval = 10 * time.Second
+
+func (l IntegrityCheckLevel) String() string {
+	switch l {
+	case FastIntegrityCheck:
+		return "fast"
+	case MetadataIntegrityCheck:
+		return "metadata"
+	case FullIntegrityCheck:
+		return "full"
+	}
+
+	return fmt.Sprintf("unknown (%d)", int(l))
+}
+
+func validIntegrityCheckLevel(l IntegrityCheckLevel) bool {
+	return l >= FastIntegrityCheck && l <= FullIntegrityCheck
+}
+
+// checkIntegrity verifies the committed txs according to the given level,
+// the last committed tx is always read back when the store is opened
+func (s *ImmuStore) checkIntegrity(level IntegrityCheckLevel) error {
+	if level == FastIntegrityCheck || s.committedTxID == 0 {
+		return nil
+	}
+
+	s.log.Infof("Checking integrity at '%s' {level=%s, txs=%d}...", s.path, level, s.committedTxID)
+
+	start := time.Now()
+	lastProgress := start
+
+	tx, err := s.fetchAllocTx()
+	if err != nil {
+		return err
+	}
+	defer s.releaseAllocTx(tx)
+
+	var acc *ahtree.Accumulator
+
+	// roots of the recomputed binary linking tree which may still be referenced by the following txs,
+	// as txs are linked to the tree as it was when they were committed
+	var blRoots [][sha256.Size]byte
+	blRootsFrom := uint64(1)
+
+	if level == FullIntegrityCheck {
+		acc = ahtree.NewAccumulator()
+	}
+
+	prevAlh := sha256.Sum256(nil)
+	var prevBlTxID uint64
+	var txOffset int64
+
+	for txID := uint64(1); txID <= s.committedTxID; txID++ {
+		if time.Since(lastProgress) >= integrityCheckProgressInterval {
+			s.log.Infof("Checking integrity at '%s': %d of %d txs verified (%.2f%%)",
+				s.path, txID-1, s.committedTxID, float64(txID-1)*100/float64(s.committedTxID))
+
+			lastProgress = time.Now()
+		}
+
+		off, size, err := s.txOffsetAndSize(txID)
+		if err != nil {
+			return err
+		}
+
+		if off != txOffset {
+			return fmt.Errorf("%w: tx %d is not placed right after the previous one", ErrCorruptedCLog, txID)
+		}
+
+		txOffset = off + int64(size)
+
+		err = tx.readFrom(appendable.NewReaderFrom(s.txLog, off, size))
+		if err != nil {
+			return fmt.Errorf("%w: tx %d could not be read: %v", ErrorCorruptedTxData, txID, err)
+		}
+
+		if tx.header.ID != txID {
+			return fmt.Errorf("%w: unexpected tx id %d at tx %d", ErrorCorruptedTxData, tx.header.ID, txID)
+		}
+
+		if tx.header.PrevAlh != prevAlh {
+			return fmt.Errorf("%w: tx %d is not linked to the previous one", ErrorCorruptedTxData, txID)
+		}
+
+		if tx.header.BlTxID >= txID || tx.header.BlTxID < prevBlTxID {
+			return fmt.Errorf("%w: invalid binary linking of tx %d", ErrorCorruptedTxData, txID)
+		}
+
+		if acc != nil && tx.header.BlTxID > 0 {
+			i := tx.header.BlTxID - blRootsFrom
+
+			if blRoots[i] != tx.header.BlRoot {
+				return fmt.Errorf("%w: binary linking root mismatch at tx %d", ErrorCorruptedTxData, txID)
+			}
+
+			// following txs are linked to the same tree or a larger one
+			blRoots = blRoots[i:]
+			blRootsFrom = tx.header.BlTxID
+		}
+
+		prevAlh = tx.header.Alh()
+		prevBlTxID = tx.header.BlTxID
+
+		if acc != nil {
+			acc.Append(prevAlh[:])
+
+			root, err := acc.Root()
+			if err != nil {
+				return err
+			}
+
+			blRoots = append(blRoots, root)
+		}
+	}
+
+	if acc != nil {
+		root, err := s.aht.RootAt(s.committedTxID)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrCorruptedAHtree, err)
+		}
+
+		if root != blRoots[len(blRoots)-1] {
+			return fmt.Errorf("%w: root mismatch", ErrCorruptedAHtree)
+		}
+	}
+
+	s.log.Infof("Integrity at '%s' successfully checked {level=%s, txs=%d} in %s",
+		s.path, level, s.committedTxID, time.Since(start))
+
+	return nil
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package store
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestImmudbStoreIntegrityCheck(t *testing.T) {
+	require.Equal(t, "fast", FastIntegrityCheck.String())
+	require.Equal(t, "metadata", MetadataIntegrityCheck.String())
+	require.Equal(t, "full", FullIntegrityCheck.String())
+
+	require.False(t, validOptions(DefaultOptions().WithIntegrityCheck(FullIntegrityCheck+1)))
+
+	dir, err := ioutil.TempDir("", "store_integrity_check")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	immuStore, err := Open(dir, DefaultOptions().WithSynced(false).WithMaxConcurrency(1))
+	require.NoError(t, err)
+
+	txCount := 50
+
+	for i := 0; i < txCount; i++ {
+		tx, err := immuStore.NewWriteOnlyTx()
+		require.NoError(t, err)
+
+		err = tx.Set([]byte(fmt.Sprintf("key%d", i)), nil, []byte(fmt.Sprintf("value%d", i)))
+		require.NoError(t, err)
+
+		_, err = tx.Commit()
+		require.NoError(t, err)
+	}
+
+	err = immuStore.Close()
+	require.NoError(t, err)
+
+	for _, level := range []IntegrityCheckLevel{FastIntegrityCheck, MetadataIntegrityCheck, FullIntegrityCheck} {
+		immuStore, err := Open(dir, DefaultOptions().WithIntegrityCheck(level))
+		require.NoError(t, err)
+		require.Equal(t, uint64(txCount), immuStore.TxCount())
+
+		err = immuStore.Close()
+		require.NoError(t, err)
+	}
+
+	// flip the given bits of the last file of the given log, counting from its end
+	corrupt := func(log, ext string, offFromEnd int64, b []byte) func() {
+		files, err := filepath.Glob(filepath.Join(dir, log, "*."+ext))
+		require.NoError(t, err)
+		require.NotEmpty(t, files)
+
+		file := files[len(files)-1]
+
+		original, err := ioutil.ReadFile(file)
+		require.NoError(t, err)
+
+		corrupted := append([]byte(nil), original...)
+		for i := range b {
+			corrupted[int64(len(corrupted))-offFromEnd+int64(i)] ^= b[i]
+		}
+
+		err = ioutil.WriteFile(file, corrupted, 0644)
+		require.NoError(t, err)
+
+		return func() {
+			err := ioutil.WriteFile(file, original, 0644)
+			require.NoError(t, err)
+		}
+	}
+
+	t.Run("commit log linking is verified from the metadata level", func(t *testing.T) {
+		// the offset of the second tx is moved forward
+		cLogEntry := make([]byte, offsetSize)
+		binary.BigEndian.PutUint64(cLogEntry, 1)
+
+		restore := corrupt("commit", "txi", int64(txCount-1)*cLogEntrySize, cLogEntry)
+		defer restore()
+
+		immuStore, err := Open(dir, DefaultOptions().WithIntegrityCheck(FastIntegrityCheck))
+		require.NoError(t, err)
+
+		err = immuStore.Close()
+		require.NoError(t, err)
+
+		_, err = Open(dir, DefaultOptions().WithIntegrityCheck(MetadataIntegrityCheck))
+		require.ErrorIs(t, err, ErrCorruptedCLog)
+	})
+
+	t.Run("binary linking tree is verified at the full level", func(t *testing.T) {
+		// the digest of the root of the whole tree is stored last
+		restore := corrupt(ahtDirname+"/tree", "sha", 1, []byte{0xFF})
+		defer restore()
+
+		immuStore, err := Open(dir, DefaultOptions().WithIntegrityCheck(MetadataIntegrityCheck))
+		require.NoError(t, err)
+
+		err = immuStore.Close()
+		require.NoError(t, err)
+
+		_, err = Open(dir, DefaultOptions().WithIntegrityCheck(FullIntegrityCheck))
+		require.ErrorIs(t, err, ErrCorruptedAHtree)
+	})
+
+	immuStore, err = Open(dir, DefaultOptions().WithIntegrityCheck(FullIntegrityCheck))
+	require.NoError(t, err)
+
+	err = immuStore.Close()
+	require.NoError(t, err)
+}
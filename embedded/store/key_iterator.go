@@ -0,0 +1,299 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package store
+
+import (
+	"bytes"
+)
+
+const historyIteratorBatchSize = 100
+
+// ValueResolver provides the value of an entry, which is only read from the value log when resolved
+type ValueResolver interface {
+	Resolve() (val []byte, err error)
+}
+
+// KeyIterator iterates over the keys of a snapshot, in lexicographical order, along with their current values.
+// Deleted and expired entries are skipped.
+//
+//	it, err := snap.NewPrefixIterator([]byte("user:"), false)
+//	if err != nil {
+//		return err
+//	}
+//	defer it.Close()
+//
+//	for it.Next() {
+//		val, err := it.Value().Resolve()
+//		...
+//	}
+//
+//	if err := it.Err(); err != nil {
+//		return err
+//	}
+type KeyIterator struct {
+	snap   *Snapshot
+	spec   KeyReaderSpec
+	reader *KeyReader
+	key    []byte
+	valRef ValueRef
+	err    error
+	closed bool
+}
+
+// HistoryIterator iterates over the revisions of a key, including deletions, as seen by a snapshot
+type HistoryIterator struct {
+	snap      *Snapshot
+	key       []byte
+	descOrder bool
+	hc        uint64 // number of revisions of the key
+	offset    uint64 // number of revisions already read
+	txs       []uint64
+	tx        *Tx
+	valRef    ValueRef
+	err       error
+	closed    bool
+}
+
+// NewPrefixIterator returns an iterator over the keys starting with prefix, all the keys when prefix is empty
+func (s *Snapshot) NewPrefixIterator(prefix []byte, descOrder bool) (*KeyIterator, error) {
+	return s.newKeyIterator(KeyReaderSpec{
+		Prefix:        prefix,
+		InclusiveSeek: true,
+		InclusiveEnd:  true,
+		DescOrder:     descOrder,
+	})
+}
+
+// NewRangeIterator returns an iterator over the keys in [start, end), an empty bound leaves the range open on its side
+func (s *Snapshot) NewRangeIterator(start, end []byte, descOrder bool) (*KeyIterator, error) {
+	if len(start) > 0 && len(end) > 0 && bytes.Compare(start, end) > 0 {
+		return nil, ErrIllegalArguments
+	}
+
+	spec := KeyReaderSpec{
+		SeekKey:       start,
+		EndKey:        end,
+		InclusiveSeek: true,
+		InclusiveEnd:  false,
+	}
+
+	if descOrder {
+		spec = KeyReaderSpec{
+			SeekKey:       end,
+			EndKey:        start,
+			InclusiveSeek: false,
+			InclusiveEnd:  true,
+			DescOrder:     true,
+		}
+	}
+
+	return s.newKeyIterator(spec)
+}
+
+func (s *Snapshot) newKeyIterator(spec KeyReaderSpec) (*KeyIterator, error) {
+	spec.Filter = IgnoreDeleted
+
+	reader, err := s.NewKeyReader(&spec)
+	if err != nil {
+		return nil, err
+	}
+
+	return &KeyIterator{
+		snap:   s,
+		spec:   spec,
+		reader: reader,
+	}, nil
+}
+
+// Seek positions the iterator right before the first key greater or equal (lower or equal in descending order)
+// than the given one, so the next call to Next reads it. Keys out of the range of the iterator are never read.
+func (it *KeyIterator) Seek(key []byte) error {
+	if it.closed {
+		return ErrAlreadyClosed
+	}
+
+	spec := it.spec
+
+	if it.spec.DescOrder {
+		if len(it.spec.SeekKey) == 0 || bytes.Compare(key, it.spec.SeekKey) < 0 {
+			spec.SeekKey = key
+			spec.InclusiveSeek = true
+		}
+	} else if bytes.Compare(key, it.spec.SeekKey) > 0 {
+		spec.SeekKey = key
+		spec.InclusiveSeek = true
+	}
+
+	reader, err := it.snap.NewKeyReader(&spec)
+	if err != nil {
+		return err
+	}
+
+	it.reader.Close()
+
+	it.reader = reader
+	it.key = nil
+	it.valRef = nil
+	it.err = nil
+
+	return nil
+}
+
+// Next advances the iterator to the next key, it returns false once there are no more keys or an error occurred
+func (it *KeyIterator) Next() bool {
+	if it.closed || it.err != nil {
+		return false
+	}
+
+	key, valRef, err := it.reader.Read()
+	if err == ErrNoMoreEntries {
+		it.key = nil
+		it.valRef = nil
+		return false
+	}
+	if err != nil {
+		it.err = err
+		return false
+	}
+
+	it.key = key
+	it.valRef = valRef
+
+	return true
+}
+
+// Key returns the key the iterator is positioned at
+func (it *KeyIterator) Key() []byte {
+	return it.key
+}
+
+// Value returns the current value of the key the iterator is positioned at
+func (it *KeyIterator) Value() ValueRef {
+	return it.valRef
+}
+
+// Err returns the error which stopped the iteration, if any
+func (it *KeyIterator) Err() error {
+	return it.err
+}
+
+func (it *KeyIterator) Close() error {
+	if it.closed {
+		return ErrAlreadyClosed
+	}
+
+	it.closed = true
+
+	return it.reader.Close()
+}
+
+// NewHistoryIterator returns an iterator over the revisions of key, oldest first unless descOrder is set
+func (s *Snapshot) NewHistoryIterator(key []byte, descOrder bool) (*HistoryIterator, error) {
+	if len(key) == 0 {
+		return nil, ErrIllegalArguments
+	}
+
+	_, _, hc, err := s.snap.Get(key)
+	if err != nil && err != ErrKeyNotFound {
+		return nil, err
+	}
+
+	return &HistoryIterator{
+		snap:      s,
+		key:       key,
+		descOrder: descOrder,
+		hc:        hc,
+		tx:        s.st.NewTxHolder(),
+	}, nil
+}
+
+// Next advances the iterator to the next revision, it returns false once there are no more revisions or an error occurred
+func (it *HistoryIterator) Next() bool {
+	if it.closed || it.err != nil || it.offset == it.hc {
+		it.valRef = nil
+		return false
+	}
+
+	if len(it.txs) == 0 {
+		txs, err := it.snap.History(it.key, it.offset, it.descOrder, historyIteratorBatchSize)
+		if err != nil {
+			it.err = err
+			return false
+		}
+
+		it.txs = txs
+	}
+
+	txID := it.txs[0]
+
+	err := it.snap.st.ReadTx(txID, it.tx)
+	if err != nil {
+		it.err = err
+		return false
+	}
+
+	e, err := it.tx.EntryOf(it.key)
+	if err != nil {
+		it.err = err
+		return false
+	}
+
+	hc := it.offset + 1
+	if it.descOrder {
+		hc = it.hc - it.offset
+	}
+
+	var valRef ValueRef = &valueRef{
+		tx:     txID,
+		hc:     hc,
+		hVal:   e.hVal,
+		vOff:   int64(e.vOff),
+		valLen: uint32(e.vLen),
+		txmd:   it.tx.header.Metadata,
+		kvmd:   e.md,
+		st:     it.snap.st,
+	}
+
+	if it.snap.refInterceptor != nil {
+		valRef = it.snap.refInterceptor(it.key, valRef)
+	}
+
+	it.txs = it.txs[1:]
+	it.offset++
+	it.valRef = valRef
+
+	return true
+}
+
+// Value returns the revision the iterator is positioned at, deletions are flagged in its KVMetadata
+func (it *HistoryIterator) Value() ValueRef {
+	return it.valRef
+}
+
+// Err returns the error which stopped the iteration, if any
+func (it *HistoryIterator) Err() error {
+	return it.err
+}
+
+func (it *HistoryIterator) Close() error {
+	if it.closed {
+		return ErrAlreadyClosed
+	}
+
+	it.closed = true
+
+	return nil
+}
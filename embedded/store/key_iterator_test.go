@@ -0,0 +1,227 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package store
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyIterator(t *testing.T) {
+	immuStore, err := Open("data_key_iterator", DefaultOptions().WithSynced(false))
+	require.NoError(t, err)
+	defer os.RemoveAll("data_key_iterator")
+	defer immuStore.Close()
+
+	tx, err := immuStore.NewWriteOnlyTx()
+	require.NoError(t, err)
+
+	for i := 0; i < 10; i++ {
+		err = tx.Set([]byte(fmt.Sprintf("key%d", i)), nil, []byte(fmt.Sprintf("value%d", i)))
+		require.NoError(t, err)
+	}
+
+	err = tx.Set([]byte("other"), nil, []byte("other"))
+	require.NoError(t, err)
+
+	_, err = tx.Commit()
+	require.NoError(t, err)
+
+	tx, err = immuStore.NewTx()
+	require.NoError(t, err)
+
+	err = tx.Delete([]byte("key5"))
+	require.NoError(t, err)
+
+	hdr, err := tx.Commit()
+	require.NoError(t, err)
+
+	snap, err := immuStore.SnapshotSince(hdr.ID)
+	require.NoError(t, err)
+	defer snap.Close()
+
+	readAll := func(it *KeyIterator) []string {
+		var keys []string
+
+		for it.Next() {
+			val, err := it.Value().Resolve()
+			require.NoError(t, err)
+			require.Equal(t, "value"+string(it.Key()[3:]), string(val))
+
+			keys = append(keys, string(it.Key()))
+		}
+
+		require.NoError(t, it.Err())
+		require.Nil(t, it.Key())
+
+		return keys
+	}
+
+	t.Run("prefix iterator should skip deleted keys", func(t *testing.T) {
+		it, err := snap.NewPrefixIterator([]byte("key"), false)
+		require.NoError(t, err)
+
+		require.Equal(t, []string{"key0", "key1", "key2", "key3", "key4", "key6", "key7", "key8", "key9"}, readAll(it))
+
+		require.NoError(t, it.Close())
+		require.ErrorIs(t, it.Close(), ErrAlreadyClosed)
+		require.False(t, it.Next())
+		require.ErrorIs(t, it.Seek([]byte("key1")), ErrAlreadyClosed)
+	})
+
+	t.Run("range iterator should read keys in [start, end)", func(t *testing.T) {
+		_, err := snap.NewRangeIterator([]byte("key5"), []byte("key2"), false)
+		require.ErrorIs(t, err, ErrIllegalArguments)
+
+		it, err := snap.NewRangeIterator([]byte("key2"), []byte("key7"), false)
+		require.NoError(t, err)
+		defer it.Close()
+
+		require.Equal(t, []string{"key2", "key3", "key4", "key6"}, readAll(it))
+
+		desc, err := snap.NewRangeIterator([]byte("key2"), []byte("key7"), true)
+		require.NoError(t, err)
+		defer desc.Close()
+
+		require.Equal(t, []string{"key6", "key4", "key3", "key2"}, readAll(desc))
+	})
+
+	t.Run("seek should reposition the iterator within its range", func(t *testing.T) {
+		it, err := snap.NewRangeIterator([]byte("key2"), []byte("key8"), false)
+		require.NoError(t, err)
+		defer it.Close()
+
+		require.True(t, it.Next())
+		require.Equal(t, []byte("key2"), it.Key())
+
+		err = it.Seek([]byte("key45"))
+		require.NoError(t, err)
+		require.Equal(t, []string{"key6", "key7"}, readAll(it))
+
+		err = it.Seek([]byte("a"))
+		require.NoError(t, err)
+		require.True(t, it.Next())
+		require.Equal(t, []byte("key2"), it.Key())
+
+		desc, err := snap.NewPrefixIterator([]byte("key"), true)
+		require.NoError(t, err)
+		defer desc.Close()
+
+		err = desc.Seek([]byte("key3"))
+		require.NoError(t, err)
+		require.Equal(t, []string{"key3", "key2", "key1", "key0"}, readAll(desc))
+
+		err = desc.Seek([]byte("z"))
+		require.NoError(t, err)
+		require.True(t, desc.Next())
+		require.Equal(t, []byte("key9"), desc.Key())
+	})
+}
+
+func TestHistoryIterator(t *testing.T) {
+	immuStore, err := Open("data_history_iterator", DefaultOptions().WithSynced(false))
+	require.NoError(t, err)
+	defer os.RemoveAll("data_history_iterator")
+	defer immuStore.Close()
+
+	revisions := historyIteratorBatchSize + 5
+
+	for i := 0; i < revisions; i++ {
+		tx, err := immuStore.NewWriteOnlyTx()
+		require.NoError(t, err)
+
+		err = tx.Set([]byte("key"), nil, []byte(fmt.Sprintf("value%d", i)))
+		require.NoError(t, err)
+
+		_, err = tx.Commit()
+		require.NoError(t, err)
+	}
+
+	tx, err := immuStore.NewTx()
+	require.NoError(t, err)
+
+	err = tx.Delete([]byte("key"))
+	require.NoError(t, err)
+
+	hdr, err := tx.Commit()
+	require.NoError(t, err)
+
+	snap, err := immuStore.SnapshotSince(hdr.ID)
+	require.NoError(t, err)
+	defer snap.Close()
+
+	_, err = snap.NewHistoryIterator(nil, false)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	t.Run("history of a missing key should be empty", func(t *testing.T) {
+		it, err := snap.NewHistoryIterator([]byte("missing"), false)
+		require.NoError(t, err)
+
+		require.False(t, it.Next())
+		require.NoError(t, it.Err())
+
+		require.NoError(t, it.Close())
+		require.ErrorIs(t, it.Close(), ErrAlreadyClosed)
+	})
+
+	t.Run("history should be read oldest first", func(t *testing.T) {
+		it, err := snap.NewHistoryIterator([]byte("key"), false)
+		require.NoError(t, err)
+		defer it.Close()
+
+		for i := 0; i < revisions; i++ {
+			require.True(t, it.Next())
+
+			valRef := it.Value()
+			require.Equal(t, uint64(i+1), valRef.Tx())
+			require.Equal(t, uint64(i+1), valRef.HC())
+
+			val, err := valRef.Resolve()
+			require.NoError(t, err)
+			require.Equal(t, fmt.Sprintf("value%d", i), string(val))
+		}
+
+		require.True(t, it.Next())
+		require.Equal(t, hdr.ID, it.Value().Tx())
+		require.True(t, it.Value().KVMetadata().Deleted())
+
+		require.False(t, it.Next())
+		require.NoError(t, it.Err())
+		require.Nil(t, it.Value())
+	})
+
+	t.Run("history should be read newest first", func(t *testing.T) {
+		it, err := snap.NewHistoryIterator([]byte("key"), true)
+		require.NoError(t, err)
+		defer it.Close()
+
+		require.True(t, it.Next())
+		require.Equal(t, hdr.ID, it.Value().Tx())
+		require.Equal(t, uint64(revisions+1), it.Value().HC())
+
+		for i := revisions - 1; i >= 0; i-- {
+			require.True(t, it.Next())
+			require.Equal(t, uint64(i+1), it.Value().Tx())
+			require.Equal(t, uint64(i+1), it.Value().HC())
+		}
+
+		require.False(t, it.Next())
+		require.NoError(t, it.Err())
+	})
+}
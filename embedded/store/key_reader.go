@@ -155,7 +155,7 @@ func (s *Snapshot) NewKeyReader(spec *KeyReaderSpec) (*KeyReader, error) {
 }
 
 type ValueRef interface {
-	Resolve() (val []byte, err error)
+	ValueResolver
 	Tx() uint64
 	HC() uint64
 	TxMetadata() *TxMetadata
@@ -258,11 +258,7 @@ func (st *ImmuStore) valueRefFrom(tx, hc uint64, indexedVal []byte) (ValueRef, e
 
 // Resolve ...
 func (v *valueRef) Resolve() (val []byte, err error) {
-	refVal := make([]byte, v.valLen)
-
-	_, err = v.st.readValueAt(refVal, v.vOff, v.hVal)
-
-	return refVal, err
+	return v.st.readValueAt(make([]byte, v.valLen), v.vOff, v.hVal, v.kvmd)
 }
 
 func (v *valueRef) Tx() uint64 {
@@ -30,13 +30,15 @@ const (
 	deletedAttrCode      attributeCode = 0
 	expiresAtAttrCode    attributeCode = 1
 	nonIndexableAttrCode attributeCode = 2
+	compressedAttrCode   attributeCode = 3
 )
 
 const deletedAttrSize = 0
 const expiresAtAttrSize = tsSize
 const nonIndexableAttrSize = 0
+const compressedAttrSize = 1
 
-const maxKVMetadataLen = (attrCodeSize + deletedAttrSize) + (attrCodeSize + expiresAtAttrSize) + (attrCodeSize + nonIndexableAttrSize)
+const maxKVMetadataLen = (attrCodeSize + deletedAttrSize) + (attrCodeSize + expiresAtAttrSize) + (attrCodeSize + nonIndexableAttrSize) + (attrCodeSize + compressedAttrSize)
 
 type KVMetadata struct {
 	attributes map[attributeCode]attribute
@@ -97,6 +99,33 @@ func (a *nonIndexableAttribute) deserialize(b []byte) (int, error) {
 	return 0, nil
 }
 
+// compressedAttribute records the algorithm the value of the entry was compressed with when it was stored
+type compressedAttribute struct {
+	algorithm int
+}
+
+func (a *compressedAttribute) code() attributeCode {
+	return compressedAttrCode
+}
+
+func (a *compressedAttribute) serialize() []byte {
+	return []byte{byte(a.algorithm)}
+}
+
+func (a *compressedAttribute) deserialize(b []byte) (int, error) {
+	if len(b) < compressedAttrSize {
+		return 0, ErrCorruptedData
+	}
+
+	a.algorithm = int(b[0])
+
+	if a.algorithm == NoValueCompression || !validValueCompression(a.algorithm) {
+		return 0, ErrCorruptedData
+	}
+
+	return compressedAttrSize, nil
+}
+
 func NewKVMetadata() *KVMetadata {
 	return &KVMetadata{
 		attributes: make(map[attributeCode]attribute),
@@ -200,10 +229,42 @@ func (md *KVMetadata) NonIndexable() bool {
 	return ok
 }
 
+// AsCompressed records the algorithm the value was compressed with, NoValueCompression meaning it was stored
+// as it is. Values are compressed by the store, which sets this attribute, but the value of an entry holding it
+// is compressed with the given algorithm, as it happens when txs are replicated
+func (md *KVMetadata) AsCompressed(algorithm int) error {
+	if md.readonly {
+		return ErrReadOnly
+	}
+
+	if !validValueCompression(algorithm) {
+		return ErrIllegalArguments
+	}
+
+	if algorithm == NoValueCompression {
+		delete(md.attributes, compressedAttrCode)
+		return nil
+	}
+
+	md.attributes[compressedAttrCode] = &compressedAttribute{algorithm: algorithm}
+
+	return nil
+}
+
+// Compression returns the algorithm the value was compressed with, NoValueCompression if it was stored as it is
+func (md *KVMetadata) Compression() int {
+	compressedAttr, ok := md.attributes[compressedAttrCode]
+	if !ok {
+		return NoValueCompression
+	}
+
+	return compressedAttr.(*compressedAttribute).algorithm
+}
+
 func (md *KVMetadata) Bytes() []byte {
 	var b bytes.Buffer
 
-	for _, attrCode := range []attributeCode{deletedAttrCode, expiresAtAttrCode, nonIndexableAttrCode} {
+	for _, attrCode := range []attributeCode{deletedAttrCode, expiresAtAttrCode, nonIndexableAttrCode, compressedAttrCode} {
 		attr, ok := md.attributes[attrCode]
 		if ok {
 			b.WriteByte(byte(attr.code()))
@@ -265,6 +326,10 @@ func newAttribute(attrCode attributeCode) (attribute, error) {
 		{
 			return &nonIndexableAttribute{}, nil
 		}
+	case compressedAttrCode:
+		{
+			return &compressedAttribute{}, nil
+		}
 	default:
 		{
 			return nil, fmt.Errorf("error reading metadata attributes: %w", ErrCorruptedData)
@@ -35,6 +35,7 @@ func TestKVMetadata(t *testing.T) {
 	require.False(t, md.Deleted())
 	require.False(t, md.IsExpirable())
 	require.False(t, md.NonIndexable())
+	require.Equal(t, NoValueCompression, md.Compression())
 
 	_, err = md.ExpirationTime()
 	require.ErrorIs(t, err, ErrNonExpirable)
@@ -60,6 +61,9 @@ func TestKVMetadata(t *testing.T) {
 
 		err = desmd.AsNonIndexable(true)
 		require.ErrorIs(t, err, ErrReadOnly)
+
+		err = desmd.AsCompressed(ZstdValueCompression)
+		require.ErrorIs(t, err, ErrReadOnly)
 	})
 
 	desmd := NewKVMetadata()
@@ -94,6 +98,15 @@ func TestKVMetadata(t *testing.T) {
 	desmd.AsNonIndexable(true)
 	require.True(t, desmd.NonIndexable())
 
+	err = desmd.AsCompressed(S2ValueCompression + 1)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	desmd.AsCompressed(NoValueCompression)
+	require.Equal(t, NoValueCompression, desmd.Compression())
+
+	desmd.AsCompressed(ZstdValueCompression)
+	require.Equal(t, ZstdValueCompression, desmd.Compression())
+
 	bs = desmd.Bytes()
 	require.NotNil(t, bs)
 	require.Len(t, bs, maxKVMetadataLen)
@@ -104,4 +117,16 @@ func TestKVMetadata(t *testing.T) {
 	require.True(t, desmd.IsExpirable())
 	require.True(t, desmd.ExpiredAt(now))
 	require.True(t, desmd.NonIndexable())
+	require.Equal(t, ZstdValueCompression, desmd.Compression())
+
+	t.Run("unknown compression algorithms should be rejected", func(t *testing.T) {
+		err = NewKVMetadata().unsafeReadFrom([]byte{byte(compressedAttrCode), byte(S2ValueCompression + 1)})
+		require.ErrorIs(t, err, ErrCorruptedData)
+
+		err = NewKVMetadata().unsafeReadFrom([]byte{byte(compressedAttrCode), byte(NoValueCompression)})
+		require.ErrorIs(t, err, ErrCorruptedData)
+
+		err = NewKVMetadata().unsafeReadFrom([]byte{byte(compressedAttrCode)})
+		require.ErrorIs(t, err, ErrCorruptedData)
+	})
 }
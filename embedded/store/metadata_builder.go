@@ -0,0 +1,95 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package store
+
+import "time"
+
+// KVMetadataBuilder builds entry metadata with chained calls e.g.
+//
+//	md, err := store.NewKVMetadataBuilder().Deleted().ExpiresAt(t).Build()
+//
+// The first error found while setting an attribute is returned when the metadata is built
+type KVMetadataBuilder struct {
+	md  *KVMetadata
+	err error
+}
+
+func NewKVMetadataBuilder() *KVMetadataBuilder {
+	return &KVMetadataBuilder{md: NewKVMetadata()}
+}
+
+func (b *KVMetadataBuilder) set(f func(md *KVMetadata) error) *KVMetadataBuilder {
+	if b.err == nil {
+		b.err = f(b.md)
+	}
+	return b
+}
+
+// Deleted marks the entry as a deletion of its key
+func (b *KVMetadataBuilder) Deleted() *KVMetadataBuilder {
+	return b.set(func(md *KVMetadata) error { return md.AsDeleted(true) })
+}
+
+// ExpiresAt makes the entry to be no longer readable once the given time is reached
+func (b *KVMetadataBuilder) ExpiresAt(expiresAt time.Time) *KVMetadataBuilder {
+	return b.set(func(md *KVMetadata) error { return md.ExpiresAt(expiresAt) })
+}
+
+// ExpiresIn makes the entry to be no longer readable once the given duration has elapsed
+func (b *KVMetadataBuilder) ExpiresIn(d time.Duration) *KVMetadataBuilder {
+	return b.ExpiresAt(time.Now().Add(d))
+}
+
+// NonIndexable makes the entry to be left out of the index, it's only readable through its transaction
+func (b *KVMetadataBuilder) NonIndexable() *KVMetadataBuilder {
+	return b.set(func(md *KVMetadata) error { return md.AsNonIndexable(true) })
+}
+
+func (b *KVMetadataBuilder) Build() (*KVMetadata, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	return b.md, nil
+}
+
+// TxMetadataBuilder builds transaction metadata with chained calls e.g.
+//
+//	md, err := store.NewTxMetadataBuilder().User("alice").Build()
+//
+// The first error found while setting an attribute is returned when the metadata is built
+type TxMetadataBuilder struct {
+	md  *TxMetadata
+	err error
+}
+
+func NewTxMetadataBuilder() *TxMetadataBuilder {
+	return &TxMetadataBuilder{md: NewTxMetadata()}
+}
+
+// User attributes the transaction to the given user, at most MaxTxMetadataUserLen bytes long
+func (b *TxMetadataBuilder) User(user string) *TxMetadataBuilder {
+	if b.err == nil {
+		b.err = b.md.SetUser(user)
+	}
+	return b
+}
+
+func (b *TxMetadataBuilder) Build() (*TxMetadata, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	return b.md, nil
+}
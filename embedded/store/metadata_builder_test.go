@@ -0,0 +1,62 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package store
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestKVMetadataBuilder(t *testing.T) {
+	md, err := NewKVMetadataBuilder().Build()
+	require.NoError(t, err)
+	require.Empty(t, md.Bytes())
+
+	expiresAt := time.Unix(time.Now().Add(time.Hour).Unix(), 0)
+
+	md, err = NewKVMetadataBuilder().
+		Deleted().
+		ExpiresAt(expiresAt).
+		NonIndexable().
+		Build()
+	require.NoError(t, err)
+	require.True(t, md.Deleted())
+	require.True(t, md.NonIndexable())
+
+	expTime, err := md.ExpirationTime()
+	require.NoError(t, err)
+	require.Equal(t, expiresAt, expTime)
+
+	md, err = NewKVMetadataBuilder().ExpiresIn(time.Hour).Build()
+	require.NoError(t, err)
+	require.False(t, md.ExpiredAt(time.Now()))
+	require.True(t, md.ExpiredAt(time.Now().Add(2*time.Hour)))
+}
+
+func TestTxMetadataBuilder(t *testing.T) {
+	md, err := NewTxMetadataBuilder().User("alice").Build()
+	require.NoError(t, err)
+	require.Equal(t, "alice", md.User())
+
+	_, err = NewTxMetadataBuilder().
+		User(strings.Repeat("u", MaxTxMetadataUserLen+1)).
+		User("alice").
+		Build()
+	require.ErrorIs(t, err, ErrIllegalArguments)
+}
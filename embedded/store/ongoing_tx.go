@@ -17,6 +17,7 @@ package store
 
 import (
 	"crypto/sha256"
+	"fmt"
 )
 
 //OngoingTx (no-thread safe) represents an interactive or incremental transaction with support of RYOW.
@@ -25,9 +26,14 @@ type OngoingTx struct {
 	st   *ImmuStore
 	snap *Snapshot
 
+	// last transaction visible through the snapshot, txs committed afterwards conflict with this one
+	snapTxID uint64
+
 	entries      []*EntrySpec
 	entriesByKey map[[sha256.Size]byte]int
 
+	preconditions []Precondition
+
 	metadata *TxMetadata
 
 	closed bool
@@ -52,14 +58,25 @@ func newReadWriteTx(s *ImmuStore) (*OngoingTx, error) {
 		entriesByKey: make(map[[sha256.Size]byte]int),
 	}
 
-	err := s.WaitForIndexingUpto(s.committedTxID, nil)
+	err := tx.openSnapshot()
 	if err != nil {
 		return nil, err
 	}
 
-	tx.snap, err = s.SnapshotSince(s.committedTxID)
+	tx.snapTxID = tx.snap.Ts()
+
+	return tx, nil
+}
+
+func (tx *OngoingTx) openSnapshot() error {
+	err := tx.st.WaitForIndexingUpto(tx.st.committedTxID, nil)
 	if err != nil {
-		return nil, err
+		return err
+	}
+
+	tx.snap, err = tx.st.SnapshotSince(tx.st.committedTxID)
+	if err != nil {
+		return err
 	}
 
 	// using an "interceptor" to construct the valueRef from current entries
@@ -72,6 +89,11 @@ func newReadWriteTx(s *ImmuStore) (*OngoingTx, error) {
 
 		entrySpec := tx.entries[keyRef]
 
+		// non-indexable entries leave the indexed value of the key as it was
+		if entrySpec.Metadata != nil && entrySpec.Metadata.NonIndexable() {
+			return valRef
+		}
+
 		return &ongoingValRef{
 			hc:    valRef.HC(),
 			value: entrySpec.Value,
@@ -80,7 +102,7 @@ func newReadWriteTx(s *ImmuStore) (*OngoingTx, error) {
 		}
 	}
 
-	return tx, nil
+	return nil
 }
 
 type ongoingValRef struct {
@@ -144,19 +166,28 @@ func (tx *OngoingTx) Set(key []byte, md *KVMetadata, value []byte) error {
 		return ErrorMaxKeyLenExceeded
 	}
 
-	if len(value) > tx.st.maxValueLen {
+	if len(value) > tx.st.maxStorableValueLen() {
 		return ErrorMaxValueLenExceeded
 	}
 
 	kid := sha256.Sum256(key)
 	keyRef, isKeyUpdate := tx.entriesByKey[kid]
 
-	if !isKeyUpdate && len(tx.entries) > tx.st.maxTxEntries {
+	if !isKeyUpdate && len(tx.entries) >= tx.st.maxTxEntries {
 		return ErrorMaxTxEntriesLimitExceeded
 	}
 
-	// updates are not needed because valueRef are resolved with the "interceptor"
-	if !tx.IsWriteOnly() && !isKeyUpdate {
+	// non-indexable entries are not readable by key, neither within the transaction nor once committed
+	indexable := md == nil || !md.NonIndexable()
+
+	// the key is only added once to the snapshot, updates are not needed because valueRef are resolved with the "interceptor"
+	inSnapshot := isKeyUpdate && (tx.entries[keyRef].Metadata == nil || !tx.entries[keyRef].Metadata.NonIndexable())
+
+	if !tx.IsWriteOnly() && inSnapshot && !indexable {
+		return fmt.Errorf("%w: key already set as indexable within the transaction", ErrIllegalArguments)
+	}
+
+	if !tx.IsWriteOnly() && indexable && !inSnapshot {
 		// vLen=0 + vOff=0 + vHash=0 + txmdLen=0 + kvmdLen=0
 		var indexedValue [lszSize + offsetSize + sha256.Size + sszSize + sszSize]byte
 
@@ -182,6 +213,27 @@ func (tx *OngoingTx) Set(key []byte, md *KVMetadata, value []byte) error {
 	return nil
 }
 
+// AddPrecondition adds a condition to be checked at commit time,
+// the transaction is not committed if any of its preconditions does not hold
+func (tx *OngoingTx) AddPrecondition(c Precondition) error {
+	if tx.closed {
+		return ErrAlreadyClosed
+	}
+
+	if c == nil {
+		return ErrIllegalArguments
+	}
+
+	err := c.Validate(tx.st)
+	if err != nil {
+		return err
+	}
+
+	tx.preconditions = append(tx.preconditions, c)
+
+	return nil
+}
+
 func (tx *OngoingTx) ExistKeyWith(prefix, neq []byte) (bool, error) {
 	if tx.closed {
 		return false, ErrAlreadyClosed
@@ -239,6 +291,19 @@ func (tx *OngoingTx) NewKeyReader(spec *KeyReaderSpec) (*KeyReader, error) {
 	return tx.snap.NewKeyReader(spec)
 }
 
+// Validate checks the entries of the transaction against the limits enforced when it's committed
+func (tx *OngoingTx) Validate() error {
+	if tx.closed {
+		return ErrAlreadyClosed
+	}
+
+	if tx.st.readOnly {
+		return ErrReadOnlyStore
+	}
+
+	return tx.st.validateEntries(tx.entries)
+}
+
 func (tx *OngoingTx) Commit() (*TxHeader, error) {
 	return tx.commit(true)
 }
@@ -277,3 +342,71 @@ func (tx *OngoingTx) Cancel() error {
 
 	return nil
 }
+
+// Savepoint holds the state of an ongoing transaction the transaction can be rolled back to
+type Savepoint struct {
+	entries       []*EntrySpec
+	preconditions []Precondition
+	metadata      *TxMetadata
+}
+
+// Savepoint returns the current state of the transaction, so changes made afterwards can be discarded with RollbackTo
+func (tx *OngoingTx) Savepoint() (*Savepoint, error) {
+	if tx.closed {
+		return nil, ErrAlreadyClosed
+	}
+
+	sp := &Savepoint{
+		entries:       make([]*EntrySpec, len(tx.entries)),
+		preconditions: make([]Precondition, len(tx.preconditions)),
+		metadata:      tx.metadata,
+	}
+
+	// entries are replaced, not modified, when their keys are set again
+	copy(sp.entries, tx.entries)
+	copy(sp.preconditions, tx.preconditions)
+
+	return sp, nil
+}
+
+// RollbackTo discards the changes made since the savepoint was taken.
+// The snapshot of the transaction can not be reverted, so a new one is opened and the entries of the savepoint set into it.
+// Conflicts are still detected since the snapshot the transaction was started with.
+func (tx *OngoingTx) RollbackTo(sp *Savepoint) error {
+	if tx.closed {
+		return ErrAlreadyClosed
+	}
+
+	if sp == nil {
+		return ErrIllegalArguments
+	}
+
+	if !tx.IsWriteOnly() {
+		err := tx.snap.Close()
+		if err != nil {
+			return err
+		}
+
+		err = tx.openSnapshot()
+		if err != nil {
+			return err
+		}
+	}
+
+	tx.entries = nil
+	tx.entriesByKey = make(map[[sha256.Size]byte]int)
+
+	for _, e := range sp.entries {
+		err := tx.Set(e.Key, e.Metadata, e.Value)
+		if err != nil {
+			return err
+		}
+	}
+
+	tx.preconditions = make([]Precondition, len(sp.preconditions))
+	copy(tx.preconditions, sp.preconditions)
+
+	tx.metadata = sp.metadata
+
+	return nil
+}
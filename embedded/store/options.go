@@ -21,6 +21,7 @@ import (
 
 	"github.com/codenotary/immudb/embedded/appendable"
 	"github.com/codenotary/immudb/embedded/appendable/multiapp"
+	"github.com/codenotary/immudb/embedded/cache"
 	"github.com/codenotary/immudb/embedded/tbtree"
 	"github.com/codenotary/immudb/pkg/logger"
 )
@@ -41,6 +42,9 @@ const DefaultVLogMaxOpenedFiles = 10
 const DefaultTxLogMaxOpenedFiles = 10
 const DefaultCommitLogMaxOpenedFiles = 10
 const DefaultWriteTxHeaderVersion = MaxTxHeaderVersion
+const DefaultReplicaFollowInterval = 100 * time.Millisecond
+const DefaultDelayDuringScrub = 10 * time.Millisecond
+const DefaultReplayConcurrency = 4
 
 const MaxFileSize = (1 << 31) - 1 // 2Gb
 
@@ -53,7 +57,18 @@ type AppFactoryFunc func(
 type TimeFunc func() time.Time
 
 type Options struct {
+	// ReadOnly opens the store as a read-only replica, which may be used along with a writer holding
+	// the same directory in the same process. Commits made by the writer are followed from the commit log
+	// every ReplicaFollowInterval. As the index and binary linking tree of the writer can not be shared,
+	// the replica keeps its own ones at ReplicaDataPath, or in a temporary directory when empty
 	ReadOnly bool
+
+	// ReadOnlyFilesystem, along with ReadOnly, opens a store placed on a read-only volume, such as immutable
+	// media or a container image: nothing is written, no directory is created and no writer is followed.
+	// The index, binary linking tree and time index of the store are used in place, so they must cover
+	// all the committed txs i.e. the writer must be closed once indexing is completed
+	ReadOnlyFilesystem bool
+
 	Synced   bool
 	FileMode os.FileMode
 	log      logger.Logger
@@ -65,6 +80,15 @@ type Options struct {
 	MaxIOConcurrency  int
 	MaxLinearProofLen int
 
+	// ReplayConcurrency is the number of workers reading txs back when the binary linking tree has to be
+	// rebuilt while opening the store, e.g. after an unclean shutdown. Txs are still linked in order
+	ReplayConcurrency int
+
+	// BinaryLinkingHorizon enables pruning the binary linking tree when greater than zero: as txs are committed,
+	// only the roots of the subtrees covering the txs older than the latest BinaryLinkingHorizon ones are kept.
+	// Dual proofs can not be built from pruned txs, it must be greater than MaxLinearProofLen
+	BinaryLinkingHorizon int
+
 	TxLogCacheSize int
 
 	VLogMaxOpenedFiles      int
@@ -72,10 +96,68 @@ type Options struct {
 	CommitLogMaxOpenedFiles int
 	WriteTxHeaderVersion    int
 
+	// VLogPreallocateFiles allocates disk space for the whole file size when vLog files are created,
+	// and VLogDirectIO makes values to be written bypassing the page cache, when supported.
+	// Both are meant for vLogs placed on dedicated disks
+	VLogPreallocateFiles bool
+	VLogDirectIO         bool
+
+	// Checksums makes new files of the transaction, commit and value logs to be written with per-block
+	// checksums, so corrupted data is detected when read. Existing files keep the format they were created with
+	Checksums bool
+
+	// IntegrityCheck is the verification made when the store is opened, FastIntegrityCheck by default.
+	// Checking all the committed txs may take long on big stores, progress is periodically logged
+	IntegrityCheck IntegrityCheckLevel
+
 	MaxWaitees int
 
+	// CommitPipelineDepth enables the commit pipeline when greater than zero, so the stages of the commit
+	// of different transactions run concurrently: values are appended by a dedicated appender per value log,
+	// entries are hashed and serialized, transactions are written in order into the transaction log and
+	// synced in batches. Commits still return once transactions are synced. It's the maximum number of
+	// transactions queued to each appender and waiting to be synced, writers are blocked when reached
+	CommitPipelineDepth int
+
+	// CommitPipelineAppenders is the number of value logs written by appenders of the commit pipeline,
+	// all the value logs (MaxIOConcurrency) when zero
+	CommitPipelineAppenders int
+
+	// CommitPipelineSerializers is the maximum number of transactions whose entries are hashed and serialized
+	// at once by the commit pipeline, MaxConcurrency when zero
+	CommitPipelineSerializers int
+
+	// CommitPipelineAffinity pins each appender of the commit pipeline to the CPUs of a NUMA node,
+	// spreading appenders across nodes. Only supported on Linux, appenders are not pinned elsewhere
+	CommitPipelineAffinity bool
+
 	TimeFunc TimeFunc
 
+	ReplicaDataPath       string
+	ReplicaFollowInterval time.Duration
+
+	// ScrubInterval is the time between background scrubs, which verify every committed transaction
+	// and its values against their digests. Background scrubbing is disabled when zero.
+	// DelayDuringScrub is the pause taken after each batch of scrubbed transactions
+	ScrubInterval    time.Duration
+	DelayDuringScrub time.Duration
+
+	// ValueDedup stores identical values only once in the vLogs, entries holding a value already stored
+	// reference it by its digest. The tx format is not affected so it may be enabled on existing stores
+	ValueDedup bool
+
+	// ValueCompression is the algorithm used to compress values of at least ValueCompressionThld bytes.
+	// The algorithm is recorded in the metadata of the entries holding compressed values so it may be changed,
+	// or compression enabled, on existing stores. Values are not compressed when the tx header version is 0
+	ValueCompression     int
+	ValueCompressionThld int
+
+	// MaxChunkedValueLen enables storing values longer than MaxValueLen, up to this length, split into
+	// chunks of MaxValueLen bytes. The chunks of a value are referenced by a manifest which must fit in
+	// MaxValueLen bytes, thus about MaxValueLen * MaxValueLen / 8 bytes is the largest length allowed.
+	// It's disabled when zero and may be enabled on existing stores
+	MaxChunkedValueLen int
+
 	// options below are only set during initialization and stored as metadata
 	MaxTxEntries      int
 	MaxKeyLen         int
@@ -90,6 +172,7 @@ type Options struct {
 
 type IndexOptions struct {
 	CacheSize                int
+	CachePolicy              int
 	FlushThld                int
 	SyncThld                 int
 	FlushBufferSize          int
@@ -102,6 +185,8 @@ type IndexOptions struct {
 	NodesLogMaxOpenedFiles   int
 	HistoryLogMaxOpenedFiles int
 	CommitLogMaxOpenedFiles  int
+	MmapReads                bool
+	OffHeapFlushBuffers      bool
 }
 
 func DefaultOptions() *Options {
@@ -115,6 +200,8 @@ func DefaultOptions() *Options {
 		MaxIOConcurrency:  DefaultMaxIOConcurrency,
 		MaxLinearProofLen: DefaultMaxLinearProofLen,
 
+		ReplayConcurrency: DefaultReplayConcurrency,
+
 		TxLogCacheSize: DefaultTxLogCacheSize,
 
 		VLogMaxOpenedFiles:      DefaultVLogMaxOpenedFiles,
@@ -129,6 +216,13 @@ func DefaultOptions() *Options {
 
 		WriteTxHeaderVersion: DefaultWriteTxHeaderVersion,
 
+		ReplicaFollowInterval: DefaultReplicaFollowInterval,
+
+		DelayDuringScrub: DefaultDelayDuringScrub,
+
+		ValueCompression:     DefaultValueCompression,
+		ValueCompressionThld: DefaultValueCompressionThreshold,
+
 		// options below are only set during initialization and stored as metadata
 		MaxTxEntries:      DefaultMaxTxEntries,
 		MaxKeyLen:         DefaultMaxKeyLen,
@@ -144,6 +238,7 @@ func DefaultOptions() *Options {
 func DefaultIndexOptions() *IndexOptions {
 	return &IndexOptions{
 		CacheSize:                tbtree.DefaultCacheSize,
+		CachePolicy:              tbtree.DefaultCachePolicy,
 		FlushThld:                tbtree.DefaultFlushThld,
 		SyncThld:                 tbtree.DefaultSyncThld,
 		FlushBufferSize:          tbtree.DefaultFlushBufferSize,
@@ -165,6 +260,8 @@ func validOptions(opts *Options) bool {
 		opts.MaxIOConcurrency > 0 &&
 		opts.MaxIOConcurrency <= MaxParallelIO &&
 		opts.MaxLinearProofLen >= 0 &&
+		opts.ReplayConcurrency > 0 &&
+		(opts.BinaryLinkingHorizon == 0 || opts.BinaryLinkingHorizon > opts.MaxLinearProofLen) &&
 
 		opts.VLogMaxOpenedFiles > 0 &&
 		opts.TxLogMaxOpenedFiles > 0 &&
@@ -174,11 +271,29 @@ func validOptions(opts *Options) bool {
 
 		opts.MaxWaitees >= 0 &&
 
+		opts.CommitPipelineDepth >= 0 &&
+		opts.CommitPipelineAppenders >= 0 &&
+		opts.CommitPipelineAppenders <= opts.MaxIOConcurrency &&
+		opts.CommitPipelineSerializers >= 0 &&
+
+		validIntegrityCheckLevel(opts.IntegrityCheck) &&
+
 		opts.TimeFunc != nil &&
 
 		opts.WriteTxHeaderVersion >= 0 &&
 		opts.WriteTxHeaderVersion <= MaxTxHeaderVersion &&
 
+		opts.ReplicaFollowInterval > 0 &&
+		(opts.ReadOnly || !opts.ReadOnlyFilesystem) &&
+
+		opts.ScrubInterval >= 0 &&
+		opts.DelayDuringScrub >= 0 &&
+
+		validValueCompression(opts.ValueCompression) &&
+		opts.ValueCompressionThld >= 0 &&
+
+		opts.MaxChunkedValueLen >= 0 &&
+
 		// options below are only set during initialization and stored as metadata
 		opts.MaxTxEntries > 0 &&
 		opts.MaxKeyLen > 0 &&
@@ -193,6 +308,7 @@ func validOptions(opts *Options) bool {
 func validIndexOptions(opts *IndexOptions) bool {
 	return opts != nil &&
 		opts.CacheSize > 0 &&
+		cache.ValidPolicy(opts.CachePolicy) &&
 		opts.FlushThld > 0 &&
 		opts.FlushBufferSize > 0 &&
 		opts.CleanupPercentage >= 0 && opts.CleanupPercentage <= 100 &&
@@ -209,6 +325,31 @@ func (opts *Options) WithReadOnly(readOnly bool) *Options {
 	return opts
 }
 
+func (opts *Options) WithReadOnlyFilesystem(readOnlyFilesystem bool) *Options {
+	opts.ReadOnlyFilesystem = readOnlyFilesystem
+	return opts
+}
+
+func (opts *Options) WithReplicaDataPath(path string) *Options {
+	opts.ReplicaDataPath = path
+	return opts
+}
+
+func (opts *Options) WithReplicaFollowInterval(interval time.Duration) *Options {
+	opts.ReplicaFollowInterval = interval
+	return opts
+}
+
+func (opts *Options) WithScrubInterval(interval time.Duration) *Options {
+	opts.ScrubInterval = interval
+	return opts
+}
+
+func (opts *Options) WithDelayDuringScrub(delay time.Duration) *Options {
+	opts.DelayDuringScrub = delay
+	return opts
+}
+
 func (opts *Options) WithSynced(synced bool) *Options {
 	opts.Synced = synced
 	return opts
@@ -259,11 +400,26 @@ func (opts *Options) WithMaxValueLen(maxValueLen int) *Options {
 	return opts
 }
 
+func (opts *Options) WithMaxChunkedValueLen(maxChunkedValueLen int) *Options {
+	opts.MaxChunkedValueLen = maxChunkedValueLen
+	return opts
+}
+
 func (opts *Options) WithMaxLinearProofLen(maxLinearProofLen int) *Options {
 	opts.MaxLinearProofLen = maxLinearProofLen
 	return opts
 }
 
+func (opts *Options) WithReplayConcurrency(replayConcurrency int) *Options {
+	opts.ReplayConcurrency = replayConcurrency
+	return opts
+}
+
+func (opts *Options) WithBinaryLinkingHorizon(binaryLinkingHorizon int) *Options {
+	opts.BinaryLinkingHorizon = binaryLinkingHorizon
+	return opts
+}
+
 func (opts *Options) WithTxLogCacheSize(txLogCacheSize int) *Options {
 	opts.TxLogCacheSize = txLogCacheSize
 	return opts
@@ -294,11 +450,46 @@ func (opts *Options) WithMaxWaitees(maxWaitees int) *Options {
 	return opts
 }
 
+func (opts *Options) WithCommitPipelineDepth(depth int) *Options {
+	opts.CommitPipelineDepth = depth
+	return opts
+}
+
+func (opts *Options) WithCommitPipelineAppenders(appenders int) *Options {
+	opts.CommitPipelineAppenders = appenders
+	return opts
+}
+
+func (opts *Options) WithCommitPipelineSerializers(serializers int) *Options {
+	opts.CommitPipelineSerializers = serializers
+	return opts
+}
+
+func (opts *Options) WithCommitPipelineAffinity(affinity bool) *Options {
+	opts.CommitPipelineAffinity = affinity
+	return opts
+}
+
 func (opts *Options) WithTimeFunc(timeFunc TimeFunc) *Options {
 	opts.TimeFunc = timeFunc
 	return opts
 }
 
+func (opts *Options) WithValueDedup(valueDedup bool) *Options {
+	opts.ValueDedup = valueDedup
+	return opts
+}
+
+func (opts *Options) WithValueCompression(algorithm int) *Options {
+	opts.ValueCompression = algorithm
+	return opts
+}
+
+func (opts *Options) WithValueCompressionThld(thld int) *Options {
+	opts.ValueCompressionThld = thld
+	return opts
+}
+
 func (opts *Options) WithWriteTxHeaderVersion(version int) *Options {
 	opts.WriteTxHeaderVersion = version
 	return opts
@@ -314,6 +505,26 @@ func (opts *Options) WithCompresionLevel(compressionLevel int) *Options {
 	return opts
 }
 
+func (opts *Options) WithVLogPreallocateFiles(preallocateFiles bool) *Options {
+	opts.VLogPreallocateFiles = preallocateFiles
+	return opts
+}
+
+func (opts *Options) WithVLogDirectIO(directIO bool) *Options {
+	opts.VLogDirectIO = directIO
+	return opts
+}
+
+func (opts *Options) WithChecksums(checksums bool) *Options {
+	opts.Checksums = checksums
+	return opts
+}
+
+func (opts *Options) WithIntegrityCheck(level IntegrityCheckLevel) *Options {
+	opts.IntegrityCheck = level
+	return opts
+}
+
 func (opts *Options) WithIndexOptions(indexOptions *IndexOptions) *Options {
 	opts.IndexOpts = indexOptions
 	return opts
@@ -326,6 +537,13 @@ func (opts *IndexOptions) WithCacheSize(cacheSize int) *IndexOptions {
 	return opts
 }
 
+// WithCachePolicy sets the replacement policy of the index nodes cache, cache.ARCPolicy by default
+// as it's not thrashed by scans, or cache.LRUPolicy
+func (opts *IndexOptions) WithCachePolicy(cachePolicy int) *IndexOptions {
+	opts.CachePolicy = cachePolicy
+	return opts
+}
+
 func (opts *IndexOptions) WithFlushThld(flushThld int) *IndexOptions {
 	opts.FlushThld = flushThld
 	return opts
@@ -385,3 +603,16 @@ func (opts *IndexOptions) WithCommitLogMaxOpenedFiles(commitLogMaxOpenedFiles in
 	opts.CommitLogMaxOpenedFiles = commitLogMaxOpenedFiles
 	return opts
 }
+
+// WithMmapReads makes index nodes to be read from memory mappings of the nodes log files, when supported
+func (opts *IndexOptions) WithMmapReads(mmapReads bool) *IndexOptions {
+	opts.MmapReads = mmapReads
+	return opts
+}
+
+// WithOffHeapFlushBuffers makes index flush buffers to be allocated outside of the Go heap, when supported,
+// reducing garbage collection pauses when FlushBufferSize is large
+func (opts *IndexOptions) WithOffHeapFlushBuffers(offHeapFlushBuffers bool) *IndexOptions {
+	opts.OffHeapFlushBuffers = offHeapFlushBuffers
+	return opts
+}
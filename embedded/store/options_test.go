@@ -21,6 +21,7 @@ import (
 
 	"github.com/codenotary/immudb/embedded/appendable"
 	"github.com/codenotary/immudb/embedded/appendable/multiapp"
+	"github.com/codenotary/immudb/embedded/cache"
 	"github.com/stretchr/testify/require"
 )
 
@@ -37,12 +38,17 @@ func TestValidOptions(t *testing.T) {
 	opts := &Options{}
 
 	require.Equal(t, 1, opts.WithCommitLogMaxOpenedFiles(1).CommitLogMaxOpenedFiles)
+	require.True(t, opts.WithVLogPreallocateFiles(true).VLogPreallocateFiles)
+	require.True(t, opts.WithVLogDirectIO(true).VLogDirectIO)
+	require.True(t, opts.WithChecksums(true).Checksums)
 	require.Equal(t, DefaultCompressionLevel, opts.WithCompresionLevel(DefaultCompressionLevel).CompressionLevel)
 	require.Equal(t, DefaultCompressionFormat, opts.WithCompressionFormat(DefaultCompressionFormat).CompressionFormat)
 	require.Equal(t, DefaultMaxConcurrency, opts.WithMaxConcurrency(DefaultMaxConcurrency).MaxConcurrency)
 	require.Equal(t, DefaultFileMode, opts.WithFileMode(DefaultFileMode).FileMode)
 	require.Equal(t, DefaultFileSize, opts.WithFileSize(DefaultFileSize).FileSize)
 	require.Equal(t, DefaultMaxIOConcurrency, opts.WithMaxIOConcurrency(DefaultMaxIOConcurrency).MaxIOConcurrency)
+	require.Equal(t, DefaultReplayConcurrency, opts.WithReplayConcurrency(DefaultReplayConcurrency).ReplayConcurrency)
+	require.Equal(t, 2048, opts.WithBinaryLinkingHorizon(2048).BinaryLinkingHorizon)
 	require.Equal(t, DefaultMaxKeyLen, opts.WithMaxKeyLen(DefaultMaxKeyLen).MaxKeyLen)
 	require.Equal(t, DefaultMaxLinearProofLen, opts.WithMaxLinearProofLen(DefaultMaxLinearProofLen).MaxLinearProofLen)
 	require.Equal(t, DefaultMaxTxEntries, opts.WithMaxTxEntries(DefaultMaxTxEntries).MaxTxEntries)
@@ -63,6 +69,22 @@ func TestValidOptions(t *testing.T) {
 
 	require.False(t, opts.WithReadOnly(false).ReadOnly)
 
+	require.True(t, opts.WithValueDedup(true).ValueDedup)
+
+	require.Equal(t, "replica", opts.WithReplicaDataPath("replica").ReplicaDataPath)
+	require.Equal(t, time.Second, opts.WithReplicaFollowInterval(time.Second).ReplicaFollowInterval)
+
+	require.Equal(t, time.Hour, opts.WithScrubInterval(time.Hour).ScrubInterval)
+	require.Equal(t, time.Second, opts.WithDelayDuringScrub(time.Second).DelayDuringScrub)
+	require.False(t, validOptions(opts.WithScrubInterval(-1)))
+	opts.WithScrubInterval(0)
+
+	require.Equal(t, S2ValueCompression, opts.WithValueCompression(S2ValueCompression).ValueCompression)
+	require.Equal(t, 1024, opts.WithValueCompressionThld(1024).ValueCompressionThld)
+
+	require.False(t, validOptions(opts.WithValueCompression(S2ValueCompression+1)))
+	opts.WithValueCompression(S2ValueCompression)
+
 	require.NotNil(t, opts.WithLog(DefaultOptions().log))
 
 	require.True(t, validOptions(opts))
@@ -70,6 +92,12 @@ func TestValidOptions(t *testing.T) {
 	require.True(t, opts.WithReadOnly(true).ReadOnly)
 	require.True(t, validOptions(opts))
 
+	require.True(t, opts.WithReadOnlyFilesystem(true).ReadOnlyFilesystem)
+	require.True(t, validOptions(opts))
+
+	require.False(t, validOptions(opts.WithReadOnly(false)))
+	opts.WithReadOnly(true).WithReadOnlyFilesystem(false)
+
 	require.Nil(t, opts.WithAppFactory(nil).appFactory)
 	require.True(t, validOptions(opts))
 
@@ -93,6 +121,7 @@ func TestValidOptions(t *testing.T) {
 	require.False(t, validOptions(opts))
 
 	require.Equal(t, 100, indexOpts.WithCacheSize(100).CacheSize)
+	require.Equal(t, cache.LRUPolicy, indexOpts.WithCachePolicy(cache.LRUPolicy).CachePolicy)
 	require.Equal(t, 1000, indexOpts.WithFlushThld(1000).FlushThld)
 	require.Equal(t, 10_000, indexOpts.WithSyncThld(10_000).SyncThld)
 	require.Equal(t, 10, indexOpts.WithMaxActiveSnapshots(10).MaxActiveSnapshots)
@@ -102,6 +131,8 @@ func TestValidOptions(t *testing.T) {
 	require.Equal(t, 10, indexOpts.WithNodesLogMaxOpenedFiles(10).NodesLogMaxOpenedFiles)
 	require.Equal(t, 11, indexOpts.WithHistoryLogMaxOpenedFiles(11).HistoryLogMaxOpenedFiles)
 	require.Equal(t, 12, indexOpts.WithCommitLogMaxOpenedFiles(12).CommitLogMaxOpenedFiles)
+	require.True(t, indexOpts.WithMmapReads(true).MmapReads)
+	require.True(t, indexOpts.WithOffHeapFlushBuffers(true).OffHeapFlushBuffers)
 	require.Equal(t, 3, indexOpts.WithCompactionThld(3).CompactionThld)
 	require.Equal(t, 1*time.Millisecond, indexOpts.WithDelayDuringCompaction(1*time.Millisecond).DelayDuringCompaction)
 	require.Equal(t, 4096*2, indexOpts.WithFlushBufferSize(4096*2).FlushBufferSize)
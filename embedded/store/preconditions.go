@@ -0,0 +1,153 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package store
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrPreconditionFailed = errors.New("precondition failed")
+
+// Precondition is a condition over the committed state of the store
+// a transaction requires to hold at commit time in order to be committed
+type Precondition interface {
+	String() string
+	Validate(st *ImmuStore) error
+	Check(idx KeyIndex) (bool, error)
+}
+
+// PreconditionKeyMustExist requires the key to be set and not deleted nor expired
+type PreconditionKeyMustExist struct {
+	Key []byte
+}
+
+func (cs *PreconditionKeyMustExist) String() string {
+	return fmt.Sprintf("KeyMustExist(%x)", cs.Key)
+}
+
+func (cs *PreconditionKeyMustExist) Validate(st *ImmuStore) error {
+	return validatePreconditionKey(st, cs.Key)
+}
+
+func (cs *PreconditionKeyMustExist) Check(idx KeyIndex) (bool, error) {
+	_, err := idx.Get(cs.Key)
+	if err != nil && !errors.Is(err, ErrKeyNotFound) {
+		return false, err
+	}
+
+	return err == nil, nil
+}
+
+// PreconditionKeyMustNotExist requires the key to be unset, deleted or expired
+type PreconditionKeyMustNotExist struct {
+	Key []byte
+}
+
+func (cs *PreconditionKeyMustNotExist) String() string {
+	return fmt.Sprintf("KeyMustNotExist(%x)", cs.Key)
+}
+
+func (cs *PreconditionKeyMustNotExist) Validate(st *ImmuStore) error {
+	return validatePreconditionKey(st, cs.Key)
+}
+
+func (cs *PreconditionKeyMustNotExist) Check(idx KeyIndex) (bool, error) {
+	_, err := idx.Get(cs.Key)
+	if err != nil && !errors.Is(err, ErrKeyNotFound) {
+		return false, err
+	}
+
+	return err != nil, nil
+}
+
+func validatePreconditionKey(st *ImmuStore, key []byte) error {
+	if len(key) == 0 {
+		return ErrNullKey
+	}
+
+	if len(key) > st.maxKeyLen {
+		return ErrorMaxKeyLenExceeded
+	}
+
+	return nil
+}
+
+// checkPreconditions must be called with the store mutex held, so no other
+// transaction can be committed in between
+func (s *ImmuStore) checkPreconditions(preconditions []Precondition) error {
+	if len(preconditions) == 0 {
+		return nil
+	}
+
+	// preconditions are checked against the latest committed state,
+	// including transactions waiting to be synced by the commit pipeline
+	if s.commitPipeline != nil {
+		err := s.commitPipeline.waitSynced()
+		if err != nil {
+			return err
+		}
+	}
+
+	committedTxID, _, _ := s.commitState()
+
+	err := s.WaitForIndexingUpto(committedTxID, nil)
+	if err != nil {
+		return err
+	}
+
+	idx := &unsafeIndex{st: s}
+
+	for _, c := range preconditions {
+		ok, err := c.Check(idx)
+		if err != nil {
+			return err
+		}
+
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrPreconditionFailed, c.String())
+		}
+	}
+
+	return nil
+}
+
+// PreconditionKeyNotModifiedAfterTx requires the key not to be set nor deleted by any tx after TxID
+type PreconditionKeyNotModifiedAfterTx struct {
+	Key  []byte
+	TxID uint64
+}
+
+func (cs *PreconditionKeyNotModifiedAfterTx) String() string {
+	return fmt.Sprintf("KeyNotModifiedAfterTx(%x, %d)", cs.Key, cs.TxID)
+}
+
+func (cs *PreconditionKeyNotModifiedAfterTx) Validate(st *ImmuStore) error {
+	return validatePreconditionKey(st, cs.Key)
+}
+
+func (cs *PreconditionKeyNotModifiedAfterTx) Check(idx KeyIndex) (bool, error) {
+	// deleted and expired entries are modifications as well
+	valRef, err := idx.GetWith(cs.Key)
+	if errors.Is(err, ErrKeyNotFound) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return valRef.Tx() <= cs.TxID, nil
+}
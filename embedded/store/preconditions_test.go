@@ -0,0 +1,174 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package store
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreconditions(t *testing.T) {
+	immuStore, err := Open("data_preconditions", DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("data_preconditions")
+	defer immuStore.Close()
+
+	t.Run("invalid preconditions should be rejected", func(t *testing.T) {
+		tx, err := immuStore.NewWriteOnlyTx()
+		require.NoError(t, err)
+
+		err = tx.AddPrecondition(nil)
+		require.ErrorIs(t, err, ErrIllegalArguments)
+
+		err = tx.AddPrecondition(&PreconditionKeyMustExist{})
+		require.ErrorIs(t, err, ErrNullKey)
+
+		err = tx.AddPrecondition(&PreconditionKeyMustNotExist{Key: make([]byte, immuStore.maxKeyLen+1)})
+		require.ErrorIs(t, err, ErrorMaxKeyLenExceeded)
+
+		err = tx.Cancel()
+		require.NoError(t, err)
+
+		err = tx.AddPrecondition(&PreconditionKeyMustExist{Key: []byte("key1")})
+		require.ErrorIs(t, err, ErrAlreadyClosed)
+	})
+
+	t.Run("key must not exist", func(t *testing.T) {
+		tx1, err := immuStore.NewWriteOnlyTx()
+		require.NoError(t, err)
+
+		tx2, err := immuStore.NewWriteOnlyTx()
+		require.NoError(t, err)
+
+		for _, tx := range []*OngoingTx{tx1, tx2} {
+			err = tx.Set([]byte("key1"), nil, []byte("value1"))
+			require.NoError(t, err)
+
+			err = tx.AddPrecondition(&PreconditionKeyMustNotExist{Key: []byte("key1")})
+			require.NoError(t, err)
+		}
+
+		_, err = tx1.Commit()
+		require.NoError(t, err)
+
+		// the key was set after tx2 was created
+		_, err = tx2.Commit()
+		require.ErrorIs(t, err, ErrPreconditionFailed)
+	})
+
+	t.Run("key must exist", func(t *testing.T) {
+		tx, err := immuStore.NewWriteOnlyTx()
+		require.NoError(t, err)
+
+		err = tx.Set([]byte("key2"), nil, []byte("value2"))
+		require.NoError(t, err)
+
+		err = tx.AddPrecondition(&PreconditionKeyMustExist{Key: []byte("key2")})
+		require.NoError(t, err)
+
+		_, err = tx.Commit()
+		require.ErrorIs(t, err, ErrPreconditionFailed)
+
+		tx, err = immuStore.NewWriteOnlyTx()
+		require.NoError(t, err)
+
+		err = tx.Set([]byte("key1"), nil, []byte("value2"))
+		require.NoError(t, err)
+
+		err = tx.AddPrecondition(&PreconditionKeyMustExist{Key: []byte("key1")})
+		require.NoError(t, err)
+
+		_, err = tx.Commit()
+		require.NoError(t, err)
+	})
+
+	t.Run("deleted keys should not exist", func(t *testing.T) {
+		tx, err := immuStore.NewTx()
+		require.NoError(t, err)
+
+		err = tx.Delete([]byte("key1"))
+		require.NoError(t, err)
+
+		_, err = tx.Commit()
+		require.NoError(t, err)
+
+		tx, err = immuStore.NewWriteOnlyTx()
+		require.NoError(t, err)
+
+		err = tx.Set([]byte("key1"), nil, []byte("value3"))
+		require.NoError(t, err)
+
+		err = tx.AddPrecondition(&PreconditionKeyMustNotExist{Key: []byte("key1")})
+		require.NoError(t, err)
+
+		_, err = tx.Commit()
+		require.NoError(t, err)
+
+		valRef, err := immuStore.Get([]byte("key1"))
+		require.NoError(t, err)
+
+		val, err := valRef.Resolve()
+		require.NoError(t, err)
+		require.Equal(t, []byte("value3"), val)
+	})
+
+	t.Run("key not modified after tx", func(t *testing.T) {
+		tx, err := immuStore.NewWriteOnlyTx()
+		require.NoError(t, err)
+
+		err = tx.Set([]byte("key3"), nil, []byte("value1"))
+		require.NoError(t, err)
+
+		txhdr, err := tx.Commit()
+		require.NoError(t, err)
+
+		for _, c := range []struct {
+			txID uint64
+			err  error
+		}{{txhdr.ID, nil}, {txhdr.ID - 1, ErrPreconditionFailed}} {
+			tx, err = immuStore.NewWriteOnlyTx()
+			require.NoError(t, err)
+
+			err = tx.Set([]byte("key3"), nil, []byte("value2"))
+			require.NoError(t, err)
+
+			err = tx.AddPrecondition(&PreconditionKeyNotModifiedAfterTx{Key: []byte("key3"), TxID: c.txID})
+			require.NoError(t, err)
+
+			_, err = tx.Commit()
+			if c.err == nil {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, c.err)
+			}
+		}
+
+		tx, err = immuStore.NewWriteOnlyTx()
+		require.NoError(t, err)
+
+		err = tx.Set([]byte("key4"), nil, []byte("value1"))
+		require.NoError(t, err)
+
+		// keys never set were not modified
+		err = tx.AddPrecondition(&PreconditionKeyNotModifiedAfterTx{Key: []byte("key4"), TxID: 1})
+		require.NoError(t, err)
+
+		_, err = tx.Commit()
+		require.NoError(t, err)
+	})
+}
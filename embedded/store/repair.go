@@ -0,0 +1,448 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/codenotary/immudb/embedded/appendable"
+	"github.com/codenotary/immudb/embedded/appendable/multiapp"
+	"github.com/codenotary/immudb/embedded/tbtree"
+)
+
+// RepairReport describes the data discarded from the tail of the logs of a store
+type RepairReport struct {
+	// CommittedTxID is the last committed transaction once the store is repaired
+	CommittedTxID uint64
+
+	// DiscardedTxs is the number of commits discarded because their transaction was not fully written
+	DiscardedTxs int
+
+	// DiscardedCommitLogBytes includes partially written commit entries
+	DiscardedCommitLogBytes int64
+
+	DiscardedTxLogBytes int64
+
+	// DiscardedValueLogBytes is the number of bytes discarded from each value log, indexed by its name
+	DiscardedValueLogBytes map[string]int64
+
+	// IndexDiscarded is set when the index contained discarded transactions and must be rebuilt
+	IndexDiscarded bool
+}
+
+// Repaired returns true if some data was discarded
+func (r *RepairReport) Repaired() bool {
+	if r.DiscardedTxs > 0 || r.DiscardedCommitLogBytes > 0 || r.DiscardedTxLogBytes > 0 || r.IndexDiscarded {
+		return true
+	}
+
+	for _, n := range r.DiscardedValueLogBytes {
+		if n > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+type repairVLog struct {
+	name string
+	app  *multiapp.MultiFileAppendable
+	size int64
+	end  int64 // end of the last committed value, -1 if not yet found
+}
+
+// storedLen returns the number of bytes taken by the value of the entry,
+// the length of compressed values is read from the value log
+func (vLog *repairVLog) storedLen(e *TxEntry, off int64) (int, error) {
+	if valueCompression(e.md) == NoValueCompression {
+		return e.vLen, nil
+	}
+
+	if off+lszSize > vLog.size {
+		return 0, fmt.Errorf("%w: value log %s is too small", ErrCorruptedData, vLog.name)
+	}
+
+	var hdr [lszSize]byte
+
+	_, err := vLog.app.ReadAt(hdr[:], off)
+	if err != nil {
+		return 0, err
+	}
+
+	return lszSize + int(binary.BigEndian.Uint32(hdr[:])), nil
+}
+
+// Repair detects and discards partially written data at the tail of the transaction, commit and value logs
+// left after a crash. Commits are discarded, starting from the last one, until one whose transaction and
+// values are fully written is found.
+// When opts.ReadOnly is set, the report of the data that would be discarded is returned without modifying the store.
+func Repair(path string, opts *Options) (*RepairReport, error) {
+	if !validOptions(opts) {
+		return nil, ErrIllegalArguments
+	}
+
+	finfo, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !finfo.IsDir() {
+		return nil, ErrorPathIsNotADirectory
+	}
+
+	appendableOpts := multiapp.DefaultOptions().
+		WithReadOnly(opts.ReadOnly).
+		WithSynced(true).
+		WithFileMode(opts.FileMode)
+
+	openApp := func(subPath, fileExt string) (*multiapp.MultiFileAppendable, error) {
+		appPath := filepath.Join(path, subPath)
+
+		// appendables must not be created while repairing
+		_, err := os.Stat(appPath)
+		if err != nil {
+			return nil, fmt.Errorf("unable to open %s: %w", subPath, err)
+		}
+
+		appendableOpts.WithFileExt(fileExt)
+
+		return multiapp.Open(appPath, appendableOpts)
+	}
+
+	txLog, err := openApp("tx", "tx")
+	if err != nil {
+		return nil, err
+	}
+	defer txLog.Close()
+
+	cLog, err := openApp("commit", "txi")
+	if err != nil {
+		return nil, err
+	}
+	defer cLog.Close()
+
+	vLogs, err := openRepairVLogs(path, openApp)
+	for _, vLog := range vLogs {
+		defer vLog.app.Close()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	metadata := appendable.NewMetadata(cLog.Metadata())
+
+	maxTxEntries, ok := metadata.GetInt(metaMaxTxEntries)
+	if !ok {
+		return nil, fmt.Errorf("corrupted commit log metadata (max tx entries): %w", ErrCorruptedCLog)
+	}
+
+	maxKeyLen, ok := metadata.GetInt(metaMaxKeyLen)
+	if !ok {
+		return nil, fmt.Errorf("corrupted commit log metadata (max key len): %w", ErrCorruptedCLog)
+	}
+
+	maxValueLen, ok := metadata.GetInt(metaMaxValueLen)
+	if !ok {
+		return nil, fmt.Errorf("corrupted commit log metadata (max value len): %w", ErrCorruptedCLog)
+	}
+	maxValueLen = maxInt(maxValueLen, opts.MaxValueLen)
+
+	cLogSize, err := cLog.Size()
+	if err != nil {
+		return nil, err
+	}
+
+	txLogSize, err := txLog.Size()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &RepairReport{
+		DiscardedValueLogBytes: make(map[string]int64, len(vLogs)),
+	}
+
+	report.DiscardedCommitLogBytes = cLogSize % cLogEntrySize
+
+	tx := newTx(maxTxEntries, maxKeyLen)
+
+	committedTxID := uint64(cLogSize / cLogEntrySize)
+	committedTxLogSize := int64(0)
+
+	for ; committedTxID > 0; committedTxID-- {
+		txOff, txSize, err := readCommitEntry(cLog, committedTxID)
+		if err != nil {
+			return nil, err
+		}
+
+		if txOff+int64(txSize) <= txLogSize && repairReadTx(tx, txLog, vLogs, committedTxID, txOff, txSize, maxValueLen) == nil {
+			committedTxLogSize = txOff + int64(txSize)
+			break
+		}
+
+		report.DiscardedTxs++
+		report.DiscardedCommitLogBytes += cLogEntrySize
+	}
+
+	report.CommittedTxID = committedTxID
+	report.DiscardedTxLogBytes = txLogSize - committedTxLogSize
+
+	err = repairVLogEnds(tx, txLog, cLog, vLogs, committedTxID, opts.MaxConcurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, vLog := range vLogs {
+		if vLog.end < 0 {
+			// no committed value is stored in this value log
+			vLog.end = 0
+		}
+
+		report.DiscardedValueLogBytes[vLog.name] = vLog.size - vLog.end
+	}
+
+	indexPath := filepath.Join(path, indexDirname)
+
+	_, err = os.Stat(indexPath)
+	if err == nil {
+		index, err := tbtree.Open(indexPath, tbtree.DefaultOptions().
+			WithReadOnly(true).
+			WithFileMode(opts.FileMode).
+			WithLog(opts.log))
+		if err != nil {
+			return nil, fmt.Errorf("could not open index: %w", err)
+		}
+
+		report.IndexDiscarded = index.Ts() > committedTxID
+
+		err = index.Close()
+		if err != nil {
+			return nil, err
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	if opts.ReadOnly || !report.Repaired() {
+		return report, nil
+	}
+
+	err = cLog.Truncate(int64(committedTxID) * cLogEntrySize)
+	if err != nil {
+		return nil, fmt.Errorf("unable to truncate commit log: %w", err)
+	}
+
+	err = txLog.Truncate(committedTxLogSize)
+	if err != nil {
+		return nil, fmt.Errorf("unable to truncate transaction log: %w", err)
+	}
+
+	for _, vLog := range vLogs {
+		if vLog.end == vLog.size {
+			continue
+		}
+
+		err = vLog.app.Truncate(vLog.end)
+		if err != nil {
+			return nil, fmt.Errorf("unable to truncate value log %s: %w", vLog.name, err)
+		}
+	}
+
+	if report.IndexDiscarded {
+		// the index is built from the logs, it will be rebuilt when the store is opened
+		err = os.RemoveAll(indexPath)
+		if err != nil {
+			return nil, fmt.Errorf("unable to discard index: %w", err)
+		}
+	}
+
+	return report, nil
+}
+
+// openRepairVLogs opens every uncompressed value log, compressed value logs are not repaired
+// as the length of their records is not known in advance
+func openRepairVLogs(path string, openApp func(subPath, fileExt string) (*multiapp.MultiFileAppendable, error)) (map[byte]*repairVLog, error) {
+	vLogs := make(map[byte]*repairVLog)
+
+	dirs, err := filepath.Glob(filepath.Join(path, "val_*"))
+	if err != nil {
+		return vLogs, err
+	}
+
+	for _, dir := range dirs {
+		name := filepath.Base(dir)
+
+		i, err := strconv.Atoi(strings.TrimPrefix(name, "val_"))
+		if err != nil || i < 0 || i > 254 {
+			continue
+		}
+
+		app, err := openApp(name, "val")
+		if err != nil {
+			return vLogs, err
+		}
+
+		if app.CompressionFormat() != appendable.NoCompression {
+			app.Close()
+			continue
+		}
+
+		size, err := app.Size()
+		if err != nil {
+			app.Close()
+			return vLogs, err
+		}
+
+		vLogs[byte(i+1)] = &repairVLog{name: name, app: app, size: size, end: -1}
+	}
+
+	return vLogs, nil
+}
+
+func readCommitEntry(cLog appendable.Appendable, txID uint64) (txOff int64, txSize int, err error) {
+	var b [cLogEntrySize]byte
+
+	_, err = cLog.ReadAt(b[:], int64(txID-1)*cLogEntrySize)
+	if err != nil {
+		return 0, 0, fmt.Errorf("corrupted commit log: could not read commit of tx %d: %w", txID, err)
+	}
+
+	return int64(binary.BigEndian.Uint64(b[:])), int(binary.BigEndian.Uint32(b[offsetSize:])), nil
+}
+
+// repairReadTx reads a transaction checking it and all its values were fully written
+func repairReadTx(tx *Tx, txLog appendable.Appendable, vLogs map[byte]*repairVLog, txID uint64, txOff int64, txSize int, maxValueLen int) (err error) {
+	defer func() {
+		// partially written data may contain an unknown tx version
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%w: %v", ErrorCorruptedTxData, r)
+		}
+	}()
+
+	err = tx.readFrom(appendable.NewReaderFrom(txLog, txOff, txSize))
+	if err != nil {
+		return err
+	}
+
+	if tx.header.ID != txID {
+		return fmt.Errorf("%w: unexpected tx id %d", ErrorCorruptedTxData, tx.header.ID)
+	}
+
+	for _, e := range tx.Entries() {
+		vLogID, off := decodeOffset(e.vOff)
+
+		vLog, ok := vLogs[vLogID]
+		if !ok {
+			continue
+		}
+
+		sLen, err := vLog.storedLen(e, off)
+		if err != nil {
+			return err
+		}
+
+		if off+int64(sLen) > vLog.size {
+			return fmt.Errorf("%w: value log %s is too small", ErrCorruptedData, vLog.name)
+		}
+
+		b := make([]byte, sLen)
+
+		_, err = vLog.app.ReadAt(b, off)
+		if err != nil {
+			return err
+		}
+
+		if algorithm := valueCompression(e.md); algorithm != NoValueCompression {
+			_, err = decompressValue(make([]byte, e.vLen), b[lszSize:], e.hVal, algorithm)
+		} else if e.hVal != sha256.Sum256(b) {
+			if !isChunkManifest(b) {
+				return fmt.Errorf("%w: value hash mismatch", ErrCorruptedData)
+			}
+
+			_, err = readChunkedValue(b, e.hVal, maxValueLen, func(cb []byte, coff int64) error {
+				cvLogID, cOff := decodeOffset(coff)
+
+				cvLog, ok := vLogs[cvLogID]
+				if !ok || cOff+int64(len(cb)) > cvLog.size {
+					return ErrCorruptedData
+				}
+
+				_, err := cvLog.app.ReadAt(cb, cOff)
+				return err
+			})
+		}
+		if err != nil {
+			return fmt.Errorf("%w: value hash mismatch", ErrCorruptedData)
+		}
+	}
+
+	return nil
+}
+
+// repairVLogEnds finds the end of the last committed value stored in each value log by reading
+// committed transactions, starting from the last one.
+// Values are written before transactions are committed, thus up to maxConcurrency transactions
+// preceding the last one using a value log may hold values placed after it
+func repairVLogEnds(tx *Tx, txLog, cLog appendable.Appendable, vLogs map[byte]*repairVLog, committedTxID uint64, maxConcurrency int) error {
+	pending := len(vLogs)
+	extra := maxConcurrency
+
+	for txID := committedTxID; txID > 0 && extra > 0; txID-- {
+		if pending == 0 {
+			extra--
+		}
+
+		txOff, txSize, err := readCommitEntry(cLog, txID)
+		if err != nil {
+			return err
+		}
+
+		err = tx.readFrom(appendable.NewReaderFrom(txLog, txOff, txSize))
+		if err != nil {
+			return fmt.Errorf("corrupted transaction log: could not read tx %d: %w", txID, err)
+		}
+
+		for _, e := range tx.Entries() {
+			vLogID, off := decodeOffset(e.vOff)
+
+			vLog, ok := vLogs[vLogID]
+			if !ok || e.vLen == 0 {
+				continue
+			}
+
+			if vLog.end < 0 {
+				pending--
+			}
+
+			sLen, err := vLog.storedLen(e, off)
+			if err != nil {
+				return err
+			}
+
+			if off+int64(sLen) > vLog.end {
+				vLog.end = off + int64(sLen)
+			}
+		}
+	}
+
+	return nil
+}
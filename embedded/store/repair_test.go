@@ -0,0 +1,263 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func setupRepairStore(t *testing.T, dir string, ntxs int) {
+	immuStore, err := Open(dir, DefaultOptions().WithMaxConcurrency(1).WithMaxIOConcurrency(1))
+	require.NoError(t, err)
+
+	for i := 0; i < ntxs; i++ {
+		tx, err := immuStore.NewWriteOnlyTx()
+		require.NoError(t, err)
+
+		err = tx.Set([]byte("key"), nil, []byte(fmt.Sprintf("value%d", i)))
+		require.NoError(t, err)
+
+		_, err = tx.Commit()
+		require.NoError(t, err)
+	}
+
+	err = immuStore.WaitForIndexingUpto(uint64(ntxs), nil)
+	require.NoError(t, err)
+
+	err = immuStore.Close()
+	require.NoError(t, err)
+}
+
+func appendToFile(t *testing.T, path string, bs []byte) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	require.NoError(t, err)
+
+	_, err = f.Write(bs)
+	require.NoError(t, err)
+
+	require.NoError(t, f.Close())
+}
+
+func truncateFile(t *testing.T, path string, n int64) {
+	stat, err := os.Stat(path)
+	require.NoError(t, err)
+
+	require.NoError(t, os.Truncate(path, stat.Size()-n))
+}
+
+func TestRepairNothingToRepair(t *testing.T) {
+	dir, err := ioutil.TempDir("", "repair_nothing")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	setupRepairStore(t, dir, 3)
+
+	report, err := Repair(dir, DefaultOptions())
+	require.NoError(t, err)
+	require.False(t, report.Repaired())
+	require.Equal(t, uint64(3), report.CommittedTxID)
+	require.Equal(t, int64(0), report.DiscardedValueLogBytes["val_0"])
+
+	_, err = Repair(filepath.Join(dir, "unexistent"), DefaultOptions())
+	require.Error(t, err)
+
+	_, err = Repair(dir, nil)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = Repair(filepath.Join(dir, "commit", "00000000.txi"), DefaultOptions())
+	require.ErrorIs(t, err, ErrorPathIsNotADirectory)
+}
+
+func TestRepairPartialWrites(t *testing.T) {
+	dir, err := ioutil.TempDir("", "repair_partial_writes")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	setupRepairStore(t, dir, 3)
+
+	appendToFile(t, filepath.Join(dir, "commit", "00000000.txi"), []byte{0, 0, 0, 0, 0})
+	appendToFile(t, filepath.Join(dir, "tx", "00000000.tx"), []byte{1, 2, 3})
+	appendToFile(t, filepath.Join(dir, "val_0", "00000000.val"), []byte{1, 2})
+
+	report, err := Repair(dir, DefaultOptions().WithReadOnly(true))
+	require.NoError(t, err)
+	require.True(t, report.Repaired())
+	require.Equal(t, uint64(3), report.CommittedTxID)
+	require.Equal(t, 0, report.DiscardedTxs)
+	require.Equal(t, int64(5), report.DiscardedCommitLogBytes)
+	require.Equal(t, int64(3), report.DiscardedTxLogBytes)
+	require.Equal(t, int64(2), report.DiscardedValueLogBytes["val_0"])
+	require.False(t, report.IndexDiscarded)
+
+	// nothing is discarded in read-only mode
+	report, err = Repair(dir, DefaultOptions().WithReadOnly(true))
+	require.NoError(t, err)
+	require.True(t, report.Repaired())
+
+	report, err = Repair(dir, DefaultOptions())
+	require.NoError(t, err)
+	require.True(t, report.Repaired())
+
+	report, err = Repair(dir, DefaultOptions())
+	require.NoError(t, err)
+	require.False(t, report.Repaired())
+
+	immuStore, err := Open(dir, DefaultOptions())
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), immuStore.TxCount())
+
+	tx, err := immuStore.NewWriteOnlyTx()
+	require.NoError(t, err)
+
+	err = tx.Set([]byte("key"), nil, []byte("value3"))
+	require.NoError(t, err)
+
+	hdr, err := tx.Commit()
+	require.NoError(t, err)
+	require.Equal(t, uint64(4), hdr.ID)
+
+	require.NoError(t, immuStore.Close())
+}
+
+func TestRepairTornTx(t *testing.T) {
+	dir, err := ioutil.TempDir("", "repair_torn_tx")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	setupRepairStore(t, dir, 3)
+
+	// the last transaction is partially written but its commit is not
+	truncateFile(t, filepath.Join(dir, "tx", "00000000.tx"), 1)
+
+	_, err = Open(dir, DefaultOptions())
+	require.Error(t, err)
+
+	report, err := Repair(dir, DefaultOptions())
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), report.CommittedTxID)
+	require.Equal(t, 1, report.DiscardedTxs)
+	require.Equal(t, int64(cLogEntrySize), report.DiscardedCommitLogBytes)
+	require.Greater(t, report.DiscardedTxLogBytes, int64(0))
+	require.Equal(t, int64(len("value2")), report.DiscardedValueLogBytes["val_0"])
+	require.True(t, report.IndexDiscarded)
+
+	immuStore, err := Open(dir, DefaultOptions())
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), immuStore.TxCount())
+
+	err = immuStore.WaitForIndexingUpto(2, nil)
+	require.NoError(t, err)
+
+	valRef, err := immuStore.Get([]byte("key"))
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), valRef.Tx())
+
+	require.NoError(t, immuStore.Close())
+}
+
+func TestRepairTornValue(t *testing.T) {
+	dir, err := ioutil.TempDir("", "repair_torn_value")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	setupRepairStore(t, dir, 3)
+
+	// the value of the last transaction is partially written
+	truncateFile(t, filepath.Join(dir, "val_0", "00000000.val"), 1)
+
+	report, err := Repair(dir, DefaultOptions())
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), report.CommittedTxID)
+	require.Equal(t, 1, report.DiscardedTxs)
+	require.Equal(t, int64(len("value2")-1), report.DiscardedValueLogBytes["val_0"])
+
+	immuStore, err := Open(dir, DefaultOptions())
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), immuStore.TxCount())
+	require.NoError(t, immuStore.Close())
+}
+
+func TestRepairTornCompressedValue(t *testing.T) {
+	dir, err := ioutil.TempDir("", "repair_torn_compressed_value")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	opts := DefaultOptions().
+		WithMaxConcurrency(1).
+		WithMaxIOConcurrency(1).
+		WithValueCompression(ZstdValueCompression).
+		WithValueCompressionThld(0)
+
+	value := func(i int) []byte {
+		return bytes.Repeat([]byte(fmt.Sprintf("value%d", i)), 100)
+	}
+
+	immuStore, err := Open(dir, opts)
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		tx, err := immuStore.NewWriteOnlyTx()
+		require.NoError(t, err)
+
+		err = tx.Set([]byte("key"), nil, value(i))
+		require.NoError(t, err)
+
+		_, err = tx.Commit()
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, immuStore.Close())
+
+	report, err := Repair(dir, opts)
+	require.NoError(t, err)
+	require.False(t, report.Repaired())
+
+	// the compressed value of the last transaction is partially written
+	truncateFile(t, filepath.Join(dir, "val_0", "00000000.val"), 1)
+
+	stored, err := compressValue(ZstdValueCompression, value(2))
+	require.NoError(t, err)
+
+	report, err = Repair(dir, opts)
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), report.CommittedTxID)
+	require.Equal(t, 1, report.DiscardedTxs)
+	require.Equal(t, int64(len(stored)-1), report.DiscardedValueLogBytes["val_0"])
+
+	immuStore, err = Open(dir, opts)
+	require.NoError(t, err)
+	defer immuStore.Close()
+
+	require.Equal(t, uint64(2), immuStore.TxCount())
+
+	err = immuStore.WaitForIndexingUpto(2, nil)
+	require.NoError(t, err)
+
+	valRef, err := immuStore.Get([]byte("key"))
+	require.NoError(t, err)
+
+	val, err := valRef.Resolve()
+	require.NoError(t, err)
+	require.Equal(t, value(1), val)
+}
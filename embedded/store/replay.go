@@ -0,0 +1,136 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package store
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"sync"
+
+	"github.com/codenotary/immudb/embedded/appendable"
+)
+
+// replayBatchSize is the number of txs read back in parallel before their alhs are handed over in order
+const replayBatchSize = 1000
+
+// txLink holds what is needed to check a tx is linked to the previous one
+type txLink struct {
+	id      uint64
+	prevAlh [sha256.Size]byte
+	alh     [sha256.Size]byte
+}
+
+// alhBatch holds the links of consecutive txs, starting from firstTxID
+type alhBatch struct {
+	firstTxID uint64
+	links     []txLink
+	err       error
+}
+
+// readAlhs reads back the txs in the given range, computing their alhs with replayConcurrency workers.
+// Batches are sent in tx order, so the links can be checked and the alhs appended to the binary linking tree
+// as they are received.
+// The channel is closed once all the txs were read, after a batch with an error or when done is closed
+func (s *ImmuStore) readAlhs(fromTxID, toTxID uint64, batches chan<- *alhBatch, done <-chan struct{}) {
+	defer close(batches)
+
+	txs := make([]*Tx, s.replayConcurrency)
+	for i := range txs {
+		txs[i] = s.NewTxHolder()
+	}
+
+	errs := make([]error, len(txs))
+
+	for firstTxID := fromTxID; firstTxID <= toTxID; firstTxID += replayBatchSize {
+		n := int(minUint64(replayBatchSize, toTxID-firstTxID+1))
+
+		batch := &alhBatch{
+			firstTxID: firstTxID,
+			links:     make([]txLink, n),
+		}
+
+		var wg sync.WaitGroup
+
+		// each worker takes every len(txs)-th tx of the batch
+		for w := range txs {
+			wg.Add(1)
+
+			go func(w int) {
+				defer wg.Done()
+
+				for i := w; i < n; i += len(txs) {
+					batch.links[i], errs[w] = s.readTxLink(txs[w], firstTxID+uint64(i))
+					if errs[w] != nil {
+						return
+					}
+				}
+			}(w)
+		}
+
+		wg.Wait()
+
+		for _, err := range errs {
+			if err != nil {
+				batch.err = err
+				break
+			}
+		}
+
+		select {
+		case batches <- batch:
+		case <-done:
+			return
+		}
+
+		if batch.err != nil {
+			return
+		}
+	}
+}
+
+// readTxLink reads the tx back from the transaction log, its alh is checked while reading.
+// Linking to the previous tx can only be checked in order, by the caller
+func (s *ImmuStore) readTxLink(tx *Tx, txID uint64) (link txLink, err error) {
+	txOff, txSize, err := s.txOffsetAndSize(txID)
+	if err != nil {
+		return link, err
+	}
+
+	err = tx.readFrom(appendable.NewReaderFrom(s.txLog, txOff, txSize))
+	if err != nil {
+		return link, s.wrapAppendableErr(err, "reading tx")
+	}
+
+	return txLink{
+		id:      tx.header.ID,
+		prevAlh: tx.header.PrevAlh,
+		alh:     tx.header.Alh(),
+	}, nil
+}
+
+// checkTxLink returns ErrorCorruptedTxData if the tx read at txID is not the one expected there
+// or if it is not linked to the tx preceding it
+func checkTxLink(link txLink, txID uint64, prevAlh [sha256.Size]byte) error {
+	if link.id != txID {
+		return fmt.Errorf("%w: unexpected tx id %d at tx %d", ErrorCorruptedTxData, link.id, txID)
+	}
+
+	if link.prevAlh != prevAlh {
+		return fmt.Errorf("%w: ALH mismatch at tx %d", ErrorCorruptedTxData, txID)
+	}
+
+	return nil
+}
@@ -0,0 +1,141 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package store
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestImmudbStoreBinaryLinkingReplay(t *testing.T) {
+	require.False(t, validOptions(DefaultOptions().WithReplayConcurrency(0)))
+
+	dir, err := ioutil.TempDir("", "store_binary_linking_replay")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	opts := DefaultOptions().WithSynced(false)
+
+	immuStore, err := Open(dir, opts)
+	require.NoError(t, err)
+
+	// more txs than a replay batch, the last batch being incomplete
+	txCount := 2*replayBatchSize + 10
+
+	for i := 0; i < txCount; i++ {
+		tx, err := immuStore.NewWriteOnlyTx()
+		require.NoError(t, err)
+
+		err = tx.Set([]byte(fmt.Sprintf("key%d", i)), nil, []byte(fmt.Sprintf("value%d", i)))
+		require.NoError(t, err)
+
+		_, err = tx.Commit()
+		require.NoError(t, err)
+	}
+
+	err = immuStore.Close()
+	require.NoError(t, err)
+
+	immuStore, err = Open(dir, opts)
+	require.NoError(t, err)
+
+	_, expectedRoot, err := immuStore.aht.Root()
+	require.NoError(t, err)
+
+	txLogSize := immuStore.committedTxLogSize
+
+	txOff, txSize, err := immuStore.txOffsetAndSize(replayBatchSize + 1)
+	require.NoError(t, err)
+
+	tamperedTx := immuStore.NewTxHolder()
+	err = immuStore.ReadTx(replayBatchSize+1, tamperedTx)
+	require.NoError(t, err)
+
+	err = immuStore.Close()
+	require.NoError(t, err)
+
+	for _, workers := range []int{1, 3, 8} {
+		t.Run(fmt.Sprintf("replay with %d workers", workers), func(t *testing.T) {
+			err := os.RemoveAll(filepath.Join(dir, ahtDirname))
+			require.NoError(t, err)
+
+			immuStore, err := Open(dir, opts.WithReplayConcurrency(workers).WithIntegrityCheck(FullIntegrityCheck))
+			require.NoError(t, err)
+			defer immuStore.Close()
+
+			n, root, err := immuStore.aht.Root()
+			require.NoError(t, err)
+			require.Equal(t, uint64(txCount), n)
+			require.Equal(t, expectedRoot, root)
+		})
+	}
+
+	t.Run("replay fails on unlinked txs", func(t *testing.T) {
+		err := os.RemoveAll(filepath.Join(dir, ahtDirname))
+		require.NoError(t, err)
+
+		file := filepath.Join(dir, "tx", "00000000.tx")
+
+		original, err := ioutil.ReadFile(file)
+		require.NoError(t, err)
+
+		b := make([]byte, len(original))
+		copy(b, original)
+
+		// the header is tampered but stays consistent with the stored alh,
+		// so that only the linking to the previous tx can reveal it
+		tamperedTx.header.PrevAlh[0] ^= 0xFF
+		alh := tamperedTx.header.Alh()
+
+		off := len(b) - int(txLogSize) + int(txOff)
+		copy(b[off+txIDSize+tsSize+txIDSize+sha256.Size:], tamperedTx.header.PrevAlh[:])
+		copy(b[off+txSize-sha256.Size:], alh[:])
+
+		err = ioutil.WriteFile(file, b, 0644)
+		require.NoError(t, err)
+
+		_, err = Open(dir, opts.WithReplayConcurrency(4).WithIntegrityCheck(FastIntegrityCheck))
+		require.ErrorIs(t, err, ErrorCorruptedTxData)
+
+		err = ioutil.WriteFile(file, original, 0644)
+		require.NoError(t, err)
+	})
+
+	t.Run("replay fails on corrupted txs", func(t *testing.T) {
+		err := os.RemoveAll(filepath.Join(dir, ahtDirname))
+		require.NoError(t, err)
+
+		file := filepath.Join(dir, "tx", "00000000.tx")
+
+		b, err := ioutil.ReadFile(file)
+		require.NoError(t, err)
+
+		// txs are stored after the file header, followed by their alh
+		b[len(b)-int(txLogSize)+int(txOff)+txSize-1] ^= 0xFF
+
+		err = ioutil.WriteFile(file, b, 0644)
+		require.NoError(t, err)
+
+		_, err = Open(dir, opts.WithReplayConcurrency(4).WithIntegrityCheck(FastIntegrityCheck))
+		require.ErrorIs(t, err, ErrorCorruptedTxData)
+	})
+}
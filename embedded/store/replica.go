@@ -0,0 +1,156 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/codenotary/immudb/embedded/appendable"
+)
+
+// replicaDataPath returns the directory where a read-only replica keeps its index and binary linking tree
+// and whether it is a temporary one
+func replicaDataPath(opts *Options) (string, bool, error) {
+	if opts.ReplicaDataPath == "" {
+		path, err := ioutil.TempDir("", "immudb_replica")
+		if err != nil {
+			return "", false, err
+		}
+
+		return path, true, nil
+	}
+
+	finfo, err := os.Stat(opts.ReplicaDataPath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return "", false, err
+		}
+
+		err := os.Mkdir(opts.ReplicaDataPath, opts.FileMode)
+		if err != nil {
+			return "", false, err
+		}
+	} else if !finfo.IsDir() {
+		return "", false, ErrorPathIsNotADirectory
+	}
+
+	return opts.ReplicaDataPath, false, nil
+}
+
+func (s *ImmuStore) followCommitLog() {
+	ticker := time.NewTicker(s.replicaFollowInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			{
+				err := s.Refresh()
+				if err == ErrAlreadyClosed {
+					return
+				}
+				if err != nil {
+					s.notify(Error, false, "Following commit log at '%s' failed: %v", s.path, err)
+				}
+			}
+		case <-s.replicaDone:
+			{
+				return
+			}
+		}
+	}
+}
+
+// Refresh makes the txs committed by the writer since the last refresh visible to a read-only replica.
+// It's periodically called but it may be explicitly called to catch up with the writer right away
+func (s *ImmuStore) Refresh() error {
+	if !s.readOnly {
+		return ErrIllegalState
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.closed {
+		return ErrAlreadyClosed
+	}
+
+	tx, err := s.fetchAllocTx()
+	if err != nil {
+		return err
+	}
+	defer s.releaseAllocTx(tx)
+
+	for {
+		committedTxID, committedAlh, _ := s.commitState()
+
+		var cb [cLogEntrySize]byte
+
+		_, err := s.cLog.ReadAt(cb[:], int64(committedTxID*cLogEntrySize))
+		if err == io.EOF || os.IsNotExist(err) {
+			// the writer may not have committed any further tx
+			return nil
+		}
+		if err != nil {
+			return s.wrapAppendableErr(err, "following commit log")
+		}
+
+		txOff := int64(binary.BigEndian.Uint64(cb[:]))
+		txSize := int(binary.BigEndian.Uint32(cb[offsetSize:]))
+
+		err = tx.readFrom(appendable.NewReaderFrom(s.txLog, txOff, txSize))
+		if err != nil {
+			return fmt.Errorf("%w: could not read tx %d", err, committedTxID+1)
+		}
+
+		if tx.header.ID != committedTxID+1 || tx.header.PrevAlh != committedAlh {
+			return fmt.Errorf("%w: tx %d does not follow the last known one", ErrCorruptedCLog, committedTxID+1)
+		}
+
+		alh := tx.header.Alh()
+
+		_, _, err = s.aht.Append(alh[:])
+		if err != nil {
+			return err
+		}
+
+		err = s.timeIndex.track(tx.header.ID, tx.header.Ts)
+		if err != nil {
+			return err
+		}
+
+		if s.valueDedup != nil {
+			err = s.valueDedup.persist(tx)
+			if err != nil {
+				return err
+			}
+		}
+
+		committedTxID = s.advanceCommitState(alh, int64(txSize))
+
+		if s.valueDedup != nil {
+			s.valueDedup.track(tx)
+		}
+
+		s.wHub.DoneUpto(committedTxID)
+	}
+}
@@ -0,0 +1,343 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestImmudbStoreReadOnlyReplica(t *testing.T) {
+	dir, err := ioutil.TempDir("", "store_replica")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// small files so the writer moves to new ones while being followed
+	immuStore, err := Open(dir, DefaultOptions().WithSynced(false).WithFileSize(256))
+	require.NoError(t, err)
+	defer immuStore.Close()
+
+	commit := func(i int) *TxHeader {
+		tx, err := immuStore.NewWriteOnlyTx()
+		require.NoError(t, err)
+
+		err = tx.Set([]byte(fmt.Sprintf("key%d", i)), nil, []byte(fmt.Sprintf("value%d", i)))
+		require.NoError(t, err)
+
+		hdr, err := tx.Commit()
+		require.NoError(t, err)
+
+		return hdr
+	}
+
+	for i := 0; i < 10; i++ {
+		commit(i)
+	}
+
+	checkReplica := func(replica *ImmuStore, n int) {
+		txID, alh := immuStore.Alh()
+
+		replicaTxID, replicaAlh := replica.Alh()
+		require.Equal(t, txID, replicaTxID)
+		require.Equal(t, alh, replicaAlh)
+
+		err := replica.WaitForIndexingUpto(txID, nil)
+		require.NoError(t, err)
+
+		for i := 0; i < n; i++ {
+			valRef, err := replica.Get([]byte(fmt.Sprintf("key%d", i)))
+			require.NoError(t, err)
+
+			val, err := valRef.Resolve()
+			require.NoError(t, err)
+			require.Equal(t, []byte(fmt.Sprintf("value%d", i)), val)
+		}
+
+		_, err = replica.Get([]byte(fmt.Sprintf("key%d", n)))
+		require.ErrorIs(t, err, ErrKeyNotFound)
+
+		sourceTx := replica.NewTxHolder()
+		err = replica.ReadTx(1, sourceTx)
+		require.NoError(t, err)
+
+		targetTx := replica.NewTxHolder()
+		err = replica.ReadTx(txID, targetTx)
+		require.NoError(t, err)
+
+		proof, err := replica.DualProof(sourceTx, targetTx)
+		require.NoError(t, err)
+		require.True(t, VerifyDualProof(proof, 1, txID, sourceTx.header.Alh(), alh))
+	}
+
+	t.Run("replicas follow the writer", func(t *testing.T) {
+		replica, err := Open(dir, DefaultOptions().
+			WithReadOnly(true).
+			WithReplicaFollowInterval(time.Hour))
+		require.NoError(t, err)
+		require.True(t, replica.ReadOnly())
+
+		replicaDataPath := replica.replicaDataPath
+		require.DirExists(t, replicaDataPath)
+
+		checkReplica(replica, 10)
+
+		for i := 10; i < 100; i++ {
+			commit(i)
+		}
+
+		require.Equal(t, uint64(10), replica.TxCount())
+
+		err = replica.Refresh()
+		require.NoError(t, err)
+
+		checkReplica(replica, 100)
+
+		_, err = replica.NewWriteOnlyTx()
+		require.NoError(t, err)
+
+		tx, err := replica.NewWriteOnlyTx()
+		require.NoError(t, err)
+
+		err = tx.Set([]byte("key"), nil, []byte("value"))
+		require.NoError(t, err)
+
+		_, err = tx.Commit()
+		require.ErrorIs(t, err, ErrReadOnlyStore)
+
+		_, err = replica.CommitWith(func(txID uint64, index KeyIndex) ([]*EntrySpec, error) {
+			return []*EntrySpec{{Key: []byte("key"), Value: []byte("value")}}, nil
+		}, false)
+		require.ErrorIs(t, err, ErrReadOnlyStore)
+
+		err = replica.Sync()
+		require.NoError(t, err)
+
+		err = replica.Close()
+		require.NoError(t, err)
+
+		require.NoDirExists(t, replicaDataPath)
+
+		err = replica.Refresh()
+		require.ErrorIs(t, err, ErrAlreadyClosed)
+	})
+
+	t.Run("replicas periodically follow the writer", func(t *testing.T) {
+		replica, err := Open(dir, DefaultOptions().
+			WithReadOnly(true).
+			WithReplicaFollowInterval(10*time.Millisecond))
+		require.NoError(t, err)
+		defer replica.Close()
+
+		hdr := commit(100)
+
+		err = replica.WaitForTx(hdr.ID, nil)
+		require.NoError(t, err)
+
+		checkReplica(replica, 101)
+	})
+
+	t.Run("replicas keep derived data apart from the writer", func(t *testing.T) {
+		replicaDataPath := filepath.Join(dir, "replica")
+
+		opts := DefaultOptions().
+			WithReadOnly(true).
+			WithReplicaDataPath(replicaDataPath).
+			WithReplicaFollowInterval(time.Hour)
+
+		replica, err := Open(dir, opts)
+		require.NoError(t, err)
+
+		checkReplica(replica, 101)
+
+		err = replica.Close()
+		require.NoError(t, err)
+
+		require.DirExists(t, filepath.Join(replicaDataPath, indexDirname))
+		require.DirExists(t, filepath.Join(replicaDataPath, ahtDirname))
+
+		commit(101)
+
+		replica, err = Open(dir, opts)
+		require.NoError(t, err)
+		defer replica.Close()
+
+		checkReplica(replica, 102)
+	})
+
+	t.Run("writers can not be refreshed", func(t *testing.T) {
+		err := immuStore.Refresh()
+		require.ErrorIs(t, err, ErrIllegalState)
+	})
+
+	t.Run("replicas of missing stores can not be opened", func(t *testing.T) {
+		_, err := Open(filepath.Join(dir, "missing"), DefaultOptions().WithReadOnly(true))
+		require.Error(t, err)
+		require.NoDirExists(t, filepath.Join(dir, "missing"))
+	})
+
+	t.Run("replica data path must be a directory", func(t *testing.T) {
+		file := filepath.Join(dir, "file")
+		err := ioutil.WriteFile(file, nil, 0644)
+		require.NoError(t, err)
+
+		_, err = Open(dir, DefaultOptions().WithReadOnly(true).WithReplicaDataPath(file))
+		require.ErrorIs(t, err, ErrorPathIsNotADirectory)
+	})
+}
+
+func TestImmudbStoreReadOnlyFilesystem(t *testing.T) {
+	dir, err := ioutil.TempDir("", "store_read_only_fs")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	immuStore, err := Open(dir, DefaultOptions().WithSynced(false).WithFileSize(256))
+	require.NoError(t, err)
+
+	var hdr *TxHeader
+
+	for i := 0; i < 10; i++ {
+		tx, err := immuStore.NewWriteOnlyTx()
+		require.NoError(t, err)
+
+		err = tx.Set([]byte(fmt.Sprintf("key%d", i)), nil, []byte(fmt.Sprintf("value%d", i)))
+		require.NoError(t, err)
+
+		hdr, err = tx.Commit()
+		require.NoError(t, err)
+	}
+
+	// derived data must cover all the committed txs
+	err = immuStore.WaitForIndexingUpto(hdr.ID, nil)
+	require.NoError(t, err)
+
+	err = immuStore.Close()
+	require.NoError(t, err)
+
+	listFiles := func() map[string]string {
+		files := make(map[string]string)
+
+		err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			files[path] = fmt.Sprintf("%d %v", info.Size(), info.ModTime())
+			return nil
+		})
+		require.NoError(t, err)
+
+		return files
+	}
+
+	files := listFiles()
+
+	// no temporary directory is available either
+	tmpDir := os.Getenv("TMPDIR")
+	os.Setenv("TMPDIR", filepath.Join(dir, "missing"))
+	defer os.Setenv("TMPDIR", tmpDir)
+
+	opts := DefaultOptions().WithReadOnly(true).WithReadOnlyFilesystem(true)
+
+	t.Run("stores on a read-only filesystem are never written", func(t *testing.T) {
+		roStore, err := Open(dir, opts)
+		require.NoError(t, err)
+		require.True(t, roStore.ReadOnly())
+
+		txID, alh := roStore.Alh()
+		require.Equal(t, hdr.ID, txID)
+		require.Equal(t, hdr.Alh(), alh)
+
+		for i := 0; i < 10; i++ {
+			valRef, err := roStore.Get([]byte(fmt.Sprintf("key%d", i)))
+			require.NoError(t, err)
+
+			val, err := valRef.Resolve()
+			require.NoError(t, err)
+			require.Equal(t, []byte(fmt.Sprintf("value%d", i)), val)
+		}
+
+		sourceTx := roStore.NewTxHolder()
+		err = roStore.ReadTx(1, sourceTx)
+		require.NoError(t, err)
+
+		targetTx := roStore.NewTxHolder()
+		err = roStore.ReadTx(hdr.ID, targetTx)
+		require.NoError(t, err)
+
+		proof, err := roStore.DualProof(sourceTx, targetTx)
+		require.NoError(t, err)
+		require.True(t, VerifyDualProof(proof, 1, hdr.ID, sourceTx.header.Alh(), alh))
+
+		firstTxID, err := roStore.FirstTxSince(time.Unix(0, 0))
+		require.NoError(t, err)
+		require.Equal(t, uint64(1), firstTxID)
+
+		tx, err := roStore.NewWriteOnlyTx()
+		require.NoError(t, err)
+
+		err = tx.Set([]byte("key"), nil, []byte("value"))
+		require.NoError(t, err)
+
+		_, err = tx.Commit()
+		require.ErrorIs(t, err, ErrReadOnlyStore)
+
+		err = roStore.CompactIndex()
+		require.ErrorIs(t, err, ErrReadOnlyStore)
+
+		err = roStore.FlushIndex(0, true)
+		require.ErrorIs(t, err, ErrReadOnlyStore)
+
+		err = roStore.Sync()
+		require.NoError(t, err)
+
+		status, err := roStore.Scrub()
+		require.NoError(t, err)
+		require.Empty(t, status.Err)
+
+		err = roStore.Close()
+		require.NoError(t, err)
+
+		require.Equal(t, files, listFiles())
+	})
+
+	t.Run("read-only filesystem requires read-only stores", func(t *testing.T) {
+		_, err := Open(dir, DefaultOptions().WithReadOnlyFilesystem(true))
+		require.ErrorIs(t, err, ErrIllegalArguments)
+	})
+
+	t.Run("derived data must be complete", func(t *testing.T) {
+		tsFiles, err := filepath.Glob(filepath.Join(dir, timeIndexDirname, "*.ts"))
+		require.NoError(t, err)
+		require.NotEmpty(t, tsFiles)
+
+		lastFile := tsFiles[len(tsFiles)-1]
+
+		finfo, err := os.Stat(lastFile)
+		require.NoError(t, err)
+
+		err = os.Truncate(lastFile, finfo.Size()-timeIndexEntrySize)
+		require.NoError(t, err)
+
+		_, err = Open(dir, opts)
+		require.ErrorIs(t, err, ErrIncompleteDerivedData)
+	})
+}
@@ -0,0 +1,284 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package store
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/codenotary/immudb/embedded/appendable"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var ErrScrubInProgress = errors.New("scrub already in progress")
+
+// scrubBatchSize is the number of transactions scrubbed between pauses
+const scrubBatchSize = 100
+
+// maxScrubMismatches is the maximum number of mismatches kept in the status of a scrub
+const maxScrubMismatches = 100
+
+var (
+	metricsScrubbedTxs = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "immudb_scrubbed_txs_total",
+		Help: "Number of transactions verified by scrubs since the immudb process was started",
+	}, []string{"db"})
+
+	metricsScrubMismatches = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "immudb_scrub_mismatches_total",
+		Help: "Number of corrupted transactions found by scrubs since the immudb process was started",
+	}, []string{"db"})
+
+	metricsLastScrubFinished = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "immudb_last_scrub_finished_seconds",
+		Help: "Unix time at which the last scrub finished",
+	}, []string{"db"})
+)
+
+// ScrubMismatch describes a transaction found to be corrupted
+type ScrubMismatch struct {
+	TxID   uint64
+	Reason string
+}
+
+// ScrubStatus describes the ongoing scrub or the last one when none is running.
+// Each scrub verifies the transactions committed when it started.
+type ScrubStatus struct {
+	Running    bool
+	StartedAt  time.Time
+	FinishedAt time.Time
+
+	// ScrubbedTxs is the number of transactions verified so far, out of TargetTxID
+	ScrubbedTxs uint64
+	TargetTxID  uint64
+
+	// Mismatches holds up to 100 corrupted transactions, MismatchCount is the total found
+	Mismatches    []ScrubMismatch
+	MismatchCount uint64
+
+	// Err is set when the scrub could not be completed
+	Err error
+}
+
+// ScrubStatus returns the status of the ongoing scrub or the last one
+func (s *ImmuStore) ScrubStatus() ScrubStatus {
+	s.scrubMutex.Lock()
+	defer s.scrubMutex.Unlock()
+
+	status := s.scrubStatus
+	status.Mismatches = append([]ScrubMismatch(nil), s.scrubStatus.Mismatches...)
+
+	return status
+}
+
+// Scrub verifies every committed transaction: transactions are read back from disk checking
+// their entries digests and linear linking, values are checked against their digests and
+// transactions against the binary linking tree. Found mismatches are reported in the returned status.
+func (s *ImmuStore) Scrub() (ScrubStatus, error) {
+	s.scrubMutex.Lock()
+
+	if s.scrubStatus.Running {
+		s.scrubMutex.Unlock()
+		return ScrubStatus{}, ErrScrubInProgress
+	}
+
+	targetTxID, _, _ := s.commitState()
+
+	s.scrubStatus = ScrubStatus{
+		Running:    true,
+		StartedAt:  time.Now(),
+		TargetTxID: targetTxID,
+	}
+
+	s.scrubMutex.Unlock()
+
+	err := s.scrub(targetTxID)
+
+	s.scrubMutex.Lock()
+	s.scrubStatus.Running = false
+	s.scrubStatus.FinishedAt = time.Now()
+	s.scrubStatus.Err = err
+	s.scrubMutex.Unlock()
+
+	if err == nil {
+		metricsLastScrubFinished.WithLabelValues(filepath.Base(s.path)).SetToCurrentTime()
+	}
+
+	return s.ScrubStatus(), err
+}
+
+func (s *ImmuStore) scrubPeriodically() {
+	timer := time.NewTimer(s.scrubInterval)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-timer.C:
+			{
+				status, err := s.Scrub()
+				if err == ErrAlreadyClosed {
+					return
+				}
+				if err != nil {
+					s.notify(Error, false, "Scrubbing at '%s' failed: %v", s.path, err)
+				} else if status.MismatchCount > 0 {
+					s.notify(Error, true, "Scrubbing at '%s' found %d corrupted transactions", s.path, status.MismatchCount)
+				}
+
+				timer.Reset(s.scrubInterval)
+			}
+		case <-s.scrubDone:
+			{
+				return
+			}
+		}
+	}
+}
+
+func (s *ImmuStore) scrub(targetTxID uint64) error {
+	tx, err := s.fetchAllocTx()
+	if err != nil {
+		return err
+	}
+	defer s.releaseAllocTx(tx)
+
+	dbName := filepath.Base(s.path)
+	scrubbedTxs := metricsScrubbedTxs.WithLabelValues(dbName)
+	scrubMismatches := metricsScrubMismatches.WithLabelValues(dbName)
+
+	// alh of the previous tx, unknown when the previous tx is corrupted
+	prevAlh := sha256.Sum256(nil)
+	prevAlhKnown := true
+
+	for txID := uint64(1); txID <= targetTxID; txID++ {
+		if txID%scrubBatchSize == 0 && s.delayDuringScrub > 0 {
+			select {
+			case <-time.After(s.delayDuringScrub):
+			case <-s.scrubDone:
+				return ErrAlreadyClosed
+			}
+		}
+
+		alh, err := s.scrubTx(tx, txID)
+		if err == ErrAlreadyClosed {
+			return err
+		}
+
+		if err == nil && prevAlhKnown && tx.header.PrevAlh != prevAlh {
+			err = fmt.Errorf("%w: tx is not linked to the previous one", ErrorCorruptedTxData)
+		}
+
+		prevAlh = alh
+		prevAlhKnown = err == nil
+
+		scrubbedTxs.Inc()
+
+		s.scrubMutex.Lock()
+
+		s.scrubStatus.ScrubbedTxs++
+
+		if err != nil {
+			s.scrubStatus.MismatchCount++
+
+			if len(s.scrubStatus.Mismatches) < maxScrubMismatches {
+				s.scrubStatus.Mismatches = append(s.scrubStatus.Mismatches, ScrubMismatch{TxID: txID, Reason: err.Error()})
+			}
+		}
+
+		s.scrubMutex.Unlock()
+
+		if err != nil {
+			scrubMismatches.Inc()
+			s.log.Errorf("Scrubbing at '%s' found tx %d to be corrupted: %v", s.path, txID, err)
+		}
+	}
+
+	return nil
+}
+
+// scrubTx reads the tx back from disk verifying its content, it returns the alh of the tx
+func (s *ImmuStore) scrubTx(tx *Tx, txID uint64) (alh [sha256.Size]byte, err error) {
+	defer func() {
+		// corrupted data may contain an unknown tx version
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%w: %v", ErrorCorruptedTxData, r)
+		}
+	}()
+
+	txOff, txSize, err := s.txOffsetAndSize(txID)
+	if err != nil {
+		return alh, err
+	}
+
+	// the tx is read skipping the cache, entries digests and alh are checked while reading
+	err = tx.readFrom(appendable.NewReaderFrom(s.txLog, txOff, txSize))
+	if err != nil {
+		return alh, s.wrapAppendableErr(err, "scrubbing tx")
+	}
+
+	if tx.header.ID != txID {
+		return alh, fmt.Errorf("%w: unexpected tx id %d", ErrorCorruptedTxData, tx.header.ID)
+	}
+
+	alh = tx.header.Alh()
+
+	for i, e := range tx.Entries() {
+		if e.vLen > s.maxValueLen {
+			return alh, fmt.Errorf("%w: invalid value length of entry %d", ErrorCorruptedTxData, i)
+		}
+
+		_, err = s.readValueAt(make([]byte, e.vLen), e.vOff, e.hVal, e.md)
+		if err == ErrAlreadyClosed {
+			return alh, err
+		}
+		if err == ErrValueTruncated {
+			continue
+		}
+		if err != nil {
+			return alh, fmt.Errorf("%w: value of entry %d could not be verified: %v", ErrCorruptedData, i, err)
+		}
+	}
+
+	blTxID := s.aht.Size()
+
+	if txID <= blTxID {
+		leaf, err := s.aht.DataAt(txID)
+		if err != nil && err != ErrBinaryLinkingPruned {
+			return alh, s.wrapAppendableErr(err, "scrubbing tx")
+		}
+
+		if err == nil && string(leaf) != string(alh[:]) {
+			return alh, fmt.Errorf("%w: tx does not match the binary linking tree", ErrorCorruptedTxData)
+		}
+	}
+
+	if tx.header.BlTxID > 0 && tx.header.BlTxID <= blTxID {
+		blRoot, err := s.aht.RootAt(tx.header.BlTxID)
+		if err != nil && err != ErrBinaryLinkingPruned {
+			return alh, s.wrapAppendableErr(err, "scrubbing tx")
+		}
+
+		if err == nil && blRoot != tx.header.BlRoot {
+			return alh, fmt.Errorf("%w: binary linking root mismatch", ErrorCorruptedTxData)
+		}
+	}
+
+	return alh, nil
+}
@@ -0,0 +1,121 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package store
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestImmudbStoreScrub(t *testing.T) {
+	dir, err := ioutil.TempDir("", "store_scrub")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	opts := DefaultOptions().WithDelayDuringScrub(time.Millisecond)
+
+	immuStore, err := Open(dir, opts)
+	require.NoError(t, err)
+
+	for i := 0; i < 150; i++ {
+		tx, err := immuStore.NewWriteOnlyTx()
+		require.NoError(t, err)
+
+		err = tx.Set([]byte(fmt.Sprintf("key%d", i)), nil, []byte(fmt.Sprintf("value%d", i)))
+		require.NoError(t, err)
+
+		_, err = tx.Commit()
+		require.NoError(t, err)
+	}
+
+	require.False(t, immuStore.ScrubStatus().Running)
+
+	status, err := immuStore.Scrub()
+	require.NoError(t, err)
+	require.False(t, status.Running)
+	require.Equal(t, uint64(150), status.TargetTxID)
+	require.Equal(t, uint64(150), status.ScrubbedTxs)
+	require.Zero(t, status.MismatchCount)
+	require.Empty(t, status.Mismatches)
+	require.False(t, status.FinishedAt.Before(status.StartedAt))
+
+	err = immuStore.Close()
+	require.NoError(t, err)
+
+	_, err = immuStore.Scrub()
+	require.ErrorIs(t, err, ErrAlreadyClosed)
+
+	// corrupts the value of the last tx
+	f, err := os.OpenFile(filepath.Join(dir, "val_0", "00000000.val"), os.O_RDWR, 0644)
+	require.NoError(t, err)
+
+	stat, err := f.Stat()
+	require.NoError(t, err)
+
+	_, err = f.WriteAt([]byte("X"), stat.Size()-1)
+	require.NoError(t, err)
+
+	err = f.Close()
+	require.NoError(t, err)
+
+	immuStore, err = Open(dir, opts)
+	require.NoError(t, err)
+	defer immuStore.Close()
+
+	status, err = immuStore.Scrub()
+	require.NoError(t, err)
+	require.Equal(t, uint64(150), status.ScrubbedTxs)
+	require.Equal(t, uint64(1), status.MismatchCount)
+	require.Len(t, status.Mismatches, 1)
+	require.Equal(t, uint64(150), status.Mismatches[0].TxID)
+	require.Contains(t, status.Mismatches[0].Reason, ErrCorruptedData.Error())
+}
+
+func TestImmudbStoreBackgroundScrub(t *testing.T) {
+	dir, err := ioutil.TempDir("", "store_background_scrub")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	immuStore, err := Open(dir, DefaultOptions().WithScrubInterval(10*time.Millisecond))
+	require.NoError(t, err)
+	defer immuStore.Close()
+
+	for i := 0; i < 10; i++ {
+		tx, err := immuStore.NewWriteOnlyTx()
+		require.NoError(t, err)
+
+		err = tx.Set([]byte(fmt.Sprintf("key%d", i)), nil, []byte(fmt.Sprintf("value%d", i)))
+		require.NoError(t, err)
+
+		_, err = tx.Commit()
+		require.NoError(t, err)
+	}
+
+	require.Eventually(t, func() bool {
+		status := immuStore.ScrubStatus()
+		return !status.Running && status.TargetTxID == 10 && status.ScrubbedTxs == 10
+	}, 5*time.Second, 10*time.Millisecond)
+
+	status := immuStore.ScrubStatus()
+	require.NoError(t, status.Err)
+	require.Zero(t, status.MismatchCount)
+}
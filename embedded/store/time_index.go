@@ -0,0 +1,272 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package store
+
+import (
+	"encoding/binary"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/codenotary/immudb/embedded/appendable"
+	"github.com/codenotary/immudb/embedded/appendable/multiapp"
+)
+
+const timeIndexDirname = "timeindex"
+const timeIndexEntrySize = 8
+
+// timeIndex maps commit times to txs. For every tx it keeps the latest commit time up to it,
+// so entries are sorted even if the clock moved backward, and the txs committed in a time range are found
+// by binary search without reading any tx header.
+// Like the binary linking tree, it's derived data, built from the committed txs when missing
+type timeIndex struct {
+	app    appendable.Appendable
+	size   uint64 // number of indexed txs
+	lastTs int64
+	mutex  sync.RWMutex
+}
+
+func openTimeIndex(path string, opts *Options, fileSize int) (*timeIndex, error) {
+	appOpts := multiapp.DefaultOptions().
+		WithReadOnly(opts.ReadOnlyFilesystem).
+		WithSynced(opts.Synced).
+		WithFileSize(fileSize).
+		WithFileMode(opts.FileMode).
+		WithFileExt("ts")
+
+	var app appendable.Appendable
+	var err error
+
+	if opts.appFactory == nil {
+		app, err = multiapp.Open(filepath.Join(path, timeIndexDirname), appOpts)
+	} else {
+		app, err = opts.appFactory(path, timeIndexDirname, appOpts)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	appSize, err := app.Size()
+	if err != nil {
+		return nil, err
+	}
+
+	ti := &timeIndex{
+		app:  app,
+		size: uint64(appSize / timeIndexEntrySize),
+	}
+
+	if ti.size > 0 {
+		ti.lastTs, err = ti.tsAt(ti.size)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return ti, nil
+}
+
+// resetSize discards the entries of the txs after the given one
+func (ti *timeIndex) resetSize(txID uint64) error {
+	ti.mutex.Lock()
+	defer ti.mutex.Unlock()
+
+	return ti.reset(txID)
+}
+
+func (ti *timeIndex) reset(txID uint64) error {
+	err := ti.app.SetOffset(int64(txID * timeIndexEntrySize))
+	if err != nil {
+		return err
+	}
+
+	ti.size = txID
+	ti.lastTs = 0
+
+	if txID > 0 {
+		ti.lastTs, err = ti.tsAt(txID)
+	}
+
+	return err
+}
+
+// track indexes the commit time of the next tx
+func (ti *timeIndex) track(txID uint64, ts int64) error {
+	ti.mutex.Lock()
+	defer ti.mutex.Unlock()
+
+	if txID == 0 || txID > ti.size+1 {
+		return fmt.Errorf("%w: tx %d does not follow the last indexed one", ErrIllegalState, txID)
+	}
+
+	// will overwrite the entries of uncommitted txs
+	if txID <= ti.size {
+		err := ti.reset(txID - 1)
+		if err != nil {
+			return err
+		}
+	}
+
+	if ts < ti.lastTs {
+		ts = ti.lastTs
+	}
+
+	var b [timeIndexEntrySize]byte
+	binary.BigEndian.PutUint64(b[:], uint64(ts))
+
+	_, _, err := ti.app.Append(b[:])
+	if err != nil {
+		return err
+	}
+
+	err = ti.app.Flush()
+	if err != nil {
+		return err
+	}
+
+	ti.size++
+	ti.lastTs = ts
+
+	return nil
+}
+
+func (ti *timeIndex) tsAt(txID uint64) (int64, error) {
+	var b [timeIndexEntrySize]byte
+
+	_, err := ti.app.ReadAt(b[:], int64((txID-1)*timeIndexEntrySize))
+	if err != nil {
+		return 0, err
+	}
+
+	return int64(binary.BigEndian.Uint64(b[:])), nil
+}
+
+// search returns the first tx for which cond holds, assuming it holds for every following tx as well,
+// or size+1 if it holds for no tx
+func (ti *timeIndex) search(cond func(ts int64) bool) (uint64, error) {
+	ti.mutex.RLock()
+	defer ti.mutex.RUnlock()
+
+	lo, hi := uint64(1), ti.size+1
+
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+
+		ts, err := ti.tsAt(mid)
+		if err != nil {
+			return 0, err
+		}
+
+		if cond(ts) {
+			hi = mid
+		} else {
+			lo = mid + 1
+		}
+	}
+
+	return lo, nil
+}
+
+func (ti *timeIndex) Sync() error {
+	return ti.app.Sync()
+}
+
+func (ti *timeIndex) Close() error {
+	return ti.app.Close()
+}
+
+func (s *ImmuStore) syncTimeIndex() error {
+	if s.timeIndex.size == s.committedTxID {
+		return nil
+	}
+
+	s.log.Infof("Indexing commit times at '%s'...", s.path)
+
+	tx, err := s.fetchAllocTx()
+	if err != nil {
+		return err
+	}
+	defer s.releaseAllocTx(tx)
+
+	txReader, err := s.NewTxReader(s.timeIndex.size+1, false, tx)
+	if err != nil {
+		return err
+	}
+
+	for {
+		tx, err := txReader.Read()
+		if err == ErrNoMoreEntries {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		err = s.timeIndex.track(tx.header.ID, tx.header.Ts)
+		if err != nil {
+			return err
+		}
+
+		if tx.header.ID%1000 == 0 {
+			s.log.Infof("Indexing commit times at '%s' in progress: processing tx: %d", s.path, tx.header.ID)
+		}
+	}
+
+	s.log.Infof("Commit times indexed at '%s'", s.path)
+
+	return nil
+}
+
+// FirstTxSince returns the first tx committed at or after the given time.
+// Commit times are kept with a precision of seconds
+func (s *ImmuStore) FirstTxSince(ts time.Time) (uint64, error) {
+	txID, err := s.timeIndex.search(func(txTs int64) bool {
+		return txTs >= ts.Unix()
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if txID > s.TxCount() {
+		return 0, ErrTxNotFound
+	}
+
+	return txID, nil
+}
+
+// LastTxUntil returns the last tx committed at or before the given time.
+// Commit times are kept with a precision of seconds
+func (s *ImmuStore) LastTxUntil(ts time.Time) (uint64, error) {
+	txID, err := s.timeIndex.search(func(txTs int64) bool {
+		return txTs > ts.Unix()
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if txID == 1 {
+		return 0, ErrTxNotFound
+	}
+
+	lastTxID := s.TxCount()
+
+	if txID-1 > lastTxID {
+		return lastTxID, nil
+	}
+
+	return txID - 1, nil
+}
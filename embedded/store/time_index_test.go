@@ -0,0 +1,113 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package store
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestImmudbStoreTimeIndex(t *testing.T) {
+	dir, err := ioutil.TempDir("", "store_time_index")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	immuStore, err := Open(dir, DefaultOptions())
+	require.NoError(t, err)
+
+	_, err = immuStore.FirstTxSince(time.Unix(0, 0))
+	require.ErrorIs(t, err, ErrTxNotFound)
+
+	_, err = immuStore.LastTxUntil(time.Unix(1000, 0))
+	require.ErrorIs(t, err, ErrTxNotFound)
+
+	// the clock moves backward when tx 4 is committed
+	for i, ts := range []int64{100, 100, 200, 150, 300} {
+		err = immuStore.UseTimeFunc(func() time.Time { return time.Unix(ts, 0) })
+		require.NoError(t, err)
+
+		tx, err := immuStore.NewWriteOnlyTx()
+		require.NoError(t, err)
+
+		err = tx.Set([]byte(fmt.Sprintf("key%d", i)), nil, []byte(fmt.Sprintf("value%d", i)))
+		require.NoError(t, err)
+
+		_, err = tx.Commit()
+		require.NoError(t, err)
+	}
+
+	checkTimeIndex := func(t *testing.T, immuStore *ImmuStore) {
+		for _, c := range []struct {
+			ts         int64
+			firstSince uint64
+			lastUntil  uint64
+		}{
+			{ts: 50, firstSince: 1},
+			{ts: 100, firstSince: 1, lastUntil: 2},
+			{ts: 150, firstSince: 3, lastUntil: 2},
+			{ts: 200, firstSince: 3, lastUntil: 4},
+			{ts: 250, firstSince: 5, lastUntil: 4},
+			{ts: 300, firstSince: 5, lastUntil: 5},
+			{ts: 350, lastUntil: 5},
+		} {
+			txID, err := immuStore.FirstTxSince(time.Unix(c.ts, 0))
+			if c.firstSince == 0 {
+				require.ErrorIs(t, err, ErrTxNotFound)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, c.firstSince, txID, "first tx since %d", c.ts)
+			}
+
+			txID, err = immuStore.LastTxUntil(time.Unix(c.ts, 0))
+			if c.lastUntil == 0 {
+				require.ErrorIs(t, err, ErrTxNotFound)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, c.lastUntil, txID, "last tx until %d", c.ts)
+			}
+		}
+	}
+
+	checkTimeIndex(t, immuStore)
+
+	err = immuStore.Close()
+	require.NoError(t, err)
+
+	t.Run("the time index should be kept when the store is reopened", func(t *testing.T) {
+		immuStore, err := Open(dir, DefaultOptions())
+		require.NoError(t, err)
+		defer immuStore.Close()
+
+		checkTimeIndex(t, immuStore)
+	})
+
+	t.Run("the time index should be rebuilt when missing", func(t *testing.T) {
+		err := os.RemoveAll(filepath.Join(dir, timeIndexDirname))
+		require.NoError(t, err)
+
+		immuStore, err := Open(dir, DefaultOptions())
+		require.NoError(t, err)
+		defer immuStore.Close()
+
+		checkTimeIndex(t, immuStore)
+	})
+}
@@ -0,0 +1,393 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package store
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+var ErrValueTruncated = errors.New("value discarded by truncation")
+
+const truncationFilename = "truncation"
+
+// truncationEntrySize is the size of the offset up to which a vLog was truncated: vLog id + offset
+const truncationEntrySize = 1 + offsetSize
+
+// truncation keeps the offsets up to which the values of each vLog were discarded.
+// Truncation is logical: discarded values can not be read anymore, but the space they take is only reclaimed
+// once the vLogs are compacted. Offsets are persisted so discarded values stay unreadable after the store is reopened
+type truncation struct {
+	path     string
+	fileMode os.FileMode
+	offsets  map[byte]int64
+
+	progress float32
+
+	// running serializes truncations and vLog compactions
+	running sync.Mutex
+	mutex   sync.RWMutex
+}
+
+func openTruncation(path string, fileMode os.FileMode) (*truncation, error) {
+	t := &truncation{
+		path:     filepath.Join(path, truncationFilename),
+		fileMode: fileMode,
+		offsets:  make(map[byte]int64),
+	}
+
+	b, err := ioutil.ReadFile(t.path)
+	if os.IsNotExist(err) {
+		return t, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(b)%truncationEntrySize != 0 {
+		return nil, fmt.Errorf("%w: invalid truncation file size", ErrCorruptedData)
+	}
+
+	for i := 0; i < len(b); i += truncationEntrySize {
+		t.offsets[b[i]] = int64(binary.BigEndian.Uint64(b[i+1:]))
+	}
+
+	return t, nil
+}
+
+// truncated returns true if the value stored at the given offset of the vLog was discarded
+func (t *truncation) truncated(vLogID byte, off int64) bool {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	return off < t.offsets[vLogID]
+}
+
+func (t *truncation) snapshot() map[byte]int64 {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	offsets := make(map[byte]int64, len(t.offsets))
+	for vLogID, off := range t.offsets {
+		offsets[vLogID] = off
+	}
+
+	return offsets
+}
+
+// advance moves the offsets forward, offsets lower than the current ones are ignored
+func (t *truncation) advance(offsets map[byte]int64) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	for vLogID, off := range offsets {
+		if off > t.offsets[vLogID] {
+			t.offsets[vLogID] = off
+		}
+	}
+}
+
+// persist writes the current offsets, the file is replaced at once so it's never partially written
+func (t *truncation) persist(synced bool) error {
+	offsets := t.snapshot()
+
+	b := make([]byte, 0, len(offsets)*truncationEntrySize)
+
+	for vLogID, off := range offsets {
+		var e [truncationEntrySize]byte
+		e[0] = vLogID
+		binary.BigEndian.PutUint64(e[1:], uint64(off))
+
+		b = append(b, e[:]...)
+	}
+
+	tmpPath := t.path + ".tmp"
+
+	f, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, t.fileMode)
+	if err != nil {
+		return err
+	}
+
+	_, err = f.Write(b)
+	if err == nil && synced {
+		err = f.Sync()
+	}
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	err = f.Close()
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmpPath, t.path)
+}
+
+func (t *truncation) setProgress(progress float32) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.progress = progress
+}
+
+// checkOffsets makes sure no offset references a discarded value. Values appended by a tx are never below
+// the truncated offsets, but de-duplicated values found before a truncation may be
+func (t *truncation) checkOffsets(offsets []int64) error {
+	for _, off := range offsets {
+		vLogID, voff := decodeOffset(off)
+		if vLogID > 0 && t.truncated(vLogID, voff) {
+			return ErrValueTruncated
+		}
+	}
+
+	return nil
+}
+
+// lowestValue is the value stored at the lowest offset of a vLog among the ones referenced by a range of txs
+type lowestValue struct {
+	off        int64
+	vLen       int
+	hVal       [sha256.Size]byte
+	compressed bool
+}
+
+func trackLowestValues(tx *Tx, lowest map[byte]*lowestValue) {
+	for _, e := range tx.Entries() {
+		vLogID, off := decodeOffset(e.vOff)
+		if vLogID == 0 || e.vLen == 0 {
+			continue
+		}
+
+		v, ok := lowest[vLogID]
+		if !ok || off < v.off {
+			lowest[vLogID] = &lowestValue{off: off, vLen: e.vLen, hVal: e.hVal, compressed: valueCompression(e.md) != NoValueCompression}
+		}
+	}
+}
+
+// TruncateUptoTx discards the values of the txs committed before txID, but the ones still referenced by later txs
+// as it happens when values are de-duplicated. Txs are kept, so they can still be read and proven, but their
+// discarded values can not be read anymore (ErrValueTruncated is returned instead).
+// Truncation is logical, the space taken by discarded values is reclaimed by CompactValueLogs.
+// The vLogs not written by txID or any later tx are left untouched
+func (s *ImmuStore) TruncateUptoTx(txID uint64) error {
+	if s.readOnly {
+		return ErrReadOnlyStore
+	}
+
+	if txID == 0 || txID > s.TxCount() {
+		return ErrIllegalArguments
+	}
+
+	s.truncation.running.Lock()
+	defer s.truncation.running.Unlock()
+
+	s.truncation.setProgress(0)
+
+	s.log.Infof("Truncating values of txs before %d at '%s'...", txID, s.path)
+
+	lowest := make(map[byte]*lowestValue)
+
+	lastTxID, err := s.trackLowestValuesSince(txID, lowest, s.TxCount())
+	if err != nil {
+		return err
+	}
+
+	offsets := make(map[byte]int64, len(lowest))
+	for vLogID, v := range lowest {
+		offsets[vLogID] = v.off
+	}
+
+	previous := s.truncation.snapshot()
+
+	// from now on values below the offsets are neither read nor referenced by new txs,
+	// de-duplicated values found by txs being committed are checked once again at commit time
+	s.mutex.Lock()
+
+	if s.closed {
+		s.mutex.Unlock()
+		return ErrAlreadyClosed
+	}
+
+	s.truncation.advance(offsets)
+
+	if s.valueDedup != nil {
+		s.valueDedup.discardTruncated(s.truncation)
+	}
+
+	precommittedTxID := s.precommittedTxID
+
+	s.mutex.Unlock()
+
+	// txs committed while the first ones were read may still reference lower offsets
+	if precommittedTxID > lastTxID {
+		err = s.WaitForTx(precommittedTxID, nil)
+		if err != nil {
+			return err
+		}
+
+		_, err = s.trackLowestValuesSince(lastTxID+1, lowest, precommittedTxID)
+		if err != nil {
+			return err
+		}
+	}
+
+	for vLogID, v := range lowest {
+		// values below previous truncations were already discarded
+		if v.off < previous[vLogID] {
+			offsets[vLogID] = previous[vLogID]
+			continue
+		}
+
+		off, err := s.valueStartOffset(vLogID, v)
+		if err != nil {
+			return err
+		}
+
+		offsets[vLogID] = off
+	}
+
+	// offsets are lowered to the start of the values, but never below the ones of previous truncations
+	s.truncation.mutex.Lock()
+	for vLogID, off := range offsets {
+		if off < previous[vLogID] {
+			off = previous[vLogID]
+		}
+		s.truncation.offsets[vLogID] = off
+	}
+	s.truncation.mutex.Unlock()
+
+	err = s.truncation.persist(s.synced)
+	if err != nil {
+		return fmt.Errorf("could not persist truncation: %w", err)
+	}
+
+	s.truncation.setProgress(100)
+
+	s.log.Infof("Values of txs before %d truncated at '%s'", txID, s.path)
+
+	return nil
+}
+
+// trackLowestValuesSince reads the txs from txID up to lastTxID, it returns the id of the last tx read
+func (s *ImmuStore) trackLowestValuesSince(txID uint64, lowest map[byte]*lowestValue, lastTxID uint64) (uint64, error) {
+	tx, err := s.fetchAllocTx()
+	if err != nil {
+		return 0, err
+	}
+	defer s.releaseAllocTx(tx)
+
+	txReader, err := s.NewTxReader(txID, false, tx)
+	if err != nil {
+		return 0, err
+	}
+
+	readTxID := txID - 1
+
+	for readTxID < lastTxID {
+		tx, err := txReader.Read()
+		if err == ErrNoMoreEntries {
+			break
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		trackLowestValues(tx, lowest)
+
+		readTxID = tx.header.ID
+
+		s.truncation.setProgress(float32(readTxID-txID+1) * 100 / float32(lastTxID-txID+1))
+	}
+
+	return readTxID, nil
+}
+
+// valueStartOffset returns the offset where the data of a value starts, chunks of a chunked value
+// are appended right before its manifest
+func (s *ImmuStore) valueStartOffset(vLogID byte, v *lowestValue) (int64, error) {
+	if v.vLen <= offsetSize || v.compressed {
+		return v.off, nil
+	}
+
+	b := make([]byte, v.vLen)
+
+	// the value may be below the offsets set while txs were still being read
+	err := s.readVLogAt(vLogID, b, v.off)
+	if err != nil {
+		return 0, err
+	}
+
+	if !isChunkManifest(b) || sha256.Sum256(b) == v.hVal {
+		return v.off, nil
+	}
+
+	chunkVLogID, chunkOff, ok := firstChunkOffset(b)
+	if !ok || chunkVLogID != vLogID || chunkOff > v.off {
+		return v.off, nil
+	}
+
+	return chunkOff, nil
+}
+
+// TruncationProgress returns the percentage of the txs already read by an ongoing truncation
+func (s *ImmuStore) TruncationProgress() float32 {
+	s.truncation.mutex.RLock()
+	defer s.truncation.mutex.RUnlock()
+
+	return s.truncation.progress
+}
+
+// CompactValueLogs reclaims the space taken by the values discarded by TruncateUptoTx,
+// the vLog chunks holding only discarded values are removed
+func (s *ImmuStore) CompactValueLogs() error {
+	if s.readOnly {
+		return ErrReadOnlyStore
+	}
+
+	if s.compactionDisabled {
+		return ErrCompactionUnsupported
+	}
+
+	s.truncation.running.Lock()
+	defer s.truncation.running.Unlock()
+
+	for vLogID, off := range s.truncation.snapshot() {
+		if _, ok := s.vLogs[vLogID-1]; !ok || off == 0 {
+			continue
+		}
+
+		vLog := s.fetchVLog(vLogID)
+		err := vLog.DiscardUpto(off)
+		s.releaseVLog(vLogID)
+
+		if err != nil {
+			return fmt.Errorf("could not compact value log %d: %w", vLogID, err)
+		}
+	}
+
+	s.log.Infof("Value logs compacted at '%s'", s.path)
+
+	return nil
+}
@@ -0,0 +1,201 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package store
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestImmudbStoreTruncation(t *testing.T) {
+	defer os.RemoveAll("data_truncation")
+
+	opts := DefaultOptions().
+		WithSynced(false).
+		WithMaxConcurrency(1).
+		WithFileSize(256).
+		WithMaxValueLen(64).
+		WithMaxChunkedValueLen(300)
+
+	immuStore, err := Open("data_truncation", opts)
+	require.NoError(t, err)
+
+	value := func(i int) []byte {
+		v := make([]byte, 100)
+		rand.New(rand.NewSource(int64(i))).Read(v)
+		return v
+	}
+
+	txCount := 10
+
+	for i := 1; i <= txCount; i++ {
+		tx, err := immuStore.NewWriteOnlyTx()
+		require.NoError(t, err)
+
+		// values are longer than MaxValueLen, so they are stored in chunks
+		err = tx.Set([]byte(fmt.Sprintf("key_%d", i)), nil, value(i))
+		require.NoError(t, err)
+
+		_, err = tx.Commit()
+		require.NoError(t, err)
+	}
+
+	err = immuStore.TruncateUptoTx(0)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	err = immuStore.TruncateUptoTx(uint64(txCount + 1))
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	err = immuStore.TruncateUptoTx(6)
+	require.NoError(t, err)
+	require.Equal(t, float32(100), immuStore.TruncationProgress())
+
+	checkValues := func(immuStore *ImmuStore) {
+		err := immuStore.WaitForIndexingUpto(uint64(txCount), nil)
+		require.NoError(t, err)
+
+		for i := 1; i <= txCount; i++ {
+			valRef, err := immuStore.Get([]byte(fmt.Sprintf("key_%d", i)))
+			require.NoError(t, err)
+
+			v, err := valRef.Resolve()
+			if i < 6 {
+				require.ErrorIs(t, err, ErrValueTruncated)
+				continue
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, value(i), v)
+		}
+
+		// txs are kept
+		tx := immuStore.NewTxHolder()
+
+		err = immuStore.ReadTx(1, tx)
+		require.NoError(t, err)
+
+		_, err = immuStore.ReadValue(tx.Entries()[0])
+		require.ErrorIs(t, err, ErrValueTruncated)
+	}
+
+	checkValues(immuStore)
+
+	countChunks := func() int {
+		files, err := ioutil.ReadDir(filepath.Join("data_truncation", "val_0"))
+		require.NoError(t, err)
+		return len(files)
+	}
+
+	chunks := countChunks()
+
+	err = immuStore.CompactValueLogs()
+	require.NoError(t, err)
+	require.Less(t, countChunks(), chunks)
+
+	checkValues(immuStore)
+
+	status, err := immuStore.Scrub()
+	require.NoError(t, err)
+	require.Empty(t, status.Err)
+
+	err = immuStore.Close()
+	require.NoError(t, err)
+
+	immuStore, err = Open("data_truncation", opts)
+	require.NoError(t, err)
+	defer immuStore.Close()
+
+	checkValues(immuStore)
+
+	t.Run("truncation never goes backward", func(t *testing.T) {
+		err = immuStore.TruncateUptoTx(2)
+		require.NoError(t, err)
+
+		checkValues(immuStore)
+	})
+}
+
+func TestImmudbStoreTruncationWithValueDedup(t *testing.T) {
+	defer os.RemoveAll("data_truncation_dedup")
+
+	opts := DefaultOptions().WithSynced(false).WithMaxConcurrency(1).WithValueDedup(true)
+
+	immuStore, err := Open("data_truncation_dedup", opts)
+	require.NoError(t, err)
+	defer immuStore.Close()
+
+	for i := 1; i <= 4; i++ {
+		tx, err := immuStore.NewWriteOnlyTx()
+		require.NoError(t, err)
+
+		err = tx.Set([]byte(fmt.Sprintf("key_%d", i)), nil, []byte(fmt.Sprintf("value_%d", i)))
+		require.NoError(t, err)
+
+		_, err = tx.Commit()
+		require.NoError(t, err)
+	}
+
+	// the value of key_2 is referenced by a tx which is not truncated
+	tx, err := immuStore.NewWriteOnlyTx()
+	require.NoError(t, err)
+
+	err = tx.Set([]byte("key_5"), nil, []byte("value_2"))
+	require.NoError(t, err)
+
+	_, err = tx.Commit()
+	require.NoError(t, err)
+
+	err = immuStore.TruncateUptoTx(4)
+	require.NoError(t, err)
+
+	err = immuStore.WaitForIndexingUpto(5, nil)
+	require.NoError(t, err)
+
+	for i, expected := range []error{ErrValueTruncated, nil, nil, nil, nil} {
+		valRef, err := immuStore.Get([]byte(fmt.Sprintf("key_%d", i+1)))
+		require.NoError(t, err)
+
+		_, err = valRef.Resolve()
+		if expected == nil {
+			require.NoError(t, err)
+		} else {
+			require.ErrorIs(t, err, expected)
+		}
+	}
+
+	// discarded values are not de-duplicated anymore
+	tx, err = immuStore.NewWriteOnlyTx()
+	require.NoError(t, err)
+
+	err = tx.Set([]byte("key_6"), nil, []byte("value_1"))
+	require.NoError(t, err)
+
+	_, err = tx.Commit()
+	require.NoError(t, err)
+
+	valRef, err := immuStore.Get([]byte("key_6"))
+	require.NoError(t, err)
+
+	v, err := valRef.Resolve()
+	require.NoError(t, err)
+	require.Equal(t, []byte("value_1"), v)
+}
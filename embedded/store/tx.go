@@ -195,6 +195,10 @@ func (hdr *TxHeader) ReadFrom(b []byte) error {
 	}
 
 	// following records are currently common in versions 0 and 1
+	if len(b) < i+sha256.Size+txIDSize+sha256.Size {
+		return ErrCorruptedData
+	}
+
 	copy(hdr.Eh[:], b[i:])
 	i += sha256.Size
 
@@ -207,9 +211,20 @@ func (hdr *TxHeader) ReadFrom(b []byte) error {
 	return nil
 }
 
+// innerHashMaxLen is the length of the largest preimage of the inner hash of a tx header
+const innerHashMaxLen = tsSize + sszSize + (sszSize + maxTxMetadataLen) + lszSize + sha256.Size + txIDSize + sha256.Size
+
 func (hdr *TxHeader) innerHash() [sha256.Size]byte {
-	// ts + version + (mdLen + md)? + nentries + eH + blTxID + blRoot
-	var b [tsSize + sszSize + (sszSize + maxTxMetadataLen) + lszSize + sha256.Size + txIDSize + sha256.Size]byte
+	var b [innerHashMaxLen]byte
+	n := hdr.innerHashPreimage(b[:])
+
+	// hash(ts + version + (mdLen + md) + nentries + eH + blTxID + blRoot)
+	return sha256.Sum256(b[:n])
+}
+
+// innerHashPreimage writes ts + version + (mdLen + md)? + nentries + eH + blTxID + blRoot into b,
+// which must be innerHashMaxLen long, and returns the number of bytes written
+func (hdr *TxHeader) innerHashPreimage(b []byte) int {
 	i := 0
 
 	binary.BigEndian.PutUint64(b[i:], uint64(hdr.Ts))
@@ -258,8 +273,7 @@ func (hdr *TxHeader) innerHash() [sha256.Size]byte {
 	copy(b[i:], hdr.BlRoot[:])
 	i += sha256.Size
 
-	// hash(ts + version + (mdLen + md) + nentries + eH + blTxID + blRoot)
-	return sha256.Sum256(b[:i])
+	return i
 }
 
 // Alh calculates the Accumulative Linear Hash up to this transaction
@@ -291,7 +305,10 @@ func (tx *Tx) TxEntryDigest() (TxEntryDigest, error) {
 }
 
 func (tx *Tx) BuildHashTree() error {
-	digests := make([][sha256.Size]byte, tx.header.NEntries)
+	digestsBuf := fetchDigests(tx.header.NEntries)
+	defer releaseDigests(digestsBuf)
+
+	digests := *digestsBuf
 
 	txEntryDigest, err := tx.TxEntryDigest()
 	if err != nil {
@@ -436,10 +453,14 @@ func (tx *Tx) readFrom(r *appendable.Reader) error {
 		}
 	default:
 		{
-			panic(fmt.Errorf("missing tx deserialization method for version %d", tx.header.Version))
+			return ErrNewerVersionOrCorruptedData
 		}
 	}
 
+	if tx.header.NEntries > len(tx.entries) {
+		return fmt.Errorf("%w: too many entries in tx %d", ErrorCorruptedTxData, tx.header.ID)
+	}
+
 	for i := 0; i < int(tx.header.NEntries); i++ {
 		// md is stored before key to ensure backward compatibility
 		mdLen, err := r.ReadUint16()
@@ -471,6 +492,9 @@ func (tx *Tx) readFrom(r *appendable.Reader) error {
 		if err != nil {
 			return err
 		}
+		if int(kLen) > len(tx.entries[i].k) {
+			return fmt.Errorf("%w: key too long in tx %d", ErrorCorruptedTxData, tx.header.ID)
+		}
 		tx.entries[i].kLen = int(kLen)
 
 		_, err = r.Read(tx.entries[i].k[:kLen])
@@ -579,7 +603,10 @@ func TxEntryDigest_v1_1(e *TxEntry) ([sha256.Size]byte, error) {
 		return [sha256.Size]byte{}, ErrMetadataUnsupported
 	}
 
-	b := make([]byte, e.kLen+sha256.Size)
+	buf := fetchEntryBuf(e.kLen + sha256.Size)
+	defer releaseEntryBuf(buf)
+
+	b := *buf
 
 	copy(b[:], e.k[:e.kLen])
 	copy(b[e.kLen:], e.hVal[:])
@@ -596,7 +623,10 @@ func TxEntryDigest_v1_2(e *TxEntry) ([sha256.Size]byte, error) {
 
 	mdLen := len(mdbs)
 
-	b := make([]byte, sszSize+mdLen+sszSize+e.kLen+sha256.Size)
+	buf := fetchEntryBuf(sszSize + mdLen + sszSize + e.kLen + sha256.Size)
+	defer releaseEntryBuf(buf)
+
+	b := *buf
 	i := 0
 
 	binary.BigEndian.PutUint16(b[i:], uint16(mdLen))
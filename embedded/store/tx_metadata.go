@@ -15,26 +15,94 @@ limitations under the License.
 */
 package store
 
-const maxTxMetadataLen = 0
+import "bytes"
 
+const userTxAttrCode attributeCode = 0
+
+// MaxTxMetadataUserLen is the maximum length of the user a transaction may be attributed to
+const MaxTxMetadataUserLen = 64
+
+const maxTxMetadataLen = attrCodeSize + 1 + MaxTxMetadataUserLen
+
+// TxMetadata holds the attributes of a transaction, covered by its header hash.
+// An empty metadata is serialized as no bytes at all, so headers of transactions without attributes remain unchanged.
 type TxMetadata struct {
+	user string
 }
 
 func NewTxMetadata() *TxMetadata {
 	return &TxMetadata{}
 }
 
+// SetUser attributes the transaction to the given user, an empty user removes the attribute
+func (md *TxMetadata) SetUser(user string) error {
+	if len(user) > MaxTxMetadataUserLen {
+		return ErrIllegalArguments
+	}
+
+	md.user = user
+
+	return nil
+}
+
+// User returns the user the transaction is attributed to, it's empty when the transaction has no user
+func (md *TxMetadata) User() string {
+	return md.user
+}
+
 func (md *TxMetadata) Equal(amd *TxMetadata) bool {
-	return true
+	if amd == nil {
+		return md.user == ""
+	}
+
+	return md.user == amd.user
 }
 
 func (md *TxMetadata) Bytes() []byte {
-	return nil
+	if md.user == "" {
+		return nil
+	}
+
+	var b bytes.Buffer
+
+	b.WriteByte(byte(userTxAttrCode))
+	b.WriteByte(byte(len(md.user)))
+	b.WriteString(md.user)
+
+	return b.Bytes()
 }
 
 func (md *TxMetadata) ReadFrom(b []byte) error {
-	if len(b) != 0 {
+	if len(b) > maxTxMetadataLen {
 		return ErrCorruptedData
 	}
+
+	md.user = ""
+
+	i := 0
+
+	for i < len(b) {
+		if len(b[i:]) < attrCodeSize+1 {
+			return ErrCorruptedData
+		}
+
+		attrCode := attributeCode(b[i])
+		i += attrCodeSize
+
+		if attrCode != userTxAttrCode {
+			return ErrCorruptedData
+		}
+
+		userLen := int(b[i])
+		i++
+
+		if userLen == 0 || userLen > MaxTxMetadataUserLen || len(b[i:]) < userLen {
+			return ErrCorruptedData
+		}
+
+		md.user = string(b[i : i+userLen])
+		i += userLen
+	}
+
 	return nil
 }
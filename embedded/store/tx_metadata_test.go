@@ -16,6 +16,8 @@ limitations under the License.
 package store
 
 import (
+	"os"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -39,3 +41,65 @@ func TestTxMetadata(t *testing.T) {
 
 	require.True(t, md.Equal(desmd))
 }
+
+func TestTxMetadataUser(t *testing.T) {
+	md := NewTxMetadata()
+
+	err := md.SetUser(strings.Repeat("u", MaxTxMetadataUserLen+1))
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	err = md.SetUser("alice")
+	require.NoError(t, err)
+	require.Equal(t, "alice", md.User())
+	require.False(t, md.Equal(NewTxMetadata()))
+	require.False(t, md.Equal(nil))
+
+	desmd := NewTxMetadata()
+	err = desmd.ReadFrom(md.Bytes())
+	require.NoError(t, err)
+	require.Equal(t, "alice", desmd.User())
+	require.True(t, md.Equal(desmd))
+
+	err = desmd.ReadFrom([]byte{byte(userTxAttrCode), 10, 'a'})
+	require.ErrorIs(t, err, ErrCorruptedData)
+
+	err = desmd.ReadFrom([]byte{1, 1, 'a'})
+	require.ErrorIs(t, err, ErrCorruptedData)
+
+	err = md.SetUser("")
+	require.NoError(t, err)
+	require.Nil(t, md.Bytes())
+
+	t.Run("the user should be committed within the tx header", func(t *testing.T) {
+		immuStore, err := Open("data_tx_metadata_user", DefaultOptions())
+		require.NoError(t, err)
+		defer os.RemoveAll("data_tx_metadata_user")
+		defer immuStore.Close()
+
+		txmd := NewTxMetadata()
+		err = txmd.SetUser("bob")
+		require.NoError(t, err)
+
+		tx, err := immuStore.NewTx()
+		require.NoError(t, err)
+
+		tx.WithMetadata(txmd)
+
+		err = tx.Set([]byte("key1"), nil, []byte("value1"))
+		require.NoError(t, err)
+
+		hdr, err := tx.Commit()
+		require.NoError(t, err)
+
+		txHolder := immuStore.NewTxHolder()
+
+		err = immuStore.ReadTx(hdr.ID, txHolder)
+		require.NoError(t, err)
+		require.Equal(t, "bob", txHolder.Header().Metadata.User())
+		require.Equal(t, hdr.Alh(), txHolder.Header().Alh())
+
+		valRef, err := immuStore.Get([]byte("key1"))
+		require.NoError(t, err)
+		require.Equal(t, "bob", valRef.TxMetadata().User())
+	})
+}
@@ -0,0 +1,157 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package store
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+
+	"github.com/codenotary/immudb/embedded/appendable"
+)
+
+// Values longer than MaxValueLen, up to MaxChunkedValueLen, are split into chunks of MaxValueLen bytes
+// (the last one may be shorter) appended to a vLog, followed by a manifest referencing them:
+//
+//	chunkedValueFormat (1 byte) + value length (uvarint) + chunk length (uvarint) + chunk offsets (8 bytes each)
+//
+// The tx entry references the manifest, which is stored as any other value, and keeps the digest of the whole
+// value so proofs cover its full content. As with compressed values, a stored value is known to be a manifest
+// when its digest doesn't match the one in the entry. Chunks are stored uncompressed.
+const chunkedValueFormat = 0xff
+
+const maxChunkManifestHeaderLen = 1 + 2*binary.MaxVarintLen64
+
+// maxChunkedValueLenFor returns the length of the largest value whose manifest is not longer than maxValueLen
+func maxChunkedValueLenFor(maxValueLen int) int {
+	if maxValueLen <= maxChunkManifestHeaderLen {
+		return 0
+	}
+
+	return (maxValueLen - maxChunkManifestHeaderLen) / offsetSize * maxValueLen
+}
+
+func isChunkManifest(b []byte) bool {
+	return len(b) > 0 && b[0] == chunkedValueFormat
+}
+
+// appendChunks appends the value in chunks of chunkLen bytes and returns its manifest
+func appendChunks(vLog appendable.Appendable, vLogID byte, value []byte, chunkLen int) ([]byte, error) {
+	chunks := (len(value) + chunkLen - 1) / chunkLen
+
+	manifest := make([]byte, maxChunkManifestHeaderLen+chunks*offsetSize)
+
+	manifest[0] = chunkedValueFormat
+	n := 1
+	n += binary.PutUvarint(manifest[n:], uint64(len(value)))
+	n += binary.PutUvarint(manifest[n:], uint64(chunkLen))
+
+	for i := 0; i < chunks; i++ {
+		end := (i + 1) * chunkLen
+		if end > len(value) {
+			end = len(value)
+		}
+
+		off, _, err := vLog.Append(value[i*chunkLen : end])
+		if err != nil {
+			return nil, err
+		}
+
+		binary.BigEndian.PutUint64(manifest[n:], uint64(encodeOffset(off, vLogID)))
+		n += offsetSize
+	}
+
+	return manifest[:n], nil
+}
+
+// firstChunkOffset returns the vLog and offset of the first chunk referenced by the manifest
+func firstChunkOffset(manifest []byte) (vLogID byte, off int64, ok bool) {
+	if !isChunkManifest(manifest) {
+		return 0, 0, false
+	}
+
+	i := 1
+
+	for j := 0; j < 2; j++ {
+		_, n := binary.Uvarint(manifest[i:])
+		if n <= 0 {
+			return 0, 0, false
+		}
+		i += n
+	}
+
+	if len(manifest)-i < offsetSize {
+		return 0, 0, false
+	}
+
+	vLogID, off = decodeOffset(int64(binary.BigEndian.Uint64(manifest[i:])))
+
+	return vLogID, off, true
+}
+
+// readChunkedValue reads the value referenced by the manifest using readAt to read each chunk,
+// the value is checked to match the given digest
+func readChunkedValue(manifest []byte, hvalue [sha256.Size]byte, maxValueLen int, readAt func(b []byte, off int64) error) ([]byte, error) {
+	if !isChunkManifest(manifest) {
+		return nil, ErrCorruptedData
+	}
+
+	i := 1
+
+	vLen, n := binary.Uvarint(manifest[i:])
+	if n <= 0 {
+		return nil, ErrCorruptedData
+	}
+	i += n
+
+	chunkLen, n := binary.Uvarint(manifest[i:])
+	if n <= 0 || chunkLen == 0 || chunkLen > uint64(maxValueLen) {
+		return nil, ErrCorruptedData
+	}
+	i += n
+
+	if (len(manifest)-i)%offsetSize != 0 {
+		return nil, ErrCorruptedData
+	}
+
+	chunks := uint64(len(manifest)-i) / offsetSize
+
+	if chunks == 0 || vLen <= (chunks-1)*chunkLen || vLen > chunks*chunkLen {
+		return nil, ErrCorruptedData
+	}
+
+	value := make([]byte, vLen)
+
+	for c := uint64(0); c < chunks; c++ {
+		end := (c + 1) * chunkLen
+		if end > vLen {
+			end = vLen
+		}
+
+		off := int64(binary.BigEndian.Uint64(manifest[i:]))
+		i += offsetSize
+
+		err := readAt(value[c*chunkLen:end], off)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if hvalue != sha256.Sum256(value) {
+		return nil, ErrCorruptedData
+	}
+
+	return value, nil
+}
@@ -0,0 +1,195 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"math/rand"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestChunkManifest(t *testing.T) {
+	value := make([]byte, 1000)
+	rand.New(rand.NewSource(1)).Read(value)
+
+	var vLog bytes.Buffer
+
+	manifest := make([]byte, maxChunkManifestHeaderLen)
+	manifest[0] = chunkedValueFormat
+	n := 1
+	n += binary.PutUvarint(manifest[n:], uint64(len(value)))
+	n += binary.PutUvarint(manifest[n:], 64)
+	manifest = manifest[:n]
+
+	for off := 0; off < len(value); off += 64 {
+		end := off + 64
+		if end > len(value) {
+			end = len(value)
+		}
+
+		var b [offsetSize]byte
+		binary.BigEndian.PutUint64(b[:], uint64(encodeOffset(int64(vLog.Len()), 0)))
+		manifest = append(manifest, b[:]...)
+
+		vLog.Write(value[off:end])
+	}
+
+	readAt := func(b []byte, off int64) error {
+		_, voff := decodeOffset(off)
+		copy(b, vLog.Bytes()[voff:])
+		return nil
+	}
+
+	hvalue := sha256.Sum256(value)
+
+	v, err := readChunkedValue(manifest, hvalue, 64, readAt)
+	require.NoError(t, err)
+	require.Equal(t, value, v)
+
+	_, err = readChunkedValue(manifest, sha256.Sum256(nil), 64, readAt)
+	require.ErrorIs(t, err, ErrCorruptedData)
+
+	// chunks longer than the max value length are rejected
+	_, err = readChunkedValue(manifest, hvalue, 63, readAt)
+	require.ErrorIs(t, err, ErrCorruptedData)
+
+	for i := 0; i < len(manifest); i++ {
+		_, err = readChunkedValue(manifest[:i], hvalue, 64, readAt)
+		require.ErrorIs(t, err, ErrCorruptedData)
+	}
+
+	_, err = readChunkedValue(append(manifest, manifest[n:n+offsetSize]...), hvalue, 64, readAt)
+	require.ErrorIs(t, err, ErrCorruptedData)
+}
+
+func TestImmudbStoreChunkedValues(t *testing.T) {
+	defer os.RemoveAll("data_chunked_values")
+
+	_, err := Open("data_chunked_values", DefaultOptions().
+		WithMaxValueLen(64).
+		WithMaxChunkedValueLen(maxChunkedValueLenFor(64)+1))
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	opts := DefaultOptions().
+		WithSynced(false).
+		WithMaxConcurrency(1).
+		WithMaxValueLen(64).
+		WithMaxChunkedValueLen(300)
+
+	immuStore, err := Open("data_chunked_values", opts)
+	require.NoError(t, err)
+	require.Equal(t, 300, immuStore.MaxChunkedValueLen())
+
+	random := make([]byte, 300)
+	rand.New(rand.NewSource(1)).Read(random)
+
+	values := map[string][]byte{
+		"small":   []byte("small value"),
+		"max":     random[:64],
+		"chunked": random[:65],
+		"largest": random,
+	}
+
+	tx, err := immuStore.NewWriteOnlyTx()
+	require.NoError(t, err)
+
+	err = tx.Set([]byte("too large"), nil, make([]byte, 301))
+	require.ErrorIs(t, err, ErrorMaxValueLenExceeded)
+
+	for k, v := range values {
+		err = tx.Set([]byte(k), nil, v)
+		require.NoError(t, err)
+	}
+
+	hdr, err := tx.Commit()
+	require.NoError(t, err)
+
+	txHolder := immuStore.NewTxHolder()
+
+	err = immuStore.ReadTx(hdr.ID, txHolder)
+	require.NoError(t, err)
+
+	for _, e := range txHolder.Entries() {
+		v := values[string(e.Key())]
+
+		if len(v) > 64 {
+			// only the manifest is referenced by the entry
+			require.LessOrEqual(t, e.VLen(), 64)
+		} else {
+			require.Equal(t, len(v), e.VLen())
+		}
+
+		val, err := immuStore.ReadValue(e)
+		require.NoError(t, err)
+		require.Equal(t, v, val)
+	}
+
+	checkValues := func(immuStore *ImmuStore) {
+		err := immuStore.WaitForIndexingUpto(hdr.ID, nil)
+		require.NoError(t, err)
+
+		for k, v := range values {
+			valRef, err := immuStore.Get([]byte(k))
+			require.NoError(t, err)
+
+			val, err := valRef.Resolve()
+			require.NoError(t, err)
+			require.Equal(t, v, val)
+		}
+	}
+
+	checkValues(immuStore)
+
+	t.Run("chunked values are exported whole", func(t *testing.T) {
+		defer os.RemoveAll("data_chunked_values_replica")
+
+		replicaStore, err := Open("data_chunked_values_replica", opts)
+		require.NoError(t, err)
+		defer replicaStore.Close()
+
+		etx, err := immuStore.ExportTx(hdr.ID, txHolder)
+		require.NoError(t, err)
+
+		rhdr, err := replicaStore.ReplicateTx(etx, true)
+		require.NoError(t, err)
+		require.Equal(t, hdr.Alh(), rhdr.Alh())
+
+		checkValues(replicaStore)
+	})
+
+	status, err := immuStore.Scrub()
+	require.NoError(t, err)
+	require.Zero(t, status.MismatchCount)
+
+	err = immuStore.Close()
+	require.NoError(t, err)
+
+	report, err := Repair("data_chunked_values", opts)
+	require.NoError(t, err)
+	require.Equal(t, hdr.ID, report.CommittedTxID)
+
+	immuStore, err = Open("data_chunked_values", opts)
+	require.NoError(t, err)
+	defer immuStore.Close()
+
+	checkValues(immuStore)
+}
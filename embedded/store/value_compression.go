@@ -0,0 +1,239 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"sync"
+
+	"github.com/klauspost/compress/s2"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Values are compressed individually when they are appended to the vLogs. The algorithm a value was compressed
+// with is recorded in the metadata of its entry, while the entry keeps the length and the digest of the
+// uncompressed value. A compressed value is stored as
+//
+//	compressed length (4 bytes) + compressed payload
+//
+// Values written with any algorithm, or with no compression at all, can be read regardless of the current settings
+const (
+	NoValueCompression = iota
+	ZstdValueCompression
+	S2ValueCompression
+)
+
+const DefaultValueCompression = NoValueCompression
+const DefaultValueCompressionThreshold = 512
+
+func validValueCompression(algorithm int) bool {
+	return algorithm >= NoValueCompression && algorithm <= S2ValueCompression
+}
+
+var zstdOnce sync.Once
+var zstdEncoder *zstd.Encoder
+var zstdDecoder *zstd.Decoder
+var zstdErr error
+
+// zstdCodec returns the zstd encoder and decoder shared by every store, both are safe for concurrent use
+func zstdCodec() (*zstd.Encoder, *zstd.Decoder, error) {
+	zstdOnce.Do(func() {
+		zstdEncoder, zstdErr = zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
+		if zstdErr != nil {
+			return
+		}
+
+		zstdDecoder, zstdErr = zstd.NewReader(nil)
+	})
+
+	return zstdEncoder, zstdDecoder, zstdErr
+}
+
+// valueCompression returns the algorithm the value of an entry with the given metadata was compressed with
+func valueCompression(md *KVMetadata) int {
+	if md == nil {
+		return NoValueCompression
+	}
+
+	return md.Compression()
+}
+
+// compressedMetadata returns a copy of the given metadata recording the algorithm the value was compressed with
+func compressedMetadata(md *KVMetadata, algorithm int) (*KVMetadata, error) {
+	cmd := NewKVMetadata()
+
+	if md != nil {
+		err := cmd.unsafeReadFrom(md.Bytes())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err := cmd.AsCompressed(algorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	return cmd, nil
+}
+
+// compressValue returns the value compressed with the given algorithm as it's stored
+func compressValue(algorithm int, value []byte) ([]byte, error) {
+	var b []byte
+
+	switch algorithm {
+	case ZstdValueCompression:
+		{
+			enc, _, err := zstdCodec()
+			if err != nil {
+				return nil, err
+			}
+
+			b = enc.EncodeAll(value, make([]byte, lszSize, lszSize+len(value)))
+		}
+	case S2ValueCompression:
+		{
+			b = make([]byte, lszSize+s2.MaxEncodedLen(len(value)))
+			b = b[:lszSize+len(s2.Encode(b[lszSize:], value))]
+		}
+	default:
+		{
+			return nil, ErrIllegalArguments
+		}
+	}
+
+	binary.BigEndian.PutUint32(b, uint32(len(b)-lszSize))
+
+	return b, nil
+}
+
+// decompressValue decompresses the payload of a value compressed with the given algorithm into b,
+// which must be as long as the uncompressed value, and checks it matches the given digest
+func decompressValue(b []byte, payload []byte, hvalue [sha256.Size]byte, algorithm int) ([]byte, error) {
+	var value []byte
+	var err error
+
+	switch algorithm {
+	case ZstdValueCompression:
+		{
+			_, dec, cerr := zstdCodec()
+			if cerr != nil {
+				return nil, cerr
+			}
+
+			value, err = dec.DecodeAll(payload, b[:0])
+		}
+	case S2ValueCompression:
+		{
+			var n int
+
+			n, err = s2.DecodedLen(payload)
+			if err == nil && n != len(b) {
+				err = fmt.Errorf("unexpected value length %d", n)
+			}
+			if err == nil {
+				value, err = s2.Decode(b, payload)
+			}
+		}
+	default:
+		{
+			return nil, ErrCorruptedData
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCorruptedData, err)
+	}
+
+	if len(value) != len(b) || hvalue != sha256.Sum256(value) {
+		return nil, ErrCorruptedData
+	}
+
+	return value, nil
+}
+
+// compressValues decides how the value of each entry is stored, setting the metadata each entry is committed with.
+// Values are compressed with the algorithm already set in the metadata of their entries, as it happens when txs are
+// replicated, so entry digests are kept. Otherwise values already stored are de-duplicated the way they were
+// stored, while new values are compressed with the algorithm of the store only when it's worth it.
+// Values stored as chunks are not compressed
+func (s *ImmuStore) compressValues(entries []*EntrySpec, version int, buffers *appendBuffers) error {
+	for i, e := range entries {
+		buffers.mds[i] = e.Metadata
+		buffers.values[i] = nil
+
+		if len(e.Value) == 0 {
+			continue
+		}
+
+		if valueCompression(e.Metadata) != NoValueCompression {
+			if len(e.Value) > s.maxValueLen {
+				return fmt.Errorf("%w: compressed values can not be longer than %d bytes", ErrIllegalArguments, s.maxValueLen)
+			}
+
+			// the value is compressed when it's appended
+			continue
+		}
+
+		// entries of txs with header version 0 can not hold metadata
+		if version < 1 ||
+			s.valueCompression == NoValueCompression ||
+			len(e.Value) < s.valueCompressionThld ||
+			len(e.Value) > s.maxValueLen {
+			continue
+		}
+
+		algorithm := s.valueCompression
+		deduped := false
+
+		if s.valueDedup != nil {
+			v, found := s.valueDedup.lookup(sha256.Sum256(e.Value))
+			if found {
+				algorithm = v.compression
+				deduped = true
+			}
+		}
+
+		if !deduped {
+			b, err := compressValue(algorithm, e.Value)
+			if err != nil {
+				return err
+			}
+
+			if len(b) >= len(e.Value) {
+				// not worth it
+				continue
+			}
+
+			buffers.values[i] = b
+		}
+
+		if algorithm == NoValueCompression {
+			continue
+		}
+
+		md, err := compressedMetadata(e.Metadata, algorithm)
+		if err != nil {
+			return err
+		}
+
+		buffers.mds[i] = md
+	}
+
+	return nil
+}
@@ -0,0 +1,340 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"math/rand"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValueCompressionAlgorithms(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+
+	random := make([]byte, 10_000)
+	rnd.Read(random)
+
+	values := [][]byte{
+		[]byte("a"),
+		[]byte("short value"),
+		bytes.Repeat([]byte("a"), 100_000),
+		bytes.Repeat([]byte("immudb "), 1000),
+		random,
+		append(bytes.Repeat(random[:300], 300), random...),
+	}
+
+	for _, algorithm := range []int{ZstdValueCompression, S2ValueCompression} {
+		for i, v := range values {
+			t.Run(fmt.Sprintf("algorithm %d value %d", algorithm, i), func(t *testing.T) {
+				hValue := sha256.Sum256(v)
+
+				b, err := compressValue(algorithm, v)
+				require.NoError(t, err)
+				require.Equal(t, len(b)-lszSize, int(binary.BigEndian.Uint32(b)))
+
+				payload := b[lszSize:]
+
+				d, err := decompressValue(make([]byte, len(v)), payload, hValue, algorithm)
+				require.NoError(t, err)
+				require.True(t, bytes.Equal(v, d))
+
+				_, err = decompressValue(make([]byte, len(v)-1), payload, hValue, algorithm)
+				require.ErrorIs(t, err, ErrCorruptedData)
+
+				_, err = decompressValue(make([]byte, len(v)+1), payload, hValue, algorithm)
+				require.ErrorIs(t, err, ErrCorruptedData)
+
+				_, err = decompressValue(make([]byte, len(v)), payload[:len(payload)-1], hValue, algorithm)
+				require.ErrorIs(t, err, ErrCorruptedData)
+
+				// a value decoding to different content does not match the digest
+				_, err = decompressValue(make([]byte, len(v)), payload, sha256.Sum256([]byte("other")), algorithm)
+				require.ErrorIs(t, err, ErrCorruptedData)
+			})
+		}
+	}
+
+	_, err := compressValue(NoValueCompression, values[0])
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = decompressValue(make([]byte, 1), values[0], sha256.Sum256(values[0]), S2ValueCompression+1)
+	require.ErrorIs(t, err, ErrCorruptedData)
+}
+
+func TestImmudbStoreValueCompression(t *testing.T) {
+	compressible := bytes.Repeat([]byte("immudb "), 200)
+
+	random := make([]byte, 1000)
+	rand.New(rand.NewSource(1)).Read(random)
+
+	values := map[string][]byte{
+		"compressible": compressible,
+		"random":       random,
+		"small":        []byte("small value"),
+		"empty":        nil,
+	}
+
+	for _, algorithm := range []int{ZstdValueCompression, S2ValueCompression} {
+		t.Run(fmt.Sprintf("algorithm %d", algorithm), func(t *testing.T) {
+			dir := fmt.Sprintf("data_value_compression_%d", algorithm)
+			defer os.RemoveAll(dir)
+
+			opts := DefaultOptions().
+				WithSynced(false).
+				WithMaxConcurrency(1).
+				WithValueCompression(algorithm).
+				WithValueCompressionThld(64)
+
+			immuStore, err := Open(dir, opts)
+			require.NoError(t, err)
+
+			tx, err := immuStore.NewWriteOnlyTx()
+			require.NoError(t, err)
+
+			for k, v := range values {
+				err = tx.Set([]byte(k), nil, v)
+				require.NoError(t, err)
+			}
+
+			hdr, err := tx.Commit()
+			require.NoError(t, err)
+
+			txHolder := immuStore.NewTxHolder()
+
+			err = immuStore.ReadTx(hdr.ID, txHolder)
+			require.NoError(t, err)
+
+			for _, e := range txHolder.Entries() {
+				v := values[string(e.Key())]
+
+				// entries keep the length of the uncompressed value
+				require.Equal(t, len(v), e.VLen())
+
+				if string(e.Key()) == "compressible" {
+					require.NotNil(t, e.Metadata())
+					require.Equal(t, algorithm, e.Metadata().Compression())
+				} else {
+					// incompressible and small values are stored as they are
+					require.Nil(t, e.Metadata())
+				}
+
+				val, err := immuStore.ReadValue(e)
+				require.NoError(t, err)
+				require.Equal(t, len(v), len(val))
+				require.True(t, bytes.Equal(v, val))
+			}
+
+			checkValues := func(immuStore *ImmuStore) {
+				err := immuStore.WaitForIndexingUpto(hdr.ID, nil)
+				require.NoError(t, err)
+
+				for k, v := range values {
+					valRef, err := immuStore.Get([]byte(k))
+					require.NoError(t, err)
+
+					require.Equal(t, uint32(len(v)), valRef.Len())
+
+					val, err := valRef.Resolve()
+					require.NoError(t, err)
+					require.True(t, bytes.Equal(v, val))
+				}
+			}
+
+			checkValues(immuStore)
+
+			t.Run("values are exported uncompressed", func(t *testing.T) {
+				replicaDir := dir + "_replica"
+				defer os.RemoveAll(replicaDir)
+
+				replicaStore, err := Open(replicaDir, DefaultOptions().WithSynced(false).WithMaxConcurrency(1))
+				require.NoError(t, err)
+				defer replicaStore.Close()
+
+				etx, err := immuStore.ExportTx(hdr.ID, txHolder)
+				require.NoError(t, err)
+
+				rhdr, err := replicaStore.ReplicateTx(etx, true)
+				require.NoError(t, err)
+				require.Equal(t, hdr.Alh(), rhdr.Alh())
+
+				checkValues(replicaStore)
+			})
+
+			err = immuStore.Close()
+			require.NoError(t, err)
+
+			t.Run("compressed values are readable once compression is disabled", func(t *testing.T) {
+				immuStore, err := Open(dir, DefaultOptions().WithSynced(false).WithMaxConcurrency(1))
+				require.NoError(t, err)
+
+				checkValues(immuStore)
+
+				err = immuStore.Close()
+				require.NoError(t, err)
+			})
+		})
+	}
+
+	t.Run("compression can be enabled on existing stores", func(t *testing.T) {
+		defer os.RemoveAll("data_value_compression_enabled")
+
+		opts := DefaultOptions().WithSynced(false).WithMaxConcurrency(1)
+
+		commit := func(immuStore *ImmuStore, kvs map[string][]byte) *TxHeader {
+			tx, err := immuStore.NewWriteOnlyTx()
+			require.NoError(t, err)
+
+			for k, v := range kvs {
+				err = tx.Set([]byte(k), nil, v)
+				require.NoError(t, err)
+			}
+
+			hdr, err := tx.Commit()
+			require.NoError(t, err)
+
+			return hdr
+		}
+
+		immuStore, err := Open("data_value_compression_enabled", opts)
+		require.NoError(t, err)
+
+		commit(immuStore, map[string][]byte{"before": compressible})
+
+		err = immuStore.Close()
+		require.NoError(t, err)
+
+		immuStore, err = Open("data_value_compression_enabled", opts.WithValueCompression(S2ValueCompression).WithValueDedup(true))
+		require.NoError(t, err)
+		defer immuStore.Close()
+
+		hdr := commit(immuStore, map[string][]byte{
+			"after":       compressible,
+			"after_other": append([]byte("other "), compressible...),
+		})
+
+		err = immuStore.WaitForIndexingUpto(hdr.ID, nil)
+		require.NoError(t, err)
+
+		for _, k := range []string{"before", "after"} {
+			valRef, err := immuStore.Get([]byte(k))
+			require.NoError(t, err)
+
+			val, err := valRef.Resolve()
+			require.NoError(t, err)
+			require.True(t, bytes.Equal(compressible, val))
+		}
+
+		// the value stored before compression was enabled is de-duplicated as it is
+		txHolder := immuStore.NewTxHolder()
+
+		err = immuStore.ReadTx(hdr.ID, txHolder)
+		require.NoError(t, err)
+
+		for _, e := range txHolder.Entries() {
+			if string(e.Key()) == "after" {
+				require.Nil(t, e.Metadata())
+			} else {
+				require.Equal(t, S2ValueCompression, e.Metadata().Compression())
+			}
+		}
+	})
+
+	t.Run("values are compressed as set in the metadata of their entries", func(t *testing.T) {
+		defer os.RemoveAll("data_value_compression_metadata")
+
+		opts := DefaultOptions().
+			WithSynced(false).
+			WithMaxConcurrency(1).
+			WithMaxChunkedValueLen(2 * DefaultMaxValueLen)
+
+		immuStore, err := Open("data_value_compression_metadata", opts)
+		require.NoError(t, err)
+		defer immuStore.Close()
+
+		md := NewKVMetadata()
+		err = md.AsCompressed(ZstdValueCompression)
+		require.NoError(t, err)
+
+		tx, err := immuStore.NewWriteOnlyTx()
+		require.NoError(t, err)
+
+		err = tx.Set([]byte("random"), md, random)
+		require.NoError(t, err)
+
+		hdr, err := tx.Commit()
+		require.NoError(t, err)
+
+		txHolder := immuStore.NewTxHolder()
+
+		err = immuStore.ReadTx(hdr.ID, txHolder)
+		require.NoError(t, err)
+
+		e := txHolder.Entries()[0]
+		require.Equal(t, ZstdValueCompression, e.Metadata().Compression())
+		require.Equal(t, len(random), e.VLen())
+
+		val, err := immuStore.ReadValue(e)
+		require.NoError(t, err)
+		require.True(t, bytes.Equal(random, val))
+
+		tx, err = immuStore.NewWriteOnlyTx()
+		require.NoError(t, err)
+
+		// chunked values are not compressed
+		err = tx.Set([]byte("large"), md, make([]byte, immuStore.maxValueLen+1))
+		require.NoError(t, err)
+
+		_, err = tx.Commit()
+		require.ErrorIs(t, err, ErrIllegalArguments)
+	})
+
+	t.Run("values are not compressed within txs with header version 0", func(t *testing.T) {
+		defer os.RemoveAll("data_value_compression_v0")
+
+		opts := DefaultOptions().
+			WithSynced(false).
+			WithMaxConcurrency(1).
+			WithWriteTxHeaderVersion(0).
+			WithValueCompression(ZstdValueCompression).
+			WithValueCompressionThld(0)
+
+		immuStore, err := Open("data_value_compression_v0", opts)
+		require.NoError(t, err)
+		defer immuStore.Close()
+
+		tx, err := immuStore.NewWriteOnlyTx()
+		require.NoError(t, err)
+
+		err = tx.Set([]byte("compressible"), nil, compressible)
+		require.NoError(t, err)
+
+		hdr, err := tx.Commit()
+		require.NoError(t, err)
+
+		txHolder := immuStore.NewTxHolder()
+
+		err = immuStore.ReadTx(hdr.ID, txHolder)
+		require.NoError(t, err)
+		require.Nil(t, txHolder.Entries()[0].Metadata())
+	})
+}
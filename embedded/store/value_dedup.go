@@ -0,0 +1,346 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package store
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"path/filepath"
+	"sync"
+
+	"github.com/codenotary/immudb/embedded/appendable"
+	"github.com/codenotary/immudb/embedded/appendable/multiapp"
+)
+
+const valueDedupDirname = "dedup"
+
+// dedupTxHeaderSize is the size of the header of the references of a tx: tx id + number of references
+const dedupTxHeaderSize = txIDSize + lszSize
+
+// dedupRefSize is the size of a reference to a stored value: digest + encoded offset + length + compression
+const dedupRefSize = sha256.Size + offsetSize + lszSize + 1
+
+// valueDedup keeps track of the values already stored in the vLogs, addressed by the digest of their content,
+// so entries holding an identical value reference the stored one instead of appending it again.
+// The number of entries referencing each stored value is kept as well.
+// References are persisted per tx, so they are loaded without reading the txs again, only the ones
+// of the txs committed since they were last persisted are tracked when the store is opened
+type valueDedup struct {
+	values map[[sha256.Size]byte]*dedupValue
+
+	app      appendable.Appendable
+	readOnly bool
+	size     uint64 // number of txs whose references were persisted
+	lastOff  int64  // offset of the references of the last persisted tx
+
+	mutex sync.RWMutex
+}
+
+type dedupValue struct {
+	off         int64
+	vLen        int // length of the value as recorded in tx entries
+	compression int // algorithm the value was compressed with
+	refCount    uint64
+}
+
+func openValueDedup(path string, opts *Options, fileSize int) (*valueDedup, error) {
+	appOpts := multiapp.DefaultOptions().
+		WithReadOnly(opts.ReadOnlyFilesystem).
+		WithSynced(opts.Synced).
+		WithFileSize(fileSize).
+		WithFileMode(opts.FileMode).
+		WithFileExt("dd")
+
+	var app appendable.Appendable
+	var err error
+
+	if opts.appFactory == nil {
+		app, err = multiapp.Open(filepath.Join(path, valueDedupDirname), appOpts)
+	} else {
+		app, err = opts.appFactory(path, valueDedupDirname, appOpts)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &valueDedup{
+		values:   make(map[[sha256.Size]byte]*dedupValue),
+		app:      app,
+		readOnly: opts.ReadOnlyFilesystem,
+	}, nil
+}
+
+// load reads the persisted references of the txs up to the given one,
+// references persisted for later txs, which were not committed, are discarded
+func (d *valueDedup) load(committedTxID uint64) error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	appSize, err := d.app.Size()
+	if err != nil {
+		return err
+	}
+
+	var off int64
+
+	for off+dedupTxHeaderSize <= appSize {
+		var hdr [dedupTxHeaderSize]byte
+
+		_, err = d.app.ReadAt(hdr[:], off)
+		if err != nil {
+			return err
+		}
+
+		txID := binary.BigEndian.Uint64(hdr[:])
+		refsLen := int64(binary.BigEndian.Uint32(hdr[txIDSize:])) * dedupRefSize
+
+		if txID != d.size+1 {
+			return fmt.Errorf("%w: unexpected tx %d in value de-duplication references", ErrCorruptedData, txID)
+		}
+
+		// references of uncommitted or partially written txs
+		if txID > committedTxID || off+dedupTxHeaderSize+refsLen > appSize {
+			break
+		}
+
+		refs := make([]byte, refsLen)
+
+		_, err = d.app.ReadAt(refs, off+dedupTxHeaderSize)
+		if err != nil {
+			return err
+		}
+
+		for i := 0; i < len(refs); i += dedupRefSize {
+			var hVal [sha256.Size]byte
+			copy(hVal[:], refs[i:])
+
+			d.reference(
+				hVal,
+				int64(binary.BigEndian.Uint64(refs[i+sha256.Size:])),
+				int(binary.BigEndian.Uint32(refs[i+sha256.Size+offsetSize:])),
+				int(refs[i+sha256.Size+offsetSize+lszSize]),
+			)
+		}
+
+		d.size = txID
+		d.lastOff = off
+
+		off += dedupTxHeaderSize + refsLen
+	}
+
+	if off == appSize || d.readOnly {
+		return nil
+	}
+
+	return d.app.SetOffset(off)
+}
+
+// lookup returns the encoded offset, length and compression algorithm of a stored value with the given digest
+func (d *valueDedup) lookup(hVal [sha256.Size]byte) (v dedupValue, found bool) {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	ref, found := d.values[hVal]
+	if !found {
+		return dedupValue{}, false
+	}
+
+	return dedupValue{off: ref.off, vLen: ref.vLen, compression: ref.compression}, true
+}
+
+// persist appends the references of the entries of a tx about to be committed,
+// the ones written by a previous attempt to commit the same tx are overwritten
+func (d *valueDedup) persist(tx *Tx) error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if d.readOnly {
+		return nil
+	}
+
+	if d.size > 0 && tx.header.ID == d.size {
+		err := d.app.SetOffset(d.lastOff)
+		if err != nil {
+			return err
+		}
+
+		d.size--
+	}
+
+	if tx.header.ID != d.size+1 {
+		return fmt.Errorf("%w: tx %d does not follow the last one with persisted value references", ErrIllegalState, tx.header.ID)
+	}
+
+	b := make([]byte, dedupTxHeaderSize, dedupTxHeaderSize+tx.header.NEntries*dedupRefSize)
+	binary.BigEndian.PutUint64(b, tx.header.ID)
+
+	n := 0
+
+	for _, e := range tx.entries[:tx.header.NEntries] {
+		if e.vLen == 0 {
+			continue
+		}
+
+		var ref [dedupRefSize]byte
+		copy(ref[:], e.hVal[:])
+		binary.BigEndian.PutUint64(ref[sha256.Size:], uint64(e.vOff))
+		binary.BigEndian.PutUint32(ref[sha256.Size+offsetSize:], uint32(e.vLen))
+		ref[sha256.Size+offsetSize+lszSize] = byte(valueCompression(e.md))
+
+		b = append(b, ref[:]...)
+		n++
+	}
+
+	binary.BigEndian.PutUint32(b[txIDSize:], uint32(n))
+
+	off, _, err := d.app.Append(b)
+	if err != nil {
+		return err
+	}
+
+	err = d.app.Flush()
+	if err != nil {
+		return err
+	}
+
+	d.size++
+	d.lastOff = off
+
+	return nil
+}
+
+// track registers the values referenced by the entries of a committed tx,
+// only committed values are tracked as uncommitted data may be overwritten
+func (d *valueDedup) track(tx *Tx) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	for _, e := range tx.entries[:tx.header.NEntries] {
+		if e.vLen == 0 {
+			continue
+		}
+
+		d.reference(e.hVal, e.vOff, e.vLen, valueCompression(e.md))
+	}
+}
+
+func (d *valueDedup) reference(hVal [sha256.Size]byte, off int64, vLen int, compression int) {
+	v, found := d.values[hVal]
+	if !found {
+		v = &dedupValue{off: off, vLen: vLen, compression: compression}
+		d.values[hVal] = v
+	}
+
+	v.refCount++
+}
+
+// discardTruncated forgets the values discarded by truncation, so they are not referenced by new entries
+func (d *valueDedup) discardTruncated(t *truncation) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	for hVal, v := range d.values {
+		if t.truncated(decodeOffset(v.off)) {
+			delete(d.values, hVal)
+		}
+	}
+}
+
+func (d *valueDedup) refCount(hVal [sha256.Size]byte) uint64 {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	v, found := d.values[hVal]
+	if !found {
+		return 0
+	}
+
+	return v.refCount
+}
+
+func (d *valueDedup) Sync() error {
+	return d.app.Sync()
+}
+
+func (d *valueDedup) Close() error {
+	return d.app.Close()
+}
+
+// loadValueDedup loads the persisted references and tracks the ones of the txs committed since
+func (s *ImmuStore) loadValueDedup() error {
+	err := s.valueDedup.load(s.committedTxID)
+	if err != nil {
+		return err
+	}
+
+	if s.valueDedup.size >= s.committedTxID {
+		return nil
+	}
+
+	s.log.Infof("Loading value de-duplication references at '%s' from tx %d...", s.path, s.valueDedup.size+1)
+
+	tx, err := s.fetchAllocTx()
+	if err != nil {
+		return err
+	}
+	defer s.releaseAllocTx(tx)
+
+	txReader, err := s.NewTxReader(s.valueDedup.size+1, false, tx)
+	if err != nil {
+		return err
+	}
+
+	for {
+		tx, err := txReader.Read()
+		if err == ErrNoMoreEntries {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		err = s.valueDedup.persist(tx)
+		if err != nil {
+			return err
+		}
+
+		s.valueDedup.track(tx)
+
+		if tx.header.ID%1000 == 0 {
+			s.log.Infof("Loading value de-duplication references at '%s' in progress: processing tx: %d", s.path, tx.header.ID)
+		}
+	}
+
+	s.log.Infof("Value de-duplication references loaded at '%s'", s.path)
+
+	return nil
+}
+
+// ValueDedup returns true when identical values are stored only once in the vLogs
+func (s *ImmuStore) ValueDedup() bool {
+	return s.valueDedup != nil
+}
+
+// ValueRefCount returns the number of committed entries referencing the stored value with the given digest.
+// Reference counts are only kept when value de-duplication is enabled
+func (s *ImmuStore) ValueRefCount(hVal [sha256.Size]byte) (uint64, error) {
+	if s.valueDedup == nil {
+		return 0, ErrIllegalState
+	}
+
+	return s.valueDedup.refCount(hVal), nil
+}
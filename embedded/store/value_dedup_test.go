@@ -0,0 +1,255 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package store
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestImmudbStoreValueDedup(t *testing.T) {
+	defer os.RemoveAll("data_value_dedup")
+
+	opts := DefaultOptions().WithSynced(false).WithMaxConcurrency(1).WithValueDedup(true)
+
+	immuStore, err := Open("data_value_dedup", opts)
+	require.NoError(t, err)
+	require.True(t, immuStore.ValueDedup())
+
+	payload := []byte("shared payload")
+	hPayload := sha256.Sum256(payload)
+
+	txCount := 10
+	eCount := 5
+
+	for i := 0; i < txCount; i++ {
+		tx, err := immuStore.NewWriteOnlyTx()
+		require.NoError(t, err)
+
+		for j := 0; j < eCount; j++ {
+			err = tx.Set([]byte(fmt.Sprintf("key_%d_%d", i, j)), nil, payload)
+			require.NoError(t, err)
+		}
+
+		err = tx.Set([]byte(fmt.Sprintf("unique_%d", i)), nil, []byte(fmt.Sprintf("value_%d", i)))
+		require.NoError(t, err)
+
+		_, err = tx.Commit()
+		require.NoError(t, err)
+	}
+
+	vLogSize, err := immuStore.vLogs[0].vLog.Size()
+	require.NoError(t, err)
+	require.Less(t, vLogSize, int64(txCount*eCount*len(payload)))
+
+	refCount, err := immuStore.ValueRefCount(hPayload)
+	require.NoError(t, err)
+	require.Equal(t, uint64(txCount*eCount), refCount)
+
+	refCount, err = immuStore.ValueRefCount(sha256.Sum256([]byte("value_0")))
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), refCount)
+
+	refCount, err = immuStore.ValueRefCount(sha256.Sum256([]byte("missing")))
+	require.NoError(t, err)
+	require.Zero(t, refCount)
+
+	checkValues := func(immuStore *ImmuStore) {
+		err := immuStore.WaitForIndexingUpto(uint64(txCount), nil)
+		require.NoError(t, err)
+
+		for i := 0; i < txCount; i++ {
+			for j := 0; j < eCount; j++ {
+				valRef, err := immuStore.Get([]byte(fmt.Sprintf("key_%d_%d", i, j)))
+				require.NoError(t, err)
+				require.Equal(t, uint64(i+1), valRef.Tx())
+
+				v, err := valRef.Resolve()
+				require.NoError(t, err)
+				require.Equal(t, payload, v)
+			}
+
+			valRef, err := immuStore.Get([]byte(fmt.Sprintf("unique_%d", i)))
+			require.NoError(t, err)
+
+			v, err := valRef.Resolve()
+			require.NoError(t, err)
+			require.Equal(t, []byte(fmt.Sprintf("value_%d", i)), v)
+		}
+
+		// inclusion proofs are not affected as entries keep the digest of their values
+		tx := immuStore.NewTxHolder()
+
+		err = immuStore.ReadTx(uint64(txCount), tx)
+		require.NoError(t, err)
+
+		for _, e := range tx.Entries() {
+			proof, err := tx.Proof(e.key())
+			require.NoError(t, err)
+
+			v, err := immuStore.ReadValue(e)
+			require.NoError(t, err)
+
+			require.True(t, VerifyInclusion(proof, EntrySpecDigest_v1(&EntrySpec{Key: e.key(), Value: v}), tx.header.Eh))
+		}
+	}
+
+	checkValues(immuStore)
+
+	err = immuStore.Close()
+	require.NoError(t, err)
+
+	t.Run("references are loaded when reopening", func(t *testing.T) {
+		immuStore, err := Open("data_value_dedup", opts)
+		require.NoError(t, err)
+
+		refCount, err := immuStore.ValueRefCount(hPayload)
+		require.NoError(t, err)
+		require.Equal(t, uint64(txCount*eCount), refCount)
+
+		tx, err := immuStore.NewWriteOnlyTx()
+		require.NoError(t, err)
+
+		err = tx.Set([]byte("key_after_reopen"), nil, payload)
+		require.NoError(t, err)
+
+		_, err = tx.Commit()
+		require.NoError(t, err)
+
+		newVLogSize, err := immuStore.vLogs[0].vLog.Size()
+		require.NoError(t, err)
+		require.Equal(t, vLogSize, newVLogSize)
+
+		refCount, err = immuStore.ValueRefCount(hPayload)
+		require.NoError(t, err)
+		require.Equal(t, uint64(txCount*eCount+1), refCount)
+
+		checkValues(immuStore)
+
+		err = immuStore.Close()
+		require.NoError(t, err)
+	})
+
+	t.Run("references are persisted along with txs", func(t *testing.T) {
+		d, err := openValueDedup("data_value_dedup", opts, DefaultFileSize)
+		require.NoError(t, err)
+
+		err = d.load(uint64(txCount + 1))
+		require.NoError(t, err)
+		require.Equal(t, uint64(txCount+1), d.size)
+		require.Equal(t, uint64(txCount*eCount+1), d.refCount(hPayload))
+
+		v, found := d.lookup(hPayload)
+		require.True(t, found)
+		require.Equal(t, len(payload), v.vLen)
+		require.Equal(t, NoValueCompression, v.compression)
+
+		err = d.Close()
+		require.NoError(t, err)
+
+		// the references of the last tx are discarded as if it was not committed,
+		// they are tracked again once the store is reopened
+		d, err = openValueDedup("data_value_dedup", opts, DefaultFileSize)
+		require.NoError(t, err)
+
+		err = d.load(uint64(txCount))
+		require.NoError(t, err)
+		require.Equal(t, uint64(txCount), d.size)
+		require.Equal(t, uint64(txCount*eCount), d.refCount(hPayload))
+
+		err = d.Close()
+		require.NoError(t, err)
+	})
+
+	t.Run("de-duplicated values are readable without de-duplication", func(t *testing.T) {
+		immuStore, err := Open("data_value_dedup", DefaultOptions().WithSynced(false).WithMaxConcurrency(1))
+		require.NoError(t, err)
+		require.False(t, immuStore.ValueDedup())
+
+		_, err = immuStore.ValueRefCount(hPayload)
+		require.ErrorIs(t, err, ErrIllegalState)
+
+		checkValues(immuStore)
+
+		err = immuStore.Close()
+		require.NoError(t, err)
+	})
+
+	t.Run("references of uncommitted txs are discarded", func(t *testing.T) {
+		d, err := openValueDedup("data_value_dedup", opts, DefaultFileSize)
+		require.NoError(t, err)
+
+		err = d.load(uint64(txCount + 1))
+		require.NoError(t, err)
+
+		tx := newTx(1, DefaultMaxKeyLen)
+		tx.header.ID = d.size + 1
+		tx.header.NEntries = 1
+		tx.entries[0].setKey([]byte("uncommitted"))
+		tx.entries[0].vLen = len(payload)
+		tx.entries[0].hVal = hPayload
+
+		err = d.persist(tx)
+		require.NoError(t, err)
+
+		err = d.Close()
+		require.NoError(t, err)
+
+		immuStore, err := Open("data_value_dedup", opts)
+		require.NoError(t, err)
+
+		refCount, err := immuStore.ValueRefCount(hPayload)
+		require.NoError(t, err)
+		require.Equal(t, uint64(txCount*eCount+1), refCount)
+
+		tx2, err := immuStore.NewWriteOnlyTx()
+		require.NoError(t, err)
+
+		err = tx2.Set([]byte("key_after_discard"), nil, payload)
+		require.NoError(t, err)
+
+		_, err = tx2.Commit()
+		require.NoError(t, err)
+
+		refCount, err = immuStore.ValueRefCount(hPayload)
+		require.NoError(t, err)
+		require.Equal(t, uint64(txCount*eCount+2), refCount)
+
+		err = immuStore.Close()
+		require.NoError(t, err)
+	})
+
+	t.Run("partially written references are tracked again", func(t *testing.T) {
+		truncateFile(t, filepath.Join("data_value_dedup", valueDedupDirname, "00000000.dd"), 1)
+
+		immuStore, err := Open("data_value_dedup", opts)
+		require.NoError(t, err)
+
+		require.Equal(t, immuStore.TxCount(), immuStore.valueDedup.size)
+
+		refCount, err := immuStore.ValueRefCount(hPayload)
+		require.NoError(t, err)
+		require.Equal(t, uint64(txCount*eCount+2), refCount)
+
+		err = immuStore.Close()
+		require.NoError(t, err)
+	})
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package tbtree
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/codenotary/immudb/embedded/appendable/mocked"
+	"github.com/stretchr/testify/require"
+)
+
+// inMemoryAppendable serves reads from b, writes are not expected
+func inMemoryAppendable(b []byte, metadata []byte) *mocked.MockedAppendable {
+	r := bytes.NewReader(b)
+
+	return &mocked.MockedAppendable{
+		MetadataFn:  func() []byte { return metadata },
+		SizeFn:      func() (int64, error) { return 0, nil },
+		SetOffsetFn: func(off int64) error { return nil },
+		ReadAtFn:    r.ReadAt,
+		CloseFn:     func() error { return nil },
+	}
+}
+
+func FuzzReadNode(f *testing.F) {
+	dir, err := ioutil.TempDir("", "fuzz_tbtree")
+	require.NoError(f, err)
+	defer os.RemoveAll(dir)
+
+	tree, err := Open(dir, DefaultOptions().WithMaxNodeSize(MinNodeSize))
+	require.NoError(f, err)
+
+	for i := 0; i < 100; i++ {
+		err = tree.Insert([]byte(fmt.Sprintf("key%03d", i)), []byte(fmt.Sprintf("value%d", i)))
+		require.NoError(f, err)
+	}
+
+	_, _, err = tree.Flush()
+	require.NoError(f, err)
+
+	metadata := tree.cLog.Metadata()
+
+	nLogSize, err := tree.nLog.Size()
+	require.NoError(f, err)
+
+	nLogBs := make([]byte, nLogSize)
+	_, err = tree.nLog.ReadAt(nLogBs, 0)
+	require.NoError(f, err)
+
+	f.Add(nLogBs, tree.root.offset(), []byte("key050"))
+	f.Add(nLogBs, int64(0), []byte("key000"))
+
+	// inner node referencing itself
+	cyclicBs := make([]byte, 1+2+2+3*8)
+	cyclicBs[0] = InnerNodeType
+	binary.BigEndian.PutUint16(cyclicBs[1:], 1)
+
+	f.Add(cyclicBs, int64(0), []byte("key"))
+
+	// inner node without children
+	f.Add([]byte{InnerNodeType, 0, 0}, int64(0), []byte("key"))
+
+	err = tree.Close()
+	require.NoError(f, err)
+
+	f.Fuzz(func(t *testing.T, nLogBs []byte, off int64, key []byte) {
+		if off < 0 {
+			return
+		}
+
+		tree, err := OpenWith(
+			"fuzz",
+			inMemoryAppendable(nLogBs, nil),
+			inMemoryAppendable(nil, nil),
+			inMemoryAppendable(nil, metadata),
+			DefaultOptions(),
+		)
+		require.NoError(t, err)
+
+		n, err := tree.readNodeAt(off)
+		if err != nil {
+			return
+		}
+
+		// a decoded node must be safely traversed, errors are expected when it's corrupted
+		n.size()
+		n.get(key)
+		n.get(n.minKey())
+		n.history(key, 0, false, 8)
+		n.findLeafNode(key, nil, 0, nil, false)
+		n.findLeafNode(key, nil, 0, nil, true)
+	})
+}
@@ -21,6 +21,7 @@ import (
 
 	"github.com/codenotary/immudb/embedded/appendable"
 	"github.com/codenotary/immudb/embedded/appendable/multiapp"
+	"github.com/codenotary/immudb/embedded/cache"
 	"github.com/codenotary/immudb/pkg/logger"
 )
 
@@ -32,6 +33,7 @@ const DefaultCleanUpPercentage float32 = 0
 const DefaultMaxActiveSnapshots = 100
 const DefaultRenewSnapRootAfter = time.Duration(1000) * time.Millisecond
 const DefaultCacheSize = 100_000
+const DefaultCachePolicy = cache.ARCPolicy
 const DefaultFileMode = os.FileMode(0755)
 const DefaultFileSize = 1 << 26 // 64Mb
 const DefaultMaxKeyLen = 1024
@@ -61,6 +63,7 @@ type Options struct {
 	maxActiveSnapshots int
 	renewSnapRootAfter time.Duration
 	cacheSize          int
+	cachePolicy        int
 	readOnly           bool
 	fileMode           os.FileMode
 
@@ -68,6 +71,12 @@ type Options struct {
 	historyLogMaxOpenedFiles int
 	commitLogMaxOpenedFiles  int
 
+	// nodes are read from memory mappings of the nodes log files, when supported
+	mmapReads bool
+
+	// flush buffers are allocated outside of the Go heap, when supported
+	offHeapFlushBuffers bool
+
 	maxKeyLen int
 
 	compactionThld        int
@@ -90,6 +99,7 @@ func DefaultOptions() *Options {
 		maxActiveSnapshots:    DefaultMaxActiveSnapshots,
 		renewSnapRootAfter:    DefaultRenewSnapRootAfter,
 		cacheSize:             DefaultCacheSize,
+		cachePolicy:           DefaultCachePolicy,
 		readOnly:              false,
 		fileMode:              DefaultFileMode,
 		maxKeyLen:             DefaultMaxKeyLen,
@@ -120,6 +130,7 @@ func validOptions(opts *Options) bool {
 		opts.maxActiveSnapshots > 0 &&
 		opts.renewSnapRootAfter >= 0 &&
 		opts.cacheSize >= MinCacheSize &&
+		cache.ValidPolicy(opts.cachePolicy) &&
 		opts.maxKeyLen > 0 &&
 		opts.compactionThld > 0 &&
 		opts.log != nil
@@ -170,6 +181,12 @@ func (opts *Options) WithCacheSize(cacheSize int) *Options {
 	return opts
 }
 
+// WithCachePolicy sets the replacement policy of the nodes cache, it's only applied when the index is opened
+func (opts *Options) WithCachePolicy(cachePolicy int) *Options {
+	opts.cachePolicy = cachePolicy
+	return opts
+}
+
 func (opts *Options) WithReadOnly(readOnly bool) *Options {
 	opts.readOnly = readOnly
 	return opts
@@ -219,3 +236,15 @@ func (opts *Options) WithDelayDuringCompaction(delay time.Duration) *Options {
 	opts.delayDuringCompaction = delay
 	return opts
 }
+
+func (opts *Options) WithMmapReads(mmapReads bool) *Options {
+	opts.mmapReads = mmapReads
+	return opts
+}
+
+// WithOffHeapFlushBuffers makes the buffers nodes are written into when the index is flushed to be allocated
+// outside of the Go heap, so large flush buffers do not lengthen garbage collection pauses
+func (opts *Options) WithOffHeapFlushBuffers(offHeapFlushBuffers bool) *Options {
+	opts.offHeapFlushBuffers = offHeapFlushBuffers
+	return opts
+}
@@ -37,6 +37,7 @@ func TestValidOptions(t *testing.T) {
 	opts := &Options{}
 
 	require.Equal(t, DefaultCacheSize, opts.WithCacheSize(DefaultCacheSize).cacheSize)
+	require.Equal(t, DefaultCachePolicy, opts.WithCachePolicy(DefaultCachePolicy).cachePolicy)
 	require.Equal(t, DefaultFileMode, opts.WithFileMode(DefaultFileMode).fileMode)
 	require.Equal(t, DefaultFileSize, opts.WithFileSize(DefaultFileSize).fileSize)
 	require.Equal(t, DefaultFlushThld, opts.WithFlushThld(DefaultFlushThld).flushThld)
@@ -59,6 +60,9 @@ func TestValidOptions(t *testing.T) {
 
 	require.True(t, validOptions(opts))
 
+	require.False(t, validOptions(opts.WithCachePolicy(-1)))
+	require.True(t, validOptions(opts.WithCachePolicy(DefaultCachePolicy)))
+
 	require.True(t, opts.WithReadOnly(true).readOnly)
 	require.True(t, validOptions(opts))
 	require.Nil(t, opts.WithAppFactory(nil).appFactory)
@@ -308,6 +308,10 @@ func (n *innerNode) writeTo(nw, hw io.Writer, writeOpts *WriteOpts, buf []byte)
 
 		cnw += wn
 		chw += wh
+
+		if writeOpts.reportChildWritten != nil {
+			writeOpts.reportChildWritten(i+1, len(n.nodes))
+		}
 	}
 
 	size, err := n.size()
@@ -340,6 +344,9 @@ func (n *innerNode) writeTo(nw, hw io.Writer, writeOpts *WriteOpts, buf []byte)
 		n.off = writeOpts.BaseNLogOffset + cnw
 		n._minOff = minOff
 
+		n.t.accountStaleNode(n.mut, n.replacedSize, size)
+		n.replacedSize = 0
+
 		if n.mut {
 			n.mut = false
 
@@ -456,6 +463,9 @@ func (l *leafNode) writeTo(nw, hw io.Writer, writeOpts *WriteOpts, buf []byte) (
 	if writeOpts.commitLog {
 		l.off = nOff
 
+		l.t.accountStaleNode(l.mut, l.replacedSize, size)
+		l.replacedSize = 0
+
 		if l.mut {
 			l.mut = false
 			l.t.cachePut(l)
@@ -464,6 +474,10 @@ func (l *leafNode) writeTo(nw, hw io.Writer, writeOpts *WriteOpts, buf []byte) (
 
 	writeOpts.reportProgress(0, 1, len(l.values))
 
+	if writeOpts.reportChildWritten != nil {
+		writeOpts.reportChildWritten(1, 1)
+	}
+
 	return nOff, nOff, wN, accH, nil
 }
 
@@ -56,6 +56,7 @@ var ErrCompactAlreadyInProgress = errors.New("compact already in progress")
 var ErrCompactionThresholdNotReached = errors.New("compaction threshold not yet reached")
 var ErrIncompatibleDataFormat = errors.New("incompatible data format")
 var ErrTargetPathAlreadyExists = errors.New("target folder already exists")
+var ErrReadOnly = errors.New("index opened in read-only mode")
 
 const Version = 3
 
@@ -162,7 +163,7 @@ type TBtree struct {
 	log  logger.Logger
 
 	nLog   appendable.Appendable
-	cache  *cache.LRUCache
+	cache  cache.Cache
 	nmutex sync.Mutex // mutex for cache and file reading
 
 	hLog appendable.Appendable
@@ -182,6 +183,7 @@ type TBtree struct {
 	renewSnapRootAfter       time.Duration
 	readOnly                 bool
 	cacheSize                int
+	cachePolicy              int
 	fileSize                 int
 	fileMode                 os.FileMode
 	maxKeyLen                int
@@ -190,6 +192,8 @@ type TBtree struct {
 	nodesLogMaxOpenedFiles   int
 	historyLogMaxOpenedFiles int
 	commitLogMaxOpenedFiles  int
+	mmapReads                bool
+	offHeapFlushBuffers      bool
 
 	snapshots      map[uint64]*Snapshot
 	maxSnapshotID  uint64
@@ -201,8 +205,15 @@ type TBtree struct {
 	committedHLogSize int64
 	minOffset         int64
 
+	// bytes of the nodes log taken by nodes replaced by newer versions since the index was opened
+	staleNLogSize int64
+
 	compacting bool
 
+	compactionProgressMutex sync.Mutex
+	compactedChildren       int
+	childrenToCompact       int
+
 	closed  bool
 	rwmutex sync.RWMutex
 }
@@ -239,6 +250,9 @@ type WriteOpts struct {
 	commitLog      bool
 	reportProgress writeProgressOutputFunc
 	MinOffset      int64
+
+	// invoked by the top-most node only, after each of its children is written
+	reportChildWritten func(written int, total int)
 }
 
 type innerNode struct {
@@ -248,6 +262,8 @@ type innerNode struct {
 	off     int64
 	_minOff int64
 	mut     bool
+
+	replacedSize int64 // size of the persisted node replaced by this mutated one
 }
 
 type leafNode struct {
@@ -256,6 +272,8 @@ type leafNode struct {
 	_ts    uint64
 	off    int64
 	mut    bool
+
+	replacedSize int64 // size of the persisted node replaced by this mutated one
 }
 
 type nodeRef struct {
@@ -282,7 +300,7 @@ func Open(path string, opts *Options) (*TBtree, error) {
 
 	finfo, err := os.Stat(path)
 	if err != nil {
-		if !os.IsNotExist(err) {
+		if !os.IsNotExist(err) || opts.readOnly {
 			return nil, err
 		}
 		err = os.Mkdir(path, opts.fileMode)
@@ -303,6 +321,7 @@ func Open(path string, opts *Options) (*TBtree, error) {
 		WithFileSize(opts.fileSize).
 		WithFileMode(opts.fileMode).
 		WithWriteBufferSize(opts.flushBufferSize).
+		WithOffHeapWriteBuffers(opts.offHeapFlushBuffers).
 		WithMetadata(metadata.Bytes())
 
 	appFactory := opts.appFactory
@@ -339,6 +358,7 @@ func Open(path string, opts *Options) (*TBtree, error) {
 
 		appendableOpts.WithFileExt("n")
 		appendableOpts.WithMaxOpenedFiles(opts.nodesLogMaxOpenedFiles)
+		appendableOpts.WithMmapReads(opts.mmapReads)
 		nLog, err := appFactory(path, nFolder, appendableOpts)
 		if err != nil {
 			opts.log.Infof("Skipping snapshots at '%s', reading node data returned: %v", snapPath, err)
@@ -347,6 +367,7 @@ func Open(path string, opts *Options) (*TBtree, error) {
 
 		appendableOpts.WithFileExt("ri")
 		appendableOpts.WithMaxOpenedFiles(opts.commitLogMaxOpenedFiles)
+		appendableOpts.WithMmapReads(false)
 		cLog, err := appFactory(path, cFolder, appendableOpts)
 		if err != nil {
 			nLog.Close()
@@ -375,9 +396,12 @@ func Open(path string, opts *Options) (*TBtree, error) {
 			nLog.Close()
 			cLog.Close()
 
-			err = discardSnapshots(path, snapIDs[i-1:i], opts.log)
-			if err != nil {
-				opts.log.Warningf("Discarding snapshots at '%s' returned: %v", path, err)
+			// snapshots are left untouched when the index is opened in read-only mode
+			if !opts.readOnly {
+				err = discardSnapshots(path, snapIDs[i-1:i], opts.log)
+				if err != nil {
+					opts.log.Warningf("Discarding snapshots at '%s' returned: %v", path, err)
+				}
 			}
 
 			continue
@@ -386,9 +410,11 @@ func Open(path string, opts *Options) (*TBtree, error) {
 		opts.log.Infof("Successfully read snapshots at '%s'", snapPath)
 
 		// Discard older snapshots upon successful validation
-		err = discardSnapshots(path, snapIDs[:i-1], opts.log)
-		if err != nil {
-			opts.log.Warningf("Discarding snapshots at '%s' returned: %v", path, err)
+		if !opts.readOnly {
+			err = discardSnapshots(path, snapIDs[:i-1], opts.log)
+			if err != nil {
+				opts.log.Warningf("Discarding snapshots at '%s' returned: %v", path, err)
+			}
 		}
 
 		return t, nil
@@ -403,6 +429,7 @@ func Open(path string, opts *Options) (*TBtree, error) {
 
 	appendableOpts.WithFileExt("n")
 	appendableOpts.WithMaxOpenedFiles(opts.nodesLogMaxOpenedFiles)
+	appendableOpts.WithMmapReads(opts.mmapReads)
 	nLog, err := appFactory(path, nodesFolderPrefix, appendableOpts)
 	if err != nil {
 		return nil, err
@@ -410,6 +437,7 @@ func Open(path string, opts *Options) (*TBtree, error) {
 
 	appendableOpts.WithFileExt("ri")
 	appendableOpts.WithMaxOpenedFiles(opts.commitLogMaxOpenedFiles)
+	appendableOpts.WithMmapReads(false)
 	cLog, err := appFactory(path, commitFolderPrefix, appendableOpts)
 	if err != nil {
 		return nil, err
@@ -512,7 +540,7 @@ func OpenWith(path string, nLog, hLog, cLog appendable.Appendable, opts *Options
 		}
 	}
 
-	cache, err := cache.NewLRUCache(opts.cacheSize)
+	cache, err := cache.NewCache(opts.cachePolicy, opts.cacheSize)
 	if err != nil {
 		return nil, err
 	}
@@ -533,6 +561,7 @@ func OpenWith(path string, nLog, hLog, cLog appendable.Appendable, opts *Options
 		maxActiveSnapshots:       opts.maxActiveSnapshots,
 		fileSize:                 opts.fileSize,
 		cacheSize:                opts.cacheSize,
+		cachePolicy:              opts.cachePolicy,
 		fileMode:                 opts.fileMode,
 		maxKeyLen:                opts.maxKeyLen,
 		compactionThld:           opts.compactionThld,
@@ -540,6 +569,8 @@ func OpenWith(path string, nLog, hLog, cLog appendable.Appendable, opts *Options
 		nodesLogMaxOpenedFiles:   opts.nodesLogMaxOpenedFiles,
 		historyLogMaxOpenedFiles: opts.historyLogMaxOpenedFiles,
 		commitLogMaxOpenedFiles:  opts.commitLogMaxOpenedFiles,
+		mmapReads:                opts.mmapReads,
+		offHeapFlushBuffers:      opts.offHeapFlushBuffers,
 		readOnly:                 opts.readOnly,
 		snapshots:                make(map[uint64]*Snapshot),
 	}
@@ -654,6 +685,7 @@ func (t *TBtree) GetOptions() *Options {
 		WithMaxKeyLen(t.maxKeyLen).
 		WithLog(t.log).
 		WithCacheSize(t.cacheSize).
+		WithCachePolicy(t.cachePolicy).
 		WithFlushThld(t.flushThld).
 		WithSyncThld(t.syncThld).
 		WithFlushBufferSize(t.flushBufferSize).
@@ -665,7 +697,85 @@ func (t *TBtree) GetOptions() *Options {
 		WithDelayDuringCompaction(t.delayDuringCompaction).
 		WithNodesLogMaxOpenedFiles(t.nodesLogMaxOpenedFiles).
 		WithHistoryLogMaxOpenedFiles(t.historyLogMaxOpenedFiles).
-		WithCommitLogMaxOpenedFiles(t.commitLogMaxOpenedFiles)
+		WithCommitLogMaxOpenedFiles(t.commitLogMaxOpenedFiles).
+		WithMmapReads(t.mmapReads).
+		WithOffHeapFlushBuffers(t.offHeapFlushBuffers)
+}
+
+// UpdateOptions applies the options which can be changed while the index is opened:
+// thresholds, snapshot settings, cache size and max number of opened files.
+// Any other option keeps the value the index was opened with.
+func (t *TBtree) UpdateOptions(opts *Options) error {
+	if !validOptions(opts) {
+		return ErrIllegalArguments
+	}
+
+	t.rwmutex.Lock()
+	defer t.rwmutex.Unlock()
+
+	if t.closed {
+		return ErrAlreadyClosed
+	}
+
+	for _, l := range []struct {
+		app            appendable.Appendable
+		maxOpenedFiles int
+	}{
+		{t.nLog, opts.nodesLogMaxOpenedFiles},
+		{t.hLog, opts.historyLogMaxOpenedFiles},
+		{t.cLog, opts.commitLogMaxOpenedFiles},
+	} {
+		app, ok := l.app.(interface{ SetMaxOpenedFiles(int) error })
+		if !ok {
+			continue
+		}
+
+		err := app.SetMaxOpenedFiles(l.maxOpenedFiles)
+		if err != nil {
+			return err
+		}
+	}
+
+	t.nmutex.Lock()
+	t.cache.Resize(opts.cacheSize)
+	t.nmutex.Unlock()
+
+	t.flushThld = opts.flushThld
+	t.syncThld = opts.syncThld
+	t.flushBufferSize = opts.flushBufferSize
+	t.cleanupPercentage = opts.cleanupPercentage
+	t.maxActiveSnapshots = opts.maxActiveSnapshots
+	t.renewSnapRootAfter = opts.renewSnapRootAfter
+	t.cacheSize = opts.cacheSize
+	t.compactionThld = opts.compactionThld
+	t.delayDuringCompaction = opts.delayDuringCompaction
+	t.nodesLogMaxOpenedFiles = opts.nodesLogMaxOpenedFiles
+	t.historyLogMaxOpenedFiles = opts.historyLogMaxOpenedFiles
+	t.commitLogMaxOpenedFiles = opts.commitLogMaxOpenedFiles
+
+	return nil
+}
+
+// SetCacheSize resizes the nodes cache, evicting nodes if it's shrunk
+func (t *TBtree) SetCacheSize(size int) error {
+	if size < MinCacheSize {
+		return ErrIllegalArguments
+	}
+
+	t.rwmutex.RLock()
+	defer t.rwmutex.RUnlock()
+
+	if t.closed {
+		return ErrAlreadyClosed
+	}
+
+	t.nmutex.Lock()
+	defer t.nmutex.Unlock()
+
+	t.cache.Resize(size)
+	t.cacheSize = size
+
+	return nil
 }
 
 func (t *TBtree) cachePut(n node) {
@@ -731,6 +841,14 @@ func (t *TBtree) readNodeFrom(r *appendable.Reader) (node, error) {
 		if err != nil {
 			return nil, err
 		}
+
+		// child nodes are always written before their parent node
+		for _, c := range n.nodes {
+			if c.offset() >= off {
+				return nil, ErrCorruptedFile
+			}
+		}
+
 		n.off = off
 		return n, nil
 	case LeafNodeType:
@@ -751,6 +869,10 @@ func (t *TBtree) readInnerNodeFrom(r *appendable.Reader) (*innerNode, error) {
 		return nil, err
 	}
 
+	if childCount == 0 {
+		return nil, ErrCorruptedFile
+	}
+
 	n := &innerNode{
 		t:       t,
 		nodes:   make([]node, childCount),
@@ -951,6 +1073,10 @@ func (t *TBtree) Sync() error {
 		return ErrAlreadyClosed
 	}
 
+	if t.readOnly {
+		return ErrReadOnly
+	}
+
 	_, _, err := t.flushTree(0, true)
 	return err
 }
@@ -967,6 +1093,10 @@ func (t *TBtree) FlushWith(cleanupPercentage float32, synced bool) (wN, wH int64
 		return 0, 0, ErrAlreadyClosed
 	}
 
+	if t.readOnly {
+		return 0, 0, ErrReadOnly
+	}
+
 	return t.flushTree(cleanupPercentage, synced)
 }
 
@@ -1191,6 +1321,60 @@ func (t *TBtree) snapshotCount() uint64 {
 	return uint64(t.committedLogSize / cLogEntrySize)
 }
 
+// Stats holds an estimation of the space taken by the index
+type Stats struct {
+	SnapshotCount uint64
+	NodesLogSize  int64 // bytes written into the nodes log since the index was created or last compacted
+	NodesLogFiles int   // files holding nodes still referenced by the latest snapshot
+	StaleNodeSize int64 // bytes of nodes replaced by newer versions since the index was opened
+}
+
+// GarbageRatio returns the estimated fraction of the nodes log taken by nodes no longer referenced
+func (s *Stats) GarbageRatio() float32 {
+	if s.NodesLogSize == 0 {
+		return 0
+	}
+
+	if s.StaleNodeSize >= s.NodesLogSize {
+		return 1
+	}
+
+	return float32(s.StaleNodeSize) / float32(s.NodesLogSize)
+}
+
+// Stats returns an estimation of the space taken by the index, so to decide when it's worth to compact it.
+// Nodes replaced before the index was opened are not accounted as garbage.
+func (t *TBtree) Stats() (*Stats, error) {
+	t.rwmutex.RLock()
+	defer t.rwmutex.RUnlock()
+
+	if t.closed {
+		return nil, ErrAlreadyClosed
+	}
+
+	stats := &Stats{
+		SnapshotCount: t.snapshotCount(),
+		NodesLogSize:  t.committedNLogSize,
+		StaleNodeSize: t.staleNLogSize,
+	}
+
+	if t.committedNLogSize > 0 {
+		stats.NodesLogFiles = int((t.committedNLogSize-1)/int64(t.fileSize)-t.minOffset/int64(t.fileSize)) + 1
+	}
+
+	return stats, nil
+}
+
+// accountStaleNode is invoked for every node written while flushing the tree, a rewritten node
+// (not mutated) leaves its previous copy as garbage while a mutated one replaces the node it was copied from
+func (t *TBtree) accountStaleNode(mutated bool, replacedSize int64, size int) {
+	if mutated {
+		t.staleNLogSize += replacedSize
+	} else {
+		t.staleNLogSize += int64(size)
+	}
+}
+
 func (t *TBtree) buildWriteProgressOutput(
 	nodesLastCycle *prometheus.GaugeVec,
 	nodesTotal *prometheus.CounterVec,
@@ -1259,6 +1443,10 @@ func (t *TBtree) Compact() (uint64, error) {
 		return 0, ErrAlreadyClosed
 	}
 
+	if t.readOnly {
+		return 0, ErrReadOnly
+	}
+
 	if t.compacting {
 		return 0, ErrCompactAlreadyInProgress
 	}
@@ -1278,8 +1466,10 @@ func (t *TBtree) Compact() (uint64, error) {
 	}
 
 	t.compacting = true
+	t.setCompactionProgress(0, 1)
 	defer func() {
 		t.compacting = false
+		t.setCompactionProgress(0, 0)
 	}()
 
 	// snapshot dumping without lock
@@ -1309,6 +1499,27 @@ func (t *TBtree) Compact() (uint64, error) {
 	return snap.Ts(), nil
 }
 
+// CompactionProgress returns the percentage of the index already dumped by the ongoing compaction.
+// Progress is estimated based on the number of subtrees of the root node already written.
+func (t *TBtree) CompactionProgress() float32 {
+	t.compactionProgressMutex.Lock()
+	defer t.compactionProgressMutex.Unlock()
+
+	if t.childrenToCompact == 0 {
+		return 0
+	}
+
+	return float32(t.compactedChildren) * 100 / float32(t.childrenToCompact)
+}
+
+func (t *TBtree) setCompactionProgress(written, total int) {
+	t.compactionProgressMutex.Lock()
+	defer t.compactionProgressMutex.Unlock()
+
+	t.compactedChildren = written
+	t.childrenToCompact = total
+}
+
 func (t *TBtree) fullDump(snap *Snapshot, progressOutput writeProgressOutputFunc) error {
 	metadata := appendable.NewMetadata(nil)
 	metadata.PutInt(MetaVersion, Version)
@@ -1320,6 +1531,7 @@ func (t *TBtree) fullDump(snap *Snapshot, progressOutput writeProgressOutputFunc
 		WithFileSize(t.fileSize).
 		WithFileMode(t.fileMode).
 		WithWriteBufferSize(t.flushBufferSize).
+		WithOffHeapWriteBuffers(t.offHeapFlushBuffers).
 		WithMetadata(t.cLog.Metadata())
 
 	appendableOpts.WithFileExt("n")
@@ -1357,6 +1569,8 @@ func (t *TBtree) fullDumpTo(snapshot *Snapshot, nLog, cLog appendable.Appendable
 		BaseNLogOffset: 0,
 		BaseHLogOffset: 0,
 		reportProgress: progressOutput,
+
+		reportChildWritten: t.setCompactionProgress,
 	}
 
 	_, _, wN, _, err := snapshot.WriteTo(&appendableWriter{nLog}, nil, wopts)
@@ -1447,10 +1661,12 @@ func (t *TBtree) Close() error {
 
 	merrors := multierr.NewMultiErr()
 
-	_, _, err := t.flushTree(0, true)
-	merrors.Append(err)
+	if !t.readOnly {
+		_, _, err := t.flushTree(0, true)
+		merrors.Append(err)
+	}
 
-	err = t.nLog.Close()
+	err := t.nLog.Close()
 	merrors.Append(err)
 
 	err = t.hLog.Close()
@@ -1476,6 +1692,10 @@ func (t *TBtree) IncreaseTs(ts uint64) error {
 		return ErrAlreadyClosed
 	}
 
+	if t.readOnly {
+		return ErrReadOnly
+	}
+
 	root, err := t.root.setTs(ts)
 	if err != nil {
 		return err
@@ -1543,6 +1763,10 @@ func (t *TBtree) BulkInsert(kvs []*KV) error {
 		return ErrAlreadyClosed
 	}
 
+	if t.readOnly {
+		return ErrReadOnly
+	}
+
 	ts := t.root.ts() + 1
 
 	for _, kv := range kvs {
@@ -1704,13 +1928,19 @@ func (n *innerNode) copyOnInsertAt(key []byte, value []byte, ts uint64) (n1 node
 		return nil, nil, 0, err
 	}
 
+	replacedSize, err := n.size()
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
 	if c2 == nil {
 		newNode := &innerNode{
-			t:       n.t,
-			nodes:   make([]node, len(n.nodes)),
-			_ts:     ts,
-			mut:     true,
-			_minOff: n._minOff,
+			t:            n.t,
+			nodes:        make([]node, len(n.nodes)),
+			_ts:          ts,
+			mut:          true,
+			_minOff:      n._minOff,
+			replacedSize: int64(replacedSize),
 		}
 
 		copy(newNode.nodes[:insertAt], n.nodes[:insertAt])
@@ -1725,11 +1955,12 @@ func (n *innerNode) copyOnInsertAt(key []byte, value []byte, ts uint64) (n1 node
 	}
 
 	newNode := &innerNode{
-		t:       n.t,
-		nodes:   make([]node, len(n.nodes)+1),
-		_ts:     ts,
-		mut:     true,
-		_minOff: n._minOff,
+		t:            n.t,
+		nodes:        make([]node, len(n.nodes)+1),
+		_ts:          ts,
+		mut:          true,
+		_minOff:      n._minOff,
+		replacedSize: int64(replacedSize),
 	}
 
 	copy(newNode.nodes[:insertAt], n.nodes[:insertAt])
@@ -2051,12 +2282,18 @@ func (l *leafNode) updateOnInsertAt(key []byte, value []byte, ts uint64) (n1 nod
 func (l *leafNode) copyOnInsertAt(key []byte, value []byte, ts uint64) (n1 node, n2 node, depth int, err error) {
 	i, found := l.indexOf(key)
 
+	replacedSize, err := l.size()
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
 	if found {
 		newLeaf := &leafNode{
-			t:      l.t,
-			values: make([]*leafValue, len(l.values)),
-			_ts:    ts,
-			mut:    true,
+			t:            l.t,
+			values:       make([]*leafValue, len(l.values)),
+			_ts:          ts,
+			mut:          true,
+			replacedSize: int64(replacedSize),
 		}
 
 		for pi := 0; pi < i; pi++ {
@@ -2094,10 +2331,11 @@ func (l *leafNode) copyOnInsertAt(key []byte, value []byte, ts uint64) (n1 node,
 	}
 
 	newLeaf := &leafNode{
-		t:      l.t,
-		values: make([]*leafValue, len(l.values)+1),
-		_ts:    ts,
-		mut:    true,
+		t:            l.t,
+		values:       make([]*leafValue, len(l.values)+1),
+		_ts:          ts,
+		mut:          true,
+		replacedSize: int64(replacedSize),
 	}
 
 	for pi := 0; pi < i; pi++ {
@@ -2197,13 +2435,27 @@ func (l *leafNode) history(key []byte, offset uint64, desc bool, limit int) ([]u
 	ti := uint64(len(leafValue.tss))
 
 	for tssOff < tssLen {
-		r := appendable.NewReaderFrom(l.t.hLog, hOff, DefaultMaxNodeSize)
-
-		hc, err := r.ReadUint32()
+		hc, err := appendable.NewReaderFrom(l.t.hLog, hOff, 4).ReadUint32()
 		if err != nil {
 			return nil, err
 		}
 
+		// records preceding the offset are skipped without reading their entries,
+		// so paging through a long history doesn't read it again up to each page
+		if ti+uint64(hc) <= initAt {
+			ti += uint64(hc)
+
+			prevOff, err := appendable.NewReaderFrom(l.t.hLog, hOff+4+8*int64(hc), 8).ReadUint64()
+			if err != nil {
+				return nil, err
+			}
+
+			hOff = int64(prevOff)
+			continue
+		}
+
+		r := appendable.NewReaderFrom(l.t.hLog, hOff+4, DefaultMaxNodeSize)
+
 		for i := 0; i < int(hc) && tssOff < tssLen; i++ {
 			ts, err := r.ReadUint64()
 			if err != nil {
@@ -33,6 +33,7 @@ import (
 	"github.com/codenotary/immudb/embedded/appendable"
 	"github.com/codenotary/immudb/embedded/appendable/mocked"
 	"github.com/codenotary/immudb/embedded/appendable/multiapp"
+	"github.com/codenotary/immudb/embedded/cache"
 
 	"github.com/stretchr/testify/require"
 )
@@ -662,6 +663,47 @@ func TestSnapshotRecovery(t *testing.T) {
 	})
 }
 
+func TestTBTreeCompactionProgress(t *testing.T) {
+	d, err := ioutil.TempDir("", "test_tree_compaction_progress")
+	require.NoError(t, err)
+	defer os.RemoveAll(d)
+
+	tree, err := Open(d, DefaultOptions().WithCompactionThld(1))
+	require.NoError(t, err)
+	defer tree.Close()
+
+	require.Zero(t, tree.CompactionProgress())
+
+	monotonicInsertions(t, tree, 1, 1_000, true)
+
+	snap, err := tree.Snapshot()
+	require.NoError(t, err)
+
+	lastWritten, lastTotal := 0, 0
+
+	wopts := &WriteOpts{
+		reportProgress: func(int, int, int) {},
+		reportChildWritten: func(written, total int) {
+			require.Equal(t, lastWritten+1, written)
+			require.LessOrEqual(t, written, total)
+
+			lastWritten, lastTotal = written, total
+		},
+	}
+
+	_, _, _, _, err = snap.WriteTo(new(bytes.Buffer), new(bytes.Buffer), wopts)
+	require.NoError(t, err)
+	require.Greater(t, lastTotal, 1)
+	require.Equal(t, lastTotal, lastWritten)
+
+	err = snap.Close()
+	require.NoError(t, err)
+
+	_, err = tree.Compact()
+	require.NoError(t, err)
+	require.Zero(t, tree.CompactionProgress())
+}
+
 func TestTBTreeCompactionEdgeCases(t *testing.T) {
 	d, err := ioutil.TempDir("", "test_tree_compaction_edge_cases")
 	require.NoError(t, err)
@@ -810,6 +852,52 @@ func TestTBTreeHistory(t *testing.T) {
 	require.Equal(t, 2, len(tss))
 }
 
+func TestTBTreeHistoryPaging(t *testing.T) {
+	opts := DefaultOptions().WithMaxNodeSize(256).WithFlushThld(100)
+	tbtree, err := Open("test_tree_history_paging", opts)
+	require.NoError(t, err)
+	defer os.RemoveAll("test_tree_history_paging")
+	defer tbtree.Close()
+
+	revisions := 50
+
+	// the history of the key is split into several records of the history log
+	for i := 0; i < revisions; i++ {
+		err = tbtree.BulkInsert([]*KV{{K: []byte("k0"), V: []byte(fmt.Sprintf("v%d", i))}})
+		require.NoError(t, err)
+
+		if i%7 == 6 {
+			_, _, err = tbtree.Flush()
+			require.NoError(t, err)
+		}
+	}
+
+	all, err := tbtree.History([]byte("k0"), 0, false, revisions)
+	require.NoError(t, err)
+	require.Len(t, all, revisions)
+
+	for _, desc := range []bool{false, true} {
+		var paged []uint64
+
+		for offset := 0; offset < revisions; offset += 3 {
+			tss, err := tbtree.History([]byte("k0"), uint64(offset), desc, 3)
+			require.NoError(t, err)
+
+			paged = append(paged, tss...)
+		}
+
+		require.Len(t, paged, revisions)
+
+		for i := range all {
+			if desc {
+				require.Equal(t, all[revisions-1-i], paged[i])
+			} else {
+				require.Equal(t, all[i], paged[i])
+			}
+		}
+	}
+}
+
 func TestTBTreeInsertionInAscendingOrder(t *testing.T) {
 	opts := DefaultOptions().WithMaxNodeSize(256).WithFlushThld(100)
 	tbtree, err := Open("test_tree_iasc", opts)
@@ -965,6 +1053,26 @@ func TestTBTreeInsertionInRandomOrder(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestTBTreeCachePolicies(t *testing.T) {
+	for _, policy := range []int{cache.LRUPolicy, cache.ARCPolicy} {
+		dir := fmt.Sprintf("test_tree_cache_policy_%d", policy)
+		defer os.RemoveAll(dir)
+
+		opts := DefaultOptions().WithMaxNodeSize(DefaultMaxNodeSize).WithCacheSize(100).WithCachePolicy(policy)
+
+		tbtree, err := Open(dir, opts)
+		require.NoError(t, err)
+		require.Equal(t, policy, tbtree.GetOptions().cachePolicy)
+
+		randomInsertions(t, tbtree, 1_000, true)
+
+		require.LessOrEqual(t, tbtree.cache.EntriesCount(), 100)
+
+		err = tbtree.Close()
+		require.NoError(t, err)
+	}
+}
+
 func TestRandomInsertionWithConcurrentReaderOrder(t *testing.T) {
 	opts := DefaultOptions().WithMaxNodeSize(DefaultMaxNodeSize).WithCacheSize(1000)
 	tbtree, err := Open("test_tree_c", opts)
@@ -1060,6 +1168,197 @@ func TestTBTreeReOpen(t *testing.T) {
 	})
 }
 
+func TestTBTreeReadOnly(t *testing.T) {
+	defer os.RemoveAll("test_tree_read_only")
+
+	_, err := Open("test_tree_read_only", DefaultOptions().WithReadOnly(true))
+	require.Error(t, err)
+	require.NoDirExists(t, "test_tree_read_only")
+
+	tbtree, err := Open("test_tree_read_only", DefaultOptions())
+	require.NoError(t, err)
+
+	err = tbtree.Insert([]byte("k0"), []byte("v0"))
+	require.NoError(t, err)
+
+	err = tbtree.Close()
+	require.NoError(t, err)
+
+	tbtree, err = Open("test_tree_read_only", DefaultOptions().WithReadOnly(true))
+	require.NoError(t, err)
+
+	v, _, _, err := tbtree.Get([]byte("k0"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("v0"), v)
+
+	err = tbtree.Insert([]byte("k1"), []byte("v1"))
+	require.ErrorIs(t, err, ErrReadOnly)
+
+	err = tbtree.IncreaseTs(tbtree.Ts() + 1)
+	require.ErrorIs(t, err, ErrReadOnly)
+
+	_, _, err = tbtree.Flush()
+	require.ErrorIs(t, err, ErrReadOnly)
+
+	err = tbtree.Sync()
+	require.ErrorIs(t, err, ErrReadOnly)
+
+	_, err = tbtree.Compact()
+	require.ErrorIs(t, err, ErrReadOnly)
+
+	err = tbtree.Close()
+	require.NoError(t, err)
+}
+
+func TestTBTreeMmapReads(t *testing.T) {
+	defer os.RemoveAll("test_tree_mmap")
+
+	opts := DefaultOptions().
+		WithCacheSize(10).
+		WithMmapReads(true)
+
+	tbtree, err := Open("test_tree_mmap", opts)
+	require.NoError(t, err)
+	require.True(t, tbtree.GetOptions().mmapReads)
+
+	insertKeys := func(from, to int) {
+		for i := from; i < to; i++ {
+			err = tbtree.Insert([]byte(fmt.Sprintf("key%06d", i)), []byte(fmt.Sprintf("value%06d", i)))
+			require.NoError(t, err)
+		}
+
+		_, _, err = tbtree.Flush()
+		require.NoError(t, err)
+	}
+
+	checkKeys := func(to int) {
+		for i := 0; i < to; i++ {
+			v, _, _, err := tbtree.Get([]byte(fmt.Sprintf("key%06d", i)))
+			require.NoError(t, err)
+			require.Equal(t, []byte(fmt.Sprintf("value%06d", i)), v)
+		}
+	}
+
+	insertKeys(0, 1000)
+	checkKeys(1000)
+
+	// nodes written after the nodes log was mapped should be read as well
+	insertKeys(1000, 2000)
+	checkKeys(2000)
+
+	err = tbtree.Close()
+	require.NoError(t, err)
+
+	tbtree, err = Open("test_tree_mmap", opts)
+	require.NoError(t, err)
+
+	checkKeys(2000)
+
+	err = tbtree.Close()
+	require.NoError(t, err)
+}
+
+func TestTBTreeOffHeapFlushBuffers(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tbtree_off_heap_flush_buffers")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	opts := DefaultOptions().
+		WithFlushBufferSize(1024 * 1024).
+		WithOffHeapFlushBuffers(true).
+		WithCompactionThld(1)
+
+	tbtree, err := Open(dir, opts)
+	require.NoError(t, err)
+	require.True(t, tbtree.GetOptions().offHeapFlushBuffers)
+
+	for i := 0; i < 1000; i++ {
+		err = tbtree.Insert([]byte(fmt.Sprintf("key%06d", i)), []byte(fmt.Sprintf("value%06d", i)))
+		require.NoError(t, err)
+	}
+
+	_, _, err = tbtree.Flush()
+	require.NoError(t, err)
+
+	// compaction writes a full dump through new flush buffers
+	_, err = tbtree.Compact()
+	require.NoError(t, err)
+
+	err = tbtree.Close()
+	require.NoError(t, err)
+
+	tbtree, err = Open(dir, opts)
+	require.NoError(t, err)
+
+	for i := 0; i < 1000; i++ {
+		v, _, _, err := tbtree.Get([]byte(fmt.Sprintf("key%06d", i)))
+		require.NoError(t, err)
+		require.Equal(t, []byte(fmt.Sprintf("value%06d", i)), v)
+	}
+
+	err = tbtree.Close()
+	require.NoError(t, err)
+}
+
+func TestTBTreeUpdateOptions(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tbtree_update_options")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	tbtree, err := Open(dir, DefaultOptions())
+	require.NoError(t, err)
+
+	err = tbtree.UpdateOptions(nil)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	err = tbtree.UpdateOptions(DefaultOptions().WithFlushThld(0))
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	for i := 0; i < 100; i++ {
+		err = tbtree.Insert([]byte(fmt.Sprintf("key%d", i)), []byte(fmt.Sprintf("value%d", i)))
+		require.NoError(t, err)
+	}
+
+	_, _, err = tbtree.Flush()
+	require.NoError(t, err)
+
+	opts := DefaultOptions().
+		WithFlushThld(10).
+		WithSyncThld(20).
+		WithCacheSize(MinCacheSize).
+		WithMaxActiveSnapshots(5).
+		WithCompactionThld(3).
+		WithNodesLogMaxOpenedFiles(2).
+		WithMaxKeyLen(DefaultMaxKeyLen / 2)
+
+	err = tbtree.UpdateOptions(opts)
+	require.NoError(t, err)
+
+	updatedOpts := tbtree.GetOptions()
+	require.Equal(t, 10, updatedOpts.flushThld)
+	require.Equal(t, 20, updatedOpts.syncThld)
+	require.Equal(t, MinCacheSize, updatedOpts.cacheSize)
+	require.Equal(t, 5, updatedOpts.maxActiveSnapshots)
+	require.Equal(t, 3, updatedOpts.compactionThld)
+	require.Equal(t, 2, updatedOpts.nodesLogMaxOpenedFiles)
+	require.Equal(t, MinCacheSize, tbtree.cache.Size())
+
+	// options which can not be changed while opened are kept
+	require.Equal(t, DefaultMaxKeyLen, updatedOpts.maxKeyLen)
+
+	for i := 0; i < 100; i++ {
+		v, _, _, err := tbtree.Get([]byte(fmt.Sprintf("key%d", i)))
+		require.NoError(t, err)
+		require.Equal(t, []byte(fmt.Sprintf("value%d", i)), v)
+	}
+
+	err = tbtree.Close()
+	require.NoError(t, err)
+
+	err = tbtree.UpdateOptions(opts)
+	require.ErrorIs(t, err, ErrAlreadyClosed)
+}
+
 func TestTBTreeSelfHealingHistory(t *testing.T) {
 	tbtree, err := Open("test_tree_self_healing_history", DefaultOptions())
 	require.NoError(t, err)
@@ -1349,3 +1648,55 @@ func BenchmarkRandomBulkInsertion(b *testing.B) {
 		tbtree.Close()
 	}
 }
+
+func TestTBTreeStats(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tbtree_stats")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	tree, err := Open(dir, DefaultOptions().WithFileSize(1024).WithCompactionThld(1))
+	require.NoError(t, err)
+
+	stats, err := tree.Stats()
+	require.NoError(t, err)
+	require.Zero(t, stats.SnapshotCount)
+	require.Zero(t, stats.NodesLogSize)
+	require.Zero(t, stats.NodesLogFiles)
+	require.Zero(t, stats.GarbageRatio())
+
+	for i := 0; i < 100; i++ {
+		err = tree.Insert([]byte(fmt.Sprintf("key%d", i)), []byte(fmt.Sprintf("value%d", i)))
+		require.NoError(t, err)
+	}
+
+	_, _, err = tree.Flush()
+	require.NoError(t, err)
+
+	stats, err = tree.Stats()
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), stats.SnapshotCount)
+	require.Positive(t, stats.NodesLogSize)
+	require.Equal(t, int(stats.NodesLogSize-1)/1024+1, stats.NodesLogFiles)
+	require.Zero(t, stats.StaleNodeSize)
+
+	// updating the same keys replaces every node written by the previous flush
+	for i := 0; i < 100; i++ {
+		err = tree.Insert([]byte(fmt.Sprintf("key%d", i)), []byte(fmt.Sprintf("value%d", i+1)))
+		require.NoError(t, err)
+	}
+
+	_, _, err = tree.Flush()
+	require.NoError(t, err)
+
+	updatedStats, err := tree.Stats()
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), updatedStats.SnapshotCount)
+	require.Equal(t, stats.NodesLogSize, updatedStats.StaleNodeSize)
+	require.InDelta(t, 0.5, updatedStats.GarbageRatio(), 0.1)
+
+	err = tree.Close()
+	require.NoError(t, err)
+
+	_, err = tree.Stats()
+	require.ErrorIs(t, err, ErrAlreadyClosed)
+}
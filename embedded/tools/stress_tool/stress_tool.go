@@ -375,7 +375,7 @@ func main() {
 							panic(err)
 						}
 
-						kv := &store.EntrySpec{Key: e.Key(), Value: val}
+						kv := &store.EntrySpec{Key: e.Key(), Metadata: e.Metadata(), Value: val}
 
 						verifies := htree.VerifyInclusion(proof, entrySpecDigest(kv), tx.Header().Eh)
 						if !verifies {
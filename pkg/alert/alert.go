@@ -0,0 +1,151 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package alert delivers tamper detection alerts to external systems such as webhooks,
+// mailboxes or incident management services.
+package alert
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+var ErrIllegalArguments = errors.New("illegal arguments")
+
+const (
+	DetectorAuditor = "auditor"
+	DetectorWitness = "witness"
+)
+
+// Alert reports a state found not to be consistent with a previously verified one
+type Alert struct {
+	// Detector is the component detecting the inconsistency
+	Detector string `json:"detector"`
+
+	ServerID      string `json:"server_id,omitempty"`
+	ServerAddress string `json:"server_address,omitempty"`
+	Database      string `json:"database"`
+
+	DetectedAt time.Time `json:"detected_at"`
+	Message    string    `json:"message"`
+
+	// PrevRoot is the previously verified root, CurrRoot is the root found inconsistent with it
+	PrevRoot *Root `json:"previous_root,omitempty"`
+	CurrRoot *Root `json:"current_root,omitempty"`
+
+	// Proof is the JSON encoded consistency proof between both roots, when one was provided
+	Proof json.RawMessage `json:"proof,omitempty"`
+}
+
+// Root identifies a state of a database, TxID is the size of the tree for external roots
+type Root struct {
+	TxID      uint64 `json:"tx_id"`
+	Hash      string `json:"hash"`
+	Signature string `json:"signature,omitempty"`
+	PublicKey string `json:"public_key,omitempty"`
+}
+
+// Summary returns a one line description of the alert
+func (a *Alert) Summary() string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "immudb %s detected tampering of database '%s'", a.Detector, a.Database)
+
+	if a.ServerAddress != "" {
+		fmt.Fprintf(&sb, " @ %s", a.ServerAddress)
+	}
+
+	if a.Message != "" {
+		fmt.Fprintf(&sb, ": %s", a.Message)
+	}
+
+	return sb.String()
+}
+
+// RootFromState returns the root of a database state
+func RootFromState(state *schema.ImmutableState) *Root {
+	if state == nil {
+		return nil
+	}
+
+	return newRoot(state.TxId, state.TxHash, state.Signature)
+}
+
+// RootFromExternalRoot returns the root of an external log
+func RootFromExternalRoot(root *schema.ExternalRoot) *Root {
+	if root == nil {
+		return nil
+	}
+
+	return newRoot(root.TreeSize, root.RootHash, root.Signature)
+}
+
+func newRoot(txID uint64, hash []byte, signature *schema.Signature) *Root {
+	root := &Root{
+		TxID: txID,
+		Hash: hex.EncodeToString(hash),
+	}
+
+	if signature != nil {
+		root.Signature = base64.StdEncoding.EncodeToString(signature.Signature)
+		root.PublicKey = base64.StdEncoding.EncodeToString(signature.PublicKey)
+	}
+
+	return root
+}
+
+// EncodeProof returns the JSON encoding of a consistency proof, nil if no proof is provided
+func EncodeProof(proof *schema.DualProof) (json.RawMessage, error) {
+	if proof == nil {
+		return nil, nil
+	}
+
+	return protojson.Marshal(proof)
+}
+
+// Sink delivers alerts
+type Sink interface {
+	Send(ctx context.Context, alert *Alert) error
+}
+
+// Sinks delivers alerts to all the sinks, every sink is attempted even when others fail
+type Sinks []Sink
+
+func (sinks Sinks) Send(ctx context.Context, alert *Alert) error {
+	var failed []string
+
+	for _, s := range sinks {
+		err := s.Send(ctx, alert)
+		if err != nil {
+			failed = append(failed, err.Error())
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("alert could not be delivered: %s", strings.Join(failed, "; "))
+	}
+
+	return nil
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/stretchr/testify/require"
+)
+
+type sinkMock struct {
+	alerts []*Alert
+	err    error
+}
+
+func (s *sinkMock) Send(ctx context.Context, alert *Alert) error {
+	s.alerts = append(s.alerts, alert)
+	return s.err
+}
+
+func testAlert() *Alert {
+	return &Alert{
+		Detector:      DetectorAuditor,
+		ServerID:      "server1",
+		ServerAddress: "127.0.0.1:3322",
+		Database:      "defaultdb",
+		DetectedAt:    time.Date(2021, 11, 1, 10, 0, 0, 0, time.UTC),
+		Message:       "consistency proof between tx 1 and tx 2 does not verify",
+		PrevRoot:      RootFromState(&schema.ImmutableState{TxId: 1, TxHash: []byte{1, 2}}),
+		CurrRoot: RootFromState(&schema.ImmutableState{
+			TxId:      2,
+			TxHash:    []byte{3, 4},
+			Signature: &schema.Signature{Signature: []byte{5}, PublicKey: []byte{6}},
+		}),
+	}
+}
+
+func TestAlert(t *testing.T) {
+	a := testAlert()
+
+	require.Equal(t,
+		"immudb auditor detected tampering of database 'defaultdb' @ 127.0.0.1:3322: consistency proof between tx 1 and tx 2 does not verify",
+		a.Summary())
+
+	require.Equal(t, &Root{TxID: 1, Hash: "0102"}, a.PrevRoot)
+	require.Equal(t, &Root{TxID: 2, Hash: "0304", Signature: "BQ==", PublicKey: "Bg=="}, a.CurrRoot)
+
+	require.Nil(t, RootFromState(nil))
+	require.Nil(t, RootFromExternalRoot(nil))
+	require.Equal(t, &Root{TxID: 7, Hash: "08"}, RootFromExternalRoot(&schema.ExternalRoot{TreeSize: 7, RootHash: []byte{8}}))
+
+	proof, err := EncodeProof(nil)
+	require.NoError(t, err)
+	require.Nil(t, proof)
+
+	a.Proof, err = EncodeProof(&schema.DualProof{SourceTxHeader: &schema.TxHeader{Id: 1}, TargetTxHeader: &schema.TxHeader{Id: 2}})
+	require.NoError(t, err)
+
+	b, err := json.Marshal(a)
+	require.NoError(t, err)
+
+	var decoded map[string]interface{}
+	err = json.Unmarshal(b, &decoded)
+	require.NoError(t, err)
+	require.Equal(t, "defaultdb", decoded["database"])
+	require.Equal(t, "0102", decoded["previous_root"].(map[string]interface{})["hash"])
+	require.Equal(t, "2", decoded["proof"].(map[string]interface{})["targetTxHeader"].(map[string]interface{})["id"])
+}
+
+func TestSinks(t *testing.T) {
+	s1 := &sinkMock{err: errors.New("sink1 failure")}
+	s2 := &sinkMock{}
+
+	a := testAlert()
+
+	err := Sinks{s1, s2}.Send(context.Background(), a)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "sink1 failure")
+
+	// failing sinks do not prevent others from being notified
+	require.Equal(t, []*Alert{a}, s1.alerts)
+	require.Equal(t, []*Alert{a}, s2.alerts)
+
+	err = Sinks{s2}.Send(context.Background(), a)
+	require.NoError(t, err)
+
+	err = Sinks(nil).Send(context.Background(), a)
+	require.NoError(t, err)
+}
@@ -0,0 +1,130 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package alert
+
+import (
+	"fmt"
+	"time"
+)
+
+const DefaultTimeout = 10 * time.Second
+
+// Options configures where alerts are delivered, no sink is configured by default
+type Options struct {
+	WebhookURL string
+
+	SMTPAddress  string
+	SMTPUsername string
+	SMTPPassword string `json:"-"`
+	SMTPFrom     string
+	SMTPTo       []string
+
+	PagerDutyRoutingKey string `json:"-"`
+	PagerDutyEventsURL  string
+
+	Timeout time.Duration
+}
+
+// DefaultOptions returns the default alert options
+func DefaultOptions() *Options {
+	return &Options{
+		PagerDutyEventsURL: DefaultPagerDutyEventsURL,
+		Timeout:            DefaultTimeout,
+	}
+}
+
+// WithWebhookURL sets the URL alerts are posted to
+func (o *Options) WithWebhookURL(webhookURL string) *Options {
+	o.WebhookURL = webhookURL
+	return o
+}
+
+// WithSMTPAddress sets the host:port address of the SMTP server used to mail alerts
+func (o *Options) WithSMTPAddress(smtpAddress string) *Options {
+	o.SMTPAddress = smtpAddress
+	return o
+}
+
+// WithSMTPUsername sets the username used to authenticate to the SMTP server
+func (o *Options) WithSMTPUsername(smtpUsername string) *Options {
+	o.SMTPUsername = smtpUsername
+	return o
+}
+
+// WithSMTPPassword sets the password used to authenticate to the SMTP server
+func (o *Options) WithSMTPPassword(smtpPassword string) *Options {
+	o.SMTPPassword = smtpPassword
+	return o
+}
+
+// WithSMTPFrom sets the sender address of alert mails
+func (o *Options) WithSMTPFrom(smtpFrom string) *Options {
+	o.SMTPFrom = smtpFrom
+	return o
+}
+
+// WithSMTPTo sets the recipient addresses of alert mails
+func (o *Options) WithSMTPTo(smtpTo []string) *Options {
+	o.SMTPTo = smtpTo
+	return o
+}
+
+// WithPagerDutyRoutingKey sets the integration key of the PagerDuty service alerts are sent to
+func (o *Options) WithPagerDutyRoutingKey(routingKey string) *Options {
+	o.PagerDutyRoutingKey = routingKey
+	return o
+}
+
+// WithPagerDutyEventsURL sets the URL of the PagerDuty Events API
+func (o *Options) WithPagerDutyEventsURL(eventsURL string) *Options {
+	o.PagerDutyEventsURL = eventsURL
+	return o
+}
+
+// WithTimeout sets the timeout of the requests delivering alerts
+func (o *Options) WithTimeout(timeout time.Duration) *Options {
+	o.Timeout = timeout
+	return o
+}
+
+// Sinks returns the configured sinks, nil if none is configured
+func (o *Options) Sinks() (Sinks, error) {
+	if o == nil {
+		return nil, nil
+	}
+
+	var sinks Sinks
+
+	if o.WebhookURL != "" {
+		sinks = append(sinks, NewWebhookSink(o.WebhookURL, o.Timeout))
+	}
+
+	if o.SMTPAddress != "" {
+		s, err := NewSMTPSink(o.SMTPAddress, o.SMTPUsername, o.SMTPPassword, o.SMTPFrom, o.SMTPTo)
+		if err != nil {
+			return nil, fmt.Errorf("invalid smtp alert settings: %w", err)
+		}
+
+		sinks = append(sinks, s)
+	}
+
+	if o.PagerDutyRoutingKey != "" {
+		sinks = append(sinks, NewPagerDutySink(o.PagerDutyEventsURL, o.PagerDutyRoutingKey, o.Timeout))
+	}
+
+	return sinks, nil
+}
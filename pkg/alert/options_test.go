@@ -0,0 +1,56 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package alert
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOptionsSinks(t *testing.T) {
+	var nilOpts *Options
+
+	sinks, err := nilOpts.Sinks()
+	require.NoError(t, err)
+	require.Empty(t, sinks)
+
+	sinks, err = DefaultOptions().Sinks()
+	require.NoError(t, err)
+	require.Empty(t, sinks)
+
+	opts := DefaultOptions().
+		WithWebhookURL("http://localhost/alerts").
+		WithSMTPAddress("localhost:25").
+		WithSMTPUsername("user").
+		WithSMTPPassword("pass").
+		WithSMTPFrom("immudb@localhost").
+		WithSMTPTo([]string{"ops@localhost"}).
+		WithPagerDutyRoutingKey("routing-key").
+		WithPagerDutyEventsURL("http://localhost/pagerduty").
+		WithTimeout(DefaultTimeout)
+
+	sinks, err = opts.Sinks()
+	require.NoError(t, err)
+	require.Len(t, sinks, 3)
+	require.IsType(t, &webhookSink{}, sinks[0])
+	require.IsType(t, &smtpSink{}, sinks[1])
+	require.IsType(t, &pagerDutySink{}, sinks[2])
+
+	_, err = opts.WithSMTPFrom("").Sinks()
+	require.ErrorIs(t, err, ErrIllegalArguments)
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+const DefaultPagerDutyEventsURL = "https://events.pagerduty.com/v2/enqueue"
+
+// pagerDutySink triggers PagerDuty incidents through the Events API v2
+type pagerDutySink struct {
+	eventsURL   string
+	routingKey  string
+	publishFunc func(*http.Request) (*http.Response, error)
+}
+
+type pagerDutyEvent struct {
+	RoutingKey  string           `json:"routing_key"`
+	EventAction string           `json:"event_action"`
+	DedupKey    string           `json:"dedup_key,omitempty"`
+	Payload     pagerDutyPayload `json:"payload"`
+}
+
+type pagerDutyPayload struct {
+	Summary       string    `json:"summary"`
+	Source        string    `json:"source"`
+	Severity      string    `json:"severity"`
+	Timestamp     time.Time `json:"timestamp"`
+	Component     string    `json:"component,omitempty"`
+	CustomDetails *Alert    `json:"custom_details"`
+}
+
+// NewPagerDutySink returns a sink triggering a critical PagerDuty event for every alert.
+// Events of the same database are grouped into the same incident
+func NewPagerDutySink(eventsURL, routingKey string, timeout time.Duration) Sink {
+	httpClient := &http.Client{Timeout: timeout}
+
+	return &pagerDutySink{
+		eventsURL:   eventsURL,
+		routingKey:  routingKey,
+		publishFunc: httpClient.Do,
+	}
+}
+
+func (s *pagerDutySink) Send(ctx context.Context, alert *Alert) error {
+	source := alert.ServerAddress
+	if source == "" {
+		source = "immudb"
+	}
+
+	event := pagerDutyEvent{
+		RoutingKey:  s.routingKey,
+		EventAction: "trigger",
+		DedupKey:    "immudb-tampering-" + alert.ServerID + "-" + alert.Database,
+		Payload: pagerDutyPayload{
+			Summary:       alert.Summary(),
+			Source:        source,
+			Severity:      "critical",
+			Timestamp:     alert.DetectedAt,
+			Component:     alert.Database,
+			CustomDetails: alert,
+		},
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	return postJSON(ctx, s.publishFunc, s.eventsURL, payload)
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newAlertReceiver(t *testing.T, status *int) (*httptest.Server, *[][]byte) {
+	var received [][]byte
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "application/json", r.Header.Get("Content-Type"))
+
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		received = append(received, body)
+
+		w.WriteHeader(*status)
+		w.Write([]byte("response body"))
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, &received
+}
+
+func TestWebhookSink(t *testing.T) {
+	status := http.StatusOK
+	srv, received := newAlertReceiver(t, &status)
+
+	s := NewWebhookSink(srv.URL, time.Second)
+
+	a := testAlert()
+
+	err := s.Send(context.Background(), a)
+	require.NoError(t, err)
+	require.Len(t, *received, 1)
+
+	var decoded Alert
+	err = json.Unmarshal((*received)[0], &decoded)
+	require.NoError(t, err)
+	require.Equal(t, a.Database, decoded.Database)
+	require.Equal(t, a.PrevRoot, decoded.PrevRoot)
+	require.Equal(t, a.CurrRoot, decoded.CurrRoot)
+
+	status = http.StatusBadRequest
+
+	err = s.Send(context.Background(), a)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "got unexpected response status 400 Bad Request with response body response body")
+
+	err = NewWebhookSink(string([]byte{0}), time.Second).Send(context.Background(), a)
+	require.Error(t, err)
+}
+
+func TestPagerDutySink(t *testing.T) {
+	status := http.StatusAccepted
+	srv, received := newAlertReceiver(t, &status)
+
+	s := NewPagerDutySink(srv.URL, "routing-key", time.Second)
+
+	a := testAlert()
+
+	err := s.Send(context.Background(), a)
+	require.NoError(t, err)
+	require.Len(t, *received, 1)
+
+	var event pagerDutyEvent
+	err = json.Unmarshal((*received)[0], &event)
+	require.NoError(t, err)
+	require.Equal(t, "routing-key", event.RoutingKey)
+	require.Equal(t, "trigger", event.EventAction)
+	require.Equal(t, "immudb-tampering-server1-defaultdb", event.DedupKey)
+	require.Equal(t, "critical", event.Payload.Severity)
+	require.Equal(t, a.Summary(), event.Payload.Summary)
+	require.Equal(t, a.ServerAddress, event.Payload.Source)
+	require.Equal(t, a.CurrRoot, event.Payload.CustomDetails.CurrRoot)
+}
+
+func TestSMTPSink(t *testing.T) {
+	_, err := NewSMTPSink("localhost", "", "", "immudb@localhost", []string{"ops@localhost"})
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = NewSMTPSink("localhost:25", "", "", "", []string{"ops@localhost"})
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = NewSMTPSink("localhost:25", "", "", "immudb@localhost", nil)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	s, err := NewSMTPSink("localhost:25", "user", "pass", "immudb@localhost", []string{"ops@localhost", "sec@localhost"})
+	require.NoError(t, err)
+
+	var sent string
+
+	s.(*smtpSink).sendMail = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		require.Equal(t, "localhost:25", addr)
+		require.NotNil(t, a)
+		require.Equal(t, "immudb@localhost", from)
+		require.Equal(t, []string{"ops@localhost", "sec@localhost"}, to)
+
+		sent = string(msg)
+		return nil
+	}
+
+	a := testAlert()
+
+	err = s.Send(context.Background(), a)
+	require.NoError(t, err)
+	require.Contains(t, sent, "To: ops@localhost, sec@localhost\r\n")
+	require.Contains(t, sent, "Subject: [immudb] tampering detected on database defaultdb\r\n")
+	require.Contains(t, sent, a.Summary())
+	require.True(t, strings.Contains(sent, `"hash": "0304"`))
+}
@@ -0,0 +1,78 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// smtpSink mails alerts through an SMTP server
+type smtpSink struct {
+	address  string
+	auth     smtp.Auth
+	from     string
+	to       []string
+	sendMail func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+// NewSMTPSink returns a sink mailing every alert to the to addresses through the SMTP server at address.
+// Plain authentication is used when a username is provided
+func NewSMTPSink(address, username, password, from string, to []string) (Sink, error) {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil || from == "" || len(to) == 0 {
+		return nil, ErrIllegalArguments
+	}
+
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	return &smtpSink{
+		address:  address,
+		auth:     auth,
+		from:     from,
+		to:       to,
+		sendMail: smtp.SendMail,
+	}, nil
+}
+
+func (s *smtpSink) Send(ctx context.Context, alert *Alert) error {
+	details, err := json.MarshalIndent(alert, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	var msg strings.Builder
+
+	fmt.Fprintf(&msg, "From: %s\r\n", s.from)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(s.to, ", "))
+	fmt.Fprintf(&msg, "Subject: [immudb] tampering detected on database %s\r\n", alert.Database)
+	fmt.Fprintf(&msg, "Date: %s\r\n", alert.DetectedAt.Format(time.RFC1123Z))
+	fmt.Fprintf(&msg, "Content-Type: text/plain; charset=UTF-8\r\n")
+	fmt.Fprintf(&msg, "\r\n")
+	fmt.Fprintf(&msg, "%s\r\n\r\n", alert.Summary())
+	fmt.Fprintf(&msg, "%s\r\n", strings.Replace(string(details), "\n", "\r\n", -1))
+
+	return s.sendMail(s.address, s.auth, s.from, s.to, []byte(msg.String()))
+}
@@ -0,0 +1,74 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package alert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+// webhookSink posts alerts as JSON payloads
+type webhookSink struct {
+	url         string
+	publishFunc func(*http.Request) (*http.Response, error)
+}
+
+// NewWebhookSink returns a sink posting every alert, JSON encoded, to url
+func NewWebhookSink(url string, timeout time.Duration) Sink {
+	httpClient := &http.Client{Timeout: timeout}
+
+	return &webhookSink{
+		url:         url,
+		publishFunc: httpClient.Do,
+	}
+}
+
+func (s *webhookSink) Send(ctx context.Context, alert *Alert) error {
+	payload, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+
+	return postJSON(ctx, s.publishFunc, s.url, payload)
+}
+
+func postJSON(ctx context.Context, publishFunc func(*http.Request) (*http.Response, error), url string, payload []byte) error {
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(payload))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := publishFunc(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		respBody, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("POST %s: got unexpected response status %s with response body %s", url, resp.Status, respBody)
+	}
+
+	return nil
+}
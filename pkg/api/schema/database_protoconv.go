@@ -55,6 +55,7 @@ func KVMetadataToProto(md *store.KVMetadata) *KVMetadata {
 	kvmd := &KVMetadata{
 		Deleted:      md.Deleted(),
 		NonIndexable: md.NonIndexable(),
+		Compression:  int32(md.Compression()),
 	}
 
 	if md.IsExpirable() {
@@ -104,6 +105,8 @@ func KVMetadataFromProto(md *KVMetadata) *store.KVMetadata {
 
 	kvmd.AsNonIndexable(md.NonIndexable)
 
+	kvmd.AsCompressed(int(md.Compression))
+
 	return kvmd
 }
 
@@ -158,7 +161,9 @@ func TxMetadataToProto(md *store.TxMetadata) *TxMetadata {
 		return nil
 	}
 
-	return &TxMetadata{}
+	return &TxMetadata{
+		User: md.User(),
+	}
 }
 
 func LinearProofToProto(linearProof *store.LinearProof) *LinearProof {
@@ -200,7 +205,12 @@ func TxMetadataFromProto(md *TxMetadata) *store.TxMetadata {
 		return nil
 	}
 
-	return &store.TxMetadata{}
+	txmd := store.NewTxMetadata()
+
+	// users exceeding the maximum length can not be committed, they are left out thus the header fails to be verified
+	_ = txmd.SetUser(md.User)
+
+	return txmd
 }
 
 func LinearProofFromProto(lproof *LinearProof) *store.LinearProof {
@@ -0,0 +1,69 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package schema
+
+import "time"
+
+// NewKVMetadata returns entry metadata without attributes, which are set with chained calls e.g.
+//
+//	md := schema.NewKVMetadata().AsNonIndexable().WithExpiration(t)
+func NewKVMetadata() *KVMetadata {
+	return &KVMetadata{}
+}
+
+// AsDeleted marks the entry as a deletion of its key
+func (md *KVMetadata) AsDeleted() *KVMetadata {
+	md.Deleted = true
+	return md
+}
+
+// WithExpiration makes the entry to be no longer readable once the given time is reached,
+// expiration times are stored with a precision of seconds
+func (md *KVMetadata) WithExpiration(expiresAt time.Time) *KVMetadata {
+	md.Expiration = &Expiration{ExpiresAt: expiresAt.Unix()}
+	return md
+}
+
+// WithTTL makes the entry to be no longer readable once the given duration has elapsed
+func (md *KVMetadata) WithTTL(ttl time.Duration) *KVMetadata {
+	return md.WithExpiration(time.Now().Add(ttl))
+}
+
+// AsNonIndexable makes the entry to be left out of the index, it's only readable through its transaction
+func (md *KVMetadata) AsNonIndexable() *KVMetadata {
+	md.NonIndexable = true
+	return md
+}
+
+// ExpirationTime returns the time the entry expires at, if it's expirable
+func (md *KVMetadata) ExpirationTime() (expiresAt time.Time, expirable bool) {
+	if md.GetExpiration() == nil {
+		return time.Time{}, false
+	}
+
+	return time.Unix(md.Expiration.ExpiresAt, 0), true
+}
+
+// NewTxMetadata returns transaction metadata without attributes, which are set with chained calls
+func NewTxMetadata() *TxMetadata {
+	return &TxMetadata{}
+}
+
+// WithUser attributes the transaction to the given user
+func (md *TxMetadata) WithUser(user string) *TxMetadata {
+	md.User = user
+	return md
+}
@@ -0,0 +1,54 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package schema
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestKVMetadataBuilder(t *testing.T) {
+	md := NewKVMetadata()
+
+	_, expirable := md.ExpirationTime()
+	require.False(t, expirable)
+
+	expiresAt := time.Unix(time.Now().Add(time.Hour).Unix(), 0)
+
+	md.AsDeleted().AsNonIndexable().WithExpiration(expiresAt)
+
+	expTime, expirable := md.ExpirationTime()
+	require.True(t, expirable)
+	require.Equal(t, expiresAt, expTime)
+
+	kvmd := KVMetadataFromProto(md)
+	require.True(t, kvmd.Deleted())
+	require.True(t, kvmd.NonIndexable())
+	require.True(t, kvmd.ExpiredAt(expiresAt))
+	require.False(t, kvmd.ExpiredAt(expiresAt.Add(-time.Second)))
+
+	require.Equal(t, md, KVMetadataToProto(kvmd))
+
+	md = NewKVMetadata().WithTTL(time.Hour)
+	require.False(t, KVMetadataFromProto(md).ExpiredAt(time.Now()))
+}
+
+func TestTxMetadataBuilder(t *testing.T) {
+	md := NewTxMetadata().WithUser("alice")
+	require.Equal(t, "alice", TxMetadataFromProto(md).User())
+}
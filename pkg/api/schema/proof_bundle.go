@@ -0,0 +1,103 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schema
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// ProofBundleVersion is the version of the proof bundles currently produced
+const ProofBundleVersion = 1
+
+var ErrInvalidProofBundle = errors.New("invalid proof bundle")
+
+// ToBytes encodes the bundle in its canonical binary format, the deterministic protobuf encoding
+// of the ProofBundle message
+func (b *ProofBundle) ToBytes() ([]byte, error) {
+	return proto.MarshalOptions{Deterministic: true}.Marshal(b)
+}
+
+// ToJSON encodes the bundle following the protobuf JSON mapping, bytes are base64 encoded.
+// The JSON encoding is meant to be portable, not canonical: whitespaces may differ between encodings
+func (b *ProofBundle) ToJSON() ([]byte, error) {
+	return protojson.MarshalOptions{Multiline: true, Indent: "  "}.Marshal(b)
+}
+
+// ParseProofBundle decodes a bundle encoded either with ToBytes or ToJSON.
+// Only the bundle structure is checked, proofs must be verified separately
+func ParseProofBundle(data []byte) (*ProofBundle, error) {
+	b := &ProofBundle{}
+
+	var err error
+
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		err = protojson.Unmarshal(trimmed, b)
+	} else {
+		err = proto.Unmarshal(data, b)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidProofBundle, err)
+	}
+
+	err = b.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// Validate checks the bundle holds all the fields required by its version and type
+func (b *ProofBundle) Validate() error {
+	if b == nil {
+		return fmt.Errorf("%w: no bundle provided", ErrInvalidProofBundle)
+	}
+
+	if b.Version != ProofBundleVersion {
+		return fmt.Errorf("%w: unsupported version %d", ErrInvalidProofBundle, b.Version)
+	}
+
+	if b.State == nil {
+		return fmt.Errorf("%w: missing state", ErrInvalidProofBundle)
+	}
+
+	if b.VerifiableTx == nil || b.VerifiableTx.Tx == nil || b.VerifiableTx.Tx.Header == nil ||
+		b.VerifiableTx.DualProof == nil || b.VerifiableTx.DualProof.SourceTxHeader == nil || b.VerifiableTx.DualProof.TargetTxHeader == nil {
+		return fmt.Errorf("%w: missing verifiable tx", ErrInvalidProofBundle)
+	}
+
+	switch b.Type {
+	case ProofType_TX_INCLUSION:
+	case ProofType_ENTRY_INCLUSION:
+		if b.Entry == nil || b.InclusionProof == nil {
+			return fmt.Errorf("%w: missing entry or inclusion proof", ErrInvalidProofBundle)
+		}
+	case ProofType_CONSISTENCY:
+		if b.SourceState == nil {
+			return fmt.Errorf("%w: missing source state", ErrInvalidProofBundle)
+		}
+	default:
+		return fmt.Errorf("%w: unsupported type %d", ErrInvalidProofBundle, b.Type)
+	}
+
+	return nil
+}
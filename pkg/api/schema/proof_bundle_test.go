@@ -0,0 +1,101 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestProofBundleEncoding(t *testing.T) {
+	bundle := &ProofBundle{
+		Version: ProofBundleVersion,
+		Type:    ProofType_ENTRY_INCLUSION,
+		State: &ImmutableState{
+			Db:     "defaultdb",
+			TxId:   2,
+			TxHash: make([]byte, 32),
+		},
+		VerifiableTx: &VerifiableTx{
+			Tx: &Tx{Header: &TxHeader{Id: 1}},
+			DualProof: &DualProof{
+				SourceTxHeader: &TxHeader{Id: 1},
+				TargetTxHeader: &TxHeader{Id: 2},
+			},
+		},
+		Entry:          &Entry{Tx: 1, Key: []byte("key1"), Value: []byte("value1")},
+		InclusionProof: &InclusionProof{Leaf: 0, Width: 1},
+	}
+
+	bs, err := bundle.ToBytes()
+	require.NoError(t, err)
+
+	bs2, err := bundle.ToBytes()
+	require.NoError(t, err)
+	require.Equal(t, bs, bs2)
+
+	decoded, err := ParseProofBundle(bs)
+	require.NoError(t, err)
+	require.True(t, proto.Equal(bundle, decoded))
+
+	js, err := bundle.ToJSON()
+	require.NoError(t, err)
+
+	decoded, err = ParseProofBundle(js)
+	require.NoError(t, err)
+	require.True(t, proto.Equal(bundle, decoded))
+
+	_, err = ParseProofBundle([]byte("{not json"))
+	require.ErrorIs(t, err, ErrInvalidProofBundle)
+}
+
+func TestProofBundleValidate(t *testing.T) {
+	var nilBundle *ProofBundle
+	require.ErrorIs(t, nilBundle.Validate(), ErrInvalidProofBundle)
+
+	bundle := &ProofBundle{Version: ProofBundleVersion + 1}
+	require.ErrorIs(t, bundle.Validate(), ErrInvalidProofBundle)
+
+	bundle.Version = ProofBundleVersion
+	require.ErrorIs(t, bundle.Validate(), ErrInvalidProofBundle)
+
+	bundle.State = &ImmutableState{Db: "defaultdb", TxId: 1}
+	require.ErrorIs(t, bundle.Validate(), ErrInvalidProofBundle)
+
+	bundle.VerifiableTx = &VerifiableTx{
+		Tx: &Tx{Header: &TxHeader{Id: 1}},
+		DualProof: &DualProof{
+			SourceTxHeader: &TxHeader{Id: 1},
+			TargetTxHeader: &TxHeader{Id: 1},
+		},
+	}
+	require.NoError(t, bundle.Validate())
+
+	bundle.Type = ProofType_ENTRY_INCLUSION
+	require.ErrorIs(t, bundle.Validate(), ErrInvalidProofBundle)
+
+	bundle.Type = ProofType_CONSISTENCY
+	require.ErrorIs(t, bundle.Validate(), ErrInvalidProofBundle)
+
+	bundle.SourceState = &ImmutableState{Db: "defaultdb", TxId: 1}
+	require.NoError(t, bundle.Validate())
+
+	bundle.Type = ProofType(99)
+	require.ErrorIs(t, bundle.Validate(), ErrInvalidProofBundle)
+}
@@ -43,6 +43,58 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+type KeyStatus int32
+
+const (
+	KeyStatus_KEY_FOUND     KeyStatus = 0
+	KeyStatus_KEY_NOT_FOUND KeyStatus = 1
+	KeyStatus_KEY_EXPIRED   KeyStatus = 2
+	KeyStatus_KEY_DELETED   KeyStatus = 3
+)
+
+// Enum value maps for KeyStatus.
+var (
+	KeyStatus_name = map[int32]string{
+		0: "KEY_FOUND",
+		1: "KEY_NOT_FOUND",
+		2: "KEY_EXPIRED",
+		3: "KEY_DELETED",
+	}
+	KeyStatus_value = map[string]int32{
+		"KEY_FOUND":     0,
+		"KEY_NOT_FOUND": 1,
+		"KEY_EXPIRED":   2,
+		"KEY_DELETED":   3,
+	}
+)
+
+func (x KeyStatus) Enum() *KeyStatus {
+	p := new(KeyStatus)
+	*p = x
+	return p
+}
+
+func (x KeyStatus) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (KeyStatus) Descriptor() protoreflect.EnumDescriptor {
+	return file_schema_proto_enumTypes[0].Descriptor()
+}
+
+func (KeyStatus) Type() protoreflect.EnumType {
+	return &file_schema_proto_enumTypes[0]
+}
+
+func (x KeyStatus) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use KeyStatus.Descriptor instead.
+func (KeyStatus) EnumDescriptor() ([]byte, []int) {
+	return file_schema_proto_rawDescGZIP(), []int{0}
+}
+
 type EntryTypeAction int32
 
 const (
@@ -79,11 +131,11 @@ func (x EntryTypeAction) String() string {
 }
 
 func (EntryTypeAction) Descriptor() protoreflect.EnumDescriptor {
-	return file_schema_proto_enumTypes[0].Descriptor()
+	return file_schema_proto_enumTypes[1].Descriptor()
 }
 
 func (EntryTypeAction) Type() protoreflect.EnumType {
-	return &file_schema_proto_enumTypes[0]
+	return &file_schema_proto_enumTypes[1]
 }
 
 func (x EntryTypeAction) Number() protoreflect.EnumNumber {
@@ -92,7 +144,7 @@ func (x EntryTypeAction) Number() protoreflect.EnumNumber {
 
 // Deprecated: Use EntryTypeAction.Descriptor instead.
 func (EntryTypeAction) EnumDescriptor() ([]byte, []int) {
-	return file_schema_proto_rawDescGZIP(), []int{0}
+	return file_schema_proto_rawDescGZIP(), []int{1}
 }
 
 type PermissionAction int32
@@ -125,11 +177,11 @@ func (x PermissionAction) String() string {
 }
 
 func (PermissionAction) Descriptor() protoreflect.EnumDescriptor {
-	return file_schema_proto_enumTypes[1].Descriptor()
+	return file_schema_proto_enumTypes[2].Descriptor()
 }
 
 func (PermissionAction) Type() protoreflect.EnumType {
-	return &file_schema_proto_enumTypes[1]
+	return &file_schema_proto_enumTypes[2]
 }
 
 func (x PermissionAction) Number() protoreflect.EnumNumber {
@@ -138,7 +190,7 @@ func (x PermissionAction) Number() protoreflect.EnumNumber {
 
 // Deprecated: Use PermissionAction.Descriptor instead.
 func (PermissionAction) EnumDescriptor() ([]byte, []int) {
-	return file_schema_proto_rawDescGZIP(), []int{1}
+	return file_schema_proto_rawDescGZIP(), []int{2}
 }
 
 type TxMode int32
@@ -174,11 +226,11 @@ func (x TxMode) String() string {
 }
 
 func (TxMode) Descriptor() protoreflect.EnumDescriptor {
-	return file_schema_proto_enumTypes[2].Descriptor()
+	return file_schema_proto_enumTypes[3].Descriptor()
 }
 
 func (TxMode) Type() protoreflect.EnumType {
-	return &file_schema_proto_enumTypes[2]
+	return &file_schema_proto_enumTypes[3]
 }
 
 func (x TxMode) Number() protoreflect.EnumNumber {
@@ -187,7 +239,203 @@ func (x TxMode) Number() protoreflect.EnumNumber {
 
 // Deprecated: Use TxMode.Descriptor instead.
 func (TxMode) EnumDescriptor() ([]byte, []int) {
-	return file_schema_proto_rawDescGZIP(), []int{2}
+	return file_schema_proto_rawDescGZIP(), []int{3}
+}
+
+type ExternalRootType int32
+
+const (
+	ExternalRootType_GENERIC ExternalRootType = 0
+	ExternalRootType_IMMUDB  ExternalRootType = 1
+	ExternalRootType_CT_LOG  ExternalRootType = 2
+)
+
+// Enum value maps for ExternalRootType.
+var (
+	ExternalRootType_name = map[int32]string{
+		0: "GENERIC",
+		1: "IMMUDB",
+		2: "CT_LOG",
+	}
+	ExternalRootType_value = map[string]int32{
+		"GENERIC": 0,
+		"IMMUDB":  1,
+		"CT_LOG":  2,
+	}
+)
+
+func (x ExternalRootType) Enum() *ExternalRootType {
+	p := new(ExternalRootType)
+	*p = x
+	return p
+}
+
+func (x ExternalRootType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ExternalRootType) Descriptor() protoreflect.EnumDescriptor {
+	return file_schema_proto_enumTypes[4].Descriptor()
+}
+
+func (ExternalRootType) Type() protoreflect.EnumType {
+	return &file_schema_proto_enumTypes[4]
+}
+
+func (x ExternalRootType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ExternalRootType.Descriptor instead.
+func (ExternalRootType) EnumDescriptor() ([]byte, []int) {
+	return file_schema_proto_rawDescGZIP(), []int{4}
+}
+
+type KVSchemaType int32
+
+const (
+	KVSchemaType_JSON_SCHEMA KVSchemaType = 0
+	KVSchemaType_PROTOBUF    KVSchemaType = 1
+)
+
+// Enum value maps for KVSchemaType.
+var (
+	KVSchemaType_name = map[int32]string{
+		0: "JSON_SCHEMA",
+		1: "PROTOBUF",
+	}
+	KVSchemaType_value = map[string]int32{
+		"JSON_SCHEMA": 0,
+		"PROTOBUF":    1,
+	}
+)
+
+func (x KVSchemaType) Enum() *KVSchemaType {
+	p := new(KVSchemaType)
+	*p = x
+	return p
+}
+
+func (x KVSchemaType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (KVSchemaType) Descriptor() protoreflect.EnumDescriptor {
+	return file_schema_proto_enumTypes[5].Descriptor()
+}
+
+func (KVSchemaType) Type() protoreflect.EnumType {
+	return &file_schema_proto_enumTypes[5]
+}
+
+func (x KVSchemaType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use KVSchemaType.Descriptor instead.
+func (KVSchemaType) EnumDescriptor() ([]byte, []int) {
+	return file_schema_proto_rawDescGZIP(), []int{5}
+}
+
+type RedactionMode int32
+
+const (
+	// the value is replaced by its sha256 digest
+	RedactionMode_REDACT_HASH RedactionMode = 0
+	// all but the last visibleChars characters of the value are masked
+	RedactionMode_REDACT_PARTIAL RedactionMode = 1
+	// the value is omitted
+	RedactionMode_REDACT_FULL RedactionMode = 2
+)
+
+// Enum value maps for RedactionMode.
+var (
+	RedactionMode_name = map[int32]string{
+		0: "REDACT_HASH",
+		1: "REDACT_PARTIAL",
+		2: "REDACT_FULL",
+	}
+	RedactionMode_value = map[string]int32{
+		"REDACT_HASH":    0,
+		"REDACT_PARTIAL": 1,
+		"REDACT_FULL":    2,
+	}
+)
+
+func (x RedactionMode) Enum() *RedactionMode {
+	p := new(RedactionMode)
+	*p = x
+	return p
+}
+
+func (x RedactionMode) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (RedactionMode) Descriptor() protoreflect.EnumDescriptor {
+	return file_schema_proto_enumTypes[6].Descriptor()
+}
+
+func (RedactionMode) Type() protoreflect.EnumType {
+	return &file_schema_proto_enumTypes[6]
+}
+
+func (x RedactionMode) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use RedactionMode.Descriptor instead.
+func (RedactionMode) EnumDescriptor() ([]byte, []int) {
+	return file_schema_proto_rawDescGZIP(), []int{6}
+}
+
+type ProofType int32
+
+const (
+	ProofType_TX_INCLUSION    ProofType = 0
+	ProofType_ENTRY_INCLUSION ProofType = 1
+	ProofType_CONSISTENCY     ProofType = 2
+)
+
+// Enum value maps for ProofType.
+var (
+	ProofType_name = map[int32]string{
+		0: "TX_INCLUSION",
+		1: "ENTRY_INCLUSION",
+		2: "CONSISTENCY",
+	}
+	ProofType_value = map[string]int32{
+		"TX_INCLUSION":    0,
+		"ENTRY_INCLUSION": 1,
+		"CONSISTENCY":     2,
+	}
+)
+
+func (x ProofType) Enum() *ProofType {
+	p := new(ProofType)
+	*p = x
+	return p
+}
+
+func (x ProofType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ProofType) Descriptor() protoreflect.EnumDescriptor {
+	return file_schema_proto_enumTypes[7].Descriptor()
+}
+
+func (ProofType) Type() protoreflect.EnumType {
+	return &file_schema_proto_enumTypes[7]
+}
+
+func (x ProofType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ProofType.Descriptor instead.
+func (ProofType) EnumDescriptor() ([]byte, []int) {
+	return file_schema_proto_rawDescGZIP(), []int{7}
 }
 
 type Key struct {
@@ -811,6 +1059,12 @@ type OpenSessionRequest struct {
 	Username     []byte `protobuf:"bytes,1,opt,name=username,proto3" json:"username,omitempty"`
 	Password     []byte `protobuf:"bytes,2,opt,name=password,proto3" json:"password,omitempty"`
 	DatabaseName string `protobuf:"bytes,3,opt,name=databaseName,proto3" json:"databaseName,omitempty"`
+	// Get, GetAll and Scan wait for the last tx committed within the session to be indexed, even when noWait is set, instead of the latest tx
+	ReadYourWrites bool `protobuf:"varint,4,opt,name=readYourWrites,proto3" json:"readYourWrites,omitempty"`
+	// max time in milliseconds reads wait for the txs committed within the session to be indexed, a server default is used when 0
+	ReadYourWritesTimeout uint32 `protobuf:"varint,5,opt,name=readYourWritesTimeout,proto3" json:"readYourWritesTimeout,omitempty"`
+	// custom session attributes SQL policies are evaluated with, e.g. USING (tenant = @tenant)
+	SessionVars map[string]string `protobuf:"bytes,6,rep,name=sessionVars,proto3" json:"sessionVars,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
 }
 
 func (x *OpenSessionRequest) Reset() {
@@ -866,6 +1120,27 @@ func (x *OpenSessionRequest) GetDatabaseName() string {
 	return ""
 }
 
+func (x *OpenSessionRequest) GetReadYourWrites() bool {
+	if x != nil {
+		return x.ReadYourWrites
+	}
+	return false
+}
+
+func (x *OpenSessionRequest) GetReadYourWritesTimeout() uint32 {
+	if x != nil {
+		return x.ReadYourWritesTimeout
+	}
+	return 0
+}
+
+func (x *OpenSessionRequest) GetSessionVars() map[string]string {
+	if x != nil {
+		return x.SessionVars
+	}
+	return nil
+}
+
 type OpenSessionResponse struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -1291,16 +1566,20 @@ func (x *ExecAllRequest) GetNoWait() bool {
 	return false
 }
 
-type Entries struct {
+type ExecScriptRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Entries []*Entry `protobuf:"bytes,1,rep,name=entries,proto3" json:"entries,omitempty"`
+	// Lua source of the script, see the embedded/script package for the functions available
+	Script string `protobuf:"bytes,1,opt,name=script,proto3" json:"script,omitempty"`
+	// parameters available through the params table of the script
+	Params []*NamedParam `protobuf:"bytes,2,rep,name=params,proto3" json:"params,omitempty"`
+	NoWait bool          `protobuf:"varint,3,opt,name=noWait,proto3" json:"noWait,omitempty"`
 }
 
-func (x *Entries) Reset() {
-	*x = Entries{}
+func (x *ExecScriptRequest) Reset() {
+	*x = ExecScriptRequest{}
 	if protoimpl.UnsafeEnabled {
 		mi := &file_schema_proto_msgTypes[18]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -1308,13 +1587,13 @@ func (x *Entries) Reset() {
 	}
 }
 
-func (x *Entries) String() string {
+func (x *ExecScriptRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Entries) ProtoMessage() {}
+func (*ExecScriptRequest) ProtoMessage() {}
 
-func (x *Entries) ProtoReflect() protoreflect.Message {
+func (x *ExecScriptRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_schema_proto_msgTypes[18]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -1326,32 +1605,45 @@ func (x *Entries) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Entries.ProtoReflect.Descriptor instead.
-func (*Entries) Descriptor() ([]byte, []int) {
+// Deprecated: Use ExecScriptRequest.ProtoReflect.Descriptor instead.
+func (*ExecScriptRequest) Descriptor() ([]byte, []int) {
 	return file_schema_proto_rawDescGZIP(), []int{18}
 }
 
-func (x *Entries) GetEntries() []*Entry {
+func (x *ExecScriptRequest) GetScript() string {
 	if x != nil {
-		return x.Entries
+		return x.Script
+	}
+	return ""
+}
+
+func (x *ExecScriptRequest) GetParams() []*NamedParam {
+	if x != nil {
+		return x.Params
 	}
 	return nil
 }
 
-type ZEntry struct {
+func (x *ExecScriptRequest) GetNoWait() bool {
+	if x != nil {
+		return x.NoWait
+	}
+	return false
+}
+
+type ExecScriptResult struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Set   []byte  `protobuf:"bytes,1,opt,name=set,proto3" json:"set,omitempty"`
-	Key   []byte  `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
-	Entry *Entry  `protobuf:"bytes,3,opt,name=entry,proto3" json:"entry,omitempty"`
-	Score float64 `protobuf:"fixed64,4,opt,name=score,proto3" json:"score,omitempty"`
-	AtTx  uint64  `protobuf:"varint,5,opt,name=atTx,proto3" json:"atTx,omitempty"`
+	// header of the transaction the writes of the script were committed in, not set when nothing was written
+	TxHeader *TxHeader `protobuf:"bytes,1,opt,name=txHeader,proto3" json:"txHeader,omitempty"`
+	// value returned by the script, null when no value is returned
+	Result *SQLValue `protobuf:"bytes,2,opt,name=result,proto3" json:"result,omitempty"`
 }
 
-func (x *ZEntry) Reset() {
-	*x = ZEntry{}
+func (x *ExecScriptResult) Reset() {
+	*x = ExecScriptResult{}
 	if protoimpl.UnsafeEnabled {
 		mi := &file_schema_proto_msgTypes[19]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -1359,13 +1651,13 @@ func (x *ZEntry) Reset() {
 	}
 }
 
-func (x *ZEntry) String() string {
+func (x *ExecScriptResult) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ZEntry) ProtoMessage() {}
+func (*ExecScriptResult) ProtoMessage() {}
 
-func (x *ZEntry) ProtoReflect() protoreflect.Message {
+func (x *ExecScriptResult) ProtoReflect() protoreflect.Message {
 	mi := &file_schema_proto_msgTypes[19]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -1377,56 +1669,37 @@ func (x *ZEntry) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ZEntry.ProtoReflect.Descriptor instead.
-func (*ZEntry) Descriptor() ([]byte, []int) {
+// Deprecated: Use ExecScriptResult.ProtoReflect.Descriptor instead.
+func (*ExecScriptResult) Descriptor() ([]byte, []int) {
 	return file_schema_proto_rawDescGZIP(), []int{19}
 }
 
-func (x *ZEntry) GetSet() []byte {
-	if x != nil {
-		return x.Set
-	}
-	return nil
-}
-
-func (x *ZEntry) GetKey() []byte {
+func (x *ExecScriptResult) GetTxHeader() *TxHeader {
 	if x != nil {
-		return x.Key
+		return x.TxHeader
 	}
 	return nil
 }
 
-func (x *ZEntry) GetEntry() *Entry {
+func (x *ExecScriptResult) GetResult() *SQLValue {
 	if x != nil {
-		return x.Entry
+		return x.Result
 	}
 	return nil
 }
 
-func (x *ZEntry) GetScore() float64 {
-	if x != nil {
-		return x.Score
-	}
-	return 0
-}
-
-func (x *ZEntry) GetAtTx() uint64 {
-	if x != nil {
-		return x.AtTx
-	}
-	return 0
-}
-
-type ZEntries struct {
+type Entries struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Entries []*ZEntry `protobuf:"bytes,1,rep,name=entries,proto3" json:"entries,omitempty"`
+	Entries []*Entry `protobuf:"bytes,1,rep,name=entries,proto3" json:"entries,omitempty"`
+	// one result per requested key, in the order keys were requested. Only filled by GetAll when withStatuses is set
+	Results []*KeyResult `protobuf:"bytes,2,rep,name=results,proto3" json:"results,omitempty"`
 }
 
-func (x *ZEntries) Reset() {
-	*x = ZEntries{}
+func (x *Entries) Reset() {
+	*x = Entries{}
 	if protoimpl.UnsafeEnabled {
 		mi := &file_schema_proto_msgTypes[20]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -1434,13 +1707,13 @@ func (x *ZEntries) Reset() {
 	}
 }
 
-func (x *ZEntries) String() string {
+func (x *Entries) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ZEntries) ProtoMessage() {}
+func (*Entries) ProtoMessage() {}
 
-func (x *ZEntries) ProtoReflect() protoreflect.Message {
+func (x *Entries) ProtoReflect() protoreflect.Message {
 	mi := &file_schema_proto_msgTypes[20]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -1452,35 +1725,232 @@ func (x *ZEntries) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ZEntries.ProtoReflect.Descriptor instead.
-func (*ZEntries) Descriptor() ([]byte, []int) {
+// Deprecated: Use Entries.ProtoReflect.Descriptor instead.
+func (*Entries) Descriptor() ([]byte, []int) {
 	return file_schema_proto_rawDescGZIP(), []int{20}
 }
 
-func (x *ZEntries) GetEntries() []*ZEntry {
+func (x *Entries) GetEntries() []*Entry {
 	if x != nil {
 		return x.Entries
 	}
 	return nil
 }
 
-type ScanRequest struct {
+func (x *Entries) GetResults() []*KeyResult {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+type KeyResult struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	SeekKey []byte `protobuf:"bytes,1,opt,name=seekKey,proto3" json:"seekKey,omitempty"`
-	Prefix  []byte `protobuf:"bytes,2,opt,name=prefix,proto3" json:"prefix,omitempty"`
-	Desc    bool   `protobuf:"varint,3,opt,name=desc,proto3" json:"desc,omitempty"`
-	Limit   uint64 `protobuf:"varint,4,opt,name=limit,proto3" json:"limit,omitempty"`
-	SinceTx uint64 `protobuf:"varint,5,opt,name=sinceTx,proto3" json:"sinceTx,omitempty"`
+	Key    []byte    `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Status KeyStatus `protobuf:"varint,2,opt,name=status,proto3,enum=immudb.schema.KeyStatus" json:"status,omitempty"`
+	// only set when the key was found
+	Entry *Entry `protobuf:"bytes,3,opt,name=entry,proto3" json:"entry,omitempty"`
+}
+
+func (x *KeyResult) Reset() {
+	*x = KeyResult{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_schema_proto_msgTypes[21]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *KeyResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KeyResult) ProtoMessage() {}
+
+func (x *KeyResult) ProtoReflect() protoreflect.Message {
+	mi := &file_schema_proto_msgTypes[21]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KeyResult.ProtoReflect.Descriptor instead.
+func (*KeyResult) Descriptor() ([]byte, []int) {
+	return file_schema_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *KeyResult) GetKey() []byte {
+	if x != nil {
+		return x.Key
+	}
+	return nil
+}
+
+func (x *KeyResult) GetStatus() KeyStatus {
+	if x != nil {
+		return x.Status
+	}
+	return KeyStatus_KEY_FOUND
+}
+
+func (x *KeyResult) GetEntry() *Entry {
+	if x != nil {
+		return x.Entry
+	}
+	return nil
+}
+
+type ZEntry struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Set   []byte  `protobuf:"bytes,1,opt,name=set,proto3" json:"set,omitempty"`
+	Key   []byte  `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
+	Entry *Entry  `protobuf:"bytes,3,opt,name=entry,proto3" json:"entry,omitempty"`
+	Score float64 `protobuf:"fixed64,4,opt,name=score,proto3" json:"score,omitempty"`
+	AtTx  uint64  `protobuf:"varint,5,opt,name=atTx,proto3" json:"atTx,omitempty"`
+}
+
+func (x *ZEntry) Reset() {
+	*x = ZEntry{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_schema_proto_msgTypes[22]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ZEntry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ZEntry) ProtoMessage() {}
+
+func (x *ZEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_schema_proto_msgTypes[22]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ZEntry.ProtoReflect.Descriptor instead.
+func (*ZEntry) Descriptor() ([]byte, []int) {
+	return file_schema_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *ZEntry) GetSet() []byte {
+	if x != nil {
+		return x.Set
+	}
+	return nil
+}
+
+func (x *ZEntry) GetKey() []byte {
+	if x != nil {
+		return x.Key
+	}
+	return nil
+}
+
+func (x *ZEntry) GetEntry() *Entry {
+	if x != nil {
+		return x.Entry
+	}
+	return nil
+}
+
+func (x *ZEntry) GetScore() float64 {
+	if x != nil {
+		return x.Score
+	}
+	return 0
+}
+
+func (x *ZEntry) GetAtTx() uint64 {
+	if x != nil {
+		return x.AtTx
+	}
+	return 0
+}
+
+type ZEntries struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Entries []*ZEntry `protobuf:"bytes,1,rep,name=entries,proto3" json:"entries,omitempty"`
+}
+
+func (x *ZEntries) Reset() {
+	*x = ZEntries{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_schema_proto_msgTypes[23]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ZEntries) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ZEntries) ProtoMessage() {}
+
+func (x *ZEntries) ProtoReflect() protoreflect.Message {
+	mi := &file_schema_proto_msgTypes[23]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ZEntries.ProtoReflect.Descriptor instead.
+func (*ZEntries) Descriptor() ([]byte, []int) {
+	return file_schema_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *ZEntries) GetEntries() []*ZEntry {
+	if x != nil {
+		return x.Entries
+	}
+	return nil
+}
+
+type ScanRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	SeekKey []byte `protobuf:"bytes,1,opt,name=seekKey,proto3" json:"seekKey,omitempty"`
+	Prefix  []byte `protobuf:"bytes,2,opt,name=prefix,proto3" json:"prefix,omitempty"`
+	Desc    bool   `protobuf:"varint,3,opt,name=desc,proto3" json:"desc,omitempty"`
+	Limit   uint64 `protobuf:"varint,4,opt,name=limit,proto3" json:"limit,omitempty"`
+	SinceTx uint64 `protobuf:"varint,5,opt,name=sinceTx,proto3" json:"sinceTx,omitempty"`
 	NoWait  bool   `protobuf:"varint,6,opt,name=noWait,proto3" json:"noWait,omitempty"`
 }
 
 func (x *ScanRequest) Reset() {
 	*x = ScanRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_schema_proto_msgTypes[21]
+		mi := &file_schema_proto_msgTypes[24]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1493,7 +1963,7 @@ func (x *ScanRequest) String() string {
 func (*ScanRequest) ProtoMessage() {}
 
 func (x *ScanRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_schema_proto_msgTypes[21]
+	mi := &file_schema_proto_msgTypes[24]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1506,7 +1976,7 @@ func (x *ScanRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ScanRequest.ProtoReflect.Descriptor instead.
 func (*ScanRequest) Descriptor() ([]byte, []int) {
-	return file_schema_proto_rawDescGZIP(), []int{21}
+	return file_schema_proto_rawDescGZIP(), []int{24}
 }
 
 func (x *ScanRequest) GetSeekKey() []byte {
@@ -1562,7 +2032,7 @@ type KeyPrefix struct {
 func (x *KeyPrefix) Reset() {
 	*x = KeyPrefix{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_schema_proto_msgTypes[22]
+		mi := &file_schema_proto_msgTypes[25]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1575,7 +2045,7 @@ func (x *KeyPrefix) String() string {
 func (*KeyPrefix) ProtoMessage() {}
 
 func (x *KeyPrefix) ProtoReflect() protoreflect.Message {
-	mi := &file_schema_proto_msgTypes[22]
+	mi := &file_schema_proto_msgTypes[25]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1588,7 +2058,7 @@ func (x *KeyPrefix) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use KeyPrefix.ProtoReflect.Descriptor instead.
 func (*KeyPrefix) Descriptor() ([]byte, []int) {
-	return file_schema_proto_rawDescGZIP(), []int{22}
+	return file_schema_proto_rawDescGZIP(), []int{25}
 }
 
 func (x *KeyPrefix) GetPrefix() []byte {
@@ -1609,7 +2079,7 @@ type EntryCount struct {
 func (x *EntryCount) Reset() {
 	*x = EntryCount{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_schema_proto_msgTypes[23]
+		mi := &file_schema_proto_msgTypes[26]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1622,7 +2092,7 @@ func (x *EntryCount) String() string {
 func (*EntryCount) ProtoMessage() {}
 
 func (x *EntryCount) ProtoReflect() protoreflect.Message {
-	mi := &file_schema_proto_msgTypes[23]
+	mi := &file_schema_proto_msgTypes[26]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1635,7 +2105,7 @@ func (x *EntryCount) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use EntryCount.ProtoReflect.Descriptor instead.
 func (*EntryCount) Descriptor() ([]byte, []int) {
-	return file_schema_proto_rawDescGZIP(), []int{23}
+	return file_schema_proto_rawDescGZIP(), []int{26}
 }
 
 func (x *EntryCount) GetCount() uint64 {
@@ -1652,12 +2122,13 @@ type Signature struct {
 
 	PublicKey []byte `protobuf:"bytes,1,opt,name=publicKey,proto3" json:"publicKey,omitempty"`
 	Signature []byte `protobuf:"bytes,2,opt,name=signature,proto3" json:"signature,omitempty"`
+	KeyId     string `protobuf:"bytes,3,opt,name=keyId,proto3" json:"keyId,omitempty"`
 }
 
 func (x *Signature) Reset() {
 	*x = Signature{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_schema_proto_msgTypes[24]
+		mi := &file_schema_proto_msgTypes[27]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1670,7 +2141,7 @@ func (x *Signature) String() string {
 func (*Signature) ProtoMessage() {}
 
 func (x *Signature) ProtoReflect() protoreflect.Message {
-	mi := &file_schema_proto_msgTypes[24]
+	mi := &file_schema_proto_msgTypes[27]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1683,7 +2154,7 @@ func (x *Signature) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Signature.ProtoReflect.Descriptor instead.
 func (*Signature) Descriptor() ([]byte, []int) {
-	return file_schema_proto_rawDescGZIP(), []int{24}
+	return file_schema_proto_rawDescGZIP(), []int{27}
 }
 
 func (x *Signature) GetPublicKey() []byte {
@@ -1700,6 +2171,13 @@ func (x *Signature) GetSignature() []byte {
 	return nil
 }
 
+func (x *Signature) GetKeyId() string {
+	if x != nil {
+		return x.KeyId
+	}
+	return ""
+}
+
 type TxHeader struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -1719,7 +2197,7 @@ type TxHeader struct {
 func (x *TxHeader) Reset() {
 	*x = TxHeader{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_schema_proto_msgTypes[25]
+		mi := &file_schema_proto_msgTypes[28]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1732,7 +2210,7 @@ func (x *TxHeader) String() string {
 func (*TxHeader) ProtoMessage() {}
 
 func (x *TxHeader) ProtoReflect() protoreflect.Message {
-	mi := &file_schema_proto_msgTypes[25]
+	mi := &file_schema_proto_msgTypes[28]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1745,7 +2223,7 @@ func (x *TxHeader) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use TxHeader.ProtoReflect.Descriptor instead.
 func (*TxHeader) Descriptor() ([]byte, []int) {
-	return file_schema_proto_rawDescGZIP(), []int{25}
+	return file_schema_proto_rawDescGZIP(), []int{28}
 }
 
 func (x *TxHeader) GetId() uint64 {
@@ -1815,12 +2293,14 @@ type TxMetadata struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
+
+	User string `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`
 }
 
 func (x *TxMetadata) Reset() {
 	*x = TxMetadata{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_schema_proto_msgTypes[26]
+		mi := &file_schema_proto_msgTypes[29]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1833,7 +2313,7 @@ func (x *TxMetadata) String() string {
 func (*TxMetadata) ProtoMessage() {}
 
 func (x *TxMetadata) ProtoReflect() protoreflect.Message {
-	mi := &file_schema_proto_msgTypes[26]
+	mi := &file_schema_proto_msgTypes[29]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1846,7 +2326,14 @@ func (x *TxMetadata) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use TxMetadata.ProtoReflect.Descriptor instead.
 func (*TxMetadata) Descriptor() ([]byte, []int) {
-	return file_schema_proto_rawDescGZIP(), []int{26}
+	return file_schema_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *TxMetadata) GetUser() string {
+	if x != nil {
+		return x.User
+	}
+	return ""
 }
 
 type LinearProof struct {
@@ -1862,7 +2349,7 @@ type LinearProof struct {
 func (x *LinearProof) Reset() {
 	*x = LinearProof{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_schema_proto_msgTypes[27]
+		mi := &file_schema_proto_msgTypes[30]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1875,7 +2362,7 @@ func (x *LinearProof) String() string {
 func (*LinearProof) ProtoMessage() {}
 
 func (x *LinearProof) ProtoReflect() protoreflect.Message {
-	mi := &file_schema_proto_msgTypes[27]
+	mi := &file_schema_proto_msgTypes[30]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1888,7 +2375,7 @@ func (x *LinearProof) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use LinearProof.ProtoReflect.Descriptor instead.
 func (*LinearProof) Descriptor() ([]byte, []int) {
-	return file_schema_proto_rawDescGZIP(), []int{27}
+	return file_schema_proto_rawDescGZIP(), []int{30}
 }
 
 func (x *LinearProof) GetSourceTxId() uint64 {
@@ -1929,7 +2416,7 @@ type DualProof struct {
 func (x *DualProof) Reset() {
 	*x = DualProof{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_schema_proto_msgTypes[28]
+		mi := &file_schema_proto_msgTypes[31]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1942,7 +2429,7 @@ func (x *DualProof) String() string {
 func (*DualProof) ProtoMessage() {}
 
 func (x *DualProof) ProtoReflect() protoreflect.Message {
-	mi := &file_schema_proto_msgTypes[28]
+	mi := &file_schema_proto_msgTypes[31]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1955,7 +2442,7 @@ func (x *DualProof) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use DualProof.ProtoReflect.Descriptor instead.
 func (*DualProof) Descriptor() ([]byte, []int) {
-	return file_schema_proto_rawDescGZIP(), []int{28}
+	return file_schema_proto_rawDescGZIP(), []int{31}
 }
 
 func (x *DualProof) GetSourceTxHeader() *TxHeader {
@@ -2021,7 +2508,7 @@ type Tx struct {
 func (x *Tx) Reset() {
 	*x = Tx{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_schema_proto_msgTypes[29]
+		mi := &file_schema_proto_msgTypes[32]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -2034,7 +2521,7 @@ func (x *Tx) String() string {
 func (*Tx) ProtoMessage() {}
 
 func (x *Tx) ProtoReflect() protoreflect.Message {
-	mi := &file_schema_proto_msgTypes[29]
+	mi := &file_schema_proto_msgTypes[32]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2047,7 +2534,7 @@ func (x *Tx) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Tx.ProtoReflect.Descriptor instead.
 func (*Tx) Descriptor() ([]byte, []int) {
-	return file_schema_proto_rawDescGZIP(), []int{29}
+	return file_schema_proto_rawDescGZIP(), []int{32}
 }
 
 func (x *Tx) GetHeader() *TxHeader {
@@ -2093,7 +2580,7 @@ type TxEntry struct {
 func (x *TxEntry) Reset() {
 	*x = TxEntry{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_schema_proto_msgTypes[30]
+		mi := &file_schema_proto_msgTypes[33]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -2106,7 +2593,7 @@ func (x *TxEntry) String() string {
 func (*TxEntry) ProtoMessage() {}
 
 func (x *TxEntry) ProtoReflect() protoreflect.Message {
-	mi := &file_schema_proto_msgTypes[30]
+	mi := &file_schema_proto_msgTypes[33]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2119,7 +2606,7 @@ func (x *TxEntry) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use TxEntry.ProtoReflect.Descriptor instead.
 func (*TxEntry) Descriptor() ([]byte, []int) {
-	return file_schema_proto_rawDescGZIP(), []int{30}
+	return file_schema_proto_rawDescGZIP(), []int{33}
 }
 
 func (x *TxEntry) GetKey() []byte {
@@ -2165,12 +2652,13 @@ type KVMetadata struct {
 	Deleted      bool        `protobuf:"varint,1,opt,name=deleted,proto3" json:"deleted,omitempty"`
 	Expiration   *Expiration `protobuf:"bytes,2,opt,name=expiration,proto3" json:"expiration,omitempty"`
 	NonIndexable bool        `protobuf:"varint,3,opt,name=nonIndexable,proto3" json:"nonIndexable,omitempty"`
+	Compression  int32       `protobuf:"varint,4,opt,name=compression,proto3" json:"compression,omitempty"`
 }
 
 func (x *KVMetadata) Reset() {
 	*x = KVMetadata{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_schema_proto_msgTypes[31]
+		mi := &file_schema_proto_msgTypes[34]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -2183,7 +2671,7 @@ func (x *KVMetadata) String() string {
 func (*KVMetadata) ProtoMessage() {}
 
 func (x *KVMetadata) ProtoReflect() protoreflect.Message {
-	mi := &file_schema_proto_msgTypes[31]
+	mi := &file_schema_proto_msgTypes[34]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2196,7 +2684,7 @@ func (x *KVMetadata) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use KVMetadata.ProtoReflect.Descriptor instead.
 func (*KVMetadata) Descriptor() ([]byte, []int) {
-	return file_schema_proto_rawDescGZIP(), []int{31}
+	return file_schema_proto_rawDescGZIP(), []int{34}
 }
 
 func (x *KVMetadata) GetDeleted() bool {
@@ -2220,6 +2708,13 @@ func (x *KVMetadata) GetNonIndexable() bool {
 	return false
 }
 
+func (x *KVMetadata) GetCompression() int32 {
+	if x != nil {
+		return x.Compression
+	}
+	return 0
+}
+
 type Expiration struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -2231,7 +2726,7 @@ type Expiration struct {
 func (x *Expiration) Reset() {
 	*x = Expiration{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_schema_proto_msgTypes[32]
+		mi := &file_schema_proto_msgTypes[35]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -2244,7 +2739,7 @@ func (x *Expiration) String() string {
 func (*Expiration) ProtoMessage() {}
 
 func (x *Expiration) ProtoReflect() protoreflect.Message {
-	mi := &file_schema_proto_msgTypes[32]
+	mi := &file_schema_proto_msgTypes[35]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2257,7 +2752,7 @@ func (x *Expiration) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Expiration.ProtoReflect.Descriptor instead.
 func (*Expiration) Descriptor() ([]byte, []int) {
-	return file_schema_proto_rawDescGZIP(), []int{32}
+	return file_schema_proto_rawDescGZIP(), []int{35}
 }
 
 func (x *Expiration) GetExpiresAt() int64 {
@@ -2280,7 +2775,7 @@ type VerifiableTx struct {
 func (x *VerifiableTx) Reset() {
 	*x = VerifiableTx{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_schema_proto_msgTypes[33]
+		mi := &file_schema_proto_msgTypes[36]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -2293,7 +2788,7 @@ func (x *VerifiableTx) String() string {
 func (*VerifiableTx) ProtoMessage() {}
 
 func (x *VerifiableTx) ProtoReflect() protoreflect.Message {
-	mi := &file_schema_proto_msgTypes[33]
+	mi := &file_schema_proto_msgTypes[36]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2306,7 +2801,7 @@ func (x *VerifiableTx) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use VerifiableTx.ProtoReflect.Descriptor instead.
 func (*VerifiableTx) Descriptor() ([]byte, []int) {
-	return file_schema_proto_rawDescGZIP(), []int{33}
+	return file_schema_proto_rawDescGZIP(), []int{36}
 }
 
 func (x *VerifiableTx) GetTx() *Tx {
@@ -2343,7 +2838,7 @@ type VerifiableEntry struct {
 func (x *VerifiableEntry) Reset() {
 	*x = VerifiableEntry{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_schema_proto_msgTypes[34]
+		mi := &file_schema_proto_msgTypes[37]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -2356,7 +2851,7 @@ func (x *VerifiableEntry) String() string {
 func (*VerifiableEntry) ProtoMessage() {}
 
 func (x *VerifiableEntry) ProtoReflect() protoreflect.Message {
-	mi := &file_schema_proto_msgTypes[34]
+	mi := &file_schema_proto_msgTypes[37]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2369,7 +2864,7 @@ func (x *VerifiableEntry) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use VerifiableEntry.ProtoReflect.Descriptor instead.
 func (*VerifiableEntry) Descriptor() ([]byte, []int) {
-	return file_schema_proto_rawDescGZIP(), []int{34}
+	return file_schema_proto_rawDescGZIP(), []int{37}
 }
 
 func (x *VerifiableEntry) GetEntry() *Entry {
@@ -2406,7 +2901,7 @@ type InclusionProof struct {
 func (x *InclusionProof) Reset() {
 	*x = InclusionProof{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_schema_proto_msgTypes[35]
+		mi := &file_schema_proto_msgTypes[38]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -2419,7 +2914,7 @@ func (x *InclusionProof) String() string {
 func (*InclusionProof) ProtoMessage() {}
 
 func (x *InclusionProof) ProtoReflect() protoreflect.Message {
-	mi := &file_schema_proto_msgTypes[35]
+	mi := &file_schema_proto_msgTypes[38]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2432,7 +2927,7 @@ func (x *InclusionProof) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use InclusionProof.ProtoReflect.Descriptor instead.
 func (*InclusionProof) Descriptor() ([]byte, []int) {
-	return file_schema_proto_rawDescGZIP(), []int{35}
+	return file_schema_proto_rawDescGZIP(), []int{38}
 }
 
 func (x *InclusionProof) GetLeaf() int32 {
@@ -2468,7 +2963,7 @@ type SetRequest struct {
 func (x *SetRequest) Reset() {
 	*x = SetRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_schema_proto_msgTypes[36]
+		mi := &file_schema_proto_msgTypes[39]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -2481,7 +2976,7 @@ func (x *SetRequest) String() string {
 func (*SetRequest) ProtoMessage() {}
 
 func (x *SetRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_schema_proto_msgTypes[36]
+	mi := &file_schema_proto_msgTypes[39]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2494,7 +2989,7 @@ func (x *SetRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SetRequest.ProtoReflect.Descriptor instead.
 func (*SetRequest) Descriptor() ([]byte, []int) {
-	return file_schema_proto_rawDescGZIP(), []int{36}
+	return file_schema_proto_rawDescGZIP(), []int{39}
 }
 
 func (x *SetRequest) GetKVs() []*KeyValue {
@@ -2525,7 +3020,7 @@ type KeyRequest struct {
 func (x *KeyRequest) Reset() {
 	*x = KeyRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_schema_proto_msgTypes[37]
+		mi := &file_schema_proto_msgTypes[40]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -2538,7 +3033,7 @@ func (x *KeyRequest) String() string {
 func (*KeyRequest) ProtoMessage() {}
 
 func (x *KeyRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_schema_proto_msgTypes[37]
+	mi := &file_schema_proto_msgTypes[40]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2551,7 +3046,7 @@ func (x *KeyRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use KeyRequest.ProtoReflect.Descriptor instead.
 func (*KeyRequest) Descriptor() ([]byte, []int) {
-	return file_schema_proto_rawDescGZIP(), []int{37}
+	return file_schema_proto_rawDescGZIP(), []int{40}
 }
 
 func (x *KeyRequest) GetKey() []byte {
@@ -2589,12 +3084,16 @@ type KeyListRequest struct {
 
 	Keys    [][]byte `protobuf:"bytes,1,rep,name=keys,proto3" json:"keys,omitempty"`
 	SinceTx uint64   `protobuf:"varint,2,opt,name=sinceTx,proto3" json:"sinceTx,omitempty"`
+	// return the status of every requested key in results, instead of the found entries
+	WithStatuses bool `protobuf:"varint,3,opt,name=withStatuses,proto3" json:"withStatuses,omitempty"`
+	// fail when a key is not found, deleted or expired, instead of leaving it out
+	FailOnMissingKeys bool `protobuf:"varint,4,opt,name=failOnMissingKeys,proto3" json:"failOnMissingKeys,omitempty"`
 }
 
 func (x *KeyListRequest) Reset() {
 	*x = KeyListRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_schema_proto_msgTypes[38]
+		mi := &file_schema_proto_msgTypes[41]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -2607,7 +3106,7 @@ func (x *KeyListRequest) String() string {
 func (*KeyListRequest) ProtoMessage() {}
 
 func (x *KeyListRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_schema_proto_msgTypes[38]
+	mi := &file_schema_proto_msgTypes[41]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2620,7 +3119,7 @@ func (x *KeyListRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use KeyListRequest.ProtoReflect.Descriptor instead.
 func (*KeyListRequest) Descriptor() ([]byte, []int) {
-	return file_schema_proto_rawDescGZIP(), []int{38}
+	return file_schema_proto_rawDescGZIP(), []int{41}
 }
 
 func (x *KeyListRequest) GetKeys() [][]byte {
@@ -2637,6 +3136,20 @@ func (x *KeyListRequest) GetSinceTx() uint64 {
 	return 0
 }
 
+func (x *KeyListRequest) GetWithStatuses() bool {
+	if x != nil {
+		return x.WithStatuses
+	}
+	return false
+}
+
+func (x *KeyListRequest) GetFailOnMissingKeys() bool {
+	if x != nil {
+		return x.FailOnMissingKeys
+	}
+	return false
+}
+
 type DeleteKeysRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -2650,7 +3163,7 @@ type DeleteKeysRequest struct {
 func (x *DeleteKeysRequest) Reset() {
 	*x = DeleteKeysRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_schema_proto_msgTypes[39]
+		mi := &file_schema_proto_msgTypes[42]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -2663,7 +3176,7 @@ func (x *DeleteKeysRequest) String() string {
 func (*DeleteKeysRequest) ProtoMessage() {}
 
 func (x *DeleteKeysRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_schema_proto_msgTypes[39]
+	mi := &file_schema_proto_msgTypes[42]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2676,7 +3189,7 @@ func (x *DeleteKeysRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use DeleteKeysRequest.ProtoReflect.Descriptor instead.
 func (*DeleteKeysRequest) Descriptor() ([]byte, []int) {
-	return file_schema_proto_rawDescGZIP(), []int{39}
+	return file_schema_proto_rawDescGZIP(), []int{42}
 }
 
 func (x *DeleteKeysRequest) GetKeys() [][]byte {
@@ -2712,7 +3225,7 @@ type VerifiableSetRequest struct {
 func (x *VerifiableSetRequest) Reset() {
 	*x = VerifiableSetRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_schema_proto_msgTypes[40]
+		mi := &file_schema_proto_msgTypes[43]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -2725,7 +3238,7 @@ func (x *VerifiableSetRequest) String() string {
 func (*VerifiableSetRequest) ProtoMessage() {}
 
 func (x *VerifiableSetRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_schema_proto_msgTypes[40]
+	mi := &file_schema_proto_msgTypes[43]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2738,7 +3251,7 @@ func (x *VerifiableSetRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use VerifiableSetRequest.ProtoReflect.Descriptor instead.
 func (*VerifiableSetRequest) Descriptor() ([]byte, []int) {
-	return file_schema_proto_rawDescGZIP(), []int{40}
+	return file_schema_proto_rawDescGZIP(), []int{43}
 }
 
 func (x *VerifiableSetRequest) GetSetRequest() *SetRequest {
@@ -2767,7 +3280,7 @@ type VerifiableGetRequest struct {
 func (x *VerifiableGetRequest) Reset() {
 	*x = VerifiableGetRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_schema_proto_msgTypes[41]
+		mi := &file_schema_proto_msgTypes[44]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -2780,7 +3293,7 @@ func (x *VerifiableGetRequest) String() string {
 func (*VerifiableGetRequest) ProtoMessage() {}
 
 func (x *VerifiableGetRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_schema_proto_msgTypes[41]
+	mi := &file_schema_proto_msgTypes[44]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2793,7 +3306,7 @@ func (x *VerifiableGetRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use VerifiableGetRequest.ProtoReflect.Descriptor instead.
 func (*VerifiableGetRequest) Descriptor() ([]byte, []int) {
-	return file_schema_proto_rawDescGZIP(), []int{41}
+	return file_schema_proto_rawDescGZIP(), []int{44}
 }
 
 func (x *VerifiableGetRequest) GetKeyRequest() *KeyRequest {
@@ -2822,7 +3335,7 @@ type HealthResponse struct {
 func (x *HealthResponse) Reset() {
 	*x = HealthResponse{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_schema_proto_msgTypes[42]
+		mi := &file_schema_proto_msgTypes[45]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -2835,7 +3348,7 @@ func (x *HealthResponse) String() string {
 func (*HealthResponse) ProtoMessage() {}
 
 func (x *HealthResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_schema_proto_msgTypes[42]
+	mi := &file_schema_proto_msgTypes[45]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2848,7 +3361,7 @@ func (x *HealthResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use HealthResponse.ProtoReflect.Descriptor instead.
 func (*HealthResponse) Descriptor() ([]byte, []int) {
-	return file_schema_proto_rawDescGZIP(), []int{42}
+	return file_schema_proto_rawDescGZIP(), []int{45}
 }
 
 func (x *HealthResponse) GetStatus() bool {
@@ -2865,6 +3378,112 @@ func (x *HealthResponse) GetVersion() string {
 	return ""
 }
 
+// ServerInfoRequest exists to provide extensibility for rpc ServerInfo.
+type ServerInfoRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *ServerInfoRequest) Reset() {
+	*x = ServerInfoRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_schema_proto_msgTypes[46]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ServerInfoRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ServerInfoRequest) ProtoMessage() {}
+
+func (x *ServerInfoRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_schema_proto_msgTypes[46]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ServerInfoRequest.ProtoReflect.Descriptor instead.
+func (*ServerInfoRequest) Descriptor() ([]byte, []int) {
+	return file_schema_proto_rawDescGZIP(), []int{46}
+}
+
+// ServerInfoResponse contains information about the server instance.
+type ServerInfoResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// The version of the server instance.
+	Version string `protobuf:"bytes,1,opt,name=version,proto3" json:"version,omitempty"`
+	// The versions of the API served by the server instance e.g. v1.
+	ApiVersions []string `protobuf:"bytes,2,rep,name=apiVersions,proto3" json:"apiVersions,omitempty"`
+	// The optional features enabled on the server instance e.g. pgsql, s3 or reflection.
+	Features []string `protobuf:"bytes,3,rep,name=features,proto3" json:"features,omitempty"`
+}
+
+func (x *ServerInfoResponse) Reset() {
+	*x = ServerInfoResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_schema_proto_msgTypes[47]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ServerInfoResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ServerInfoResponse) ProtoMessage() {}
+
+func (x *ServerInfoResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_schema_proto_msgTypes[47]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ServerInfoResponse.ProtoReflect.Descriptor instead.
+func (*ServerInfoResponse) Descriptor() ([]byte, []int) {
+	return file_schema_proto_rawDescGZIP(), []int{47}
+}
+
+func (x *ServerInfoResponse) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *ServerInfoResponse) GetApiVersions() []string {
+	if x != nil {
+		return x.ApiVersions
+	}
+	return nil
+}
+
+func (x *ServerInfoResponse) GetFeatures() []string {
+	if x != nil {
+		return x.Features
+	}
+	return nil
+}
+
 type DatabaseHealthResponse struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -2877,7 +3496,7 @@ type DatabaseHealthResponse struct {
 func (x *DatabaseHealthResponse) Reset() {
 	*x = DatabaseHealthResponse{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_schema_proto_msgTypes[43]
+		mi := &file_schema_proto_msgTypes[48]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -2890,7 +3509,7 @@ func (x *DatabaseHealthResponse) String() string {
 func (*DatabaseHealthResponse) ProtoMessage() {}
 
 func (x *DatabaseHealthResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_schema_proto_msgTypes[43]
+	mi := &file_schema_proto_msgTypes[48]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2903,7 +3522,7 @@ func (x *DatabaseHealthResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use DatabaseHealthResponse.ProtoReflect.Descriptor instead.
 func (*DatabaseHealthResponse) Descriptor() ([]byte, []int) {
-	return file_schema_proto_rawDescGZIP(), []int{43}
+	return file_schema_proto_rawDescGZIP(), []int{48}
 }
 
 func (x *DatabaseHealthResponse) GetPendingRequests() uint32 {
@@ -2934,7 +3553,7 @@ type ImmutableState struct {
 func (x *ImmutableState) Reset() {
 	*x = ImmutableState{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_schema_proto_msgTypes[44]
+		mi := &file_schema_proto_msgTypes[49]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -2947,7 +3566,7 @@ func (x *ImmutableState) String() string {
 func (*ImmutableState) ProtoMessage() {}
 
 func (x *ImmutableState) ProtoReflect() protoreflect.Message {
-	mi := &file_schema_proto_msgTypes[44]
+	mi := &file_schema_proto_msgTypes[49]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2960,7 +3579,7 @@ func (x *ImmutableState) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ImmutableState.ProtoReflect.Descriptor instead.
 func (*ImmutableState) Descriptor() ([]byte, []int) {
-	return file_schema_proto_rawDescGZIP(), []int{44}
+	return file_schema_proto_rawDescGZIP(), []int{49}
 }
 
 func (x *ImmutableState) GetDb() string {
@@ -3001,12 +3620,14 @@ type ReferenceRequest struct {
 	AtTx          uint64 `protobuf:"varint,3,opt,name=atTx,proto3" json:"atTx,omitempty"`
 	BoundRef      bool   `protobuf:"varint,4,opt,name=boundRef,proto3" json:"boundRef,omitempty"`
 	NoWait        bool   `protobuf:"varint,5,opt,name=noWait,proto3" json:"noWait,omitempty"`
+	// the reference is no longer resolved once expired, the referenced key is not affected
+	Expiration *Expiration `protobuf:"bytes,6,opt,name=expiration,proto3" json:"expiration,omitempty"`
 }
 
 func (x *ReferenceRequest) Reset() {
 	*x = ReferenceRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_schema_proto_msgTypes[45]
+		mi := &file_schema_proto_msgTypes[50]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -3019,7 +3640,7 @@ func (x *ReferenceRequest) String() string {
 func (*ReferenceRequest) ProtoMessage() {}
 
 func (x *ReferenceRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_schema_proto_msgTypes[45]
+	mi := &file_schema_proto_msgTypes[50]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3032,7 +3653,7 @@ func (x *ReferenceRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ReferenceRequest.ProtoReflect.Descriptor instead.
 func (*ReferenceRequest) Descriptor() ([]byte, []int) {
-	return file_schema_proto_rawDescGZIP(), []int{45}
+	return file_schema_proto_rawDescGZIP(), []int{50}
 }
 
 func (x *ReferenceRequest) GetKey() []byte {
@@ -3070,6 +3691,13 @@ func (x *ReferenceRequest) GetNoWait() bool {
 	return false
 }
 
+func (x *ReferenceRequest) GetExpiration() *Expiration {
+	if x != nil {
+		return x.Expiration
+	}
+	return nil
+}
+
 type VerifiableReferenceRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -3082,7 +3710,7 @@ type VerifiableReferenceRequest struct {
 func (x *VerifiableReferenceRequest) Reset() {
 	*x = VerifiableReferenceRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_schema_proto_msgTypes[46]
+		mi := &file_schema_proto_msgTypes[51]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -3095,7 +3723,7 @@ func (x *VerifiableReferenceRequest) String() string {
 func (*VerifiableReferenceRequest) ProtoMessage() {}
 
 func (x *VerifiableReferenceRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_schema_proto_msgTypes[46]
+	mi := &file_schema_proto_msgTypes[51]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3108,7 +3736,7 @@ func (x *VerifiableReferenceRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use VerifiableReferenceRequest.ProtoReflect.Descriptor instead.
 func (*VerifiableReferenceRequest) Descriptor() ([]byte, []int) {
-	return file_schema_proto_rawDescGZIP(), []int{46}
+	return file_schema_proto_rawDescGZIP(), []int{51}
 }
 
 func (x *VerifiableReferenceRequest) GetReferenceRequest() *ReferenceRequest {
@@ -3125,36 +3753,34 @@ func (x *VerifiableReferenceRequest) GetProveSinceTx() uint64 {
 	return 0
 }
 
-type ZAddRequest struct {
+type ResolveAllRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Set      []byte  `protobuf:"bytes,1,opt,name=set,proto3" json:"set,omitempty"`
-	Score    float64 `protobuf:"fixed64,2,opt,name=score,proto3" json:"score,omitempty"`
-	Key      []byte  `protobuf:"bytes,3,opt,name=key,proto3" json:"key,omitempty"`
-	AtTx     uint64  `protobuf:"varint,4,opt,name=atTx,proto3" json:"atTx,omitempty"`
-	BoundRef bool    `protobuf:"varint,5,opt,name=boundRef,proto3" json:"boundRef,omitempty"`
-	NoWait   bool    `protobuf:"varint,6,opt,name=noWait,proto3" json:"noWait,omitempty"`
+	// key referenced by the references to be resolved
+	Key     []byte `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	SinceTx uint64 `protobuf:"varint,2,opt,name=sinceTx,proto3" json:"sinceTx,omitempty"`
+	NoWait  bool   `protobuf:"varint,3,opt,name=noWait,proto3" json:"noWait,omitempty"`
 }
 
-func (x *ZAddRequest) Reset() {
-	*x = ZAddRequest{}
+func (x *ResolveAllRequest) Reset() {
+	*x = ResolveAllRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_schema_proto_msgTypes[47]
+		mi := &file_schema_proto_msgTypes[52]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *ZAddRequest) String() string {
+func (x *ResolveAllRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ZAddRequest) ProtoMessage() {}
+func (*ResolveAllRequest) ProtoMessage() {}
 
-func (x *ZAddRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_schema_proto_msgTypes[47]
+func (x *ResolveAllRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_schema_proto_msgTypes[52]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3165,78 +3791,60 @@ func (x *ZAddRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ZAddRequest.ProtoReflect.Descriptor instead.
-func (*ZAddRequest) Descriptor() ([]byte, []int) {
-	return file_schema_proto_rawDescGZIP(), []int{47}
-}
-
-func (x *ZAddRequest) GetSet() []byte {
-	if x != nil {
-		return x.Set
-	}
-	return nil
-}
-
-func (x *ZAddRequest) GetScore() float64 {
-	if x != nil {
-		return x.Score
-	}
-	return 0
+// Deprecated: Use ResolveAllRequest.ProtoReflect.Descriptor instead.
+func (*ResolveAllRequest) Descriptor() ([]byte, []int) {
+	return file_schema_proto_rawDescGZIP(), []int{52}
 }
 
-func (x *ZAddRequest) GetKey() []byte {
+func (x *ResolveAllRequest) GetKey() []byte {
 	if x != nil {
 		return x.Key
 	}
 	return nil
 }
 
-func (x *ZAddRequest) GetAtTx() uint64 {
+func (x *ResolveAllRequest) GetSinceTx() uint64 {
 	if x != nil {
-		return x.AtTx
+		return x.SinceTx
 	}
 	return 0
 }
 
-func (x *ZAddRequest) GetBoundRef() bool {
-	if x != nil {
-		return x.BoundRef
-	}
-	return false
-}
-
-func (x *ZAddRequest) GetNoWait() bool {
+func (x *ResolveAllRequest) GetNoWait() bool {
 	if x != nil {
 		return x.NoWait
 	}
 	return false
 }
 
-type Score struct {
+type RenameRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Score float64 `protobuf:"fixed64,1,opt,name=score,proto3" json:"score,omitempty"`
+	OldKey []byte `protobuf:"bytes,1,opt,name=oldKey,proto3" json:"oldKey,omitempty"`
+	// key the value of oldKey is moved to, it must not exist
+	NewKey []byte `protobuf:"bytes,2,opt,name=newKey,proto3" json:"newKey,omitempty"`
+	NoWait bool   `protobuf:"varint,3,opt,name=noWait,proto3" json:"noWait,omitempty"`
 }
 
-func (x *Score) Reset() {
-	*x = Score{}
+func (x *RenameRequest) Reset() {
+	*x = RenameRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_schema_proto_msgTypes[48]
+		mi := &file_schema_proto_msgTypes[53]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *Score) String() string {
+func (x *RenameRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Score) ProtoMessage() {}
+func (*RenameRequest) ProtoMessage() {}
 
-func (x *Score) ProtoReflect() protoreflect.Message {
-	mi := &file_schema_proto_msgTypes[48]
+func (x *RenameRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_schema_proto_msgTypes[53]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3247,16 +3855,173 @@ func (x *Score) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Score.ProtoReflect.Descriptor instead.
-func (*Score) Descriptor() ([]byte, []int) {
-	return file_schema_proto_rawDescGZIP(), []int{48}
+// Deprecated: Use RenameRequest.ProtoReflect.Descriptor instead.
+func (*RenameRequest) Descriptor() ([]byte, []int) {
+	return file_schema_proto_rawDescGZIP(), []int{53}
 }
 
-func (x *Score) GetScore() float64 {
+func (x *RenameRequest) GetOldKey() []byte {
 	if x != nil {
-		return x.Score
+		return x.OldKey
 	}
-	return 0
+	return nil
+}
+
+func (x *RenameRequest) GetNewKey() []byte {
+	if x != nil {
+		return x.NewKey
+	}
+	return nil
+}
+
+func (x *RenameRequest) GetNoWait() bool {
+	if x != nil {
+		return x.NoWait
+	}
+	return false
+}
+
+type ZAddRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Set      []byte  `protobuf:"bytes,1,opt,name=set,proto3" json:"set,omitempty"`
+	Score    float64 `protobuf:"fixed64,2,opt,name=score,proto3" json:"score,omitempty"`
+	Key      []byte  `protobuf:"bytes,3,opt,name=key,proto3" json:"key,omitempty"`
+	AtTx     uint64  `protobuf:"varint,4,opt,name=atTx,proto3" json:"atTx,omitempty"`
+	BoundRef bool    `protobuf:"varint,5,opt,name=boundRef,proto3" json:"boundRef,omitempty"`
+	NoWait   bool    `protobuf:"varint,6,opt,name=noWait,proto3" json:"noWait,omitempty"`
+	// the key is no longer part of the sorted set once expired
+	Expiration *Expiration `protobuf:"bytes,7,opt,name=expiration,proto3" json:"expiration,omitempty"`
+}
+
+func (x *ZAddRequest) Reset() {
+	*x = ZAddRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_schema_proto_msgTypes[54]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ZAddRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ZAddRequest) ProtoMessage() {}
+
+func (x *ZAddRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_schema_proto_msgTypes[54]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ZAddRequest.ProtoReflect.Descriptor instead.
+func (*ZAddRequest) Descriptor() ([]byte, []int) {
+	return file_schema_proto_rawDescGZIP(), []int{54}
+}
+
+func (x *ZAddRequest) GetSet() []byte {
+	if x != nil {
+		return x.Set
+	}
+	return nil
+}
+
+func (x *ZAddRequest) GetScore() float64 {
+	if x != nil {
+		return x.Score
+	}
+	return 0
+}
+
+func (x *ZAddRequest) GetKey() []byte {
+	if x != nil {
+		return x.Key
+	}
+	return nil
+}
+
+func (x *ZAddRequest) GetAtTx() uint64 {
+	if x != nil {
+		return x.AtTx
+	}
+	return 0
+}
+
+func (x *ZAddRequest) GetBoundRef() bool {
+	if x != nil {
+		return x.BoundRef
+	}
+	return false
+}
+
+func (x *ZAddRequest) GetNoWait() bool {
+	if x != nil {
+		return x.NoWait
+	}
+	return false
+}
+
+func (x *ZAddRequest) GetExpiration() *Expiration {
+	if x != nil {
+		return x.Expiration
+	}
+	return nil
+}
+
+type Score struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Score float64 `protobuf:"fixed64,1,opt,name=score,proto3" json:"score,omitempty"`
+}
+
+func (x *Score) Reset() {
+	*x = Score{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_schema_proto_msgTypes[55]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Score) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Score) ProtoMessage() {}
+
+func (x *Score) ProtoReflect() protoreflect.Message {
+	mi := &file_schema_proto_msgTypes[55]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Score.ProtoReflect.Descriptor instead.
+func (*Score) Descriptor() ([]byte, []int) {
+	return file_schema_proto_rawDescGZIP(), []int{55}
+}
+
+func (x *Score) GetScore() float64 {
+	if x != nil {
+		return x.Score
+	}
+	return 0
 }
 
 type ZScanRequest struct {
@@ -3264,23 +4029,25 @@ type ZScanRequest struct {
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Set           []byte  `protobuf:"bytes,1,opt,name=set,proto3" json:"set,omitempty"`
-	SeekKey       []byte  `protobuf:"bytes,2,opt,name=seekKey,proto3" json:"seekKey,omitempty"`
-	SeekScore     float64 `protobuf:"fixed64,3,opt,name=seekScore,proto3" json:"seekScore,omitempty"`
-	SeekAtTx      uint64  `protobuf:"varint,4,opt,name=seekAtTx,proto3" json:"seekAtTx,omitempty"`
-	InclusiveSeek bool    `protobuf:"varint,5,opt,name=inclusiveSeek,proto3" json:"inclusiveSeek,omitempty"`
-	Limit         uint64  `protobuf:"varint,6,opt,name=limit,proto3" json:"limit,omitempty"`
-	Desc          bool    `protobuf:"varint,7,opt,name=desc,proto3" json:"desc,omitempty"`
-	MinScore      *Score  `protobuf:"bytes,8,opt,name=minScore,proto3" json:"minScore,omitempty"`
-	MaxScore      *Score  `protobuf:"bytes,9,opt,name=maxScore,proto3" json:"maxScore,omitempty"`
-	SinceTx       uint64  `protobuf:"varint,10,opt,name=sinceTx,proto3" json:"sinceTx,omitempty"`
-	NoWait        bool    `protobuf:"varint,11,opt,name=noWait,proto3" json:"noWait,omitempty"`
+	Set               []byte  `protobuf:"bytes,1,opt,name=set,proto3" json:"set,omitempty"`
+	SeekKey           []byte  `protobuf:"bytes,2,opt,name=seekKey,proto3" json:"seekKey,omitempty"`
+	SeekScore         float64 `protobuf:"fixed64,3,opt,name=seekScore,proto3" json:"seekScore,omitempty"`
+	SeekAtTx          uint64  `protobuf:"varint,4,opt,name=seekAtTx,proto3" json:"seekAtTx,omitempty"`
+	InclusiveSeek     bool    `protobuf:"varint,5,opt,name=inclusiveSeek,proto3" json:"inclusiveSeek,omitempty"`
+	Limit             uint64  `protobuf:"varint,6,opt,name=limit,proto3" json:"limit,omitempty"`
+	Desc              bool    `protobuf:"varint,7,opt,name=desc,proto3" json:"desc,omitempty"`
+	MinScore          *Score  `protobuf:"bytes,8,opt,name=minScore,proto3" json:"minScore,omitempty"`
+	MaxScore          *Score  `protobuf:"bytes,9,opt,name=maxScore,proto3" json:"maxScore,omitempty"`
+	SinceTx           uint64  `protobuf:"varint,10,opt,name=sinceTx,proto3" json:"sinceTx,omitempty"`
+	NoWait            bool    `protobuf:"varint,11,opt,name=noWait,proto3" json:"noWait,omitempty"`
+	MinScoreExclusive bool    `protobuf:"varint,12,opt,name=minScoreExclusive,proto3" json:"minScoreExclusive,omitempty"`
+	MaxScoreExclusive bool    `protobuf:"varint,13,opt,name=maxScoreExclusive,proto3" json:"maxScoreExclusive,omitempty"`
 }
 
 func (x *ZScanRequest) Reset() {
 	*x = ZScanRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_schema_proto_msgTypes[49]
+		mi := &file_schema_proto_msgTypes[56]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -3293,7 +4060,7 @@ func (x *ZScanRequest) String() string {
 func (*ZScanRequest) ProtoMessage() {}
 
 func (x *ZScanRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_schema_proto_msgTypes[49]
+	mi := &file_schema_proto_msgTypes[56]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3306,7 +4073,7 @@ func (x *ZScanRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ZScanRequest.ProtoReflect.Descriptor instead.
 func (*ZScanRequest) Descriptor() ([]byte, []int) {
-	return file_schema_proto_rawDescGZIP(), []int{49}
+	return file_schema_proto_rawDescGZIP(), []int{56}
 }
 
 func (x *ZScanRequest) GetSet() []byte {
@@ -3386,22 +4153,40 @@ func (x *ZScanRequest) GetNoWait() bool {
 	return false
 }
 
+func (x *ZScanRequest) GetMinScoreExclusive() bool {
+	if x != nil {
+		return x.MinScoreExclusive
+	}
+	return false
+}
+
+func (x *ZScanRequest) GetMaxScoreExclusive() bool {
+	if x != nil {
+		return x.MaxScoreExclusive
+	}
+	return false
+}
+
 type HistoryRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Key     []byte `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
-	Offset  uint64 `protobuf:"varint,2,opt,name=offset,proto3" json:"offset,omitempty"`
-	Limit   int32  `protobuf:"varint,3,opt,name=limit,proto3" json:"limit,omitempty"`
-	Desc    bool   `protobuf:"varint,4,opt,name=desc,proto3" json:"desc,omitempty"`
-	SinceTx uint64 `protobuf:"varint,5,opt,name=sinceTx,proto3" json:"sinceTx,omitempty"`
+	Key       []byte `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Offset    uint64 `protobuf:"varint,2,opt,name=offset,proto3" json:"offset,omitempty"`
+	Limit     int32  `protobuf:"varint,3,opt,name=limit,proto3" json:"limit,omitempty"`
+	Desc      bool   `protobuf:"varint,4,opt,name=desc,proto3" json:"desc,omitempty"`
+	SinceTx   uint64 `protobuf:"varint,5,opt,name=sinceTx,proto3" json:"sinceTx,omitempty"`
+	StartTime int64  `protobuf:"varint,6,opt,name=startTime,proto3" json:"startTime,omitempty"`
+	EndTime   int64  `protobuf:"varint,7,opt,name=endTime,proto3" json:"endTime,omitempty"`
+	// entries of the keys renamed into the key are included, following the whole rename chain
+	FollowRenames bool `protobuf:"varint,8,opt,name=followRenames,proto3" json:"followRenames,omitempty"`
 }
 
 func (x *HistoryRequest) Reset() {
 	*x = HistoryRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_schema_proto_msgTypes[50]
+		mi := &file_schema_proto_msgTypes[57]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -3414,7 +4199,7 @@ func (x *HistoryRequest) String() string {
 func (*HistoryRequest) ProtoMessage() {}
 
 func (x *HistoryRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_schema_proto_msgTypes[50]
+	mi := &file_schema_proto_msgTypes[57]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3427,7 +4212,7 @@ func (x *HistoryRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use HistoryRequest.ProtoReflect.Descriptor instead.
 func (*HistoryRequest) Descriptor() ([]byte, []int) {
-	return file_schema_proto_rawDescGZIP(), []int{50}
+	return file_schema_proto_rawDescGZIP(), []int{57}
 }
 
 func (x *HistoryRequest) GetKey() []byte {
@@ -3465,6 +4250,27 @@ func (x *HistoryRequest) GetSinceTx() uint64 {
 	return 0
 }
 
+func (x *HistoryRequest) GetStartTime() int64 {
+	if x != nil {
+		return x.StartTime
+	}
+	return 0
+}
+
+func (x *HistoryRequest) GetEndTime() int64 {
+	if x != nil {
+		return x.EndTime
+	}
+	return 0
+}
+
+func (x *HistoryRequest) GetFollowRenames() bool {
+	if x != nil {
+		return x.FollowRenames
+	}
+	return false
+}
+
 type VerifiableZAddRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -3477,7 +4283,7 @@ type VerifiableZAddRequest struct {
 func (x *VerifiableZAddRequest) Reset() {
 	*x = VerifiableZAddRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_schema_proto_msgTypes[51]
+		mi := &file_schema_proto_msgTypes[58]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -3490,7 +4296,7 @@ func (x *VerifiableZAddRequest) String() string {
 func (*VerifiableZAddRequest) ProtoMessage() {}
 
 func (x *VerifiableZAddRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_schema_proto_msgTypes[51]
+	mi := &file_schema_proto_msgTypes[58]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3503,7 +4309,7 @@ func (x *VerifiableZAddRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use VerifiableZAddRequest.ProtoReflect.Descriptor instead.
 func (*VerifiableZAddRequest) Descriptor() ([]byte, []int) {
-	return file_schema_proto_rawDescGZIP(), []int{51}
+	return file_schema_proto_rawDescGZIP(), []int{58}
 }
 
 func (x *VerifiableZAddRequest) GetZAddRequest() *ZAddRequest {
@@ -3534,7 +4340,7 @@ type TxRequest struct {
 func (x *TxRequest) Reset() {
 	*x = TxRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_schema_proto_msgTypes[52]
+		mi := &file_schema_proto_msgTypes[59]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -3547,7 +4353,7 @@ func (x *TxRequest) String() string {
 func (*TxRequest) ProtoMessage() {}
 
 func (x *TxRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_schema_proto_msgTypes[52]
+	mi := &file_schema_proto_msgTypes[59]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3560,7 +4366,7 @@ func (x *TxRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use TxRequest.ProtoReflect.Descriptor instead.
 func (*TxRequest) Descriptor() ([]byte, []int) {
-	return file_schema_proto_rawDescGZIP(), []int{52}
+	return file_schema_proto_rawDescGZIP(), []int{59}
 }
 
 func (x *TxRequest) GetTx() uint64 {
@@ -3604,7 +4410,7 @@ type EntriesSpec struct {
 func (x *EntriesSpec) Reset() {
 	*x = EntriesSpec{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_schema_proto_msgTypes[53]
+		mi := &file_schema_proto_msgTypes[60]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -3617,7 +4423,7 @@ func (x *EntriesSpec) String() string {
 func (*EntriesSpec) ProtoMessage() {}
 
 func (x *EntriesSpec) ProtoReflect() protoreflect.Message {
-	mi := &file_schema_proto_msgTypes[53]
+	mi := &file_schema_proto_msgTypes[60]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3630,7 +4436,7 @@ func (x *EntriesSpec) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use EntriesSpec.ProtoReflect.Descriptor instead.
 func (*EntriesSpec) Descriptor() ([]byte, []int) {
-	return file_schema_proto_rawDescGZIP(), []int{53}
+	return file_schema_proto_rawDescGZIP(), []int{60}
 }
 
 func (x *EntriesSpec) GetKvEntriesSpec() *EntryTypeSpec {
@@ -3665,7 +4471,7 @@ type EntryTypeSpec struct {
 func (x *EntryTypeSpec) Reset() {
 	*x = EntryTypeSpec{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_schema_proto_msgTypes[54]
+		mi := &file_schema_proto_msgTypes[61]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -3678,7 +4484,7 @@ func (x *EntryTypeSpec) String() string {
 func (*EntryTypeSpec) ProtoMessage() {}
 
 func (x *EntryTypeSpec) ProtoReflect() protoreflect.Message {
-	mi := &file_schema_proto_msgTypes[54]
+	mi := &file_schema_proto_msgTypes[61]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3691,7 +4497,7 @@ func (x *EntryTypeSpec) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use EntryTypeSpec.ProtoReflect.Descriptor instead.
 func (*EntryTypeSpec) Descriptor() ([]byte, []int) {
-	return file_schema_proto_rawDescGZIP(), []int{54}
+	return file_schema_proto_rawDescGZIP(), []int{61}
 }
 
 func (x *EntryTypeSpec) GetAction() EntryTypeAction {
@@ -3716,7 +4522,7 @@ type VerifiableTxRequest struct {
 func (x *VerifiableTxRequest) Reset() {
 	*x = VerifiableTxRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_schema_proto_msgTypes[55]
+		mi := &file_schema_proto_msgTypes[62]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -3729,7 +4535,7 @@ func (x *VerifiableTxRequest) String() string {
 func (*VerifiableTxRequest) ProtoMessage() {}
 
 func (x *VerifiableTxRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_schema_proto_msgTypes[55]
+	mi := &file_schema_proto_msgTypes[62]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3742,7 +4548,7 @@ func (x *VerifiableTxRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use VerifiableTxRequest.ProtoReflect.Descriptor instead.
 func (*VerifiableTxRequest) Descriptor() ([]byte, []int) {
-	return file_schema_proto_rawDescGZIP(), []int{55}
+	return file_schema_proto_rawDescGZIP(), []int{62}
 }
 
 func (x *VerifiableTxRequest) GetTx() uint64 {
@@ -3791,12 +4597,14 @@ type TxScanRequest struct {
 	EntriesSpec *EntriesSpec `protobuf:"bytes,4,opt,name=entriesSpec,proto3" json:"entriesSpec,omitempty"`
 	SinceTx     uint64       `protobuf:"varint,5,opt,name=sinceTx,proto3" json:"sinceTx,omitempty"`
 	NoWait      bool         `protobuf:"varint,6,opt,name=noWait,proto3" json:"noWait,omitempty"`
+	StartTime   int64        `protobuf:"varint,7,opt,name=startTime,proto3" json:"startTime,omitempty"`
+	EndTime     int64        `protobuf:"varint,8,opt,name=endTime,proto3" json:"endTime,omitempty"`
 }
 
 func (x *TxScanRequest) Reset() {
 	*x = TxScanRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_schema_proto_msgTypes[56]
+		mi := &file_schema_proto_msgTypes[63]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -3809,7 +4617,7 @@ func (x *TxScanRequest) String() string {
 func (*TxScanRequest) ProtoMessage() {}
 
 func (x *TxScanRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_schema_proto_msgTypes[56]
+	mi := &file_schema_proto_msgTypes[63]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3822,7 +4630,7 @@ func (x *TxScanRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use TxScanRequest.ProtoReflect.Descriptor instead.
 func (*TxScanRequest) Descriptor() ([]byte, []int) {
-	return file_schema_proto_rawDescGZIP(), []int{56}
+	return file_schema_proto_rawDescGZIP(), []int{63}
 }
 
 func (x *TxScanRequest) GetInitialTx() uint64 {
@@ -3867,6 +4675,20 @@ func (x *TxScanRequest) GetNoWait() bool {
 	return false
 }
 
+func (x *TxScanRequest) GetStartTime() int64 {
+	if x != nil {
+		return x.StartTime
+	}
+	return 0
+}
+
+func (x *TxScanRequest) GetEndTime() int64 {
+	if x != nil {
+		return x.EndTime
+	}
+	return 0
+}
+
 type TxList struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -3878,7 +4700,7 @@ type TxList struct {
 func (x *TxList) Reset() {
 	*x = TxList{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_schema_proto_msgTypes[57]
+		mi := &file_schema_proto_msgTypes[64]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -3891,7 +4713,7 @@ func (x *TxList) String() string {
 func (*TxList) ProtoMessage() {}
 
 func (x *TxList) ProtoReflect() protoreflect.Message {
-	mi := &file_schema_proto_msgTypes[57]
+	mi := &file_schema_proto_msgTypes[64]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3904,7 +4726,7 @@ func (x *TxList) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use TxList.ProtoReflect.Descriptor instead.
 func (*TxList) Descriptor() ([]byte, []int) {
-	return file_schema_proto_rawDescGZIP(), []int{57}
+	return file_schema_proto_rawDescGZIP(), []int{64}
 }
 
 func (x *TxList) GetTxs() []*Tx {
@@ -3925,7 +4747,7 @@ type ExportTxRequest struct {
 func (x *ExportTxRequest) Reset() {
 	*x = ExportTxRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_schema_proto_msgTypes[58]
+		mi := &file_schema_proto_msgTypes[65]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -3938,7 +4760,7 @@ func (x *ExportTxRequest) String() string {
 func (*ExportTxRequest) ProtoMessage() {}
 
 func (x *ExportTxRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_schema_proto_msgTypes[58]
+	mi := &file_schema_proto_msgTypes[65]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3951,7 +4773,7 @@ func (x *ExportTxRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ExportTxRequest.ProtoReflect.Descriptor instead.
 func (*ExportTxRequest) Descriptor() ([]byte, []int) {
-	return file_schema_proto_rawDescGZIP(), []int{58}
+	return file_schema_proto_rawDescGZIP(), []int{65}
 }
 
 func (x *ExportTxRequest) GetTx() uint64 {
@@ -3972,7 +4794,7 @@ type Database struct {
 func (x *Database) Reset() {
 	*x = Database{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_schema_proto_msgTypes[59]
+		mi := &file_schema_proto_msgTypes[66]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -3985,7 +4807,7 @@ func (x *Database) String() string {
 func (*Database) ProtoMessage() {}
 
 func (x *Database) ProtoReflect() protoreflect.Message {
-	mi := &file_schema_proto_msgTypes[59]
+	mi := &file_schema_proto_msgTypes[66]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3998,7 +4820,7 @@ func (x *Database) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Database.ProtoReflect.Descriptor instead.
 func (*Database) Descriptor() ([]byte, []int) {
-	return file_schema_proto_rawDescGZIP(), []int{59}
+	return file_schema_proto_rawDescGZIP(), []int{66}
 }
 
 func (x *Database) GetDatabaseName() string {
@@ -4030,7 +4852,7 @@ type DatabaseSettings struct {
 func (x *DatabaseSettings) Reset() {
 	*x = DatabaseSettings{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_schema_proto_msgTypes[60]
+		mi := &file_schema_proto_msgTypes[67]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -4043,7 +4865,7 @@ func (x *DatabaseSettings) String() string {
 func (*DatabaseSettings) ProtoMessage() {}
 
 func (x *DatabaseSettings) ProtoReflect() protoreflect.Message {
-	mi := &file_schema_proto_msgTypes[60]
+	mi := &file_schema_proto_msgTypes[67]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -4056,7 +4878,7 @@ func (x *DatabaseSettings) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use DatabaseSettings.ProtoReflect.Descriptor instead.
 func (*DatabaseSettings) Descriptor() ([]byte, []int) {
-	return file_schema_proto_rawDescGZIP(), []int{60}
+	return file_schema_proto_rawDescGZIP(), []int{67}
 }
 
 func (x *DatabaseSettings) GetDatabaseName() string {
@@ -4149,12 +4971,14 @@ type DatabaseSettingsUpdateResult struct {
 	unknownFields protoimpl.UnknownFields
 
 	CurrentSettings *DatabaseSettingsV2 `protobuf:"bytes,1,opt,name=currentSettings,proto3" json:"currentSettings,omitempty"`
+	// settings updated but only taking effect once the database is reloaded, any other setting is already applied
+	ReloadRequired []string `protobuf:"bytes,2,rep,name=reloadRequired,proto3" json:"reloadRequired,omitempty"`
 }
 
 func (x *DatabaseSettingsUpdateResult) Reset() {
 	*x = DatabaseSettingsUpdateResult{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_schema_proto_msgTypes[61]
+		mi := &file_schema_proto_msgTypes[68]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -4167,7 +4991,7 @@ func (x *DatabaseSettingsUpdateResult) String() string {
 func (*DatabaseSettingsUpdateResult) ProtoMessage() {}
 
 func (x *DatabaseSettingsUpdateResult) ProtoReflect() protoreflect.Message {
-	mi := &file_schema_proto_msgTypes[61]
+	mi := &file_schema_proto_msgTypes[68]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -4180,7 +5004,7 @@ func (x *DatabaseSettingsUpdateResult) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use DatabaseSettingsUpdateResult.ProtoReflect.Descriptor instead.
 func (*DatabaseSettingsUpdateResult) Descriptor() ([]byte, []int) {
-	return file_schema_proto_rawDescGZIP(), []int{61}
+	return file_schema_proto_rawDescGZIP(), []int{68}
 }
 
 func (x *DatabaseSettingsUpdateResult) GetCurrentSettings() *DatabaseSettingsV2 {
@@ -4190,31 +5014,41 @@ func (x *DatabaseSettingsUpdateResult) GetCurrentSettings() *DatabaseSettingsV2
 	return nil
 }
 
-type ConditionalUint32 struct {
+func (x *DatabaseSettingsUpdateResult) GetReloadRequired() []string {
+	if x != nil {
+		return x.ReloadRequired
+	}
+	return nil
+}
+
+type CloneDatabaseRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Value uint32 `protobuf:"varint,1,opt,name=value,proto3" json:"value,omitempty"`
+	SourceDatabase string `protobuf:"bytes,1,opt,name=sourceDatabase,proto3" json:"sourceDatabase,omitempty"`
+	DatabaseName   string `protobuf:"bytes,2,opt,name=databaseName,proto3" json:"databaseName,omitempty"`
+	// last transaction of the source database included in the clone, the latest one when zero
+	AsOfTx uint64 `protobuf:"varint,3,opt,name=asOfTx,proto3" json:"asOfTx,omitempty"`
 }
 
-func (x *ConditionalUint32) Reset() {
-	*x = ConditionalUint32{}
+func (x *CloneDatabaseRequest) Reset() {
+	*x = CloneDatabaseRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_schema_proto_msgTypes[62]
+		mi := &file_schema_proto_msgTypes[69]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *ConditionalUint32) String() string {
+func (x *CloneDatabaseRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ConditionalUint32) ProtoMessage() {}
+func (*CloneDatabaseRequest) ProtoMessage() {}
 
-func (x *ConditionalUint32) ProtoReflect() protoreflect.Message {
-	mi := &file_schema_proto_msgTypes[62]
+func (x *CloneDatabaseRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_schema_proto_msgTypes[69]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -4225,43 +5059,58 @@ func (x *ConditionalUint32) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ConditionalUint32.ProtoReflect.Descriptor instead.
-func (*ConditionalUint32) Descriptor() ([]byte, []int) {
-	return file_schema_proto_rawDescGZIP(), []int{62}
+// Deprecated: Use CloneDatabaseRequest.ProtoReflect.Descriptor instead.
+func (*CloneDatabaseRequest) Descriptor() ([]byte, []int) {
+	return file_schema_proto_rawDescGZIP(), []int{69}
 }
 
-func (x *ConditionalUint32) GetValue() uint32 {
+func (x *CloneDatabaseRequest) GetSourceDatabase() string {
 	if x != nil {
-		return x.Value
+		return x.SourceDatabase
+	}
+	return ""
+}
+
+func (x *CloneDatabaseRequest) GetDatabaseName() string {
+	if x != nil {
+		return x.DatabaseName
+	}
+	return ""
+}
+
+func (x *CloneDatabaseRequest) GetAsOfTx() uint64 {
+	if x != nil {
+		return x.AsOfTx
 	}
 	return 0
 }
 
-type ConditionalUint64 struct {
+type CloneDatabaseResponse struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Value uint64 `protobuf:"varint,1,opt,name=value,proto3" json:"value,omitempty"`
+	Settings *DatabaseSettingsV2 `protobuf:"bytes,1,opt,name=settings,proto3" json:"settings,omitempty"`
+	TxId     uint64              `protobuf:"varint,2,opt,name=txId,proto3" json:"txId,omitempty"`
 }
 
-func (x *ConditionalUint64) Reset() {
-	*x = ConditionalUint64{}
+func (x *CloneDatabaseResponse) Reset() {
+	*x = CloneDatabaseResponse{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_schema_proto_msgTypes[63]
+		mi := &file_schema_proto_msgTypes[70]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *ConditionalUint64) String() string {
+func (x *CloneDatabaseResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ConditionalUint64) ProtoMessage() {}
+func (*CloneDatabaseResponse) ProtoMessage() {}
 
-func (x *ConditionalUint64) ProtoReflect() protoreflect.Message {
-	mi := &file_schema_proto_msgTypes[63]
+func (x *CloneDatabaseResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_schema_proto_msgTypes[70]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -4272,43 +5121,53 @@ func (x *ConditionalUint64) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ConditionalUint64.ProtoReflect.Descriptor instead.
-func (*ConditionalUint64) Descriptor() ([]byte, []int) {
-	return file_schema_proto_rawDescGZIP(), []int{63}
+// Deprecated: Use CloneDatabaseResponse.ProtoReflect.Descriptor instead.
+func (*CloneDatabaseResponse) Descriptor() ([]byte, []int) {
+	return file_schema_proto_rawDescGZIP(), []int{70}
 }
 
-func (x *ConditionalUint64) GetValue() uint64 {
+func (x *CloneDatabaseResponse) GetSettings() *DatabaseSettingsV2 {
 	if x != nil {
-		return x.Value
+		return x.Settings
+	}
+	return nil
+}
+
+func (x *CloneDatabaseResponse) GetTxId() uint64 {
+	if x != nil {
+		return x.TxId
 	}
 	return 0
 }
 
-type ConditionalFloat struct {
+type DatabaseSettingsHistoryRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Value float32 `protobuf:"fixed32,1,opt,name=value,proto3" json:"value,omitempty"`
+	Database string `protobuf:"bytes,1,opt,name=database,proto3" json:"database,omitempty"`
+	Offset   uint64 `protobuf:"varint,2,opt,name=offset,proto3" json:"offset,omitempty"`
+	Limit    uint32 `protobuf:"varint,3,opt,name=limit,proto3" json:"limit,omitempty"`
+	Desc     bool   `protobuf:"varint,4,opt,name=desc,proto3" json:"desc,omitempty"`
 }
 
-func (x *ConditionalFloat) Reset() {
-	*x = ConditionalFloat{}
+func (x *DatabaseSettingsHistoryRequest) Reset() {
+	*x = DatabaseSettingsHistoryRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_schema_proto_msgTypes[64]
+		mi := &file_schema_proto_msgTypes[71]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *ConditionalFloat) String() string {
+func (x *DatabaseSettingsHistoryRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ConditionalFloat) ProtoMessage() {}
+func (*DatabaseSettingsHistoryRequest) ProtoMessage() {}
 
-func (x *ConditionalFloat) ProtoReflect() protoreflect.Message {
-	mi := &file_schema_proto_msgTypes[64]
+func (x *DatabaseSettingsHistoryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_schema_proto_msgTypes[71]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -4319,43 +5178,64 @@ func (x *ConditionalFloat) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ConditionalFloat.ProtoReflect.Descriptor instead.
-func (*ConditionalFloat) Descriptor() ([]byte, []int) {
-	return file_schema_proto_rawDescGZIP(), []int{64}
+// Deprecated: Use DatabaseSettingsHistoryRequest.ProtoReflect.Descriptor instead.
+func (*DatabaseSettingsHistoryRequest) Descriptor() ([]byte, []int) {
+	return file_schema_proto_rawDescGZIP(), []int{71}
 }
 
-func (x *ConditionalFloat) GetValue() float32 {
+func (x *DatabaseSettingsHistoryRequest) GetDatabase() string {
 	if x != nil {
-		return x.Value
+		return x.Database
+	}
+	return ""
+}
+
+func (x *DatabaseSettingsHistoryRequest) GetOffset() uint64 {
+	if x != nil {
+		return x.Offset
 	}
 	return 0
 }
 
-type ConditionalBool struct {
+func (x *DatabaseSettingsHistoryRequest) GetLimit() uint32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *DatabaseSettingsHistoryRequest) GetDesc() bool {
+	if x != nil {
+		return x.Desc
+	}
+	return false
+}
+
+type DatabaseSettingsHistory struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Value bool `protobuf:"varint,1,opt,name=value,proto3" json:"value,omitempty"`
+	Versions []*DatabaseSettingsVersion `protobuf:"bytes,1,rep,name=versions,proto3" json:"versions,omitempty"`
 }
 
-func (x *ConditionalBool) Reset() {
-	*x = ConditionalBool{}
+func (x *DatabaseSettingsHistory) Reset() {
+	*x = DatabaseSettingsHistory{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_schema_proto_msgTypes[65]
+		mi := &file_schema_proto_msgTypes[72]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *ConditionalBool) String() string {
+func (x *DatabaseSettingsHistory) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ConditionalBool) ProtoMessage() {}
+func (*DatabaseSettingsHistory) ProtoMessage() {}
 
-func (x *ConditionalBool) ProtoReflect() protoreflect.Message {
-	mi := &file_schema_proto_msgTypes[65]
+func (x *DatabaseSettingsHistory) ProtoReflect() protoreflect.Message {
+	mi := &file_schema_proto_msgTypes[72]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -4366,43 +5246,49 @@ func (x *ConditionalBool) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ConditionalBool.ProtoReflect.Descriptor instead.
-func (*ConditionalBool) Descriptor() ([]byte, []int) {
-	return file_schema_proto_rawDescGZIP(), []int{65}
+// Deprecated: Use DatabaseSettingsHistory.ProtoReflect.Descriptor instead.
+func (*DatabaseSettingsHistory) Descriptor() ([]byte, []int) {
+	return file_schema_proto_rawDescGZIP(), []int{72}
 }
 
-func (x *ConditionalBool) GetValue() bool {
+func (x *DatabaseSettingsHistory) GetVersions() []*DatabaseSettingsVersion {
 	if x != nil {
-		return x.Value
+		return x.Versions
 	}
-	return false
+	return nil
 }
 
-type ConditionalString struct {
+type DatabaseSettingsVersion struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Value string `protobuf:"bytes,1,opt,name=value,proto3" json:"value,omitempty"`
+	// versions are numbered from 1, the first one holding the settings the database was created with
+	Version   uint64              `protobuf:"varint,1,opt,name=version,proto3" json:"version,omitempty"`
+	UpdatedBy string              `protobuf:"bytes,2,opt,name=updatedBy,proto3" json:"updatedBy,omitempty"`
+	UpdatedAt int64               `protobuf:"varint,3,opt,name=updatedAt,proto3" json:"updatedAt,omitempty"`
+	Settings  *DatabaseSettingsV2 `protobuf:"bytes,4,opt,name=settings,proto3" json:"settings,omitempty"`
+	// settings changed from the previous version
+	Changes []*DatabaseSettingChange `protobuf:"bytes,5,rep,name=changes,proto3" json:"changes,omitempty"`
 }
 
-func (x *ConditionalString) Reset() {
-	*x = ConditionalString{}
+func (x *DatabaseSettingsVersion) Reset() {
+	*x = DatabaseSettingsVersion{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_schema_proto_msgTypes[66]
+		mi := &file_schema_proto_msgTypes[73]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *ConditionalString) String() string {
+func (x *DatabaseSettingsVersion) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ConditionalString) ProtoMessage() {}
+func (*DatabaseSettingsVersion) ProtoMessage() {}
 
-func (x *ConditionalString) ProtoReflect() protoreflect.Message {
-	mi := &file_schema_proto_msgTypes[66]
+func (x *DatabaseSettingsVersion) ProtoReflect() protoreflect.Message {
+	mi := &file_schema_proto_msgTypes[73]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -4413,48 +5299,73 @@ func (x *ConditionalString) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ConditionalString.ProtoReflect.Descriptor instead.
-func (*ConditionalString) Descriptor() ([]byte, []int) {
-	return file_schema_proto_rawDescGZIP(), []int{66}
+// Deprecated: Use DatabaseSettingsVersion.ProtoReflect.Descriptor instead.
+func (*DatabaseSettingsVersion) Descriptor() ([]byte, []int) {
+	return file_schema_proto_rawDescGZIP(), []int{73}
 }
 
-func (x *ConditionalString) GetValue() string {
+func (x *DatabaseSettingsVersion) GetVersion() uint64 {
 	if x != nil {
-		return x.Value
+		return x.Version
+	}
+	return 0
+}
+
+func (x *DatabaseSettingsVersion) GetUpdatedBy() string {
+	if x != nil {
+		return x.UpdatedBy
 	}
 	return ""
 }
 
-type ReplicationSettings struct {
+func (x *DatabaseSettingsVersion) GetUpdatedAt() int64 {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return 0
+}
+
+func (x *DatabaseSettingsVersion) GetSettings() *DatabaseSettingsV2 {
+	if x != nil {
+		return x.Settings
+	}
+	return nil
+}
+
+func (x *DatabaseSettingsVersion) GetChanges() []*DatabaseSettingChange {
+	if x != nil {
+		return x.Changes
+	}
+	return nil
+}
+
+type DatabaseSettingChange struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Replica          *ConditionalBool   `protobuf:"bytes,1,opt,name=replica,proto3" json:"replica,omitempty"`
-	MasterDatabase   *ConditionalString `protobuf:"bytes,2,opt,name=masterDatabase,proto3" json:"masterDatabase,omitempty"`
-	MasterAddress    *ConditionalString `protobuf:"bytes,3,opt,name=masterAddress,proto3" json:"masterAddress,omitempty"`
-	MasterPort       *ConditionalUint32 `protobuf:"bytes,4,opt,name=masterPort,proto3" json:"masterPort,omitempty"`
-	FollowerUsername *ConditionalString `protobuf:"bytes,5,opt,name=followerUsername,proto3" json:"followerUsername,omitempty"`
-	FollowerPassword *ConditionalString `protobuf:"bytes,6,opt,name=followerPassword,proto3" json:"followerPassword,omitempty"`
+	Setting  string `protobuf:"bytes,1,opt,name=setting,proto3" json:"setting,omitempty"`
+	OldValue string `protobuf:"bytes,2,opt,name=oldValue,proto3" json:"oldValue,omitempty"`
+	NewValue string `protobuf:"bytes,3,opt,name=newValue,proto3" json:"newValue,omitempty"`
 }
 
-func (x *ReplicationSettings) Reset() {
-	*x = ReplicationSettings{}
+func (x *DatabaseSettingChange) Reset() {
+	*x = DatabaseSettingChange{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_schema_proto_msgTypes[67]
+		mi := &file_schema_proto_msgTypes[74]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *ReplicationSettings) String() string {
+func (x *DatabaseSettingChange) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ReplicationSettings) ProtoMessage() {}
+func (*DatabaseSettingChange) ProtoMessage() {}
 
-func (x *ReplicationSettings) ProtoReflect() protoreflect.Message {
-	mi := &file_schema_proto_msgTypes[67]
+func (x *DatabaseSettingChange) ProtoReflect() protoreflect.Message {
+	mi := &file_schema_proto_msgTypes[74]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -4465,92 +5376,58 @@ func (x *ReplicationSettings) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ReplicationSettings.ProtoReflect.Descriptor instead.
-func (*ReplicationSettings) Descriptor() ([]byte, []int) {
-	return file_schema_proto_rawDescGZIP(), []int{67}
-}
-
-func (x *ReplicationSettings) GetReplica() *ConditionalBool {
-	if x != nil {
-		return x.Replica
-	}
-	return nil
-}
-
-func (x *ReplicationSettings) GetMasterDatabase() *ConditionalString {
-	if x != nil {
-		return x.MasterDatabase
-	}
-	return nil
-}
-
-func (x *ReplicationSettings) GetMasterAddress() *ConditionalString {
-	if x != nil {
-		return x.MasterAddress
-	}
-	return nil
+// Deprecated: Use DatabaseSettingChange.ProtoReflect.Descriptor instead.
+func (*DatabaseSettingChange) Descriptor() ([]byte, []int) {
+	return file_schema_proto_rawDescGZIP(), []int{74}
 }
 
-func (x *ReplicationSettings) GetMasterPort() *ConditionalUint32 {
+func (x *DatabaseSettingChange) GetSetting() string {
 	if x != nil {
-		return x.MasterPort
+		return x.Setting
 	}
-	return nil
+	return ""
 }
 
-func (x *ReplicationSettings) GetFollowerUsername() *ConditionalString {
+func (x *DatabaseSettingChange) GetOldValue() string {
 	if x != nil {
-		return x.FollowerUsername
+		return x.OldValue
 	}
-	return nil
+	return ""
 }
 
-func (x *ReplicationSettings) GetFollowerPassword() *ConditionalString {
+func (x *DatabaseSettingChange) GetNewValue() string {
 	if x != nil {
-		return x.FollowerPassword
+		return x.NewValue
 	}
-	return nil
+	return ""
 }
 
-type DatabaseSettingsV2 struct {
+type DatabaseSettingsRollbackRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	DatabaseName            string               `protobuf:"bytes,1,opt,name=databaseName,proto3" json:"databaseName,omitempty"`
-	ReplicationSettings     *ReplicationSettings `protobuf:"bytes,2,opt,name=replicationSettings,proto3" json:"replicationSettings,omitempty"`
-	FileSize                *ConditionalUint32   `protobuf:"bytes,8,opt,name=fileSize,proto3" json:"fileSize,omitempty"`
-	MaxKeyLen               *ConditionalUint32   `protobuf:"bytes,9,opt,name=maxKeyLen,proto3" json:"maxKeyLen,omitempty"`
-	MaxValueLen             *ConditionalUint32   `protobuf:"bytes,10,opt,name=maxValueLen,proto3" json:"maxValueLen,omitempty"`
-	MaxTxEntries            *ConditionalUint32   `protobuf:"bytes,11,opt,name=maxTxEntries,proto3" json:"maxTxEntries,omitempty"`
-	ExcludeCommitTime       *ConditionalBool     `protobuf:"bytes,12,opt,name=excludeCommitTime,proto3" json:"excludeCommitTime,omitempty"`
-	MaxConcurrency          *ConditionalUint32   `protobuf:"bytes,13,opt,name=maxConcurrency,proto3" json:"maxConcurrency,omitempty"`
-	MaxIOConcurrency        *ConditionalUint32   `protobuf:"bytes,14,opt,name=maxIOConcurrency,proto3" json:"maxIOConcurrency,omitempty"`
-	TxLogCacheSize          *ConditionalUint32   `protobuf:"bytes,15,opt,name=txLogCacheSize,proto3" json:"txLogCacheSize,omitempty"`
-	VLogMaxOpenedFiles      *ConditionalUint32   `protobuf:"bytes,16,opt,name=vLogMaxOpenedFiles,proto3" json:"vLogMaxOpenedFiles,omitempty"`
-	TxLogMaxOpenedFiles     *ConditionalUint32   `protobuf:"bytes,17,opt,name=txLogMaxOpenedFiles,proto3" json:"txLogMaxOpenedFiles,omitempty"`
-	CommitLogMaxOpenedFiles *ConditionalUint32   `protobuf:"bytes,18,opt,name=commitLogMaxOpenedFiles,proto3" json:"commitLogMaxOpenedFiles,omitempty"`
-	IndexSettings           *IndexSettings       `protobuf:"bytes,19,opt,name=indexSettings,proto3" json:"indexSettings,omitempty"`
-	WriteTxHeaderVersion    *ConditionalUint32   `protobuf:"bytes,20,opt,name=writeTxHeaderVersion,proto3" json:"writeTxHeaderVersion,omitempty"`
+	Database string `protobuf:"bytes,1,opt,name=database,proto3" json:"database,omitempty"`
+	Version  uint64 `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"`
 }
 
-func (x *DatabaseSettingsV2) Reset() {
-	*x = DatabaseSettingsV2{}
+func (x *DatabaseSettingsRollbackRequest) Reset() {
+	*x = DatabaseSettingsRollbackRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_schema_proto_msgTypes[68]
+		mi := &file_schema_proto_msgTypes[75]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *DatabaseSettingsV2) String() string {
+func (x *DatabaseSettingsRollbackRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*DatabaseSettingsV2) ProtoMessage() {}
+func (*DatabaseSettingsRollbackRequest) ProtoMessage() {}
 
-func (x *DatabaseSettingsV2) ProtoReflect() protoreflect.Message {
-	mi := &file_schema_proto_msgTypes[68]
+func (x *DatabaseSettingsRollbackRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_schema_proto_msgTypes[75]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -4561,153 +5438,97 @@ func (x *DatabaseSettingsV2) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use DatabaseSettingsV2.ProtoReflect.Descriptor instead.
-func (*DatabaseSettingsV2) Descriptor() ([]byte, []int) {
-	return file_schema_proto_rawDescGZIP(), []int{68}
+// Deprecated: Use DatabaseSettingsRollbackRequest.ProtoReflect.Descriptor instead.
+func (*DatabaseSettingsRollbackRequest) Descriptor() ([]byte, []int) {
+	return file_schema_proto_rawDescGZIP(), []int{75}
 }
 
-func (x *DatabaseSettingsV2) GetDatabaseName() string {
+func (x *DatabaseSettingsRollbackRequest) GetDatabase() string {
 	if x != nil {
-		return x.DatabaseName
+		return x.Database
 	}
 	return ""
 }
 
-func (x *DatabaseSettingsV2) GetReplicationSettings() *ReplicationSettings {
+func (x *DatabaseSettingsRollbackRequest) GetVersion() uint64 {
 	if x != nil {
-		return x.ReplicationSettings
+		return x.Version
 	}
-	return nil
+	return 0
 }
 
-func (x *DatabaseSettingsV2) GetFileSize() *ConditionalUint32 {
-	if x != nil {
-		return x.FileSize
-	}
-	return nil
+type ConditionalUint32 struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Value uint32 `protobuf:"varint,1,opt,name=value,proto3" json:"value,omitempty"`
 }
 
-func (x *DatabaseSettingsV2) GetMaxKeyLen() *ConditionalUint32 {
-	if x != nil {
-		return x.MaxKeyLen
+func (x *ConditionalUint32) Reset() {
+	*x = ConditionalUint32{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_schema_proto_msgTypes[76]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
 	}
-	return nil
 }
 
-func (x *DatabaseSettingsV2) GetMaxValueLen() *ConditionalUint32 {
-	if x != nil {
-		return x.MaxValueLen
-	}
-	return nil
-}
-
-func (x *DatabaseSettingsV2) GetMaxTxEntries() *ConditionalUint32 {
-	if x != nil {
-		return x.MaxTxEntries
-	}
-	return nil
-}
-
-func (x *DatabaseSettingsV2) GetExcludeCommitTime() *ConditionalBool {
-	if x != nil {
-		return x.ExcludeCommitTime
-	}
-	return nil
-}
-
-func (x *DatabaseSettingsV2) GetMaxConcurrency() *ConditionalUint32 {
-	if x != nil {
-		return x.MaxConcurrency
-	}
-	return nil
-}
-
-func (x *DatabaseSettingsV2) GetMaxIOConcurrency() *ConditionalUint32 {
-	if x != nil {
-		return x.MaxIOConcurrency
-	}
-	return nil
-}
-
-func (x *DatabaseSettingsV2) GetTxLogCacheSize() *ConditionalUint32 {
-	if x != nil {
-		return x.TxLogCacheSize
-	}
-	return nil
-}
-
-func (x *DatabaseSettingsV2) GetVLogMaxOpenedFiles() *ConditionalUint32 {
-	if x != nil {
-		return x.VLogMaxOpenedFiles
-	}
-	return nil
+func (x *ConditionalUint32) String() string {
+	return protoimpl.X.MessageStringOf(x)
 }
 
-func (x *DatabaseSettingsV2) GetTxLogMaxOpenedFiles() *ConditionalUint32 {
-	if x != nil {
-		return x.TxLogMaxOpenedFiles
-	}
-	return nil
-}
+func (*ConditionalUint32) ProtoMessage() {}
 
-func (x *DatabaseSettingsV2) GetCommitLogMaxOpenedFiles() *ConditionalUint32 {
-	if x != nil {
-		return x.CommitLogMaxOpenedFiles
+func (x *ConditionalUint32) ProtoReflect() protoreflect.Message {
+	mi := &file_schema_proto_msgTypes[76]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return nil
+	return mi.MessageOf(x)
 }
 
-func (x *DatabaseSettingsV2) GetIndexSettings() *IndexSettings {
-	if x != nil {
-		return x.IndexSettings
-	}
-	return nil
+// Deprecated: Use ConditionalUint32.ProtoReflect.Descriptor instead.
+func (*ConditionalUint32) Descriptor() ([]byte, []int) {
+	return file_schema_proto_rawDescGZIP(), []int{76}
 }
 
-func (x *DatabaseSettingsV2) GetWriteTxHeaderVersion() *ConditionalUint32 {
+func (x *ConditionalUint32) GetValue() uint32 {
 	if x != nil {
-		return x.WriteTxHeaderVersion
+		return x.Value
 	}
-	return nil
+	return 0
 }
 
-type IndexSettings struct {
+type ConditionalUint64 struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	FlushThreshold           *ConditionalUint32 `protobuf:"bytes,1,opt,name=flushThreshold,proto3" json:"flushThreshold,omitempty"`
-	SyncThreshold            *ConditionalUint32 `protobuf:"bytes,2,opt,name=syncThreshold,proto3" json:"syncThreshold,omitempty"`
-	CacheSize                *ConditionalUint32 `protobuf:"bytes,3,opt,name=cacheSize,proto3" json:"cacheSize,omitempty"`
-	MaxNodeSize              *ConditionalUint32 `protobuf:"bytes,4,opt,name=maxNodeSize,proto3" json:"maxNodeSize,omitempty"`
-	MaxActiveSnapshots       *ConditionalUint32 `protobuf:"bytes,5,opt,name=maxActiveSnapshots,proto3" json:"maxActiveSnapshots,omitempty"`
-	RenewSnapRootAfter       *ConditionalUint64 `protobuf:"bytes,6,opt,name=renewSnapRootAfter,proto3" json:"renewSnapRootAfter,omitempty"`
-	CompactionThld           *ConditionalUint32 `protobuf:"bytes,7,opt,name=compactionThld,proto3" json:"compactionThld,omitempty"`
-	DelayDuringCompaction    *ConditionalUint32 `protobuf:"bytes,8,opt,name=delayDuringCompaction,proto3" json:"delayDuringCompaction,omitempty"`
-	NodesLogMaxOpenedFiles   *ConditionalUint32 `protobuf:"bytes,9,opt,name=nodesLogMaxOpenedFiles,proto3" json:"nodesLogMaxOpenedFiles,omitempty"`
-	HistoryLogMaxOpenedFiles *ConditionalUint32 `protobuf:"bytes,10,opt,name=historyLogMaxOpenedFiles,proto3" json:"historyLogMaxOpenedFiles,omitempty"`
-	CommitLogMaxOpenedFiles  *ConditionalUint32 `protobuf:"bytes,11,opt,name=commitLogMaxOpenedFiles,proto3" json:"commitLogMaxOpenedFiles,omitempty"`
-	FlushBufferSize          *ConditionalUint32 `protobuf:"bytes,12,opt,name=flushBufferSize,proto3" json:"flushBufferSize,omitempty"`
-	CleanupPercentage        *ConditionalFloat  `protobuf:"bytes,13,opt,name=cleanupPercentage,proto3" json:"cleanupPercentage,omitempty"`
+	Value uint64 `protobuf:"varint,1,opt,name=value,proto3" json:"value,omitempty"`
 }
 
-func (x *IndexSettings) Reset() {
-	*x = IndexSettings{}
+func (x *ConditionalUint64) Reset() {
+	*x = ConditionalUint64{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_schema_proto_msgTypes[69]
+		mi := &file_schema_proto_msgTypes[77]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *IndexSettings) String() string {
+func (x *ConditionalUint64) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*IndexSettings) ProtoMessage() {}
+func (*ConditionalUint64) ProtoMessage() {}
 
-func (x *IndexSettings) ProtoReflect() protoreflect.Message {
-	mi := &file_schema_proto_msgTypes[69]
+func (x *ConditionalUint64) ProtoReflect() protoreflect.Message {
+	mi := &file_schema_proto_msgTypes[77]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -4718,128 +5539,90 @@ func (x *IndexSettings) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use IndexSettings.ProtoReflect.Descriptor instead.
-func (*IndexSettings) Descriptor() ([]byte, []int) {
-	return file_schema_proto_rawDescGZIP(), []int{69}
-}
-
-func (x *IndexSettings) GetFlushThreshold() *ConditionalUint32 {
-	if x != nil {
-		return x.FlushThreshold
-	}
-	return nil
-}
-
-func (x *IndexSettings) GetSyncThreshold() *ConditionalUint32 {
-	if x != nil {
-		return x.SyncThreshold
-	}
-	return nil
-}
-
-func (x *IndexSettings) GetCacheSize() *ConditionalUint32 {
-	if x != nil {
-		return x.CacheSize
-	}
-	return nil
-}
-
-func (x *IndexSettings) GetMaxNodeSize() *ConditionalUint32 {
-	if x != nil {
-		return x.MaxNodeSize
-	}
-	return nil
+// Deprecated: Use ConditionalUint64.ProtoReflect.Descriptor instead.
+func (*ConditionalUint64) Descriptor() ([]byte, []int) {
+	return file_schema_proto_rawDescGZIP(), []int{77}
 }
 
-func (x *IndexSettings) GetMaxActiveSnapshots() *ConditionalUint32 {
+func (x *ConditionalUint64) GetValue() uint64 {
 	if x != nil {
-		return x.MaxActiveSnapshots
+		return x.Value
 	}
-	return nil
+	return 0
 }
 
-func (x *IndexSettings) GetRenewSnapRootAfter() *ConditionalUint64 {
-	if x != nil {
-		return x.RenewSnapRootAfter
-	}
-	return nil
-}
+type ConditionalFloat struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
 
-func (x *IndexSettings) GetCompactionThld() *ConditionalUint32 {
-	if x != nil {
-		return x.CompactionThld
-	}
-	return nil
+	Value float32 `protobuf:"fixed32,1,opt,name=value,proto3" json:"value,omitempty"`
 }
 
-func (x *IndexSettings) GetDelayDuringCompaction() *ConditionalUint32 {
-	if x != nil {
-		return x.DelayDuringCompaction
+func (x *ConditionalFloat) Reset() {
+	*x = ConditionalFloat{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_schema_proto_msgTypes[78]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
 	}
-	return nil
 }
 
-func (x *IndexSettings) GetNodesLogMaxOpenedFiles() *ConditionalUint32 {
-	if x != nil {
-		return x.NodesLogMaxOpenedFiles
-	}
-	return nil
+func (x *ConditionalFloat) String() string {
+	return protoimpl.X.MessageStringOf(x)
 }
 
-func (x *IndexSettings) GetHistoryLogMaxOpenedFiles() *ConditionalUint32 {
-	if x != nil {
-		return x.HistoryLogMaxOpenedFiles
-	}
-	return nil
-}
+func (*ConditionalFloat) ProtoMessage() {}
 
-func (x *IndexSettings) GetCommitLogMaxOpenedFiles() *ConditionalUint32 {
-	if x != nil {
-		return x.CommitLogMaxOpenedFiles
+func (x *ConditionalFloat) ProtoReflect() protoreflect.Message {
+	mi := &file_schema_proto_msgTypes[78]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return nil
+	return mi.MessageOf(x)
 }
 
-func (x *IndexSettings) GetFlushBufferSize() *ConditionalUint32 {
-	if x != nil {
-		return x.FlushBufferSize
-	}
-	return nil
+// Deprecated: Use ConditionalFloat.ProtoReflect.Descriptor instead.
+func (*ConditionalFloat) Descriptor() ([]byte, []int) {
+	return file_schema_proto_rawDescGZIP(), []int{78}
 }
 
-func (x *IndexSettings) GetCleanupPercentage() *ConditionalFloat {
+func (x *ConditionalFloat) GetValue() float32 {
 	if x != nil {
-		return x.CleanupPercentage
+		return x.Value
 	}
-	return nil
+	return 0
 }
 
-type FlushIndexRequest struct {
+type ConditionalBool struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	CleanupPercentage float32 `protobuf:"fixed32,1,opt,name=cleanupPercentage,proto3" json:"cleanupPercentage,omitempty"`
-	Synced            bool    `protobuf:"varint,2,opt,name=synced,proto3" json:"synced,omitempty"`
+	Value bool `protobuf:"varint,1,opt,name=value,proto3" json:"value,omitempty"`
 }
 
-func (x *FlushIndexRequest) Reset() {
-	*x = FlushIndexRequest{}
+func (x *ConditionalBool) Reset() {
+	*x = ConditionalBool{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_schema_proto_msgTypes[70]
+		mi := &file_schema_proto_msgTypes[79]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *FlushIndexRequest) String() string {
+func (x *ConditionalBool) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*FlushIndexRequest) ProtoMessage() {}
+func (*ConditionalBool) ProtoMessage() {}
 
-func (x *FlushIndexRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_schema_proto_msgTypes[70]
+func (x *ConditionalBool) ProtoReflect() protoreflect.Message {
+	mi := &file_schema_proto_msgTypes[79]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -4850,50 +5633,43 @@ func (x *FlushIndexRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use FlushIndexRequest.ProtoReflect.Descriptor instead.
-func (*FlushIndexRequest) Descriptor() ([]byte, []int) {
-	return file_schema_proto_rawDescGZIP(), []int{70}
-}
-
-func (x *FlushIndexRequest) GetCleanupPercentage() float32 {
-	if x != nil {
-		return x.CleanupPercentage
-	}
-	return 0
+// Deprecated: Use ConditionalBool.ProtoReflect.Descriptor instead.
+func (*ConditionalBool) Descriptor() ([]byte, []int) {
+	return file_schema_proto_rawDescGZIP(), []int{79}
 }
 
-func (x *FlushIndexRequest) GetSynced() bool {
+func (x *ConditionalBool) GetValue() bool {
 	if x != nil {
-		return x.Synced
+		return x.Value
 	}
 	return false
 }
 
-type Table struct {
+type ConditionalString struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	TableName string `protobuf:"bytes,1,opt,name=tableName,proto3" json:"tableName,omitempty"`
+	Value string `protobuf:"bytes,1,opt,name=value,proto3" json:"value,omitempty"`
 }
 
-func (x *Table) Reset() {
-	*x = Table{}
+func (x *ConditionalString) Reset() {
+	*x = ConditionalString{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_schema_proto_msgTypes[71]
+		mi := &file_schema_proto_msgTypes[80]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *Table) String() string {
+func (x *ConditionalString) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Table) ProtoMessage() {}
+func (*ConditionalString) ProtoMessage() {}
 
-func (x *Table) ProtoReflect() protoreflect.Message {
-	mi := &file_schema_proto_msgTypes[71]
+func (x *ConditionalString) ProtoReflect() protoreflect.Message {
+	mi := &file_schema_proto_msgTypes[80]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -4904,46 +5680,48 @@ func (x *Table) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Table.ProtoReflect.Descriptor instead.
-func (*Table) Descriptor() ([]byte, []int) {
-	return file_schema_proto_rawDescGZIP(), []int{71}
+// Deprecated: Use ConditionalString.ProtoReflect.Descriptor instead.
+func (*ConditionalString) Descriptor() ([]byte, []int) {
+	return file_schema_proto_rawDescGZIP(), []int{80}
 }
 
-func (x *Table) GetTableName() string {
+func (x *ConditionalString) GetValue() string {
 	if x != nil {
-		return x.TableName
+		return x.Value
 	}
 	return ""
 }
 
-type SQLGetRequest struct {
+type ReplicationSettings struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Table    string      `protobuf:"bytes,1,opt,name=table,proto3" json:"table,omitempty"`
-	PkValues []*SQLValue `protobuf:"bytes,2,rep,name=pkValues,proto3" json:"pkValues,omitempty"`
-	AtTx     uint64      `protobuf:"varint,3,opt,name=atTx,proto3" json:"atTx,omitempty"`
-	SinceTx  uint64      `protobuf:"varint,4,opt,name=sinceTx,proto3" json:"sinceTx,omitempty"`
+	Replica          *ConditionalBool   `protobuf:"bytes,1,opt,name=replica,proto3" json:"replica,omitempty"`
+	MasterDatabase   *ConditionalString `protobuf:"bytes,2,opt,name=masterDatabase,proto3" json:"masterDatabase,omitempty"`
+	MasterAddress    *ConditionalString `protobuf:"bytes,3,opt,name=masterAddress,proto3" json:"masterAddress,omitempty"`
+	MasterPort       *ConditionalUint32 `protobuf:"bytes,4,opt,name=masterPort,proto3" json:"masterPort,omitempty"`
+	FollowerUsername *ConditionalString `protobuf:"bytes,5,opt,name=followerUsername,proto3" json:"followerUsername,omitempty"`
+	FollowerPassword *ConditionalString `protobuf:"bytes,6,opt,name=followerPassword,proto3" json:"followerPassword,omitempty"`
 }
 
-func (x *SQLGetRequest) Reset() {
-	*x = SQLGetRequest{}
+func (x *ReplicationSettings) Reset() {
+	*x = ReplicationSettings{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_schema_proto_msgTypes[72]
+		mi := &file_schema_proto_msgTypes[81]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *SQLGetRequest) String() string {
+func (x *ReplicationSettings) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SQLGetRequest) ProtoMessage() {}
+func (*ReplicationSettings) ProtoMessage() {}
 
-func (x *SQLGetRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_schema_proto_msgTypes[72]
+func (x *ReplicationSettings) ProtoReflect() protoreflect.Message {
+	mi := &file_schema_proto_msgTypes[81]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -4954,122 +5732,110 @@ func (x *SQLGetRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SQLGetRequest.ProtoReflect.Descriptor instead.
-func (*SQLGetRequest) Descriptor() ([]byte, []int) {
-	return file_schema_proto_rawDescGZIP(), []int{72}
+// Deprecated: Use ReplicationSettings.ProtoReflect.Descriptor instead.
+func (*ReplicationSettings) Descriptor() ([]byte, []int) {
+	return file_schema_proto_rawDescGZIP(), []int{81}
 }
 
-func (x *SQLGetRequest) GetTable() string {
+func (x *ReplicationSettings) GetReplica() *ConditionalBool {
 	if x != nil {
-		return x.Table
+		return x.Replica
 	}
-	return ""
+	return nil
 }
 
-func (x *SQLGetRequest) GetPkValues() []*SQLValue {
+func (x *ReplicationSettings) GetMasterDatabase() *ConditionalString {
 	if x != nil {
-		return x.PkValues
+		return x.MasterDatabase
 	}
 	return nil
 }
 
-func (x *SQLGetRequest) GetAtTx() uint64 {
+func (x *ReplicationSettings) GetMasterAddress() *ConditionalString {
 	if x != nil {
-		return x.AtTx
+		return x.MasterAddress
 	}
-	return 0
+	return nil
 }
 
-func (x *SQLGetRequest) GetSinceTx() uint64 {
+func (x *ReplicationSettings) GetMasterPort() *ConditionalUint32 {
 	if x != nil {
-		return x.SinceTx
-	}
-	return 0
-}
-
-type VerifiableSQLGetRequest struct {
-	state         protoimpl.MessageState
-	sizeCache     protoimpl.SizeCache
-	unknownFields protoimpl.UnknownFields
-
-	SqlGetRequest *SQLGetRequest `protobuf:"bytes,1,opt,name=sqlGetRequest,proto3" json:"sqlGetRequest,omitempty"`
-	ProveSinceTx  uint64         `protobuf:"varint,2,opt,name=proveSinceTx,proto3" json:"proveSinceTx,omitempty"`
-}
-
-func (x *VerifiableSQLGetRequest) Reset() {
-	*x = VerifiableSQLGetRequest{}
-	if protoimpl.UnsafeEnabled {
-		mi := &file_schema_proto_msgTypes[73]
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		ms.StoreMessageInfo(mi)
-	}
-}
-
-func (x *VerifiableSQLGetRequest) String() string {
-	return protoimpl.X.MessageStringOf(x)
-}
-
-func (*VerifiableSQLGetRequest) ProtoMessage() {}
-
-func (x *VerifiableSQLGetRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_schema_proto_msgTypes[73]
-	if protoimpl.UnsafeEnabled && x != nil {
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		if ms.LoadMessageInfo() == nil {
-			ms.StoreMessageInfo(mi)
-		}
-		return ms
+		return x.MasterPort
 	}
-	return mi.MessageOf(x)
-}
-
-// Deprecated: Use VerifiableSQLGetRequest.ProtoReflect.Descriptor instead.
-func (*VerifiableSQLGetRequest) Descriptor() ([]byte, []int) {
-	return file_schema_proto_rawDescGZIP(), []int{73}
+	return nil
 }
 
-func (x *VerifiableSQLGetRequest) GetSqlGetRequest() *SQLGetRequest {
+func (x *ReplicationSettings) GetFollowerUsername() *ConditionalString {
 	if x != nil {
-		return x.SqlGetRequest
+		return x.FollowerUsername
 	}
 	return nil
 }
 
-func (x *VerifiableSQLGetRequest) GetProveSinceTx() uint64 {
+func (x *ReplicationSettings) GetFollowerPassword() *ConditionalString {
 	if x != nil {
-		return x.ProveSinceTx
+		return x.FollowerPassword
 	}
-	return 0
+	return nil
 }
 
-type SQLEntry struct {
+type DatabaseSettingsV2 struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Tx       uint64      `protobuf:"varint,1,opt,name=tx,proto3" json:"tx,omitempty"`
-	Key      []byte      `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
-	Value    []byte      `protobuf:"bytes,3,opt,name=value,proto3" json:"value,omitempty"`
-	Metadata *KVMetadata `protobuf:"bytes,4,opt,name=metadata,proto3" json:"metadata,omitempty"`
+	DatabaseName              string               `protobuf:"bytes,1,opt,name=databaseName,proto3" json:"databaseName,omitempty"`
+	ReplicationSettings       *ReplicationSettings `protobuf:"bytes,2,opt,name=replicationSettings,proto3" json:"replicationSettings,omitempty"`
+	FileSize                  *ConditionalUint32   `protobuf:"bytes,8,opt,name=fileSize,proto3" json:"fileSize,omitempty"`
+	MaxKeyLen                 *ConditionalUint32   `protobuf:"bytes,9,opt,name=maxKeyLen,proto3" json:"maxKeyLen,omitempty"`
+	MaxValueLen               *ConditionalUint32   `protobuf:"bytes,10,opt,name=maxValueLen,proto3" json:"maxValueLen,omitempty"`
+	MaxTxEntries              *ConditionalUint32   `protobuf:"bytes,11,opt,name=maxTxEntries,proto3" json:"maxTxEntries,omitempty"`
+	ExcludeCommitTime         *ConditionalBool     `protobuf:"bytes,12,opt,name=excludeCommitTime,proto3" json:"excludeCommitTime,omitempty"`
+	MaxConcurrency            *ConditionalUint32   `protobuf:"bytes,13,opt,name=maxConcurrency,proto3" json:"maxConcurrency,omitempty"`
+	MaxIOConcurrency          *ConditionalUint32   `protobuf:"bytes,14,opt,name=maxIOConcurrency,proto3" json:"maxIOConcurrency,omitempty"`
+	TxLogCacheSize            *ConditionalUint32   `protobuf:"bytes,15,opt,name=txLogCacheSize,proto3" json:"txLogCacheSize,omitempty"`
+	VLogMaxOpenedFiles        *ConditionalUint32   `protobuf:"bytes,16,opt,name=vLogMaxOpenedFiles,proto3" json:"vLogMaxOpenedFiles,omitempty"`
+	TxLogMaxOpenedFiles       *ConditionalUint32   `protobuf:"bytes,17,opt,name=txLogMaxOpenedFiles,proto3" json:"txLogMaxOpenedFiles,omitempty"`
+	CommitLogMaxOpenedFiles   *ConditionalUint32   `protobuf:"bytes,18,opt,name=commitLogMaxOpenedFiles,proto3" json:"commitLogMaxOpenedFiles,omitempty"`
+	IndexSettings             *IndexSettings       `protobuf:"bytes,19,opt,name=indexSettings,proto3" json:"indexSettings,omitempty"`
+	WriteTxHeaderVersion      *ConditionalUint32   `protobuf:"bytes,20,opt,name=writeTxHeaderVersion,proto3" json:"writeTxHeaderVersion,omitempty"`
+	ValueCompression          *ConditionalString   `protobuf:"bytes,21,opt,name=valueCompression,proto3" json:"valueCompression,omitempty"`
+	ValueCompressionThreshold *ConditionalUint32   `protobuf:"bytes,22,opt,name=valueCompressionThreshold,proto3" json:"valueCompressionThreshold,omitempty"`
+	// name of the server-side template the database settings are based on, it can only be set on creation
+	Template string `protobuf:"bytes,23,opt,name=template,proto3" json:"template,omitempty"`
+	// allows reading the database without logging in, writes still require authentication
+	AnonymousReads *ConditionalBool `protobuf:"bytes,24,opt,name=anonymousReads,proto3" json:"anonymousReads,omitempty"`
+	// makes the database temporarily read-only, writes are rejected until maintenance mode is switched off
+	MaintenanceMode *ConditionalBool `protobuf:"bytes,25,opt,name=maintenanceMode,proto3" json:"maintenanceMode,omitempty"`
+	// name of the tenant owning the database, it can only be set on creation
+	Tenant string `protobuf:"bytes,26,opt,name=tenant,proto3" json:"tenant,omitempty"`
+	// disk space in bytes the database may take before writes are rejected, 0 means unlimited
+	MaxDiskUsage *ConditionalUint64 `protobuf:"bytes,27,opt,name=maxDiskUsage,proto3" json:"maxDiskUsage,omitempty"`
+	// writes a reverse entry along with each reference so the references to a key can be resolved with ResolveAll
+	ReverseReferenceIndex *ConditionalBool `protobuf:"bytes,28,opt,name=reverseReferenceIndex,proto3" json:"reverseReferenceIndex,omitempty"`
+	// milliseconds between background scrubs verifying every committed transaction, 0 disables them
+	ScrubInterval *ConditionalUint64 `protobuf:"bytes,29,opt,name=scrubInterval,proto3" json:"scrubInterval,omitempty"`
+	// milliseconds paused after each batch of scrubbed transactions
+	DelayDuringScrub *ConditionalUint32 `protobuf:"bytes,30,opt,name=delayDuringScrub,proto3" json:"delayDuringScrub,omitempty"`
 }
 
-func (x *SQLEntry) Reset() {
-	*x = SQLEntry{}
+func (x *DatabaseSettingsV2) Reset() {
+	*x = DatabaseSettingsV2{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_schema_proto_msgTypes[74]
+		mi := &file_schema_proto_msgTypes[82]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *SQLEntry) String() string {
+func (x *DatabaseSettingsV2) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SQLEntry) ProtoMessage() {}
+func (*DatabaseSettingsV2) ProtoMessage() {}
 
-func (x *SQLEntry) ProtoReflect() protoreflect.Message {
-	mi := &file_schema_proto_msgTypes[74]
+func (x *DatabaseSettingsV2) ProtoReflect() protoreflect.Message {
+	mi := &file_schema_proto_msgTypes[82]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -5080,233 +5846,227 @@ func (x *SQLEntry) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SQLEntry.ProtoReflect.Descriptor instead.
-func (*SQLEntry) Descriptor() ([]byte, []int) {
-	return file_schema_proto_rawDescGZIP(), []int{74}
+// Deprecated: Use DatabaseSettingsV2.ProtoReflect.Descriptor instead.
+func (*DatabaseSettingsV2) Descriptor() ([]byte, []int) {
+	return file_schema_proto_rawDescGZIP(), []int{82}
 }
 
-func (x *SQLEntry) GetTx() uint64 {
+func (x *DatabaseSettingsV2) GetDatabaseName() string {
 	if x != nil {
-		return x.Tx
+		return x.DatabaseName
 	}
-	return 0
+	return ""
 }
 
-func (x *SQLEntry) GetKey() []byte {
+func (x *DatabaseSettingsV2) GetReplicationSettings() *ReplicationSettings {
 	if x != nil {
-		return x.Key
+		return x.ReplicationSettings
 	}
 	return nil
 }
 
-func (x *SQLEntry) GetValue() []byte {
+func (x *DatabaseSettingsV2) GetFileSize() *ConditionalUint32 {
 	if x != nil {
-		return x.Value
+		return x.FileSize
 	}
 	return nil
 }
 
-func (x *SQLEntry) GetMetadata() *KVMetadata {
+func (x *DatabaseSettingsV2) GetMaxKeyLen() *ConditionalUint32 {
 	if x != nil {
-		return x.Metadata
+		return x.MaxKeyLen
 	}
 	return nil
 }
 
-type VerifiableSQLEntry struct {
-	state         protoimpl.MessageState
-	sizeCache     protoimpl.SizeCache
-	unknownFields protoimpl.UnknownFields
-
-	SqlEntry       *SQLEntry         `protobuf:"bytes,1,opt,name=sqlEntry,proto3" json:"sqlEntry,omitempty"`
-	VerifiableTx   *VerifiableTx     `protobuf:"bytes,2,opt,name=verifiableTx,proto3" json:"verifiableTx,omitempty"`
-	InclusionProof *InclusionProof   `protobuf:"bytes,3,opt,name=inclusionProof,proto3" json:"inclusionProof,omitempty"`
-	DatabaseId     uint32            `protobuf:"varint,4,opt,name=DatabaseId,proto3" json:"DatabaseId,omitempty"`
-	TableId        uint32            `protobuf:"varint,5,opt,name=TableId,proto3" json:"TableId,omitempty"`
-	PKIDs          []uint32          `protobuf:"varint,16,rep,packed,name=PKIDs,proto3" json:"PKIDs,omitempty"`
-	ColNamesById   map[uint32]string `protobuf:"bytes,8,rep,name=ColNamesById,proto3" json:"ColNamesById,omitempty" protobuf_key:"varint,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
-	ColIdsByName   map[string]uint32 `protobuf:"bytes,9,rep,name=ColIdsByName,proto3" json:"ColIdsByName,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"varint,2,opt,name=value,proto3"`
-	ColTypesById   map[uint32]string `protobuf:"bytes,10,rep,name=ColTypesById,proto3" json:"ColTypesById,omitempty" protobuf_key:"varint,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
-	ColLenById     map[uint32]int32  `protobuf:"bytes,11,rep,name=ColLenById,proto3" json:"ColLenById,omitempty" protobuf_key:"varint,1,opt,name=key,proto3" protobuf_val:"varint,2,opt,name=value,proto3"`
-}
-
-func (x *VerifiableSQLEntry) Reset() {
-	*x = VerifiableSQLEntry{}
-	if protoimpl.UnsafeEnabled {
-		mi := &file_schema_proto_msgTypes[75]
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		ms.StoreMessageInfo(mi)
+func (x *DatabaseSettingsV2) GetMaxValueLen() *ConditionalUint32 {
+	if x != nil {
+		return x.MaxValueLen
 	}
+	return nil
 }
 
-func (x *VerifiableSQLEntry) String() string {
-	return protoimpl.X.MessageStringOf(x)
-}
-
-func (*VerifiableSQLEntry) ProtoMessage() {}
-
-func (x *VerifiableSQLEntry) ProtoReflect() protoreflect.Message {
-	mi := &file_schema_proto_msgTypes[75]
-	if protoimpl.UnsafeEnabled && x != nil {
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		if ms.LoadMessageInfo() == nil {
-			ms.StoreMessageInfo(mi)
-		}
-		return ms
+func (x *DatabaseSettingsV2) GetMaxTxEntries() *ConditionalUint32 {
+	if x != nil {
+		return x.MaxTxEntries
 	}
-	return mi.MessageOf(x)
-}
-
-// Deprecated: Use VerifiableSQLEntry.ProtoReflect.Descriptor instead.
-func (*VerifiableSQLEntry) Descriptor() ([]byte, []int) {
-	return file_schema_proto_rawDescGZIP(), []int{75}
+	return nil
 }
 
-func (x *VerifiableSQLEntry) GetSqlEntry() *SQLEntry {
+func (x *DatabaseSettingsV2) GetExcludeCommitTime() *ConditionalBool {
 	if x != nil {
-		return x.SqlEntry
+		return x.ExcludeCommitTime
 	}
 	return nil
 }
 
-func (x *VerifiableSQLEntry) GetVerifiableTx() *VerifiableTx {
+func (x *DatabaseSettingsV2) GetMaxConcurrency() *ConditionalUint32 {
 	if x != nil {
-		return x.VerifiableTx
+		return x.MaxConcurrency
 	}
 	return nil
 }
 
-func (x *VerifiableSQLEntry) GetInclusionProof() *InclusionProof {
+func (x *DatabaseSettingsV2) GetMaxIOConcurrency() *ConditionalUint32 {
 	if x != nil {
-		return x.InclusionProof
+		return x.MaxIOConcurrency
 	}
 	return nil
 }
 
-func (x *VerifiableSQLEntry) GetDatabaseId() uint32 {
+func (x *DatabaseSettingsV2) GetTxLogCacheSize() *ConditionalUint32 {
 	if x != nil {
-		return x.DatabaseId
+		return x.TxLogCacheSize
 	}
-	return 0
+	return nil
 }
 
-func (x *VerifiableSQLEntry) GetTableId() uint32 {
+func (x *DatabaseSettingsV2) GetVLogMaxOpenedFiles() *ConditionalUint32 {
 	if x != nil {
-		return x.TableId
+		return x.VLogMaxOpenedFiles
 	}
-	return 0
+	return nil
 }
 
-func (x *VerifiableSQLEntry) GetPKIDs() []uint32 {
+func (x *DatabaseSettingsV2) GetTxLogMaxOpenedFiles() *ConditionalUint32 {
 	if x != nil {
-		return x.PKIDs
+		return x.TxLogMaxOpenedFiles
 	}
 	return nil
 }
 
-func (x *VerifiableSQLEntry) GetColNamesById() map[uint32]string {
+func (x *DatabaseSettingsV2) GetCommitLogMaxOpenedFiles() *ConditionalUint32 {
 	if x != nil {
-		return x.ColNamesById
+		return x.CommitLogMaxOpenedFiles
 	}
 	return nil
 }
 
-func (x *VerifiableSQLEntry) GetColIdsByName() map[string]uint32 {
+func (x *DatabaseSettingsV2) GetIndexSettings() *IndexSettings {
 	if x != nil {
-		return x.ColIdsByName
+		return x.IndexSettings
 	}
 	return nil
 }
 
-func (x *VerifiableSQLEntry) GetColTypesById() map[uint32]string {
+func (x *DatabaseSettingsV2) GetWriteTxHeaderVersion() *ConditionalUint32 {
 	if x != nil {
-		return x.ColTypesById
+		return x.WriteTxHeaderVersion
 	}
 	return nil
 }
 
-func (x *VerifiableSQLEntry) GetColLenById() map[uint32]int32 {
+func (x *DatabaseSettingsV2) GetValueCompression() *ConditionalString {
 	if x != nil {
-		return x.ColLenById
+		return x.ValueCompression
 	}
 	return nil
 }
 
-type UseDatabaseReply struct {
-	state         protoimpl.MessageState
-	sizeCache     protoimpl.SizeCache
-	unknownFields protoimpl.UnknownFields
+func (x *DatabaseSettingsV2) GetValueCompressionThreshold() *ConditionalUint32 {
+	if x != nil {
+		return x.ValueCompressionThreshold
+	}
+	return nil
+}
 
-	Token string `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+func (x *DatabaseSettingsV2) GetTemplate() string {
+	if x != nil {
+		return x.Template
+	}
+	return ""
 }
 
-func (x *UseDatabaseReply) Reset() {
-	*x = UseDatabaseReply{}
-	if protoimpl.UnsafeEnabled {
-		mi := &file_schema_proto_msgTypes[76]
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		ms.StoreMessageInfo(mi)
+func (x *DatabaseSettingsV2) GetAnonymousReads() *ConditionalBool {
+	if x != nil {
+		return x.AnonymousReads
 	}
+	return nil
 }
 
-func (x *UseDatabaseReply) String() string {
-	return protoimpl.X.MessageStringOf(x)
+func (x *DatabaseSettingsV2) GetMaintenanceMode() *ConditionalBool {
+	if x != nil {
+		return x.MaintenanceMode
+	}
+	return nil
 }
 
-func (*UseDatabaseReply) ProtoMessage() {}
+func (x *DatabaseSettingsV2) GetTenant() string {
+	if x != nil {
+		return x.Tenant
+	}
+	return ""
+}
 
-func (x *UseDatabaseReply) ProtoReflect() protoreflect.Message {
-	mi := &file_schema_proto_msgTypes[76]
-	if protoimpl.UnsafeEnabled && x != nil {
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		if ms.LoadMessageInfo() == nil {
-			ms.StoreMessageInfo(mi)
-		}
-		return ms
+func (x *DatabaseSettingsV2) GetMaxDiskUsage() *ConditionalUint64 {
+	if x != nil {
+		return x.MaxDiskUsage
 	}
-	return mi.MessageOf(x)
+	return nil
 }
 
-// Deprecated: Use UseDatabaseReply.ProtoReflect.Descriptor instead.
-func (*UseDatabaseReply) Descriptor() ([]byte, []int) {
-	return file_schema_proto_rawDescGZIP(), []int{76}
+func (x *DatabaseSettingsV2) GetReverseReferenceIndex() *ConditionalBool {
+	if x != nil {
+		return x.ReverseReferenceIndex
+	}
+	return nil
 }
 
-func (x *UseDatabaseReply) GetToken() string {
+func (x *DatabaseSettingsV2) GetScrubInterval() *ConditionalUint64 {
 	if x != nil {
-		return x.Token
+		return x.ScrubInterval
 	}
-	return ""
+	return nil
 }
 
-type ChangePermissionRequest struct {
+func (x *DatabaseSettingsV2) GetDelayDuringScrub() *ConditionalUint32 {
+	if x != nil {
+		return x.DelayDuringScrub
+	}
+	return nil
+}
+
+type IndexSettings struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Action     PermissionAction `protobuf:"varint,1,opt,name=action,proto3,enum=immudb.schema.PermissionAction" json:"action,omitempty"`
-	Username   string           `protobuf:"bytes,2,opt,name=username,proto3" json:"username,omitempty"`
-	Database   string           `protobuf:"bytes,3,opt,name=database,proto3" json:"database,omitempty"`
-	Permission uint32           `protobuf:"varint,4,opt,name=permission,proto3" json:"permission,omitempty"`
+	FlushThreshold              *ConditionalUint32 `protobuf:"bytes,1,opt,name=flushThreshold,proto3" json:"flushThreshold,omitempty"`
+	SyncThreshold               *ConditionalUint32 `protobuf:"bytes,2,opt,name=syncThreshold,proto3" json:"syncThreshold,omitempty"`
+	CacheSize                   *ConditionalUint32 `protobuf:"bytes,3,opt,name=cacheSize,proto3" json:"cacheSize,omitempty"`
+	MaxNodeSize                 *ConditionalUint32 `protobuf:"bytes,4,opt,name=maxNodeSize,proto3" json:"maxNodeSize,omitempty"`
+	MaxActiveSnapshots          *ConditionalUint32 `protobuf:"bytes,5,opt,name=maxActiveSnapshots,proto3" json:"maxActiveSnapshots,omitempty"`
+	RenewSnapRootAfter          *ConditionalUint64 `protobuf:"bytes,6,opt,name=renewSnapRootAfter,proto3" json:"renewSnapRootAfter,omitempty"`
+	CompactionThld              *ConditionalUint32 `protobuf:"bytes,7,opt,name=compactionThld,proto3" json:"compactionThld,omitempty"`
+	DelayDuringCompaction       *ConditionalUint32 `protobuf:"bytes,8,opt,name=delayDuringCompaction,proto3" json:"delayDuringCompaction,omitempty"`
+	NodesLogMaxOpenedFiles      *ConditionalUint32 `protobuf:"bytes,9,opt,name=nodesLogMaxOpenedFiles,proto3" json:"nodesLogMaxOpenedFiles,omitempty"`
+	HistoryLogMaxOpenedFiles    *ConditionalUint32 `protobuf:"bytes,10,opt,name=historyLogMaxOpenedFiles,proto3" json:"historyLogMaxOpenedFiles,omitempty"`
+	CommitLogMaxOpenedFiles     *ConditionalUint32 `protobuf:"bytes,11,opt,name=commitLogMaxOpenedFiles,proto3" json:"commitLogMaxOpenedFiles,omitempty"`
+	FlushBufferSize             *ConditionalUint32 `protobuf:"bytes,12,opt,name=flushBufferSize,proto3" json:"flushBufferSize,omitempty"`
+	CleanupPercentage           *ConditionalFloat  `protobuf:"bytes,13,opt,name=cleanupPercentage,proto3" json:"cleanupPercentage,omitempty"`
+	AutoCompaction              *ConditionalBool   `protobuf:"bytes,14,opt,name=autoCompaction,proto3" json:"autoCompaction,omitempty"`
+	AutoCompactionGarbageThld   *ConditionalFloat  `protobuf:"bytes,15,opt,name=autoCompactionGarbageThld,proto3" json:"autoCompactionGarbageThld,omitempty"`
+	AutoCompactionFilesThld     *ConditionalUint32 `protobuf:"bytes,16,opt,name=autoCompactionFilesThld,proto3" json:"autoCompactionFilesThld,omitempty"`
+	AutoCompactionLatencyBudget *ConditionalUint32 `protobuf:"bytes,17,opt,name=autoCompactionLatencyBudget,proto3" json:"autoCompactionLatencyBudget,omitempty"`
 }
 
-func (x *ChangePermissionRequest) Reset() {
-	*x = ChangePermissionRequest{}
+func (x *IndexSettings) Reset() {
+	*x = IndexSettings{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_schema_proto_msgTypes[77]
+		mi := &file_schema_proto_msgTypes[83]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *ChangePermissionRequest) String() string {
+func (x *IndexSettings) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ChangePermissionRequest) ProtoMessage() {}
+func (*IndexSettings) ProtoMessage() {}
 
-func (x *ChangePermissionRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_schema_proto_msgTypes[77]
+func (x *IndexSettings) ProtoReflect() protoreflect.Message {
+	mi := &file_schema_proto_msgTypes[83]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -5317,119 +6077,156 @@ func (x *ChangePermissionRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ChangePermissionRequest.ProtoReflect.Descriptor instead.
-func (*ChangePermissionRequest) Descriptor() ([]byte, []int) {
-	return file_schema_proto_rawDescGZIP(), []int{77}
+// Deprecated: Use IndexSettings.ProtoReflect.Descriptor instead.
+func (*IndexSettings) Descriptor() ([]byte, []int) {
+	return file_schema_proto_rawDescGZIP(), []int{83}
 }
 
-func (x *ChangePermissionRequest) GetAction() PermissionAction {
+func (x *IndexSettings) GetFlushThreshold() *ConditionalUint32 {
 	if x != nil {
-		return x.Action
+		return x.FlushThreshold
 	}
-	return PermissionAction_GRANT
+	return nil
 }
 
-func (x *ChangePermissionRequest) GetUsername() string {
+func (x *IndexSettings) GetSyncThreshold() *ConditionalUint32 {
 	if x != nil {
-		return x.Username
+		return x.SyncThreshold
 	}
-	return ""
+	return nil
 }
 
-func (x *ChangePermissionRequest) GetDatabase() string {
+func (x *IndexSettings) GetCacheSize() *ConditionalUint32 {
 	if x != nil {
-		return x.Database
+		return x.CacheSize
 	}
-	return ""
+	return nil
 }
 
-func (x *ChangePermissionRequest) GetPermission() uint32 {
+func (x *IndexSettings) GetMaxNodeSize() *ConditionalUint32 {
 	if x != nil {
-		return x.Permission
+		return x.MaxNodeSize
 	}
-	return 0
+	return nil
 }
 
-type SetActiveUserRequest struct {
-	state         protoimpl.MessageState
-	sizeCache     protoimpl.SizeCache
-	unknownFields protoimpl.UnknownFields
+func (x *IndexSettings) GetMaxActiveSnapshots() *ConditionalUint32 {
+	if x != nil {
+		return x.MaxActiveSnapshots
+	}
+	return nil
+}
 
-	Active   bool   `protobuf:"varint,1,opt,name=active,proto3" json:"active,omitempty"`
-	Username string `protobuf:"bytes,2,opt,name=username,proto3" json:"username,omitempty"`
+func (x *IndexSettings) GetRenewSnapRootAfter() *ConditionalUint64 {
+	if x != nil {
+		return x.RenewSnapRootAfter
+	}
+	return nil
 }
 
-func (x *SetActiveUserRequest) Reset() {
-	*x = SetActiveUserRequest{}
-	if protoimpl.UnsafeEnabled {
-		mi := &file_schema_proto_msgTypes[78]
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		ms.StoreMessageInfo(mi)
+func (x *IndexSettings) GetCompactionThld() *ConditionalUint32 {
+	if x != nil {
+		return x.CompactionThld
 	}
+	return nil
 }
 
-func (x *SetActiveUserRequest) String() string {
-	return protoimpl.X.MessageStringOf(x)
+func (x *IndexSettings) GetDelayDuringCompaction() *ConditionalUint32 {
+	if x != nil {
+		return x.DelayDuringCompaction
+	}
+	return nil
 }
 
-func (*SetActiveUserRequest) ProtoMessage() {}
+func (x *IndexSettings) GetNodesLogMaxOpenedFiles() *ConditionalUint32 {
+	if x != nil {
+		return x.NodesLogMaxOpenedFiles
+	}
+	return nil
+}
 
-func (x *SetActiveUserRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_schema_proto_msgTypes[78]
-	if protoimpl.UnsafeEnabled && x != nil {
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		if ms.LoadMessageInfo() == nil {
-			ms.StoreMessageInfo(mi)
-		}
-		return ms
+func (x *IndexSettings) GetHistoryLogMaxOpenedFiles() *ConditionalUint32 {
+	if x != nil {
+		return x.HistoryLogMaxOpenedFiles
 	}
-	return mi.MessageOf(x)
+	return nil
 }
 
-// Deprecated: Use SetActiveUserRequest.ProtoReflect.Descriptor instead.
-func (*SetActiveUserRequest) Descriptor() ([]byte, []int) {
-	return file_schema_proto_rawDescGZIP(), []int{78}
+func (x *IndexSettings) GetCommitLogMaxOpenedFiles() *ConditionalUint32 {
+	if x != nil {
+		return x.CommitLogMaxOpenedFiles
+	}
+	return nil
 }
 
-func (x *SetActiveUserRequest) GetActive() bool {
+func (x *IndexSettings) GetFlushBufferSize() *ConditionalUint32 {
 	if x != nil {
-		return x.Active
+		return x.FlushBufferSize
 	}
-	return false
+	return nil
 }
 
-func (x *SetActiveUserRequest) GetUsername() string {
+func (x *IndexSettings) GetCleanupPercentage() *ConditionalFloat {
 	if x != nil {
-		return x.Username
+		return x.CleanupPercentage
 	}
-	return ""
+	return nil
 }
 
-type DatabaseListResponse struct {
+func (x *IndexSettings) GetAutoCompaction() *ConditionalBool {
+	if x != nil {
+		return x.AutoCompaction
+	}
+	return nil
+}
+
+func (x *IndexSettings) GetAutoCompactionGarbageThld() *ConditionalFloat {
+	if x != nil {
+		return x.AutoCompactionGarbageThld
+	}
+	return nil
+}
+
+func (x *IndexSettings) GetAutoCompactionFilesThld() *ConditionalUint32 {
+	if x != nil {
+		return x.AutoCompactionFilesThld
+	}
+	return nil
+}
+
+func (x *IndexSettings) GetAutoCompactionLatencyBudget() *ConditionalUint32 {
+	if x != nil {
+		return x.AutoCompactionLatencyBudget
+	}
+	return nil
+}
+
+type FlushIndexRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Databases []*Database `protobuf:"bytes,1,rep,name=databases,proto3" json:"databases,omitempty"`
+	CleanupPercentage float32 `protobuf:"fixed32,1,opt,name=cleanupPercentage,proto3" json:"cleanupPercentage,omitempty"`
+	Synced            bool    `protobuf:"varint,2,opt,name=synced,proto3" json:"synced,omitempty"`
 }
 
-func (x *DatabaseListResponse) Reset() {
-	*x = DatabaseListResponse{}
+func (x *FlushIndexRequest) Reset() {
+	*x = FlushIndexRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_schema_proto_msgTypes[79]
+		mi := &file_schema_proto_msgTypes[84]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *DatabaseListResponse) String() string {
+func (x *FlushIndexRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*DatabaseListResponse) ProtoMessage() {}
+func (*FlushIndexRequest) ProtoMessage() {}
 
-func (x *DatabaseListResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_schema_proto_msgTypes[79]
+func (x *FlushIndexRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_schema_proto_msgTypes[84]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -5440,43 +6237,50 @@ func (x *DatabaseListResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use DatabaseListResponse.ProtoReflect.Descriptor instead.
-func (*DatabaseListResponse) Descriptor() ([]byte, []int) {
-	return file_schema_proto_rawDescGZIP(), []int{79}
+// Deprecated: Use FlushIndexRequest.ProtoReflect.Descriptor instead.
+func (*FlushIndexRequest) Descriptor() ([]byte, []int) {
+	return file_schema_proto_rawDescGZIP(), []int{84}
 }
 
-func (x *DatabaseListResponse) GetDatabases() []*Database {
+func (x *FlushIndexRequest) GetCleanupPercentage() float32 {
 	if x != nil {
-		return x.Databases
+		return x.CleanupPercentage
 	}
-	return nil
+	return 0
 }
 
-type Chunk struct {
+func (x *FlushIndexRequest) GetSynced() bool {
+	if x != nil {
+		return x.Synced
+	}
+	return false
+}
+
+type Table struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Content []byte `protobuf:"bytes,1,opt,name=content,proto3" json:"content,omitempty"`
+	TableName string `protobuf:"bytes,1,opt,name=tableName,proto3" json:"tableName,omitempty"`
 }
 
-func (x *Chunk) Reset() {
-	*x = Chunk{}
+func (x *Table) Reset() {
+	*x = Table{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_schema_proto_msgTypes[80]
+		mi := &file_schema_proto_msgTypes[85]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *Chunk) String() string {
+func (x *Table) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Chunk) ProtoMessage() {}
+func (*Table) ProtoMessage() {}
 
-func (x *Chunk) ProtoReflect() protoreflect.Message {
-	mi := &file_schema_proto_msgTypes[80]
+func (x *Table) ProtoReflect() protoreflect.Message {
+	mi := &file_schema_proto_msgTypes[85]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -5487,44 +6291,46 @@ func (x *Chunk) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Chunk.ProtoReflect.Descriptor instead.
-func (*Chunk) Descriptor() ([]byte, []int) {
-	return file_schema_proto_rawDescGZIP(), []int{80}
+// Deprecated: Use Table.ProtoReflect.Descriptor instead.
+func (*Table) Descriptor() ([]byte, []int) {
+	return file_schema_proto_rawDescGZIP(), []int{85}
 }
 
-func (x *Chunk) GetContent() []byte {
+func (x *Table) GetTableName() string {
 	if x != nil {
-		return x.Content
+		return x.TableName
 	}
-	return nil
+	return ""
 }
 
-type UseSnapshotRequest struct {
+type SQLGetRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	SinceTx    uint64 `protobuf:"varint,1,opt,name=sinceTx,proto3" json:"sinceTx,omitempty"`
-	AsBeforeTx uint64 `protobuf:"varint,2,opt,name=asBeforeTx,proto3" json:"asBeforeTx,omitempty"`
+	Table    string      `protobuf:"bytes,1,opt,name=table,proto3" json:"table,omitempty"`
+	PkValues []*SQLValue `protobuf:"bytes,2,rep,name=pkValues,proto3" json:"pkValues,omitempty"`
+	AtTx     uint64      `protobuf:"varint,3,opt,name=atTx,proto3" json:"atTx,omitempty"`
+	SinceTx  uint64      `protobuf:"varint,4,opt,name=sinceTx,proto3" json:"sinceTx,omitempty"`
 }
 
-func (x *UseSnapshotRequest) Reset() {
-	*x = UseSnapshotRequest{}
+func (x *SQLGetRequest) Reset() {
+	*x = SQLGetRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_schema_proto_msgTypes[81]
+		mi := &file_schema_proto_msgTypes[86]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *UseSnapshotRequest) String() string {
+func (x *SQLGetRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*UseSnapshotRequest) ProtoMessage() {}
+func (*SQLGetRequest) ProtoMessage() {}
 
-func (x *UseSnapshotRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_schema_proto_msgTypes[81]
+func (x *SQLGetRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_schema_proto_msgTypes[86]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -5535,52 +6341,65 @@ func (x *UseSnapshotRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use UseSnapshotRequest.ProtoReflect.Descriptor instead.
-func (*UseSnapshotRequest) Descriptor() ([]byte, []int) {
-	return file_schema_proto_rawDescGZIP(), []int{81}
+// Deprecated: Use SQLGetRequest.ProtoReflect.Descriptor instead.
+func (*SQLGetRequest) Descriptor() ([]byte, []int) {
+	return file_schema_proto_rawDescGZIP(), []int{86}
 }
 
-func (x *UseSnapshotRequest) GetSinceTx() uint64 {
+func (x *SQLGetRequest) GetTable() string {
 	if x != nil {
-		return x.SinceTx
+		return x.Table
+	}
+	return ""
+}
+
+func (x *SQLGetRequest) GetPkValues() []*SQLValue {
+	if x != nil {
+		return x.PkValues
+	}
+	return nil
+}
+
+func (x *SQLGetRequest) GetAtTx() uint64 {
+	if x != nil {
+		return x.AtTx
 	}
 	return 0
 }
 
-func (x *UseSnapshotRequest) GetAsBeforeTx() uint64 {
+func (x *SQLGetRequest) GetSinceTx() uint64 {
 	if x != nil {
-		return x.AsBeforeTx
+		return x.SinceTx
 	}
 	return 0
 }
 
-type SQLExecRequest struct {
+type VerifiableSQLGetRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Sql    string        `protobuf:"bytes,1,opt,name=sql,proto3" json:"sql,omitempty"`
-	Params []*NamedParam `protobuf:"bytes,2,rep,name=params,proto3" json:"params,omitempty"`
-	NoWait bool          `protobuf:"varint,3,opt,name=noWait,proto3" json:"noWait,omitempty"`
+	SqlGetRequest *SQLGetRequest `protobuf:"bytes,1,opt,name=sqlGetRequest,proto3" json:"sqlGetRequest,omitempty"`
+	ProveSinceTx  uint64         `protobuf:"varint,2,opt,name=proveSinceTx,proto3" json:"proveSinceTx,omitempty"`
 }
 
-func (x *SQLExecRequest) Reset() {
-	*x = SQLExecRequest{}
+func (x *VerifiableSQLGetRequest) Reset() {
+	*x = VerifiableSQLGetRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_schema_proto_msgTypes[82]
+		mi := &file_schema_proto_msgTypes[87]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *SQLExecRequest) String() string {
+func (x *VerifiableSQLGetRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SQLExecRequest) ProtoMessage() {}
+func (*VerifiableSQLGetRequest) ProtoMessage() {}
 
-func (x *SQLExecRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_schema_proto_msgTypes[82]
+func (x *VerifiableSQLGetRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_schema_proto_msgTypes[87]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -5591,59 +6410,53 @@ func (x *SQLExecRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SQLExecRequest.ProtoReflect.Descriptor instead.
-func (*SQLExecRequest) Descriptor() ([]byte, []int) {
-	return file_schema_proto_rawDescGZIP(), []int{82}
-}
-
-func (x *SQLExecRequest) GetSql() string {
-	if x != nil {
-		return x.Sql
-	}
-	return ""
+// Deprecated: Use VerifiableSQLGetRequest.ProtoReflect.Descriptor instead.
+func (*VerifiableSQLGetRequest) Descriptor() ([]byte, []int) {
+	return file_schema_proto_rawDescGZIP(), []int{87}
 }
 
-func (x *SQLExecRequest) GetParams() []*NamedParam {
+func (x *VerifiableSQLGetRequest) GetSqlGetRequest() *SQLGetRequest {
 	if x != nil {
-		return x.Params
+		return x.SqlGetRequest
 	}
 	return nil
 }
 
-func (x *SQLExecRequest) GetNoWait() bool {
+func (x *VerifiableSQLGetRequest) GetProveSinceTx() uint64 {
 	if x != nil {
-		return x.NoWait
+		return x.ProveSinceTx
 	}
-	return false
+	return 0
 }
 
-type SQLQueryRequest struct {
+type SQLEntry struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Sql           string        `protobuf:"bytes,1,opt,name=sql,proto3" json:"sql,omitempty"`
-	Params        []*NamedParam `protobuf:"bytes,2,rep,name=params,proto3" json:"params,omitempty"`
-	ReuseSnapshot bool          `protobuf:"varint,3,opt,name=reuseSnapshot,proto3" json:"reuseSnapshot,omitempty"`
+	Tx       uint64      `protobuf:"varint,1,opt,name=tx,proto3" json:"tx,omitempty"`
+	Key      []byte      `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
+	Value    []byte      `protobuf:"bytes,3,opt,name=value,proto3" json:"value,omitempty"`
+	Metadata *KVMetadata `protobuf:"bytes,4,opt,name=metadata,proto3" json:"metadata,omitempty"`
 }
 
-func (x *SQLQueryRequest) Reset() {
-	*x = SQLQueryRequest{}
+func (x *SQLEntry) Reset() {
+	*x = SQLEntry{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_schema_proto_msgTypes[83]
+		mi := &file_schema_proto_msgTypes[88]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *SQLQueryRequest) String() string {
+func (x *SQLEntry) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SQLQueryRequest) ProtoMessage() {}
+func (*SQLEntry) ProtoMessage() {}
 
-func (x *SQLQueryRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_schema_proto_msgTypes[83]
+func (x *SQLEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_schema_proto_msgTypes[88]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -5654,58 +6467,73 @@ func (x *SQLQueryRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SQLQueryRequest.ProtoReflect.Descriptor instead.
-func (*SQLQueryRequest) Descriptor() ([]byte, []int) {
-	return file_schema_proto_rawDescGZIP(), []int{83}
+// Deprecated: Use SQLEntry.ProtoReflect.Descriptor instead.
+func (*SQLEntry) Descriptor() ([]byte, []int) {
+	return file_schema_proto_rawDescGZIP(), []int{88}
 }
 
-func (x *SQLQueryRequest) GetSql() string {
+func (x *SQLEntry) GetTx() uint64 {
 	if x != nil {
-		return x.Sql
+		return x.Tx
 	}
-	return ""
+	return 0
 }
 
-func (x *SQLQueryRequest) GetParams() []*NamedParam {
+func (x *SQLEntry) GetKey() []byte {
 	if x != nil {
-		return x.Params
+		return x.Key
 	}
 	return nil
 }
 
-func (x *SQLQueryRequest) GetReuseSnapshot() bool {
+func (x *SQLEntry) GetValue() []byte {
 	if x != nil {
-		return x.ReuseSnapshot
+		return x.Value
 	}
-	return false
+	return nil
 }
 
-type NamedParam struct {
+func (x *SQLEntry) GetMetadata() *KVMetadata {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+type VerifiableSQLEntry struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Name  string    `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
-	Value *SQLValue `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	SqlEntry       *SQLEntry         `protobuf:"bytes,1,opt,name=sqlEntry,proto3" json:"sqlEntry,omitempty"`
+	VerifiableTx   *VerifiableTx     `protobuf:"bytes,2,opt,name=verifiableTx,proto3" json:"verifiableTx,omitempty"`
+	InclusionProof *InclusionProof   `protobuf:"bytes,3,opt,name=inclusionProof,proto3" json:"inclusionProof,omitempty"`
+	DatabaseId     uint32            `protobuf:"varint,4,opt,name=DatabaseId,proto3" json:"DatabaseId,omitempty"`
+	TableId        uint32            `protobuf:"varint,5,opt,name=TableId,proto3" json:"TableId,omitempty"`
+	PKIDs          []uint32          `protobuf:"varint,16,rep,packed,name=PKIDs,proto3" json:"PKIDs,omitempty"`
+	ColNamesById   map[uint32]string `protobuf:"bytes,8,rep,name=ColNamesById,proto3" json:"ColNamesById,omitempty" protobuf_key:"varint,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	ColIdsByName   map[string]uint32 `protobuf:"bytes,9,rep,name=ColIdsByName,proto3" json:"ColIdsByName,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"varint,2,opt,name=value,proto3"`
+	ColTypesById   map[uint32]string `protobuf:"bytes,10,rep,name=ColTypesById,proto3" json:"ColTypesById,omitempty" protobuf_key:"varint,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	ColLenById     map[uint32]int32  `protobuf:"bytes,11,rep,name=ColLenById,proto3" json:"ColLenById,omitempty" protobuf_key:"varint,1,opt,name=key,proto3" protobuf_val:"varint,2,opt,name=value,proto3"`
 }
 
-func (x *NamedParam) Reset() {
-	*x = NamedParam{}
+func (x *VerifiableSQLEntry) Reset() {
+	*x = VerifiableSQLEntry{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_schema_proto_msgTypes[84]
+		mi := &file_schema_proto_msgTypes[89]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *NamedParam) String() string {
+func (x *VerifiableSQLEntry) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*NamedParam) ProtoMessage() {}
+func (*VerifiableSQLEntry) ProtoMessage() {}
 
-func (x *NamedParam) ProtoReflect() protoreflect.Message {
-	mi := &file_schema_proto_msgTypes[84]
+func (x *VerifiableSQLEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_schema_proto_msgTypes[89]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -5716,108 +6544,106 @@ func (x *NamedParam) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use NamedParam.ProtoReflect.Descriptor instead.
-func (*NamedParam) Descriptor() ([]byte, []int) {
-	return file_schema_proto_rawDescGZIP(), []int{84}
+// Deprecated: Use VerifiableSQLEntry.ProtoReflect.Descriptor instead.
+func (*VerifiableSQLEntry) Descriptor() ([]byte, []int) {
+	return file_schema_proto_rawDescGZIP(), []int{89}
 }
 
-func (x *NamedParam) GetName() string {
+func (x *VerifiableSQLEntry) GetSqlEntry() *SQLEntry {
 	if x != nil {
-		return x.Name
+		return x.SqlEntry
 	}
-	return ""
+	return nil
 }
 
-func (x *NamedParam) GetValue() *SQLValue {
+func (x *VerifiableSQLEntry) GetVerifiableTx() *VerifiableTx {
 	if x != nil {
-		return x.Value
+		return x.VerifiableTx
 	}
 	return nil
 }
 
-type SQLExecResult struct {
-	state         protoimpl.MessageState
-	sizeCache     protoimpl.SizeCache
-	unknownFields protoimpl.UnknownFields
-
-	Txs       []*CommittedSQLTx `protobuf:"bytes,5,rep,name=txs,proto3" json:"txs,omitempty"`
-	OngoingTx bool              `protobuf:"varint,6,opt,name=ongoingTx,proto3" json:"ongoingTx,omitempty"`
-}
-
-func (x *SQLExecResult) Reset() {
-	*x = SQLExecResult{}
-	if protoimpl.UnsafeEnabled {
-		mi := &file_schema_proto_msgTypes[85]
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		ms.StoreMessageInfo(mi)
+func (x *VerifiableSQLEntry) GetInclusionProof() *InclusionProof {
+	if x != nil {
+		return x.InclusionProof
 	}
+	return nil
 }
 
-func (x *SQLExecResult) String() string {
-	return protoimpl.X.MessageStringOf(x)
-}
-
-func (*SQLExecResult) ProtoMessage() {}
-
-func (x *SQLExecResult) ProtoReflect() protoreflect.Message {
-	mi := &file_schema_proto_msgTypes[85]
-	if protoimpl.UnsafeEnabled && x != nil {
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		if ms.LoadMessageInfo() == nil {
-			ms.StoreMessageInfo(mi)
-		}
-		return ms
+func (x *VerifiableSQLEntry) GetDatabaseId() uint32 {
+	if x != nil {
+		return x.DatabaseId
 	}
-	return mi.MessageOf(x)
+	return 0
 }
 
-// Deprecated: Use SQLExecResult.ProtoReflect.Descriptor instead.
-func (*SQLExecResult) Descriptor() ([]byte, []int) {
-	return file_schema_proto_rawDescGZIP(), []int{85}
+func (x *VerifiableSQLEntry) GetTableId() uint32 {
+	if x != nil {
+		return x.TableId
+	}
+	return 0
 }
 
-func (x *SQLExecResult) GetTxs() []*CommittedSQLTx {
+func (x *VerifiableSQLEntry) GetPKIDs() []uint32 {
 	if x != nil {
-		return x.Txs
+		return x.PKIDs
 	}
 	return nil
 }
 
-func (x *SQLExecResult) GetOngoingTx() bool {
+func (x *VerifiableSQLEntry) GetColNamesById() map[uint32]string {
 	if x != nil {
-		return x.OngoingTx
+		return x.ColNamesById
 	}
-	return false
+	return nil
 }
 
-type CommittedSQLTx struct {
+func (x *VerifiableSQLEntry) GetColIdsByName() map[string]uint32 {
+	if x != nil {
+		return x.ColIdsByName
+	}
+	return nil
+}
+
+func (x *VerifiableSQLEntry) GetColTypesById() map[uint32]string {
+	if x != nil {
+		return x.ColTypesById
+	}
+	return nil
+}
+
+func (x *VerifiableSQLEntry) GetColLenById() map[uint32]int32 {
+	if x != nil {
+		return x.ColLenById
+	}
+	return nil
+}
+
+type UseDatabaseReply struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Header           *TxHeader            `protobuf:"bytes,1,opt,name=header,proto3" json:"header,omitempty"`
-	UpdatedRows      uint32               `protobuf:"varint,2,opt,name=updatedRows,proto3" json:"updatedRows,omitempty"`
-	LastInsertedPKs  map[string]*SQLValue `protobuf:"bytes,3,rep,name=lastInsertedPKs,proto3" json:"lastInsertedPKs,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
-	FirstInsertedPKs map[string]*SQLValue `protobuf:"bytes,4,rep,name=firstInsertedPKs,proto3" json:"firstInsertedPKs,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	Token string `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
 }
 
-func (x *CommittedSQLTx) Reset() {
-	*x = CommittedSQLTx{}
+func (x *UseDatabaseReply) Reset() {
+	*x = UseDatabaseReply{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_schema_proto_msgTypes[86]
+		mi := &file_schema_proto_msgTypes[90]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *CommittedSQLTx) String() string {
+func (x *UseDatabaseReply) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*CommittedSQLTx) ProtoMessage() {}
+func (*UseDatabaseReply) ProtoMessage() {}
 
-func (x *CommittedSQLTx) ProtoReflect() protoreflect.Message {
-	mi := &file_schema_proto_msgTypes[86]
+func (x *UseDatabaseReply) ProtoReflect() protoreflect.Message {
+	mi := &file_schema_proto_msgTypes[90]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -5828,65 +6654,46 @@ func (x *CommittedSQLTx) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use CommittedSQLTx.ProtoReflect.Descriptor instead.
-func (*CommittedSQLTx) Descriptor() ([]byte, []int) {
-	return file_schema_proto_rawDescGZIP(), []int{86}
-}
-
-func (x *CommittedSQLTx) GetHeader() *TxHeader {
-	if x != nil {
-		return x.Header
-	}
-	return nil
-}
-
-func (x *CommittedSQLTx) GetUpdatedRows() uint32 {
-	if x != nil {
-		return x.UpdatedRows
-	}
-	return 0
-}
-
-func (x *CommittedSQLTx) GetLastInsertedPKs() map[string]*SQLValue {
-	if x != nil {
-		return x.LastInsertedPKs
-	}
-	return nil
+// Deprecated: Use UseDatabaseReply.ProtoReflect.Descriptor instead.
+func (*UseDatabaseReply) Descriptor() ([]byte, []int) {
+	return file_schema_proto_rawDescGZIP(), []int{90}
 }
 
-func (x *CommittedSQLTx) GetFirstInsertedPKs() map[string]*SQLValue {
+func (x *UseDatabaseReply) GetToken() string {
 	if x != nil {
-		return x.FirstInsertedPKs
+		return x.Token
 	}
-	return nil
+	return ""
 }
 
-type SQLQueryResult struct {
+type ChangePermissionRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Columns []*Column `protobuf:"bytes,2,rep,name=columns,proto3" json:"columns,omitempty"`
-	Rows    []*Row    `protobuf:"bytes,1,rep,name=rows,proto3" json:"rows,omitempty"`
+	Action     PermissionAction `protobuf:"varint,1,opt,name=action,proto3,enum=immudb.schema.PermissionAction" json:"action,omitempty"`
+	Username   string           `protobuf:"bytes,2,opt,name=username,proto3" json:"username,omitempty"`
+	Database   string           `protobuf:"bytes,3,opt,name=database,proto3" json:"database,omitempty"`
+	Permission uint32           `protobuf:"varint,4,opt,name=permission,proto3" json:"permission,omitempty"`
 }
 
-func (x *SQLQueryResult) Reset() {
-	*x = SQLQueryResult{}
+func (x *ChangePermissionRequest) Reset() {
+	*x = ChangePermissionRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_schema_proto_msgTypes[87]
+		mi := &file_schema_proto_msgTypes[91]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *SQLQueryResult) String() string {
+func (x *ChangePermissionRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SQLQueryResult) ProtoMessage() {}
+func (*ChangePermissionRequest) ProtoMessage() {}
 
-func (x *SQLQueryResult) ProtoReflect() protoreflect.Message {
-	mi := &file_schema_proto_msgTypes[87]
+func (x *ChangePermissionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_schema_proto_msgTypes[91]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -5897,51 +6704,65 @@ func (x *SQLQueryResult) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SQLQueryResult.ProtoReflect.Descriptor instead.
-func (*SQLQueryResult) Descriptor() ([]byte, []int) {
-	return file_schema_proto_rawDescGZIP(), []int{87}
+// Deprecated: Use ChangePermissionRequest.ProtoReflect.Descriptor instead.
+func (*ChangePermissionRequest) Descriptor() ([]byte, []int) {
+	return file_schema_proto_rawDescGZIP(), []int{91}
 }
 
-func (x *SQLQueryResult) GetColumns() []*Column {
+func (x *ChangePermissionRequest) GetAction() PermissionAction {
 	if x != nil {
-		return x.Columns
+		return x.Action
 	}
-	return nil
+	return PermissionAction_GRANT
 }
 
-func (x *SQLQueryResult) GetRows() []*Row {
+func (x *ChangePermissionRequest) GetUsername() string {
 	if x != nil {
-		return x.Rows
+		return x.Username
 	}
-	return nil
+	return ""
 }
 
-type Column struct {
+func (x *ChangePermissionRequest) GetDatabase() string {
+	if x != nil {
+		return x.Database
+	}
+	return ""
+}
+
+func (x *ChangePermissionRequest) GetPermission() uint32 {
+	if x != nil {
+		return x.Permission
+	}
+	return 0
+}
+
+type SetActiveUserRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
-	Type string `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
+	Active   bool   `protobuf:"varint,1,opt,name=active,proto3" json:"active,omitempty"`
+	Username string `protobuf:"bytes,2,opt,name=username,proto3" json:"username,omitempty"`
 }
 
-func (x *Column) Reset() {
-	*x = Column{}
+func (x *SetActiveUserRequest) Reset() {
+	*x = SetActiveUserRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_schema_proto_msgTypes[88]
+		mi := &file_schema_proto_msgTypes[92]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *Column) String() string {
+func (x *SetActiveUserRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Column) ProtoMessage() {}
+func (*SetActiveUserRequest) ProtoMessage() {}
 
-func (x *Column) ProtoReflect() protoreflect.Message {
-	mi := &file_schema_proto_msgTypes[88]
+func (x *SetActiveUserRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_schema_proto_msgTypes[92]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -5952,51 +6773,52 @@ func (x *Column) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Column.ProtoReflect.Descriptor instead.
-func (*Column) Descriptor() ([]byte, []int) {
-	return file_schema_proto_rawDescGZIP(), []int{88}
+// Deprecated: Use SetActiveUserRequest.ProtoReflect.Descriptor instead.
+func (*SetActiveUserRequest) Descriptor() ([]byte, []int) {
+	return file_schema_proto_rawDescGZIP(), []int{92}
 }
 
-func (x *Column) GetName() string {
+func (x *SetActiveUserRequest) GetActive() bool {
 	if x != nil {
-		return x.Name
+		return x.Active
 	}
-	return ""
+	return false
 }
 
-func (x *Column) GetType() string {
+func (x *SetActiveUserRequest) GetUsername() string {
 	if x != nil {
-		return x.Type
+		return x.Username
 	}
 	return ""
 }
 
-type Row struct {
+type DatabaseListResponse struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Columns []string    `protobuf:"bytes,1,rep,name=columns,proto3" json:"columns,omitempty"`
-	Values  []*SQLValue `protobuf:"bytes,2,rep,name=values,proto3" json:"values,omitempty"`
+	Databases []*Database `protobuf:"bytes,1,rep,name=databases,proto3" json:"databases,omitempty"`
+	// names of the listed databases which are in maintenance mode
+	InMaintenance []string `protobuf:"bytes,2,rep,name=inMaintenance,proto3" json:"inMaintenance,omitempty"`
 }
 
-func (x *Row) Reset() {
-	*x = Row{}
+func (x *DatabaseListResponse) Reset() {
+	*x = DatabaseListResponse{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_schema_proto_msgTypes[89]
+		mi := &file_schema_proto_msgTypes[93]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *Row) String() string {
+func (x *DatabaseListResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Row) ProtoMessage() {}
+func (*DatabaseListResponse) ProtoMessage() {}
 
-func (x *Row) ProtoReflect() protoreflect.Message {
-	mi := &file_schema_proto_msgTypes[89]
+func (x *DatabaseListResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_schema_proto_msgTypes[93]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -6007,57 +6829,50 @@ func (x *Row) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Row.ProtoReflect.Descriptor instead.
-func (*Row) Descriptor() ([]byte, []int) {
-	return file_schema_proto_rawDescGZIP(), []int{89}
+// Deprecated: Use DatabaseListResponse.ProtoReflect.Descriptor instead.
+func (*DatabaseListResponse) Descriptor() ([]byte, []int) {
+	return file_schema_proto_rawDescGZIP(), []int{93}
 }
 
-func (x *Row) GetColumns() []string {
+func (x *DatabaseListResponse) GetDatabases() []*Database {
 	if x != nil {
-		return x.Columns
+		return x.Databases
 	}
 	return nil
 }
 
-func (x *Row) GetValues() []*SQLValue {
+func (x *DatabaseListResponse) GetInMaintenance() []string {
 	if x != nil {
-		return x.Values
+		return x.InMaintenance
 	}
 	return nil
 }
 
-type SQLValue struct {
+type Chunk struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	// Types that are assignable to Value:
-	//	*SQLValue_Null
-	//	*SQLValue_N
-	//	*SQLValue_S
-	//	*SQLValue_B
-	//	*SQLValue_Bs
-	//	*SQLValue_Ts
-	Value isSQLValue_Value `protobuf_oneof:"value"`
+	Content []byte `protobuf:"bytes,1,opt,name=content,proto3" json:"content,omitempty"`
 }
 
-func (x *SQLValue) Reset() {
-	*x = SQLValue{}
+func (x *Chunk) Reset() {
+	*x = Chunk{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_schema_proto_msgTypes[90]
+		mi := &file_schema_proto_msgTypes[94]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *SQLValue) String() string {
+func (x *Chunk) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SQLValue) ProtoMessage() {}
+func (*Chunk) ProtoMessage() {}
 
-func (x *SQLValue) ProtoReflect() protoreflect.Message {
-	mi := &file_schema_proto_msgTypes[90]
+func (x *Chunk) ProtoReflect() protoreflect.Message {
+	mi := &file_schema_proto_msgTypes[94]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -6068,125 +6883,100 @@ func (x *SQLValue) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SQLValue.ProtoReflect.Descriptor instead.
-func (*SQLValue) Descriptor() ([]byte, []int) {
-	return file_schema_proto_rawDescGZIP(), []int{90}
+// Deprecated: Use Chunk.ProtoReflect.Descriptor instead.
+func (*Chunk) Descriptor() ([]byte, []int) {
+	return file_schema_proto_rawDescGZIP(), []int{94}
 }
 
-func (m *SQLValue) GetValue() isSQLValue_Value {
-	if m != nil {
-		return m.Value
+func (x *Chunk) GetContent() []byte {
+	if x != nil {
+		return x.Content
 	}
 	return nil
 }
 
-func (x *SQLValue) GetNull() _struct.NullValue {
-	if x, ok := x.GetValue().(*SQLValue_Null); ok {
-		return x.Null
-	}
-	return _struct.NullValue(0)
-}
+type UseSnapshotRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
 
-func (x *SQLValue) GetN() int64 {
-	if x, ok := x.GetValue().(*SQLValue_N); ok {
-		return x.N
-	}
-	return 0
+	SinceTx    uint64 `protobuf:"varint,1,opt,name=sinceTx,proto3" json:"sinceTx,omitempty"`
+	AsBeforeTx uint64 `protobuf:"varint,2,opt,name=asBeforeTx,proto3" json:"asBeforeTx,omitempty"`
 }
 
-func (x *SQLValue) GetS() string {
-	if x, ok := x.GetValue().(*SQLValue_S); ok {
-		return x.S
-	}
-	return ""
-}
-
-func (x *SQLValue) GetB() bool {
-	if x, ok := x.GetValue().(*SQLValue_B); ok {
-		return x.B
-	}
-	return false
-}
-
-func (x *SQLValue) GetBs() []byte {
-	if x, ok := x.GetValue().(*SQLValue_Bs); ok {
-		return x.Bs
-	}
-	return nil
-}
-
-func (x *SQLValue) GetTs() int64 {
-	if x, ok := x.GetValue().(*SQLValue_Ts); ok {
-		return x.Ts
+func (x *UseSnapshotRequest) Reset() {
+	*x = UseSnapshotRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_schema_proto_msgTypes[95]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
 	}
-	return 0
-}
-
-type isSQLValue_Value interface {
-	isSQLValue_Value()
 }
 
-type SQLValue_Null struct {
-	Null _struct.NullValue `protobuf:"varint,1,opt,name=null,proto3,enum=google.protobuf.NullValue,oneof"`
+func (x *UseSnapshotRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
 }
 
-type SQLValue_N struct {
-	N int64 `protobuf:"varint,2,opt,name=n,proto3,oneof"`
-}
+func (*UseSnapshotRequest) ProtoMessage() {}
 
-type SQLValue_S struct {
-	S string `protobuf:"bytes,3,opt,name=s,proto3,oneof"`
+func (x *UseSnapshotRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_schema_proto_msgTypes[95]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
 }
 
-type SQLValue_B struct {
-	B bool `protobuf:"varint,4,opt,name=b,proto3,oneof"`
+// Deprecated: Use UseSnapshotRequest.ProtoReflect.Descriptor instead.
+func (*UseSnapshotRequest) Descriptor() ([]byte, []int) {
+	return file_schema_proto_rawDescGZIP(), []int{95}
 }
 
-type SQLValue_Bs struct {
-	Bs []byte `protobuf:"bytes,5,opt,name=bs,proto3,oneof"`
+func (x *UseSnapshotRequest) GetSinceTx() uint64 {
+	if x != nil {
+		return x.SinceTx
+	}
+	return 0
 }
 
-type SQLValue_Ts struct {
-	Ts int64 `protobuf:"varint,6,opt,name=ts,proto3,oneof"`
+func (x *UseSnapshotRequest) GetAsBeforeTx() uint64 {
+	if x != nil {
+		return x.AsBeforeTx
+	}
+	return 0
 }
 
-func (*SQLValue_Null) isSQLValue_Value() {}
-
-func (*SQLValue_N) isSQLValue_Value() {}
-
-func (*SQLValue_S) isSQLValue_Value() {}
-
-func (*SQLValue_B) isSQLValue_Value() {}
-
-func (*SQLValue_Bs) isSQLValue_Value() {}
-
-func (*SQLValue_Ts) isSQLValue_Value() {}
-
-type NewTxRequest struct {
+type SQLExecRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Mode TxMode `protobuf:"varint,1,opt,name=mode,proto3,enum=immudb.schema.TxMode" json:"mode,omitempty"`
+	Sql    string        `protobuf:"bytes,1,opt,name=sql,proto3" json:"sql,omitempty"`
+	Params []*NamedParam `protobuf:"bytes,2,rep,name=params,proto3" json:"params,omitempty"`
+	NoWait bool          `protobuf:"varint,3,opt,name=noWait,proto3" json:"noWait,omitempty"`
 }
 
-func (x *NewTxRequest) Reset() {
-	*x = NewTxRequest{}
+func (x *SQLExecRequest) Reset() {
+	*x = SQLExecRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_schema_proto_msgTypes[91]
+		mi := &file_schema_proto_msgTypes[96]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *NewTxRequest) String() string {
+func (x *SQLExecRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*NewTxRequest) ProtoMessage() {}
+func (*SQLExecRequest) ProtoMessage() {}
 
-func (x *NewTxRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_schema_proto_msgTypes[91]
+func (x *SQLExecRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_schema_proto_msgTypes[96]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -6197,43 +6987,59 @@ func (x *NewTxRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use NewTxRequest.ProtoReflect.Descriptor instead.
-func (*NewTxRequest) Descriptor() ([]byte, []int) {
-	return file_schema_proto_rawDescGZIP(), []int{91}
+// Deprecated: Use SQLExecRequest.ProtoReflect.Descriptor instead.
+func (*SQLExecRequest) Descriptor() ([]byte, []int) {
+	return file_schema_proto_rawDescGZIP(), []int{96}
 }
 
-func (x *NewTxRequest) GetMode() TxMode {
+func (x *SQLExecRequest) GetSql() string {
 	if x != nil {
-		return x.Mode
+		return x.Sql
 	}
-	return TxMode_ReadOnly
+	return ""
 }
 
-type NewTxResponse struct {
+func (x *SQLExecRequest) GetParams() []*NamedParam {
+	if x != nil {
+		return x.Params
+	}
+	return nil
+}
+
+func (x *SQLExecRequest) GetNoWait() bool {
+	if x != nil {
+		return x.NoWait
+	}
+	return false
+}
+
+type SQLQueryRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	TransactionID string `protobuf:"bytes,1,opt,name=transactionID,proto3" json:"transactionID,omitempty"`
+	Sql           string        `protobuf:"bytes,1,opt,name=sql,proto3" json:"sql,omitempty"`
+	Params        []*NamedParam `protobuf:"bytes,2,rep,name=params,proto3" json:"params,omitempty"`
+	ReuseSnapshot bool          `protobuf:"varint,3,opt,name=reuseSnapshot,proto3" json:"reuseSnapshot,omitempty"`
 }
 
-func (x *NewTxResponse) Reset() {
-	*x = NewTxResponse{}
+func (x *SQLQueryRequest) Reset() {
+	*x = SQLQueryRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_schema_proto_msgTypes[92]
+		mi := &file_schema_proto_msgTypes[97]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *NewTxResponse) String() string {
+func (x *SQLQueryRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*NewTxResponse) ProtoMessage() {}
+func (*SQLQueryRequest) ProtoMessage() {}
 
-func (x *NewTxResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_schema_proto_msgTypes[92]
+func (x *SQLQueryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_schema_proto_msgTypes[97]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -6244,44 +7050,58 @@ func (x *NewTxResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use NewTxResponse.ProtoReflect.Descriptor instead.
-func (*NewTxResponse) Descriptor() ([]byte, []int) {
-	return file_schema_proto_rawDescGZIP(), []int{92}
+// Deprecated: Use SQLQueryRequest.ProtoReflect.Descriptor instead.
+func (*SQLQueryRequest) Descriptor() ([]byte, []int) {
+	return file_schema_proto_rawDescGZIP(), []int{97}
 }
 
-func (x *NewTxResponse) GetTransactionID() string {
+func (x *SQLQueryRequest) GetSql() string {
 	if x != nil {
-		return x.TransactionID
+		return x.Sql
 	}
 	return ""
 }
 
-type ErrorInfo struct {
+func (x *SQLQueryRequest) GetParams() []*NamedParam {
+	if x != nil {
+		return x.Params
+	}
+	return nil
+}
+
+func (x *SQLQueryRequest) GetReuseSnapshot() bool {
+	if x != nil {
+		return x.ReuseSnapshot
+	}
+	return false
+}
+
+type NamedParam struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Code  string `protobuf:"bytes,1,opt,name=code,proto3" json:"code,omitempty"`
-	Cause string `protobuf:"bytes,2,opt,name=cause,proto3" json:"cause,omitempty"`
+	Name  string    `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Value *SQLValue `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
 }
 
-func (x *ErrorInfo) Reset() {
-	*x = ErrorInfo{}
+func (x *NamedParam) Reset() {
+	*x = NamedParam{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_schema_proto_msgTypes[93]
+		mi := &file_schema_proto_msgTypes[98]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *ErrorInfo) String() string {
+func (x *NamedParam) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ErrorInfo) ProtoMessage() {}
+func (*NamedParam) ProtoMessage() {}
 
-func (x *ErrorInfo) ProtoReflect() protoreflect.Message {
-	mi := &file_schema_proto_msgTypes[93]
+func (x *NamedParam) ProtoReflect() protoreflect.Message {
+	mi := &file_schema_proto_msgTypes[98]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -6292,50 +7112,51 @@ func (x *ErrorInfo) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ErrorInfo.ProtoReflect.Descriptor instead.
-func (*ErrorInfo) Descriptor() ([]byte, []int) {
-	return file_schema_proto_rawDescGZIP(), []int{93}
+// Deprecated: Use NamedParam.ProtoReflect.Descriptor instead.
+func (*NamedParam) Descriptor() ([]byte, []int) {
+	return file_schema_proto_rawDescGZIP(), []int{98}
 }
 
-func (x *ErrorInfo) GetCode() string {
+func (x *NamedParam) GetName() string {
 	if x != nil {
-		return x.Code
+		return x.Name
 	}
 	return ""
 }
 
-func (x *ErrorInfo) GetCause() string {
+func (x *NamedParam) GetValue() *SQLValue {
 	if x != nil {
-		return x.Cause
+		return x.Value
 	}
-	return ""
+	return nil
 }
 
-type DebugInfo struct {
+type SQLExecResult struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Stack string `protobuf:"bytes,1,opt,name=stack,proto3" json:"stack,omitempty"`
+	Txs       []*CommittedSQLTx `protobuf:"bytes,5,rep,name=txs,proto3" json:"txs,omitempty"`
+	OngoingTx bool              `protobuf:"varint,6,opt,name=ongoingTx,proto3" json:"ongoingTx,omitempty"`
 }
 
-func (x *DebugInfo) Reset() {
-	*x = DebugInfo{}
+func (x *SQLExecResult) Reset() {
+	*x = SQLExecResult{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_schema_proto_msgTypes[94]
+		mi := &file_schema_proto_msgTypes[99]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *DebugInfo) String() string {
+func (x *SQLExecResult) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*DebugInfo) ProtoMessage() {}
+func (*SQLExecResult) ProtoMessage() {}
 
-func (x *DebugInfo) ProtoReflect() protoreflect.Message {
-	mi := &file_schema_proto_msgTypes[94]
+func (x *SQLExecResult) ProtoReflect() protoreflect.Message {
+	mi := &file_schema_proto_msgTypes[99]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -6346,43 +7167,53 @@ func (x *DebugInfo) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use DebugInfo.ProtoReflect.Descriptor instead.
-func (*DebugInfo) Descriptor() ([]byte, []int) {
-	return file_schema_proto_rawDescGZIP(), []int{94}
+// Deprecated: Use SQLExecResult.ProtoReflect.Descriptor instead.
+func (*SQLExecResult) Descriptor() ([]byte, []int) {
+	return file_schema_proto_rawDescGZIP(), []int{99}
 }
 
-func (x *DebugInfo) GetStack() string {
+func (x *SQLExecResult) GetTxs() []*CommittedSQLTx {
 	if x != nil {
-		return x.Stack
+		return x.Txs
 	}
-	return ""
+	return nil
 }
 
-type RetryInfo struct {
+func (x *SQLExecResult) GetOngoingTx() bool {
+	if x != nil {
+		return x.OngoingTx
+	}
+	return false
+}
+
+type CommittedSQLTx struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	RetryDelay int32 `protobuf:"varint,1,opt,name=retry_delay,json=retryDelay,proto3" json:"retry_delay,omitempty"`
+	Header           *TxHeader            `protobuf:"bytes,1,opt,name=header,proto3" json:"header,omitempty"`
+	UpdatedRows      uint32               `protobuf:"varint,2,opt,name=updatedRows,proto3" json:"updatedRows,omitempty"`
+	LastInsertedPKs  map[string]*SQLValue `protobuf:"bytes,3,rep,name=lastInsertedPKs,proto3" json:"lastInsertedPKs,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	FirstInsertedPKs map[string]*SQLValue `protobuf:"bytes,4,rep,name=firstInsertedPKs,proto3" json:"firstInsertedPKs,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
 }
 
-func (x *RetryInfo) Reset() {
-	*x = RetryInfo{}
+func (x *CommittedSQLTx) Reset() {
+	*x = CommittedSQLTx{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_schema_proto_msgTypes[95]
+		mi := &file_schema_proto_msgTypes[100]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *RetryInfo) String() string {
+func (x *CommittedSQLTx) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*RetryInfo) ProtoMessage() {}
+func (*CommittedSQLTx) ProtoMessage() {}
 
-func (x *RetryInfo) ProtoReflect() protoreflect.Message {
-	mi := &file_schema_proto_msgTypes[95]
+func (x *CommittedSQLTx) ProtoReflect() protoreflect.Message {
+	mi := &file_schema_proto_msgTypes[100]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -6393,1673 +7224,5509 @@ func (x *RetryInfo) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use RetryInfo.ProtoReflect.Descriptor instead.
-func (*RetryInfo) Descriptor() ([]byte, []int) {
-	return file_schema_proto_rawDescGZIP(), []int{95}
+// Deprecated: Use CommittedSQLTx.ProtoReflect.Descriptor instead.
+func (*CommittedSQLTx) Descriptor() ([]byte, []int) {
+	return file_schema_proto_rawDescGZIP(), []int{100}
 }
 
-func (x *RetryInfo) GetRetryDelay() int32 {
+func (x *CommittedSQLTx) GetHeader() *TxHeader {
 	if x != nil {
-		return x.RetryDelay
+		return x.Header
+	}
+	return nil
+}
+
+func (x *CommittedSQLTx) GetUpdatedRows() uint32 {
+	if x != nil {
+		return x.UpdatedRows
 	}
 	return 0
 }
 
-var File_schema_proto protoreflect.FileDescriptor
+func (x *CommittedSQLTx) GetLastInsertedPKs() map[string]*SQLValue {
+	if x != nil {
+		return x.LastInsertedPKs
+	}
+	return nil
+}
 
-var file_schema_proto_rawDesc = []byte{
-	0x0a, 0x0c, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x0d,
-	0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x1a, 0x1c, 0x67,
-	0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x61, 0x70, 0x69, 0x2f, 0x61, 0x6e, 0x6e, 0x6f, 0x74, 0x61,
-	0x74, 0x69, 0x6f, 0x6e, 0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x1a, 0x1b, 0x67, 0x6f, 0x6f,
-	0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x65, 0x6d, 0x70,
-	0x74, 0x79, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x1a, 0x2c, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63,
-	0x2d, 0x67, 0x65, 0x6e, 0x2d, 0x73, 0x77, 0x61, 0x67, 0x67, 0x65, 0x72, 0x2f, 0x6f, 0x70, 0x74,
-	0x69, 0x6f, 0x6e, 0x73, 0x2f, 0x61, 0x6e, 0x6e, 0x6f, 0x74, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73,
-	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x1a, 0x1c, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70,
-	0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x73, 0x74, 0x72, 0x75, 0x63, 0x74, 0x2e, 0x70,
-	0x72, 0x6f, 0x74, 0x6f, 0x22, 0x17, 0x0a, 0x03, 0x4b, 0x65, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b,
-	0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x22, 0x48, 0x0a,
-	0x0a, 0x50, 0x65, 0x72, 0x6d, 0x69, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x1a, 0x0a, 0x08, 0x64,
-	0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x64,
-	0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x12, 0x1e, 0x0a, 0x0a, 0x70, 0x65, 0x72, 0x6d, 0x69,
-	0x73, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0a, 0x70, 0x65, 0x72,
-	0x6d, 0x69, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x22, 0xab, 0x01, 0x0a, 0x04, 0x55, 0x73, 0x65, 0x72,
-	0x12, 0x12, 0x0a, 0x04, 0x75, 0x73, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04,
-	0x75, 0x73, 0x65, 0x72, 0x12, 0x3b, 0x0a, 0x0b, 0x70, 0x65, 0x72, 0x6d, 0x69, 0x73, 0x73, 0x69,
-	0x6f, 0x6e, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x69, 0x6d, 0x6d, 0x75,
-	0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x50, 0x65, 0x72, 0x6d, 0x69, 0x73,
-	0x73, 0x69, 0x6f, 0x6e, 0x52, 0x0b, 0x70, 0x65, 0x72, 0x6d, 0x69, 0x73, 0x73, 0x69, 0x6f, 0x6e,
-	0x73, 0x12, 0x1c, 0x0a, 0x09, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x64, 0x62, 0x79, 0x18, 0x04,
-	0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x64, 0x62, 0x79, 0x12,
-	0x1c, 0x0a, 0x09, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x64, 0x61, 0x74, 0x18, 0x05, 0x20, 0x01,
-	0x28, 0x09, 0x52, 0x09, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x64, 0x61, 0x74, 0x12, 0x16, 0x0a,
-	0x06, 0x61, 0x63, 0x74, 0x69, 0x76, 0x65, 0x18, 0x06, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x61,
-	0x63, 0x74, 0x69, 0x76, 0x65, 0x22, 0x35, 0x0a, 0x08, 0x55, 0x73, 0x65, 0x72, 0x4c, 0x69, 0x73,
-	0x74, 0x12, 0x29, 0x0a, 0x05, 0x75, 0x73, 0x65, 0x72, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b,
-	0x32, 0x13, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61,
-	0x2e, 0x55, 0x73, 0x65, 0x72, 0x52, 0x05, 0x75, 0x73, 0x65, 0x72, 0x73, 0x22, 0x7f, 0x0a, 0x11,
-	0x43, 0x72, 0x65, 0x61, 0x74, 0x65, 0x55, 0x73, 0x65, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
-	0x74, 0x12, 0x12, 0x0a, 0x04, 0x75, 0x73, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52,
-	0x04, 0x75, 0x73, 0x65, 0x72, 0x12, 0x1a, 0x0a, 0x08, 0x70, 0x61, 0x73, 0x73, 0x77, 0x6f, 0x72,
-	0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x08, 0x70, 0x61, 0x73, 0x73, 0x77, 0x6f, 0x72,
-	0x64, 0x12, 0x1e, 0x0a, 0x0a, 0x70, 0x65, 0x72, 0x6d, 0x69, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x18,
-	0x03, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0a, 0x70, 0x65, 0x72, 0x6d, 0x69, 0x73, 0x73, 0x69, 0x6f,
-	0x6e, 0x12, 0x1a, 0x0a, 0x08, 0x64, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x18, 0x04, 0x20,
-	0x01, 0x28, 0x09, 0x52, 0x08, 0x64, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x22, 0x21, 0x0a,
-	0x0b, 0x55, 0x73, 0x65, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x12, 0x0a, 0x04,
-	0x75, 0x73, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x75, 0x73, 0x65, 0x72,
-	0x22, 0x6f, 0x0a, 0x15, 0x43, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x50, 0x61, 0x73, 0x73, 0x77, 0x6f,
-	0x72, 0x64, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x75, 0x73, 0x65,
-	0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x75, 0x73, 0x65, 0x72, 0x12, 0x20, 0x0a,
-	0x0b, 0x6f, 0x6c, 0x64, 0x50, 0x61, 0x73, 0x73, 0x77, 0x6f, 0x72, 0x64, 0x18, 0x02, 0x20, 0x01,
-	0x28, 0x0c, 0x52, 0x0b, 0x6f, 0x6c, 0x64, 0x50, 0x61, 0x73, 0x73, 0x77, 0x6f, 0x72, 0x64, 0x12,
-	0x20, 0x0a, 0x0b, 0x6e, 0x65, 0x77, 0x50, 0x61, 0x73, 0x73, 0x77, 0x6f, 0x72, 0x64, 0x18, 0x03,
-	0x20, 0x01, 0x28, 0x0c, 0x52, 0x0b, 0x6e, 0x65, 0x77, 0x50, 0x61, 0x73, 0x73, 0x77, 0x6f, 0x72,
-	0x64, 0x22, 0x3e, 0x0a, 0x0c, 0x4c, 0x6f, 0x67, 0x69, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
-	0x74, 0x12, 0x12, 0x0a, 0x04, 0x75, 0x73, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52,
-	0x04, 0x75, 0x73, 0x65, 0x72, 0x12, 0x1a, 0x0a, 0x08, 0x70, 0x61, 0x73, 0x73, 0x77, 0x6f, 0x72,
-	0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x08, 0x70, 0x61, 0x73, 0x73, 0x77, 0x6f, 0x72,
-	0x64, 0x22, 0x3f, 0x0a, 0x0d, 0x4c, 0x6f, 0x67, 0x69, 0x6e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
-	0x73, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28,
-	0x09, 0x52, 0x05, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x12, 0x18, 0x0a, 0x07, 0x77, 0x61, 0x72, 0x6e,
-	0x69, 0x6e, 0x67, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x07, 0x77, 0x61, 0x72, 0x6e, 0x69,
-	0x6e, 0x67, 0x22, 0x20, 0x0a, 0x0a, 0x41, 0x75, 0x74, 0x68, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67,
-	0x12, 0x12, 0x0a, 0x04, 0x6b, 0x69, 0x6e, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x04,
-	0x6b, 0x69, 0x6e, 0x64, 0x22, 0x26, 0x0a, 0x0a, 0x4d, 0x54, 0x4c, 0x53, 0x43, 0x6f, 0x6e, 0x66,
-	0x69, 0x67, 0x12, 0x18, 0x0a, 0x07, 0x65, 0x6e, 0x61, 0x62, 0x6c, 0x65, 0x64, 0x18, 0x01, 0x20,
-	0x01, 0x28, 0x08, 0x52, 0x07, 0x65, 0x6e, 0x61, 0x62, 0x6c, 0x65, 0x64, 0x22, 0x70, 0x0a, 0x12,
-	0x4f, 0x70, 0x65, 0x6e, 0x53, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65,
-	0x73, 0x74, 0x12, 0x1a, 0x0a, 0x08, 0x75, 0x73, 0x65, 0x72, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01,
-	0x20, 0x01, 0x28, 0x0c, 0x52, 0x08, 0x75, 0x73, 0x65, 0x72, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x1a,
-	0x0a, 0x08, 0x70, 0x61, 0x73, 0x73, 0x77, 0x6f, 0x72, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c,
-	0x52, 0x08, 0x70, 0x61, 0x73, 0x73, 0x77, 0x6f, 0x72, 0x64, 0x12, 0x22, 0x0a, 0x0c, 0x64, 0x61,
-	0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x4e, 0x61, 0x6d, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09,
-	0x52, 0x0c, 0x64, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x4e, 0x61, 0x6d, 0x65, 0x22, 0x53,
-	0x0a, 0x13, 0x4f, 0x70, 0x65, 0x6e, 0x53, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x73,
-	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x1c, 0x0a, 0x09, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e,
-	0x49, 0x44, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f,
-	0x6e, 0x49, 0x44, 0x12, 0x1e, 0x0a, 0x0a, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x55, 0x55, 0x49,
-	0x44, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x55,
-	0x55, 0x49, 0x44, 0x22, 0x69, 0x0a, 0x08, 0x4b, 0x65, 0x79, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x12,
-	0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x03, 0x6b, 0x65,
-	0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c,
-	0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x12, 0x35, 0x0a, 0x08, 0x6d, 0x65, 0x74, 0x61, 0x64,
-	0x61, 0x74, 0x61, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x69, 0x6d, 0x6d, 0x75,
-	0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x4b, 0x56, 0x4d, 0x65, 0x74, 0x61,
-	0x64, 0x61, 0x74, 0x61, 0x52, 0x08, 0x6d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x22, 0xce,
-	0x01, 0x0a, 0x05, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x0e, 0x0a, 0x02, 0x74, 0x78, 0x18, 0x01,
-	0x20, 0x01, 0x28, 0x04, 0x52, 0x02, 0x74, 0x78, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18,
-	0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61,
-	0x6c, 0x75, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65,
-	0x12, 0x3c, 0x0a, 0x0c, 0x72, 0x65, 0x66, 0x65, 0x72, 0x65, 0x6e, 0x63, 0x65, 0x64, 0x42, 0x79,
-	0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e,
-	0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x52, 0x65, 0x66, 0x65, 0x72, 0x65, 0x6e, 0x63, 0x65,
-	0x52, 0x0c, 0x72, 0x65, 0x66, 0x65, 0x72, 0x65, 0x6e, 0x63, 0x65, 0x64, 0x42, 0x79, 0x12, 0x35,
-	0x0a, 0x08, 0x6d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b,
-	0x32, 0x19, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61,
-	0x2e, 0x4b, 0x56, 0x4d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x52, 0x08, 0x6d, 0x65, 0x74,
-	0x61, 0x64, 0x61, 0x74, 0x61, 0x12, 0x18, 0x0a, 0x07, 0x65, 0x78, 0x70, 0x69, 0x72, 0x65, 0x64,
-	0x18, 0x06, 0x20, 0x01, 0x28, 0x08, 0x52, 0x07, 0x65, 0x78, 0x70, 0x69, 0x72, 0x65, 0x64, 0x22,
-	0x78, 0x0a, 0x09, 0x52, 0x65, 0x66, 0x65, 0x72, 0x65, 0x6e, 0x63, 0x65, 0x12, 0x0e, 0x0a, 0x02,
-	0x74, 0x78, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x02, 0x74, 0x78, 0x12, 0x10, 0x0a, 0x03,
-	0x6b, 0x65, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x12,
-	0x0a, 0x04, 0x61, 0x74, 0x54, 0x78, 0x18, 0x03, 0x20, 0x01, 0x28, 0x04, 0x52, 0x04, 0x61, 0x74,
-	0x54, 0x78, 0x12, 0x35, 0x0a, 0x08, 0x6d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x18, 0x04,
-	0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63,
-	0x68, 0x65, 0x6d, 0x61, 0x2e, 0x4b, 0x56, 0x4d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x52,
-	0x08, 0x6d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x22, 0xa3, 0x01, 0x0a, 0x02, 0x4f, 0x70,
-	0x12, 0x29, 0x0a, 0x02, 0x6b, 0x76, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x69,
-	0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x4b, 0x65, 0x79,
-	0x56, 0x61, 0x6c, 0x75, 0x65, 0x48, 0x00, 0x52, 0x02, 0x6b, 0x76, 0x12, 0x30, 0x0a, 0x04, 0x7a,
-	0x41, 0x64, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x69, 0x6d, 0x6d, 0x75,
-	0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x5a, 0x41, 0x64, 0x64, 0x52, 0x65,
-	0x71, 0x75, 0x65, 0x73, 0x74, 0x48, 0x00, 0x52, 0x04, 0x7a, 0x41, 0x64, 0x64, 0x12, 0x33, 0x0a,
-	0x03, 0x72, 0x65, 0x66, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1f, 0x2e, 0x69, 0x6d, 0x6d,
-	0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x52, 0x65, 0x66, 0x65, 0x72,
-	0x65, 0x6e, 0x63, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x48, 0x00, 0x52, 0x03, 0x72,
-	0x65, 0x66, 0x42, 0x0b, 0x0a, 0x09, 0x6f, 0x70, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x22,
-	0x5b, 0x0a, 0x0e, 0x45, 0x78, 0x65, 0x63, 0x41, 0x6c, 0x6c, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
-	0x74, 0x12, 0x31, 0x0a, 0x0a, 0x4f, 0x70, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18,
-	0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73,
-	0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x4f, 0x70, 0x52, 0x0a, 0x4f, 0x70, 0x65, 0x72, 0x61, 0x74,
-	0x69, 0x6f, 0x6e, 0x73, 0x12, 0x16, 0x0a, 0x06, 0x6e, 0x6f, 0x57, 0x61, 0x69, 0x74, 0x18, 0x02,
-	0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x6e, 0x6f, 0x57, 0x61, 0x69, 0x74, 0x22, 0x39, 0x0a, 0x07,
-	0x45, 0x6e, 0x74, 0x72, 0x69, 0x65, 0x73, 0x12, 0x2e, 0x0a, 0x07, 0x65, 0x6e, 0x74, 0x72, 0x69,
-	0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64,
-	0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x07,
-	0x65, 0x6e, 0x74, 0x72, 0x69, 0x65, 0x73, 0x22, 0x82, 0x01, 0x0a, 0x06, 0x5a, 0x45, 0x6e, 0x74,
-	0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x73, 0x65, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52,
-	0x03, 0x73, 0x65, 0x74, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28,
-	0x0c, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x2a, 0x0a, 0x05, 0x65, 0x6e, 0x74, 0x72, 0x79, 0x18,
-	0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73,
-	0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x05, 0x65, 0x6e, 0x74,
-	0x72, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x73, 0x63, 0x6f, 0x72, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28,
-	0x01, 0x52, 0x05, 0x73, 0x63, 0x6f, 0x72, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x61, 0x74, 0x54, 0x78,
-	0x18, 0x05, 0x20, 0x01, 0x28, 0x04, 0x52, 0x04, 0x61, 0x74, 0x54, 0x78, 0x22, 0x3b, 0x0a, 0x08,
-	0x5a, 0x45, 0x6e, 0x74, 0x72, 0x69, 0x65, 0x73, 0x12, 0x2f, 0x0a, 0x07, 0x65, 0x6e, 0x74, 0x72,
-	0x69, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x69, 0x6d, 0x6d, 0x75,
-	0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x5a, 0x45, 0x6e, 0x74, 0x72, 0x79,
-	0x52, 0x07, 0x65, 0x6e, 0x74, 0x72, 0x69, 0x65, 0x73, 0x22, 0x9b, 0x01, 0x0a, 0x0b, 0x53, 0x63,
-	0x61, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x73, 0x65, 0x65,
-	0x6b, 0x4b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x07, 0x73, 0x65, 0x65, 0x6b,
-	0x4b, 0x65, 0x79, 0x12, 0x16, 0x0a, 0x06, 0x70, 0x72, 0x65, 0x66, 0x69, 0x78, 0x18, 0x02, 0x20,
-	0x01, 0x28, 0x0c, 0x52, 0x06, 0x70, 0x72, 0x65, 0x66, 0x69, 0x78, 0x12, 0x12, 0x0a, 0x04, 0x64,
-	0x65, 0x73, 0x63, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x52, 0x04, 0x64, 0x65, 0x73, 0x63, 0x12,
-	0x14, 0x0a, 0x05, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x04, 0x52, 0x05,
-	0x6c, 0x69, 0x6d, 0x69, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x73, 0x69, 0x6e, 0x63, 0x65, 0x54, 0x78,
-	0x18, 0x05, 0x20, 0x01, 0x28, 0x04, 0x52, 0x07, 0x73, 0x69, 0x6e, 0x63, 0x65, 0x54, 0x78, 0x12,
-	0x16, 0x0a, 0x06, 0x6e, 0x6f, 0x57, 0x61, 0x69, 0x74, 0x18, 0x06, 0x20, 0x01, 0x28, 0x08, 0x52,
-	0x06, 0x6e, 0x6f, 0x57, 0x61, 0x69, 0x74, 0x22, 0x23, 0x0a, 0x09, 0x4b, 0x65, 0x79, 0x50, 0x72,
-	0x65, 0x66, 0x69, 0x78, 0x12, 0x16, 0x0a, 0x06, 0x70, 0x72, 0x65, 0x66, 0x69, 0x78, 0x18, 0x01,
-	0x20, 0x01, 0x28, 0x0c, 0x52, 0x06, 0x70, 0x72, 0x65, 0x66, 0x69, 0x78, 0x22, 0x22, 0x0a, 0x0a,
-	0x45, 0x6e, 0x74, 0x72, 0x79, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x63, 0x6f,
-	0x75, 0x6e, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x05, 0x63, 0x6f, 0x75, 0x6e, 0x74,
-	0x22, 0x47, 0x0a, 0x09, 0x53, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x12, 0x1c, 0x0a,
-	0x09, 0x70, 0x75, 0x62, 0x6c, 0x69, 0x63, 0x4b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c,
-	0x52, 0x09, 0x70, 0x75, 0x62, 0x6c, 0x69, 0x63, 0x4b, 0x65, 0x79, 0x12, 0x1c, 0x0a, 0x09, 0x73,
-	0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x09,
-	0x73, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x22, 0xf1, 0x01, 0x0a, 0x08, 0x54, 0x78,
-	0x48, 0x65, 0x61, 0x64, 0x65, 0x72, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01,
-	0x28, 0x04, 0x52, 0x02, 0x69, 0x64, 0x12, 0x18, 0x0a, 0x07, 0x70, 0x72, 0x65, 0x76, 0x41, 0x6c,
-	0x68, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x07, 0x70, 0x72, 0x65, 0x76, 0x41, 0x6c, 0x68,
-	0x12, 0x0e, 0x0a, 0x02, 0x74, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x02, 0x74, 0x73,
-	0x12, 0x1a, 0x0a, 0x08, 0x6e, 0x65, 0x6e, 0x74, 0x72, 0x69, 0x65, 0x73, 0x18, 0x04, 0x20, 0x01,
-	0x28, 0x05, 0x52, 0x08, 0x6e, 0x65, 0x6e, 0x74, 0x72, 0x69, 0x65, 0x73, 0x12, 0x0e, 0x0a, 0x02,
-	0x65, 0x48, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x02, 0x65, 0x48, 0x12, 0x16, 0x0a, 0x06,
-	0x62, 0x6c, 0x54, 0x78, 0x49, 0x64, 0x18, 0x06, 0x20, 0x01, 0x28, 0x04, 0x52, 0x06, 0x62, 0x6c,
-	0x54, 0x78, 0x49, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x62, 0x6c, 0x52, 0x6f, 0x6f, 0x74, 0x18, 0x07,
-	0x20, 0x01, 0x28, 0x0c, 0x52, 0x06, 0x62, 0x6c, 0x52, 0x6f, 0x6f, 0x74, 0x12, 0x18, 0x0a, 0x07,
-	0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x08, 0x20, 0x01, 0x28, 0x05, 0x52, 0x07, 0x76,
-	0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x35, 0x0a, 0x08, 0x6d, 0x65, 0x74, 0x61, 0x64, 0x61,
-	0x74, 0x61, 0x18, 0x09, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64,
-	0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x54, 0x78, 0x4d, 0x65, 0x74, 0x61, 0x64,
-	0x61, 0x74, 0x61, 0x52, 0x08, 0x6d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x22, 0x0c, 0x0a,
-	0x0a, 0x54, 0x78, 0x4d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x22, 0x63, 0x0a, 0x0b, 0x4c,
-	0x69, 0x6e, 0x65, 0x61, 0x72, 0x50, 0x72, 0x6f, 0x6f, 0x66, 0x12, 0x1e, 0x0a, 0x0a, 0x73, 0x6f,
-	0x75, 0x72, 0x63, 0x65, 0x54, 0x78, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0a,
-	0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x54, 0x78, 0x49, 0x64, 0x12, 0x1e, 0x0a, 0x0a, 0x54, 0x61,
-	0x72, 0x67, 0x65, 0x74, 0x54, 0x78, 0x49, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0a,
-	0x54, 0x61, 0x72, 0x67, 0x65, 0x74, 0x54, 0x78, 0x49, 0x64, 0x12, 0x14, 0x0a, 0x05, 0x74, 0x65,
-	0x72, 0x6d, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0c, 0x52, 0x05, 0x74, 0x65, 0x72, 0x6d, 0x73,
-	0x22, 0xf5, 0x02, 0x0a, 0x09, 0x44, 0x75, 0x61, 0x6c, 0x50, 0x72, 0x6f, 0x6f, 0x66, 0x12, 0x3f,
-	0x0a, 0x0e, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x54, 0x78, 0x48, 0x65, 0x61, 0x64, 0x65, 0x72,
-	0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e,
-	0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x54, 0x78, 0x48, 0x65, 0x61, 0x64, 0x65, 0x72, 0x52,
-	0x0e, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x54, 0x78, 0x48, 0x65, 0x61, 0x64, 0x65, 0x72, 0x12,
-	0x3f, 0x0a, 0x0e, 0x74, 0x61, 0x72, 0x67, 0x65, 0x74, 0x54, 0x78, 0x48, 0x65, 0x61, 0x64, 0x65,
-	0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62,
-	0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x54, 0x78, 0x48, 0x65, 0x61, 0x64, 0x65, 0x72,
-	0x52, 0x0e, 0x74, 0x61, 0x72, 0x67, 0x65, 0x74, 0x54, 0x78, 0x48, 0x65, 0x61, 0x64, 0x65, 0x72,
-	0x12, 0x26, 0x0a, 0x0e, 0x69, 0x6e, 0x63, 0x6c, 0x75, 0x73, 0x69, 0x6f, 0x6e, 0x50, 0x72, 0x6f,
-	0x6f, 0x66, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0c, 0x52, 0x0e, 0x69, 0x6e, 0x63, 0x6c, 0x75, 0x73,
-	0x69, 0x6f, 0x6e, 0x50, 0x72, 0x6f, 0x6f, 0x66, 0x12, 0x2a, 0x0a, 0x10, 0x63, 0x6f, 0x6e, 0x73,
-	0x69, 0x73, 0x74, 0x65, 0x6e, 0x63, 0x79, 0x50, 0x72, 0x6f, 0x6f, 0x66, 0x18, 0x04, 0x20, 0x03,
-	0x28, 0x0c, 0x52, 0x10, 0x63, 0x6f, 0x6e, 0x73, 0x69, 0x73, 0x74, 0x65, 0x6e, 0x63, 0x79, 0x50,
-	0x72, 0x6f, 0x6f, 0x66, 0x12, 0x24, 0x0a, 0x0d, 0x74, 0x61, 0x72, 0x67, 0x65, 0x74, 0x42, 0x6c,
-	0x54, 0x78, 0x41, 0x6c, 0x68, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0d, 0x74, 0x61, 0x72,
-	0x67, 0x65, 0x74, 0x42, 0x6c, 0x54, 0x78, 0x41, 0x6c, 0x68, 0x12, 0x2e, 0x0a, 0x12, 0x6c, 0x61,
-	0x73, 0x74, 0x49, 0x6e, 0x63, 0x6c, 0x75, 0x73, 0x69, 0x6f, 0x6e, 0x50, 0x72, 0x6f, 0x6f, 0x66,
-	0x18, 0x06, 0x20, 0x03, 0x28, 0x0c, 0x52, 0x12, 0x6c, 0x61, 0x73, 0x74, 0x49, 0x6e, 0x63, 0x6c,
-	0x75, 0x73, 0x69, 0x6f, 0x6e, 0x50, 0x72, 0x6f, 0x6f, 0x66, 0x12, 0x3c, 0x0a, 0x0b, 0x6c, 0x69,
-	0x6e, 0x65, 0x61, 0x72, 0x50, 0x72, 0x6f, 0x6f, 0x66, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0b, 0x32,
-	0x1a, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e,
-	0x4c, 0x69, 0x6e, 0x65, 0x61, 0x72, 0x50, 0x72, 0x6f, 0x6f, 0x66, 0x52, 0x0b, 0x6c, 0x69, 0x6e,
-	0x65, 0x61, 0x72, 0x50, 0x72, 0x6f, 0x6f, 0x66, 0x22, 0xce, 0x01, 0x0a, 0x02, 0x54, 0x78, 0x12,
-	0x2f, 0x0a, 0x06, 0x68, 0x65, 0x61, 0x64, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32,
-	0x17, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e,
-	0x54, 0x78, 0x48, 0x65, 0x61, 0x64, 0x65, 0x72, 0x52, 0x06, 0x68, 0x65, 0x61, 0x64, 0x65, 0x72,
-	0x12, 0x30, 0x0a, 0x07, 0x65, 0x6e, 0x74, 0x72, 0x69, 0x65, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28,
-	0x0b, 0x32, 0x16, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d,
-	0x61, 0x2e, 0x54, 0x78, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x07, 0x65, 0x6e, 0x74, 0x72, 0x69,
-	0x65, 0x73, 0x12, 0x32, 0x0a, 0x09, 0x6b, 0x76, 0x45, 0x6e, 0x74, 0x72, 0x69, 0x65, 0x73, 0x18,
-	0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73,
-	0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x09, 0x6b, 0x76, 0x45,
-	0x6e, 0x74, 0x72, 0x69, 0x65, 0x73, 0x12, 0x31, 0x0a, 0x08, 0x7a, 0x45, 0x6e, 0x74, 0x72, 0x69,
-	0x65, 0x73, 0x18, 0x04, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64,
-	0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x5a, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52,
-	0x08, 0x7a, 0x45, 0x6e, 0x74, 0x72, 0x69, 0x65, 0x73, 0x22, 0x94, 0x01, 0x0a, 0x07, 0x54, 0x78,
-	0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01,
-	0x28, 0x0c, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x16, 0x0a, 0x06, 0x68, 0x56, 0x61, 0x6c, 0x75,
-	0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x06, 0x68, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x12,
-	0x12, 0x0a, 0x04, 0x76, 0x4c, 0x65, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52, 0x04, 0x76,
-	0x4c, 0x65, 0x6e, 0x12, 0x35, 0x0a, 0x08, 0x6d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x18,
-	0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73,
-	0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x4b, 0x56, 0x4d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61,
-	0x52, 0x08, 0x6d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61,
-	0x6c, 0x75, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65,
-	0x22, 0x85, 0x01, 0x0a, 0x0a, 0x4b, 0x56, 0x4d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x12,
-	0x18, 0x0a, 0x07, 0x64, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08,
-	0x52, 0x07, 0x64, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x64, 0x12, 0x39, 0x0a, 0x0a, 0x65, 0x78, 0x70,
-	0x69, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e,
-	0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x45, 0x78,
-	0x70, 0x69, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x0a, 0x65, 0x78, 0x70, 0x69, 0x72, 0x61,
-	0x74, 0x69, 0x6f, 0x6e, 0x12, 0x22, 0x0a, 0x0c, 0x6e, 0x6f, 0x6e, 0x49, 0x6e, 0x64, 0x65, 0x78,
-	0x61, 0x62, 0x6c, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0c, 0x6e, 0x6f, 0x6e, 0x49,
-	0x6e, 0x64, 0x65, 0x78, 0x61, 0x62, 0x6c, 0x65, 0x22, 0x2a, 0x0a, 0x0a, 0x45, 0x78, 0x70, 0x69,
-	0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x1c, 0x0a, 0x09, 0x65, 0x78, 0x70, 0x69, 0x72, 0x65,
-	0x73, 0x41, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x65, 0x78, 0x70, 0x69, 0x72,
-	0x65, 0x73, 0x41, 0x74, 0x22, 0xa1, 0x01, 0x0a, 0x0c, 0x56, 0x65, 0x72, 0x69, 0x66, 0x69, 0x61,
-	0x62, 0x6c, 0x65, 0x54, 0x78, 0x12, 0x21, 0x0a, 0x02, 0x74, 0x78, 0x18, 0x01, 0x20, 0x01, 0x28,
-	0x0b, 0x32, 0x11, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d,
-	0x61, 0x2e, 0x54, 0x78, 0x52, 0x02, 0x74, 0x78, 0x12, 0x36, 0x0a, 0x09, 0x64, 0x75, 0x61, 0x6c,
-	0x50, 0x72, 0x6f, 0x6f, 0x66, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x69, 0x6d,
-	0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x44, 0x75, 0x61, 0x6c,
-	0x50, 0x72, 0x6f, 0x6f, 0x66, 0x52, 0x09, 0x64, 0x75, 0x61, 0x6c, 0x50, 0x72, 0x6f, 0x6f, 0x66,
-	0x12, 0x36, 0x0a, 0x09, 0x73, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x18, 0x03, 0x20,
-	0x01, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68,
-	0x65, 0x6d, 0x61, 0x2e, 0x53, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x52, 0x09, 0x73,
-	0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x22, 0xc5, 0x01, 0x0a, 0x0f, 0x56, 0x65, 0x72,
-	0x69, 0x66, 0x69, 0x61, 0x62, 0x6c, 0x65, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x2a, 0x0a, 0x05,
-	0x65, 0x6e, 0x74, 0x72, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x69, 0x6d,
-	0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x45, 0x6e, 0x74, 0x72,
-	0x79, 0x52, 0x05, 0x65, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x3f, 0x0a, 0x0c, 0x76, 0x65, 0x72, 0x69,
-	0x66, 0x69, 0x61, 0x62, 0x6c, 0x65, 0x54, 0x78, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1b,
-	0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x56,
-	0x65, 0x72, 0x69, 0x66, 0x69, 0x61, 0x62, 0x6c, 0x65, 0x54, 0x78, 0x52, 0x0c, 0x76, 0x65, 0x72,
-	0x69, 0x66, 0x69, 0x61, 0x62, 0x6c, 0x65, 0x54, 0x78, 0x12, 0x45, 0x0a, 0x0e, 0x69, 0x6e, 0x63,
-	0x6c, 0x75, 0x73, 0x69, 0x6f, 0x6e, 0x50, 0x72, 0x6f, 0x6f, 0x66, 0x18, 0x03, 0x20, 0x01, 0x28,
-	0x0b, 0x32, 0x1d, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d,
-	0x61, 0x2e, 0x49, 0x6e, 0x63, 0x6c, 0x75, 0x73, 0x69, 0x6f, 0x6e, 0x50, 0x72, 0x6f, 0x6f, 0x66,
-	0x52, 0x0e, 0x69, 0x6e, 0x63, 0x6c, 0x75, 0x73, 0x69, 0x6f, 0x6e, 0x50, 0x72, 0x6f, 0x6f, 0x66,
-	0x22, 0x50, 0x0a, 0x0e, 0x49, 0x6e, 0x63, 0x6c, 0x75, 0x73, 0x69, 0x6f, 0x6e, 0x50, 0x72, 0x6f,
-	0x6f, 0x66, 0x12, 0x12, 0x0a, 0x04, 0x6c, 0x65, 0x61, 0x66, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05,
-	0x52, 0x04, 0x6c, 0x65, 0x61, 0x66, 0x12, 0x14, 0x0a, 0x05, 0x77, 0x69, 0x64, 0x74, 0x68, 0x18,
-	0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x77, 0x69, 0x64, 0x74, 0x68, 0x12, 0x14, 0x0a, 0x05,
-	0x74, 0x65, 0x72, 0x6d, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0c, 0x52, 0x05, 0x74, 0x65, 0x72,
-	0x6d, 0x73, 0x22, 0x4f, 0x0a, 0x0a, 0x53, 0x65, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
-	0x12, 0x29, 0x0a, 0x03, 0x4b, 0x56, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x17, 0x2e,
-	0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x4b, 0x65,
-	0x79, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x52, 0x03, 0x4b, 0x56, 0x73, 0x12, 0x16, 0x0a, 0x06, 0x6e,
-	0x6f, 0x57, 0x61, 0x69, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x6e, 0x6f, 0x57,
-	0x61, 0x69, 0x74, 0x22, 0x64, 0x0a, 0x0a, 0x4b, 0x65, 0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
-	0x74, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x03,
-	0x6b, 0x65, 0x79, 0x12, 0x12, 0x0a, 0x04, 0x61, 0x74, 0x54, 0x78, 0x18, 0x02, 0x20, 0x01, 0x28,
-	0x04, 0x52, 0x04, 0x61, 0x74, 0x54, 0x78, 0x12, 0x18, 0x0a, 0x07, 0x73, 0x69, 0x6e, 0x63, 0x65,
-	0x54, 0x78, 0x18, 0x03, 0x20, 0x01, 0x28, 0x04, 0x52, 0x07, 0x73, 0x69, 0x6e, 0x63, 0x65, 0x54,
-	0x78, 0x12, 0x16, 0x0a, 0x06, 0x6e, 0x6f, 0x57, 0x61, 0x69, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28,
-	0x08, 0x52, 0x06, 0x6e, 0x6f, 0x57, 0x61, 0x69, 0x74, 0x22, 0x3e, 0x0a, 0x0e, 0x4b, 0x65, 0x79,
-	0x4c, 0x69, 0x73, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x6b,
-	0x65, 0x79, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0c, 0x52, 0x04, 0x6b, 0x65, 0x79, 0x73, 0x12,
-	0x18, 0x0a, 0x07, 0x73, 0x69, 0x6e, 0x63, 0x65, 0x54, 0x78, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04,
-	0x52, 0x07, 0x73, 0x69, 0x6e, 0x63, 0x65, 0x54, 0x78, 0x22, 0x59, 0x0a, 0x11, 0x44, 0x65, 0x6c,
-	0x65, 0x74, 0x65, 0x4b, 0x65, 0x79, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x12,
-	0x0a, 0x04, 0x6b, 0x65, 0x79, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0c, 0x52, 0x04, 0x6b, 0x65,
-	0x79, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x73, 0x69, 0x6e, 0x63, 0x65, 0x54, 0x78, 0x18, 0x02, 0x20,
-	0x01, 0x28, 0x04, 0x52, 0x07, 0x73, 0x69, 0x6e, 0x63, 0x65, 0x54, 0x78, 0x12, 0x16, 0x0a, 0x06,
-	0x6e, 0x6f, 0x57, 0x61, 0x69, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x6e, 0x6f,
-	0x57, 0x61, 0x69, 0x74, 0x22, 0x75, 0x0a, 0x14, 0x56, 0x65, 0x72, 0x69, 0x66, 0x69, 0x61, 0x62,
-	0x6c, 0x65, 0x53, 0x65, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x39, 0x0a, 0x0a,
-	0x73, 0x65, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b,
-	0x32, 0x19, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61,
-	0x2e, 0x53, 0x65, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x52, 0x0a, 0x73, 0x65, 0x74,
-	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x22, 0x0a, 0x0c, 0x70, 0x72, 0x6f, 0x76, 0x65,
-	0x53, 0x69, 0x6e, 0x63, 0x65, 0x54, 0x78, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0c, 0x70,
-	0x72, 0x6f, 0x76, 0x65, 0x53, 0x69, 0x6e, 0x63, 0x65, 0x54, 0x78, 0x22, 0x75, 0x0a, 0x14, 0x56,
-	0x65, 0x72, 0x69, 0x66, 0x69, 0x61, 0x62, 0x6c, 0x65, 0x47, 0x65, 0x74, 0x52, 0x65, 0x71, 0x75,
-	0x65, 0x73, 0x74, 0x12, 0x39, 0x0a, 0x0a, 0x6b, 0x65, 0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
-	0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62,
-	0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x4b, 0x65, 0x79, 0x52, 0x65, 0x71, 0x75, 0x65,
-	0x73, 0x74, 0x52, 0x0a, 0x6b, 0x65, 0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x22,
-	0x0a, 0x0c, 0x70, 0x72, 0x6f, 0x76, 0x65, 0x53, 0x69, 0x6e, 0x63, 0x65, 0x54, 0x78, 0x18, 0x02,
-	0x20, 0x01, 0x28, 0x04, 0x52, 0x0c, 0x70, 0x72, 0x6f, 0x76, 0x65, 0x53, 0x69, 0x6e, 0x63, 0x65,
-	0x54, 0x78, 0x22, 0x42, 0x0a, 0x0e, 0x48, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x52, 0x65, 0x73, 0x70,
-	0x6f, 0x6e, 0x73, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x01,
-	0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x18, 0x0a, 0x07,
-	0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x76,
-	0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x22, 0x7a, 0x0a, 0x16, 0x44, 0x61, 0x74, 0x61, 0x62, 0x61,
-	0x73, 0x65, 0x48, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
-	0x12, 0x28, 0x0a, 0x0f, 0x70, 0x65, 0x6e, 0x64, 0x69, 0x6e, 0x67, 0x52, 0x65, 0x71, 0x75, 0x65,
-	0x73, 0x74, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0f, 0x70, 0x65, 0x6e, 0x64, 0x69,
-	0x6e, 0x67, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x73, 0x12, 0x36, 0x0a, 0x16, 0x6c, 0x61,
-	0x73, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x43, 0x6f, 0x6d, 0x70, 0x6c, 0x65, 0x74,
-	0x65, 0x64, 0x41, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x16, 0x6c, 0x61, 0x73, 0x74,
-	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x43, 0x6f, 0x6d, 0x70, 0x6c, 0x65, 0x74, 0x65, 0x64,
-	0x41, 0x74, 0x22, 0x84, 0x01, 0x0a, 0x0e, 0x49, 0x6d, 0x6d, 0x75, 0x74, 0x61, 0x62, 0x6c, 0x65,
-	0x53, 0x74, 0x61, 0x74, 0x65, 0x12, 0x0e, 0x0a, 0x02, 0x64, 0x62, 0x18, 0x01, 0x20, 0x01, 0x28,
-	0x09, 0x52, 0x02, 0x64, 0x62, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x78, 0x49, 0x64, 0x18, 0x02, 0x20,
-	0x01, 0x28, 0x04, 0x52, 0x04, 0x74, 0x78, 0x49, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x74, 0x78, 0x48,
-	0x61, 0x73, 0x68, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x06, 0x74, 0x78, 0x48, 0x61, 0x73,
-	0x68, 0x12, 0x36, 0x0a, 0x09, 0x73, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x18, 0x04,
-	0x20, 0x01, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63,
-	0x68, 0x65, 0x6d, 0x61, 0x2e, 0x53, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x52, 0x09,
-	0x73, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x22, 0x92, 0x01, 0x0a, 0x10, 0x52, 0x65,
-	0x66, 0x65, 0x72, 0x65, 0x6e, 0x63, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x10,
-	0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x03, 0x6b, 0x65, 0x79,
-	0x12, 0x24, 0x0a, 0x0d, 0x72, 0x65, 0x66, 0x65, 0x72, 0x65, 0x6e, 0x63, 0x65, 0x64, 0x4b, 0x65,
-	0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0d, 0x72, 0x65, 0x66, 0x65, 0x72, 0x65, 0x6e,
-	0x63, 0x65, 0x64, 0x4b, 0x65, 0x79, 0x12, 0x12, 0x0a, 0x04, 0x61, 0x74, 0x54, 0x78, 0x18, 0x03,
-	0x20, 0x01, 0x28, 0x04, 0x52, 0x04, 0x61, 0x74, 0x54, 0x78, 0x12, 0x1a, 0x0a, 0x08, 0x62, 0x6f,
-	0x75, 0x6e, 0x64, 0x52, 0x65, 0x66, 0x18, 0x04, 0x20, 0x01, 0x28, 0x08, 0x52, 0x08, 0x62, 0x6f,
-	0x75, 0x6e, 0x64, 0x52, 0x65, 0x66, 0x12, 0x16, 0x0a, 0x06, 0x6e, 0x6f, 0x57, 0x61, 0x69, 0x74,
-	0x18, 0x05, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x6e, 0x6f, 0x57, 0x61, 0x69, 0x74, 0x22, 0x8d,
-	0x01, 0x0a, 0x1a, 0x56, 0x65, 0x72, 0x69, 0x66, 0x69, 0x61, 0x62, 0x6c, 0x65, 0x52, 0x65, 0x66,
-	0x65, 0x72, 0x65, 0x6e, 0x63, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x4b, 0x0a,
-	0x10, 0x72, 0x65, 0x66, 0x65, 0x72, 0x65, 0x6e, 0x63, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
-	0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1f, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62,
-	0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x52, 0x65, 0x66, 0x65, 0x72, 0x65, 0x6e, 0x63,
-	0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x52, 0x10, 0x72, 0x65, 0x66, 0x65, 0x72, 0x65,
-	0x6e, 0x63, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x22, 0x0a, 0x0c, 0x70, 0x72,
-	0x6f, 0x76, 0x65, 0x53, 0x69, 0x6e, 0x63, 0x65, 0x54, 0x78, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04,
-	0x52, 0x0c, 0x70, 0x72, 0x6f, 0x76, 0x65, 0x53, 0x69, 0x6e, 0x63, 0x65, 0x54, 0x78, 0x22, 0x8f,
-	0x01, 0x0a, 0x0b, 0x5a, 0x41, 0x64, 0x64, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x10,
-	0x0a, 0x03, 0x73, 0x65, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x03, 0x73, 0x65, 0x74,
-	0x12, 0x14, 0x0a, 0x05, 0x73, 0x63, 0x6f, 0x72, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x01, 0x52,
-	0x05, 0x73, 0x63, 0x6f, 0x72, 0x65, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x03, 0x20,
-	0x01, 0x28, 0x0c, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x12, 0x0a, 0x04, 0x61, 0x74, 0x54, 0x78,
-	0x18, 0x04, 0x20, 0x01, 0x28, 0x04, 0x52, 0x04, 0x61, 0x74, 0x54, 0x78, 0x12, 0x1a, 0x0a, 0x08,
-	0x62, 0x6f, 0x75, 0x6e, 0x64, 0x52, 0x65, 0x66, 0x18, 0x05, 0x20, 0x01, 0x28, 0x08, 0x52, 0x08,
-	0x62, 0x6f, 0x75, 0x6e, 0x64, 0x52, 0x65, 0x66, 0x12, 0x16, 0x0a, 0x06, 0x6e, 0x6f, 0x57, 0x61,
-	0x69, 0x74, 0x18, 0x06, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x6e, 0x6f, 0x57, 0x61, 0x69, 0x74,
-	0x22, 0x1d, 0x0a, 0x05, 0x53, 0x63, 0x6f, 0x72, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x73, 0x63, 0x6f,
-	0x72, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x01, 0x52, 0x05, 0x73, 0x63, 0x6f, 0x72, 0x65, 0x22,
-	0xda, 0x02, 0x0a, 0x0c, 0x5a, 0x53, 0x63, 0x61, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
-	0x12, 0x10, 0x0a, 0x03, 0x73, 0x65, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x03, 0x73,
-	0x65, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x73, 0x65, 0x65, 0x6b, 0x4b, 0x65, 0x79, 0x18, 0x02, 0x20,
-	0x01, 0x28, 0x0c, 0x52, 0x07, 0x73, 0x65, 0x65, 0x6b, 0x4b, 0x65, 0x79, 0x12, 0x1c, 0x0a, 0x09,
-	0x73, 0x65, 0x65, 0x6b, 0x53, 0x63, 0x6f, 0x72, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x01, 0x52,
-	0x09, 0x73, 0x65, 0x65, 0x6b, 0x53, 0x63, 0x6f, 0x72, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x73, 0x65,
-	0x65, 0x6b, 0x41, 0x74, 0x54, 0x78, 0x18, 0x04, 0x20, 0x01, 0x28, 0x04, 0x52, 0x08, 0x73, 0x65,
-	0x65, 0x6b, 0x41, 0x74, 0x54, 0x78, 0x12, 0x24, 0x0a, 0x0d, 0x69, 0x6e, 0x63, 0x6c, 0x75, 0x73,
-	0x69, 0x76, 0x65, 0x53, 0x65, 0x65, 0x6b, 0x18, 0x05, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0d, 0x69,
-	0x6e, 0x63, 0x6c, 0x75, 0x73, 0x69, 0x76, 0x65, 0x53, 0x65, 0x65, 0x6b, 0x12, 0x14, 0x0a, 0x05,
-	0x6c, 0x69, 0x6d, 0x69, 0x74, 0x18, 0x06, 0x20, 0x01, 0x28, 0x04, 0x52, 0x05, 0x6c, 0x69, 0x6d,
-	0x69, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x64, 0x65, 0x73, 0x63, 0x18, 0x07, 0x20, 0x01, 0x28, 0x08,
-	0x52, 0x04, 0x64, 0x65, 0x73, 0x63, 0x12, 0x30, 0x0a, 0x08, 0x6d, 0x69, 0x6e, 0x53, 0x63, 0x6f,
-	0x72, 0x65, 0x18, 0x08, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64,
-	0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x53, 0x63, 0x6f, 0x72, 0x65, 0x52, 0x08,
-	0x6d, 0x69, 0x6e, 0x53, 0x63, 0x6f, 0x72, 0x65, 0x12, 0x30, 0x0a, 0x08, 0x6d, 0x61, 0x78, 0x53,
-	0x63, 0x6f, 0x72, 0x65, 0x18, 0x09, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x69, 0x6d, 0x6d,
-	0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x53, 0x63, 0x6f, 0x72, 0x65,
-	0x52, 0x08, 0x6d, 0x61, 0x78, 0x53, 0x63, 0x6f, 0x72, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x73, 0x69,
-	0x6e, 0x63, 0x65, 0x54, 0x78, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x04, 0x52, 0x07, 0x73, 0x69, 0x6e,
-	0x63, 0x65, 0x54, 0x78, 0x12, 0x16, 0x0a, 0x06, 0x6e, 0x6f, 0x57, 0x61, 0x69, 0x74, 0x18, 0x0b,
-	0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x6e, 0x6f, 0x57, 0x61, 0x69, 0x74, 0x22, 0x7e, 0x0a, 0x0e,
-	0x48, 0x69, 0x73, 0x74, 0x6f, 0x72, 0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x10,
-	0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x03, 0x6b, 0x65, 0x79,
-	0x12, 0x16, 0x0a, 0x06, 0x6f, 0x66, 0x66, 0x73, 0x65, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04,
-	0x52, 0x06, 0x6f, 0x66, 0x66, 0x73, 0x65, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x6c, 0x69, 0x6d, 0x69,
-	0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x12, 0x12,
-	0x0a, 0x04, 0x64, 0x65, 0x73, 0x63, 0x18, 0x04, 0x20, 0x01, 0x28, 0x08, 0x52, 0x04, 0x64, 0x65,
-	0x73, 0x63, 0x12, 0x18, 0x0a, 0x07, 0x73, 0x69, 0x6e, 0x63, 0x65, 0x54, 0x78, 0x18, 0x05, 0x20,
-	0x01, 0x28, 0x04, 0x52, 0x07, 0x73, 0x69, 0x6e, 0x63, 0x65, 0x54, 0x78, 0x22, 0x79, 0x0a, 0x15,
-	0x56, 0x65, 0x72, 0x69, 0x66, 0x69, 0x61, 0x62, 0x6c, 0x65, 0x5a, 0x41, 0x64, 0x64, 0x52, 0x65,
-	0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x3c, 0x0a, 0x0b, 0x7a, 0x41, 0x64, 0x64, 0x52, 0x65, 0x71,
-	0x75, 0x65, 0x73, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x69, 0x6d, 0x6d,
-	0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x5a, 0x41, 0x64, 0x64, 0x52,
-	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x52, 0x0b, 0x7a, 0x41, 0x64, 0x64, 0x52, 0x65, 0x71, 0x75,
-	0x65, 0x73, 0x74, 0x12, 0x22, 0x0a, 0x0c, 0x70, 0x72, 0x6f, 0x76, 0x65, 0x53, 0x69, 0x6e, 0x63,
-	0x65, 0x54, 0x78, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0c, 0x70, 0x72, 0x6f, 0x76, 0x65,
-	0x53, 0x69, 0x6e, 0x63, 0x65, 0x54, 0x78, 0x22, 0x8b, 0x01, 0x0a, 0x09, 0x54, 0x78, 0x52, 0x65,
-	0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x0e, 0x0a, 0x02, 0x74, 0x78, 0x18, 0x01, 0x20, 0x01, 0x28,
-	0x04, 0x52, 0x02, 0x74, 0x78, 0x12, 0x3c, 0x0a, 0x0b, 0x65, 0x6e, 0x74, 0x72, 0x69, 0x65, 0x73,
-	0x53, 0x70, 0x65, 0x63, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x69, 0x6d, 0x6d,
-	0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x45, 0x6e, 0x74, 0x72, 0x69,
-	0x65, 0x73, 0x53, 0x70, 0x65, 0x63, 0x52, 0x0b, 0x65, 0x6e, 0x74, 0x72, 0x69, 0x65, 0x73, 0x53,
-	0x70, 0x65, 0x63, 0x12, 0x18, 0x0a, 0x07, 0x73, 0x69, 0x6e, 0x63, 0x65, 0x54, 0x78, 0x18, 0x03,
-	0x20, 0x01, 0x28, 0x04, 0x52, 0x07, 0x73, 0x69, 0x6e, 0x63, 0x65, 0x54, 0x78, 0x12, 0x16, 0x0a,
-	0x06, 0x6e, 0x6f, 0x57, 0x61, 0x69, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x6e,
-	0x6f, 0x57, 0x61, 0x69, 0x74, 0x22, 0xd9, 0x01, 0x0a, 0x0b, 0x45, 0x6e, 0x74, 0x72, 0x69, 0x65,
-	0x73, 0x53, 0x70, 0x65, 0x63, 0x12, 0x42, 0x0a, 0x0d, 0x6b, 0x76, 0x45, 0x6e, 0x74, 0x72, 0x69,
-	0x65, 0x73, 0x53, 0x70, 0x65, 0x63, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1c, 0x2e, 0x69,
-	0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x45, 0x6e, 0x74,
-	0x72, 0x79, 0x54, 0x79, 0x70, 0x65, 0x53, 0x70, 0x65, 0x63, 0x52, 0x0d, 0x6b, 0x76, 0x45, 0x6e,
-	0x74, 0x72, 0x69, 0x65, 0x73, 0x53, 0x70, 0x65, 0x63, 0x12, 0x40, 0x0a, 0x0c, 0x7a, 0x45, 0x6e,
-	0x74, 0x72, 0x69, 0x65, 0x73, 0x53, 0x70, 0x65, 0x63, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32,
-	0x1c, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e,
-	0x45, 0x6e, 0x74, 0x72, 0x79, 0x54, 0x79, 0x70, 0x65, 0x53, 0x70, 0x65, 0x63, 0x52, 0x0c, 0x7a,
-	0x45, 0x6e, 0x74, 0x72, 0x69, 0x65, 0x73, 0x53, 0x70, 0x65, 0x63, 0x12, 0x44, 0x0a, 0x0e, 0x73,
-	0x71, 0x6c, 0x45, 0x6e, 0x74, 0x72, 0x69, 0x65, 0x73, 0x53, 0x70, 0x65, 0x63, 0x18, 0x03, 0x20,
-	0x01, 0x28, 0x0b, 0x32, 0x1c, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68,
-	0x65, 0x6d, 0x61, 0x2e, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x54, 0x79, 0x70, 0x65, 0x53, 0x70, 0x65,
-	0x63, 0x52, 0x0e, 0x73, 0x71, 0x6c, 0x45, 0x6e, 0x74, 0x72, 0x69, 0x65, 0x73, 0x53, 0x70, 0x65,
-	0x63, 0x22, 0x47, 0x0a, 0x0d, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x54, 0x79, 0x70, 0x65, 0x53, 0x70,
-	0x65, 0x63, 0x12, 0x36, 0x0a, 0x06, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01,
-	0x28, 0x0e, 0x32, 0x1e, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65,
-	0x6d, 0x61, 0x2e, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x54, 0x79, 0x70, 0x65, 0x41, 0x63, 0x74, 0x69,
-	0x6f, 0x6e, 0x52, 0x06, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x22, 0xb9, 0x01, 0x0a, 0x13, 0x56,
-	0x65, 0x72, 0x69, 0x66, 0x69, 0x61, 0x62, 0x6c, 0x65, 0x54, 0x78, 0x52, 0x65, 0x71, 0x75, 0x65,
-	0x73, 0x74, 0x12, 0x0e, 0x0a, 0x02, 0x74, 0x78, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x02,
-	0x74, 0x78, 0x12, 0x22, 0x0a, 0x0c, 0x70, 0x72, 0x6f, 0x76, 0x65, 0x53, 0x69, 0x6e, 0x63, 0x65,
-	0x54, 0x78, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0c, 0x70, 0x72, 0x6f, 0x76, 0x65, 0x53,
-	0x69, 0x6e, 0x63, 0x65, 0x54, 0x78, 0x12, 0x3c, 0x0a, 0x0b, 0x65, 0x6e, 0x74, 0x72, 0x69, 0x65,
-	0x73, 0x53, 0x70, 0x65, 0x63, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x69, 0x6d,
-	0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x45, 0x6e, 0x74, 0x72,
-	0x69, 0x65, 0x73, 0x53, 0x70, 0x65, 0x63, 0x52, 0x0b, 0x65, 0x6e, 0x74, 0x72, 0x69, 0x65, 0x73,
-	0x53, 0x70, 0x65, 0x63, 0x12, 0x18, 0x0a, 0x07, 0x73, 0x69, 0x6e, 0x63, 0x65, 0x54, 0x78, 0x18,
-	0x04, 0x20, 0x01, 0x28, 0x04, 0x52, 0x07, 0x73, 0x69, 0x6e, 0x63, 0x65, 0x54, 0x78, 0x12, 0x16,
-	0x0a, 0x06, 0x6e, 0x6f, 0x57, 0x61, 0x69, 0x74, 0x18, 0x05, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06,
-	0x6e, 0x6f, 0x57, 0x61, 0x69, 0x74, 0x22, 0xc7, 0x01, 0x0a, 0x0d, 0x54, 0x78, 0x53, 0x63, 0x61,
-	0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1c, 0x0a, 0x09, 0x69, 0x6e, 0x69, 0x74,
-	0x69, 0x61, 0x6c, 0x54, 0x78, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x09, 0x69, 0x6e, 0x69,
-	0x74, 0x69, 0x61, 0x6c, 0x54, 0x78, 0x12, 0x14, 0x0a, 0x05, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x18,
-	0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x05, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x12, 0x12, 0x0a, 0x04,
-	0x64, 0x65, 0x73, 0x63, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x52, 0x04, 0x64, 0x65, 0x73, 0x63,
-	0x12, 0x3c, 0x0a, 0x0b, 0x65, 0x6e, 0x74, 0x72, 0x69, 0x65, 0x73, 0x53, 0x70, 0x65, 0x63, 0x18,
-	0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73,
-	0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x45, 0x6e, 0x74, 0x72, 0x69, 0x65, 0x73, 0x53, 0x70, 0x65,
-	0x63, 0x52, 0x0b, 0x65, 0x6e, 0x74, 0x72, 0x69, 0x65, 0x73, 0x53, 0x70, 0x65, 0x63, 0x12, 0x18,
-	0x0a, 0x07, 0x73, 0x69, 0x6e, 0x63, 0x65, 0x54, 0x78, 0x18, 0x05, 0x20, 0x01, 0x28, 0x04, 0x52,
-	0x07, 0x73, 0x69, 0x6e, 0x63, 0x65, 0x54, 0x78, 0x12, 0x16, 0x0a, 0x06, 0x6e, 0x6f, 0x57, 0x61,
-	0x69, 0x74, 0x18, 0x06, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x6e, 0x6f, 0x57, 0x61, 0x69, 0x74,
-	0x22, 0x2d, 0x0a, 0x06, 0x54, 0x78, 0x4c, 0x69, 0x73, 0x74, 0x12, 0x23, 0x0a, 0x03, 0x74, 0x78,
-	0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62,
-	0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x54, 0x78, 0x52, 0x03, 0x74, 0x78, 0x73, 0x22,
-	0x21, 0x0a, 0x0f, 0x45, 0x78, 0x70, 0x6f, 0x72, 0x74, 0x54, 0x78, 0x52, 0x65, 0x71, 0x75, 0x65,
-	0x73, 0x74, 0x12, 0x0e, 0x0a, 0x02, 0x74, 0x78, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x02,
-	0x74, 0x78, 0x22, 0x2e, 0x0a, 0x08, 0x44, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x12, 0x22,
-	0x0a, 0x0c, 0x64, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x4e, 0x61, 0x6d, 0x65, 0x18, 0x01,
-	0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x64, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x4e, 0x61,
-	0x6d, 0x65, 0x22, 0xc4, 0x03, 0x0a, 0x10, 0x44, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x53,
-	0x65, 0x74, 0x74, 0x69, 0x6e, 0x67, 0x73, 0x12, 0x22, 0x0a, 0x0c, 0x64, 0x61, 0x74, 0x61, 0x62,
-	0x61, 0x73, 0x65, 0x4e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x64,
-	0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x72,
-	0x65, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52, 0x07, 0x72, 0x65,
-	0x70, 0x6c, 0x69, 0x63, 0x61, 0x12, 0x26, 0x0a, 0x0e, 0x6d, 0x61, 0x73, 0x74, 0x65, 0x72, 0x44,
-	0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0e, 0x6d,
-	0x61, 0x73, 0x74, 0x65, 0x72, 0x44, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x12, 0x24, 0x0a,
-	0x0d, 0x6d, 0x61, 0x73, 0x74, 0x65, 0x72, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x18, 0x04,
-	0x20, 0x01, 0x28, 0x09, 0x52, 0x0d, 0x6d, 0x61, 0x73, 0x74, 0x65, 0x72, 0x41, 0x64, 0x64, 0x72,
-	0x65, 0x73, 0x73, 0x12, 0x1e, 0x0a, 0x0a, 0x6d, 0x61, 0x73, 0x74, 0x65, 0x72, 0x50, 0x6f, 0x72,
-	0x74, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0a, 0x6d, 0x61, 0x73, 0x74, 0x65, 0x72, 0x50,
-	0x6f, 0x72, 0x74, 0x12, 0x2a, 0x0a, 0x10, 0x66, 0x6f, 0x6c, 0x6c, 0x6f, 0x77, 0x65, 0x72, 0x55,
-	0x73, 0x65, 0x72, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x10, 0x66,
-	0x6f, 0x6c, 0x6c, 0x6f, 0x77, 0x65, 0x72, 0x55, 0x73, 0x65, 0x72, 0x6e, 0x61, 0x6d, 0x65, 0x12,
-	0x2a, 0x0a, 0x10, 0x66, 0x6f, 0x6c, 0x6c, 0x6f, 0x77, 0x65, 0x72, 0x50, 0x61, 0x73, 0x73, 0x77,
-	0x6f, 0x72, 0x64, 0x18, 0x07, 0x20, 0x01, 0x28, 0x09, 0x52, 0x10, 0x66, 0x6f, 0x6c, 0x6c, 0x6f,
-	0x77, 0x65, 0x72, 0x50, 0x61, 0x73, 0x73, 0x77, 0x6f, 0x72, 0x64, 0x12, 0x1a, 0x0a, 0x08, 0x66,
-	0x69, 0x6c, 0x65, 0x53, 0x69, 0x7a, 0x65, 0x18, 0x08, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x08, 0x66,
-	0x69, 0x6c, 0x65, 0x53, 0x69, 0x7a, 0x65, 0x12, 0x1c, 0x0a, 0x09, 0x6d, 0x61, 0x78, 0x4b, 0x65,
-	0x79, 0x4c, 0x65, 0x6e, 0x18, 0x09, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x09, 0x6d, 0x61, 0x78, 0x4b,
-	0x65, 0x79, 0x4c, 0x65, 0x6e, 0x12, 0x20, 0x0a, 0x0b, 0x6d, 0x61, 0x78, 0x56, 0x61, 0x6c, 0x75,
-	0x65, 0x4c, 0x65, 0x6e, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0b, 0x6d, 0x61, 0x78, 0x56,
-	0x61, 0x6c, 0x75, 0x65, 0x4c, 0x65, 0x6e, 0x12, 0x22, 0x0a, 0x0c, 0x6d, 0x61, 0x78, 0x54, 0x78,
-	0x45, 0x6e, 0x74, 0x72, 0x69, 0x65, 0x73, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0c, 0x6d,
-	0x61, 0x78, 0x54, 0x78, 0x45, 0x6e, 0x74, 0x72, 0x69, 0x65, 0x73, 0x12, 0x2c, 0x0a, 0x11, 0x65,
-	0x78, 0x63, 0x6c, 0x75, 0x64, 0x65, 0x43, 0x6f, 0x6d, 0x6d, 0x69, 0x74, 0x54, 0x69, 0x6d, 0x65,
-	0x18, 0x0c, 0x20, 0x01, 0x28, 0x08, 0x52, 0x11, 0x65, 0x78, 0x63, 0x6c, 0x75, 0x64, 0x65, 0x43,
-	0x6f, 0x6d, 0x6d, 0x69, 0x74, 0x54, 0x69, 0x6d, 0x65, 0x22, 0x6b, 0x0a, 0x1c, 0x44, 0x61, 0x74,
-	0x61, 0x62, 0x61, 0x73, 0x65, 0x53, 0x65, 0x74, 0x74, 0x69, 0x6e, 0x67, 0x73, 0x55, 0x70, 0x64,
-	0x61, 0x74, 0x65, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x12, 0x4b, 0x0a, 0x0f, 0x63, 0x75, 0x72,
-	0x72, 0x65, 0x6e, 0x74, 0x53, 0x65, 0x74, 0x74, 0x69, 0x6e, 0x67, 0x73, 0x18, 0x01, 0x20, 0x01,
-	0x28, 0x0b, 0x32, 0x21, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65,
-	0x6d, 0x61, 0x2e, 0x44, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x53, 0x65, 0x74, 0x74, 0x69,
-	0x6e, 0x67, 0x73, 0x56, 0x32, 0x52, 0x0f, 0x63, 0x75, 0x72, 0x72, 0x65, 0x6e, 0x74, 0x53, 0x65,
-	0x74, 0x74, 0x69, 0x6e, 0x67, 0x73, 0x22, 0x29, 0x0a, 0x11, 0x43, 0x6f, 0x6e, 0x64, 0x69, 0x74,
-	0x69, 0x6f, 0x6e, 0x61, 0x6c, 0x55, 0x69, 0x6e, 0x74, 0x33, 0x32, 0x12, 0x14, 0x0a, 0x05, 0x76,
-	0x61, 0x6c, 0x75, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75,
-	0x65, 0x22, 0x29, 0x0a, 0x11, 0x43, 0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x61, 0x6c,
-	0x55, 0x69, 0x6e, 0x74, 0x36, 0x34, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18,
-	0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x22, 0x28, 0x0a, 0x10,
-	0x43, 0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x61, 0x6c, 0x46, 0x6c, 0x6f, 0x61, 0x74,
-	0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x02, 0x52,
-	0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x22, 0x27, 0x0a, 0x0f, 0x43, 0x6f, 0x6e, 0x64, 0x69, 0x74,
-	0x69, 0x6f, 0x6e, 0x61, 0x6c, 0x42, 0x6f, 0x6f, 0x6c, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c,
-	0x75, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x22,
-	0x29, 0x0a, 0x11, 0x43, 0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x61, 0x6c, 0x53, 0x74,
-	0x72, 0x69, 0x6e, 0x67, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x01, 0x20,
-	0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x22, 0xbf, 0x03, 0x0a, 0x13, 0x52,
-	0x65, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x53, 0x65, 0x74, 0x74, 0x69, 0x6e,
-	0x67, 0x73, 0x12, 0x38, 0x0a, 0x07, 0x72, 0x65, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x18, 0x01, 0x20,
-	0x01, 0x28, 0x0b, 0x32, 0x1e, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68,
-	0x65, 0x6d, 0x61, 0x2e, 0x43, 0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x61, 0x6c, 0x42,
-	0x6f, 0x6f, 0x6c, 0x52, 0x07, 0x72, 0x65, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x12, 0x48, 0x0a, 0x0e,
-	0x6d, 0x61, 0x73, 0x74, 0x65, 0x72, 0x44, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x18, 0x02,
-	0x20, 0x01, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63,
-	0x68, 0x65, 0x6d, 0x61, 0x2e, 0x43, 0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x61, 0x6c,
-	0x53, 0x74, 0x72, 0x69, 0x6e, 0x67, 0x52, 0x0e, 0x6d, 0x61, 0x73, 0x74, 0x65, 0x72, 0x44, 0x61,
-	0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x12, 0x46, 0x0a, 0x0d, 0x6d, 0x61, 0x73, 0x74, 0x65, 0x72,
-	0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x20, 0x2e,
-	0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x43, 0x6f,
-	0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x61, 0x6c, 0x53, 0x74, 0x72, 0x69, 0x6e, 0x67, 0x52,
-	0x0d, 0x6d, 0x61, 0x73, 0x74, 0x65, 0x72, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x12, 0x40,
-	0x0a, 0x0a, 0x6d, 0x61, 0x73, 0x74, 0x65, 0x72, 0x50, 0x6f, 0x72, 0x74, 0x18, 0x04, 0x20, 0x01,
-	0x28, 0x0b, 0x32, 0x20, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65,
-	0x6d, 0x61, 0x2e, 0x43, 0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x61, 0x6c, 0x55, 0x69,
-	0x6e, 0x74, 0x33, 0x32, 0x52, 0x0a, 0x6d, 0x61, 0x73, 0x74, 0x65, 0x72, 0x50, 0x6f, 0x72, 0x74,
-	0x12, 0x4c, 0x0a, 0x10, 0x66, 0x6f, 0x6c, 0x6c, 0x6f, 0x77, 0x65, 0x72, 0x55, 0x73, 0x65, 0x72,
-	0x6e, 0x61, 0x6d, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x69, 0x6d, 0x6d,
-	0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x43, 0x6f, 0x6e, 0x64, 0x69,
-	0x74, 0x69, 0x6f, 0x6e, 0x61, 0x6c, 0x53, 0x74, 0x72, 0x69, 0x6e, 0x67, 0x52, 0x10, 0x66, 0x6f,
-	0x6c, 0x6c, 0x6f, 0x77, 0x65, 0x72, 0x55, 0x73, 0x65, 0x72, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x4c,
-	0x0a, 0x10, 0x66, 0x6f, 0x6c, 0x6c, 0x6f, 0x77, 0x65, 0x72, 0x50, 0x61, 0x73, 0x73, 0x77, 0x6f,
-	0x72, 0x64, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64,
-	0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x43, 0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69,
-	0x6f, 0x6e, 0x61, 0x6c, 0x53, 0x74, 0x72, 0x69, 0x6e, 0x67, 0x52, 0x10, 0x66, 0x6f, 0x6c, 0x6c,
-	0x6f, 0x77, 0x65, 0x72, 0x50, 0x61, 0x73, 0x73, 0x77, 0x6f, 0x72, 0x64, 0x22, 0xe2, 0x08, 0x0a,
-	0x12, 0x44, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x53, 0x65, 0x74, 0x74, 0x69, 0x6e, 0x67,
-	0x73, 0x56, 0x32, 0x12, 0x22, 0x0a, 0x0c, 0x64, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x4e,
-	0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x64, 0x61, 0x74, 0x61, 0x62,
-	0x61, 0x73, 0x65, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x54, 0x0a, 0x13, 0x72, 0x65, 0x70, 0x6c, 0x69,
-	0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x53, 0x65, 0x74, 0x74, 0x69, 0x6e, 0x67, 0x73, 0x18, 0x02,
-	0x20, 0x01, 0x28, 0x0b, 0x32, 0x22, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63,
-	0x68, 0x65, 0x6d, 0x61, 0x2e, 0x52, 0x65, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e,
-	0x53, 0x65, 0x74, 0x74, 0x69, 0x6e, 0x67, 0x73, 0x52, 0x13, 0x72, 0x65, 0x70, 0x6c, 0x69, 0x63,
-	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x53, 0x65, 0x74, 0x74, 0x69, 0x6e, 0x67, 0x73, 0x12, 0x3c, 0x0a,
-	0x08, 0x66, 0x69, 0x6c, 0x65, 0x53, 0x69, 0x7a, 0x65, 0x18, 0x08, 0x20, 0x01, 0x28, 0x0b, 0x32,
-	0x20, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e,
-	0x43, 0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x61, 0x6c, 0x55, 0x69, 0x6e, 0x74, 0x33,
-	0x32, 0x52, 0x08, 0x66, 0x69, 0x6c, 0x65, 0x53, 0x69, 0x7a, 0x65, 0x12, 0x3e, 0x0a, 0x09, 0x6d,
-	0x61, 0x78, 0x4b, 0x65, 0x79, 0x4c, 0x65, 0x6e, 0x18, 0x09, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x20,
-	0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x43,
-	0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x61, 0x6c, 0x55, 0x69, 0x6e, 0x74, 0x33, 0x32,
-	0x52, 0x09, 0x6d, 0x61, 0x78, 0x4b, 0x65, 0x79, 0x4c, 0x65, 0x6e, 0x12, 0x42, 0x0a, 0x0b, 0x6d,
-	0x61, 0x78, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x4c, 0x65, 0x6e, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x0b,
-	0x32, 0x20, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61,
-	0x2e, 0x43, 0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x61, 0x6c, 0x55, 0x69, 0x6e, 0x74,
-	0x33, 0x32, 0x52, 0x0b, 0x6d, 0x61, 0x78, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x4c, 0x65, 0x6e, 0x12,
-	0x44, 0x0a, 0x0c, 0x6d, 0x61, 0x78, 0x54, 0x78, 0x45, 0x6e, 0x74, 0x72, 0x69, 0x65, 0x73, 0x18,
-	0x0b, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73,
-	0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x43, 0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x61,
-	0x6c, 0x55, 0x69, 0x6e, 0x74, 0x33, 0x32, 0x52, 0x0c, 0x6d, 0x61, 0x78, 0x54, 0x78, 0x45, 0x6e,
-	0x74, 0x72, 0x69, 0x65, 0x73, 0x12, 0x4c, 0x0a, 0x11, 0x65, 0x78, 0x63, 0x6c, 0x75, 0x64, 0x65,
-	0x43, 0x6f, 0x6d, 0x6d, 0x69, 0x74, 0x54, 0x69, 0x6d, 0x65, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x0b,
-	0x32, 0x1e, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61,
-	0x2e, 0x43, 0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x61, 0x6c, 0x42, 0x6f, 0x6f, 0x6c,
-	0x52, 0x11, 0x65, 0x78, 0x63, 0x6c, 0x75, 0x64, 0x65, 0x43, 0x6f, 0x6d, 0x6d, 0x69, 0x74, 0x54,
-	0x69, 0x6d, 0x65, 0x12, 0x48, 0x0a, 0x0e, 0x6d, 0x61, 0x78, 0x43, 0x6f, 0x6e, 0x63, 0x75, 0x72,
-	0x72, 0x65, 0x6e, 0x63, 0x79, 0x18, 0x0d, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x69, 0x6d,
-	0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x43, 0x6f, 0x6e, 0x64,
-	0x69, 0x74, 0x69, 0x6f, 0x6e, 0x61, 0x6c, 0x55, 0x69, 0x6e, 0x74, 0x33, 0x32, 0x52, 0x0e, 0x6d,
-	0x61, 0x78, 0x43, 0x6f, 0x6e, 0x63, 0x75, 0x72, 0x72, 0x65, 0x6e, 0x63, 0x79, 0x12, 0x4c, 0x0a,
-	0x10, 0x6d, 0x61, 0x78, 0x49, 0x4f, 0x43, 0x6f, 0x6e, 0x63, 0x75, 0x72, 0x72, 0x65, 0x6e, 0x63,
-	0x79, 0x18, 0x0e, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62,
-	0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x43, 0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f,
-	0x6e, 0x61, 0x6c, 0x55, 0x69, 0x6e, 0x74, 0x33, 0x32, 0x52, 0x10, 0x6d, 0x61, 0x78, 0x49, 0x4f,
-	0x43, 0x6f, 0x6e, 0x63, 0x75, 0x72, 0x72, 0x65, 0x6e, 0x63, 0x79, 0x12, 0x48, 0x0a, 0x0e, 0x74,
-	0x78, 0x4c, 0x6f, 0x67, 0x43, 0x61, 0x63, 0x68, 0x65, 0x53, 0x69, 0x7a, 0x65, 0x18, 0x0f, 0x20,
-	0x01, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68,
-	0x65, 0x6d, 0x61, 0x2e, 0x43, 0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x61, 0x6c, 0x55,
-	0x69, 0x6e, 0x74, 0x33, 0x32, 0x52, 0x0e, 0x74, 0x78, 0x4c, 0x6f, 0x67, 0x43, 0x61, 0x63, 0x68,
-	0x65, 0x53, 0x69, 0x7a, 0x65, 0x12, 0x50, 0x0a, 0x12, 0x76, 0x4c, 0x6f, 0x67, 0x4d, 0x61, 0x78,
-	0x4f, 0x70, 0x65, 0x6e, 0x65, 0x64, 0x46, 0x69, 0x6c, 0x65, 0x73, 0x18, 0x10, 0x20, 0x01, 0x28,
-	0x0b, 0x32, 0x20, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d,
-	0x61, 0x2e, 0x43, 0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x61, 0x6c, 0x55, 0x69, 0x6e,
-	0x74, 0x33, 0x32, 0x52, 0x12, 0x76, 0x4c, 0x6f, 0x67, 0x4d, 0x61, 0x78, 0x4f, 0x70, 0x65, 0x6e,
-	0x65, 0x64, 0x46, 0x69, 0x6c, 0x65, 0x73, 0x12, 0x52, 0x0a, 0x13, 0x74, 0x78, 0x4c, 0x6f, 0x67,
-	0x4d, 0x61, 0x78, 0x4f, 0x70, 0x65, 0x6e, 0x65, 0x64, 0x46, 0x69, 0x6c, 0x65, 0x73, 0x18, 0x11,
-	0x20, 0x01, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63,
-	0x68, 0x65, 0x6d, 0x61, 0x2e, 0x43, 0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x61, 0x6c,
-	0x55, 0x69, 0x6e, 0x74, 0x33, 0x32, 0x52, 0x13, 0x74, 0x78, 0x4c, 0x6f, 0x67, 0x4d, 0x61, 0x78,
-	0x4f, 0x70, 0x65, 0x6e, 0x65, 0x64, 0x46, 0x69, 0x6c, 0x65, 0x73, 0x12, 0x5a, 0x0a, 0x17, 0x63,
-	0x6f, 0x6d, 0x6d, 0x69, 0x74, 0x4c, 0x6f, 0x67, 0x4d, 0x61, 0x78, 0x4f, 0x70, 0x65, 0x6e, 0x65,
-	0x64, 0x46, 0x69, 0x6c, 0x65, 0x73, 0x18, 0x12, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x69,
-	0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x43, 0x6f, 0x6e,
-	0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x61, 0x6c, 0x55, 0x69, 0x6e, 0x74, 0x33, 0x32, 0x52, 0x17,
-	0x63, 0x6f, 0x6d, 0x6d, 0x69, 0x74, 0x4c, 0x6f, 0x67, 0x4d, 0x61, 0x78, 0x4f, 0x70, 0x65, 0x6e,
-	0x65, 0x64, 0x46, 0x69, 0x6c, 0x65, 0x73, 0x12, 0x42, 0x0a, 0x0d, 0x69, 0x6e, 0x64, 0x65, 0x78,
-	0x53, 0x65, 0x74, 0x74, 0x69, 0x6e, 0x67, 0x73, 0x18, 0x13, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1c,
-	0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x49,
-	0x6e, 0x64, 0x65, 0x78, 0x53, 0x65, 0x74, 0x74, 0x69, 0x6e, 0x67, 0x73, 0x52, 0x0d, 0x69, 0x6e,
-	0x64, 0x65, 0x78, 0x53, 0x65, 0x74, 0x74, 0x69, 0x6e, 0x67, 0x73, 0x12, 0x54, 0x0a, 0x14, 0x77,
-	0x72, 0x69, 0x74, 0x65, 0x54, 0x78, 0x48, 0x65, 0x61, 0x64, 0x65, 0x72, 0x56, 0x65, 0x72, 0x73,
-	0x69, 0x6f, 0x6e, 0x18, 0x14, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x69, 0x6d, 0x6d, 0x75,
-	0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x43, 0x6f, 0x6e, 0x64, 0x69, 0x74,
-	0x69, 0x6f, 0x6e, 0x61, 0x6c, 0x55, 0x69, 0x6e, 0x74, 0x33, 0x32, 0x52, 0x14, 0x77, 0x72, 0x69,
-	0x74, 0x65, 0x54, 0x78, 0x48, 0x65, 0x61, 0x64, 0x65, 0x72, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f,
-	0x6e, 0x22, 0x9a, 0x08, 0x0a, 0x0d, 0x49, 0x6e, 0x64, 0x65, 0x78, 0x53, 0x65, 0x74, 0x74, 0x69,
-	0x6e, 0x67, 0x73, 0x12, 0x48, 0x0a, 0x0e, 0x66, 0x6c, 0x75, 0x73, 0x68, 0x54, 0x68, 0x72, 0x65,
-	0x73, 0x68, 0x6f, 0x6c, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x69, 0x6d,
-	0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x43, 0x6f, 0x6e, 0x64,
-	0x69, 0x74, 0x69, 0x6f, 0x6e, 0x61, 0x6c, 0x55, 0x69, 0x6e, 0x74, 0x33, 0x32, 0x52, 0x0e, 0x66,
-	0x6c, 0x75, 0x73, 0x68, 0x54, 0x68, 0x72, 0x65, 0x73, 0x68, 0x6f, 0x6c, 0x64, 0x12, 0x46, 0x0a,
-	0x0d, 0x73, 0x79, 0x6e, 0x63, 0x54, 0x68, 0x72, 0x65, 0x73, 0x68, 0x6f, 0x6c, 0x64, 0x18, 0x02,
-	0x20, 0x01, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63,
-	0x68, 0x65, 0x6d, 0x61, 0x2e, 0x43, 0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x61, 0x6c,
-	0x55, 0x69, 0x6e, 0x74, 0x33, 0x32, 0x52, 0x0d, 0x73, 0x79, 0x6e, 0x63, 0x54, 0x68, 0x72, 0x65,
-	0x73, 0x68, 0x6f, 0x6c, 0x64, 0x12, 0x3e, 0x0a, 0x09, 0x63, 0x61, 0x63, 0x68, 0x65, 0x53, 0x69,
-	0x7a, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64,
-	0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x43, 0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69,
-	0x6f, 0x6e, 0x61, 0x6c, 0x55, 0x69, 0x6e, 0x74, 0x33, 0x32, 0x52, 0x09, 0x63, 0x61, 0x63, 0x68,
-	0x65, 0x53, 0x69, 0x7a, 0x65, 0x12, 0x42, 0x0a, 0x0b, 0x6d, 0x61, 0x78, 0x4e, 0x6f, 0x64, 0x65,
-	0x53, 0x69, 0x7a, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x69, 0x6d, 0x6d,
-	0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x43, 0x6f, 0x6e, 0x64, 0x69,
-	0x74, 0x69, 0x6f, 0x6e, 0x61, 0x6c, 0x55, 0x69, 0x6e, 0x74, 0x33, 0x32, 0x52, 0x0b, 0x6d, 0x61,
-	0x78, 0x4e, 0x6f, 0x64, 0x65, 0x53, 0x69, 0x7a, 0x65, 0x12, 0x50, 0x0a, 0x12, 0x6d, 0x61, 0x78,
-	0x41, 0x63, 0x74, 0x69, 0x76, 0x65, 0x53, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x73, 0x18,
-	0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73,
-	0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x43, 0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x61,
-	0x6c, 0x55, 0x69, 0x6e, 0x74, 0x33, 0x32, 0x52, 0x12, 0x6d, 0x61, 0x78, 0x41, 0x63, 0x74, 0x69,
-	0x76, 0x65, 0x53, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x73, 0x12, 0x50, 0x0a, 0x12, 0x72,
-	0x65, 0x6e, 0x65, 0x77, 0x53, 0x6e, 0x61, 0x70, 0x52, 0x6f, 0x6f, 0x74, 0x41, 0x66, 0x74, 0x65,
-	0x72, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62,
-	0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x43, 0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f,
-	0x6e, 0x61, 0x6c, 0x55, 0x69, 0x6e, 0x74, 0x36, 0x34, 0x52, 0x12, 0x72, 0x65, 0x6e, 0x65, 0x77,
-	0x53, 0x6e, 0x61, 0x70, 0x52, 0x6f, 0x6f, 0x74, 0x41, 0x66, 0x74, 0x65, 0x72, 0x12, 0x48, 0x0a,
-	0x0e, 0x63, 0x6f, 0x6d, 0x70, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x54, 0x68, 0x6c, 0x64, 0x18,
-	0x07, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73,
-	0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x43, 0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x61,
-	0x6c, 0x55, 0x69, 0x6e, 0x74, 0x33, 0x32, 0x52, 0x0e, 0x63, 0x6f, 0x6d, 0x70, 0x61, 0x63, 0x74,
-	0x69, 0x6f, 0x6e, 0x54, 0x68, 0x6c, 0x64, 0x12, 0x56, 0x0a, 0x15, 0x64, 0x65, 0x6c, 0x61, 0x79,
-	0x44, 0x75, 0x72, 0x69, 0x6e, 0x67, 0x43, 0x6f, 0x6d, 0x70, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e,
-	0x18, 0x08, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e,
-	0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x43, 0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e,
-	0x61, 0x6c, 0x55, 0x69, 0x6e, 0x74, 0x33, 0x32, 0x52, 0x15, 0x64, 0x65, 0x6c, 0x61, 0x79, 0x44,
-	0x75, 0x72, 0x69, 0x6e, 0x67, 0x43, 0x6f, 0x6d, 0x70, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12,
-	0x58, 0x0a, 0x16, 0x6e, 0x6f, 0x64, 0x65, 0x73, 0x4c, 0x6f, 0x67, 0x4d, 0x61, 0x78, 0x4f, 0x70,
-	0x65, 0x6e, 0x65, 0x64, 0x46, 0x69, 0x6c, 0x65, 0x73, 0x18, 0x09, 0x20, 0x01, 0x28, 0x0b, 0x32,
-	0x20, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e,
-	0x43, 0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x61, 0x6c, 0x55, 0x69, 0x6e, 0x74, 0x33,
-	0x32, 0x52, 0x16, 0x6e, 0x6f, 0x64, 0x65, 0x73, 0x4c, 0x6f, 0x67, 0x4d, 0x61, 0x78, 0x4f, 0x70,
-	0x65, 0x6e, 0x65, 0x64, 0x46, 0x69, 0x6c, 0x65, 0x73, 0x12, 0x5c, 0x0a, 0x18, 0x68, 0x69, 0x73,
-	0x74, 0x6f, 0x72, 0x79, 0x4c, 0x6f, 0x67, 0x4d, 0x61, 0x78, 0x4f, 0x70, 0x65, 0x6e, 0x65, 0x64,
-	0x46, 0x69, 0x6c, 0x65, 0x73, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x69, 0x6d,
-	0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x43, 0x6f, 0x6e, 0x64,
-	0x69, 0x74, 0x69, 0x6f, 0x6e, 0x61, 0x6c, 0x55, 0x69, 0x6e, 0x74, 0x33, 0x32, 0x52, 0x18, 0x68,
-	0x69, 0x73, 0x74, 0x6f, 0x72, 0x79, 0x4c, 0x6f, 0x67, 0x4d, 0x61, 0x78, 0x4f, 0x70, 0x65, 0x6e,
-	0x65, 0x64, 0x46, 0x69, 0x6c, 0x65, 0x73, 0x12, 0x5a, 0x0a, 0x17, 0x63, 0x6f, 0x6d, 0x6d, 0x69,
-	0x74, 0x4c, 0x6f, 0x67, 0x4d, 0x61, 0x78, 0x4f, 0x70, 0x65, 0x6e, 0x65, 0x64, 0x46, 0x69, 0x6c,
-	0x65, 0x73, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64,
-	0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x43, 0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69,
-	0x6f, 0x6e, 0x61, 0x6c, 0x55, 0x69, 0x6e, 0x74, 0x33, 0x32, 0x52, 0x17, 0x63, 0x6f, 0x6d, 0x6d,
-	0x69, 0x74, 0x4c, 0x6f, 0x67, 0x4d, 0x61, 0x78, 0x4f, 0x70, 0x65, 0x6e, 0x65, 0x64, 0x46, 0x69,
-	0x6c, 0x65, 0x73, 0x12, 0x4a, 0x0a, 0x0f, 0x66, 0x6c, 0x75, 0x73, 0x68, 0x42, 0x75, 0x66, 0x66,
-	0x65, 0x72, 0x53, 0x69, 0x7a, 0x65, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x69,
-	0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x43, 0x6f, 0x6e,
-	0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x61, 0x6c, 0x55, 0x69, 0x6e, 0x74, 0x33, 0x32, 0x52, 0x0f,
-	0x66, 0x6c, 0x75, 0x73, 0x68, 0x42, 0x75, 0x66, 0x66, 0x65, 0x72, 0x53, 0x69, 0x7a, 0x65, 0x12,
-	0x4d, 0x0a, 0x11, 0x63, 0x6c, 0x65, 0x61, 0x6e, 0x75, 0x70, 0x50, 0x65, 0x72, 0x63, 0x65, 0x6e,
-	0x74, 0x61, 0x67, 0x65, 0x18, 0x0d, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1f, 0x2e, 0x69, 0x6d, 0x6d,
-	0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x43, 0x6f, 0x6e, 0x64, 0x69,
-	0x74, 0x69, 0x6f, 0x6e, 0x61, 0x6c, 0x46, 0x6c, 0x6f, 0x61, 0x74, 0x52, 0x11, 0x63, 0x6c, 0x65,
-	0x61, 0x6e, 0x75, 0x70, 0x50, 0x65, 0x72, 0x63, 0x65, 0x6e, 0x74, 0x61, 0x67, 0x65, 0x22, 0x59,
-	0x0a, 0x11, 0x46, 0x6c, 0x75, 0x73, 0x68, 0x49, 0x6e, 0x64, 0x65, 0x78, 0x52, 0x65, 0x71, 0x75,
-	0x65, 0x73, 0x74, 0x12, 0x2c, 0x0a, 0x11, 0x63, 0x6c, 0x65, 0x61, 0x6e, 0x75, 0x70, 0x50, 0x65,
-	0x72, 0x63, 0x65, 0x6e, 0x74, 0x61, 0x67, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x02, 0x52, 0x11,
-	0x63, 0x6c, 0x65, 0x61, 0x6e, 0x75, 0x70, 0x50, 0x65, 0x72, 0x63, 0x65, 0x6e, 0x74, 0x61, 0x67,
-	0x65, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x79, 0x6e, 0x63, 0x65, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28,
-	0x08, 0x52, 0x06, 0x73, 0x79, 0x6e, 0x63, 0x65, 0x64, 0x22, 0x25, 0x0a, 0x05, 0x54, 0x61, 0x62,
-	0x6c, 0x65, 0x12, 0x1c, 0x0a, 0x09, 0x74, 0x61, 0x62, 0x6c, 0x65, 0x4e, 0x61, 0x6d, 0x65, 0x18,
-	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x74, 0x61, 0x62, 0x6c, 0x65, 0x4e, 0x61, 0x6d, 0x65,
-	0x22, 0x88, 0x01, 0x0a, 0x0d, 0x53, 0x51, 0x4c, 0x47, 0x65, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65,
-	0x73, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x74, 0x61, 0x62, 0x6c, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28,
-	0x09, 0x52, 0x05, 0x74, 0x61, 0x62, 0x6c, 0x65, 0x12, 0x33, 0x0a, 0x08, 0x70, 0x6b, 0x56, 0x61,
-	0x6c, 0x75, 0x65, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x69, 0x6d, 0x6d,
-	0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x53, 0x51, 0x4c, 0x56, 0x61,
-	0x6c, 0x75, 0x65, 0x52, 0x08, 0x70, 0x6b, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x73, 0x12, 0x12, 0x0a,
-	0x04, 0x61, 0x74, 0x54, 0x78, 0x18, 0x03, 0x20, 0x01, 0x28, 0x04, 0x52, 0x04, 0x61, 0x74, 0x54,
-	0x78, 0x12, 0x18, 0x0a, 0x07, 0x73, 0x69, 0x6e, 0x63, 0x65, 0x54, 0x78, 0x18, 0x04, 0x20, 0x01,
-	0x28, 0x04, 0x52, 0x07, 0x73, 0x69, 0x6e, 0x63, 0x65, 0x54, 0x78, 0x22, 0x81, 0x01, 0x0a, 0x17,
-	0x56, 0x65, 0x72, 0x69, 0x66, 0x69, 0x61, 0x62, 0x6c, 0x65, 0x53, 0x51, 0x4c, 0x47, 0x65, 0x74,
-	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x42, 0x0a, 0x0d, 0x73, 0x71, 0x6c, 0x47, 0x65,
-	0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1c,
-	0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x53,
-	0x51, 0x4c, 0x47, 0x65, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x52, 0x0d, 0x73, 0x71,
-	0x6c, 0x47, 0x65, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x22, 0x0a, 0x0c, 0x70,
-	0x72, 0x6f, 0x76, 0x65, 0x53, 0x69, 0x6e, 0x63, 0x65, 0x54, 0x78, 0x18, 0x02, 0x20, 0x01, 0x28,
-	0x04, 0x52, 0x0c, 0x70, 0x72, 0x6f, 0x76, 0x65, 0x53, 0x69, 0x6e, 0x63, 0x65, 0x54, 0x78, 0x22,
-	0x79, 0x0a, 0x08, 0x53, 0x51, 0x4c, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x0e, 0x0a, 0x02, 0x74,
-	0x78, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x02, 0x74, 0x78, 0x12, 0x10, 0x0a, 0x03, 0x6b,
-	0x65, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a,
-	0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x05, 0x76, 0x61,
-	0x6c, 0x75, 0x65, 0x12, 0x35, 0x0a, 0x08, 0x6d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x18,
-	0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73,
-	0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x4b, 0x56, 0x4d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61,
-	0x52, 0x08, 0x6d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x22, 0x87, 0x07, 0x0a, 0x12, 0x56,
-	0x65, 0x72, 0x69, 0x66, 0x69, 0x61, 0x62, 0x6c, 0x65, 0x53, 0x51, 0x4c, 0x45, 0x6e, 0x74, 0x72,
-	0x79, 0x12, 0x33, 0x0a, 0x08, 0x73, 0x71, 0x6c, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x18, 0x01, 0x20,
-	0x01, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68,
-	0x65, 0x6d, 0x61, 0x2e, 0x53, 0x51, 0x4c, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x08, 0x73, 0x71,
-	0x6c, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x3f, 0x0a, 0x0c, 0x76, 0x65, 0x72, 0x69, 0x66, 0x69,
-	0x61, 0x62, 0x6c, 0x65, 0x54, 0x78, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1b, 0x2e, 0x69,
-	0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x56, 0x65, 0x72,
-	0x69, 0x66, 0x69, 0x61, 0x62, 0x6c, 0x65, 0x54, 0x78, 0x52, 0x0c, 0x76, 0x65, 0x72, 0x69, 0x66,
-	0x69, 0x61, 0x62, 0x6c, 0x65, 0x54, 0x78, 0x12, 0x45, 0x0a, 0x0e, 0x69, 0x6e, 0x63, 0x6c, 0x75,
-	0x73, 0x69, 0x6f, 0x6e, 0x50, 0x72, 0x6f, 0x6f, 0x66, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32,
-	0x1d, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e,
-	0x49, 0x6e, 0x63, 0x6c, 0x75, 0x73, 0x69, 0x6f, 0x6e, 0x50, 0x72, 0x6f, 0x6f, 0x66, 0x52, 0x0e,
-	0x69, 0x6e, 0x63, 0x6c, 0x75, 0x73, 0x69, 0x6f, 0x6e, 0x50, 0x72, 0x6f, 0x6f, 0x66, 0x12, 0x1e,
-	0x0a, 0x0a, 0x44, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x49, 0x64, 0x18, 0x04, 0x20, 0x01,
-	0x28, 0x0d, 0x52, 0x0a, 0x44, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x49, 0x64, 0x12, 0x18,
-	0x0a, 0x07, 0x54, 0x61, 0x62, 0x6c, 0x65, 0x49, 0x64, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0d, 0x52,
-	0x07, 0x54, 0x61, 0x62, 0x6c, 0x65, 0x49, 0x64, 0x12, 0x14, 0x0a, 0x05, 0x50, 0x4b, 0x49, 0x44,
-	0x73, 0x18, 0x10, 0x20, 0x03, 0x28, 0x0d, 0x52, 0x05, 0x50, 0x4b, 0x49, 0x44, 0x73, 0x12, 0x57,
-	0x0a, 0x0c, 0x43, 0x6f, 0x6c, 0x4e, 0x61, 0x6d, 0x65, 0x73, 0x42, 0x79, 0x49, 0x64, 0x18, 0x08,
-	0x20, 0x03, 0x28, 0x0b, 0x32, 0x33, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63,
-	0x68, 0x65, 0x6d, 0x61, 0x2e, 0x56, 0x65, 0x72, 0x69, 0x66, 0x69, 0x61, 0x62, 0x6c, 0x65, 0x53,
-	0x51, 0x4c, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x2e, 0x43, 0x6f, 0x6c, 0x4e, 0x61, 0x6d, 0x65, 0x73,
-	0x42, 0x79, 0x49, 0x64, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x0c, 0x43, 0x6f, 0x6c, 0x4e, 0x61,
-	0x6d, 0x65, 0x73, 0x42, 0x79, 0x49, 0x64, 0x12, 0x57, 0x0a, 0x0c, 0x43, 0x6f, 0x6c, 0x49, 0x64,
-	0x73, 0x42, 0x79, 0x4e, 0x61, 0x6d, 0x65, 0x18, 0x09, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x33, 0x2e,
-	0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x56, 0x65,
-	0x72, 0x69, 0x66, 0x69, 0x61, 0x62, 0x6c, 0x65, 0x53, 0x51, 0x4c, 0x45, 0x6e, 0x74, 0x72, 0x79,
-	0x2e, 0x43, 0x6f, 0x6c, 0x49, 0x64, 0x73, 0x42, 0x79, 0x4e, 0x61, 0x6d, 0x65, 0x45, 0x6e, 0x74,
-	0x72, 0x79, 0x52, 0x0c, 0x43, 0x6f, 0x6c, 0x49, 0x64, 0x73, 0x42, 0x79, 0x4e, 0x61, 0x6d, 0x65,
-	0x12, 0x57, 0x0a, 0x0c, 0x43, 0x6f, 0x6c, 0x54, 0x79, 0x70, 0x65, 0x73, 0x42, 0x79, 0x49, 0x64,
-	0x18, 0x0a, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x33, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e,
-	0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x56, 0x65, 0x72, 0x69, 0x66, 0x69, 0x61, 0x62, 0x6c,
-	0x65, 0x53, 0x51, 0x4c, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x2e, 0x43, 0x6f, 0x6c, 0x54, 0x79, 0x70,
-	0x65, 0x73, 0x42, 0x79, 0x49, 0x64, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x0c, 0x43, 0x6f, 0x6c,
-	0x54, 0x79, 0x70, 0x65, 0x73, 0x42, 0x79, 0x49, 0x64, 0x12, 0x51, 0x0a, 0x0a, 0x43, 0x6f, 0x6c,
-	0x4c, 0x65, 0x6e, 0x42, 0x79, 0x49, 0x64, 0x18, 0x0b, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x31, 0x2e,
-	0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x56, 0x65,
-	0x72, 0x69, 0x66, 0x69, 0x61, 0x62, 0x6c, 0x65, 0x53, 0x51, 0x4c, 0x45, 0x6e, 0x74, 0x72, 0x79,
-	0x2e, 0x43, 0x6f, 0x6c, 0x4c, 0x65, 0x6e, 0x42, 0x79, 0x49, 0x64, 0x45, 0x6e, 0x74, 0x72, 0x79,
-	0x52, 0x0a, 0x43, 0x6f, 0x6c, 0x4c, 0x65, 0x6e, 0x42, 0x79, 0x49, 0x64, 0x1a, 0x3f, 0x0a, 0x11,
-	0x43, 0x6f, 0x6c, 0x4e, 0x61, 0x6d, 0x65, 0x73, 0x42, 0x79, 0x49, 0x64, 0x45, 0x6e, 0x74, 0x72,
-	0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x03,
-	0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01,
-	0x28, 0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x1a, 0x3f, 0x0a,
-	0x11, 0x43, 0x6f, 0x6c, 0x49, 0x64, 0x73, 0x42, 0x79, 0x4e, 0x61, 0x6d, 0x65, 0x45, 0x6e, 0x74,
-	0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
-	0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20,
-	0x01, 0x28, 0x0d, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x1a, 0x3f,
-	0x0a, 0x11, 0x43, 0x6f, 0x6c, 0x54, 0x79, 0x70, 0x65, 0x73, 0x42, 0x79, 0x49, 0x64, 0x45, 0x6e,
-	0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d,
-	0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02,
-	0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x1a,
-	0x3d, 0x0a, 0x0f, 0x43, 0x6f, 0x6c, 0x4c, 0x65, 0x6e, 0x42, 0x79, 0x49, 0x64, 0x45, 0x6e, 0x74,
-	0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52,
-	0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20,
-	0x01, 0x28, 0x05, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x4a, 0x04,
-	0x08, 0x06, 0x10, 0x07, 0x22, 0x28, 0x0a, 0x10, 0x55, 0x73, 0x65, 0x44, 0x61, 0x74, 0x61, 0x62,
-	0x61, 0x73, 0x65, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x74, 0x6f, 0x6b, 0x65,
-	0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x22, 0xaa,
-	0x01, 0x0a, 0x17, 0x43, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x50, 0x65, 0x72, 0x6d, 0x69, 0x73, 0x73,
-	0x69, 0x6f, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x37, 0x0a, 0x06, 0x61, 0x63,
-	0x74, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x1f, 0x2e, 0x69, 0x6d, 0x6d,
-	0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x50, 0x65, 0x72, 0x6d, 0x69,
-	0x73, 0x73, 0x69, 0x6f, 0x6e, 0x41, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x06, 0x61, 0x63, 0x74,
-	0x69, 0x6f, 0x6e, 0x12, 0x1a, 0x0a, 0x08, 0x75, 0x73, 0x65, 0x72, 0x6e, 0x61, 0x6d, 0x65, 0x18,
-	0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x75, 0x73, 0x65, 0x72, 0x6e, 0x61, 0x6d, 0x65, 0x12,
-	0x1a, 0x0a, 0x08, 0x64, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28,
-	0x09, 0x52, 0x08, 0x64, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x12, 0x1e, 0x0a, 0x0a, 0x70,
-	0x65, 0x72, 0x6d, 0x69, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0d, 0x52,
-	0x0a, 0x70, 0x65, 0x72, 0x6d, 0x69, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x22, 0x4a, 0x0a, 0x14, 0x53,
-	0x65, 0x74, 0x41, 0x63, 0x74, 0x69, 0x76, 0x65, 0x55, 0x73, 0x65, 0x72, 0x52, 0x65, 0x71, 0x75,
-	0x65, 0x73, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x61, 0x63, 0x74, 0x69, 0x76, 0x65, 0x18, 0x01, 0x20,
-	0x01, 0x28, 0x08, 0x52, 0x06, 0x61, 0x63, 0x74, 0x69, 0x76, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x75,
-	0x73, 0x65, 0x72, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x75,
-	0x73, 0x65, 0x72, 0x6e, 0x61, 0x6d, 0x65, 0x22, 0x4d, 0x0a, 0x14, 0x44, 0x61, 0x74, 0x61, 0x62,
-	0x61, 0x73, 0x65, 0x4c, 0x69, 0x73, 0x74, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12,
-	0x35, 0x0a, 0x09, 0x64, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03,
-	0x28, 0x0b, 0x32, 0x17, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65,
-	0x6d, 0x61, 0x2e, 0x44, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x52, 0x09, 0x64, 0x61, 0x74,
-	0x61, 0x62, 0x61, 0x73, 0x65, 0x73, 0x22, 0x21, 0x0a, 0x05, 0x43, 0x68, 0x75, 0x6e, 0x6b, 0x12,
-	0x18, 0x0a, 0x07, 0x63, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c,
-	0x52, 0x07, 0x63, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x22, 0x4e, 0x0a, 0x12, 0x55, 0x73, 0x65,
-	0x53, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12,
-	0x18, 0x0a, 0x07, 0x73, 0x69, 0x6e, 0x63, 0x65, 0x54, 0x78, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04,
-	0x52, 0x07, 0x73, 0x69, 0x6e, 0x63, 0x65, 0x54, 0x78, 0x12, 0x1e, 0x0a, 0x0a, 0x61, 0x73, 0x42,
-	0x65, 0x66, 0x6f, 0x72, 0x65, 0x54, 0x78, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0a, 0x61,
-	0x73, 0x42, 0x65, 0x66, 0x6f, 0x72, 0x65, 0x54, 0x78, 0x22, 0x6d, 0x0a, 0x0e, 0x53, 0x51, 0x4c,
-	0x45, 0x78, 0x65, 0x63, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x10, 0x0a, 0x03, 0x73,
-	0x71, 0x6c, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x73, 0x71, 0x6c, 0x12, 0x31, 0x0a,
-	0x06, 0x70, 0x61, 0x72, 0x61, 0x6d, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x19, 0x2e,
-	0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x4e, 0x61,
-	0x6d, 0x65, 0x64, 0x50, 0x61, 0x72, 0x61, 0x6d, 0x52, 0x06, 0x70, 0x61, 0x72, 0x61, 0x6d, 0x73,
-	0x12, 0x16, 0x0a, 0x06, 0x6e, 0x6f, 0x57, 0x61, 0x69, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08,
-	0x52, 0x06, 0x6e, 0x6f, 0x57, 0x61, 0x69, 0x74, 0x22, 0x7c, 0x0a, 0x0f, 0x53, 0x51, 0x4c, 0x51,
-	0x75, 0x65, 0x72, 0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x10, 0x0a, 0x03, 0x73,
-	0x71, 0x6c, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x73, 0x71, 0x6c, 0x12, 0x31, 0x0a,
-	0x06, 0x70, 0x61, 0x72, 0x61, 0x6d, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x19, 0x2e,
-	0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x4e, 0x61,
-	0x6d, 0x65, 0x64, 0x50, 0x61, 0x72, 0x61, 0x6d, 0x52, 0x06, 0x70, 0x61, 0x72, 0x61, 0x6d, 0x73,
-	0x12, 0x24, 0x0a, 0x0d, 0x72, 0x65, 0x75, 0x73, 0x65, 0x53, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f,
-	0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0d, 0x72, 0x65, 0x75, 0x73, 0x65, 0x53, 0x6e,
-	0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x22, 0x4f, 0x0a, 0x0a, 0x4e, 0x61, 0x6d, 0x65, 0x64, 0x50,
-	0x61, 0x72, 0x61, 0x6d, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01,
-	0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x2d, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75,
-	0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62,
-	0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x53, 0x51, 0x4c, 0x56, 0x61, 0x6c, 0x75, 0x65,
-	0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x22, 0x5e, 0x0a, 0x0d, 0x53, 0x51, 0x4c, 0x45, 0x78,
-	0x65, 0x63, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x12, 0x2f, 0x0a, 0x03, 0x74, 0x78, 0x73, 0x18,
-	0x05, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1d, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73,
-	0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x43, 0x6f, 0x6d, 0x6d, 0x69, 0x74, 0x74, 0x65, 0x64, 0x53,
-	0x51, 0x4c, 0x54, 0x78, 0x52, 0x03, 0x74, 0x78, 0x73, 0x12, 0x1c, 0x0a, 0x09, 0x6f, 0x6e, 0x67,
-	0x6f, 0x69, 0x6e, 0x67, 0x54, 0x78, 0x18, 0x06, 0x20, 0x01, 0x28, 0x08, 0x52, 0x09, 0x6f, 0x6e,
-	0x67, 0x6f, 0x69, 0x6e, 0x67, 0x54, 0x78, 0x22, 0xdd, 0x03, 0x0a, 0x0e, 0x43, 0x6f, 0x6d, 0x6d,
-	0x69, 0x74, 0x74, 0x65, 0x64, 0x53, 0x51, 0x4c, 0x54, 0x78, 0x12, 0x2f, 0x0a, 0x06, 0x68, 0x65,
-	0x61, 0x64, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x69, 0x6d, 0x6d,
-	0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x54, 0x78, 0x48, 0x65, 0x61,
-	0x64, 0x65, 0x72, 0x52, 0x06, 0x68, 0x65, 0x61, 0x64, 0x65, 0x72, 0x12, 0x20, 0x0a, 0x0b, 0x75,
-	0x70, 0x64, 0x61, 0x74, 0x65, 0x64, 0x52, 0x6f, 0x77, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d,
-	0x52, 0x0b, 0x75, 0x70, 0x64, 0x61, 0x74, 0x65, 0x64, 0x52, 0x6f, 0x77, 0x73, 0x12, 0x5c, 0x0a,
-	0x0f, 0x6c, 0x61, 0x73, 0x74, 0x49, 0x6e, 0x73, 0x65, 0x72, 0x74, 0x65, 0x64, 0x50, 0x4b, 0x73,
-	0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x32, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e,
-	0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x43, 0x6f, 0x6d, 0x6d, 0x69, 0x74, 0x74, 0x65, 0x64,
-	0x53, 0x51, 0x4c, 0x54, 0x78, 0x2e, 0x4c, 0x61, 0x73, 0x74, 0x49, 0x6e, 0x73, 0x65, 0x72, 0x74,
-	0x65, 0x64, 0x50, 0x4b, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x0f, 0x6c, 0x61, 0x73, 0x74,
-	0x49, 0x6e, 0x73, 0x65, 0x72, 0x74, 0x65, 0x64, 0x50, 0x4b, 0x73, 0x12, 0x5f, 0x0a, 0x10, 0x66,
-	0x69, 0x72, 0x73, 0x74, 0x49, 0x6e, 0x73, 0x65, 0x72, 0x74, 0x65, 0x64, 0x50, 0x4b, 0x73, 0x18,
-	0x04, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x33, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73,
-	0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x43, 0x6f, 0x6d, 0x6d, 0x69, 0x74, 0x74, 0x65, 0x64, 0x53,
-	0x51, 0x4c, 0x54, 0x78, 0x2e, 0x46, 0x69, 0x72, 0x73, 0x74, 0x49, 0x6e, 0x73, 0x65, 0x72, 0x74,
-	0x65, 0x64, 0x50, 0x4b, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x10, 0x66, 0x69, 0x72, 0x73,
-	0x74, 0x49, 0x6e, 0x73, 0x65, 0x72, 0x74, 0x65, 0x64, 0x50, 0x4b, 0x73, 0x1a, 0x5b, 0x0a, 0x14,
-	0x4c, 0x61, 0x73, 0x74, 0x49, 0x6e, 0x73, 0x65, 0x72, 0x74, 0x65, 0x64, 0x50, 0x4b, 0x73, 0x45,
-	0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28,
-	0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x2d, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18,
-	0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73,
-	0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x53, 0x51, 0x4c, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x52, 0x05,
-	0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x1a, 0x5c, 0x0a, 0x15, 0x46, 0x69, 0x72,
-	0x73, 0x74, 0x49, 0x6e, 0x73, 0x65, 0x72, 0x74, 0x65, 0x64, 0x50, 0x4b, 0x73, 0x45, 0x6e, 0x74,
-	0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
-	0x03, 0x6b, 0x65, 0x79, 0x12, 0x2d, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20,
-	0x01, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68,
-	0x65, 0x6d, 0x61, 0x2e, 0x53, 0x51, 0x4c, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x52, 0x05, 0x76, 0x61,
-	0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x22, 0x69, 0x0a, 0x0e, 0x53, 0x51, 0x4c, 0x51, 0x75,
-	0x65, 0x72, 0x79, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x12, 0x2f, 0x0a, 0x07, 0x63, 0x6f, 0x6c,
-	0x75, 0x6d, 0x6e, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x69, 0x6d, 0x6d,
-	0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x43, 0x6f, 0x6c, 0x75, 0x6d,
-	0x6e, 0x52, 0x07, 0x63, 0x6f, 0x6c, 0x75, 0x6d, 0x6e, 0x73, 0x12, 0x26, 0x0a, 0x04, 0x72, 0x6f,
-	0x77, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64,
-	0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x52, 0x6f, 0x77, 0x52, 0x04, 0x72, 0x6f,
-	0x77, 0x73, 0x22, 0x30, 0x0a, 0x06, 0x43, 0x6f, 0x6c, 0x75, 0x6d, 0x6e, 0x12, 0x12, 0x0a, 0x04,
-	0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65,
-	0x12, 0x12, 0x0a, 0x04, 0x74, 0x79, 0x70, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04,
-	0x74, 0x79, 0x70, 0x65, 0x22, 0x50, 0x0a, 0x03, 0x52, 0x6f, 0x77, 0x12, 0x18, 0x0a, 0x07, 0x63,
-	0x6f, 0x6c, 0x75, 0x6d, 0x6e, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x07, 0x63, 0x6f,
-	0x6c, 0x75, 0x6d, 0x6e, 0x73, 0x12, 0x2f, 0x0a, 0x06, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x73, 0x18,
-	0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73,
-	0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x53, 0x51, 0x4c, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x52, 0x06,
-	0x76, 0x61, 0x6c, 0x75, 0x65, 0x73, 0x22, 0x99, 0x01, 0x0a, 0x08, 0x53, 0x51, 0x4c, 0x56, 0x61,
-	0x6c, 0x75, 0x65, 0x12, 0x30, 0x0a, 0x04, 0x6e, 0x75, 0x6c, 0x6c, 0x18, 0x01, 0x20, 0x01, 0x28,
-	0x0e, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
-	0x62, 0x75, 0x66, 0x2e, 0x4e, 0x75, 0x6c, 0x6c, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x48, 0x00, 0x52,
-	0x04, 0x6e, 0x75, 0x6c, 0x6c, 0x12, 0x0e, 0x0a, 0x01, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03,
-	0x48, 0x00, 0x52, 0x01, 0x6e, 0x12, 0x0e, 0x0a, 0x01, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09,
-	0x48, 0x00, 0x52, 0x01, 0x73, 0x12, 0x0e, 0x0a, 0x01, 0x62, 0x18, 0x04, 0x20, 0x01, 0x28, 0x08,
-	0x48, 0x00, 0x52, 0x01, 0x62, 0x12, 0x10, 0x0a, 0x02, 0x62, 0x73, 0x18, 0x05, 0x20, 0x01, 0x28,
-	0x0c, 0x48, 0x00, 0x52, 0x02, 0x62, 0x73, 0x12, 0x10, 0x0a, 0x02, 0x74, 0x73, 0x18, 0x06, 0x20,
-	0x01, 0x28, 0x03, 0x48, 0x00, 0x52, 0x02, 0x74, 0x73, 0x42, 0x07, 0x0a, 0x05, 0x76, 0x61, 0x6c,
-	0x75, 0x65, 0x22, 0x39, 0x0a, 0x0c, 0x4e, 0x65, 0x77, 0x54, 0x78, 0x52, 0x65, 0x71, 0x75, 0x65,
-	0x73, 0x74, 0x12, 0x29, 0x0a, 0x04, 0x6d, 0x6f, 0x64, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e,
-	0x32, 0x15, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61,
-	0x2e, 0x54, 0x78, 0x4d, 0x6f, 0x64, 0x65, 0x52, 0x04, 0x6d, 0x6f, 0x64, 0x65, 0x22, 0x35, 0x0a,
-	0x0d, 0x4e, 0x65, 0x77, 0x54, 0x78, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x24,
-	0x0a, 0x0d, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x49, 0x44, 0x18,
-	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0d, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69,
-	0x6f, 0x6e, 0x49, 0x44, 0x22, 0x35, 0x0a, 0x09, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x49, 0x6e, 0x66,
-	0x6f, 0x12, 0x12, 0x0a, 0x04, 0x63, 0x6f, 0x64, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
-	0x04, 0x63, 0x6f, 0x64, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x63, 0x61, 0x75, 0x73, 0x65, 0x18, 0x02,
-	0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x63, 0x61, 0x75, 0x73, 0x65, 0x22, 0x21, 0x0a, 0x09, 0x44,
-	0x65, 0x62, 0x75, 0x67, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x14, 0x0a, 0x05, 0x73, 0x74, 0x61, 0x63,
-	0x6b, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x73, 0x74, 0x61, 0x63, 0x6b, 0x22, 0x2c,
-	0x0a, 0x09, 0x52, 0x65, 0x74, 0x72, 0x79, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x1f, 0x0a, 0x0b, 0x72,
-	0x65, 0x74, 0x72, 0x79, 0x5f, 0x64, 0x65, 0x6c, 0x61, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05,
-	0x52, 0x0a, 0x72, 0x65, 0x74, 0x72, 0x79, 0x44, 0x65, 0x6c, 0x61, 0x79, 0x2a, 0x4b, 0x0a, 0x0f,
-	0x45, 0x6e, 0x74, 0x72, 0x79, 0x54, 0x79, 0x70, 0x65, 0x41, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12,
-	0x0b, 0x0a, 0x07, 0x45, 0x58, 0x43, 0x4c, 0x55, 0x44, 0x45, 0x10, 0x00, 0x12, 0x0f, 0x0a, 0x0b,
-	0x4f, 0x4e, 0x4c, 0x59, 0x5f, 0x44, 0x49, 0x47, 0x45, 0x53, 0x54, 0x10, 0x01, 0x12, 0x0d, 0x0a,
-	0x09, 0x52, 0x41, 0x57, 0x5f, 0x56, 0x41, 0x4c, 0x55, 0x45, 0x10, 0x02, 0x12, 0x0b, 0x0a, 0x07,
-	0x52, 0x45, 0x53, 0x4f, 0x4c, 0x56, 0x45, 0x10, 0x03, 0x2a, 0x29, 0x0a, 0x10, 0x50, 0x65, 0x72,
-	0x6d, 0x69, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x41, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x09, 0x0a,
-	0x05, 0x47, 0x52, 0x41, 0x4e, 0x54, 0x10, 0x00, 0x12, 0x0a, 0x0a, 0x06, 0x52, 0x45, 0x56, 0x4f,
-	0x4b, 0x45, 0x10, 0x01, 0x2a, 0x34, 0x0a, 0x06, 0x54, 0x78, 0x4d, 0x6f, 0x64, 0x65, 0x12, 0x0c,
-	0x0a, 0x08, 0x52, 0x65, 0x61, 0x64, 0x4f, 0x6e, 0x6c, 0x79, 0x10, 0x00, 0x12, 0x0d, 0x0a, 0x09,
-	0x57, 0x72, 0x69, 0x74, 0x65, 0x4f, 0x6e, 0x6c, 0x79, 0x10, 0x01, 0x12, 0x0d, 0x0a, 0x09, 0x52,
-	0x65, 0x61, 0x64, 0x57, 0x72, 0x69, 0x74, 0x65, 0x10, 0x02, 0x32, 0xff, 0x2d, 0x0a, 0x0b, 0x49,
-	0x6d, 0x6d, 0x75, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x50, 0x0a, 0x09, 0x4c, 0x69,
-	0x73, 0x74, 0x55, 0x73, 0x65, 0x72, 0x73, 0x12, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65,
-	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x1a,
-	0x17, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e,
-	0x55, 0x73, 0x65, 0x72, 0x4c, 0x69, 0x73, 0x74, 0x22, 0x12, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x0c,
-	0x12, 0x0a, 0x2f, 0x75, 0x73, 0x65, 0x72, 0x2f, 0x6c, 0x69, 0x73, 0x74, 0x12, 0x58, 0x0a, 0x0a,
-	0x43, 0x72, 0x65, 0x61, 0x74, 0x65, 0x55, 0x73, 0x65, 0x72, 0x12, 0x20, 0x2e, 0x69, 0x6d, 0x6d,
-	0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x43, 0x72, 0x65, 0x61, 0x74,
-	0x65, 0x55, 0x73, 0x65, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x16, 0x2e, 0x67,
-	0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45,
-	0x6d, 0x70, 0x74, 0x79, 0x22, 0x10, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x0a, 0x22, 0x05, 0x2f, 0x75,
-	0x73, 0x65, 0x72, 0x3a, 0x01, 0x2a, 0x12, 0x70, 0x0a, 0x0e, 0x43, 0x68, 0x61, 0x6e, 0x67, 0x65,
-	0x50, 0x61, 0x73, 0x73, 0x77, 0x6f, 0x72, 0x64, 0x12, 0x24, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64,
-	0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x43, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x50,
-	0x61, 0x73, 0x73, 0x77, 0x6f, 0x72, 0x64, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x16,
-	0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66,
-	0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x20, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x1a, 0x22, 0x15,
-	0x2f, 0x75, 0x73, 0x65, 0x72, 0x2f, 0x70, 0x61, 0x73, 0x73, 0x77, 0x6f, 0x72, 0x64, 0x2f, 0x63,
-	0x68, 0x61, 0x6e, 0x67, 0x65, 0x3a, 0x01, 0x2a, 0x12, 0x4a, 0x0a, 0x10, 0x55, 0x70, 0x64, 0x61,
-	0x74, 0x65, 0x41, 0x75, 0x74, 0x68, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x12, 0x19, 0x2e, 0x69,
-	0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x41, 0x75, 0x74,
-	0x68, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65,
-	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22,
-	0x03, 0x88, 0x02, 0x01, 0x12, 0x4a, 0x0a, 0x10, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x4d, 0x54,
-	0x4c, 0x53, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x12, 0x19, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64,
-	0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x4d, 0x54, 0x4c, 0x53, 0x43, 0x6f, 0x6e,
-	0x66, 0x69, 0x67, 0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f,
-	0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x03, 0x88, 0x02, 0x01,
-	0x12, 0x56, 0x0a, 0x0b, 0x4f, 0x70, 0x65, 0x6e, 0x53, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x12,
-	0x21, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e,
-	0x4f, 0x70, 0x65, 0x6e, 0x53, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65,
-	0x73, 0x74, 0x1a, 0x22, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65,
-	0x6d, 0x61, 0x2e, 0x4f, 0x70, 0x65, 0x6e, 0x53, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x52, 0x65,
-	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x12, 0x40, 0x0a, 0x0c, 0x43, 0x6c, 0x6f, 0x73,
-	0x65, 0x53, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c,
-	0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79,
-	0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62,
-	0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x00, 0x12, 0x3d, 0x0a, 0x09, 0x4b, 0x65,
-	0x65, 0x70, 0x41, 0x6c, 0x69, 0x76, 0x65, 0x12, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65,
-	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x1a,
-	0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75,
-	0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x00, 0x12, 0x44, 0x0a, 0x05, 0x4e, 0x65, 0x77,
-	0x54, 0x78, 0x12, 0x1b, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65,
-	0x6d, 0x61, 0x2e, 0x4e, 0x65, 0x77, 0x54, 0x78, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a,
-	0x1c, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e,
-	0x4e, 0x65, 0x77, 0x54, 0x78, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x12,
-	0x41, 0x0a, 0x06, 0x43, 0x6f, 0x6d, 0x6d, 0x69, 0x74, 0x12, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67,
-	0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74,
-	0x79, 0x1a, 0x1d, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d,
-	0x61, 0x2e, 0x43, 0x6f, 0x6d, 0x6d, 0x69, 0x74, 0x74, 0x65, 0x64, 0x53, 0x51, 0x4c, 0x54, 0x78,
-	0x22, 0x00, 0x12, 0x3c, 0x0a, 0x08, 0x52, 0x6f, 0x6c, 0x6c, 0x62, 0x61, 0x63, 0x6b, 0x12, 0x16,
-	0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66,
-	0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e,
-	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x00,
-	0x12, 0x44, 0x0a, 0x09, 0x54, 0x78, 0x53, 0x51, 0x4c, 0x45, 0x78, 0x65, 0x63, 0x12, 0x1d, 0x2e,
-	0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x53, 0x51,
-	0x4c, 0x45, 0x78, 0x65, 0x63, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x16, 0x2e, 0x67,
-	0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45,
-	0x6d, 0x70, 0x74, 0x79, 0x22, 0x00, 0x12, 0x4d, 0x0a, 0x0a, 0x54, 0x78, 0x53, 0x51, 0x4c, 0x51,
-	0x75, 0x65, 0x72, 0x79, 0x12, 0x1e, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63,
-	0x68, 0x65, 0x6d, 0x61, 0x2e, 0x53, 0x51, 0x4c, 0x51, 0x75, 0x65, 0x72, 0x79, 0x52, 0x65, 0x71,
-	0x75, 0x65, 0x73, 0x74, 0x1a, 0x1d, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63,
-	0x68, 0x65, 0x6d, 0x61, 0x2e, 0x53, 0x51, 0x4c, 0x51, 0x75, 0x65, 0x72, 0x79, 0x52, 0x65, 0x73,
-	0x75, 0x6c, 0x74, 0x22, 0x00, 0x12, 0x5d, 0x0a, 0x05, 0x4c, 0x6f, 0x67, 0x69, 0x6e, 0x12, 0x1b,
-	0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x4c,
-	0x6f, 0x67, 0x69, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1c, 0x2e, 0x69, 0x6d,
-	0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x4c, 0x6f, 0x67, 0x69,
-	0x6e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x19, 0x88, 0x02, 0x01, 0x82, 0xd3,
-	0xe4, 0x93, 0x02, 0x0b, 0x22, 0x06, 0x2f, 0x6c, 0x6f, 0x67, 0x69, 0x6e, 0x3a, 0x01, 0x2a, 0x92,
-	0x41, 0x02, 0x62, 0x00, 0x12, 0x4f, 0x0a, 0x06, 0x4c, 0x6f, 0x67, 0x6f, 0x75, 0x74, 0x12, 0x16,
-	0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66,
-	0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e,
-	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x15,
-	0x88, 0x02, 0x01, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x0c, 0x22, 0x07, 0x2f, 0x6c, 0x6f, 0x67, 0x6f,
-	0x75, 0x74, 0x3a, 0x01, 0x2a, 0x12, 0x4d, 0x0a, 0x03, 0x53, 0x65, 0x74, 0x12, 0x19, 0x2e, 0x69,
-	0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x53, 0x65, 0x74,
-	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x17, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62,
-	0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x54, 0x78, 0x48, 0x65, 0x61, 0x64, 0x65, 0x72,
-	0x22, 0x12, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x0c, 0x22, 0x07, 0x2f, 0x64, 0x62, 0x2f, 0x73, 0x65,
-	0x74, 0x3a, 0x01, 0x2a, 0x12, 0x70, 0x0a, 0x0d, 0x56, 0x65, 0x72, 0x69, 0x66, 0x69, 0x61, 0x62,
-	0x6c, 0x65, 0x53, 0x65, 0x74, 0x12, 0x23, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73,
-	0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x56, 0x65, 0x72, 0x69, 0x66, 0x69, 0x61, 0x62, 0x6c, 0x65,
-	0x53, 0x65, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1b, 0x2e, 0x69, 0x6d, 0x6d,
-	0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x56, 0x65, 0x72, 0x69, 0x66,
-	0x69, 0x61, 0x62, 0x6c, 0x65, 0x54, 0x78, 0x22, 0x1d, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x17, 0x22,
-	0x12, 0x2f, 0x64, 0x62, 0x2f, 0x76, 0x65, 0x72, 0x69, 0x66, 0x69, 0x61, 0x62, 0x6c, 0x65, 0x2f,
-	0x73, 0x65, 0x74, 0x3a, 0x01, 0x2a, 0x12, 0x4d, 0x0a, 0x03, 0x47, 0x65, 0x74, 0x12, 0x19, 0x2e,
-	0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x4b, 0x65,
-	0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x14, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64,
-	0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x22, 0x15,
-	0x82, 0xd3, 0xe4, 0x93, 0x02, 0x0f, 0x12, 0x0d, 0x2f, 0x64, 0x62, 0x2f, 0x67, 0x65, 0x74, 0x2f,
-	0x7b, 0x6b, 0x65, 0x79, 0x7d, 0x12, 0x73, 0x0a, 0x0d, 0x56, 0x65, 0x72, 0x69, 0x66, 0x69, 0x61,
-	0x62, 0x6c, 0x65, 0x47, 0x65, 0x74, 0x12, 0x23, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e,
-	0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x56, 0x65, 0x72, 0x69, 0x66, 0x69, 0x61, 0x62, 0x6c,
-	0x65, 0x47, 0x65, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1e, 0x2e, 0x69, 0x6d,
-	0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x56, 0x65, 0x72, 0x69,
-	0x66, 0x69, 0x61, 0x62, 0x6c, 0x65, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x22, 0x1d, 0x82, 0xd3, 0xe4,
-	0x93, 0x02, 0x17, 0x22, 0x12, 0x2f, 0x64, 0x62, 0x2f, 0x76, 0x65, 0x72, 0x69, 0x66, 0x69, 0x61,
-	0x62, 0x6c, 0x65, 0x2f, 0x67, 0x65, 0x74, 0x3a, 0x01, 0x2a, 0x12, 0x5a, 0x0a, 0x06, 0x44, 0x65,
-	0x6c, 0x65, 0x74, 0x65, 0x12, 0x20, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63,
-	0x68, 0x65, 0x6d, 0x61, 0x2e, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x4b, 0x65, 0x79, 0x73, 0x52,
-	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x17, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e,
-	0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x54, 0x78, 0x48, 0x65, 0x61, 0x64, 0x65, 0x72, 0x22,
-	0x15, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x0f, 0x22, 0x0a, 0x2f, 0x64, 0x62, 0x2f, 0x64, 0x65, 0x6c,
-	0x65, 0x74, 0x65, 0x3a, 0x01, 0x2a, 0x12, 0x56, 0x0a, 0x06, 0x47, 0x65, 0x74, 0x41, 0x6c, 0x6c,
-	0x12, 0x1d, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61,
-	0x2e, 0x4b, 0x65, 0x79, 0x4c, 0x69, 0x73, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a,
-	0x16, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e,
-	0x45, 0x6e, 0x74, 0x72, 0x69, 0x65, 0x73, 0x22, 0x15, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x0f, 0x22,
-	0x0a, 0x2f, 0x64, 0x62, 0x2f, 0x67, 0x65, 0x74, 0x61, 0x6c, 0x6c, 0x3a, 0x01, 0x2a, 0x12, 0x59,
-	0x0a, 0x07, 0x45, 0x78, 0x65, 0x63, 0x41, 0x6c, 0x6c, 0x12, 0x1d, 0x2e, 0x69, 0x6d, 0x6d, 0x75,
-	0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x45, 0x78, 0x65, 0x63, 0x41, 0x6c,
-	0x6c, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x17, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64,
-	0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x54, 0x78, 0x48, 0x65, 0x61, 0x64, 0x65,
-	0x72, 0x22, 0x16, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x10, 0x22, 0x0b, 0x2f, 0x64, 0x62, 0x2f, 0x65,
-	0x78, 0x65, 0x63, 0x61, 0x6c, 0x6c, 0x3a, 0x01, 0x2a, 0x12, 0x4f, 0x0a, 0x04, 0x53, 0x63, 0x61,
-	0x6e, 0x12, 0x1a, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d,
-	0x61, 0x2e, 0x53, 0x63, 0x61, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x16, 0x2e,
-	0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x45, 0x6e,
-	0x74, 0x72, 0x69, 0x65, 0x73, 0x22, 0x13, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x0d, 0x22, 0x08, 0x2f,
-	0x64, 0x62, 0x2f, 0x73, 0x63, 0x61, 0x6e, 0x3a, 0x01, 0x2a, 0x12, 0x58, 0x0a, 0x05, 0x43, 0x6f,
-	0x75, 0x6e, 0x74, 0x12, 0x18, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68,
-	0x65, 0x6d, 0x61, 0x2e, 0x4b, 0x65, 0x79, 0x50, 0x72, 0x65, 0x66, 0x69, 0x78, 0x1a, 0x19, 0x2e,
-	0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x45, 0x6e,
-	0x74, 0x72, 0x79, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x22, 0x1a, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x14,
-	0x12, 0x12, 0x2f, 0x64, 0x62, 0x2f, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x2f, 0x7b, 0x70, 0x72, 0x65,
-	0x66, 0x69, 0x78, 0x7d, 0x12, 0x53, 0x0a, 0x08, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x41, 0x6c, 0x6c,
-	0x12, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62,
-	0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x1a, 0x19, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64,
-	0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x43, 0x6f,
-	0x75, 0x6e, 0x74, 0x22, 0x14, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x0e, 0x12, 0x0c, 0x2f, 0x64, 0x62,
-	0x2f, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x61, 0x6c, 0x6c, 0x12, 0x4a, 0x0a, 0x06, 0x54, 0x78, 0x42,
-	0x79, 0x49, 0x64, 0x12, 0x18, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68,
-	0x65, 0x6d, 0x61, 0x2e, 0x54, 0x78, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x11, 0x2e,
-	0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x54, 0x78,
-	0x22, 0x13, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x0d, 0x12, 0x0b, 0x2f, 0x64, 0x62, 0x2f, 0x74, 0x78,
-	0x2f, 0x7b, 0x74, 0x78, 0x7d, 0x12, 0x73, 0x0a, 0x10, 0x56, 0x65, 0x72, 0x69, 0x66, 0x69, 0x61,
-	0x62, 0x6c, 0x65, 0x54, 0x78, 0x42, 0x79, 0x49, 0x64, 0x12, 0x22, 0x2e, 0x69, 0x6d, 0x6d, 0x75,
-	0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x56, 0x65, 0x72, 0x69, 0x66, 0x69,
-	0x61, 0x62, 0x6c, 0x65, 0x54, 0x78, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1b, 0x2e,
-	0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x56, 0x65,
-	0x72, 0x69, 0x66, 0x69, 0x61, 0x62, 0x6c, 0x65, 0x54, 0x78, 0x22, 0x1e, 0x82, 0xd3, 0xe4, 0x93,
-	0x02, 0x18, 0x12, 0x16, 0x2f, 0x64, 0x62, 0x2f, 0x76, 0x65, 0x72, 0x69, 0x66, 0x69, 0x61, 0x62,
-	0x6c, 0x65, 0x2f, 0x74, 0x78, 0x2f, 0x7b, 0x74, 0x78, 0x7d, 0x12, 0x50, 0x0a, 0x06, 0x54, 0x78,
-	0x53, 0x63, 0x61, 0x6e, 0x12, 0x1c, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63,
-	0x68, 0x65, 0x6d, 0x61, 0x2e, 0x54, 0x78, 0x53, 0x63, 0x61, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65,
-	0x73, 0x74, 0x1a, 0x15, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65,
-	0x6d, 0x61, 0x2e, 0x54, 0x78, 0x4c, 0x69, 0x73, 0x74, 0x22, 0x11, 0x82, 0xd3, 0xe4, 0x93, 0x02,
-	0x0b, 0x22, 0x06, 0x2f, 0x64, 0x62, 0x2f, 0x74, 0x78, 0x3a, 0x01, 0x2a, 0x12, 0x58, 0x0a, 0x07,
-	0x48, 0x69, 0x73, 0x74, 0x6f, 0x72, 0x79, 0x12, 0x1d, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62,
-	0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x48, 0x69, 0x73, 0x74, 0x6f, 0x72, 0x79, 0x52,
-	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x16, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e,
-	0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x45, 0x6e, 0x74, 0x72, 0x69, 0x65, 0x73, 0x22, 0x16,
-	0x82, 0xd3, 0xe4, 0x93, 0x02, 0x10, 0x22, 0x0b, 0x2f, 0x64, 0x62, 0x2f, 0x68, 0x69, 0x73, 0x74,
-	0x6f, 0x72, 0x79, 0x3a, 0x01, 0x2a, 0x12, 0x55, 0x0a, 0x06, 0x48, 0x65, 0x61, 0x6c, 0x74, 0x68,
-	0x12, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62,
-	0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x1a, 0x1d, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64,
-	0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x48, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x52,
-	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x14, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x09, 0x12,
-	0x07, 0x2f, 0x68, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x92, 0x41, 0x02, 0x62, 0x00, 0x12, 0x68, 0x0a,
-	0x0e, 0x44, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x48, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x12,
-	0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75,
-	0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x1a, 0x25, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62,
-	0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x44, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65,
-	0x48, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x17,
-	0x82, 0xd3, 0xe4, 0x93, 0x02, 0x0c, 0x12, 0x0a, 0x2f, 0x64, 0x62, 0x2f, 0x68, 0x65, 0x61, 0x6c,
-	0x74, 0x68, 0x92, 0x41, 0x02, 0x62, 0x00, 0x12, 0x5d, 0x0a, 0x0c, 0x43, 0x75, 0x72, 0x72, 0x65,
-	0x6e, 0x74, 0x53, 0x74, 0x61, 0x74, 0x65, 0x12, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65,
-	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x1a,
-	0x1d, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e,
-	0x49, 0x6d, 0x6d, 0x75, 0x74, 0x61, 0x62, 0x6c, 0x65, 0x53, 0x74, 0x61, 0x74, 0x65, 0x22, 0x16,
-	0x82, 0xd3, 0xe4, 0x93, 0x02, 0x0b, 0x12, 0x09, 0x2f, 0x64, 0x62, 0x2f, 0x73, 0x74, 0x61, 0x74,
-	0x65, 0x92, 0x41, 0x02, 0x62, 0x00, 0x12, 0x65, 0x0a, 0x0c, 0x53, 0x65, 0x74, 0x52, 0x65, 0x66,
-	0x65, 0x72, 0x65, 0x6e, 0x63, 0x65, 0x12, 0x1f, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e,
-	0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x52, 0x65, 0x66, 0x65, 0x72, 0x65, 0x6e, 0x63, 0x65,
-	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x17, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62,
-	0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x54, 0x78, 0x48, 0x65, 0x61, 0x64, 0x65, 0x72,
-	0x22, 0x1b, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x15, 0x22, 0x10, 0x2f, 0x64, 0x62, 0x2f, 0x73, 0x65,
-	0x74, 0x72, 0x65, 0x66, 0x65, 0x72, 0x65, 0x6e, 0x63, 0x65, 0x3a, 0x01, 0x2a, 0x12, 0x88, 0x01,
-	0x0a, 0x16, 0x56, 0x65, 0x72, 0x69, 0x66, 0x69, 0x61, 0x62, 0x6c, 0x65, 0x53, 0x65, 0x74, 0x52,
-	0x65, 0x66, 0x65, 0x72, 0x65, 0x6e, 0x63, 0x65, 0x12, 0x29, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64,
-	0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x56, 0x65, 0x72, 0x69, 0x66, 0x69, 0x61,
-	0x62, 0x6c, 0x65, 0x52, 0x65, 0x66, 0x65, 0x72, 0x65, 0x6e, 0x63, 0x65, 0x52, 0x65, 0x71, 0x75,
-	0x65, 0x73, 0x74, 0x1a, 0x1b, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68,
-	0x65, 0x6d, 0x61, 0x2e, 0x56, 0x65, 0x72, 0x69, 0x66, 0x69, 0x61, 0x62, 0x6c, 0x65, 0x54, 0x78,
-	0x22, 0x26, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x20, 0x22, 0x1b, 0x2f, 0x64, 0x62, 0x2f, 0x76, 0x65,
-	0x72, 0x69, 0x66, 0x69, 0x61, 0x62, 0x6c, 0x65, 0x2f, 0x73, 0x65, 0x74, 0x72, 0x65, 0x66, 0x65,
-	0x72, 0x65, 0x6e, 0x63, 0x65, 0x3a, 0x01, 0x2a, 0x12, 0x50, 0x0a, 0x04, 0x5a, 0x41, 0x64, 0x64,
-	0x12, 0x1a, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61,
-	0x2e, 0x5a, 0x41, 0x64, 0x64, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x17, 0x2e, 0x69,
-	0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x54, 0x78, 0x48,
-	0x65, 0x61, 0x64, 0x65, 0x72, 0x22, 0x13, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x0d, 0x22, 0x08, 0x2f,
-	0x64, 0x62, 0x2f, 0x7a, 0x61, 0x64, 0x64, 0x3a, 0x01, 0x2a, 0x12, 0x73, 0x0a, 0x0e, 0x56, 0x65,
-	0x72, 0x69, 0x66, 0x69, 0x61, 0x62, 0x6c, 0x65, 0x5a, 0x41, 0x64, 0x64, 0x12, 0x24, 0x2e, 0x69,
-	0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x56, 0x65, 0x72,
-	0x69, 0x66, 0x69, 0x61, 0x62, 0x6c, 0x65, 0x5a, 0x41, 0x64, 0x64, 0x52, 0x65, 0x71, 0x75, 0x65,
-	0x73, 0x74, 0x1a, 0x1b, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65,
-	0x6d, 0x61, 0x2e, 0x56, 0x65, 0x72, 0x69, 0x66, 0x69, 0x61, 0x62, 0x6c, 0x65, 0x54, 0x78, 0x22,
-	0x1e, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x18, 0x22, 0x13, 0x2f, 0x64, 0x62, 0x2f, 0x76, 0x65, 0x72,
-	0x69, 0x66, 0x69, 0x61, 0x62, 0x6c, 0x65, 0x2f, 0x7a, 0x61, 0x64, 0x64, 0x3a, 0x01, 0x2a, 0x12,
-	0x53, 0x0a, 0x05, 0x5a, 0x53, 0x63, 0x61, 0x6e, 0x12, 0x1b, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64,
-	0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x5a, 0x53, 0x63, 0x61, 0x6e, 0x52, 0x65,
-	0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x17, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73,
-	0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x5a, 0x45, 0x6e, 0x74, 0x72, 0x69, 0x65, 0x73, 0x22, 0x14,
-	0x82, 0xd3, 0xe4, 0x93, 0x02, 0x0e, 0x22, 0x09, 0x2f, 0x64, 0x62, 0x2f, 0x7a, 0x73, 0x63, 0x61,
-	0x6e, 0x3a, 0x01, 0x2a, 0x12, 0x58, 0x0a, 0x0e, 0x43, 0x72, 0x65, 0x61, 0x74, 0x65, 0x44, 0x61,
-	0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x12, 0x17, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e,
-	0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x44, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x1a,
-	0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75,
-	0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x15, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x0f, 0x22,
-	0x0a, 0x2f, 0x64, 0x62, 0x2f, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x3a, 0x01, 0x2a, 0x12, 0x68,
-	0x0a, 0x12, 0x43, 0x72, 0x65, 0x61, 0x74, 0x65, 0x44, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65,
-	0x57, 0x69, 0x74, 0x68, 0x12, 0x1f, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63,
-	0x68, 0x65, 0x6d, 0x61, 0x2e, 0x44, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x53, 0x65, 0x74,
-	0x74, 0x69, 0x6e, 0x67, 0x73, 0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70,
-	0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x19, 0x82,
-	0xd3, 0xe4, 0x93, 0x02, 0x13, 0x22, 0x0e, 0x2f, 0x64, 0x62, 0x2f, 0x63, 0x72, 0x65, 0x61, 0x74,
-	0x65, 0x77, 0x69, 0x74, 0x68, 0x3a, 0x01, 0x2a, 0x12, 0x7a, 0x0a, 0x14, 0x43, 0x72, 0x65, 0x61,
-	0x74, 0x65, 0x44, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x57, 0x69, 0x74, 0x68, 0x56, 0x32,
-	0x12, 0x21, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61,
-	0x2e, 0x44, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x53, 0x65, 0x74, 0x74, 0x69, 0x6e, 0x67,
-	0x73, 0x56, 0x32, 0x1a, 0x21, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68,
-	0x65, 0x6d, 0x61, 0x2e, 0x44, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x53, 0x65, 0x74, 0x74,
-	0x69, 0x6e, 0x67, 0x73, 0x56, 0x32, 0x22, 0x1c, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x16, 0x22, 0x11,
-	0x2f, 0x64, 0x62, 0x2f, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x77, 0x69, 0x74, 0x68, 0x2f, 0x76,
-	0x32, 0x3a, 0x01, 0x2a, 0x12, 0x60, 0x0a, 0x0c, 0x44, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65,
-	0x4c, 0x69, 0x73, 0x74, 0x12, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72,
-	0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x1a, 0x23, 0x2e, 0x69,
-	0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x44, 0x61, 0x74,
-	0x61, 0x62, 0x61, 0x73, 0x65, 0x4c, 0x69, 0x73, 0x74, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
-	0x65, 0x22, 0x13, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x0d, 0x22, 0x08, 0x2f, 0x64, 0x62, 0x2f, 0x6c,
-	0x69, 0x73, 0x74, 0x3a, 0x01, 0x2a, 0x12, 0x67, 0x0a, 0x0b, 0x55, 0x73, 0x65, 0x44, 0x61, 0x74,
-	0x61, 0x62, 0x61, 0x73, 0x65, 0x12, 0x17, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73,
-	0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x44, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x1a, 0x1f,
-	0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x55,
-	0x73, 0x65, 0x44, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22,
-	0x1e, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x18, 0x12, 0x16, 0x2f, 0x64, 0x62, 0x2f, 0x75, 0x73, 0x65,
-	0x2f, 0x7b, 0x64, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x4e, 0x61, 0x6d, 0x65, 0x7d, 0x12,
-	0x60, 0x0a, 0x0e, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x44, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73,
-	0x65, 0x12, 0x1f, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d,
-	0x61, 0x2e, 0x44, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x53, 0x65, 0x74, 0x74, 0x69, 0x6e,
-	0x67, 0x73, 0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74,
-	0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x15, 0x82, 0xd3, 0xe4, 0x93,
-	0x02, 0x0f, 0x22, 0x0a, 0x2f, 0x64, 0x62, 0x2f, 0x75, 0x70, 0x64, 0x61, 0x74, 0x65, 0x3a, 0x01,
-	0x2a, 0x12, 0x7c, 0x0a, 0x10, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x44, 0x61, 0x74, 0x61, 0x62,
-	0x61, 0x73, 0x65, 0x56, 0x32, 0x12, 0x21, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73,
-	0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x44, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x53, 0x65,
-	0x74, 0x74, 0x69, 0x6e, 0x67, 0x73, 0x56, 0x32, 0x1a, 0x2b, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64,
-	0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x44, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73,
-	0x65, 0x53, 0x65, 0x74, 0x74, 0x69, 0x6e, 0x67, 0x73, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x52,
-	0x65, 0x73, 0x75, 0x6c, 0x74, 0x22, 0x18, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x12, 0x22, 0x0d, 0x2f,
-	0x64, 0x62, 0x2f, 0x75, 0x70, 0x64, 0x61, 0x74, 0x65, 0x2f, 0x76, 0x32, 0x3a, 0x01, 0x2a, 0x12,
-	0x67, 0x0a, 0x13, 0x47, 0x65, 0x74, 0x44, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x53, 0x65,
-	0x74, 0x74, 0x69, 0x6e, 0x67, 0x73, 0x12, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e,
-	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x1a, 0x1f,
-	0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x44,
-	0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x53, 0x65, 0x74, 0x74, 0x69, 0x6e, 0x67, 0x73, 0x22,
-	0x17, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x11, 0x22, 0x0c, 0x2f, 0x64, 0x62, 0x2f, 0x73, 0x65, 0x74,
-	0x74, 0x69, 0x6e, 0x67, 0x73, 0x3a, 0x01, 0x2a, 0x12, 0x6e, 0x0a, 0x15, 0x47, 0x65, 0x74, 0x44,
-	0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x53, 0x65, 0x74, 0x74, 0x69, 0x6e, 0x67, 0x73, 0x56,
-	0x32, 0x12, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
-	0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x1a, 0x21, 0x2e, 0x69, 0x6d, 0x6d, 0x75,
-	0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x44, 0x61, 0x74, 0x61, 0x62, 0x61,
-	0x73, 0x65, 0x53, 0x65, 0x74, 0x74, 0x69, 0x6e, 0x67, 0x73, 0x56, 0x32, 0x22, 0x1a, 0x82, 0xd3,
-	0xe4, 0x93, 0x02, 0x14, 0x22, 0x0f, 0x2f, 0x64, 0x62, 0x2f, 0x73, 0x65, 0x74, 0x74, 0x69, 0x6e,
-	0x67, 0x73, 0x2f, 0x76, 0x32, 0x3a, 0x01, 0x2a, 0x12, 0x5e, 0x0a, 0x0a, 0x46, 0x6c, 0x75, 0x73,
-	0x68, 0x49, 0x6e, 0x64, 0x65, 0x78, 0x12, 0x20, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e,
-	0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x46, 0x6c, 0x75, 0x73, 0x68, 0x49, 0x6e, 0x64, 0x65,
-	0x78, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c,
-	0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79,
-	0x22, 0x16, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x10, 0x12, 0x0e, 0x2f, 0x64, 0x62, 0x2f, 0x66, 0x6c,
-	0x75, 0x73, 0x68, 0x69, 0x6e, 0x64, 0x65, 0x78, 0x12, 0x58, 0x0a, 0x0c, 0x43, 0x6f, 0x6d, 0x70,
-	0x61, 0x63, 0x74, 0x49, 0x6e, 0x64, 0x65, 0x78, 0x12, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c,
-	0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79,
-	0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62,
-	0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x18, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x12,
-	0x12, 0x10, 0x2f, 0x64, 0x62, 0x2f, 0x63, 0x6f, 0x6d, 0x70, 0x61, 0x63, 0x74, 0x69, 0x6e, 0x64,
-	0x65, 0x78, 0x12, 0x75, 0x0a, 0x10, 0x43, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x50, 0x65, 0x72, 0x6d,
-	0x69, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x26, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e,
-	0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x43, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x50, 0x65, 0x72,
-	0x6d, 0x69, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x16,
-	0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66,
-	0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x21, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x1b, 0x22, 0x16,
-	0x2f, 0x75, 0x73, 0x65, 0x72, 0x2f, 0x63, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x70, 0x65, 0x72, 0x6d,
-	0x69, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x3a, 0x01, 0x2a, 0x12, 0x6c, 0x0a, 0x0d, 0x53, 0x65, 0x74,
-	0x41, 0x63, 0x74, 0x69, 0x76, 0x65, 0x55, 0x73, 0x65, 0x72, 0x12, 0x23, 0x2e, 0x69, 0x6d, 0x6d,
-	0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x53, 0x65, 0x74, 0x41, 0x63,
-	0x74, 0x69, 0x76, 0x65, 0x55, 0x73, 0x65, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a,
-	0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75,
-	0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x1e, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x18, 0x22,
-	0x13, 0x2f, 0x75, 0x73, 0x65, 0x72, 0x2f, 0x73, 0x65, 0x74, 0x61, 0x63, 0x74, 0x69, 0x76, 0x65,
-	0x55, 0x73, 0x65, 0x72, 0x3a, 0x01, 0x2a, 0x12, 0x40, 0x0a, 0x09, 0x73, 0x74, 0x72, 0x65, 0x61,
-	0x6d, 0x47, 0x65, 0x74, 0x12, 0x19, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63,
-	0x68, 0x65, 0x6d, 0x61, 0x2e, 0x4b, 0x65, 0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a,
-	0x14, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e,
-	0x43, 0x68, 0x75, 0x6e, 0x6b, 0x22, 0x00, 0x30, 0x01, 0x12, 0x3e, 0x0a, 0x09, 0x73, 0x74, 0x72,
-	0x65, 0x61, 0x6d, 0x53, 0x65, 0x74, 0x12, 0x14, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e,
-	0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x43, 0x68, 0x75, 0x6e, 0x6b, 0x1a, 0x17, 0x2e, 0x69,
-	0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x54, 0x78, 0x48,
-	0x65, 0x61, 0x64, 0x65, 0x72, 0x22, 0x00, 0x28, 0x01, 0x12, 0x54, 0x0a, 0x13, 0x73, 0x74, 0x72,
-	0x65, 0x61, 0x6d, 0x56, 0x65, 0x72, 0x69, 0x66, 0x69, 0x61, 0x62, 0x6c, 0x65, 0x47, 0x65, 0x74,
-	0x12, 0x23, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61,
-	0x2e, 0x56, 0x65, 0x72, 0x69, 0x66, 0x69, 0x61, 0x62, 0x6c, 0x65, 0x47, 0x65, 0x74, 0x52, 0x65,
-	0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x14, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73,
-	0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x43, 0x68, 0x75, 0x6e, 0x6b, 0x22, 0x00, 0x30, 0x01, 0x12,
-	0x4c, 0x0a, 0x13, 0x73, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x56, 0x65, 0x72, 0x69, 0x66, 0x69, 0x61,
-	0x62, 0x6c, 0x65, 0x53, 0x65, 0x74, 0x12, 0x14, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e,
-	0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x43, 0x68, 0x75, 0x6e, 0x6b, 0x1a, 0x1b, 0x2e, 0x69,
-	0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x56, 0x65, 0x72,
-	0x69, 0x66, 0x69, 0x61, 0x62, 0x6c, 0x65, 0x54, 0x78, 0x22, 0x00, 0x28, 0x01, 0x12, 0x42, 0x0a,
-	0x0a, 0x73, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x53, 0x63, 0x61, 0x6e, 0x12, 0x1a, 0x2e, 0x69, 0x6d,
-	0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x53, 0x63, 0x61, 0x6e,
-	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x14, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62,
-	0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x43, 0x68, 0x75, 0x6e, 0x6b, 0x22, 0x00, 0x30,
-	0x01, 0x12, 0x44, 0x0a, 0x0b, 0x73, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x5a, 0x53, 0x63, 0x61, 0x6e,
-	0x12, 0x1b, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61,
-	0x2e, 0x5a, 0x53, 0x63, 0x61, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x14, 0x2e,
-	0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x43, 0x68,
-	0x75, 0x6e, 0x6b, 0x22, 0x00, 0x30, 0x01, 0x12, 0x48, 0x0a, 0x0d, 0x73, 0x74, 0x72, 0x65, 0x61,
-	0x6d, 0x48, 0x69, 0x73, 0x74, 0x6f, 0x72, 0x79, 0x12, 0x1d, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64,
-	0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x48, 0x69, 0x73, 0x74, 0x6f, 0x72, 0x79,
-	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x14, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62,
-	0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x43, 0x68, 0x75, 0x6e, 0x6b, 0x22, 0x00, 0x30,
-	0x01, 0x12, 0x42, 0x0a, 0x0d, 0x73, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x45, 0x78, 0x65, 0x63, 0x41,
-	0x6c, 0x6c, 0x12, 0x14, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65,
-	0x6d, 0x61, 0x2e, 0x43, 0x68, 0x75, 0x6e, 0x6b, 0x1a, 0x17, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64,
-	0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x54, 0x78, 0x48, 0x65, 0x61, 0x64, 0x65,
-	0x72, 0x22, 0x00, 0x28, 0x01, 0x12, 0x44, 0x0a, 0x08, 0x65, 0x78, 0x70, 0x6f, 0x72, 0x74, 0x54,
-	0x78, 0x12, 0x1e, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d,
-	0x61, 0x2e, 0x45, 0x78, 0x70, 0x6f, 0x72, 0x74, 0x54, 0x78, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
-	0x74, 0x1a, 0x14, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d,
-	0x61, 0x2e, 0x43, 0x68, 0x75, 0x6e, 0x6b, 0x22, 0x00, 0x30, 0x01, 0x12, 0x40, 0x0a, 0x0b, 0x72,
-	0x65, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x74, 0x65, 0x54, 0x78, 0x12, 0x14, 0x2e, 0x69, 0x6d, 0x6d,
-	0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x43, 0x68, 0x75, 0x6e, 0x6b,
-	0x1a, 0x17, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61,
-	0x2e, 0x54, 0x78, 0x48, 0x65, 0x61, 0x64, 0x65, 0x72, 0x22, 0x00, 0x28, 0x01, 0x12, 0x5e, 0x0a,
-	0x07, 0x53, 0x51, 0x4c, 0x45, 0x78, 0x65, 0x63, 0x12, 0x1d, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64,
-	0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x53, 0x51, 0x4c, 0x45, 0x78, 0x65, 0x63,
-	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1c, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62,
-	0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x53, 0x51, 0x4c, 0x45, 0x78, 0x65, 0x63, 0x52,
-	0x65, 0x73, 0x75, 0x6c, 0x74, 0x22, 0x16, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x10, 0x22, 0x0b, 0x2f,
-	0x64, 0x62, 0x2f, 0x73, 0x71, 0x6c, 0x65, 0x78, 0x65, 0x63, 0x3a, 0x01, 0x2a, 0x12, 0x62, 0x0a,
-	0x08, 0x53, 0x51, 0x4c, 0x51, 0x75, 0x65, 0x72, 0x79, 0x12, 0x1e, 0x2e, 0x69, 0x6d, 0x6d, 0x75,
-	0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x53, 0x51, 0x4c, 0x51, 0x75, 0x65,
-	0x72, 0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1d, 0x2e, 0x69, 0x6d, 0x6d, 0x75,
-	0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x53, 0x51, 0x4c, 0x51, 0x75, 0x65,
-	0x72, 0x79, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x22, 0x17, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x11,
-	0x22, 0x0c, 0x2f, 0x64, 0x62, 0x2f, 0x73, 0x71, 0x6c, 0x71, 0x75, 0x65, 0x72, 0x79, 0x3a, 0x01,
-	0x2a, 0x12, 0x5b, 0x0a, 0x0a, 0x4c, 0x69, 0x73, 0x74, 0x54, 0x61, 0x62, 0x6c, 0x65, 0x73, 0x12,
-	0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75,
-	0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x1a, 0x1d, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62,
-	0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x53, 0x51, 0x4c, 0x51, 0x75, 0x65, 0x72, 0x79,
-	0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x22, 0x16, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x10, 0x12, 0x0e,
-	0x2f, 0x64, 0x62, 0x2f, 0x74, 0x61, 0x62, 0x6c, 0x65, 0x2f, 0x6c, 0x69, 0x73, 0x74, 0x12, 0x5b,
-	0x0a, 0x0d, 0x44, 0x65, 0x73, 0x63, 0x72, 0x69, 0x62, 0x65, 0x54, 0x61, 0x62, 0x6c, 0x65, 0x12,
-	0x14, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e,
-	0x54, 0x61, 0x62, 0x6c, 0x65, 0x1a, 0x1d, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73,
-	0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x53, 0x51, 0x4c, 0x51, 0x75, 0x65, 0x72, 0x79, 0x52, 0x65,
-	0x73, 0x75, 0x6c, 0x74, 0x22, 0x15, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x0f, 0x22, 0x0a, 0x2f, 0x64,
-	0x62, 0x2f, 0x74, 0x61, 0x62, 0x6c, 0x65, 0x73, 0x3a, 0x01, 0x2a, 0x12, 0x7f, 0x0a, 0x10, 0x56,
-	0x65, 0x72, 0x69, 0x66, 0x69, 0x61, 0x62, 0x6c, 0x65, 0x53, 0x51, 0x4c, 0x47, 0x65, 0x74, 0x12,
-	0x26, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e,
-	0x56, 0x65, 0x72, 0x69, 0x66, 0x69, 0x61, 0x62, 0x6c, 0x65, 0x53, 0x51, 0x4c, 0x47, 0x65, 0x74,
-	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x21, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62,
-	0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x56, 0x65, 0x72, 0x69, 0x66, 0x69, 0x61, 0x62,
-	0x6c, 0x65, 0x53, 0x51, 0x4c, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x22, 0x20, 0x82, 0xd3, 0xe4, 0x93,
-	0x02, 0x1a, 0x22, 0x15, 0x2f, 0x64, 0x62, 0x2f, 0x76, 0x65, 0x72, 0x69, 0x66, 0x69, 0x61, 0x62,
-	0x6c, 0x65, 0x2f, 0x73, 0x71, 0x6c, 0x67, 0x65, 0x74, 0x3a, 0x01, 0x2a, 0x42, 0x8b, 0x03, 0x5a,
-	0x2b, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x63, 0x6f, 0x64, 0x65,
-	0x6e, 0x6f, 0x74, 0x61, 0x72, 0x79, 0x2f, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2f, 0x70, 0x6b,
-	0x67, 0x2f, 0x61, 0x70, 0x69, 0x2f, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x92, 0x41, 0xda, 0x02,
-	0x12, 0xee, 0x01, 0x0a, 0x0f, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x20, 0x52, 0x45, 0x53, 0x54,
-	0x20, 0x41, 0x50, 0x49, 0x12, 0xda, 0x01, 0x3c, 0x62, 0x3e, 0x49, 0x4d, 0x50, 0x4f, 0x52, 0x54,
-	0x41, 0x4e, 0x54, 0x3c, 0x2f, 0x62, 0x3e, 0x3a, 0x20, 0x41, 0x6c, 0x6c, 0x20, 0x3c, 0x63, 0x6f,
-	0x64, 0x65, 0x3e, 0x67, 0x65, 0x74, 0x3c, 0x2f, 0x63, 0x6f, 0x64, 0x65, 0x3e, 0x20, 0x61, 0x6e,
-	0x64, 0x20, 0x3c, 0x63, 0x6f, 0x64, 0x65, 0x3e, 0x73, 0x61, 0x66, 0x65, 0x67, 0x65, 0x74, 0x3c,
-	0x2f, 0x63, 0x6f, 0x64, 0x65, 0x3e, 0x20, 0x66, 0x75, 0x6e, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73,
-	0x20, 0x72, 0x65, 0x74, 0x75, 0x72, 0x6e, 0x20, 0x3c, 0x75, 0x3e, 0x62, 0x61, 0x73, 0x65, 0x36,
-	0x34, 0x2d, 0x65, 0x6e, 0x63, 0x6f, 0x64, 0x65, 0x64, 0x3c, 0x2f, 0x75, 0x3e, 0x20, 0x6b, 0x65,
-	0x79, 0x73, 0x20, 0x61, 0x6e, 0x64, 0x20, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x73, 0x2c, 0x20, 0x77,
-	0x68, 0x69, 0x6c, 0x65, 0x20, 0x61, 0x6c, 0x6c, 0x20, 0x3c, 0x63, 0x6f, 0x64, 0x65, 0x3e, 0x73,
-	0x65, 0x74, 0x3c, 0x2f, 0x63, 0x6f, 0x64, 0x65, 0x3e, 0x20, 0x61, 0x6e, 0x64, 0x20, 0x3c, 0x63,
-	0x6f, 0x64, 0x65, 0x3e, 0x73, 0x61, 0x66, 0x65, 0x73, 0x65, 0x74, 0x3c, 0x2f, 0x63, 0x6f, 0x64,
-	0x65, 0x3e, 0x20, 0x66, 0x75, 0x6e, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x20, 0x65, 0x78, 0x70,
-	0x65, 0x63, 0x74, 0x20, 0x3c, 0x75, 0x3e, 0x62, 0x61, 0x73, 0x65, 0x36, 0x34, 0x2d, 0x65, 0x6e,
-	0x63, 0x6f, 0x64, 0x65, 0x64, 0x3c, 0x2f, 0x75, 0x3e, 0x20, 0x69, 0x6e, 0x70, 0x75, 0x74, 0x73,
-	0x2e, 0x5a, 0x59, 0x0a, 0x57, 0x0a, 0x06, 0x62, 0x65, 0x61, 0x72, 0x65, 0x72, 0x12, 0x4d, 0x08,
-	0x02, 0x12, 0x38, 0x41, 0x75, 0x74, 0x68, 0x65, 0x6e, 0x74, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f,
-	0x6e, 0x20, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x2c, 0x20, 0x70, 0x72, 0x65, 0x66, 0x69, 0x78, 0x65,
-	0x64, 0x20, 0x62, 0x79, 0x20, 0x42, 0x65, 0x61, 0x72, 0x65, 0x72, 0x3a, 0x20, 0x42, 0x65, 0x61,
-	0x72, 0x65, 0x72, 0x20, 0x3c, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x3e, 0x1a, 0x0d, 0x41, 0x75, 0x74,
-	0x68, 0x6f, 0x72, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x20, 0x02, 0x62, 0x0c, 0x0a, 0x0a,
-	0x0a, 0x06, 0x62, 0x65, 0x61, 0x72, 0x65, 0x72, 0x12, 0x00, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74,
-	0x6f, 0x33,
+func (x *CommittedSQLTx) GetFirstInsertedPKs() map[string]*SQLValue {
+	if x != nil {
+		return x.FirstInsertedPKs
+	}
+	return nil
 }
 
-var (
-	file_schema_proto_rawDescOnce sync.Once
-	file_schema_proto_rawDescData = file_schema_proto_rawDesc
-)
+type SQLQueryResult struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
 
-func file_schema_proto_rawDescGZIP() []byte {
-	file_schema_proto_rawDescOnce.Do(func() {
-		file_schema_proto_rawDescData = protoimpl.X.CompressGZIP(file_schema_proto_rawDescData)
-	})
-	return file_schema_proto_rawDescData
+	Columns []*Column `protobuf:"bytes,2,rep,name=columns,proto3" json:"columns,omitempty"`
+	Rows    []*Row    `protobuf:"bytes,1,rep,name=rows,proto3" json:"rows,omitempty"`
 }
 
-var file_schema_proto_enumTypes = make([]protoimpl.EnumInfo, 3)
-var file_schema_proto_msgTypes = make([]protoimpl.MessageInfo, 102)
-var file_schema_proto_goTypes = []interface{}{
-	(EntryTypeAction)(0),                 // 0: immudb.schema.EntryTypeAction
-	(PermissionAction)(0),                // 1: immudb.schema.PermissionAction
-	(TxMode)(0),                          // 2: immudb.schema.TxMode
-	(*Key)(nil),                          // 3: immudb.schema.Key
-	(*Permission)(nil),                   // 4: immudb.schema.Permission
-	(*User)(nil),                         // 5: immudb.schema.User
-	(*UserList)(nil),                     // 6: immudb.schema.UserList
-	(*CreateUserRequest)(nil),            // 7: immudb.schema.CreateUserRequest
-	(*UserRequest)(nil),                  // 8: immudb.schema.UserRequest
-	(*ChangePasswordRequest)(nil),        // 9: immudb.schema.ChangePasswordRequest
-	(*LoginRequest)(nil),                 // 10: immudb.schema.LoginRequest
-	(*LoginResponse)(nil),                // 11: immudb.schema.LoginResponse
-	(*AuthConfig)(nil),                   // 12: immudb.schema.AuthConfig
-	(*MTLSConfig)(nil),                   // 13: immudb.schema.MTLSConfig
-	(*OpenSessionRequest)(nil),           // 14: immudb.schema.OpenSessionRequest
-	(*OpenSessionResponse)(nil),          // 15: immudb.schema.OpenSessionResponse
-	(*KeyValue)(nil),                     // 16: immudb.schema.KeyValue
-	(*Entry)(nil),                        // 17: immudb.schema.Entry
-	(*Reference)(nil),                    // 18: immudb.schema.Reference
-	(*Op)(nil),                           // 19: immudb.schema.Op
-	(*ExecAllRequest)(nil),               // 20: immudb.schema.ExecAllRequest
-	(*Entries)(nil),                      // 21: immudb.schema.Entries
-	(*ZEntry)(nil),                       // 22: immudb.schema.ZEntry
-	(*ZEntries)(nil),                     // 23: immudb.schema.ZEntries
-	(*ScanRequest)(nil),                  // 24: immudb.schema.ScanRequest
-	(*KeyPrefix)(nil),                    // 25: immudb.schema.KeyPrefix
-	(*EntryCount)(nil),                   // 26: immudb.schema.EntryCount
-	(*Signature)(nil),                    // 27: immudb.schema.Signature
-	(*TxHeader)(nil),                     // 28: immudb.schema.TxHeader
-	(*TxMetadata)(nil),                   // 29: immudb.schema.TxMetadata
-	(*LinearProof)(nil),                  // 30: immudb.schema.LinearProof
-	(*DualProof)(nil),                    // 31: immudb.schema.DualProof
-	(*Tx)(nil),                           // 32: immudb.schema.Tx
-	(*TxEntry)(nil),                      // 33: immudb.schema.TxEntry
-	(*KVMetadata)(nil),                   // 34: immudb.schema.KVMetadata
-	(*Expiration)(nil),                   // 35: immudb.schema.Expiration
-	(*VerifiableTx)(nil),                 // 36: immudb.schema.VerifiableTx
-	(*VerifiableEntry)(nil),              // 37: immudb.schema.VerifiableEntry
-	(*InclusionProof)(nil),               // 38: immudb.schema.InclusionProof
-	(*SetRequest)(nil),                   // 39: immudb.schema.SetRequest
-	(*KeyRequest)(nil),                   // 40: immudb.schema.KeyRequest
-	(*KeyListRequest)(nil),               // 41: immudb.schema.KeyListRequest
-	(*DeleteKeysRequest)(nil),            // 42: immudb.schema.DeleteKeysRequest
-	(*VerifiableSetRequest)(nil),         // 43: immudb.schema.VerifiableSetRequest
-	(*VerifiableGetRequest)(nil),         // 44: immudb.schema.VerifiableGetRequest
-	(*HealthResponse)(nil),               // 45: immudb.schema.HealthResponse
-	(*DatabaseHealthResponse)(nil),       // 46: immudb.schema.DatabaseHealthResponse
-	(*ImmutableState)(nil),               // 47: immudb.schema.ImmutableState
-	(*ReferenceRequest)(nil),             // 48: immudb.schema.ReferenceRequest
-	(*VerifiableReferenceRequest)(nil),   // 49: immudb.schema.VerifiableReferenceRequest
-	(*ZAddRequest)(nil),                  // 50: immudb.schema.ZAddRequest
-	(*Score)(nil),                        // 51: immudb.schema.Score
-	(*ZScanRequest)(nil),                 // 52: immudb.schema.ZScanRequest
-	(*HistoryRequest)(nil),               // 53: immudb.schema.HistoryRequest
-	(*VerifiableZAddRequest)(nil),        // 54: immudb.schema.VerifiableZAddRequest
-	(*TxRequest)(nil),                    // 55: immudb.schema.TxRequest
-	(*EntriesSpec)(nil),                  // 56: immudb.schema.EntriesSpec
-	(*EntryTypeSpec)(nil),                // 57: immudb.schema.EntryTypeSpec
-	(*VerifiableTxRequest)(nil),          // 58: immudb.schema.VerifiableTxRequest
-	(*TxScanRequest)(nil),                // 59: immudb.schema.TxScanRequest
-	(*TxList)(nil),                       // 60: immudb.schema.TxList
-	(*ExportTxRequest)(nil),              // 61: immudb.schema.ExportTxRequest
-	(*Database)(nil),                     // 62: immudb.schema.Database
-	(*DatabaseSettings)(nil),             // 63: immudb.schema.DatabaseSettings
-	(*DatabaseSettingsUpdateResult)(nil), // 64: immudb.schema.DatabaseSettingsUpdateResult
-	(*ConditionalUint32)(nil),            // 65: immudb.schema.ConditionalUint32
-	(*ConditionalUint64)(nil),            // 66: immudb.schema.ConditionalUint64
-	(*ConditionalFloat)(nil),             // 67: immudb.schema.ConditionalFloat
-	(*ConditionalBool)(nil),              // 68: immudb.schema.ConditionalBool
-	(*ConditionalString)(nil),            // 69: immudb.schema.ConditionalString
-	(*ReplicationSettings)(nil),          // 70: immudb.schema.ReplicationSettings
-	(*DatabaseSettingsV2)(nil),           // 71: immudb.schema.DatabaseSettingsV2
-	(*IndexSettings)(nil),                // 72: immudb.schema.IndexSettings
-	(*FlushIndexRequest)(nil),            // 73: immudb.schema.FlushIndexRequest
-	(*Table)(nil),                        // 74: immudb.schema.Table
-	(*SQLGetRequest)(nil),                // 75: immudb.schema.SQLGetRequest
-	(*VerifiableSQLGetRequest)(nil),      // 76: immudb.schema.VerifiableSQLGetRequest
-	(*SQLEntry)(nil),                     // 77: immudb.schema.SQLEntry
-	(*VerifiableSQLEntry)(nil),           // 78: immudb.schema.VerifiableSQLEntry
-	(*UseDatabaseReply)(nil),             // 79: immudb.schema.UseDatabaseReply
-	(*ChangePermissionRequest)(nil),      // 80: immudb.schema.ChangePermissionRequest
-	(*SetActiveUserRequest)(nil),         // 81: immudb.schema.SetActiveUserRequest
-	(*DatabaseListResponse)(nil),         // 82: immudb.schema.DatabaseListResponse
-	(*Chunk)(nil),                        // 83: immudb.schema.Chunk
-	(*UseSnapshotRequest)(nil),           // 84: immudb.schema.UseSnapshotRequest
-	(*SQLExecRequest)(nil),               // 85: immudb.schema.SQLExecRequest
-	(*SQLQueryRequest)(nil),              // 86: immudb.schema.SQLQueryRequest
-	(*NamedParam)(nil),                   // 87: immudb.schema.NamedParam
-	(*SQLExecResult)(nil),                // 88: immudb.schema.SQLExecResult
-	(*CommittedSQLTx)(nil),               // 89: immudb.schema.CommittedSQLTx
-	(*SQLQueryResult)(nil),               // 90: immudb.schema.SQLQueryResult
-	(*Column)(nil),                       // 91: immudb.schema.Column
-	(*Row)(nil),                          // 92: immudb.schema.Row
-	(*SQLValue)(nil),                     // 93: immudb.schema.SQLValue
-	(*NewTxRequest)(nil),                 // 94: immudb.schema.NewTxRequest
-	(*NewTxResponse)(nil),                // 95: immudb.schema.NewTxResponse
-	(*ErrorInfo)(nil),                    // 96: immudb.schema.ErrorInfo
-	(*DebugInfo)(nil),                    // 97: immudb.schema.DebugInfo
-	(*RetryInfo)(nil),                    // 98: immudb.schema.RetryInfo
-	nil,                                  // 99: immudb.schema.VerifiableSQLEntry.ColNamesByIdEntry
-	nil,                                  // 100: immudb.schema.VerifiableSQLEntry.ColIdsByNameEntry
-	nil,                                  // 101: immudb.schema.VerifiableSQLEntry.ColTypesByIdEntry
-	nil,                                  // 102: immudb.schema.VerifiableSQLEntry.ColLenByIdEntry
-	nil,                                  // 103: immudb.schema.CommittedSQLTx.LastInsertedPKsEntry
-	nil,                                  // 104: immudb.schema.CommittedSQLTx.FirstInsertedPKsEntry
-	(_struct.NullValue)(0),               // 105: google.protobuf.NullValue
-	(*empty.Empty)(nil),                  // 106: google.protobuf.Empty
+func (x *SQLQueryResult) Reset() {
+	*x = SQLQueryResult{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_schema_proto_msgTypes[101]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
 }
-var file_schema_proto_depIdxs = []int32{
-	4,   // 0: immudb.schema.User.permissions:type_name -> immudb.schema.Permission
-	5,   // 1: immudb.schema.UserList.users:type_name -> immudb.schema.User
-	34,  // 2: immudb.schema.KeyValue.metadata:type_name -> immudb.schema.KVMetadata
-	18,  // 3: immudb.schema.Entry.referencedBy:type_name -> immudb.schema.Reference
-	34,  // 4: immudb.schema.Entry.metadata:type_name -> immudb.schema.KVMetadata
-	34,  // 5: immudb.schema.Reference.metadata:type_name -> immudb.schema.KVMetadata
-	16,  // 6: immudb.schema.Op.kv:type_name -> immudb.schema.KeyValue
-	50,  // 7: immudb.schema.Op.zAdd:type_name -> immudb.schema.ZAddRequest
-	48,  // 8: immudb.schema.Op.ref:type_name -> immudb.schema.ReferenceRequest
-	19,  // 9: immudb.schema.ExecAllRequest.Operations:type_name -> immudb.schema.Op
-	17,  // 10: immudb.schema.Entries.entries:type_name -> immudb.schema.Entry
-	17,  // 11: immudb.schema.ZEntry.entry:type_name -> immudb.schema.Entry
-	22,  // 12: immudb.schema.ZEntries.entries:type_name -> immudb.schema.ZEntry
-	29,  // 13: immudb.schema.TxHeader.metadata:type_name -> immudb.schema.TxMetadata
-	28,  // 14: immudb.schema.DualProof.sourceTxHeader:type_name -> immudb.schema.TxHeader
-	28,  // 15: immudb.schema.DualProof.targetTxHeader:type_name -> immudb.schema.TxHeader
-	30,  // 16: immudb.schema.DualProof.linearProof:type_name -> immudb.schema.LinearProof
-	28,  // 17: immudb.schema.Tx.header:type_name -> immudb.schema.TxHeader
-	33,  // 18: immudb.schema.Tx.entries:type_name -> immudb.schema.TxEntry
-	17,  // 19: immudb.schema.Tx.kvEntries:type_name -> immudb.schema.Entry
-	22,  // 20: immudb.schema.Tx.zEntries:type_name -> immudb.schema.ZEntry
-	34,  // 21: immudb.schema.TxEntry.metadata:type_name -> immudb.schema.KVMetadata
-	35,  // 22: immudb.schema.KVMetadata.expiration:type_name -> immudb.schema.Expiration
-	32,  // 23: immudb.schema.VerifiableTx.tx:type_name -> immudb.schema.Tx
-	31,  // 24: immudb.schema.VerifiableTx.dualProof:type_name -> immudb.schema.DualProof
-	27,  // 25: immudb.schema.VerifiableTx.signature:type_name -> immudb.schema.Signature
-	17,  // 26: immudb.schema.VerifiableEntry.entry:type_name -> immudb.schema.Entry
-	36,  // 27: immudb.schema.VerifiableEntry.verifiableTx:type_name -> immudb.schema.VerifiableTx
-	38,  // 28: immudb.schema.VerifiableEntry.inclusionProof:type_name -> immudb.schema.InclusionProof
-	16,  // 29: immudb.schema.SetRequest.KVs:type_name -> immudb.schema.KeyValue
-	39,  // 30: immudb.schema.VerifiableSetRequest.setRequest:type_name -> immudb.schema.SetRequest
-	40,  // 31: immudb.schema.VerifiableGetRequest.keyRequest:type_name -> immudb.schema.KeyRequest
-	27,  // 32: immudb.schema.ImmutableState.signature:type_name -> immudb.schema.Signature
-	48,  // 33: immudb.schema.VerifiableReferenceRequest.referenceRequest:type_name -> immudb.schema.ReferenceRequest
-	51,  // 34: immudb.schema.ZScanRequest.minScore:type_name -> immudb.schema.Score
-	51,  // 35: immudb.schema.ZScanRequest.maxScore:type_name -> immudb.schema.Score
-	50,  // 36: immudb.schema.VerifiableZAddRequest.zAddRequest:type_name -> immudb.schema.ZAddRequest
-	56,  // 37: immudb.schema.TxRequest.entriesSpec:type_name -> immudb.schema.EntriesSpec
-	57,  // 38: immudb.schema.EntriesSpec.kvEntriesSpec:type_name -> immudb.schema.EntryTypeSpec
-	57,  // 39: immudb.schema.EntriesSpec.zEntriesSpec:type_name -> immudb.schema.EntryTypeSpec
-	57,  // 40: immudb.schema.EntriesSpec.sqlEntriesSpec:type_name -> immudb.schema.EntryTypeSpec
-	0,   // 41: immudb.schema.EntryTypeSpec.action:type_name -> immudb.schema.EntryTypeAction
-	56,  // 42: immudb.schema.VerifiableTxRequest.entriesSpec:type_name -> immudb.schema.EntriesSpec
-	56,  // 43: immudb.schema.TxScanRequest.entriesSpec:type_name -> immudb.schema.EntriesSpec
-	32,  // 44: immudb.schema.TxList.txs:type_name -> immudb.schema.Tx
-	71,  // 45: immudb.schema.DatabaseSettingsUpdateResult.currentSettings:type_name -> immudb.schema.DatabaseSettingsV2
-	68,  // 46: immudb.schema.ReplicationSettings.replica:type_name -> immudb.schema.ConditionalBool
-	69,  // 47: immudb.schema.ReplicationSettings.masterDatabase:type_name -> immudb.schema.ConditionalString
-	69,  // 48: immudb.schema.ReplicationSettings.masterAddress:type_name -> immudb.schema.ConditionalString
-	65,  // 49: immudb.schema.ReplicationSettings.masterPort:type_name -> immudb.schema.ConditionalUint32
-	69,  // 50: immudb.schema.ReplicationSettings.followerUsername:type_name -> immudb.schema.ConditionalString
-	69,  // 51: immudb.schema.ReplicationSettings.followerPassword:type_name -> immudb.schema.ConditionalString
-	70,  // 52: immudb.schema.DatabaseSettingsV2.replicationSettings:type_name -> immudb.schema.ReplicationSettings
-	65,  // 53: immudb.schema.DatabaseSettingsV2.fileSize:type_name -> immudb.schema.ConditionalUint32
-	65,  // 54: immudb.schema.DatabaseSettingsV2.maxKeyLen:type_name -> immudb.schema.ConditionalUint32
-	65,  // 55: immudb.schema.DatabaseSettingsV2.maxValueLen:type_name -> immudb.schema.ConditionalUint32
-	65,  // 56: immudb.schema.DatabaseSettingsV2.maxTxEntries:type_name -> immudb.schema.ConditionalUint32
-	68,  // 57: immudb.schema.DatabaseSettingsV2.excludeCommitTime:type_name -> immudb.schema.ConditionalBool
-	65,  // 58: immudb.schema.DatabaseSettingsV2.maxConcurrency:type_name -> immudb.schema.ConditionalUint32
-	65,  // 59: immudb.schema.DatabaseSettingsV2.maxIOConcurrency:type_name -> immudb.schema.ConditionalUint32
-	65,  // 60: immudb.schema.DatabaseSettingsV2.txLogCacheSize:type_name -> immudb.schema.ConditionalUint32
-	65,  // 61: immudb.schema.DatabaseSettingsV2.vLogMaxOpenedFiles:type_name -> immudb.schema.ConditionalUint32
-	65,  // 62: immudb.schema.DatabaseSettingsV2.txLogMaxOpenedFiles:type_name -> immudb.schema.ConditionalUint32
-	65,  // 63: immudb.schema.DatabaseSettingsV2.commitLogMaxOpenedFiles:type_name -> immudb.schema.ConditionalUint32
-	72,  // 64: immudb.schema.DatabaseSettingsV2.indexSettings:type_name -> immudb.schema.IndexSettings
-	65,  // 65: immudb.schema.DatabaseSettingsV2.writeTxHeaderVersion:type_name -> immudb.schema.ConditionalUint32
-	65,  // 66: immudb.schema.IndexSettings.flushThreshold:type_name -> immudb.schema.ConditionalUint32
-	65,  // 67: immudb.schema.IndexSettings.syncThreshold:type_name -> immudb.schema.ConditionalUint32
-	65,  // 68: immudb.schema.IndexSettings.cacheSize:type_name -> immudb.schema.ConditionalUint32
-	65,  // 69: immudb.schema.IndexSettings.maxNodeSize:type_name -> immudb.schema.ConditionalUint32
-	65,  // 70: immudb.schema.IndexSettings.maxActiveSnapshots:type_name -> immudb.schema.ConditionalUint32
-	66,  // 71: immudb.schema.IndexSettings.renewSnapRootAfter:type_name -> immudb.schema.ConditionalUint64
-	65,  // 72: immudb.schema.IndexSettings.compactionThld:type_name -> immudb.schema.ConditionalUint32
-	65,  // 73: immudb.schema.IndexSettings.delayDuringCompaction:type_name -> immudb.schema.ConditionalUint32
-	65,  // 74: immudb.schema.IndexSettings.nodesLogMaxOpenedFiles:type_name -> immudb.schema.ConditionalUint32
-	65,  // 75: immudb.schema.IndexSettings.historyLogMaxOpenedFiles:type_name -> immudb.schema.ConditionalUint32
-	65,  // 76: immudb.schema.IndexSettings.commitLogMaxOpenedFiles:type_name -> immudb.schema.ConditionalUint32
-	65,  // 77: immudb.schema.IndexSettings.flushBufferSize:type_name -> immudb.schema.ConditionalUint32
-	67,  // 78: immudb.schema.IndexSettings.cleanupPercentage:type_name -> immudb.schema.ConditionalFloat
-	93,  // 79: immudb.schema.SQLGetRequest.pkValues:type_name -> immudb.schema.SQLValue
-	75,  // 80: immudb.schema.VerifiableSQLGetRequest.sqlGetRequest:type_name -> immudb.schema.SQLGetRequest
-	34,  // 81: immudb.schema.SQLEntry.metadata:type_name -> immudb.schema.KVMetadata
-	77,  // 82: immudb.schema.VerifiableSQLEntry.sqlEntry:type_name -> immudb.schema.SQLEntry
-	36,  // 83: immudb.schema.VerifiableSQLEntry.verifiableTx:type_name -> immudb.schema.VerifiableTx
-	38,  // 84: immudb.schema.VerifiableSQLEntry.inclusionProof:type_name -> immudb.schema.InclusionProof
-	99,  // 85: immudb.schema.VerifiableSQLEntry.ColNamesById:type_name -> immudb.schema.VerifiableSQLEntry.ColNamesByIdEntry
-	100, // 86: immudb.schema.VerifiableSQLEntry.ColIdsByName:type_name -> immudb.schema.VerifiableSQLEntry.ColIdsByNameEntry
-	101, // 87: immudb.schema.VerifiableSQLEntry.ColTypesById:type_name -> immudb.schema.VerifiableSQLEntry.ColTypesByIdEntry
-	102, // 88: immudb.schema.VerifiableSQLEntry.ColLenById:type_name -> immudb.schema.VerifiableSQLEntry.ColLenByIdEntry
-	1,   // 89: immudb.schema.ChangePermissionRequest.action:type_name -> immudb.schema.PermissionAction
-	62,  // 90: immudb.schema.DatabaseListResponse.databases:type_name -> immudb.schema.Database
-	87,  // 91: immudb.schema.SQLExecRequest.params:type_name -> immudb.schema.NamedParam
-	87,  // 92: immudb.schema.SQLQueryRequest.params:type_name -> immudb.schema.NamedParam
-	93,  // 93: immudb.schema.NamedParam.value:type_name -> immudb.schema.SQLValue
-	89,  // 94: immudb.schema.SQLExecResult.txs:type_name -> immudb.schema.CommittedSQLTx
-	28,  // 95: immudb.schema.CommittedSQLTx.header:type_name -> immudb.schema.TxHeader
-	103, // 96: immudb.schema.CommittedSQLTx.lastInsertedPKs:type_name -> immudb.schema.CommittedSQLTx.LastInsertedPKsEntry
-	104, // 97: immudb.schema.CommittedSQLTx.firstInsertedPKs:type_name -> immudb.schema.CommittedSQLTx.FirstInsertedPKsEntry
-	91,  // 98: immudb.schema.SQLQueryResult.columns:type_name -> immudb.schema.Column
-	92,  // 99: immudb.schema.SQLQueryResult.rows:type_name -> immudb.schema.Row
-	93,  // 100: immudb.schema.Row.values:type_name -> immudb.schema.SQLValue
-	105, // 101: immudb.schema.SQLValue.null:type_name -> google.protobuf.NullValue
-	2,   // 102: immudb.schema.NewTxRequest.mode:type_name -> immudb.schema.TxMode
-	93,  // 103: immudb.schema.CommittedSQLTx.LastInsertedPKsEntry.value:type_name -> immudb.schema.SQLValue
-	93,  // 104: immudb.schema.CommittedSQLTx.FirstInsertedPKsEntry.value:type_name -> immudb.schema.SQLValue
-	106, // 105: immudb.schema.ImmuService.ListUsers:input_type -> google.protobuf.Empty
-	7,   // 106: immudb.schema.ImmuService.CreateUser:input_type -> immudb.schema.CreateUserRequest
-	9,   // 107: immudb.schema.ImmuService.ChangePassword:input_type -> immudb.schema.ChangePasswordRequest
-	12,  // 108: immudb.schema.ImmuService.UpdateAuthConfig:input_type -> immudb.schema.AuthConfig
-	13,  // 109: immudb.schema.ImmuService.UpdateMTLSConfig:input_type -> immudb.schema.MTLSConfig
-	14,  // 110: immudb.schema.ImmuService.OpenSession:input_type -> immudb.schema.OpenSessionRequest
-	106, // 111: immudb.schema.ImmuService.CloseSession:input_type -> google.protobuf.Empty
-	106, // 112: immudb.schema.ImmuService.KeepAlive:input_type -> google.protobuf.Empty
-	94,  // 113: immudb.schema.ImmuService.NewTx:input_type -> immudb.schema.NewTxRequest
-	106, // 114: immudb.schema.ImmuService.Commit:input_type -> google.protobuf.Empty
-	106, // 115: immudb.schema.ImmuService.Rollback:input_type -> google.protobuf.Empty
-	85,  // 116: immudb.schema.ImmuService.TxSQLExec:input_type -> immudb.schema.SQLExecRequest
-	86,  // 117: immudb.schema.ImmuService.TxSQLQuery:input_type -> immudb.schema.SQLQueryRequest
-	10,  // 118: immudb.schema.ImmuService.Login:input_type -> immudb.schema.LoginRequest
-	106, // 119: immudb.schema.ImmuService.Logout:input_type -> google.protobuf.Empty
-	39,  // 120: immudb.schema.ImmuService.Set:input_type -> immudb.schema.SetRequest
-	43,  // 121: immudb.schema.ImmuService.VerifiableSet:input_type -> immudb.schema.VerifiableSetRequest
-	40,  // 122: immudb.schema.ImmuService.Get:input_type -> immudb.schema.KeyRequest
-	44,  // 123: immudb.schema.ImmuService.VerifiableGet:input_type -> immudb.schema.VerifiableGetRequest
-	42,  // 124: immudb.schema.ImmuService.Delete:input_type -> immudb.schema.DeleteKeysRequest
-	41,  // 125: immudb.schema.ImmuService.GetAll:input_type -> immudb.schema.KeyListRequest
-	20,  // 126: immudb.schema.ImmuService.ExecAll:input_type -> immudb.schema.ExecAllRequest
-	24,  // 127: immudb.schema.ImmuService.Scan:input_type -> immudb.schema.ScanRequest
-	25,  // 128: immudb.schema.ImmuService.Count:input_type -> immudb.schema.KeyPrefix
-	106, // 129: immudb.schema.ImmuService.CountAll:input_type -> google.protobuf.Empty
-	55,  // 130: immudb.schema.ImmuService.TxById:input_type -> immudb.schema.TxRequest
-	58,  // 131: immudb.schema.ImmuService.VerifiableTxById:input_type -> immudb.schema.VerifiableTxRequest
-	59,  // 132: immudb.schema.ImmuService.TxScan:input_type -> immudb.schema.TxScanRequest
-	53,  // 133: immudb.schema.ImmuService.History:input_type -> immudb.schema.HistoryRequest
-	106, // 134: immudb.schema.ImmuService.Health:input_type -> google.protobuf.Empty
-	106, // 135: immudb.schema.ImmuService.DatabaseHealth:input_type -> google.protobuf.Empty
-	106, // 136: immudb.schema.ImmuService.CurrentState:input_type -> google.protobuf.Empty
-	48,  // 137: immudb.schema.ImmuService.SetReference:input_type -> immudb.schema.ReferenceRequest
-	49,  // 138: immudb.schema.ImmuService.VerifiableSetReference:input_type -> immudb.schema.VerifiableReferenceRequest
-	50,  // 139: immudb.schema.ImmuService.ZAdd:input_type -> immudb.schema.ZAddRequest
-	54,  // 140: immudb.schema.ImmuService.VerifiableZAdd:input_type -> immudb.schema.VerifiableZAddRequest
-	52,  // 141: immudb.schema.ImmuService.ZScan:input_type -> immudb.schema.ZScanRequest
-	62,  // 142: immudb.schema.ImmuService.CreateDatabase:input_type -> immudb.schema.Database
-	63,  // 143: immudb.schema.ImmuService.CreateDatabaseWith:input_type -> immudb.schema.DatabaseSettings
-	71,  // 144: immudb.schema.ImmuService.CreateDatabaseWithV2:input_type -> immudb.schema.DatabaseSettingsV2
-	106, // 145: immudb.schema.ImmuService.DatabaseList:input_type -> google.protobuf.Empty
-	62,  // 146: immudb.schema.ImmuService.UseDatabase:input_type -> immudb.schema.Database
-	63,  // 147: immudb.schema.ImmuService.UpdateDatabase:input_type -> immudb.schema.DatabaseSettings
-	71,  // 148: immudb.schema.ImmuService.UpdateDatabaseV2:input_type -> immudb.schema.DatabaseSettingsV2
-	106, // 149: immudb.schema.ImmuService.GetDatabaseSettings:input_type -> google.protobuf.Empty
-	106, // 150: immudb.schema.ImmuService.GetDatabaseSettingsV2:input_type -> google.protobuf.Empty
-	73,  // 151: immudb.schema.ImmuService.FlushIndex:input_type -> immudb.schema.FlushIndexRequest
-	106, // 152: immudb.schema.ImmuService.CompactIndex:input_type -> google.protobuf.Empty
-	80,  // 153: immudb.schema.ImmuService.ChangePermission:input_type -> immudb.schema.ChangePermissionRequest
-	81,  // 154: immudb.schema.ImmuService.SetActiveUser:input_type -> immudb.schema.SetActiveUserRequest
-	40,  // 155: immudb.schema.ImmuService.streamGet:input_type -> immudb.schema.KeyRequest
-	83,  // 156: immudb.schema.ImmuService.streamSet:input_type -> immudb.schema.Chunk
-	44,  // 157: immudb.schema.ImmuService.streamVerifiableGet:input_type -> immudb.schema.VerifiableGetRequest
-	83,  // 158: immudb.schema.ImmuService.streamVerifiableSet:input_type -> immudb.schema.Chunk
-	24,  // 159: immudb.schema.ImmuService.streamScan:input_type -> immudb.schema.ScanRequest
-	52,  // 160: immudb.schema.ImmuService.streamZScan:input_type -> immudb.schema.ZScanRequest
-	53,  // 161: immudb.schema.ImmuService.streamHistory:input_type -> immudb.schema.HistoryRequest
-	83,  // 162: immudb.schema.ImmuService.streamExecAll:input_type -> immudb.schema.Chunk
-	61,  // 163: immudb.schema.ImmuService.exportTx:input_type -> immudb.schema.ExportTxRequest
-	83,  // 164: immudb.schema.ImmuService.replicateTx:input_type -> immudb.schema.Chunk
-	85,  // 165: immudb.schema.ImmuService.SQLExec:input_type -> immudb.schema.SQLExecRequest
-	86,  // 166: immudb.schema.ImmuService.SQLQuery:input_type -> immudb.schema.SQLQueryRequest
-	106, // 167: immudb.schema.ImmuService.ListTables:input_type -> google.protobuf.Empty
-	74,  // 168: immudb.schema.ImmuService.DescribeTable:input_type -> immudb.schema.Table
-	76,  // 169: immudb.schema.ImmuService.VerifiableSQLGet:input_type -> immudb.schema.VerifiableSQLGetRequest
-	6,   // 170: immudb.schema.ImmuService.ListUsers:output_type -> immudb.schema.UserList
-	106, // 171: immudb.schema.ImmuService.CreateUser:output_type -> google.protobuf.Empty
-	106, // 172: immudb.schema.ImmuService.ChangePassword:output_type -> google.protobuf.Empty
-	106, // 173: immudb.schema.ImmuService.UpdateAuthConfig:output_type -> google.protobuf.Empty
-	106, // 174: immudb.schema.ImmuService.UpdateMTLSConfig:output_type -> google.protobuf.Empty
-	15,  // 175: immudb.schema.ImmuService.OpenSession:output_type -> immudb.schema.OpenSessionResponse
-	106, // 176: immudb.schema.ImmuService.CloseSession:output_type -> google.protobuf.Empty
-	106, // 177: immudb.schema.ImmuService.KeepAlive:output_type -> google.protobuf.Empty
-	95,  // 178: immudb.schema.ImmuService.NewTx:output_type -> immudb.schema.NewTxResponse
-	89,  // 179: immudb.schema.ImmuService.Commit:output_type -> immudb.schema.CommittedSQLTx
-	106, // 180: immudb.schema.ImmuService.Rollback:output_type -> google.protobuf.Empty
-	106, // 181: immudb.schema.ImmuService.TxSQLExec:output_type -> google.protobuf.Empty
-	90,  // 182: immudb.schema.ImmuService.TxSQLQuery:output_type -> immudb.schema.SQLQueryResult
-	11,  // 183: immudb.schema.ImmuService.Login:output_type -> immudb.schema.LoginResponse
-	106, // 184: immudb.schema.ImmuService.Logout:output_type -> google.protobuf.Empty
-	28,  // 185: immudb.schema.ImmuService.Set:output_type -> immudb.schema.TxHeader
-	36,  // 186: immudb.schema.ImmuService.VerifiableSet:output_type -> immudb.schema.VerifiableTx
-	17,  // 187: immudb.schema.ImmuService.Get:output_type -> immudb.schema.Entry
-	37,  // 188: immudb.schema.ImmuService.VerifiableGet:output_type -> immudb.schema.VerifiableEntry
-	28,  // 189: immudb.schema.ImmuService.Delete:output_type -> immudb.schema.TxHeader
-	21,  // 190: immudb.schema.ImmuService.GetAll:output_type -> immudb.schema.Entries
-	28,  // 191: immudb.schema.ImmuService.ExecAll:output_type -> immudb.schema.TxHeader
-	21,  // 192: immudb.schema.ImmuService.Scan:output_type -> immudb.schema.Entries
-	26,  // 193: immudb.schema.ImmuService.Count:output_type -> immudb.schema.EntryCount
-	26,  // 194: immudb.schema.ImmuService.CountAll:output_type -> immudb.schema.EntryCount
-	32,  // 195: immudb.schema.ImmuService.TxById:output_type -> immudb.schema.Tx
-	36,  // 196: immudb.schema.ImmuService.VerifiableTxById:output_type -> immudb.schema.VerifiableTx
-	60,  // 197: immudb.schema.ImmuService.TxScan:output_type -> immudb.schema.TxList
-	21,  // 198: immudb.schema.ImmuService.History:output_type -> immudb.schema.Entries
-	45,  // 199: immudb.schema.ImmuService.Health:output_type -> immudb.schema.HealthResponse
-	46,  // 200: immudb.schema.ImmuService.DatabaseHealth:output_type -> immudb.schema.DatabaseHealthResponse
-	47,  // 201: immudb.schema.ImmuService.CurrentState:output_type -> immudb.schema.ImmutableState
-	28,  // 202: immudb.schema.ImmuService.SetReference:output_type -> immudb.schema.TxHeader
-	36,  // 203: immudb.schema.ImmuService.VerifiableSetReference:output_type -> immudb.schema.VerifiableTx
-	28,  // 204: immudb.schema.ImmuService.ZAdd:output_type -> immudb.schema.TxHeader
-	36,  // 205: immudb.schema.ImmuService.VerifiableZAdd:output_type -> immudb.schema.VerifiableTx
-	23,  // 206: immudb.schema.ImmuService.ZScan:output_type -> immudb.schema.ZEntries
-	106, // 207: immudb.schema.ImmuService.CreateDatabase:output_type -> google.protobuf.Empty
-	106, // 208: immudb.schema.ImmuService.CreateDatabaseWith:output_type -> google.protobuf.Empty
-	71,  // 209: immudb.schema.ImmuService.CreateDatabaseWithV2:output_type -> immudb.schema.DatabaseSettingsV2
-	82,  // 210: immudb.schema.ImmuService.DatabaseList:output_type -> immudb.schema.DatabaseListResponse
-	79,  // 211: immudb.schema.ImmuService.UseDatabase:output_type -> immudb.schema.UseDatabaseReply
-	106, // 212: immudb.schema.ImmuService.UpdateDatabase:output_type -> google.protobuf.Empty
-	64,  // 213: immudb.schema.ImmuService.UpdateDatabaseV2:output_type -> immudb.schema.DatabaseSettingsUpdateResult
-	63,  // 214: immudb.schema.ImmuService.GetDatabaseSettings:output_type -> immudb.schema.DatabaseSettings
-	71,  // 215: immudb.schema.ImmuService.GetDatabaseSettingsV2:output_type -> immudb.schema.DatabaseSettingsV2
-	106, // 216: immudb.schema.ImmuService.FlushIndex:output_type -> google.protobuf.Empty
-	106, // 217: immudb.schema.ImmuService.CompactIndex:output_type -> google.protobuf.Empty
-	106, // 218: immudb.schema.ImmuService.ChangePermission:output_type -> google.protobuf.Empty
-	106, // 219: immudb.schema.ImmuService.SetActiveUser:output_type -> google.protobuf.Empty
-	83,  // 220: immudb.schema.ImmuService.streamGet:output_type -> immudb.schema.Chunk
-	28,  // 221: immudb.schema.ImmuService.streamSet:output_type -> immudb.schema.TxHeader
-	83,  // 222: immudb.schema.ImmuService.streamVerifiableGet:output_type -> immudb.schema.Chunk
-	36,  // 223: immudb.schema.ImmuService.streamVerifiableSet:output_type -> immudb.schema.VerifiableTx
-	83,  // 224: immudb.schema.ImmuService.streamScan:output_type -> immudb.schema.Chunk
-	83,  // 225: immudb.schema.ImmuService.streamZScan:output_type -> immudb.schema.Chunk
-	83,  // 226: immudb.schema.ImmuService.streamHistory:output_type -> immudb.schema.Chunk
-	28,  // 227: immudb.schema.ImmuService.streamExecAll:output_type -> immudb.schema.TxHeader
-	83,  // 228: immudb.schema.ImmuService.exportTx:output_type -> immudb.schema.Chunk
-	28,  // 229: immudb.schema.ImmuService.replicateTx:output_type -> immudb.schema.TxHeader
-	88,  // 230: immudb.schema.ImmuService.SQLExec:output_type -> immudb.schema.SQLExecResult
-	90,  // 231: immudb.schema.ImmuService.SQLQuery:output_type -> immudb.schema.SQLQueryResult
-	90,  // 232: immudb.schema.ImmuService.ListTables:output_type -> immudb.schema.SQLQueryResult
-	90,  // 233: immudb.schema.ImmuService.DescribeTable:output_type -> immudb.schema.SQLQueryResult
-	78,  // 234: immudb.schema.ImmuService.VerifiableSQLGet:output_type -> immudb.schema.VerifiableSQLEntry
-	170, // [170:235] is the sub-list for method output_type
-	105, // [105:170] is the sub-list for method input_type
-	105, // [105:105] is the sub-list for extension type_name
-	105, // [105:105] is the sub-list for extension extendee
-	0,   // [0:105] is the sub-list for field type_name
+
+func (x *SQLQueryResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
 }
 
-func init() { file_schema_proto_init() }
-func file_schema_proto_init() {
-	if File_schema_proto != nil {
-		return
+func (*SQLQueryResult) ProtoMessage() {}
+
+func (x *SQLQueryResult) ProtoReflect() protoreflect.Message {
+	mi := &file_schema_proto_msgTypes[101]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	if !protoimpl.UnsafeEnabled {
-		file_schema_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Key); i {
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SQLQueryResult.ProtoReflect.Descriptor instead.
+func (*SQLQueryResult) Descriptor() ([]byte, []int) {
+	return file_schema_proto_rawDescGZIP(), []int{101}
+}
+
+func (x *SQLQueryResult) GetColumns() []*Column {
+	if x != nil {
+		return x.Columns
+	}
+	return nil
+}
+
+func (x *SQLQueryResult) GetRows() []*Row {
+	if x != nil {
+		return x.Rows
+	}
+	return nil
+}
+
+type Column struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Type string `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
+}
+
+func (x *Column) Reset() {
+	*x = Column{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_schema_proto_msgTypes[102]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Column) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Column) ProtoMessage() {}
+
+func (x *Column) ProtoReflect() protoreflect.Message {
+	mi := &file_schema_proto_msgTypes[102]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Column.ProtoReflect.Descriptor instead.
+func (*Column) Descriptor() ([]byte, []int) {
+	return file_schema_proto_rawDescGZIP(), []int{102}
+}
+
+func (x *Column) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Column) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+type Row struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Columns []string    `protobuf:"bytes,1,rep,name=columns,proto3" json:"columns,omitempty"`
+	Values  []*SQLValue `protobuf:"bytes,2,rep,name=values,proto3" json:"values,omitempty"`
+}
+
+func (x *Row) Reset() {
+	*x = Row{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_schema_proto_msgTypes[103]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Row) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Row) ProtoMessage() {}
+
+func (x *Row) ProtoReflect() protoreflect.Message {
+	mi := &file_schema_proto_msgTypes[103]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Row.ProtoReflect.Descriptor instead.
+func (*Row) Descriptor() ([]byte, []int) {
+	return file_schema_proto_rawDescGZIP(), []int{103}
+}
+
+func (x *Row) GetColumns() []string {
+	if x != nil {
+		return x.Columns
+	}
+	return nil
+}
+
+func (x *Row) GetValues() []*SQLValue {
+	if x != nil {
+		return x.Values
+	}
+	return nil
+}
+
+type SQLValue struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Types that are assignable to Value:
+	//	*SQLValue_Null
+	//	*SQLValue_N
+	//	*SQLValue_S
+	//	*SQLValue_B
+	//	*SQLValue_Bs
+	//	*SQLValue_Ts
+	Value isSQLValue_Value `protobuf_oneof:"value"`
+}
+
+func (x *SQLValue) Reset() {
+	*x = SQLValue{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_schema_proto_msgTypes[104]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SQLValue) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SQLValue) ProtoMessage() {}
+
+func (x *SQLValue) ProtoReflect() protoreflect.Message {
+	mi := &file_schema_proto_msgTypes[104]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SQLValue.ProtoReflect.Descriptor instead.
+func (*SQLValue) Descriptor() ([]byte, []int) {
+	return file_schema_proto_rawDescGZIP(), []int{104}
+}
+
+func (m *SQLValue) GetValue() isSQLValue_Value {
+	if m != nil {
+		return m.Value
+	}
+	return nil
+}
+
+func (x *SQLValue) GetNull() _struct.NullValue {
+	if x, ok := x.GetValue().(*SQLValue_Null); ok {
+		return x.Null
+	}
+	return _struct.NullValue(0)
+}
+
+func (x *SQLValue) GetN() int64 {
+	if x, ok := x.GetValue().(*SQLValue_N); ok {
+		return x.N
+	}
+	return 0
+}
+
+func (x *SQLValue) GetS() string {
+	if x, ok := x.GetValue().(*SQLValue_S); ok {
+		return x.S
+	}
+	return ""
+}
+
+func (x *SQLValue) GetB() bool {
+	if x, ok := x.GetValue().(*SQLValue_B); ok {
+		return x.B
+	}
+	return false
+}
+
+func (x *SQLValue) GetBs() []byte {
+	if x, ok := x.GetValue().(*SQLValue_Bs); ok {
+		return x.Bs
+	}
+	return nil
+}
+
+func (x *SQLValue) GetTs() int64 {
+	if x, ok := x.GetValue().(*SQLValue_Ts); ok {
+		return x.Ts
+	}
+	return 0
+}
+
+type isSQLValue_Value interface {
+	isSQLValue_Value()
+}
+
+type SQLValue_Null struct {
+	Null _struct.NullValue `protobuf:"varint,1,opt,name=null,proto3,enum=google.protobuf.NullValue,oneof"`
+}
+
+type SQLValue_N struct {
+	N int64 `protobuf:"varint,2,opt,name=n,proto3,oneof"`
+}
+
+type SQLValue_S struct {
+	S string `protobuf:"bytes,3,opt,name=s,proto3,oneof"`
+}
+
+type SQLValue_B struct {
+	B bool `protobuf:"varint,4,opt,name=b,proto3,oneof"`
+}
+
+type SQLValue_Bs struct {
+	Bs []byte `protobuf:"bytes,5,opt,name=bs,proto3,oneof"`
+}
+
+type SQLValue_Ts struct {
+	Ts int64 `protobuf:"varint,6,opt,name=ts,proto3,oneof"`
+}
+
+func (*SQLValue_Null) isSQLValue_Value() {}
+
+func (*SQLValue_N) isSQLValue_Value() {}
+
+func (*SQLValue_S) isSQLValue_Value() {}
+
+func (*SQLValue_B) isSQLValue_Value() {}
+
+func (*SQLValue_Bs) isSQLValue_Value() {}
+
+func (*SQLValue_Ts) isSQLValue_Value() {}
+
+type NewTxRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Mode TxMode `protobuf:"varint,1,opt,name=mode,proto3,enum=immudb.schema.TxMode" json:"mode,omitempty"`
+}
+
+func (x *NewTxRequest) Reset() {
+	*x = NewTxRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_schema_proto_msgTypes[105]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *NewTxRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NewTxRequest) ProtoMessage() {}
+
+func (x *NewTxRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_schema_proto_msgTypes[105]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NewTxRequest.ProtoReflect.Descriptor instead.
+func (*NewTxRequest) Descriptor() ([]byte, []int) {
+	return file_schema_proto_rawDescGZIP(), []int{105}
+}
+
+func (x *NewTxRequest) GetMode() TxMode {
+	if x != nil {
+		return x.Mode
+	}
+	return TxMode_ReadOnly
+}
+
+type NewTxResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	TransactionID string `protobuf:"bytes,1,opt,name=transactionID,proto3" json:"transactionID,omitempty"`
+}
+
+func (x *NewTxResponse) Reset() {
+	*x = NewTxResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_schema_proto_msgTypes[106]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *NewTxResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NewTxResponse) ProtoMessage() {}
+
+func (x *NewTxResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_schema_proto_msgTypes[106]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NewTxResponse.ProtoReflect.Descriptor instead.
+func (*NewTxResponse) Descriptor() ([]byte, []int) {
+	return file_schema_proto_rawDescGZIP(), []int{106}
+}
+
+func (x *NewTxResponse) GetTransactionID() string {
+	if x != nil {
+		return x.TransactionID
+	}
+	return ""
+}
+
+type ExternalRoot struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Source     string           `protobuf:"bytes,1,opt,name=source,proto3" json:"source,omitempty"`
+	Type       ExternalRootType `protobuf:"varint,2,opt,name=type,proto3,enum=immudb.schema.ExternalRootType" json:"type,omitempty"`
+	TreeSize   uint64           `protobuf:"varint,3,opt,name=treeSize,proto3" json:"treeSize,omitempty"`
+	RootHash   []byte           `protobuf:"bytes,4,opt,name=rootHash,proto3" json:"rootHash,omitempty"`
+	Signature  *Signature       `protobuf:"bytes,5,opt,name=signature,proto3" json:"signature,omitempty"`
+	ObservedAt int64            `protobuf:"varint,6,opt,name=observedAt,proto3" json:"observedAt,omitempty"`
+	Verified   bool             `protobuf:"varint,7,opt,name=verified,proto3" json:"verified,omitempty"`
+}
+
+func (x *ExternalRoot) Reset() {
+	*x = ExternalRoot{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_schema_proto_msgTypes[107]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ExternalRoot) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExternalRoot) ProtoMessage() {}
+
+func (x *ExternalRoot) ProtoReflect() protoreflect.Message {
+	mi := &file_schema_proto_msgTypes[107]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExternalRoot.ProtoReflect.Descriptor instead.
+func (*ExternalRoot) Descriptor() ([]byte, []int) {
+	return file_schema_proto_rawDescGZIP(), []int{107}
+}
+
+func (x *ExternalRoot) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *ExternalRoot) GetType() ExternalRootType {
+	if x != nil {
+		return x.Type
+	}
+	return ExternalRootType_GENERIC
+}
+
+func (x *ExternalRoot) GetTreeSize() uint64 {
+	if x != nil {
+		return x.TreeSize
+	}
+	return 0
+}
+
+func (x *ExternalRoot) GetRootHash() []byte {
+	if x != nil {
+		return x.RootHash
+	}
+	return nil
+}
+
+func (x *ExternalRoot) GetSignature() *Signature {
+	if x != nil {
+		return x.Signature
+	}
+	return nil
+}
+
+func (x *ExternalRoot) GetObservedAt() int64 {
+	if x != nil {
+		return x.ObservedAt
+	}
+	return 0
+}
+
+func (x *ExternalRoot) GetVerified() bool {
+	if x != nil {
+		return x.Verified
+	}
+	return false
+}
+
+type ExternalRootsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Source       string `protobuf:"bytes,1,opt,name=source,proto3" json:"source,omitempty"`
+	SeekTreeSize uint64 `protobuf:"varint,2,opt,name=seekTreeSize,proto3" json:"seekTreeSize,omitempty"`
+	Limit        uint64 `protobuf:"varint,3,opt,name=limit,proto3" json:"limit,omitempty"`
+	Desc         bool   `protobuf:"varint,4,opt,name=desc,proto3" json:"desc,omitempty"`
+}
+
+func (x *ExternalRootsRequest) Reset() {
+	*x = ExternalRootsRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_schema_proto_msgTypes[108]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ExternalRootsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExternalRootsRequest) ProtoMessage() {}
+
+func (x *ExternalRootsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_schema_proto_msgTypes[108]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExternalRootsRequest.ProtoReflect.Descriptor instead.
+func (*ExternalRootsRequest) Descriptor() ([]byte, []int) {
+	return file_schema_proto_rawDescGZIP(), []int{108}
+}
+
+func (x *ExternalRootsRequest) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *ExternalRootsRequest) GetSeekTreeSize() uint64 {
+	if x != nil {
+		return x.SeekTreeSize
+	}
+	return 0
+}
+
+func (x *ExternalRootsRequest) GetLimit() uint64 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *ExternalRootsRequest) GetDesc() bool {
+	if x != nil {
+		return x.Desc
+	}
+	return false
+}
+
+type ExternalRootList struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Roots []*ExternalRoot `protobuf:"bytes,1,rep,name=roots,proto3" json:"roots,omitempty"`
+}
+
+func (x *ExternalRootList) Reset() {
+	*x = ExternalRootList{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_schema_proto_msgTypes[109]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ExternalRootList) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExternalRootList) ProtoMessage() {}
+
+func (x *ExternalRootList) ProtoReflect() protoreflect.Message {
+	mi := &file_schema_proto_msgTypes[109]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExternalRootList.ProtoReflect.Descriptor instead.
+func (*ExternalRootList) Descriptor() ([]byte, []int) {
+	return file_schema_proto_rawDescGZIP(), []int{109}
+}
+
+func (x *ExternalRootList) GetRoots() []*ExternalRoot {
+	if x != nil {
+		return x.Roots
+	}
+	return nil
+}
+
+type KVSchema struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Prefix []byte       `protobuf:"bytes,1,opt,name=prefix,proto3" json:"prefix,omitempty"`
+	Type   KVSchemaType `protobuf:"varint,2,opt,name=type,proto3,enum=immudb.schema.KVSchemaType" json:"type,omitempty"`
+	// JSON schema document, or serialized FileDescriptorSet for protobuf schemas
+	Definition []byte `protobuf:"bytes,3,opt,name=definition,proto3" json:"definition,omitempty"`
+	// fully-qualified name of the message type, only for protobuf schemas
+	MessageType string `protobuf:"bytes,4,opt,name=messageType,proto3" json:"messageType,omitempty"`
+}
+
+func (x *KVSchema) Reset() {
+	*x = KVSchema{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_schema_proto_msgTypes[110]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *KVSchema) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KVSchema) ProtoMessage() {}
+
+func (x *KVSchema) ProtoReflect() protoreflect.Message {
+	mi := &file_schema_proto_msgTypes[110]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KVSchema.ProtoReflect.Descriptor instead.
+func (*KVSchema) Descriptor() ([]byte, []int) {
+	return file_schema_proto_rawDescGZIP(), []int{110}
+}
+
+func (x *KVSchema) GetPrefix() []byte {
+	if x != nil {
+		return x.Prefix
+	}
+	return nil
+}
+
+func (x *KVSchema) GetType() KVSchemaType {
+	if x != nil {
+		return x.Type
+	}
+	return KVSchemaType_JSON_SCHEMA
+}
+
+func (x *KVSchema) GetDefinition() []byte {
+	if x != nil {
+		return x.Definition
+	}
+	return nil
+}
+
+func (x *KVSchema) GetMessageType() string {
+	if x != nil {
+		return x.MessageType
+	}
+	return ""
+}
+
+type KVSchemaRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Prefix []byte `protobuf:"bytes,1,opt,name=prefix,proto3" json:"prefix,omitempty"`
+}
+
+func (x *KVSchemaRequest) Reset() {
+	*x = KVSchemaRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_schema_proto_msgTypes[111]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *KVSchemaRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KVSchemaRequest) ProtoMessage() {}
+
+func (x *KVSchemaRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_schema_proto_msgTypes[111]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KVSchemaRequest.ProtoReflect.Descriptor instead.
+func (*KVSchemaRequest) Descriptor() ([]byte, []int) {
+	return file_schema_proto_rawDescGZIP(), []int{111}
+}
+
+func (x *KVSchemaRequest) GetPrefix() []byte {
+	if x != nil {
+		return x.Prefix
+	}
+	return nil
+}
+
+type KVSchemaList struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Schemas []*KVSchema `protobuf:"bytes,1,rep,name=schemas,proto3" json:"schemas,omitempty"`
+}
+
+func (x *KVSchemaList) Reset() {
+	*x = KVSchemaList{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_schema_proto_msgTypes[112]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *KVSchemaList) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KVSchemaList) ProtoMessage() {}
+
+func (x *KVSchemaList) ProtoReflect() protoreflect.Message {
+	mi := &file_schema_proto_msgTypes[112]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KVSchemaList.ProtoReflect.Descriptor instead.
+func (*KVSchemaList) Descriptor() ([]byte, []int) {
+	return file_schema_proto_rawDescGZIP(), []int{112}
+}
+
+func (x *KVSchemaList) GetSchemas() []*KVSchema {
+	if x != nil {
+		return x.Schemas
+	}
+	return nil
+}
+
+type RedactionRule struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// key prefix of the values to be redacted, only for key-value rules
+	Prefix []byte `protobuf:"bytes,1,opt,name=prefix,proto3" json:"prefix,omitempty"`
+	// table and column of the values to be redacted, only for SQL rules
+	Table  string        `protobuf:"bytes,2,opt,name=table,proto3" json:"table,omitempty"`
+	Column string        `protobuf:"bytes,3,opt,name=column,proto3" json:"column,omitempty"`
+	Mode   RedactionMode `protobuf:"varint,4,opt,name=mode,proto3,enum=immudb.schema.RedactionMode" json:"mode,omitempty"`
+	// number of trailing characters left visible, only for partial redaction
+	VisibleChars uint32 `protobuf:"varint,5,opt,name=visibleChars,proto3" json:"visibleChars,omitempty"`
+}
+
+func (x *RedactionRule) Reset() {
+	*x = RedactionRule{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_schema_proto_msgTypes[113]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RedactionRule) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RedactionRule) ProtoMessage() {}
+
+func (x *RedactionRule) ProtoReflect() protoreflect.Message {
+	mi := &file_schema_proto_msgTypes[113]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RedactionRule.ProtoReflect.Descriptor instead.
+func (*RedactionRule) Descriptor() ([]byte, []int) {
+	return file_schema_proto_rawDescGZIP(), []int{113}
+}
+
+func (x *RedactionRule) GetPrefix() []byte {
+	if x != nil {
+		return x.Prefix
+	}
+	return nil
+}
+
+func (x *RedactionRule) GetTable() string {
+	if x != nil {
+		return x.Table
+	}
+	return ""
+}
+
+func (x *RedactionRule) GetColumn() string {
+	if x != nil {
+		return x.Column
+	}
+	return ""
+}
+
+func (x *RedactionRule) GetMode() RedactionMode {
+	if x != nil {
+		return x.Mode
+	}
+	return RedactionMode_REDACT_HASH
+}
+
+func (x *RedactionRule) GetVisibleChars() uint32 {
+	if x != nil {
+		return x.VisibleChars
+	}
+	return 0
+}
+
+type RedactionRuleRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Prefix []byte `protobuf:"bytes,1,opt,name=prefix,proto3" json:"prefix,omitempty"`
+	Table  string `protobuf:"bytes,2,opt,name=table,proto3" json:"table,omitempty"`
+	Column string `protobuf:"bytes,3,opt,name=column,proto3" json:"column,omitempty"`
+}
+
+func (x *RedactionRuleRequest) Reset() {
+	*x = RedactionRuleRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_schema_proto_msgTypes[114]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RedactionRuleRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RedactionRuleRequest) ProtoMessage() {}
+
+func (x *RedactionRuleRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_schema_proto_msgTypes[114]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RedactionRuleRequest.ProtoReflect.Descriptor instead.
+func (*RedactionRuleRequest) Descriptor() ([]byte, []int) {
+	return file_schema_proto_rawDescGZIP(), []int{114}
+}
+
+func (x *RedactionRuleRequest) GetPrefix() []byte {
+	if x != nil {
+		return x.Prefix
+	}
+	return nil
+}
+
+func (x *RedactionRuleRequest) GetTable() string {
+	if x != nil {
+		return x.Table
+	}
+	return ""
+}
+
+func (x *RedactionRuleRequest) GetColumn() string {
+	if x != nil {
+		return x.Column
+	}
+	return ""
+}
+
+type RedactionRuleList struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Rules []*RedactionRule `protobuf:"bytes,1,rep,name=rules,proto3" json:"rules,omitempty"`
+}
+
+func (x *RedactionRuleList) Reset() {
+	*x = RedactionRuleList{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_schema_proto_msgTypes[115]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RedactionRuleList) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RedactionRuleList) ProtoMessage() {}
+
+func (x *RedactionRuleList) ProtoReflect() protoreflect.Message {
+	mi := &file_schema_proto_msgTypes[115]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RedactionRuleList.ProtoReflect.Descriptor instead.
+func (*RedactionRuleList) Descriptor() ([]byte, []int) {
+	return file_schema_proto_rawDescGZIP(), []int{115}
+}
+
+func (x *RedactionRuleList) GetRules() []*RedactionRule {
+	if x != nil {
+		return x.Rules
+	}
+	return nil
+}
+
+type ReplicationRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Database string `protobuf:"bytes,1,opt,name=database,proto3" json:"database,omitempty"`
+}
+
+func (x *ReplicationRequest) Reset() {
+	*x = ReplicationRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_schema_proto_msgTypes[116]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ReplicationRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReplicationRequest) ProtoMessage() {}
+
+func (x *ReplicationRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_schema_proto_msgTypes[116]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReplicationRequest.ProtoReflect.Descriptor instead.
+func (*ReplicationRequest) Descriptor() ([]byte, []int) {
+	return file_schema_proto_rawDescGZIP(), []int{116}
+}
+
+func (x *ReplicationRequest) GetDatabase() string {
+	if x != nil {
+		return x.Database
+	}
+	return ""
+}
+
+type ReplicationStatusResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Database       string `protobuf:"bytes,1,opt,name=database,proto3" json:"database,omitempty"`
+	Running        bool   `protobuf:"varint,2,opt,name=running,proto3" json:"running,omitempty"`
+	Paused         bool   `protobuf:"varint,3,opt,name=paused,proto3" json:"paused,omitempty"`
+	Connected      bool   `protobuf:"varint,4,opt,name=connected,proto3" json:"connected,omitempty"`
+	LastTx         uint64 `protobuf:"varint,5,opt,name=lastTx,proto3" json:"lastTx,omitempty"`
+	MasterTx       uint64 `protobuf:"varint,6,opt,name=masterTx,proto3" json:"masterTx,omitempty"`
+	Lag            uint64 `protobuf:"varint,7,opt,name=lag,proto3" json:"lag,omitempty"`
+	FailedAttempts uint32 `protobuf:"varint,8,opt,name=failedAttempts,proto3" json:"failedAttempts,omitempty"`
+	LastError      string `protobuf:"bytes,9,opt,name=lastError,proto3" json:"lastError,omitempty"`
+}
+
+func (x *ReplicationStatusResponse) Reset() {
+	*x = ReplicationStatusResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_schema_proto_msgTypes[117]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ReplicationStatusResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReplicationStatusResponse) ProtoMessage() {}
+
+func (x *ReplicationStatusResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_schema_proto_msgTypes[117]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReplicationStatusResponse.ProtoReflect.Descriptor instead.
+func (*ReplicationStatusResponse) Descriptor() ([]byte, []int) {
+	return file_schema_proto_rawDescGZIP(), []int{117}
+}
+
+func (x *ReplicationStatusResponse) GetDatabase() string {
+	if x != nil {
+		return x.Database
+	}
+	return ""
+}
+
+func (x *ReplicationStatusResponse) GetRunning() bool {
+	if x != nil {
+		return x.Running
+	}
+	return false
+}
+
+func (x *ReplicationStatusResponse) GetPaused() bool {
+	if x != nil {
+		return x.Paused
+	}
+	return false
+}
+
+func (x *ReplicationStatusResponse) GetConnected() bool {
+	if x != nil {
+		return x.Connected
+	}
+	return false
+}
+
+func (x *ReplicationStatusResponse) GetLastTx() uint64 {
+	if x != nil {
+		return x.LastTx
+	}
+	return 0
+}
+
+func (x *ReplicationStatusResponse) GetMasterTx() uint64 {
+	if x != nil {
+		return x.MasterTx
+	}
+	return 0
+}
+
+func (x *ReplicationStatusResponse) GetLag() uint64 {
+	if x != nil {
+		return x.Lag
+	}
+	return 0
+}
+
+func (x *ReplicationStatusResponse) GetFailedAttempts() uint32 {
+	if x != nil {
+		return x.FailedAttempts
+	}
+	return 0
+}
+
+func (x *ReplicationStatusResponse) GetLastError() string {
+	if x != nil {
+		return x.LastError
+	}
+	return ""
+}
+
+type KeyspaceStatsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Database string `protobuf:"bytes,1,opt,name=database,proto3" json:"database,omitempty"`
+	// number of leading bytes keys are grouped by, 1 if 0
+	PrefixLength uint32 `protobuf:"varint,2,opt,name=prefixLength,proto3" json:"prefixLength,omitempty"`
+	// number of keys with most revisions to report, 10 if 0
+	Outliers uint32 `protobuf:"varint,3,opt,name=outliers,proto3" json:"outliers,omitempty"`
+}
+
+func (x *KeyspaceStatsRequest) Reset() {
+	*x = KeyspaceStatsRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_schema_proto_msgTypes[118]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *KeyspaceStatsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KeyspaceStatsRequest) ProtoMessage() {}
+
+func (x *KeyspaceStatsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_schema_proto_msgTypes[118]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KeyspaceStatsRequest.ProtoReflect.Descriptor instead.
+func (*KeyspaceStatsRequest) Descriptor() ([]byte, []int) {
+	return file_schema_proto_rawDescGZIP(), []int{118}
+}
+
+func (x *KeyspaceStatsRequest) GetDatabase() string {
+	if x != nil {
+		return x.Database
+	}
+	return ""
+}
+
+func (x *KeyspaceStatsRequest) GetPrefixLength() uint32 {
+	if x != nil {
+		return x.PrefixLength
+	}
+	return 0
+}
+
+func (x *KeyspaceStatsRequest) GetOutliers() uint32 {
+	if x != nil {
+		return x.Outliers
+	}
+	return 0
+}
+
+// KeyspaceStatsResponse summarizes the keys of a database as found in its index at txId.
+// Deleted keys are not accounted for, sizes are the ones of keys and values as stored
+type KeyspaceStatsResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	TxId      uint64           `protobuf:"varint,1,opt,name=txId,proto3" json:"txId,omitempty"`
+	Keyspaces []*KeyspaceUsage `protobuf:"bytes,2,rep,name=keyspaces,proto3" json:"keyspaces,omitempty"`
+	// key-value keys grouped by their leading bytes, sorted by number of keys
+	Prefixes []*KeyspaceUsage `protobuf:"bytes,3,rep,name=prefixes,proto3" json:"prefixes,omitempty"`
+	// keys of the prefixes found once the max number of prefixes were already tracked
+	OtherPrefixes *KeyspaceUsage    `protobuf:"bytes,4,opt,name=otherPrefixes,proto3" json:"otherPrefixes,omitempty"`
+	KeySizes      *SizeDistribution `protobuf:"bytes,5,opt,name=keySizes,proto3" json:"keySizes,omitempty"`
+	ValueSizes    *SizeDistribution `protobuf:"bytes,6,opt,name=valueSizes,proto3" json:"valueSizes,omitempty"`
+	// key-value keys with most revisions, sorted by number of revisions
+	RevisionOutliers []*KeyRevisions `protobuf:"bytes,7,rep,name=revisionOutliers,proto3" json:"revisionOutliers,omitempty"`
+}
+
+func (x *KeyspaceStatsResponse) Reset() {
+	*x = KeyspaceStatsResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_schema_proto_msgTypes[119]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *KeyspaceStatsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KeyspaceStatsResponse) ProtoMessage() {}
+
+func (x *KeyspaceStatsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_schema_proto_msgTypes[119]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KeyspaceStatsResponse.ProtoReflect.Descriptor instead.
+func (*KeyspaceStatsResponse) Descriptor() ([]byte, []int) {
+	return file_schema_proto_rawDescGZIP(), []int{119}
+}
+
+func (x *KeyspaceStatsResponse) GetTxId() uint64 {
+	if x != nil {
+		return x.TxId
+	}
+	return 0
+}
+
+func (x *KeyspaceStatsResponse) GetKeyspaces() []*KeyspaceUsage {
+	if x != nil {
+		return x.Keyspaces
+	}
+	return nil
+}
+
+func (x *KeyspaceStatsResponse) GetPrefixes() []*KeyspaceUsage {
+	if x != nil {
+		return x.Prefixes
+	}
+	return nil
+}
+
+func (x *KeyspaceStatsResponse) GetOtherPrefixes() *KeyspaceUsage {
+	if x != nil {
+		return x.OtherPrefixes
+	}
+	return nil
+}
+
+func (x *KeyspaceStatsResponse) GetKeySizes() *SizeDistribution {
+	if x != nil {
+		return x.KeySizes
+	}
+	return nil
+}
+
+func (x *KeyspaceStatsResponse) GetValueSizes() *SizeDistribution {
+	if x != nil {
+		return x.ValueSizes
+	}
+	return nil
+}
+
+func (x *KeyspaceStatsResponse) GetRevisionOutliers() []*KeyRevisions {
+	if x != nil {
+		return x.RevisionOutliers
+	}
+	return nil
+}
+
+type KeyspaceUsage struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Name       string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Prefix     []byte `protobuf:"bytes,2,opt,name=prefix,proto3" json:"prefix,omitempty"`
+	Keys       uint64 `protobuf:"varint,3,opt,name=keys,proto3" json:"keys,omitempty"`
+	KeyBytes   uint64 `protobuf:"varint,4,opt,name=keyBytes,proto3" json:"keyBytes,omitempty"`
+	ValueBytes uint64 `protobuf:"varint,5,opt,name=valueBytes,proto3" json:"valueBytes,omitempty"`
+}
+
+func (x *KeyspaceUsage) Reset() {
+	*x = KeyspaceUsage{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_schema_proto_msgTypes[120]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *KeyspaceUsage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KeyspaceUsage) ProtoMessage() {}
+
+func (x *KeyspaceUsage) ProtoReflect() protoreflect.Message {
+	mi := &file_schema_proto_msgTypes[120]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KeyspaceUsage.ProtoReflect.Descriptor instead.
+func (*KeyspaceUsage) Descriptor() ([]byte, []int) {
+	return file_schema_proto_rawDescGZIP(), []int{120}
+}
+
+func (x *KeyspaceUsage) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *KeyspaceUsage) GetPrefix() []byte {
+	if x != nil {
+		return x.Prefix
+	}
+	return nil
+}
+
+func (x *KeyspaceUsage) GetKeys() uint64 {
+	if x != nil {
+		return x.Keys
+	}
+	return 0
+}
+
+func (x *KeyspaceUsage) GetKeyBytes() uint64 {
+	if x != nil {
+		return x.KeyBytes
+	}
+	return 0
+}
+
+func (x *KeyspaceUsage) GetValueBytes() uint64 {
+	if x != nil {
+		return x.ValueBytes
+	}
+	return 0
+}
+
+// SizeDistribution counts sizes into buckets bounded by powers of two
+type SizeDistribution struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Count   uint64        `protobuf:"varint,1,opt,name=count,proto3" json:"count,omitempty"`
+	Min     uint64        `protobuf:"varint,2,opt,name=min,proto3" json:"min,omitempty"`
+	Max     uint64        `protobuf:"varint,3,opt,name=max,proto3" json:"max,omitempty"`
+	Sum     uint64        `protobuf:"varint,4,opt,name=sum,proto3" json:"sum,omitempty"`
+	Buckets []*SizeBucket `protobuf:"bytes,5,rep,name=buckets,proto3" json:"buckets,omitempty"`
+}
+
+func (x *SizeDistribution) Reset() {
+	*x = SizeDistribution{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_schema_proto_msgTypes[121]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SizeDistribution) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SizeDistribution) ProtoMessage() {}
+
+func (x *SizeDistribution) ProtoReflect() protoreflect.Message {
+	mi := &file_schema_proto_msgTypes[121]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SizeDistribution.ProtoReflect.Descriptor instead.
+func (*SizeDistribution) Descriptor() ([]byte, []int) {
+	return file_schema_proto_rawDescGZIP(), []int{121}
+}
+
+func (x *SizeDistribution) GetCount() uint64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *SizeDistribution) GetMin() uint64 {
+	if x != nil {
+		return x.Min
+	}
+	return 0
+}
+
+func (x *SizeDistribution) GetMax() uint64 {
+	if x != nil {
+		return x.Max
+	}
+	return 0
+}
+
+func (x *SizeDistribution) GetSum() uint64 {
+	if x != nil {
+		return x.Sum
+	}
+	return 0
+}
+
+func (x *SizeDistribution) GetBuckets() []*SizeBucket {
+	if x != nil {
+		return x.Buckets
+	}
+	return nil
+}
+
+type SizeBucket struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	UpperBound uint64 `protobuf:"varint,1,opt,name=upperBound,proto3" json:"upperBound,omitempty"`
+	Count      uint64 `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
+}
+
+func (x *SizeBucket) Reset() {
+	*x = SizeBucket{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_schema_proto_msgTypes[122]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SizeBucket) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SizeBucket) ProtoMessage() {}
+
+func (x *SizeBucket) ProtoReflect() protoreflect.Message {
+	mi := &file_schema_proto_msgTypes[122]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SizeBucket.ProtoReflect.Descriptor instead.
+func (*SizeBucket) Descriptor() ([]byte, []int) {
+	return file_schema_proto_rawDescGZIP(), []int{122}
+}
+
+func (x *SizeBucket) GetUpperBound() uint64 {
+	if x != nil {
+		return x.UpperBound
+	}
+	return 0
+}
+
+func (x *SizeBucket) GetCount() uint64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+type KeyRevisions struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Key       []byte `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Revisions uint64 `protobuf:"varint,2,opt,name=revisions,proto3" json:"revisions,omitempty"`
+}
+
+func (x *KeyRevisions) Reset() {
+	*x = KeyRevisions{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_schema_proto_msgTypes[123]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *KeyRevisions) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KeyRevisions) ProtoMessage() {}
+
+func (x *KeyRevisions) ProtoReflect() protoreflect.Message {
+	mi := &file_schema_proto_msgTypes[123]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KeyRevisions.ProtoReflect.Descriptor instead.
+func (*KeyRevisions) Descriptor() ([]byte, []int) {
+	return file_schema_proto_rawDescGZIP(), []int{123}
+}
+
+func (x *KeyRevisions) GetKey() []byte {
+	if x != nil {
+		return x.Key
+	}
+	return nil
+}
+
+func (x *KeyRevisions) GetRevisions() uint64 {
+	if x != nil {
+		return x.Revisions
+	}
+	return 0
+}
+
+type Job struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id         string  `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Kind       string  `protobuf:"bytes,2,opt,name=kind,proto3" json:"kind,omitempty"`
+	Database   string  `protobuf:"bytes,3,opt,name=database,proto3" json:"database,omitempty"`
+	Status     string  `protobuf:"bytes,4,opt,name=status,proto3" json:"status,omitempty"`
+	Progress   float32 `protobuf:"fixed32,5,opt,name=progress,proto3" json:"progress,omitempty"`
+	Error      string  `protobuf:"bytes,6,opt,name=error,proto3" json:"error,omitempty"`
+	StartedAt  int64   `protobuf:"varint,7,opt,name=startedAt,proto3" json:"startedAt,omitempty"`
+	FinishedAt int64   `protobuf:"varint,8,opt,name=finishedAt,proto3" json:"finishedAt,omitempty"`
+	Cancelable bool    `protobuf:"varint,9,opt,name=cancelable,proto3" json:"cancelable,omitempty"`
+}
+
+func (x *Job) Reset() {
+	*x = Job{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_schema_proto_msgTypes[124]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Job) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Job) ProtoMessage() {}
+
+func (x *Job) ProtoReflect() protoreflect.Message {
+	mi := &file_schema_proto_msgTypes[124]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Job.ProtoReflect.Descriptor instead.
+func (*Job) Descriptor() ([]byte, []int) {
+	return file_schema_proto_rawDescGZIP(), []int{124}
+}
+
+func (x *Job) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Job) GetKind() string {
+	if x != nil {
+		return x.Kind
+	}
+	return ""
+}
+
+func (x *Job) GetDatabase() string {
+	if x != nil {
+		return x.Database
+	}
+	return ""
+}
+
+func (x *Job) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Job) GetProgress() float32 {
+	if x != nil {
+		return x.Progress
+	}
+	return 0
+}
+
+func (x *Job) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *Job) GetStartedAt() int64 {
+	if x != nil {
+		return x.StartedAt
+	}
+	return 0
+}
+
+func (x *Job) GetFinishedAt() int64 {
+	if x != nil {
+		return x.FinishedAt
+	}
+	return 0
+}
+
+func (x *Job) GetCancelable() bool {
+	if x != nil {
+		return x.Cancelable
+	}
+	return false
+}
+
+type ScrubMismatch struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	TxId   uint64 `protobuf:"varint,1,opt,name=txId,proto3" json:"txId,omitempty"`
+	Reason string `protobuf:"bytes,2,opt,name=reason,proto3" json:"reason,omitempty"`
+}
+
+func (x *ScrubMismatch) Reset() {
+	*x = ScrubMismatch{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_schema_proto_msgTypes[125]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ScrubMismatch) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ScrubMismatch) ProtoMessage() {}
+
+func (x *ScrubMismatch) ProtoReflect() protoreflect.Message {
+	mi := &file_schema_proto_msgTypes[125]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ScrubMismatch.ProtoReflect.Descriptor instead.
+func (*ScrubMismatch) Descriptor() ([]byte, []int) {
+	return file_schema_proto_rawDescGZIP(), []int{125}
+}
+
+func (x *ScrubMismatch) GetTxId() uint64 {
+	if x != nil {
+		return x.TxId
+	}
+	return 0
+}
+
+func (x *ScrubMismatch) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+// ScrubStatusResponse describes the ongoing scrub of a database or the last one when none is running
+type ScrubStatusResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Database   string `protobuf:"bytes,1,opt,name=database,proto3" json:"database,omitempty"`
+	Running    bool   `protobuf:"varint,2,opt,name=running,proto3" json:"running,omitempty"`
+	StartedAt  int64  `protobuf:"varint,3,opt,name=startedAt,proto3" json:"startedAt,omitempty"`
+	FinishedAt int64  `protobuf:"varint,4,opt,name=finishedAt,proto3" json:"finishedAt,omitempty"`
+	// number of transactions verified so far, out of targetTxId
+	ScrubbedTxs uint64 `protobuf:"varint,5,opt,name=scrubbedTxs,proto3" json:"scrubbedTxs,omitempty"`
+	TargetTxId  uint64 `protobuf:"varint,6,opt,name=targetTxId,proto3" json:"targetTxId,omitempty"`
+	// up to 100 corrupted transactions, mismatchCount is the total found
+	Mismatches    []*ScrubMismatch `protobuf:"bytes,7,rep,name=mismatches,proto3" json:"mismatches,omitempty"`
+	MismatchCount uint64           `protobuf:"varint,8,opt,name=mismatchCount,proto3" json:"mismatchCount,omitempty"`
+	// set when the scrub could not be completed
+	Error string `protobuf:"bytes,9,opt,name=error,proto3" json:"error,omitempty"`
+}
+
+func (x *ScrubStatusResponse) Reset() {
+	*x = ScrubStatusResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_schema_proto_msgTypes[126]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ScrubStatusResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ScrubStatusResponse) ProtoMessage() {}
+
+func (x *ScrubStatusResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_schema_proto_msgTypes[126]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ScrubStatusResponse.ProtoReflect.Descriptor instead.
+func (*ScrubStatusResponse) Descriptor() ([]byte, []int) {
+	return file_schema_proto_rawDescGZIP(), []int{126}
+}
+
+func (x *ScrubStatusResponse) GetDatabase() string {
+	if x != nil {
+		return x.Database
+	}
+	return ""
+}
+
+func (x *ScrubStatusResponse) GetRunning() bool {
+	if x != nil {
+		return x.Running
+	}
+	return false
+}
+
+func (x *ScrubStatusResponse) GetStartedAt() int64 {
+	if x != nil {
+		return x.StartedAt
+	}
+	return 0
+}
+
+func (x *ScrubStatusResponse) GetFinishedAt() int64 {
+	if x != nil {
+		return x.FinishedAt
+	}
+	return 0
+}
+
+func (x *ScrubStatusResponse) GetScrubbedTxs() uint64 {
+	if x != nil {
+		return x.ScrubbedTxs
+	}
+	return 0
+}
+
+func (x *ScrubStatusResponse) GetTargetTxId() uint64 {
+	if x != nil {
+		return x.TargetTxId
+	}
+	return 0
+}
+
+func (x *ScrubStatusResponse) GetMismatches() []*ScrubMismatch {
+	if x != nil {
+		return x.Mismatches
+	}
+	return nil
+}
+
+func (x *ScrubStatusResponse) GetMismatchCount() uint64 {
+	if x != nil {
+		return x.MismatchCount
+	}
+	return 0
+}
+
+func (x *ScrubStatusResponse) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type JobRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+}
+
+func (x *JobRequest) Reset() {
+	*x = JobRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_schema_proto_msgTypes[127]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *JobRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*JobRequest) ProtoMessage() {}
+
+func (x *JobRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_schema_proto_msgTypes[127]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use JobRequest.ProtoReflect.Descriptor instead.
+func (*JobRequest) Descriptor() ([]byte, []int) {
+	return file_schema_proto_rawDescGZIP(), []int{127}
+}
+
+func (x *JobRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type ListJobsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Kind string `protobuf:"bytes,1,opt,name=kind,proto3" json:"kind,omitempty"`
+}
+
+func (x *ListJobsRequest) Reset() {
+	*x = ListJobsRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_schema_proto_msgTypes[128]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListJobsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListJobsRequest) ProtoMessage() {}
+
+func (x *ListJobsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_schema_proto_msgTypes[128]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListJobsRequest.ProtoReflect.Descriptor instead.
+func (*ListJobsRequest) Descriptor() ([]byte, []int) {
+	return file_schema_proto_rawDescGZIP(), []int{128}
+}
+
+func (x *ListJobsRequest) GetKind() string {
+	if x != nil {
+		return x.Kind
+	}
+	return ""
+}
+
+type JobList struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Jobs []*Job `protobuf:"bytes,1,rep,name=jobs,proto3" json:"jobs,omitempty"`
+}
+
+func (x *JobList) Reset() {
+	*x = JobList{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_schema_proto_msgTypes[129]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *JobList) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*JobList) ProtoMessage() {}
+
+func (x *JobList) ProtoReflect() protoreflect.Message {
+	mi := &file_schema_proto_msgTypes[129]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use JobList.ProtoReflect.Descriptor instead.
+func (*JobList) Descriptor() ([]byte, []int) {
+	return file_schema_proto_rawDescGZIP(), []int{129}
+}
+
+func (x *JobList) GetJobs() []*Job {
+	if x != nil {
+		return x.Jobs
+	}
+	return nil
+}
+
+type ServerKey struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	KeyId     string `protobuf:"bytes,1,opt,name=keyId,proto3" json:"keyId,omitempty"`
+	PublicKey []byte `protobuf:"bytes,2,opt,name=publicKey,proto3" json:"publicKey,omitempty"`
+	Current   bool   `protobuf:"varint,3,opt,name=current,proto3" json:"current,omitempty"`
+}
+
+func (x *ServerKey) Reset() {
+	*x = ServerKey{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_schema_proto_msgTypes[130]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ServerKey) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ServerKey) ProtoMessage() {}
+
+func (x *ServerKey) ProtoReflect() protoreflect.Message {
+	mi := &file_schema_proto_msgTypes[130]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ServerKey.ProtoReflect.Descriptor instead.
+func (*ServerKey) Descriptor() ([]byte, []int) {
+	return file_schema_proto_rawDescGZIP(), []int{130}
+}
+
+func (x *ServerKey) GetKeyId() string {
+	if x != nil {
+		return x.KeyId
+	}
+	return ""
+}
+
+func (x *ServerKey) GetPublicKey() []byte {
+	if x != nil {
+		return x.PublicKey
+	}
+	return nil
+}
+
+func (x *ServerKey) GetCurrent() bool {
+	if x != nil {
+		return x.Current
+	}
+	return false
+}
+
+type ServerKeys struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Keys []*ServerKey `protobuf:"bytes,1,rep,name=keys,proto3" json:"keys,omitempty"`
+}
+
+func (x *ServerKeys) Reset() {
+	*x = ServerKeys{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_schema_proto_msgTypes[131]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ServerKeys) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ServerKeys) ProtoMessage() {}
+
+func (x *ServerKeys) ProtoReflect() protoreflect.Message {
+	mi := &file_schema_proto_msgTypes[131]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ServerKeys.ProtoReflect.Descriptor instead.
+func (*ServerKeys) Descriptor() ([]byte, []int) {
+	return file_schema_proto_rawDescGZIP(), []int{131}
+}
+
+func (x *ServerKeys) GetKeys() []*ServerKey {
+	if x != nil {
+		return x.Keys
+	}
+	return nil
+}
+
+// ProofBundle is a self-contained proof, verifiable offline against the state it holds
+type ProofBundle struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Version        uint32          `protobuf:"varint,1,opt,name=version,proto3" json:"version,omitempty"`
+	Type           ProofType       `protobuf:"varint,2,opt,name=type,proto3,enum=immudb.schema.ProofType" json:"type,omitempty"`
+	State          *ImmutableState `protobuf:"bytes,3,opt,name=state,proto3" json:"state,omitempty"`
+	SourceState    *ImmutableState `protobuf:"bytes,4,opt,name=sourceState,proto3" json:"sourceState,omitempty"`
+	VerifiableTx   *VerifiableTx   `protobuf:"bytes,5,opt,name=verifiableTx,proto3" json:"verifiableTx,omitempty"`
+	Entry          *Entry          `protobuf:"bytes,6,opt,name=entry,proto3" json:"entry,omitempty"`
+	InclusionProof *InclusionProof `protobuf:"bytes,7,opt,name=inclusionProof,proto3" json:"inclusionProof,omitempty"`
+}
+
+func (x *ProofBundle) Reset() {
+	*x = ProofBundle{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_schema_proto_msgTypes[132]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ProofBundle) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProofBundle) ProtoMessage() {}
+
+func (x *ProofBundle) ProtoReflect() protoreflect.Message {
+	mi := &file_schema_proto_msgTypes[132]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProofBundle.ProtoReflect.Descriptor instead.
+func (*ProofBundle) Descriptor() ([]byte, []int) {
+	return file_schema_proto_rawDescGZIP(), []int{132}
+}
+
+func (x *ProofBundle) GetVersion() uint32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *ProofBundle) GetType() ProofType {
+	if x != nil {
+		return x.Type
+	}
+	return ProofType_TX_INCLUSION
+}
+
+func (x *ProofBundle) GetState() *ImmutableState {
+	if x != nil {
+		return x.State
+	}
+	return nil
+}
+
+func (x *ProofBundle) GetSourceState() *ImmutableState {
+	if x != nil {
+		return x.SourceState
+	}
+	return nil
+}
+
+func (x *ProofBundle) GetVerifiableTx() *VerifiableTx {
+	if x != nil {
+		return x.VerifiableTx
+	}
+	return nil
+}
+
+func (x *ProofBundle) GetEntry() *Entry {
+	if x != nil {
+		return x.Entry
+	}
+	return nil
+}
+
+func (x *ProofBundle) GetInclusionProof() *InclusionProof {
+	if x != nil {
+		return x.InclusionProof
+	}
+	return nil
+}
+
+type DatabaseKVs struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Database string      `protobuf:"bytes,1,opt,name=database,proto3" json:"database,omitempty"`
+	KVs      []*KeyValue `protobuf:"bytes,2,rep,name=KVs,proto3" json:"KVs,omitempty"`
+}
+
+func (x *DatabaseKVs) Reset() {
+	*x = DatabaseKVs{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_schema_proto_msgTypes[133]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DatabaseKVs) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DatabaseKVs) ProtoMessage() {}
+
+func (x *DatabaseKVs) ProtoReflect() protoreflect.Message {
+	mi := &file_schema_proto_msgTypes[133]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DatabaseKVs.ProtoReflect.Descriptor instead.
+func (*DatabaseKVs) Descriptor() ([]byte, []int) {
+	return file_schema_proto_rawDescGZIP(), []int{133}
+}
+
+func (x *DatabaseKVs) GetDatabase() string {
+	if x != nil {
+		return x.Database
+	}
+	return ""
+}
+
+func (x *DatabaseKVs) GetKVs() []*KeyValue {
+	if x != nil {
+		return x.KVs
+	}
+	return nil
+}
+
+type MultiDatabaseSetRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Writes []*DatabaseKVs `protobuf:"bytes,1,rep,name=writes,proto3" json:"writes,omitempty"`
+	NoWait bool           `protobuf:"varint,2,opt,name=noWait,proto3" json:"noWait,omitempty"`
+}
+
+func (x *MultiDatabaseSetRequest) Reset() {
+	*x = MultiDatabaseSetRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_schema_proto_msgTypes[134]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *MultiDatabaseSetRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MultiDatabaseSetRequest) ProtoMessage() {}
+
+func (x *MultiDatabaseSetRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_schema_proto_msgTypes[134]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MultiDatabaseSetRequest.ProtoReflect.Descriptor instead.
+func (*MultiDatabaseSetRequest) Descriptor() ([]byte, []int) {
+	return file_schema_proto_rawDescGZIP(), []int{134}
+}
+
+func (x *MultiDatabaseSetRequest) GetWrites() []*DatabaseKVs {
+	if x != nil {
+		return x.Writes
+	}
+	return nil
+}
+
+func (x *MultiDatabaseSetRequest) GetNoWait() bool {
+	if x != nil {
+		return x.NoWait
+	}
+	return false
+}
+
+type DatabaseTxHeader struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Database string    `protobuf:"bytes,1,opt,name=database,proto3" json:"database,omitempty"`
+	Header   *TxHeader `protobuf:"bytes,2,opt,name=header,proto3" json:"header,omitempty"`
+}
+
+func (x *DatabaseTxHeader) Reset() {
+	*x = DatabaseTxHeader{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_schema_proto_msgTypes[135]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DatabaseTxHeader) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DatabaseTxHeader) ProtoMessage() {}
+
+func (x *DatabaseTxHeader) ProtoReflect() protoreflect.Message {
+	mi := &file_schema_proto_msgTypes[135]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DatabaseTxHeader.ProtoReflect.Descriptor instead.
+func (*DatabaseTxHeader) Descriptor() ([]byte, []int) {
+	return file_schema_proto_rawDescGZIP(), []int{135}
+}
+
+func (x *DatabaseTxHeader) GetDatabase() string {
+	if x != nil {
+		return x.Database
+	}
+	return ""
+}
+
+func (x *DatabaseTxHeader) GetHeader() *TxHeader {
+	if x != nil {
+		return x.Header
+	}
+	return nil
+}
+
+type MultiDatabaseTxHeaders struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	TransactionId string              `protobuf:"bytes,1,opt,name=transactionId,proto3" json:"transactionId,omitempty"`
+	Headers       []*DatabaseTxHeader `protobuf:"bytes,2,rep,name=headers,proto3" json:"headers,omitempty"`
+}
+
+func (x *MultiDatabaseTxHeaders) Reset() {
+	*x = MultiDatabaseTxHeaders{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_schema_proto_msgTypes[136]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *MultiDatabaseTxHeaders) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MultiDatabaseTxHeaders) ProtoMessage() {}
+
+func (x *MultiDatabaseTxHeaders) ProtoReflect() protoreflect.Message {
+	mi := &file_schema_proto_msgTypes[136]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MultiDatabaseTxHeaders.ProtoReflect.Descriptor instead.
+func (*MultiDatabaseTxHeaders) Descriptor() ([]byte, []int) {
+	return file_schema_proto_rawDescGZIP(), []int{136}
+}
+
+func (x *MultiDatabaseTxHeaders) GetTransactionId() string {
+	if x != nil {
+		return x.TransactionId
+	}
+	return ""
+}
+
+func (x *MultiDatabaseTxHeaders) GetHeaders() []*DatabaseTxHeader {
+	if x != nil {
+		return x.Headers
+	}
+	return nil
+}
+
+// MultiDatabaseTx is stored in each database taking part of a multi-database transaction
+type MultiDatabaseTx struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	TransactionId string   `protobuf:"bytes,1,opt,name=transactionId,proto3" json:"transactionId,omitempty"`
+	Databases     []string `protobuf:"bytes,2,rep,name=databases,proto3" json:"databases,omitempty"`
+}
+
+func (x *MultiDatabaseTx) Reset() {
+	*x = MultiDatabaseTx{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_schema_proto_msgTypes[137]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *MultiDatabaseTx) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MultiDatabaseTx) ProtoMessage() {}
+
+func (x *MultiDatabaseTx) ProtoReflect() protoreflect.Message {
+	mi := &file_schema_proto_msgTypes[137]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MultiDatabaseTx.ProtoReflect.Descriptor instead.
+func (*MultiDatabaseTx) Descriptor() ([]byte, []int) {
+	return file_schema_proto_rawDescGZIP(), []int{137}
+}
+
+func (x *MultiDatabaseTx) GetTransactionId() string {
+	if x != nil {
+		return x.TransactionId
+	}
+	return ""
+}
+
+func (x *MultiDatabaseTx) GetDatabases() []string {
+	if x != nil {
+		return x.Databases
+	}
+	return nil
+}
+
+type ErrorInfo struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Code  string `protobuf:"bytes,1,opt,name=code,proto3" json:"code,omitempty"`
+	Cause string `protobuf:"bytes,2,opt,name=cause,proto3" json:"cause,omitempty"`
+}
+
+func (x *ErrorInfo) Reset() {
+	*x = ErrorInfo{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_schema_proto_msgTypes[138]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ErrorInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ErrorInfo) ProtoMessage() {}
+
+func (x *ErrorInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_schema_proto_msgTypes[138]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ErrorInfo.ProtoReflect.Descriptor instead.
+func (*ErrorInfo) Descriptor() ([]byte, []int) {
+	return file_schema_proto_rawDescGZIP(), []int{138}
+}
+
+func (x *ErrorInfo) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+func (x *ErrorInfo) GetCause() string {
+	if x != nil {
+		return x.Cause
+	}
+	return ""
+}
+
+type DebugInfo struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Stack string `protobuf:"bytes,1,opt,name=stack,proto3" json:"stack,omitempty"`
+}
+
+func (x *DebugInfo) Reset() {
+	*x = DebugInfo{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_schema_proto_msgTypes[139]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DebugInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DebugInfo) ProtoMessage() {}
+
+func (x *DebugInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_schema_proto_msgTypes[139]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DebugInfo.ProtoReflect.Descriptor instead.
+func (*DebugInfo) Descriptor() ([]byte, []int) {
+	return file_schema_proto_rawDescGZIP(), []int{139}
+}
+
+func (x *DebugInfo) GetStack() string {
+	if x != nil {
+		return x.Stack
+	}
+	return ""
+}
+
+type RetryInfo struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	RetryDelay int32 `protobuf:"varint,1,opt,name=retry_delay,json=retryDelay,proto3" json:"retry_delay,omitempty"`
+}
+
+func (x *RetryInfo) Reset() {
+	*x = RetryInfo{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_schema_proto_msgTypes[140]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RetryInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RetryInfo) ProtoMessage() {}
+
+func (x *RetryInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_schema_proto_msgTypes[140]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RetryInfo.ProtoReflect.Descriptor instead.
+func (*RetryInfo) Descriptor() ([]byte, []int) {
+	return file_schema_proto_rawDescGZIP(), []int{140}
+}
+
+func (x *RetryInfo) GetRetryDelay() int32 {
+	if x != nil {
+		return x.RetryDelay
+	}
+	return 0
+}
+
+var File_schema_proto protoreflect.FileDescriptor
+
+var file_schema_proto_rawDesc = []byte{
+	0x0a, 0x0c, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x0d,
+	0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x1a, 0x1c, 0x67,
+	0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x61, 0x70, 0x69, 0x2f, 0x61, 0x6e, 0x6e, 0x6f, 0x74, 0x61,
+	0x74, 0x69, 0x6f, 0x6e, 0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x1a, 0x1b, 0x67, 0x6f, 0x6f,
+	0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x65, 0x6d, 0x70,
+	0x74, 0x79, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x1a, 0x2c, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63,
+	0x2d, 0x67, 0x65, 0x6e, 0x2d, 0x73, 0x77, 0x61, 0x67, 0x67, 0x65, 0x72, 0x2f, 0x6f, 0x70, 0x74,
+	0x69, 0x6f, 0x6e, 0x73, 0x2f, 0x61, 0x6e, 0x6e, 0x6f, 0x74, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x1a, 0x1c, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x73, 0x74, 0x72, 0x75, 0x63, 0x74, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x22, 0x17, 0x0a, 0x03, 0x4b, 0x65, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b,
+	0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x22, 0x48, 0x0a,
+	0x0a, 0x50, 0x65, 0x72, 0x6d, 0x69, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x1a, 0x0a, 0x08, 0x64,
+	0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x64,
+	0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x12, 0x1e, 0x0a, 0x0a, 0x70, 0x65, 0x72, 0x6d, 0x69,
+	0x73, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0a, 0x70, 0x65, 0x72,
+	0x6d, 0x69, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x22, 0xab, 0x01, 0x0a, 0x04, 0x55, 0x73, 0x65, 0x72,
+	0x12, 0x12, 0x0a, 0x04, 0x75, 0x73, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04,
+	0x75, 0x73, 0x65, 0x72, 0x12, 0x3b, 0x0a, 0x0b, 0x70, 0x65, 0x72, 0x6d, 0x69, 0x73, 0x73, 0x69,
+	0x6f, 0x6e, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x69, 0x6d, 0x6d, 0x75,
+	0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x50, 0x65, 0x72, 0x6d, 0x69, 0x73,
+	0x73, 0x69, 0x6f, 0x6e, 0x52, 0x0b, 0x70, 0x65, 0x72, 0x6d, 0x69, 0x73, 0x73, 0x69, 0x6f, 0x6e,
+	0x73, 0x12, 0x1c, 0x0a, 0x09, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x64, 0x62, 0x79, 0x18, 0x04,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x64, 0x62, 0x79, 0x12,
+	0x1c, 0x0a, 0x09, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x64, 0x61, 0x74, 0x18, 0x05, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x09, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x64, 0x61, 0x74, 0x12, 0x16, 0x0a,
+	0x06, 0x61, 0x63, 0x74, 0x69, 0x76, 0x65, 0x18, 0x06, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x61,
+	0x63, 0x74, 0x69, 0x76, 0x65, 0x22, 0x35, 0x0a, 0x08, 0x55, 0x73, 0x65, 0x72, 0x4c, 0x69, 0x73,
+	0x74, 0x12, 0x29, 0x0a, 0x05, 0x75, 0x73, 0x65, 0x72, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b,
+	0x32, 0x13, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61,
+	0x2e, 0x55, 0x73, 0x65, 0x72, 0x52, 0x05, 0x75, 0x73, 0x65, 0x72, 0x73, 0x22, 0x7f, 0x0a, 0x11,
+	0x43, 0x72, 0x65, 0x61, 0x74, 0x65, 0x55, 0x73, 0x65, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x12, 0x12, 0x0a, 0x04, 0x75, 0x73, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52,
+	0x04, 0x75, 0x73, 0x65, 0x72, 0x12, 0x1a, 0x0a, 0x08, 0x70, 0x61, 0x73, 0x73, 0x77, 0x6f, 0x72,
+	0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x08, 0x70, 0x61, 0x73, 0x73, 0x77, 0x6f, 0x72,
+	0x64, 0x12, 0x1e, 0x0a, 0x0a, 0x70, 0x65, 0x72, 0x6d, 0x69, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0a, 0x70, 0x65, 0x72, 0x6d, 0x69, 0x73, 0x73, 0x69, 0x6f,
+	0x6e, 0x12, 0x1a, 0x0a, 0x08, 0x64, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x18, 0x04, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x08, 0x64, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x22, 0x21, 0x0a,
+	0x0b, 0x55, 0x73, 0x65, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x12, 0x0a, 0x04,
+	0x75, 0x73, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x75, 0x73, 0x65, 0x72,
+	0x22, 0x6f, 0x0a, 0x15, 0x43, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x50, 0x61, 0x73, 0x73, 0x77, 0x6f,
+	0x72, 0x64, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x75, 0x73, 0x65,
+	0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x75, 0x73, 0x65, 0x72, 0x12, 0x20, 0x0a,
+	0x0b, 0x6f, 0x6c, 0x64, 0x50, 0x61, 0x73, 0x73, 0x77, 0x6f, 0x72, 0x64, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x0c, 0x52, 0x0b, 0x6f, 0x6c, 0x64, 0x50, 0x61, 0x73, 0x73, 0x77, 0x6f, 0x72, 0x64, 0x12,
+	0x20, 0x0a, 0x0b, 0x6e, 0x65, 0x77, 0x50, 0x61, 0x73, 0x73, 0x77, 0x6f, 0x72, 0x64, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x0c, 0x52, 0x0b, 0x6e, 0x65, 0x77, 0x50, 0x61, 0x73, 0x73, 0x77, 0x6f, 0x72,
+	0x64, 0x22, 0x3e, 0x0a, 0x0c, 0x4c, 0x6f, 0x67, 0x69, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x12, 0x12, 0x0a, 0x04, 0x75, 0x73, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52,
+	0x04, 0x75, 0x73, 0x65, 0x72, 0x12, 0x1a, 0x0a, 0x08, 0x70, 0x61, 0x73, 0x73, 0x77, 0x6f, 0x72,
+	0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x08, 0x70, 0x61, 0x73, 0x73, 0x77, 0x6f, 0x72,
+	0x64, 0x22, 0x3f, 0x0a, 0x0d, 0x4c, 0x6f, 0x67, 0x69, 0x6e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
+	0x73, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x05, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x12, 0x18, 0x0a, 0x07, 0x77, 0x61, 0x72, 0x6e,
+	0x69, 0x6e, 0x67, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x07, 0x77, 0x61, 0x72, 0x6e, 0x69,
+	0x6e, 0x67, 0x22, 0x20, 0x0a, 0x0a, 0x41, 0x75, 0x74, 0x68, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67,
+	0x12, 0x12, 0x0a, 0x04, 0x6b, 0x69, 0x6e, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x04,
+	0x6b, 0x69, 0x6e, 0x64, 0x22, 0x26, 0x0a, 0x0a, 0x4d, 0x54, 0x4c, 0x53, 0x43, 0x6f, 0x6e, 0x66,
+	0x69, 0x67, 0x12, 0x18, 0x0a, 0x07, 0x65, 0x6e, 0x61, 0x62, 0x6c, 0x65, 0x64, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x08, 0x52, 0x07, 0x65, 0x6e, 0x61, 0x62, 0x6c, 0x65, 0x64, 0x22, 0xe4, 0x02, 0x0a,
+	0x12, 0x4f, 0x70, 0x65, 0x6e, 0x53, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x12, 0x1a, 0x0a, 0x08, 0x75, 0x73, 0x65, 0x72, 0x6e, 0x61, 0x6d, 0x65, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x08, 0x75, 0x73, 0x65, 0x72, 0x6e, 0x61, 0x6d, 0x65, 0x12,
+	0x1a, 0x0a, 0x08, 0x70, 0x61, 0x73, 0x73, 0x77, 0x6f, 0x72, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x0c, 0x52, 0x08, 0x70, 0x61, 0x73, 0x73, 0x77, 0x6f, 0x72, 0x64, 0x12, 0x22, 0x0a, 0x0c, 0x64,
+	0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x4e, 0x61, 0x6d, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x0c, 0x64, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x4e, 0x61, 0x6d, 0x65, 0x12,
+	0x26, 0x0a, 0x0e, 0x72, 0x65, 0x61, 0x64, 0x59, 0x6f, 0x75, 0x72, 0x57, 0x72, 0x69, 0x74, 0x65,
+	0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0e, 0x72, 0x65, 0x61, 0x64, 0x59, 0x6f, 0x75,
+	0x72, 0x57, 0x72, 0x69, 0x74, 0x65, 0x73, 0x12, 0x34, 0x0a, 0x15, 0x72, 0x65, 0x61, 0x64, 0x59,
+	0x6f, 0x75, 0x72, 0x57, 0x72, 0x69, 0x74, 0x65, 0x73, 0x54, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74,
+	0x18, 0x05, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x15, 0x72, 0x65, 0x61, 0x64, 0x59, 0x6f, 0x75, 0x72,
+	0x57, 0x72, 0x69, 0x74, 0x65, 0x73, 0x54, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x12, 0x54, 0x0a,
+	0x0b, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x56, 0x61, 0x72, 0x73, 0x18, 0x06, 0x20, 0x03,
+	0x28, 0x0b, 0x32, 0x32, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65,
+	0x6d, 0x61, 0x2e, 0x4f, 0x70, 0x65, 0x6e, 0x53, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x2e, 0x53, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x56, 0x61, 0x72,
+	0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x0b, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x56,
+	0x61, 0x72, 0x73, 0x1a, 0x3e, 0x0a, 0x10, 0x53, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x56, 0x61,
+	0x72, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c,
+	0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a,
+	0x02, 0x38, 0x01, 0x22, 0x53, 0x0a, 0x13, 0x4f, 0x70, 0x65, 0x6e, 0x53, 0x65, 0x73, 0x73, 0x69,
+	0x6f, 0x6e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x1c, 0x0a, 0x09, 0x73, 0x65,
+	0x73, 0x73, 0x69, 0x6f, 0x6e, 0x49, 0x44, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x73,
+	0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x49, 0x44, 0x12, 0x1e, 0x0a, 0x0a, 0x73, 0x65, 0x72, 0x76,
+	0x65, 0x72, 0x55, 0x55, 0x49, 0x44, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x73, 0x65,
+	0x72, 0x76, 0x65, 0x72, 0x55, 0x55, 0x49, 0x44, 0x22, 0x69, 0x0a, 0x08, 0x4b, 0x65, 0x79, 0x56,
+	0x61, 0x6c, 0x75, 0x65, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x0c, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x12, 0x35, 0x0a, 0x08,
+	0x6d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19,
+	0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x4b,
+	0x56, 0x4d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x52, 0x08, 0x6d, 0x65, 0x74, 0x61, 0x64,
+	0x61, 0x74, 0x61, 0x22, 0xce, 0x01, 0x0a, 0x05, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x0e, 0x0a,
+	0x02, 0x74, 0x78, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x02, 0x74, 0x78, 0x12, 0x10, 0x0a,
+	0x03, 0x6b, 0x65, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12,
+	0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x05,
+	0x76, 0x61, 0x6c, 0x75, 0x65, 0x12, 0x3c, 0x0a, 0x0c, 0x72, 0x65, 0x66, 0x65, 0x72, 0x65, 0x6e,
+	0x63, 0x65, 0x64, 0x42, 0x79, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x69, 0x6d,
+	0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x52, 0x65, 0x66, 0x65,
+	0x72, 0x65, 0x6e, 0x63, 0x65, 0x52, 0x0c, 0x72, 0x65, 0x66, 0x65, 0x72, 0x65, 0x6e, 0x63, 0x65,
+	0x64, 0x42, 0x79, 0x12, 0x35, 0x0a, 0x08, 0x6d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x18,
+	0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73,
+	0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x4b, 0x56, 0x4d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61,
+	0x52, 0x08, 0x6d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x12, 0x18, 0x0a, 0x07, 0x65, 0x78,
+	0x70, 0x69, 0x72, 0x65, 0x64, 0x18, 0x06, 0x20, 0x01, 0x28, 0x08, 0x52, 0x07, 0x65, 0x78, 0x70,
+	0x69, 0x72, 0x65, 0x64, 0x22, 0x78, 0x0a, 0x09, 0x52, 0x65, 0x66, 0x65, 0x72, 0x65, 0x6e, 0x63,
+	0x65, 0x12, 0x0e, 0x0a, 0x02, 0x74, 0x78, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x02, 0x74,
+	0x78, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x03,
+	0x6b, 0x65, 0x79, 0x12, 0x12, 0x0a, 0x04, 0x61, 0x74, 0x54, 0x78, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x04, 0x52, 0x04, 0x61, 0x74, 0x54, 0x78, 0x12, 0x35, 0x0a, 0x08, 0x6d, 0x65, 0x74, 0x61, 0x64,
+	0x61, 0x74, 0x61, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x69, 0x6d, 0x6d, 0x75,
+	0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x4b, 0x56, 0x4d, 0x65, 0x74, 0x61,
+	0x64, 0x61, 0x74, 0x61, 0x52, 0x08, 0x6d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x22, 0xa3,
+	0x01, 0x0a, 0x02, 0x4f, 0x70, 0x12, 0x29, 0x0a, 0x02, 0x6b, 0x76, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x17, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d,
+	0x61, 0x2e, 0x4b, 0x65, 0x79, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x48, 0x00, 0x52, 0x02, 0x6b, 0x76,
+	0x12, 0x30, 0x0a, 0x04, 0x7a, 0x41, 0x64, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a,
+	0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x5a,
+	0x41, 0x64, 0x64, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x48, 0x00, 0x52, 0x04, 0x7a, 0x41,
+	0x64, 0x64, 0x12, 0x33, 0x0a, 0x03, 0x72, 0x65, 0x66, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x1f, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e,
+	0x52, 0x65, 0x66, 0x65, 0x72, 0x65, 0x6e, 0x63, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x48, 0x00, 0x52, 0x03, 0x72, 0x65, 0x66, 0x42, 0x0b, 0x0a, 0x09, 0x6f, 0x70, 0x65, 0x72, 0x61,
+	0x74, 0x69, 0x6f, 0x6e, 0x22, 0x5b, 0x0a, 0x0e, 0x45, 0x78, 0x65, 0x63, 0x41, 0x6c, 0x6c, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x31, 0x0a, 0x0a, 0x4f, 0x70, 0x65, 0x72, 0x61, 0x74,
+	0x69, 0x6f, 0x6e, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x69, 0x6d, 0x6d,
+	0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x4f, 0x70, 0x52, 0x0a, 0x4f,
+	0x70, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x16, 0x0a, 0x06, 0x6e, 0x6f, 0x57,
+	0x61, 0x69, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x6e, 0x6f, 0x57, 0x61, 0x69,
+	0x74, 0x22, 0x76, 0x0a, 0x11, 0x45, 0x78, 0x65, 0x63, 0x53, 0x63, 0x72, 0x69, 0x70, 0x74, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x12, 0x31,
+	0x0a, 0x06, 0x70, 0x61, 0x72, 0x61, 0x6d, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x19,
+	0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x4e,
+	0x61, 0x6d, 0x65, 0x64, 0x50, 0x61, 0x72, 0x61, 0x6d, 0x52, 0x06, 0x70, 0x61, 0x72, 0x61, 0x6d,
+	0x73, 0x12, 0x16, 0x0a, 0x06, 0x6e, 0x6f, 0x57, 0x61, 0x69, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x08, 0x52, 0x06, 0x6e, 0x6f, 0x57, 0x61, 0x69, 0x74, 0x22, 0x78, 0x0a, 0x10, 0x45, 0x78, 0x65,
+	0x63, 0x53, 0x63, 0x72, 0x69, 0x70, 0x74, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x12, 0x33, 0x0a,
+	0x08, 0x74, 0x78, 0x48, 0x65, 0x61, 0x64, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x17, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e,
+	0x54, 0x78, 0x48, 0x65, 0x61, 0x64, 0x65, 0x72, 0x52, 0x08, 0x74, 0x78, 0x48, 0x65, 0x61, 0x64,
+	0x65, 0x72, 0x12, 0x2f, 0x0a, 0x06, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x17, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65,
+	0x6d, 0x61, 0x2e, 0x53, 0x51, 0x4c, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x52, 0x06, 0x72, 0x65, 0x73,
+	0x75, 0x6c, 0x74, 0x22, 0x6d, 0x0a, 0x07, 0x45, 0x6e, 0x74, 0x72, 0x69, 0x65, 0x73, 0x12, 0x2e,
+	0x0a, 0x07, 0x65, 0x6e, 0x74, 0x72, 0x69, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32,
+	0x14, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e,
+	0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x07, 0x65, 0x6e, 0x74, 0x72, 0x69, 0x65, 0x73, 0x12, 0x32,
+	0x0a, 0x07, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32,
+	0x18, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e,
+	0x4b, 0x65, 0x79, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x52, 0x07, 0x72, 0x65, 0x73, 0x75, 0x6c,
+	0x74, 0x73, 0x22, 0x7b, 0x0a, 0x09, 0x4b, 0x65, 0x79, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x12,
+	0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x03, 0x6b, 0x65,
+	0x79, 0x12, 0x30, 0x0a, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x0e, 0x32, 0x18, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d,
+	0x61, 0x2e, 0x4b, 0x65, 0x79, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x06, 0x73, 0x74, 0x61,
+	0x74, 0x75, 0x73, 0x12, 0x2a, 0x0a, 0x05, 0x65, 0x6e, 0x74, 0x72, 0x79, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x14, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65,
+	0x6d, 0x61, 0x2e, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x05, 0x65, 0x6e, 0x74, 0x72, 0x79, 0x22,
+	0x82, 0x01, 0x0a, 0x06, 0x5a, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x73, 0x65,
+	0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x03, 0x73, 0x65, 0x74, 0x12, 0x10, 0x0a, 0x03,
+	0x6b, 0x65, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x2a,
+	0x0a, 0x05, 0x65, 0x6e, 0x74, 0x72, 0x79, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x14, 0x2e,
+	0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x45, 0x6e,
+	0x74, 0x72, 0x79, 0x52, 0x05, 0x65, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x73, 0x63,
+	0x6f, 0x72, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x01, 0x52, 0x05, 0x73, 0x63, 0x6f, 0x72, 0x65,
+	0x12, 0x12, 0x0a, 0x04, 0x61, 0x74, 0x54, 0x78, 0x18, 0x05, 0x20, 0x01, 0x28, 0x04, 0x52, 0x04,
+	0x61, 0x74, 0x54, 0x78, 0x22, 0x3b, 0x0a, 0x08, 0x5a, 0x45, 0x6e, 0x74, 0x72, 0x69, 0x65, 0x73,
+	0x12, 0x2f, 0x0a, 0x07, 0x65, 0x6e, 0x74, 0x72, 0x69, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28,
+	0x0b, 0x32, 0x15, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d,
+	0x61, 0x2e, 0x5a, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x07, 0x65, 0x6e, 0x74, 0x72, 0x69, 0x65,
+	0x73, 0x22, 0x9b, 0x01, 0x0a, 0x0b, 0x53, 0x63, 0x61, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x12, 0x18, 0x0a, 0x07, 0x73, 0x65, 0x65, 0x6b, 0x4b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x0c, 0x52, 0x07, 0x73, 0x65, 0x65, 0x6b, 0x4b, 0x65, 0x79, 0x12, 0x16, 0x0a, 0x06, 0x70,
+	0x72, 0x65, 0x66, 0x69, 0x78, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x06, 0x70, 0x72, 0x65,
+	0x66, 0x69, 0x78, 0x12, 0x12, 0x0a, 0x04, 0x64, 0x65, 0x73, 0x63, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x08, 0x52, 0x04, 0x64, 0x65, 0x73, 0x63, 0x12, 0x14, 0x0a, 0x05, 0x6c, 0x69, 0x6d, 0x69, 0x74,
+	0x18, 0x04, 0x20, 0x01, 0x28, 0x04, 0x52, 0x05, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x12, 0x18, 0x0a,
+	0x07, 0x73, 0x69, 0x6e, 0x63, 0x65, 0x54, 0x78, 0x18, 0x05, 0x20, 0x01, 0x28, 0x04, 0x52, 0x07,
+	0x73, 0x69, 0x6e, 0x63, 0x65, 0x54, 0x78, 0x12, 0x16, 0x0a, 0x06, 0x6e, 0x6f, 0x57, 0x61, 0x69,
+	0x74, 0x18, 0x06, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x6e, 0x6f, 0x57, 0x61, 0x69, 0x74, 0x22,
+	0x23, 0x0a, 0x09, 0x4b, 0x65, 0x79, 0x50, 0x72, 0x65, 0x66, 0x69, 0x78, 0x12, 0x16, 0x0a, 0x06,
+	0x70, 0x72, 0x65, 0x66, 0x69, 0x78, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x06, 0x70, 0x72,
+	0x65, 0x66, 0x69, 0x78, 0x22, 0x22, 0x0a, 0x0a, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x43, 0x6f, 0x75,
+	0x6e, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x04, 0x52, 0x05, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x22, 0x5d, 0x0a, 0x09, 0x53, 0x69, 0x67, 0x6e,
+	0x61, 0x74, 0x75, 0x72, 0x65, 0x12, 0x1c, 0x0a, 0x09, 0x70, 0x75, 0x62, 0x6c, 0x69, 0x63, 0x4b,
+	0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x09, 0x70, 0x75, 0x62, 0x6c, 0x69, 0x63,
+	0x4b, 0x65, 0x79, 0x12, 0x1c, 0x0a, 0x09, 0x73, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x09, 0x73, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72,
+	0x65, 0x12, 0x14, 0x0a, 0x05, 0x6b, 0x65, 0x79, 0x49, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x05, 0x6b, 0x65, 0x79, 0x49, 0x64, 0x22, 0xf1, 0x01, 0x0a, 0x08, 0x54, 0x78, 0x48, 0x65,
+	0x61, 0x64, 0x65, 0x72, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04,
+	0x52, 0x02, 0x69, 0x64, 0x12, 0x18, 0x0a, 0x07, 0x70, 0x72, 0x65, 0x76, 0x41, 0x6c, 0x68, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x07, 0x70, 0x72, 0x65, 0x76, 0x41, 0x6c, 0x68, 0x12, 0x0e,
+	0x0a, 0x02, 0x74, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x02, 0x74, 0x73, 0x12, 0x1a,
+	0x0a, 0x08, 0x6e, 0x65, 0x6e, 0x74, 0x72, 0x69, 0x65, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x05,
+	0x52, 0x08, 0x6e, 0x65, 0x6e, 0x74, 0x72, 0x69, 0x65, 0x73, 0x12, 0x0e, 0x0a, 0x02, 0x65, 0x48,
+	0x18, 0x05, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x02, 0x65, 0x48, 0x12, 0x16, 0x0a, 0x06, 0x62, 0x6c,
+	0x54, 0x78, 0x49, 0x64, 0x18, 0x06, 0x20, 0x01, 0x28, 0x04, 0x52, 0x06, 0x62, 0x6c, 0x54, 0x78,
+	0x49, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x62, 0x6c, 0x52, 0x6f, 0x6f, 0x74, 0x18, 0x07, 0x20, 0x01,
+	0x28, 0x0c, 0x52, 0x06, 0x62, 0x6c, 0x52, 0x6f, 0x6f, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x76, 0x65,
+	0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x08, 0x20, 0x01, 0x28, 0x05, 0x52, 0x07, 0x76, 0x65, 0x72,
+	0x73, 0x69, 0x6f, 0x6e, 0x12, 0x35, 0x0a, 0x08, 0x6d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61,
+	0x18, 0x09, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e,
+	0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x54, 0x78, 0x4d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74,
+	0x61, 0x52, 0x08, 0x6d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x22, 0x20, 0x0a, 0x0a, 0x54,
+	0x78, 0x4d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x12, 0x12, 0x0a, 0x04, 0x75, 0x73, 0x65,
+	0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x75, 0x73, 0x65, 0x72, 0x22, 0x63, 0x0a,
+	0x0b, 0x4c, 0x69, 0x6e, 0x65, 0x61, 0x72, 0x50, 0x72, 0x6f, 0x6f, 0x66, 0x12, 0x1e, 0x0a, 0x0a,
+	0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x54, 0x78, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04,
+	0x52, 0x0a, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x54, 0x78, 0x49, 0x64, 0x12, 0x1e, 0x0a, 0x0a,
+	0x54, 0x61, 0x72, 0x67, 0x65, 0x74, 0x54, 0x78, 0x49, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04,
+	0x52, 0x0a, 0x54, 0x61, 0x72, 0x67, 0x65, 0x74, 0x54, 0x78, 0x49, 0x64, 0x12, 0x14, 0x0a, 0x05,
+	0x74, 0x65, 0x72, 0x6d, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0c, 0x52, 0x05, 0x74, 0x65, 0x72,
+	0x6d, 0x73, 0x22, 0xf5, 0x02, 0x0a, 0x09, 0x44, 0x75, 0x61, 0x6c, 0x50, 0x72, 0x6f, 0x6f, 0x66,
+	0x12, 0x3f, 0x0a, 0x0e, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x54, 0x78, 0x48, 0x65, 0x61, 0x64,
+	0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64,
+	0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x54, 0x78, 0x48, 0x65, 0x61, 0x64, 0x65,
+	0x72, 0x52, 0x0e, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x54, 0x78, 0x48, 0x65, 0x61, 0x64, 0x65,
+	0x72, 0x12, 0x3f, 0x0a, 0x0e, 0x74, 0x61, 0x72, 0x67, 0x65, 0x74, 0x54, 0x78, 0x48, 0x65, 0x61,
+	0x64, 0x65, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x69, 0x6d, 0x6d, 0x75,
+	0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x54, 0x78, 0x48, 0x65, 0x61, 0x64,
+	0x65, 0x72, 0x52, 0x0e, 0x74, 0x61, 0x72, 0x67, 0x65, 0x74, 0x54, 0x78, 0x48, 0x65, 0x61, 0x64,
+	0x65, 0x72, 0x12, 0x26, 0x0a, 0x0e, 0x69, 0x6e, 0x63, 0x6c, 0x75, 0x73, 0x69, 0x6f, 0x6e, 0x50,
+	0x72, 0x6f, 0x6f, 0x66, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0c, 0x52, 0x0e, 0x69, 0x6e, 0x63, 0x6c,
+	0x75, 0x73, 0x69, 0x6f, 0x6e, 0x50, 0x72, 0x6f, 0x6f, 0x66, 0x12, 0x2a, 0x0a, 0x10, 0x63, 0x6f,
+	0x6e, 0x73, 0x69, 0x73, 0x74, 0x65, 0x6e, 0x63, 0x79, 0x50, 0x72, 0x6f, 0x6f, 0x66, 0x18, 0x04,
+	0x20, 0x03, 0x28, 0x0c, 0x52, 0x10, 0x63, 0x6f, 0x6e, 0x73, 0x69, 0x73, 0x74, 0x65, 0x6e, 0x63,
+	0x79, 0x50, 0x72, 0x6f, 0x6f, 0x66, 0x12, 0x24, 0x0a, 0x0d, 0x74, 0x61, 0x72, 0x67, 0x65, 0x74,
+	0x42, 0x6c, 0x54, 0x78, 0x41, 0x6c, 0x68, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0d, 0x74,
+	0x61, 0x72, 0x67, 0x65, 0x74, 0x42, 0x6c, 0x54, 0x78, 0x41, 0x6c, 0x68, 0x12, 0x2e, 0x0a, 0x12,
+	0x6c, 0x61, 0x73, 0x74, 0x49, 0x6e, 0x63, 0x6c, 0x75, 0x73, 0x69, 0x6f, 0x6e, 0x50, 0x72, 0x6f,
+	0x6f, 0x66, 0x18, 0x06, 0x20, 0x03, 0x28, 0x0c, 0x52, 0x12, 0x6c, 0x61, 0x73, 0x74, 0x49, 0x6e,
+	0x63, 0x6c, 0x75, 0x73, 0x69, 0x6f, 0x6e, 0x50, 0x72, 0x6f, 0x6f, 0x66, 0x12, 0x3c, 0x0a, 0x0b,
+	0x6c, 0x69, 0x6e, 0x65, 0x61, 0x72, 0x50, 0x72, 0x6f, 0x6f, 0x66, 0x18, 0x07, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x1a, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d,
+	0x61, 0x2e, 0x4c, 0x69, 0x6e, 0x65, 0x61, 0x72, 0x50, 0x72, 0x6f, 0x6f, 0x66, 0x52, 0x0b, 0x6c,
+	0x69, 0x6e, 0x65, 0x61, 0x72, 0x50, 0x72, 0x6f, 0x6f, 0x66, 0x22, 0xce, 0x01, 0x0a, 0x02, 0x54,
+	0x78, 0x12, 0x2f, 0x0a, 0x06, 0x68, 0x65, 0x61, 0x64, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x17, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d,
+	0x61, 0x2e, 0x54, 0x78, 0x48, 0x65, 0x61, 0x64, 0x65, 0x72, 0x52, 0x06, 0x68, 0x65, 0x61, 0x64,
+	0x65, 0x72, 0x12, 0x30, 0x0a, 0x07, 0x65, 0x6e, 0x74, 0x72, 0x69, 0x65, 0x73, 0x18, 0x02, 0x20,
+	0x03, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68,
+	0x65, 0x6d, 0x61, 0x2e, 0x54, 0x78, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x07, 0x65, 0x6e, 0x74,
+	0x72, 0x69, 0x65, 0x73, 0x12, 0x32, 0x0a, 0x09, 0x6b, 0x76, 0x45, 0x6e, 0x74, 0x72, 0x69, 0x65,
+	0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62,
+	0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x09, 0x6b,
+	0x76, 0x45, 0x6e, 0x74, 0x72, 0x69, 0x65, 0x73, 0x12, 0x31, 0x0a, 0x08, 0x7a, 0x45, 0x6e, 0x74,
+	0x72, 0x69, 0x65, 0x73, 0x18, 0x04, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x69, 0x6d, 0x6d,
+	0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x5a, 0x45, 0x6e, 0x74, 0x72,
+	0x79, 0x52, 0x08, 0x7a, 0x45, 0x6e, 0x74, 0x72, 0x69, 0x65, 0x73, 0x22, 0x94, 0x01, 0x0a, 0x07,
+	0x54, 0x78, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x0c, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x16, 0x0a, 0x06, 0x68, 0x56, 0x61,
+	0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x06, 0x68, 0x56, 0x61, 0x6c, 0x75,
+	0x65, 0x12, 0x12, 0x0a, 0x04, 0x76, 0x4c, 0x65, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52,
+	0x04, 0x76, 0x4c, 0x65, 0x6e, 0x12, 0x35, 0x0a, 0x08, 0x6d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74,
+	0x61, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62,
+	0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x4b, 0x56, 0x4d, 0x65, 0x74, 0x61, 0x64, 0x61,
+	0x74, 0x61, 0x52, 0x08, 0x6d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x12, 0x14, 0x0a, 0x05,
+	0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x05, 0x76, 0x61, 0x6c,
+	0x75, 0x65, 0x22, 0xa7, 0x01, 0x0a, 0x0a, 0x4b, 0x56, 0x4d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74,
+	0x61, 0x12, 0x18, 0x0a, 0x07, 0x64, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x64, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x08, 0x52, 0x07, 0x64, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x64, 0x12, 0x39, 0x0a, 0x0a, 0x65,
+	0x78, 0x70, 0x69, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x19, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e,
+	0x45, 0x78, 0x70, 0x69, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x0a, 0x65, 0x78, 0x70, 0x69,
+	0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x22, 0x0a, 0x0c, 0x6e, 0x6f, 0x6e, 0x49, 0x6e, 0x64,
+	0x65, 0x78, 0x61, 0x62, 0x6c, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0c, 0x6e, 0x6f,
+	0x6e, 0x49, 0x6e, 0x64, 0x65, 0x78, 0x61, 0x62, 0x6c, 0x65, 0x12, 0x20, 0x0a, 0x0b, 0x63, 0x6f,
+	0x6d, 0x70, 0x72, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x04, 0x20, 0x01, 0x28, 0x05, 0x52,
+	0x0b, 0x63, 0x6f, 0x6d, 0x70, 0x72, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x22, 0x2a, 0x0a, 0x0a,
+	0x45, 0x78, 0x70, 0x69, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x1c, 0x0a, 0x09, 0x65, 0x78,
+	0x70, 0x69, 0x72, 0x65, 0x73, 0x41, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x65,
+	0x78, 0x70, 0x69, 0x72, 0x65, 0x73, 0x41, 0x74, 0x22, 0xa1, 0x01, 0x0a, 0x0c, 0x56, 0x65, 0x72,
+	0x69, 0x66, 0x69, 0x61, 0x62, 0x6c, 0x65, 0x54, 0x78, 0x12, 0x21, 0x0a, 0x02, 0x74, 0x78, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73,
+	0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x54, 0x78, 0x52, 0x02, 0x74, 0x78, 0x12, 0x36, 0x0a, 0x09,
+	0x64, 0x75, 0x61, 0x6c, 0x50, 0x72, 0x6f, 0x6f, 0x66, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x18, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e,
+	0x44, 0x75, 0x61, 0x6c, 0x50, 0x72, 0x6f, 0x6f, 0x66, 0x52, 0x09, 0x64, 0x75, 0x61, 0x6c, 0x50,
+	0x72, 0x6f, 0x6f, 0x66, 0x12, 0x36, 0x0a, 0x09, 0x73, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72,
+	0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62,
+	0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x53, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72,
+	0x65, 0x52, 0x09, 0x73, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x22, 0xc5, 0x01, 0x0a,
+	0x0f, 0x56, 0x65, 0x72, 0x69, 0x66, 0x69, 0x61, 0x62, 0x6c, 0x65, 0x45, 0x6e, 0x74, 0x72, 0x79,
+	0x12, 0x2a, 0x0a, 0x05, 0x65, 0x6e, 0x74, 0x72, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x14, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e,
+	0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x05, 0x65, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x3f, 0x0a, 0x0c,
+	0x76, 0x65, 0x72, 0x69, 0x66, 0x69, 0x61, 0x62, 0x6c, 0x65, 0x54, 0x78, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x1b, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65,
+	0x6d, 0x61, 0x2e, 0x56, 0x65, 0x72, 0x69, 0x66, 0x69, 0x61, 0x62, 0x6c, 0x65, 0x54, 0x78, 0x52,
+	0x0c, 0x76, 0x65, 0x72, 0x69, 0x66, 0x69, 0x61, 0x62, 0x6c, 0x65, 0x54, 0x78, 0x12, 0x45, 0x0a,
+	0x0e, 0x69, 0x6e, 0x63, 0x6c, 0x75, 0x73, 0x69, 0x6f, 0x6e, 0x50, 0x72, 0x6f, 0x6f, 0x66, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1d, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73,
+	0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x49, 0x6e, 0x63, 0x6c, 0x75, 0x73, 0x69, 0x6f, 0x6e, 0x50,
+	0x72, 0x6f, 0x6f, 0x66, 0x52, 0x0e, 0x69, 0x6e, 0x63, 0x6c, 0x75, 0x73, 0x69, 0x6f, 0x6e, 0x50,
+	0x72, 0x6f, 0x6f, 0x66, 0x22, 0x50, 0x0a, 0x0e, 0x49, 0x6e, 0x63, 0x6c, 0x75, 0x73, 0x69, 0x6f,
+	0x6e, 0x50, 0x72, 0x6f, 0x6f, 0x66, 0x12, 0x12, 0x0a, 0x04, 0x6c, 0x65, 0x61, 0x66, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x05, 0x52, 0x04, 0x6c, 0x65, 0x61, 0x66, 0x12, 0x14, 0x0a, 0x05, 0x77, 0x69,
+	0x64, 0x74, 0x68, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x77, 0x69, 0x64, 0x74, 0x68,
+	0x12, 0x14, 0x0a, 0x05, 0x74, 0x65, 0x72, 0x6d, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0c, 0x52,
+	0x05, 0x74, 0x65, 0x72, 0x6d, 0x73, 0x22, 0x4f, 0x0a, 0x0a, 0x53, 0x65, 0x74, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x12, 0x29, 0x0a, 0x03, 0x4b, 0x56, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28,
+	0x0b, 0x32, 0x17, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d,
+	0x61, 0x2e, 0x4b, 0x65, 0x79, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x52, 0x03, 0x4b, 0x56, 0x73, 0x12,
+	0x16, 0x0a, 0x06, 0x6e, 0x6f, 0x57, 0x61, 0x69, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52,
+	0x06, 0x6e, 0x6f, 0x57, 0x61, 0x69, 0x74, 0x22, 0x64, 0x0a, 0x0a, 0x4b, 0x65, 0x79, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x0c, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x12, 0x0a, 0x04, 0x61, 0x74, 0x54, 0x78, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x04, 0x61, 0x74, 0x54, 0x78, 0x12, 0x18, 0x0a, 0x07, 0x73,
+	0x69, 0x6e, 0x63, 0x65, 0x54, 0x78, 0x18, 0x03, 0x20, 0x01, 0x28, 0x04, 0x52, 0x07, 0x73, 0x69,
+	0x6e, 0x63, 0x65, 0x54, 0x78, 0x12, 0x16, 0x0a, 0x06, 0x6e, 0x6f, 0x57, 0x61, 0x69, 0x74, 0x18,
+	0x04, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x6e, 0x6f, 0x57, 0x61, 0x69, 0x74, 0x22, 0x90, 0x01,
+	0x0a, 0x0e, 0x4b, 0x65, 0x79, 0x4c, 0x69, 0x73, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x12, 0x12, 0x0a, 0x04, 0x6b, 0x65, 0x79, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0c, 0x52, 0x04,
+	0x6b, 0x65, 0x79, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x73, 0x69, 0x6e, 0x63, 0x65, 0x54, 0x78, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x07, 0x73, 0x69, 0x6e, 0x63, 0x65, 0x54, 0x78, 0x12, 0x22,
+	0x0a, 0x0c, 0x77, 0x69, 0x74, 0x68, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x65, 0x73, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x08, 0x52, 0x0c, 0x77, 0x69, 0x74, 0x68, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73,
+	0x65, 0x73, 0x12, 0x2c, 0x0a, 0x11, 0x66, 0x61, 0x69, 0x6c, 0x4f, 0x6e, 0x4d, 0x69, 0x73, 0x73,
+	0x69, 0x6e, 0x67, 0x4b, 0x65, 0x79, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x08, 0x52, 0x11, 0x66,
+	0x61, 0x69, 0x6c, 0x4f, 0x6e, 0x4d, 0x69, 0x73, 0x73, 0x69, 0x6e, 0x67, 0x4b, 0x65, 0x79, 0x73,
+	0x22, 0x59, 0x0a, 0x11, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x4b, 0x65, 0x79, 0x73, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x6b, 0x65, 0x79, 0x73, 0x18, 0x01, 0x20,
+	0x03, 0x28, 0x0c, 0x52, 0x04, 0x6b, 0x65, 0x79, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x73, 0x69, 0x6e,
+	0x63, 0x65, 0x54, 0x78, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x07, 0x73, 0x69, 0x6e, 0x63,
+	0x65, 0x54, 0x78, 0x12, 0x16, 0x0a, 0x06, 0x6e, 0x6f, 0x57, 0x61, 0x69, 0x74, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x08, 0x52, 0x06, 0x6e, 0x6f, 0x57, 0x61, 0x69, 0x74, 0x22, 0x75, 0x0a, 0x14, 0x56,
+	0x65, 0x72, 0x69, 0x66, 0x69, 0x61, 0x62, 0x6c, 0x65, 0x53, 0x65, 0x74, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x12, 0x39, 0x0a, 0x0a, 0x73, 0x65, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62,
+	0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x53, 0x65, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x52, 0x0a, 0x73, 0x65, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x22,
+	0x0a, 0x0c, 0x70, 0x72, 0x6f, 0x76, 0x65, 0x53, 0x69, 0x6e, 0x63, 0x65, 0x54, 0x78, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x04, 0x52, 0x0c, 0x70, 0x72, 0x6f, 0x76, 0x65, 0x53, 0x69, 0x6e, 0x63, 0x65,
+	0x54, 0x78, 0x22, 0x75, 0x0a, 0x14, 0x56, 0x65, 0x72, 0x69, 0x66, 0x69, 0x61, 0x62, 0x6c, 0x65,
+	0x47, 0x65, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x39, 0x0a, 0x0a, 0x6b, 0x65,
+	0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19,
+	0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x4b,
+	0x65, 0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x52, 0x0a, 0x6b, 0x65, 0x79, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x22, 0x0a, 0x0c, 0x70, 0x72, 0x6f, 0x76, 0x65, 0x53, 0x69,
+	0x6e, 0x63, 0x65, 0x54, 0x78, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0c, 0x70, 0x72, 0x6f,
+	0x76, 0x65, 0x53, 0x69, 0x6e, 0x63, 0x65, 0x54, 0x78, 0x22, 0x42, 0x0a, 0x0e, 0x48, 0x65, 0x61,
+	0x6c, 0x74, 0x68, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x73,
+	0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x73, 0x74, 0x61,
+	0x74, 0x75, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x22, 0x13, 0x0a,
+	0x11, 0x53, 0x65, 0x72, 0x76, 0x65, 0x72, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x22, 0x6c, 0x0a, 0x12, 0x53, 0x65, 0x72, 0x76, 0x65, 0x72, 0x49, 0x6e, 0x66, 0x6f,
+	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x76, 0x65, 0x72, 0x73,
+	0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69,
+	0x6f, 0x6e, 0x12, 0x20, 0x0a, 0x0b, 0x61, 0x70, 0x69, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e,
+	0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0b, 0x61, 0x70, 0x69, 0x56, 0x65, 0x72, 0x73,
+	0x69, 0x6f, 0x6e, 0x73, 0x12, 0x1a, 0x0a, 0x08, 0x66, 0x65, 0x61, 0x74, 0x75, 0x72, 0x65, 0x73,
+	0x18, 0x03, 0x20, 0x03, 0x28, 0x09, 0x52, 0x08, 0x66, 0x65, 0x61, 0x74, 0x75, 0x72, 0x65, 0x73,
+	0x22, 0x7a, 0x0a, 0x16, 0x44, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x48, 0x65, 0x61, 0x6c,
+	0x74, 0x68, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x28, 0x0a, 0x0f, 0x70, 0x65,
+	0x6e, 0x64, 0x69, 0x6e, 0x67, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x73, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x0d, 0x52, 0x0f, 0x70, 0x65, 0x6e, 0x64, 0x69, 0x6e, 0x67, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x73, 0x12, 0x36, 0x0a, 0x16, 0x6c, 0x61, 0x73, 0x74, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x43, 0x6f, 0x6d, 0x70, 0x6c, 0x65, 0x74, 0x65, 0x64, 0x41, 0x74, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x03, 0x52, 0x16, 0x6c, 0x61, 0x73, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x43, 0x6f, 0x6d, 0x70, 0x6c, 0x65, 0x74, 0x65, 0x64, 0x41, 0x74, 0x22, 0x84, 0x01, 0x0a,
+	0x0e, 0x49, 0x6d, 0x6d, 0x75, 0x74, 0x61, 0x62, 0x6c, 0x65, 0x53, 0x74, 0x61, 0x74, 0x65, 0x12,
+	0x0e, 0x0a, 0x02, 0x64, 0x62, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x64, 0x62, 0x12,
+	0x12, 0x0a, 0x04, 0x74, 0x78, 0x49, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x04, 0x74,
+	0x78, 0x49, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x74, 0x78, 0x48, 0x61, 0x73, 0x68, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x0c, 0x52, 0x06, 0x74, 0x78, 0x48, 0x61, 0x73, 0x68, 0x12, 0x36, 0x0a, 0x09, 0x73,
+	0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x18,
+	0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x53,
+	0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x52, 0x09, 0x73, 0x69, 0x67, 0x6e, 0x61, 0x74,
+	0x75, 0x72, 0x65, 0x22, 0xcd, 0x01, 0x0a, 0x10, 0x52, 0x65, 0x66, 0x65, 0x72, 0x65, 0x6e, 0x63,
+	0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x24, 0x0a, 0x0d, 0x72, 0x65,
+	0x66, 0x65, 0x72, 0x65, 0x6e, 0x63, 0x65, 0x64, 0x4b, 0x65, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x0c, 0x52, 0x0d, 0x72, 0x65, 0x66, 0x65, 0x72, 0x65, 0x6e, 0x63, 0x65, 0x64, 0x4b, 0x65, 0x79,
+	0x12, 0x12, 0x0a, 0x04, 0x61, 0x74, 0x54, 0x78, 0x18, 0x03, 0x20, 0x01, 0x28, 0x04, 0x52, 0x04,
+	0x61, 0x74, 0x54, 0x78, 0x12, 0x1a, 0x0a, 0x08, 0x62, 0x6f, 0x75, 0x6e, 0x64, 0x52, 0x65, 0x66,
+	0x18, 0x04, 0x20, 0x01, 0x28, 0x08, 0x52, 0x08, 0x62, 0x6f, 0x75, 0x6e, 0x64, 0x52, 0x65, 0x66,
+	0x12, 0x16, 0x0a, 0x06, 0x6e, 0x6f, 0x57, 0x61, 0x69, 0x74, 0x18, 0x05, 0x20, 0x01, 0x28, 0x08,
+	0x52, 0x06, 0x6e, 0x6f, 0x57, 0x61, 0x69, 0x74, 0x12, 0x39, 0x0a, 0x0a, 0x65, 0x78, 0x70, 0x69,
+	0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x69,
+	0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x45, 0x78, 0x70,
+	0x69, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x0a, 0x65, 0x78, 0x70, 0x69, 0x72, 0x61, 0x74,
+	0x69, 0x6f, 0x6e, 0x22, 0x8d, 0x01, 0x0a, 0x1a, 0x56, 0x65, 0x72, 0x69, 0x66, 0x69, 0x61, 0x62,
+	0x6c, 0x65, 0x52, 0x65, 0x66, 0x65, 0x72, 0x65, 0x6e, 0x63, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x12, 0x4b, 0x0a, 0x10, 0x72, 0x65, 0x66, 0x65, 0x72, 0x65, 0x6e, 0x63, 0x65, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1f, 0x2e, 0x69,
+	0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x52, 0x65, 0x66,
+	0x65, 0x72, 0x65, 0x6e, 0x63, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x52, 0x10, 0x72,
+	0x65, 0x66, 0x65, 0x72, 0x65, 0x6e, 0x63, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12,
+	0x22, 0x0a, 0x0c, 0x70, 0x72, 0x6f, 0x76, 0x65, 0x53, 0x69, 0x6e, 0x63, 0x65, 0x54, 0x78, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0c, 0x70, 0x72, 0x6f, 0x76, 0x65, 0x53, 0x69, 0x6e, 0x63,
+	0x65, 0x54, 0x78, 0x22, 0x57, 0x0a, 0x11, 0x52, 0x65, 0x73, 0x6f, 0x6c, 0x76, 0x65, 0x41, 0x6c,
+	0x6c, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x18, 0x0a, 0x07, 0x73, 0x69,
+	0x6e, 0x63, 0x65, 0x54, 0x78, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x07, 0x73, 0x69, 0x6e,
+	0x63, 0x65, 0x54, 0x78, 0x12, 0x16, 0x0a, 0x06, 0x6e, 0x6f, 0x57, 0x61, 0x69, 0x74, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x6e, 0x6f, 0x57, 0x61, 0x69, 0x74, 0x22, 0x57, 0x0a, 0x0d,
+	0x52, 0x65, 0x6e, 0x61, 0x6d, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x16, 0x0a,
+	0x06, 0x6f, 0x6c, 0x64, 0x4b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x06, 0x6f,
+	0x6c, 0x64, 0x4b, 0x65, 0x79, 0x12, 0x16, 0x0a, 0x06, 0x6e, 0x65, 0x77, 0x4b, 0x65, 0x79, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x06, 0x6e, 0x65, 0x77, 0x4b, 0x65, 0x79, 0x12, 0x16, 0x0a,
+	0x06, 0x6e, 0x6f, 0x57, 0x61, 0x69, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x6e,
+	0x6f, 0x57, 0x61, 0x69, 0x74, 0x22, 0xca, 0x01, 0x0a, 0x0b, 0x5a, 0x41, 0x64, 0x64, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x10, 0x0a, 0x03, 0x73, 0x65, 0x74, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x0c, 0x52, 0x03, 0x73, 0x65, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x73, 0x63, 0x6f, 0x72, 0x65,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x01, 0x52, 0x05, 0x73, 0x63, 0x6f, 0x72, 0x65, 0x12, 0x10, 0x0a,
+	0x03, 0x6b, 0x65, 0x79, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12,
+	0x12, 0x0a, 0x04, 0x61, 0x74, 0x54, 0x78, 0x18, 0x04, 0x20, 0x01, 0x28, 0x04, 0x52, 0x04, 0x61,
+	0x74, 0x54, 0x78, 0x12, 0x1a, 0x0a, 0x08, 0x62, 0x6f, 0x75, 0x6e, 0x64, 0x52, 0x65, 0x66, 0x18,
+	0x05, 0x20, 0x01, 0x28, 0x08, 0x52, 0x08, 0x62, 0x6f, 0x75, 0x6e, 0x64, 0x52, 0x65, 0x66, 0x12,
+	0x16, 0x0a, 0x06, 0x6e, 0x6f, 0x57, 0x61, 0x69, 0x74, 0x18, 0x06, 0x20, 0x01, 0x28, 0x08, 0x52,
+	0x06, 0x6e, 0x6f, 0x57, 0x61, 0x69, 0x74, 0x12, 0x39, 0x0a, 0x0a, 0x65, 0x78, 0x70, 0x69, 0x72,
+	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x69, 0x6d,
+	0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x45, 0x78, 0x70, 0x69,
+	0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x0a, 0x65, 0x78, 0x70, 0x69, 0x72, 0x61, 0x74, 0x69,
+	0x6f, 0x6e, 0x22, 0x1d, 0x0a, 0x05, 0x53, 0x63, 0x6f, 0x72, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x73,
+	0x63, 0x6f, 0x72, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x01, 0x52, 0x05, 0x73, 0x63, 0x6f, 0x72,
+	0x65, 0x22, 0xb6, 0x03, 0x0a, 0x0c, 0x5a, 0x53, 0x63, 0x61, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x12, 0x10, 0x0a, 0x03, 0x73, 0x65, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52,
+	0x03, 0x73, 0x65, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x73, 0x65, 0x65, 0x6b, 0x4b, 0x65, 0x79, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x07, 0x73, 0x65, 0x65, 0x6b, 0x4b, 0x65, 0x79, 0x12, 0x1c,
+	0x0a, 0x09, 0x73, 0x65, 0x65, 0x6b, 0x53, 0x63, 0x6f, 0x72, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x01, 0x52, 0x09, 0x73, 0x65, 0x65, 0x6b, 0x53, 0x63, 0x6f, 0x72, 0x65, 0x12, 0x1a, 0x0a, 0x08,
+	0x73, 0x65, 0x65, 0x6b, 0x41, 0x74, 0x54, 0x78, 0x18, 0x04, 0x20, 0x01, 0x28, 0x04, 0x52, 0x08,
+	0x73, 0x65, 0x65, 0x6b, 0x41, 0x74, 0x54, 0x78, 0x12, 0x24, 0x0a, 0x0d, 0x69, 0x6e, 0x63, 0x6c,
+	0x75, 0x73, 0x69, 0x76, 0x65, 0x53, 0x65, 0x65, 0x6b, 0x18, 0x05, 0x20, 0x01, 0x28, 0x08, 0x52,
+	0x0d, 0x69, 0x6e, 0x63, 0x6c, 0x75, 0x73, 0x69, 0x76, 0x65, 0x53, 0x65, 0x65, 0x6b, 0x12, 0x14,
+	0x0a, 0x05, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x18, 0x06, 0x20, 0x01, 0x28, 0x04, 0x52, 0x05, 0x6c,
+	0x69, 0x6d, 0x69, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x64, 0x65, 0x73, 0x63, 0x18, 0x07, 0x20, 0x01,
+	0x28, 0x08, 0x52, 0x04, 0x64, 0x65, 0x73, 0x63, 0x12, 0x30, 0x0a, 0x08, 0x6d, 0x69, 0x6e, 0x53,
+	0x63, 0x6f, 0x72, 0x65, 0x18, 0x08, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x69, 0x6d, 0x6d,
+	0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x53, 0x63, 0x6f, 0x72, 0x65,
+	0x52, 0x08, 0x6d, 0x69, 0x6e, 0x53, 0x63, 0x6f, 0x72, 0x65, 0x12, 0x30, 0x0a, 0x08, 0x6d, 0x61,
+	0x78, 0x53, 0x63, 0x6f, 0x72, 0x65, 0x18, 0x09, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x69,
+	0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x53, 0x63, 0x6f,
+	0x72, 0x65, 0x52, 0x08, 0x6d, 0x61, 0x78, 0x53, 0x63, 0x6f, 0x72, 0x65, 0x12, 0x18, 0x0a, 0x07,
+	0x73, 0x69, 0x6e, 0x63, 0x65, 0x54, 0x78, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x04, 0x52, 0x07, 0x73,
+	0x69, 0x6e, 0x63, 0x65, 0x54, 0x78, 0x12, 0x16, 0x0a, 0x06, 0x6e, 0x6f, 0x57, 0x61, 0x69, 0x74,
+	0x18, 0x0b, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x6e, 0x6f, 0x57, 0x61, 0x69, 0x74, 0x12, 0x2c,
+	0x0a, 0x11, 0x6d, 0x69, 0x6e, 0x53, 0x63, 0x6f, 0x72, 0x65, 0x45, 0x78, 0x63, 0x6c, 0x75, 0x73,
+	0x69, 0x76, 0x65, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x08, 0x52, 0x11, 0x6d, 0x69, 0x6e, 0x53, 0x63,
+	0x6f, 0x72, 0x65, 0x45, 0x78, 0x63, 0x6c, 0x75, 0x73, 0x69, 0x76, 0x65, 0x12, 0x2c, 0x0a, 0x11,
+	0x6d, 0x61, 0x78, 0x53, 0x63, 0x6f, 0x72, 0x65, 0x45, 0x78, 0x63, 0x6c, 0x75, 0x73, 0x69, 0x76,
+	0x65, 0x18, 0x0d, 0x20, 0x01, 0x28, 0x08, 0x52, 0x11, 0x6d, 0x61, 0x78, 0x53, 0x63, 0x6f, 0x72,
+	0x65, 0x45, 0x78, 0x63, 0x6c, 0x75, 0x73, 0x69, 0x76, 0x65, 0x22, 0xdc, 0x01, 0x0a, 0x0e, 0x48,
+	0x69, 0x73, 0x74, 0x6f, 0x72, 0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x10, 0x0a,
+	0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12,
+	0x16, 0x0a, 0x06, 0x6f, 0x66, 0x66, 0x73, 0x65, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52,
+	0x06, 0x6f, 0x66, 0x66, 0x73, 0x65, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x6c, 0x69, 0x6d, 0x69, 0x74,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x12, 0x12, 0x0a,
+	0x04, 0x64, 0x65, 0x73, 0x63, 0x18, 0x04, 0x20, 0x01, 0x28, 0x08, 0x52, 0x04, 0x64, 0x65, 0x73,
+	0x63, 0x12, 0x18, 0x0a, 0x07, 0x73, 0x69, 0x6e, 0x63, 0x65, 0x54, 0x78, 0x18, 0x05, 0x20, 0x01,
+	0x28, 0x04, 0x52, 0x07, 0x73, 0x69, 0x6e, 0x63, 0x65, 0x54, 0x78, 0x12, 0x1c, 0x0a, 0x09, 0x73,
+	0x74, 0x61, 0x72, 0x74, 0x54, 0x69, 0x6d, 0x65, 0x18, 0x06, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09,
+	0x73, 0x74, 0x61, 0x72, 0x74, 0x54, 0x69, 0x6d, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x65, 0x6e, 0x64,
+	0x54, 0x69, 0x6d, 0x65, 0x18, 0x07, 0x20, 0x01, 0x28, 0x03, 0x52, 0x07, 0x65, 0x6e, 0x64, 0x54,
+	0x69, 0x6d, 0x65, 0x12, 0x24, 0x0a, 0x0d, 0x66, 0x6f, 0x6c, 0x6c, 0x6f, 0x77, 0x52, 0x65, 0x6e,
+	0x61, 0x6d, 0x65, 0x73, 0x18, 0x08, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0d, 0x66, 0x6f, 0x6c, 0x6c,
+	0x6f, 0x77, 0x52, 0x65, 0x6e, 0x61, 0x6d, 0x65, 0x73, 0x22, 0x79, 0x0a, 0x15, 0x56, 0x65, 0x72,
+	0x69, 0x66, 0x69, 0x61, 0x62, 0x6c, 0x65, 0x5a, 0x41, 0x64, 0x64, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x12, 0x3c, 0x0a, 0x0b, 0x7a, 0x41, 0x64, 0x64, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62,
+	0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x5a, 0x41, 0x64, 0x64, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x52, 0x0b, 0x7a, 0x41, 0x64, 0x64, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x12, 0x22, 0x0a, 0x0c, 0x70, 0x72, 0x6f, 0x76, 0x65, 0x53, 0x69, 0x6e, 0x63, 0x65, 0x54, 0x78,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0c, 0x70, 0x72, 0x6f, 0x76, 0x65, 0x53, 0x69, 0x6e,
+	0x63, 0x65, 0x54, 0x78, 0x22, 0x8b, 0x01, 0x0a, 0x09, 0x54, 0x78, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x12, 0x0e, 0x0a, 0x02, 0x74, 0x78, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x02,
+	0x74, 0x78, 0x12, 0x3c, 0x0a, 0x0b, 0x65, 0x6e, 0x74, 0x72, 0x69, 0x65, 0x73, 0x53, 0x70, 0x65,
+	0x63, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62,
+	0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x45, 0x6e, 0x74, 0x72, 0x69, 0x65, 0x73, 0x53,
+	0x70, 0x65, 0x63, 0x52, 0x0b, 0x65, 0x6e, 0x74, 0x72, 0x69, 0x65, 0x73, 0x53, 0x70, 0x65, 0x63,
+	0x12, 0x18, 0x0a, 0x07, 0x73, 0x69, 0x6e, 0x63, 0x65, 0x54, 0x78, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x04, 0x52, 0x07, 0x73, 0x69, 0x6e, 0x63, 0x65, 0x54, 0x78, 0x12, 0x16, 0x0a, 0x06, 0x6e, 0x6f,
+	0x57, 0x61, 0x69, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x6e, 0x6f, 0x57, 0x61,
+	0x69, 0x74, 0x22, 0xd9, 0x01, 0x0a, 0x0b, 0x45, 0x6e, 0x74, 0x72, 0x69, 0x65, 0x73, 0x53, 0x70,
+	0x65, 0x63, 0x12, 0x42, 0x0a, 0x0d, 0x6b, 0x76, 0x45, 0x6e, 0x74, 0x72, 0x69, 0x65, 0x73, 0x53,
+	0x70, 0x65, 0x63, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1c, 0x2e, 0x69, 0x6d, 0x6d, 0x75,
+	0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x54,
+	0x79, 0x70, 0x65, 0x53, 0x70, 0x65, 0x63, 0x52, 0x0d, 0x6b, 0x76, 0x45, 0x6e, 0x74, 0x72, 0x69,
+	0x65, 0x73, 0x53, 0x70, 0x65, 0x63, 0x12, 0x40, 0x0a, 0x0c, 0x7a, 0x45, 0x6e, 0x74, 0x72, 0x69,
+	0x65, 0x73, 0x53, 0x70, 0x65, 0x63, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1c, 0x2e, 0x69,
+	0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x45, 0x6e, 0x74,
+	0x72, 0x79, 0x54, 0x79, 0x70, 0x65, 0x53, 0x70, 0x65, 0x63, 0x52, 0x0c, 0x7a, 0x45, 0x6e, 0x74,
+	0x72, 0x69, 0x65, 0x73, 0x53, 0x70, 0x65, 0x63, 0x12, 0x44, 0x0a, 0x0e, 0x73, 0x71, 0x6c, 0x45,
+	0x6e, 0x74, 0x72, 0x69, 0x65, 0x73, 0x53, 0x70, 0x65, 0x63, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x1c, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61,
+	0x2e, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x54, 0x79, 0x70, 0x65, 0x53, 0x70, 0x65, 0x63, 0x52, 0x0e,
+	0x73, 0x71, 0x6c, 0x45, 0x6e, 0x74, 0x72, 0x69, 0x65, 0x73, 0x53, 0x70, 0x65, 0x63, 0x22, 0x47,
+	0x0a, 0x0d, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x54, 0x79, 0x70, 0x65, 0x53, 0x70, 0x65, 0x63, 0x12,
+	0x36, 0x0a, 0x06, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32,
+	0x1e, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e,
+	0x45, 0x6e, 0x74, 0x72, 0x79, 0x54, 0x79, 0x70, 0x65, 0x41, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x52,
+	0x06, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x22, 0xb9, 0x01, 0x0a, 0x13, 0x56, 0x65, 0x72, 0x69,
+	0x66, 0x69, 0x61, 0x62, 0x6c, 0x65, 0x54, 0x78, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12,
+	0x0e, 0x0a, 0x02, 0x74, 0x78, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x02, 0x74, 0x78, 0x12,
+	0x22, 0x0a, 0x0c, 0x70, 0x72, 0x6f, 0x76, 0x65, 0x53, 0x69, 0x6e, 0x63, 0x65, 0x54, 0x78, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0c, 0x70, 0x72, 0x6f, 0x76, 0x65, 0x53, 0x69, 0x6e, 0x63,
+	0x65, 0x54, 0x78, 0x12, 0x3c, 0x0a, 0x0b, 0x65, 0x6e, 0x74, 0x72, 0x69, 0x65, 0x73, 0x53, 0x70,
+	0x65, 0x63, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64,
+	0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x45, 0x6e, 0x74, 0x72, 0x69, 0x65, 0x73,
+	0x53, 0x70, 0x65, 0x63, 0x52, 0x0b, 0x65, 0x6e, 0x74, 0x72, 0x69, 0x65, 0x73, 0x53, 0x70, 0x65,
+	0x63, 0x12, 0x18, 0x0a, 0x07, 0x73, 0x69, 0x6e, 0x63, 0x65, 0x54, 0x78, 0x18, 0x04, 0x20, 0x01,
+	0x28, 0x04, 0x52, 0x07, 0x73, 0x69, 0x6e, 0x63, 0x65, 0x54, 0x78, 0x12, 0x16, 0x0a, 0x06, 0x6e,
+	0x6f, 0x57, 0x61, 0x69, 0x74, 0x18, 0x05, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x6e, 0x6f, 0x57,
+	0x61, 0x69, 0x74, 0x22, 0xff, 0x01, 0x0a, 0x0d, 0x54, 0x78, 0x53, 0x63, 0x61, 0x6e, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1c, 0x0a, 0x09, 0x69, 0x6e, 0x69, 0x74, 0x69, 0x61, 0x6c,
+	0x54, 0x78, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x09, 0x69, 0x6e, 0x69, 0x74, 0x69, 0x61,
+	0x6c, 0x54, 0x78, 0x12, 0x14, 0x0a, 0x05, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x0d, 0x52, 0x05, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x64, 0x65, 0x73,
+	0x63, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x52, 0x04, 0x64, 0x65, 0x73, 0x63, 0x12, 0x3c, 0x0a,
+	0x0b, 0x65, 0x6e, 0x74, 0x72, 0x69, 0x65, 0x73, 0x53, 0x70, 0x65, 0x63, 0x18, 0x04, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65,
+	0x6d, 0x61, 0x2e, 0x45, 0x6e, 0x74, 0x72, 0x69, 0x65, 0x73, 0x53, 0x70, 0x65, 0x63, 0x52, 0x0b,
+	0x65, 0x6e, 0x74, 0x72, 0x69, 0x65, 0x73, 0x53, 0x70, 0x65, 0x63, 0x12, 0x18, 0x0a, 0x07, 0x73,
+	0x69, 0x6e, 0x63, 0x65, 0x54, 0x78, 0x18, 0x05, 0x20, 0x01, 0x28, 0x04, 0x52, 0x07, 0x73, 0x69,
+	0x6e, 0x63, 0x65, 0x54, 0x78, 0x12, 0x16, 0x0a, 0x06, 0x6e, 0x6f, 0x57, 0x61, 0x69, 0x74, 0x18,
+	0x06, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x6e, 0x6f, 0x57, 0x61, 0x69, 0x74, 0x12, 0x1c, 0x0a,
+	0x09, 0x73, 0x74, 0x61, 0x72, 0x74, 0x54, 0x69, 0x6d, 0x65, 0x18, 0x07, 0x20, 0x01, 0x28, 0x03,
+	0x52, 0x09, 0x73, 0x74, 0x61, 0x72, 0x74, 0x54, 0x69, 0x6d, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x65,
+	0x6e, 0x64, 0x54, 0x69, 0x6d, 0x65, 0x18, 0x08, 0x20, 0x01, 0x28, 0x03, 0x52, 0x07, 0x65, 0x6e,
+	0x64, 0x54, 0x69, 0x6d, 0x65, 0x22, 0x2d, 0x0a, 0x06, 0x54, 0x78, 0x4c, 0x69, 0x73, 0x74, 0x12,
+	0x23, 0x0a, 0x03, 0x74, 0x78, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x69,
+	0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x54, 0x78, 0x52,
+	0x03, 0x74, 0x78, 0x73, 0x22, 0x21, 0x0a, 0x0f, 0x45, 0x78, 0x70, 0x6f, 0x72, 0x74, 0x54, 0x78,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x0e, 0x0a, 0x02, 0x74, 0x78, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x04, 0x52, 0x02, 0x74, 0x78, 0x22, 0x2e, 0x0a, 0x08, 0x44, 0x61, 0x74, 0x61, 0x62,
+	0x61, 0x73, 0x65, 0x12, 0x22, 0x0a, 0x0c, 0x64, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x4e,
+	0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x64, 0x61, 0x74, 0x61, 0x62,
+	0x61, 0x73, 0x65, 0x4e, 0x61, 0x6d, 0x65, 0x22, 0xc4, 0x03, 0x0a, 0x10, 0x44, 0x61, 0x74, 0x61,
+	0x62, 0x61, 0x73, 0x65, 0x53, 0x65, 0x74, 0x74, 0x69, 0x6e, 0x67, 0x73, 0x12, 0x22, 0x0a, 0x0c,
+	0x64, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x4e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x0c, 0x64, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x4e, 0x61, 0x6d, 0x65,
+	0x12, 0x18, 0x0a, 0x07, 0x72, 0x65, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x08, 0x52, 0x07, 0x72, 0x65, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x12, 0x26, 0x0a, 0x0e, 0x6d, 0x61,
+	0x73, 0x74, 0x65, 0x72, 0x44, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x0e, 0x6d, 0x61, 0x73, 0x74, 0x65, 0x72, 0x44, 0x61, 0x74, 0x61, 0x62, 0x61,
+	0x73, 0x65, 0x12, 0x24, 0x0a, 0x0d, 0x6d, 0x61, 0x73, 0x74, 0x65, 0x72, 0x41, 0x64, 0x64, 0x72,
+	0x65, 0x73, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0d, 0x6d, 0x61, 0x73, 0x74, 0x65,
+	0x72, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x12, 0x1e, 0x0a, 0x0a, 0x6d, 0x61, 0x73, 0x74,
+	0x65, 0x72, 0x50, 0x6f, 0x72, 0x74, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0a, 0x6d, 0x61,
+	0x73, 0x74, 0x65, 0x72, 0x50, 0x6f, 0x72, 0x74, 0x12, 0x2a, 0x0a, 0x10, 0x66, 0x6f, 0x6c, 0x6c,
+	0x6f, 0x77, 0x65, 0x72, 0x55, 0x73, 0x65, 0x72, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x06, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x10, 0x66, 0x6f, 0x6c, 0x6c, 0x6f, 0x77, 0x65, 0x72, 0x55, 0x73, 0x65, 0x72,
+	0x6e, 0x61, 0x6d, 0x65, 0x12, 0x2a, 0x0a, 0x10, 0x66, 0x6f, 0x6c, 0x6c, 0x6f, 0x77, 0x65, 0x72,
+	0x50, 0x61, 0x73, 0x73, 0x77, 0x6f, 0x72, 0x64, 0x18, 0x07, 0x20, 0x01, 0x28, 0x09, 0x52, 0x10,
+	0x66, 0x6f, 0x6c, 0x6c, 0x6f, 0x77, 0x65, 0x72, 0x50, 0x61, 0x73, 0x73, 0x77, 0x6f, 0x72, 0x64,
+	0x12, 0x1a, 0x0a, 0x08, 0x66, 0x69, 0x6c, 0x65, 0x53, 0x69, 0x7a, 0x65, 0x18, 0x08, 0x20, 0x01,
+	0x28, 0x0d, 0x52, 0x08, 0x66, 0x69, 0x6c, 0x65, 0x53, 0x69, 0x7a, 0x65, 0x12, 0x1c, 0x0a, 0x09,
+	0x6d, 0x61, 0x78, 0x4b, 0x65, 0x79, 0x4c, 0x65, 0x6e, 0x18, 0x09, 0x20, 0x01, 0x28, 0x0d, 0x52,
+	0x09, 0x6d, 0x61, 0x78, 0x4b, 0x65, 0x79, 0x4c, 0x65, 0x6e, 0x12, 0x20, 0x0a, 0x0b, 0x6d, 0x61,
+	0x78, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x4c, 0x65, 0x6e, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x0d, 0x52,
+	0x0b, 0x6d, 0x61, 0x78, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x4c, 0x65, 0x6e, 0x12, 0x22, 0x0a, 0x0c,
+	0x6d, 0x61, 0x78, 0x54, 0x78, 0x45, 0x6e, 0x74, 0x72, 0x69, 0x65, 0x73, 0x18, 0x0b, 0x20, 0x01,
+	0x28, 0x0d, 0x52, 0x0c, 0x6d, 0x61, 0x78, 0x54, 0x78, 0x45, 0x6e, 0x74, 0x72, 0x69, 0x65, 0x73,
+	0x12, 0x2c, 0x0a, 0x11, 0x65, 0x78, 0x63, 0x6c, 0x75, 0x64, 0x65, 0x43, 0x6f, 0x6d, 0x6d, 0x69,
+	0x74, 0x54, 0x69, 0x6d, 0x65, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x08, 0x52, 0x11, 0x65, 0x78, 0x63,
+	0x6c, 0x75, 0x64, 0x65, 0x43, 0x6f, 0x6d, 0x6d, 0x69, 0x74, 0x54, 0x69, 0x6d, 0x65, 0x22, 0x93,
+	0x01, 0x0a, 0x1c, 0x44, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x53, 0x65, 0x74, 0x74, 0x69,
+	0x6e, 0x67, 0x73, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x12,
+	0x4b, 0x0a, 0x0f, 0x63, 0x75, 0x72, 0x72, 0x65, 0x6e, 0x74, 0x53, 0x65, 0x74, 0x74, 0x69, 0x6e,
+	0x67, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x21, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64,
+	0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x44, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73,
+	0x65, 0x53, 0x65, 0x74, 0x74, 0x69, 0x6e, 0x67, 0x73, 0x56, 0x32, 0x52, 0x0f, 0x63, 0x75, 0x72,
+	0x72, 0x65, 0x6e, 0x74, 0x53, 0x65, 0x74, 0x74, 0x69, 0x6e, 0x67, 0x73, 0x12, 0x26, 0x0a, 0x0e,
+	0x72, 0x65, 0x6c, 0x6f, 0x61, 0x64, 0x52, 0x65, 0x71, 0x75, 0x69, 0x72, 0x65, 0x64, 0x18, 0x02,
+	0x20, 0x03, 0x28, 0x09, 0x52, 0x0e, 0x72, 0x65, 0x6c, 0x6f, 0x61, 0x64, 0x52, 0x65, 0x71, 0x75,
+	0x69, 0x72, 0x65, 0x64, 0x22, 0x7a, 0x0a, 0x14, 0x43, 0x6c, 0x6f, 0x6e, 0x65, 0x44, 0x61, 0x74,
+	0x61, 0x62, 0x61, 0x73, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x26, 0x0a, 0x0e,
+	0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x44, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x0e, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x44, 0x61, 0x74, 0x61,
+	0x62, 0x61, 0x73, 0x65, 0x12, 0x22, 0x0a, 0x0c, 0x64, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65,
+	0x4e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x64, 0x61, 0x74, 0x61,
+	0x62, 0x61, 0x73, 0x65, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x61, 0x73, 0x4f, 0x66,
+	0x54, 0x78, 0x18, 0x03, 0x20, 0x01, 0x28, 0x04, 0x52, 0x06, 0x61, 0x73, 0x4f, 0x66, 0x54, 0x78,
+	0x22, 0x6a, 0x0a, 0x15, 0x43, 0x6c, 0x6f, 0x6e, 0x65, 0x44, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73,
+	0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x3d, 0x0a, 0x08, 0x73, 0x65, 0x74,
+	0x74, 0x69, 0x6e, 0x67, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x21, 0x2e, 0x69, 0x6d,
+	0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x44, 0x61, 0x74, 0x61,
+	0x62, 0x61, 0x73, 0x65, 0x53, 0x65, 0x74, 0x74, 0x69, 0x6e, 0x67, 0x73, 0x56, 0x32, 0x52, 0x08,
+	0x73, 0x65, 0x74, 0x74, 0x69, 0x6e, 0x67, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x78, 0x49, 0x64,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x04, 0x74, 0x78, 0x49, 0x64, 0x22, 0x7e, 0x0a, 0x1e,
+	0x44, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x53, 0x65, 0x74, 0x74, 0x69, 0x6e, 0x67, 0x73,
+	0x48, 0x69, 0x73, 0x74, 0x6f, 0x72, 0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1a,
+	0x0a, 0x08, 0x64, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x08, 0x64, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x6f, 0x66,
+	0x66, 0x73, 0x65, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x06, 0x6f, 0x66, 0x66, 0x73,
+	0x65, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x0d, 0x52, 0x05, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x64, 0x65, 0x73, 0x63,
+	0x18, 0x04, 0x20, 0x01, 0x28, 0x08, 0x52, 0x04, 0x64, 0x65, 0x73, 0x63, 0x22, 0x5d, 0x0a, 0x17,
+	0x44, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x53, 0x65, 0x74, 0x74, 0x69, 0x6e, 0x67, 0x73,
+	0x48, 0x69, 0x73, 0x74, 0x6f, 0x72, 0x79, 0x12, 0x42, 0x0a, 0x08, 0x76, 0x65, 0x72, 0x73, 0x69,
+	0x6f, 0x6e, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x26, 0x2e, 0x69, 0x6d, 0x6d, 0x75,
+	0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x44, 0x61, 0x74, 0x61, 0x62, 0x61,
+	0x73, 0x65, 0x53, 0x65, 0x74, 0x74, 0x69, 0x6e, 0x67, 0x73, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f,
+	0x6e, 0x52, 0x08, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x22, 0xee, 0x01, 0x0a, 0x17,
+	0x44, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x53, 0x65, 0x74, 0x74, 0x69, 0x6e, 0x67, 0x73,
+	0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x18, 0x0a, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69,
+	0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f,
+	0x6e, 0x12, 0x1c, 0x0a, 0x09, 0x75, 0x70, 0x64, 0x61, 0x74, 0x65, 0x64, 0x42, 0x79, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x75, 0x70, 0x64, 0x61, 0x74, 0x65, 0x64, 0x42, 0x79, 0x12,
+	0x1c, 0x0a, 0x09, 0x75, 0x70, 0x64, 0x61, 0x74, 0x65, 0x64, 0x41, 0x74, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x03, 0x52, 0x09, 0x75, 0x70, 0x64, 0x61, 0x74, 0x65, 0x64, 0x41, 0x74, 0x12, 0x3d, 0x0a,
+	0x08, 0x73, 0x65, 0x74, 0x74, 0x69, 0x6e, 0x67, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x21, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e,
+	0x44, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x53, 0x65, 0x74, 0x74, 0x69, 0x6e, 0x67, 0x73,
+	0x56, 0x32, 0x52, 0x08, 0x73, 0x65, 0x74, 0x74, 0x69, 0x6e, 0x67, 0x73, 0x12, 0x3e, 0x0a, 0x07,
+	0x63, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x73, 0x18, 0x05, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x24, 0x2e,
+	0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x44, 0x61,
+	0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x53, 0x65, 0x74, 0x74, 0x69, 0x6e, 0x67, 0x43, 0x68, 0x61,
+	0x6e, 0x67, 0x65, 0x52, 0x07, 0x63, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x73, 0x22, 0x69, 0x0a, 0x15,
+	0x44, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x53, 0x65, 0x74, 0x74, 0x69, 0x6e, 0x67, 0x43,
+	0x68, 0x61, 0x6e, 0x67, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x73, 0x65, 0x74, 0x74, 0x69, 0x6e, 0x67,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x73, 0x65, 0x74, 0x74, 0x69, 0x6e, 0x67, 0x12,
+	0x1a, 0x0a, 0x08, 0x6f, 0x6c, 0x64, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x08, 0x6f, 0x6c, 0x64, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x6e,
+	0x65, 0x77, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x6e,
+	0x65, 0x77, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x22, 0x57, 0x0a, 0x1f, 0x44, 0x61, 0x74, 0x61, 0x62,
+	0x61, 0x73, 0x65, 0x53, 0x65, 0x74, 0x74, 0x69, 0x6e, 0x67, 0x73, 0x52, 0x6f, 0x6c, 0x6c, 0x62,
+	0x61, 0x63, 0x6b, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1a, 0x0a, 0x08, 0x64, 0x61,
+	0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x64, 0x61,
+	0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f,
+	0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e,
+	0x22, 0x29, 0x0a, 0x11, 0x43, 0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x61, 0x6c, 0x55,
+	0x69, 0x6e, 0x74, 0x33, 0x32, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x0d, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x22, 0x29, 0x0a, 0x11, 0x43,
+	0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x61, 0x6c, 0x55, 0x69, 0x6e, 0x74, 0x36, 0x34,
+	0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52,
+	0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x22, 0x28, 0x0a, 0x10, 0x43, 0x6f, 0x6e, 0x64, 0x69, 0x74,
+	0x69, 0x6f, 0x6e, 0x61, 0x6c, 0x46, 0x6c, 0x6f, 0x61, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61,
+	0x6c, 0x75, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x02, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65,
+	0x22, 0x27, 0x0a, 0x0f, 0x43, 0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x61, 0x6c, 0x42,
+	0x6f, 0x6f, 0x6c, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x08, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x22, 0x29, 0x0a, 0x11, 0x43, 0x6f, 0x6e,
+	0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x61, 0x6c, 0x53, 0x74, 0x72, 0x69, 0x6e, 0x67, 0x12, 0x14,
+	0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76,
+	0x61, 0x6c, 0x75, 0x65, 0x22, 0xbf, 0x03, 0x0a, 0x13, 0x52, 0x65, 0x70, 0x6c, 0x69, 0x63, 0x61,
+	0x74, 0x69, 0x6f, 0x6e, 0x53, 0x65, 0x74, 0x74, 0x69, 0x6e, 0x67, 0x73, 0x12, 0x38, 0x0a, 0x07,
+	0x72, 0x65, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1e, 0x2e,
+	0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x43, 0x6f,
+	0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x61, 0x6c, 0x42, 0x6f, 0x6f, 0x6c, 0x52, 0x07, 0x72,
+	0x65, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x12, 0x48, 0x0a, 0x0e, 0x6d, 0x61, 0x73, 0x74, 0x65, 0x72,
+	0x44, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x20,
+	0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x43,
+	0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x61, 0x6c, 0x53, 0x74, 0x72, 0x69, 0x6e, 0x67,
+	0x52, 0x0e, 0x6d, 0x61, 0x73, 0x74, 0x65, 0x72, 0x44, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65,
+	0x12, 0x46, 0x0a, 0x0d, 0x6d, 0x61, 0x73, 0x74, 0x65, 0x72, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73,
+	0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62,
+	0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x43, 0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f,
+	0x6e, 0x61, 0x6c, 0x53, 0x74, 0x72, 0x69, 0x6e, 0x67, 0x52, 0x0d, 0x6d, 0x61, 0x73, 0x74, 0x65,
+	0x72, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x12, 0x40, 0x0a, 0x0a, 0x6d, 0x61, 0x73, 0x74,
+	0x65, 0x72, 0x50, 0x6f, 0x72, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x69,
+	0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x43, 0x6f, 0x6e,
+	0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x61, 0x6c, 0x55, 0x69, 0x6e, 0x74, 0x33, 0x32, 0x52, 0x0a,
+	0x6d, 0x61, 0x73, 0x74, 0x65, 0x72, 0x50, 0x6f, 0x72, 0x74, 0x12, 0x4c, 0x0a, 0x10, 0x66, 0x6f,
+	0x6c, 0x6c, 0x6f, 0x77, 0x65, 0x72, 0x55, 0x73, 0x65, 0x72, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x05,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63,
+	0x68, 0x65, 0x6d, 0x61, 0x2e, 0x43, 0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x61, 0x6c,
+	0x53, 0x74, 0x72, 0x69, 0x6e, 0x67, 0x52, 0x10, 0x66, 0x6f, 0x6c, 0x6c, 0x6f, 0x77, 0x65, 0x72,
+	0x55, 0x73, 0x65, 0x72, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x4c, 0x0a, 0x10, 0x66, 0x6f, 0x6c, 0x6c,
+	0x6f, 0x77, 0x65, 0x72, 0x50, 0x61, 0x73, 0x73, 0x77, 0x6f, 0x72, 0x64, 0x18, 0x06, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x20, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65,
+	0x6d, 0x61, 0x2e, 0x43, 0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x61, 0x6c, 0x53, 0x74,
+	0x72, 0x69, 0x6e, 0x67, 0x52, 0x10, 0x66, 0x6f, 0x6c, 0x6c, 0x6f, 0x77, 0x65, 0x72, 0x50, 0x61,
+	0x73, 0x73, 0x77, 0x6f, 0x72, 0x64, 0x22, 0x88, 0x0e, 0x0a, 0x12, 0x44, 0x61, 0x74, 0x61, 0x62,
+	0x61, 0x73, 0x65, 0x53, 0x65, 0x74, 0x74, 0x69, 0x6e, 0x67, 0x73, 0x56, 0x32, 0x12, 0x22, 0x0a,
+	0x0c, 0x64, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x4e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x0c, 0x64, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x4e, 0x61, 0x6d,
+	0x65, 0x12, 0x54, 0x0a, 0x13, 0x72, 0x65, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e,
+	0x53, 0x65, 0x74, 0x74, 0x69, 0x6e, 0x67, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x22,
+	0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x52,
+	0x65, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x53, 0x65, 0x74, 0x74, 0x69, 0x6e,
+	0x67, 0x73, 0x52, 0x13, 0x72, 0x65, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x53,
+	0x65, 0x74, 0x74, 0x69, 0x6e, 0x67, 0x73, 0x12, 0x3c, 0x0a, 0x08, 0x66, 0x69, 0x6c, 0x65, 0x53,
+	0x69, 0x7a, 0x65, 0x18, 0x08, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x69, 0x6d, 0x6d, 0x75,
+	0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x43, 0x6f, 0x6e, 0x64, 0x69, 0x74,
+	0x69, 0x6f, 0x6e, 0x61, 0x6c, 0x55, 0x69, 0x6e, 0x74, 0x33, 0x32, 0x52, 0x08, 0x66, 0x69, 0x6c,
+	0x65, 0x53, 0x69, 0x7a, 0x65, 0x12, 0x3e, 0x0a, 0x09, 0x6d, 0x61, 0x78, 0x4b, 0x65, 0x79, 0x4c,
+	0x65, 0x6e, 0x18, 0x09, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64,
+	0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x43, 0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69,
+	0x6f, 0x6e, 0x61, 0x6c, 0x55, 0x69, 0x6e, 0x74, 0x33, 0x32, 0x52, 0x09, 0x6d, 0x61, 0x78, 0x4b,
+	0x65, 0x79, 0x4c, 0x65, 0x6e, 0x12, 0x42, 0x0a, 0x0b, 0x6d, 0x61, 0x78, 0x56, 0x61, 0x6c, 0x75,
+	0x65, 0x4c, 0x65, 0x6e, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x69, 0x6d, 0x6d,
+	0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x43, 0x6f, 0x6e, 0x64, 0x69,
+	0x74, 0x69, 0x6f, 0x6e, 0x61, 0x6c, 0x55, 0x69, 0x6e, 0x74, 0x33, 0x32, 0x52, 0x0b, 0x6d, 0x61,
+	0x78, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x4c, 0x65, 0x6e, 0x12, 0x44, 0x0a, 0x0c, 0x6d, 0x61, 0x78,
+	0x54, 0x78, 0x45, 0x6e, 0x74, 0x72, 0x69, 0x65, 0x73, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x20, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e,
+	0x43, 0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x61, 0x6c, 0x55, 0x69, 0x6e, 0x74, 0x33,
+	0x32, 0x52, 0x0c, 0x6d, 0x61, 0x78, 0x54, 0x78, 0x45, 0x6e, 0x74, 0x72, 0x69, 0x65, 0x73, 0x12,
+	0x4c, 0x0a, 0x11, 0x65, 0x78, 0x63, 0x6c, 0x75, 0x64, 0x65, 0x43, 0x6f, 0x6d, 0x6d, 0x69, 0x74,
+	0x54, 0x69, 0x6d, 0x65, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1e, 0x2e, 0x69, 0x6d, 0x6d,
+	0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x43, 0x6f, 0x6e, 0x64, 0x69,
+	0x74, 0x69, 0x6f, 0x6e, 0x61, 0x6c, 0x42, 0x6f, 0x6f, 0x6c, 0x52, 0x11, 0x65, 0x78, 0x63, 0x6c,
+	0x75, 0x64, 0x65, 0x43, 0x6f, 0x6d, 0x6d, 0x69, 0x74, 0x54, 0x69, 0x6d, 0x65, 0x12, 0x48, 0x0a,
+	0x0e, 0x6d, 0x61, 0x78, 0x43, 0x6f, 0x6e, 0x63, 0x75, 0x72, 0x72, 0x65, 0x6e, 0x63, 0x79, 0x18,
+	0x0d, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73,
+	0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x43, 0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x61,
+	0x6c, 0x55, 0x69, 0x6e, 0x74, 0x33, 0x32, 0x52, 0x0e, 0x6d, 0x61, 0x78, 0x43, 0x6f, 0x6e, 0x63,
+	0x75, 0x72, 0x72, 0x65, 0x6e, 0x63, 0x79, 0x12, 0x4c, 0x0a, 0x10, 0x6d, 0x61, 0x78, 0x49, 0x4f,
+	0x43, 0x6f, 0x6e, 0x63, 0x75, 0x72, 0x72, 0x65, 0x6e, 0x63, 0x79, 0x18, 0x0e, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x20, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d,
+	0x61, 0x2e, 0x43, 0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x61, 0x6c, 0x55, 0x69, 0x6e,
+	0x74, 0x33, 0x32, 0x52, 0x10, 0x6d, 0x61, 0x78, 0x49, 0x4f, 0x43, 0x6f, 0x6e, 0x63, 0x75, 0x72,
+	0x72, 0x65, 0x6e, 0x63, 0x79, 0x12, 0x48, 0x0a, 0x0e, 0x74, 0x78, 0x4c, 0x6f, 0x67, 0x43, 0x61,
+	0x63, 0x68, 0x65, 0x53, 0x69, 0x7a, 0x65, 0x18, 0x0f, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x20, 0x2e,
+	0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x43, 0x6f,
+	0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x61, 0x6c, 0x55, 0x69, 0x6e, 0x74, 0x33, 0x32, 0x52,
+	0x0e, 0x74, 0x78, 0x4c, 0x6f, 0x67, 0x43, 0x61, 0x63, 0x68, 0x65, 0x53, 0x69, 0x7a, 0x65, 0x12,
+	0x50, 0x0a, 0x12, 0x76, 0x4c, 0x6f, 0x67, 0x4d, 0x61, 0x78, 0x4f, 0x70, 0x65, 0x6e, 0x65, 0x64,
+	0x46, 0x69, 0x6c, 0x65, 0x73, 0x18, 0x10, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x69, 0x6d,
+	0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x43, 0x6f, 0x6e, 0x64,
+	0x69, 0x74, 0x69, 0x6f, 0x6e, 0x61, 0x6c, 0x55, 0x69, 0x6e, 0x74, 0x33, 0x32, 0x52, 0x12, 0x76,
+	0x4c, 0x6f, 0x67, 0x4d, 0x61, 0x78, 0x4f, 0x70, 0x65, 0x6e, 0x65, 0x64, 0x46, 0x69, 0x6c, 0x65,
+	0x73, 0x12, 0x52, 0x0a, 0x13, 0x74, 0x78, 0x4c, 0x6f, 0x67, 0x4d, 0x61, 0x78, 0x4f, 0x70, 0x65,
+	0x6e, 0x65, 0x64, 0x46, 0x69, 0x6c, 0x65, 0x73, 0x18, 0x11, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x20,
+	0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x43,
+	0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x61, 0x6c, 0x55, 0x69, 0x6e, 0x74, 0x33, 0x32,
+	0x52, 0x13, 0x74, 0x78, 0x4c, 0x6f, 0x67, 0x4d, 0x61, 0x78, 0x4f, 0x70, 0x65, 0x6e, 0x65, 0x64,
+	0x46, 0x69, 0x6c, 0x65, 0x73, 0x12, 0x5a, 0x0a, 0x17, 0x63, 0x6f, 0x6d, 0x6d, 0x69, 0x74, 0x4c,
+	0x6f, 0x67, 0x4d, 0x61, 0x78, 0x4f, 0x70, 0x65, 0x6e, 0x65, 0x64, 0x46, 0x69, 0x6c, 0x65, 0x73,
+	0x18, 0x12, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e,
+	0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x43, 0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e,
+	0x61, 0x6c, 0x55, 0x69, 0x6e, 0x74, 0x33, 0x32, 0x52, 0x17, 0x63, 0x6f, 0x6d, 0x6d, 0x69, 0x74,
+	0x4c, 0x6f, 0x67, 0x4d, 0x61, 0x78, 0x4f, 0x70, 0x65, 0x6e, 0x65, 0x64, 0x46, 0x69, 0x6c, 0x65,
+	0x73, 0x12, 0x42, 0x0a, 0x0d, 0x69, 0x6e, 0x64, 0x65, 0x78, 0x53, 0x65, 0x74, 0x74, 0x69, 0x6e,
+	0x67, 0x73, 0x18, 0x13, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1c, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64,
+	0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x49, 0x6e, 0x64, 0x65, 0x78, 0x53, 0x65,
+	0x74, 0x74, 0x69, 0x6e, 0x67, 0x73, 0x52, 0x0d, 0x69, 0x6e, 0x64, 0x65, 0x78, 0x53, 0x65, 0x74,
+	0x74, 0x69, 0x6e, 0x67, 0x73, 0x12, 0x54, 0x0a, 0x14, 0x77, 0x72, 0x69, 0x74, 0x65, 0x54, 0x78,
+	0x48, 0x65, 0x61, 0x64, 0x65, 0x72, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x14, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68,
+	0x65, 0x6d, 0x61, 0x2e, 0x43, 0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x61, 0x6c, 0x55,
+	0x69, 0x6e, 0x74, 0x33, 0x32, 0x52, 0x14, 0x77, 0x72, 0x69, 0x74, 0x65, 0x54, 0x78, 0x48, 0x65,
+	0x61, 0x64, 0x65, 0x72, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x4c, 0x0a, 0x10, 0x76,
+	0x61, 0x6c, 0x75, 0x65, 0x43, 0x6f, 0x6d, 0x70, 0x72, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x18,
+	0x15, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73,
+	0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x43, 0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x61,
+	0x6c, 0x53, 0x74, 0x72, 0x69, 0x6e, 0x67, 0x52, 0x10, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x43, 0x6f,
+	0x6d, 0x70, 0x72, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x5e, 0x0a, 0x19, 0x76, 0x61, 0x6c,
+	0x75, 0x65, 0x43, 0x6f, 0x6d, 0x70, 0x72, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x54, 0x68, 0x72,
+	0x65, 0x73, 0x68, 0x6f, 0x6c, 0x64, 0x18, 0x16, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x69,
+	0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x43, 0x6f, 0x6e,
+	0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x61, 0x6c, 0x55, 0x69, 0x6e, 0x74, 0x33, 0x32, 0x52, 0x19,
+	0x76, 0x61, 0x6c, 0x75, 0x65, 0x43, 0x6f, 0x6d, 0x70, 0x72, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e,
+	0x54, 0x68, 0x72, 0x65, 0x73, 0x68, 0x6f, 0x6c, 0x64, 0x12, 0x1a, 0x0a, 0x08, 0x74, 0x65, 0x6d,
+	0x70, 0x6c, 0x61, 0x74, 0x65, 0x18, 0x17, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x74, 0x65, 0x6d,
+	0x70, 0x6c, 0x61, 0x74, 0x65, 0x12, 0x46, 0x0a, 0x0e, 0x61, 0x6e, 0x6f, 0x6e, 0x79, 0x6d, 0x6f,
+	0x75, 0x73, 0x52, 0x65, 0x61, 0x64, 0x73, 0x18, 0x18, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1e, 0x2e,
+	0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x43, 0x6f,
+	0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x61, 0x6c, 0x42, 0x6f, 0x6f, 0x6c, 0x52, 0x0e, 0x61,
+	0x6e, 0x6f, 0x6e, 0x79, 0x6d, 0x6f, 0x75, 0x73, 0x52, 0x65, 0x61, 0x64, 0x73, 0x12, 0x48, 0x0a,
+	0x0f, 0x6d, 0x61, 0x69, 0x6e, 0x74, 0x65, 0x6e, 0x61, 0x6e, 0x63, 0x65, 0x4d, 0x6f, 0x64, 0x65,
+	0x18, 0x19, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1e, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e,
+	0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x43, 0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e,
+	0x61, 0x6c, 0x42, 0x6f, 0x6f, 0x6c, 0x52, 0x0f, 0x6d, 0x61, 0x69, 0x6e, 0x74, 0x65, 0x6e, 0x61,
+	0x6e, 0x63, 0x65, 0x4d, 0x6f, 0x64, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x74, 0x65, 0x6e, 0x61, 0x6e,
+	0x74, 0x18, 0x1a, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x74, 0x65, 0x6e, 0x61, 0x6e, 0x74, 0x12,
+	0x44, 0x0a, 0x0c, 0x6d, 0x61, 0x78, 0x44, 0x69, 0x73, 0x6b, 0x55, 0x73, 0x61, 0x67, 0x65, 0x18,
+	0x1b, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73,
+	0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x43, 0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x61,
+	0x6c, 0x55, 0x69, 0x6e, 0x74, 0x36, 0x34, 0x52, 0x0c, 0x6d, 0x61, 0x78, 0x44, 0x69, 0x73, 0x6b,
+	0x55, 0x73, 0x61, 0x67, 0x65, 0x12, 0x54, 0x0a, 0x15, 0x72, 0x65, 0x76, 0x65, 0x72, 0x73, 0x65,
+	0x52, 0x65, 0x66, 0x65, 0x72, 0x65, 0x6e, 0x63, 0x65, 0x49, 0x6e, 0x64, 0x65, 0x78, 0x18, 0x1c,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x1e, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63,
+	0x68, 0x65, 0x6d, 0x61, 0x2e, 0x43, 0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x61, 0x6c,
+	0x42, 0x6f, 0x6f, 0x6c, 0x52, 0x15, 0x72, 0x65, 0x76, 0x65, 0x72, 0x73, 0x65, 0x52, 0x65, 0x66,
+	0x65, 0x72, 0x65, 0x6e, 0x63, 0x65, 0x49, 0x6e, 0x64, 0x65, 0x78, 0x12, 0x46, 0x0a, 0x0d, 0x73,
+	0x63, 0x72, 0x75, 0x62, 0x49, 0x6e, 0x74, 0x65, 0x72, 0x76, 0x61, 0x6c, 0x18, 0x1d, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x20, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65,
+	0x6d, 0x61, 0x2e, 0x43, 0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x61, 0x6c, 0x55, 0x69,
+	0x6e, 0x74, 0x36, 0x34, 0x52, 0x0d, 0x73, 0x63, 0x72, 0x75, 0x62, 0x49, 0x6e, 0x74, 0x65, 0x72,
+	0x76, 0x61, 0x6c, 0x12, 0x4c, 0x0a, 0x10, 0x64, 0x65, 0x6c, 0x61, 0x79, 0x44, 0x75, 0x72, 0x69,
+	0x6e, 0x67, 0x53, 0x63, 0x72, 0x75, 0x62, 0x18, 0x1e, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x20, 0x2e,
+	0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x43, 0x6f,
+	0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x61, 0x6c, 0x55, 0x69, 0x6e, 0x74, 0x33, 0x32, 0x52,
+	0x10, 0x64, 0x65, 0x6c, 0x61, 0x79, 0x44, 0x75, 0x72, 0x69, 0x6e, 0x67, 0x53, 0x63, 0x72, 0x75,
+	0x62, 0x22, 0x81, 0x0b, 0x0a, 0x0d, 0x49, 0x6e, 0x64, 0x65, 0x78, 0x53, 0x65, 0x74, 0x74, 0x69,
+	0x6e, 0x67, 0x73, 0x12, 0x48, 0x0a, 0x0e, 0x66, 0x6c, 0x75, 0x73, 0x68, 0x54, 0x68, 0x72, 0x65,
+	0x73, 0x68, 0x6f, 0x6c, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x69, 0x6d,
+	0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x43, 0x6f, 0x6e, 0x64,
+	0x69, 0x74, 0x69, 0x6f, 0x6e, 0x61, 0x6c, 0x55, 0x69, 0x6e, 0x74, 0x33, 0x32, 0x52, 0x0e, 0x66,
+	0x6c, 0x75, 0x73, 0x68, 0x54, 0x68, 0x72, 0x65, 0x73, 0x68, 0x6f, 0x6c, 0x64, 0x12, 0x46, 0x0a,
+	0x0d, 0x73, 0x79, 0x6e, 0x63, 0x54, 0x68, 0x72, 0x65, 0x73, 0x68, 0x6f, 0x6c, 0x64, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63,
+	0x68, 0x65, 0x6d, 0x61, 0x2e, 0x43, 0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x61, 0x6c,
+	0x55, 0x69, 0x6e, 0x74, 0x33, 0x32, 0x52, 0x0d, 0x73, 0x79, 0x6e, 0x63, 0x54, 0x68, 0x72, 0x65,
+	0x73, 0x68, 0x6f, 0x6c, 0x64, 0x12, 0x3e, 0x0a, 0x09, 0x63, 0x61, 0x63, 0x68, 0x65, 0x53, 0x69,
+	0x7a, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64,
+	0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x43, 0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69,
+	0x6f, 0x6e, 0x61, 0x6c, 0x55, 0x69, 0x6e, 0x74, 0x33, 0x32, 0x52, 0x09, 0x63, 0x61, 0x63, 0x68,
+	0x65, 0x53, 0x69, 0x7a, 0x65, 0x12, 0x42, 0x0a, 0x0b, 0x6d, 0x61, 0x78, 0x4e, 0x6f, 0x64, 0x65,
+	0x53, 0x69, 0x7a, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x69, 0x6d, 0x6d,
+	0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x43, 0x6f, 0x6e, 0x64, 0x69,
+	0x74, 0x69, 0x6f, 0x6e, 0x61, 0x6c, 0x55, 0x69, 0x6e, 0x74, 0x33, 0x32, 0x52, 0x0b, 0x6d, 0x61,
+	0x78, 0x4e, 0x6f, 0x64, 0x65, 0x53, 0x69, 0x7a, 0x65, 0x12, 0x50, 0x0a, 0x12, 0x6d, 0x61, 0x78,
+	0x41, 0x63, 0x74, 0x69, 0x76, 0x65, 0x53, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x73, 0x18,
+	0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73,
+	0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x43, 0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x61,
+	0x6c, 0x55, 0x69, 0x6e, 0x74, 0x33, 0x32, 0x52, 0x12, 0x6d, 0x61, 0x78, 0x41, 0x63, 0x74, 0x69,
+	0x76, 0x65, 0x53, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x73, 0x12, 0x50, 0x0a, 0x12, 0x72,
+	0x65, 0x6e, 0x65, 0x77, 0x53, 0x6e, 0x61, 0x70, 0x52, 0x6f, 0x6f, 0x74, 0x41, 0x66, 0x74, 0x65,
+	0x72, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62,
+	0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x43, 0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f,
+	0x6e, 0x61, 0x6c, 0x55, 0x69, 0x6e, 0x74, 0x36, 0x34, 0x52, 0x12, 0x72, 0x65, 0x6e, 0x65, 0x77,
+	0x53, 0x6e, 0x61, 0x70, 0x52, 0x6f, 0x6f, 0x74, 0x41, 0x66, 0x74, 0x65, 0x72, 0x12, 0x48, 0x0a,
+	0x0e, 0x63, 0x6f, 0x6d, 0x70, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x54, 0x68, 0x6c, 0x64, 0x18,
+	0x07, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73,
+	0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x43, 0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x61,
+	0x6c, 0x55, 0x69, 0x6e, 0x74, 0x33, 0x32, 0x52, 0x0e, 0x63, 0x6f, 0x6d, 0x70, 0x61, 0x63, 0x74,
+	0x69, 0x6f, 0x6e, 0x54, 0x68, 0x6c, 0x64, 0x12, 0x56, 0x0a, 0x15, 0x64, 0x65, 0x6c, 0x61, 0x79,
+	0x44, 0x75, 0x72, 0x69, 0x6e, 0x67, 0x43, 0x6f, 0x6d, 0x70, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e,
+	0x18, 0x08, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e,
+	0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x43, 0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e,
+	0x61, 0x6c, 0x55, 0x69, 0x6e, 0x74, 0x33, 0x32, 0x52, 0x15, 0x64, 0x65, 0x6c, 0x61, 0x79, 0x44,
+	0x75, 0x72, 0x69, 0x6e, 0x67, 0x43, 0x6f, 0x6d, 0x70, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12,
+	0x58, 0x0a, 0x16, 0x6e, 0x6f, 0x64, 0x65, 0x73, 0x4c, 0x6f, 0x67, 0x4d, 0x61, 0x78, 0x4f, 0x70,
+	0x65, 0x6e, 0x65, 0x64, 0x46, 0x69, 0x6c, 0x65, 0x73, 0x18, 0x09, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x20, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e,
+	0x43, 0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x61, 0x6c, 0x55, 0x69, 0x6e, 0x74, 0x33,
+	0x32, 0x52, 0x16, 0x6e, 0x6f, 0x64, 0x65, 0x73, 0x4c, 0x6f, 0x67, 0x4d, 0x61, 0x78, 0x4f, 0x70,
+	0x65, 0x6e, 0x65, 0x64, 0x46, 0x69, 0x6c, 0x65, 0x73, 0x12, 0x5c, 0x0a, 0x18, 0x68, 0x69, 0x73,
+	0x74, 0x6f, 0x72, 0x79, 0x4c, 0x6f, 0x67, 0x4d, 0x61, 0x78, 0x4f, 0x70, 0x65, 0x6e, 0x65, 0x64,
+	0x46, 0x69, 0x6c, 0x65, 0x73, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x69, 0x6d,
+	0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x43, 0x6f, 0x6e, 0x64,
+	0x69, 0x74, 0x69, 0x6f, 0x6e, 0x61, 0x6c, 0x55, 0x69, 0x6e, 0x74, 0x33, 0x32, 0x52, 0x18, 0x68,
+	0x69, 0x73, 0x74, 0x6f, 0x72, 0x79, 0x4c, 0x6f, 0x67, 0x4d, 0x61, 0x78, 0x4f, 0x70, 0x65, 0x6e,
+	0x65, 0x64, 0x46, 0x69, 0x6c, 0x65, 0x73, 0x12, 0x5a, 0x0a, 0x17, 0x63, 0x6f, 0x6d, 0x6d, 0x69,
+	0x74, 0x4c, 0x6f, 0x67, 0x4d, 0x61, 0x78, 0x4f, 0x70, 0x65, 0x6e, 0x65, 0x64, 0x46, 0x69, 0x6c,
+	0x65, 0x73, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64,
+	0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x43, 0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69,
+	0x6f, 0x6e, 0x61, 0x6c, 0x55, 0x69, 0x6e, 0x74, 0x33, 0x32, 0x52, 0x17, 0x63, 0x6f, 0x6d, 0x6d,
+	0x69, 0x74, 0x4c, 0x6f, 0x67, 0x4d, 0x61, 0x78, 0x4f, 0x70, 0x65, 0x6e, 0x65, 0x64, 0x46, 0x69,
+	0x6c, 0x65, 0x73, 0x12, 0x4a, 0x0a, 0x0f, 0x66, 0x6c, 0x75, 0x73, 0x68, 0x42, 0x75, 0x66, 0x66,
+	0x65, 0x72, 0x53, 0x69, 0x7a, 0x65, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x69,
+	0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x43, 0x6f, 0x6e,
+	0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x61, 0x6c, 0x55, 0x69, 0x6e, 0x74, 0x33, 0x32, 0x52, 0x0f,
+	0x66, 0x6c, 0x75, 0x73, 0x68, 0x42, 0x75, 0x66, 0x66, 0x65, 0x72, 0x53, 0x69, 0x7a, 0x65, 0x12,
+	0x4d, 0x0a, 0x11, 0x63, 0x6c, 0x65, 0x61, 0x6e, 0x75, 0x70, 0x50, 0x65, 0x72, 0x63, 0x65, 0x6e,
+	0x74, 0x61, 0x67, 0x65, 0x18, 0x0d, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1f, 0x2e, 0x69, 0x6d, 0x6d,
+	0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x43, 0x6f, 0x6e, 0x64, 0x69,
+	0x74, 0x69, 0x6f, 0x6e, 0x61, 0x6c, 0x46, 0x6c, 0x6f, 0x61, 0x74, 0x52, 0x11, 0x63, 0x6c, 0x65,
+	0x61, 0x6e, 0x75, 0x70, 0x50, 0x65, 0x72, 0x63, 0x65, 0x6e, 0x74, 0x61, 0x67, 0x65, 0x12, 0x46,
+	0x0a, 0x0e, 0x61, 0x75, 0x74, 0x6f, 0x43, 0x6f, 0x6d, 0x70, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e,
+	0x18, 0x0e, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1e, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e,
+	0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x43, 0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e,
+	0x61, 0x6c, 0x42, 0x6f, 0x6f, 0x6c, 0x52, 0x0e, 0x61, 0x75, 0x74, 0x6f, 0x43, 0x6f, 0x6d, 0x70,
+	0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x5d, 0x0a, 0x19, 0x61, 0x75, 0x74, 0x6f, 0x43, 0x6f,
+	0x6d, 0x70, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x47, 0x61, 0x72, 0x62, 0x61, 0x67, 0x65, 0x54,
+	0x68, 0x6c, 0x64, 0x18, 0x0f, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1f, 0x2e, 0x69, 0x6d, 0x6d, 0x75,
+	0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x43, 0x6f, 0x6e, 0x64, 0x69, 0x74,
+	0x69, 0x6f, 0x6e, 0x61, 0x6c, 0x46, 0x6c, 0x6f, 0x61, 0x74, 0x52, 0x19, 0x61, 0x75, 0x74, 0x6f,
+	0x43, 0x6f, 0x6d, 0x70, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x47, 0x61, 0x72, 0x62, 0x61, 0x67,
+	0x65, 0x54, 0x68, 0x6c, 0x64, 0x12, 0x5a, 0x0a, 0x17, 0x61, 0x75, 0x74, 0x6f, 0x43, 0x6f, 0x6d,
+	0x70, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x46, 0x69, 0x6c, 0x65, 0x73, 0x54, 0x68, 0x6c, 0x64,
+	0x18, 0x10, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e,
+	0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x43, 0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e,
+	0x61, 0x6c, 0x55, 0x69, 0x6e, 0x74, 0x33, 0x32, 0x52, 0x17, 0x61, 0x75, 0x74, 0x6f, 0x43, 0x6f,
+	0x6d, 0x70, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x46, 0x69, 0x6c, 0x65, 0x73, 0x54, 0x68, 0x6c,
+	0x64, 0x12, 0x62, 0x0a, 0x1b, 0x61, 0x75, 0x74, 0x6f, 0x43, 0x6f, 0x6d, 0x70, 0x61, 0x63, 0x74,
+	0x69, 0x6f, 0x6e, 0x4c, 0x61, 0x74, 0x65, 0x6e, 0x63, 0x79, 0x42, 0x75, 0x64, 0x67, 0x65, 0x74,
+	0x18, 0x11, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e,
+	0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x43, 0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e,
+	0x61, 0x6c, 0x55, 0x69, 0x6e, 0x74, 0x33, 0x32, 0x52, 0x1b, 0x61, 0x75, 0x74, 0x6f, 0x43, 0x6f,
+	0x6d, 0x70, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x4c, 0x61, 0x74, 0x65, 0x6e, 0x63, 0x79, 0x42,
+	0x75, 0x64, 0x67, 0x65, 0x74, 0x22, 0x59, 0x0a, 0x11, 0x46, 0x6c, 0x75, 0x73, 0x68, 0x49, 0x6e,
+	0x64, 0x65, 0x78, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x2c, 0x0a, 0x11, 0x63, 0x6c,
+	0x65, 0x61, 0x6e, 0x75, 0x70, 0x50, 0x65, 0x72, 0x63, 0x65, 0x6e, 0x74, 0x61, 0x67, 0x65, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x02, 0x52, 0x11, 0x63, 0x6c, 0x65, 0x61, 0x6e, 0x75, 0x70, 0x50, 0x65,
+	0x72, 0x63, 0x65, 0x6e, 0x74, 0x61, 0x67, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x79, 0x6e, 0x63,
+	0x65, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x73, 0x79, 0x6e, 0x63, 0x65, 0x64,
+	0x22, 0x25, 0x0a, 0x05, 0x54, 0x61, 0x62, 0x6c, 0x65, 0x12, 0x1c, 0x0a, 0x09, 0x74, 0x61, 0x62,
+	0x6c, 0x65, 0x4e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x74, 0x61,
+	0x62, 0x6c, 0x65, 0x4e, 0x61, 0x6d, 0x65, 0x22, 0x88, 0x01, 0x0a, 0x0d, 0x53, 0x51, 0x4c, 0x47,
+	0x65, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x74, 0x61, 0x62,
+	0x6c, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x74, 0x61, 0x62, 0x6c, 0x65, 0x12,
+	0x33, 0x0a, 0x08, 0x70, 0x6b, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28,
+	0x0b, 0x32, 0x17, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d,
+	0x61, 0x2e, 0x53, 0x51, 0x4c, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x52, 0x08, 0x70, 0x6b, 0x56, 0x61,
+	0x6c, 0x75, 0x65, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x61, 0x74, 0x54, 0x78, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x04, 0x52, 0x04, 0x61, 0x74, 0x54, 0x78, 0x12, 0x18, 0x0a, 0x07, 0x73, 0x69, 0x6e, 0x63,
+	0x65, 0x54, 0x78, 0x18, 0x04, 0x20, 0x01, 0x28, 0x04, 0x52, 0x07, 0x73, 0x69, 0x6e, 0x63, 0x65,
+	0x54, 0x78, 0x22, 0x81, 0x01, 0x0a, 0x17, 0x56, 0x65, 0x72, 0x69, 0x66, 0x69, 0x61, 0x62, 0x6c,
+	0x65, 0x53, 0x51, 0x4c, 0x47, 0x65, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x42,
+	0x0a, 0x0d, 0x73, 0x71, 0x6c, 0x47, 0x65, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1c, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73,
+	0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x53, 0x51, 0x4c, 0x47, 0x65, 0x74, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x52, 0x0d, 0x73, 0x71, 0x6c, 0x47, 0x65, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x12, 0x22, 0x0a, 0x0c, 0x70, 0x72, 0x6f, 0x76, 0x65, 0x53, 0x69, 0x6e, 0x63, 0x65,
+	0x54, 0x78, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0c, 0x70, 0x72, 0x6f, 0x76, 0x65, 0x53,
+	0x69, 0x6e, 0x63, 0x65, 0x54, 0x78, 0x22, 0x79, 0x0a, 0x08, 0x53, 0x51, 0x4c, 0x45, 0x6e, 0x74,
+	0x72, 0x79, 0x12, 0x0e, 0x0a, 0x02, 0x74, 0x78, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x02,
+	0x74, 0x78, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52,
+	0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x0c, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x12, 0x35, 0x0a, 0x08, 0x6d, 0x65,
+	0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x69,
+	0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x4b, 0x56, 0x4d,
+	0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x52, 0x08, 0x6d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74,
+	0x61, 0x22, 0x87, 0x07, 0x0a, 0x12, 0x56, 0x65, 0x72, 0x69, 0x66, 0x69, 0x61, 0x62, 0x6c, 0x65,
+	0x53, 0x51, 0x4c, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x33, 0x0a, 0x08, 0x73, 0x71, 0x6c, 0x45,
+	0x6e, 0x74, 0x72, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x69, 0x6d, 0x6d,
+	0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x53, 0x51, 0x4c, 0x45, 0x6e,
+	0x74, 0x72, 0x79, 0x52, 0x08, 0x73, 0x71, 0x6c, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x3f, 0x0a,
+	0x0c, 0x76, 0x65, 0x72, 0x69, 0x66, 0x69, 0x61, 0x62, 0x6c, 0x65, 0x54, 0x78, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x1b, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68,
+	0x65, 0x6d, 0x61, 0x2e, 0x56, 0x65, 0x72, 0x69, 0x66, 0x69, 0x61, 0x62, 0x6c, 0x65, 0x54, 0x78,
+	0x52, 0x0c, 0x76, 0x65, 0x72, 0x69, 0x66, 0x69, 0x61, 0x62, 0x6c, 0x65, 0x54, 0x78, 0x12, 0x45,
+	0x0a, 0x0e, 0x69, 0x6e, 0x63, 0x6c, 0x75, 0x73, 0x69, 0x6f, 0x6e, 0x50, 0x72, 0x6f, 0x6f, 0x66,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1d, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e,
+	0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x49, 0x6e, 0x63, 0x6c, 0x75, 0x73, 0x69, 0x6f, 0x6e,
+	0x50, 0x72, 0x6f, 0x6f, 0x66, 0x52, 0x0e, 0x69, 0x6e, 0x63, 0x6c, 0x75, 0x73, 0x69, 0x6f, 0x6e,
+	0x50, 0x72, 0x6f, 0x6f, 0x66, 0x12, 0x1e, 0x0a, 0x0a, 0x44, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73,
+	0x65, 0x49, 0x64, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0a, 0x44, 0x61, 0x74, 0x61, 0x62,
+	0x61, 0x73, 0x65, 0x49, 0x64, 0x12, 0x18, 0x0a, 0x07, 0x54, 0x61, 0x62, 0x6c, 0x65, 0x49, 0x64,
+	0x18, 0x05, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x07, 0x54, 0x61, 0x62, 0x6c, 0x65, 0x49, 0x64, 0x12,
+	0x14, 0x0a, 0x05, 0x50, 0x4b, 0x49, 0x44, 0x73, 0x18, 0x10, 0x20, 0x03, 0x28, 0x0d, 0x52, 0x05,
+	0x50, 0x4b, 0x49, 0x44, 0x73, 0x12, 0x57, 0x0a, 0x0c, 0x43, 0x6f, 0x6c, 0x4e, 0x61, 0x6d, 0x65,
+	0x73, 0x42, 0x79, 0x49, 0x64, 0x18, 0x08, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x33, 0x2e, 0x69, 0x6d,
+	0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x56, 0x65, 0x72, 0x69,
+	0x66, 0x69, 0x61, 0x62, 0x6c, 0x65, 0x53, 0x51, 0x4c, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x2e, 0x43,
+	0x6f, 0x6c, 0x4e, 0x61, 0x6d, 0x65, 0x73, 0x42, 0x79, 0x49, 0x64, 0x45, 0x6e, 0x74, 0x72, 0x79,
+	0x52, 0x0c, 0x43, 0x6f, 0x6c, 0x4e, 0x61, 0x6d, 0x65, 0x73, 0x42, 0x79, 0x49, 0x64, 0x12, 0x57,
+	0x0a, 0x0c, 0x43, 0x6f, 0x6c, 0x49, 0x64, 0x73, 0x42, 0x79, 0x4e, 0x61, 0x6d, 0x65, 0x18, 0x09,
+	0x20, 0x03, 0x28, 0x0b, 0x32, 0x33, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63,
+	0x68, 0x65, 0x6d, 0x61, 0x2e, 0x56, 0x65, 0x72, 0x69, 0x66, 0x69, 0x61, 0x62, 0x6c, 0x65, 0x53,
+	0x51, 0x4c, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x2e, 0x43, 0x6f, 0x6c, 0x49, 0x64, 0x73, 0x42, 0x79,
+	0x4e, 0x61, 0x6d, 0x65, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x0c, 0x43, 0x6f, 0x6c, 0x49, 0x64,
+	0x73, 0x42, 0x79, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x57, 0x0a, 0x0c, 0x43, 0x6f, 0x6c, 0x54, 0x79,
+	0x70, 0x65, 0x73, 0x42, 0x79, 0x49, 0x64, 0x18, 0x0a, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x33, 0x2e,
+	0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x56, 0x65,
+	0x72, 0x69, 0x66, 0x69, 0x61, 0x62, 0x6c, 0x65, 0x53, 0x51, 0x4c, 0x45, 0x6e, 0x74, 0x72, 0x79,
+	0x2e, 0x43, 0x6f, 0x6c, 0x54, 0x79, 0x70, 0x65, 0x73, 0x42, 0x79, 0x49, 0x64, 0x45, 0x6e, 0x74,
+	0x72, 0x79, 0x52, 0x0c, 0x43, 0x6f, 0x6c, 0x54, 0x79, 0x70, 0x65, 0x73, 0x42, 0x79, 0x49, 0x64,
+	0x12, 0x51, 0x0a, 0x0a, 0x43, 0x6f, 0x6c, 0x4c, 0x65, 0x6e, 0x42, 0x79, 0x49, 0x64, 0x18, 0x0b,
+	0x20, 0x03, 0x28, 0x0b, 0x32, 0x31, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63,
+	0x68, 0x65, 0x6d, 0x61, 0x2e, 0x56, 0x65, 0x72, 0x69, 0x66, 0x69, 0x61, 0x62, 0x6c, 0x65, 0x53,
+	0x51, 0x4c, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x2e, 0x43, 0x6f, 0x6c, 0x4c, 0x65, 0x6e, 0x42, 0x79,
+	0x49, 0x64, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x0a, 0x43, 0x6f, 0x6c, 0x4c, 0x65, 0x6e, 0x42,
+	0x79, 0x49, 0x64, 0x1a, 0x3f, 0x0a, 0x11, 0x43, 0x6f, 0x6c, 0x4e, 0x61, 0x6d, 0x65, 0x73, 0x42,
+	0x79, 0x49, 0x64, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61,
+	0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65,
+	0x3a, 0x02, 0x38, 0x01, 0x1a, 0x3f, 0x0a, 0x11, 0x43, 0x6f, 0x6c, 0x49, 0x64, 0x73, 0x42, 0x79,
+	0x4e, 0x61, 0x6d, 0x65, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76,
+	0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75,
+	0x65, 0x3a, 0x02, 0x38, 0x01, 0x1a, 0x3f, 0x0a, 0x11, 0x43, 0x6f, 0x6c, 0x54, 0x79, 0x70, 0x65,
+	0x73, 0x42, 0x79, 0x49, 0x64, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65,
+	0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05,
+	0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61, 0x6c,
+	0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x1a, 0x3d, 0x0a, 0x0f, 0x43, 0x6f, 0x6c, 0x4c, 0x65, 0x6e,
+	0x42, 0x79, 0x49, 0x64, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76,
+	0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75,
+	0x65, 0x3a, 0x02, 0x38, 0x01, 0x4a, 0x04, 0x08, 0x06, 0x10, 0x07, 0x22, 0x28, 0x0a, 0x10, 0x55,
+	0x73, 0x65, 0x44, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x12,
+	0x14, 0x0a, 0x05, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05,
+	0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x22, 0xaa, 0x01, 0x0a, 0x17, 0x43, 0x68, 0x61, 0x6e, 0x67, 0x65,
+	0x50, 0x65, 0x72, 0x6d, 0x69, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x12, 0x37, 0x0a, 0x06, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x0e, 0x32, 0x1f, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d,
+	0x61, 0x2e, 0x50, 0x65, 0x72, 0x6d, 0x69, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x41, 0x63, 0x74, 0x69,
+	0x6f, 0x6e, 0x52, 0x06, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x1a, 0x0a, 0x08, 0x75, 0x73,
+	0x65, 0x72, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x75, 0x73,
+	0x65, 0x72, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x64, 0x61, 0x74, 0x61, 0x62, 0x61,
+	0x73, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x64, 0x61, 0x74, 0x61, 0x62, 0x61,
+	0x73, 0x65, 0x12, 0x1e, 0x0a, 0x0a, 0x70, 0x65, 0x72, 0x6d, 0x69, 0x73, 0x73, 0x69, 0x6f, 0x6e,
+	0x18, 0x04, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0a, 0x70, 0x65, 0x72, 0x6d, 0x69, 0x73, 0x73, 0x69,
+	0x6f, 0x6e, 0x22, 0x4a, 0x0a, 0x14, 0x53, 0x65, 0x74, 0x41, 0x63, 0x74, 0x69, 0x76, 0x65, 0x55,
+	0x73, 0x65, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x61, 0x63,
+	0x74, 0x69, 0x76, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x61, 0x63, 0x74, 0x69,
+	0x76, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x75, 0x73, 0x65, 0x72, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x75, 0x73, 0x65, 0x72, 0x6e, 0x61, 0x6d, 0x65, 0x22, 0x73,
+	0x0a, 0x14, 0x44, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x4c, 0x69, 0x73, 0x74, 0x52, 0x65,
+	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x35, 0x0a, 0x09, 0x64, 0x61, 0x74, 0x61, 0x62, 0x61,
+	0x73, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x69, 0x6d, 0x6d, 0x75,
+	0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x44, 0x61, 0x74, 0x61, 0x62, 0x61,
+	0x73, 0x65, 0x52, 0x09, 0x64, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x73, 0x12, 0x24, 0x0a,
+	0x0d, 0x69, 0x6e, 0x4d, 0x61, 0x69, 0x6e, 0x74, 0x65, 0x6e, 0x61, 0x6e, 0x63, 0x65, 0x18, 0x02,
+	0x20, 0x03, 0x28, 0x09, 0x52, 0x0d, 0x69, 0x6e, 0x4d, 0x61, 0x69, 0x6e, 0x74, 0x65, 0x6e, 0x61,
+	0x6e, 0x63, 0x65, 0x22, 0x21, 0x0a, 0x05, 0x43, 0x68, 0x75, 0x6e, 0x6b, 0x12, 0x18, 0x0a, 0x07,
+	0x63, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x07, 0x63,
+	0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x22, 0x4e, 0x0a, 0x12, 0x55, 0x73, 0x65, 0x53, 0x6e, 0x61,
+	0x70, 0x73, 0x68, 0x6f, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x18, 0x0a, 0x07,
+	0x73, 0x69, 0x6e, 0x63, 0x65, 0x54, 0x78, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x07, 0x73,
+	0x69, 0x6e, 0x63, 0x65, 0x54, 0x78, 0x12, 0x1e, 0x0a, 0x0a, 0x61, 0x73, 0x42, 0x65, 0x66, 0x6f,
+	0x72, 0x65, 0x54, 0x78, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0a, 0x61, 0x73, 0x42, 0x65,
+	0x66, 0x6f, 0x72, 0x65, 0x54, 0x78, 0x22, 0x6d, 0x0a, 0x0e, 0x53, 0x51, 0x4c, 0x45, 0x78, 0x65,
+	0x63, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x10, 0x0a, 0x03, 0x73, 0x71, 0x6c, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x73, 0x71, 0x6c, 0x12, 0x31, 0x0a, 0x06, 0x70, 0x61,
+	0x72, 0x61, 0x6d, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x69, 0x6d, 0x6d,
+	0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x4e, 0x61, 0x6d, 0x65, 0x64,
+	0x50, 0x61, 0x72, 0x61, 0x6d, 0x52, 0x06, 0x70, 0x61, 0x72, 0x61, 0x6d, 0x73, 0x12, 0x16, 0x0a,
+	0x06, 0x6e, 0x6f, 0x57, 0x61, 0x69, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x6e,
+	0x6f, 0x57, 0x61, 0x69, 0x74, 0x22, 0x7c, 0x0a, 0x0f, 0x53, 0x51, 0x4c, 0x51, 0x75, 0x65, 0x72,
+	0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x10, 0x0a, 0x03, 0x73, 0x71, 0x6c, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x73, 0x71, 0x6c, 0x12, 0x31, 0x0a, 0x06, 0x70, 0x61,
+	0x72, 0x61, 0x6d, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x69, 0x6d, 0x6d,
+	0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x4e, 0x61, 0x6d, 0x65, 0x64,
+	0x50, 0x61, 0x72, 0x61, 0x6d, 0x52, 0x06, 0x70, 0x61, 0x72, 0x61, 0x6d, 0x73, 0x12, 0x24, 0x0a,
+	0x0d, 0x72, 0x65, 0x75, 0x73, 0x65, 0x53, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x08, 0x52, 0x0d, 0x72, 0x65, 0x75, 0x73, 0x65, 0x53, 0x6e, 0x61, 0x70, 0x73,
+	0x68, 0x6f, 0x74, 0x22, 0x4f, 0x0a, 0x0a, 0x4e, 0x61, 0x6d, 0x65, 0x64, 0x50, 0x61, 0x72, 0x61,
+	0x6d, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x2d, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63,
+	0x68, 0x65, 0x6d, 0x61, 0x2e, 0x53, 0x51, 0x4c, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x52, 0x05, 0x76,
+	0x61, 0x6c, 0x75, 0x65, 0x22, 0x5e, 0x0a, 0x0d, 0x53, 0x51, 0x4c, 0x45, 0x78, 0x65, 0x63, 0x52,
+	0x65, 0x73, 0x75, 0x6c, 0x74, 0x12, 0x2f, 0x0a, 0x03, 0x74, 0x78, 0x73, 0x18, 0x05, 0x20, 0x03,
+	0x28, 0x0b, 0x32, 0x1d, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65,
+	0x6d, 0x61, 0x2e, 0x43, 0x6f, 0x6d, 0x6d, 0x69, 0x74, 0x74, 0x65, 0x64, 0x53, 0x51, 0x4c, 0x54,
+	0x78, 0x52, 0x03, 0x74, 0x78, 0x73, 0x12, 0x1c, 0x0a, 0x09, 0x6f, 0x6e, 0x67, 0x6f, 0x69, 0x6e,
+	0x67, 0x54, 0x78, 0x18, 0x06, 0x20, 0x01, 0x28, 0x08, 0x52, 0x09, 0x6f, 0x6e, 0x67, 0x6f, 0x69,
+	0x6e, 0x67, 0x54, 0x78, 0x22, 0xdd, 0x03, 0x0a, 0x0e, 0x43, 0x6f, 0x6d, 0x6d, 0x69, 0x74, 0x74,
+	0x65, 0x64, 0x53, 0x51, 0x4c, 0x54, 0x78, 0x12, 0x2f, 0x0a, 0x06, 0x68, 0x65, 0x61, 0x64, 0x65,
+	0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62,
+	0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x54, 0x78, 0x48, 0x65, 0x61, 0x64, 0x65, 0x72,
+	0x52, 0x06, 0x68, 0x65, 0x61, 0x64, 0x65, 0x72, 0x12, 0x20, 0x0a, 0x0b, 0x75, 0x70, 0x64, 0x61,
+	0x74, 0x65, 0x64, 0x52, 0x6f, 0x77, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0b, 0x75,
+	0x70, 0x64, 0x61, 0x74, 0x65, 0x64, 0x52, 0x6f, 0x77, 0x73, 0x12, 0x5c, 0x0a, 0x0f, 0x6c, 0x61,
+	0x73, 0x74, 0x49, 0x6e, 0x73, 0x65, 0x72, 0x74, 0x65, 0x64, 0x50, 0x4b, 0x73, 0x18, 0x03, 0x20,
+	0x03, 0x28, 0x0b, 0x32, 0x32, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68,
+	0x65, 0x6d, 0x61, 0x2e, 0x43, 0x6f, 0x6d, 0x6d, 0x69, 0x74, 0x74, 0x65, 0x64, 0x53, 0x51, 0x4c,
+	0x54, 0x78, 0x2e, 0x4c, 0x61, 0x73, 0x74, 0x49, 0x6e, 0x73, 0x65, 0x72, 0x74, 0x65, 0x64, 0x50,
+	0x4b, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x0f, 0x6c, 0x61, 0x73, 0x74, 0x49, 0x6e, 0x73,
+	0x65, 0x72, 0x74, 0x65, 0x64, 0x50, 0x4b, 0x73, 0x12, 0x5f, 0x0a, 0x10, 0x66, 0x69, 0x72, 0x73,
+	0x74, 0x49, 0x6e, 0x73, 0x65, 0x72, 0x74, 0x65, 0x64, 0x50, 0x4b, 0x73, 0x18, 0x04, 0x20, 0x03,
+	0x28, 0x0b, 0x32, 0x33, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65,
+	0x6d, 0x61, 0x2e, 0x43, 0x6f, 0x6d, 0x6d, 0x69, 0x74, 0x74, 0x65, 0x64, 0x53, 0x51, 0x4c, 0x54,
+	0x78, 0x2e, 0x46, 0x69, 0x72, 0x73, 0x74, 0x49, 0x6e, 0x73, 0x65, 0x72, 0x74, 0x65, 0x64, 0x50,
+	0x4b, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x10, 0x66, 0x69, 0x72, 0x73, 0x74, 0x49, 0x6e,
+	0x73, 0x65, 0x72, 0x74, 0x65, 0x64, 0x50, 0x4b, 0x73, 0x1a, 0x5b, 0x0a, 0x14, 0x4c, 0x61, 0x73,
+	0x74, 0x49, 0x6e, 0x73, 0x65, 0x72, 0x74, 0x65, 0x64, 0x50, 0x4b, 0x73, 0x45, 0x6e, 0x74, 0x72,
+	0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03,
+	0x6b, 0x65, 0x79, 0x12, 0x2d, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x17, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65,
+	0x6d, 0x61, 0x2e, 0x53, 0x51, 0x4c, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x52, 0x05, 0x76, 0x61, 0x6c,
+	0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x1a, 0x5c, 0x0a, 0x15, 0x46, 0x69, 0x72, 0x73, 0x74, 0x49,
+	0x6e, 0x73, 0x65, 0x72, 0x74, 0x65, 0x64, 0x50, 0x4b, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12,
+	0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65,
+	0x79, 0x12, 0x2d, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x17, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61,
+	0x2e, 0x53, 0x51, 0x4c, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65,
+	0x3a, 0x02, 0x38, 0x01, 0x22, 0x69, 0x0a, 0x0e, 0x53, 0x51, 0x4c, 0x51, 0x75, 0x65, 0x72, 0x79,
+	0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x12, 0x2f, 0x0a, 0x07, 0x63, 0x6f, 0x6c, 0x75, 0x6d, 0x6e,
+	0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62,
+	0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x43, 0x6f, 0x6c, 0x75, 0x6d, 0x6e, 0x52, 0x07,
+	0x63, 0x6f, 0x6c, 0x75, 0x6d, 0x6e, 0x73, 0x12, 0x26, 0x0a, 0x04, 0x72, 0x6f, 0x77, 0x73, 0x18,
+	0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73,
+	0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x52, 0x6f, 0x77, 0x52, 0x04, 0x72, 0x6f, 0x77, 0x73, 0x22,
+	0x30, 0x0a, 0x06, 0x43, 0x6f, 0x6c, 0x75, 0x6d, 0x6e, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d,
+	0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x12, 0x0a,
+	0x04, 0x74, 0x79, 0x70, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x74, 0x79, 0x70,
+	0x65, 0x22, 0x50, 0x0a, 0x03, 0x52, 0x6f, 0x77, 0x12, 0x18, 0x0a, 0x07, 0x63, 0x6f, 0x6c, 0x75,
+	0x6d, 0x6e, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x07, 0x63, 0x6f, 0x6c, 0x75, 0x6d,
+	0x6e, 0x73, 0x12, 0x2f, 0x0a, 0x06, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x73, 0x18, 0x02, 0x20, 0x03,
+	0x28, 0x0b, 0x32, 0x17, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65,
+	0x6d, 0x61, 0x2e, 0x53, 0x51, 0x4c, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x52, 0x06, 0x76, 0x61, 0x6c,
+	0x75, 0x65, 0x73, 0x22, 0x99, 0x01, 0x0a, 0x08, 0x53, 0x51, 0x4c, 0x56, 0x61, 0x6c, 0x75, 0x65,
+	0x12, 0x30, 0x0a, 0x04, 0x6e, 0x75, 0x6c, 0x6c, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x1a,
+	0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66,
+	0x2e, 0x4e, 0x75, 0x6c, 0x6c, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x48, 0x00, 0x52, 0x04, 0x6e, 0x75,
+	0x6c, 0x6c, 0x12, 0x0e, 0x0a, 0x01, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x48, 0x00, 0x52,
+	0x01, 0x6e, 0x12, 0x0e, 0x0a, 0x01, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x48, 0x00, 0x52,
+	0x01, 0x73, 0x12, 0x0e, 0x0a, 0x01, 0x62, 0x18, 0x04, 0x20, 0x01, 0x28, 0x08, 0x48, 0x00, 0x52,
+	0x01, 0x62, 0x12, 0x10, 0x0a, 0x02, 0x62, 0x73, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0c, 0x48, 0x00,
+	0x52, 0x02, 0x62, 0x73, 0x12, 0x10, 0x0a, 0x02, 0x74, 0x73, 0x18, 0x06, 0x20, 0x01, 0x28, 0x03,
+	0x48, 0x00, 0x52, 0x02, 0x74, 0x73, 0x42, 0x07, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x22,
+	0x39, 0x0a, 0x0c, 0x4e, 0x65, 0x77, 0x54, 0x78, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12,
+	0x29, 0x0a, 0x04, 0x6d, 0x6f, 0x64, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x15, 0x2e,
+	0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x54, 0x78,
+	0x4d, 0x6f, 0x64, 0x65, 0x52, 0x04, 0x6d, 0x6f, 0x64, 0x65, 0x22, 0x35, 0x0a, 0x0d, 0x4e, 0x65,
+	0x77, 0x54, 0x78, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x24, 0x0a, 0x0d, 0x74,
+	0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x49, 0x44, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x0d, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x49,
+	0x44, 0x22, 0x87, 0x02, 0x0a, 0x0c, 0x45, 0x78, 0x74, 0x65, 0x72, 0x6e, 0x61, 0x6c, 0x52, 0x6f,
+	0x6f, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x06, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x12, 0x33, 0x0a, 0x04, 0x74, 0x79,
+	0x70, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x1f, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64,
+	0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x45, 0x78, 0x74, 0x65, 0x72, 0x6e, 0x61,
+	0x6c, 0x52, 0x6f, 0x6f, 0x74, 0x54, 0x79, 0x70, 0x65, 0x52, 0x04, 0x74, 0x79, 0x70, 0x65, 0x12,
+	0x1a, 0x0a, 0x08, 0x74, 0x72, 0x65, 0x65, 0x53, 0x69, 0x7a, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x04, 0x52, 0x08, 0x74, 0x72, 0x65, 0x65, 0x53, 0x69, 0x7a, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x72,
+	0x6f, 0x6f, 0x74, 0x48, 0x61, 0x73, 0x68, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x08, 0x72,
+	0x6f, 0x6f, 0x74, 0x48, 0x61, 0x73, 0x68, 0x12, 0x36, 0x0a, 0x09, 0x73, 0x69, 0x67, 0x6e, 0x61,
+	0x74, 0x75, 0x72, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x69, 0x6d, 0x6d,
+	0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x53, 0x69, 0x67, 0x6e, 0x61,
+	0x74, 0x75, 0x72, 0x65, 0x52, 0x09, 0x73, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x12,
+	0x1e, 0x0a, 0x0a, 0x6f, 0x62, 0x73, 0x65, 0x72, 0x76, 0x65, 0x64, 0x41, 0x74, 0x18, 0x06, 0x20,
+	0x01, 0x28, 0x03, 0x52, 0x0a, 0x6f, 0x62, 0x73, 0x65, 0x72, 0x76, 0x65, 0x64, 0x41, 0x74, 0x12,
+	0x1a, 0x0a, 0x08, 0x76, 0x65, 0x72, 0x69, 0x66, 0x69, 0x65, 0x64, 0x18, 0x07, 0x20, 0x01, 0x28,
+	0x08, 0x52, 0x08, 0x76, 0x65, 0x72, 0x69, 0x66, 0x69, 0x65, 0x64, 0x22, 0x7c, 0x0a, 0x14, 0x45,
+	0x78, 0x74, 0x65, 0x72, 0x6e, 0x61, 0x6c, 0x52, 0x6f, 0x6f, 0x74, 0x73, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x06, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x12, 0x22, 0x0a, 0x0c, 0x73,
+	0x65, 0x65, 0x6b, 0x54, 0x72, 0x65, 0x65, 0x53, 0x69, 0x7a, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x04, 0x52, 0x0c, 0x73, 0x65, 0x65, 0x6b, 0x54, 0x72, 0x65, 0x65, 0x53, 0x69, 0x7a, 0x65, 0x12,
+	0x14, 0x0a, 0x05, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x04, 0x52, 0x05,
+	0x6c, 0x69, 0x6d, 0x69, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x64, 0x65, 0x73, 0x63, 0x18, 0x04, 0x20,
+	0x01, 0x28, 0x08, 0x52, 0x04, 0x64, 0x65, 0x73, 0x63, 0x22, 0x45, 0x0a, 0x10, 0x45, 0x78, 0x74,
+	0x65, 0x72, 0x6e, 0x61, 0x6c, 0x52, 0x6f, 0x6f, 0x74, 0x4c, 0x69, 0x73, 0x74, 0x12, 0x31, 0x0a,
+	0x05, 0x72, 0x6f, 0x6f, 0x74, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1b, 0x2e, 0x69,
+	0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x45, 0x78, 0x74,
+	0x65, 0x72, 0x6e, 0x61, 0x6c, 0x52, 0x6f, 0x6f, 0x74, 0x52, 0x05, 0x72, 0x6f, 0x6f, 0x74, 0x73,
+	0x22, 0x95, 0x01, 0x0a, 0x08, 0x4b, 0x56, 0x53, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x12, 0x16, 0x0a,
+	0x06, 0x70, 0x72, 0x65, 0x66, 0x69, 0x78, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x06, 0x70,
+	0x72, 0x65, 0x66, 0x69, 0x78, 0x12, 0x2f, 0x0a, 0x04, 0x74, 0x79, 0x70, 0x65, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x0e, 0x32, 0x1b, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68,
+	0x65, 0x6d, 0x61, 0x2e, 0x4b, 0x56, 0x53, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x54, 0x79, 0x70, 0x65,
+	0x52, 0x04, 0x74, 0x79, 0x70, 0x65, 0x12, 0x1e, 0x0a, 0x0a, 0x64, 0x65, 0x66, 0x69, 0x6e, 0x69,
+	0x74, 0x69, 0x6f, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0a, 0x64, 0x65, 0x66, 0x69,
+	0x6e, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x20, 0x0a, 0x0b, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67,
+	0x65, 0x54, 0x79, 0x70, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x6d, 0x65, 0x73,
+	0x73, 0x61, 0x67, 0x65, 0x54, 0x79, 0x70, 0x65, 0x22, 0x29, 0x0a, 0x0f, 0x4b, 0x56, 0x53, 0x63,
+	0x68, 0x65, 0x6d, 0x61, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x70,
+	0x72, 0x65, 0x66, 0x69, 0x78, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x06, 0x70, 0x72, 0x65,
+	0x66, 0x69, 0x78, 0x22, 0x41, 0x0a, 0x0c, 0x4b, 0x56, 0x53, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x4c,
+	0x69, 0x73, 0x74, 0x12, 0x31, 0x0a, 0x07, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x73, 0x18, 0x01,
+	0x20, 0x03, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63,
+	0x68, 0x65, 0x6d, 0x61, 0x2e, 0x4b, 0x56, 0x53, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x52, 0x07, 0x73,
+	0x63, 0x68, 0x65, 0x6d, 0x61, 0x73, 0x22, 0xab, 0x01, 0x0a, 0x0d, 0x52, 0x65, 0x64, 0x61, 0x63,
+	0x74, 0x69, 0x6f, 0x6e, 0x52, 0x75, 0x6c, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x70, 0x72, 0x65, 0x66,
+	0x69, 0x78, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x06, 0x70, 0x72, 0x65, 0x66, 0x69, 0x78,
+	0x12, 0x14, 0x0a, 0x05, 0x74, 0x61, 0x62, 0x6c, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x05, 0x74, 0x61, 0x62, 0x6c, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x63, 0x6f, 0x6c, 0x75, 0x6d, 0x6e,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x63, 0x6f, 0x6c, 0x75, 0x6d, 0x6e, 0x12, 0x30,
+	0x0a, 0x04, 0x6d, 0x6f, 0x64, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x1c, 0x2e, 0x69,
+	0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x52, 0x65, 0x64,
+	0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x4d, 0x6f, 0x64, 0x65, 0x52, 0x04, 0x6d, 0x6f, 0x64, 0x65,
+	0x12, 0x22, 0x0a, 0x0c, 0x76, 0x69, 0x73, 0x69, 0x62, 0x6c, 0x65, 0x43, 0x68, 0x61, 0x72, 0x73,
+	0x18, 0x05, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0c, 0x76, 0x69, 0x73, 0x69, 0x62, 0x6c, 0x65, 0x43,
+	0x68, 0x61, 0x72, 0x73, 0x22, 0x5c, 0x0a, 0x14, 0x52, 0x65, 0x64, 0x61, 0x63, 0x74, 0x69, 0x6f,
+	0x6e, 0x52, 0x75, 0x6c, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x16, 0x0a, 0x06,
+	0x70, 0x72, 0x65, 0x66, 0x69, 0x78, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x06, 0x70, 0x72,
+	0x65, 0x66, 0x69, 0x78, 0x12, 0x14, 0x0a, 0x05, 0x74, 0x61, 0x62, 0x6c, 0x65, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x05, 0x74, 0x61, 0x62, 0x6c, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x63, 0x6f,
+	0x6c, 0x75, 0x6d, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x63, 0x6f, 0x6c, 0x75,
+	0x6d, 0x6e, 0x22, 0x47, 0x0a, 0x11, 0x52, 0x65, 0x64, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x52,
+	0x75, 0x6c, 0x65, 0x4c, 0x69, 0x73, 0x74, 0x12, 0x32, 0x0a, 0x05, 0x72, 0x75, 0x6c, 0x65, 0x73,
+	0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1c, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e,
+	0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x52, 0x65, 0x64, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e,
+	0x52, 0x75, 0x6c, 0x65, 0x52, 0x05, 0x72, 0x75, 0x6c, 0x65, 0x73, 0x22, 0x30, 0x0a, 0x12, 0x52,
+	0x65, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x12, 0x1a, 0x0a, 0x08, 0x64, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x08, 0x64, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x22, 0x93, 0x02,
+	0x0a, 0x19, 0x52, 0x65, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x53, 0x74, 0x61,
+	0x74, 0x75, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x64,
+	0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x64,
+	0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x72, 0x75, 0x6e, 0x6e, 0x69,
+	0x6e, 0x67, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52, 0x07, 0x72, 0x75, 0x6e, 0x6e, 0x69, 0x6e,
+	0x67, 0x12, 0x16, 0x0a, 0x06, 0x70, 0x61, 0x75, 0x73, 0x65, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x08, 0x52, 0x06, 0x70, 0x61, 0x75, 0x73, 0x65, 0x64, 0x12, 0x1c, 0x0a, 0x09, 0x63, 0x6f, 0x6e,
+	0x6e, 0x65, 0x63, 0x74, 0x65, 0x64, 0x18, 0x04, 0x20, 0x01, 0x28, 0x08, 0x52, 0x09, 0x63, 0x6f,
+	0x6e, 0x6e, 0x65, 0x63, 0x74, 0x65, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x6c, 0x61, 0x73, 0x74, 0x54,
+	0x78, 0x18, 0x05, 0x20, 0x01, 0x28, 0x04, 0x52, 0x06, 0x6c, 0x61, 0x73, 0x74, 0x54, 0x78, 0x12,
+	0x1a, 0x0a, 0x08, 0x6d, 0x61, 0x73, 0x74, 0x65, 0x72, 0x54, 0x78, 0x18, 0x06, 0x20, 0x01, 0x28,
+	0x04, 0x52, 0x08, 0x6d, 0x61, 0x73, 0x74, 0x65, 0x72, 0x54, 0x78, 0x12, 0x10, 0x0a, 0x03, 0x6c,
+	0x61, 0x67, 0x18, 0x07, 0x20, 0x01, 0x28, 0x04, 0x52, 0x03, 0x6c, 0x61, 0x67, 0x12, 0x26, 0x0a,
+	0x0e, 0x66, 0x61, 0x69, 0x6c, 0x65, 0x64, 0x41, 0x74, 0x74, 0x65, 0x6d, 0x70, 0x74, 0x73, 0x18,
+	0x08, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0e, 0x66, 0x61, 0x69, 0x6c, 0x65, 0x64, 0x41, 0x74, 0x74,
+	0x65, 0x6d, 0x70, 0x74, 0x73, 0x12, 0x1c, 0x0a, 0x09, 0x6c, 0x61, 0x73, 0x74, 0x45, 0x72, 0x72,
+	0x6f, 0x72, 0x18, 0x09, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x6c, 0x61, 0x73, 0x74, 0x45, 0x72,
+	0x72, 0x6f, 0x72, 0x22, 0x72, 0x0a, 0x14, 0x4b, 0x65, 0x79, 0x73, 0x70, 0x61, 0x63, 0x65, 0x53,
+	0x74, 0x61, 0x74, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1a, 0x0a, 0x08, 0x64,
+	0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x64,
+	0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x12, 0x22, 0x0a, 0x0c, 0x70, 0x72, 0x65, 0x66, 0x69,
+	0x78, 0x4c, 0x65, 0x6e, 0x67, 0x74, 0x68, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0c, 0x70,
+	0x72, 0x65, 0x66, 0x69, 0x78, 0x4c, 0x65, 0x6e, 0x67, 0x74, 0x68, 0x12, 0x1a, 0x0a, 0x08, 0x6f,
+	0x75, 0x74, 0x6c, 0x69, 0x65, 0x72, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x08, 0x6f,
+	0x75, 0x74, 0x6c, 0x69, 0x65, 0x72, 0x73, 0x22, 0xac, 0x03, 0x0a, 0x15, 0x4b, 0x65, 0x79, 0x73,
+	0x70, 0x61, 0x63, 0x65, 0x53, 0x74, 0x61, 0x74, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
+	0x65, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x78, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52,
+	0x04, 0x74, 0x78, 0x49, 0x64, 0x12, 0x3a, 0x0a, 0x09, 0x6b, 0x65, 0x79, 0x73, 0x70, 0x61, 0x63,
+	0x65, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1c, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64,
+	0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x4b, 0x65, 0x79, 0x73, 0x70, 0x61, 0x63,
+	0x65, 0x55, 0x73, 0x61, 0x67, 0x65, 0x52, 0x09, 0x6b, 0x65, 0x79, 0x73, 0x70, 0x61, 0x63, 0x65,
+	0x73, 0x12, 0x38, 0x0a, 0x08, 0x70, 0x72, 0x65, 0x66, 0x69, 0x78, 0x65, 0x73, 0x18, 0x03, 0x20,
+	0x03, 0x28, 0x0b, 0x32, 0x1c, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68,
+	0x65, 0x6d, 0x61, 0x2e, 0x4b, 0x65, 0x79, 0x73, 0x70, 0x61, 0x63, 0x65, 0x55, 0x73, 0x61, 0x67,
+	0x65, 0x52, 0x08, 0x70, 0x72, 0x65, 0x66, 0x69, 0x78, 0x65, 0x73, 0x12, 0x42, 0x0a, 0x0d, 0x6f,
+	0x74, 0x68, 0x65, 0x72, 0x50, 0x72, 0x65, 0x66, 0x69, 0x78, 0x65, 0x73, 0x18, 0x04, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x1c, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65,
+	0x6d, 0x61, 0x2e, 0x4b, 0x65, 0x79, 0x73, 0x70, 0x61, 0x63, 0x65, 0x55, 0x73, 0x61, 0x67, 0x65,
+	0x52, 0x0d, 0x6f, 0x74, 0x68, 0x65, 0x72, 0x50, 0x72, 0x65, 0x66, 0x69, 0x78, 0x65, 0x73, 0x12,
+	0x3b, 0x0a, 0x08, 0x6b, 0x65, 0x79, 0x53, 0x69, 0x7a, 0x65, 0x73, 0x18, 0x05, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x1f, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d,
+	0x61, 0x2e, 0x53, 0x69, 0x7a, 0x65, 0x44, 0x69, 0x73, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x69,
+	0x6f, 0x6e, 0x52, 0x08, 0x6b, 0x65, 0x79, 0x53, 0x69, 0x7a, 0x65, 0x73, 0x12, 0x3f, 0x0a, 0x0a,
+	0x76, 0x61, 0x6c, 0x75, 0x65, 0x53, 0x69, 0x7a, 0x65, 0x73, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x1f, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61,
+	0x2e, 0x53, 0x69, 0x7a, 0x65, 0x44, 0x69, 0x73, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x69, 0x6f,
+	0x6e, 0x52, 0x0a, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x53, 0x69, 0x7a, 0x65, 0x73, 0x12, 0x47, 0x0a,
+	0x10, 0x72, 0x65, 0x76, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x4f, 0x75, 0x74, 0x6c, 0x69, 0x65, 0x72,
+	0x73, 0x18, 0x07, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1b, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62,
+	0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x4b, 0x65, 0x79, 0x52, 0x65, 0x76, 0x69, 0x73,
+	0x69, 0x6f, 0x6e, 0x73, 0x52, 0x10, 0x72, 0x65, 0x76, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x4f, 0x75,
+	0x74, 0x6c, 0x69, 0x65, 0x72, 0x73, 0x22, 0x8b, 0x01, 0x0a, 0x0d, 0x4b, 0x65, 0x79, 0x73, 0x70,
+	0x61, 0x63, 0x65, 0x55, 0x73, 0x61, 0x67, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x16, 0x0a, 0x06,
+	0x70, 0x72, 0x65, 0x66, 0x69, 0x78, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x06, 0x70, 0x72,
+	0x65, 0x66, 0x69, 0x78, 0x12, 0x12, 0x0a, 0x04, 0x6b, 0x65, 0x79, 0x73, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x04, 0x52, 0x04, 0x6b, 0x65, 0x79, 0x73, 0x12, 0x1a, 0x0a, 0x08, 0x6b, 0x65, 0x79, 0x42,
+	0x79, 0x74, 0x65, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x04, 0x52, 0x08, 0x6b, 0x65, 0x79, 0x42,
+	0x79, 0x74, 0x65, 0x73, 0x12, 0x1e, 0x0a, 0x0a, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x42, 0x79, 0x74,
+	0x65, 0x73, 0x18, 0x05, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0a, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x42,
+	0x79, 0x74, 0x65, 0x73, 0x22, 0x93, 0x01, 0x0a, 0x10, 0x53, 0x69, 0x7a, 0x65, 0x44, 0x69, 0x73,
+	0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x14, 0x0a, 0x05, 0x63, 0x6f, 0x75,
+	0x6e, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x05, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x12,
+	0x10, 0x0a, 0x03, 0x6d, 0x69, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x03, 0x6d, 0x69,
+	0x6e, 0x12, 0x10, 0x0a, 0x03, 0x6d, 0x61, 0x78, 0x18, 0x03, 0x20, 0x01, 0x28, 0x04, 0x52, 0x03,
+	0x6d, 0x61, 0x78, 0x12, 0x10, 0x0a, 0x03, 0x73, 0x75, 0x6d, 0x18, 0x04, 0x20, 0x01, 0x28, 0x04,
+	0x52, 0x03, 0x73, 0x75, 0x6d, 0x12, 0x33, 0x0a, 0x07, 0x62, 0x75, 0x63, 0x6b, 0x65, 0x74, 0x73,
+	0x18, 0x05, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e,
+	0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x53, 0x69, 0x7a, 0x65, 0x42, 0x75, 0x63, 0x6b, 0x65,
+	0x74, 0x52, 0x07, 0x62, 0x75, 0x63, 0x6b, 0x65, 0x74, 0x73, 0x22, 0x42, 0x0a, 0x0a, 0x53, 0x69,
+	0x7a, 0x65, 0x42, 0x75, 0x63, 0x6b, 0x65, 0x74, 0x12, 0x1e, 0x0a, 0x0a, 0x75, 0x70, 0x70, 0x65,
+	0x72, 0x42, 0x6f, 0x75, 0x6e, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0a, 0x75, 0x70,
+	0x70, 0x65, 0x72, 0x42, 0x6f, 0x75, 0x6e, 0x64, 0x12, 0x14, 0x0a, 0x05, 0x63, 0x6f, 0x75, 0x6e,
+	0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x05, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x22, 0x3e,
+	0x0a, 0x0c, 0x4b, 0x65, 0x79, 0x52, 0x65, 0x76, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x10,
+	0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x03, 0x6b, 0x65, 0x79,
+	0x12, 0x1c, 0x0a, 0x09, 0x72, 0x65, 0x76, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x04, 0x52, 0x09, 0x72, 0x65, 0x76, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x22, 0xed,
+	0x01, 0x0a, 0x03, 0x4a, 0x6f, 0x62, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x02, 0x69, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x6b, 0x69, 0x6e, 0x64, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6b, 0x69, 0x6e, 0x64, 0x12, 0x1a, 0x0a, 0x08, 0x64, 0x61,
+	0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x64, 0x61,
+	0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73,
+	0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x1a,
+	0x0a, 0x08, 0x70, 0x72, 0x6f, 0x67, 0x72, 0x65, 0x73, 0x73, 0x18, 0x05, 0x20, 0x01, 0x28, 0x02,
+	0x52, 0x08, 0x70, 0x72, 0x6f, 0x67, 0x72, 0x65, 0x73, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x65, 0x72,
+	0x72, 0x6f, 0x72, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72,
+	0x12, 0x1c, 0x0a, 0x09, 0x73, 0x74, 0x61, 0x72, 0x74, 0x65, 0x64, 0x41, 0x74, 0x18, 0x07, 0x20,
+	0x01, 0x28, 0x03, 0x52, 0x09, 0x73, 0x74, 0x61, 0x72, 0x74, 0x65, 0x64, 0x41, 0x74, 0x12, 0x1e,
+	0x0a, 0x0a, 0x66, 0x69, 0x6e, 0x69, 0x73, 0x68, 0x65, 0x64, 0x41, 0x74, 0x18, 0x08, 0x20, 0x01,
+	0x28, 0x03, 0x52, 0x0a, 0x66, 0x69, 0x6e, 0x69, 0x73, 0x68, 0x65, 0x64, 0x41, 0x74, 0x12, 0x1e,
+	0x0a, 0x0a, 0x63, 0x61, 0x6e, 0x63, 0x65, 0x6c, 0x61, 0x62, 0x6c, 0x65, 0x18, 0x09, 0x20, 0x01,
+	0x28, 0x08, 0x52, 0x0a, 0x63, 0x61, 0x6e, 0x63, 0x65, 0x6c, 0x61, 0x62, 0x6c, 0x65, 0x22, 0x3b,
+	0x0a, 0x0d, 0x53, 0x63, 0x72, 0x75, 0x62, 0x4d, 0x69, 0x73, 0x6d, 0x61, 0x74, 0x63, 0x68, 0x12,
+	0x12, 0x0a, 0x04, 0x74, 0x78, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x04, 0x74,
+	0x78, 0x49, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x72, 0x65, 0x61, 0x73, 0x6f, 0x6e, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x06, 0x72, 0x65, 0x61, 0x73, 0x6f, 0x6e, 0x22, 0xc5, 0x02, 0x0a, 0x13,
+	0x53, 0x63, 0x72, 0x75, 0x62, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f,
+	0x6e, 0x73, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x64, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x64, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x12,
+	0x18, 0x0a, 0x07, 0x72, 0x75, 0x6e, 0x6e, 0x69, 0x6e, 0x67, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08,
+	0x52, 0x07, 0x72, 0x75, 0x6e, 0x6e, 0x69, 0x6e, 0x67, 0x12, 0x1c, 0x0a, 0x09, 0x73, 0x74, 0x61,
+	0x72, 0x74, 0x65, 0x64, 0x41, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x73, 0x74,
+	0x61, 0x72, 0x74, 0x65, 0x64, 0x41, 0x74, 0x12, 0x1e, 0x0a, 0x0a, 0x66, 0x69, 0x6e, 0x69, 0x73,
+	0x68, 0x65, 0x64, 0x41, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0a, 0x66, 0x69, 0x6e,
+	0x69, 0x73, 0x68, 0x65, 0x64, 0x41, 0x74, 0x12, 0x20, 0x0a, 0x0b, 0x73, 0x63, 0x72, 0x75, 0x62,
+	0x62, 0x65, 0x64, 0x54, 0x78, 0x73, 0x18, 0x05, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0b, 0x73, 0x63,
+	0x72, 0x75, 0x62, 0x62, 0x65, 0x64, 0x54, 0x78, 0x73, 0x12, 0x1e, 0x0a, 0x0a, 0x74, 0x61, 0x72,
+	0x67, 0x65, 0x74, 0x54, 0x78, 0x49, 0x64, 0x18, 0x06, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0a, 0x74,
+	0x61, 0x72, 0x67, 0x65, 0x74, 0x54, 0x78, 0x49, 0x64, 0x12, 0x3c, 0x0a, 0x0a, 0x6d, 0x69, 0x73,
+	0x6d, 0x61, 0x74, 0x63, 0x68, 0x65, 0x73, 0x18, 0x07, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1c, 0x2e,
+	0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x53, 0x63,
+	0x72, 0x75, 0x62, 0x4d, 0x69, 0x73, 0x6d, 0x61, 0x74, 0x63, 0x68, 0x52, 0x0a, 0x6d, 0x69, 0x73,
+	0x6d, 0x61, 0x74, 0x63, 0x68, 0x65, 0x73, 0x12, 0x24, 0x0a, 0x0d, 0x6d, 0x69, 0x73, 0x6d, 0x61,
+	0x74, 0x63, 0x68, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x08, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0d,
+	0x6d, 0x69, 0x73, 0x6d, 0x61, 0x74, 0x63, 0x68, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x14, 0x0a,
+	0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x18, 0x09, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x65, 0x72,
+	0x72, 0x6f, 0x72, 0x22, 0x1c, 0x0a, 0x0a, 0x4a, 0x6f, 0x62, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x69,
+	0x64, 0x22, 0x25, 0x0a, 0x0f, 0x4c, 0x69, 0x73, 0x74, 0x4a, 0x6f, 0x62, 0x73, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x6b, 0x69, 0x6e, 0x64, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x04, 0x6b, 0x69, 0x6e, 0x64, 0x22, 0x31, 0x0a, 0x07, 0x4a, 0x6f, 0x62, 0x4c,
+	0x69, 0x73, 0x74, 0x12, 0x26, 0x0a, 0x04, 0x6a, 0x6f, 0x62, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28,
+	0x0b, 0x32, 0x12, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d,
+	0x61, 0x2e, 0x4a, 0x6f, 0x62, 0x52, 0x04, 0x6a, 0x6f, 0x62, 0x73, 0x22, 0x59, 0x0a, 0x09, 0x53,
+	0x65, 0x72, 0x76, 0x65, 0x72, 0x4b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x6b, 0x65, 0x79, 0x49,
+	0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x6b, 0x65, 0x79, 0x49, 0x64, 0x12, 0x1c,
+	0x0a, 0x09, 0x70, 0x75, 0x62, 0x6c, 0x69, 0x63, 0x4b, 0x65, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x0c, 0x52, 0x09, 0x70, 0x75, 0x62, 0x6c, 0x69, 0x63, 0x4b, 0x65, 0x79, 0x12, 0x18, 0x0a, 0x07,
+	0x63, 0x75, 0x72, 0x72, 0x65, 0x6e, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x52, 0x07, 0x63,
+	0x75, 0x72, 0x72, 0x65, 0x6e, 0x74, 0x22, 0x3a, 0x0a, 0x0a, 0x53, 0x65, 0x72, 0x76, 0x65, 0x72,
+	0x4b, 0x65, 0x79, 0x73, 0x12, 0x2c, 0x0a, 0x04, 0x6b, 0x65, 0x79, 0x73, 0x18, 0x01, 0x20, 0x03,
+	0x28, 0x0b, 0x32, 0x18, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65,
+	0x6d, 0x61, 0x2e, 0x53, 0x65, 0x72, 0x76, 0x65, 0x72, 0x4b, 0x65, 0x79, 0x52, 0x04, 0x6b, 0x65,
+	0x79, 0x73, 0x22, 0xff, 0x02, 0x0a, 0x0b, 0x50, 0x72, 0x6f, 0x6f, 0x66, 0x42, 0x75, 0x6e, 0x64,
+	0x6c, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x0d, 0x52, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x2c, 0x0a, 0x04,
+	0x74, 0x79, 0x70, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x18, 0x2e, 0x69, 0x6d, 0x6d,
+	0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x50, 0x72, 0x6f, 0x6f, 0x66,
+	0x54, 0x79, 0x70, 0x65, 0x52, 0x04, 0x74, 0x79, 0x70, 0x65, 0x12, 0x33, 0x0a, 0x05, 0x73, 0x74,
+	0x61, 0x74, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1d, 0x2e, 0x69, 0x6d, 0x6d, 0x75,
+	0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x49, 0x6d, 0x6d, 0x75, 0x74, 0x61,
+	0x62, 0x6c, 0x65, 0x53, 0x74, 0x61, 0x74, 0x65, 0x52, 0x05, 0x73, 0x74, 0x61, 0x74, 0x65, 0x12,
+	0x3f, 0x0a, 0x0b, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x53, 0x74, 0x61, 0x74, 0x65, 0x18, 0x04,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x1d, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63,
+	0x68, 0x65, 0x6d, 0x61, 0x2e, 0x49, 0x6d, 0x6d, 0x75, 0x74, 0x61, 0x62, 0x6c, 0x65, 0x53, 0x74,
+	0x61, 0x74, 0x65, 0x52, 0x0b, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x53, 0x74, 0x61, 0x74, 0x65,
+	0x12, 0x3f, 0x0a, 0x0c, 0x76, 0x65, 0x72, 0x69, 0x66, 0x69, 0x61, 0x62, 0x6c, 0x65, 0x54, 0x78,
+	0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1b, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e,
+	0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x56, 0x65, 0x72, 0x69, 0x66, 0x69, 0x61, 0x62, 0x6c,
+	0x65, 0x54, 0x78, 0x52, 0x0c, 0x76, 0x65, 0x72, 0x69, 0x66, 0x69, 0x61, 0x62, 0x6c, 0x65, 0x54,
+	0x78, 0x12, 0x2a, 0x0a, 0x05, 0x65, 0x6e, 0x74, 0x72, 0x79, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x14, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61,
+	0x2e, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x05, 0x65, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x45, 0x0a,
+	0x0e, 0x69, 0x6e, 0x63, 0x6c, 0x75, 0x73, 0x69, 0x6f, 0x6e, 0x50, 0x72, 0x6f, 0x6f, 0x66, 0x18,
+	0x07, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1d, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73,
+	0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x49, 0x6e, 0x63, 0x6c, 0x75, 0x73, 0x69, 0x6f, 0x6e, 0x50,
+	0x72, 0x6f, 0x6f, 0x66, 0x52, 0x0e, 0x69, 0x6e, 0x63, 0x6c, 0x75, 0x73, 0x69, 0x6f, 0x6e, 0x50,
+	0x72, 0x6f, 0x6f, 0x66, 0x22, 0x54, 0x0a, 0x0b, 0x44, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65,
+	0x4b, 0x56, 0x73, 0x12, 0x1a, 0x0a, 0x08, 0x64, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x64, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x12,
+	0x29, 0x0a, 0x03, 0x4b, 0x56, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x69,
+	0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x4b, 0x65, 0x79,
+	0x56, 0x61, 0x6c, 0x75, 0x65, 0x52, 0x03, 0x4b, 0x56, 0x73, 0x22, 0x65, 0x0a, 0x17, 0x4d, 0x75,
+	0x6c, 0x74, 0x69, 0x44, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x53, 0x65, 0x74, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x32, 0x0a, 0x06, 0x77, 0x72, 0x69, 0x74, 0x65, 0x73, 0x18,
+	0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73,
+	0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x44, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x4b, 0x56,
+	0x73, 0x52, 0x06, 0x77, 0x72, 0x69, 0x74, 0x65, 0x73, 0x12, 0x16, 0x0a, 0x06, 0x6e, 0x6f, 0x57,
+	0x61, 0x69, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x6e, 0x6f, 0x57, 0x61, 0x69,
+	0x74, 0x22, 0x5f, 0x0a, 0x10, 0x44, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x54, 0x78, 0x48,
+	0x65, 0x61, 0x64, 0x65, 0x72, 0x12, 0x1a, 0x0a, 0x08, 0x64, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73,
+	0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x64, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73,
+	0x65, 0x12, 0x2f, 0x0a, 0x06, 0x68, 0x65, 0x61, 0x64, 0x65, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x17, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d,
+	0x61, 0x2e, 0x54, 0x78, 0x48, 0x65, 0x61, 0x64, 0x65, 0x72, 0x52, 0x06, 0x68, 0x65, 0x61, 0x64,
+	0x65, 0x72, 0x22, 0x79, 0x0a, 0x16, 0x4d, 0x75, 0x6c, 0x74, 0x69, 0x44, 0x61, 0x74, 0x61, 0x62,
+	0x61, 0x73, 0x65, 0x54, 0x78, 0x48, 0x65, 0x61, 0x64, 0x65, 0x72, 0x73, 0x12, 0x24, 0x0a, 0x0d,
+	0x74, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x49, 0x64, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x0d, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e,
+	0x49, 0x64, 0x12, 0x39, 0x0a, 0x07, 0x68, 0x65, 0x61, 0x64, 0x65, 0x72, 0x73, 0x18, 0x02, 0x20,
+	0x03, 0x28, 0x0b, 0x32, 0x1f, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68,
+	0x65, 0x6d, 0x61, 0x2e, 0x44, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x54, 0x78, 0x48, 0x65,
+	0x61, 0x64, 0x65, 0x72, 0x52, 0x07, 0x68, 0x65, 0x61, 0x64, 0x65, 0x72, 0x73, 0x22, 0x55, 0x0a,
+	0x0f, 0x4d, 0x75, 0x6c, 0x74, 0x69, 0x44, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x54, 0x78,
+	0x12, 0x24, 0x0a, 0x0d, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x49,
+	0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0d, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63,
+	0x74, 0x69, 0x6f, 0x6e, 0x49, 0x64, 0x12, 0x1c, 0x0a, 0x09, 0x64, 0x61, 0x74, 0x61, 0x62, 0x61,
+	0x73, 0x65, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09, 0x52, 0x09, 0x64, 0x61, 0x74, 0x61, 0x62,
+	0x61, 0x73, 0x65, 0x73, 0x22, 0x35, 0x0a, 0x09, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x49, 0x6e, 0x66,
+	0x6f, 0x12, 0x12, 0x0a, 0x04, 0x63, 0x6f, 0x64, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x04, 0x63, 0x6f, 0x64, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x63, 0x61, 0x75, 0x73, 0x65, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x63, 0x61, 0x75, 0x73, 0x65, 0x22, 0x21, 0x0a, 0x09, 0x44,
+	0x65, 0x62, 0x75, 0x67, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x14, 0x0a, 0x05, 0x73, 0x74, 0x61, 0x63,
+	0x6b, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x73, 0x74, 0x61, 0x63, 0x6b, 0x22, 0x2c,
+	0x0a, 0x09, 0x52, 0x65, 0x74, 0x72, 0x79, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x1f, 0x0a, 0x0b, 0x72,
+	0x65, 0x74, 0x72, 0x79, 0x5f, 0x64, 0x65, 0x6c, 0x61, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05,
+	0x52, 0x0a, 0x72, 0x65, 0x74, 0x72, 0x79, 0x44, 0x65, 0x6c, 0x61, 0x79, 0x2a, 0x4f, 0x0a, 0x09,
+	0x4b, 0x65, 0x79, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x0d, 0x0a, 0x09, 0x4b, 0x45, 0x59,
+	0x5f, 0x46, 0x4f, 0x55, 0x4e, 0x44, 0x10, 0x00, 0x12, 0x11, 0x0a, 0x0d, 0x4b, 0x45, 0x59, 0x5f,
+	0x4e, 0x4f, 0x54, 0x5f, 0x46, 0x4f, 0x55, 0x4e, 0x44, 0x10, 0x01, 0x12, 0x0f, 0x0a, 0x0b, 0x4b,
+	0x45, 0x59, 0x5f, 0x45, 0x58, 0x50, 0x49, 0x52, 0x45, 0x44, 0x10, 0x02, 0x12, 0x0f, 0x0a, 0x0b,
+	0x4b, 0x45, 0x59, 0x5f, 0x44, 0x45, 0x4c, 0x45, 0x54, 0x45, 0x44, 0x10, 0x03, 0x2a, 0x4b, 0x0a,
+	0x0f, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x54, 0x79, 0x70, 0x65, 0x41, 0x63, 0x74, 0x69, 0x6f, 0x6e,
+	0x12, 0x0b, 0x0a, 0x07, 0x45, 0x58, 0x43, 0x4c, 0x55, 0x44, 0x45, 0x10, 0x00, 0x12, 0x0f, 0x0a,
+	0x0b, 0x4f, 0x4e, 0x4c, 0x59, 0x5f, 0x44, 0x49, 0x47, 0x45, 0x53, 0x54, 0x10, 0x01, 0x12, 0x0d,
+	0x0a, 0x09, 0x52, 0x41, 0x57, 0x5f, 0x56, 0x41, 0x4c, 0x55, 0x45, 0x10, 0x02, 0x12, 0x0b, 0x0a,
+	0x07, 0x52, 0x45, 0x53, 0x4f, 0x4c, 0x56, 0x45, 0x10, 0x03, 0x2a, 0x29, 0x0a, 0x10, 0x50, 0x65,
+	0x72, 0x6d, 0x69, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x41, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x09,
+	0x0a, 0x05, 0x47, 0x52, 0x41, 0x4e, 0x54, 0x10, 0x00, 0x12, 0x0a, 0x0a, 0x06, 0x52, 0x45, 0x56,
+	0x4f, 0x4b, 0x45, 0x10, 0x01, 0x2a, 0x34, 0x0a, 0x06, 0x54, 0x78, 0x4d, 0x6f, 0x64, 0x65, 0x12,
+	0x0c, 0x0a, 0x08, 0x52, 0x65, 0x61, 0x64, 0x4f, 0x6e, 0x6c, 0x79, 0x10, 0x00, 0x12, 0x0d, 0x0a,
+	0x09, 0x57, 0x72, 0x69, 0x74, 0x65, 0x4f, 0x6e, 0x6c, 0x79, 0x10, 0x01, 0x12, 0x0d, 0x0a, 0x09,
+	0x52, 0x65, 0x61, 0x64, 0x57, 0x72, 0x69, 0x74, 0x65, 0x10, 0x02, 0x2a, 0x37, 0x0a, 0x10, 0x45,
+	0x78, 0x74, 0x65, 0x72, 0x6e, 0x61, 0x6c, 0x52, 0x6f, 0x6f, 0x74, 0x54, 0x79, 0x70, 0x65, 0x12,
+	0x0b, 0x0a, 0x07, 0x47, 0x45, 0x4e, 0x45, 0x52, 0x49, 0x43, 0x10, 0x00, 0x12, 0x0a, 0x0a, 0x06,
+	0x49, 0x4d, 0x4d, 0x55, 0x44, 0x42, 0x10, 0x01, 0x12, 0x0a, 0x0a, 0x06, 0x43, 0x54, 0x5f, 0x4c,
+	0x4f, 0x47, 0x10, 0x02, 0x2a, 0x2d, 0x0a, 0x0c, 0x4b, 0x56, 0x53, 0x63, 0x68, 0x65, 0x6d, 0x61,
+	0x54, 0x79, 0x70, 0x65, 0x12, 0x0f, 0x0a, 0x0b, 0x4a, 0x53, 0x4f, 0x4e, 0x5f, 0x53, 0x43, 0x48,
+	0x45, 0x4d, 0x41, 0x10, 0x00, 0x12, 0x0c, 0x0a, 0x08, 0x50, 0x52, 0x4f, 0x54, 0x4f, 0x42, 0x55,
+	0x46, 0x10, 0x01, 0x2a, 0x45, 0x0a, 0x0d, 0x52, 0x65, 0x64, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e,
+	0x4d, 0x6f, 0x64, 0x65, 0x12, 0x0f, 0x0a, 0x0b, 0x52, 0x45, 0x44, 0x41, 0x43, 0x54, 0x5f, 0x48,
+	0x41, 0x53, 0x48, 0x10, 0x00, 0x12, 0x12, 0x0a, 0x0e, 0x52, 0x45, 0x44, 0x41, 0x43, 0x54, 0x5f,
+	0x50, 0x41, 0x52, 0x54, 0x49, 0x41, 0x4c, 0x10, 0x01, 0x12, 0x0f, 0x0a, 0x0b, 0x52, 0x45, 0x44,
+	0x41, 0x43, 0x54, 0x5f, 0x46, 0x55, 0x4c, 0x4c, 0x10, 0x02, 0x2a, 0x43, 0x0a, 0x09, 0x50, 0x72,
+	0x6f, 0x6f, 0x66, 0x54, 0x79, 0x70, 0x65, 0x12, 0x10, 0x0a, 0x0c, 0x54, 0x58, 0x5f, 0x49, 0x4e,
+	0x43, 0x4c, 0x55, 0x53, 0x49, 0x4f, 0x4e, 0x10, 0x00, 0x12, 0x13, 0x0a, 0x0f, 0x45, 0x4e, 0x54,
+	0x52, 0x59, 0x5f, 0x49, 0x4e, 0x43, 0x4c, 0x55, 0x53, 0x49, 0x4f, 0x4e, 0x10, 0x01, 0x12, 0x0f,
+	0x0a, 0x0b, 0x43, 0x4f, 0x4e, 0x53, 0x49, 0x53, 0x54, 0x45, 0x4e, 0x43, 0x59, 0x10, 0x02, 0x32,
+	0xb9, 0x46, 0x0a, 0x0b, 0x49, 0x6d, 0x6d, 0x75, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12,
+	0x50, 0x0a, 0x09, 0x4c, 0x69, 0x73, 0x74, 0x55, 0x73, 0x65, 0x72, 0x73, 0x12, 0x16, 0x2e, 0x67,
+	0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45,
+	0x6d, 0x70, 0x74, 0x79, 0x1a, 0x17, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63,
+	0x68, 0x65, 0x6d, 0x61, 0x2e, 0x55, 0x73, 0x65, 0x72, 0x4c, 0x69, 0x73, 0x74, 0x22, 0x12, 0x82,
+	0xd3, 0xe4, 0x93, 0x02, 0x0c, 0x12, 0x0a, 0x2f, 0x75, 0x73, 0x65, 0x72, 0x2f, 0x6c, 0x69, 0x73,
+	0x74, 0x12, 0x58, 0x0a, 0x0a, 0x43, 0x72, 0x65, 0x61, 0x74, 0x65, 0x55, 0x73, 0x65, 0x72, 0x12,
+	0x20, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e,
+	0x43, 0x72, 0x65, 0x61, 0x74, 0x65, 0x55, 0x73, 0x65, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x10, 0x82, 0xd3, 0xe4, 0x93, 0x02,
+	0x0a, 0x3a, 0x01, 0x2a, 0x22, 0x05, 0x2f, 0x75, 0x73, 0x65, 0x72, 0x12, 0x70, 0x0a, 0x0e, 0x43,
+	0x68, 0x61, 0x6e, 0x67, 0x65, 0x50, 0x61, 0x73, 0x73, 0x77, 0x6f, 0x72, 0x64, 0x12, 0x24, 0x2e,
+	0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x43, 0x68,
+	0x61, 0x6e, 0x67, 0x65, 0x50, 0x61, 0x73, 0x73, 0x77, 0x6f, 0x72, 0x64, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x20, 0x82, 0xd3, 0xe4,
+	0x93, 0x02, 0x1a, 0x22, 0x15, 0x2f, 0x75, 0x73, 0x65, 0x72, 0x2f, 0x70, 0x61, 0x73, 0x73, 0x77,
+	0x6f, 0x72, 0x64, 0x2f, 0x63, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x3a, 0x01, 0x2a, 0x12, 0x4a, 0x0a,
+	0x10, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x41, 0x75, 0x74, 0x68, 0x43, 0x6f, 0x6e, 0x66, 0x69,
+	0x67, 0x12, 0x19, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d,
+	0x61, 0x2e, 0x41, 0x75, 0x74, 0x68, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x1a, 0x16, 0x2e, 0x67,
+	0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45,
+	0x6d, 0x70, 0x74, 0x79, 0x22, 0x03, 0x88, 0x02, 0x01, 0x12, 0x4a, 0x0a, 0x10, 0x55, 0x70, 0x64,
+	0x61, 0x74, 0x65, 0x4d, 0x54, 0x4c, 0x53, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x12, 0x19, 0x2e,
+	0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x4d, 0x54,
+	0x4c, 0x53, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c,
+	0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79,
+	0x22, 0x03, 0x88, 0x02, 0x01, 0x12, 0x56, 0x0a, 0x0b, 0x4f, 0x70, 0x65, 0x6e, 0x53, 0x65, 0x73,
+	0x73, 0x69, 0x6f, 0x6e, 0x12, 0x21, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63,
+	0x68, 0x65, 0x6d, 0x61, 0x2e, 0x4f, 0x70, 0x65, 0x6e, 0x53, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x22, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62,
+	0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x4f, 0x70, 0x65, 0x6e, 0x53, 0x65, 0x73, 0x73,
+	0x69, 0x6f, 0x6e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x12, 0x40, 0x0a,
+	0x0c, 0x43, 0x6c, 0x6f, 0x73, 0x65, 0x53, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x16, 0x2e,
+	0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e,
+	0x45, 0x6d, 0x70, 0x74, 0x79, 0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x00, 0x12,
+	0x3d, 0x0a, 0x09, 0x4b, 0x65, 0x65, 0x70, 0x41, 0x6c, 0x69, 0x76, 0x65, 0x12, 0x16, 0x2e, 0x67,
+	0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45,
+	0x6d, 0x70, 0x74, 0x79, 0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x00, 0x12, 0x44,
+	0x0a, 0x05, 0x4e, 0x65, 0x77, 0x54, 0x78, 0x12, 0x1b, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62,
+	0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x4e, 0x65, 0x77, 0x54, 0x78, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x1a, 0x1c, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63,
+	0x68, 0x65, 0x6d, 0x61, 0x2e, 0x4e, 0x65, 0x77, 0x54, 0x78, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
+	0x73, 0x65, 0x22, 0x00, 0x12, 0x41, 0x0a, 0x06, 0x43, 0x6f, 0x6d, 0x6d, 0x69, 0x74, 0x12, 0x16,
+	0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66,
+	0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x1a, 0x1d, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e,
+	0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x43, 0x6f, 0x6d, 0x6d, 0x69, 0x74, 0x74, 0x65, 0x64,
+	0x53, 0x51, 0x4c, 0x54, 0x78, 0x22, 0x00, 0x12, 0x3c, 0x0a, 0x08, 0x52, 0x6f, 0x6c, 0x6c, 0x62,
+	0x61, 0x63, 0x6b, 0x12, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x1a, 0x16, 0x2e, 0x67, 0x6f,
+	0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d,
+	0x70, 0x74, 0x79, 0x22, 0x00, 0x12, 0x44, 0x0a, 0x09, 0x54, 0x78, 0x53, 0x51, 0x4c, 0x45, 0x78,
+	0x65, 0x63, 0x12, 0x1d, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65,
+	0x6d, 0x61, 0x2e, 0x53, 0x51, 0x4c, 0x45, 0x78, 0x65, 0x63, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x00, 0x12, 0x4d, 0x0a, 0x0a, 0x54,
+	0x78, 0x53, 0x51, 0x4c, 0x51, 0x75, 0x65, 0x72, 0x79, 0x12, 0x1e, 0x2e, 0x69, 0x6d, 0x6d, 0x75,
+	0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x53, 0x51, 0x4c, 0x51, 0x75, 0x65,
+	0x72, 0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1d, 0x2e, 0x69, 0x6d, 0x6d, 0x75,
+	0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x53, 0x51, 0x4c, 0x51, 0x75, 0x65,
+	0x72, 0x79, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x22, 0x00, 0x12, 0x3c, 0x0a, 0x05, 0x54, 0x78,
+	0x53, 0x65, 0x74, 0x12, 0x19, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68,
+	0x65, 0x6d, 0x61, 0x2e, 0x53, 0x65, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x16,
+	0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66,
+	0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x00, 0x12, 0x5d, 0x0a, 0x05, 0x4c, 0x6f, 0x67, 0x69,
+	0x6e, 0x12, 0x1b, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d,
+	0x61, 0x2e, 0x4c, 0x6f, 0x67, 0x69, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1c,
+	0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x4c,
+	0x6f, 0x67, 0x69, 0x6e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x19, 0x88, 0x02,
+	0x01, 0x92, 0x41, 0x02, 0x62, 0x00, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x0b, 0x3a, 0x01, 0x2a, 0x22,
+	0x06, 0x2f, 0x6c, 0x6f, 0x67, 0x69, 0x6e, 0x12, 0x4f, 0x0a, 0x06, 0x4c, 0x6f, 0x67, 0x6f, 0x75,
+	0x74, 0x12, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67,
+	0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74,
+	0x79, 0x22, 0x15, 0x88, 0x02, 0x01, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x0c, 0x22, 0x07, 0x2f, 0x6c,
+	0x6f, 0x67, 0x6f, 0x75, 0x74, 0x3a, 0x01, 0x2a, 0x12, 0x4d, 0x0a, 0x03, 0x53, 0x65, 0x74, 0x12,
+	0x19, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e,
+	0x53, 0x65, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x17, 0x2e, 0x69, 0x6d, 0x6d,
+	0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x54, 0x78, 0x48, 0x65, 0x61,
+	0x64, 0x65, 0x72, 0x22, 0x12, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x0c, 0x22, 0x07, 0x2f, 0x64, 0x62,
+	0x2f, 0x73, 0x65, 0x74, 0x3a, 0x01, 0x2a, 0x12, 0x70, 0x0a, 0x0d, 0x56, 0x65, 0x72, 0x69, 0x66,
+	0x69, 0x61, 0x62, 0x6c, 0x65, 0x53, 0x65, 0x74, 0x12, 0x23, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64,
+	0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x56, 0x65, 0x72, 0x69, 0x66, 0x69, 0x61,
+	0x62, 0x6c, 0x65, 0x53, 0x65, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1b, 0x2e,
+	0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x56, 0x65,
+	0x72, 0x69, 0x66, 0x69, 0x61, 0x62, 0x6c, 0x65, 0x54, 0x78, 0x22, 0x1d, 0x82, 0xd3, 0xe4, 0x93,
+	0x02, 0x17, 0x22, 0x12, 0x2f, 0x64, 0x62, 0x2f, 0x76, 0x65, 0x72, 0x69, 0x66, 0x69, 0x61, 0x62,
+	0x6c, 0x65, 0x2f, 0x73, 0x65, 0x74, 0x3a, 0x01, 0x2a, 0x12, 0x4d, 0x0a, 0x03, 0x47, 0x65, 0x74,
+	0x12, 0x19, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61,
+	0x2e, 0x4b, 0x65, 0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x14, 0x2e, 0x69, 0x6d,
+	0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x45, 0x6e, 0x74, 0x72,
+	0x79, 0x22, 0x15, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x0f, 0x12, 0x0d, 0x2f, 0x64, 0x62, 0x2f, 0x67,
+	0x65, 0x74, 0x2f, 0x7b, 0x6b, 0x65, 0x79, 0x7d, 0x12, 0x73, 0x0a, 0x0d, 0x56, 0x65, 0x72, 0x69,
+	0x66, 0x69, 0x61, 0x62, 0x6c, 0x65, 0x47, 0x65, 0x74, 0x12, 0x23, 0x2e, 0x69, 0x6d, 0x6d, 0x75,
+	0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x56, 0x65, 0x72, 0x69, 0x66, 0x69,
+	0x61, 0x62, 0x6c, 0x65, 0x47, 0x65, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1e,
+	0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x56,
+	0x65, 0x72, 0x69, 0x66, 0x69, 0x61, 0x62, 0x6c, 0x65, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x22, 0x1d,
+	0x82, 0xd3, 0xe4, 0x93, 0x02, 0x17, 0x22, 0x12, 0x2f, 0x64, 0x62, 0x2f, 0x76, 0x65, 0x72, 0x69,
+	0x66, 0x69, 0x61, 0x62, 0x6c, 0x65, 0x2f, 0x67, 0x65, 0x74, 0x3a, 0x01, 0x2a, 0x12, 0x5a, 0x0a,
+	0x06, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x12, 0x20, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62,
+	0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x4b, 0x65,
+	0x79, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x17, 0x2e, 0x69, 0x6d, 0x6d, 0x75,
+	0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x54, 0x78, 0x48, 0x65, 0x61, 0x64,
+	0x65, 0x72, 0x22, 0x15, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x0f, 0x22, 0x0a, 0x2f, 0x64, 0x62, 0x2f,
+	0x64, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x3a, 0x01, 0x2a, 0x12, 0x56, 0x0a, 0x06, 0x47, 0x65, 0x74,
+	0x41, 0x6c, 0x6c, 0x12, 0x1d, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68,
+	0x65, 0x6d, 0x61, 0x2e, 0x4b, 0x65, 0x79, 0x4c, 0x69, 0x73, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x1a, 0x16, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65,
+	0x6d, 0x61, 0x2e, 0x45, 0x6e, 0x74, 0x72, 0x69, 0x65, 0x73, 0x22, 0x15, 0x82, 0xd3, 0xe4, 0x93,
+	0x02, 0x0f, 0x22, 0x0a, 0x2f, 0x64, 0x62, 0x2f, 0x67, 0x65, 0x74, 0x61, 0x6c, 0x6c, 0x3a, 0x01,
+	0x2a, 0x12, 0x59, 0x0a, 0x07, 0x45, 0x78, 0x65, 0x63, 0x41, 0x6c, 0x6c, 0x12, 0x1d, 0x2e, 0x69,
+	0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x45, 0x78, 0x65,
+	0x63, 0x41, 0x6c, 0x6c, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x17, 0x2e, 0x69, 0x6d,
+	0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x54, 0x78, 0x48, 0x65,
+	0x61, 0x64, 0x65, 0x72, 0x22, 0x16, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x10, 0x22, 0x0b, 0x2f, 0x64,
+	0x62, 0x2f, 0x65, 0x78, 0x65, 0x63, 0x61, 0x6c, 0x6c, 0x3a, 0x01, 0x2a, 0x12, 0x6a, 0x0a, 0x0a,
+	0x45, 0x78, 0x65, 0x63, 0x53, 0x63, 0x72, 0x69, 0x70, 0x74, 0x12, 0x20, 0x2e, 0x69, 0x6d, 0x6d,
+	0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x45, 0x78, 0x65, 0x63, 0x53,
+	0x63, 0x72, 0x69, 0x70, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1f, 0x2e, 0x69,
+	0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x45, 0x78, 0x65,
+	0x63, 0x53, 0x63, 0x72, 0x69, 0x70, 0x74, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x22, 0x19, 0x82,
+	0xd3, 0xe4, 0x93, 0x02, 0x13, 0x22, 0x0e, 0x2f, 0x64, 0x62, 0x2f, 0x65, 0x78, 0x65, 0x63, 0x73,
+	0x63, 0x72, 0x69, 0x70, 0x74, 0x3a, 0x01, 0x2a, 0x12, 0x4f, 0x0a, 0x04, 0x53, 0x63, 0x61, 0x6e,
+	0x12, 0x1a, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61,
+	0x2e, 0x53, 0x63, 0x61, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x16, 0x2e, 0x69,
+	0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x45, 0x6e, 0x74,
+	0x72, 0x69, 0x65, 0x73, 0x22, 0x13, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x0d, 0x3a, 0x01, 0x2a, 0x22,
+	0x08, 0x2f, 0x64, 0x62, 0x2f, 0x73, 0x63, 0x61, 0x6e, 0x12, 0x58, 0x0a, 0x05, 0x43, 0x6f, 0x75,
+	0x6e, 0x74, 0x12, 0x18, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65,
+	0x6d, 0x61, 0x2e, 0x4b, 0x65, 0x79, 0x50, 0x72, 0x65, 0x66, 0x69, 0x78, 0x1a, 0x19, 0x2e, 0x69,
+	0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x45, 0x6e, 0x74,
+	0x72, 0x79, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x22, 0x1a, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x14, 0x12,
+	0x12, 0x2f, 0x64, 0x62, 0x2f, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x2f, 0x7b, 0x70, 0x72, 0x65, 0x66,
+	0x69, 0x78, 0x7d, 0x12, 0x53, 0x0a, 0x08, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x41, 0x6c, 0x6c, 0x12,
+	0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75,
+	0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x1a, 0x19, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62,
+	0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x43, 0x6f, 0x75,
+	0x6e, 0x74, 0x22, 0x14, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x0e, 0x12, 0x0c, 0x2f, 0x64, 0x62, 0x2f,
+	0x63, 0x6f, 0x75, 0x6e, 0x74, 0x61, 0x6c, 0x6c, 0x12, 0x4a, 0x0a, 0x06, 0x54, 0x78, 0x42, 0x79,
+	0x49, 0x64, 0x12, 0x18, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65,
+	0x6d, 0x61, 0x2e, 0x54, 0x78, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x11, 0x2e, 0x69,
+	0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x54, 0x78, 0x22,
+	0x13, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x0d, 0x12, 0x0b, 0x2f, 0x64, 0x62, 0x2f, 0x74, 0x78, 0x2f,
+	0x7b, 0x74, 0x78, 0x7d, 0x12, 0x73, 0x0a, 0x10, 0x56, 0x65, 0x72, 0x69, 0x66, 0x69, 0x61, 0x62,
+	0x6c, 0x65, 0x54, 0x78, 0x42, 0x79, 0x49, 0x64, 0x12, 0x22, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64,
+	0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x56, 0x65, 0x72, 0x69, 0x66, 0x69, 0x61,
+	0x62, 0x6c, 0x65, 0x54, 0x78, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1b, 0x2e, 0x69,
+	0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x56, 0x65, 0x72,
+	0x69, 0x66, 0x69, 0x61, 0x62, 0x6c, 0x65, 0x54, 0x78, 0x22, 0x1e, 0x82, 0xd3, 0xe4, 0x93, 0x02,
+	0x18, 0x12, 0x16, 0x2f, 0x64, 0x62, 0x2f, 0x76, 0x65, 0x72, 0x69, 0x66, 0x69, 0x61, 0x62, 0x6c,
+	0x65, 0x2f, 0x74, 0x78, 0x2f, 0x7b, 0x74, 0x78, 0x7d, 0x12, 0x50, 0x0a, 0x06, 0x54, 0x78, 0x53,
+	0x63, 0x61, 0x6e, 0x12, 0x1c, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68,
+	0x65, 0x6d, 0x61, 0x2e, 0x54, 0x78, 0x53, 0x63, 0x61, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x1a, 0x15, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d,
+	0x61, 0x2e, 0x54, 0x78, 0x4c, 0x69, 0x73, 0x74, 0x22, 0x11, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x0b,
+	0x3a, 0x01, 0x2a, 0x22, 0x06, 0x2f, 0x64, 0x62, 0x2f, 0x74, 0x78, 0x12, 0x58, 0x0a, 0x07, 0x48,
+	0x69, 0x73, 0x74, 0x6f, 0x72, 0x79, 0x12, 0x1d, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e,
+	0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x48, 0x69, 0x73, 0x74, 0x6f, 0x72, 0x79, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x16, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73,
+	0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x45, 0x6e, 0x74, 0x72, 0x69, 0x65, 0x73, 0x22, 0x16, 0x82,
+	0xd3, 0xe4, 0x93, 0x02, 0x10, 0x22, 0x0b, 0x2f, 0x64, 0x62, 0x2f, 0x68, 0x69, 0x73, 0x74, 0x6f,
+	0x72, 0x79, 0x3a, 0x01, 0x2a, 0x12, 0x55, 0x0a, 0x06, 0x48, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x12,
+	0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75,
+	0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x1a, 0x1d, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62,
+	0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x48, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x52, 0x65,
+	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x14, 0x92, 0x41, 0x02, 0x62, 0x00, 0x82, 0xd3, 0xe4,
+	0x93, 0x02, 0x09, 0x12, 0x07, 0x2f, 0x68, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x12, 0x6b, 0x0a, 0x0a,
+	0x53, 0x65, 0x72, 0x76, 0x65, 0x72, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x20, 0x2e, 0x69, 0x6d, 0x6d,
+	0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x53, 0x65, 0x72, 0x76, 0x65,
+	0x72, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x21, 0x2e, 0x69,
+	0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x53, 0x65, 0x72,
+	0x76, 0x65, 0x72, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22,
+	0x18, 0x92, 0x41, 0x02, 0x62, 0x00, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x0d, 0x12, 0x0b, 0x2f, 0x73,
+	0x65, 0x72, 0x76, 0x65, 0x72, 0x69, 0x6e, 0x66, 0x6f, 0x12, 0x68, 0x0a, 0x0e, 0x44, 0x61, 0x74,
+	0x61, 0x62, 0x61, 0x73, 0x65, 0x48, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x12, 0x16, 0x2e, 0x67, 0x6f,
+	0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d,
+	0x70, 0x74, 0x79, 0x1a, 0x25, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68,
+	0x65, 0x6d, 0x61, 0x2e, 0x44, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x48, 0x65, 0x61, 0x6c,
+	0x74, 0x68, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x17, 0x92, 0x41, 0x02, 0x62,
+	0x00, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x0c, 0x12, 0x0a, 0x2f, 0x64, 0x62, 0x2f, 0x68, 0x65, 0x61,
+	0x6c, 0x74, 0x68, 0x12, 0x5d, 0x0a, 0x0c, 0x43, 0x75, 0x72, 0x72, 0x65, 0x6e, 0x74, 0x53, 0x74,
+	0x61, 0x74, 0x65, 0x12, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x1a, 0x1d, 0x2e, 0x69, 0x6d,
+	0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x49, 0x6d, 0x6d, 0x75,
+	0x74, 0x61, 0x62, 0x6c, 0x65, 0x53, 0x74, 0x61, 0x74, 0x65, 0x22, 0x16, 0x92, 0x41, 0x02, 0x62,
+	0x00, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x0b, 0x12, 0x09, 0x2f, 0x64, 0x62, 0x2f, 0x73, 0x74, 0x61,
+	0x74, 0x65, 0x12, 0x65, 0x0a, 0x0c, 0x53, 0x65, 0x74, 0x52, 0x65, 0x66, 0x65, 0x72, 0x65, 0x6e,
+	0x63, 0x65, 0x12, 0x1f, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65,
+	0x6d, 0x61, 0x2e, 0x52, 0x65, 0x66, 0x65, 0x72, 0x65, 0x6e, 0x63, 0x65, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x1a, 0x17, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68,
+	0x65, 0x6d, 0x61, 0x2e, 0x54, 0x78, 0x48, 0x65, 0x61, 0x64, 0x65, 0x72, 0x22, 0x1b, 0x82, 0xd3,
+	0xe4, 0x93, 0x02, 0x15, 0x22, 0x10, 0x2f, 0x64, 0x62, 0x2f, 0x73, 0x65, 0x74, 0x72, 0x65, 0x66,
+	0x65, 0x72, 0x65, 0x6e, 0x63, 0x65, 0x3a, 0x01, 0x2a, 0x12, 0x88, 0x01, 0x0a, 0x16, 0x56, 0x65,
+	0x72, 0x69, 0x66, 0x69, 0x61, 0x62, 0x6c, 0x65, 0x53, 0x65, 0x74, 0x52, 0x65, 0x66, 0x65, 0x72,
+	0x65, 0x6e, 0x63, 0x65, 0x12, 0x29, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63,
+	0x68, 0x65, 0x6d, 0x61, 0x2e, 0x56, 0x65, 0x72, 0x69, 0x66, 0x69, 0x61, 0x62, 0x6c, 0x65, 0x52,
+	0x65, 0x66, 0x65, 0x72, 0x65, 0x6e, 0x63, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a,
+	0x1b, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e,
+	0x56, 0x65, 0x72, 0x69, 0x66, 0x69, 0x61, 0x62, 0x6c, 0x65, 0x54, 0x78, 0x22, 0x26, 0x82, 0xd3,
+	0xe4, 0x93, 0x02, 0x20, 0x3a, 0x01, 0x2a, 0x22, 0x1b, 0x2f, 0x64, 0x62, 0x2f, 0x76, 0x65, 0x72,
+	0x69, 0x66, 0x69, 0x61, 0x62, 0x6c, 0x65, 0x2f, 0x73, 0x65, 0x74, 0x72, 0x65, 0x66, 0x65, 0x72,
+	0x65, 0x6e, 0x63, 0x65, 0x12, 0x61, 0x0a, 0x0a, 0x52, 0x65, 0x73, 0x6f, 0x6c, 0x76, 0x65, 0x41,
+	0x6c, 0x6c, 0x12, 0x20, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65,
+	0x6d, 0x61, 0x2e, 0x52, 0x65, 0x73, 0x6f, 0x6c, 0x76, 0x65, 0x41, 0x6c, 0x6c, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x1a, 0x16, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63,
+	0x68, 0x65, 0x6d, 0x61, 0x2e, 0x45, 0x6e, 0x74, 0x72, 0x69, 0x65, 0x73, 0x22, 0x19, 0x82, 0xd3,
+	0xe4, 0x93, 0x02, 0x13, 0x22, 0x0e, 0x2f, 0x64, 0x62, 0x2f, 0x72, 0x65, 0x73, 0x6f, 0x6c, 0x76,
+	0x65, 0x61, 0x6c, 0x6c, 0x3a, 0x01, 0x2a, 0x12, 0x56, 0x0a, 0x06, 0x52, 0x65, 0x6e, 0x61, 0x6d,
+	0x65, 0x12, 0x1c, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d,
+	0x61, 0x2e, 0x52, 0x65, 0x6e, 0x61, 0x6d, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a,
+	0x17, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e,
+	0x54, 0x78, 0x48, 0x65, 0x61, 0x64, 0x65, 0x72, 0x22, 0x15, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x0f,
+	0x22, 0x0a, 0x2f, 0x64, 0x62, 0x2f, 0x72, 0x65, 0x6e, 0x61, 0x6d, 0x65, 0x3a, 0x01, 0x2a, 0x12,
+	0x50, 0x0a, 0x04, 0x5a, 0x41, 0x64, 0x64, 0x12, 0x1a, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62,
+	0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x5a, 0x41, 0x64, 0x64, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x1a, 0x17, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68,
+	0x65, 0x6d, 0x61, 0x2e, 0x54, 0x78, 0x48, 0x65, 0x61, 0x64, 0x65, 0x72, 0x22, 0x13, 0x82, 0xd3,
+	0xe4, 0x93, 0x02, 0x0d, 0x22, 0x08, 0x2f, 0x64, 0x62, 0x2f, 0x7a, 0x61, 0x64, 0x64, 0x3a, 0x01,
+	0x2a, 0x12, 0x73, 0x0a, 0x0e, 0x56, 0x65, 0x72, 0x69, 0x66, 0x69, 0x61, 0x62, 0x6c, 0x65, 0x5a,
+	0x41, 0x64, 0x64, 0x12, 0x24, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68,
+	0x65, 0x6d, 0x61, 0x2e, 0x56, 0x65, 0x72, 0x69, 0x66, 0x69, 0x61, 0x62, 0x6c, 0x65, 0x5a, 0x41,
+	0x64, 0x64, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1b, 0x2e, 0x69, 0x6d, 0x6d, 0x75,
+	0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x56, 0x65, 0x72, 0x69, 0x66, 0x69,
+	0x61, 0x62, 0x6c, 0x65, 0x54, 0x78, 0x22, 0x1e, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x18, 0x22, 0x13,
+	0x2f, 0x64, 0x62, 0x2f, 0x76, 0x65, 0x72, 0x69, 0x66, 0x69, 0x61, 0x62, 0x6c, 0x65, 0x2f, 0x7a,
+	0x61, 0x64, 0x64, 0x3a, 0x01, 0x2a, 0x12, 0x53, 0x0a, 0x05, 0x5a, 0x53, 0x63, 0x61, 0x6e, 0x12,
+	0x1b, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e,
+	0x5a, 0x53, 0x63, 0x61, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x17, 0x2e, 0x69,
+	0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x5a, 0x45, 0x6e,
+	0x74, 0x72, 0x69, 0x65, 0x73, 0x22, 0x14, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x0e, 0x3a, 0x01, 0x2a,
+	0x22, 0x09, 0x2f, 0x64, 0x62, 0x2f, 0x7a, 0x73, 0x63, 0x61, 0x6e, 0x12, 0x58, 0x0a, 0x0e, 0x43,
+	0x72, 0x65, 0x61, 0x74, 0x65, 0x44, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x12, 0x17, 0x2e,
+	0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x44, 0x61,
+	0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x15,
+	0x82, 0xd3, 0xe4, 0x93, 0x02, 0x0f, 0x22, 0x0a, 0x2f, 0x64, 0x62, 0x2f, 0x63, 0x72, 0x65, 0x61,
+	0x74, 0x65, 0x3a, 0x01, 0x2a, 0x12, 0x68, 0x0a, 0x12, 0x43, 0x72, 0x65, 0x61, 0x74, 0x65, 0x44,
+	0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x57, 0x69, 0x74, 0x68, 0x12, 0x1f, 0x2e, 0x69, 0x6d,
+	0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x44, 0x61, 0x74, 0x61,
+	0x62, 0x61, 0x73, 0x65, 0x53, 0x65, 0x74, 0x74, 0x69, 0x6e, 0x67, 0x73, 0x1a, 0x16, 0x2e, 0x67,
+	0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45,
+	0x6d, 0x70, 0x74, 0x79, 0x22, 0x19, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x13, 0x22, 0x0e, 0x2f, 0x64,
+	0x62, 0x2f, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x77, 0x69, 0x74, 0x68, 0x3a, 0x01, 0x2a, 0x12,
+	0x7a, 0x0a, 0x14, 0x43, 0x72, 0x65, 0x61, 0x74, 0x65, 0x44, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73,
+	0x65, 0x57, 0x69, 0x74, 0x68, 0x56, 0x32, 0x12, 0x21, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62,
+	0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x44, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65,
+	0x53, 0x65, 0x74, 0x74, 0x69, 0x6e, 0x67, 0x73, 0x56, 0x32, 0x1a, 0x21, 0x2e, 0x69, 0x6d, 0x6d,
+	0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x44, 0x61, 0x74, 0x61, 0x62,
+	0x61, 0x73, 0x65, 0x53, 0x65, 0x74, 0x74, 0x69, 0x6e, 0x67, 0x73, 0x56, 0x32, 0x22, 0x1c, 0x82,
+	0xd3, 0xe4, 0x93, 0x02, 0x16, 0x22, 0x11, 0x2f, 0x64, 0x62, 0x2f, 0x63, 0x72, 0x65, 0x61, 0x74,
+	0x65, 0x77, 0x69, 0x74, 0x68, 0x2f, 0x76, 0x32, 0x3a, 0x01, 0x2a, 0x12, 0x70, 0x0a, 0x0d, 0x43,
+	0x6c, 0x6f, 0x6e, 0x65, 0x44, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x12, 0x23, 0x2e, 0x69,
+	0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x43, 0x6c, 0x6f,
+	0x6e, 0x65, 0x44, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x1a, 0x24, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d,
+	0x61, 0x2e, 0x43, 0x6c, 0x6f, 0x6e, 0x65, 0x44, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x52,
+	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x14, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x0e, 0x22,
+	0x09, 0x2f, 0x64, 0x62, 0x2f, 0x63, 0x6c, 0x6f, 0x6e, 0x65, 0x3a, 0x01, 0x2a, 0x12, 0x60, 0x0a,
+	0x0c, 0x44, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x4c, 0x69, 0x73, 0x74, 0x12, 0x16, 0x2e,
+	0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e,
+	0x45, 0x6d, 0x70, 0x74, 0x79, 0x1a, 0x23, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73,
+	0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x44, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x4c, 0x69,
+	0x73, 0x74, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x13, 0x82, 0xd3, 0xe4, 0x93,
+	0x02, 0x0d, 0x3a, 0x01, 0x2a, 0x22, 0x08, 0x2f, 0x64, 0x62, 0x2f, 0x6c, 0x69, 0x73, 0x74, 0x12,
+	0x67, 0x0a, 0x0b, 0x55, 0x73, 0x65, 0x44, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x12, 0x17,
+	0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x44,
+	0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x1a, 0x1f, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62,
+	0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x55, 0x73, 0x65, 0x44, 0x61, 0x74, 0x61, 0x62,
+	0x61, 0x73, 0x65, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x1e, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x18,
+	0x12, 0x16, 0x2f, 0x64, 0x62, 0x2f, 0x75, 0x73, 0x65, 0x2f, 0x7b, 0x64, 0x61, 0x74, 0x61, 0x62,
+	0x61, 0x73, 0x65, 0x4e, 0x61, 0x6d, 0x65, 0x7d, 0x12, 0x60, 0x0a, 0x0e, 0x55, 0x70, 0x64, 0x61,
+	0x74, 0x65, 0x44, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x12, 0x1f, 0x2e, 0x69, 0x6d, 0x6d,
+	0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x44, 0x61, 0x74, 0x61, 0x62,
+	0x61, 0x73, 0x65, 0x53, 0x65, 0x74, 0x74, 0x69, 0x6e, 0x67, 0x73, 0x1a, 0x16, 0x2e, 0x67, 0x6f,
+	0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d,
+	0x70, 0x74, 0x79, 0x22, 0x15, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x0f, 0x22, 0x0a, 0x2f, 0x64, 0x62,
+	0x2f, 0x75, 0x70, 0x64, 0x61, 0x74, 0x65, 0x3a, 0x01, 0x2a, 0x12, 0x7c, 0x0a, 0x10, 0x55, 0x70,
+	0x64, 0x61, 0x74, 0x65, 0x44, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x56, 0x32, 0x12, 0x21,
+	0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x44,
+	0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x53, 0x65, 0x74, 0x74, 0x69, 0x6e, 0x67, 0x73, 0x56,
+	0x32, 0x1a, 0x2b, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d,
+	0x61, 0x2e, 0x44, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x53, 0x65, 0x74, 0x74, 0x69, 0x6e,
+	0x67, 0x73, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x22, 0x18,
+	0x82, 0xd3, 0xe4, 0x93, 0x02, 0x12, 0x22, 0x0d, 0x2f, 0x64, 0x62, 0x2f, 0x75, 0x70, 0x64, 0x61,
+	0x74, 0x65, 0x2f, 0x76, 0x32, 0x3a, 0x01, 0x2a, 0x12, 0x67, 0x0a, 0x13, 0x47, 0x65, 0x74, 0x44,
+	0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x53, 0x65, 0x74, 0x74, 0x69, 0x6e, 0x67, 0x73, 0x12,
+	0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75,
+	0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x1a, 0x1f, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62,
+	0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x44, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65,
+	0x53, 0x65, 0x74, 0x74, 0x69, 0x6e, 0x67, 0x73, 0x22, 0x17, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x11,
+	0x22, 0x0c, 0x2f, 0x64, 0x62, 0x2f, 0x73, 0x65, 0x74, 0x74, 0x69, 0x6e, 0x67, 0x73, 0x3a, 0x01,
+	0x2a, 0x12, 0x6e, 0x0a, 0x15, 0x47, 0x65, 0x74, 0x44, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65,
+	0x53, 0x65, 0x74, 0x74, 0x69, 0x6e, 0x67, 0x73, 0x56, 0x32, 0x12, 0x16, 0x2e, 0x67, 0x6f, 0x6f,
+	0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70,
+	0x74, 0x79, 0x1a, 0x21, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65,
+	0x6d, 0x61, 0x2e, 0x44, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x53, 0x65, 0x74, 0x74, 0x69,
+	0x6e, 0x67, 0x73, 0x56, 0x32, 0x22, 0x1a, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x14, 0x3a, 0x01, 0x2a,
+	0x22, 0x0f, 0x2f, 0x64, 0x62, 0x2f, 0x73, 0x65, 0x74, 0x74, 0x69, 0x6e, 0x67, 0x73, 0x2f, 0x76,
+	0x32, 0x12, 0x94, 0x01, 0x0a, 0x1a, 0x47, 0x65, 0x74, 0x44, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73,
+	0x65, 0x53, 0x65, 0x74, 0x74, 0x69, 0x6e, 0x67, 0x73, 0x48, 0x69, 0x73, 0x74, 0x6f, 0x72, 0x79,
+	0x12, 0x2d, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61,
+	0x2e, 0x44, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x53, 0x65, 0x74, 0x74, 0x69, 0x6e, 0x67,
+	0x73, 0x48, 0x69, 0x73, 0x74, 0x6f, 0x72, 0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a,
+	0x26, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e,
+	0x44, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x53, 0x65, 0x74, 0x74, 0x69, 0x6e, 0x67, 0x73,
+	0x48, 0x69, 0x73, 0x74, 0x6f, 0x72, 0x79, 0x22, 0x1f, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x19, 0x22,
+	0x14, 0x2f, 0x64, 0x62, 0x2f, 0x73, 0x65, 0x74, 0x74, 0x69, 0x6e, 0x67, 0x73, 0x2f, 0x68, 0x69,
+	0x73, 0x74, 0x6f, 0x72, 0x79, 0x3a, 0x01, 0x2a, 0x12, 0x99, 0x01, 0x0a, 0x18, 0x52, 0x6f, 0x6c,
+	0x6c, 0x62, 0x61, 0x63, 0x6b, 0x44, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x53, 0x65, 0x74,
+	0x74, 0x69, 0x6e, 0x67, 0x73, 0x12, 0x2e, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73,
+	0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x44, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x53, 0x65,
+	0x74, 0x74, 0x69, 0x6e, 0x67, 0x73, 0x52, 0x6f, 0x6c, 0x6c, 0x62, 0x61, 0x63, 0x6b, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x2b, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73,
+	0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x44, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x53, 0x65,
+	0x74, 0x74, 0x69, 0x6e, 0x67, 0x73, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x52, 0x65, 0x73, 0x75,
+	0x6c, 0x74, 0x22, 0x20, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x1a, 0x22, 0x15, 0x2f, 0x64, 0x62, 0x2f,
+	0x73, 0x65, 0x74, 0x74, 0x69, 0x6e, 0x67, 0x73, 0x2f, 0x72, 0x6f, 0x6c, 0x6c, 0x62, 0x61, 0x63,
+	0x6b, 0x3a, 0x01, 0x2a, 0x12, 0x5e, 0x0a, 0x0a, 0x46, 0x6c, 0x75, 0x73, 0x68, 0x49, 0x6e, 0x64,
+	0x65, 0x78, 0x12, 0x20, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65,
+	0x6d, 0x61, 0x2e, 0x46, 0x6c, 0x75, 0x73, 0x68, 0x49, 0x6e, 0x64, 0x65, 0x78, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x16, 0x82, 0xd3,
+	0xe4, 0x93, 0x02, 0x10, 0x12, 0x0e, 0x2f, 0x64, 0x62, 0x2f, 0x66, 0x6c, 0x75, 0x73, 0x68, 0x69,
+	0x6e, 0x64, 0x65, 0x78, 0x12, 0x58, 0x0a, 0x0c, 0x43, 0x6f, 0x6d, 0x70, 0x61, 0x63, 0x74, 0x49,
+	0x6e, 0x64, 0x65, 0x78, 0x12, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x1a, 0x16, 0x2e, 0x67,
+	0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45,
+	0x6d, 0x70, 0x74, 0x79, 0x22, 0x18, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x12, 0x12, 0x10, 0x2f, 0x64,
+	0x62, 0x2f, 0x63, 0x6f, 0x6d, 0x70, 0x61, 0x63, 0x74, 0x69, 0x6e, 0x64, 0x65, 0x78, 0x12, 0x75,
+	0x0a, 0x10, 0x43, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x50, 0x65, 0x72, 0x6d, 0x69, 0x73, 0x73, 0x69,
+	0x6f, 0x6e, 0x12, 0x26, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65,
+	0x6d, 0x61, 0x2e, 0x43, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x50, 0x65, 0x72, 0x6d, 0x69, 0x73, 0x73,
+	0x69, 0x6f, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f,
+	0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70,
+	0x74, 0x79, 0x22, 0x21, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x1b, 0x22, 0x16, 0x2f, 0x75, 0x73, 0x65,
+	0x72, 0x2f, 0x63, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x70, 0x65, 0x72, 0x6d, 0x69, 0x73, 0x73, 0x69,
+	0x6f, 0x6e, 0x3a, 0x01, 0x2a, 0x12, 0x6c, 0x0a, 0x0d, 0x53, 0x65, 0x74, 0x41, 0x63, 0x74, 0x69,
+	0x76, 0x65, 0x55, 0x73, 0x65, 0x72, 0x12, 0x23, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e,
+	0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x53, 0x65, 0x74, 0x41, 0x63, 0x74, 0x69, 0x76, 0x65,
+	0x55, 0x73, 0x65, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x16, 0x2e, 0x67, 0x6f,
+	0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d,
+	0x70, 0x74, 0x79, 0x22, 0x1e, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x18, 0x22, 0x13, 0x2f, 0x75, 0x73,
+	0x65, 0x72, 0x2f, 0x73, 0x65, 0x74, 0x61, 0x63, 0x74, 0x69, 0x76, 0x65, 0x55, 0x73, 0x65, 0x72,
+	0x3a, 0x01, 0x2a, 0x12, 0x40, 0x0a, 0x09, 0x73, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x47, 0x65, 0x74,
+	0x12, 0x19, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61,
+	0x2e, 0x4b, 0x65, 0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x14, 0x2e, 0x69, 0x6d,
+	0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x43, 0x68, 0x75, 0x6e,
+	0x6b, 0x22, 0x00, 0x30, 0x01, 0x12, 0x3e, 0x0a, 0x09, 0x73, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x53,
+	0x65, 0x74, 0x12, 0x14, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65,
+	0x6d, 0x61, 0x2e, 0x43, 0x68, 0x75, 0x6e, 0x6b, 0x1a, 0x17, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64,
+	0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x54, 0x78, 0x48, 0x65, 0x61, 0x64, 0x65,
+	0x72, 0x22, 0x00, 0x28, 0x01, 0x12, 0x54, 0x0a, 0x13, 0x73, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x56,
+	0x65, 0x72, 0x69, 0x66, 0x69, 0x61, 0x62, 0x6c, 0x65, 0x47, 0x65, 0x74, 0x12, 0x23, 0x2e, 0x69,
+	0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x56, 0x65, 0x72,
+	0x69, 0x66, 0x69, 0x61, 0x62, 0x6c, 0x65, 0x47, 0x65, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x1a, 0x14, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d,
+	0x61, 0x2e, 0x43, 0x68, 0x75, 0x6e, 0x6b, 0x22, 0x00, 0x30, 0x01, 0x12, 0x4c, 0x0a, 0x13, 0x73,
+	0x74, 0x72, 0x65, 0x61, 0x6d, 0x56, 0x65, 0x72, 0x69, 0x66, 0x69, 0x61, 0x62, 0x6c, 0x65, 0x53,
+	0x65, 0x74, 0x12, 0x14, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65,
+	0x6d, 0x61, 0x2e, 0x43, 0x68, 0x75, 0x6e, 0x6b, 0x1a, 0x1b, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64,
+	0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x56, 0x65, 0x72, 0x69, 0x66, 0x69, 0x61,
+	0x62, 0x6c, 0x65, 0x54, 0x78, 0x22, 0x00, 0x28, 0x01, 0x12, 0x42, 0x0a, 0x0a, 0x73, 0x74, 0x72,
+	0x65, 0x61, 0x6d, 0x53, 0x63, 0x61, 0x6e, 0x12, 0x1a, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62,
+	0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x53, 0x63, 0x61, 0x6e, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x1a, 0x14, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68,
+	0x65, 0x6d, 0x61, 0x2e, 0x43, 0x68, 0x75, 0x6e, 0x6b, 0x22, 0x00, 0x30, 0x01, 0x12, 0x44, 0x0a,
+	0x0b, 0x73, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x5a, 0x53, 0x63, 0x61, 0x6e, 0x12, 0x1b, 0x2e, 0x69,
+	0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x5a, 0x53, 0x63,
+	0x61, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x14, 0x2e, 0x69, 0x6d, 0x6d, 0x75,
+	0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x43, 0x68, 0x75, 0x6e, 0x6b, 0x22,
+	0x00, 0x30, 0x01, 0x12, 0x48, 0x0a, 0x0d, 0x73, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x48, 0x69, 0x73,
+	0x74, 0x6f, 0x72, 0x79, 0x12, 0x1d, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63,
+	0x68, 0x65, 0x6d, 0x61, 0x2e, 0x48, 0x69, 0x73, 0x74, 0x6f, 0x72, 0x79, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x1a, 0x14, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68,
+	0x65, 0x6d, 0x61, 0x2e, 0x43, 0x68, 0x75, 0x6e, 0x6b, 0x22, 0x00, 0x30, 0x01, 0x12, 0x42, 0x0a,
+	0x0d, 0x73, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x45, 0x78, 0x65, 0x63, 0x41, 0x6c, 0x6c, 0x12, 0x14,
+	0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x43,
+	0x68, 0x75, 0x6e, 0x6b, 0x1a, 0x17, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63,
+	0x68, 0x65, 0x6d, 0x61, 0x2e, 0x54, 0x78, 0x48, 0x65, 0x61, 0x64, 0x65, 0x72, 0x22, 0x00, 0x28,
+	0x01, 0x12, 0x44, 0x0a, 0x08, 0x65, 0x78, 0x70, 0x6f, 0x72, 0x74, 0x54, 0x78, 0x12, 0x1e, 0x2e,
+	0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x45, 0x78,
+	0x70, 0x6f, 0x72, 0x74, 0x54, 0x78, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x14, 0x2e,
+	0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x43, 0x68,
+	0x75, 0x6e, 0x6b, 0x22, 0x00, 0x30, 0x01, 0x12, 0x40, 0x0a, 0x0b, 0x72, 0x65, 0x70, 0x6c, 0x69,
+	0x63, 0x61, 0x74, 0x65, 0x54, 0x78, 0x12, 0x14, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e,
+	0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x43, 0x68, 0x75, 0x6e, 0x6b, 0x1a, 0x17, 0x2e, 0x69,
+	0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x54, 0x78, 0x48,
+	0x65, 0x61, 0x64, 0x65, 0x72, 0x22, 0x00, 0x28, 0x01, 0x12, 0x5e, 0x0a, 0x07, 0x53, 0x51, 0x4c,
+	0x45, 0x78, 0x65, 0x63, 0x12, 0x1d, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63,
+	0x68, 0x65, 0x6d, 0x61, 0x2e, 0x53, 0x51, 0x4c, 0x45, 0x78, 0x65, 0x63, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x1a, 0x1c, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68,
+	0x65, 0x6d, 0x61, 0x2e, 0x53, 0x51, 0x4c, 0x45, 0x78, 0x65, 0x63, 0x52, 0x65, 0x73, 0x75, 0x6c,
+	0x74, 0x22, 0x16, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x10, 0x22, 0x0b, 0x2f, 0x64, 0x62, 0x2f, 0x73,
+	0x71, 0x6c, 0x65, 0x78, 0x65, 0x63, 0x3a, 0x01, 0x2a, 0x12, 0x62, 0x0a, 0x08, 0x53, 0x51, 0x4c,
+	0x51, 0x75, 0x65, 0x72, 0x79, 0x12, 0x1e, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73,
+	0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x53, 0x51, 0x4c, 0x51, 0x75, 0x65, 0x72, 0x79, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1d, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73,
+	0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x53, 0x51, 0x4c, 0x51, 0x75, 0x65, 0x72, 0x79, 0x52, 0x65,
+	0x73, 0x75, 0x6c, 0x74, 0x22, 0x17, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x11, 0x22, 0x0c, 0x2f, 0x64,
+	0x62, 0x2f, 0x73, 0x71, 0x6c, 0x71, 0x75, 0x65, 0x72, 0x79, 0x3a, 0x01, 0x2a, 0x12, 0x5b, 0x0a,
+	0x0a, 0x4c, 0x69, 0x73, 0x74, 0x54, 0x61, 0x62, 0x6c, 0x65, 0x73, 0x12, 0x16, 0x2e, 0x67, 0x6f,
+	0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d,
+	0x70, 0x74, 0x79, 0x1a, 0x1d, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68,
+	0x65, 0x6d, 0x61, 0x2e, 0x53, 0x51, 0x4c, 0x51, 0x75, 0x65, 0x72, 0x79, 0x52, 0x65, 0x73, 0x75,
+	0x6c, 0x74, 0x22, 0x16, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x10, 0x12, 0x0e, 0x2f, 0x64, 0x62, 0x2f,
+	0x74, 0x61, 0x62, 0x6c, 0x65, 0x2f, 0x6c, 0x69, 0x73, 0x74, 0x12, 0x5b, 0x0a, 0x0d, 0x44, 0x65,
+	0x73, 0x63, 0x72, 0x69, 0x62, 0x65, 0x54, 0x61, 0x62, 0x6c, 0x65, 0x12, 0x14, 0x2e, 0x69, 0x6d,
+	0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x54, 0x61, 0x62, 0x6c,
+	0x65, 0x1a, 0x1d, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d,
+	0x61, 0x2e, 0x53, 0x51, 0x4c, 0x51, 0x75, 0x65, 0x72, 0x79, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74,
+	0x22, 0x15, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x0f, 0x22, 0x0a, 0x2f, 0x64, 0x62, 0x2f, 0x74, 0x61,
+	0x62, 0x6c, 0x65, 0x73, 0x3a, 0x01, 0x2a, 0x12, 0x7f, 0x0a, 0x10, 0x56, 0x65, 0x72, 0x69, 0x66,
+	0x69, 0x61, 0x62, 0x6c, 0x65, 0x53, 0x51, 0x4c, 0x47, 0x65, 0x74, 0x12, 0x26, 0x2e, 0x69, 0x6d,
+	0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x56, 0x65, 0x72, 0x69,
+	0x66, 0x69, 0x61, 0x62, 0x6c, 0x65, 0x53, 0x51, 0x4c, 0x47, 0x65, 0x74, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x1a, 0x21, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68,
+	0x65, 0x6d, 0x61, 0x2e, 0x56, 0x65, 0x72, 0x69, 0x66, 0x69, 0x61, 0x62, 0x6c, 0x65, 0x53, 0x51,
+	0x4c, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x22, 0x20, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x1a, 0x22, 0x15,
+	0x2f, 0x64, 0x62, 0x2f, 0x76, 0x65, 0x72, 0x69, 0x66, 0x69, 0x61, 0x62, 0x6c, 0x65, 0x2f, 0x73,
+	0x71, 0x6c, 0x67, 0x65, 0x74, 0x3a, 0x01, 0x2a, 0x12, 0x73, 0x0a, 0x14, 0x52, 0x65, 0x67, 0x69,
+	0x73, 0x74, 0x65, 0x72, 0x45, 0x78, 0x74, 0x65, 0x72, 0x6e, 0x61, 0x6c, 0x52, 0x6f, 0x6f, 0x74,
+	0x12, 0x1b, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61,
+	0x2e, 0x45, 0x78, 0x74, 0x65, 0x72, 0x6e, 0x61, 0x6c, 0x52, 0x6f, 0x6f, 0x74, 0x1a, 0x17, 0x2e,
+	0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x54, 0x78,
+	0x48, 0x65, 0x61, 0x64, 0x65, 0x72, 0x22, 0x25, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x1f, 0x3a, 0x01,
+	0x2a, 0x22, 0x1a, 0x2f, 0x64, 0x62, 0x2f, 0x65, 0x78, 0x74, 0x65, 0x72, 0x6e, 0x61, 0x6c, 0x72,
+	0x6f, 0x6f, 0x74, 0x73, 0x2f, 0x72, 0x65, 0x67, 0x69, 0x73, 0x74, 0x65, 0x72, 0x12, 0x73, 0x0a,
+	0x0d, 0x45, 0x78, 0x74, 0x65, 0x72, 0x6e, 0x61, 0x6c, 0x52, 0x6f, 0x6f, 0x74, 0x73, 0x12, 0x23,
+	0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x45,
+	0x78, 0x74, 0x65, 0x72, 0x6e, 0x61, 0x6c, 0x52, 0x6f, 0x6f, 0x74, 0x73, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x1a, 0x1f, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68,
+	0x65, 0x6d, 0x61, 0x2e, 0x45, 0x78, 0x74, 0x65, 0x72, 0x6e, 0x61, 0x6c, 0x52, 0x6f, 0x6f, 0x74,
+	0x4c, 0x69, 0x73, 0x74, 0x22, 0x1c, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x16, 0x22, 0x11, 0x2f, 0x64,
+	0x62, 0x2f, 0x65, 0x78, 0x74, 0x65, 0x72, 0x6e, 0x61, 0x6c, 0x72, 0x6f, 0x6f, 0x74, 0x73, 0x3a,
+	0x01, 0x2a, 0x12, 0x5d, 0x0a, 0x0b, 0x53, 0x65, 0x74, 0x4b, 0x56, 0x53, 0x63, 0x68, 0x65, 0x6d,
+	0x61, 0x12, 0x17, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d,
+	0x61, 0x2e, 0x4b, 0x56, 0x53, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x1a, 0x17, 0x2e, 0x69, 0x6d, 0x6d,
+	0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x54, 0x78, 0x48, 0x65, 0x61,
+	0x64, 0x65, 0x72, 0x22, 0x1c, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x16, 0x3a, 0x01, 0x2a, 0x22, 0x11,
+	0x2f, 0x64, 0x62, 0x2f, 0x6b, 0x76, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x73, 0x2f, 0x73, 0x65,
+	0x74, 0x12, 0x6a, 0x0a, 0x0e, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x4b, 0x56, 0x53, 0x63, 0x68,
+	0x65, 0x6d, 0x61, 0x12, 0x1e, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68,
+	0x65, 0x6d, 0x61, 0x2e, 0x4b, 0x56, 0x53, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x1a, 0x17, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68,
+	0x65, 0x6d, 0x61, 0x2e, 0x54, 0x78, 0x48, 0x65, 0x61, 0x64, 0x65, 0x72, 0x22, 0x1f, 0x82, 0xd3,
+	0xe4, 0x93, 0x02, 0x19, 0x3a, 0x01, 0x2a, 0x22, 0x14, 0x2f, 0x64, 0x62, 0x2f, 0x6b, 0x76, 0x73,
+	0x63, 0x68, 0x65, 0x6d, 0x61, 0x73, 0x2f, 0x64, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x12, 0x57, 0x0a,
+	0x09, 0x4b, 0x56, 0x53, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x73, 0x12, 0x16, 0x2e, 0x67, 0x6f, 0x6f,
+	0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70,
+	0x74, 0x79, 0x1a, 0x1b, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65,
+	0x6d, 0x61, 0x2e, 0x4b, 0x56, 0x53, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x4c, 0x69, 0x73, 0x74, 0x22,
+	0x15, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x0f, 0x12, 0x0d, 0x2f, 0x64, 0x62, 0x2f, 0x6b, 0x76, 0x73,
+	0x63, 0x68, 0x65, 0x6d, 0x61, 0x73, 0x12, 0x6c, 0x0a, 0x10, 0x53, 0x65, 0x74, 0x52, 0x65, 0x64,
+	0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x75, 0x6c, 0x65, 0x12, 0x1c, 0x2e, 0x69, 0x6d, 0x6d,
+	0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x52, 0x65, 0x64, 0x61, 0x63,
+	0x74, 0x69, 0x6f, 0x6e, 0x52, 0x75, 0x6c, 0x65, 0x1a, 0x17, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64,
+	0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x54, 0x78, 0x48, 0x65, 0x61, 0x64, 0x65,
+	0x72, 0x22, 0x21, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x1b, 0x22, 0x16, 0x2f, 0x64, 0x62, 0x2f, 0x72,
+	0x65, 0x64, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x72, 0x75, 0x6c, 0x65, 0x73, 0x2f, 0x73, 0x65,
+	0x74, 0x3a, 0x01, 0x2a, 0x12, 0x79, 0x0a, 0x13, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x52, 0x65,
+	0x64, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x75, 0x6c, 0x65, 0x12, 0x23, 0x2e, 0x69, 0x6d,
+	0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x52, 0x65, 0x64, 0x61,
+	0x63, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x75, 0x6c, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x1a, 0x17, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61,
+	0x2e, 0x54, 0x78, 0x48, 0x65, 0x61, 0x64, 0x65, 0x72, 0x22, 0x24, 0x82, 0xd3, 0xe4, 0x93, 0x02,
+	0x1e, 0x22, 0x19, 0x2f, 0x64, 0x62, 0x2f, 0x72, 0x65, 0x64, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e,
+	0x72, 0x75, 0x6c, 0x65, 0x73, 0x2f, 0x64, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x3a, 0x01, 0x2a, 0x12,
+	0x66, 0x0a, 0x0e, 0x52, 0x65, 0x64, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x75, 0x6c, 0x65,
+	0x73, 0x12, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x1a, 0x20, 0x2e, 0x69, 0x6d, 0x6d, 0x75,
+	0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x52, 0x65, 0x64, 0x61, 0x63, 0x74,
+	0x69, 0x6f, 0x6e, 0x52, 0x75, 0x6c, 0x65, 0x4c, 0x69, 0x73, 0x74, 0x22, 0x1a, 0x82, 0xd3, 0xe4,
+	0x93, 0x02, 0x14, 0x12, 0x12, 0x2f, 0x64, 0x62, 0x2f, 0x72, 0x65, 0x64, 0x61, 0x63, 0x74, 0x69,
+	0x6f, 0x6e, 0x72, 0x75, 0x6c, 0x65, 0x73, 0x12, 0x83, 0x01, 0x0a, 0x10, 0x4d, 0x75, 0x6c, 0x74,
+	0x69, 0x44, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x53, 0x65, 0x74, 0x12, 0x26, 0x2e, 0x69,
+	0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x4d, 0x75, 0x6c,
+	0x74, 0x69, 0x44, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x53, 0x65, 0x74, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x1a, 0x25, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63,
+	0x68, 0x65, 0x6d, 0x61, 0x2e, 0x4d, 0x75, 0x6c, 0x74, 0x69, 0x44, 0x61, 0x74, 0x61, 0x62, 0x61,
+	0x73, 0x65, 0x54, 0x78, 0x48, 0x65, 0x61, 0x64, 0x65, 0x72, 0x73, 0x22, 0x20, 0x82, 0xd3, 0xe4,
+	0x93, 0x02, 0x1a, 0x3a, 0x01, 0x2a, 0x22, 0x15, 0x2f, 0x64, 0x62, 0x2f, 0x6d, 0x75, 0x6c, 0x74,
+	0x69, 0x64, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x2f, 0x73, 0x65, 0x74, 0x12, 0x83, 0x01,
+	0x0a, 0x11, 0x52, 0x65, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x53, 0x74, 0x61,
+	0x74, 0x75, 0x73, 0x12, 0x21, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68,
+	0x65, 0x6d, 0x61, 0x2e, 0x52, 0x65, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x28, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e,
+	0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x52, 0x65, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x74, 0x69,
+	0x6f, 0x6e, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
+	0x22, 0x21, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x1b, 0x22, 0x16, 0x2f, 0x64, 0x62, 0x2f, 0x72, 0x65,
+	0x70, 0x6c, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x2f, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73,
+	0x3a, 0x01, 0x2a, 0x12, 0x6f, 0x0a, 0x10, 0x50, 0x61, 0x75, 0x73, 0x65, 0x52, 0x65, 0x70, 0x6c,
+	0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x21, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62,
+	0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x52, 0x65, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x74,
+	0x69, 0x6f, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f,
+	0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70,
+	0x74, 0x79, 0x22, 0x20, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x1a, 0x3a, 0x01, 0x2a, 0x22, 0x15, 0x2f,
+	0x64, 0x62, 0x2f, 0x72, 0x65, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x2f, 0x70,
+	0x61, 0x75, 0x73, 0x65, 0x12, 0x71, 0x0a, 0x11, 0x52, 0x65, 0x73, 0x75, 0x6d, 0x65, 0x52, 0x65,
+	0x70, 0x6c, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x21, 0x2e, 0x69, 0x6d, 0x6d, 0x75,
+	0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x52, 0x65, 0x70, 0x6c, 0x69, 0x63,
+	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x16, 0x2e, 0x67,
+	0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45,
+	0x6d, 0x70, 0x74, 0x79, 0x22, 0x21, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x1b, 0x22, 0x16, 0x2f, 0x64,
+	0x62, 0x2f, 0x72, 0x65, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x2f, 0x72, 0x65,
+	0x73, 0x75, 0x6d, 0x65, 0x3a, 0x01, 0x2a, 0x12, 0x6f, 0x0a, 0x10, 0x52, 0x65, 0x73, 0x65, 0x74,
+	0x52, 0x65, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x21, 0x2e, 0x69, 0x6d,
+	0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x52, 0x65, 0x70, 0x6c,
+	0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x16,
+	0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66,
+	0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x20, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x1a, 0x22, 0x15,
+	0x2f, 0x64, 0x62, 0x2f, 0x72, 0x65, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x2f,
+	0x72, 0x65, 0x73, 0x65, 0x74, 0x3a, 0x01, 0x2a, 0x12, 0x79, 0x0a, 0x0d, 0x4b, 0x65, 0x79, 0x73,
+	0x70, 0x61, 0x63, 0x65, 0x53, 0x74, 0x61, 0x74, 0x73, 0x12, 0x23, 0x2e, 0x69, 0x6d, 0x6d, 0x75,
+	0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x4b, 0x65, 0x79, 0x73, 0x70, 0x61,
+	0x63, 0x65, 0x53, 0x74, 0x61, 0x74, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x24,
+	0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x4b,
+	0x65, 0x79, 0x73, 0x70, 0x61, 0x63, 0x65, 0x53, 0x74, 0x61, 0x74, 0x73, 0x52, 0x65, 0x73, 0x70,
+	0x6f, 0x6e, 0x73, 0x65, 0x22, 0x1d, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x17, 0x3a, 0x01, 0x2a, 0x22,
+	0x12, 0x2f, 0x64, 0x62, 0x2f, 0x6b, 0x65, 0x79, 0x73, 0x70, 0x61, 0x63, 0x65, 0x2f, 0x73, 0x74,
+	0x61, 0x74, 0x73, 0x12, 0x62, 0x0a, 0x11, 0x43, 0x6f, 0x6d, 0x70, 0x61, 0x63, 0x74, 0x49, 0x6e,
+	0x64, 0x65, 0x78, 0x41, 0x73, 0x79, 0x6e, 0x63, 0x12, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c,
+	0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79,
+	0x1a, 0x12, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61,
+	0x2e, 0x4a, 0x6f, 0x62, 0x22, 0x21, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x1b, 0x3a, 0x01, 0x2a, 0x22,
+	0x16, 0x2f, 0x64, 0x62, 0x2f, 0x63, 0x6f, 0x6d, 0x70, 0x61, 0x63, 0x74, 0x69, 0x6e, 0x64, 0x65,
+	0x78, 0x2f, 0x61, 0x73, 0x79, 0x6e, 0x63, 0x12, 0x54, 0x0a, 0x0a, 0x53, 0x63, 0x72, 0x75, 0x62,
+	0x41, 0x73, 0x79, 0x6e, 0x63, 0x12, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x1a, 0x12, 0x2e,
+	0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x4a, 0x6f,
+	0x62, 0x22, 0x1a, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x14, 0x22, 0x0f, 0x2f, 0x64, 0x62, 0x2f, 0x73,
+	0x63, 0x72, 0x75, 0x62, 0x2f, 0x61, 0x73, 0x79, 0x6e, 0x63, 0x3a, 0x01, 0x2a, 0x12, 0x66, 0x0a,
+	0x0b, 0x53, 0x63, 0x72, 0x75, 0x62, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x16, 0x2e, 0x67,
+	0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45,
+	0x6d, 0x70, 0x74, 0x79, 0x1a, 0x22, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63,
+	0x68, 0x65, 0x6d, 0x61, 0x2e, 0x53, 0x63, 0x72, 0x75, 0x62, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73,
+	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x1b, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x15,
+	0x22, 0x10, 0x2f, 0x64, 0x62, 0x2f, 0x73, 0x63, 0x72, 0x75, 0x62, 0x2f, 0x73, 0x74, 0x61, 0x74,
+	0x75, 0x73, 0x3a, 0x01, 0x2a, 0x12, 0x5c, 0x0a, 0x08, 0x4c, 0x69, 0x73, 0x74, 0x4a, 0x6f, 0x62,
+	0x73, 0x12, 0x1e, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d,
+	0x61, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x4a, 0x6f, 0x62, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x1a, 0x16, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d,
+	0x61, 0x2e, 0x4a, 0x6f, 0x62, 0x4c, 0x69, 0x73, 0x74, 0x22, 0x18, 0x82, 0xd3, 0xe4, 0x93, 0x02,
+	0x12, 0x22, 0x0d, 0x2f, 0x64, 0x62, 0x2f, 0x6a, 0x6f, 0x62, 0x73, 0x2f, 0x6c, 0x69, 0x73, 0x74,
+	0x3a, 0x01, 0x2a, 0x12, 0x50, 0x0a, 0x06, 0x47, 0x65, 0x74, 0x4a, 0x6f, 0x62, 0x12, 0x19, 0x2e,
+	0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x4a, 0x6f,
+	0x62, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x12, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64,
+	0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x4a, 0x6f, 0x62, 0x22, 0x17, 0x82, 0xd3,
+	0xe4, 0x93, 0x02, 0x11, 0x3a, 0x01, 0x2a, 0x22, 0x0c, 0x2f, 0x64, 0x62, 0x2f, 0x6a, 0x6f, 0x62,
+	0x73, 0x2f, 0x67, 0x65, 0x74, 0x12, 0x56, 0x0a, 0x09, 0x43, 0x61, 0x6e, 0x63, 0x65, 0x6c, 0x4a,
+	0x6f, 0x62, 0x12, 0x19, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65,
+	0x6d, 0x61, 0x2e, 0x4a, 0x6f, 0x62, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x12, 0x2e,
+	0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x4a, 0x6f,
+	0x62, 0x22, 0x1a, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x14, 0x22, 0x0f, 0x2f, 0x64, 0x62, 0x2f, 0x6a,
+	0x6f, 0x62, 0x73, 0x2f, 0x63, 0x61, 0x6e, 0x63, 0x65, 0x6c, 0x3a, 0x01, 0x2a, 0x12, 0x5c, 0x0a,
+	0x0d, 0x47, 0x65, 0x74, 0x53, 0x65, 0x72, 0x76, 0x65, 0x72, 0x4b, 0x65, 0x79, 0x73, 0x12, 0x16,
+	0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66,
+	0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x1a, 0x19, 0x2e, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2e,
+	0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x2e, 0x53, 0x65, 0x72, 0x76, 0x65, 0x72, 0x4b, 0x65, 0x79,
+	0x73, 0x22, 0x18, 0x92, 0x41, 0x02, 0x62, 0x00, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x0d, 0x12, 0x0b,
+	0x2f, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x6b, 0x65, 0x79, 0x73, 0x42, 0x8b, 0x03, 0x5a, 0x2b,
+	0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x63, 0x6f, 0x64, 0x65, 0x6e,
+	0x6f, 0x74, 0x61, 0x72, 0x79, 0x2f, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x2f, 0x70, 0x6b, 0x67,
+	0x2f, 0x61, 0x70, 0x69, 0x2f, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x92, 0x41, 0xda, 0x02, 0x12,
+	0xee, 0x01, 0x0a, 0x0f, 0x69, 0x6d, 0x6d, 0x75, 0x64, 0x62, 0x20, 0x52, 0x45, 0x53, 0x54, 0x20,
+	0x41, 0x50, 0x49, 0x12, 0xda, 0x01, 0x3c, 0x62, 0x3e, 0x49, 0x4d, 0x50, 0x4f, 0x52, 0x54, 0x41,
+	0x4e, 0x54, 0x3c, 0x2f, 0x62, 0x3e, 0x3a, 0x20, 0x41, 0x6c, 0x6c, 0x20, 0x3c, 0x63, 0x6f, 0x64,
+	0x65, 0x3e, 0x67, 0x65, 0x74, 0x3c, 0x2f, 0x63, 0x6f, 0x64, 0x65, 0x3e, 0x20, 0x61, 0x6e, 0x64,
+	0x20, 0x3c, 0x63, 0x6f, 0x64, 0x65, 0x3e, 0x73, 0x61, 0x66, 0x65, 0x67, 0x65, 0x74, 0x3c, 0x2f,
+	0x63, 0x6f, 0x64, 0x65, 0x3e, 0x20, 0x66, 0x75, 0x6e, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x20,
+	0x72, 0x65, 0x74, 0x75, 0x72, 0x6e, 0x20, 0x3c, 0x75, 0x3e, 0x62, 0x61, 0x73, 0x65, 0x36, 0x34,
+	0x2d, 0x65, 0x6e, 0x63, 0x6f, 0x64, 0x65, 0x64, 0x3c, 0x2f, 0x75, 0x3e, 0x20, 0x6b, 0x65, 0x79,
+	0x73, 0x20, 0x61, 0x6e, 0x64, 0x20, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x73, 0x2c, 0x20, 0x77, 0x68,
+	0x69, 0x6c, 0x65, 0x20, 0x61, 0x6c, 0x6c, 0x20, 0x3c, 0x63, 0x6f, 0x64, 0x65, 0x3e, 0x73, 0x65,
+	0x74, 0x3c, 0x2f, 0x63, 0x6f, 0x64, 0x65, 0x3e, 0x20, 0x61, 0x6e, 0x64, 0x20, 0x3c, 0x63, 0x6f,
+	0x64, 0x65, 0x3e, 0x73, 0x61, 0x66, 0x65, 0x73, 0x65, 0x74, 0x3c, 0x2f, 0x63, 0x6f, 0x64, 0x65,
+	0x3e, 0x20, 0x66, 0x75, 0x6e, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x20, 0x65, 0x78, 0x70, 0x65,
+	0x63, 0x74, 0x20, 0x3c, 0x75, 0x3e, 0x62, 0x61, 0x73, 0x65, 0x36, 0x34, 0x2d, 0x65, 0x6e, 0x63,
+	0x6f, 0x64, 0x65, 0x64, 0x3c, 0x2f, 0x75, 0x3e, 0x20, 0x69, 0x6e, 0x70, 0x75, 0x74, 0x73, 0x2e,
+	0x5a, 0x59, 0x0a, 0x57, 0x0a, 0x06, 0x62, 0x65, 0x61, 0x72, 0x65, 0x72, 0x12, 0x4d, 0x12, 0x38,
+	0x41, 0x75, 0x74, 0x68, 0x65, 0x6e, 0x74, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x20, 0x74,
+	0x6f, 0x6b, 0x65, 0x6e, 0x2c, 0x20, 0x70, 0x72, 0x65, 0x66, 0x69, 0x78, 0x65, 0x64, 0x20, 0x62,
+	0x79, 0x20, 0x42, 0x65, 0x61, 0x72, 0x65, 0x72, 0x3a, 0x20, 0x42, 0x65, 0x61, 0x72, 0x65, 0x72,
+	0x20, 0x3c, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x3e, 0x08, 0x02, 0x20, 0x02, 0x1a, 0x0d, 0x41, 0x75,
+	0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x62, 0x0c, 0x0a, 0x0a, 0x0a,
+	0x06, 0x62, 0x65, 0x61, 0x72, 0x65, 0x72, 0x12, 0x00, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x33,
+}
+
+var (
+	file_schema_proto_rawDescOnce sync.Once
+	file_schema_proto_rawDescData = file_schema_proto_rawDesc
+)
+
+func file_schema_proto_rawDescGZIP() []byte {
+	file_schema_proto_rawDescOnce.Do(func() {
+		file_schema_proto_rawDescData = protoimpl.X.CompressGZIP(file_schema_proto_rawDescData)
+	})
+	return file_schema_proto_rawDescData
+}
+
+var file_schema_proto_enumTypes = make([]protoimpl.EnumInfo, 8)
+var file_schema_proto_msgTypes = make([]protoimpl.MessageInfo, 148)
+var file_schema_proto_goTypes = []interface{}{
+	(KeyStatus)(0),                          // 0: immudb.schema.KeyStatus
+	(EntryTypeAction)(0),                    // 1: immudb.schema.EntryTypeAction
+	(PermissionAction)(0),                   // 2: immudb.schema.PermissionAction
+	(TxMode)(0),                             // 3: immudb.schema.TxMode
+	(ExternalRootType)(0),                   // 4: immudb.schema.ExternalRootType
+	(KVSchemaType)(0),                       // 5: immudb.schema.KVSchemaType
+	(RedactionMode)(0),                      // 6: immudb.schema.RedactionMode
+	(ProofType)(0),                          // 7: immudb.schema.ProofType
+	(*Key)(nil),                             // 8: immudb.schema.Key
+	(*Permission)(nil),                      // 9: immudb.schema.Permission
+	(*User)(nil),                            // 10: immudb.schema.User
+	(*UserList)(nil),                        // 11: immudb.schema.UserList
+	(*CreateUserRequest)(nil),               // 12: immudb.schema.CreateUserRequest
+	(*UserRequest)(nil),                     // 13: immudb.schema.UserRequest
+	(*ChangePasswordRequest)(nil),           // 14: immudb.schema.ChangePasswordRequest
+	(*LoginRequest)(nil),                    // 15: immudb.schema.LoginRequest
+	(*LoginResponse)(nil),                   // 16: immudb.schema.LoginResponse
+	(*AuthConfig)(nil),                      // 17: immudb.schema.AuthConfig
+	(*MTLSConfig)(nil),                      // 18: immudb.schema.MTLSConfig
+	(*OpenSessionRequest)(nil),              // 19: immudb.schema.OpenSessionRequest
+	(*OpenSessionResponse)(nil),             // 20: immudb.schema.OpenSessionResponse
+	(*KeyValue)(nil),                        // 21: immudb.schema.KeyValue
+	(*Entry)(nil),                           // 22: immudb.schema.Entry
+	(*Reference)(nil),                       // 23: immudb.schema.Reference
+	(*Op)(nil),                              // 24: immudb.schema.Op
+	(*ExecAllRequest)(nil),                  // 25: immudb.schema.ExecAllRequest
+	(*ExecScriptRequest)(nil),               // 26: immudb.schema.ExecScriptRequest
+	(*ExecScriptResult)(nil),                // 27: immudb.schema.ExecScriptResult
+	(*Entries)(nil),                         // 28: immudb.schema.Entries
+	(*KeyResult)(nil),                       // 29: immudb.schema.KeyResult
+	(*ZEntry)(nil),                          // 30: immudb.schema.ZEntry
+	(*ZEntries)(nil),                        // 31: immudb.schema.ZEntries
+	(*ScanRequest)(nil),                     // 32: immudb.schema.ScanRequest
+	(*KeyPrefix)(nil),                       // 33: immudb.schema.KeyPrefix
+	(*EntryCount)(nil),                      // 34: immudb.schema.EntryCount
+	(*Signature)(nil),                       // 35: immudb.schema.Signature
+	(*TxHeader)(nil),                        // 36: immudb.schema.TxHeader
+	(*TxMetadata)(nil),                      // 37: immudb.schema.TxMetadata
+	(*LinearProof)(nil),                     // 38: immudb.schema.LinearProof
+	(*DualProof)(nil),                       // 39: immudb.schema.DualProof
+	(*Tx)(nil),                              // 40: immudb.schema.Tx
+	(*TxEntry)(nil),                         // 41: immudb.schema.TxEntry
+	(*KVMetadata)(nil),                      // 42: immudb.schema.KVMetadata
+	(*Expiration)(nil),                      // 43: immudb.schema.Expiration
+	(*VerifiableTx)(nil),                    // 44: immudb.schema.VerifiableTx
+	(*VerifiableEntry)(nil),                 // 45: immudb.schema.VerifiableEntry
+	(*InclusionProof)(nil),                  // 46: immudb.schema.InclusionProof
+	(*SetRequest)(nil),                      // 47: immudb.schema.SetRequest
+	(*KeyRequest)(nil),                      // 48: immudb.schema.KeyRequest
+	(*KeyListRequest)(nil),                  // 49: immudb.schema.KeyListRequest
+	(*DeleteKeysRequest)(nil),               // 50: immudb.schema.DeleteKeysRequest
+	(*VerifiableSetRequest)(nil),            // 51: immudb.schema.VerifiableSetRequest
+	(*VerifiableGetRequest)(nil),            // 52: immudb.schema.VerifiableGetRequest
+	(*HealthResponse)(nil),                  // 53: immudb.schema.HealthResponse
+	(*ServerInfoRequest)(nil),               // 54: immudb.schema.ServerInfoRequest
+	(*ServerInfoResponse)(nil),              // 55: immudb.schema.ServerInfoResponse
+	(*DatabaseHealthResponse)(nil),          // 56: immudb.schema.DatabaseHealthResponse
+	(*ImmutableState)(nil),                  // 57: immudb.schema.ImmutableState
+	(*ReferenceRequest)(nil),                // 58: immudb.schema.ReferenceRequest
+	(*VerifiableReferenceRequest)(nil),      // 59: immudb.schema.VerifiableReferenceRequest
+	(*ResolveAllRequest)(nil),               // 60: immudb.schema.ResolveAllRequest
+	(*RenameRequest)(nil),                   // 61: immudb.schema.RenameRequest
+	(*ZAddRequest)(nil),                     // 62: immudb.schema.ZAddRequest
+	(*Score)(nil),                           // 63: immudb.schema.Score
+	(*ZScanRequest)(nil),                    // 64: immudb.schema.ZScanRequest
+	(*HistoryRequest)(nil),                  // 65: immudb.schema.HistoryRequest
+	(*VerifiableZAddRequest)(nil),           // 66: immudb.schema.VerifiableZAddRequest
+	(*TxRequest)(nil),                       // 67: immudb.schema.TxRequest
+	(*EntriesSpec)(nil),                     // 68: immudb.schema.EntriesSpec
+	(*EntryTypeSpec)(nil),                   // 69: immudb.schema.EntryTypeSpec
+	(*VerifiableTxRequest)(nil),             // 70: immudb.schema.VerifiableTxRequest
+	(*TxScanRequest)(nil),                   // 71: immudb.schema.TxScanRequest
+	(*TxList)(nil),                          // 72: immudb.schema.TxList
+	(*ExportTxRequest)(nil),                 // 73: immudb.schema.ExportTxRequest
+	(*Database)(nil),                        // 74: immudb.schema.Database
+	(*DatabaseSettings)(nil),                // 75: immudb.schema.DatabaseSettings
+	(*DatabaseSettingsUpdateResult)(nil),    // 76: immudb.schema.DatabaseSettingsUpdateResult
+	(*CloneDatabaseRequest)(nil),            // 77: immudb.schema.CloneDatabaseRequest
+	(*CloneDatabaseResponse)(nil),           // 78: immudb.schema.CloneDatabaseResponse
+	(*DatabaseSettingsHistoryRequest)(nil),  // 79: immudb.schema.DatabaseSettingsHistoryRequest
+	(*DatabaseSettingsHistory)(nil),         // 80: immudb.schema.DatabaseSettingsHistory
+	(*DatabaseSettingsVersion)(nil),         // 81: immudb.schema.DatabaseSettingsVersion
+	(*DatabaseSettingChange)(nil),           // 82: immudb.schema.DatabaseSettingChange
+	(*DatabaseSettingsRollbackRequest)(nil), // 83: immudb.schema.DatabaseSettingsRollbackRequest
+	(*ConditionalUint32)(nil),               // 84: immudb.schema.ConditionalUint32
+	(*ConditionalUint64)(nil),               // 85: immudb.schema.ConditionalUint64
+	(*ConditionalFloat)(nil),                // 86: immudb.schema.ConditionalFloat
+	(*ConditionalBool)(nil),                 // 87: immudb.schema.ConditionalBool
+	(*ConditionalString)(nil),               // 88: immudb.schema.ConditionalString
+	(*ReplicationSettings)(nil),             // 89: immudb.schema.ReplicationSettings
+	(*DatabaseSettingsV2)(nil),              // 90: immudb.schema.DatabaseSettingsV2
+	(*IndexSettings)(nil),                   // 91: immudb.schema.IndexSettings
+	(*FlushIndexRequest)(nil),               // 92: immudb.schema.FlushIndexRequest
+	(*Table)(nil),                           // 93: immudb.schema.Table
+	(*SQLGetRequest)(nil),                   // 94: immudb.schema.SQLGetRequest
+	(*VerifiableSQLGetRequest)(nil),         // 95: immudb.schema.VerifiableSQLGetRequest
+	(*SQLEntry)(nil),                        // 96: immudb.schema.SQLEntry
+	(*VerifiableSQLEntry)(nil),              // 97: immudb.schema.VerifiableSQLEntry
+	(*UseDatabaseReply)(nil),                // 98: immudb.schema.UseDatabaseReply
+	(*ChangePermissionRequest)(nil),         // 99: immudb.schema.ChangePermissionRequest
+	(*SetActiveUserRequest)(nil),            // 100: immudb.schema.SetActiveUserRequest
+	(*DatabaseListResponse)(nil),            // 101: immudb.schema.DatabaseListResponse
+	(*Chunk)(nil),                           // 102: immudb.schema.Chunk
+	(*UseSnapshotRequest)(nil),              // 103: immudb.schema.UseSnapshotRequest
+	(*SQLExecRequest)(nil),                  // 104: immudb.schema.SQLExecRequest
+	(*SQLQueryRequest)(nil),                 // 105: immudb.schema.SQLQueryRequest
+	(*NamedParam)(nil),                      // 106: immudb.schema.NamedParam
+	(*SQLExecResult)(nil),                   // 107: immudb.schema.SQLExecResult
+	(*CommittedSQLTx)(nil),                  // 108: immudb.schema.CommittedSQLTx
+	(*SQLQueryResult)(nil),                  // 109: immudb.schema.SQLQueryResult
+	(*Column)(nil),                          // 110: immudb.schema.Column
+	(*Row)(nil),                             // 111: immudb.schema.Row
+	(*SQLValue)(nil),                        // 112: immudb.schema.SQLValue
+	(*NewTxRequest)(nil),                    // 113: immudb.schema.NewTxRequest
+	(*NewTxResponse)(nil),                   // 114: immudb.schema.NewTxResponse
+	(*ExternalRoot)(nil),                    // 115: immudb.schema.ExternalRoot
+	(*ExternalRootsRequest)(nil),            // 116: immudb.schema.ExternalRootsRequest
+	(*ExternalRootList)(nil),                // 117: immudb.schema.ExternalRootList
+	(*KVSchema)(nil),                        // 118: immudb.schema.KVSchema
+	(*KVSchemaRequest)(nil),                 // 119: immudb.schema.KVSchemaRequest
+	(*KVSchemaList)(nil),                    // 120: immudb.schema.KVSchemaList
+	(*RedactionRule)(nil),                   // 121: immudb.schema.RedactionRule
+	(*RedactionRuleRequest)(nil),            // 122: immudb.schema.RedactionRuleRequest
+	(*RedactionRuleList)(nil),               // 123: immudb.schema.RedactionRuleList
+	(*ReplicationRequest)(nil),              // 124: immudb.schema.ReplicationRequest
+	(*ReplicationStatusResponse)(nil),       // 125: immudb.schema.ReplicationStatusResponse
+	(*KeyspaceStatsRequest)(nil),            // 126: immudb.schema.KeyspaceStatsRequest
+	(*KeyspaceStatsResponse)(nil),           // 127: immudb.schema.KeyspaceStatsResponse
+	(*KeyspaceUsage)(nil),                   // 128: immudb.schema.KeyspaceUsage
+	(*SizeDistribution)(nil),                // 129: immudb.schema.SizeDistribution
+	(*SizeBucket)(nil),                      // 130: immudb.schema.SizeBucket
+	(*KeyRevisions)(nil),                    // 131: immudb.schema.KeyRevisions
+	(*Job)(nil),                             // 132: immudb.schema.Job
+	(*ScrubMismatch)(nil),                   // 133: immudb.schema.ScrubMismatch
+	(*ScrubStatusResponse)(nil),             // 134: immudb.schema.ScrubStatusResponse
+	(*JobRequest)(nil),                      // 135: immudb.schema.JobRequest
+	(*ListJobsRequest)(nil),                 // 136: immudb.schema.ListJobsRequest
+	(*JobList)(nil),                         // 137: immudb.schema.JobList
+	(*ServerKey)(nil),                       // 138: immudb.schema.ServerKey
+	(*ServerKeys)(nil),                      // 139: immudb.schema.ServerKeys
+	(*ProofBundle)(nil),                     // 140: immudb.schema.ProofBundle
+	(*DatabaseKVs)(nil),                     // 141: immudb.schema.DatabaseKVs
+	(*MultiDatabaseSetRequest)(nil),         // 142: immudb.schema.MultiDatabaseSetRequest
+	(*DatabaseTxHeader)(nil),                // 143: immudb.schema.DatabaseTxHeader
+	(*MultiDatabaseTxHeaders)(nil),          // 144: immudb.schema.MultiDatabaseTxHeaders
+	(*MultiDatabaseTx)(nil),                 // 145: immudb.schema.MultiDatabaseTx
+	(*ErrorInfo)(nil),                       // 146: immudb.schema.ErrorInfo
+	(*DebugInfo)(nil),                       // 147: immudb.schema.DebugInfo
+	(*RetryInfo)(nil),                       // 148: immudb.schema.RetryInfo
+	nil,                                     // 149: immudb.schema.OpenSessionRequest.SessionVarsEntry
+	nil,                                     // 150: immudb.schema.VerifiableSQLEntry.ColNamesByIdEntry
+	nil,                                     // 151: immudb.schema.VerifiableSQLEntry.ColIdsByNameEntry
+	nil,                                     // 152: immudb.schema.VerifiableSQLEntry.ColTypesByIdEntry
+	nil,                                     // 153: immudb.schema.VerifiableSQLEntry.ColLenByIdEntry
+	nil,                                     // 154: immudb.schema.CommittedSQLTx.LastInsertedPKsEntry
+	nil,                                     // 155: immudb.schema.CommittedSQLTx.FirstInsertedPKsEntry
+	(_struct.NullValue)(0),                  // 156: google.protobuf.NullValue
+	(*empty.Empty)(nil),                     // 157: google.protobuf.Empty
+}
+var file_schema_proto_depIdxs = []int32{
+	9,   // 0: immudb.schema.User.permissions:type_name -> immudb.schema.Permission
+	10,  // 1: immudb.schema.UserList.users:type_name -> immudb.schema.User
+	149, // 2: immudb.schema.OpenSessionRequest.sessionVars:type_name -> immudb.schema.OpenSessionRequest.SessionVarsEntry
+	42,  // 3: immudb.schema.KeyValue.metadata:type_name -> immudb.schema.KVMetadata
+	23,  // 4: immudb.schema.Entry.referencedBy:type_name -> immudb.schema.Reference
+	42,  // 5: immudb.schema.Entry.metadata:type_name -> immudb.schema.KVMetadata
+	42,  // 6: immudb.schema.Reference.metadata:type_name -> immudb.schema.KVMetadata
+	21,  // 7: immudb.schema.Op.kv:type_name -> immudb.schema.KeyValue
+	62,  // 8: immudb.schema.Op.zAdd:type_name -> immudb.schema.ZAddRequest
+	58,  // 9: immudb.schema.Op.ref:type_name -> immudb.schema.ReferenceRequest
+	24,  // 10: immudb.schema.ExecAllRequest.Operations:type_name -> immudb.schema.Op
+	106, // 11: immudb.schema.ExecScriptRequest.params:type_name -> immudb.schema.NamedParam
+	36,  // 12: immudb.schema.ExecScriptResult.txHeader:type_name -> immudb.schema.TxHeader
+	112, // 13: immudb.schema.ExecScriptResult.result:type_name -> immudb.schema.SQLValue
+	22,  // 14: immudb.schema.Entries.entries:type_name -> immudb.schema.Entry
+	29,  // 15: immudb.schema.Entries.results:type_name -> immudb.schema.KeyResult
+	0,   // 16: immudb.schema.KeyResult.status:type_name -> immudb.schema.KeyStatus
+	22,  // 17: immudb.schema.KeyResult.entry:type_name -> immudb.schema.Entry
+	22,  // 18: immudb.schema.ZEntry.entry:type_name -> immudb.schema.Entry
+	30,  // 19: immudb.schema.ZEntries.entries:type_name -> immudb.schema.ZEntry
+	37,  // 20: immudb.schema.TxHeader.metadata:type_name -> immudb.schema.TxMetadata
+	36,  // 21: immudb.schema.DualProof.sourceTxHeader:type_name -> immudb.schema.TxHeader
+	36,  // 22: immudb.schema.DualProof.targetTxHeader:type_name -> immudb.schema.TxHeader
+	38,  // 23: immudb.schema.DualProof.linearProof:type_name -> immudb.schema.LinearProof
+	36,  // 24: immudb.schema.Tx.header:type_name -> immudb.schema.TxHeader
+	41,  // 25: immudb.schema.Tx.entries:type_name -> immudb.schema.TxEntry
+	22,  // 26: immudb.schema.Tx.kvEntries:type_name -> immudb.schema.Entry
+	30,  // 27: immudb.schema.Tx.zEntries:type_name -> immudb.schema.ZEntry
+	42,  // 28: immudb.schema.TxEntry.metadata:type_name -> immudb.schema.KVMetadata
+	43,  // 29: immudb.schema.KVMetadata.expiration:type_name -> immudb.schema.Expiration
+	40,  // 30: immudb.schema.VerifiableTx.tx:type_name -> immudb.schema.Tx
+	39,  // 31: immudb.schema.VerifiableTx.dualProof:type_name -> immudb.schema.DualProof
+	35,  // 32: immudb.schema.VerifiableTx.signature:type_name -> immudb.schema.Signature
+	22,  // 33: immudb.schema.VerifiableEntry.entry:type_name -> immudb.schema.Entry
+	44,  // 34: immudb.schema.VerifiableEntry.verifiableTx:type_name -> immudb.schema.VerifiableTx
+	46,  // 35: immudb.schema.VerifiableEntry.inclusionProof:type_name -> immudb.schema.InclusionProof
+	21,  // 36: immudb.schema.SetRequest.KVs:type_name -> immudb.schema.KeyValue
+	47,  // 37: immudb.schema.VerifiableSetRequest.setRequest:type_name -> immudb.schema.SetRequest
+	48,  // 38: immudb.schema.VerifiableGetRequest.keyRequest:type_name -> immudb.schema.KeyRequest
+	35,  // 39: immudb.schema.ImmutableState.signature:type_name -> immudb.schema.Signature
+	43,  // 40: immudb.schema.ReferenceRequest.expiration:type_name -> immudb.schema.Expiration
+	58,  // 41: immudb.schema.VerifiableReferenceRequest.referenceRequest:type_name -> immudb.schema.ReferenceRequest
+	43,  // 42: immudb.schema.ZAddRequest.expiration:type_name -> immudb.schema.Expiration
+	63,  // 43: immudb.schema.ZScanRequest.minScore:type_name -> immudb.schema.Score
+	63,  // 44: immudb.schema.ZScanRequest.maxScore:type_name -> immudb.schema.Score
+	62,  // 45: immudb.schema.VerifiableZAddRequest.zAddRequest:type_name -> immudb.schema.ZAddRequest
+	68,  // 46: immudb.schema.TxRequest.entriesSpec:type_name -> immudb.schema.EntriesSpec
+	69,  // 47: immudb.schema.EntriesSpec.kvEntriesSpec:type_name -> immudb.schema.EntryTypeSpec
+	69,  // 48: immudb.schema.EntriesSpec.zEntriesSpec:type_name -> immudb.schema.EntryTypeSpec
+	69,  // 49: immudb.schema.EntriesSpec.sqlEntriesSpec:type_name -> immudb.schema.EntryTypeSpec
+	1,   // 50: immudb.schema.EntryTypeSpec.action:type_name -> immudb.schema.EntryTypeAction
+	68,  // 51: immudb.schema.VerifiableTxRequest.entriesSpec:type_name -> immudb.schema.EntriesSpec
+	68,  // 52: immudb.schema.TxScanRequest.entriesSpec:type_name -> immudb.schema.EntriesSpec
+	40,  // 53: immudb.schema.TxList.txs:type_name -> immudb.schema.Tx
+	90,  // 54: immudb.schema.DatabaseSettingsUpdateResult.currentSettings:type_name -> immudb.schema.DatabaseSettingsV2
+	90,  // 55: immudb.schema.CloneDatabaseResponse.settings:type_name -> immudb.schema.DatabaseSettingsV2
+	81,  // 56: immudb.schema.DatabaseSettingsHistory.versions:type_name -> immudb.schema.DatabaseSettingsVersion
+	90,  // 57: immudb.schema.DatabaseSettingsVersion.settings:type_name -> immudb.schema.DatabaseSettingsV2
+	82,  // 58: immudb.schema.DatabaseSettingsVersion.changes:type_name -> immudb.schema.DatabaseSettingChange
+	87,  // 59: immudb.schema.ReplicationSettings.replica:type_name -> immudb.schema.ConditionalBool
+	88,  // 60: immudb.schema.ReplicationSettings.masterDatabase:type_name -> immudb.schema.ConditionalString
+	88,  // 61: immudb.schema.ReplicationSettings.masterAddress:type_name -> immudb.schema.ConditionalString
+	84,  // 62: immudb.schema.ReplicationSettings.masterPort:type_name -> immudb.schema.ConditionalUint32
+	88,  // 63: immudb.schema.ReplicationSettings.followerUsername:type_name -> immudb.schema.ConditionalString
+	88,  // 64: immudb.schema.ReplicationSettings.followerPassword:type_name -> immudb.schema.ConditionalString
+	89,  // 65: immudb.schema.DatabaseSettingsV2.replicationSettings:type_name -> immudb.schema.ReplicationSettings
+	84,  // 66: immudb.schema.DatabaseSettingsV2.fileSize:type_name -> immudb.schema.ConditionalUint32
+	84,  // 67: immudb.schema.DatabaseSettingsV2.maxKeyLen:type_name -> immudb.schema.ConditionalUint32
+	84,  // 68: immudb.schema.DatabaseSettingsV2.maxValueLen:type_name -> immudb.schema.ConditionalUint32
+	84,  // 69: immudb.schema.DatabaseSettingsV2.maxTxEntries:type_name -> immudb.schema.ConditionalUint32
+	87,  // 70: immudb.schema.DatabaseSettingsV2.excludeCommitTime:type_name -> immudb.schema.ConditionalBool
+	84,  // 71: immudb.schema.DatabaseSettingsV2.maxConcurrency:type_name -> immudb.schema.ConditionalUint32
+	84,  // 72: immudb.schema.DatabaseSettingsV2.maxIOConcurrency:type_name -> immudb.schema.ConditionalUint32
+	84,  // 73: immudb.schema.DatabaseSettingsV2.txLogCacheSize:type_name -> immudb.schema.ConditionalUint32
+	84,  // 74: immudb.schema.DatabaseSettingsV2.vLogMaxOpenedFiles:type_name -> immudb.schema.ConditionalUint32
+	84,  // 75: immudb.schema.DatabaseSettingsV2.txLogMaxOpenedFiles:type_name -> immudb.schema.ConditionalUint32
+	84,  // 76: immudb.schema.DatabaseSettingsV2.commitLogMaxOpenedFiles:type_name -> immudb.schema.ConditionalUint32
+	91,  // 77: immudb.schema.DatabaseSettingsV2.indexSettings:type_name -> immudb.schema.IndexSettings
+	84,  // 78: immudb.schema.DatabaseSettingsV2.writeTxHeaderVersion:type_name -> immudb.schema.ConditionalUint32
+	88,  // 79: immudb.schema.DatabaseSettingsV2.valueCompression:type_name -> immudb.schema.ConditionalString
+	84,  // 80: immudb.schema.DatabaseSettingsV2.valueCompressionThreshold:type_name -> immudb.schema.ConditionalUint32
+	87,  // 81: immudb.schema.DatabaseSettingsV2.anonymousReads:type_name -> immudb.schema.ConditionalBool
+	87,  // 82: immudb.schema.DatabaseSettingsV2.maintenanceMode:type_name -> immudb.schema.ConditionalBool
+	85,  // 83: immudb.schema.DatabaseSettingsV2.maxDiskUsage:type_name -> immudb.schema.ConditionalUint64
+	87,  // 84: immudb.schema.DatabaseSettingsV2.reverseReferenceIndex:type_name -> immudb.schema.ConditionalBool
+	85,  // 85: immudb.schema.DatabaseSettingsV2.scrubInterval:type_name -> immudb.schema.ConditionalUint64
+	84,  // 86: immudb.schema.DatabaseSettingsV2.delayDuringScrub:type_name -> immudb.schema.ConditionalUint32
+	84,  // 87: immudb.schema.IndexSettings.flushThreshold:type_name -> immudb.schema.ConditionalUint32
+	84,  // 88: immudb.schema.IndexSettings.syncThreshold:type_name -> immudb.schema.ConditionalUint32
+	84,  // 89: immudb.schema.IndexSettings.cacheSize:type_name -> immudb.schema.ConditionalUint32
+	84,  // 90: immudb.schema.IndexSettings.maxNodeSize:type_name -> immudb.schema.ConditionalUint32
+	84,  // 91: immudb.schema.IndexSettings.maxActiveSnapshots:type_name -> immudb.schema.ConditionalUint32
+	85,  // 92: immudb.schema.IndexSettings.renewSnapRootAfter:type_name -> immudb.schema.ConditionalUint64
+	84,  // 93: immudb.schema.IndexSettings.compactionThld:type_name -> immudb.schema.ConditionalUint32
+	84,  // 94: immudb.schema.IndexSettings.delayDuringCompaction:type_name -> immudb.schema.ConditionalUint32
+	84,  // 95: immudb.schema.IndexSettings.nodesLogMaxOpenedFiles:type_name -> immudb.schema.ConditionalUint32
+	84,  // 96: immudb.schema.IndexSettings.historyLogMaxOpenedFiles:type_name -> immudb.schema.ConditionalUint32
+	84,  // 97: immudb.schema.IndexSettings.commitLogMaxOpenedFiles:type_name -> immudb.schema.ConditionalUint32
+	84,  // 98: immudb.schema.IndexSettings.flushBufferSize:type_name -> immudb.schema.ConditionalUint32
+	86,  // 99: immudb.schema.IndexSettings.cleanupPercentage:type_name -> immudb.schema.ConditionalFloat
+	87,  // 100: immudb.schema.IndexSettings.autoCompaction:type_name -> immudb.schema.ConditionalBool
+	86,  // 101: immudb.schema.IndexSettings.autoCompactionGarbageThld:type_name -> immudb.schema.ConditionalFloat
+	84,  // 102: immudb.schema.IndexSettings.autoCompactionFilesThld:type_name -> immudb.schema.ConditionalUint32
+	84,  // 103: immudb.schema.IndexSettings.autoCompactionLatencyBudget:type_name -> immudb.schema.ConditionalUint32
+	112, // 104: immudb.schema.SQLGetRequest.pkValues:type_name -> immudb.schema.SQLValue
+	94,  // 105: immudb.schema.VerifiableSQLGetRequest.sqlGetRequest:type_name -> immudb.schema.SQLGetRequest
+	42,  // 106: immudb.schema.SQLEntry.metadata:type_name -> immudb.schema.KVMetadata
+	96,  // 107: immudb.schema.VerifiableSQLEntry.sqlEntry:type_name -> immudb.schema.SQLEntry
+	44,  // 108: immudb.schema.VerifiableSQLEntry.verifiableTx:type_name -> immudb.schema.VerifiableTx
+	46,  // 109: immudb.schema.VerifiableSQLEntry.inclusionProof:type_name -> immudb.schema.InclusionProof
+	150, // 110: immudb.schema.VerifiableSQLEntry.ColNamesById:type_name -> immudb.schema.VerifiableSQLEntry.ColNamesByIdEntry
+	151, // 111: immudb.schema.VerifiableSQLEntry.ColIdsByName:type_name -> immudb.schema.VerifiableSQLEntry.ColIdsByNameEntry
+	152, // 112: immudb.schema.VerifiableSQLEntry.ColTypesById:type_name -> immudb.schema.VerifiableSQLEntry.ColTypesByIdEntry
+	153, // 113: immudb.schema.VerifiableSQLEntry.ColLenById:type_name -> immudb.schema.VerifiableSQLEntry.ColLenByIdEntry
+	2,   // 114: immudb.schema.ChangePermissionRequest.action:type_name -> immudb.schema.PermissionAction
+	74,  // 115: immudb.schema.DatabaseListResponse.databases:type_name -> immudb.schema.Database
+	106, // 116: immudb.schema.SQLExecRequest.params:type_name -> immudb.schema.NamedParam
+	106, // 117: immudb.schema.SQLQueryRequest.params:type_name -> immudb.schema.NamedParam
+	112, // 118: immudb.schema.NamedParam.value:type_name -> immudb.schema.SQLValue
+	108, // 119: immudb.schema.SQLExecResult.txs:type_name -> immudb.schema.CommittedSQLTx
+	36,  // 120: immudb.schema.CommittedSQLTx.header:type_name -> immudb.schema.TxHeader
+	154, // 121: immudb.schema.CommittedSQLTx.lastInsertedPKs:type_name -> immudb.schema.CommittedSQLTx.LastInsertedPKsEntry
+	155, // 122: immudb.schema.CommittedSQLTx.firstInsertedPKs:type_name -> immudb.schema.CommittedSQLTx.FirstInsertedPKsEntry
+	110, // 123: immudb.schema.SQLQueryResult.columns:type_name -> immudb.schema.Column
+	111, // 124: immudb.schema.SQLQueryResult.rows:type_name -> immudb.schema.Row
+	112, // 125: immudb.schema.Row.values:type_name -> immudb.schema.SQLValue
+	156, // 126: immudb.schema.SQLValue.null:type_name -> google.protobuf.NullValue
+	3,   // 127: immudb.schema.NewTxRequest.mode:type_name -> immudb.schema.TxMode
+	4,   // 128: immudb.schema.ExternalRoot.type:type_name -> immudb.schema.ExternalRootType
+	35,  // 129: immudb.schema.ExternalRoot.signature:type_name -> immudb.schema.Signature
+	115, // 130: immudb.schema.ExternalRootList.roots:type_name -> immudb.schema.ExternalRoot
+	5,   // 131: immudb.schema.KVSchema.type:type_name -> immudb.schema.KVSchemaType
+	118, // 132: immudb.schema.KVSchemaList.schemas:type_name -> immudb.schema.KVSchema
+	6,   // 133: immudb.schema.RedactionRule.mode:type_name -> immudb.schema.RedactionMode
+	121, // 134: immudb.schema.RedactionRuleList.rules:type_name -> immudb.schema.RedactionRule
+	128, // 135: immudb.schema.KeyspaceStatsResponse.keyspaces:type_name -> immudb.schema.KeyspaceUsage
+	128, // 136: immudb.schema.KeyspaceStatsResponse.prefixes:type_name -> immudb.schema.KeyspaceUsage
+	128, // 137: immudb.schema.KeyspaceStatsResponse.otherPrefixes:type_name -> immudb.schema.KeyspaceUsage
+	129, // 138: immudb.schema.KeyspaceStatsResponse.keySizes:type_name -> immudb.schema.SizeDistribution
+	129, // 139: immudb.schema.KeyspaceStatsResponse.valueSizes:type_name -> immudb.schema.SizeDistribution
+	131, // 140: immudb.schema.KeyspaceStatsResponse.revisionOutliers:type_name -> immudb.schema.KeyRevisions
+	130, // 141: immudb.schema.SizeDistribution.buckets:type_name -> immudb.schema.SizeBucket
+	133, // 142: immudb.schema.ScrubStatusResponse.mismatches:type_name -> immudb.schema.ScrubMismatch
+	132, // 143: immudb.schema.JobList.jobs:type_name -> immudb.schema.Job
+	138, // 144: immudb.schema.ServerKeys.keys:type_name -> immudb.schema.ServerKey
+	7,   // 145: immudb.schema.ProofBundle.type:type_name -> immudb.schema.ProofType
+	57,  // 146: immudb.schema.ProofBundle.state:type_name -> immudb.schema.ImmutableState
+	57,  // 147: immudb.schema.ProofBundle.sourceState:type_name -> immudb.schema.ImmutableState
+	44,  // 148: immudb.schema.ProofBundle.verifiableTx:type_name -> immudb.schema.VerifiableTx
+	22,  // 149: immudb.schema.ProofBundle.entry:type_name -> immudb.schema.Entry
+	46,  // 150: immudb.schema.ProofBundle.inclusionProof:type_name -> immudb.schema.InclusionProof
+	21,  // 151: immudb.schema.DatabaseKVs.KVs:type_name -> immudb.schema.KeyValue
+	141, // 152: immudb.schema.MultiDatabaseSetRequest.writes:type_name -> immudb.schema.DatabaseKVs
+	36,  // 153: immudb.schema.DatabaseTxHeader.header:type_name -> immudb.schema.TxHeader
+	143, // 154: immudb.schema.MultiDatabaseTxHeaders.headers:type_name -> immudb.schema.DatabaseTxHeader
+	112, // 155: immudb.schema.CommittedSQLTx.LastInsertedPKsEntry.value:type_name -> immudb.schema.SQLValue
+	112, // 156: immudb.schema.CommittedSQLTx.FirstInsertedPKsEntry.value:type_name -> immudb.schema.SQLValue
+	157, // 157: immudb.schema.ImmuService.ListUsers:input_type -> google.protobuf.Empty
+	12,  // 158: immudb.schema.ImmuService.CreateUser:input_type -> immudb.schema.CreateUserRequest
+	14,  // 159: immudb.schema.ImmuService.ChangePassword:input_type -> immudb.schema.ChangePasswordRequest
+	17,  // 160: immudb.schema.ImmuService.UpdateAuthConfig:input_type -> immudb.schema.AuthConfig
+	18,  // 161: immudb.schema.ImmuService.UpdateMTLSConfig:input_type -> immudb.schema.MTLSConfig
+	19,  // 162: immudb.schema.ImmuService.OpenSession:input_type -> immudb.schema.OpenSessionRequest
+	157, // 163: immudb.schema.ImmuService.CloseSession:input_type -> google.protobuf.Empty
+	157, // 164: immudb.schema.ImmuService.KeepAlive:input_type -> google.protobuf.Empty
+	113, // 165: immudb.schema.ImmuService.NewTx:input_type -> immudb.schema.NewTxRequest
+	157, // 166: immudb.schema.ImmuService.Commit:input_type -> google.protobuf.Empty
+	157, // 167: immudb.schema.ImmuService.Rollback:input_type -> google.protobuf.Empty
+	104, // 168: immudb.schema.ImmuService.TxSQLExec:input_type -> immudb.schema.SQLExecRequest
+	105, // 169: immudb.schema.ImmuService.TxSQLQuery:input_type -> immudb.schema.SQLQueryRequest
+	47,  // 170: immudb.schema.ImmuService.TxSet:input_type -> immudb.schema.SetRequest
+	15,  // 171: immudb.schema.ImmuService.Login:input_type -> immudb.schema.LoginRequest
+	157, // 172: immudb.schema.ImmuService.Logout:input_type -> google.protobuf.Empty
+	47,  // 173: immudb.schema.ImmuService.Set:input_type -> immudb.schema.SetRequest
+	51,  // 174: immudb.schema.ImmuService.VerifiableSet:input_type -> immudb.schema.VerifiableSetRequest
+	48,  // 175: immudb.schema.ImmuService.Get:input_type -> immudb.schema.KeyRequest
+	52,  // 176: immudb.schema.ImmuService.VerifiableGet:input_type -> immudb.schema.VerifiableGetRequest
+	50,  // 177: immudb.schema.ImmuService.Delete:input_type -> immudb.schema.DeleteKeysRequest
+	49,  // 178: immudb.schema.ImmuService.GetAll:input_type -> immudb.schema.KeyListRequest
+	25,  // 179: immudb.schema.ImmuService.ExecAll:input_type -> immudb.schema.ExecAllRequest
+	26,  // 180: immudb.schema.ImmuService.ExecScript:input_type -> immudb.schema.ExecScriptRequest
+	32,  // 181: immudb.schema.ImmuService.Scan:input_type -> immudb.schema.ScanRequest
+	33,  // 182: immudb.schema.ImmuService.Count:input_type -> immudb.schema.KeyPrefix
+	157, // 183: immudb.schema.ImmuService.CountAll:input_type -> google.protobuf.Empty
+	67,  // 184: immudb.schema.ImmuService.TxById:input_type -> immudb.schema.TxRequest
+	70,  // 185: immudb.schema.ImmuService.VerifiableTxById:input_type -> immudb.schema.VerifiableTxRequest
+	71,  // 186: immudb.schema.ImmuService.TxScan:input_type -> immudb.schema.TxScanRequest
+	65,  // 187: immudb.schema.ImmuService.History:input_type -> immudb.schema.HistoryRequest
+	157, // 188: immudb.schema.ImmuService.Health:input_type -> google.protobuf.Empty
+	54,  // 189: immudb.schema.ImmuService.ServerInfo:input_type -> immudb.schema.ServerInfoRequest
+	157, // 190: immudb.schema.ImmuService.DatabaseHealth:input_type -> google.protobuf.Empty
+	157, // 191: immudb.schema.ImmuService.CurrentState:input_type -> google.protobuf.Empty
+	58,  // 192: immudb.schema.ImmuService.SetReference:input_type -> immudb.schema.ReferenceRequest
+	59,  // 193: immudb.schema.ImmuService.VerifiableSetReference:input_type -> immudb.schema.VerifiableReferenceRequest
+	60,  // 194: immudb.schema.ImmuService.ResolveAll:input_type -> immudb.schema.ResolveAllRequest
+	61,  // 195: immudb.schema.ImmuService.Rename:input_type -> immudb.schema.RenameRequest
+	62,  // 196: immudb.schema.ImmuService.ZAdd:input_type -> immudb.schema.ZAddRequest
+	66,  // 197: immudb.schema.ImmuService.VerifiableZAdd:input_type -> immudb.schema.VerifiableZAddRequest
+	64,  // 198: immudb.schema.ImmuService.ZScan:input_type -> immudb.schema.ZScanRequest
+	74,  // 199: immudb.schema.ImmuService.CreateDatabase:input_type -> immudb.schema.Database
+	75,  // 200: immudb.schema.ImmuService.CreateDatabaseWith:input_type -> immudb.schema.DatabaseSettings
+	90,  // 201: immudb.schema.ImmuService.CreateDatabaseWithV2:input_type -> immudb.schema.DatabaseSettingsV2
+	77,  // 202: immudb.schema.ImmuService.CloneDatabase:input_type -> immudb.schema.CloneDatabaseRequest
+	157, // 203: immudb.schema.ImmuService.DatabaseList:input_type -> google.protobuf.Empty
+	74,  // 204: immudb.schema.ImmuService.UseDatabase:input_type -> immudb.schema.Database
+	75,  // 205: immudb.schema.ImmuService.UpdateDatabase:input_type -> immudb.schema.DatabaseSettings
+	90,  // 206: immudb.schema.ImmuService.UpdateDatabaseV2:input_type -> immudb.schema.DatabaseSettingsV2
+	157, // 207: immudb.schema.ImmuService.GetDatabaseSettings:input_type -> google.protobuf.Empty
+	157, // 208: immudb.schema.ImmuService.GetDatabaseSettingsV2:input_type -> google.protobuf.Empty
+	79,  // 209: immudb.schema.ImmuService.GetDatabaseSettingsHistory:input_type -> immudb.schema.DatabaseSettingsHistoryRequest
+	83,  // 210: immudb.schema.ImmuService.RollbackDatabaseSettings:input_type -> immudb.schema.DatabaseSettingsRollbackRequest
+	92,  // 211: immudb.schema.ImmuService.FlushIndex:input_type -> immudb.schema.FlushIndexRequest
+	157, // 212: immudb.schema.ImmuService.CompactIndex:input_type -> google.protobuf.Empty
+	99,  // 213: immudb.schema.ImmuService.ChangePermission:input_type -> immudb.schema.ChangePermissionRequest
+	100, // 214: immudb.schema.ImmuService.SetActiveUser:input_type -> immudb.schema.SetActiveUserRequest
+	48,  // 215: immudb.schema.ImmuService.streamGet:input_type -> immudb.schema.KeyRequest
+	102, // 216: immudb.schema.ImmuService.streamSet:input_type -> immudb.schema.Chunk
+	52,  // 217: immudb.schema.ImmuService.streamVerifiableGet:input_type -> immudb.schema.VerifiableGetRequest
+	102, // 218: immudb.schema.ImmuService.streamVerifiableSet:input_type -> immudb.schema.Chunk
+	32,  // 219: immudb.schema.ImmuService.streamScan:input_type -> immudb.schema.ScanRequest
+	64,  // 220: immudb.schema.ImmuService.streamZScan:input_type -> immudb.schema.ZScanRequest
+	65,  // 221: immudb.schema.ImmuService.streamHistory:input_type -> immudb.schema.HistoryRequest
+	102, // 222: immudb.schema.ImmuService.streamExecAll:input_type -> immudb.schema.Chunk
+	73,  // 223: immudb.schema.ImmuService.exportTx:input_type -> immudb.schema.ExportTxRequest
+	102, // 224: immudb.schema.ImmuService.replicateTx:input_type -> immudb.schema.Chunk
+	104, // 225: immudb.schema.ImmuService.SQLExec:input_type -> immudb.schema.SQLExecRequest
+	105, // 226: immudb.schema.ImmuService.SQLQuery:input_type -> immudb.schema.SQLQueryRequest
+	157, // 227: immudb.schema.ImmuService.ListTables:input_type -> google.protobuf.Empty
+	93,  // 228: immudb.schema.ImmuService.DescribeTable:input_type -> immudb.schema.Table
+	95,  // 229: immudb.schema.ImmuService.VerifiableSQLGet:input_type -> immudb.schema.VerifiableSQLGetRequest
+	115, // 230: immudb.schema.ImmuService.RegisterExternalRoot:input_type -> immudb.schema.ExternalRoot
+	116, // 231: immudb.schema.ImmuService.ExternalRoots:input_type -> immudb.schema.ExternalRootsRequest
+	118, // 232: immudb.schema.ImmuService.SetKVSchema:input_type -> immudb.schema.KVSchema
+	119, // 233: immudb.schema.ImmuService.DeleteKVSchema:input_type -> immudb.schema.KVSchemaRequest
+	157, // 234: immudb.schema.ImmuService.KVSchemas:input_type -> google.protobuf.Empty
+	121, // 235: immudb.schema.ImmuService.SetRedactionRule:input_type -> immudb.schema.RedactionRule
+	122, // 236: immudb.schema.ImmuService.DeleteRedactionRule:input_type -> immudb.schema.RedactionRuleRequest
+	157, // 237: immudb.schema.ImmuService.RedactionRules:input_type -> google.protobuf.Empty
+	142, // 238: immudb.schema.ImmuService.MultiDatabaseSet:input_type -> immudb.schema.MultiDatabaseSetRequest
+	124, // 239: immudb.schema.ImmuService.ReplicationStatus:input_type -> immudb.schema.ReplicationRequest
+	124, // 240: immudb.schema.ImmuService.PauseReplication:input_type -> immudb.schema.ReplicationRequest
+	124, // 241: immudb.schema.ImmuService.ResumeReplication:input_type -> immudb.schema.ReplicationRequest
+	124, // 242: immudb.schema.ImmuService.ResetReplication:input_type -> immudb.schema.ReplicationRequest
+	126, // 243: immudb.schema.ImmuService.KeyspaceStats:input_type -> immudb.schema.KeyspaceStatsRequest
+	157, // 244: immudb.schema.ImmuService.CompactIndexAsync:input_type -> google.protobuf.Empty
+	157, // 245: immudb.schema.ImmuService.ScrubAsync:input_type -> google.protobuf.Empty
+	157, // 246: immudb.schema.ImmuService.ScrubStatus:input_type -> google.protobuf.Empty
+	136, // 247: immudb.schema.ImmuService.ListJobs:input_type -> immudb.schema.ListJobsRequest
+	135, // 248: immudb.schema.ImmuService.GetJob:input_type -> immudb.schema.JobRequest
+	135, // 249: immudb.schema.ImmuService.CancelJob:input_type -> immudb.schema.JobRequest
+	157, // 250: immudb.schema.ImmuService.GetServerKeys:input_type -> google.protobuf.Empty
+	11,  // 251: immudb.schema.ImmuService.ListUsers:output_type -> immudb.schema.UserList
+	157, // 252: immudb.schema.ImmuService.CreateUser:output_type -> google.protobuf.Empty
+	157, // 253: immudb.schema.ImmuService.ChangePassword:output_type -> google.protobuf.Empty
+	157, // 254: immudb.schema.ImmuService.UpdateAuthConfig:output_type -> google.protobuf.Empty
+	157, // 255: immudb.schema.ImmuService.UpdateMTLSConfig:output_type -> google.protobuf.Empty
+	20,  // 256: immudb.schema.ImmuService.OpenSession:output_type -> immudb.schema.OpenSessionResponse
+	157, // 257: immudb.schema.ImmuService.CloseSession:output_type -> google.protobuf.Empty
+	157, // 258: immudb.schema.ImmuService.KeepAlive:output_type -> google.protobuf.Empty
+	114, // 259: immudb.schema.ImmuService.NewTx:output_type -> immudb.schema.NewTxResponse
+	108, // 260: immudb.schema.ImmuService.Commit:output_type -> immudb.schema.CommittedSQLTx
+	157, // 261: immudb.schema.ImmuService.Rollback:output_type -> google.protobuf.Empty
+	157, // 262: immudb.schema.ImmuService.TxSQLExec:output_type -> google.protobuf.Empty
+	109, // 263: immudb.schema.ImmuService.TxSQLQuery:output_type -> immudb.schema.SQLQueryResult
+	157, // 264: immudb.schema.ImmuService.TxSet:output_type -> google.protobuf.Empty
+	16,  // 265: immudb.schema.ImmuService.Login:output_type -> immudb.schema.LoginResponse
+	157, // 266: immudb.schema.ImmuService.Logout:output_type -> google.protobuf.Empty
+	36,  // 267: immudb.schema.ImmuService.Set:output_type -> immudb.schema.TxHeader
+	44,  // 268: immudb.schema.ImmuService.VerifiableSet:output_type -> immudb.schema.VerifiableTx
+	22,  // 269: immudb.schema.ImmuService.Get:output_type -> immudb.schema.Entry
+	45,  // 270: immudb.schema.ImmuService.VerifiableGet:output_type -> immudb.schema.VerifiableEntry
+	36,  // 271: immudb.schema.ImmuService.Delete:output_type -> immudb.schema.TxHeader
+	28,  // 272: immudb.schema.ImmuService.GetAll:output_type -> immudb.schema.Entries
+	36,  // 273: immudb.schema.ImmuService.ExecAll:output_type -> immudb.schema.TxHeader
+	27,  // 274: immudb.schema.ImmuService.ExecScript:output_type -> immudb.schema.ExecScriptResult
+	28,  // 275: immudb.schema.ImmuService.Scan:output_type -> immudb.schema.Entries
+	34,  // 276: immudb.schema.ImmuService.Count:output_type -> immudb.schema.EntryCount
+	34,  // 277: immudb.schema.ImmuService.CountAll:output_type -> immudb.schema.EntryCount
+	40,  // 278: immudb.schema.ImmuService.TxById:output_type -> immudb.schema.Tx
+	44,  // 279: immudb.schema.ImmuService.VerifiableTxById:output_type -> immudb.schema.VerifiableTx
+	72,  // 280: immudb.schema.ImmuService.TxScan:output_type -> immudb.schema.TxList
+	28,  // 281: immudb.schema.ImmuService.History:output_type -> immudb.schema.Entries
+	53,  // 282: immudb.schema.ImmuService.Health:output_type -> immudb.schema.HealthResponse
+	55,  // 283: immudb.schema.ImmuService.ServerInfo:output_type -> immudb.schema.ServerInfoResponse
+	56,  // 284: immudb.schema.ImmuService.DatabaseHealth:output_type -> immudb.schema.DatabaseHealthResponse
+	57,  // 285: immudb.schema.ImmuService.CurrentState:output_type -> immudb.schema.ImmutableState
+	36,  // 286: immudb.schema.ImmuService.SetReference:output_type -> immudb.schema.TxHeader
+	44,  // 287: immudb.schema.ImmuService.VerifiableSetReference:output_type -> immudb.schema.VerifiableTx
+	28,  // 288: immudb.schema.ImmuService.ResolveAll:output_type -> immudb.schema.Entries
+	36,  // 289: immudb.schema.ImmuService.Rename:output_type -> immudb.schema.TxHeader
+	36,  // 290: immudb.schema.ImmuService.ZAdd:output_type -> immudb.schema.TxHeader
+	44,  // 291: immudb.schema.ImmuService.VerifiableZAdd:output_type -> immudb.schema.VerifiableTx
+	31,  // 292: immudb.schema.ImmuService.ZScan:output_type -> immudb.schema.ZEntries
+	157, // 293: immudb.schema.ImmuService.CreateDatabase:output_type -> google.protobuf.Empty
+	157, // 294: immudb.schema.ImmuService.CreateDatabaseWith:output_type -> google.protobuf.Empty
+	90,  // 295: immudb.schema.ImmuService.CreateDatabaseWithV2:output_type -> immudb.schema.DatabaseSettingsV2
+	78,  // 296: immudb.schema.ImmuService.CloneDatabase:output_type -> immudb.schema.CloneDatabaseResponse
+	101, // 297: immudb.schema.ImmuService.DatabaseList:output_type -> immudb.schema.DatabaseListResponse
+	98,  // 298: immudb.schema.ImmuService.UseDatabase:output_type -> immudb.schema.UseDatabaseReply
+	157, // 299: immudb.schema.ImmuService.UpdateDatabase:output_type -> google.protobuf.Empty
+	76,  // 300: immudb.schema.ImmuService.UpdateDatabaseV2:output_type -> immudb.schema.DatabaseSettingsUpdateResult
+	75,  // 301: immudb.schema.ImmuService.GetDatabaseSettings:output_type -> immudb.schema.DatabaseSettings
+	90,  // 302: immudb.schema.ImmuService.GetDatabaseSettingsV2:output_type -> immudb.schema.DatabaseSettingsV2
+	80,  // 303: immudb.schema.ImmuService.GetDatabaseSettingsHistory:output_type -> immudb.schema.DatabaseSettingsHistory
+	76,  // 304: immudb.schema.ImmuService.RollbackDatabaseSettings:output_type -> immudb.schema.DatabaseSettingsUpdateResult
+	157, // 305: immudb.schema.ImmuService.FlushIndex:output_type -> google.protobuf.Empty
+	157, // 306: immudb.schema.ImmuService.CompactIndex:output_type -> google.protobuf.Empty
+	157, // 307: immudb.schema.ImmuService.ChangePermission:output_type -> google.protobuf.Empty
+	157, // 308: immudb.schema.ImmuService.SetActiveUser:output_type -> google.protobuf.Empty
+	102, // 309: immudb.schema.ImmuService.streamGet:output_type -> immudb.schema.Chunk
+	36,  // 310: immudb.schema.ImmuService.streamSet:output_type -> immudb.schema.TxHeader
+	102, // 311: immudb.schema.ImmuService.streamVerifiableGet:output_type -> immudb.schema.Chunk
+	44,  // 312: immudb.schema.ImmuService.streamVerifiableSet:output_type -> immudb.schema.VerifiableTx
+	102, // 313: immudb.schema.ImmuService.streamScan:output_type -> immudb.schema.Chunk
+	102, // 314: immudb.schema.ImmuService.streamZScan:output_type -> immudb.schema.Chunk
+	102, // 315: immudb.schema.ImmuService.streamHistory:output_type -> immudb.schema.Chunk
+	36,  // 316: immudb.schema.ImmuService.streamExecAll:output_type -> immudb.schema.TxHeader
+	102, // 317: immudb.schema.ImmuService.exportTx:output_type -> immudb.schema.Chunk
+	36,  // 318: immudb.schema.ImmuService.replicateTx:output_type -> immudb.schema.TxHeader
+	107, // 319: immudb.schema.ImmuService.SQLExec:output_type -> immudb.schema.SQLExecResult
+	109, // 320: immudb.schema.ImmuService.SQLQuery:output_type -> immudb.schema.SQLQueryResult
+	109, // 321: immudb.schema.ImmuService.ListTables:output_type -> immudb.schema.SQLQueryResult
+	109, // 322: immudb.schema.ImmuService.DescribeTable:output_type -> immudb.schema.SQLQueryResult
+	97,  // 323: immudb.schema.ImmuService.VerifiableSQLGet:output_type -> immudb.schema.VerifiableSQLEntry
+	36,  // 324: immudb.schema.ImmuService.RegisterExternalRoot:output_type -> immudb.schema.TxHeader
+	117, // 325: immudb.schema.ImmuService.ExternalRoots:output_type -> immudb.schema.ExternalRootList
+	36,  // 326: immudb.schema.ImmuService.SetKVSchema:output_type -> immudb.schema.TxHeader
+	36,  // 327: immudb.schema.ImmuService.DeleteKVSchema:output_type -> immudb.schema.TxHeader
+	120, // 328: immudb.schema.ImmuService.KVSchemas:output_type -> immudb.schema.KVSchemaList
+	36,  // 329: immudb.schema.ImmuService.SetRedactionRule:output_type -> immudb.schema.TxHeader
+	36,  // 330: immudb.schema.ImmuService.DeleteRedactionRule:output_type -> immudb.schema.TxHeader
+	123, // 331: immudb.schema.ImmuService.RedactionRules:output_type -> immudb.schema.RedactionRuleList
+	144, // 332: immudb.schema.ImmuService.MultiDatabaseSet:output_type -> immudb.schema.MultiDatabaseTxHeaders
+	125, // 333: immudb.schema.ImmuService.ReplicationStatus:output_type -> immudb.schema.ReplicationStatusResponse
+	157, // 334: immudb.schema.ImmuService.PauseReplication:output_type -> google.protobuf.Empty
+	157, // 335: immudb.schema.ImmuService.ResumeReplication:output_type -> google.protobuf.Empty
+	157, // 336: immudb.schema.ImmuService.ResetReplication:output_type -> google.protobuf.Empty
+	127, // 337: immudb.schema.ImmuService.KeyspaceStats:output_type -> immudb.schema.KeyspaceStatsResponse
+	132, // 338: immudb.schema.ImmuService.CompactIndexAsync:output_type -> immudb.schema.Job
+	132, // 339: immudb.schema.ImmuService.ScrubAsync:output_type -> immudb.schema.Job
+	134, // 340: immudb.schema.ImmuService.ScrubStatus:output_type -> immudb.schema.ScrubStatusResponse
+	137, // 341: immudb.schema.ImmuService.ListJobs:output_type -> immudb.schema.JobList
+	132, // 342: immudb.schema.ImmuService.GetJob:output_type -> immudb.schema.Job
+	132, // 343: immudb.schema.ImmuService.CancelJob:output_type -> immudb.schema.Job
+	139, // 344: immudb.schema.ImmuService.GetServerKeys:output_type -> immudb.schema.ServerKeys
+	251, // [251:345] is the sub-list for method output_type
+	157, // [157:251] is the sub-list for method input_type
+	157, // [157:157] is the sub-list for extension type_name
+	157, // [157:157] is the sub-list for extension extendee
+	0,   // [0:157] is the sub-list for field type_name
+}
+
+func init() { file_schema_proto_init() }
+func file_schema_proto_init() {
+	if File_schema_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_schema_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Key); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_schema_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Permission); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_schema_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*User); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_schema_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*UserList); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_schema_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CreateUserRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_schema_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*UserRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_schema_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ChangePasswordRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_schema_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*LoginRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_schema_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*LoginResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_schema_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AuthConfig); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_schema_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*MTLSConfig); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_schema_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*OpenSessionRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_schema_proto_msgTypes[12].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*OpenSessionResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_schema_proto_msgTypes[13].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*KeyValue); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_schema_proto_msgTypes[14].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Entry); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_schema_proto_msgTypes[15].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Reference); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_schema_proto_msgTypes[16].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Op); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_schema_proto_msgTypes[17].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ExecAllRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_schema_proto_msgTypes[18].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ExecScriptRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_schema_proto_msgTypes[19].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ExecScriptResult); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_schema_proto_msgTypes[20].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Entries); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_schema_proto_msgTypes[21].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*KeyResult); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_schema_proto_msgTypes[22].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ZEntry); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_schema_proto_msgTypes[23].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ZEntries); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_schema_proto_msgTypes[24].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ScanRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_schema_proto_msgTypes[25].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*KeyPrefix); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_schema_proto_msgTypes[26].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*EntryCount); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_schema_proto_msgTypes[27].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Signature); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_schema_proto_msgTypes[28].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*TxHeader); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_schema_proto_msgTypes[29].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*TxMetadata); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -8070,8 +12737,8 @@ func file_schema_proto_init() {
 				return nil
 			}
 		}
-		file_schema_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Permission); i {
+		file_schema_proto_msgTypes[30].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*LinearProof); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -8082,8 +12749,8 @@ func file_schema_proto_init() {
 				return nil
 			}
 		}
-		file_schema_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*User); i {
+		file_schema_proto_msgTypes[31].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DualProof); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -8094,8 +12761,8 @@ func file_schema_proto_init() {
 				return nil
 			}
 		}
-		file_schema_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*UserList); i {
+		file_schema_proto_msgTypes[32].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Tx); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -8106,8 +12773,8 @@ func file_schema_proto_init() {
 				return nil
 			}
 		}
-		file_schema_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*CreateUserRequest); i {
+		file_schema_proto_msgTypes[33].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*TxEntry); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -8118,8 +12785,8 @@ func file_schema_proto_init() {
 				return nil
 			}
 		}
-		file_schema_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*UserRequest); i {
+		file_schema_proto_msgTypes[34].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*KVMetadata); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -8130,8 +12797,8 @@ func file_schema_proto_init() {
 				return nil
 			}
 		}
-		file_schema_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*ChangePasswordRequest); i {
+		file_schema_proto_msgTypes[35].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Expiration); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -8142,8 +12809,8 @@ func file_schema_proto_init() {
 				return nil
 			}
 		}
-		file_schema_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*LoginRequest); i {
+		file_schema_proto_msgTypes[36].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*VerifiableTx); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -8154,8 +12821,8 @@ func file_schema_proto_init() {
 				return nil
 			}
 		}
-		file_schema_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*LoginResponse); i {
+		file_schema_proto_msgTypes[37].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*VerifiableEntry); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -8166,8 +12833,8 @@ func file_schema_proto_init() {
 				return nil
 			}
 		}
-		file_schema_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*AuthConfig); i {
+		file_schema_proto_msgTypes[38].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*InclusionProof); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -8178,8 +12845,8 @@ func file_schema_proto_init() {
 				return nil
 			}
 		}
-		file_schema_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*MTLSConfig); i {
+		file_schema_proto_msgTypes[39].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SetRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -8190,8 +12857,8 @@ func file_schema_proto_init() {
 				return nil
 			}
 		}
-		file_schema_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*OpenSessionRequest); i {
+		file_schema_proto_msgTypes[40].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*KeyRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -8202,8 +12869,8 @@ func file_schema_proto_init() {
 				return nil
 			}
 		}
-		file_schema_proto_msgTypes[12].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*OpenSessionResponse); i {
+		file_schema_proto_msgTypes[41].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*KeyListRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -8214,8 +12881,8 @@ func file_schema_proto_init() {
 				return nil
 			}
 		}
-		file_schema_proto_msgTypes[13].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*KeyValue); i {
+		file_schema_proto_msgTypes[42].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DeleteKeysRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -8226,8 +12893,8 @@ func file_schema_proto_init() {
 				return nil
 			}
 		}
-		file_schema_proto_msgTypes[14].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Entry); i {
+		file_schema_proto_msgTypes[43].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*VerifiableSetRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -8238,8 +12905,8 @@ func file_schema_proto_init() {
 				return nil
 			}
 		}
-		file_schema_proto_msgTypes[15].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Reference); i {
+		file_schema_proto_msgTypes[44].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*VerifiableGetRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -8250,8 +12917,8 @@ func file_schema_proto_init() {
 				return nil
 			}
 		}
-		file_schema_proto_msgTypes[16].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Op); i {
+		file_schema_proto_msgTypes[45].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*HealthResponse); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -8262,8 +12929,200 @@ func file_schema_proto_init() {
 				return nil
 			}
 		}
-		file_schema_proto_msgTypes[17].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*ExecAllRequest); i {
+		file_schema_proto_msgTypes[46].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ServerInfoRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_schema_proto_msgTypes[47].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ServerInfoResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_schema_proto_msgTypes[48].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DatabaseHealthResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_schema_proto_msgTypes[49].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ImmutableState); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_schema_proto_msgTypes[50].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ReferenceRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_schema_proto_msgTypes[51].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*VerifiableReferenceRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_schema_proto_msgTypes[52].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ResolveAllRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_schema_proto_msgTypes[53].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RenameRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_schema_proto_msgTypes[54].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ZAddRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_schema_proto_msgTypes[55].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Score); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_schema_proto_msgTypes[56].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ZScanRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_schema_proto_msgTypes[57].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*HistoryRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_schema_proto_msgTypes[58].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*VerifiableZAddRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_schema_proto_msgTypes[59].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*TxRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_schema_proto_msgTypes[60].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*EntriesSpec); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_schema_proto_msgTypes[61].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*EntryTypeSpec); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_schema_proto_msgTypes[62].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*VerifiableTxRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -8274,8 +13133,8 @@ func file_schema_proto_init() {
 				return nil
 			}
 		}
-		file_schema_proto_msgTypes[18].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Entries); i {
+		file_schema_proto_msgTypes[63].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*TxScanRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -8286,8 +13145,8 @@ func file_schema_proto_init() {
 				return nil
 			}
 		}
-		file_schema_proto_msgTypes[19].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*ZEntry); i {
+		file_schema_proto_msgTypes[64].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*TxList); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -8298,8 +13157,8 @@ func file_schema_proto_init() {
 				return nil
 			}
 		}
-		file_schema_proto_msgTypes[20].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*ZEntries); i {
+		file_schema_proto_msgTypes[65].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ExportTxRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -8310,8 +13169,8 @@ func file_schema_proto_init() {
 				return nil
 			}
 		}
-		file_schema_proto_msgTypes[21].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*ScanRequest); i {
+		file_schema_proto_msgTypes[66].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Database); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -8322,8 +13181,8 @@ func file_schema_proto_init() {
 				return nil
 			}
 		}
-		file_schema_proto_msgTypes[22].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*KeyPrefix); i {
+		file_schema_proto_msgTypes[67].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DatabaseSettings); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -8334,8 +13193,8 @@ func file_schema_proto_init() {
 				return nil
 			}
 		}
-		file_schema_proto_msgTypes[23].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*EntryCount); i {
+		file_schema_proto_msgTypes[68].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DatabaseSettingsUpdateResult); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -8346,8 +13205,8 @@ func file_schema_proto_init() {
 				return nil
 			}
 		}
-		file_schema_proto_msgTypes[24].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Signature); i {
+		file_schema_proto_msgTypes[69].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CloneDatabaseRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -8358,8 +13217,8 @@ func file_schema_proto_init() {
 				return nil
 			}
 		}
-		file_schema_proto_msgTypes[25].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*TxHeader); i {
+		file_schema_proto_msgTypes[70].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CloneDatabaseResponse); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -8370,8 +13229,8 @@ func file_schema_proto_init() {
 				return nil
 			}
 		}
-		file_schema_proto_msgTypes[26].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*TxMetadata); i {
+		file_schema_proto_msgTypes[71].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DatabaseSettingsHistoryRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -8382,8 +13241,8 @@ func file_schema_proto_init() {
 				return nil
 			}
 		}
-		file_schema_proto_msgTypes[27].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*LinearProof); i {
+		file_schema_proto_msgTypes[72].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DatabaseSettingsHistory); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -8394,8 +13253,8 @@ func file_schema_proto_init() {
 				return nil
 			}
 		}
-		file_schema_proto_msgTypes[28].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*DualProof); i {
+		file_schema_proto_msgTypes[73].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DatabaseSettingsVersion); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -8406,8 +13265,8 @@ func file_schema_proto_init() {
 				return nil
 			}
 		}
-		file_schema_proto_msgTypes[29].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Tx); i {
+		file_schema_proto_msgTypes[74].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DatabaseSettingChange); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -8418,8 +13277,8 @@ func file_schema_proto_init() {
 				return nil
 			}
 		}
-		file_schema_proto_msgTypes[30].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*TxEntry); i {
+		file_schema_proto_msgTypes[75].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DatabaseSettingsRollbackRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -8430,8 +13289,8 @@ func file_schema_proto_init() {
 				return nil
 			}
 		}
-		file_schema_proto_msgTypes[31].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*KVMetadata); i {
+		file_schema_proto_msgTypes[76].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ConditionalUint32); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -8442,8 +13301,8 @@ func file_schema_proto_init() {
 				return nil
 			}
 		}
-		file_schema_proto_msgTypes[32].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Expiration); i {
+		file_schema_proto_msgTypes[77].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ConditionalUint64); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -8454,8 +13313,8 @@ func file_schema_proto_init() {
 				return nil
 			}
 		}
-		file_schema_proto_msgTypes[33].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*VerifiableTx); i {
+		file_schema_proto_msgTypes[78].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ConditionalFloat); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -8466,8 +13325,8 @@ func file_schema_proto_init() {
 				return nil
 			}
 		}
-		file_schema_proto_msgTypes[34].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*VerifiableEntry); i {
+		file_schema_proto_msgTypes[79].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ConditionalBool); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -8478,8 +13337,8 @@ func file_schema_proto_init() {
 				return nil
 			}
 		}
-		file_schema_proto_msgTypes[35].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*InclusionProof); i {
+		file_schema_proto_msgTypes[80].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ConditionalString); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -8490,8 +13349,8 @@ func file_schema_proto_init() {
 				return nil
 			}
 		}
-		file_schema_proto_msgTypes[36].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*SetRequest); i {
+		file_schema_proto_msgTypes[81].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ReplicationSettings); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -8502,8 +13361,8 @@ func file_schema_proto_init() {
 				return nil
 			}
 		}
-		file_schema_proto_msgTypes[37].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*KeyRequest); i {
+		file_schema_proto_msgTypes[82].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DatabaseSettingsV2); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -8514,8 +13373,8 @@ func file_schema_proto_init() {
 				return nil
 			}
 		}
-		file_schema_proto_msgTypes[38].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*KeyListRequest); i {
+		file_schema_proto_msgTypes[83].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*IndexSettings); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -8526,8 +13385,8 @@ func file_schema_proto_init() {
 				return nil
 			}
 		}
-		file_schema_proto_msgTypes[39].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*DeleteKeysRequest); i {
+		file_schema_proto_msgTypes[84].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*FlushIndexRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -8538,8 +13397,8 @@ func file_schema_proto_init() {
 				return nil
 			}
 		}
-		file_schema_proto_msgTypes[40].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*VerifiableSetRequest); i {
+		file_schema_proto_msgTypes[85].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Table); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -8550,8 +13409,8 @@ func file_schema_proto_init() {
 				return nil
 			}
 		}
-		file_schema_proto_msgTypes[41].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*VerifiableGetRequest); i {
+		file_schema_proto_msgTypes[86].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SQLGetRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -8562,8 +13421,8 @@ func file_schema_proto_init() {
 				return nil
 			}
 		}
-		file_schema_proto_msgTypes[42].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*HealthResponse); i {
+		file_schema_proto_msgTypes[87].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*VerifiableSQLGetRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -8574,8 +13433,8 @@ func file_schema_proto_init() {
 				return nil
 			}
 		}
-		file_schema_proto_msgTypes[43].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*DatabaseHealthResponse); i {
+		file_schema_proto_msgTypes[88].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SQLEntry); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -8586,8 +13445,8 @@ func file_schema_proto_init() {
 				return nil
 			}
 		}
-		file_schema_proto_msgTypes[44].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*ImmutableState); i {
+		file_schema_proto_msgTypes[89].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*VerifiableSQLEntry); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -8598,8 +13457,8 @@ func file_schema_proto_init() {
 				return nil
 			}
 		}
-		file_schema_proto_msgTypes[45].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*ReferenceRequest); i {
+		file_schema_proto_msgTypes[90].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*UseDatabaseReply); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -8610,8 +13469,8 @@ func file_schema_proto_init() {
 				return nil
 			}
 		}
-		file_schema_proto_msgTypes[46].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*VerifiableReferenceRequest); i {
+		file_schema_proto_msgTypes[91].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ChangePermissionRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -8622,8 +13481,8 @@ func file_schema_proto_init() {
 				return nil
 			}
 		}
-		file_schema_proto_msgTypes[47].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*ZAddRequest); i {
+		file_schema_proto_msgTypes[92].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SetActiveUserRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -8634,8 +13493,8 @@ func file_schema_proto_init() {
 				return nil
 			}
 		}
-		file_schema_proto_msgTypes[48].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Score); i {
+		file_schema_proto_msgTypes[93].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DatabaseListResponse); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -8646,8 +13505,8 @@ func file_schema_proto_init() {
 				return nil
 			}
 		}
-		file_schema_proto_msgTypes[49].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*ZScanRequest); i {
+		file_schema_proto_msgTypes[94].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Chunk); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -8658,8 +13517,8 @@ func file_schema_proto_init() {
 				return nil
 			}
 		}
-		file_schema_proto_msgTypes[50].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*HistoryRequest); i {
+		file_schema_proto_msgTypes[95].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*UseSnapshotRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -8670,8 +13529,8 @@ func file_schema_proto_init() {
 				return nil
 			}
 		}
-		file_schema_proto_msgTypes[51].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*VerifiableZAddRequest); i {
+		file_schema_proto_msgTypes[96].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SQLExecRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -8682,8 +13541,8 @@ func file_schema_proto_init() {
 				return nil
 			}
 		}
-		file_schema_proto_msgTypes[52].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*TxRequest); i {
+		file_schema_proto_msgTypes[97].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SQLQueryRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -8694,8 +13553,8 @@ func file_schema_proto_init() {
 				return nil
 			}
 		}
-		file_schema_proto_msgTypes[53].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*EntriesSpec); i {
+		file_schema_proto_msgTypes[98].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*NamedParam); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -8706,8 +13565,8 @@ func file_schema_proto_init() {
 				return nil
 			}
 		}
-		file_schema_proto_msgTypes[54].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*EntryTypeSpec); i {
+		file_schema_proto_msgTypes[99].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SQLExecResult); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -8718,8 +13577,8 @@ func file_schema_proto_init() {
 				return nil
 			}
 		}
-		file_schema_proto_msgTypes[55].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*VerifiableTxRequest); i {
+		file_schema_proto_msgTypes[100].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CommittedSQLTx); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -8730,8 +13589,8 @@ func file_schema_proto_init() {
 				return nil
 			}
 		}
-		file_schema_proto_msgTypes[56].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*TxScanRequest); i {
+		file_schema_proto_msgTypes[101].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SQLQueryResult); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -8742,8 +13601,8 @@ func file_schema_proto_init() {
 				return nil
 			}
 		}
-		file_schema_proto_msgTypes[57].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*TxList); i {
+		file_schema_proto_msgTypes[102].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Column); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -8754,8 +13613,8 @@ func file_schema_proto_init() {
 				return nil
 			}
 		}
-		file_schema_proto_msgTypes[58].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*ExportTxRequest); i {
+		file_schema_proto_msgTypes[103].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Row); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -8766,8 +13625,8 @@ func file_schema_proto_init() {
 				return nil
 			}
 		}
-		file_schema_proto_msgTypes[59].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Database); i {
+		file_schema_proto_msgTypes[104].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SQLValue); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -8778,8 +13637,8 @@ func file_schema_proto_init() {
 				return nil
 			}
 		}
-		file_schema_proto_msgTypes[60].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*DatabaseSettings); i {
+		file_schema_proto_msgTypes[105].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*NewTxRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -8790,8 +13649,8 @@ func file_schema_proto_init() {
 				return nil
 			}
 		}
-		file_schema_proto_msgTypes[61].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*DatabaseSettingsUpdateResult); i {
+		file_schema_proto_msgTypes[106].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*NewTxResponse); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -8802,8 +13661,8 @@ func file_schema_proto_init() {
 				return nil
 			}
 		}
-		file_schema_proto_msgTypes[62].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*ConditionalUint32); i {
+		file_schema_proto_msgTypes[107].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ExternalRoot); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -8814,8 +13673,8 @@ func file_schema_proto_init() {
 				return nil
 			}
 		}
-		file_schema_proto_msgTypes[63].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*ConditionalUint64); i {
+		file_schema_proto_msgTypes[108].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ExternalRootsRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -8826,8 +13685,8 @@ func file_schema_proto_init() {
 				return nil
 			}
 		}
-		file_schema_proto_msgTypes[64].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*ConditionalFloat); i {
+		file_schema_proto_msgTypes[109].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ExternalRootList); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -8838,8 +13697,8 @@ func file_schema_proto_init() {
 				return nil
 			}
 		}
-		file_schema_proto_msgTypes[65].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*ConditionalBool); i {
+		file_schema_proto_msgTypes[110].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*KVSchema); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -8850,8 +13709,8 @@ func file_schema_proto_init() {
 				return nil
 			}
 		}
-		file_schema_proto_msgTypes[66].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*ConditionalString); i {
+		file_schema_proto_msgTypes[111].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*KVSchemaRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -8862,8 +13721,8 @@ func file_schema_proto_init() {
 				return nil
 			}
 		}
-		file_schema_proto_msgTypes[67].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*ReplicationSettings); i {
+		file_schema_proto_msgTypes[112].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*KVSchemaList); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -8874,8 +13733,8 @@ func file_schema_proto_init() {
 				return nil
 			}
 		}
-		file_schema_proto_msgTypes[68].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*DatabaseSettingsV2); i {
+		file_schema_proto_msgTypes[113].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RedactionRule); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -8886,8 +13745,8 @@ func file_schema_proto_init() {
 				return nil
 			}
 		}
-		file_schema_proto_msgTypes[69].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*IndexSettings); i {
+		file_schema_proto_msgTypes[114].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RedactionRuleRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -8898,8 +13757,8 @@ func file_schema_proto_init() {
 				return nil
 			}
 		}
-		file_schema_proto_msgTypes[70].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*FlushIndexRequest); i {
+		file_schema_proto_msgTypes[115].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RedactionRuleList); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -8910,8 +13769,8 @@ func file_schema_proto_init() {
 				return nil
 			}
 		}
-		file_schema_proto_msgTypes[71].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Table); i {
+		file_schema_proto_msgTypes[116].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ReplicationRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -8922,8 +13781,8 @@ func file_schema_proto_init() {
 				return nil
 			}
 		}
-		file_schema_proto_msgTypes[72].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*SQLGetRequest); i {
+		file_schema_proto_msgTypes[117].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ReplicationStatusResponse); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -8934,8 +13793,8 @@ func file_schema_proto_init() {
 				return nil
 			}
 		}
-		file_schema_proto_msgTypes[73].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*VerifiableSQLGetRequest); i {
+		file_schema_proto_msgTypes[118].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*KeyspaceStatsRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -8946,8 +13805,8 @@ func file_schema_proto_init() {
 				return nil
 			}
 		}
-		file_schema_proto_msgTypes[74].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*SQLEntry); i {
+		file_schema_proto_msgTypes[119].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*KeyspaceStatsResponse); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -8958,8 +13817,8 @@ func file_schema_proto_init() {
 				return nil
 			}
 		}
-		file_schema_proto_msgTypes[75].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*VerifiableSQLEntry); i {
+		file_schema_proto_msgTypes[120].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*KeyspaceUsage); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -8970,8 +13829,8 @@ func file_schema_proto_init() {
 				return nil
 			}
 		}
-		file_schema_proto_msgTypes[76].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*UseDatabaseReply); i {
+		file_schema_proto_msgTypes[121].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SizeDistribution); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -8982,8 +13841,8 @@ func file_schema_proto_init() {
 				return nil
 			}
 		}
-		file_schema_proto_msgTypes[77].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*ChangePermissionRequest); i {
+		file_schema_proto_msgTypes[122].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SizeBucket); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -8994,8 +13853,8 @@ func file_schema_proto_init() {
 				return nil
 			}
 		}
-		file_schema_proto_msgTypes[78].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*SetActiveUserRequest); i {
+		file_schema_proto_msgTypes[123].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*KeyRevisions); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -9006,8 +13865,8 @@ func file_schema_proto_init() {
 				return nil
 			}
 		}
-		file_schema_proto_msgTypes[79].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*DatabaseListResponse); i {
+		file_schema_proto_msgTypes[124].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Job); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -9018,8 +13877,8 @@ func file_schema_proto_init() {
 				return nil
 			}
 		}
-		file_schema_proto_msgTypes[80].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Chunk); i {
+		file_schema_proto_msgTypes[125].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ScrubMismatch); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -9030,8 +13889,8 @@ func file_schema_proto_init() {
 				return nil
 			}
 		}
-		file_schema_proto_msgTypes[81].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*UseSnapshotRequest); i {
+		file_schema_proto_msgTypes[126].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ScrubStatusResponse); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -9042,8 +13901,8 @@ func file_schema_proto_init() {
 				return nil
 			}
 		}
-		file_schema_proto_msgTypes[82].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*SQLExecRequest); i {
+		file_schema_proto_msgTypes[127].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*JobRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -9054,8 +13913,8 @@ func file_schema_proto_init() {
 				return nil
 			}
 		}
-		file_schema_proto_msgTypes[83].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*SQLQueryRequest); i {
+		file_schema_proto_msgTypes[128].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ListJobsRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -9066,8 +13925,8 @@ func file_schema_proto_init() {
 				return nil
 			}
 		}
-		file_schema_proto_msgTypes[84].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*NamedParam); i {
+		file_schema_proto_msgTypes[129].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*JobList); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -9078,8 +13937,8 @@ func file_schema_proto_init() {
 				return nil
 			}
 		}
-		file_schema_proto_msgTypes[85].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*SQLExecResult); i {
+		file_schema_proto_msgTypes[130].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ServerKey); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -9090,8 +13949,8 @@ func file_schema_proto_init() {
 				return nil
 			}
 		}
-		file_schema_proto_msgTypes[86].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*CommittedSQLTx); i {
+		file_schema_proto_msgTypes[131].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ServerKeys); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -9102,8 +13961,8 @@ func file_schema_proto_init() {
 				return nil
 			}
 		}
-		file_schema_proto_msgTypes[87].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*SQLQueryResult); i {
+		file_schema_proto_msgTypes[132].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ProofBundle); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -9114,8 +13973,8 @@ func file_schema_proto_init() {
 				return nil
 			}
 		}
-		file_schema_proto_msgTypes[88].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Column); i {
+		file_schema_proto_msgTypes[133].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DatabaseKVs); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -9126,8 +13985,8 @@ func file_schema_proto_init() {
 				return nil
 			}
 		}
-		file_schema_proto_msgTypes[89].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Row); i {
+		file_schema_proto_msgTypes[134].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*MultiDatabaseSetRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -9138,8 +13997,8 @@ func file_schema_proto_init() {
 				return nil
 			}
 		}
-		file_schema_proto_msgTypes[90].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*SQLValue); i {
+		file_schema_proto_msgTypes[135].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DatabaseTxHeader); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -9150,8 +14009,8 @@ func file_schema_proto_init() {
 				return nil
 			}
 		}
-		file_schema_proto_msgTypes[91].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*NewTxRequest); i {
+		file_schema_proto_msgTypes[136].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*MultiDatabaseTxHeaders); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -9162,8 +14021,8 @@ func file_schema_proto_init() {
 				return nil
 			}
 		}
-		file_schema_proto_msgTypes[92].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*NewTxResponse); i {
+		file_schema_proto_msgTypes[137].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*MultiDatabaseTx); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -9174,7 +14033,7 @@ func file_schema_proto_init() {
 				return nil
 			}
 		}
-		file_schema_proto_msgTypes[93].Exporter = func(v interface{}, i int) interface{} {
+		file_schema_proto_msgTypes[138].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*ErrorInfo); i {
 			case 0:
 				return &v.state
@@ -9186,7 +14045,7 @@ func file_schema_proto_init() {
 				return nil
 			}
 		}
-		file_schema_proto_msgTypes[94].Exporter = func(v interface{}, i int) interface{} {
+		file_schema_proto_msgTypes[139].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*DebugInfo); i {
 			case 0:
 				return &v.state
@@ -9198,7 +14057,7 @@ func file_schema_proto_init() {
 				return nil
 			}
 		}
-		file_schema_proto_msgTypes[95].Exporter = func(v interface{}, i int) interface{} {
+		file_schema_proto_msgTypes[140].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*RetryInfo); i {
 			case 0:
 				return &v.state
@@ -9216,7 +14075,7 @@ func file_schema_proto_init() {
 		(*Op_ZAdd)(nil),
 		(*Op_Ref)(nil),
 	}
-	file_schema_proto_msgTypes[90].OneofWrappers = []interface{}{
+	file_schema_proto_msgTypes[104].OneofWrappers = []interface{}{
 		(*SQLValue_Null)(nil),
 		(*SQLValue_N)(nil),
 		(*SQLValue_S)(nil),
@@ -9229,8 +14088,8 @@ func file_schema_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_schema_proto_rawDesc,
-			NumEnums:      3,
-			NumMessages:   102,
+			NumEnums:      8,
+			NumMessages:   148,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -9272,6 +14131,7 @@ type ImmuServiceClient interface {
 	Rollback(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*empty.Empty, error)
 	TxSQLExec(ctx context.Context, in *SQLExecRequest, opts ...grpc.CallOption) (*empty.Empty, error)
 	TxSQLQuery(ctx context.Context, in *SQLQueryRequest, opts ...grpc.CallOption) (*SQLQueryResult, error)
+	TxSet(ctx context.Context, in *SetRequest, opts ...grpc.CallOption) (*empty.Empty, error)
 	// Deprecated: Do not use.
 	Login(ctx context.Context, in *LoginRequest, opts ...grpc.CallOption) (*LoginResponse, error)
 	// Deprecated: Do not use.
@@ -9283,6 +14143,9 @@ type ImmuServiceClient interface {
 	Delete(ctx context.Context, in *DeleteKeysRequest, opts ...grpc.CallOption) (*TxHeader, error)
 	GetAll(ctx context.Context, in *KeyListRequest, opts ...grpc.CallOption) (*Entries, error)
 	ExecAll(ctx context.Context, in *ExecAllRequest, opts ...grpc.CallOption) (*TxHeader, error)
+	// ExecScript runs a script reading and conditionally writing keys within a single transaction,
+	// nothing is written when the script fails
+	ExecScript(ctx context.Context, in *ExecScriptRequest, opts ...grpc.CallOption) (*ExecScriptResult, error)
 	Scan(ctx context.Context, in *ScanRequest, opts ...grpc.CallOption) (*Entries, error)
 	// NOT YET SUPPORTED
 	Count(ctx context.Context, in *KeyPrefix, opts ...grpc.CallOption) (*EntryCount, error)
@@ -9293,10 +14156,16 @@ type ImmuServiceClient interface {
 	TxScan(ctx context.Context, in *TxScanRequest, opts ...grpc.CallOption) (*TxList, error)
 	History(ctx context.Context, in *HistoryRequest, opts ...grpc.CallOption) (*Entries, error)
 	Health(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*HealthResponse, error)
+	// ServerInfo returns the version of the server, the API versions it serves and its enabled features
+	ServerInfo(ctx context.Context, in *ServerInfoRequest, opts ...grpc.CallOption) (*ServerInfoResponse, error)
 	DatabaseHealth(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*DatabaseHealthResponse, error)
 	CurrentState(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*ImmutableState, error)
 	SetReference(ctx context.Context, in *ReferenceRequest, opts ...grpc.CallOption) (*TxHeader, error)
 	VerifiableSetReference(ctx context.Context, in *VerifiableReferenceRequest, opts ...grpc.CallOption) (*VerifiableTx, error)
+	// ResolveAll returns the entries of the references currently pointing to a key, resolved as Get does
+	ResolveAll(ctx context.Context, in *ResolveAllRequest, opts ...grpc.CallOption) (*Entries, error)
+	// Rename moves the value of a key to a new one in a single transaction, the history of the old key stays linked to the new one
+	Rename(ctx context.Context, in *RenameRequest, opts ...grpc.CallOption) (*TxHeader, error)
 	ZAdd(ctx context.Context, in *ZAddRequest, opts ...grpc.CallOption) (*TxHeader, error)
 	VerifiableZAdd(ctx context.Context, in *VerifiableZAddRequest, opts ...grpc.CallOption) (*VerifiableTx, error)
 	ZScan(ctx context.Context, in *ZScanRequest, opts ...grpc.CallOption) (*ZEntries, error)
@@ -9305,6 +14174,7 @@ type ImmuServiceClient interface {
 	// DEPRECATED: Use CreateDatabaseWithV2
 	CreateDatabaseWith(ctx context.Context, in *DatabaseSettings, opts ...grpc.CallOption) (*empty.Empty, error)
 	CreateDatabaseWithV2(ctx context.Context, in *DatabaseSettingsV2, opts ...grpc.CallOption) (*DatabaseSettingsV2, error)
+	CloneDatabase(ctx context.Context, in *CloneDatabaseRequest, opts ...grpc.CallOption) (*CloneDatabaseResponse, error)
 	DatabaseList(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*DatabaseListResponse, error)
 	UseDatabase(ctx context.Context, in *Database, opts ...grpc.CallOption) (*UseDatabaseReply, error)
 	// DEPRECATED: Use UpdateDatabaseV2
@@ -9313,6 +14183,8 @@ type ImmuServiceClient interface {
 	// DEPRECATED: Use GetDatabaseSettingsV2
 	GetDatabaseSettings(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*DatabaseSettings, error)
 	GetDatabaseSettingsV2(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*DatabaseSettingsV2, error)
+	GetDatabaseSettingsHistory(ctx context.Context, in *DatabaseSettingsHistoryRequest, opts ...grpc.CallOption) (*DatabaseSettingsHistory, error)
+	RollbackDatabaseSettings(ctx context.Context, in *DatabaseSettingsRollbackRequest, opts ...grpc.CallOption) (*DatabaseSettingsUpdateResult, error)
 	FlushIndex(ctx context.Context, in *FlushIndexRequest, opts ...grpc.CallOption) (*empty.Empty, error)
 	CompactIndex(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*empty.Empty, error)
 	ChangePermission(ctx context.Context, in *ChangePermissionRequest, opts ...grpc.CallOption) (*empty.Empty, error)
@@ -9334,6 +14206,31 @@ type ImmuServiceClient interface {
 	ListTables(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*SQLQueryResult, error)
 	DescribeTable(ctx context.Context, in *Table, opts ...grpc.CallOption) (*SQLQueryResult, error)
 	VerifiableSQLGet(ctx context.Context, in *VerifiableSQLGetRequest, opts ...grpc.CallOption) (*VerifiableSQLEntry, error)
+	RegisterExternalRoot(ctx context.Context, in *ExternalRoot, opts ...grpc.CallOption) (*TxHeader, error)
+	ExternalRoots(ctx context.Context, in *ExternalRootsRequest, opts ...grpc.CallOption) (*ExternalRootList, error)
+	SetKVSchema(ctx context.Context, in *KVSchema, opts ...grpc.CallOption) (*TxHeader, error)
+	DeleteKVSchema(ctx context.Context, in *KVSchemaRequest, opts ...grpc.CallOption) (*TxHeader, error)
+	KVSchemas(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*KVSchemaList, error)
+	SetRedactionRule(ctx context.Context, in *RedactionRule, opts ...grpc.CallOption) (*TxHeader, error)
+	DeleteRedactionRule(ctx context.Context, in *RedactionRuleRequest, opts ...grpc.CallOption) (*TxHeader, error)
+	RedactionRules(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*RedactionRuleList, error)
+	MultiDatabaseSet(ctx context.Context, in *MultiDatabaseSetRequest, opts ...grpc.CallOption) (*MultiDatabaseTxHeaders, error)
+	ReplicationStatus(ctx context.Context, in *ReplicationRequest, opts ...grpc.CallOption) (*ReplicationStatusResponse, error)
+	PauseReplication(ctx context.Context, in *ReplicationRequest, opts ...grpc.CallOption) (*empty.Empty, error)
+	ResumeReplication(ctx context.Context, in *ReplicationRequest, opts ...grpc.CallOption) (*empty.Empty, error)
+	ResetReplication(ctx context.Context, in *ReplicationRequest, opts ...grpc.CallOption) (*empty.Empty, error)
+	// KeyspaceStats walks the whole index of a database to summarize the keys it holds,
+	// the distribution of key and value sizes and the keys with most revisions
+	KeyspaceStats(ctx context.Context, in *KeyspaceStatsRequest, opts ...grpc.CallOption) (*KeyspaceStatsResponse, error)
+	CompactIndexAsync(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*Job, error)
+	// ScrubAsync starts verifying every committed transaction of the selected database as a background job,
+	// the corrupted transactions found are reported by ScrubStatus
+	ScrubAsync(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*Job, error)
+	ScrubStatus(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*ScrubStatusResponse, error)
+	ListJobs(ctx context.Context, in *ListJobsRequest, opts ...grpc.CallOption) (*JobList, error)
+	GetJob(ctx context.Context, in *JobRequest, opts ...grpc.CallOption) (*Job, error)
+	CancelJob(ctx context.Context, in *JobRequest, opts ...grpc.CallOption) (*Job, error)
+	GetServerKeys(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*ServerKeys, error)
 }
 
 type immuServiceClient struct {
@@ -9463,6 +14360,15 @@ func (c *immuServiceClient) TxSQLQuery(ctx context.Context, in *SQLQueryRequest,
 	return out, nil
 }
 
+func (c *immuServiceClient) TxSet(ctx context.Context, in *SetRequest, opts ...grpc.CallOption) (*empty.Empty, error) {
+	out := new(empty.Empty)
+	err := c.cc.Invoke(ctx, "/immudb.schema.ImmuService/TxSet", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // Deprecated: Do not use.
 func (c *immuServiceClient) Login(ctx context.Context, in *LoginRequest, opts ...grpc.CallOption) (*LoginResponse, error) {
 	out := new(LoginResponse)
@@ -9546,6 +14452,15 @@ func (c *immuServiceClient) ExecAll(ctx context.Context, in *ExecAllRequest, opt
 	return out, nil
 }
 
+func (c *immuServiceClient) ExecScript(ctx context.Context, in *ExecScriptRequest, opts ...grpc.CallOption) (*ExecScriptResult, error) {
+	out := new(ExecScriptResult)
+	err := c.cc.Invoke(ctx, "/immudb.schema.ImmuService/ExecScript", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *immuServiceClient) Scan(ctx context.Context, in *ScanRequest, opts ...grpc.CallOption) (*Entries, error) {
 	out := new(Entries)
 	err := c.cc.Invoke(ctx, "/immudb.schema.ImmuService/Scan", in, out, opts...)
@@ -9618,6 +14533,15 @@ func (c *immuServiceClient) Health(ctx context.Context, in *empty.Empty, opts ..
 	return out, nil
 }
 
+func (c *immuServiceClient) ServerInfo(ctx context.Context, in *ServerInfoRequest, opts ...grpc.CallOption) (*ServerInfoResponse, error) {
+	out := new(ServerInfoResponse)
+	err := c.cc.Invoke(ctx, "/immudb.schema.ImmuService/ServerInfo", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *immuServiceClient) DatabaseHealth(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*DatabaseHealthResponse, error) {
 	out := new(DatabaseHealthResponse)
 	err := c.cc.Invoke(ctx, "/immudb.schema.ImmuService/DatabaseHealth", in, out, opts...)
@@ -9654,6 +14578,24 @@ func (c *immuServiceClient) VerifiableSetReference(ctx context.Context, in *Veri
 	return out, nil
 }
 
+func (c *immuServiceClient) ResolveAll(ctx context.Context, in *ResolveAllRequest, opts ...grpc.CallOption) (*Entries, error) {
+	out := new(Entries)
+	err := c.cc.Invoke(ctx, "/immudb.schema.ImmuService/ResolveAll", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *immuServiceClient) Rename(ctx context.Context, in *RenameRequest, opts ...grpc.CallOption) (*TxHeader, error) {
+	out := new(TxHeader)
+	err := c.cc.Invoke(ctx, "/immudb.schema.ImmuService/Rename", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *immuServiceClient) ZAdd(ctx context.Context, in *ZAddRequest, opts ...grpc.CallOption) (*TxHeader, error) {
 	out := new(TxHeader)
 	err := c.cc.Invoke(ctx, "/immudb.schema.ImmuService/ZAdd", in, out, opts...)
@@ -9708,6 +14650,15 @@ func (c *immuServiceClient) CreateDatabaseWithV2(ctx context.Context, in *Databa
 	return out, nil
 }
 
+func (c *immuServiceClient) CloneDatabase(ctx context.Context, in *CloneDatabaseRequest, opts ...grpc.CallOption) (*CloneDatabaseResponse, error) {
+	out := new(CloneDatabaseResponse)
+	err := c.cc.Invoke(ctx, "/immudb.schema.ImmuService/CloneDatabase", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *immuServiceClient) DatabaseList(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*DatabaseListResponse, error) {
 	out := new(DatabaseListResponse)
 	err := c.cc.Invoke(ctx, "/immudb.schema.ImmuService/DatabaseList", in, out, opts...)
@@ -9762,6 +14713,24 @@ func (c *immuServiceClient) GetDatabaseSettingsV2(ctx context.Context, in *empty
 	return out, nil
 }
 
+func (c *immuServiceClient) GetDatabaseSettingsHistory(ctx context.Context, in *DatabaseSettingsHistoryRequest, opts ...grpc.CallOption) (*DatabaseSettingsHistory, error) {
+	out := new(DatabaseSettingsHistory)
+	err := c.cc.Invoke(ctx, "/immudb.schema.ImmuService/GetDatabaseSettingsHistory", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *immuServiceClient) RollbackDatabaseSettings(ctx context.Context, in *DatabaseSettingsRollbackRequest, opts ...grpc.CallOption) (*DatabaseSettingsUpdateResult, error) {
+	out := new(DatabaseSettingsUpdateResult)
+	err := c.cc.Invoke(ctx, "/immudb.schema.ImmuService/RollbackDatabaseSettings", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *immuServiceClient) FlushIndex(ctx context.Context, in *FlushIndexRequest, opts ...grpc.CallOption) (*empty.Empty, error) {
 	out := new(empty.Empty)
 	err := c.cc.Invoke(ctx, "/immudb.schema.ImmuService/FlushIndex", in, out, opts...)
@@ -10171,6 +15140,195 @@ func (c *immuServiceClient) VerifiableSQLGet(ctx context.Context, in *Verifiable
 	return out, nil
 }
 
+func (c *immuServiceClient) RegisterExternalRoot(ctx context.Context, in *ExternalRoot, opts ...grpc.CallOption) (*TxHeader, error) {
+	out := new(TxHeader)
+	err := c.cc.Invoke(ctx, "/immudb.schema.ImmuService/RegisterExternalRoot", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *immuServiceClient) ExternalRoots(ctx context.Context, in *ExternalRootsRequest, opts ...grpc.CallOption) (*ExternalRootList, error) {
+	out := new(ExternalRootList)
+	err := c.cc.Invoke(ctx, "/immudb.schema.ImmuService/ExternalRoots", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *immuServiceClient) SetKVSchema(ctx context.Context, in *KVSchema, opts ...grpc.CallOption) (*TxHeader, error) {
+	out := new(TxHeader)
+	err := c.cc.Invoke(ctx, "/immudb.schema.ImmuService/SetKVSchema", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *immuServiceClient) DeleteKVSchema(ctx context.Context, in *KVSchemaRequest, opts ...grpc.CallOption) (*TxHeader, error) {
+	out := new(TxHeader)
+	err := c.cc.Invoke(ctx, "/immudb.schema.ImmuService/DeleteKVSchema", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *immuServiceClient) KVSchemas(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*KVSchemaList, error) {
+	out := new(KVSchemaList)
+	err := c.cc.Invoke(ctx, "/immudb.schema.ImmuService/KVSchemas", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *immuServiceClient) SetRedactionRule(ctx context.Context, in *RedactionRule, opts ...grpc.CallOption) (*TxHeader, error) {
+	out := new(TxHeader)
+	err := c.cc.Invoke(ctx, "/immudb.schema.ImmuService/SetRedactionRule", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *immuServiceClient) DeleteRedactionRule(ctx context.Context, in *RedactionRuleRequest, opts ...grpc.CallOption) (*TxHeader, error) {
+	out := new(TxHeader)
+	err := c.cc.Invoke(ctx, "/immudb.schema.ImmuService/DeleteRedactionRule", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *immuServiceClient) RedactionRules(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*RedactionRuleList, error) {
+	out := new(RedactionRuleList)
+	err := c.cc.Invoke(ctx, "/immudb.schema.ImmuService/RedactionRules", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *immuServiceClient) MultiDatabaseSet(ctx context.Context, in *MultiDatabaseSetRequest, opts ...grpc.CallOption) (*MultiDatabaseTxHeaders, error) {
+	out := new(MultiDatabaseTxHeaders)
+	err := c.cc.Invoke(ctx, "/immudb.schema.ImmuService/MultiDatabaseSet", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *immuServiceClient) ReplicationStatus(ctx context.Context, in *ReplicationRequest, opts ...grpc.CallOption) (*ReplicationStatusResponse, error) {
+	out := new(ReplicationStatusResponse)
+	err := c.cc.Invoke(ctx, "/immudb.schema.ImmuService/ReplicationStatus", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *immuServiceClient) PauseReplication(ctx context.Context, in *ReplicationRequest, opts ...grpc.CallOption) (*empty.Empty, error) {
+	out := new(empty.Empty)
+	err := c.cc.Invoke(ctx, "/immudb.schema.ImmuService/PauseReplication", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *immuServiceClient) ResumeReplication(ctx context.Context, in *ReplicationRequest, opts ...grpc.CallOption) (*empty.Empty, error) {
+	out := new(empty.Empty)
+	err := c.cc.Invoke(ctx, "/immudb.schema.ImmuService/ResumeReplication", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *immuServiceClient) ResetReplication(ctx context.Context, in *ReplicationRequest, opts ...grpc.CallOption) (*empty.Empty, error) {
+	out := new(empty.Empty)
+	err := c.cc.Invoke(ctx, "/immudb.schema.ImmuService/ResetReplication", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *immuServiceClient) KeyspaceStats(ctx context.Context, in *KeyspaceStatsRequest, opts ...grpc.CallOption) (*KeyspaceStatsResponse, error) {
+	out := new(KeyspaceStatsResponse)
+	err := c.cc.Invoke(ctx, "/immudb.schema.ImmuService/KeyspaceStats", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *immuServiceClient) CompactIndexAsync(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*Job, error) {
+	out := new(Job)
+	err := c.cc.Invoke(ctx, "/immudb.schema.ImmuService/CompactIndexAsync", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *immuServiceClient) ScrubAsync(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*Job, error) {
+	out := new(Job)
+	err := c.cc.Invoke(ctx, "/immudb.schema.ImmuService/ScrubAsync", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *immuServiceClient) ScrubStatus(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*ScrubStatusResponse, error) {
+	out := new(ScrubStatusResponse)
+	err := c.cc.Invoke(ctx, "/immudb.schema.ImmuService/ScrubStatus", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *immuServiceClient) ListJobs(ctx context.Context, in *ListJobsRequest, opts ...grpc.CallOption) (*JobList, error) {
+	out := new(JobList)
+	err := c.cc.Invoke(ctx, "/immudb.schema.ImmuService/ListJobs", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *immuServiceClient) GetJob(ctx context.Context, in *JobRequest, opts ...grpc.CallOption) (*Job, error) {
+	out := new(Job)
+	err := c.cc.Invoke(ctx, "/immudb.schema.ImmuService/GetJob", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *immuServiceClient) CancelJob(ctx context.Context, in *JobRequest, opts ...grpc.CallOption) (*Job, error) {
+	out := new(Job)
+	err := c.cc.Invoke(ctx, "/immudb.schema.ImmuService/CancelJob", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *immuServiceClient) GetServerKeys(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*ServerKeys, error) {
+	out := new(ServerKeys)
+	err := c.cc.Invoke(ctx, "/immudb.schema.ImmuService/GetServerKeys", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // ImmuServiceServer is the server API for ImmuService service.
 type ImmuServiceServer interface {
 	ListUsers(context.Context, *empty.Empty) (*UserList, error)
@@ -10188,6 +15346,7 @@ type ImmuServiceServer interface {
 	Rollback(context.Context, *empty.Empty) (*empty.Empty, error)
 	TxSQLExec(context.Context, *SQLExecRequest) (*empty.Empty, error)
 	TxSQLQuery(context.Context, *SQLQueryRequest) (*SQLQueryResult, error)
+	TxSet(context.Context, *SetRequest) (*empty.Empty, error)
 	// Deprecated: Do not use.
 	Login(context.Context, *LoginRequest) (*LoginResponse, error)
 	// Deprecated: Do not use.
@@ -10199,6 +15358,9 @@ type ImmuServiceServer interface {
 	Delete(context.Context, *DeleteKeysRequest) (*TxHeader, error)
 	GetAll(context.Context, *KeyListRequest) (*Entries, error)
 	ExecAll(context.Context, *ExecAllRequest) (*TxHeader, error)
+	// ExecScript runs a script reading and conditionally writing keys within a single transaction,
+	// nothing is written when the script fails
+	ExecScript(context.Context, *ExecScriptRequest) (*ExecScriptResult, error)
 	Scan(context.Context, *ScanRequest) (*Entries, error)
 	// NOT YET SUPPORTED
 	Count(context.Context, *KeyPrefix) (*EntryCount, error)
@@ -10209,10 +15371,16 @@ type ImmuServiceServer interface {
 	TxScan(context.Context, *TxScanRequest) (*TxList, error)
 	History(context.Context, *HistoryRequest) (*Entries, error)
 	Health(context.Context, *empty.Empty) (*HealthResponse, error)
+	// ServerInfo returns the version of the server, the API versions it serves and its enabled features
+	ServerInfo(context.Context, *ServerInfoRequest) (*ServerInfoResponse, error)
 	DatabaseHealth(context.Context, *empty.Empty) (*DatabaseHealthResponse, error)
 	CurrentState(context.Context, *empty.Empty) (*ImmutableState, error)
 	SetReference(context.Context, *ReferenceRequest) (*TxHeader, error)
 	VerifiableSetReference(context.Context, *VerifiableReferenceRequest) (*VerifiableTx, error)
+	// ResolveAll returns the entries of the references currently pointing to a key, resolved as Get does
+	ResolveAll(context.Context, *ResolveAllRequest) (*Entries, error)
+	// Rename moves the value of a key to a new one in a single transaction, the history of the old key stays linked to the new one
+	Rename(context.Context, *RenameRequest) (*TxHeader, error)
 	ZAdd(context.Context, *ZAddRequest) (*TxHeader, error)
 	VerifiableZAdd(context.Context, *VerifiableZAddRequest) (*VerifiableTx, error)
 	ZScan(context.Context, *ZScanRequest) (*ZEntries, error)
@@ -10221,6 +15389,7 @@ type ImmuServiceServer interface {
 	// DEPRECATED: Use CreateDatabaseWithV2
 	CreateDatabaseWith(context.Context, *DatabaseSettings) (*empty.Empty, error)
 	CreateDatabaseWithV2(context.Context, *DatabaseSettingsV2) (*DatabaseSettingsV2, error)
+	CloneDatabase(context.Context, *CloneDatabaseRequest) (*CloneDatabaseResponse, error)
 	DatabaseList(context.Context, *empty.Empty) (*DatabaseListResponse, error)
 	UseDatabase(context.Context, *Database) (*UseDatabaseReply, error)
 	// DEPRECATED: Use UpdateDatabaseV2
@@ -10229,6 +15398,8 @@ type ImmuServiceServer interface {
 	// DEPRECATED: Use GetDatabaseSettingsV2
 	GetDatabaseSettings(context.Context, *empty.Empty) (*DatabaseSettings, error)
 	GetDatabaseSettingsV2(context.Context, *empty.Empty) (*DatabaseSettingsV2, error)
+	GetDatabaseSettingsHistory(context.Context, *DatabaseSettingsHistoryRequest) (*DatabaseSettingsHistory, error)
+	RollbackDatabaseSettings(context.Context, *DatabaseSettingsRollbackRequest) (*DatabaseSettingsUpdateResult, error)
 	FlushIndex(context.Context, *FlushIndexRequest) (*empty.Empty, error)
 	CompactIndex(context.Context, *empty.Empty) (*empty.Empty, error)
 	ChangePermission(context.Context, *ChangePermissionRequest) (*empty.Empty, error)
@@ -10250,6 +15421,31 @@ type ImmuServiceServer interface {
 	ListTables(context.Context, *empty.Empty) (*SQLQueryResult, error)
 	DescribeTable(context.Context, *Table) (*SQLQueryResult, error)
 	VerifiableSQLGet(context.Context, *VerifiableSQLGetRequest) (*VerifiableSQLEntry, error)
+	RegisterExternalRoot(context.Context, *ExternalRoot) (*TxHeader, error)
+	ExternalRoots(context.Context, *ExternalRootsRequest) (*ExternalRootList, error)
+	SetKVSchema(context.Context, *KVSchema) (*TxHeader, error)
+	DeleteKVSchema(context.Context, *KVSchemaRequest) (*TxHeader, error)
+	KVSchemas(context.Context, *empty.Empty) (*KVSchemaList, error)
+	SetRedactionRule(context.Context, *RedactionRule) (*TxHeader, error)
+	DeleteRedactionRule(context.Context, *RedactionRuleRequest) (*TxHeader, error)
+	RedactionRules(context.Context, *empty.Empty) (*RedactionRuleList, error)
+	MultiDatabaseSet(context.Context, *MultiDatabaseSetRequest) (*MultiDatabaseTxHeaders, error)
+	ReplicationStatus(context.Context, *ReplicationRequest) (*ReplicationStatusResponse, error)
+	PauseReplication(context.Context, *ReplicationRequest) (*empty.Empty, error)
+	ResumeReplication(context.Context, *ReplicationRequest) (*empty.Empty, error)
+	ResetReplication(context.Context, *ReplicationRequest) (*empty.Empty, error)
+	// KeyspaceStats walks the whole index of a database to summarize the keys it holds,
+	// the distribution of key and value sizes and the keys with most revisions
+	KeyspaceStats(context.Context, *KeyspaceStatsRequest) (*KeyspaceStatsResponse, error)
+	CompactIndexAsync(context.Context, *empty.Empty) (*Job, error)
+	// ScrubAsync starts verifying every committed transaction of the selected database as a background job,
+	// the corrupted transactions found are reported by ScrubStatus
+	ScrubAsync(context.Context, *empty.Empty) (*Job, error)
+	ScrubStatus(context.Context, *empty.Empty) (*ScrubStatusResponse, error)
+	ListJobs(context.Context, *ListJobsRequest) (*JobList, error)
+	GetJob(context.Context, *JobRequest) (*Job, error)
+	CancelJob(context.Context, *JobRequest) (*Job, error)
+	GetServerKeys(context.Context, *empty.Empty) (*ServerKeys, error)
 }
 
 // UnimplementedImmuServiceServer can be embedded to have forward compatible implementations.
@@ -10295,6 +15491,9 @@ func (*UnimplementedImmuServiceServer) TxSQLExec(context.Context, *SQLExecReques
 func (*UnimplementedImmuServiceServer) TxSQLQuery(context.Context, *SQLQueryRequest) (*SQLQueryResult, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method TxSQLQuery not implemented")
 }
+func (*UnimplementedImmuServiceServer) TxSet(context.Context, *SetRequest) (*empty.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method TxSet not implemented")
+}
 func (*UnimplementedImmuServiceServer) Login(context.Context, *LoginRequest) (*LoginResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Login not implemented")
 }
@@ -10322,6 +15521,9 @@ func (*UnimplementedImmuServiceServer) GetAll(context.Context, *KeyListRequest)
 func (*UnimplementedImmuServiceServer) ExecAll(context.Context, *ExecAllRequest) (*TxHeader, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ExecAll not implemented")
 }
+func (*UnimplementedImmuServiceServer) ExecScript(context.Context, *ExecScriptRequest) (*ExecScriptResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ExecScript not implemented")
+}
 func (*UnimplementedImmuServiceServer) Scan(context.Context, *ScanRequest) (*Entries, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Scan not implemented")
 }
@@ -10346,6 +15548,9 @@ func (*UnimplementedImmuServiceServer) History(context.Context, *HistoryRequest)
 func (*UnimplementedImmuServiceServer) Health(context.Context, *empty.Empty) (*HealthResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Health not implemented")
 }
+func (*UnimplementedImmuServiceServer) ServerInfo(context.Context, *ServerInfoRequest) (*ServerInfoResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ServerInfo not implemented")
+}
 func (*UnimplementedImmuServiceServer) DatabaseHealth(context.Context, *empty.Empty) (*DatabaseHealthResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method DatabaseHealth not implemented")
 }
@@ -10358,6 +15563,12 @@ func (*UnimplementedImmuServiceServer) SetReference(context.Context, *ReferenceR
 func (*UnimplementedImmuServiceServer) VerifiableSetReference(context.Context, *VerifiableReferenceRequest) (*VerifiableTx, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method VerifiableSetReference not implemented")
 }
+func (*UnimplementedImmuServiceServer) ResolveAll(context.Context, *ResolveAllRequest) (*Entries, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ResolveAll not implemented")
+}
+func (*UnimplementedImmuServiceServer) Rename(context.Context, *RenameRequest) (*TxHeader, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Rename not implemented")
+}
 func (*UnimplementedImmuServiceServer) ZAdd(context.Context, *ZAddRequest) (*TxHeader, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ZAdd not implemented")
 }
@@ -10376,6 +15587,9 @@ func (*UnimplementedImmuServiceServer) CreateDatabaseWith(context.Context, *Data
 func (*UnimplementedImmuServiceServer) CreateDatabaseWithV2(context.Context, *DatabaseSettingsV2) (*DatabaseSettingsV2, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method CreateDatabaseWithV2 not implemented")
 }
+func (*UnimplementedImmuServiceServer) CloneDatabase(context.Context, *CloneDatabaseRequest) (*CloneDatabaseResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CloneDatabase not implemented")
+}
 func (*UnimplementedImmuServiceServer) DatabaseList(context.Context, *empty.Empty) (*DatabaseListResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method DatabaseList not implemented")
 }
@@ -10394,6 +15608,12 @@ func (*UnimplementedImmuServiceServer) GetDatabaseSettings(context.Context, *emp
 func (*UnimplementedImmuServiceServer) GetDatabaseSettingsV2(context.Context, *empty.Empty) (*DatabaseSettingsV2, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetDatabaseSettingsV2 not implemented")
 }
+func (*UnimplementedImmuServiceServer) GetDatabaseSettingsHistory(context.Context, *DatabaseSettingsHistoryRequest) (*DatabaseSettingsHistory, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetDatabaseSettingsHistory not implemented")
+}
+func (*UnimplementedImmuServiceServer) RollbackDatabaseSettings(context.Context, *DatabaseSettingsRollbackRequest) (*DatabaseSettingsUpdateResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RollbackDatabaseSettings not implemented")
+}
 func (*UnimplementedImmuServiceServer) FlushIndex(context.Context, *FlushIndexRequest) (*empty.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method FlushIndex not implemented")
 }
@@ -10451,6 +15671,69 @@ func (*UnimplementedImmuServiceServer) DescribeTable(context.Context, *Table) (*
 func (*UnimplementedImmuServiceServer) VerifiableSQLGet(context.Context, *VerifiableSQLGetRequest) (*VerifiableSQLEntry, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method VerifiableSQLGet not implemented")
 }
+func (*UnimplementedImmuServiceServer) RegisterExternalRoot(context.Context, *ExternalRoot) (*TxHeader, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RegisterExternalRoot not implemented")
+}
+func (*UnimplementedImmuServiceServer) ExternalRoots(context.Context, *ExternalRootsRequest) (*ExternalRootList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ExternalRoots not implemented")
+}
+func (*UnimplementedImmuServiceServer) SetKVSchema(context.Context, *KVSchema) (*TxHeader, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetKVSchema not implemented")
+}
+func (*UnimplementedImmuServiceServer) DeleteKVSchema(context.Context, *KVSchemaRequest) (*TxHeader, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteKVSchema not implemented")
+}
+func (*UnimplementedImmuServiceServer) KVSchemas(context.Context, *empty.Empty) (*KVSchemaList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method KVSchemas not implemented")
+}
+func (*UnimplementedImmuServiceServer) SetRedactionRule(context.Context, *RedactionRule) (*TxHeader, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetRedactionRule not implemented")
+}
+func (*UnimplementedImmuServiceServer) DeleteRedactionRule(context.Context, *RedactionRuleRequest) (*TxHeader, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteRedactionRule not implemented")
+}
+func (*UnimplementedImmuServiceServer) RedactionRules(context.Context, *empty.Empty) (*RedactionRuleList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RedactionRules not implemented")
+}
+func (*UnimplementedImmuServiceServer) MultiDatabaseSet(context.Context, *MultiDatabaseSetRequest) (*MultiDatabaseTxHeaders, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method MultiDatabaseSet not implemented")
+}
+func (*UnimplementedImmuServiceServer) ReplicationStatus(context.Context, *ReplicationRequest) (*ReplicationStatusResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReplicationStatus not implemented")
+}
+func (*UnimplementedImmuServiceServer) PauseReplication(context.Context, *ReplicationRequest) (*empty.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PauseReplication not implemented")
+}
+func (*UnimplementedImmuServiceServer) ResumeReplication(context.Context, *ReplicationRequest) (*empty.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ResumeReplication not implemented")
+}
+func (*UnimplementedImmuServiceServer) ResetReplication(context.Context, *ReplicationRequest) (*empty.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ResetReplication not implemented")
+}
+func (*UnimplementedImmuServiceServer) KeyspaceStats(context.Context, *KeyspaceStatsRequest) (*KeyspaceStatsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method KeyspaceStats not implemented")
+}
+func (*UnimplementedImmuServiceServer) CompactIndexAsync(context.Context, *empty.Empty) (*Job, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CompactIndexAsync not implemented")
+}
+func (*UnimplementedImmuServiceServer) ScrubAsync(context.Context, *empty.Empty) (*Job, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ScrubAsync not implemented")
+}
+func (*UnimplementedImmuServiceServer) ScrubStatus(context.Context, *empty.Empty) (*ScrubStatusResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ScrubStatus not implemented")
+}
+func (*UnimplementedImmuServiceServer) ListJobs(context.Context, *ListJobsRequest) (*JobList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListJobs not implemented")
+}
+func (*UnimplementedImmuServiceServer) GetJob(context.Context, *JobRequest) (*Job, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetJob not implemented")
+}
+func (*UnimplementedImmuServiceServer) CancelJob(context.Context, *JobRequest) (*Job, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CancelJob not implemented")
+}
+func (*UnimplementedImmuServiceServer) GetServerKeys(context.Context, *empty.Empty) (*ServerKeys, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetServerKeys not implemented")
+}
 
 func RegisterImmuServiceServer(s *grpc.Server, srv ImmuServiceServer) {
 	s.RegisterService(&_ImmuService_serviceDesc, srv)
@@ -10690,6 +15973,24 @@ func _ImmuService_TxSQLQuery_Handler(srv interface{}, ctx context.Context, dec f
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ImmuService_TxSet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ImmuServiceServer).TxSet(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/immudb.schema.ImmuService/TxSet",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ImmuServiceServer).TxSet(ctx, req.(*SetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _ImmuService_Login_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(LoginRequest)
 	if err := dec(in); err != nil {
@@ -10852,6 +16153,24 @@ func _ImmuService_ExecAll_Handler(srv interface{}, ctx context.Context, dec func
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ImmuService_ExecScript_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExecScriptRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ImmuServiceServer).ExecScript(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/immudb.schema.ImmuService/ExecScript",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ImmuServiceServer).ExecScript(ctx, req.(*ExecScriptRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _ImmuService_Scan_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(ScanRequest)
 	if err := dec(in); err != nil {
@@ -10996,6 +16315,24 @@ func _ImmuService_Health_Handler(srv interface{}, ctx context.Context, dec func(
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ImmuService_ServerInfo_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ServerInfoRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ImmuServiceServer).ServerInfo(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/immudb.schema.ImmuService/ServerInfo",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ImmuServiceServer).ServerInfo(ctx, req.(*ServerInfoRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _ImmuService_DatabaseHealth_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(empty.Empty)
 	if err := dec(in); err != nil {
@@ -11020,50 +16357,86 @@ func _ImmuService_CurrentState_Handler(srv interface{}, ctx context.Context, dec
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(ImmuServiceServer).CurrentState(ctx, in)
+		return srv.(ImmuServiceServer).CurrentState(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/immudb.schema.ImmuService/CurrentState",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ImmuServiceServer).CurrentState(ctx, req.(*empty.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ImmuService_SetReference_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReferenceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ImmuServiceServer).SetReference(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/immudb.schema.ImmuService/SetReference",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ImmuServiceServer).SetReference(ctx, req.(*ReferenceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ImmuService_VerifiableSetReference_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VerifiableReferenceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ImmuServiceServer).VerifiableSetReference(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/immudb.schema.ImmuService/CurrentState",
+		FullMethod: "/immudb.schema.ImmuService/VerifiableSetReference",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(ImmuServiceServer).CurrentState(ctx, req.(*empty.Empty))
+		return srv.(ImmuServiceServer).VerifiableSetReference(ctx, req.(*VerifiableReferenceRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _ImmuService_SetReference_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(ReferenceRequest)
+func _ImmuService_ResolveAll_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ResolveAllRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(ImmuServiceServer).SetReference(ctx, in)
+		return srv.(ImmuServiceServer).ResolveAll(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/immudb.schema.ImmuService/SetReference",
+		FullMethod: "/immudb.schema.ImmuService/ResolveAll",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(ImmuServiceServer).SetReference(ctx, req.(*ReferenceRequest))
+		return srv.(ImmuServiceServer).ResolveAll(ctx, req.(*ResolveAllRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _ImmuService_VerifiableSetReference_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(VerifiableReferenceRequest)
+func _ImmuService_Rename_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RenameRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(ImmuServiceServer).VerifiableSetReference(ctx, in)
+		return srv.(ImmuServiceServer).Rename(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/immudb.schema.ImmuService/VerifiableSetReference",
+		FullMethod: "/immudb.schema.ImmuService/Rename",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(ImmuServiceServer).VerifiableSetReference(ctx, req.(*VerifiableReferenceRequest))
+		return srv.(ImmuServiceServer).Rename(ctx, req.(*RenameRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
@@ -11176,6 +16549,24 @@ func _ImmuService_CreateDatabaseWithV2_Handler(srv interface{}, ctx context.Cont
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ImmuService_CloneDatabase_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CloneDatabaseRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ImmuServiceServer).CloneDatabase(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/immudb.schema.ImmuService/CloneDatabase",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ImmuServiceServer).CloneDatabase(ctx, req.(*CloneDatabaseRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _ImmuService_DatabaseList_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(empty.Empty)
 	if err := dec(in); err != nil {
@@ -11284,6 +16675,42 @@ func _ImmuService_GetDatabaseSettingsV2_Handler(srv interface{}, ctx context.Con
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ImmuService_GetDatabaseSettingsHistory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DatabaseSettingsHistoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ImmuServiceServer).GetDatabaseSettingsHistory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/immudb.schema.ImmuService/GetDatabaseSettingsHistory",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ImmuServiceServer).GetDatabaseSettingsHistory(ctx, req.(*DatabaseSettingsHistoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ImmuService_RollbackDatabaseSettings_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DatabaseSettingsRollbackRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ImmuServiceServer).RollbackDatabaseSettings(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/immudb.schema.ImmuService/RollbackDatabaseSettings",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ImmuServiceServer).RollbackDatabaseSettings(ctx, req.(*DatabaseSettingsRollbackRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _ImmuService_FlushIndex_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(FlushIndexRequest)
 	if err := dec(in); err != nil {
@@ -11523,155 +16950,533 @@ type ImmuService_StreamExecAllServer interface {
 	grpc.ServerStream
 }
 
-type immuServiceStreamExecAllServer struct {
-	grpc.ServerStream
+type immuServiceStreamExecAllServer struct {
+	grpc.ServerStream
+}
+
+func (x *immuServiceStreamExecAllServer) SendAndClose(m *TxHeader) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *immuServiceStreamExecAllServer) Recv() (*Chunk, error) {
+	m := new(Chunk)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _ImmuService_ExportTx_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ExportTxRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ImmuServiceServer).ExportTx(m, &immuServiceExportTxServer{stream})
+}
+
+type ImmuService_ExportTxServer interface {
+	Send(*Chunk) error
+	grpc.ServerStream
+}
+
+type immuServiceExportTxServer struct {
+	grpc.ServerStream
+}
+
+func (x *immuServiceExportTxServer) Send(m *Chunk) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _ImmuService_ReplicateTx_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(ImmuServiceServer).ReplicateTx(&immuServiceReplicateTxServer{stream})
+}
+
+type ImmuService_ReplicateTxServer interface {
+	SendAndClose(*TxHeader) error
+	Recv() (*Chunk, error)
+	grpc.ServerStream
+}
+
+type immuServiceReplicateTxServer struct {
+	grpc.ServerStream
+}
+
+func (x *immuServiceReplicateTxServer) SendAndClose(m *TxHeader) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *immuServiceReplicateTxServer) Recv() (*Chunk, error) {
+	m := new(Chunk)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _ImmuService_SQLExec_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SQLExecRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ImmuServiceServer).SQLExec(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/immudb.schema.ImmuService/SQLExec",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ImmuServiceServer).SQLExec(ctx, req.(*SQLExecRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ImmuService_SQLQuery_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SQLQueryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ImmuServiceServer).SQLQuery(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/immudb.schema.ImmuService/SQLQuery",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ImmuServiceServer).SQLQuery(ctx, req.(*SQLQueryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ImmuService_ListTables_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(empty.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ImmuServiceServer).ListTables(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/immudb.schema.ImmuService/ListTables",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ImmuServiceServer).ListTables(ctx, req.(*empty.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ImmuService_DescribeTable_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Table)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ImmuServiceServer).DescribeTable(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/immudb.schema.ImmuService/DescribeTable",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ImmuServiceServer).DescribeTable(ctx, req.(*Table))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ImmuService_VerifiableSQLGet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VerifiableSQLGetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ImmuServiceServer).VerifiableSQLGet(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/immudb.schema.ImmuService/VerifiableSQLGet",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ImmuServiceServer).VerifiableSQLGet(ctx, req.(*VerifiableSQLGetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ImmuService_RegisterExternalRoot_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExternalRoot)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ImmuServiceServer).RegisterExternalRoot(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/immudb.schema.ImmuService/RegisterExternalRoot",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ImmuServiceServer).RegisterExternalRoot(ctx, req.(*ExternalRoot))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ImmuService_ExternalRoots_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExternalRootsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ImmuServiceServer).ExternalRoots(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/immudb.schema.ImmuService/ExternalRoots",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ImmuServiceServer).ExternalRoots(ctx, req.(*ExternalRootsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ImmuService_SetKVSchema_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(KVSchema)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ImmuServiceServer).SetKVSchema(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/immudb.schema.ImmuService/SetKVSchema",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ImmuServiceServer).SetKVSchema(ctx, req.(*KVSchema))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ImmuService_DeleteKVSchema_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(KVSchemaRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ImmuServiceServer).DeleteKVSchema(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/immudb.schema.ImmuService/DeleteKVSchema",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ImmuServiceServer).DeleteKVSchema(ctx, req.(*KVSchemaRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ImmuService_KVSchemas_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(empty.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ImmuServiceServer).KVSchemas(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/immudb.schema.ImmuService/KVSchemas",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ImmuServiceServer).KVSchemas(ctx, req.(*empty.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-func (x *immuServiceStreamExecAllServer) SendAndClose(m *TxHeader) error {
-	return x.ServerStream.SendMsg(m)
+func _ImmuService_SetRedactionRule_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RedactionRule)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ImmuServiceServer).SetRedactionRule(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/immudb.schema.ImmuService/SetRedactionRule",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ImmuServiceServer).SetRedactionRule(ctx, req.(*RedactionRule))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-func (x *immuServiceStreamExecAllServer) Recv() (*Chunk, error) {
-	m := new(Chunk)
-	if err := x.ServerStream.RecvMsg(m); err != nil {
+func _ImmuService_DeleteRedactionRule_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RedactionRuleRequest)
+	if err := dec(in); err != nil {
 		return nil, err
 	}
-	return m, nil
+	if interceptor == nil {
+		return srv.(ImmuServiceServer).DeleteRedactionRule(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/immudb.schema.ImmuService/DeleteRedactionRule",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ImmuServiceServer).DeleteRedactionRule(ctx, req.(*RedactionRuleRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-func _ImmuService_ExportTx_Handler(srv interface{}, stream grpc.ServerStream) error {
-	m := new(ExportTxRequest)
-	if err := stream.RecvMsg(m); err != nil {
-		return err
+func _ImmuService_RedactionRules_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(empty.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
 	}
-	return srv.(ImmuServiceServer).ExportTx(m, &immuServiceExportTxServer{stream})
+	if interceptor == nil {
+		return srv.(ImmuServiceServer).RedactionRules(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/immudb.schema.ImmuService/RedactionRules",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ImmuServiceServer).RedactionRules(ctx, req.(*empty.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-type ImmuService_ExportTxServer interface {
-	Send(*Chunk) error
-	grpc.ServerStream
+func _ImmuService_MultiDatabaseSet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MultiDatabaseSetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ImmuServiceServer).MultiDatabaseSet(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/immudb.schema.ImmuService/MultiDatabaseSet",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ImmuServiceServer).MultiDatabaseSet(ctx, req.(*MultiDatabaseSetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-type immuServiceExportTxServer struct {
-	grpc.ServerStream
+func _ImmuService_ReplicationStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReplicationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ImmuServiceServer).ReplicationStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/immudb.schema.ImmuService/ReplicationStatus",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ImmuServiceServer).ReplicationStatus(ctx, req.(*ReplicationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-func (x *immuServiceExportTxServer) Send(m *Chunk) error {
-	return x.ServerStream.SendMsg(m)
+func _ImmuService_PauseReplication_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReplicationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ImmuServiceServer).PauseReplication(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/immudb.schema.ImmuService/PauseReplication",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ImmuServiceServer).PauseReplication(ctx, req.(*ReplicationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-func _ImmuService_ReplicateTx_Handler(srv interface{}, stream grpc.ServerStream) error {
-	return srv.(ImmuServiceServer).ReplicateTx(&immuServiceReplicateTxServer{stream})
+func _ImmuService_ResumeReplication_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReplicationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ImmuServiceServer).ResumeReplication(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/immudb.schema.ImmuService/ResumeReplication",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ImmuServiceServer).ResumeReplication(ctx, req.(*ReplicationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-type ImmuService_ReplicateTxServer interface {
-	SendAndClose(*TxHeader) error
-	Recv() (*Chunk, error)
-	grpc.ServerStream
+func _ImmuService_ResetReplication_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReplicationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ImmuServiceServer).ResetReplication(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/immudb.schema.ImmuService/ResetReplication",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ImmuServiceServer).ResetReplication(ctx, req.(*ReplicationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-type immuServiceReplicateTxServer struct {
-	grpc.ServerStream
+func _ImmuService_KeyspaceStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(KeyspaceStatsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ImmuServiceServer).KeyspaceStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/immudb.schema.ImmuService/KeyspaceStats",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ImmuServiceServer).KeyspaceStats(ctx, req.(*KeyspaceStatsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-func (x *immuServiceReplicateTxServer) SendAndClose(m *TxHeader) error {
-	return x.ServerStream.SendMsg(m)
+func _ImmuService_CompactIndexAsync_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(empty.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ImmuServiceServer).CompactIndexAsync(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/immudb.schema.ImmuService/CompactIndexAsync",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ImmuServiceServer).CompactIndexAsync(ctx, req.(*empty.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-func (x *immuServiceReplicateTxServer) Recv() (*Chunk, error) {
-	m := new(Chunk)
-	if err := x.ServerStream.RecvMsg(m); err != nil {
+func _ImmuService_ScrubAsync_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(empty.Empty)
+	if err := dec(in); err != nil {
 		return nil, err
 	}
-	return m, nil
+	if interceptor == nil {
+		return srv.(ImmuServiceServer).ScrubAsync(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/immudb.schema.ImmuService/ScrubAsync",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ImmuServiceServer).ScrubAsync(ctx, req.(*empty.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-func _ImmuService_SQLExec_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(SQLExecRequest)
+func _ImmuService_ScrubStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(empty.Empty)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(ImmuServiceServer).SQLExec(ctx, in)
+		return srv.(ImmuServiceServer).ScrubStatus(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/immudb.schema.ImmuService/SQLExec",
+		FullMethod: "/immudb.schema.ImmuService/ScrubStatus",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(ImmuServiceServer).SQLExec(ctx, req.(*SQLExecRequest))
+		return srv.(ImmuServiceServer).ScrubStatus(ctx, req.(*empty.Empty))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _ImmuService_SQLQuery_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(SQLQueryRequest)
+func _ImmuService_ListJobs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListJobsRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(ImmuServiceServer).SQLQuery(ctx, in)
+		return srv.(ImmuServiceServer).ListJobs(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/immudb.schema.ImmuService/SQLQuery",
+		FullMethod: "/immudb.schema.ImmuService/ListJobs",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(ImmuServiceServer).SQLQuery(ctx, req.(*SQLQueryRequest))
+		return srv.(ImmuServiceServer).ListJobs(ctx, req.(*ListJobsRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _ImmuService_ListTables_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(empty.Empty)
+func _ImmuService_GetJob_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(JobRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(ImmuServiceServer).ListTables(ctx, in)
+		return srv.(ImmuServiceServer).GetJob(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/immudb.schema.ImmuService/ListTables",
+		FullMethod: "/immudb.schema.ImmuService/GetJob",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(ImmuServiceServer).ListTables(ctx, req.(*empty.Empty))
+		return srv.(ImmuServiceServer).GetJob(ctx, req.(*JobRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _ImmuService_DescribeTable_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(Table)
+func _ImmuService_CancelJob_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(JobRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(ImmuServiceServer).DescribeTable(ctx, in)
+		return srv.(ImmuServiceServer).CancelJob(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/immudb.schema.ImmuService/DescribeTable",
+		FullMethod: "/immudb.schema.ImmuService/CancelJob",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(ImmuServiceServer).DescribeTable(ctx, req.(*Table))
+		return srv.(ImmuServiceServer).CancelJob(ctx, req.(*JobRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _ImmuService_VerifiableSQLGet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(VerifiableSQLGetRequest)
+func _ImmuService_GetServerKeys_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(empty.Empty)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(ImmuServiceServer).VerifiableSQLGet(ctx, in)
+		return srv.(ImmuServiceServer).GetServerKeys(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/immudb.schema.ImmuService/VerifiableSQLGet",
+		FullMethod: "/immudb.schema.ImmuService/GetServerKeys",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(ImmuServiceServer).VerifiableSQLGet(ctx, req.(*VerifiableSQLGetRequest))
+		return srv.(ImmuServiceServer).GetServerKeys(ctx, req.(*empty.Empty))
 	}
 	return interceptor(ctx, in, info, handler)
 }
@@ -11732,6 +17537,10 @@ var _ImmuService_serviceDesc = grpc.ServiceDesc{
 			MethodName: "TxSQLQuery",
 			Handler:    _ImmuService_TxSQLQuery_Handler,
 		},
+		{
+			MethodName: "TxSet",
+			Handler:    _ImmuService_TxSet_Handler,
+		},
 		{
 			MethodName: "Login",
 			Handler:    _ImmuService_Login_Handler,
@@ -11768,6 +17577,10 @@ var _ImmuService_serviceDesc = grpc.ServiceDesc{
 			MethodName: "ExecAll",
 			Handler:    _ImmuService_ExecAll_Handler,
 		},
+		{
+			MethodName: "ExecScript",
+			Handler:    _ImmuService_ExecScript_Handler,
+		},
 		{
 			MethodName: "Scan",
 			Handler:    _ImmuService_Scan_Handler,
@@ -11800,6 +17613,10 @@ var _ImmuService_serviceDesc = grpc.ServiceDesc{
 			MethodName: "Health",
 			Handler:    _ImmuService_Health_Handler,
 		},
+		{
+			MethodName: "ServerInfo",
+			Handler:    _ImmuService_ServerInfo_Handler,
+		},
 		{
 			MethodName: "DatabaseHealth",
 			Handler:    _ImmuService_DatabaseHealth_Handler,
@@ -11816,6 +17633,14 @@ var _ImmuService_serviceDesc = grpc.ServiceDesc{
 			MethodName: "VerifiableSetReference",
 			Handler:    _ImmuService_VerifiableSetReference_Handler,
 		},
+		{
+			MethodName: "ResolveAll",
+			Handler:    _ImmuService_ResolveAll_Handler,
+		},
+		{
+			MethodName: "Rename",
+			Handler:    _ImmuService_Rename_Handler,
+		},
 		{
 			MethodName: "ZAdd",
 			Handler:    _ImmuService_ZAdd_Handler,
@@ -11840,6 +17665,10 @@ var _ImmuService_serviceDesc = grpc.ServiceDesc{
 			MethodName: "CreateDatabaseWithV2",
 			Handler:    _ImmuService_CreateDatabaseWithV2_Handler,
 		},
+		{
+			MethodName: "CloneDatabase",
+			Handler:    _ImmuService_CloneDatabase_Handler,
+		},
 		{
 			MethodName: "DatabaseList",
 			Handler:    _ImmuService_DatabaseList_Handler,
@@ -11864,6 +17693,14 @@ var _ImmuService_serviceDesc = grpc.ServiceDesc{
 			MethodName: "GetDatabaseSettingsV2",
 			Handler:    _ImmuService_GetDatabaseSettingsV2_Handler,
 		},
+		{
+			MethodName: "GetDatabaseSettingsHistory",
+			Handler:    _ImmuService_GetDatabaseSettingsHistory_Handler,
+		},
+		{
+			MethodName: "RollbackDatabaseSettings",
+			Handler:    _ImmuService_RollbackDatabaseSettings_Handler,
+		},
 		{
 			MethodName: "FlushIndex",
 			Handler:    _ImmuService_FlushIndex_Handler,
@@ -11900,6 +17737,90 @@ var _ImmuService_serviceDesc = grpc.ServiceDesc{
 			MethodName: "VerifiableSQLGet",
 			Handler:    _ImmuService_VerifiableSQLGet_Handler,
 		},
+		{
+			MethodName: "RegisterExternalRoot",
+			Handler:    _ImmuService_RegisterExternalRoot_Handler,
+		},
+		{
+			MethodName: "ExternalRoots",
+			Handler:    _ImmuService_ExternalRoots_Handler,
+		},
+		{
+			MethodName: "SetKVSchema",
+			Handler:    _ImmuService_SetKVSchema_Handler,
+		},
+		{
+			MethodName: "DeleteKVSchema",
+			Handler:    _ImmuService_DeleteKVSchema_Handler,
+		},
+		{
+			MethodName: "KVSchemas",
+			Handler:    _ImmuService_KVSchemas_Handler,
+		},
+		{
+			MethodName: "SetRedactionRule",
+			Handler:    _ImmuService_SetRedactionRule_Handler,
+		},
+		{
+			MethodName: "DeleteRedactionRule",
+			Handler:    _ImmuService_DeleteRedactionRule_Handler,
+		},
+		{
+			MethodName: "RedactionRules",
+			Handler:    _ImmuService_RedactionRules_Handler,
+		},
+		{
+			MethodName: "MultiDatabaseSet",
+			Handler:    _ImmuService_MultiDatabaseSet_Handler,
+		},
+		{
+			MethodName: "ReplicationStatus",
+			Handler:    _ImmuService_ReplicationStatus_Handler,
+		},
+		{
+			MethodName: "PauseReplication",
+			Handler:    _ImmuService_PauseReplication_Handler,
+		},
+		{
+			MethodName: "ResumeReplication",
+			Handler:    _ImmuService_ResumeReplication_Handler,
+		},
+		{
+			MethodName: "ResetReplication",
+			Handler:    _ImmuService_ResetReplication_Handler,
+		},
+		{
+			MethodName: "KeyspaceStats",
+			Handler:    _ImmuService_KeyspaceStats_Handler,
+		},
+		{
+			MethodName: "CompactIndexAsync",
+			Handler:    _ImmuService_CompactIndexAsync_Handler,
+		},
+		{
+			MethodName: "ScrubAsync",
+			Handler:    _ImmuService_ScrubAsync_Handler,
+		},
+		{
+			MethodName: "ScrubStatus",
+			Handler:    _ImmuService_ScrubStatus_Handler,
+		},
+		{
+			MethodName: "ListJobs",
+			Handler:    _ImmuService_ListJobs_Handler,
+		},
+		{
+			MethodName: "GetJob",
+			Handler:    _ImmuService_GetJob_Handler,
+		},
+		{
+			MethodName: "CancelJob",
+			Handler:    _ImmuService_CancelJob_Handler,
+		},
+		{
+			MethodName: "GetServerKeys",
+			Handler:    _ImmuService_GetServerKeys_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -0,0 +1,237 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package schema
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"time"
+
+	"github.com/codenotary/immudb/embedded/sql"
+	"github.com/codenotary/immudb/embedded/store"
+)
+
+// VerifySQLRow checks that row, identified by pkVals, holds the values of the entry proven by vEntry, that such entry
+// is included in its transaction and that the transaction is consistent with the given state.
+// It returns the id and alh of the most recent transaction among the proven one and the state.
+// sqlPrefix is the prefix used by the SQL engine of the database the entry belongs to.
+func VerifySQLRow(row *Row, pkVals []*SQLValue, vEntry *VerifiableSQLEntry, state *ImmutableState, sqlPrefix []byte) (targetID uint64, targetAlh [sha256.Size]byte, err error) {
+	if len(vEntry.PKIDs) < len(pkVals) {
+		return 0, targetAlh, store.ErrIllegalArguments
+	}
+
+	entrySpecDigest, err := store.EntrySpecDigestFor(int(vEntry.VerifiableTx.Tx.Header.Version))
+	if err != nil {
+		return 0, targetAlh, err
+	}
+
+	inclusionProof := InclusionProofFromProto(vEntry.InclusionProof)
+	dualProof := DualProofFromProto(vEntry.VerifiableTx.DualProof)
+
+	var eh [sha256.Size]byte
+
+	var sourceID uint64
+	var sourceAlh [sha256.Size]byte
+
+	vTx := vEntry.SqlEntry.Tx
+
+	valbuf := bytes.Buffer{}
+
+	for i, pkVal := range pkVals {
+		pkID := vEntry.PKIDs[i]
+
+		pkType, ok := vEntry.ColTypesById[pkID]
+		if !ok {
+			return 0, targetAlh, sql.ErrCorruptedData
+		}
+
+		pkLen, ok := vEntry.ColLenById[pkID]
+		if !ok {
+			return 0, targetAlh, sql.ErrCorruptedData
+		}
+
+		pkEncVal, err := sql.EncodeAsKey(RawValue(pkVal), pkType, int(pkLen))
+		if err != nil {
+			return 0, targetAlh, err
+		}
+
+		_, err = valbuf.Write(pkEncVal)
+		if err != nil {
+			return 0, targetAlh, err
+		}
+	}
+
+	pkKey := sql.MapKey(
+		sqlPrefix,
+		sql.PIndexPrefix,
+		sql.EncodeID(vEntry.DatabaseId),
+		sql.EncodeID(vEntry.TableId),
+		sql.EncodeID(sql.PKIndexID),
+		valbuf.Bytes())
+
+	decodedRow, err := decodeRow(vEntry.SqlEntry.Value, vEntry.ColTypesById)
+	if err != nil {
+		return 0, targetAlh, err
+	}
+
+	err = verifyRowAgainst(row, decodedRow, vEntry.ColIdsByName)
+	if err != nil {
+		return 0, targetAlh, err
+	}
+
+	e := &store.EntrySpec{Key: pkKey, Metadata: KVMetadataFromProto(vEntry.SqlEntry.Metadata), Value: vEntry.SqlEntry.Value}
+
+	if state.TxId <= vTx {
+		eh = DigestFromProto(vEntry.VerifiableTx.DualProof.TargetTxHeader.EH)
+
+		sourceID = state.TxId
+		sourceAlh = DigestFromProto(state.TxHash)
+		targetID = vTx
+		targetAlh = dualProof.TargetTxHeader.Alh()
+	} else {
+		eh = DigestFromProto(vEntry.VerifiableTx.DualProof.SourceTxHeader.EH)
+
+		sourceID = vTx
+		sourceAlh = dualProof.SourceTxHeader.Alh()
+		targetID = state.TxId
+		targetAlh = DigestFromProto(state.TxHash)
+	}
+
+	verifies := store.VerifyInclusion(
+		inclusionProof,
+		entrySpecDigest(e),
+		eh)
+	if !verifies {
+		return 0, targetAlh, store.ErrCorruptedData
+	}
+
+	if state.TxId > 0 {
+		verifies = store.VerifyDualProof(
+			dualProof,
+			sourceID,
+			targetID,
+			sourceAlh,
+			targetAlh,
+		)
+		if !verifies {
+			return 0, targetAlh, store.ErrCorruptedData
+		}
+	}
+
+	return targetID, targetAlh, nil
+}
+
+func verifyRowAgainst(row *Row, decodedRow map[uint32]*SQLValue, colIdsByName map[string]uint32) error {
+	for i, colName := range row.Columns {
+		colID, ok := colIdsByName[colName]
+		if !ok {
+			return sql.ErrColumnDoesNotExist
+		}
+
+		val := row.Values[i]
+
+		if val == nil || val.Value == nil {
+			return sql.ErrCorruptedData
+		}
+
+		decodedVal, ok := decodedRow[colID]
+		if !ok {
+			_, isNull := val.Value.(*SQLValue_Null)
+			if isNull {
+				continue
+			}
+			return sql.ErrCorruptedData
+		}
+
+		if decodedVal == nil || decodedVal.Value == nil {
+			return sql.ErrCorruptedData
+		}
+
+		equals, err := val.Value.(SqlValue).Equal(decodedVal.Value.(SqlValue))
+		if err != nil {
+			return err
+		}
+		if !equals {
+			return sql.ErrCorruptedData
+		}
+	}
+
+	return nil
+}
+
+func decodeRow(encodedRow []byte, colTypes map[uint32]sql.SQLValueType) (map[uint32]*SQLValue, error) {
+	off := 0
+
+	if len(encodedRow) < off+sql.EncLenLen {
+		return nil, sql.ErrCorruptedData
+	}
+
+	colsCount := binary.BigEndian.Uint32(encodedRow[off:])
+	off += sql.EncLenLen
+
+	values := make(map[uint32]*SQLValue, colsCount)
+
+	for i := 0; i < int(colsCount); i++ {
+		if len(encodedRow) < off+sql.EncIDLen {
+			return nil, sql.ErrCorruptedData
+		}
+
+		colID := binary.BigEndian.Uint32(encodedRow[off:])
+		off += sql.EncIDLen
+
+		colType, ok := colTypes[colID]
+		if !ok {
+			return nil, sql.ErrCorruptedData
+		}
+
+		val, n, err := sql.DecodeValue(encodedRow[off:], colType)
+		if err != nil {
+			return nil, err
+		}
+
+		values[colID] = typedValueToRowValue(val)
+		off += n
+	}
+
+	return values, nil
+}
+
+func typedValueToRowValue(tv sql.TypedValue) *SQLValue {
+	switch tv.Type() {
+	case sql.IntegerType:
+		{
+			return &SQLValue{Value: &SQLValue_N{N: tv.Value().(int64)}}
+		}
+	case sql.VarcharType:
+		{
+			return &SQLValue{Value: &SQLValue_S{S: tv.Value().(string)}}
+		}
+	case sql.BooleanType:
+		{
+			return &SQLValue{Value: &SQLValue_B{B: tv.Value().(bool)}}
+		}
+	case sql.BLOBType:
+		{
+			return &SQLValue{Value: &SQLValue_Bs{Bs: tv.Value().([]byte)}}
+		}
+	case sql.TimestampType:
+		{
+			return &SQLValue{Value: &SQLValue_Ts{Ts: sql.TimeToInt64(tv.Value().(time.Time))}}
+		}
+	}
+	return nil
+}
@@ -13,14 +13,13 @@ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
 See the License for the specific language governing permissions and
 limitations under the License.
 */
-package client
+package schema
 
 import (
 	"errors"
 	"testing"
 
 	"github.com/codenotary/immudb/embedded/sql"
-	"github.com/codenotary/immudb/pkg/api/schema"
 	"github.com/stretchr/testify/require"
 )
 
@@ -72,39 +71,39 @@ func TestDecodeRowErrors(t *testing.T) {
 func TestVerifyAgainst(t *testing.T) {
 
 	// Missing column type
-	err := verifyRowAgainst(&schema.Row{
+	err := verifyRowAgainst(&Row{
 		Columns: []string{"c1"},
-		Values:  []*schema.SQLValue{{Value: nil}},
-	}, map[uint32]*schema.SQLValue{}, map[string]uint32{})
+		Values:  []*SQLValue{{Value: nil}},
+	}, map[uint32]*SQLValue{}, map[string]uint32{})
 	require.True(t, errors.Is(err, sql.ErrColumnDoesNotExist))
 
 	// Nil value
-	err = verifyRowAgainst(&schema.Row{
+	err = verifyRowAgainst(&Row{
 		Columns: []string{"c1"},
-		Values:  []*schema.SQLValue{{Value: nil}},
-	}, map[uint32]*schema.SQLValue{}, map[string]uint32{
+		Values:  []*SQLValue{{Value: nil}},
+	}, map[uint32]*SQLValue{}, map[string]uint32{
 		"c1": 0,
 	})
 	require.True(t, errors.Is(err, sql.ErrCorruptedData))
 
 	// Missing decoded value
-	err = verifyRowAgainst(&schema.Row{
+	err = verifyRowAgainst(&Row{
 		Columns: []string{"c1"},
-		Values: []*schema.SQLValue{
-			{Value: &schema.SQLValue_N{N: 1}},
+		Values: []*SQLValue{
+			{Value: &SQLValue_N{N: 1}},
 		},
-	}, map[uint32]*schema.SQLValue{}, map[string]uint32{
+	}, map[uint32]*SQLValue{}, map[string]uint32{
 		"c1": 0,
 	})
 	require.True(t, errors.Is(err, sql.ErrCorruptedData))
 
 	// Invalid decoded value
-	err = verifyRowAgainst(&schema.Row{
+	err = verifyRowAgainst(&Row{
 		Columns: []string{"c1"},
-		Values: []*schema.SQLValue{
-			{Value: &schema.SQLValue_N{N: 1}},
+		Values: []*SQLValue{
+			{Value: &SQLValue_N{N: 1}},
 		},
-	}, map[uint32]*schema.SQLValue{
+	}, map[uint32]*SQLValue{
 		0: {Value: nil},
 	}, map[string]uint32{
 		"c1": 0,
@@ -112,39 +111,39 @@ func TestVerifyAgainst(t *testing.T) {
 	require.True(t, errors.Is(err, sql.ErrCorruptedData))
 
 	// Not comparable types
-	err = verifyRowAgainst(&schema.Row{
+	err = verifyRowAgainst(&Row{
 		Columns: []string{"c1"},
-		Values: []*schema.SQLValue{
-			{Value: &schema.SQLValue_N{N: 1}},
+		Values: []*SQLValue{
+			{Value: &SQLValue_N{N: 1}},
 		},
-	}, map[uint32]*schema.SQLValue{
-		0: {Value: &schema.SQLValue_S{S: "1"}},
+	}, map[uint32]*SQLValue{
+		0: {Value: &SQLValue_S{S: "1"}},
 	}, map[string]uint32{
 		"c1": 0,
 	})
 	require.True(t, errors.Is(err, sql.ErrNotComparableValues))
 
 	// Different values
-	err = verifyRowAgainst(&schema.Row{
+	err = verifyRowAgainst(&Row{
 		Columns: []string{"c1"},
-		Values: []*schema.SQLValue{
-			{Value: &schema.SQLValue_N{N: 1}},
+		Values: []*SQLValue{
+			{Value: &SQLValue_N{N: 1}},
 		},
-	}, map[uint32]*schema.SQLValue{
-		0: {Value: &schema.SQLValue_N{N: 2}},
+	}, map[uint32]*SQLValue{
+		0: {Value: &SQLValue_N{N: 2}},
 	}, map[string]uint32{
 		"c1": 0,
 	})
 	require.True(t, errors.Is(err, sql.ErrCorruptedData))
 
 	// Successful verify
-	err = verifyRowAgainst(&schema.Row{
+	err = verifyRowAgainst(&Row{
 		Columns: []string{"c1"},
-		Values: []*schema.SQLValue{
-			{Value: &schema.SQLValue_N{N: 1}},
+		Values: []*SQLValue{
+			{Value: &SQLValue_N{N: 1}},
 		},
-	}, map[uint32]*schema.SQLValue{
-		0: {Value: &schema.SQLValue_N{N: 1}},
+	}, map[uint32]*SQLValue{
+		0: {Value: &SQLValue_N{N: 1}},
 	}, map[string]uint32{
 		"c1": 0,
 	})
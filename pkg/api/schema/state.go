@@ -16,14 +16,24 @@ limitations under the License.
 package schema
 
 import (
+	"bytes"
 	"crypto/ecdsa"
 	"crypto/sha256"
 	"encoding/binary"
 	"errors"
+	"fmt"
 
 	"github.com/codenotary/immudb/pkg/signer"
+	"google.golang.org/protobuf/encoding/protojson"
 )
 
+// ExportedRootVersion is the version of the compact format used to export signed states
+const ExportedRootVersion = 1
+
+var ErrStateNotSigned = errors.New("state is not signed")
+var ErrInvalidExportedRoot = errors.New("invalid exported root")
+var ErrInvalidRootSignature = errors.New("exported root signature does not verify")
+
 func (state *ImmutableState) ToBytes() []byte {
 	b := make([]byte, 4+len(state.Db)+8+sha256.Size)
 	i := 0
@@ -49,3 +59,166 @@ func (state *ImmutableState) CheckSignature(key *ecdsa.PublicKey) (ok bool, err
 	}
 	return signer.Verify(state.ToBytes(), state.Signature.Signature, key)
 }
+
+// ExportRoot encodes a signed state in a compact binary format, suitable to be anchored into external
+// systems such as blockchains or timestamping authorities.
+// Format: version + state bytes (dbLen + db + txID + alh) + sigLen + signature + pubKeyLen + pubKey
+func (state *ImmutableState) ExportRoot() ([]byte, error) {
+	if state.Signature == nil || len(state.Signature.Signature) == 0 {
+		return nil, ErrStateNotSigned
+	}
+
+	if len(state.TxHash) != sha256.Size {
+		return nil, fmt.Errorf("%w: invalid state hash", ErrInvalidExportedRoot)
+	}
+
+	var b bytes.Buffer
+
+	b.WriteByte(ExportedRootVersion)
+	b.Write(state.ToBytes())
+
+	var lenBs [2]byte
+
+	binary.BigEndian.PutUint16(lenBs[:], uint16(len(state.Signature.Signature)))
+	b.Write(lenBs[:])
+	b.Write(state.Signature.Signature)
+
+	binary.BigEndian.PutUint16(lenBs[:], uint16(len(state.Signature.PublicKey)))
+	b.Write(lenBs[:])
+	b.Write(state.Signature.PublicKey)
+
+	return b.Bytes(), nil
+}
+
+// ImportRoot decodes a state exported with ExportRoot, its signature is not checked
+func ImportRoot(exportedRoot []byte) (*ImmutableState, error) {
+	r := bytes.NewReader(exportedRoot)
+
+	readN := func(n int) ([]byte, error) {
+		if n > r.Len() {
+			return nil, fmt.Errorf("%w: unexpected end of data", ErrInvalidExportedRoot)
+		}
+		b := make([]byte, n)
+		r.Read(b)
+		return b, nil
+	}
+
+	readUint16 := func() (int, error) {
+		b, err := readN(2)
+		if err != nil {
+			return 0, err
+		}
+		return int(binary.BigEndian.Uint16(b)), nil
+	}
+
+	version, err := readN(1)
+	if err != nil {
+		return nil, err
+	}
+	if version[0] != ExportedRootVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidExportedRoot, version[0])
+	}
+
+	dbLen, err := readN(4)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := readN(int(binary.BigEndian.Uint32(dbLen)))
+	if err != nil {
+		return nil, err
+	}
+
+	txID, err := readN(8)
+	if err != nil {
+		return nil, err
+	}
+
+	txHash, err := readN(sha256.Size)
+	if err != nil {
+		return nil, err
+	}
+
+	sigLen, err := readUint16()
+	if err != nil {
+		return nil, err
+	}
+
+	sig, err := readN(sigLen)
+	if err != nil {
+		return nil, err
+	}
+
+	pubKeyLen, err := readUint16()
+	if err != nil {
+		return nil, err
+	}
+
+	pubKey, err := readN(pubKeyLen)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.Len() > 0 {
+		return nil, fmt.Errorf("%w: unexpected trailing data", ErrInvalidExportedRoot)
+	}
+
+	return &ImmutableState{
+		Db:     string(db),
+		TxId:   binary.BigEndian.Uint64(txID),
+		TxHash: txHash,
+		Signature: &Signature{
+			Signature: sig,
+			PublicKey: pubKey,
+			KeyId:     signer.KeyID(pubKey),
+		},
+	}, nil
+}
+
+// VerifyExportedRoot decodes an exported root and checks it was signed with the given key
+func VerifyExportedRoot(exportedRoot []byte, key *ecdsa.PublicKey) (*ImmutableState, error) {
+	if key == nil {
+		return nil, errors.New("no public key provided")
+	}
+
+	state, err := ImportRoot(exportedRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	ok, err := state.CheckSignature(key)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRootSignature, err)
+	}
+	if !ok {
+		return nil, ErrInvalidRootSignature
+	}
+
+	return state, nil
+}
+
+// ParseRoot decodes a saved signed state, either encoded with ExportRoot or following the protobuf
+// JSON mapping of ImmutableState. Its signature is not checked
+func ParseRoot(data []byte) (*ImmutableState, error) {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 || trimmed[0] != '{' {
+		return ImportRoot(data)
+	}
+
+	state := &ImmutableState{}
+
+	err := protojson.Unmarshal(trimmed, state)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidExportedRoot, err)
+	}
+
+	if len(state.TxHash) != sha256.Size {
+		return nil, fmt.Errorf("%w: invalid state hash", ErrInvalidExportedRoot)
+	}
+
+	if state.Signature == nil || len(state.Signature.Signature) == 0 {
+		return nil, ErrStateNotSigned
+	}
+
+	return state, nil
+}
@@ -0,0 +1,141 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schema
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"github.com/codenotary/immudb/pkg/signer"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+func TestExportRoot(t *testing.T) {
+	txHash := sha256.Sum256([]byte("alh"))
+
+	state := &ImmutableState{
+		Db:     "defaultdb",
+		TxId:   42,
+		TxHash: txHash[:],
+	}
+
+	_, err := state.ExportRoot()
+	require.ErrorIs(t, err, ErrStateNotSigned)
+
+	s, err := signer.NewSigner("./../../../test/signer/ec1.key")
+	require.NoError(t, err)
+
+	signature, publicKey, err := s.Sign(state.ToBytes())
+	require.NoError(t, err)
+
+	state.Signature = &Signature{Signature: signature, PublicKey: publicKey}
+
+	exportedRoot, err := state.ExportRoot()
+	require.NoError(t, err)
+
+	imported, err := ImportRoot(exportedRoot)
+	require.NoError(t, err)
+	require.Equal(t, state.Db, imported.Db)
+	require.Equal(t, state.TxId, imported.TxId)
+	require.Equal(t, state.TxHash, imported.TxHash)
+	require.Equal(t, state.Signature.Signature, imported.Signature.Signature)
+	require.Equal(t, state.Signature.PublicKey, imported.Signature.PublicKey)
+
+	pubKey, err := signer.ParsePublicKeyFile("./../../../test/signer/ec1.pub")
+	require.NoError(t, err)
+
+	verified, err := VerifyExportedRoot(exportedRoot, pubKey)
+	require.NoError(t, err)
+	require.Equal(t, state.TxId, verified.TxId)
+
+	_, err = VerifyExportedRoot(exportedRoot, nil)
+	require.Error(t, err)
+
+	otherPubKey, err := signer.ParsePublicKeyFile("./../../../test/signer/ec3.pub")
+	require.NoError(t, err)
+
+	_, err = VerifyExportedRoot(exportedRoot, otherPubKey)
+	require.ErrorIs(t, err, ErrInvalidRootSignature)
+
+	t.Run("invalid exported roots", func(t *testing.T) {
+		for i := 0; i < len(exportedRoot); i++ {
+			_, err := ImportRoot(exportedRoot[:i])
+			require.ErrorIs(t, err, ErrInvalidExportedRoot)
+		}
+
+		_, err := ImportRoot(append(exportedRoot, 0))
+		require.ErrorIs(t, err, ErrInvalidExportedRoot)
+
+		unsupported := make([]byte, len(exportedRoot))
+		copy(unsupported, exportedRoot)
+		unsupported[0] = ExportedRootVersion + 1
+
+		_, err = ImportRoot(unsupported)
+		require.ErrorIs(t, err, ErrInvalidExportedRoot)
+	})
+
+	t.Run("invalid state hash", func(t *testing.T) {
+		_, err := (&ImmutableState{TxHash: []byte{1}, Signature: state.Signature}).ExportRoot()
+		require.ErrorIs(t, err, ErrInvalidExportedRoot)
+	})
+}
+
+func TestParseRoot(t *testing.T) {
+	txHash := sha256.Sum256([]byte("alh"))
+
+	state := &ImmutableState{
+		Db:     "defaultdb",
+		TxId:   42,
+		TxHash: txHash[:],
+	}
+
+	s, err := signer.NewSigner("./../../../test/signer/ec1.key")
+	require.NoError(t, err)
+
+	signature, publicKey, err := s.Sign(state.ToBytes())
+	require.NoError(t, err)
+
+	state.Signature = &Signature{Signature: signature, PublicKey: publicKey}
+
+	exportedRoot, err := state.ExportRoot()
+	require.NoError(t, err)
+
+	js, err := protojson.Marshal(state)
+	require.NoError(t, err)
+
+	for _, data := range [][]byte{exportedRoot, js} {
+		parsed, err := ParseRoot(data)
+		require.NoError(t, err)
+		require.Equal(t, state.Db, parsed.Db)
+		require.Equal(t, state.TxId, parsed.TxId)
+		require.Equal(t, state.TxHash, parsed.TxHash)
+		require.Equal(t, state.Signature.Signature, parsed.Signature.Signature)
+	}
+
+	_, err = ParseRoot([]byte("{\"txId\": \"foo\"}"))
+	require.ErrorIs(t, err, ErrInvalidExportedRoot)
+
+	_, err = ParseRoot([]byte("{\"txId\": \"42\"}"))
+	require.ErrorIs(t, err, ErrInvalidExportedRoot)
+
+	unsigned, err := protojson.Marshal(&ImmutableState{Db: "defaultdb", TxId: 42, TxHash: txHash[:]})
+	require.NoError(t, err)
+
+	_, err = ParseRoot(unsigned)
+	require.ErrorIs(t, err, ErrStateNotSigned)
+}
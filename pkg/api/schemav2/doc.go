@@ -0,0 +1,49 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+Package schemav2 contains the version 2 of the immudb API, the immudb.v2 protobuf package.
+
+Breaking changes are not made to an existing version of the API. Instead of adding methods
+with a version suffix (e.g. CreateDatabaseWithV2) to immudb.schema.ImmuService, the changed
+methods are defined again in the next version of the service and every rpc takes its own
+request and returns its own response message, so it can be extended with new fields later on.
+
+The server serves every version of the API at the same time. Methods of a newer version are
+implemented as adapters over the previous one, so both versions share the same behaviour.
+
+Superseded methods go through the following deprecation pipeline:
+
+ 1. the replacement is added to the newer version of the API and the superseded method is listed
+    in Replacements. The server keeps serving it, but logs its first use and announces the
+    replacement to clients in the immudb-deprecated response header
+ 2. the superseded method is removed from the API in the next major release of immudb
+
+The REST gateway of this version of the API is served under /api/v2.
+*/
+package schemav2
+
+// Replacements maps the full gRPC name of every deprecated method of the previous
+// version of the API to the full gRPC name of the method replacing it
+var Replacements = map[string]string{
+	"/immudb.schema.ImmuService/CreateDatabase":        "/immudb.v2.ImmuService/CreateDatabase",
+	"/immudb.schema.ImmuService/CreateDatabaseWith":    "/immudb.v2.ImmuService/CreateDatabase",
+	"/immudb.schema.ImmuService/CreateDatabaseWithV2":  "/immudb.v2.ImmuService/CreateDatabase",
+	"/immudb.schema.ImmuService/UpdateDatabase":        "/immudb.v2.ImmuService/UpdateDatabase",
+	"/immudb.schema.ImmuService/UpdateDatabaseV2":      "/immudb.v2.ImmuService/UpdateDatabase",
+	"/immudb.schema.ImmuService/GetDatabaseSettings":   "/immudb.v2.ImmuService/GetDatabaseSettings",
+	"/immudb.schema.ImmuService/GetDatabaseSettingsV2": "/immudb.v2.ImmuService/GetDatabaseSettings",
+}
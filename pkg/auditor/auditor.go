@@ -0,0 +1,384 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package auditor continuously verifies that the history of the databases of an immudb server is not altered.
+//
+// Databases are audited in turn: the current state of a database is checked to be consistent with the
+// last state verified by a previous audit, which is then replaced by the current one.
+// Results are reported to callbacks and published to notification sinks, so continuous auditing
+// can be embedded into any service.
+package auditor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/client/state"
+	"github.com/codenotary/immudb/pkg/signer"
+	"github.com/golang/protobuf/ptypes/empty"
+	"google.golang.org/grpc/metadata"
+)
+
+var ErrIllegalArguments = errors.New("illegal arguments")
+
+// Result describes the audit of a database
+type Result struct {
+	// Index is the sequence number of the audit
+	Index uint64
+
+	ServerID      string
+	ServerAddress string
+	Database      string
+	RunAt         time.Time
+
+	// Checked is set when the consistency of the current state with a previously verified one was checked
+	Checked bool
+
+	// Tampered is set when the current state is not consistent with the previously verified one
+	Tampered bool
+
+	PrevState *schema.ImmutableState
+	CurrState *schema.ImmutableState
+
+	// Proof is the consistency proof between the previous and the current state, when checked
+	Proof *schema.DualProof
+
+	// Err is the error preventing the audit from being completed
+	Err error
+}
+
+// Auditor audits the databases of an immudb server
+type Auditor struct {
+	serviceClient schema.ImmuServiceClient
+	uuidProvider  state.UUIDProvider
+	opts          *Options
+
+	index         uint64
+	databases     []string
+	databaseIndex int
+
+	slugifyRegExp *regexp.Regexp
+
+	mutex sync.Mutex
+}
+
+// NewAuditor returns an auditor of the server reached through serviceClient
+func NewAuditor(serviceClient schema.ImmuServiceClient, opts *Options) (*Auditor, error) {
+	if serviceClient == nil || !opts.Valid() {
+		return nil, ErrIllegalArguments
+	}
+
+	uuidProvider := opts.uuidProvider
+	if uuidProvider == nil {
+		uuidProvider = state.NewUUIDProvider(serviceClient)
+	}
+
+	return &Auditor{
+		serviceClient: serviceClient,
+		uuidProvider:  uuidProvider,
+		opts:          opts,
+		slugifyRegExp: regexp.MustCompile(`[^a-zA-Z0-9\-_]+`),
+	}, nil
+}
+
+// Run audits a database every interval until ctx is done, the first audit is run right away.
+// Failed audits are reported through their result, they do not stop the auditor.
+func (a *Auditor) Run(ctx context.Context) error {
+	a.opts.logger.Infof("starting auditor with a %s interval ...", a.opts.interval)
+
+	tick := time.NewTicker(a.opts.interval)
+	defer tick.Stop()
+
+	for {
+		a.Audit(ctx)
+
+		select {
+		case <-ctx.Done():
+			a.opts.logger.Infof("auditor stopped")
+			return nil
+		case <-tick.C:
+		}
+	}
+}
+
+// Audit audits the next database. The list of databases to audit is (re)loaded once all of them were audited
+func (a *Auditor) Audit(ctx context.Context) *Result {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	a.index++
+
+	result := &Result{
+		Index:         a.index,
+		ServerID:      "unknown",
+		ServerAddress: a.opts.serverAddress,
+		RunAt:         time.Now(),
+	}
+
+	a.opts.logger.Infof("audit #%d started @ %s", a.index, result.RunAt)
+
+	err := a.audit(ctx, result)
+	if err != nil {
+		result.Err = err
+		a.opts.logger.Errorf(err.Error())
+	}
+
+	if result.Checked {
+		a.notify(ctx, result)
+	}
+
+	if result.Tampered && a.opts.onTamper != nil {
+		a.opts.onTamper(result)
+	}
+
+	if a.opts.onResult != nil {
+		a.opts.onResult(result)
+	}
+
+	return result
+}
+
+func (a *Auditor) audit(ctx context.Context, result *Result) error {
+	loginResponse, err := a.serviceClient.Login(ctx, &schema.LoginRequest{
+		User:     []byte(a.opts.username),
+		Password: []byte(a.opts.password),
+	})
+	if err != nil {
+		return fmt.Errorf("error logging in with user %s: %w", a.opts.username, err)
+	}
+
+	defer a.serviceClient.Logout(ctx, &empty.Empty{})
+
+	authCtx := metadata.NewOutgoingContext(ctx, metadata.Pairs("authorization", loginResponse.Token))
+
+	//check if we have cycled through the list of databases
+	if a.databaseIndex == len(a.databases) {
+		err = a.loadDatabases(authCtx)
+		if err != nil {
+			return err
+		}
+	}
+
+	dbName := a.databases[a.databaseIndex]
+	result.Database = dbName
+
+	resp, err := a.serviceClient.UseDatabase(authCtx, &schema.Database{
+		DatabaseName: dbName,
+	})
+	if err != nil {
+		return fmt.Errorf("error selecting database %s: %w", dbName, err)
+	}
+
+	dbCtx := metadata.NewOutgoingContext(ctx, metadata.Pairs("authorization", resp.Token))
+
+	a.opts.logger.Infof("audit #%d - auditing database %s\n", a.index, dbName)
+	a.databaseIndex++
+
+	currState, err := a.serviceClient.CurrentState(dbCtx, &empty.Empty{})
+	if err != nil {
+		return fmt.Errorf("error getting current state: %w", err)
+	}
+	result.CurrState = currState
+
+	if err := a.verifyStateSignature(result.ServerID, currState); err != nil {
+		return fmt.Errorf("audit #%d aborted: %w", a.index, err)
+	}
+
+	isEmptyDB := currState.TxId == 0
+
+	result.ServerID = a.getServerID(dbCtx)
+
+	prevState, err := a.opts.stateStore.Get(result.ServerID, dbName)
+	if err != nil {
+		return err
+	}
+	result.PrevState = prevState
+
+	if prevState != nil {
+		if isEmptyDB {
+			return fmt.Errorf(
+				"audit #%d aborted: database is empty on server %s @ %s, "+
+					"but locally a previous state exists with hash %x at id %d",
+				a.index, result.ServerID, a.opts.serverAddress, prevState.TxHash, prevState.TxId)
+		}
+
+		vtx, err := a.serviceClient.VerifiableTxById(dbCtx, &schema.VerifiableTxRequest{
+			Tx:           currState.TxId,
+			ProveSinceTx: prevState.TxId,
+		})
+		if err != nil {
+			return fmt.Errorf(
+				"error fetching consistency proof for previous state %d: %w",
+				prevState.TxId, err)
+		}
+		result.Proof = vtx.DualProof
+
+		verified := store.VerifyDualProof(
+			schema.DualProofFromProto(vtx.DualProof),
+			prevState.TxId,
+			currState.TxId,
+			schema.DigestFromProto(prevState.TxHash),
+			schema.DigestFromProto(currState.TxHash),
+		)
+
+		a.opts.logger.Infof("audit #%d result:\n db: %s, consistent:	%t\n"+
+			"  previous state:	%x at tx: %d\n  current state:	%x at tx: %d",
+			a.index, dbName, verified,
+			prevState.TxHash, prevState.TxId, currState.TxHash, currState.TxId)
+
+		result.Checked = true
+		result.Tampered = !verified
+	} else if isEmptyDB {
+		a.opts.logger.Warningf("audit #%d canceled: database is empty on server %s @ %s",
+			a.index, result.ServerID, a.opts.serverAddress)
+		return nil
+	}
+
+	if result.Tampered {
+		a.opts.logger.Warningf(
+			"audit #%d detected possible tampering of db %s remote state (at id %d) "+
+				"so it will not overwrite the previous local state (at id %d)",
+			a.index, dbName, currState.TxId, prevState.TxId)
+	} else if prevState == nil || currState.TxId != prevState.TxId {
+		if err := a.opts.stateStore.Set(result.ServerID, dbName, currState); err != nil {
+			return err
+		}
+	}
+
+	a.opts.logger.Infof("audit #%d finished in %s @ %s",
+		a.index, time.Since(result.RunAt), time.Now().Format(time.RFC3339Nano))
+
+	return nil
+}
+
+// loadDatabases (re)loads the list of databases to audit among the ones the user has access to
+func (a *Auditor) loadDatabases(ctx context.Context) error {
+	dbs, err := a.serviceClient.DatabaseList(ctx, &empty.Empty{})
+	if err != nil {
+		return fmt.Errorf("error getting a list of databases %w", err)
+	}
+
+	a.databases = nil
+
+	for _, db := range dbs.Databases {
+		dbMustBeAudited := len(a.opts.databases) <= 0
+		for _, dbPrefix := range a.opts.databases {
+			if strings.HasPrefix(db.DatabaseName, dbPrefix) {
+				dbMustBeAudited = true
+				break
+			}
+		}
+		if dbMustBeAudited {
+			a.databases = append(a.databases, db.DatabaseName)
+		}
+	}
+
+	a.databaseIndex = 0
+	if len(a.databases) <= 0 {
+		return fmt.Errorf(
+			"audit #%d aborted: no databases to audit found after (re)loading the list of databases",
+			a.index)
+	}
+
+	a.opts.logger.Infof(
+		"audit #%d - list of databases to audit has been (re)loaded - %d database(s) found: %v",
+		a.index, len(a.databases), a.databases)
+
+	return nil
+}
+
+func (a *Auditor) notify(ctx context.Context, result *Result) {
+	for _, n := range a.opts.notifiers {
+		err := n.Notify(ctx, result)
+		if err != nil {
+			a.opts.logger.Errorf(
+				"error publishing audit notification for db %s: %v", result.Database, err)
+		} else {
+			a.opts.logger.Infof(
+				"audit notification for db %s has been published", result.Database)
+		}
+	}
+}
+
+func (a *Auditor) verifyStateSignature(
+	serverID string,
+	serverState *schema.ImmutableState,
+) error {
+
+	if a.opts.serverSigningPubKey != nil && serverState.GetSignature() == nil {
+		return fmt.Errorf(
+			"a server signing public key has been specified for the auditor, "+
+				"but the state %s at TX %d received from server %s @ %s is not signed",
+			serverState.GetTxHash(), serverState.GetTxId(), serverID, a.opts.serverAddress)
+	}
+
+	if serverState.GetSignature() != nil {
+		pk := a.opts.serverSigningPubKey
+		if pk == nil {
+			a.opts.logger.Warningf(
+				"server signature will be verified using untrusted public key (embedded in the server state payload) " +
+					"- for better security please configure a public key for the auditor process")
+			var err error
+			pk, err = signer.UnmarshalKey(serverState.GetSignature().GetPublicKey())
+			if err != nil {
+				return fmt.Errorf(
+					"failed to verify signature for state %s at TX %d received from server %s @ %s: "+
+						"error unmarshaling the public key embedded in the server state payload: %w",
+					serverState.GetTxHash(), serverState.GetTxId(), serverID, a.opts.serverAddress, err)
+			}
+		}
+
+		if okSig, err := serverState.CheckSignature(pk); err != nil || !okSig {
+			return fmt.Errorf(
+				"failed to verify signature for state %s at TX %d received from server %s @ %s: "+
+					"verification result: %t, verification error: %v",
+				serverState.GetTxHash(), serverState.GetTxId(), serverID, a.opts.serverAddress,
+				okSig, err)
+		}
+	}
+
+	return nil
+}
+
+func (a *Auditor) getServerID(ctx context.Context) string {
+	serverID, err := a.uuidProvider.CurrentUUID(ctx)
+	if err != nil {
+		if err != state.ErrNoServerUuid {
+			a.opts.logger.Errorf("error getting server UUID: %v", err)
+		} else {
+			a.opts.logger.Warningf(err.Error())
+		}
+	}
+
+	if serverID == "" {
+		serverID = strings.ReplaceAll(
+			strings.ReplaceAll(a.opts.serverAddress, ".", "-"),
+			":", "_")
+		serverID = a.slugifyRegExp.ReplaceAllString(serverID, "")
+		a.opts.logger.Debugf(
+			"the current immudb server @ %s will be identified as %s",
+			a.opts.serverAddress, serverID)
+	}
+
+	return serverID
+}
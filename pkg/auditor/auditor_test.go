@@ -0,0 +1,214 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auditor
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/auth"
+	ic "github.com/codenotary/immudb/pkg/client"
+	"github.com/codenotary/immudb/pkg/logger"
+	"github.com/codenotary/immudb/pkg/server"
+	"github.com/codenotary/immudb/pkg/server/servertest"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// setupAuditedServer returns a client to write into the server and the service client used by the auditor
+func setupAuditedServer(t *testing.T) (ic.ImmuClient, context.Context, schema.ImmuServiceClient) {
+	options := server.DefaultOptions().WithAuth(true).WithDir("audited")
+	bs := servertest.NewBufconnServer(options)
+
+	err := bs.Start()
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		bs.Stop()
+		os.RemoveAll(options.Dir)
+	})
+
+	dialOptions := []grpc.DialOption{grpc.WithContextDialer(bs.Dialer), grpc.WithInsecure()}
+
+	client, err := ic.NewImmuClient(ic.DefaultOptions().WithDialOptions(dialOptions))
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Disconnect() })
+
+	lr, err := client.Login(context.Background(), []byte(`immudb`), []byte(`immudb`))
+	require.NoError(t, err)
+
+	ctx := metadata.NewOutgoingContext(context.Background(), metadata.Pairs("authorization", lr.Token))
+
+	// auditing only requires read access
+	err = client.CreateUser(ctx, []byte("auditor"), []byte("Auditor1!"), auth.PermissionR, "defaultdb")
+	require.NoError(t, err)
+
+	conn, err := grpc.Dial("bufconn", dialOptions...)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return client, ctx, schema.NewImmuServiceClient(conn)
+}
+
+func auditorOptions() *Options {
+	return DefaultOptions().
+		WithServerAddress("bufconn").
+		WithUsername("auditor").
+		WithPassword("Auditor1!").
+		WithDatabases([]string{"defaultdb"}).
+		WithLogger(logger.NewSimpleLogger("auditor_test", os.Stderr))
+}
+
+func TestNewAuditor(t *testing.T) {
+	_, err := NewAuditor(nil, auditorOptions())
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, _, serviceClient := setupAuditedServer(t)
+
+	_, err = NewAuditor(serviceClient, DefaultOptions())
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = NewAuditor(serviceClient, auditorOptions().WithStateStore(nil))
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = NewAuditor(serviceClient, auditorOptions().WithInterval(0))
+	require.ErrorIs(t, err, ErrIllegalArguments)
+}
+
+func TestAuditor(t *testing.T) {
+	client, ctx, serviceClient := setupAuditedServer(t)
+
+	_, err := client.Set(ctx, []byte("key1"), []byte("value1"))
+	require.NoError(t, err)
+
+	var results []*Result
+	var notified []*Result
+	var tampered []*Result
+
+	stateStore := NewInMemoryStateStore()
+
+	a, err := NewAuditor(serviceClient, auditorOptions().
+		WithStateStore(stateStore).
+		WithNotifiers(NotifierFunc(func(ctx context.Context, result *Result) error {
+			notified = append(notified, result)
+			return nil
+		})).
+		WithOnResult(func(result *Result) { results = append(results, result) }).
+		WithOnTamper(func(result *Result) { tampered = append(tampered, result) }))
+	require.NoError(t, err)
+
+	// the first audit of a database verifies nothing but stores its state
+	result := a.Audit(context.Background())
+	require.NoError(t, result.Err)
+	require.Equal(t, uint64(1), result.Index)
+	require.Equal(t, "defaultdb", result.Database)
+	require.NotEqual(t, "unknown", result.ServerID)
+	require.False(t, result.Checked)
+	require.Nil(t, result.PrevState)
+	require.NotNil(t, result.CurrState)
+	require.Empty(t, notified)
+
+	stored, err := stateStore.Get(result.ServerID, "defaultdb")
+	require.NoError(t, err)
+	require.Equal(t, result.CurrState.TxId, stored.TxId)
+
+	_, err = client.Set(ctx, []byte("key2"), []byte("value2"))
+	require.NoError(t, err)
+
+	result = a.Audit(context.Background())
+	require.NoError(t, result.Err)
+	require.True(t, result.Checked)
+	require.False(t, result.Tampered)
+	require.Equal(t, stored.TxId, result.PrevState.TxId)
+	require.Equal(t, stored.TxId+1, result.CurrState.TxId)
+	require.Equal(t, []*Result{result}, notified)
+	require.Empty(t, tampered)
+
+	t.Run("tampering is detected", func(t *testing.T) {
+		forged := &schema.ImmutableState{
+			Db:     "defaultdb",
+			TxId:   1,
+			TxHash: make([]byte, 32),
+		}
+
+		err = stateStore.Set(result.ServerID, "defaultdb", forged)
+		require.NoError(t, err)
+
+		result := a.Audit(context.Background())
+		require.NoError(t, result.Err)
+		require.True(t, result.Checked)
+		require.True(t, result.Tampered)
+		require.NotNil(t, result.Proof)
+		require.Equal(t, []*Result{result}, tampered)
+
+		// the previously verified state is kept
+		stored, err := stateStore.Get(result.ServerID, "defaultdb")
+		require.NoError(t, err)
+		require.Equal(t, forged, stored)
+	})
+
+	t.Run("failed audits are reported", func(t *testing.T) {
+		a, err := NewAuditor(serviceClient, auditorOptions().
+			WithPassword("wrong").
+			WithOnResult(func(result *Result) { results = append(results, result) }))
+		require.NoError(t, err)
+
+		result := a.Audit(context.Background())
+		require.Error(t, result.Err)
+		require.Contains(t, result.Err.Error(), "error logging in with user auditor")
+		require.Equal(t, result, results[len(results)-1])
+	})
+
+	require.Len(t, results, 4)
+}
+
+func TestAuditorRun(t *testing.T) {
+	client, ctx, serviceClient := setupAuditedServer(t)
+
+	_, err := client.Set(ctx, []byte("key1"), []byte("value1"))
+	require.NoError(t, err)
+
+	runCtx, cancel := context.WithCancel(context.Background())
+	audited := make(chan *Result, 10)
+
+	a, err := NewAuditor(serviceClient, auditorOptions().
+		WithInterval(time.Millisecond).
+		WithOnResult(func(result *Result) {
+			select {
+			case audited <- result:
+			default:
+			}
+		}))
+	require.NoError(t, err)
+
+	done := make(chan error)
+	go func() {
+		done <- a.Run(runCtx)
+	}()
+
+	for i := 0; i < 2; i++ {
+		result := <-audited
+		require.NoError(t, result.Err)
+	}
+
+	cancel()
+	require.NoError(t, <-done)
+}
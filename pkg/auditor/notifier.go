@@ -0,0 +1,201 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auditor
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/alert"
+	"github.com/codenotary/immudb/pkg/api/schema"
+)
+
+// Notifier publishes the result of the audits checking the consistency of a database
+type Notifier interface {
+	Notify(ctx context.Context, result *Result) error
+}
+
+// NotifierFunc adapts a function to the Notifier interface
+type NotifierFunc func(ctx context.Context, result *Result) error
+
+// Notify calls f(ctx, result)
+func (f NotifierFunc) Notify(ctx context.Context, result *Result) error {
+	return f(ctx, result)
+}
+
+// NewAlertNotifier returns a notifier delivering an alert to the sink for every tampered result,
+// including both conflicting states and the consistency proof failing to verify
+func NewAlertNotifier(sink alert.Sink) Notifier {
+	return NotifierFunc(func(ctx context.Context, result *Result) error {
+		if !result.Tampered {
+			return nil
+		}
+
+		a := &alert.Alert{
+			Detector:      alert.DetectorAuditor,
+			ServerID:      result.ServerID,
+			ServerAddress: result.ServerAddress,
+			Database:      result.Database,
+			DetectedAt:    result.RunAt,
+			PrevRoot:      alert.RootFromState(result.PrevState),
+			CurrRoot:      alert.RootFromState(result.CurrState),
+		}
+
+		if result.PrevState != nil && result.CurrState != nil {
+			a.Message = fmt.Sprintf("consistency proof between tx %d and tx %d does not verify",
+				result.PrevState.TxId, result.CurrState.TxId)
+		}
+
+		proof, err := alert.EncodeProof(result.Proof)
+		if err != nil {
+			return err
+		}
+		a.Proof = proof
+
+		return sink.Send(ctx, a)
+	})
+}
+
+// AuditNotificationConfig holds the URL and credentials used to publish audit
+// result to ledger compliance.
+type AuditNotificationConfig struct {
+	URL            string
+	Username       string
+	Password       string
+	RequestTimeout time.Duration
+
+	PublishFunc func(*http.Request) (*http.Response, error)
+}
+
+// Signature ...
+type Signature struct {
+	Signature string `json:"signature"`
+	PublicKey string `json:"public_key"`
+}
+
+// State ...
+type State struct {
+	Tx        uint64    `json:"tx" validate:"required"`
+	Hash      string    `json:"hash" validate:"required"`
+	Signature Signature `json:"signature" validate:"required"`
+}
+
+// AuditNotificationRequest ...
+type AuditNotificationRequest struct {
+	Username      string    `json:"username" validate:"required"`
+	Password      string    `json:"password" validate:"required"`
+	DB            string    `json:"db" validate:"required"`
+	RunAt         time.Time `json:"run_at" validate:"required" example:"2020-11-13T00:53:42+01:00"`
+	Tampered      bool      `json:"tampered"`
+	PreviousState *State    `json:"previous_state"`
+	CurrentState  *State    `json:"current_state"`
+}
+
+// HTTPNotifier posts audit results as JSON encoded AuditNotificationRequest payloads
+type HTTPNotifier struct {
+	config AuditNotificationConfig
+}
+
+// NewHTTPNotifier returns a notifier posting audit results to config.URL.
+// Requests are sent with an HTTP client honoring config.RequestTimeout unless config.PublishFunc is set
+func NewHTTPNotifier(config AuditNotificationConfig) *HTTPNotifier {
+	if config.PublishFunc == nil {
+		httpClient := &http.Client{Timeout: config.RequestTimeout}
+		config.PublishFunc = httpClient.Do
+	}
+
+	return &HTTPNotifier{config: config}
+}
+
+// Notify publishes the result of an audit
+func (n *HTTPNotifier) Notify(ctx context.Context, result *Result) error {
+	return n.Publish(ctx, result.Database, result.RunAt, result.Tampered, stateFromProto(result.PrevState), stateFromProto(result.CurrState))
+}
+
+func stateFromProto(state *schema.ImmutableState) *State {
+	if state == nil {
+		return nil
+	}
+
+	return &State{
+		Tx:   state.TxId,
+		Hash: base64.StdEncoding.EncodeToString(state.TxHash),
+		Signature: Signature{
+			Signature: base64.StdEncoding.EncodeToString(state.GetSignature().GetSignature()),
+			PublicKey: base64.StdEncoding.EncodeToString(state.GetSignature().GetPublicKey()),
+		},
+	}
+}
+
+// Publish posts an audit notification
+func (n *HTTPNotifier) Publish(
+	ctx context.Context,
+	db string,
+	runAt time.Time,
+	tampered bool,
+	prevState *State,
+	currState *State) error {
+
+	payload := AuditNotificationRequest{
+		Username:      n.config.Username,
+		Password:      n.config.Password,
+		DB:            db,
+		RunAt:         runAt,
+		Tampered:      tampered,
+		PreviousState: prevState,
+		CurrentState:  currState,
+	}
+
+	reqBody, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", n.config.URL, bytes.NewBuffer(reqBody))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.config.PublishFunc(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	payload.Password = ""
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusCreated, http.StatusAccepted, http.StatusNoContent:
+	default:
+		respBody, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf(
+			"POST %s request with payload %+v: "+
+				"got unexpected response status %s with response body %s",
+			n.config.URL, payload,
+			resp.Status, respBody)
+	}
+
+	return nil
+}
@@ -0,0 +1,132 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auditor
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/alert"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPNotifier(t *testing.T) {
+	var received []*AuditNotificationRequest
+	status := http.StatusNoContent
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		var req AuditNotificationRequest
+		err = json.Unmarshal(body, &req)
+		require.NoError(t, err)
+
+		received = append(received, &req)
+
+		w.WriteHeader(status)
+		w.Write([]byte("response body"))
+	}))
+	defer srv.Close()
+
+	n := NewHTTPNotifier(AuditNotificationConfig{
+		URL:            srv.URL,
+		Username:       "some-username",
+		Password:       "some-password",
+		RequestTimeout: time.Second,
+	})
+
+	result := &Result{
+		Database:  "defaultdb",
+		RunAt:     time.Now(),
+		Checked:   true,
+		Tampered:  true,
+		PrevState: &schema.ImmutableState{TxId: 1, TxHash: []byte{1}},
+		CurrState: &schema.ImmutableState{TxId: 2, TxHash: []byte{2}, Signature: &schema.Signature{Signature: []byte{3}, PublicKey: []byte{4}}},
+	}
+
+	err := n.Notify(context.Background(), result)
+	require.NoError(t, err)
+	require.Len(t, received, 1)
+	require.Equal(t, "defaultdb", received[0].DB)
+	require.Equal(t, "some-password", received[0].Password)
+	require.True(t, received[0].Tampered)
+	require.Equal(t, uint64(1), received[0].PreviousState.Tx)
+	require.Equal(t, "AQ==", received[0].PreviousState.Hash)
+	require.Equal(t, uint64(2), received[0].CurrentState.Tx)
+	require.Equal(t, "Aw==", received[0].CurrentState.Signature.Signature)
+	require.Equal(t, "BA==", received[0].CurrentState.Signature.PublicKey)
+
+	status = http.StatusInternalServerError
+
+	err = n.Notify(context.Background(), result)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "got unexpected response status 500 Internal Server Error with response body response body")
+	require.NotContains(t, err.Error(), "some-password")
+}
+
+type alertSinkMock struct {
+	alerts []*alert.Alert
+}
+
+func (s *alertSinkMock) Send(ctx context.Context, a *alert.Alert) error {
+	s.alerts = append(s.alerts, a)
+	return nil
+}
+
+func TestAlertNotifier(t *testing.T) {
+	sink := &alertSinkMock{}
+	n := NewAlertNotifier(sink)
+
+	result := &Result{
+		ServerID:      "server1",
+		ServerAddress: "127.0.0.1:3322",
+		Database:      "defaultdb",
+		RunAt:         time.Now(),
+		Checked:       true,
+		PrevState:     &schema.ImmutableState{TxId: 1, TxHash: []byte{1}},
+		CurrState:     &schema.ImmutableState{TxId: 2, TxHash: []byte{2}},
+		Proof:         &schema.DualProof{SourceTxHeader: &schema.TxHeader{Id: 1}, TargetTxHeader: &schema.TxHeader{Id: 2}},
+	}
+
+	// consistent states are not alerted
+	err := n.Notify(context.Background(), result)
+	require.NoError(t, err)
+	require.Empty(t, sink.alerts)
+
+	result.Tampered = true
+
+	err = n.Notify(context.Background(), result)
+	require.NoError(t, err)
+	require.Len(t, sink.alerts, 1)
+
+	a := sink.alerts[0]
+	require.Equal(t, alert.DetectorAuditor, a.Detector)
+	require.Equal(t, "server1", a.ServerID)
+	require.Equal(t, "127.0.0.1:3322", a.ServerAddress)
+	require.Equal(t, "defaultdb", a.Database)
+	require.Equal(t, "consistency proof between tx 1 and tx 2 does not verify", a.Message)
+	require.Equal(t, &alert.Root{TxID: 1, Hash: "01"}, a.PrevRoot)
+	require.Equal(t, &alert.Root{TxID: 2, Hash: "02"}, a.CurrRoot)
+	require.NotEmpty(t, a.Proof)
+}
@@ -0,0 +1,143 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auditor
+
+import (
+	"crypto/ecdsa"
+	"os"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/client/state"
+	"github.com/codenotary/immudb/pkg/logger"
+)
+
+const DefaultInterval = 5 * time.Minute
+
+// Options of an auditor
+type Options struct {
+	serverAddress string
+
+	username string
+	password string
+
+	databases []string
+
+	serverSigningPubKey *ecdsa.PublicKey
+
+	interval time.Duration
+
+	stateStore   StateStore
+	uuidProvider state.UUIDProvider
+	notifiers    []Notifier
+
+	onResult func(result *Result)
+	onTamper func(result *Result)
+
+	logger logger.Logger
+}
+
+// DefaultOptions returns the default auditor options, audited states are kept in memory
+func DefaultOptions() *Options {
+	return &Options{
+		interval:   DefaultInterval,
+		stateStore: NewInMemoryStateStore(),
+		logger:     logger.NewSimpleLogger("immudb auditor ", os.Stderr),
+	}
+}
+
+func (opts *Options) Valid() bool {
+	return opts != nil &&
+		opts.username != "" &&
+		opts.interval > 0 &&
+		opts.stateStore != nil &&
+		opts.logger != nil
+}
+
+// WithServerAddress sets the address of the audited server, used to identify it when its uuid is not available
+func (o *Options) WithServerAddress(serverAddress string) *Options {
+	o.serverAddress = serverAddress
+	return o
+}
+
+// WithUsername sets the username used to audit the server
+func (o *Options) WithUsername(username string) *Options {
+	o.username = username
+	return o
+}
+
+// WithPassword sets the password used to audit the server
+func (o *Options) WithPassword(password string) *Options {
+	o.password = password
+	return o
+}
+
+// WithDatabases sets the prefixes of the names of the audited databases, all the databases
+// the user has access to are audited when empty
+func (o *Options) WithDatabases(databases []string) *Options {
+	o.databases = databases
+	return o
+}
+
+// WithServerSigningPubKey sets the public key used to verify the signature of the states returned by the server.
+// When not set, signed states are verified using the untrusted public key included in the state
+func (o *Options) WithServerSigningPubKey(serverSigningPubKey *ecdsa.PublicKey) *Options {
+	o.serverSigningPubKey = serverSigningPubKey
+	return o
+}
+
+// WithInterval sets the time between two consecutive audits, databases are audited in turn
+func (o *Options) WithInterval(interval time.Duration) *Options {
+	o.interval = interval
+	return o
+}
+
+// WithStateStore sets the storage of the last verified state of each audited database
+func (o *Options) WithStateStore(stateStore StateStore) *Options {
+	o.stateStore = stateStore
+	return o
+}
+
+// WithUUIDProvider sets how the audited server is identified, the uuid returned by the server is used by default
+func (o *Options) WithUUIDProvider(uuidProvider state.UUIDProvider) *Options {
+	o.uuidProvider = uuidProvider
+	return o
+}
+
+// WithNotifiers sets the sinks the result of every audit checking the consistency of a database is published to
+func (o *Options) WithNotifiers(notifiers ...Notifier) *Options {
+	o.notifiers = notifiers
+	return o
+}
+
+// WithOnResult sets the function called with the result of every audit
+func (o *Options) WithOnResult(onResult func(result *Result)) *Options {
+	o.onResult = onResult
+	return o
+}
+
+// WithOnTamper sets the function called when the current state of a database is not consistent
+// with the previously verified one
+func (o *Options) WithOnTamper(onTamper func(result *Result)) *Options {
+	o.onTamper = onTamper
+	return o
+}
+
+// WithLogger sets the logger of the auditor
+func (o *Options) WithLogger(logger logger.Logger) *Options {
+	o.logger = logger
+	return o
+}
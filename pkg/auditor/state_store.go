@@ -0,0 +1,59 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auditor
+
+import (
+	"sync"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+)
+
+// StateStore keeps the last verified state of each audited database.
+// Get must return a nil state when no state was stored yet for the database.
+// The history file cache of the client cache package can be used as a persistent state store.
+type StateStore interface {
+	Get(serverID string, db string) (*schema.ImmutableState, error)
+	Set(serverID string, db string, state *schema.ImmutableState) error
+}
+
+type inMemoryStateStore struct {
+	states map[string]*schema.ImmutableState
+	mutex  sync.RWMutex
+}
+
+// NewInMemoryStateStore returns a state store whose content is lost when the process ends
+func NewInMemoryStateStore() StateStore {
+	return &inMemoryStateStore{
+		states: make(map[string]*schema.ImmutableState),
+	}
+}
+
+func (s *inMemoryStateStore) Get(serverID string, db string) (*schema.ImmutableState, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.states[serverID+"/"+db], nil
+}
+
+func (s *inMemoryStateStore) Set(serverID string, db string, state *schema.ImmutableState) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.states[serverID+"/"+db] = state
+
+	return nil
+}
@@ -22,6 +22,7 @@ var maintenanceMethods = map[string]struct{}{
 	"StreamGet":           {},
 	"StreamVerifiableGet": {},
 	"GetAll":              {},
+	"ResolveAll":          {},
 	"ZScan":               {},
 	"StreamZScan":         {},
 	"VerifiableTxByID":    {},
@@ -49,8 +50,63 @@ var maintenanceMethods = map[string]struct{}{
 	"Dump":         {},
 	"FlushIndex":   {},
 	"CompactIndex": {},
+
+	// background jobs
+	"CompactIndexAsync": {},
+	"ScrubAsync":        {},
+	"ScrubStatus":       {},
+	"ListJobs":          {},
+	"GetJob":            {},
+	"CancelJob":         {},
+}
+
+// anonymousReadMethods can be called without logging in on databases allowing anonymous reads
+var anonymousReadMethods = map[string]struct{}{
+	"Get":                 {},
+	"VerifiableGet":       {},
+	"StreamGet":           {},
+	"StreamVerifiableGet": {},
+	"ResolveAll":          {},
+	"ZScan":               {},
+	"StreamZScan":         {},
+	"VerifiableTxByID":    {},
+	"Scan":                {},
+	"StreamScan":          {},
+	"History":             {},
+	"StreamHistory":       {},
+	"TxByID":              {},
+	"TxScan":              {},
+	"Count":               {},
+	"CountAll":            {},
+	"CurrentState":        {},
+	"SQLQuery":            {},
+	"ListTables":          {},
+	"DescribeTable":       {},
+	"VerifiableSQLGet":    {},
+}
+
+// writeMethods store data into the database they are called on
+var writeMethods = map[string]struct{}{
+	"Set":                    {},
+	"Delete":                 {},
+	"VerifiableSet":          {},
+	"StreamSet":              {},
+	"StreamVerifiableSet":    {},
+	"ExecAll":                {},
+	"StreamExecAll":          {},
+	"ExecScript":             {},
+	"SetReference":           {},
+	"VerifiableSetReference": {},
+	"Rename":                 {},
+	"ZAdd":                   {},
+	"VerifiableZAdd":         {},
+	"SQLExec":                {},
+	"ReplicateTx":            {},
 }
 
+// DatabaseHeader is the request metadata naming the database read by requests made without logging in
+const DatabaseHeader = "database"
+
 // PermissionSysAdmin the admin permission byte
 const PermissionSysAdmin = 255
 
@@ -62,6 +118,8 @@ const (
 	PermissionNone = iota
 	PermissionR
 	PermissionRW
+	// PermissionRestrictedR allows reading the values of a database redacted according to its redaction rules
+	PermissionRestrictedR
 )
 
 var methodsPermissions = map[string][]uint32{
@@ -71,39 +129,51 @@ var methodsPermissions = map[string][]uint32{
 	"VerifiableSet":          {PermissionSysAdmin, PermissionAdmin, PermissionRW},
 	"StreamSet":              {PermissionSysAdmin, PermissionAdmin, PermissionRW},
 	"StreamVerifiableSet":    {PermissionSysAdmin, PermissionAdmin, PermissionRW},
-	"Get":                    {PermissionSysAdmin, PermissionAdmin, PermissionRW, PermissionR},
+	"Get":                    {PermissionSysAdmin, PermissionAdmin, PermissionRW, PermissionR, PermissionRestrictedR},
 	"VerifiableGet":          {PermissionSysAdmin, PermissionAdmin, PermissionRW, PermissionR},
 	"StreamGet":              {PermissionSysAdmin, PermissionAdmin, PermissionRW, PermissionR},
 	"StreamVerifiableGet":    {PermissionSysAdmin, PermissionAdmin, PermissionRW, PermissionR},
 	"GetAll":                 {PermissionSysAdmin, PermissionAdmin, PermissionRW},
 	"ExecAll":                {PermissionSysAdmin, PermissionAdmin, PermissionRW},
 	"StreamExecAll":          {PermissionSysAdmin, PermissionAdmin, PermissionRW},
+	"ExecScript":             {PermissionSysAdmin, PermissionAdmin, PermissionRW},
 	"SetReference":           {PermissionSysAdmin, PermissionAdmin, PermissionRW},
 	"VerifiableSetReference": {PermissionSysAdmin, PermissionAdmin, PermissionRW},
+	"ResolveAll":             {PermissionSysAdmin, PermissionAdmin, PermissionRW, PermissionR, PermissionRestrictedR},
+	"Rename":                 {PermissionSysAdmin, PermissionAdmin, PermissionRW},
 	"ZAdd":                   {PermissionSysAdmin, PermissionAdmin, PermissionRW},
 	"VerifiableZAdd":         {PermissionSysAdmin, PermissionAdmin, PermissionRW},
-	"ZScan":                  {PermissionSysAdmin, PermissionAdmin, PermissionRW, PermissionR},
+	"ZScan":                  {PermissionSysAdmin, PermissionAdmin, PermissionRW, PermissionR, PermissionRestrictedR},
 	"StreamZScan":            {PermissionSysAdmin, PermissionAdmin, PermissionRW, PermissionR},
 	"VerifiableTxByID":       {PermissionSysAdmin, PermissionAdmin, PermissionRW, PermissionR},
 	"IScan":                  {PermissionSysAdmin, PermissionAdmin, PermissionRW, PermissionR},
-	"Scan":                   {PermissionSysAdmin, PermissionAdmin, PermissionRW, PermissionR},
+	"Scan":                   {PermissionSysAdmin, PermissionAdmin, PermissionRW, PermissionR, PermissionRestrictedR},
 	"StreamScan":             {PermissionSysAdmin, PermissionAdmin, PermissionRW, PermissionR},
-	"History":                {PermissionSysAdmin, PermissionAdmin, PermissionRW, PermissionR},
+	"History":                {PermissionSysAdmin, PermissionAdmin, PermissionRW, PermissionR, PermissionRestrictedR},
 	"StreamHistory":          {PermissionSysAdmin, PermissionAdmin, PermissionRW, PermissionR},
 	"TxByID":                 {PermissionSysAdmin, PermissionAdmin, PermissionRW, PermissionR},
 	"TxScan":                 {PermissionSysAdmin, PermissionAdmin, PermissionRW, PermissionR},
-	"Count":                  {PermissionSysAdmin, PermissionAdmin, PermissionRW, PermissionR},
-	"CountAll":               {PermissionSysAdmin, PermissionAdmin, PermissionRW, PermissionR},
-	"DatabaseList":           {PermissionSysAdmin, PermissionAdmin, PermissionRW, PermissionR},
-	"CurrentState":           {PermissionSysAdmin, PermissionAdmin, PermissionRW, PermissionR},
-	"DatabaseHealth":         {PermissionSysAdmin, PermissionAdmin, PermissionRW, PermissionR},
-	"DatabaseSettings":       {PermissionSysAdmin, PermissionAdmin, PermissionRW, PermissionR},
+	"Count":                  {PermissionSysAdmin, PermissionAdmin, PermissionRW, PermissionR, PermissionRestrictedR},
+	"CountAll":               {PermissionSysAdmin, PermissionAdmin, PermissionRW, PermissionR, PermissionRestrictedR},
+	"DatabaseList":           {PermissionSysAdmin, PermissionAdmin, PermissionRW, PermissionR, PermissionRestrictedR},
+	"CurrentState":           {PermissionSysAdmin, PermissionAdmin, PermissionRW, PermissionR, PermissionRestrictedR},
+	"DatabaseHealth":         {PermissionSysAdmin, PermissionAdmin, PermissionRW, PermissionR, PermissionRestrictedR},
+	"DatabaseSettings":       {PermissionSysAdmin, PermissionAdmin, PermissionRW, PermissionR, PermissionRestrictedR},
 	"SQLExec":                {PermissionSysAdmin, PermissionAdmin, PermissionRW},
-	"UseSnapshot":            {PermissionSysAdmin, PermissionAdmin, PermissionRW, PermissionR},
-	"SQLQuery":               {PermissionSysAdmin, PermissionAdmin, PermissionRW, PermissionR},
-	"ListTables":             {PermissionSysAdmin, PermissionAdmin, PermissionRW, PermissionR},
-	"DescribeTable":          {PermissionSysAdmin, PermissionAdmin, PermissionRW, PermissionR},
+	"UseSnapshot":            {PermissionSysAdmin, PermissionAdmin, PermissionRW, PermissionR, PermissionRestrictedR},
+	"SQLQuery":               {PermissionSysAdmin, PermissionAdmin, PermissionRW, PermissionR, PermissionRestrictedR},
+	"ListTables":             {PermissionSysAdmin, PermissionAdmin, PermissionRW, PermissionR, PermissionRestrictedR},
+	"DescribeTable":          {PermissionSysAdmin, PermissionAdmin, PermissionRW, PermissionR, PermissionRestrictedR},
 	"VerifiableSQLGet":       {PermissionSysAdmin, PermissionAdmin, PermissionRW, PermissionR},
+	"RegisterExternalRoot":   {PermissionSysAdmin, PermissionAdmin, PermissionRW},
+	"ExternalRoots":          {PermissionSysAdmin, PermissionAdmin, PermissionRW, PermissionR, PermissionRestrictedR},
+	"MultiDatabaseSet":       {PermissionSysAdmin, PermissionAdmin, PermissionRW},
+	"SetKVSchema":            {PermissionSysAdmin, PermissionAdmin},
+	"DeleteKVSchema":         {PermissionSysAdmin, PermissionAdmin},
+	"KVSchemas":              {PermissionSysAdmin, PermissionAdmin, PermissionRW, PermissionR, PermissionRestrictedR},
+	"SetRedactionRule":       {PermissionSysAdmin, PermissionAdmin},
+	"DeleteRedactionRule":    {PermissionSysAdmin, PermissionAdmin},
+	"RedactionRules":         {PermissionSysAdmin, PermissionAdmin, PermissionRW, PermissionR, PermissionRestrictedR},
 
 	// admin methods
 	"ListUsers":        {PermissionSysAdmin, PermissionAdmin},
@@ -116,6 +186,7 @@ var methodsPermissions = map[string][]uint32{
 	"UpdateMTLSConfig": {PermissionSysAdmin},
 	"CreateDatabase":   {PermissionSysAdmin},
 	"CreateDatabaseV2": {PermissionSysAdmin},
+	"CloneDatabase":    {PermissionSysAdmin},
 	"UpdateDatabase":   {PermissionSysAdmin},
 	"UpdateDatabaseV2": {PermissionSysAdmin},
 	"Dump":             {PermissionSysAdmin, PermissionAdmin},
@@ -123,6 +194,27 @@ var methodsPermissions = map[string][]uint32{
 	"CompactIndex":     {PermissionSysAdmin, PermissionAdmin},
 	"ExportTx":         {PermissionSysAdmin, PermissionAdmin},
 	"ReplicateTx":      {PermissionSysAdmin, PermissionAdmin},
+
+	// replication management methods, permission is checked against the requested database
+	"ReplicationStatus": {PermissionSysAdmin, PermissionAdmin},
+	"PauseReplication":  {PermissionSysAdmin, PermissionAdmin},
+	"ResumeReplication": {PermissionSysAdmin, PermissionAdmin},
+	"ResetReplication":  {PermissionSysAdmin, PermissionAdmin},
+
+	// database settings history, permission is checked against the requested database
+	"GetDatabaseSettingsHistory": {PermissionSysAdmin, PermissionAdmin},
+	"RollbackDatabaseSettings":   {PermissionSysAdmin, PermissionAdmin},
+
+	// keyspace statistics, permission is checked against the requested database
+	"KeyspaceStats": {PermissionSysAdmin, PermissionAdmin},
+
+	// background jobs
+	"CompactIndexAsync": {PermissionSysAdmin, PermissionAdmin},
+	"ScrubAsync":        {PermissionSysAdmin, PermissionAdmin},
+	"ScrubStatus":       {PermissionSysAdmin, PermissionAdmin},
+	"ListJobs":          {PermissionSysAdmin, PermissionAdmin},
+	"GetJob":            {PermissionSysAdmin, PermissionAdmin},
+	"CancelJob":         {PermissionSysAdmin, PermissionAdmin},
 }
 
 //HasPermissionForMethod checks if userPermission can access method name
@@ -143,3 +235,15 @@ func IsMaintenanceMethod(method string) bool {
 	_, maintenanceMethod := maintenanceMethods[method]
 	return maintenanceMethod
 }
+
+// IsAnonymousReadMethod checks if method can be called without logging in on databases allowing anonymous reads
+func IsAnonymousReadMethod(method string) bool {
+	_, anonymousReadMethod := anonymousReadMethods[method]
+	return anonymousReadMethod
+}
+
+// IsWriteMethod checks if method stores data into the database it's called on
+func IsWriteMethod(method string) bool {
+	_, writeMethod := writeMethods[method]
+	return writeMethod
+}
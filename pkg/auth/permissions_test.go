@@ -37,4 +37,26 @@ func TestHasPermissionForMethod(t *testing.T) {
 	if HasPermissionForMethod(PermissionNone, "CountAll") {
 		t.Errorf("expected PermissionNone to be insufficient for CountAll")
 	}
+
+	if !HasPermissionForMethod(PermissionRestrictedR, "SQLQuery") {
+		t.Errorf("expected PermissionRestrictedR to be sufficient for SQLQuery")
+	}
+	if HasPermissionForMethod(PermissionRestrictedR, "VerifiableGet") {
+		t.Errorf("expected PermissionRestrictedR to be insufficient for VerifiableGet")
+	}
+}
+
+func TestIsAnonymousReadMethod(t *testing.T) {
+	for method := range anonymousReadMethods {
+		if !HasPermissionForMethod(PermissionR, method) {
+			t.Errorf("expected anonymous read method %s to require only PermissionR", method)
+		}
+	}
+
+	if !IsAnonymousReadMethod("Get") {
+		t.Errorf("expected Get to be allowed for anonymous reads")
+	}
+	if IsAnonymousReadMethod("Set") {
+		t.Errorf("expected Set not to be allowed for anonymous reads")
+	}
 }
@@ -82,6 +82,10 @@ func isLocalClient(ctx context.Context) bool {
 	isLocal := false
 	p, ok := peer.FromContext(ctx)
 	if ok && p != nil {
+		// clients connected through a unix socket are running on the same host
+		if p.Addr.Network() == "unix" {
+			return true
+		}
 		ipAndPort := strings.Split(p.Addr.String(), ":")
 		if len(ipAndPort) > 0 {
 			_, isLocal = localAddress[ipAndPort[0]]
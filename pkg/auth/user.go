@@ -37,6 +37,7 @@ type User struct {
 	IsSysAdmin     bool         `json:"-"`         //for the sysadmin we'll use this instead of adding all db and permissions to Permissions, to save some cpu cycles
 	CreatedBy      string       `json:"createdBy"` //user which created this user
 	CreatedAt      time.Time    `json:"createdat"` //time in which this user is created/updated
+	Tenant         string       `json:"tenant"`    //tenant owning the user, empty when the user is not owned by any tenant
 }
 
 // SysAdminUsername the system admin username
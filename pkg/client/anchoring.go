@@ -0,0 +1,142 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/client/errors"
+	"github.com/codenotary/immudb/pkg/signer"
+	"github.com/golang/protobuf/ptypes/empty"
+)
+
+// ExportRoot returns the current state of the database signed by the server, encoded in the compact
+// format described by schema.ImmutableState.ExportRoot so it can be anchored into an external system.
+// The server must be started with a signing key.
+func (c *immuClient) ExportRoot(ctx context.Context) ([]byte, error) {
+	state, err := c.CurrentState(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.serverSigningPubKey != nil {
+		err = checkStateSignature(state, c.serverSigningPubKey)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return state.ExportRoot()
+}
+
+// VerifyRootConsistency checks that a previously exported root is consistent with the current state of
+// the database, proving the history up to the exported root was not altered since it was anchored.
+// The exported root is checked against the server signing public key when provided,
+// otherwise it must be signed with the same key as the current state.
+// The current state, proven to be consistent with the exported root, is returned.
+func (c *immuClient) VerifyRootConsistency(ctx context.Context, exportedRoot []byte) (*schema.ImmutableState, error) {
+	root, err := schema.ImportRoot(exportedRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	key := c.serverSigningPubKey
+	if key == nil {
+		key, err = signer.UnmarshalKey(root.Signature.PublicKey)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	_, err = schema.VerifyExportedRoot(exportedRoot, key)
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := c.CurrentState(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if state.Db != root.Db {
+		return nil, fmt.Errorf("%w: root was exported from database '%s'", ErrIllegalArguments, root.Db)
+	}
+
+	err = checkStateSignature(state, key)
+	if err != nil {
+		return nil, err
+	}
+
+	if state.TxId < root.TxId {
+		return nil, errors.NewCorruptedStateError(fmt.Errorf("%w: current state at tx %d precedes exported root at tx %d", store.ErrCorruptedData, state.TxId, root.TxId))
+	}
+
+	if state.TxId == root.TxId {
+		if !bytes.Equal(state.TxHash, root.TxHash) {
+			return nil, errors.NewCorruptedStateError(fmt.Errorf("%w: hash mismatch at tx %d", store.ErrCorruptedData, root.TxId))
+		}
+		return state, nil
+	}
+
+	vTx, err := c.ServiceClient.VerifiableTxById(ctx, &schema.VerifiableTxRequest{
+		Tx:           state.TxId,
+		ProveSinceTx: root.TxId,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	verifies := store.VerifyDualProof(
+		schema.DualProofFromProto(vTx.DualProof),
+		root.TxId,
+		state.TxId,
+		schema.DigestFromProto(root.TxHash),
+		schema.DigestFromProto(state.TxHash),
+	)
+	if !verifies {
+		return nil, errors.NewCorruptedStateError(fmt.Errorf("%w: consistency proof between tx %d and tx %d does not verify", store.ErrCorruptedData, root.TxId, state.TxId))
+	}
+
+	return state, nil
+}
+
+// GetServerKeys returns the public keys used by the server to sign states, the current one first.
+// Keys retired after a rotation are included so states signed before the rotation can still be
+// verified by selecting the key matching the key id of their signature.
+func (c *immuClient) GetServerKeys(ctx context.Context) (*schema.ServerKeys, error) {
+	if !c.IsConnected() {
+		return nil, errors.FromError(ErrNotConnected)
+	}
+
+	return c.ServiceClient.GetServerKeys(ctx, &empty.Empty{})
+}
+
+func checkStateSignature(state *schema.ImmutableState, key *ecdsa.PublicKey) error {
+	ok, err := state.CheckSignature(key)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.NewCorruptedStateError(store.ErrCorruptedData)
+	}
+	return nil
+}
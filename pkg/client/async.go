@@ -0,0 +1,231 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"sync"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/client/errors"
+)
+
+// TxFuture holds the outcome of an async write, available once the write is committed or fails
+type TxFuture struct {
+	done chan struct{}
+
+	hdr *schema.TxHeader
+	err error
+}
+
+func newTxFuture() *TxFuture {
+	return &TxFuture{done: make(chan struct{})}
+}
+
+func (f *TxFuture) complete(hdr *schema.TxHeader, err error) {
+	f.hdr = hdr
+	f.err = err
+	close(f.done)
+}
+
+// Done returns a channel closed once the write has been completed
+func (f *TxFuture) Done() <-chan struct{} {
+	return f.done
+}
+
+// Wait blocks until the write is completed, returning the header of the transaction the write was committed in
+func (f *TxFuture) Wait(ctx context.Context) (*schema.TxHeader, error) {
+	select {
+	case <-f.done:
+		return f.hdr, f.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// asyncWrite is a write waiting to be sent by one of the workers of the asyncWriter
+type asyncWrite struct {
+	write func() (*schema.TxHeader, error)
+	f     *TxFuture
+}
+
+// asyncWriter keeps track of the writes sent without waiting for their commit.
+// Writes are queued and sent by up to maxWorkers goroutines, which are started on demand
+// and exit once there are no more queued writes
+type asyncWriter struct {
+	queue      chan *asyncWrite
+	maxWorkers int
+
+	mutex   sync.Mutex
+	workers int
+	pending int           // writes submitted but not yet completed
+	idle    chan struct{} // closed once there are no pending writes, if someone is flushing
+	err     error         // first error since the last flush
+}
+
+func newAsyncWriter(maxPendingWrites int) *asyncWriter {
+	if maxPendingWrites < 1 {
+		maxPendingWrites = 1
+	}
+
+	return &asyncWriter{
+		queue:      make(chan *asyncWrite, maxPendingWrites),
+		maxWorkers: maxPendingWrites,
+	}
+}
+
+func (w *asyncWriter) submit(ctx context.Context, write func() (*schema.TxHeader, error)) (*TxFuture, error) {
+	aw := &asyncWrite{
+		write: write,
+		f:     newTxFuture(),
+	}
+
+	// the write is accounted before being queued, so that a concurrent flush waits for it
+	w.mutex.Lock()
+	w.pending++
+	w.mutex.Unlock()
+
+	select {
+	case w.queue <- aw:
+	case <-ctx.Done():
+		w.mutex.Lock()
+		w.completed(nil)
+		w.mutex.Unlock()
+
+		return nil, ctx.Err()
+	}
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.workers < w.maxWorkers {
+		w.workers++
+		go w.work()
+	}
+
+	return aw.f, nil
+}
+
+func (w *asyncWriter) work() {
+	for {
+		w.mutex.Lock()
+
+		var aw *asyncWrite
+
+		select {
+		case aw = <-w.queue:
+		default:
+			// writes queued afterwards start a new worker
+			w.workers--
+			w.mutex.Unlock()
+			return
+		}
+
+		w.mutex.Unlock()
+
+		hdr, err := aw.write()
+
+		// the future is completed before the write stops being pending,
+		// so that it's already completed once flush returns
+		aw.f.complete(hdr, err)
+
+		w.mutex.Lock()
+		w.completed(err)
+		w.mutex.Unlock()
+	}
+}
+
+// completed must be called with the mutex held
+func (w *asyncWriter) completed(err error) {
+	w.pending--
+
+	if err != nil && w.err == nil {
+		w.err = err
+	}
+
+	if w.pending == 0 && w.idle != nil {
+		close(w.idle)
+		w.idle = nil
+	}
+}
+
+func (w *asyncWriter) flush(ctx context.Context) error {
+	w.mutex.Lock()
+
+	if w.pending > 0 {
+		if w.idle == nil {
+			w.idle = make(chan struct{})
+		}
+
+		idle := w.idle
+
+		w.mutex.Unlock()
+
+		select {
+		case <-idle:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		w.mutex.Lock()
+	}
+
+	defer w.mutex.Unlock()
+
+	err := w.err
+	w.err = nil
+
+	return err
+}
+
+func (c *immuClient) getAsyncWriter() *asyncWriter {
+	c.asyncWriterMutex.Lock()
+	defer c.asyncWriterMutex.Unlock()
+
+	if c.asyncWriter == nil {
+		c.asyncWriter = newAsyncWriter(c.Options.MaxPendingAsyncWrites)
+	}
+
+	return c.asyncWriter
+}
+
+// AsyncSet sends a write without waiting for it to be committed, returning a future for its transaction.
+// Up to Options.MaxPendingAsyncWrites writes are sent concurrently over the connection, so the commit order of
+// concurrent writes is not guaranteed. The provided context must remain valid until the write completes.
+func (c *immuClient) AsyncSet(ctx context.Context, key []byte, value []byte) (*TxFuture, error) {
+	if !c.IsConnected() {
+		return nil, errors.FromError(ErrNotConnected)
+	}
+
+	// the caller may reuse its slices as soon as the write is submitted
+	k := make([]byte, len(key))
+	copy(k, key)
+
+	v := make([]byte, len(value))
+	copy(v, value)
+
+	return c.getAsyncWriter().submit(ctx, func() (*schema.TxHeader, error) {
+		hdr, err := c.set(ctx, k, nil, v)
+		return hdr, errors.FromError(err)
+	})
+}
+
+// Flush waits until all the pending async writes are completed,
+// returning the first error found among the writes sent since the previous flush
+func (c *immuClient) Flush(ctx context.Context) error {
+	return c.getAsyncWriter().flush(ctx)
+}
@@ -0,0 +1,133 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAsyncWriter(t *testing.T) {
+	maxWorkers := 4
+
+	w := newAsyncWriter(maxWorkers)
+
+	var inFlight, maxInFlight int32
+	var txID uint64
+
+	write := func() (*schema.TxHeader, error) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+
+		for {
+			max := atomic.LoadInt32(&maxInFlight)
+			if n <= max || atomic.CompareAndSwapInt32(&maxInFlight, max, n) {
+				break
+			}
+		}
+
+		return &schema.TxHeader{Id: atomic.AddUint64(&txID, 1)}, nil
+	}
+
+	// writes and flushes from different goroutines
+	var wg sync.WaitGroup
+
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for i := 0; i < 100; i++ {
+				f, err := w.submit(context.Background(), write)
+				require.NoError(t, err)
+
+				if i%10 == 0 {
+					err = w.flush(context.Background())
+					require.NoError(t, err)
+
+					select {
+					case <-f.Done():
+					default:
+						require.Fail(t, "async write must be completed after flush")
+					}
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	err := w.flush(context.Background())
+	require.NoError(t, err)
+
+	require.Equal(t, uint64(800), atomic.LoadUint64(&txID))
+	require.LessOrEqual(t, atomic.LoadInt32(&maxInFlight), int32(maxWorkers))
+
+	t.Run("the first error is returned by the next flush", func(t *testing.T) {
+		errWrite := errors.New("write failed")
+
+		f, err := w.submit(context.Background(), func() (*schema.TxHeader, error) {
+			return nil, errWrite
+		})
+		require.NoError(t, err)
+
+		_, err = f.Wait(context.Background())
+		require.ErrorIs(t, err, errWrite)
+
+		err = w.flush(context.Background())
+		require.ErrorIs(t, err, errWrite)
+
+		err = w.flush(context.Background())
+		require.NoError(t, err)
+	})
+
+	t.Run("flush and submit honour the context", func(t *testing.T) {
+		release := make(chan struct{})
+
+		blocked := func() (*schema.TxHeader, error) {
+			<-release
+			return &schema.TxHeader{}, nil
+		}
+
+		for i := 0; i < 2*maxWorkers; i++ {
+			_, err := w.submit(context.Background(), blocked)
+			require.NoError(t, err)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err := w.flush(ctx)
+		require.ErrorIs(t, err, context.Canceled)
+
+		// the queue is full, the write can not be submitted
+		_, err = w.submit(ctx, blocked)
+		require.ErrorIs(t, err, context.Canceled)
+
+		close(release)
+
+		err = w.flush(context.Background())
+		require.NoError(t, err)
+	})
+}
@@ -26,6 +26,7 @@ import (
 	"io"
 	"io/ioutil"
 	"os"
+	"sync"
 	"time"
 
 	"github.com/codenotary/immudb/pkg/client/heartbeater"
@@ -61,6 +62,7 @@ type ImmuClient interface {
 	// Deprecated: grpc retry mechanism can be implemented with WithConnectParams dialOption
 	WaitForHealthCheck(ctx context.Context) (err error)
 	HealthCheck(ctx context.Context) error
+	ServerInfo(ctx context.Context) (*schema.ServerInfoResponse, error)
 	// Deprecated: connection is handled in OpenSession
 	Connect(ctx context.Context) (clientConn *grpc.ClientConn, err error)
 
@@ -99,16 +101,27 @@ type ImmuClient interface {
 	DatabaseList(ctx context.Context) (*schema.DatabaseListResponse, error)
 	CreateDatabase(ctx context.Context, d *schema.DatabaseSettings) error
 	CreateDatabaseV2(ctx context.Context, d *schema.DatabaseSettingsV2) (*schema.DatabaseSettingsV2, error)
+	CloneDatabase(ctx context.Context, req *schema.CloneDatabaseRequest) (*schema.CloneDatabaseResponse, error)
 	UseDatabase(ctx context.Context, d *schema.Database) (*schema.UseDatabaseReply, error)
 	UpdateDatabase(ctx context.Context, settings *schema.DatabaseSettings) error
 	UpdateDatabaseV2(ctx context.Context, settings *schema.DatabaseSettingsV2) (*schema.DatabaseSettingsUpdateResult, error)
 	GetDatabaseSettings(ctx context.Context) (*schema.DatabaseSettings, error)
 	GetDatabaseSettingsV2(ctx context.Context) (*schema.DatabaseSettingsV2, error)
+	GetDatabaseSettingsHistory(ctx context.Context, req *schema.DatabaseSettingsHistoryRequest) (*schema.DatabaseSettingsHistory, error)
+	RollbackDatabaseSettings(ctx context.Context, database string, version uint64) (*schema.DatabaseSettingsUpdateResult, error)
 
 	SetActiveUser(ctx context.Context, u *schema.SetActiveUserRequest) error
 
 	FlushIndex(ctx context.Context, cleanupPercentage float32, synced bool) error
 	CompactIndex(ctx context.Context, req *empty.Empty) error
+	CompactIndexAsync(ctx context.Context) (*schema.Job, error)
+	ScrubAsync(ctx context.Context) (*schema.Job, error)
+	ScrubStatus(ctx context.Context) (*schema.ScrubStatusResponse, error)
+
+	ListJobs(ctx context.Context, kind string) (*schema.JobList, error)
+	GetJob(ctx context.Context, jobID string) (*schema.Job, error)
+	CancelJob(ctx context.Context, jobID string) (*schema.Job, error)
+	WaitForJob(ctx context.Context, jobID string, pollInterval time.Duration, onProgress func(job *schema.Job)) (*schema.Job, error)
 
 	Health(ctx context.Context) (*schema.DatabaseHealthResponse, error)
 	CurrentState(ctx context.Context) (*schema.ImmutableState, error)
@@ -117,6 +130,7 @@ type ImmuClient interface {
 	VerifiedSet(ctx context.Context, key []byte, value []byte) (*schema.TxHeader, error)
 
 	ExpirableSet(ctx context.Context, key []byte, value []byte, expiresAt time.Time) (*schema.TxHeader, error)
+	SetWithMetadata(ctx context.Context, key []byte, value []byte, md *schema.KVMetadata) (*schema.TxHeader, error)
 
 	Get(ctx context.Context, key []byte) (*schema.Entry, error)
 	GetSince(ctx context.Context, key []byte, tx uint64) (*schema.Entry, error)
@@ -148,11 +162,17 @@ type ImmuClient interface {
 	CountAll(ctx context.Context) (*schema.EntryCount, error)
 
 	SetAll(ctx context.Context, kvList *schema.SetRequest) (*schema.TxHeader, error)
+
+	AsyncSet(ctx context.Context, key []byte, value []byte) (*TxFuture, error)
+	Flush(ctx context.Context) error
+
 	GetAll(ctx context.Context, keys [][]byte) (*schema.Entries, error)
+	GetAllWithStatuses(ctx context.Context, keys [][]byte) ([]*schema.KeyResult, error)
 
 	Delete(ctx context.Context, req *schema.DeleteKeysRequest) (*schema.TxHeader, error)
 
 	ExecAll(ctx context.Context, in *schema.ExecAllRequest) (*schema.TxHeader, error)
+	ExecScript(ctx context.Context, script string, params map[string]interface{}) (*schema.ExecScriptResult, error)
 
 	SetReference(ctx context.Context, key []byte, referencedKey []byte) (*schema.TxHeader, error)
 	VerifiedSetReference(ctx context.Context, key []byte, referencedKey []byte) (*schema.TxHeader, error)
@@ -160,6 +180,10 @@ type ImmuClient interface {
 	SetReferenceAt(ctx context.Context, key []byte, referencedKey []byte, atTx uint64) (*schema.TxHeader, error)
 	VerifiedSetReferenceAt(ctx context.Context, key []byte, referencedKey []byte, atTx uint64) (*schema.TxHeader, error)
 
+	ExpirableSetReference(ctx context.Context, key []byte, referencedKey []byte, expiresAt time.Time) (*schema.TxHeader, error)
+	ResolveAll(ctx context.Context, key []byte) (*schema.Entries, error)
+	Rename(ctx context.Context, oldKey []byte, newKey []byte) (*schema.TxHeader, error)
+
 	Dump(ctx context.Context, writer io.WriteSeeker) (int64, error)
 
 	StreamSet(ctx context.Context, kv []*stream.KeyValue) (*schema.TxHeader, error)
@@ -169,6 +193,8 @@ type ImmuClient interface {
 	StreamScan(ctx context.Context, req *schema.ScanRequest) (*schema.Entries, error)
 	StreamZScan(ctx context.Context, req *schema.ZScanRequest) (*schema.ZEntries, error)
 	StreamHistory(ctx context.Context, req *schema.HistoryRequest) (*schema.Entries, error)
+	StreamZScanReader(ctx context.Context, req *schema.ZScanRequest) (ZEntryStreamReader, error)
+	StreamHistoryReader(ctx context.Context, req *schema.HistoryRequest) (EntryStreamReader, error)
 	StreamExecAll(ctx context.Context, req *stream.ExecAllRequest) (*schema.TxHeader, error)
 
 	ExportTx(ctx context.Context, req *schema.ExportTxRequest) (schema.ImmuService_ExportTxClient, error)
@@ -181,11 +207,63 @@ type ImmuClient interface {
 
 	VerifyRow(ctx context.Context, row *schema.Row, table string, pkVals []*schema.SQLValue) error
 
+	RegisterExternalRoot(ctx context.Context, root *schema.ExternalRoot) (*schema.TxHeader, error)
+	ExternalRoots(ctx context.Context, req *schema.ExternalRootsRequest) (*schema.ExternalRootList, error)
+
+	SetKVSchema(ctx context.Context, kvSchema *schema.KVSchema) (*schema.TxHeader, error)
+	DeleteKVSchema(ctx context.Context, prefix []byte) (*schema.TxHeader, error)
+	KVSchemas(ctx context.Context) (*schema.KVSchemaList, error)
+
+	SetRedactionRule(ctx context.Context, rule *schema.RedactionRule) (*schema.TxHeader, error)
+	DeleteRedactionRule(ctx context.Context, req *schema.RedactionRuleRequest) (*schema.TxHeader, error)
+	RedactionRules(ctx context.Context) (*schema.RedactionRuleList, error)
+
+	MultiDatabaseSet(ctx context.Context, req *schema.MultiDatabaseSetRequest) (*schema.MultiDatabaseTxHeaders, error)
+
+	ExportRoot(ctx context.Context) ([]byte, error)
+	VerifyRootConsistency(ctx context.Context, exportedRoot []byte) (*schema.ImmutableState, error)
+	GetServerKeys(ctx context.Context) (*schema.ServerKeys, error)
+
+	ExportProof(ctx context.Context, req *ProofRequest) (*schema.ProofBundle, error)
+
+	ReplicationStatus(ctx context.Context, database string) (*schema.ReplicationStatusResponse, error)
+	PauseReplication(ctx context.Context, database string) error
+	ResumeReplication(ctx context.Context, database string) error
+	ResetReplication(ctx context.Context, database string) error
+
+	KeyspaceStats(ctx context.Context, req *schema.KeyspaceStatsRequest) (*schema.KeyspaceStatsResponse, error)
+
 	NewTx(ctx context.Context) (Tx, error)
 }
 
 const DefaultDB = "defaultdb"
 
+// maxReverseReferenceEntries is the number of entries the reverse reference index of a database may write along with
+// a reference: its reverse entry and the removal of the one of the key it pointed to before
+const maxReverseReferenceEntries = 2
+
+// committedMetadata returns the metadata of an entry written without metadata as it was committed,
+// values may be compressed by the server, which records the algorithm in the metadata of the entry
+func committedMetadata(tx *store.Tx, key []byte) (*store.KVMetadata, error) {
+	txe, err := tx.EntryOf(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if txe.Metadata() == nil || txe.Metadata().Compression() == store.NoValueCompression {
+		return nil, nil
+	}
+
+	md := store.NewKVMetadata()
+
+	err = md.AsCompressed(txe.Metadata().Compression())
+	if err != nil {
+		return nil, err
+	}
+
+	return md, nil
+}
+
 type immuClient struct {
 	Dir                  string
 	Logger               logger.Logger
@@ -198,6 +276,12 @@ type immuClient struct {
 	StreamServiceFactory stream.ServiceFactory
 	SessionID            string
 	HeartBeater          heartbeater.HeartBeater
+
+	asyncWriter      *asyncWriter
+	asyncWriterMutex sync.Mutex
+
+	valueCache      *valueCache
+	valueCacheMutex sync.Mutex
 }
 
 // NewClient ...
@@ -330,7 +414,8 @@ func (c *immuClient) SetupDialOptions(options *Options) []grpc.DialOption {
 
 		opts = []grpc.DialOption{grpc.WithTransportCredentials(transportCreds)}
 	}
-	var uic []grpc.UnaryClientInterceptor
+	// ErrorMapperInterceptor needs to be the first one, so it is applied on errors returned by the others
+	uic := []grpc.UnaryClientInterceptor{c.ErrorMapperInterceptor}
 
 	if c.serverSigningPubKey != nil {
 		uic = append(uic, c.SignatureVerifierInterceptor)
@@ -347,6 +432,11 @@ func (c *immuClient) SetupDialOptions(options *Options) []grpc.DialOption {
 	}
 	uic = append(uic, c.TokenInterceptor, c.SessionIDInjectorInterceptor)
 
+	if options.ValueCacheSize > 0 {
+		uic = append(uic, c.valueCacheInterceptor)
+		opts = append(opts, grpc.WithChainStreamInterceptor(c.valueCacheStreamInterceptor))
+	}
+
 	opts = append(opts, grpc.WithUnaryInterceptor(grpc_middleware.ChainUnaryClient(uic...)), grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(options.MaxRecvMsgSize)))
 
 	return opts
@@ -369,6 +459,8 @@ func (c *immuClient) Disconnect() error {
 		return errors.FromError(ErrNotConnected)
 	}
 
+	c.stopHeartBeater()
+
 	if err := c.clientConn.Close(); err != nil {
 		return err
 	}
@@ -395,7 +487,11 @@ func (c *immuClient) WaitForHealthCheck(ctx context.Context) (err error) {
 		c.Logger.Debugf("health check failed: %v", err)
 
 		if c.Options.HealthCheckRetries > 0 {
-			time.Sleep(time.Second)
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(time.Second):
+			}
 		}
 	}
 	return err
@@ -574,6 +670,10 @@ func (c *immuClient) Get(ctx context.Context, key []byte) (*schema.Entry, error)
 	start := time.Now()
 	defer c.Logger.Debugf("get finished in %s", time.Since(start))
 
+	if c.Options.ValueCacheSize > 0 {
+		return c.cachedGet(ctx, key, 0)
+	}
+
 	return c.ServiceClient.Get(ctx, &schema.KeyRequest{Key: key})
 }
 
@@ -678,7 +778,7 @@ func (c *immuClient) verifiedGet(ctx context.Context, kReq *schema.KeyRequest) (
 		entrySpecDigest(e),
 		eh)
 	if !verifies {
-		return nil, store.ErrCorruptedData
+		return nil, errors.NewCorruptedStateError(store.ErrCorruptedData)
 	}
 
 	if state.TxId > 0 {
@@ -690,7 +790,7 @@ func (c *immuClient) verifiedGet(ctx context.Context, kReq *schema.KeyRequest) (
 			targetAlh,
 		)
 		if !verifies {
-			return nil, store.ErrCorruptedData
+			return nil, errors.NewCorruptedStateError(store.ErrCorruptedData)
 		}
 	}
 
@@ -707,7 +807,7 @@ func (c *immuClient) verifiedGet(ctx context.Context, kReq *schema.KeyRequest) (
 			return nil, err
 		}
 		if !ok {
-			return nil, store.ErrCorruptedData
+			return nil, errors.NewCorruptedStateError(store.ErrCorruptedData)
 		}
 	}
 
@@ -740,6 +840,10 @@ func (c *immuClient) GetAt(ctx context.Context, key []byte, tx uint64) (*schema.
 	start := time.Now()
 	defer c.Logger.Debugf("get finished in %s", time.Since(start))
 
+	if c.Options.ValueCacheSize > 0 {
+		return c.cachedGet(ctx, key, tx)
+	}
+
 	return c.ServiceClient.Get(ctx, &schema.KeyRequest{Key: key, AtTx: tx})
 }
 
@@ -793,7 +897,7 @@ func (c *immuClient) set(ctx context.Context, key []byte, md *schema.KVMetadata,
 	}
 
 	if int(txmd.Nentries) != 1 {
-		return nil, store.ErrCorruptedData
+		return nil, errors.NewCorruptedStateError(store.ErrCorruptedData)
 	}
 
 	return txmd, nil
@@ -836,7 +940,7 @@ func (c *immuClient) VerifiedSet(ctx context.Context, key []byte, value []byte)
 	}
 
 	if verifiableTx.Tx.Header.Nentries != 1 || len(verifiableTx.Tx.Entries) != 1 {
-		return nil, store.ErrCorruptedData
+		return nil, errors.NewCorruptedStateError(store.ErrCorruptedData)
 	}
 
 	tx := schema.TxFromProto(verifiableTx.Tx)
@@ -854,18 +958,18 @@ func (c *immuClient) VerifiedSet(ctx context.Context, key []byte, value []byte)
 	md := tx.Entries()[0].Metadata()
 
 	if md != nil && md.Deleted() {
-		return nil, store.ErrCorruptedData
+		return nil, errors.NewCorruptedStateError(store.ErrCorruptedData)
 	}
 
 	e := database.EncodeEntrySpec(key, md, value)
 
 	verifies := store.VerifyInclusion(inclusionProof, entrySpecDigest(e), tx.Header().Eh)
 	if !verifies {
-		return nil, store.ErrCorruptedData
+		return nil, errors.NewCorruptedStateError(store.ErrCorruptedData)
 	}
 
 	if tx.Header().Eh != schema.DigestFromProto(verifiableTx.DualProof.TargetTxHeader.EH) {
-		return nil, store.ErrCorruptedData
+		return nil, errors.NewCorruptedStateError(store.ErrCorruptedData)
 	}
 
 	var sourceID, targetID uint64
@@ -886,7 +990,7 @@ func (c *immuClient) VerifiedSet(ctx context.Context, key []byte, value []byte)
 		)
 
 		if !verifies {
-			return nil, store.ErrCorruptedData
+			return nil, errors.NewCorruptedStateError(store.ErrCorruptedData)
 		}
 	}
 
@@ -903,7 +1007,7 @@ func (c *immuClient) VerifiedSet(ctx context.Context, key []byte, value []byte)
 			return nil, err
 		}
 		if !ok {
-			return nil, store.ErrCorruptedData
+			return nil, errors.NewCorruptedStateError(store.ErrCorruptedData)
 		}
 	}
 
@@ -916,7 +1020,12 @@ func (c *immuClient) VerifiedSet(ctx context.Context, key []byte, value []byte)
 }
 
 func (c *immuClient) ExpirableSet(ctx context.Context, key []byte, value []byte, expiresAt time.Time) (*schema.TxHeader, error) {
-	return c.set(ctx, key, &schema.KVMetadata{Expiration: &schema.Expiration{ExpiresAt: expiresAt.Unix()}}, value)
+	return c.set(ctx, key, schema.NewKVMetadata().WithExpiration(expiresAt), value)
+}
+
+// SetWithMetadata sets the value of the key along with the given entry metadata, built with schema.NewKVMetadata
+func (c *immuClient) SetWithMetadata(ctx context.Context, key []byte, value []byte, md *schema.KVMetadata) (*schema.TxHeader, error) {
+	return c.set(ctx, key, md, value)
 }
 
 func (c *immuClient) SetAll(ctx context.Context, req *schema.SetRequest) (*schema.TxHeader, error) {
@@ -930,7 +1039,7 @@ func (c *immuClient) SetAll(ctx context.Context, req *schema.SetRequest) (*schem
 	}
 
 	if int(txmd.Nentries) != len(req.KVs) {
-		return nil, store.ErrCorruptedData
+		return nil, errors.NewCorruptedStateError(store.ErrCorruptedData)
 	}
 
 	return txmd, nil
@@ -943,13 +1052,37 @@ func (c *immuClient) ExecAll(ctx context.Context, req *schema.ExecAllRequest) (*
 		return nil, err
 	}
 
-	if int(txhdr.Nentries) != len(req.Operations) {
-		return nil, store.ErrCorruptedData
+	// references may be written along with the entries of the reverse reference index
+	maxEntries := len(req.Operations)
+
+	for _, op := range req.Operations {
+		if _, ok := op.Operation.(*schema.Op_Ref); ok {
+			maxEntries += maxReverseReferenceEntries
+		}
+	}
+
+	if int(txhdr.Nentries) < len(req.Operations) || int(txhdr.Nentries) > maxEntries {
+		return nil, errors.NewCorruptedStateError(store.ErrCorruptedData)
 	}
 
 	return txhdr, nil
 }
 
+// ExecScript runs a script reading and conditionally writing keys within a single transaction,
+// params are available through the params table of the script. Scripts must be enabled on the server
+func (c *immuClient) ExecScript(ctx context.Context, script string, params map[string]interface{}) (*schema.ExecScriptResult, error) {
+	if !c.IsConnected() {
+		return nil, errors.FromError(ErrNotConnected)
+	}
+
+	namedParams, err := schema.EncodeParams(params)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.ServiceClient.ExecScript(ctx, &schema.ExecScriptRequest{Script: script, Params: namedParams})
+}
+
 // GetAll ...
 func (c *immuClient) GetAll(ctx context.Context, keys [][]byte) (*schema.Entries, error) {
 	if !c.IsConnected() {
@@ -966,6 +1099,26 @@ func (c *immuClient) GetAll(ctx context.Context, keys [][]byte) (*schema.Entries
 	return c.ServiceClient.GetAll(ctx, keyList)
 }
 
+// GetAllWithStatuses returns one result per key, in the given order, telling whether it was found, not found, deleted or expired
+func (c *immuClient) GetAllWithStatuses(ctx context.Context, keys [][]byte) ([]*schema.KeyResult, error) {
+	if !c.IsConnected() {
+		return nil, errors.FromError(ErrNotConnected)
+	}
+
+	start := time.Now()
+	defer func() { c.Logger.Debugf("get-batch finished in %s", time.Since(start)) }()
+
+	entries, err := c.ServiceClient.GetAll(ctx, &schema.KeyListRequest{
+		Keys:         keys,
+		WithStatuses: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return entries.Results, nil
+}
+
 func (c *immuClient) Delete(ctx context.Context, req *schema.DeleteKeysRequest) (*schema.TxHeader, error) {
 	if !c.IsConnected() {
 		return nil, errors.FromError(ErrNotConnected)
@@ -1058,7 +1211,7 @@ func (c *immuClient) VerifiedTxByID(ctx context.Context, tx uint64) (*schema.Tx,
 			targetAlh,
 		)
 		if !verifies {
-			return nil, store.ErrCorruptedData
+			return nil, errors.NewCorruptedStateError(store.ErrCorruptedData)
 		}
 	}
 
@@ -1075,7 +1228,7 @@ func (c *immuClient) VerifiedTxByID(ctx context.Context, tx uint64) (*schema.Tx,
 			return nil, err
 		}
 		if !ok {
-			return nil, store.ErrCorruptedData
+			return nil, errors.NewCorruptedStateError(store.ErrCorruptedData)
 		}
 	}
 
@@ -1117,6 +1270,24 @@ func (c *immuClient) SetReference(ctx context.Context, key []byte, referencedKey
 
 // SetReferenceAt ...
 func (c *immuClient) SetReferenceAt(ctx context.Context, key []byte, referencedKey []byte, atTx uint64) (*schema.TxHeader, error) {
+	return c.setReference(ctx, &schema.ReferenceRequest{
+		Key:           key,
+		ReferencedKey: referencedKey,
+		AtTx:          atTx,
+		BoundRef:      atTx > 0,
+	})
+}
+
+// ExpirableSetReference sets a reference which is no longer resolved once expiresAt is reached
+func (c *immuClient) ExpirableSetReference(ctx context.Context, key []byte, referencedKey []byte, expiresAt time.Time) (*schema.TxHeader, error) {
+	return c.setReference(ctx, &schema.ReferenceRequest{
+		Key:           key,
+		ReferencedKey: referencedKey,
+		Expiration:    &schema.Expiration{ExpiresAt: expiresAt.Unix()},
+	})
+}
+
+func (c *immuClient) setReference(ctx context.Context, req *schema.ReferenceRequest) (*schema.TxHeader, error) {
 	if !c.IsConnected() {
 		return nil, errors.FromError(ErrNotConnected)
 	}
@@ -1124,23 +1295,43 @@ func (c *immuClient) SetReferenceAt(ctx context.Context, key []byte, referencedK
 	start := time.Now()
 	defer c.Logger.Debugf("SetReference finished in %s", time.Since(start))
 
-	txhdr, err := c.ServiceClient.SetReference(ctx, &schema.ReferenceRequest{
-		Key:           key,
-		ReferencedKey: referencedKey,
-		AtTx:          atTx,
-		BoundRef:      atTx > 0,
-	})
+	txhdr, err := c.ServiceClient.SetReference(ctx, req)
 	if err != nil {
 		return nil, err
 	}
 
-	if int(txhdr.Nentries) != 1 {
-		return nil, store.ErrCorruptedData
+	if txhdr.Nentries < 1 || txhdr.Nentries > 1+maxReverseReferenceEntries {
+		return nil, errors.NewCorruptedStateError(store.ErrCorruptedData)
 	}
 
 	return txhdr, nil
 }
 
+// ResolveAll returns the entries of the references currently pointing to the key, each one resolved as Get does
+func (c *immuClient) ResolveAll(ctx context.Context, key []byte) (*schema.Entries, error) {
+	if !c.IsConnected() {
+		return nil, errors.FromError(ErrNotConnected)
+	}
+
+	start := time.Now()
+	defer func() { c.Logger.Debugf("ResolveAll finished in %s", time.Since(start)) }()
+
+	return c.ServiceClient.ResolveAll(ctx, &schema.ResolveAllRequest{Key: key})
+}
+
+// Rename moves the value of oldKey to newKey in a single transaction, the history of newKey
+// can be followed back into the one of oldKey by setting FollowRenames when calling History
+func (c *immuClient) Rename(ctx context.Context, oldKey []byte, newKey []byte) (*schema.TxHeader, error) {
+	if !c.IsConnected() {
+		return nil, errors.FromError(ErrNotConnected)
+	}
+
+	start := time.Now()
+	defer func() { c.Logger.Debugf("Rename finished in %s", time.Since(start)) }()
+
+	return c.ServiceClient.Rename(ctx, &schema.RenameRequest{OldKey: oldKey, NewKey: newKey})
+}
+
 // VerifiedSetReference ...
 func (c *immuClient) VerifiedSetReference(ctx context.Context, key []byte, referencedKey []byte) (*schema.TxHeader, error) {
 	return c.VerifiedSetReferenceAt(ctx, key, referencedKey, 0)
@@ -1186,8 +1377,8 @@ func (c *immuClient) VerifiedSetReferenceAt(ctx context.Context, key []byte, ref
 		return nil, err
 	}
 
-	if verifiableTx.Tx.Header.Nentries != 1 {
-		return nil, store.ErrCorruptedData
+	if verifiableTx.Tx.Header.Nentries < 1 || verifiableTx.Tx.Header.Nentries > 1+maxReverseReferenceEntries {
+		return nil, errors.NewCorruptedStateError(store.ErrCorruptedData)
 	}
 
 	tx := schema.TxFromProto(verifiableTx.Tx)
@@ -1197,20 +1388,32 @@ func (c *immuClient) VerifiedSetReferenceAt(ctx context.Context, key []byte, ref
 		return nil, err
 	}
 
-	inclusionProof, err := tx.Proof(database.EncodeKey(key))
-	if err != nil {
-		return nil, err
+	entries := []*store.EntrySpec{database.EncodeReference(key, nil, referencedKey, atTx)}
+
+	// the reverse entry is only written when the reverse reference index is enabled
+	if verifiableTx.Tx.Header.Nentries > 1 {
+		entries = append(entries, database.EncodeReverseReference(key, nil, referencedKey))
 	}
 
-	e := database.EncodeReference(key, nil, referencedKey, atTx)
+	for _, e := range entries {
+		inclusionProof, err := tx.Proof(e.Key)
+		if err != nil {
+			return nil, err
+		}
 
-	verifies := store.VerifyInclusion(inclusionProof, entrySpecDigest(e), tx.Header().Eh)
-	if !verifies {
-		return nil, store.ErrCorruptedData
+		e.Metadata, err = committedMetadata(tx, e.Key)
+		if err != nil {
+			return nil, err
+		}
+
+		verifies := store.VerifyInclusion(inclusionProof, entrySpecDigest(e), tx.Header().Eh)
+		if !verifies {
+			return nil, errors.NewCorruptedStateError(store.ErrCorruptedData)
+		}
 	}
 
 	if tx.Header().Eh != schema.DigestFromProto(verifiableTx.DualProof.TargetTxHeader.EH) {
-		return nil, store.ErrCorruptedData
+		return nil, errors.NewCorruptedStateError(store.ErrCorruptedData)
 	}
 
 	var sourceID, targetID uint64
@@ -1222,7 +1425,7 @@ func (c *immuClient) VerifiedSetReferenceAt(ctx context.Context, key []byte, ref
 	targetAlh = tx.Header().Alh()
 
 	if state.TxId > 0 {
-		verifies = store.VerifyDualProof(
+		verifies := store.VerifyDualProof(
 			schema.DualProofFromProto(verifiableTx.DualProof),
 			sourceID,
 			targetID,
@@ -1230,7 +1433,7 @@ func (c *immuClient) VerifiedSetReferenceAt(ctx context.Context, key []byte, ref
 			targetAlh,
 		)
 		if !verifies {
-			return nil, store.ErrCorruptedData
+			return nil, errors.NewCorruptedStateError(store.ErrCorruptedData)
 		}
 	}
 
@@ -1247,7 +1450,7 @@ func (c *immuClient) VerifiedSetReferenceAt(ctx context.Context, key []byte, ref
 			return nil, err
 		}
 		if !ok {
-			return nil, store.ErrCorruptedData
+			return nil, errors.NewCorruptedStateError(store.ErrCorruptedData)
 		}
 	}
 
@@ -1285,7 +1488,7 @@ func (c *immuClient) ZAddAt(ctx context.Context, set []byte, score float64, key
 	}
 
 	if int(txmd.Nentries) != 1 {
-		return nil, store.ErrCorruptedData
+		return nil, errors.NewCorruptedStateError(store.ErrCorruptedData)
 	}
 
 	return txmd, nil
@@ -1338,7 +1541,7 @@ func (c *immuClient) VerifiedZAddAt(ctx context.Context, set []byte, score float
 	}
 
 	if vtx.Tx.Header.Nentries != 1 {
-		return nil, store.ErrCorruptedData
+		return nil, errors.NewCorruptedStateError(store.ErrCorruptedData)
 	}
 
 	tx := schema.TxFromProto(vtx.Tx)
@@ -1359,13 +1562,18 @@ func (c *immuClient) VerifiedZAddAt(ctx context.Context, set []byte, score float
 		return nil, err
 	}
 
+	ekv.Metadata, err = committedMetadata(tx, ekv.Key)
+	if err != nil {
+		return nil, err
+	}
+
 	verifies := store.VerifyInclusion(inclusionProof, entrySpecDigest(ekv), tx.Header().Eh)
 	if !verifies {
-		return nil, store.ErrCorruptedData
+		return nil, errors.NewCorruptedStateError(store.ErrCorruptedData)
 	}
 
 	if tx.Header().Eh != schema.DigestFromProto(vtx.DualProof.TargetTxHeader.EH) {
-		return nil, store.ErrCorruptedData
+		return nil, errors.NewCorruptedStateError(store.ErrCorruptedData)
 	}
 
 	var sourceID, targetID uint64
@@ -1385,7 +1593,7 @@ func (c *immuClient) VerifiedZAddAt(ctx context.Context, set []byte, score float
 			targetAlh,
 		)
 		if !verifies {
-			return nil, store.ErrCorruptedData
+			return nil, errors.NewCorruptedStateError(store.ErrCorruptedData)
 		}
 	}
 
@@ -1402,7 +1610,7 @@ func (c *immuClient) VerifiedZAddAt(ctx context.Context, set []byte, score float
 			return nil, err
 		}
 		if !ok {
-			return nil, store.ErrCorruptedData
+			return nil, errors.NewCorruptedStateError(store.ErrCorruptedData)
 		}
 	}
 
@@ -1440,6 +1648,21 @@ func (c *immuClient) HealthCheck(ctx context.Context) error {
 	return nil
 }
 
+// ServerInfo returns the version of the server, the API versions it serves and its enabled features
+func (c *immuClient) ServerInfo(ctx context.Context) (*schema.ServerInfoResponse, error) {
+	start := time.Now()
+
+	if !c.IsConnected() {
+		return nil, ErrNotConnected
+	}
+
+	info, err := c.ServiceClient.ServerInfo(ctx, &schema.ServerInfoRequest{})
+
+	c.Logger.Debugf("server-info finished in %s", time.Since(start))
+
+	return info, err
+}
+
 func (c *immuClient) currentDatabase() string {
 	if c.Options.CurrentDatabase == "" {
 		return DefaultDB
@@ -1477,6 +1700,21 @@ func (c *immuClient) CreateDatabaseV2(ctx context.Context, settings *schema.Data
 	return appliedSettings, err
 }
 
+// CloneDatabase creates a new read-only database holding the state of another database up to a given transaction
+func (c *immuClient) CloneDatabase(ctx context.Context, req *schema.CloneDatabaseRequest) (*schema.CloneDatabaseResponse, error) {
+	start := time.Now()
+
+	if !c.IsConnected() {
+		return nil, ErrNotConnected
+	}
+
+	res, err := c.ServiceClient.CloneDatabase(ctx, req)
+
+	c.Logger.Debugf("CloneDatabase finished in %s", time.Since(start))
+
+	return res, err
+}
+
 // UseDatabase create a new database by making a grpc call
 func (c *immuClient) UseDatabase(ctx context.Context, db *schema.Database) (*schema.UseDatabaseReply, error) {
 	start := time.Now()
@@ -1549,6 +1787,36 @@ func (c *immuClient) GetDatabaseSettingsV2(ctx context.Context) (*schema.Databas
 	return c.ServiceClient.GetDatabaseSettingsV2(ctx, &empty.Empty{})
 }
 
+// GetDatabaseSettingsHistory returns the versions of the settings of a database
+func (c *immuClient) GetDatabaseSettingsHistory(ctx context.Context, req *schema.DatabaseSettingsHistoryRequest) (*schema.DatabaseSettingsHistory, error) {
+	if !c.IsConnected() {
+		return nil, ErrNotConnected
+	}
+
+	return c.ServiceClient.GetDatabaseSettingsHistory(ctx, req)
+}
+
+// RollbackDatabaseSettings sets the settings of a database back to a previous version
+func (c *immuClient) RollbackDatabaseSettings(ctx context.Context, database string, version uint64) (*schema.DatabaseSettingsUpdateResult, error) {
+	if !c.IsConnected() {
+		return nil, ErrNotConnected
+	}
+
+	return c.ServiceClient.RollbackDatabaseSettings(ctx, &schema.DatabaseSettingsRollbackRequest{
+		Database: database,
+		Version:  version,
+	})
+}
+
+// KeyspaceStats summarizes the keys of a database, the whole index is walked so it may take a while on large databases
+func (c *immuClient) KeyspaceStats(ctx context.Context, req *schema.KeyspaceStatsRequest) (*schema.KeyspaceStatsResponse, error) {
+	if !c.IsConnected() {
+		return nil, errors.FromError(ErrNotConnected)
+	}
+
+	return c.ServiceClient.KeyspaceStats(ctx, req)
+}
+
 func (c *immuClient) FlushIndex(ctx context.Context, cleanupPercentage float32, synced bool) error {
 	if !c.IsConnected() {
 		return errors.FromError(ErrNotConnected)
@@ -1576,6 +1844,35 @@ func (c *immuClient) CompactIndex(ctx context.Context, req *empty.Empty) error {
 	return err
 }
 
+// CompactIndexAsync starts the compaction of the index of the selected database as a background job,
+// the returned job can be polled with GetJob or WaitForJob
+func (c *immuClient) CompactIndexAsync(ctx context.Context) (*schema.Job, error) {
+	if !c.IsConnected() {
+		return nil, errors.FromError(ErrNotConnected)
+	}
+
+	return c.ServiceClient.CompactIndexAsync(ctx, &empty.Empty{})
+}
+
+// ScrubAsync starts verifying every committed transaction of the selected database as a background job,
+// the returned job can be polled with GetJob or WaitForJob and the corrupted transactions found read with ScrubStatus
+func (c *immuClient) ScrubAsync(ctx context.Context) (*schema.Job, error) {
+	if !c.IsConnected() {
+		return nil, errors.FromError(ErrNotConnected)
+	}
+
+	return c.ServiceClient.ScrubAsync(ctx, &empty.Empty{})
+}
+
+// ScrubStatus returns the status of the ongoing scrub of the selected database or the last one
+func (c *immuClient) ScrubStatus(ctx context.Context) (*schema.ScrubStatusResponse, error) {
+	if !c.IsConnected() {
+		return nil, errors.FromError(ErrNotConnected)
+	}
+
+	return c.ServiceClient.ScrubStatus(ctx, &empty.Empty{})
+}
+
 func (c *immuClient) ChangePermission(ctx context.Context, action schema.PermissionAction, username string, database string, permissions uint32) error {
 	start := time.Now()
 
@@ -17,10 +17,32 @@ limitations under the License.
 package errors
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/codenotary/immudb/pkg/api/schema"
 	"google.golang.org/grpc/status"
 )
 
+// Typed errors mapped from the error meta sent by the server. They can be
+// checked with errors.Is regardless of the details the server adds to the message:
+//
+// _, err = client.Get(ctx, []byte("key"))
+// if errors.Is(err, immuErrors.ErrKeyNotFound) { ... }
+//
+// The same code is shared by several server errors, e.g. expired entries are
+// not found either, so typed errors are matched by both code and message.
+var (
+	ErrKeyNotFound         = newTypedError("key not found", CodNoDataFound)
+	ErrTxNotFound          = newTypedError("tx not found", CodTxNotFound)
+	ErrPreconditionFailed  = newTypedError("precondition failed", CodIntegrityConstraintViolation).withDetails()
+	ErrKeyAlreadyExists    = newTypedError("key already exists", CodUniqueViolation)
+	ErrCorruptedState      = newTypedError("data is corrupted", CodDataCorrupted)
+	ErrCorruptedIndex      = newTypedError("corrupted index", CodIndexCorrupted)
+	ErrTxReadConflict      = newTypedError("tx read conflict", CodInFailedSqlTransaction)
+	ErrResultLimitExceeded = newTypedError("result limit exceeded", CodProgramLimitExceeded).withDetails()
+)
+
 // ImmuError SDK immudb error interface.
 // _, err = client.StreamSet(ctx, kvs)
 // code := err.(errors.ImmuError).Code()) //errors.CodDataException
@@ -50,6 +72,59 @@ type immuError struct {
 	msg        string
 	retryDelay int32
 	stack      string
+	typed      bool
+	detailed   bool
+}
+
+func newTypedError(message string, code Code) *immuError {
+	return &immuError{
+		msg:   message,
+		code:  code,
+		typed: true,
+	}
+}
+
+// withDetails makes the typed error match the messages the server extends with details, e.g. the precondition not satisfied
+func (e *immuError) withDetails() *immuError {
+	e.detailed = true
+	return e
+}
+
+// corruptedStateError is ErrCorruptedState detected by the client when the data sent by the server does not verify.
+type corruptedStateError struct {
+	*immuError
+	err error
+}
+
+// NewCorruptedStateError returns ErrCorruptedState caused by err, errors.Is keeps matching the cause as well
+func NewCorruptedStateError(err error) error {
+	return &corruptedStateError{
+		immuError: &immuError{msg: ErrCorruptedState.msg, code: ErrCorruptedState.code},
+		err:       err,
+	}
+}
+
+func (e *corruptedStateError) Error() string {
+	// most causes already tell the data is corrupted, e.g. store.ErrCorruptedData
+	if strings.HasPrefix(e.err.Error(), e.msg) {
+		return e.err.Error()
+	}
+	return fmt.Sprintf("%s: %v", e.msg, e.err)
+}
+
+func (e *corruptedStateError) Cause() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the verification failure detected by the client
+func (e *corruptedStateError) Unwrap() error {
+	return e.err
+}
+
+// grpcError is an immuError built from a gRPC status returned by the server.
+type grpcError struct {
+	*immuError
+	err error
 }
 
 func FromError(err error) ImmuError {
@@ -57,6 +132,10 @@ func FromError(err error) ImmuError {
 		return nil
 	}
 
+	if ie, ok := err.(ImmuError); ok {
+		return ie
+	}
+
 	st, ok := status.FromError(err)
 	if ok {
 		ie := New(st.Message())
@@ -70,7 +149,7 @@ func FromError(err error) ImmuError {
 				ie.WithRetryDelay(ele.RetryDelay)
 			}
 		}
-		return ie
+		return &grpcError{immuError: ie, err: err}
 	}
 	return New(err.Error())
 }
@@ -95,6 +174,17 @@ func (f *immuError) RetryDelay() int32 {
 	return f.retryDelay
 }
 
+// Unwrap returns the original gRPC error, so that errors.Is keeps matching server errors defined as gRPC statuses.
+func (f *grpcError) Unwrap() error {
+	return f.err
+}
+
+// GRPCStatus returns the gRPC status the error was built from, status.FromError and status.Code keep working
+// on mapped errors.
+func (f *grpcError) GRPCStatus() *status.Status {
+	return status.Convert(f.err)
+}
+
 func (e *immuError) WithMessage(message string) *immuError {
 	e.msg = message
 	return e
@@ -128,6 +218,10 @@ func (e *immuError) Is(target error) bool {
 	if !ok {
 		return e.Error() == target.Error()
 	}
+	if te, ok := target.(*immuError); ok && te.typed {
+		return e.Code() == te.Code() &&
+			(e.Error() == te.Error() || (te.detailed && strings.HasPrefix(e.Error(), te.Error()+": ")))
+	}
 	return compare(e, t)
 }
 
@@ -0,0 +1,112 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package errors
+
+import (
+	stdErrors "errors"
+	"fmt"
+	"testing"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/database"
+	serverErrors "github.com/codenotary/immudb/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestFromError(t *testing.T) {
+	require.Nil(t, FromError(nil))
+
+	st, err := status.New(codes.NotFound, "key not found").WithDetails(&schema.ErrorInfo{
+		Code:  string(CodNoDataFound),
+		Cause: "key not found",
+	})
+	require.NoError(t, err)
+
+	ie := FromError(st.Err())
+	require.Equal(t, "key not found", ie.Error())
+	require.Equal(t, CodNoDataFound, ie.Code())
+	require.True(t, stdErrors.Is(ie, ErrKeyNotFound))
+	require.False(t, stdErrors.Is(ie, ErrTxNotFound))
+	require.True(t, stdErrors.Is(ie, st.Err()))
+	require.Equal(t, codes.NotFound, status.Code(ie))
+
+	require.Same(t, ie, FromError(ie))
+
+	ie = FromError(stdErrors.New("some error"))
+	require.Equal(t, "some error", ie.Error())
+	require.Equal(t, CodInternalError, ie.Code())
+	require.False(t, stdErrors.Is(ie, ErrKeyNotFound))
+
+	_, ok := status.FromError(ie)
+	require.False(t, ok)
+}
+
+func TestTypedErrorsSharingCodes(t *testing.T) {
+	fromServer := func(msg string, code serverErrors.Code) error {
+		return FromError(serverErrors.New(msg).WithCode(code).GRPCStatus().Err())
+	}
+
+	require.ErrorIs(t, fromServer(store.ErrKeyNotFound.Error(), serverErrors.CodNoDataFound), ErrKeyNotFound)
+	require.ErrorIs(t, fromServer(store.ErrTxReadConflict.Error(), serverErrors.CodInFailedSqlTransaction), ErrTxReadConflict)
+
+	// the server adds the details of the failure to some of them
+	preconditionErr := fmt.Errorf("%w: key must exist", store.ErrPreconditionFailed)
+	require.ErrorIs(t, fromServer(preconditionErr.Error(), serverErrors.CodIntegrityConstraintViolation), ErrPreconditionFailed)
+
+	limitErr := &database.ResultLimitError{Limit: "rows", Max: 10, Rows: 10}
+	require.ErrorIs(t, fromServer(limitErr.Error(), serverErrors.CodProgramLimitExceeded), ErrResultLimitExceeded)
+
+	for _, err := range []error{
+		fromServer("job not found", serverErrors.CodNoDataFound),
+		fromServer(store.ErrExpiredEntry.Error(), serverErrors.CodNoDataFound),
+		fromServer(store.ErrValueTruncated.Error(), serverErrors.CodNoDataFound),
+		fromServer(database.ErrExternalRootNotFound.Error(), serverErrors.CodNoDataFound),
+		fromServer(database.ErrKVSchemaNotFound.Error(), serverErrors.CodNoDataFound),
+		fromServer(database.ErrRedactionRuleNotFound.Error(), serverErrors.CodNoDataFound),
+	} {
+		require.NotErrorIs(t, err, ErrKeyNotFound, err.Error())
+	}
+
+	for _, err := range []error{
+		fromServer(database.ErrExternalRootOutdated.Error(), serverErrors.CodIntegrityConstraintViolation),
+		fromServer(database.ErrExternalRootConflict.Error(), serverErrors.CodIntegrityConstraintViolation),
+	} {
+		require.NotErrorIs(t, err, ErrPreconditionFailed, err.Error())
+	}
+
+	require.NotErrorIs(t, fromServer("current transaction is aborted", serverErrors.CodInFailedSqlTransaction), ErrTxReadConflict)
+	require.NotErrorIs(t, fromServer(store.ErrKeyNotFound.Error(), serverErrors.CodTxNotFound), ErrKeyNotFound)
+}
+
+func TestCorruptedStateError(t *testing.T) {
+	cause := fmt.Errorf("%w: hash mismatch at tx 1", store.ErrCorruptedData)
+
+	err := NewCorruptedStateError(cause)
+	require.ErrorIs(t, err, ErrCorruptedState)
+	require.ErrorIs(t, err, store.ErrCorruptedData)
+	require.NotErrorIs(t, err, ErrCorruptedIndex)
+	require.Equal(t, "data is corrupted: hash mismatch at tx 1", err.Error())
+
+	require.Equal(t, "data is corrupted: signature mismatch", NewCorruptedStateError(stdErrors.New("signature mismatch")).Error())
+
+	ie := FromError(err)
+	require.Equal(t, CodDataCorrupted, ie.Code())
+	require.Equal(t, cause.Error(), ie.Cause())
+}
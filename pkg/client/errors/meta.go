@@ -30,4 +30,11 @@ const (
 	CodInvalidDatabaseName                           Code = "3F000"
 	CodNoSessionAuthDataProvided                     Code = "28000"
 	CodInFailedSqlTransaction                        Code = "25P02"
+	CodNoDataFound                                   Code = "02000"
+	CodTxNotFound                                    Code = "02I01"
+	CodIntegrityConstraintViolation                  Code = "23000"
+	CodUniqueViolation                               Code = "23505"
+	CodDataCorrupted                                 Code = "XX001"
+	CodIndexCorrupted                                Code = "XX002"
+	CodProgramLimitExceeded                          Code = "54000"
 )
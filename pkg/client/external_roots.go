@@ -0,0 +1,41 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package client
+
+import (
+	"context"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/client/errors"
+)
+
+// RegisterExternalRoot appends a root observed from an external log (e.g. another immudb instance or a CT log)
+func (c *immuClient) RegisterExternalRoot(ctx context.Context, root *schema.ExternalRoot) (*schema.TxHeader, error) {
+	if !c.IsConnected() {
+		return nil, errors.FromError(ErrNotConnected)
+	}
+
+	return c.ServiceClient.RegisterExternalRoot(ctx, root)
+}
+
+// ExternalRoots lists the roots registered for an external log
+func (c *immuClient) ExternalRoots(ctx context.Context, req *schema.ExternalRootsRequest) (*schema.ExternalRootList, error) {
+	if !c.IsConnected() {
+		return nil, errors.FromError(ErrNotConnected)
+	}
+
+	return c.ServiceClient.ExternalRoots(ctx, req)
+}
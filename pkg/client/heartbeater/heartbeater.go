@@ -18,23 +18,29 @@ package heartbeater
 
 import (
 	"context"
+	stdos "os"
+	"sync"
+	"time"
+
 	"github.com/codenotary/immudb/pkg/api/schema"
 	"github.com/codenotary/immudb/pkg/logger"
 	"github.com/golang/protobuf/ptypes/empty"
-	stdos "os"
-	"time"
 )
 
 type heartBeater struct {
 	sessionID     string
 	logger        logger.Logger
 	serviceClient schema.ImmuServiceClient
-	done          chan bool
+	done          chan struct{}
+	stopOnce      sync.Once
 	t             *time.Ticker
 }
 
+// HeartBeater periodically sends keep alive messages so that idle sessions are not reaped by the server
 type HeartBeater interface {
+	// KeepAlive starts sending keep alive messages in background until Stop is called or ctx is done
 	KeepAlive(ctx context.Context)
+	// Stop ends the keep alive loop, it's safe to call it more than once
 	Stop()
 }
 
@@ -43,17 +49,23 @@ func NewHeartBeater(sessionID string, sc schema.ImmuServiceClient, keepAliveInte
 		sessionID:     sessionID,
 		logger:        logger.NewSimpleLogger("immuclient", stdos.Stdout),
 		serviceClient: sc,
-		done:          make(chan bool),
+		done:          make(chan struct{}),
 		t:             time.NewTicker(keepAliveInterval),
 	}
 }
 
 func (hb *heartBeater) KeepAlive(ctx context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+
 	go func() {
+		defer cancel()
+
 		for {
 			select {
 			case <-hb.done:
 				return
+			case <-ctx.Done():
+				return
 			case t := <-hb.t.C:
 				hb.logger.Debugf("keep alive for %s at %s\n", hb.sessionID, t.String())
 				err := hb.keepAliveRequest(ctx)
@@ -66,7 +78,10 @@ func (hb *heartBeater) KeepAlive(ctx context.Context) {
 }
 
 func (hb *heartBeater) Stop() {
-	hb.done <- true
+	hb.stopOnce.Do(func() {
+		hb.t.Stop()
+		close(hb.done)
+	})
 }
 
 func (hb *heartBeater) keepAliveRequest(ctx context.Context) error {
@@ -0,0 +1,77 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package client
+
+import (
+	"context"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/client/errors"
+)
+
+// ListJobs returns the jobs run in background on the selected database, newest first.
+// All kinds of jobs are returned when kind is empty.
+func (c *immuClient) ListJobs(ctx context.Context, kind string) (*schema.JobList, error) {
+	if !c.IsConnected() {
+		return nil, errors.FromError(ErrNotConnected)
+	}
+
+	return c.ServiceClient.ListJobs(ctx, &schema.ListJobsRequest{Kind: kind})
+}
+
+// GetJob returns the state of a job run in background on the selected database
+func (c *immuClient) GetJob(ctx context.Context, jobID string) (*schema.Job, error) {
+	if !c.IsConnected() {
+		return nil, errors.FromError(ErrNotConnected)
+	}
+
+	return c.ServiceClient.GetJob(ctx, &schema.JobRequest{Id: jobID})
+}
+
+// CancelJob requests the cancelation of a running job, use WaitForJob to wait until it is actually stopped
+func (c *immuClient) CancelJob(ctx context.Context, jobID string) (*schema.Job, error) {
+	if !c.IsConnected() {
+		return nil, errors.FromError(ErrNotConnected)
+	}
+
+	return c.ServiceClient.CancelJob(ctx, &schema.JobRequest{Id: jobID})
+}
+
+// WaitForJob polls the state of a job until it is finished. onProgress, if not nil, is called with
+// every state retrieved while the job is running.
+func (c *immuClient) WaitForJob(ctx context.Context, jobID string, pollInterval time.Duration, onProgress func(job *schema.Job)) (*schema.Job, error) {
+	for {
+		job, err := c.GetJob(ctx, jobID)
+		if err != nil {
+			return nil, err
+		}
+
+		if job.Finished() {
+			return job, nil
+		}
+
+		if onProgress != nil {
+			onProgress(job)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(pollInterval):
+		}
+	}
+}
@@ -0,0 +1,51 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package client
+
+import (
+	"context"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/client/errors"
+	"github.com/golang/protobuf/ptypes/empty"
+)
+
+// SetKVSchema registers the schema (JSON schema or protobuf message type) the values written under a key prefix must conform to
+func (c *immuClient) SetKVSchema(ctx context.Context, kvSchema *schema.KVSchema) (*schema.TxHeader, error) {
+	if !c.IsConnected() {
+		return nil, errors.FromError(ErrNotConnected)
+	}
+
+	return c.ServiceClient.SetKVSchema(ctx, kvSchema)
+}
+
+// DeleteKVSchema removes the schema registered for a key prefix
+func (c *immuClient) DeleteKVSchema(ctx context.Context, prefix []byte) (*schema.TxHeader, error) {
+	if !c.IsConnected() {
+		return nil, errors.FromError(ErrNotConnected)
+	}
+
+	return c.ServiceClient.DeleteKVSchema(ctx, &schema.KVSchemaRequest{Prefix: prefix})
+}
+
+// KVSchemas lists the schemas registered in the database
+func (c *immuClient) KVSchemas(ctx context.Context) (*schema.KVSchemaList, error) {
+	if !c.IsConnected() {
+		return nil, errors.FromError(ErrNotConnected)
+	}
+
+	return c.ServiceClient.KVSchemas(ctx, &empty.Empty{})
+}
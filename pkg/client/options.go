@@ -19,6 +19,7 @@ package client
 import (
 	"encoding/json"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/codenotary/immudb/pkg/stream"
@@ -30,6 +31,9 @@ import (
 // AdminTokenFileSuffix is the suffix used for the token file name
 const AdminTokenFileSuffix = "_admin"
 
+// UnixSocketScheme prefixes addresses of unix domain sockets e.g. unix:///var/run/immudb.sock
+const UnixSocketScheme = "unix://"
+
 // Options client options
 type Options struct {
 	Dir                string
@@ -55,28 +59,42 @@ type Options struct {
 	LogFileName         string
 	ServerSigningPubKey string
 	StreamChunkSize     int
-	HeartBeatFrequency  time.Duration
+	// HeartBeatFrequency is the interval keep alive messages are sent at while a session is open, zero disables them
+	HeartBeatFrequency time.Duration
+	// MaxPendingAsyncWrites limits the number of async writes in flight, further writes wait for a slot
+	MaxPendingAsyncWrites int
+	// ReadYourWrites makes reads within the session wait for the txs committed within it to be indexed
+	ReadYourWrites bool
+	// ReadYourWritesTimeout bounds the time reads wait for the session writes to be indexed, a server default is used when 0
+	ReadYourWritesTimeout time.Duration
+	// ValueCacheSize is the max number of entries kept in the client-side cache of Get and GetAt, zero disables it.
+	// Latest values are served as of the latest tx the client knows about, through its own writes or the states
+	// it reads e.g. with CurrentState, writes of other clients are seen once a later tx is known
+	ValueCacheSize int
+	// SessionVars are custom session attributes the SQL policies are evaluated with
+	SessionVars map[string]string
 }
 
 // DefaultOptions ...
 func DefaultOptions() *Options {
 	return &Options{
-		Dir:                 ".",
-		Address:             "127.0.0.1",
-		Port:                3322,
-		HealthCheckRetries:  5,
-		MTLs:                false,
-		Auth:                true,
-		MaxRecvMsgSize:      4 * 1024 * 1024, //4Mb
-		Config:              "configs/immuclient.toml",
-		DialOptions:         []grpc.DialOption{grpc.WithInsecure()},
-		PasswordReader:      c.DefaultPasswordReader,
-		Metrics:             true,
-		PidPath:             "",
-		LogFileName:         "",
-		ServerSigningPubKey: "",
-		StreamChunkSize:     stream.DefaultChunkSize,
-		HeartBeatFrequency:  time.Minute * 1,
+		Dir:                   ".",
+		Address:               "127.0.0.1",
+		Port:                  3322,
+		HealthCheckRetries:    5,
+		MTLs:                  false,
+		Auth:                  true,
+		MaxRecvMsgSize:        4 * 1024 * 1024, //4Mb
+		Config:                "configs/immuclient.toml",
+		DialOptions:           []grpc.DialOption{grpc.WithInsecure()},
+		PasswordReader:        c.DefaultPasswordReader,
+		Metrics:               true,
+		PidPath:               "",
+		LogFileName:           "",
+		ServerSigningPubKey:   "",
+		StreamChunkSize:       stream.DefaultChunkSize,
+		HeartBeatFrequency:    time.Minute * 1,
+		MaxPendingAsyncWrites: 64,
 	}
 }
 
@@ -166,8 +184,11 @@ func (o *Options) WithDialOptions(dialOptions []grpc.DialOption) *Options {
 	return o
 }
 
-// Bind concatenates address and port
+// Bind concatenates address and port, unix socket addresses are dialed as they are and the port is ignored
 func (o *Options) Bind() string {
+	if strings.HasPrefix(o.Address, UnixSocketScheme) {
+		return o.Address
+	}
 	return o.Address + ":" + strconv.Itoa(o.Port)
 }
 
@@ -207,12 +228,42 @@ func (o *Options) WithStreamChunkSize(streamChunkSize int) *Options {
 	return o
 }
 
-// WithHeartBeatFrequency set the keep alive message frequency
+// WithHeartBeatFrequency set the keep alive message frequency, keep alive messages are not sent when it's zero
 func (o *Options) WithHeartBeatFrequency(heartBeatFrequency time.Duration) *Options {
 	o.HeartBeatFrequency = heartBeatFrequency
 	return o
 }
 
+// WithMaxPendingAsyncWrites set the maximum number of async writes in flight
+func (o *Options) WithMaxPendingAsyncWrites(maxPendingAsyncWrites int) *Options {
+	o.MaxPendingAsyncWrites = maxPendingAsyncWrites
+	return o
+}
+
+// WithReadYourWrites set whether reads within the session wait for the txs committed within it to be indexed
+func (o *Options) WithReadYourWrites(readYourWrites bool) *Options {
+	o.ReadYourWrites = readYourWrites
+	return o
+}
+
+// WithReadYourWritesTimeout set the max time reads wait for the session writes to be indexed
+func (o *Options) WithReadYourWritesTimeout(timeout time.Duration) *Options {
+	o.ReadYourWritesTimeout = timeout
+	return o
+}
+
+// WithSessionVars set the custom session attributes the SQL policies are evaluated with
+func (o *Options) WithSessionVars(vars map[string]string) *Options {
+	o.SessionVars = vars
+	return o
+}
+
+// WithValueCacheSize set the max number of entries kept in the client-side cache of Get and GetAt
+func (o *Options) WithValueCacheSize(valueCacheSize int) *Options {
+	o.ValueCacheSize = valueCacheSize
+	return o
+}
+
 func (o *Options) String() string {
 	optionsJSON, err := json.Marshal(o)
 	if err != nil {
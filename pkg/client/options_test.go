@@ -43,7 +43,8 @@ func TestOptions(t *testing.T) {
 		WithUsername("some-username").
 		WithPassword("some-password").
 		WithDatabase("some-db").
-		WithStreamChunkSize(4096)
+		WithStreamChunkSize(4096).
+		WithMaxPendingAsyncWrites(8)
 
 	if op.LogFileName != "logfilename" ||
 		op.PidPath != "pidpath" ||
@@ -65,8 +66,16 @@ func TestOptions(t *testing.T) {
 		op.Password != "some-password" ||
 		op.Database != "some-db" ||
 		op.StreamChunkSize != 4096 ||
+		op.MaxPendingAsyncWrites != 8 ||
 		op.Bind() != "127.0.0.1:4321" ||
 		len(op.String()) == 0 {
 		t.Fatal("Client options fail")
 	}
 }
+
+func TestOptionsUnixSocket(t *testing.T) {
+	op := DefaultOptions().WithAddress("unix:///var/run/immudb.sock").WithPort(4321)
+	if op.Bind() != "unix:///var/run/immudb.sock" {
+		t.Fatal("unix socket address must be dialed as it is")
+	}
+}
@@ -0,0 +1,356 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"fmt"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/client/errors"
+	"github.com/codenotary/immudb/pkg/database"
+	"github.com/codenotary/immudb/pkg/signer"
+)
+
+// ProofRequest selects the proof to be exported by ExportProof
+type ProofRequest struct {
+	Type schema.ProofType
+
+	// Tx is the transaction proven by TX_INCLUSION proofs
+	Tx uint64
+
+	// Key and AtTx select the entry proven by ENTRY_INCLUSION proofs, the latest entry is used when AtTx is 0
+	Key  []byte
+	AtTx uint64
+
+	// SourceState is the trusted state proven to be consistent with the current one by CONSISTENCY proofs,
+	// the locally stored state is used when not provided
+	SourceState *schema.ImmutableState
+}
+
+// ExportProof builds a self-contained proof bundle anchored to the current state of the database.
+// The bundle is verified before being returned and can be serialized with ToBytes or ToJSON,
+// so it can be stored and later verified offline with VerifyProofBundle.
+func (c *immuClient) ExportProof(ctx context.Context, req *ProofRequest) (*schema.ProofBundle, error) {
+	if req == nil {
+		return nil, ErrIllegalArguments
+	}
+
+	var bundle *schema.ProofBundle
+	var err error
+
+	switch req.Type {
+	case schema.ProofType_TX_INCLUSION:
+		bundle, err = c.exportTxProof(ctx, req.Tx)
+	case schema.ProofType_ENTRY_INCLUSION:
+		bundle, err = c.exportEntryProof(ctx, req.Key, req.AtTx)
+	case schema.ProofType_CONSISTENCY:
+		bundle, err = c.exportConsistencyProof(ctx, req.SourceState)
+	default:
+		return nil, fmt.Errorf("%w: unsupported proof type %d", ErrIllegalArguments, req.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = VerifyProofBundle(bundle, c.serverSigningPubKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return bundle, nil
+}
+
+func (c *immuClient) exportTxProof(ctx context.Context, tx uint64) (*schema.ProofBundle, error) {
+	if tx == 0 {
+		return nil, fmt.Errorf("%w: no tx provided", ErrIllegalArguments)
+	}
+
+	state, err := c.CurrentState(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if state.TxId < tx {
+		return nil, fmt.Errorf("%w: tx %d was not yet committed", ErrIllegalArguments, tx)
+	}
+
+	vTx, err := c.ServiceClient.VerifiableTxById(ctx, &schema.VerifiableTxRequest{
+		Tx:           tx,
+		ProveSinceTx: state.TxId,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &schema.ProofBundle{
+		Version:      schema.ProofBundleVersion,
+		Type:         schema.ProofType_TX_INCLUSION,
+		State:        state,
+		VerifiableTx: vTx,
+	}, nil
+}
+
+func (c *immuClient) exportEntryProof(ctx context.Context, key []byte, atTx uint64) (*schema.ProofBundle, error) {
+	if len(key) == 0 {
+		return nil, fmt.Errorf("%w: no key provided", ErrIllegalArguments)
+	}
+
+	if atTx == 0 {
+		// the entry tx is pinned so it can't be newer than the state the proof is anchored to
+		entry, err := c.Get(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+
+		atTx = entry.Tx
+		if entry.ReferencedBy != nil {
+			atTx = entry.ReferencedBy.Tx
+		}
+	}
+
+	state, err := c.CurrentState(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if state.TxId < atTx {
+		return nil, fmt.Errorf("%w: tx %d was not yet committed", ErrIllegalArguments, atTx)
+	}
+
+	vEntry, err := c.ServiceClient.VerifiableGet(ctx, &schema.VerifiableGetRequest{
+		KeyRequest: &schema.KeyRequest{
+			Key:  key,
+			AtTx: atTx,
+		},
+		ProveSinceTx: state.TxId,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &schema.ProofBundle{
+		Version:        schema.ProofBundleVersion,
+		Type:           schema.ProofType_ENTRY_INCLUSION,
+		State:          state,
+		VerifiableTx:   vEntry.VerifiableTx,
+		Entry:          vEntry.Entry,
+		InclusionProof: vEntry.InclusionProof,
+	}, nil
+}
+
+func (c *immuClient) exportConsistencyProof(ctx context.Context, sourceState *schema.ImmutableState) (*schema.ProofBundle, error) {
+	var err error
+
+	if sourceState == nil {
+		sourceState, err = c.StateService.GetState(ctx, c.Options.CurrentDatabase)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if sourceState.TxId == 0 {
+		return nil, fmt.Errorf("%w: source state is empty", ErrIllegalArguments)
+	}
+
+	state, err := c.CurrentState(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if state.TxId < sourceState.TxId {
+		return nil, fmt.Errorf("%w: source state at tx %d is newer than current state", ErrIllegalArguments, sourceState.TxId)
+	}
+
+	vTx, err := c.ServiceClient.VerifiableTxById(ctx, &schema.VerifiableTxRequest{
+		Tx:           state.TxId,
+		ProveSinceTx: sourceState.TxId,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &schema.ProofBundle{
+		Version:      schema.ProofBundleVersion,
+		Type:         schema.ProofType_CONSISTENCY,
+		State:        state,
+		SourceState:  sourceState,
+		VerifiableTx: vTx,
+	}, nil
+}
+
+// VerifyProofBundle checks the proofs held by the bundle without contacting any server.
+// The state the bundle is anchored to is checked against the given key when provided,
+// the source state of consistency proofs is trusted as is.
+func VerifyProofBundle(bundle *schema.ProofBundle, key *ecdsa.PublicKey) error {
+	err := bundle.Validate()
+	if err != nil {
+		return err
+	}
+
+	state := bundle.State
+
+	if len(state.TxHash) != sha256.Size {
+		return fmt.Errorf("%w: invalid state hash", schema.ErrInvalidProofBundle)
+	}
+
+	if key != nil {
+		err = checkStateSignature(state, key)
+		if err != nil {
+			return err
+		}
+	}
+
+	hdr := schema.TxHeaderFromProto(bundle.VerifiableTx.Tx.Header)
+	dualProof := schema.DualProofFromProto(bundle.VerifiableTx.DualProof)
+
+	switch bundle.Type {
+	case schema.ProofType_TX_INCLUSION:
+		// entries must hash to the tx header
+		tx := schema.TxFromProto(bundle.VerifiableTx.Tx)
+		if tx.Header().Alh() != hdr.Alh() {
+			return errors.NewCorruptedStateError(fmt.Errorf("%w: tx %d entries do not match its header", store.ErrCorruptedData, hdr.ID))
+		}
+	case schema.ProofType_ENTRY_INCLUSION:
+		err = verifyEntryInclusion(bundle.Entry, bundle.InclusionProof, hdr)
+		if err != nil {
+			return err
+		}
+	case schema.ProofType_CONSISTENCY:
+		sourceState := bundle.SourceState
+
+		if sourceState.Db != state.Db {
+			return fmt.Errorf("%w: source state belongs to database '%s'", schema.ErrInvalidProofBundle, sourceState.Db)
+		}
+
+		if hdr.ID != state.TxId {
+			return errors.NewCorruptedStateError(fmt.Errorf("%w: tx %d does not match the state", store.ErrCorruptedData, hdr.ID))
+		}
+
+		verifies := store.VerifyDualProof(
+			dualProof,
+			sourceState.TxId,
+			state.TxId,
+			schema.DigestFromProto(sourceState.TxHash),
+			schema.DigestFromProto(state.TxHash),
+		)
+		if !verifies {
+			return errors.NewCorruptedStateError(fmt.Errorf("%w: consistency proof between tx %d and tx %d does not verify", store.ErrCorruptedData, sourceState.TxId, state.TxId))
+		}
+
+		return nil
+	}
+
+	verifies := store.VerifyDualProof(
+		dualProof,
+		hdr.ID,
+		state.TxId,
+		hdr.Alh(),
+		schema.DigestFromProto(state.TxHash),
+	)
+	if !verifies {
+		return errors.NewCorruptedStateError(fmt.Errorf("%w: tx %d is not consistent with the state at tx %d", store.ErrCorruptedData, hdr.ID, state.TxId))
+	}
+
+	return nil
+}
+
+func verifyEntryInclusion(entry *schema.Entry, inclusionProof *schema.InclusionProof, hdr *store.TxHeader) error {
+	entrySpecDigest, err := store.EntrySpecDigestFor(hdr.Version)
+	if err != nil {
+		return err
+	}
+
+	var vTx uint64
+	var e *store.EntrySpec
+
+	if entry.ReferencedBy == nil {
+		vTx = entry.Tx
+		e = database.EncodeEntrySpec(entry.Key, schema.KVMetadataFromProto(entry.Metadata), entry.Value)
+	} else {
+		ref := entry.ReferencedBy
+		vTx = ref.Tx
+		e = database.EncodeReference(ref.Key, schema.KVMetadataFromProto(ref.Metadata), entry.Key, ref.AtTx)
+	}
+
+	if vTx != hdr.ID {
+		return fmt.Errorf("%w: entry was not written in tx %d", schema.ErrInvalidProofBundle, hdr.ID)
+	}
+
+	verifies := store.VerifyInclusion(
+		schema.InclusionProofFromProto(inclusionProof),
+		entrySpecDigest(e),
+		hdr.Eh,
+	)
+	if !verifies {
+		return errors.NewCorruptedStateError(fmt.Errorf("%w: entry is not included in tx %d", store.ErrCorruptedData, hdr.ID))
+	}
+
+	return nil
+}
+
+// VerifyProofBundleWithRoot checks the proofs held by the bundle against a previously saved trusted root,
+// without contacting any server. The trusted root must be signed with the given key, or with its own
+// embedded key when no key is provided, and the state the bundle is anchored to must be signed with the
+// same key. Whenever the bundle refers to the trusted root tx, either as its state or as the source state
+// of a consistency proof, its hash must match the one of the trusted root.
+func VerifyProofBundleWithRoot(bundle *schema.ProofBundle, root *schema.ImmutableState, key *ecdsa.PublicKey) error {
+	if root == nil || root.Signature == nil {
+		return schema.ErrStateNotSigned
+	}
+
+	var err error
+
+	if key == nil {
+		key, err = signer.UnmarshalKey(root.Signature.PublicKey)
+		if err != nil {
+			return err
+		}
+	}
+
+	ok, err := root.CheckSignature(key)
+	if err != nil {
+		return fmt.Errorf("%w: %v", schema.ErrInvalidRootSignature, err)
+	}
+	if !ok {
+		return schema.ErrInvalidRootSignature
+	}
+
+	err = VerifyProofBundle(bundle, key)
+	if err != nil {
+		return err
+	}
+
+	if bundle.State.Db != root.Db {
+		return fmt.Errorf("%w: bundle belongs to database '%s'", schema.ErrInvalidProofBundle, bundle.State.Db)
+	}
+
+	if bundle.State.TxId == root.TxId && !bytes.Equal(bundle.State.TxHash, root.TxHash) {
+		return errors.NewCorruptedStateError(fmt.Errorf("%w: hash mismatch at tx %d", store.ErrCorruptedData, root.TxId))
+	}
+
+	if bundle.Type == schema.ProofType_CONSISTENCY && bundle.SourceState.TxId == root.TxId &&
+		!bytes.Equal(bundle.SourceState.TxHash, root.TxHash) {
+		return errors.NewCorruptedStateError(fmt.Errorf("%w: hash mismatch at tx %d", store.ErrCorruptedData, root.TxId))
+	}
+
+	return nil
+}
@@ -0,0 +1,52 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package client
+
+import (
+	"context"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/client/errors"
+	"github.com/golang/protobuf/ptypes/empty"
+)
+
+// SetRedactionRule registers how the values under a key prefix or of a SQL column are redacted
+// when read by users with restricted read permission
+func (c *immuClient) SetRedactionRule(ctx context.Context, rule *schema.RedactionRule) (*schema.TxHeader, error) {
+	if !c.IsConnected() {
+		return nil, errors.FromError(ErrNotConnected)
+	}
+
+	return c.ServiceClient.SetRedactionRule(ctx, rule)
+}
+
+// DeleteRedactionRule removes the redaction rule registered for a key prefix or a SQL column
+func (c *immuClient) DeleteRedactionRule(ctx context.Context, req *schema.RedactionRuleRequest) (*schema.TxHeader, error) {
+	if !c.IsConnected() {
+		return nil, errors.FromError(ErrNotConnected)
+	}
+
+	return c.ServiceClient.DeleteRedactionRule(ctx, req)
+}
+
+// RedactionRules lists the redaction rules registered in the database
+func (c *immuClient) RedactionRules(ctx context.Context) (*schema.RedactionRuleList, error) {
+	if !c.IsConnected() {
+		return nil, errors.FromError(ErrNotConnected)
+	}
+
+	return c.ServiceClient.RedactionRules(ctx, &empty.Empty{})
+}
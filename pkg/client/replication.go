@@ -0,0 +1,62 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package client
+
+import (
+	"context"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/client/errors"
+)
+
+// ReplicationStatus returns the progress of the replication of a replica database
+func (c *immuClient) ReplicationStatus(ctx context.Context, database string) (*schema.ReplicationStatusResponse, error) {
+	if !c.IsConnected() {
+		return nil, errors.FromError(ErrNotConnected)
+	}
+
+	return c.ServiceClient.ReplicationStatus(ctx, &schema.ReplicationRequest{Database: database})
+}
+
+// PauseReplication suspends the replication of a replica database until it's resumed
+func (c *immuClient) PauseReplication(ctx context.Context, database string) error {
+	if !c.IsConnected() {
+		return errors.FromError(ErrNotConnected)
+	}
+
+	_, err := c.ServiceClient.PauseReplication(ctx, &schema.ReplicationRequest{Database: database})
+	return err
+}
+
+// ResumeReplication continues a paused replication
+func (c *immuClient) ResumeReplication(ctx context.Context, database string) error {
+	if !c.IsConnected() {
+		return errors.FromError(ErrNotConnected)
+	}
+
+	_, err := c.ServiceClient.ResumeReplication(ctx, &schema.ReplicationRequest{Database: database})
+	return err
+}
+
+// ResetReplication discards all the data of a replica database and replicates it again from scratch
+func (c *immuClient) ResetReplication(ctx context.Context, database string) error {
+	if !c.IsConnected() {
+		return errors.FromError(ErrNotConnected)
+	}
+
+	_, err := c.ServiceClient.ResetReplication(ctx, &schema.ReplicationRequest{Database: database})
+	return err
+}
@@ -41,9 +41,12 @@ func (c *immuClient) OpenSession(ctx context.Context, user []byte, pass []byte,
 	}
 
 	resp, err := c.ServiceClient.OpenSession(ctx, &schema.OpenSessionRequest{
-		Username:     user,
-		Password:     pass,
-		DatabaseName: database,
+		Username:              user,
+		Password:              pass,
+		DatabaseName:          database,
+		ReadYourWrites:        c.Options.ReadYourWrites,
+		ReadYourWritesTimeout: uint32(c.Options.ReadYourWritesTimeout.Milliseconds()),
+		SessionVars:           c.Options.SessionVars,
 	})
 	if err != nil {
 		return errors.FromError(err)
@@ -51,8 +54,11 @@ func (c *immuClient) OpenSession(ctx context.Context, user []byte, pass []byte,
 
 	c.SessionID = resp.GetSessionID()
 
-	c.HeartBeater = heartbeater.NewHeartBeater(resp.GetSessionID(), c.ServiceClient, c.Options.HeartBeatFrequency)
-	c.HeartBeater.KeepAlive(ctx)
+	if c.Options.HeartBeatFrequency > 0 {
+		// keep alive messages must outlive the context the session was opened with
+		c.HeartBeater = heartbeater.NewHeartBeater(resp.GetSessionID(), c.ServiceClient, c.Options.HeartBeatFrequency)
+		c.HeartBeater.KeepAlive(context.Background())
+	}
 
 	stateProvider := state.NewStateProvider(c.ServiceClient)
 
@@ -73,7 +79,7 @@ func (c *immuClient) CloseSession(ctx context.Context) error {
 		return errors.FromError(ErrNotConnected)
 	}
 
-	c.HeartBeater.Stop()
+	c.stopHeartBeater()
 
 	_, err := c.ServiceClient.CloseSession(ctx, new(empty.Empty))
 	if err != nil {
@@ -85,6 +91,13 @@ func (c *immuClient) CloseSession(ctx context.Context) error {
 	return nil
 }
 
+func (c *immuClient) stopHeartBeater() {
+	if c.HeartBeater != nil {
+		c.HeartBeater.Stop()
+		c.HeartBeater = nil
+	}
+}
+
 func (c *immuClient) GetSessionID() string {
 	return c.SessionID
 }
@@ -0,0 +1,138 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"io"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/client/errors"
+	"github.com/codenotary/immudb/pkg/stream"
+)
+
+// ZEntryStreamReader reads sorted set entries one at a time as they are received from the server.
+// Next returns io.EOF once all the entries were read. Close must be called to release the underlying stream.
+type ZEntryStreamReader interface {
+	Next() (*schema.ZEntry, error)
+	Close() error
+}
+
+// EntryStreamReader reads entries one at a time as they are received from the server.
+// Next returns io.EOF once all the entries were read. Close must be called to release the underlying stream.
+type EntryStreamReader interface {
+	Next() (*schema.Entry, error)
+	Close() error
+}
+
+type zEntryStreamReader struct {
+	zr        stream.ZStreamReceiver
+	chunkSize int
+	cancel    context.CancelFunc
+}
+
+func (r *zEntryStreamReader) Next() (*schema.ZEntry, error) {
+	set, key, score, atTx, vr, err := r.zr.Next()
+	if err == io.EOF {
+		return nil, io.EOF
+	}
+	if err != nil {
+		return nil, errors.FromError(err)
+	}
+
+	entry, err := stream.ParseZEntry(set, key, score, atTx, vr, r.chunkSize)
+	if err != nil {
+		return nil, errors.FromError(err)
+	}
+
+	return entry, nil
+}
+
+func (r *zEntryStreamReader) Close() error {
+	r.cancel()
+	return nil
+}
+
+type entryStreamReader struct {
+	kvr       stream.KvStreamReceiver
+	chunkSize int
+	cancel    context.CancelFunc
+}
+
+func (r *entryStreamReader) Next() (*schema.Entry, error) {
+	key, vr, err := r.kvr.Next()
+	if err == io.EOF {
+		return nil, io.EOF
+	}
+	if err != nil {
+		return nil, errors.FromError(err)
+	}
+
+	value, err := stream.ReadValue(vr, r.chunkSize)
+	if err == io.EOF {
+		return nil, errors.New(stream.ErrMissingExpectedData)
+	}
+	if err != nil {
+		return nil, errors.FromError(err)
+	}
+
+	return &schema.Entry{
+		Key:   key,
+		Value: value,
+	}, nil
+}
+
+func (r *entryStreamReader) Close() error {
+	r.cancel()
+	return nil
+}
+
+// StreamZScanReader scans a sorted set returning a reader over the entries sent by the server,
+// so sets of any size can be consumed with bounded memory. A zero limit reads all the entries.
+func (c *immuClient) StreamZScanReader(ctx context.Context, req *schema.ZScanRequest) (ZEntryStreamReader, error) {
+	sctx, cancel := context.WithCancel(ctx)
+
+	zs, err := c.streamZScan(sctx, req)
+	if err != nil {
+		cancel()
+		return nil, errors.FromError(err)
+	}
+
+	return &zEntryStreamReader{
+		zr:        c.StreamServiceFactory.NewZStreamReceiver(c.StreamServiceFactory.NewMsgReceiver(zs)),
+		chunkSize: c.Options.StreamChunkSize,
+		cancel:    cancel,
+	}, nil
+}
+
+// StreamHistoryReader returns a reader over the history of a key as sent by the server,
+// so histories of any length can be consumed with bounded memory. A zero limit reads the whole history.
+func (c *immuClient) StreamHistoryReader(ctx context.Context, req *schema.HistoryRequest) (EntryStreamReader, error) {
+	sctx, cancel := context.WithCancel(ctx)
+
+	hs, err := c.streamHistory(sctx, req)
+	if err != nil {
+		cancel()
+		return nil, errors.FromError(err)
+	}
+
+	return &entryStreamReader{
+		kvr:       c.StreamServiceFactory.NewKvStreamReceiver(c.StreamServiceFactory.NewMsgReceiver(hs)),
+		chunkSize: c.Options.StreamChunkSize,
+		cancel:    cancel,
+	}, nil
+}
@@ -56,6 +56,11 @@ func TestImmuClient_Errors(t *testing.T) {
 		func() (string, error) { _, err := client.StreamScan(ctx, nil); return "StreamScan", err },
 		func() (string, error) { _, err := client.StreamZScan(ctx, nil); return "StreamZScan", err },
 		func() (string, error) { _, err := client.StreamHistory(ctx, nil); return "StreamHistory", err },
+		func() (string, error) { _, err := client.StreamZScanReader(ctx, nil); return "StreamZScanReader", err },
+		func() (string, error) {
+			_, err := client.StreamHistoryReader(ctx, nil)
+			return "StreamHistoryReader", err
+		},
 		func() (string, error) { _, err := client.StreamExecAll(ctx, nil); return "StreamExecAll", err },
 	}
 	for _, f := range fs {
@@ -199,7 +199,7 @@ func (c *immuClient) _streamVerifiedSet(ctx context.Context, kvs []*stream.KeyVa
 	}
 
 	if verifiableTx.Tx.Header.Nentries != int32(len(kvs)) || len(verifiableTx.Tx.Entries) != len(kvs) {
-		return nil, store.ErrCorruptedData
+		return nil, errors.NewCorruptedStateError(store.ErrCorruptedData)
 	}
 
 	tx := schema.TxFromProto(verifiableTx.Tx)
@@ -222,12 +222,12 @@ func (c *immuClient) _streamVerifiedSet(ctx context.Context, kvs []*stream.KeyVa
 
 		verifies = store.VerifyInclusion(inclusionProof, entrySpecDigest(e), tx.Header().Eh)
 		if !verifies {
-			return nil, store.ErrCorruptedData
+			return nil, errors.NewCorruptedStateError(store.ErrCorruptedData)
 		}
 	}
 
 	if tx.Header().Eh != schema.DigestFromProto(verifiableTx.DualProof.TargetTxHeader.EH) {
-		return nil, store.ErrCorruptedData
+		return nil, errors.NewCorruptedStateError(store.ErrCorruptedData)
 	}
 
 	var sourceID, targetID uint64
@@ -248,7 +248,7 @@ func (c *immuClient) _streamVerifiedSet(ctx context.Context, kvs []*stream.KeyVa
 		)
 
 		if !verifies {
-			return nil, store.ErrCorruptedData
+			return nil, errors.NewCorruptedStateError(store.ErrCorruptedData)
 		}
 	}
 
@@ -265,7 +265,7 @@ func (c *immuClient) _streamVerifiedSet(ctx context.Context, kvs []*stream.KeyVa
 			return nil, err
 		}
 		if !ok {
-			return nil, store.ErrCorruptedData
+			return nil, errors.NewCorruptedStateError(store.ErrCorruptedData)
 		}
 	}
 
@@ -349,7 +349,7 @@ func (c *immuClient) _streamVerifiedGet(ctx context.Context, req *schema.Verifia
 
 	verifies := store.VerifyInclusion(inclusionProof, entrySpecDigest(e), eh)
 	if !verifies {
-		return nil, store.ErrCorruptedData
+		return nil, errors.NewCorruptedStateError(store.ErrCorruptedData)
 	}
 
 	if state.TxId > 0 {
@@ -361,7 +361,7 @@ func (c *immuClient) _streamVerifiedGet(ctx context.Context, req *schema.Verifia
 			targetAlh,
 		)
 		if !verifies {
-			return nil, store.ErrCorruptedData
+			return nil, errors.NewCorruptedStateError(store.ErrCorruptedData)
 		}
 	}
 
@@ -378,7 +378,7 @@ func (c *immuClient) _streamVerifiedGet(ctx context.Context, req *schema.Verifia
 			return nil, err
 		}
 		if !ok {
-			return nil, store.ErrCorruptedData
+			return nil, errors.NewCorruptedStateError(store.ErrCorruptedData)
 		}
 	}
 
@@ -428,21 +428,18 @@ func (c *immuClient) _streamScan(ctx context.Context, req *schema.ScanRequest) (
 }
 
 func (c *immuClient) _streamZScan(ctx context.Context, req *schema.ZScanRequest) (*schema.ZEntries, error) {
-	gs, err := c.streamZScan(ctx, req)
+	zr, err := c.StreamZScanReader(ctx, req)
 	if err != nil {
 		return nil, err
 	}
-	zr := c.StreamServiceFactory.NewZStreamReceiver(c.StreamServiceFactory.NewMsgReceiver(gs))
+	defer zr.Close()
+
 	var entries []*schema.ZEntry
 	for {
-		set, key, score, atTx, vr, err := zr.Next()
-		if err != nil {
-			if err == io.EOF {
-				break
-			}
-			return nil, err
+		entry, err := zr.Next()
+		if err == io.EOF {
+			break
 		}
-		entry, err := stream.ParseZEntry(set, key, score, atTx, vr, c.Options.StreamChunkSize)
 		if err != nil {
 			return nil, err
 		}
@@ -452,32 +449,21 @@ func (c *immuClient) _streamZScan(ctx context.Context, req *schema.ZScanRequest)
 }
 
 func (c *immuClient) _streamHistory(ctx context.Context, req *schema.HistoryRequest) (*schema.Entries, error) {
-	gs, err := c.streamHistory(ctx, req)
+	hr, err := c.StreamHistoryReader(ctx, req)
 	if err != nil {
 		return nil, err
 	}
-	kvr := c.StreamServiceFactory.NewKvStreamReceiver(c.StreamServiceFactory.NewMsgReceiver(gs))
+	defer hr.Close()
+
 	var entries []*schema.Entry
 	for {
-		key, vr, err := kvr.Next()
-		if err != nil {
-			if err == io.EOF {
-				break
-			}
-			return nil, err
+		entry, err := hr.Next()
+		if err == io.EOF {
+			break
 		}
-		value, err := stream.ReadValue(vr, c.Options.StreamChunkSize)
 		if err != nil {
-			if err == io.EOF {
-				return nil, errors.New(stream.ErrMissingExpectedData)
-			}
 			return nil, err
 		}
-
-		entry := &schema.Entry{
-			Key:   key,
-			Value: value,
-		}
 		entries = append(entries, entry)
 	}
 	return &schema.Entries{Entries: entries}, nil
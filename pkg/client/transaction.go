@@ -30,6 +30,11 @@ type Tx interface {
 
 	SQLExec(ctx context.Context, sql string, params map[string]interface{}) error
 	SQLQuery(ctx context.Context, sql string, params map[string]interface{}) (*schema.SQLQueryResult, error)
+
+	// Set writes a key-value entry within the transaction, it's committed along with the SQL statements
+	Set(ctx context.Context, key []byte, value []byte) error
+	// SetAll writes the key-value entries within the transaction, they are committed along with the SQL statements
+	SetAll(ctx context.Context, kvList *schema.SetRequest) error
 }
 
 type tx struct {
@@ -85,6 +90,15 @@ func (c *tx) SQLQuery(ctx context.Context, sql string, params map[string]interfa
 	return res, errors.FromError(err)
 }
 
+func (c *tx) Set(ctx context.Context, key []byte, value []byte) error {
+	return c.SetAll(ctx, &schema.SetRequest{KVs: []*schema.KeyValue{{Key: key, Value: value}}})
+}
+
+func (c *tx) SetAll(ctx context.Context, kvList *schema.SetRequest) error {
+	_, err := c.ic.ServiceClient.TxSet(c.populateCtx(ctx), kvList)
+	return errors.FromError(err)
+}
+
 func (c *tx) GetTransactionID() string {
 	return c.transactionID
 }
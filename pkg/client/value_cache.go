@@ -0,0 +1,373 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"container/list"
+	"context"
+	"encoding/binary"
+	"sync"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/database"
+	"github.com/golang/protobuf/ptypes/empty"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+)
+
+// valueCacheSyncLimit is the max number of txs read to bring the cache up to date,
+// when more txs were committed the latest values of the database are dropped instead
+const valueCacheSyncLimit = 100
+
+// valueCache keeps the entries read by Get and GetAt, up to a max number of them, evicting the least recently used.
+// Entries read at a given tx never change, so they're served as they are. The latest values of a database are served
+// as of the latest tx the client knows about, the ones it committed and the ones of the states it read. Once a later
+// tx is known, the keys written by the txs committed since the cache was synced are read and evicted, so cached values
+// are served without any call to the server as long as no later tx is known
+type valueCache struct {
+	mutex sync.Mutex
+
+	maxEntries int
+	knownTx    map[string]uint64 // per database, the latest tx known to be committed
+	syncedTx   map[string]uint64 // per database, txs up to it were applied to the cache
+	entries    map[string]*list.Element
+	lru        *list.List
+}
+
+type cachedValue struct {
+	cacheKey string
+	db       string
+	atTx     uint64   // zero for the latest value of a key
+	keys     [][]byte // keys the value depends on, the requested one and the one it references
+	entry    *schema.Entry
+}
+
+func newValueCache(maxEntries int) *valueCache {
+	return &valueCache{
+		maxEntries: maxEntries,
+		knownTx:    make(map[string]uint64),
+		syncedTx:   make(map[string]uint64),
+		entries:    make(map[string]*list.Element),
+		lru:        list.New(),
+	}
+}
+
+func valueCacheKey(db string, key []byte, atTx uint64) string {
+	k := make([]byte, len(db)+1+8+len(key))
+	copy(k, db)
+	binary.BigEndian.PutUint64(k[len(db)+1:], atTx)
+	copy(k[len(db)+1+8:], key)
+	return string(k)
+}
+
+func (vc *valueCache) get(db string, key []byte, atTx uint64) (*schema.Entry, bool) {
+	vc.mutex.Lock()
+	defer vc.mutex.Unlock()
+
+	e, ok := vc.entries[valueCacheKey(db, key, atTx)]
+	if !ok {
+		return nil, false
+	}
+
+	vc.lru.MoveToFront(e)
+
+	return proto.Clone(e.Value.(*cachedValue).entry).(*schema.Entry), true
+}
+
+// put caches an entry. The latest value of a key is only cached if no tx was applied to the cache since syncedTx,
+// the tx the cache was synced to before reading it, as it may have been written by one of them
+func (vc *valueCache) put(db string, key []byte, atTx uint64, entry *schema.Entry, syncedTx uint64) {
+	// entries may expire and references not bound to a tx may be updated
+	if entry.GetMetadata().GetExpiration() != nil || entry.GetReferencedBy().GetMetadata().GetExpiration() != nil {
+		return
+	}
+	if atTx > 0 && entry.ReferencedBy != nil && entry.ReferencedBy.AtTx == 0 {
+		return
+	}
+
+	vc.mutex.Lock()
+	defer vc.mutex.Unlock()
+
+	if atTx == 0 && vc.syncedTx[db] != syncedTx {
+		return
+	}
+
+	cacheKey := valueCacheKey(db, key, atTx)
+
+	if e, ok := vc.entries[cacheKey]; ok {
+		vc.remove(e)
+	}
+
+	v := &cachedValue{
+		cacheKey: cacheKey,
+		db:       db,
+		atTx:     atTx,
+		keys:     [][]byte{key, entry.Key},
+		entry:    proto.Clone(entry).(*schema.Entry),
+	}
+
+	vc.entries[cacheKey] = vc.lru.PushFront(v)
+
+	for vc.lru.Len() > vc.maxEntries {
+		vc.remove(vc.lru.Back())
+	}
+}
+
+func (vc *valueCache) remove(e *list.Element) {
+	vc.lru.Remove(e)
+	delete(vc.entries, e.Value.(*cachedValue).cacheKey)
+}
+
+// observe records a tx known to be committed
+func (vc *valueCache) observe(db string, txID uint64) {
+	vc.mutex.Lock()
+	defer vc.mutex.Unlock()
+
+	if txID > vc.knownTx[db] {
+		vc.knownTx[db] = txID
+	}
+}
+
+func (vc *valueCache) known(db string) uint64 {
+	vc.mutex.Lock()
+	defer vc.mutex.Unlock()
+
+	return vc.knownTx[db]
+}
+
+func (vc *valueCache) synced(db string) (uint64, bool) {
+	vc.mutex.Lock()
+	defer vc.mutex.Unlock()
+
+	txID, ok := vc.syncedTx[db]
+	return txID, ok
+}
+
+// apply evicts the latest values of the keys written up to the given tx
+func (vc *valueCache) apply(db string, txID uint64, writtenKeys map[string]struct{}) {
+	vc.mutex.Lock()
+	defer vc.mutex.Unlock()
+
+	if len(writtenKeys) > 0 {
+		for e := vc.lru.Front(); e != nil; {
+			next := e.Next()
+
+			v := e.Value.(*cachedValue)
+
+			if v.db == db && v.atTx == 0 {
+				for _, k := range v.keys {
+					if _, ok := writtenKeys[string(k)]; ok {
+						vc.remove(e)
+						break
+					}
+				}
+			}
+
+			e = next
+		}
+	}
+
+	if txID > vc.syncedTx[db] {
+		vc.syncedTx[db] = txID
+	}
+}
+
+// reset drops the latest values of a database, considering the cache synced up to the given tx
+func (vc *valueCache) reset(db string, txID uint64) {
+	vc.mutex.Lock()
+	defer vc.mutex.Unlock()
+
+	for e := vc.lru.Front(); e != nil; {
+		next := e.Next()
+
+		v := e.Value.(*cachedValue)
+		if v.db == db && v.atTx == 0 {
+			vc.remove(e)
+		}
+
+		e = next
+	}
+
+	vc.syncedTx[db] = txID
+}
+
+func (c *immuClient) getValueCache() *valueCache {
+	c.valueCacheMutex.Lock()
+	defer c.valueCacheMutex.Unlock()
+
+	if c.valueCache == nil {
+		c.valueCache = newValueCache(c.Options.ValueCacheSize)
+	}
+
+	return c.valueCache
+}
+
+// syncValueCache evicts the cached values written by the txs committed since the cache was last synced,
+// returning the tx the cache was synced to. The server is only called when a tx later than it is known
+func (c *immuClient) syncValueCache(ctx context.Context, vc *valueCache, db string) (uint64, error) {
+	syncedTx, ok := vc.synced(db)
+	if !ok {
+		return c.resetValueCache(ctx, vc, db)
+	}
+
+	if vc.known(db) <= syncedTx {
+		return syncedTx, nil
+	}
+
+	txs, err := c.ServiceClient.TxScan(ctx, &schema.TxScanRequest{
+		InitialTx: syncedTx + 1,
+		Limit:     valueCacheSyncLimit,
+		EntriesSpec: &schema.EntriesSpec{
+			KvEntriesSpec: &schema.EntryTypeSpec{Action: schema.EntryTypeAction_ONLY_DIGEST},
+		},
+		NoWait: true,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if len(txs.Txs) == 0 {
+		return syncedTx, nil
+	}
+
+	if len(txs.Txs) == valueCacheSyncLimit {
+		return c.resetValueCache(ctx, vc, db)
+	}
+
+	writtenKeys := make(map[string]struct{})
+
+	for _, tx := range txs.Txs {
+		for _, e := range tx.Entries {
+			if len(e.Key) > 0 && e.Key[0] == database.SetKeyPrefix {
+				writtenKeys[string(e.Key[1:])] = struct{}{}
+			}
+		}
+	}
+
+	lastTxID := txs.Txs[len(txs.Txs)-1].Header.Id
+
+	vc.apply(db, lastTxID, writtenKeys)
+
+	return lastTxID, nil
+}
+
+func (c *immuClient) resetValueCache(ctx context.Context, vc *valueCache, db string) (uint64, error) {
+	state, err := c.ServiceClient.CurrentState(ctx, &empty.Empty{})
+	if err != nil {
+		return 0, err
+	}
+
+	vc.reset(db, state.TxId)
+
+	return state.TxId, nil
+}
+
+// cachedGet reads an entry through the value cache, the latest value of the key when atTx is zero
+func (c *immuClient) cachedGet(ctx context.Context, key []byte, atTx uint64) (*schema.Entry, error) {
+	vc := c.getValueCache()
+	db := c.Options.CurrentDatabase
+
+	var syncedTx uint64
+
+	if atTx == 0 {
+		var err error
+
+		syncedTx, err = c.syncValueCache(ctx, vc, db)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if entry, ok := vc.get(db, key, atTx); ok {
+		return entry, nil
+	}
+
+	entry, err := c.ServiceClient.Get(ctx, &schema.KeyRequest{Key: key, AtTx: atTx})
+	if err != nil {
+		return nil, err
+	}
+
+	vc.put(db, key, atTx, entry, syncedTx)
+
+	return entry, nil
+}
+
+// committedTxID returns the latest tx a response shows to be committed, zero if none
+func committedTxID(reply interface{}) uint64 {
+	switch r := reply.(type) {
+	case *schema.TxHeader:
+		return r.GetId()
+	case *schema.VerifiableTx:
+		return r.GetTx().GetHeader().GetId()
+	case *schema.ImmutableState:
+		return r.GetTxId()
+	case *schema.ExecScriptResult:
+		return r.GetTxHeader().GetId()
+	case *schema.SQLExecResult:
+		var txID uint64
+		for _, tx := range r.GetTxs() {
+			if tx.GetHeader().GetId() > txID {
+				txID = tx.GetHeader().GetId()
+			}
+		}
+		return txID
+	}
+
+	return 0
+}
+
+// valueCacheInterceptor records the txs the responses of the server show to be committed
+func (c *immuClient) valueCacheInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	err := invoker(ctx, method, req, reply, cc, opts...)
+	if err != nil {
+		return err
+	}
+
+	if txID := committedTxID(reply); txID > 0 {
+		c.getValueCache().observe(c.Options.CurrentDatabase, txID)
+	}
+
+	return nil
+}
+
+// valueCacheStreamInterceptor records the txs the streamed responses of the server show to be committed
+func (c *immuClient) valueCacheStreamInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	s, err := streamer(ctx, desc, cc, method, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &valueCacheStream{ClientStream: s, c: c}, nil
+}
+
+type valueCacheStream struct {
+	grpc.ClientStream
+	c *immuClient
+}
+
+func (s *valueCacheStream) RecvMsg(m interface{}) error {
+	err := s.ClientStream.RecvMsg(m)
+	if err != nil {
+		return err
+	}
+
+	if txID := committedTxID(m); txID > 0 {
+		s.c.getValueCache().observe(s.c.Options.CurrentDatabase, txID)
+	}
+
+	return nil
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"testing"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValueCache(t *testing.T) {
+	vc := newValueCache(2)
+
+	_, ok := vc.synced("db1")
+	require.False(t, ok)
+
+	vc.reset("db1", 10)
+
+	entry := func(key, value string) *schema.Entry {
+		return &schema.Entry{Key: []byte(key), Value: []byte(value)}
+	}
+
+	vc.put("db1", []byte("key1"), 0, entry("key1", "value1"), 10)
+	vc.put("db1", []byte("key1"), 5, entry("key1", "value0"), 0)
+
+	e, ok := vc.get("db1", []byte("key1"), 0)
+	require.True(t, ok)
+	require.Equal(t, []byte("value1"), e.Value)
+
+	e, ok = vc.get("db1", []byte("key1"), 5)
+	require.True(t, ok)
+	require.Equal(t, []byte("value0"), e.Value)
+
+	_, ok = vc.get("db2", []byte("key1"), 0)
+	require.False(t, ok)
+
+	t.Run("the least recently used entry should be evicted", func(t *testing.T) {
+		vc.put("db1", []byte("key2"), 0, entry("key2", "value2"), 10)
+
+		_, ok := vc.get("db1", []byte("key1"), 0)
+		require.False(t, ok)
+
+		_, ok = vc.get("db1", []byte("key1"), 5)
+		require.True(t, ok)
+	})
+
+	t.Run("written keys should be evicted", func(t *testing.T) {
+		vc.apply("db1", 12, map[string]struct{}{"key2": {}})
+
+		txID, ok := vc.synced("db1")
+		require.True(t, ok)
+		require.Equal(t, uint64(12), txID)
+
+		_, ok = vc.get("db1", []byte("key2"), 0)
+		require.False(t, ok)
+
+		// entries read at a given tx are kept
+		_, ok = vc.get("db1", []byte("key1"), 5)
+		require.True(t, ok)
+	})
+
+	t.Run("values read before the cache was synced should not be cached", func(t *testing.T) {
+		vc.put("db1", []byte("key3"), 0, entry("key3", "value3"), 10)
+
+		_, ok := vc.get("db1", []byte("key3"), 0)
+		require.False(t, ok)
+	})
+
+	t.Run("the latest known tx should be kept", func(t *testing.T) {
+		require.Zero(t, vc.known("db1"))
+
+		vc.observe("db1", committedTxID(&schema.TxHeader{Id: 14}))
+		vc.observe("db1", committedTxID(&schema.ImmutableState{TxId: 13}))
+		require.Equal(t, uint64(14), vc.known("db1"))
+
+		vc.observe("db1", committedTxID(&schema.SQLExecResult{Txs: []*schema.CommittedSQLTx{
+			{Header: &schema.TxHeader{Id: 16}},
+			{Header: &schema.TxHeader{Id: 15}},
+		}}))
+		require.Equal(t, uint64(16), vc.known("db1"))
+
+		require.Zero(t, committedTxID(&schema.Entry{Tx: 20}))
+		require.Zero(t, vc.known("db2"))
+	})
+
+	t.Run("values which may change should not be cached", func(t *testing.T) {
+		expiring := entry("key4", "value4")
+		expiring.Metadata = &schema.KVMetadata{Expiration: &schema.Expiration{ExpiresAt: 1}}
+
+		vc.put("db1", []byte("key4"), 0, expiring, 12)
+
+		_, ok := vc.get("db1", []byte("key4"), 0)
+		require.False(t, ok)
+
+		ref := entry("key1", "value1")
+		ref.ReferencedBy = &schema.Reference{Key: []byte("ref1")}
+
+		vc.put("db1", []byte("ref1"), 7, ref, 0)
+
+		_, ok = vc.get("db1", []byte("ref1"), 7)
+		require.False(t, ok)
+	})
+}
@@ -25,7 +25,8 @@ import (
 
 // ExecAll like Set it permits many insertions at once.
 // The difference is that is possible to to specify a list of a mix of key value set and zAdd insertions.
-// If zAdd reference is not yet present on disk it's possible to add it as a regular key value and the reference is done onFly
+// If zAdd reference is not yet present on disk it's possible to add it as a regular key value and the reference is done onFly.
+// References are written along with a reverse entry, thus they count twice against the max number of entries per tx
 func (d *db) ExecAll(req *schema.ExecAllRequest) (*schema.TxHeader, error) {
 	if req == nil {
 		return nil, store.ErrIllegalArguments
@@ -42,6 +43,14 @@ func (d *db) ExecAll(req *schema.ExecAllRequest) (*schema.TxHeader, error) {
 		return nil, ErrIsReplica
 	}
 
+	if d.isInMaintenance() {
+		return nil, ErrIsInMaintenance
+	}
+
+	if d.isDiskQuotaExceeded() {
+		return nil, ErrDiskQuotaExceeded
+	}
+
 	if !req.NoWait {
 		lastTxID, _ := d.st.Alh()
 		err := d.st.WaitForIndexingUpto(lastTxID, nil)
@@ -50,6 +59,41 @@ func (d *db) ExecAll(req *schema.ExecAllRequest) (*schema.TxHeader, error) {
 		}
 	}
 
+	reverseIndex := d.options.reverseReferenceIndex
+
+	if reverseIndex {
+		// each reference is written along with its reverse entry, so it takes two entries of the tx
+		txEntries := len(req.Operations)
+
+		for _, op := range req.Operations {
+			if _, ok := op.Operation.(*schema.Op_Ref); ok {
+				txEntries++
+			}
+		}
+
+		if txEntries > d.st.MaxTxEntries() {
+			return nil, fmt.Errorf("%w: %d entries are needed but only %d are allowed per tx, references take two entries",
+				store.ErrorMaxTxEntriesLimitExceeded, txEntries, d.st.MaxTxEntries())
+		}
+	}
+
+	kvSchemas, err := d.kvSchemaRegistry()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, op := range req.Operations {
+		kv, ok := op.Operation.(*schema.Op_Kv)
+		if !ok || kv.Kv.Metadata.GetDeleted() {
+			continue
+		}
+
+		err = kvSchemas.validate(kv.Kv.Key, kv.Kv.Value)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	callback := func(txID uint64, index store.KeyIndex) ([]*store.EntrySpec, error) {
 		entries := make([]*store.EntrySpec, len(req.Operations))
 
@@ -125,11 +169,25 @@ func (d *db) ExecAll(req *schema.ExecAllRequest) (*schema.TxHeader, error) {
 					}
 				}
 
+				md := expirationMetadata(x.Ref.Expiration)
+
 				// reference arguments are converted in regular key value items and then atomically inserted
 				if x.Ref.BoundRef && x.Ref.AtTx == 0 {
-					e = EncodeReference(x.Ref.Key, nil, x.Ref.ReferencedKey, txID)
+					e = EncodeReference(x.Ref.Key, md, x.Ref.ReferencedKey, txID)
 				} else {
-					e = EncodeReference(x.Ref.Key, nil, x.Ref.ReferencedKey, x.Ref.AtTx)
+					e = EncodeReference(x.Ref.Key, md, x.Ref.ReferencedKey, x.Ref.AtTx)
+				}
+
+				if reverseIndex {
+					// reverse entries are placed after the ones of the operations, the ones left by the
+					// previous target of the reference are removed as well, which is validated along with
+					// the rest of the entries against the max number of entries per tx
+					reverseEntries, err := d.reverseReferenceEntries(x.Ref.Key, md, x.Ref.ReferencedKey, index)
+					if err != nil {
+						return nil, err
+					}
+
+					entries = append(entries, reverseEntries...)
 				}
 
 			case *schema.Op_ZAdd:
@@ -176,6 +234,8 @@ func (d *db) ExecAll(req *schema.ExecAllRequest) (*schema.TxHeader, error) {
 				} else {
 					e = EncodeZAdd(x.ZAdd.Set, x.ZAdd.Score, key, x.ZAdd.AtTx)
 				}
+
+				e.Metadata = expirationMetadata(x.ZAdd.Expiration)
 			}
 
 			entries[i] = e
@@ -16,6 +16,7 @@ limitations under the License.
 package database
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log"
@@ -159,12 +160,12 @@ func TestSetBatch(t *testing.T) {
 		for i := 0; i < batchSize; i++ {
 			key := []byte(strconv.FormatUint(uint64(i), 10))
 			value := []byte(strconv.FormatUint(uint64(b*batchSize+batchSize+i), 10))
-			entry, err := db.Get(&schema.KeyRequest{Key: key, SinceTx: txhdr.Id})
+			entry, err := db.Get(context.Background(), &schema.KeyRequest{Key: key, SinceTx: txhdr.Id})
 			require.NoError(t, err)
 			require.Equal(t, value, entry.Value)
 			require.Equal(t, uint64(b+2), entry.Tx)
 
-			vitem, err := db.VerifiableGet(&schema.VerifiableGetRequest{KeyRequest: &schema.KeyRequest{Key: key}}) //no prev root
+			vitem, err := db.VerifiableGet(context.Background(), &schema.VerifiableGetRequest{KeyRequest: &schema.KeyRequest{Key: key}}) //no prev root
 			require.NoError(t, err)
 			require.Equal(t, key, vitem.Entry.Key)
 			require.Equal(t, value, vitem.Entry.Value)
@@ -282,7 +283,7 @@ func TestExecAllOps(t *testing.T) {
 	zScanOpt := &schema.ZScanRequest{
 		Set: []byte(`mySet`),
 	}
-	zList, err := db.ZScan(zScanOpt)
+	zList, err := db.ZScan(context.Background(), zScanOpt)
 	require.NoError(t, err)
 	println(len(zList.Entries))
 	require.Len(t, zList.Entries, batchCount*batchSize)
@@ -339,7 +340,7 @@ func TestExecAllOpsZAddOnMixedAlreadyPersitedNotPersistedItems(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, uint64(3), index.Id)
 
-	list, err := db.ZScan(&schema.ZScanRequest{
+	list, err := db.ZScan(context.Background(), &schema.ZScanRequest{
 		Set:     []byte(`mySet`),
 		SinceTx: index.Id,
 	})
@@ -649,7 +650,7 @@ func TestStore_ExecAllOpsConcurrent(t *testing.T) {
 	for i := 1; i <= 10; i++ {
 		set := strconv.FormatUint(uint64(i), 10)
 
-		zList, err := db.ZScan(&schema.ZScanRequest{
+		zList, err := db.ZScan(context.Background(), &schema.ZScanRequest{
 			Set:     []byte(set),
 			SinceTx: 11,
 		})
@@ -749,7 +750,7 @@ func TestExecAllNoWait(t *testing.T) {
 		hdr, err := db.ExecAll(aOps)
 		require.NoError(t, err)
 
-		entry, err := db.Get(&schema.KeyRequest{Key: []byte("key"), SinceTx: hdr.Id})
+		entry, err := db.Get(context.Background(), &schema.KeyRequest{Key: []byte("key"), SinceTx: hdr.Id})
 		require.NoError(t, err)
 		require.NotNil(t, entry)
 		require.Equal(t, []byte(`key1`), entry.Key)
@@ -759,11 +760,11 @@ func TestExecAllNoWait(t *testing.T) {
 		require.Equal(t, hdr.Id, entry.ReferencedBy.Tx)
 
 		// ref became a reference of a reference
-		_, err = db.Get(&schema.KeyRequest{Key: []byte("ref")})
+		_, err = db.Get(context.Background(), &schema.KeyRequest{Key: []byte("ref")})
 		require.ErrorIs(t, err, ErrMaxKeyResolutionLimitReached)
 
 		// "key" became a reference
-		_, err = db.ZScan(&schema.ZScanRequest{
+		_, err = db.ZScan(context.Background(), &schema.ZScanRequest{
 			Set: []byte("set"),
 		})
 		require.ErrorIs(t, err, ErrMaxKeyResolutionLimitReached)
@@ -1182,7 +1183,7 @@ func TestOps_ReferenceKeyAlreadyPersisted(t *testing.T) {
 	_, err = db.ExecAll(aOps)
 	require.Equal(t, ErrFinalKeyCannotBeConvertedIntoReference, err)
 
-	ref, err := db.Get(&schema.KeyRequest{Key: []byte(`myReference`), SinceTx: idx1.Id})
+	ref, err := db.Get(context.Background(), &schema.KeyRequest{Key: []byte(`myReference`), SinceTx: idx1.Id})
 	require.NoError(t, err)
 	require.NotEmptyf(t, ref, "Should not be empty")
 	require.Equal(t, []byte(`persistedVal`), ref.Value, "Should have referenced item value")
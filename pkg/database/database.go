@@ -17,6 +17,7 @@ limitations under the License.
 package database
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/binary"
 	"errors"
@@ -29,6 +30,8 @@ import (
 
 	"github.com/codenotary/immudb/embedded/sql"
 	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/embedded/tbtree"
+	"github.com/codenotary/immudb/embedded/watchers"
 
 	"github.com/codenotary/immudb/pkg/api/schema"
 	"github.com/codenotary/immudb/pkg/logger"
@@ -44,6 +47,7 @@ var ErrMaxKeyScanLimitExceeded = errors.New("max key scan limit exceeded")
 var ErrIllegalArguments = store.ErrIllegalArguments
 var ErrIllegalState = store.ErrIllegalState
 var ErrIsReplica = errors.New("database is read-only because it's a replica")
+var ErrIsInMaintenance = errors.New("database is read-only because it's in maintenance mode")
 var ErrNotReplica = errors.New("database is NOT a replica")
 
 type DB interface {
@@ -55,7 +59,24 @@ type DB interface {
 	AsReplica(asReplica bool)
 	IsReplica() bool
 
+	AsInMaintenance(inMaintenance bool)
+	IsInMaintenance() bool
+
+	AllowAnonymousReads(allow bool)
+	AnonymousReadsAllowed() bool
+
+	EnableReverseReferenceIndex(enable bool)
+	ReverseReferenceIndexEnabled() bool
+
+	SetMaxDiskUsage(maxDiskUsage int64)
+	DiskUsage() (int64, error)
+	DiskQuotaExceeded() bool
+
+	KeyspaceStats(ctx context.Context, req *KeyspaceStatsRequest) (*KeyspaceStats, error)
+
 	UseTimeFunc(timeFunc store.TimeFunc) error
+	UpdateStoreOptions(storeOpts *store.Options) error
+	SetMemoryLimits(limits MemoryLimits) error
 
 	// State
 	Health() (waitingCount int, lastReleaseAt time.Time)
@@ -66,38 +87,68 @@ type DB interface {
 	Set(req *schema.SetRequest) (*schema.TxHeader, error)
 	VerifiableSet(req *schema.VerifiableSetRequest) (*schema.VerifiableTx, error)
 
-	Get(req *schema.KeyRequest) (*schema.Entry, error)
-	VerifiableGet(req *schema.VerifiableGetRequest) (*schema.VerifiableEntry, error)
-	GetAll(req *schema.KeyListRequest) (*schema.Entries, error)
+	Get(ctx context.Context, req *schema.KeyRequest) (*schema.Entry, error)
+	VerifiableGet(ctx context.Context, req *schema.VerifiableGetRequest) (*schema.VerifiableEntry, error)
+	GetInclusionProof(ctx context.Context, key []byte, atTx uint64) (*EntryInclusionProof, error)
+	GetAll(ctx context.Context, req *schema.KeyListRequest) (*schema.Entries, error)
 
 	Delete(req *schema.DeleteKeysRequest) (*schema.TxHeader, error)
 
 	SetReference(req *schema.ReferenceRequest) (*schema.TxHeader, error)
 	VerifiableSetReference(req *schema.VerifiableReferenceRequest) (*schema.VerifiableTx, error)
+	ResolveAll(req *schema.ResolveAllRequest) (*schema.Entries, error)
+	Rename(req *schema.RenameRequest) (*schema.TxHeader, error)
 
-	Scan(req *schema.ScanRequest) (*schema.Entries, error)
+	Scan(ctx context.Context, req *schema.ScanRequest) (*schema.Entries, error)
 
-	History(req *schema.HistoryRequest) (*schema.Entries, error)
+	History(ctx context.Context, req *schema.HistoryRequest) (*schema.Entries, error)
 
 	ExecAll(operations *schema.ExecAllRequest) (*schema.TxHeader, error)
+	ExecScript(req *schema.ExecScriptRequest) (*schema.ExecScriptResult, error)
 
 	Count(prefix *schema.KeyPrefix) (*schema.EntryCount, error)
 	CountAll() (*schema.EntryCount, error)
 
 	ZAdd(req *schema.ZAddRequest) (*schema.TxHeader, error)
 	VerifiableZAdd(req *schema.VerifiableZAddRequest) (*schema.VerifiableTx, error)
-	ZScan(req *schema.ZScanRequest) (*schema.ZEntries, error)
+	ZScan(ctx context.Context, req *schema.ZScanRequest) (*schema.ZEntries, error)
+
+	// External roots
+	RegisterExternalRoot(root *schema.ExternalRoot) (*schema.TxHeader, error)
+	LatestExternalRoot(source string) (*schema.ExternalRoot, error)
+	ExternalRoots(req *schema.ExternalRootsRequest) (*schema.ExternalRootList, error)
+
+	// Schemas of the values
+	SetKVSchema(req *schema.KVSchema) (*schema.TxHeader, error)
+	DeleteKVSchema(req *schema.KVSchemaRequest) (*schema.TxHeader, error)
+	KVSchemas() (*schema.KVSchemaList, error)
+
+	// Redaction of the values read by users with restricted read permission
+	SetRedactionRule(req *schema.RedactionRule) (*schema.TxHeader, error)
+	DeleteRedactionRule(req *schema.RedactionRuleRequest) (*schema.TxHeader, error)
+	RedactionRules() (*schema.RedactionRuleList, error)
+	HasRedactionRules() (bool, error)
+	Redacted() DB
+
+	// Row-level security policies of the SQL tables
+	WithSessionAttributes(attrs map[string]interface{}) DB
+
+	// Multi-database transactions
+	PrepareMultiDBTx(txID string, databases []string, req *schema.SetRequest) (*PreparedTx, error)
+	RecoverMultiDBTx(txID string, databases []string, req *schema.SetRequest, preparedAt uint64) (*PreparedTx, error)
+	MultiDBTxCommitted(txID string) (bool, error)
 
 	// SQL-related
 	SQLExec(req *schema.SQLExecRequest, tx *sql.SQLTx) (ntx *sql.SQLTx, ctxs []*sql.SQLTx, err error)
 	SQLExecPrepared(stmts []sql.SQLStmt, namedParams []*schema.NamedParam, tx *sql.SQLTx) (ntx *sql.SQLTx, ctxs []*sql.SQLTx, err error)
+	SetInSQLTx(req *schema.SetRequest, tx *sql.SQLTx) error
 
 	InferParameters(sql string, tx *sql.SQLTx) (map[string]sql.SQLValueType, error)
 	InferParametersPrepared(stmt sql.SQLStmt, tx *sql.SQLTx) (map[string]sql.SQLValueType, error)
 
-	SQLQuery(req *schema.SQLQueryRequest, tx *sql.SQLTx) (*schema.SQLQueryResult, error)
-	SQLQueryPrepared(stmt *sql.SelectStmt, namedParams []*schema.NamedParam, tx *sql.SQLTx) (*schema.SQLQueryResult, error)
-	SQLQueryRowReader(stmt *sql.SelectStmt, tx *sql.SQLTx) (sql.RowReader, error)
+	SQLQuery(ctx context.Context, req *schema.SQLQueryRequest, tx *sql.SQLTx) (*schema.SQLQueryResult, error)
+	SQLQueryPrepared(ctx context.Context, stmt *sql.SelectStmt, namedParams []*schema.NamedParam, tx *sql.SQLTx) (*schema.SQLQueryResult, error)
+	SQLQueryRowReader(ctx context.Context, stmt *sql.SelectStmt, tx *sql.SQLTx) (sql.RowReader, error)
 
 	VerifiableSQLGet(req *schema.VerifiableSQLGetRequest) (*schema.VerifiableSQLEntry, error)
 
@@ -108,15 +159,23 @@ type DB interface {
 	WaitForTx(txID uint64, cancellation <-chan struct{}) error
 	WaitForIndexingUpto(txID uint64, cancellation <-chan struct{}) error
 
-	TxByID(req *schema.TxRequest) (*schema.Tx, error)
+	TxByID(ctx context.Context, req *schema.TxRequest) (*schema.Tx, error)
 	ExportTxByID(req *schema.ExportTxRequest) ([]byte, error)
 	ReplicateTx(exportedTx []byte) (*schema.TxHeader, error)
-	VerifiableTxByID(req *schema.VerifiableTxRequest) (*schema.VerifiableTx, error)
-	TxScan(req *schema.TxScanRequest) (*schema.TxList, error)
+	VerifiableTxByID(ctx context.Context, req *schema.VerifiableTxRequest) (*schema.VerifiableTx, error)
+	TxScan(ctx context.Context, req *schema.TxScanRequest) (*schema.TxList, error)
 
 	// Maintenance
 	FlushIndex(req *schema.FlushIndexRequest) error
 	CompactIndex() error
+	IndexCompactionProgress() float32
+	IndexStats() (*tbtree.Stats, error)
+	Truncate(ctx context.Context, retention time.Duration) (uint64, error)
+	TruncationProgress() float32
+	CompactValueLogs() error
+	CommitLatency(p float64) (time.Duration, error)
+	Scrub() (store.ScrubStatus, error)
+	ScrubStatus() store.ScrubStatus
 
 	Close() error
 }
@@ -131,9 +190,17 @@ type db struct {
 
 	mutex *instrumentedRWMutex
 
+	kvSchemas      *kvSchemaRegistry
+	kvSchemasMutex sync.RWMutex
+
+	redactionRules      *redactionRuleSet
+	redactionRulesMutex sync.RWMutex
+
 	Logger  logger.Logger
 	options *Options
 
+	diskUsage diskUsageTracker
+
 	name string
 }
 
@@ -295,15 +362,89 @@ func NewDB(dbName string, op *Options, log logger.Logger) (DB, error) {
 	return dbi, nil
 }
 
+// readLatestValues returns the current values of the keys under prefix, deleted keys excluded.
+// It's meant for the metadata kept by the database itself, e.g. the registered schemas
+func (d *db) readLatestValues(prefix []byte) ([][]byte, error) {
+	lastTxID, _ := d.st.Alh()
+	err := d.st.WaitForIndexingUpto(lastTxID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	snap, err := d.st.SnapshotSince(lastTxID)
+	if err != nil {
+		return nil, err
+	}
+	defer snap.Close()
+
+	r, err := snap.NewKeyReader(
+		&store.KeyReaderSpec{
+			Prefix: prefix,
+			Filter: store.IgnoreDeleted,
+		})
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	var values [][]byte
+
+	for {
+		_, valRef, err := r.Read()
+		if err == store.ErrNoMoreEntries {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := valRef.Resolve()
+		if err != nil {
+			return nil, err
+		}
+
+		values = append(values, value)
+	}
+
+	return values, nil
+}
+
 func (d *db) isReplica() bool {
 	return d.options.replica
 }
 
+func (d *db) isInMaintenance() bool {
+	return d.options.maintenanceMode
+}
+
 // UseTimeFunc ...
 func (d *db) UseTimeFunc(timeFunc store.TimeFunc) error {
 	return d.st.UseTimeFunc(timeFunc)
 }
 
+// UpdateStoreOptions applies the store options which can be changed without reloading the database
+func (d *db) UpdateStoreOptions(storeOpts *store.Options) error {
+	return d.st.UpdateOptions(storeOpts)
+}
+
+// MemoryLimits bounds the memory taken by the caches and the SQL working memory of a database
+type MemoryLimits struct {
+	IndexCacheSize   int // index nodes
+	TxLogCacheSize   int // transactions
+	SQLDistinctLimit int // rows held in memory by DISTINCT clauses
+}
+
+// SetMemoryLimits resizes the caches of the database, evicting entries if they're shrunk.
+// The SQL limit is applied to the transactions started afterwards
+func (d *db) SetMemoryLimits(limits MemoryLimits) error {
+	err := d.st.SetCacheSizes(limits.IndexCacheSize, limits.TxLogCacheSize)
+	if err != nil {
+		return err
+	}
+
+	return d.sqlEngine.SetDistinctLimit(limits.SQLDistinctLimit)
+}
+
 func (d *db) FlushIndex(req *schema.FlushIndexRequest) error {
 	if req == nil {
 		return store.ErrIllegalArguments
@@ -317,6 +458,31 @@ func (d *db) CompactIndex() error {
 	return d.st.CompactIndex()
 }
 
+// IndexCompactionProgress ...
+func (d *db) IndexCompactionProgress() float32 {
+	return d.st.IndexCompactionProgress()
+}
+
+// IndexStats returns an estimation of the space taken by the index
+func (d *db) IndexStats() (*tbtree.Stats, error) {
+	return d.st.IndexStats()
+}
+
+// CommitLatency returns the p-th percentile of the duration of the latest commits
+func (d *db) CommitLatency(p float64) (time.Duration, error) {
+	return d.st.CommitLatency(p)
+}
+
+// Scrub verifies every committed transaction, the corrupted ones are reported in the returned status
+func (d *db) Scrub() (store.ScrubStatus, error) {
+	return d.st.Scrub()
+}
+
+// ScrubStatus returns the status of the ongoing scrub or the last one
+func (d *db) ScrubStatus() store.ScrubStatus {
+	return d.st.ScrubStatus()
+}
+
 // Set ...
 func (d *db) Set(req *schema.SetRequest) (*schema.TxHeader, error) {
 	d.mutex.RLock()
@@ -326,6 +492,14 @@ func (d *db) Set(req *schema.SetRequest) (*schema.TxHeader, error) {
 		return nil, ErrIsReplica
 	}
 
+	if d.isInMaintenance() {
+		return nil, ErrIsInMaintenance
+	}
+
+	if d.isDiskQuotaExceeded() {
+		return nil, ErrDiskQuotaExceeded
+	}
+
 	return d.set(req)
 }
 
@@ -334,6 +508,16 @@ func (d *db) set(req *schema.SetRequest) (*schema.TxHeader, error) {
 		return nil, ErrIllegalArguments
 	}
 
+	kvSchemas, err := d.kvSchemaRegistry()
+	if err != nil {
+		return nil, err
+	}
+
+	err = kvSchemas.validateKVs(req.KVs)
+	if err != nil {
+		return nil, err
+	}
+
 	tx, err := d.st.NewWriteOnlyTx()
 	if err != nil {
 		return nil, err
@@ -377,7 +561,7 @@ func (d *db) set(req *schema.SetRequest) (*schema.TxHeader, error) {
 }
 
 //Get ...
-func (d *db) Get(req *schema.KeyRequest) (*schema.Entry, error) {
+func (d *db) Get(ctx context.Context, req *schema.KeyRequest) (*schema.Entry, error) {
 	if req == nil || len(req.Key) == 0 {
 		return nil, ErrIllegalArguments
 	}
@@ -394,7 +578,7 @@ func (d *db) Get(req *schema.KeyRequest) (*schema.Entry, error) {
 			waitUntilTx = currTxID
 		}
 
-		err := d.WaitForIndexingUpto(waitUntilTx, nil)
+		err := d.waitForIndexingUpto(ctx, waitUntilTx)
 		if err != nil {
 			return nil, err
 		}
@@ -529,6 +713,15 @@ func (d *db) WaitForIndexingUpto(txID uint64, cancellation <-chan struct{}) erro
 	return d.st.WaitForIndexingUpto(txID, cancellation)
 }
 
+// waitForIndexingUpto blocks caller until specified tx gets indexed, or the context is done
+func (d *db) waitForIndexingUpto(ctx context.Context, txID uint64) error {
+	err := d.st.WaitForIndexingUpto(txID, ctx.Done())
+	if err == watchers.ErrCancellationRequested {
+		return ctx.Err()
+	}
+	return err
+}
+
 //VerifiableSet ...
 func (d *db) VerifiableSet(req *schema.VerifiableSetRequest) (*schema.VerifiableTx, error) {
 	if req == nil {
@@ -577,7 +770,7 @@ func (d *db) VerifiableSet(req *schema.VerifiableSetRequest) (*schema.Verifiable
 }
 
 //VerifiableGet ...
-func (d *db) VerifiableGet(req *schema.VerifiableGetRequest) (*schema.VerifiableEntry, error) {
+func (d *db) VerifiableGet(ctx context.Context, req *schema.VerifiableGetRequest) (*schema.VerifiableEntry, error) {
 	if req == nil {
 		return nil, ErrIllegalArguments
 	}
@@ -587,7 +780,7 @@ func (d *db) VerifiableGet(req *schema.VerifiableGetRequest) (*schema.Verifiable
 		return nil, ErrIllegalState
 	}
 
-	e, err := d.Get(req.KeyRequest)
+	e, err := d.Get(ctx, req.KeyRequest)
 	if err != nil {
 		return nil, err
 	}
@@ -668,6 +861,14 @@ func (d *db) Delete(req *schema.DeleteKeysRequest) (*schema.TxHeader, error) {
 		return nil, ErrIsReplica
 	}
 
+	if d.isInMaintenance() {
+		return nil, ErrIsInMaintenance
+	}
+
+	if d.isDiskQuotaExceeded() {
+		return nil, ErrDiskQuotaExceeded
+	}
+
 	currTxID, _ := d.st.Alh()
 
 	if req.SinceTx > currTxID {
@@ -701,6 +902,22 @@ func (d *db) Delete(req *schema.DeleteKeysRequest) (*schema.TxHeader, error) {
 
 		e := EncodeEntrySpec(k, md, nil)
 
+		if d.options.reverseReferenceIndex {
+			referencedKey, err := referencedKeyOf(e.Key, tx)
+			if err != nil {
+				return nil, err
+			}
+
+			if referencedKey != nil {
+				re := deletedReverseReference(k, referencedKey)
+
+				err = tx.Set(re.Key, re.Metadata, re.Value)
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+
 		err = tx.Delete(e.Key)
 		if err != nil {
 			return nil, err
@@ -720,8 +937,9 @@ func (d *db) Delete(req *schema.DeleteKeysRequest) (*schema.TxHeader, error) {
 	return schema.TxHeaderToProto(hdr), nil
 }
 
-//GetAll ...
-func (d *db) GetAll(req *schema.KeyListRequest) (*schema.Entries, error) {
+// GetAll returns the entries of the given keys. Keys not found, deleted or expired are left out,
+// unless failOnMissingKeys is set. When withStatuses is set, the status of every requested key is returned instead
+func (d *db) GetAll(ctx context.Context, req *schema.KeyListRequest) (*schema.Entries, error) {
 	currTxID, _ := d.st.Alh()
 
 	if req.SinceTx > currTxID {
@@ -733,7 +951,7 @@ func (d *db) GetAll(req *schema.KeyListRequest) (*schema.Entries, error) {
 		waitUntilTx = currTxID
 	}
 
-	err := d.WaitForIndexingUpto(waitUntilTx, nil)
+	err := d.waitForIndexingUpto(ctx, waitUntilTx)
 	if err != nil {
 		return nil, err
 	}
@@ -744,24 +962,100 @@ func (d *db) GetAll(req *schema.KeyListRequest) (*schema.Entries, error) {
 	}
 	defer snapshot.Close()
 
+	results := make([]*schema.KeyResult, len(req.Keys))
+
+	found := make([]int, 0, len(req.Keys))
+	keys := make([][]byte, 0, len(req.Keys))
+	valRefs := make([]store.ValueRef, 0, len(req.Keys))
+
+	for i, key := range req.Keys {
+		results[i] = &schema.KeyResult{Key: key}
+
+		encKey := EncodeKey(key)
+
+		// deleted entries are not filtered out so they can be told apart from missing ones
+		valRef, err := snapshot.GetWith(encKey)
+		if err != nil && err != store.ErrKeyNotFound && err != store.ErrExpiredEntry {
+			return nil, err
+		}
+
+		status := keyStatusOf(valRef, err)
+		if status != schema.KeyStatus_KEY_FOUND {
+			if req.FailOnMissingKeys {
+				return nil, missingKeyError(status)
+			}
+
+			results[i].Status = status
+			continue
+		}
+
+		found = append(found, i)
+		keys = append(keys, encKey)
+		valRefs = append(valRefs, valRef)
+	}
+
+	// values are resolved concurrently across vLogs
+	values, err := d.st.ResolveValues(valRefs)
+	if err != nil {
+		return nil, err
+	}
+
 	list := &schema.Entries{}
 
 	txHolder := d.st.NewTxHolder()
 
-	for _, key := range req.Keys {
-		e, err := d.get(EncodeKey(key), snapshot, txHolder)
-		if err == nil || err == store.ErrKeyNotFound {
-			if e != nil {
-				list.Entries = append(list.Entries, e)
+	for i, valRef := range valRefs {
+		res := results[found[i]]
+
+		e, err := d.resolveValue(keys[i], values[i], 0, valRef.Tx(), valRef.KVMetadata(), snapshot, txHolder)
+		// the key may be a reference to a missing entry
+		if err == store.ErrKeyNotFound || err == store.ErrExpiredEntry {
+			if req.FailOnMissingKeys {
+				return nil, err
 			}
-		} else {
+
+			res.Status = keyStatusOf(nil, err)
+			continue
+		}
+		if err != nil {
 			return nil, err
 		}
+
+		res.Entry = e
+
+		if !req.WithStatuses {
+			list.Entries = append(list.Entries, e)
+		}
+	}
+
+	if req.WithStatuses {
+		list.Results = results
 	}
 
 	return list, nil
 }
 
+func keyStatusOf(valRef store.ValueRef, err error) schema.KeyStatus {
+	switch {
+	case err == store.ErrKeyNotFound:
+		return schema.KeyStatus_KEY_NOT_FOUND
+	case err == store.ErrExpiredEntry:
+		return schema.KeyStatus_KEY_EXPIRED
+	case valRef.KVMetadata() != nil && valRef.KVMetadata().Deleted():
+		return schema.KeyStatus_KEY_DELETED
+	}
+
+	return schema.KeyStatus_KEY_FOUND
+}
+
+func missingKeyError(status schema.KeyStatus) error {
+	if status == schema.KeyStatus_KEY_EXPIRED {
+		return store.ErrExpiredEntry
+	}
+
+	return store.ErrKeyNotFound
+}
+
 //Size ...
 func (d *db) Size() (uint64, error) {
 	return d.st.TxCount(), nil
@@ -778,12 +1072,12 @@ func (d *db) CountAll() (*schema.EntryCount, error) {
 }
 
 // TxByID ...
-func (d *db) TxByID(req *schema.TxRequest) (*schema.Tx, error) {
+func (d *db) TxByID(ctx context.Context, req *schema.TxRequest) (*schema.Tx, error) {
 	if req == nil {
 		return nil, ErrIllegalArguments
 	}
 
-	snap, err := d.snapshotSince(req.SinceTx, req.NoWait)
+	snap, err := d.snapshotSince(ctx, req.SinceTx, req.NoWait)
 	if err != nil {
 		return nil, err
 	}
@@ -800,7 +1094,7 @@ func (d *db) TxByID(req *schema.TxRequest) (*schema.Tx, error) {
 	return d.serializeTx(tx, req.EntriesSpec, snap)
 }
 
-func (d *db) snapshotSince(txID uint64, noWait bool) (*store.Snapshot, error) {
+func (d *db) snapshotSince(ctx context.Context, txID uint64, noWait bool) (*store.Snapshot, error) {
 	currTxID, _ := d.st.Alh()
 
 	if txID > currTxID {
@@ -813,7 +1107,7 @@ func (d *db) snapshotSince(txID uint64, noWait bool) (*store.Snapshot, error) {
 	}
 
 	if !noWait {
-		err := d.st.WaitForIndexingUpto(waitUntilTx, nil)
+		err := d.waitForIndexingUpto(ctx, waitUntilTx)
 		if err != nil {
 			return nil, err
 		}
@@ -831,10 +1125,15 @@ func (d *db) serializeTx(tx *store.Tx, spec *schema.EntriesSpec, snap *store.Sna
 		Header: schema.TxHeaderToProto(tx.Header()),
 	}
 
+	values, err := d.readEntryValues(tx, spec)
+	if err != nil {
+		return nil, err
+	}
+
 	// lazily initalized re-usable txHolder
 	var txHolder *store.Tx
 
-	for _, e := range tx.Entries() {
+	for i, e := range tx.Entries() {
 		switch e.Key()[0] {
 		case SetKeyPrefix:
 			{
@@ -847,18 +1146,14 @@ func (d *db) serializeTx(tx *store.Tx, spec *schema.EntriesSpec, snap *store.Sna
 					break
 				}
 
-				v, err := d.st.ReadValue(e)
-				if err == store.ErrExpiredEntry {
+				v := values[i]
+				if v == nil {
+					// expired entry
 					break
 				}
-				if err != nil {
-					return nil, err
-				}
 
 				if spec.KvEntriesSpec.Action == schema.EntryTypeAction_RAW_VALUE {
-					kve := schema.TxEntryToProto(e)
-					kve.Value = v
-					stx.Entries = append(stx.Entries, kve)
+					stx.Entries = append(stx.Entries, rawValueTxEntry(e, v))
 					break
 				}
 
@@ -890,17 +1185,13 @@ func (d *db) serializeTx(tx *store.Tx, spec *schema.EntriesSpec, snap *store.Sna
 				}
 
 				if spec.ZEntriesSpec.Action == schema.EntryTypeAction_RAW_VALUE {
-					v, err := d.st.ReadValue(e)
-					if err == store.ErrExpiredEntry {
+					v := values[i]
+					if v == nil {
+						// expired entry
 						break
 					}
-					if err != nil {
-						return nil, err
-					}
 
-					kve := schema.TxEntryToProto(e)
-					kve.Value = v
-					stx.Entries = append(stx.Entries, kve)
+					stx.Entries = append(stx.Entries, rawValueTxEntry(e, v))
 					break
 				}
 
@@ -956,17 +1247,13 @@ func (d *db) serializeTx(tx *store.Tx, spec *schema.EntriesSpec, snap *store.Sna
 				}
 
 				if spec.SqlEntriesSpec.Action == schema.EntryTypeAction_RAW_VALUE {
-					v, err := d.st.ReadValue(e)
-					if err == store.ErrExpiredEntry {
+					v := values[i]
+					if v == nil {
+						// expired entry
 						break
 					}
-					if err != nil {
-						return nil, err
-					}
 
-					kve := schema.TxEntryToProto(e)
-					kve.Value = v
-					stx.Entries = append(stx.Entries, kve)
+					stx.Entries = append(stx.Entries, rawValueTxEntry(e, v))
 					break
 				}
 
@@ -978,6 +1265,54 @@ func (d *db) serializeTx(tx *store.Tx, spec *schema.EntriesSpec, snap *store.Sna
 	return stx, nil
 }
 
+// readEntryValues reads the values of the tx entries required by the spec, values are read concurrently
+// across vLogs. Values of entries not required by the spec or already expired are left nil
+func (d *db) readEntryValues(tx *store.Tx, spec *schema.EntriesSpec) ([][]byte, error) {
+	var entries []*store.TxEntry
+	var positions []int
+
+	for i, e := range tx.Entries() {
+		var required bool
+
+		switch e.Key()[0] {
+		case SetKeyPrefix:
+			required = spec.KvEntriesSpec != nil &&
+				(spec.KvEntriesSpec.Action == schema.EntryTypeAction_RAW_VALUE || spec.KvEntriesSpec.Action == schema.EntryTypeAction_RESOLVE)
+		case SortedSetKeyPrefix:
+			required = spec.ZEntriesSpec != nil && spec.ZEntriesSpec.Action == schema.EntryTypeAction_RAW_VALUE
+		case SQLPrefix:
+			required = spec.SqlEntriesSpec != nil && spec.SqlEntriesSpec.Action == schema.EntryTypeAction_RAW_VALUE
+		}
+
+		if required {
+			entries = append(entries, e)
+			positions = append(positions, i)
+		}
+	}
+
+	readValues, err := d.st.ReadValues(entries)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([][]byte, len(tx.Entries()))
+
+	for i, v := range readValues {
+		values[positions[i]] = v
+	}
+
+	return values, nil
+}
+
+// rawValueTxEntry returns the tx entry along with its value, the reported length is the one of the value
+// instead of the one recorded in the entry, which is the length of the manifest of chunked values
+func rawValueTxEntry(e *store.TxEntry, v []byte) *schema.TxEntry {
+	kve := schema.TxEntryToProto(e)
+	kve.Value = v
+	kve.VLen = int32(len(v))
+	return kve
+}
+
 func (d *db) ExportTxByID(req *schema.ExportTxRequest) ([]byte, error) {
 	if req == nil {
 		return nil, ErrIllegalArguments
@@ -994,16 +1329,28 @@ func (d *db) ReplicateTx(exportedTx []byte) (*schema.TxHeader, error) {
 		return nil, ErrNotReplica
 	}
 
+	if d.isInMaintenance() {
+		return nil, ErrIsInMaintenance
+	}
+
+	if d.isDiskQuotaExceeded() {
+		return nil, ErrDiskQuotaExceeded
+	}
+
 	hdr, err := d.st.ReplicateTx(exportedTx, false)
 	if err != nil {
 		return nil, err
 	}
 
+	// replicated txs may update the registered schemas and redaction rules
+	d.resetKVSchemaRegistry()
+	d.resetRedactionRules()
+
 	return schema.TxHeaderToProto(hdr), nil
 }
 
 //VerifiableTxByID ...
-func (d *db) VerifiableTxByID(req *schema.VerifiableTxRequest) (*schema.VerifiableTx, error) {
+func (d *db) VerifiableTxByID(ctx context.Context, req *schema.VerifiableTxRequest) (*schema.VerifiableTx, error) {
 	if req == nil {
 		return nil, ErrIllegalArguments
 	}
@@ -1013,7 +1360,7 @@ func (d *db) VerifiableTxByID(req *schema.VerifiableTxRequest) (*schema.Verifiab
 		return nil, fmt.Errorf("%w: latest txID=%d is lower than specified as initial tx=%d", ErrIllegalState, lastTxID, req.ProveSinceTx)
 	}
 
-	snap, err := d.snapshotSince(req.SinceTx, req.NoWait)
+	snap, err := d.snapshotSince(ctx, req.SinceTx, req.NoWait)
 	if err != nil {
 		return nil, err
 	}
@@ -1067,7 +1414,7 @@ func (d *db) VerifiableTxByID(req *schema.VerifiableTxRequest) (*schema.Verifiab
 }
 
 //TxScan ...
-func (d *db) TxScan(req *schema.TxScanRequest) (*schema.TxList, error) {
+func (d *db) TxScan(ctx context.Context, req *schema.TxScanRequest) (*schema.TxList, error) {
 	if req == nil {
 		return nil, ErrIllegalArguments
 	}
@@ -1082,20 +1429,46 @@ func (d *db) TxScan(req *schema.TxScanRequest) (*schema.TxList, error) {
 		limit = MaxKeyScanLimit
 	}
 
-	snap, err := d.snapshotSince(req.SinceTx, req.NoWait)
+	snap, err := d.snapshotSince(ctx, req.SinceTx, req.NoWait)
 	if err != nil {
 		return nil, err
 	}
 	defer snap.Close()
 
-	txReader, err := d.st.NewTxReader(req.InitialTx, req.Desc, d.st.NewTxHolder())
+	txList := &schema.TxList{}
+
+	initialTx := req.InitialTx
+	firstTx, lastTx := uint64(0), uint64(math.MaxUint64)
+
+	if req.StartTime != 0 || req.EndTime != 0 {
+		firstTx, lastTx, err = d.txRangeByTime(req.StartTime, req.EndTime)
+		if err == store.ErrTxNotFound {
+			return txList, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// the scan starts from the first tx within the range, unless a later one is requested
+		if !req.Desc && initialTx < firstTx {
+			initialTx = firstTx
+		}
+
+		if req.Desc && (initialTx == 0 || initialTx > lastTx) {
+			initialTx = lastTx
+		}
+	}
+
+	txReader, err := d.st.NewTxReader(initialTx, req.Desc, d.st.NewTxHolder())
 	if err != nil {
 		return nil, err
 	}
 
-	txList := &schema.TxList{}
+	for len(txList.Txs) < limit {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
 
-	for i := 0; i < limit; i++ {
 		tx, err := txReader.Read()
 		if err == store.ErrNoMoreEntries {
 			break
@@ -1104,6 +1477,15 @@ func (d *db) TxScan(req *schema.TxScanRequest) (*schema.TxList, error) {
 			return nil, err
 		}
 
+		if tx.Header().ID < firstTx || tx.Header().ID > lastTx {
+			break
+		}
+
+		// txs committed while the clock was behind may be found within the range
+		if !withinTime(tx.Header().Ts, req.StartTime, req.EndTime) {
+			continue
+		}
+
 		sTx, err := d.serializeTx(tx, req.EntriesSpec, snap)
 		if err != nil {
 			return nil, err
@@ -1116,7 +1498,7 @@ func (d *db) TxScan(req *schema.TxScanRequest) (*schema.TxList, error) {
 }
 
 //History ...
-func (d *db) History(req *schema.HistoryRequest) (*schema.Entries, error) {
+func (d *db) History(ctx context.Context, req *schema.HistoryRequest) (*schema.Entries, error) {
 	if req == nil {
 		return nil, ErrIllegalArguments
 	}
@@ -1136,7 +1518,7 @@ func (d *db) History(req *schema.HistoryRequest) (*schema.Entries, error) {
 		waitUntilTx = currTxID
 	}
 
-	err := d.WaitForIndexingUpto(waitUntilTx, nil)
+	err := d.waitForIndexingUpto(ctx, waitUntilTx)
 	if err != nil {
 		return nil, err
 	}
@@ -1149,6 +1531,20 @@ func (d *db) History(req *schema.HistoryRequest) (*schema.Entries, error) {
 
 	key := EncodeKey(req.Key)
 
+	tx := d.st.NewTxHolder()
+
+	if req.FollowRenames {
+		if req.StartTime != 0 || req.EndTime != 0 {
+			return nil, ErrIllegalArguments
+		}
+
+		return d.historyFollowingRenames(req, limit, tx)
+	}
+
+	if req.StartTime != 0 || req.EndTime != 0 {
+		return d.historyByTime(req, key, limit, tx)
+	}
+
 	txs, err := d.st.History(key, req.Offset, req.Desc, limit)
 	if err != nil && err != store.ErrOffsetOutOfRange {
 		return nil, err
@@ -1158,39 +1554,152 @@ func (d *db) History(req *schema.HistoryRequest) (*schema.Entries, error) {
 		Entries: make([]*schema.Entry, len(txs)),
 	}
 
-	tx := d.st.NewTxHolder()
-
 	for i, txID := range txs {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
 		err = d.st.ReadTx(txID, tx)
 		if err != nil {
 			return nil, err
 		}
 
-		entry, err := tx.EntryOf(key)
+		list.Entries[i], err = d.historyEntry(req.Key, key, tx)
 		if err != nil {
 			return nil, err
 		}
+	}
 
-		val, err := d.st.ReadValue(entry)
-		if err != nil && err != store.ErrExpiredEntry {
+	return list, nil
+}
+
+// historyByTime returns the entries of the key committed within the times of the request,
+// the offset is counted from the first of them
+func (d *db) historyByTime(req *schema.HistoryRequest, key []byte, limit int, tx *store.Tx) (*schema.Entries, error) {
+	list := &schema.Entries{}
+
+	firstTx, lastTx, err := d.txRangeByTime(req.StartTime, req.EndTime)
+	if err == store.ErrTxNotFound {
+		return list, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	skipped := uint64(0)
+
+	for offset := uint64(0); ; offset += MaxKeyScanLimit {
+		txs, err := d.st.History(key, offset, req.Desc, MaxKeyScanLimit)
+		if err == store.ErrOffsetOutOfRange {
+			break
+		}
+		if err != nil {
 			return nil, err
 		}
-		if len(val) > 0 {
-			val = TrimPrefix(val)
+
+		for _, txID := range txs {
+			if (!req.Desc && txID > lastTx) || (req.Desc && txID < firstTx) {
+				return list, nil
+			}
+
+			if txID < firstTx || txID > lastTx {
+				continue
+			}
+
+			err = d.st.ReadTx(txID, tx)
+			if err != nil {
+				return nil, err
+			}
+
+			if !withinTime(tx.Header().Ts, req.StartTime, req.EndTime) {
+				continue
+			}
+
+			if skipped < req.Offset {
+				skipped++
+				continue
+			}
+
+			entry, err := d.historyEntry(req.Key, key, tx)
+			if err != nil {
+				return nil, err
+			}
+
+			list.Entries = append(list.Entries, entry)
+
+			if len(list.Entries) == limit {
+				return list, nil
+			}
 		}
 
-		list.Entries[i] = &schema.Entry{
-			Tx:       txID,
-			Key:      req.Key,
-			Metadata: schema.KVMetadataToProto(entry.Metadata()),
-			Value:    val,
-			Expired:  err == store.ErrExpiredEntry,
+		if len(txs) < MaxKeyScanLimit {
+			break
 		}
 	}
 
 	return list, nil
 }
 
+func (d *db) historyEntry(reqKey, key []byte, tx *store.Tx) (*schema.Entry, error) {
+	entry, err := tx.EntryOf(key)
+	if err != nil {
+		return nil, err
+	}
+
+	val, err := d.st.ReadValue(entry)
+	if err != nil && err != store.ErrExpiredEntry {
+		return nil, err
+	}
+	if len(val) > 0 {
+		val = TrimPrefix(val)
+	}
+
+	return &schema.Entry{
+		Tx:       tx.Header().ID,
+		Key:      reqKey,
+		Metadata: schema.KVMetadataToProto(entry.Metadata()),
+		Value:    val,
+		Expired:  err == store.ErrExpiredEntry,
+	}, nil
+}
+
+// txRangeByTime returns the first and the last txs committed within the given times,
+// in seconds since the epoch. A zero time leaves its side of the range unbounded.
+// As commit times are indexed by the latest one up to each tx, txs committed while the clock was behind
+// may be found within the range, so the commit time of each tx is still checked
+func (d *db) txRangeByTime(startTime, endTime int64) (firstTx, lastTx uint64, err error) {
+	if startTime < 0 || endTime < 0 || (endTime > 0 && startTime > endTime) {
+		return 0, 0, ErrIllegalArguments
+	}
+
+	firstTx = 1
+	lastTx = d.st.TxCount()
+
+	if startTime > 0 {
+		firstTx, err = d.st.FirstTxSince(time.Unix(startTime, 0))
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+
+	if endTime > 0 {
+		lastTx, err = d.st.LastTxUntil(time.Unix(endTime, 0))
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+
+	if firstTx > lastTx {
+		return 0, 0, store.ErrTxNotFound
+	}
+
+	return firstTx, lastTx, nil
+}
+
+func withinTime(ts, startTime, endTime int64) bool {
+	return (startTime == 0 || ts >= startTime) && (endTime == 0 || ts <= endTime)
+}
+
 //Close ...
 func (d *db) Close() error {
 	d.mutex.Lock()
@@ -1223,6 +1732,9 @@ func (d *db) AsReplica(asReplica bool) {
 	defer d.mutex.Unlock()
 
 	d.options.replica = asReplica
+
+	d.resetKVSchemaRegistry()
+	d.resetRedactionRules()
 }
 
 func (d *db) IsReplica() bool {
@@ -1232,6 +1744,52 @@ func (d *db) IsReplica() bool {
 	return d.options.replica
 }
 
+// AsInMaintenance sets if the database is in maintenance mode, rejecting writes while it's read
+func (d *db) AsInMaintenance(inMaintenance bool) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.options.maintenanceMode = inMaintenance
+}
+
+func (d *db) IsInMaintenance() bool {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	return d.options.maintenanceMode
+}
+
+// AllowAnonymousReads sets if the database can be read without logging in
+func (d *db) AllowAnonymousReads(allow bool) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.options.anonymousReads = allow
+}
+
+func (d *db) AnonymousReadsAllowed() bool {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	return d.options.anonymousReads
+}
+
+// EnableReverseReferenceIndex sets if a reverse entry is written along with each reference,
+// references set while it's disabled are not resolved by ResolveAll
+func (d *db) EnableReverseReferenceIndex(enable bool) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.options.reverseReferenceIndex = enable
+}
+
+func (d *db) ReverseReferenceIndexEnabled() bool {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	return d.options.reverseReferenceIndex
+}
+
 func logErr(log logger.Logger, formattedMessage string, err error) error {
 	if err != nil {
 		log.Errorf(formattedMessage, err)
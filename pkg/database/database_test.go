@@ -16,6 +16,7 @@ limitations under the License.
 package database
 
 import (
+	"context"
 	"crypto/sha256"
 	"fmt"
 	"log"
@@ -219,7 +220,7 @@ func TestDbSynchronousSet(t *testing.T) {
 		_, err := db.Set(&schema.SetRequest{KVs: []*schema.KeyValue{kv}})
 		require.NoError(t, err)
 
-		item, err := db.Get(&schema.KeyRequest{Key: kv.Key})
+		item, err := db.Get(context.Background(), &schema.KeyRequest{Key: kv.Key})
 		require.NoError(t, err)
 		require.Equal(t, kv.Key, item.Key)
 		require.Equal(t, kv.Value, item.Value)
@@ -236,7 +237,7 @@ func TestDbSetGet(t *testing.T) {
 	_, err := db.Set(nil)
 	require.Equal(t, ErrIllegalArguments, err)
 
-	_, err = db.VerifiableGet(nil)
+	_, err = db.VerifiableGet(context.Background(), nil)
 	require.Equal(t, ErrIllegalArguments, err)
 
 	for i, kv := range kvs[:1] {
@@ -252,15 +253,15 @@ func TestDbSetGet(t *testing.T) {
 
 		keyReq := &schema.KeyRequest{Key: kv.Key, SinceTx: txhdr.Id}
 
-		item, err := db.Get(keyReq)
+		item, err := db.Get(context.Background(), keyReq)
 		require.NoError(t, err)
 		require.Equal(t, kv.Key, item.Key)
 		require.Equal(t, kv.Value, item.Value)
 
-		_, err = db.Get(&schema.KeyRequest{Key: kv.Key, SinceTx: txhdr.Id, AtTx: txhdr.Id})
+		_, err = db.Get(context.Background(), &schema.KeyRequest{Key: kv.Key, SinceTx: txhdr.Id, AtTx: txhdr.Id})
 		require.Equal(t, ErrIllegalArguments, err)
 
-		vitem, err := db.VerifiableGet(&schema.VerifiableGetRequest{
+		vitem, err := db.VerifiableGet(context.Background(), &schema.VerifiableGetRequest{
 			KeyRequest:   keyReq,
 			ProveSinceTx: trustedIndex,
 		})
@@ -312,7 +313,7 @@ func TestDbSetGet(t *testing.T) {
 		require.True(t, verifies)
 	}
 
-	_, err = db.Get(&schema.KeyRequest{Key: []byte{}})
+	_, err = db.Get(context.Background(), &schema.KeyRequest{Key: []byte{}})
 	require.Error(t, err)
 }
 
@@ -353,7 +354,7 @@ func TestDelete(t *testing.T) {
 		require.ErrorIs(t, err, ErrIllegalArguments)
 	})
 
-	_, err = db.Get(&schema.KeyRequest{
+	_, err = db.Get(context.Background(), &schema.KeyRequest{
 		Key: []byte("key1"),
 	})
 	require.NoError(t, err)
@@ -365,7 +366,7 @@ func TestDelete(t *testing.T) {
 	})
 	require.NoError(t, err)
 
-	_, err = db.Get(&schema.KeyRequest{
+	_, err = db.Get(context.Background(), &schema.KeyRequest{
 		Key: []byte("key1"),
 	})
 	require.ErrorIs(t, err, store.ErrKeyNotFound)
@@ -452,7 +453,7 @@ func TestSafeSetGet(t *testing.T) {
 		require.NoError(t, err)
 		require.NotNil(t, vtx)
 
-		vit, err := db.VerifiableGet(&schema.VerifiableGetRequest{
+		vit, err := db.VerifiableGet(context.Background(), &schema.VerifiableGetRequest{
 			KeyRequest: &schema.KeyRequest{
 				Key:     val.SetRequest.KVs[0].Key,
 				SinceTx: vtx.Tx.Header.Id,
@@ -489,7 +490,7 @@ func TestSetGetAll(t *testing.T) {
 	err = db.CompactIndex()
 	require.NoError(t, err)
 
-	itList, err := db.GetAll(&schema.KeyListRequest{
+	itList, err := db.GetAll(context.Background(), &schema.KeyListRequest{
 		Keys: [][]byte{
 			[]byte("Alberto"),
 			[]byte("Jean-Claude"),
@@ -504,11 +505,112 @@ func TestSetGetAll(t *testing.T) {
 	}
 }
 
+func TestGetAllWithStatuses(t *testing.T) {
+	db, closer := makeDb()
+	defer closer()
+
+	// entries already expired when indexed are not indexed at all
+	expiresAt := time.Now().Add(2 * time.Second).Truncate(time.Second)
+
+	_, err := db.Set(&schema.SetRequest{
+		KVs: []*schema.KeyValue{
+			{Key: []byte("key1"), Value: []byte("value1")},
+			{Key: []byte("key2"), Value: []byte("value2")},
+			{Key: []byte("key3"), Value: []byte("value3")},
+			{
+				Key:   []byte("key4"),
+				Value: []byte("value4"),
+				Metadata: &schema.KVMetadata{
+					Expiration: &schema.Expiration{ExpiresAt: expiresAt.Unix()},
+				},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	_, err = db.SetReference(&schema.ReferenceRequest{Key: []byte("ref3"), ReferencedKey: []byte("key3")})
+	require.NoError(t, err)
+
+	hdr, err := db.Delete(&schema.DeleteKeysRequest{Keys: [][]byte{[]byte("key2"), []byte("key3")}})
+	require.NoError(t, err)
+
+	err = db.WaitForIndexingUpto(hdr.Id, nil)
+	require.NoError(t, err)
+
+	time.Sleep(time.Until(expiresAt.Add(time.Second)))
+
+	keys := [][]byte{
+		[]byte("key1"),
+		[]byte("key2"),
+		[]byte("missing"),
+		[]byte("key4"),
+		[]byte("ref3"),
+		[]byte("key1"),
+	}
+
+	t.Run("missing keys should be left out by default", func(t *testing.T) {
+		list, err := db.GetAll(context.Background(), &schema.KeyListRequest{Keys: keys})
+		require.NoError(t, err)
+		require.Len(t, list.Entries, 2)
+		require.Empty(t, list.Results)
+		require.Equal(t, []byte("value1"), list.Entries[0].Value)
+		require.Equal(t, []byte("value1"), list.Entries[1].Value)
+	})
+
+	t.Run("the status of every key should be returned", func(t *testing.T) {
+		list, err := db.GetAll(context.Background(), &schema.KeyListRequest{Keys: keys, WithStatuses: true})
+		require.NoError(t, err)
+		require.Empty(t, list.Entries)
+		require.Len(t, list.Results, len(keys))
+
+		for i, status := range []schema.KeyStatus{
+			schema.KeyStatus_KEY_FOUND,
+			schema.KeyStatus_KEY_DELETED,
+			schema.KeyStatus_KEY_NOT_FOUND,
+			schema.KeyStatus_KEY_EXPIRED,
+			schema.KeyStatus_KEY_NOT_FOUND,
+			schema.KeyStatus_KEY_FOUND,
+		} {
+			res := list.Results[i]
+			require.Equal(t, keys[i], res.Key)
+			require.Equal(t, status, res.Status, string(keys[i]))
+
+			if status == schema.KeyStatus_KEY_FOUND {
+				require.Equal(t, []byte("value1"), res.Entry.Value)
+			} else {
+				require.Nil(t, res.Entry)
+			}
+		}
+	})
+
+	t.Run("missing keys should make the request fail when not tolerated", func(t *testing.T) {
+		_, err := db.GetAll(context.Background(), &schema.KeyListRequest{Keys: keys[:1], FailOnMissingKeys: true})
+		require.NoError(t, err)
+
+		for _, c := range []struct {
+			key string
+			err error
+		}{
+			{"key2", store.ErrKeyNotFound},
+			{"missing", store.ErrKeyNotFound},
+			{"key4", store.ErrExpiredEntry},
+			{"ref3", store.ErrKeyNotFound},
+		} {
+			_, err := db.GetAll(context.Background(), &schema.KeyListRequest{
+				Keys:              [][]byte{[]byte("key1"), []byte(c.key)},
+				WithStatuses:      true,
+				FailOnMissingKeys: true,
+			})
+			require.ErrorIs(t, err, c.err, c.key)
+		}
+	})
+}
+
 func TestTxByID(t *testing.T) {
 	db, closer := makeDb()
 	defer closer()
 
-	_, err := db.TxByID(nil)
+	_, err := db.TxByID(context.Background(), nil)
 	require.Error(t, ErrIllegalArguments, err)
 
 	txhdr1, err := db.Set(&schema.SetRequest{
@@ -559,7 +661,7 @@ func TestTxByID(t *testing.T) {
 	txhdr3 := ctx1[0].TxHeader()
 
 	t.Run("values should not be resolved but digests returned in entries field", func(t *testing.T) {
-		tx, err := db.TxByID(&schema.TxRequest{Tx: txhdr1.Id})
+		tx, err := db.TxByID(context.Background(), &schema.TxRequest{Tx: txhdr1.Id})
 		require.NoError(t, err)
 		require.NotNil(t, tx)
 		require.Len(t, tx.Entries, 1)
@@ -570,7 +672,7 @@ func TestTxByID(t *testing.T) {
 			require.Len(t, e.Value, 0)
 		}
 
-		tx, err = db.TxByID(&schema.TxRequest{Tx: txhdr2.Id})
+		tx, err = db.TxByID(context.Background(), &schema.TxRequest{Tx: txhdr2.Id})
 		require.NoError(t, err)
 		require.NotNil(t, tx)
 		require.Len(t, tx.Entries, 3)
@@ -581,7 +683,7 @@ func TestTxByID(t *testing.T) {
 			require.Len(t, e.Value, 0)
 		}
 
-		tx, err = db.TxByID(&schema.TxRequest{Tx: txhdr3.ID})
+		tx, err = db.TxByID(context.Background(), &schema.TxRequest{Tx: txhdr3.ID})
 		require.NoError(t, err)
 		require.NotNil(t, tx)
 		require.Len(t, tx.Entries, 1)
@@ -594,7 +696,7 @@ func TestTxByID(t *testing.T) {
 	})
 
 	t.Run("values should not be resolved but digests returned in entries field", func(t *testing.T) {
-		tx, err := db.TxByID(&schema.TxRequest{Tx: txhdr1.Id, EntriesSpec: &schema.EntriesSpec{
+		tx, err := db.TxByID(context.Background(), &schema.TxRequest{Tx: txhdr1.Id, EntriesSpec: &schema.EntriesSpec{
 			KvEntriesSpec: &schema.EntryTypeSpec{Action: schema.EntryTypeAction_ONLY_DIGEST},
 		}})
 		require.NoError(t, err)
@@ -607,7 +709,7 @@ func TestTxByID(t *testing.T) {
 			require.Len(t, e.Value, 0)
 		}
 
-		tx, err = db.TxByID(&schema.TxRequest{Tx: txhdr2.Id, EntriesSpec: &schema.EntriesSpec{
+		tx, err = db.TxByID(context.Background(), &schema.TxRequest{Tx: txhdr2.Id, EntriesSpec: &schema.EntriesSpec{
 			KvEntriesSpec: &schema.EntryTypeSpec{Action: schema.EntryTypeAction_ONLY_DIGEST},
 			ZEntriesSpec:  &schema.EntryTypeSpec{Action: schema.EntryTypeAction_ONLY_DIGEST},
 		}})
@@ -621,7 +723,7 @@ func TestTxByID(t *testing.T) {
 			require.Len(t, e.Value, 0)
 		}
 
-		tx, err = db.TxByID(&schema.TxRequest{Tx: txhdr3.ID, EntriesSpec: &schema.EntriesSpec{
+		tx, err = db.TxByID(context.Background(), &schema.TxRequest{Tx: txhdr3.ID, EntriesSpec: &schema.EntriesSpec{
 			SqlEntriesSpec: &schema.EntryTypeSpec{Action: schema.EntryTypeAction_ONLY_DIGEST},
 		}})
 		require.NoError(t, err)
@@ -636,21 +738,21 @@ func TestTxByID(t *testing.T) {
 	})
 
 	t.Run("no entries should be returned if not explicitly included", func(t *testing.T) {
-		tx, err := db.TxByID(&schema.TxRequest{Tx: txhdr1.Id, EntriesSpec: &schema.EntriesSpec{}})
+		tx, err := db.TxByID(context.Background(), &schema.TxRequest{Tx: txhdr1.Id, EntriesSpec: &schema.EntriesSpec{}})
 		require.NoError(t, err)
 		require.NotNil(t, tx)
 		require.Empty(t, tx.Entries)
 		require.Empty(t, tx.KvEntries)
 		require.Empty(t, tx.ZEntries)
 
-		tx, err = db.TxByID(&schema.TxRequest{Tx: txhdr2.Id, EntriesSpec: &schema.EntriesSpec{}})
+		tx, err = db.TxByID(context.Background(), &schema.TxRequest{Tx: txhdr2.Id, EntriesSpec: &schema.EntriesSpec{}})
 		require.NoError(t, err)
 		require.NotNil(t, tx)
 		require.Empty(t, tx.Entries)
 		require.Empty(t, tx.KvEntries)
 		require.Empty(t, tx.ZEntries)
 
-		tx, err = db.TxByID(&schema.TxRequest{Tx: txhdr3.ID, EntriesSpec: &schema.EntriesSpec{}})
+		tx, err = db.TxByID(context.Background(), &schema.TxRequest{Tx: txhdr3.ID, EntriesSpec: &schema.EntriesSpec{}})
 		require.NoError(t, err)
 		require.NotNil(t, tx)
 		require.Empty(t, tx.Entries)
@@ -659,7 +761,7 @@ func TestTxByID(t *testing.T) {
 	})
 
 	t.Run("no entries should be returned if explicitly excluded", func(t *testing.T) {
-		tx, err := db.TxByID(&schema.TxRequest{Tx: txhdr1.Id, EntriesSpec: &schema.EntriesSpec{
+		tx, err := db.TxByID(context.Background(), &schema.TxRequest{Tx: txhdr1.Id, EntriesSpec: &schema.EntriesSpec{
 			KvEntriesSpec: &schema.EntryTypeSpec{Action: schema.EntryTypeAction_EXCLUDE},
 		}})
 		require.NoError(t, err)
@@ -668,7 +770,7 @@ func TestTxByID(t *testing.T) {
 		require.Empty(t, tx.KvEntries)
 		require.Empty(t, tx.ZEntries)
 
-		tx, err = db.TxByID(&schema.TxRequest{Tx: txhdr2.Id, EntriesSpec: &schema.EntriesSpec{
+		tx, err = db.TxByID(context.Background(), &schema.TxRequest{Tx: txhdr2.Id, EntriesSpec: &schema.EntriesSpec{
 			KvEntriesSpec: &schema.EntryTypeSpec{Action: schema.EntryTypeAction_EXCLUDE},
 			ZEntriesSpec:  &schema.EntryTypeSpec{Action: schema.EntryTypeAction_EXCLUDE},
 		}})
@@ -678,7 +780,7 @@ func TestTxByID(t *testing.T) {
 		require.Empty(t, tx.KvEntries)
 		require.Empty(t, tx.ZEntries)
 
-		tx, err = db.TxByID(&schema.TxRequest{Tx: txhdr3.ID, EntriesSpec: &schema.EntriesSpec{
+		tx, err = db.TxByID(context.Background(), &schema.TxRequest{Tx: txhdr3.ID, EntriesSpec: &schema.EntriesSpec{
 			SqlEntriesSpec: &schema.EntryTypeSpec{Action: schema.EntryTypeAction_EXCLUDE},
 		}})
 		require.NoError(t, err)
@@ -689,7 +791,7 @@ func TestTxByID(t *testing.T) {
 	})
 
 	t.Run("raw entries should be returned", func(t *testing.T) {
-		tx, err := db.TxByID(&schema.TxRequest{Tx: txhdr1.Id, EntriesSpec: &schema.EntriesSpec{
+		tx, err := db.TxByID(context.Background(), &schema.TxRequest{Tx: txhdr1.Id, EntriesSpec: &schema.EntriesSpec{
 			KvEntriesSpec: &schema.EntryTypeSpec{Action: schema.EntryTypeAction_RAW_VALUE},
 		}})
 		require.NoError(t, err)
@@ -703,7 +805,7 @@ func TestTxByID(t *testing.T) {
 			require.Equal(t, e.HValue, hval[:])
 		}
 
-		tx, err = db.TxByID(&schema.TxRequest{Tx: txhdr2.Id, EntriesSpec: &schema.EntriesSpec{
+		tx, err = db.TxByID(context.Background(), &schema.TxRequest{Tx: txhdr2.Id, EntriesSpec: &schema.EntriesSpec{
 			ZEntriesSpec: &schema.EntryTypeSpec{Action: schema.EntryTypeAction_RAW_VALUE},
 		}})
 		require.NoError(t, err)
@@ -717,7 +819,7 @@ func TestTxByID(t *testing.T) {
 			require.Equal(t, e.HValue, hval[:])
 		}
 
-		tx, err = db.TxByID(&schema.TxRequest{Tx: txhdr3.ID, EntriesSpec: &schema.EntriesSpec{
+		tx, err = db.TxByID(context.Background(), &schema.TxRequest{Tx: txhdr3.ID, EntriesSpec: &schema.EntriesSpec{
 			SqlEntriesSpec: &schema.EntryTypeSpec{Action: schema.EntryTypeAction_RAW_VALUE},
 		}})
 		require.NoError(t, err)
@@ -733,7 +835,7 @@ func TestTxByID(t *testing.T) {
 	})
 
 	t.Run("only kv entries should be resolved", func(t *testing.T) {
-		tx, err := db.TxByID(&schema.TxRequest{Tx: txhdr2.Id, EntriesSpec: &schema.EntriesSpec{
+		tx, err := db.TxByID(context.Background(), &schema.TxRequest{Tx: txhdr2.Id, EntriesSpec: &schema.EntriesSpec{
 			KvEntriesSpec: &schema.EntryTypeSpec{Action: schema.EntryTypeAction_RESOLVE},
 		}})
 		require.NoError(t, err)
@@ -749,7 +851,7 @@ func TestTxByID(t *testing.T) {
 	})
 
 	t.Run("only zentries should be resolved", func(t *testing.T) {
-		tx, err := db.TxByID(&schema.TxRequest{Tx: txhdr2.Id, EntriesSpec: &schema.EntriesSpec{
+		tx, err := db.TxByID(context.Background(), &schema.TxRequest{Tx: txhdr2.Id, EntriesSpec: &schema.EntriesSpec{
 			ZEntriesSpec: &schema.EntryTypeSpec{Action: schema.EntryTypeAction_RESOLVE},
 		}})
 		require.NoError(t, err)
@@ -764,7 +866,7 @@ func TestTxByID(t *testing.T) {
 	})
 
 	t.Run("sql entries can not be resolved", func(t *testing.T) {
-		_, err := db.TxByID(&schema.TxRequest{Tx: txhdr3.ID, EntriesSpec: &schema.EntriesSpec{
+		_, err := db.TxByID(context.Background(), &schema.TxRequest{Tx: txhdr3.ID, EntriesSpec: &schema.EntriesSpec{
 			SqlEntriesSpec: &schema.EntryTypeSpec{Action: schema.EntryTypeAction_RESOLVE},
 		}})
 		require.ErrorIs(t, err, ErrIllegalArguments)
@@ -775,7 +877,7 @@ func TestVerifiableTxByID(t *testing.T) {
 	db, closer := makeDb()
 	defer closer()
 
-	_, err := db.VerifiableTxByID(nil)
+	_, err := db.VerifiableTxByID(context.Background(), nil)
 	require.Error(t, ErrIllegalArguments, err)
 
 	var txhdr *schema.TxHeader
@@ -786,7 +888,7 @@ func TestVerifiableTxByID(t *testing.T) {
 	}
 
 	t.Run("values should be returned", func(t *testing.T) {
-		vtx, err := db.VerifiableTxByID(&schema.VerifiableTxRequest{
+		vtx, err := db.VerifiableTxByID(context.Background(), &schema.VerifiableTxRequest{
 			Tx:           txhdr.Id,
 			ProveSinceTx: 0,
 			EntriesSpec: &schema.EntriesSpec{
@@ -804,7 +906,7 @@ func TestVerifiableTxByID(t *testing.T) {
 	})
 
 	t.Run("values should not be returned", func(t *testing.T) {
-		vtx, err := db.VerifiableTxByID(&schema.VerifiableTxRequest{
+		vtx, err := db.VerifiableTxByID(context.Background(), &schema.VerifiableTxRequest{
 			Tx:           txhdr.Id,
 			ProveSinceTx: 0,
 		})
@@ -824,22 +926,22 @@ func TestTxScan(t *testing.T) {
 		require.NoError(t, err)
 	}
 
-	_, err := db.TxScan(nil)
+	_, err := db.TxScan(context.Background(), nil)
 	require.Equal(t, ErrIllegalArguments, err)
 
-	_, err = db.TxScan(&schema.TxScanRequest{
+	_, err = db.TxScan(context.Background(), &schema.TxScanRequest{
 		InitialTx: 0,
 	})
 	require.Equal(t, ErrIllegalArguments, err)
 
-	_, err = db.TxScan(&schema.TxScanRequest{
+	_, err = db.TxScan(context.Background(), &schema.TxScanRequest{
 		InitialTx: 1,
 		Limit:     MaxKeyScanLimit + 1,
 	})
 	require.Equal(t, ErrMaxKeyScanLimitExceeded, err)
 
 	t.Run("values should be returned", func(t *testing.T) {
-		txList, err := db.TxScan(&schema.TxScanRequest{
+		txList, err := db.TxScan(context.Background(), &schema.TxScanRequest{
 			InitialTx: 1,
 			EntriesSpec: &schema.EntriesSpec{
 				KvEntriesSpec: &schema.EntryTypeSpec{
@@ -859,7 +961,7 @@ func TestTxScan(t *testing.T) {
 	})
 
 	t.Run("values should not be returned", func(t *testing.T) {
-		txList, err := db.TxScan(&schema.TxScanRequest{
+		txList, err := db.TxScan(context.Background(), &schema.TxScanRequest{
 			InitialTx: 1,
 		})
 		require.NoError(t, err)
@@ -895,17 +997,17 @@ func TestHistory(t *testing.T) {
 
 	time.Sleep(1 * time.Millisecond)
 
-	_, err = db.History(nil)
+	_, err = db.History(context.Background(), nil)
 	require.Equal(t, ErrIllegalArguments, err)
 
-	_, err = db.History(&schema.HistoryRequest{
+	_, err = db.History(context.Background(), &schema.HistoryRequest{
 		Key:     kvs[0].Key,
 		SinceTx: lastTx,
 		Limit:   MaxKeyScanLimit + 1,
 	})
 	require.Equal(t, ErrMaxKeyScanLimitExceeded, err)
 
-	inc, err := db.History(&schema.HistoryRequest{
+	inc, err := db.History(context.Background(), &schema.HistoryRequest{
 		Key:     kvs[0].Key,
 		SinceTx: lastTx,
 	})
@@ -920,7 +1022,7 @@ func TestHistory(t *testing.T) {
 		}
 	}
 
-	inc, err = db.History(&schema.HistoryRequest{
+	inc, err = db.History(context.Background(), &schema.HistoryRequest{
 		Key:     kvs[0].Key,
 		Offset:  uint64(len(kvs) + 1),
 		SinceTx: lastTx,
@@ -929,6 +1031,104 @@ func TestHistory(t *testing.T) {
 	require.Empty(t, inc.Entries)
 }
 
+func TestTxScanAndHistoryByTime(t *testing.T) {
+	db, closer := makeDb()
+	defer closer()
+
+	// txs 2 to 5 set the same key, the database is created with tx 1
+	now := time.Now().Unix()
+
+	for i, ts := range []int64{100, 200, 300, 400} {
+		err := db.UseTimeFunc(func() time.Time { return time.Unix(now+ts, 0) })
+		require.NoError(t, err)
+
+		_, err = db.Set(&schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte("key1"), Value: []byte(fmt.Sprintf("value%d", i))}}})
+		require.NoError(t, err)
+	}
+
+	txIDs := func(txList *schema.TxList) []uint64 {
+		ids := make([]uint64, len(txList.Txs))
+		for i, tx := range txList.Txs {
+			ids[i] = tx.Header.Id
+		}
+		return ids
+	}
+
+	t.Run("txs should be scanned within the given times", func(t *testing.T) {
+		txList, err := db.TxScan(context.Background(), &schema.TxScanRequest{StartTime: now + 200, EndTime: now + 300})
+		require.NoError(t, err)
+		require.Equal(t, []uint64{3, 4}, txIDs(txList))
+
+		txList, err = db.TxScan(context.Background(), &schema.TxScanRequest{StartTime: now + 150, Desc: true})
+		require.NoError(t, err)
+		require.Equal(t, []uint64{5, 4, 3}, txIDs(txList))
+
+		txList, err = db.TxScan(context.Background(), &schema.TxScanRequest{InitialTx: 4, StartTime: now + 150, Limit: 1})
+		require.NoError(t, err)
+		require.Equal(t, []uint64{4}, txIDs(txList))
+
+		txList, err = db.TxScan(context.Background(), &schema.TxScanRequest{StartTime: now + 500})
+		require.NoError(t, err)
+		require.Empty(t, txList.Txs)
+
+		_, err = db.TxScan(context.Background(), &schema.TxScanRequest{StartTime: now + 300, EndTime: now + 200})
+		require.ErrorIs(t, err, ErrIllegalArguments)
+	})
+
+	t.Run("history should be filtered by the given times", func(t *testing.T) {
+		history, err := db.History(context.Background(), &schema.HistoryRequest{Key: []byte("key1"), StartTime: now + 150, EndTime: now + 350})
+		require.NoError(t, err)
+		require.Len(t, history.Entries, 2)
+		require.Equal(t, []byte("value1"), history.Entries[0].Value)
+		require.Equal(t, []byte("value2"), history.Entries[1].Value)
+
+		history, err = db.History(context.Background(), &schema.HistoryRequest{Key: []byte("key1"), StartTime: now + 150, Offset: 1, Limit: 1, Desc: true})
+		require.NoError(t, err)
+		require.Len(t, history.Entries, 1)
+		require.Equal(t, []byte("value2"), history.Entries[0].Value)
+
+		history, err = db.History(context.Background(), &schema.HistoryRequest{Key: []byte("key1"), EndTime: now + 50})
+		require.NoError(t, err)
+		require.Empty(t, history.Entries)
+	})
+}
+
+func TestReadsWithCancelledContext(t *testing.T) {
+	db, closer := makeDb()
+	defer closer()
+
+	_, err := db.Set(&schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte("key1"), Value: []byte("value1")}}})
+	require.NoError(t, err)
+
+	_, err = db.ZAdd(&schema.ZAddRequest{Set: []byte("set1"), Score: 1, Key: []byte("key1")})
+	require.NoError(t, err)
+
+	_, _, err = db.SQLExec(&schema.SQLExecRequest{Sql: "CREATE TABLE table1(id INTEGER, PRIMARY KEY id)"}, nil)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = db.Scan(ctx, &schema.ScanRequest{})
+	require.ErrorIs(t, err, context.Canceled)
+
+	_, err = db.ZScan(ctx, &schema.ZScanRequest{Set: []byte("set1")})
+	require.ErrorIs(t, err, context.Canceled)
+
+	_, err = db.History(ctx, &schema.HistoryRequest{Key: []byte("key1")})
+	require.ErrorIs(t, err, context.Canceled)
+
+	_, err = db.TxScan(ctx, &schema.TxScanRequest{InitialTx: 1})
+	require.ErrorIs(t, err, context.Canceled)
+
+	_, err = db.SQLQuery(ctx, &schema.SQLQueryRequest{Sql: "SELECT * FROM table1"}, nil)
+	require.ErrorIs(t, err, context.Canceled)
+
+	// no wait is needed for already indexed entries
+	_, err = db.Get(ctx, &schema.KeyRequest{Key: []byte("key1")})
+	require.NoError(t, err)
+}
+
 /*
 func TestReference(t *testing.T) {
 	db, closer := makeDb()
@@ -1303,3 +1503,39 @@ func TestDb_SetBatchAtomicOperations(t *testing.T) {
 	require.NoError(t, err)
 }
 */
+
+func TestMaintenanceMode(t *testing.T) {
+	db, closer := makeDb()
+	defer closer()
+
+	_, err := db.Set(&schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte("key1"), Value: []byte("value1")}}})
+	require.NoError(t, err)
+
+	db.AsInMaintenance(true)
+	require.True(t, db.IsInMaintenance())
+
+	_, err = db.Set(&schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte("key2"), Value: []byte("value2")}}})
+	require.ErrorIs(t, err, ErrIsInMaintenance)
+
+	_, err = db.Delete(&schema.DeleteKeysRequest{Keys: [][]byte{[]byte("key1")}})
+	require.ErrorIs(t, err, ErrIsInMaintenance)
+
+	_, err = db.SetReference(&schema.ReferenceRequest{Key: []byte("ref1"), ReferencedKey: []byte("key1")})
+	require.ErrorIs(t, err, ErrIsInMaintenance)
+
+	_, err = db.ZAdd(&schema.ZAddRequest{Set: []byte("set1"), Key: []byte("key1"), Score: 1})
+	require.ErrorIs(t, err, ErrIsInMaintenance)
+
+	_, _, err = db.SQLExec(&schema.SQLExecRequest{Sql: "CREATE TABLE table1(id INTEGER, PRIMARY KEY id)"}, nil)
+	require.ErrorIs(t, err, ErrIsInMaintenance)
+
+	// reads are still allowed
+	entry, err := db.Get(context.Background(), &schema.KeyRequest{Key: []byte("key1")})
+	require.NoError(t, err)
+	require.Equal(t, []byte("value1"), entry.Value)
+
+	db.AsInMaintenance(false)
+
+	_, err = db.Set(&schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte("key2"), Value: []byte("value2")}}})
+	require.NoError(t, err)
+}
@@ -26,6 +26,17 @@ type Options struct {
 
 	replica bool
 
+	maintenanceMode bool
+
+	anonymousReads bool
+
+	reverseReferenceIndex bool
+
+	maxDiskUsage int64
+
+	maxResultRows  int
+	maxResultBytes int
+
 	corruptionChecker bool
 }
 
@@ -75,3 +86,39 @@ func (o *Options) AsReplica(replica bool) *Options {
 	o.replica = replica
 	return o
 }
+
+// AsInMaintenance sets if the database is in maintenance mode, so writes are rejected
+func (o *Options) AsInMaintenance(maintenanceMode bool) *Options {
+	o.maintenanceMode = maintenanceMode
+	return o
+}
+
+// WithAnonymousReads sets if the database can be read without logging in
+func (o *Options) WithAnonymousReads(anonymousReads bool) *Options {
+	o.anonymousReads = anonymousReads
+	return o
+}
+
+// WithReverseReferenceIndex sets if a reverse entry is written along with each reference, as required by ResolveAll
+func (o *Options) WithReverseReferenceIndex(reverseReferenceIndex bool) *Options {
+	o.reverseReferenceIndex = reverseReferenceIndex
+	return o
+}
+
+// WithMaxResultRows sets the max number of rows of a SQL result or entries of a scan, 0 means unlimited
+func (o *Options) WithMaxResultRows(maxResultRows int) *Options {
+	o.maxResultRows = maxResultRows
+	return o
+}
+
+// WithMaxResultBytes sets the max size in bytes of a SQL result or a scan, 0 means unlimited
+func (o *Options) WithMaxResultBytes(maxResultBytes int) *Options {
+	o.maxResultBytes = maxResultBytes
+	return o
+}
+
+// WithMaxDiskUsage sets the disk space in bytes the database may take before writes are rejected, 0 means unlimited
+func (o *Options) WithMaxDiskUsage(maxDiskUsage int64) *Options {
+	o.maxDiskUsage = maxDiskUsage
+	return o
+}
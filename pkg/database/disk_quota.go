@@ -0,0 +1,144 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package database
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// DefaultDiskUsageCheckInterval is how long the disk usage of a database is cached before being computed again
+const DefaultDiskUsageCheckInterval = 10 * time.Second
+
+var ErrDiskQuotaExceeded = errors.New("database exceeds its disk usage quota, writes are rejected")
+
+// diskUsageTracker caches the disk space taken by a database, walking its directory on every write would be too expensive
+type diskUsageTracker struct {
+	mutex sync.Mutex
+
+	checkInterval time.Duration // DefaultDiskUsageCheckInterval when not set
+
+	bytes     int64
+	checkedAt time.Time
+
+	exceeded bool
+}
+
+func dirSize(dir string) (int64, error) {
+	var size int64
+
+	err := filepath.Walk(dir, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			// files may be removed while walking, e.g. by compaction
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+
+		if !info.IsDir() {
+			size += info.Size()
+		}
+
+		return nil
+	})
+
+	return size, err
+}
+
+// SetMaxDiskUsage sets the disk space in bytes the database may take before writes are rejected, 0 means unlimited
+func (d *db) SetMaxDiskUsage(maxDiskUsage int64) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.options.maxDiskUsage = maxDiskUsage
+}
+
+// DiskUsage returns the disk space in bytes taken by the database,
+// it's computed again once the previous value is older than the check interval
+func (d *db) DiskUsage() (int64, error) {
+	d.diskUsage.mutex.Lock()
+	defer d.diskUsage.mutex.Unlock()
+
+	return d.diskUsageLocked()
+}
+
+func (d *db) diskUsageLocked() (int64, error) {
+	checkInterval := d.diskUsage.checkInterval
+	if checkInterval == 0 {
+		checkInterval = DefaultDiskUsageCheckInterval
+	}
+
+	if !d.diskUsage.checkedAt.IsZero() && time.Since(d.diskUsage.checkedAt) < checkInterval {
+		return d.diskUsage.bytes, nil
+	}
+
+	size, err := dirSize(d.path())
+	if err != nil {
+		return 0, err
+	}
+
+	d.diskUsage.bytes = size
+	d.diskUsage.checkedAt = time.Now()
+
+	return size, nil
+}
+
+// DiskQuotaExceeded returns if the database takes more disk space than allowed, so writes are rejected
+func (d *db) DiskQuotaExceeded() bool {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	return d.isDiskQuotaExceeded()
+}
+
+// isDiskQuotaExceeded checks the disk usage of the database against its quota,
+// an alert is logged when the quota gets exceeded and once the database is back within its quota
+func (d *db) isDiskQuotaExceeded() bool {
+	maxDiskUsage := d.options.maxDiskUsage
+
+	d.diskUsage.mutex.Lock()
+	defer d.diskUsage.mutex.Unlock()
+
+	if maxDiskUsage == 0 {
+		d.diskUsage.exceeded = false
+		return false
+	}
+
+	usage, err := d.diskUsageLocked()
+	if err != nil {
+		// the last known state is kept while the disk usage can not be computed
+		d.Logger.Warningf("Disk usage of database '%s' could not be computed: %v", d.name, err)
+		return d.diskUsage.exceeded
+	}
+
+	exceeded := usage >= maxDiskUsage
+
+	if exceeded && !d.diskUsage.exceeded {
+		d.Logger.Errorf("Database '%s' exceeds its disk usage quota (%d of %d bytes), writes are rejected", d.name, usage, maxDiskUsage)
+	}
+	if !exceeded && d.diskUsage.exceeded {
+		d.Logger.Infof("Database '%s' is back within its disk usage quota (%d of %d bytes), writes are accepted", d.name, usage, maxDiskUsage)
+	}
+
+	d.diskUsage.exceeded = exceeded
+
+	return exceeded
+}
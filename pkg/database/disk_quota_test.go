@@ -0,0 +1,74 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiskQuota(t *testing.T) {
+	d, closer := makeDb()
+	db := d.(*db)
+	defer closer()
+
+	db.diskUsage.checkInterval = time.Nanosecond
+
+	_, err := db.Set(&schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte("key1"), Value: []byte("value1")}}})
+	require.NoError(t, err)
+
+	usage, err := db.DiskUsage()
+	require.NoError(t, err)
+	require.Positive(t, usage)
+	require.False(t, db.DiskQuotaExceeded())
+
+	db.SetMaxDiskUsage(1)
+	require.True(t, db.DiskQuotaExceeded())
+
+	_, err = db.Set(&schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte("key2"), Value: []byte("value2")}}})
+	require.ErrorIs(t, err, ErrDiskQuotaExceeded)
+
+	_, err = db.Delete(&schema.DeleteKeysRequest{Keys: [][]byte{[]byte("key1")}})
+	require.ErrorIs(t, err, ErrDiskQuotaExceeded)
+
+	_, err = db.SetReference(&schema.ReferenceRequest{Key: []byte("ref1"), ReferencedKey: []byte("key1")})
+	require.ErrorIs(t, err, ErrDiskQuotaExceeded)
+
+	_, err = db.ZAdd(&schema.ZAddRequest{Set: []byte("set1"), Key: []byte("key1"), Score: 1})
+	require.ErrorIs(t, err, ErrDiskQuotaExceeded)
+
+	_, _, err = db.SQLExec(&schema.SQLExecRequest{Sql: "CREATE TABLE table1(id INTEGER, PRIMARY KEY id)"}, nil)
+	require.ErrorIs(t, err, ErrDiskQuotaExceeded)
+
+	// data can still be read
+	entry, err := db.Get(context.Background(), &schema.KeyRequest{Key: []byte("key1")})
+	require.NoError(t, err)
+	require.Equal(t, []byte("value1"), entry.Value)
+
+	db.SetMaxDiskUsage(usage * 1024)
+	require.False(t, db.DiskQuotaExceeded())
+
+	_, err = db.Set(&schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte("key2"), Value: []byte("value2")}}})
+	require.NoError(t, err)
+
+	db.SetMaxDiskUsage(0)
+	require.False(t, db.DiskQuotaExceeded())
+}
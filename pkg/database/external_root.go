@@ -0,0 +1,267 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package database
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"time"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"google.golang.org/protobuf/proto"
+)
+
+const sourceLenLen = 8
+const treeSizeLen = 8
+
+var ErrExternalRootConflict = errors.New("external root conflicts with an already registered root of the same size")
+var ErrExternalRootOutdated = errors.New("external root is older than the latest registered one")
+var ErrExternalRootNotFound = errors.New("no external root registered for the given source")
+
+// EncodeExternalRootKey builds the key under which roots observed from an external source are stored
+// externalRootKey = [1+sourceLenLen+len(source)+treeSizeLen]
+func EncodeExternalRootKey(source string, treeSize uint64) []byte {
+	prefix := externalRootPrefix(source)
+
+	key := make([]byte, len(prefix)+treeSizeLen)
+	copy(key, prefix)
+	binary.BigEndian.PutUint64(key[len(prefix):], treeSize)
+
+	return key
+}
+
+func externalRootPrefix(source string) []byte {
+	prefix := make([]byte, 1+sourceLenLen+len(source))
+	prefix[0] = ExternalRootPrefix
+	binary.BigEndian.PutUint64(prefix[1:], uint64(len(source)))
+	copy(prefix[1+sourceLenLen:], source)
+
+	return prefix
+}
+
+// RegisterExternalRoot appends a root observed from an external log (e.g. another immudb instance or a CT log).
+// Roots of the same source must be registered in non-decreasing tree size order, and a root whose
+// tree size was already registered is only accepted if its hash matches the one previously registered.
+func (d *db) RegisterExternalRoot(root *schema.ExternalRoot) (*schema.TxHeader, error) {
+	if root == nil || len(root.Source) == 0 || root.TreeSize == 0 || len(root.RootHash) != sha256.Size {
+		return nil, store.ErrIllegalArguments
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if d.isReplica() {
+		return nil, ErrIsReplica
+	}
+
+	if d.isInMaintenance() {
+		return nil, ErrIsInMaintenance
+	}
+
+	if d.isDiskQuotaExceeded() {
+		return nil, ErrDiskQuotaExceeded
+	}
+
+	lastTxID, _ := d.st.Alh()
+	err := d.st.WaitForIndexingUpto(lastTxID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	snap, err := d.st.SnapshotSince(lastTxID)
+	if err != nil {
+		return nil, err
+	}
+	defer snap.Close()
+
+	latest, err := d.latestExternalRoot(snap, root.Source)
+	if err != nil && err != ErrExternalRootNotFound {
+		return nil, err
+	}
+
+	if latest != nil {
+		if root.TreeSize < latest.TreeSize {
+			return nil, ErrExternalRootOutdated
+		}
+
+		if root.TreeSize == latest.TreeSize && !bytes.Equal(root.RootHash, latest.RootHash) {
+			return nil, ErrExternalRootConflict
+		}
+	}
+
+	if root.ObservedAt == 0 {
+		root.ObservedAt = time.Now().Unix()
+	}
+
+	value, err := proto.Marshal(root)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := d.st.NewWriteOnlyTx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Cancel()
+
+	err = tx.Set(EncodeExternalRootKey(root.Source, root.TreeSize), nil, value)
+	if err != nil {
+		return nil, err
+	}
+
+	hdr, err := tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	return schema.TxHeaderToProto(hdr), nil
+}
+
+// LatestExternalRoot returns the root with the biggest tree size registered for the given source
+func (d *db) LatestExternalRoot(source string) (*schema.ExternalRoot, error) {
+	if len(source) == 0 {
+		return nil, store.ErrIllegalArguments
+	}
+
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	lastTxID, _ := d.st.Alh()
+	err := d.st.WaitForIndexingUpto(lastTxID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	snap, err := d.st.SnapshotSince(lastTxID)
+	if err != nil {
+		return nil, err
+	}
+	defer snap.Close()
+
+	return d.latestExternalRoot(snap, source)
+}
+
+func (d *db) latestExternalRoot(snap *store.Snapshot, source string) (*schema.ExternalRoot, error) {
+	r, err := snap.NewKeyReader(
+		&store.KeyReaderSpec{
+			Prefix:    externalRootPrefix(source),
+			DescOrder: true,
+		})
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	_, valRef, err := r.Read()
+	if err == store.ErrNoMoreEntries {
+		return nil, ErrExternalRootNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeExternalRoot(valRef)
+}
+
+// ExternalRoots lists the roots registered for a given external source
+func (d *db) ExternalRoots(req *schema.ExternalRootsRequest) (*schema.ExternalRootList, error) {
+	if req == nil || len(req.Source) == 0 {
+		return nil, store.ErrIllegalArguments
+	}
+
+	if req.Limit > MaxKeyScanLimit {
+		return nil, ErrMaxKeyScanLimitExceeded
+	}
+
+	limit := req.Limit
+
+	if req.Limit == 0 {
+		limit = MaxKeyScanLimit
+	}
+
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	lastTxID, _ := d.st.Alh()
+	err := d.st.WaitForIndexingUpto(lastTxID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	snap, err := d.st.SnapshotSince(lastTxID)
+	if err != nil {
+		return nil, err
+	}
+	defer snap.Close()
+
+	var seekKey []byte
+
+	if req.SeekTreeSize > 0 {
+		seekKey = EncodeExternalRootKey(req.Source, req.SeekTreeSize)
+	}
+
+	r, err := snap.NewKeyReader(
+		&store.KeyReaderSpec{
+			SeekKey:       seekKey,
+			Prefix:        externalRootPrefix(req.Source),
+			InclusiveSeek: true,
+			DescOrder:     req.Desc,
+		})
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	var roots []*schema.ExternalRoot
+
+	for i := uint64(0); i < limit; i++ {
+		_, valRef, err := r.Read()
+		if err == store.ErrNoMoreEntries {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		root, err := decodeExternalRoot(valRef)
+		if err != nil {
+			return nil, err
+		}
+
+		roots = append(roots, root)
+	}
+
+	return &schema.ExternalRootList{Roots: roots}, nil
+}
+
+func decodeExternalRoot(valRef store.ValueRef) (*schema.ExternalRoot, error) {
+	val, err := valRef.Resolve()
+	if err != nil {
+		return nil, err
+	}
+
+	var root schema.ExternalRoot
+
+	err = proto.Unmarshal(val, &root)
+	if err != nil {
+		return nil, store.ErrCorruptedData
+	}
+
+	return &root, nil
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package database
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExternalRoots(t *testing.T) {
+	db, closer := makeDb()
+	defer closer()
+
+	_, err := db.RegisterExternalRoot(nil)
+	require.ErrorIs(t, err, store.ErrIllegalArguments)
+
+	_, err = db.RegisterExternalRoot(&schema.ExternalRoot{Source: "log1", TreeSize: 1, RootHash: []byte{1}})
+	require.ErrorIs(t, err, store.ErrIllegalArguments)
+
+	_, err = db.LatestExternalRoot("log1")
+	require.ErrorIs(t, err, ErrExternalRootNotFound)
+
+	for i := 1; i <= 10; i++ {
+		h := sha256.Sum256([]byte{byte(i)})
+
+		_, err = db.RegisterExternalRoot(&schema.ExternalRoot{
+			Source:   "log1",
+			Type:     schema.ExternalRootType_IMMUDB,
+			TreeSize: uint64(i),
+			RootHash: h[:],
+		})
+		require.NoError(t, err)
+	}
+
+	// roots of other sources are kept apart
+	h := sha256.Sum256([]byte("ct"))
+
+	_, err = db.RegisterExternalRoot(&schema.ExternalRoot{
+		Source:   "log",
+		Type:     schema.ExternalRootType_CT_LOG,
+		TreeSize: 100,
+		RootHash: h[:],
+	})
+	require.NoError(t, err)
+
+	latest, err := db.LatestExternalRoot("log1")
+	require.NoError(t, err)
+	require.Equal(t, uint64(10), latest.TreeSize)
+	require.Equal(t, schema.ExternalRootType_IMMUDB, latest.Type)
+	require.NotZero(t, latest.ObservedAt)
+
+	t.Run("registering an older root should fail", func(t *testing.T) {
+		h := sha256.Sum256([]byte{5})
+
+		_, err = db.RegisterExternalRoot(&schema.ExternalRoot{Source: "log1", TreeSize: 5, RootHash: h[:]})
+		require.ErrorIs(t, err, ErrExternalRootOutdated)
+	})
+
+	t.Run("registering a conflicting root should fail", func(t *testing.T) {
+		h := sha256.Sum256([]byte{11})
+
+		_, err = db.RegisterExternalRoot(&schema.ExternalRoot{Source: "log1", TreeSize: 10, RootHash: h[:]})
+		require.ErrorIs(t, err, ErrExternalRootConflict)
+	})
+
+	t.Run("registering the same root again should succeed", func(t *testing.T) {
+		_, err = db.RegisterExternalRoot(&schema.ExternalRoot{Source: "log1", TreeSize: 10, RootHash: latest.RootHash})
+		require.NoError(t, err)
+	})
+
+	t.Run("listing roots", func(t *testing.T) {
+		_, err = db.ExternalRoots(nil)
+		require.ErrorIs(t, err, store.ErrIllegalArguments)
+
+		_, err = db.ExternalRoots(&schema.ExternalRootsRequest{Source: "log1", Limit: MaxKeyScanLimit + 1})
+		require.ErrorIs(t, err, ErrMaxKeyScanLimitExceeded)
+
+		roots, err := db.ExternalRoots(&schema.ExternalRootsRequest{Source: "log1"})
+		require.NoError(t, err)
+		require.Len(t, roots.Roots, 10)
+
+		for i, r := range roots.Roots {
+			require.Equal(t, uint64(i+1), r.TreeSize)
+		}
+
+		roots, err = db.ExternalRoots(&schema.ExternalRootsRequest{Source: "log1", SeekTreeSize: 4, Limit: 3, Desc: true})
+		require.NoError(t, err)
+		require.Len(t, roots.Roots, 3)
+		require.Equal(t, uint64(4), roots.Roots[0].TreeSize)
+		require.Equal(t, uint64(2), roots.Roots[2].TreeSize)
+
+		roots, err = db.ExternalRoots(&schema.ExternalRootsRequest{Source: "log"})
+		require.NoError(t, err)
+		require.Len(t, roots.Roots, 1)
+		require.Equal(t, schema.ExternalRootType_CT_LOG, roots.Roots[0].Type)
+	})
+}
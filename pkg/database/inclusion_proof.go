@@ -0,0 +1,127 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package database
+
+import (
+	"context"
+	"crypto/sha256"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/api/schema"
+)
+
+// EntryInclusionProof is the whole audit path of an entry, from its digest up to the state of the database.
+// Starting from EntryDigest, each step hashes the digest computed so far as sha256(prefix + digest + suffix),
+// the last one yields the hash of the state. Verifiers need no other knowledge of how entries and txs are
+// linked together, but checking the signature of the state and, if the value matters, its digest
+type EntryInclusionProof struct {
+	Entry *schema.Entry `json:"entry"`
+
+	// EntryTx is the tx the proven entry was committed in,
+	// the entry of the reference is the one proven when the key was resolved through a reference
+	EntryTx     uint64 `json:"entryTx"`
+	EntryDigest []byte `json:"entryDigest"`
+
+	Path []*AuditPathStep `json:"path"`
+
+	State *schema.ImmutableState `json:"state"`
+}
+
+type AuditPathStep struct {
+	Prefix []byte `json:"prefix,omitempty"`
+	Suffix []byte `json:"suffix,omitempty"`
+}
+
+// Verify evaluates the path from the entry digest and compares the result with the hash of the state,
+// the signature of the state is not checked
+func (p *EntryInclusionProof) Verify() bool {
+	if p == nil || p.State == nil || len(p.EntryDigest) != sha256.Size || len(p.State.TxHash) != sha256.Size {
+		return false
+	}
+
+	path := make([]*store.AuditStep, len(p.Path))
+
+	for i, step := range p.Path {
+		if step == nil {
+			return false
+		}
+
+		path[i] = &store.AuditStep{Prefix: step.Prefix, Suffix: step.Suffix}
+	}
+
+	var digest [sha256.Size]byte
+	copy(digest[:], p.EntryDigest)
+
+	root := store.EvalAuditPath(path, digest)
+
+	return string(root[:]) == string(p.State.TxHash)
+}
+
+// GetInclusionProof returns the audit path of the entry of key, as committed at atTx or its latest one if atTx is 0,
+// up to the current state of the database. The state is neither signed nor named after the database
+func (d *db) GetInclusionProof(ctx context.Context, key []byte, atTx uint64) (*EntryInclusionProof, error) {
+	e, err := d.Get(ctx, &schema.KeyRequest{Key: key, AtTx: atTx})
+	if err != nil {
+		return nil, err
+	}
+
+	entryTxID := e.Tx
+	entryKey := e.Key
+
+	if e.ReferencedBy != nil {
+		entryTxID = e.ReferencedBy.Tx
+		entryKey = e.ReferencedBy.Key
+	}
+
+	tx := d.st.NewTxHolder()
+
+	err = d.st.ReadTx(entryTxID, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	stateTxID, stateAlh := d.st.Alh()
+
+	stateTx := d.st.NewTxHolder()
+
+	err = d.st.ReadTx(stateTxID, stateTx)
+	if err != nil {
+		return nil, err
+	}
+
+	entryDigest, path, err := d.st.AuditPath(tx, EncodeKey(entryKey), stateTx)
+	if err != nil {
+		return nil, err
+	}
+
+	proof := &EntryInclusionProof{
+		Entry:       e,
+		EntryTx:     entryTxID,
+		EntryDigest: entryDigest[:],
+		Path:        make([]*AuditPathStep, len(path)),
+		State: &schema.ImmutableState{
+			TxId:   stateTxID,
+			TxHash: stateAlh[:],
+		},
+	}
+
+	for i, step := range path {
+		proof.Path[i] = &AuditPathStep{Prefix: step.Prefix, Suffix: step.Suffix}
+	}
+
+	return proof, nil
+}
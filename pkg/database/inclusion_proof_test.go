@@ -0,0 +1,111 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package database
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetInclusionProof(t *testing.T) {
+	db, closer := makeDb()
+	defer closer()
+
+	var hdrs []*schema.TxHeader
+
+	for i := 0; i < 10; i++ {
+		hdr, err := db.Set(&schema.SetRequest{KVs: []*schema.KeyValue{
+			{Key: []byte("key1"), Value: []byte(fmt.Sprintf("value%d", i))},
+			{Key: []byte(fmt.Sprintf("key%d", i+2)), Value: []byte("value")},
+		}})
+		require.NoError(t, err)
+
+		hdrs = append(hdrs, hdr)
+	}
+
+	refHdr, err := db.SetReference(&schema.ReferenceRequest{Key: []byte("ref1"), ReferencedKey: []byte("key1")})
+	require.NoError(t, err)
+
+	state, err := db.CurrentState()
+	require.NoError(t, err)
+
+	for i, hdr := range hdrs {
+		proof, err := db.GetInclusionProof(context.Background(), []byte("key1"), hdr.Id)
+		require.NoError(t, err)
+		require.Equal(t, []byte(fmt.Sprintf("value%d", i)), proof.Entry.Value)
+		require.Equal(t, hdr.Id, proof.EntryTx)
+		require.Equal(t, state.TxId, proof.State.TxId)
+		require.Equal(t, state.TxHash, proof.State.TxHash)
+		require.True(t, proof.Verify())
+
+		// the entry digest is the one the tx commits to
+		vEntry, err := db.VerifiableGet(context.Background(), &schema.VerifiableGetRequest{
+			KeyRequest: &schema.KeyRequest{Key: []byte("key1"), AtTx: hdr.Id},
+		})
+		require.NoError(t, err)
+
+		iproof := schema.InclusionProofFromProto(vEntry.InclusionProof)
+		eh := schema.DigestFromProto(vEntry.VerifiableTx.Tx.Header.EH)
+
+		var entryDigest [32]byte
+		copy(entryDigest[:], proof.EntryDigest)
+
+		require.True(t, store.VerifyInclusion(iproof, entryDigest, eh))
+	}
+
+	t.Run("the latest entry is proven when no tx is given", func(t *testing.T) {
+		proof, err := db.GetInclusionProof(context.Background(), []byte("key1"), 0)
+		require.NoError(t, err)
+		require.Equal(t, hdrs[len(hdrs)-1].Id, proof.EntryTx)
+		require.True(t, proof.Verify())
+	})
+
+	t.Run("the entry of the reference is proven", func(t *testing.T) {
+		proof, err := db.GetInclusionProof(context.Background(), []byte("ref1"), 0)
+		require.NoError(t, err)
+		require.Equal(t, []byte("value9"), proof.Entry.Value)
+		require.Equal(t, refHdr.Id, proof.EntryTx)
+		require.Equal(t, refHdr.Id, proof.State.TxId)
+		require.True(t, proof.Verify())
+	})
+
+	t.Run("tampered proofs should not verify", func(t *testing.T) {
+		proof, err := db.GetInclusionProof(context.Background(), []byte("key1"), hdrs[0].Id)
+		require.NoError(t, err)
+
+		proof.EntryDigest[0] ^= 1
+		require.False(t, proof.Verify())
+
+		proof.EntryDigest[0] ^= 1
+		proof.Path = proof.Path[1:]
+		require.False(t, proof.Verify())
+
+		proof.State = nil
+		require.False(t, proof.Verify())
+	})
+
+	_, err = db.GetInclusionProof(context.Background(), []byte("key3"), hdrs[0].Id)
+	require.ErrorIs(t, err, store.ErrKeyNotFound)
+
+	_, err = db.GetInclusionProof(context.Background(), nil, 0)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+}
@@ -0,0 +1,421 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package database
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"unicode/utf8"
+)
+
+// jsonSchema is a compiled JSON schema. The validation keywords supported are
+// type, enum, const, properties, required, additionalProperties, items, minItems, maxItems,
+// minimum, maximum, exclusiveMinimum, exclusiveMaximum, minLength, maxLength and pattern.
+// Any other keyword is ignored, as established by the JSON schema specification.
+type jsonSchema struct {
+	rejectAll bool
+
+	types []string
+	enum  []interface{}
+
+	properties           map[string]*jsonSchema
+	required             []string
+	additionalProperties *jsonSchema
+
+	items    *jsonSchema
+	minItems *float64
+	maxItems *float64
+
+	minimum          *float64
+	maximum          *float64
+	exclusiveMinimum *float64
+	exclusiveMaximum *float64
+
+	minLength *float64
+	maxLength *float64
+	pattern   *regexp.Regexp
+}
+
+var jsonSchemaTypes = map[string]struct{}{
+	"null":    {},
+	"boolean": {},
+	"object":  {},
+	"array":   {},
+	"number":  {},
+	"integer": {},
+	"string":  {},
+}
+
+func compileJSONSchema(definition []byte) (*jsonSchema, error) {
+	var doc interface{}
+
+	err := json.Unmarshal(definition, &doc)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseJSONSchema(doc, "$")
+}
+
+func parseJSONSchema(doc interface{}, path string) (*jsonSchema, error) {
+	switch d := doc.(type) {
+	case bool:
+		return &jsonSchema{rejectAll: !d}, nil
+	case map[string]interface{}:
+		return parseJSONSchemaObject(d, path)
+	}
+
+	return nil, fmt.Errorf("%s: schema must be an object or a boolean", path)
+}
+
+func parseJSONSchemaObject(doc map[string]interface{}, path string) (*jsonSchema, error) {
+	s := &jsonSchema{}
+
+	switch t := doc["type"].(type) {
+	case nil:
+	case string:
+		s.types = []string{t}
+	case []interface{}:
+		for _, v := range t {
+			name, ok := v.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s: type must be a string or an array of strings", path)
+			}
+			s.types = append(s.types, name)
+		}
+	default:
+		return nil, fmt.Errorf("%s: type must be a string or an array of strings", path)
+	}
+
+	for _, t := range s.types {
+		_, ok := jsonSchemaTypes[t]
+		if !ok {
+			return nil, fmt.Errorf("%s: unknown type '%s'", path, t)
+		}
+	}
+
+	if enum, ok := doc["enum"]; ok {
+		values, ok := enum.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s: enum must be an array", path)
+		}
+		s.enum = values
+	}
+
+	if c, ok := doc["const"]; ok {
+		s.enum = []interface{}{c}
+	}
+
+	if props, ok := doc["properties"]; ok {
+		m, ok := props.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s: properties must be an object", path)
+		}
+
+		s.properties = make(map[string]*jsonSchema, len(m))
+
+		for name, p := range m {
+			ps, err := parseJSONSchema(p, path+"."+name)
+			if err != nil {
+				return nil, err
+			}
+			s.properties[name] = ps
+		}
+	}
+
+	if req, ok := doc["required"]; ok {
+		names, ok := req.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s: required must be an array of strings", path)
+		}
+
+		for _, n := range names {
+			name, ok := n.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s: required must be an array of strings", path)
+			}
+			s.required = append(s.required, name)
+		}
+	}
+
+	if ap, ok := doc["additionalProperties"]; ok {
+		aps, err := parseJSONSchema(ap, path+".additionalProperties")
+		if err != nil {
+			return nil, err
+		}
+		s.additionalProperties = aps
+	}
+
+	if items, ok := doc["items"]; ok {
+		is, err := parseJSONSchema(items, path+"[]")
+		if err != nil {
+			return nil, err
+		}
+		s.items = is
+	}
+
+	for _, n := range []struct {
+		keyword string
+		value   **float64
+	}{
+		{"minItems", &s.minItems},
+		{"maxItems", &s.maxItems},
+		{"minimum", &s.minimum},
+		{"maximum", &s.maximum},
+		{"exclusiveMinimum", &s.exclusiveMinimum},
+		{"exclusiveMaximum", &s.exclusiveMaximum},
+		{"minLength", &s.minLength},
+		{"maxLength", &s.maxLength},
+	} {
+		v, ok := doc[n.keyword]
+		if !ok {
+			continue
+		}
+
+		f, ok := v.(float64)
+		if !ok {
+			return nil, fmt.Errorf("%s: %s must be a number", path, n.keyword)
+		}
+		*n.value = &f
+	}
+
+	if p, ok := doc["pattern"]; ok {
+		expr, ok := p.(string)
+		if !ok {
+			return nil, fmt.Errorf("%s: pattern must be a string", path)
+		}
+
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid pattern: %v", path, err)
+		}
+		s.pattern = re
+	}
+
+	return s, nil
+}
+
+func (s *jsonSchema) validateValue(value []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(value))
+
+	var v interface{}
+
+	err := dec.Decode(&v)
+	if err != nil {
+		return fmt.Errorf("value is not a valid json document: %v", err)
+	}
+
+	if dec.More() {
+		return fmt.Errorf("value is not a valid json document: unexpected data after the document")
+	}
+
+	return s.validate(v, "$")
+}
+
+func (s *jsonSchema) validate(v interface{}, path string) error {
+	if s.rejectAll {
+		return fmt.Errorf("%s: no value is allowed", path)
+	}
+
+	if len(s.types) > 0 && !s.matchesType(v) {
+		return fmt.Errorf("%s: expected %s but found %s", path, joinTypes(s.types), jsonTypeOf(v))
+	}
+
+	if s.enum != nil {
+		found := false
+
+		for _, e := range s.enum {
+			if reflect.DeepEqual(e, v) {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return fmt.Errorf("%s: value is not one of the allowed ones", path)
+		}
+	}
+
+	switch x := v.(type) {
+	case map[string]interface{}:
+		return s.validateObject(x, path)
+	case []interface{}:
+		return s.validateArray(x, path)
+	case float64:
+		return s.validateNumber(x, path)
+	case string:
+		return s.validateString(x, path)
+	}
+
+	return nil
+}
+
+func (s *jsonSchema) validateObject(obj map[string]interface{}, path string) error {
+	for _, name := range s.required {
+		_, ok := obj[name]
+		if !ok {
+			return fmt.Errorf("%s: missing required property '%s'", path, name)
+		}
+	}
+
+	names := make([]string, 0, len(obj))
+	for name := range obj {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		ps, ok := s.properties[name]
+		if !ok {
+			ps = s.additionalProperties
+		}
+		if ps == nil {
+			continue
+		}
+
+		if !ok && ps.rejectAll {
+			return fmt.Errorf("%s: property '%s' is not allowed", path, name)
+		}
+
+		err := ps.validate(obj[name], path+"."+name)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *jsonSchema) validateArray(arr []interface{}, path string) error {
+	if s.minItems != nil && float64(len(arr)) < *s.minItems {
+		return fmt.Errorf("%s: expected at least %v items but found %d", path, *s.minItems, len(arr))
+	}
+
+	if s.maxItems != nil && float64(len(arr)) > *s.maxItems {
+		return fmt.Errorf("%s: expected at most %v items but found %d", path, *s.maxItems, len(arr))
+	}
+
+	if s.items == nil {
+		return nil
+	}
+
+	for i, item := range arr {
+		err := s.items.validate(item, fmt.Sprintf("%s[%d]", path, i))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *jsonSchema) validateNumber(n float64, path string) error {
+	if s.minimum != nil && n < *s.minimum {
+		return fmt.Errorf("%s: %v is lower than the minimum %v", path, n, *s.minimum)
+	}
+
+	if s.maximum != nil && n > *s.maximum {
+		return fmt.Errorf("%s: %v is greater than the maximum %v", path, n, *s.maximum)
+	}
+
+	if s.exclusiveMinimum != nil && n <= *s.exclusiveMinimum {
+		return fmt.Errorf("%s: %v is not greater than %v", path, n, *s.exclusiveMinimum)
+	}
+
+	if s.exclusiveMaximum != nil && n >= *s.exclusiveMaximum {
+		return fmt.Errorf("%s: %v is not lower than %v", path, n, *s.exclusiveMaximum)
+	}
+
+	return nil
+}
+
+func (s *jsonSchema) validateString(str string, path string) error {
+	l := float64(utf8.RuneCountInString(str))
+
+	if s.minLength != nil && l < *s.minLength {
+		return fmt.Errorf("%s: expected at least %v characters but found %v", path, *s.minLength, l)
+	}
+
+	if s.maxLength != nil && l > *s.maxLength {
+		return fmt.Errorf("%s: expected at most %v characters but found %v", path, *s.maxLength, l)
+	}
+
+	if s.pattern != nil && !s.pattern.MatchString(str) {
+		return fmt.Errorf("%s: value does not match the pattern '%s'", path, s.pattern.String())
+	}
+
+	return nil
+}
+
+func (s *jsonSchema) matchesType(v interface{}) bool {
+	vt := jsonTypeOf(v)
+
+	for _, t := range s.types {
+		if t == vt {
+			return true
+		}
+
+		if t == "number" && vt == "integer" {
+			return true
+		}
+	}
+
+	return false
+}
+
+func jsonTypeOf(v interface{}) string {
+	switch x := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case float64:
+		if x == math.Trunc(x) && !math.IsInf(x, 0) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	}
+
+	return fmt.Sprintf("%T", v)
+}
+
+func joinTypes(types []string) string {
+	if len(types) == 1 {
+		return types[0]
+	}
+
+	var b bytes.Buffer
+
+	for i, t := range types {
+		if i > 0 {
+			b.WriteString(" or ")
+		}
+		b.WriteString(t)
+	}
+
+	return b.String()
+}
@@ -0,0 +1,292 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package database
+
+import (
+	"container/heap"
+	"context"
+	"math/bits"
+	"sort"
+
+	"github.com/codenotary/immudb/embedded/store"
+)
+
+const (
+	DefaultKeyspacePrefixLength = 1
+	DefaultKeyspaceOutliers     = 10
+
+	// MaxKeyspacePrefixes bounds the number of prefixes tracked, keys of further prefixes are counted together
+	MaxKeyspacePrefixes = 1000
+	MaxKeyspaceOutliers = 1000
+)
+
+// names of the keyspaces the index of a database is made of
+const (
+	KeyspaceKV        = "kv"
+	KeyspaceSortedSet = "sorted set"
+	KeyspaceSQL       = "sql"
+	KeyspaceInternal  = "internal"
+)
+
+// KeyspaceStatsRequest sets how the keys of a database are summarized
+type KeyspaceStatsRequest struct {
+	// PrefixLength is the number of leading bytes key-value keys are grouped by, DefaultKeyspacePrefixLength if 0
+	PrefixLength int `json:"prefixLength"`
+	// Outliers is the number of keys with most revisions reported, DefaultKeyspaceOutliers if 0
+	Outliers int `json:"outliers"`
+}
+
+// KeyspaceStats summarizes the keys of a database as found in its index at TxID.
+// Deleted keys are not accounted for, sizes are the ones of keys and values as stored
+type KeyspaceStats struct {
+	TxID uint64 `json:"txId"`
+
+	Keyspaces []*KeyspaceUsage `json:"keyspaces"`
+
+	// Prefixes groups key-value keys by their leading bytes, sorted by number of keys
+	Prefixes []*KeyspaceUsage `json:"prefixes"`
+	// OtherPrefixes holds the keys of the prefixes found after MaxKeyspacePrefixes were already tracked
+	OtherPrefixes *KeyspaceUsage `json:"otherPrefixes,omitempty"`
+
+	KeySizes   *SizeDistribution `json:"keySizes"`
+	ValueSizes *SizeDistribution `json:"valueSizes"`
+
+	// RevisionOutliers are the key-value keys with most revisions, sorted by number of revisions
+	RevisionOutliers []*KeyRevisions `json:"revisionOutliers"`
+}
+
+// KeyspaceUsage is the number of keys of a keyspace or prefix and the bytes they take
+type KeyspaceUsage struct {
+	Name       string `json:"name,omitempty"`
+	Prefix     []byte `json:"prefix,omitempty"`
+	Keys       uint64 `json:"keys"`
+	KeyBytes   uint64 `json:"keyBytes"`
+	ValueBytes uint64 `json:"valueBytes"`
+}
+
+// SizeDistribution counts sizes into buckets bounded by powers of two
+type SizeDistribution struct {
+	Count   uint64        `json:"count"`
+	Min     uint64        `json:"min"`
+	Max     uint64        `json:"max"`
+	Sum     uint64        `json:"sum"`
+	Buckets []*SizeBucket `json:"buckets"`
+}
+
+// SizeBucket holds the number of sizes up to UpperBound and greater than the upper bound of the previous bucket
+type SizeBucket struct {
+	UpperBound uint64 `json:"upperBound"`
+	Count      uint64 `json:"count"`
+}
+
+type KeyRevisions struct {
+	Key       []byte `json:"key"`
+	Revisions uint64 `json:"revisions"`
+}
+
+func (u *KeyspaceUsage) add(keyLen, valLen uint64) {
+	u.Keys++
+	u.KeyBytes += keyLen
+	u.ValueBytes += valLen
+}
+
+func (d *SizeDistribution) observe(size uint64) {
+	if d.Count == 0 || size < d.Min {
+		d.Min = size
+	}
+	if size > d.Max {
+		d.Max = size
+	}
+
+	d.Count++
+	d.Sum += size
+
+	// the bucket of size is the one bounded by the smallest power of two not lower than size
+	b := bits.Len64(size)
+	if size > 0 && size&(size-1) == 0 {
+		b--
+	}
+
+	for len(d.Buckets) <= b {
+		d.Buckets = append(d.Buckets, &SizeBucket{UpperBound: 1 << len(d.Buckets)})
+	}
+
+	d.Buckets[b].Count++
+}
+
+// Mean returns the mean of the observed sizes
+func (d *SizeDistribution) Mean() float64 {
+	if d.Count == 0 {
+		return 0
+	}
+	return float64(d.Sum) / float64(d.Count)
+}
+
+// revisionsHeap keeps the keys with most revisions, the one with fewest revisions on top
+type revisionsHeap []*KeyRevisions
+
+func (h revisionsHeap) Len() int            { return len(h) }
+func (h revisionsHeap) Less(i, j int) bool  { return h[i].Revisions < h[j].Revisions }
+func (h revisionsHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *revisionsHeap) Push(x interface{}) { *h = append(*h, x.(*KeyRevisions)) }
+func (h *revisionsHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	*h = old[:n-1]
+	return x
+}
+
+func keyspaceName(prefix byte) string {
+	switch prefix {
+	case SetKeyPrefix:
+		return KeyspaceKV
+	case SortedSetKeyPrefix:
+		return KeyspaceSortedSet
+	case SQLPrefix:
+		return KeyspaceSQL
+	default:
+		return KeyspaceInternal
+	}
+}
+
+// KeyspaceStats walks the whole index of the database, reading no values, to summarize the keys it holds.
+// The index is first brought up to date with the last committed transaction
+func (d *db) KeyspaceStats(ctx context.Context, req *KeyspaceStatsRequest) (*KeyspaceStats, error) {
+	if req == nil || req.PrefixLength < 0 || req.Outliers < 0 || req.Outliers > MaxKeyspaceOutliers {
+		return nil, ErrIllegalArguments
+	}
+
+	prefixLen := req.PrefixLength
+	if prefixLen == 0 {
+		prefixLen = DefaultKeyspacePrefixLength
+	}
+
+	outliers := req.Outliers
+	if outliers == 0 {
+		outliers = DefaultKeyspaceOutliers
+	}
+
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	currTxID, _ := d.st.Alh()
+
+	err := d.waitForIndexingUpto(ctx, currTxID)
+	if err != nil {
+		return nil, err
+	}
+
+	snap, err := d.st.SnapshotSince(currTxID)
+	if err != nil {
+		return nil, err
+	}
+	defer snap.Close()
+
+	r, err := snap.NewKeyReader(&store.KeyReaderSpec{Filter: store.IgnoreDeleted})
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	stats := &KeyspaceStats{
+		TxID:       snap.Ts(),
+		KeySizes:   &SizeDistribution{},
+		ValueSizes: &SizeDistribution{},
+	}
+
+	keyspaces := make(map[string]*KeyspaceUsage)
+	prefixes := make(map[string]*KeyspaceUsage)
+	revisions := &revisionsHeap{}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		key, valRef, err := r.Read()
+		if err == store.ErrNoMoreEntries {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		keyLen := uint64(len(key))
+		valLen := uint64(valRef.Len())
+
+		name := keyspaceName(key[0])
+
+		ks, ok := keyspaces[name]
+		if !ok {
+			ks = &KeyspaceUsage{Name: name}
+			keyspaces[name] = ks
+			stats.Keyspaces = append(stats.Keyspaces, ks)
+		}
+		ks.add(keyLen, valLen)
+
+		if name != KeyspaceKV {
+			continue
+		}
+
+		// sizes are the ones of user keys and values, without the prefixes added by the database
+		userKey := key[1:]
+		keyLen--
+		if valLen > 0 {
+			valLen--
+		}
+
+		stats.KeySizes.observe(keyLen)
+		stats.ValueSizes.observe(valLen)
+
+		prefix := userKey
+		if len(prefix) > prefixLen {
+			prefix = prefix[:prefixLen]
+		}
+
+		p, ok := prefixes[string(prefix)]
+		if !ok && len(prefixes) < MaxKeyspacePrefixes {
+			p = &KeyspaceUsage{Prefix: append([]byte{}, prefix...)}
+			prefixes[string(prefix)] = p
+			stats.Prefixes = append(stats.Prefixes, p)
+		} else if !ok {
+			if stats.OtherPrefixes == nil {
+				stats.OtherPrefixes = &KeyspaceUsage{}
+			}
+			p = stats.OtherPrefixes
+		}
+		p.add(keyLen, valLen)
+
+		if revisions.Len() < outliers {
+			heap.Push(revisions, &KeyRevisions{Key: append([]byte{}, userKey...), Revisions: valRef.HC()})
+		} else if (*revisions)[0].Revisions < valRef.HC() {
+			(*revisions)[0] = &KeyRevisions{Key: append([]byte{}, userKey...), Revisions: valRef.HC()}
+			heap.Fix(revisions, 0)
+		}
+	}
+
+	sort.SliceStable(stats.Prefixes, func(i, j int) bool {
+		return stats.Prefixes[i].Keys > stats.Prefixes[j].Keys
+	})
+
+	stats.RevisionOutliers = make([]*KeyRevisions, revisions.Len())
+	for i := len(stats.RevisionOutliers) - 1; i >= 0; i-- {
+		stats.RevisionOutliers[i] = heap.Pop(revisions).(*KeyRevisions)
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,127 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package database
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSizeDistribution(t *testing.T) {
+	d := &SizeDistribution{}
+
+	for _, size := range []uint64{0, 1, 2, 3, 4, 5, 8, 9} {
+		d.observe(size)
+	}
+
+	require.Equal(t, uint64(8), d.Count)
+	require.Equal(t, uint64(0), d.Min)
+	require.Equal(t, uint64(9), d.Max)
+	require.Equal(t, 4.0, d.Mean())
+
+	counts := make(map[uint64]uint64)
+	for _, b := range d.Buckets {
+		counts[b.UpperBound] = b.Count
+	}
+
+	require.Equal(t, map[uint64]uint64{1: 2, 2: 1, 4: 2, 8: 2, 16: 1}, counts)
+}
+
+func TestKeyspaceStats(t *testing.T) {
+	db, closer := makeDb()
+	defer closer()
+
+	for i := 0; i < 10; i++ {
+		_, err := db.Set(&schema.SetRequest{KVs: []*schema.KeyValue{
+			{Key: []byte(fmt.Sprintf("user:%d", i)), Value: []byte("value")},
+		}})
+		require.NoError(t, err)
+	}
+
+	for i := 0; i < 5; i++ {
+		_, err := db.Set(&schema.SetRequest{KVs: []*schema.KeyValue{
+			{Key: []byte("order:1"), Value: []byte(fmt.Sprintf("revision%d", i))},
+		}})
+		require.NoError(t, err)
+	}
+
+	_, err := db.Delete(&schema.DeleteKeysRequest{Keys: [][]byte{[]byte("user:9")}})
+	require.NoError(t, err)
+
+	_, err = db.ZAdd(&schema.ZAddRequest{Set: []byte("set1"), Key: []byte("user:1"), Score: 1})
+	require.NoError(t, err)
+
+	_, _, err = db.SQLExec(&schema.SQLExecRequest{Sql: "CREATE TABLE table1(id INTEGER, PRIMARY KEY id)"}, nil)
+	require.NoError(t, err)
+
+	_, err = db.KeyspaceStats(context.Background(), nil)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = db.KeyspaceStats(context.Background(), &KeyspaceStatsRequest{Outliers: MaxKeyspaceOutliers + 1})
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	stats, err := db.KeyspaceStats(context.Background(), &KeyspaceStatsRequest{PrefixLength: 5, Outliers: 2})
+	require.NoError(t, err)
+	require.Positive(t, stats.TxID)
+
+	keyspaces := make(map[string]*KeyspaceUsage)
+	for _, ks := range stats.Keyspaces {
+		keyspaces[ks.Name] = ks
+	}
+
+	require.Equal(t, uint64(10), keyspaces[KeyspaceKV].Keys)
+	require.Equal(t, uint64(1), keyspaces[KeyspaceSortedSet].Keys)
+	require.Contains(t, keyspaces, KeyspaceSQL)
+
+	require.Len(t, stats.Prefixes, 2)
+	require.Equal(t, []byte("user:"), stats.Prefixes[0].Prefix)
+	require.Equal(t, uint64(9), stats.Prefixes[0].Keys)
+	require.Equal(t, uint64(9*len("user:0")), stats.Prefixes[0].KeyBytes)
+	require.Equal(t, uint64(9*len("value")), stats.Prefixes[0].ValueBytes)
+	require.Equal(t, []byte("order"), stats.Prefixes[1].Prefix)
+	require.Equal(t, uint64(1), stats.Prefixes[1].Keys)
+	require.Nil(t, stats.OtherPrefixes)
+
+	require.Equal(t, uint64(10), stats.KeySizes.Count)
+	require.Equal(t, uint64(len("user:0")), stats.KeySizes.Min)
+	require.Equal(t, uint64(len("order:1")), stats.KeySizes.Max)
+	require.Equal(t, uint64(len("revision4")), stats.ValueSizes.Max)
+
+	require.Len(t, stats.RevisionOutliers, 2)
+	require.Equal(t, []byte("order:1"), stats.RevisionOutliers[0].Key)
+	require.Equal(t, uint64(5), stats.RevisionOutliers[0].Revisions)
+	require.Equal(t, uint64(1), stats.RevisionOutliers[1].Revisions)
+
+	t.Run("whole keys as prefixes", func(t *testing.T) {
+		stats, err := db.KeyspaceStats(context.Background(), &KeyspaceStatsRequest{PrefixLength: 100})
+		require.NoError(t, err)
+		require.Len(t, stats.Prefixes, 10)
+		require.Len(t, stats.RevisionOutliers, DefaultKeyspaceOutliers)
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := db.KeyspaceStats(ctx, &KeyspaceStatsRequest{})
+		require.ErrorIs(t, err, context.Canceled)
+	})
+}
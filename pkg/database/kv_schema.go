@@ -0,0 +1,362 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package database
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+var ErrKVSchemaViolation = errors.New("value does not match the schema registered for the key")
+var ErrKVSchemaNotFound = errors.New("no schema registered for the given prefix")
+
+// EncodeKVSchemaKey builds the key under which the schema of the values written under a key prefix is stored
+// kvSchemaKey = [1+len(prefix)]
+func EncodeKVSchemaKey(prefix []byte) []byte {
+	return WrapWithPrefix(prefix, KVSchemaPrefix)
+}
+
+type kvValueValidator interface {
+	validateValue(value []byte) error
+}
+
+type registeredKVSchema struct {
+	prefix    []byte
+	validator kvValueValidator
+}
+
+// kvSchemaRegistry holds the validators of the schemas registered in a database,
+// the longest registered prefix of a key selects the schema its values are validated against
+type kvSchemaRegistry struct {
+	schemas []*registeredKVSchema
+}
+
+func (r *kvSchemaRegistry) validate(key, value []byte) error {
+	for _, s := range r.schemas {
+		if !bytes.HasPrefix(key, s.prefix) {
+			continue
+		}
+
+		err := s.validator.validateValue(value)
+		if err != nil {
+			return fmt.Errorf("%w: key '%s' (schema of prefix '%s'): %v", ErrKVSchemaViolation, key, s.prefix, err)
+		}
+
+		return nil
+	}
+
+	return nil
+}
+
+func (r *kvSchemaRegistry) validateKVs(kvs []*schema.KeyValue) error {
+	for _, kv := range kvs {
+		if kv.Metadata.GetDeleted() {
+			continue
+		}
+
+		err := r.validate(kv.Key, kv.Value)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func compileKVSchema(s *schema.KVSchema) (kvValueValidator, error) {
+	switch s.Type {
+	case schema.KVSchemaType_JSON_SCHEMA:
+		{
+			js, err := compileJSONSchema(s.Definition)
+			if err != nil {
+				return nil, fmt.Errorf("%w: invalid json schema: %v", ErrIllegalArguments, err)
+			}
+			return js, nil
+		}
+	case schema.KVSchemaType_PROTOBUF:
+		{
+			ps, err := compileProtoSchema(s.Definition, s.MessageType)
+			if err != nil {
+				return nil, fmt.Errorf("%w: invalid protobuf schema: %v", ErrIllegalArguments, err)
+			}
+			return ps, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: unknown schema type %d", ErrIllegalArguments, s.Type)
+}
+
+// protoSchema validates values are serialized messages of a given type, without unknown fields
+type protoSchema struct {
+	md protoreflect.MessageDescriptor
+}
+
+func compileProtoSchema(definition []byte, messageType string) (*protoSchema, error) {
+	var fds descriptorpb.FileDescriptorSet
+
+	err := proto.Unmarshal(definition, &fds)
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := protodesc.NewFiles(&fds)
+	if err != nil {
+		return nil, err
+	}
+
+	d, err := files.FindDescriptorByName(protoreflect.FullName(messageType))
+	if err != nil {
+		return nil, fmt.Errorf("message type '%s': %v", messageType, err)
+	}
+
+	md, ok := d.(protoreflect.MessageDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("'%s' is not a message type", messageType)
+	}
+
+	return &protoSchema{md: md}, nil
+}
+
+func (s *protoSchema) validateValue(value []byte) error {
+	msg := dynamicpb.NewMessage(s.md)
+
+	err := proto.Unmarshal(value, msg)
+	if err != nil {
+		return fmt.Errorf("value is not a valid '%s' message: %v", s.md.FullName(), err)
+	}
+
+	return checkNoUnknownFields(msg)
+}
+
+func checkNoUnknownFields(msg protoreflect.Message) error {
+	if len(msg.GetUnknown()) > 0 {
+		return fmt.Errorf("unknown fields found in '%s' message", msg.Descriptor().FullName())
+	}
+
+	var err error
+
+	msg.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		switch {
+		case fd.IsList() && fd.Message() != nil:
+			l := v.List()
+			for i := 0; i < l.Len() && err == nil; i++ {
+				err = checkNoUnknownFields(l.Get(i).Message())
+			}
+		case fd.IsMap() && fd.MapValue().Message() != nil:
+			v.Map().Range(func(_ protoreflect.MapKey, mv protoreflect.Value) bool {
+				err = checkNoUnknownFields(mv.Message())
+				return err == nil
+			})
+		case !fd.IsList() && !fd.IsMap() && fd.Message() != nil:
+			err = checkNoUnknownFields(v.Message())
+		}
+
+		return err == nil
+	})
+
+	return err
+}
+
+// SetKVSchema registers the schema the values written under a key prefix are validated against.
+// A schema already registered for the same prefix is replaced.
+func (d *db) SetKVSchema(req *schema.KVSchema) (*schema.TxHeader, error) {
+	if req == nil {
+		return nil, ErrIllegalArguments
+	}
+
+	_, err := compileKVSchema(req)
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := proto.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return d.writeKVSchema(req.Prefix, nil, value)
+}
+
+// DeleteKVSchema removes the schema registered for a key prefix, values written under it are not validated anymore
+func (d *db) DeleteKVSchema(req *schema.KVSchemaRequest) (*schema.TxHeader, error) {
+	if req == nil {
+		return nil, ErrIllegalArguments
+	}
+
+	md := store.NewKVMetadata()
+
+	err := md.AsDeleted(true)
+	if err != nil {
+		return nil, err
+	}
+
+	return d.writeKVSchema(req.Prefix, md, nil)
+}
+
+func (d *db) writeKVSchema(prefix []byte, md *store.KVMetadata, value []byte) (*schema.TxHeader, error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if d.isReplica() {
+		return nil, ErrIsReplica
+	}
+
+	if d.isInMaintenance() {
+		return nil, ErrIsInMaintenance
+	}
+
+	if d.isDiskQuotaExceeded() {
+		return nil, ErrDiskQuotaExceeded
+	}
+
+	if md != nil && md.Deleted() {
+		registry, err := d.kvSchemaRegistry()
+		if err != nil {
+			return nil, err
+		}
+
+		found := false
+
+		for _, s := range registry.schemas {
+			if bytes.Equal(s.prefix, prefix) {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return nil, ErrKVSchemaNotFound
+		}
+	}
+
+	tx, err := d.st.NewWriteOnlyTx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Cancel()
+
+	err = tx.Set(EncodeKVSchemaKey(prefix), md, value)
+	if err != nil {
+		return nil, err
+	}
+
+	hdr, err := tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	d.resetKVSchemaRegistry()
+
+	return schema.TxHeaderToProto(hdr), nil
+}
+
+// KVSchemas lists the schemas registered in the database
+func (d *db) KVSchemas() (*schema.KVSchemaList, error) {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	schemas, err := d.readKVSchemas()
+	if err != nil {
+		return nil, err
+	}
+
+	return &schema.KVSchemaList{Schemas: schemas}, nil
+}
+
+func (d *db) readKVSchemas() ([]*schema.KVSchema, error) {
+	values, err := d.readLatestValues([]byte{KVSchemaPrefix})
+	if err != nil {
+		return nil, err
+	}
+
+	schemas := make([]*schema.KVSchema, len(values))
+
+	for i, value := range values {
+		s := &schema.KVSchema{}
+
+		err = proto.Unmarshal(value, s)
+		if err != nil {
+			return nil, err
+		}
+
+		schemas[i] = s
+	}
+
+	return schemas, nil
+}
+
+// kvSchemaRegistry returns the validators of the registered schemas, loading them the first time they're needed
+func (d *db) kvSchemaRegistry() (*kvSchemaRegistry, error) {
+	d.kvSchemasMutex.RLock()
+	registry := d.kvSchemas
+	d.kvSchemasMutex.RUnlock()
+
+	if registry != nil {
+		return registry, nil
+	}
+
+	d.kvSchemasMutex.Lock()
+	defer d.kvSchemasMutex.Unlock()
+
+	if d.kvSchemas != nil {
+		return d.kvSchemas, nil
+	}
+
+	schemas, err := d.readKVSchemas()
+	if err != nil {
+		return nil, err
+	}
+
+	registry = &kvSchemaRegistry{
+		schemas: make([]*registeredKVSchema, len(schemas)),
+	}
+
+	for i, s := range schemas {
+		validator, err := compileKVSchema(s)
+		if err != nil {
+			return nil, err
+		}
+
+		registry.schemas[i] = &registeredKVSchema{prefix: s.Prefix, validator: validator}
+	}
+
+	// the longest prefix is the first one to be matched
+	sort.SliceStable(registry.schemas, func(i, j int) bool {
+		return len(registry.schemas[i].prefix) > len(registry.schemas[j].prefix)
+	})
+
+	d.kvSchemas = registry
+
+	return registry, nil
+}
+
+// resetKVSchemaRegistry makes the registered schemas to be loaded again, e.g. after they were updated by replication
+func (d *db) resetKVSchemaRegistry() {
+	d.kvSchemasMutex.Lock()
+	defer d.kvSchemasMutex.Unlock()
+
+	d.kvSchemas = nil
+}
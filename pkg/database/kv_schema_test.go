@@ -0,0 +1,201 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+func TestKVSchemasJSON(t *testing.T) {
+	db, closer := makeDb()
+	defer closer()
+
+	_, err := db.SetKVSchema(nil)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = db.SetKVSchema(&schema.KVSchema{Prefix: []byte("user:"), Definition: []byte(`{"type": "unknown"}`)})
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = db.DeleteKVSchema(&schema.KVSchemaRequest{Prefix: []byte("user:")})
+	require.ErrorIs(t, err, ErrKVSchemaNotFound)
+
+	_, err = db.SetKVSchema(&schema.KVSchema{
+		Prefix: []byte("user:"),
+		Definition: []byte(`{
+			"type": "object",
+			"required": ["name"],
+			"properties": {
+				"name": {"type": "string", "minLength": 1},
+				"age": {"type": "integer", "minimum": 0},
+				"tags": {"type": "array", "items": {"type": "string"}}
+			},
+			"additionalProperties": false
+		}`),
+	})
+	require.NoError(t, err)
+
+	// the most specific prefix takes precedence
+	_, err = db.SetKVSchema(&schema.KVSchema{
+		Prefix:     []byte("user:admin:"),
+		Definition: []byte(`{"type": "object", "required": ["name", "role"]}`),
+	})
+	require.NoError(t, err)
+
+	for _, c := range []struct {
+		key   string
+		value string
+		valid bool
+	}{
+		{"user:1", `{"name": "alice", "age": 30, "tags": ["a", "b"]}`, true},
+		{"user:2", `{"name": "bob"}`, true},
+		{"user:3", `{"age": 30}`, false},
+		{"user:4", `{"name": ""}`, false},
+		{"user:5", `{"name": "carol", "age": 1.5}`, false},
+		{"user:6", `{"name": "dave", "tags": ["a", 1]}`, false},
+		{"user:7", `{"name": "eve", "email": "eve@example.com"}`, false},
+		{"user:8", `not json`, false},
+		{"user:admin:1", `{"name": "root", "role": "admin", "email": "root@example.com"}`, true},
+		{"user:admin:2", `{"name": "root"}`, false},
+		{"other:1", `not json`, true},
+	} {
+		_, err = db.Set(&schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte(c.key), Value: []byte(c.value)}}})
+		if c.valid {
+			require.NoError(t, err, c.key)
+		} else {
+			require.ErrorIs(t, err, ErrKVSchemaViolation, c.key)
+		}
+	}
+
+	t.Run("values in atomic operations should be validated", func(t *testing.T) {
+		_, err := db.ExecAll(&schema.ExecAllRequest{
+			Operations: []*schema.Op{
+				{Operation: &schema.Op_Kv{Kv: &schema.KeyValue{Key: []byte("other:2"), Value: []byte("any")}}},
+				{Operation: &schema.Op_Kv{Kv: &schema.KeyValue{Key: []byte("user:9"), Value: []byte(`{}`)}}},
+			},
+		})
+		require.ErrorIs(t, err, ErrKVSchemaViolation)
+
+		_, err = db.Get(context.Background(), &schema.KeyRequest{Key: []byte("other:2")})
+		require.ErrorIs(t, err, store.ErrKeyNotFound)
+	})
+
+	t.Run("schemas should be listed", func(t *testing.T) {
+		schemas, err := db.KVSchemas()
+		require.NoError(t, err)
+		require.Len(t, schemas.Schemas, 2)
+		require.Equal(t, []byte("user:"), schemas.Schemas[0].Prefix)
+		require.Equal(t, []byte("user:admin:"), schemas.Schemas[1].Prefix)
+	})
+
+	t.Run("values should not be validated once the schema is deleted", func(t *testing.T) {
+		_, err := db.DeleteKVSchema(&schema.KVSchemaRequest{Prefix: []byte("user:")})
+		require.NoError(t, err)
+
+		_, err = db.Set(&schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte("user:3"), Value: []byte(`{"age": 30}`)}}})
+		require.NoError(t, err)
+
+		_, err = db.Set(&schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte("user:admin:2"), Value: []byte(`{"name": "root"}`)}}})
+		require.ErrorIs(t, err, ErrKVSchemaViolation)
+
+		schemas, err := db.KVSchemas()
+		require.NoError(t, err)
+		require.Len(t, schemas.Schemas, 1)
+	})
+}
+
+func TestKVSchemasProtobuf(t *testing.T) {
+	db, closer := makeDb()
+	defer closer()
+
+	fdp := &descriptorpb.FileDescriptorProto{
+		Name:    proto.String("person.proto"),
+		Package: proto.String("test"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				Name: proto.String("Person"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					{
+						Name:     proto.String("name"),
+						Number:   proto.Int32(1),
+						Type:     descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+						Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+						JsonName: proto.String("name"),
+					},
+					{
+						Name:     proto.String("age"),
+						Number:   proto.Int32(2),
+						Type:     descriptorpb.FieldDescriptorProto_TYPE_INT32.Enum(),
+						Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+						JsonName: proto.String("age"),
+					},
+				},
+			},
+		},
+	}
+
+	definition, err := proto.Marshal(&descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{fdp}})
+	require.NoError(t, err)
+
+	_, err = db.SetKVSchema(&schema.KVSchema{
+		Prefix:      []byte("person:"),
+		Type:        schema.KVSchemaType_PROTOBUF,
+		Definition:  definition,
+		MessageType: "test.Unknown",
+	})
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = db.SetKVSchema(&schema.KVSchema{
+		Prefix:      []byte("person:"),
+		Type:        schema.KVSchemaType_PROTOBUF,
+		Definition:  definition,
+		MessageType: "test.Person",
+	})
+	require.NoError(t, err)
+
+	fd, err := protodesc.NewFile(fdp, nil)
+	require.NoError(t, err)
+
+	person := dynamicpb.NewMessage(fd.Messages().ByName("Person"))
+	person.Set(person.Descriptor().Fields().ByName("name"), protoreflect.ValueOfString("alice"))
+	person.Set(person.Descriptor().Fields().ByName("age"), protoreflect.ValueOfInt32(30))
+
+	value, err := proto.Marshal(person)
+	require.NoError(t, err)
+
+	_, err = db.Set(&schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte("person:1"), Value: value}}})
+	require.NoError(t, err)
+
+	// a message of another type carries fields unknown to the registered one
+	value, err = proto.Marshal(&schema.KeyValue{Key: []byte("key"), Value: []byte("value")})
+	require.NoError(t, err)
+
+	_, err = db.Set(&schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte("person:2"), Value: value}}})
+	require.ErrorIs(t, err, ErrKVSchemaViolation)
+
+	_, err = db.Set(&schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte("person:3"), Value: []byte{0xff}}}})
+	require.ErrorIs(t, err, ErrKVSchemaViolation)
+}
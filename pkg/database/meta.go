@@ -27,6 +27,12 @@ const (
 	SetKeyPrefix byte = iota
 	SortedSetKeyPrefix
 	SQLPrefix
+	ExternalRootPrefix
+	MultiDBTxPrefix
+	KVSchemaPrefix
+	RedactionRulePrefix
+	ReverseReferencePrefix
+	RenameLinkPrefix
 )
 
 const (
@@ -68,6 +74,40 @@ func EncodeReference(key []byte, md *store.KVMetadata, referencedKey []byte, atT
 	}
 }
 
+// EncodeReverseReference returns the entry written along with a reference so it can be found from the key it points to
+func EncodeReverseReference(key []byte, md *store.KVMetadata, referencedKey []byte) *store.EntrySpec {
+	prefix := WrapReverseReferencePrefix(referencedKey)
+
+	rKey := make([]byte, len(prefix)+len(key))
+	copy(rKey, prefix)
+	copy(rKey[len(prefix):], key)
+
+	return &store.EntrySpec{
+		Key:      rKey,
+		Metadata: md,
+		Value:    nil,
+	}
+}
+
+// WrapReverseReferencePrefix returns the prefix shared by the reverse entries of the references to referencedKey
+func WrapReverseReferencePrefix(referencedKey []byte) []byte {
+	prefix := make([]byte, 1+keyLenLen+len(referencedKey))
+
+	prefix[0] = ReverseReferencePrefix
+	binary.BigEndian.PutUint64(prefix[1:], uint64(len(referencedKey)))
+	copy(prefix[1+keyLenLen:], referencedKey)
+
+	return prefix
+}
+
+// EncodeRenameLink returns the entry linking newKey to the entry of oldKey committed at atTx, whose value was moved into newKey
+func EncodeRenameLink(newKey, oldKey []byte, atTx uint64) *store.EntrySpec {
+	return &store.EntrySpec{
+		Key:   WrapWithPrefix(newKey, RenameLinkPrefix),
+		Value: WrapReferenceValueAt(WrapWithPrefix(oldKey, SetKeyPrefix), atTx),
+	}
+}
+
 func WrapReferenceValueAt(key []byte, atTx uint64) []byte {
 	refVal := make([]byte, 1+8+len(key))
 
@@ -0,0 +1,35 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package database
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	metricsSQLStatementDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "immudb_sql_statement_duration_seconds",
+		Help:    "Duration of the execution of SQL statements, by kind: exec or query",
+		Buckets: prometheus.ExponentialBuckets(0.0001, 2, 16),
+	}, []string{"db", "kind"})
+
+	metricsSQLStatementErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "immudb_sql_statement_errors_total",
+		Help: "Number of SQL statements failed, by kind: exec or query",
+	}, []string{"db", "kind"})
+)
@@ -0,0 +1,231 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package database
+
+import (
+	"crypto/sha256"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"google.golang.org/protobuf/proto"
+)
+
+// EncodeMultiDBTxKey builds the key under which a database records its part of a multi-database transaction
+// multiDBTxKey = [1+len(txID)]
+func EncodeMultiDBTxKey(txID string) []byte {
+	return WrapWithPrefix([]byte(txID), MultiDBTxPrefix)
+}
+
+// PreparedTx is the part of a multi-database transaction to be committed into a single database.
+// Once prepared, it's either committed or cancelled.
+type PreparedTx struct {
+	tx         *store.OngoingTx
+	noWait     bool
+	preparedAt uint64
+}
+
+// PreparedAt returns the last transaction committed into the database when the part was prepared
+func (p *PreparedTx) PreparedAt() uint64 {
+	return p.preparedAt
+}
+
+// Commit commits the prepared entries together with the record of the multi-database transaction
+func (p *PreparedTx) Commit() (*schema.TxHeader, error) {
+	var hdr *store.TxHeader
+	var err error
+
+	if p.noWait {
+		hdr, err = p.tx.AsyncCommit()
+	} else {
+		hdr, err = p.tx.Commit()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return schema.TxHeaderToProto(hdr), nil
+}
+
+// Cancel discards the prepared entries
+func (p *PreparedTx) Cancel() error {
+	return p.tx.Cancel()
+}
+
+// PrepareMultiDBTx validates the entries to be written into this database as part of the multi-database
+// transaction txID, in which the given databases take part.
+// Entries are only written once the returned tx is committed, along with an entry recording the transaction.
+func (d *db) PrepareMultiDBTx(txID string, databases []string, req *schema.SetRequest) (*PreparedTx, error) {
+	if len(txID) == 0 || len(databases) == 0 || req == nil {
+		return nil, ErrIllegalArguments
+	}
+
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	preparedAt, _ := d.st.Alh()
+
+	return d.prepareMultiDBTx(txID, databases, req.KVs, req.NoWait, preparedAt, false)
+}
+
+// RecoverMultiDBTx prepares again the part of the multi-database transaction txID which was prepared
+// when preparedAt was the last transaction committed into this database, but could not be committed.
+// Entries of keys written after preparedAt are left out, so the part doesn't overwrite newer values.
+// The remaining entries are only committed if their keys are still not written by then.
+func (d *db) RecoverMultiDBTx(txID string, databases []string, req *schema.SetRequest, preparedAt uint64) (*PreparedTx, error) {
+	if len(txID) == 0 || len(databases) == 0 || req == nil {
+		return nil, ErrIllegalArguments
+	}
+
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	lastTxID, _ := d.st.Alh()
+	if preparedAt > lastTxID {
+		return nil, ErrIllegalArguments
+	}
+
+	err := d.st.WaitForIndexingUpto(lastTxID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	kvs := make([]*schema.KeyValue, 0, len(req.KVs))
+
+	for _, kv := range req.KVs {
+		if len(kv.Key) == 0 {
+			return nil, ErrIllegalArguments
+		}
+
+		// deleted and expired entries are revisions of the key as well
+		txs, err := d.st.History(EncodeKey(kv.Key), 0, true, 1)
+		if err != nil && err != store.ErrKeyNotFound {
+			return nil, err
+		}
+
+		if len(txs) > 0 && txs[0] > preparedAt {
+			continue
+		}
+
+		kvs = append(kvs, kv)
+	}
+
+	return d.prepareMultiDBTx(txID, databases, kvs, req.NoWait, preparedAt, true)
+}
+
+func (d *db) prepareMultiDBTx(txID string, databases []string, kvs []*schema.KeyValue, noWait bool, preparedAt uint64, recovering bool) (*PreparedTx, error) {
+	if d.isReplica() {
+		return nil, ErrIsReplica
+	}
+
+	if d.isInMaintenance() {
+		return nil, ErrIsInMaintenance
+	}
+
+	if d.isDiskQuotaExceeded() {
+		return nil, ErrDiskQuotaExceeded
+	}
+
+	kvSchemas, err := d.kvSchemaRegistry()
+	if err != nil {
+		return nil, err
+	}
+
+	err = kvSchemas.validateKVs(kvs)
+	if err != nil {
+		return nil, err
+	}
+
+	mtx, err := proto.Marshal(&schema.MultiDatabaseTx{TransactionId: txID, Databases: databases})
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := d.st.NewWriteOnlyTx()
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make(map[[sha256.Size]byte]struct{}, len(kvs))
+
+	for _, kv := range kvs {
+		if len(kv.Key) == 0 {
+			tx.Cancel()
+			return nil, ErrIllegalArguments
+		}
+
+		kid := sha256.Sum256(kv.Key)
+		_, ok := keys[kid]
+		if ok {
+			tx.Cancel()
+			return nil, schema.ErrDuplicatedKeysNotSupported
+		}
+		keys[kid] = struct{}{}
+
+		e := EncodeEntrySpec(kv.Key, schema.KVMetadataFromProto(kv.Metadata), kv.Value)
+
+		err = tx.Set(e.Key, e.Metadata, e.Value)
+		if err != nil {
+			tx.Cancel()
+			return nil, err
+		}
+
+		if recovering {
+			err = tx.AddPrecondition(&store.PreconditionKeyNotModifiedAfterTx{Key: e.Key, TxID: preparedAt})
+			if err != nil {
+				tx.Cancel()
+				return nil, err
+			}
+		}
+	}
+
+	err = tx.Set(EncodeMultiDBTxKey(txID), nil, mtx)
+	if err != nil {
+		tx.Cancel()
+		return nil, err
+	}
+
+	// the part must not be rejected once the multi-database transaction is committed
+	err = tx.Validate()
+	if err != nil {
+		tx.Cancel()
+		return nil, err
+	}
+
+	return &PreparedTx{tx: tx, noWait: noWait, preparedAt: preparedAt}, nil
+}
+
+// MultiDBTxCommitted returns whether this database already committed its part of the multi-database transaction txID
+func (d *db) MultiDBTxCommitted(txID string) (bool, error) {
+	if len(txID) == 0 {
+		return false, ErrIllegalArguments
+	}
+
+	lastTxID, _ := d.st.Alh()
+	err := d.st.WaitForIndexingUpto(lastTxID, nil)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = d.st.Get(EncodeMultiDBTxKey(txID))
+	if err == store.ErrKeyNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
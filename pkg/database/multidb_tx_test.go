@@ -0,0 +1,154 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrepareMultiDBTx(t *testing.T) {
+	db, closer := makeDb()
+	defer closer()
+
+	databases := []string{"db1", "db2"}
+
+	_, err := db.PrepareMultiDBTx("", databases, &schema.SetRequest{})
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = db.PrepareMultiDBTx("tx1", nil, &schema.SetRequest{})
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = db.PrepareMultiDBTx("tx1", databases, nil)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = db.PrepareMultiDBTx("tx1", databases, &schema.SetRequest{KVs: []*schema.KeyValue{{Value: []byte("value")}}})
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = db.PrepareMultiDBTx("tx1", databases, &schema.SetRequest{KVs: []*schema.KeyValue{
+		{Key: []byte("key"), Value: []byte("value1")},
+		{Key: []byte("key"), Value: []byte("value2")},
+	}})
+	require.ErrorIs(t, err, schema.ErrDuplicatedKeysNotSupported)
+
+	_, err = db.MultiDBTxCommitted("")
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	t.Run("cancelled txs should not be written", func(t *testing.T) {
+		p, err := db.PrepareMultiDBTx("tx1", databases, &schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte("key"), Value: []byte("value1")}}})
+		require.NoError(t, err)
+
+		err = p.Cancel()
+		require.NoError(t, err)
+
+		_, err = db.Get(context.Background(), &schema.KeyRequest{Key: []byte("key")})
+		require.ErrorIs(t, err, store.ErrKeyNotFound)
+
+		committed, err := db.MultiDBTxCommitted("tx1")
+		require.NoError(t, err)
+		require.False(t, committed)
+	})
+
+	t.Run("committed txs should be recorded", func(t *testing.T) {
+		p, err := db.PrepareMultiDBTx("tx1", databases, &schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte("key"), Value: []byte("value1")}}})
+		require.NoError(t, err)
+
+		hdr, err := p.Commit()
+		require.NoError(t, err)
+		require.Equal(t, int32(2), hdr.Nentries)
+
+		entry, err := db.Get(context.Background(), &schema.KeyRequest{Key: []byte("key")})
+		require.NoError(t, err)
+		require.Equal(t, []byte("value1"), entry.Value)
+		require.Equal(t, hdr.Id, entry.Tx)
+
+		committed, err := db.MultiDBTxCommitted("tx1")
+		require.NoError(t, err)
+		require.True(t, committed)
+
+		tx, err := db.TxByID(context.Background(), &schema.TxRequest{Tx: hdr.Id})
+		require.NoError(t, err)
+		require.Len(t, tx.Entries, 2)
+		require.Equal(t, EncodeMultiDBTxKey("tx1"), tx.Entries[1].Key)
+	})
+
+	t.Run("recovered txs should not overwrite keys written after being prepared", func(t *testing.T) {
+		req := &schema.SetRequest{KVs: []*schema.KeyValue{
+			{Key: []byte("key1"), Value: []byte("value1")},
+			{Key: []byte("key2"), Value: []byte("value1")},
+			{Key: []byte("key3"), Value: []byte("value1")},
+		}}
+
+		p, err := db.PrepareMultiDBTx("tx3", databases, req)
+		require.NoError(t, err)
+
+		err = p.Cancel()
+		require.NoError(t, err)
+
+		_, err = db.RecoverMultiDBTx("tx3", databases, req, p.PreparedAt()+1)
+		require.ErrorIs(t, err, ErrIllegalArguments)
+
+		_, err = db.Set(&schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte("key1"), Value: []byte("value2")}}})
+		require.NoError(t, err)
+
+		_, err = db.Delete(&schema.DeleteKeysRequest{Keys: [][]byte{[]byte("key1")}})
+		require.NoError(t, err)
+
+		r, err := db.RecoverMultiDBTx("tx3", databases, req, p.PreparedAt())
+		require.NoError(t, err)
+
+		// written after the part was recovered
+		_, err = db.Set(&schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte("key2"), Value: []byte("value2")}}})
+		require.NoError(t, err)
+
+		_, err = r.Commit()
+		require.ErrorIs(t, err, store.ErrPreconditionFailed)
+
+		r, err = db.RecoverMultiDBTx("tx3", databases, req, p.PreparedAt())
+		require.NoError(t, err)
+
+		hdr, err := r.Commit()
+		require.NoError(t, err)
+		require.Equal(t, int32(2), hdr.Nentries)
+
+		_, err = db.Get(context.Background(), &schema.KeyRequest{Key: []byte("key1")})
+		require.ErrorIs(t, err, store.ErrKeyNotFound)
+
+		entry, err := db.Get(context.Background(), &schema.KeyRequest{Key: []byte("key2")})
+		require.NoError(t, err)
+		require.Equal(t, []byte("value2"), entry.Value)
+
+		entry, err = db.Get(context.Background(), &schema.KeyRequest{Key: []byte("key3")})
+		require.NoError(t, err)
+		require.Equal(t, []byte("value1"), entry.Value)
+
+		committed, err := db.MultiDBTxCommitted("tx3")
+		require.NoError(t, err)
+		require.True(t, committed)
+	})
+
+	t.Run("replicas should not take part of multi-database txs", func(t *testing.T) {
+		db.AsReplica(true)
+		defer db.AsReplica(false)
+
+		_, err := db.PrepareMultiDBTx("tx2", databases, &schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte("key"), Value: []byte("value2")}}})
+		require.ErrorIs(t, err, ErrIsReplica)
+	})
+}
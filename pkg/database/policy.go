@@ -0,0 +1,190 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package database
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/codenotary/immudb/embedded/sql"
+	"github.com/codenotary/immudb/pkg/api/schema"
+)
+
+var ErrPolicyManagementNotAllowed = errors.New("policies can only be managed by database administrators")
+var ErrPolicyRestrictedRead = errors.New("rows of tables with policies can not be read verifiably")
+
+// WithSessionAttributes returns a view of the database whose SQL queries only read the rows
+// allowed by the policies of the tables, evaluated with the given session attributes.
+// Policies are not enforced when attrs is nil.
+func (d *db) WithSessionAttributes(attrs map[string]interface{}) DB {
+	if attrs == nil {
+		return d
+	}
+
+	return &sessionDB{DB: d, sqlEngine: d.sqlEngine, attrs: attrs}
+}
+
+func (r *redactedDB) WithSessionAttributes(attrs map[string]interface{}) DB {
+	if attrs == nil {
+		return r
+	}
+
+	return &sessionDB{DB: r, sqlEngine: r.sqlEngine, attrs: attrs}
+}
+
+// sessionDB enforces the policies of the tables on the SQL queries made on behalf of a user,
+// who is not allowed to create or drop them
+type sessionDB struct {
+	DB
+	sqlEngine *sql.Engine
+	attrs     map[string]interface{}
+}
+
+func (s *sessionDB) Redacted() DB {
+	return &sessionDB{DB: s.DB.Redacted(), sqlEngine: s.sqlEngine, attrs: s.attrs}
+}
+
+func (s *sessionDB) WithSessionAttributes(attrs map[string]interface{}) DB {
+	return s.DB.WithSessionAttributes(attrs)
+}
+
+func (s *sessionDB) SQLExec(req *schema.SQLExecRequest, tx *sql.SQLTx) (ntx *sql.SQLTx, ctxs []*sql.SQLTx, err error) {
+	if req == nil {
+		return nil, nil, ErrIllegalArguments
+	}
+
+	stmts, err := sql.Parse(strings.NewReader(req.Sql))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, stmt := range stmts {
+		switch stmt.(type) {
+		case *sql.UseDatabaseStmt:
+			{
+				return nil, nil, errors.New("SQL statement not supported. Please use `UseDatabase` operation instead")
+			}
+		case *sql.CreateDatabaseStmt:
+			{
+				return nil, nil, errors.New("SQL statement not supported. Please use `CreateDatabase` operation instead")
+			}
+		}
+	}
+
+	return s.SQLExecPrepared(stmts, req.Params, tx)
+}
+
+func (s *sessionDB) SQLExecPrepared(stmts []sql.SQLStmt, namedParams []*schema.NamedParam, tx *sql.SQLTx) (ntx *sql.SQLTx, ctxs []*sql.SQLTx, err error) {
+	for _, stmt := range stmts {
+		switch stmt.(type) {
+		case *sql.CreatePolicyStmt, *sql.DropPolicyStmt:
+			return nil, nil, ErrPolicyManagementNotAllowed
+		}
+	}
+
+	return s.DB.SQLExecPrepared(stmts, namedParams, tx)
+}
+
+func (s *sessionDB) SQLQuery(ctx context.Context, req *schema.SQLQueryRequest, tx *sql.SQLTx) (*schema.SQLQueryResult, error) {
+	if req == nil {
+		return nil, ErrIllegalArguments
+	}
+
+	stmts, err := sql.Parse(strings.NewReader(req.Sql))
+	if err != nil {
+		return nil, err
+	}
+
+	stmt, ok := stmts[0].(*sql.SelectStmt)
+	if !ok {
+		return nil, sql.ErrExpectingDQLStmt
+	}
+
+	return s.SQLQueryPrepared(ctx, stmt, req.Params, tx)
+}
+
+func (s *sessionDB) SQLQueryPrepared(ctx context.Context, stmt *sql.SelectStmt, namedParams []*schema.NamedParam, tx *sql.SQLTx) (*schema.SQLQueryResult, error) {
+	if tx == nil {
+		qtx, err := s.sqlEngine.NewTx(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		defer qtx.Cancel()
+
+		tx = qtx
+	}
+
+	err := tx.SetSessionAttributes(s.attrs)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.DB.SQLQueryPrepared(ctx, stmt, namedParams, tx)
+}
+
+func (s *sessionDB) SQLQueryRowReader(ctx context.Context, stmt *sql.SelectStmt, tx *sql.SQLTx) (sql.RowReader, error) {
+	if tx != nil {
+		err := tx.SetSessionAttributes(s.attrs)
+		if err != nil {
+			return nil, err
+		}
+
+		return s.DB.SQLQueryRowReader(ctx, stmt, tx)
+	}
+
+	qtx, err := s.sqlEngine.NewTx(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	err = qtx.SetSessionAttributes(s.attrs)
+	if err != nil {
+		qtx.Cancel()
+		return nil, err
+	}
+
+	rr, err := s.DB.SQLQueryRowReader(ctx, stmt, qtx)
+	if err != nil {
+		qtx.Cancel()
+		return nil, err
+	}
+
+	return &txRowReader{RowReader: rr, tx: qtx}, nil
+}
+
+// VerifiableSQLGet reads rows by primary key, bypassing the policies of their tables
+func (s *sessionDB) VerifiableSQLGet(req *schema.VerifiableSQLGetRequest) (*schema.VerifiableSQLEntry, error) {
+	if req == nil || req.SqlGetRequest == nil {
+		return nil, ErrIllegalArguments
+	}
+
+	catalog, err := s.sqlEngine.Catalog(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	table, err := catalog.GetTableByName(dbInstanceName, req.SqlGetRequest.Table)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(table.Policies()) > 0 {
+		return nil, ErrPolicyRestrictedRead
+	}
+
+	return s.DB.VerifiableSQLGet(req)
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package database
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/codenotary/immudb/embedded/sql"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionAttributes(t *testing.T) {
+	db, closer := makeDb()
+	defer closer()
+
+	_, _, err := db.SQLExec(&schema.SQLExecRequest{Sql: `
+		CREATE TABLE orders (id INTEGER, tenant VARCHAR, card VARCHAR, PRIMARY KEY id);
+		INSERT INTO orders(id, tenant, card) VALUES (1, 't1', '4111111111111111'), (2, 't2', '5555555555554444');
+		CREATE POLICY tenancy ON orders USING (tenant = @tenant);
+	`}, nil)
+	require.NoError(t, err)
+
+	require.Equal(t, db, db.WithSessionAttributes(nil))
+
+	sdb := db.WithSessionAttributes(map[string]interface{}{"user": "alice", "tenant": "t1"})
+
+	res, err := sdb.SQLQuery(context.Background(), &schema.SQLQueryRequest{Sql: "SELECT id FROM orders"}, nil)
+	require.NoError(t, err)
+	require.Len(t, res.Rows, 1)
+	require.Equal(t, int64(1), res.Rows[0].Values[0].GetN())
+
+	res, err = db.SQLQuery(context.Background(), &schema.SQLQueryRequest{Sql: "SELECT id FROM orders"}, nil)
+	require.NoError(t, err)
+	require.Len(t, res.Rows, 2)
+
+	t.Run("row readers should be filtered by policies", func(t *testing.T) {
+		stmts, err := sql.Parse(strings.NewReader("SELECT id FROM orders"))
+		require.NoError(t, err)
+
+		r, err := sdb.SQLQueryRowReader(context.Background(), stmts[0].(*sql.SelectStmt), nil)
+		require.NoError(t, err)
+
+		row, err := r.Read()
+		require.NoError(t, err)
+		require.Len(t, row.Values, 1)
+
+		for _, v := range row.Values {
+			require.Equal(t, int64(1), v.Value())
+		}
+
+		_, err = r.Read()
+		require.ErrorIs(t, err, sql.ErrNoMoreRows)
+
+		err = r.Close()
+		require.NoError(t, err)
+	})
+
+	t.Run("policies should be enforced on top of redaction", func(t *testing.T) {
+		_, err = db.SetRedactionRule(&schema.RedactionRule{Table: "orders", Column: "card", Mode: schema.RedactionMode_REDACT_PARTIAL, VisibleChars: 4})
+		require.NoError(t, err)
+
+		for _, rdb := range []DB{sdb.Redacted(), db.Redacted().WithSessionAttributes(map[string]interface{}{"tenant": "t2"})} {
+			res, err := rdb.SQLQuery(context.Background(), &schema.SQLQueryRequest{Sql: "SELECT card FROM orders"}, nil)
+			require.NoError(t, err)
+			require.Len(t, res.Rows, 1)
+			require.True(t, strings.HasPrefix(res.Rows[0].Values[0].GetS(), "************"))
+		}
+	})
+
+	t.Run("policies should not be managed through session views", func(t *testing.T) {
+		_, _, err := sdb.SQLExec(&schema.SQLExecRequest{Sql: "DROP POLICY tenancy ON orders"}, nil)
+		require.ErrorIs(t, err, ErrPolicyManagementNotAllowed)
+
+		_, _, err = sdb.SQLExec(&schema.SQLExecRequest{Sql: "CREATE POLICY everything ON orders USING (true)"}, nil)
+		require.ErrorIs(t, err, ErrPolicyManagementNotAllowed)
+
+		_, _, err = sdb.SQLExec(&schema.SQLExecRequest{Sql: "INSERT INTO orders(id, tenant) VALUES (3, 't1')"}, nil)
+		require.NoError(t, err)
+	})
+
+	t.Run("rows of tables with policies should not be read verifiably", func(t *testing.T) {
+		_, err := sdb.VerifiableSQLGet(&schema.VerifiableSQLGetRequest{
+			SqlGetRequest: &schema.SQLGetRequest{
+				Table:    "orders",
+				PkValues: []*schema.SQLValue{{Value: &schema.SQLValue_N{N: 2}}},
+			},
+		})
+		require.ErrorIs(t, err, ErrPolicyRestrictedRead)
+	})
+}
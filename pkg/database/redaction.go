@@ -0,0 +1,602 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package database
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/codenotary/immudb/embedded/sql"
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"google.golang.org/protobuf/proto"
+)
+
+var ErrRedactionRuleNotFound = errors.New("no redaction rule registered for the given prefix or column")
+var ErrRestrictedRead = errors.New("operation not allowed with restricted read permission")
+
+const (
+	kvRedactionRule byte = iota
+	sqlRedactionRule
+)
+
+// redactionMask is the character the redacted part of the values is replaced by
+const redactionMask = '*'
+
+// EncodeRedactionRuleKey builds the key under which a redaction rule is stored
+// kv rule key = [1+1+len(prefix)]
+// sql rule key = [1+1+len(table)+1+len(column)]
+func EncodeRedactionRuleKey(prefix []byte, table, column string) []byte {
+	if table == "" {
+		return WrapWithPrefix(WrapWithPrefix(prefix, kvRedactionRule), RedactionRulePrefix)
+	}
+
+	var b bytes.Buffer
+
+	b.WriteByte(sqlRedactionRule)
+	b.WriteString(table)
+	b.WriteByte(0)
+	b.WriteString(column)
+
+	return WrapWithPrefix(b.Bytes(), RedactionRulePrefix)
+}
+
+func sqlRedactionRuleID(table, column string) string {
+	return table + "." + column
+}
+
+// redactionRuleSet holds the redaction rules registered in a database,
+// the longest registered prefix of a key selects the rule its values are redacted with
+type redactionRuleSet struct {
+	kvRules  []*schema.RedactionRule
+	sqlRules map[string]*schema.RedactionRule
+}
+
+func (rs *redactionRuleSet) redactEntry(e *schema.Entry) {
+	if e == nil {
+		return
+	}
+
+	for _, r := range rs.kvRules {
+		if bytes.HasPrefix(e.Key, r.Prefix) {
+			e.Value = redactBytes(r, e.Value)
+			return
+		}
+	}
+}
+
+func (rs *redactionRuleSet) redactEntries(entries []*schema.Entry) {
+	for _, e := range entries {
+		rs.redactEntry(e)
+	}
+}
+
+// maskColumn is the sql.ColumnMask applying the rules registered for SQL columns,
+// values of columns other than VARCHAR and BLOB ones are omitted whatever the mode
+func (rs *redactionRuleSet) maskColumn(table *sql.Table, col *sql.Column, value interface{}) (interface{}, error) {
+	r, ok := rs.sqlRules[sqlRedactionRuleID(table.Name(), col.Name())]
+	if !ok || value == nil {
+		return value, nil
+	}
+
+	switch v := value.(type) {
+	case string:
+		return redactString(r, v), nil
+	case []byte:
+		return redactBytes(r, v), nil
+	}
+
+	return nil, nil
+}
+
+func redactBytes(r *schema.RedactionRule, value []byte) []byte {
+	switch r.Mode {
+	case schema.RedactionMode_REDACT_HASH:
+		{
+			h := sha256.Sum256(value)
+			return h[:]
+		}
+	case schema.RedactionMode_REDACT_PARTIAL:
+		{
+			visible := len(value)
+			if int(r.VisibleChars) < visible {
+				visible = int(r.VisibleChars)
+			}
+
+			masked := bytes.Repeat([]byte{redactionMask}, len(value)-visible)
+			return append(masked, value[len(value)-visible:]...)
+		}
+	}
+
+	return nil
+}
+
+func redactString(r *schema.RedactionRule, value string) interface{} {
+	switch r.Mode {
+	case schema.RedactionMode_REDACT_HASH:
+		{
+			h := sha256.Sum256([]byte(value))
+			return hex.EncodeToString(h[:])
+		}
+	case schema.RedactionMode_REDACT_PARTIAL:
+		{
+			runes := []rune(value)
+
+			visible := len(runes)
+			if int(r.VisibleChars) < visible {
+				visible = int(r.VisibleChars)
+			}
+
+			return strings.Repeat(string(redactionMask), len(runes)-visible) + string(runes[len(runes)-visible:])
+		}
+	}
+
+	return nil
+}
+
+func normalizeRedactionRule(prefix []byte, table, column string) (string, string, error) {
+	if table == "" && column == "" {
+		return "", "", nil
+	}
+
+	if len(prefix) > 0 || table == "" || column == "" {
+		return "", "", fmt.Errorf("%w: either a key prefix or both table and column must be specified", ErrIllegalArguments)
+	}
+
+	// sql identifiers are case insensitive
+	return strings.ToLower(table), strings.ToLower(column), nil
+}
+
+// SetRedactionRule registers how the values under a key prefix or of a SQL column are redacted
+// when read by users with restricted read permission. A rule already registered for them is replaced.
+func (d *db) SetRedactionRule(req *schema.RedactionRule) (*schema.TxHeader, error) {
+	if req == nil {
+		return nil, ErrIllegalArguments
+	}
+
+	_, validMode := schema.RedactionMode_name[int32(req.Mode)]
+	if !validMode {
+		return nil, fmt.Errorf("%w: unknown redaction mode %d", ErrIllegalArguments, req.Mode)
+	}
+
+	table, column, err := normalizeRedactionRule(req.Prefix, req.Table, req.Column)
+	if err != nil {
+		return nil, err
+	}
+
+	rule := &schema.RedactionRule{
+		Prefix:       req.Prefix,
+		Table:        table,
+		Column:       column,
+		Mode:         req.Mode,
+		VisibleChars: req.VisibleChars,
+	}
+
+	value, err := proto.Marshal(rule)
+	if err != nil {
+		return nil, err
+	}
+
+	return d.writeRedactionRule(EncodeRedactionRuleKey(req.Prefix, table, column), nil, value)
+}
+
+// DeleteRedactionRule removes the redaction rule registered for a key prefix or a SQL column
+func (d *db) DeleteRedactionRule(req *schema.RedactionRuleRequest) (*schema.TxHeader, error) {
+	if req == nil {
+		return nil, ErrIllegalArguments
+	}
+
+	table, column, err := normalizeRedactionRule(req.Prefix, req.Table, req.Column)
+	if err != nil {
+		return nil, err
+	}
+
+	md := store.NewKVMetadata()
+
+	err = md.AsDeleted(true)
+	if err != nil {
+		return nil, err
+	}
+
+	return d.writeRedactionRule(EncodeRedactionRuleKey(req.Prefix, table, column), md, nil)
+}
+
+func (d *db) writeRedactionRule(key []byte, md *store.KVMetadata, value []byte) (*schema.TxHeader, error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if d.isReplica() {
+		return nil, ErrIsReplica
+	}
+
+	if d.isInMaintenance() {
+		return nil, ErrIsInMaintenance
+	}
+
+	if d.isDiskQuotaExceeded() {
+		return nil, ErrDiskQuotaExceeded
+	}
+
+	if md != nil && md.Deleted() {
+		_, err := d.st.Get(key)
+		if err == store.ErrKeyNotFound {
+			return nil, ErrRedactionRuleNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	tx, err := d.st.NewWriteOnlyTx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Cancel()
+
+	err = tx.Set(key, md, value)
+	if err != nil {
+		return nil, err
+	}
+
+	hdr, err := tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	d.resetRedactionRules()
+
+	return schema.TxHeaderToProto(hdr), nil
+}
+
+// RedactionRules lists the redaction rules registered in the database
+func (d *db) RedactionRules() (*schema.RedactionRuleList, error) {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	rules, err := d.readRedactionRules()
+	if err != nil {
+		return nil, err
+	}
+
+	return &schema.RedactionRuleList{Rules: rules}, nil
+}
+
+// HasRedactionRules checks if any redaction rule is registered in the database
+func (d *db) HasRedactionRules() (bool, error) {
+	rs, err := d.redactionRuleSet()
+	if err != nil {
+		return false, err
+	}
+
+	return len(rs.kvRules) > 0 || len(rs.sqlRules) > 0, nil
+}
+
+func (d *db) readRedactionRules() ([]*schema.RedactionRule, error) {
+	values, err := d.readLatestValues([]byte{RedactionRulePrefix})
+	if err != nil {
+		return nil, err
+	}
+
+	rules := make([]*schema.RedactionRule, len(values))
+
+	for i, value := range values {
+		r := &schema.RedactionRule{}
+
+		err = proto.Unmarshal(value, r)
+		if err != nil {
+			return nil, err
+		}
+
+		rules[i] = r
+	}
+
+	return rules, nil
+}
+
+// redactionRuleSet returns the registered redaction rules, loading them the first time they're needed
+func (d *db) redactionRuleSet() (*redactionRuleSet, error) {
+	d.redactionRulesMutex.RLock()
+	rs := d.redactionRules
+	d.redactionRulesMutex.RUnlock()
+
+	if rs != nil {
+		return rs, nil
+	}
+
+	d.redactionRulesMutex.Lock()
+	defer d.redactionRulesMutex.Unlock()
+
+	if d.redactionRules != nil {
+		return d.redactionRules, nil
+	}
+
+	rules, err := d.readRedactionRules()
+	if err != nil {
+		return nil, err
+	}
+
+	rs = &redactionRuleSet{
+		sqlRules: make(map[string]*schema.RedactionRule),
+	}
+
+	for _, r := range rules {
+		if r.Table != "" {
+			rs.sqlRules[sqlRedactionRuleID(r.Table, r.Column)] = r
+			continue
+		}
+
+		rs.kvRules = append(rs.kvRules, r)
+	}
+
+	// the longest prefix is the first one to be matched
+	sort.SliceStable(rs.kvRules, func(i, j int) bool {
+		return len(rs.kvRules[i].Prefix) > len(rs.kvRules[j].Prefix)
+	})
+
+	d.redactionRules = rs
+
+	return rs, nil
+}
+
+// resetRedactionRules makes the registered redaction rules to be loaded again, e.g. after they were updated by replication
+func (d *db) resetRedactionRules() {
+	d.redactionRulesMutex.Lock()
+	defer d.redactionRulesMutex.Unlock()
+
+	d.redactionRules = nil
+}
+
+// Redacted returns a view of the database for users with restricted read permission,
+// the values it reads are redacted according to the registered rules
+func (d *db) Redacted() DB {
+	return &redactedDB{db: d}
+}
+
+// redactedDB redacts the values read from the database, reads which would disclose
+// the values as they're stored, e.g. the verifiable ones, are rejected
+type redactedDB struct {
+	*db
+}
+
+func (r *redactedDB) Redacted() DB {
+	return r
+}
+
+func (r *redactedDB) Get(ctx context.Context, req *schema.KeyRequest) (*schema.Entry, error) {
+	entry, err := r.db.Get(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	rs, err := r.redactionRuleSet()
+	if err != nil {
+		return nil, err
+	}
+
+	rs.redactEntry(entry)
+
+	return entry, nil
+}
+
+func (r *redactedDB) GetAll(ctx context.Context, req *schema.KeyListRequest) (*schema.Entries, error) {
+	entries, err := r.db.GetAll(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	rs, err := r.redactionRuleSet()
+	if err != nil {
+		return nil, err
+	}
+
+	rs.redactEntries(entries.Entries)
+
+	for _, res := range entries.Results {
+		rs.redactEntry(res.Entry)
+	}
+
+	return entries, nil
+}
+
+func (r *redactedDB) Scan(ctx context.Context, req *schema.ScanRequest) (*schema.Entries, error) {
+	entries, err := r.db.Scan(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	rs, err := r.redactionRuleSet()
+	if err != nil {
+		return nil, err
+	}
+
+	rs.redactEntries(entries.Entries)
+
+	return entries, nil
+}
+
+func (r *redactedDB) History(ctx context.Context, req *schema.HistoryRequest) (*schema.Entries, error) {
+	entries, err := r.db.History(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	rs, err := r.redactionRuleSet()
+	if err != nil {
+		return nil, err
+	}
+
+	rs.redactEntries(entries.Entries)
+
+	return entries, nil
+}
+
+func (r *redactedDB) ResolveAll(req *schema.ResolveAllRequest) (*schema.Entries, error) {
+	entries, err := r.db.ResolveAll(req)
+	if err != nil {
+		return nil, err
+	}
+
+	rs, err := r.redactionRuleSet()
+	if err != nil {
+		return nil, err
+	}
+
+	rs.redactEntries(entries.Entries)
+
+	return entries, nil
+}
+
+func (r *redactedDB) ZScan(ctx context.Context, req *schema.ZScanRequest) (*schema.ZEntries, error) {
+	entries, err := r.db.ZScan(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	rs, err := r.redactionRuleSet()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, e := range entries.Entries {
+		rs.redactEntry(e.Entry)
+	}
+
+	return entries, nil
+}
+
+func (r *redactedDB) SQLQuery(ctx context.Context, req *schema.SQLQueryRequest, tx *sql.SQLTx) (*schema.SQLQueryResult, error) {
+	if req == nil {
+		return nil, ErrIllegalArguments
+	}
+
+	stmts, err := sql.Parse(strings.NewReader(req.Sql))
+	if err != nil {
+		return nil, err
+	}
+
+	stmt, ok := stmts[0].(*sql.SelectStmt)
+	if !ok {
+		return nil, sql.ErrExpectingDQLStmt
+	}
+
+	return r.SQLQueryPrepared(ctx, stmt, req.Params, tx)
+}
+
+func (r *redactedDB) SQLQueryPrepared(ctx context.Context, stmt *sql.SelectStmt, namedParams []*schema.NamedParam, tx *sql.SQLTx) (*schema.SQLQueryResult, error) {
+	if tx == nil {
+		qtx, err := r.sqlEngine.NewTx(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		defer qtx.Cancel()
+
+		tx = qtx
+	}
+
+	err := r.maskSQLTx(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.db.SQLQueryPrepared(ctx, stmt, namedParams, tx)
+}
+
+func (r *redactedDB) SQLQueryRowReader(ctx context.Context, stmt *sql.SelectStmt, tx *sql.SQLTx) (sql.RowReader, error) {
+	if tx != nil {
+		err := r.maskSQLTx(tx)
+		if err != nil {
+			return nil, err
+		}
+
+		return r.db.SQLQueryRowReader(ctx, stmt, tx)
+	}
+
+	qtx, err := r.sqlEngine.NewTx(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.maskSQLTx(qtx)
+	if err != nil {
+		qtx.Cancel()
+		return nil, err
+	}
+
+	rr, err := r.db.SQLQueryRowReader(ctx, stmt, qtx)
+	if err != nil {
+		qtx.Cancel()
+		return nil, err
+	}
+
+	return &txRowReader{RowReader: rr, tx: qtx}, nil
+}
+
+func (r *redactedDB) maskSQLTx(tx *sql.SQLTx) error {
+	rs, err := r.redactionRuleSet()
+	if err != nil {
+		return err
+	}
+
+	tx.SetColumnMask(rs.maskColumn)
+
+	return nil
+}
+
+func (r *redactedDB) VerifiableGet(ctx context.Context, req *schema.VerifiableGetRequest) (*schema.VerifiableEntry, error) {
+	return nil, ErrRestrictedRead
+}
+
+func (r *redactedDB) GetInclusionProof(ctx context.Context, key []byte, atTx uint64) (*EntryInclusionProof, error) {
+	return nil, ErrRestrictedRead
+}
+
+func (r *redactedDB) VerifiableSQLGet(req *schema.VerifiableSQLGetRequest) (*schema.VerifiableSQLEntry, error) {
+	return nil, ErrRestrictedRead
+}
+
+func (r *redactedDB) TxByID(ctx context.Context, req *schema.TxRequest) (*schema.Tx, error) {
+	return nil, ErrRestrictedRead
+}
+
+func (r *redactedDB) ExportTxByID(req *schema.ExportTxRequest) ([]byte, error) {
+	return nil, ErrRestrictedRead
+}
+
+func (r *redactedDB) VerifiableTxByID(ctx context.Context, req *schema.VerifiableTxRequest) (*schema.VerifiableTx, error) {
+	return nil, ErrRestrictedRead
+}
+
+func (r *redactedDB) TxScan(ctx context.Context, req *schema.TxScanRequest) (*schema.TxList, error) {
+	return nil, ErrRestrictedRead
+}
+
+// txRowReader cancels the tx the rows are read within once the reader is closed
+type txRowReader struct {
+	sql.RowReader
+	tx *sql.SQLTx
+}
+
+func (r *txRowReader) Close() error {
+	defer r.tx.Cancel()
+
+	return r.RowReader.Close()
+}
@@ -0,0 +1,248 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package database
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/codenotary/immudb/embedded/sql"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedactionRules(t *testing.T) {
+	db, closer := makeDb()
+	defer closer()
+
+	_, err := db.SetRedactionRule(nil)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = db.SetRedactionRule(&schema.RedactionRule{Prefix: []byte("user:"), Mode: 10})
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = db.SetRedactionRule(&schema.RedactionRule{Prefix: []byte("user:"), Table: "people", Column: "ssn"})
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = db.SetRedactionRule(&schema.RedactionRule{Table: "people"})
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = db.DeleteRedactionRule(nil)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = db.DeleteRedactionRule(&schema.RedactionRuleRequest{Prefix: []byte("user:")})
+	require.ErrorIs(t, err, ErrRedactionRuleNotFound)
+
+	_, err = db.SetRedactionRule(&schema.RedactionRule{Prefix: []byte("user:"), Mode: schema.RedactionMode_REDACT_HASH})
+	require.NoError(t, err)
+
+	// the most specific prefix takes precedence
+	_, err = db.SetRedactionRule(&schema.RedactionRule{Prefix: []byte("user:card:"), Mode: schema.RedactionMode_REDACT_PARTIAL, VisibleChars: 4})
+	require.NoError(t, err)
+
+	_, err = db.SetRedactionRule(&schema.RedactionRule{Table: "People", Column: "SSN", Mode: schema.RedactionMode_REDACT_FULL})
+	require.NoError(t, err)
+
+	rules, err := db.RedactionRules()
+	require.NoError(t, err)
+	require.Len(t, rules.Rules, 3)
+
+	_, err = db.DeleteRedactionRule(&schema.RedactionRuleRequest{Table: "people", Column: "ssn"})
+	require.NoError(t, err)
+
+	_, err = db.DeleteRedactionRule(&schema.RedactionRuleRequest{Table: "people", Column: "ssn"})
+	require.ErrorIs(t, err, ErrRedactionRuleNotFound)
+
+	rules, err = db.RedactionRules()
+	require.NoError(t, err)
+	require.Len(t, rules.Rules, 2)
+}
+
+func TestRedactedKVReads(t *testing.T) {
+	db, closer := makeDb()
+	defer closer()
+
+	_, err := db.SetRedactionRule(&schema.RedactionRule{Prefix: []byte("user:"), Mode: schema.RedactionMode_REDACT_HASH})
+	require.NoError(t, err)
+
+	_, err = db.SetRedactionRule(&schema.RedactionRule{Prefix: []byte("user:card:"), Mode: schema.RedactionMode_REDACT_PARTIAL, VisibleChars: 4})
+	require.NoError(t, err)
+
+	_, err = db.SetRedactionRule(&schema.RedactionRule{Prefix: []byte("secret:"), Mode: schema.RedactionMode_REDACT_FULL})
+	require.NoError(t, err)
+
+	_, err = db.Set(&schema.SetRequest{KVs: []*schema.KeyValue{
+		{Key: []byte("user:1"), Value: []byte("alice")},
+		{Key: []byte("user:card:1"), Value: []byte("4111111111111111")},
+		{Key: []byte("secret:1"), Value: []byte("s3cr3t")},
+		{Key: []byte("public:1"), Value: []byte("hello")},
+	}})
+	require.NoError(t, err)
+
+	hdr, err := db.ZAdd(&schema.ZAddRequest{Set: []byte("users"), Score: 1, Key: []byte("user:1")})
+	require.NoError(t, err)
+
+	rdb := db.Redacted()
+	require.Equal(t, rdb, rdb.Redacted())
+
+	aliceHash := sha256.Sum256([]byte("alice"))
+
+	expected := map[string][]byte{
+		"user:1":      aliceHash[:],
+		"user:card:1": []byte("************1111"),
+		"secret:1":    nil,
+		"public:1":    []byte("hello"),
+	}
+
+	for key, value := range expected {
+		entry, err := rdb.Get(context.Background(), &schema.KeyRequest{Key: []byte(key), SinceTx: hdr.Id})
+		require.NoError(t, err)
+		require.Equal(t, value, entry.Value)
+
+		// values read with the database itself are not redacted
+		entry, err = db.Get(context.Background(), &schema.KeyRequest{Key: []byte(key), SinceTx: hdr.Id})
+		require.NoError(t, err)
+		require.Equal(t, key == "public:1", bytes.Equal(value, entry.Value))
+	}
+
+	entries, err := rdb.Scan(context.Background(), &schema.ScanRequest{Prefix: []byte("user:"), SinceTx: hdr.Id})
+	require.NoError(t, err)
+	require.Len(t, entries.Entries, 2)
+	require.Equal(t, aliceHash[:], entries.Entries[0].Value)
+	require.Equal(t, []byte("************1111"), entries.Entries[1].Value)
+
+	entries, err = rdb.History(context.Background(), &schema.HistoryRequest{Key: []byte("secret:1"), SinceTx: hdr.Id})
+	require.NoError(t, err)
+	require.Len(t, entries.Entries, 1)
+	require.Nil(t, entries.Entries[0].Value)
+
+	entries, err = rdb.GetAll(context.Background(), &schema.KeyListRequest{Keys: [][]byte{[]byte("user:1"), []byte("public:1")}, SinceTx: hdr.Id})
+	require.NoError(t, err)
+	require.Equal(t, aliceHash[:], entries.Entries[0].Value)
+	require.Equal(t, []byte("hello"), entries.Entries[1].Value)
+
+	entries, err = rdb.GetAll(context.Background(), &schema.KeyListRequest{Keys: [][]byte{[]byte("user:card:1")}, SinceTx: hdr.Id, WithStatuses: true})
+	require.NoError(t, err)
+	require.Equal(t, []byte("************1111"), entries.Results[0].Entry.Value)
+
+	zentries, err := rdb.ZScan(context.Background(), &schema.ZScanRequest{Set: []byte("users"), SinceTx: hdr.Id})
+	require.NoError(t, err)
+	require.Len(t, zentries.Entries, 1)
+	require.Equal(t, aliceHash[:], zentries.Entries[0].Entry.Value)
+
+	t.Run("reads disclosing the stored values should be rejected", func(t *testing.T) {
+		_, err := rdb.VerifiableGet(context.Background(), &schema.VerifiableGetRequest{KeyRequest: &schema.KeyRequest{Key: []byte("user:1")}})
+		require.ErrorIs(t, err, ErrRestrictedRead)
+
+		_, err = rdb.GetInclusionProof(context.Background(), []byte("user:1"), 0)
+		require.ErrorIs(t, err, ErrRestrictedRead)
+
+		_, err = rdb.TxByID(context.Background(), &schema.TxRequest{Tx: hdr.Id})
+		require.ErrorIs(t, err, ErrRestrictedRead)
+
+		_, err = rdb.VerifiableTxByID(context.Background(), &schema.VerifiableTxRequest{Tx: hdr.Id})
+		require.ErrorIs(t, err, ErrRestrictedRead)
+
+		_, err = rdb.TxScan(context.Background(), &schema.TxScanRequest{InitialTx: 1})
+		require.ErrorIs(t, err, ErrRestrictedRead)
+
+		_, err = rdb.ExportTxByID(&schema.ExportTxRequest{Tx: hdr.Id})
+		require.ErrorIs(t, err, ErrRestrictedRead)
+
+		_, err = rdb.VerifiableSQLGet(&schema.VerifiableSQLGetRequest{})
+		require.ErrorIs(t, err, ErrRestrictedRead)
+	})
+
+	t.Run("rules should be applied as soon as they're updated", func(t *testing.T) {
+		_, err := db.DeleteRedactionRule(&schema.RedactionRuleRequest{Prefix: []byte("secret:")})
+		require.NoError(t, err)
+
+		entry, err := rdb.Get(context.Background(), &schema.KeyRequest{Key: []byte("secret:1")})
+		require.NoError(t, err)
+		require.Equal(t, []byte("s3cr3t"), entry.Value)
+	})
+}
+
+func TestRedactedSQLReads(t *testing.T) {
+	db, closer := makeDb()
+	defer closer()
+
+	_, _, err := db.SQLExec(&schema.SQLExecRequest{Sql: `
+		CREATE TABLE people (id INTEGER, name VARCHAR, ssn VARCHAR, card VARCHAR, photo BLOB, age INTEGER, PRIMARY KEY id);
+		INSERT INTO people(id, name, ssn, card, photo, age) VALUES (1, 'alice', '123-45-6789', '4111111111111111', x'0102030405', 30);
+	`}, nil)
+	require.NoError(t, err)
+
+	_, err = db.SetRedactionRule(&schema.RedactionRule{Table: "people", Column: "ssn", Mode: schema.RedactionMode_REDACT_HASH})
+	require.NoError(t, err)
+
+	_, err = db.SetRedactionRule(&schema.RedactionRule{Table: "people", Column: "card", Mode: schema.RedactionMode_REDACT_PARTIAL, VisibleChars: 4})
+	require.NoError(t, err)
+
+	_, err = db.SetRedactionRule(&schema.RedactionRule{Table: "people", Column: "photo", Mode: schema.RedactionMode_REDACT_PARTIAL, VisibleChars: 1})
+	require.NoError(t, err)
+
+	_, err = db.SetRedactionRule(&schema.RedactionRule{Table: "people", Column: "age", Mode: schema.RedactionMode_REDACT_HASH})
+	require.NoError(t, err)
+
+	rdb := db.Redacted()
+
+	ssnHash := sha256.Sum256([]byte("123-45-6789"))
+
+	res, err := rdb.SQLQuery(context.Background(), &schema.SQLQueryRequest{Sql: "SELECT name, ssn AS s, card, photo, age FROM people AS p"}, nil)
+	require.NoError(t, err)
+	require.Len(t, res.Rows, 1)
+
+	row := res.Rows[0]
+	require.Equal(t, "alice", row.Values[0].GetS())
+	require.Equal(t, hex.EncodeToString(ssnHash[:]), row.Values[1].GetS())
+	require.Equal(t, "************1111", row.Values[2].GetS())
+	require.Equal(t, []byte("****\x05"), row.Values[3].GetBs())
+	require.NotNil(t, row.Values[4].GetNull())
+
+	t.Run("queries should not disclose redacted values", func(t *testing.T) {
+		res, err := rdb.SQLQuery(context.Background(), &schema.SQLQueryRequest{Sql: "SELECT id FROM people WHERE ssn = '123-45-6789'"}, nil)
+		require.NoError(t, err)
+		require.Empty(t, res.Rows)
+
+		res, err = db.SQLQuery(context.Background(), &schema.SQLQueryRequest{Sql: "SELECT id FROM people WHERE ssn = '123-45-6789'"}, nil)
+		require.NoError(t, err)
+		require.Len(t, res.Rows, 1)
+	})
+
+	t.Run("row readers should read redacted values", func(t *testing.T) {
+		stmts, err := sql.Parse(strings.NewReader("SELECT ssn FROM people"))
+		require.NoError(t, err)
+
+		r, err := rdb.SQLQueryRowReader(context.Background(), stmts[0].(*sql.SelectStmt), nil)
+		require.NoError(t, err)
+
+		row, err := r.Read()
+		require.NoError(t, err)
+		require.Len(t, row.Values, 1)
+
+		for _, v := range row.Values {
+			require.Equal(t, hex.EncodeToString(ssnHash[:]), v.Value())
+		}
+
+		err = r.Close()
+		require.NoError(t, err)
+	})
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package database
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 
@@ -26,6 +27,7 @@ import (
 
 var ErrReferencedKeyCannotBeAReference = errors.New("referenced key cannot be a reference")
 var ErrFinalKeyCannotBeConvertedIntoReference = errors.New("final key cannot be converted into a reference")
+var ErrReverseReferenceIndexDisabled = errors.New("reverse reference index is disabled")
 var ErrNoWaitOperationMustBeSelfContained = fmt.Errorf("no wait operation must be self-contained: %w", store.ErrIllegalArguments)
 
 //Reference ...
@@ -45,6 +47,14 @@ func (d *db) SetReference(req *schema.ReferenceRequest) (*schema.TxHeader, error
 		return nil, ErrIsReplica
 	}
 
+	if d.isInMaintenance() {
+		return nil, ErrIsInMaintenance
+	}
+
+	if d.isDiskQuotaExceeded() {
+		return nil, ErrDiskQuotaExceeded
+	}
+
 	lastTxID, _ := d.st.Alh()
 	err := d.st.WaitForIndexingUpto(lastTxID, nil)
 	if err != nil {
@@ -77,13 +87,29 @@ func (d *db) SetReference(req *schema.ReferenceRequest) (*schema.TxHeader, error
 	}
 	defer tx.Cancel()
 
-	e := EncodeReference(req.Key, nil, req.ReferencedKey, req.AtTx)
+	md := expirationMetadata(req.Expiration)
+
+	e := EncodeReference(req.Key, md, req.ReferencedKey, req.AtTx)
 
 	err = tx.Set(e.Key, e.Metadata, e.Value)
 	if err != nil {
 		return nil, err
 	}
 
+	if d.options.reverseReferenceIndex {
+		reverseEntries, err := d.reverseReferenceEntries(req.Key, md, req.ReferencedKey, d.st)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, re := range reverseEntries {
+			err = tx.Set(re.Key, re.Metadata, re.Value)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
 	var hdr *store.TxHeader
 
 	if req.NoWait {
@@ -98,6 +124,59 @@ func (d *db) SetReference(req *schema.ReferenceRequest) (*schema.TxHeader, error
 	return schema.TxHeaderToProto(hdr), err
 }
 
+// reverseReferenceEntries returns the reverse entry of the reference being set under key and,
+// if key was already a reference to another key, the deletion of the reverse entry it had
+func (d *db) reverseReferenceEntries(key []byte, md *store.KVMetadata, referencedKey []byte, index store.KeyIndex) ([]*store.EntrySpec, error) {
+	entries := []*store.EntrySpec{EncodeReverseReference(key, md, referencedKey)}
+
+	prevReferencedKey, err := referencedKeyOf(EncodeKey(key), index)
+	if err != nil {
+		return nil, err
+	}
+
+	if prevReferencedKey != nil && !bytes.Equal(prevReferencedKey, referencedKey) {
+		entries = append(entries, deletedReverseReference(key, prevReferencedKey))
+	}
+
+	return entries, nil
+}
+
+// referencedKeyOf returns the key the reference stored under key points to, or nil if key is not a reference
+func referencedKeyOf(key []byte, index store.KeyIndex) ([]byte, error) {
+	valRef, err := index.Get(key)
+	if errors.Is(err, store.ErrKeyNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	val, err := valRef.Resolve()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(val) < 1 || val[0] != ReferenceValuePrefix {
+		return nil, nil
+	}
+
+	if len(val) < 1+8+1 {
+		return nil, fmt.Errorf(
+			"%w: internal value consistency error - invalid reference",
+			store.ErrCorruptedData,
+		)
+	}
+
+	return TrimPrefix(val[1+8:]), nil
+}
+
+func deletedReverseReference(key, referencedKey []byte) *store.EntrySpec {
+	md := store.NewKVMetadata()
+	md.AsDeleted(true)
+
+	return EncodeReverseReference(key, md, referencedKey)
+}
+
 //SafeReference ...
 func (d *db) VerifiableSetReference(req *schema.VerifiableReferenceRequest) (*schema.VerifiableTx, error) {
 	if req == nil {
@@ -144,3 +223,101 @@ func (d *db) VerifiableSetReference(req *schema.VerifiableReferenceRequest) (*sc
 		DualProof: schema.DualProofToProto(dualProof),
 	}, nil
 }
+
+// ResolveAll returns the entries of the references currently pointing to the given key, resolved as Get does.
+// References are found through the reverse entries written along with them when the reverse reference index
+// is enabled, thus references set while it was disabled are not returned and ErrReverseReferenceIndexDisabled
+// is returned if it's not enabled. ErrMaxKeyScanLimitExceeded is returned when more than MaxKeyScanLimit
+// references point to the key, instead of a partial result
+func (d *db) ResolveAll(req *schema.ResolveAllRequest) (*schema.Entries, error) {
+	if req == nil || len(req.Key) == 0 {
+		return nil, store.ErrIllegalArguments
+	}
+
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	if !d.options.reverseReferenceIndex {
+		return nil, ErrReverseReferenceIndexDisabled
+	}
+
+	currTxID, _ := d.st.Alh()
+
+	if req.SinceTx > currTxID {
+		return nil, ErrIllegalArguments
+	}
+
+	waitUntilTx := req.SinceTx
+	if waitUntilTx == 0 {
+		waitUntilTx = currTxID
+	}
+
+	if !req.NoWait {
+		err := d.st.WaitForIndexingUpto(waitUntilTx, nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	snap, err := d.st.SnapshotSince(waitUntilTx)
+	if err != nil {
+		return nil, err
+	}
+	defer snap.Close()
+
+	prefix := WrapReverseReferencePrefix(req.Key)
+
+	r, err := snap.NewKeyReader(
+		&store.KeyReaderSpec{
+			Prefix: prefix,
+			Filter: store.IgnoreDeleted,
+		})
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	entries := &schema.Entries{}
+
+	tx := d.st.NewTxHolder()
+
+	for {
+		rKey, _, err := r.Read()
+		if err == store.ErrNoMoreEntries {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		entry, err := d.getAt(EncodeKey(rKey[len(prefix):]), 0, 0, snap, tx)
+		if errors.Is(err, store.ErrKeyNotFound) {
+			// the reference was deleted or it has expired
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// the reference may have been set to point to another key afterwards
+		if entry.ReferencedBy == nil || !bytes.Equal(entry.Key, req.Key) {
+			continue
+		}
+
+		if len(entries.Entries) == MaxKeyScanLimit {
+			return nil, fmt.Errorf("%w: more than %d references point to the key", ErrMaxKeyScanLimitExceeded, MaxKeyScanLimit)
+		}
+
+		entries.Entries = append(entries.Entries, entry)
+	}
+
+	return entries, nil
+}
+
+func expirationMetadata(expiration *schema.Expiration) *store.KVMetadata {
+	if expiration == nil {
+		return nil
+	}
+
+	return schema.KVMetadataFromProto(&schema.KVMetadata{Expiration: expiration})
+}
@@ -17,9 +17,13 @@ limitations under the License.
 package database
 
 import (
+	"context"
 	"crypto/sha256"
+	"errors"
+	"fmt"
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/codenotary/immudb/embedded/store"
 	"github.com/codenotary/immudb/pkg/api/schema"
@@ -33,7 +37,7 @@ func TestStoreReference(t *testing.T) {
 	req := &schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte(`firstKey`), Value: []byte(`firstValue`)}}}
 	txhdr, err := db.Set(req)
 
-	item, err := db.Get(&schema.KeyRequest{Key: []byte(`firstKey`), SinceTx: txhdr.Id})
+	item, err := db.Get(context.Background(), &schema.KeyRequest{Key: []byte(`firstKey`), SinceTx: txhdr.Id})
 	require.NoError(t, err)
 	require.Equal(t, []byte(`firstKey`), item.Key)
 	require.Equal(t, []byte(`firstValue`), item.Value)
@@ -71,11 +75,11 @@ func TestStoreReference(t *testing.T) {
 
 	keyReq := &schema.KeyRequest{Key: []byte(`myTag`), SinceTx: txhdr.Id}
 
-	firstItemRet, err := db.Get(keyReq)
+	firstItemRet, err := db.Get(context.Background(), keyReq)
 	require.NoError(t, err)
 	require.Equal(t, []byte(`firstValue`), firstItemRet.Value, "Should have referenced item value")
 
-	vitem, err := db.VerifiableGet(&schema.VerifiableGetRequest{
+	vitem, err := db.VerifiableGet(context.Background(), &schema.VerifiableGetRequest{
 		KeyRequest:   keyReq,
 		ProveSinceTx: 1,
 	})
@@ -115,7 +119,7 @@ func TestStore_GetReferenceWithIndexResolution(t *testing.T) {
 	ref, err := db.SetReference(&schema.ReferenceRequest{Key: []byte(`myTag1`), ReferencedKey: []byte(`aaa`), AtTx: set.Id, BoundRef: true})
 	require.NoError(t, err)
 
-	tag3, err := db.Get(&schema.KeyRequest{Key: []byte(`myTag1`), SinceTx: ref.Id})
+	tag3, err := db.Get(context.Background(), &schema.KeyRequest{Key: []byte(`myTag1`), SinceTx: ref.Id})
 	require.NoError(t, err)
 	require.Equal(t, []byte(`aaa`), tag3.Key)
 	require.Equal(t, []byte(`value1`), tag3.Value)
@@ -131,7 +135,7 @@ func TestStoreInvalidReferenceToReference(t *testing.T) {
 	ref1, err := db.SetReference(&schema.ReferenceRequest{Key: []byte(`myTag1`), ReferencedKey: []byte(`firstKey`), AtTx: txhdr.Id, BoundRef: true})
 	require.NoError(t, err)
 
-	_, err = db.Get(&schema.KeyRequest{Key: []byte(`myTag1`), SinceTx: ref1.Id})
+	_, err = db.Get(context.Background(), &schema.KeyRequest{Key: []byte(`myTag1`), SinceTx: ref1.Id})
 	require.NoError(t, err)
 
 	_, err = db.SetReference(&schema.ReferenceRequest{Key: []byte(`myTag2`), ReferencedKey: []byte(`myTag1`)})
@@ -188,7 +192,7 @@ func TestStoreReferenceAsyncCommit(t *testing.T) {
 			index = secondIndex.Id
 		}
 
-		item, err := db.Get(&schema.KeyRequest{Key: tag, SinceTx: 67})
+		item, err := db.Get(context.Background(), &schema.KeyRequest{Key: tag, SinceTx: 67})
 		require.NoError(t, err, "n=%d", n)
 		require.Equal(t, index, item.Tx, "n=%d", n)
 		require.Equal(t, itemVal, item.Value, "n=%d", n)
@@ -240,17 +244,17 @@ func TestStoreMultipleReferenceOnSameKey(t *testing.T) {
 	require.Exactly(t, uint64(6), reference3.Id)
 	require.NotEmptyf(t, reference3, "Should not be empty")
 
-	firstTagRet, err := db.Get(&schema.KeyRequest{Key: []byte(`myTag1`), SinceTx: reference3.Id})
+	firstTagRet, err := db.Get(context.Background(), &schema.KeyRequest{Key: []byte(`myTag1`), SinceTx: reference3.Id})
 	require.NoError(t, err)
 	require.NotEmptyf(t, firstTagRet, "Should not be empty")
 	require.Equal(t, []byte(`firstValue`), firstTagRet.Value, "Should have referenced item value")
 
-	secondTagRet, err := db.Get(&schema.KeyRequest{Key: []byte(`myTag2`), SinceTx: reference3.Id})
+	secondTagRet, err := db.Get(context.Background(), &schema.KeyRequest{Key: []byte(`myTag2`), SinceTx: reference3.Id})
 	require.NoError(t, err)
 	require.NotEmptyf(t, secondTagRet, "Should not be empty")
 	require.Equal(t, []byte(`firstValue`), secondTagRet.Value, "Should have referenced item value")
 
-	thirdItemRet, err := db.Get(&schema.KeyRequest{Key: []byte(`myTag3`), SinceTx: reference3.Id})
+	thirdItemRet, err := db.Get(context.Background(), &schema.KeyRequest{Key: []byte(`myTag3`), SinceTx: reference3.Id})
 	require.NoError(t, err)
 	require.NotEmptyf(t, thirdItemRet, "Should not be empty")
 	require.Equal(t, []byte(`secondValue`), thirdItemRet.Value, "Should have referenced item value")
@@ -272,12 +276,12 @@ func TestStoreIndexReference(t *testing.T) {
 	ref, err = db.SetReference(&schema.ReferenceRequest{ReferencedKey: []byte(`aaa`), Key: []byte(`myTag2`), AtTx: idx2.Id, BoundRef: true})
 	require.NoError(t, err)
 
-	tag1, err := db.Get(&schema.KeyRequest{Key: []byte(`myTag1`), SinceTx: ref.Id})
+	tag1, err := db.Get(context.Background(), &schema.KeyRequest{Key: []byte(`myTag1`), SinceTx: ref.Id})
 	require.NoError(t, err)
 	require.Equal(t, []byte(`aaa`), tag1.Key)
 	require.Equal(t, []byte(`item1`), tag1.Value)
 
-	tag2, err := db.Get(&schema.KeyRequest{Key: []byte(`myTag2`), SinceTx: ref.Id})
+	tag2, err := db.Get(context.Background(), &schema.KeyRequest{Key: []byte(`myTag2`), SinceTx: ref.Id})
 	require.NoError(t, err)
 	require.Equal(t, []byte(`aaa`), tag2.Key)
 	require.Equal(t, []byte(`item2`), tag2.Value)
@@ -306,12 +310,12 @@ func TestStore_GetOnReferenceOnSameKeyReturnsAlwaysLastValue(t *testing.T) {
 	ref, err := db.SetReference(&schema.ReferenceRequest{Key: []byte(`myTag2`), ReferencedKey: []byte(`aaa`), AtTx: idx1.Id, BoundRef: true})
 	require.NoError(t, err)
 
-	tag2, err := db.Get(&schema.KeyRequest{Key: []byte(`myTag2`), SinceTx: ref.Id})
+	tag2, err := db.Get(context.Background(), &schema.KeyRequest{Key: []byte(`myTag2`), SinceTx: ref.Id})
 	require.NoError(t, err)
 	require.Equal(t, []byte(`aaa`), tag2.Key)
 	require.Equal(t, []byte(`item1`), tag2.Value)
 
-	tag1b, err := db.Get(&schema.KeyRequest{Key: []byte(`myTag1`), SinceTx: ref.Id})
+	tag1b, err := db.Get(context.Background(), &schema.KeyRequest{Key: []byte(`myTag1`), SinceTx: ref.Id})
 	require.NoError(t, err)
 	require.Equal(t, []byte(`aaa`), tag1b.Key)
 	require.Equal(t, []byte(`item2`), tag1b.Value)
@@ -381,7 +385,193 @@ func TestStoreVerifiableReference(t *testing.T) {
 
 	keyReq := &schema.KeyRequest{Key: []byte(`myTag`), SinceTx: vtx.Tx.Header.Id}
 
-	firstItemRet, err := db.Get(keyReq)
+	firstItemRet, err := db.Get(context.Background(), keyReq)
 	require.NoError(t, err)
 	require.Equal(t, []byte(`firstValue`), firstItemRet.Value, "Should have referenced item value")
 }
+
+func TestStoreExpirableReference(t *testing.T) {
+	db, closer := makeDb()
+	defer closer()
+
+	_, err := db.Set(&schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte("key1"), Value: []byte("value1")}}})
+	require.NoError(t, err)
+
+	expiresAt := time.Now().Add(2 * time.Second).Truncate(time.Second)
+
+	_, err = db.SetReference(&schema.ReferenceRequest{
+		Key:           []byte("ref1"),
+		ReferencedKey: []byte("key1"),
+		Expiration:    &schema.Expiration{ExpiresAt: expiresAt.Unix()},
+	})
+	require.NoError(t, err)
+
+	hdr, err := db.ZAdd(&schema.ZAddRequest{
+		Set:        []byte("set1"),
+		Score:      1,
+		Key:        []byte("key1"),
+		Expiration: &schema.Expiration{ExpiresAt: expiresAt.Unix()},
+	})
+	require.NoError(t, err)
+
+	entry, err := db.Get(context.Background(), &schema.KeyRequest{Key: []byte("ref1"), SinceTx: hdr.Id})
+	require.NoError(t, err)
+	require.Equal(t, []byte("value1"), entry.Value)
+	require.Equal(t, expiresAt.Unix(), entry.ReferencedBy.Metadata.Expiration.ExpiresAt)
+
+	zentries, err := db.ZScan(context.Background(), &schema.ZScanRequest{Set: []byte("set1"), SinceTx: hdr.Id})
+	require.NoError(t, err)
+	require.Len(t, zentries.Entries, 1)
+
+	time.Sleep(time.Until(expiresAt.Add(time.Second)))
+
+	_, err = db.Get(context.Background(), &schema.KeyRequest{Key: []byte("ref1")})
+	require.ErrorIs(t, err, store.ErrExpiredEntry)
+
+	zentries, err = db.ZScan(context.Background(), &schema.ZScanRequest{Set: []byte("set1")})
+	require.NoError(t, err)
+	require.Empty(t, zentries.Entries)
+
+	// the referenced key is not affected
+	entry, err = db.Get(context.Background(), &schema.KeyRequest{Key: []byte("key1")})
+	require.NoError(t, err)
+	require.Equal(t, []byte("value1"), entry.Value)
+}
+
+func TestResolveAll(t *testing.T) {
+	db, closer := makeDb()
+	defer closer()
+
+	_, err := db.ResolveAll(nil)
+	require.ErrorIs(t, err, store.ErrIllegalArguments)
+
+	_, err = db.ResolveAll(&schema.ResolveAllRequest{Key: []byte("key1")})
+	require.ErrorIs(t, err, ErrReverseReferenceIndexDisabled)
+
+	_, err = db.Set(&schema.SetRequest{KVs: []*schema.KeyValue{
+		{Key: []byte("key1"), Value: []byte("value1")},
+		{Key: []byte("key2"), Value: []byte("value2")},
+	}})
+	require.NoError(t, err)
+
+	// no reverse entry is written while the index is disabled
+	hdr, err := db.SetReference(&schema.ReferenceRequest{Key: []byte("ref0"), ReferencedKey: []byte("key1")})
+	require.NoError(t, err)
+	require.Equal(t, int32(1), hdr.Nentries)
+
+	db.EnableReverseReferenceIndex(true)
+	require.True(t, db.ReverseReferenceIndexEnabled())
+
+	_, err = db.ResolveAll(&schema.ResolveAllRequest{Key: []byte("key1"), SinceTx: 100})
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	for _, ref := range []string{"ref1", "ref2", "ref3"} {
+		hdr, err = db.SetReference(&schema.ReferenceRequest{Key: []byte(ref), ReferencedKey: []byte("key1")})
+		require.NoError(t, err)
+		require.Equal(t, int32(2), hdr.Nentries)
+	}
+
+	hdr, err = db.ExecAll(&schema.ExecAllRequest{Operations: []*schema.Op{
+		{Operation: &schema.Op_Ref{Ref: &schema.ReferenceRequest{Key: []byte("ref4"), ReferencedKey: []byte("key1")}}},
+		{Operation: &schema.Op_Ref{Ref: &schema.ReferenceRequest{Key: []byte("ref5"), ReferencedKey: []byte("key2")}}},
+	}})
+	require.NoError(t, err)
+	require.Equal(t, int32(4), hdr.Nentries)
+
+	// the reverse entries of references no longer pointing to the key are removed
+	hdr, err = db.SetReference(&schema.ReferenceRequest{Key: []byte("ref2"), ReferencedKey: []byte("key2")})
+	require.NoError(t, err)
+	require.Equal(t, int32(3), hdr.Nentries)
+
+	hdr, err = db.Delete(&schema.DeleteKeysRequest{Keys: [][]byte{[]byte("ref3")}})
+	require.NoError(t, err)
+	require.Equal(t, int32(2), hdr.Nentries)
+
+	t.Run("only the reverse entries of the references pointing to the key should be left", func(t *testing.T) {
+		require.Equal(t, []string{"ref1", "ref4"}, reverseReferencesTo(t, db, []byte("key1"), hdr.Id))
+	})
+
+	entries, err := db.ResolveAll(&schema.ResolveAllRequest{Key: []byte("key1"), SinceTx: hdr.Id})
+	require.NoError(t, err)
+	require.Len(t, entries.Entries, 2)
+
+	for i, ref := range []string{"ref1", "ref4"} {
+		require.Equal(t, []byte("key1"), entries.Entries[i].Key)
+		require.Equal(t, []byte("value1"), entries.Entries[i].Value)
+		require.Equal(t, []byte(ref), entries.Entries[i].ReferencedBy.Key)
+	}
+
+	entries, err = db.ResolveAll(&schema.ResolveAllRequest{Key: []byte("key2")})
+	require.NoError(t, err)
+	require.Len(t, entries.Entries, 2)
+	require.Equal(t, []byte("ref2"), entries.Entries[0].ReferencedBy.Key)
+	require.Equal(t, []byte("ref5"), entries.Entries[1].ReferencedBy.Key)
+
+	entries, err = db.ResolveAll(&schema.ResolveAllRequest{Key: []byte("key3")})
+	require.NoError(t, err)
+	require.Empty(t, entries.Entries)
+}
+
+func reverseReferencesTo(t *testing.T, d DB, key []byte, sinceTx uint64) []string {
+	prefix := WrapReverseReferencePrefix(key)
+
+	snap, err := d.(*db).st.SnapshotSince(sinceTx)
+	require.NoError(t, err)
+	defer snap.Close()
+
+	r, err := snap.NewKeyReader(&store.KeyReaderSpec{Prefix: prefix, Filter: store.IgnoreDeleted})
+	require.NoError(t, err)
+	defer r.Close()
+
+	var refs []string
+
+	for {
+		rKey, _, err := r.Read()
+		if errors.Is(err, store.ErrNoMoreEntries) {
+			break
+		}
+		require.NoError(t, err)
+
+		refs = append(refs, string(rKey[len(prefix):]))
+	}
+
+	return refs
+}
+
+func TestResolveAllLimits(t *testing.T) {
+	db, closer := makeDb()
+	defer closer()
+
+	db.EnableReverseReferenceIndex(true)
+
+	_, err := db.Set(&schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte("key1"), Value: []byte("value1")}}})
+	require.NoError(t, err)
+
+	refOps := func(from, to int) []*schema.Op {
+		ops := make([]*schema.Op, 0, to-from)
+
+		for i := from; i < to; i++ {
+			ops = append(ops, &schema.Op{Operation: &schema.Op_Ref{Ref: &schema.ReferenceRequest{
+				Key:           []byte(fmt.Sprintf("ref%d", i)),
+				ReferencedKey: []byte("key1"),
+			}}})
+		}
+
+		return ops
+	}
+
+	// references count twice against the max number of entries per tx
+	maxRefs := store.DefaultMaxTxEntries / 2
+
+	_, err = db.ExecAll(&schema.ExecAllRequest{Operations: refOps(0, maxRefs+1)})
+	require.ErrorIs(t, err, store.ErrorMaxTxEntriesLimitExceeded)
+
+	for from := 0; from < MaxKeyScanLimit; from += maxRefs {
+		_, err = db.ExecAll(&schema.ExecAllRequest{Operations: refOps(from, from+maxRefs)})
+		require.NoError(t, err)
+	}
+
+	entries, err := db.ResolveAll(&schema.ResolveAllRequest{Key: []byte("key1")})
+	require.ErrorIs(t, err, ErrMaxKeyScanLimitExceeded)
+	require.Nil(t, entries)
+}
@@ -0,0 +1,306 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package database
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/api/schema"
+)
+
+// Rename moves the value of a key to a new one within a single transaction: the old key is deleted,
+// its value and expiration are copied into the new key and a link from the new key to the entry the value
+// was taken from is written, so the history of the new key can be followed back into the one of the old key
+func (d *db) Rename(req *schema.RenameRequest) (*schema.TxHeader, error) {
+	if req == nil || len(req.OldKey) == 0 || len(req.NewKey) == 0 || bytes.Equal(req.OldKey, req.NewKey) {
+		return nil, ErrIllegalArguments
+	}
+
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	if d.isReplica() {
+		return nil, ErrIsReplica
+	}
+
+	if d.isInMaintenance() {
+		return nil, ErrIsInMaintenance
+	}
+
+	if d.isDiskQuotaExceeded() {
+		return nil, ErrDiskQuotaExceeded
+	}
+
+	// txs committed after the snapshot of the transaction conflict with it,
+	// so the keys can not be modified in between
+	tx, err := d.st.NewTx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Cancel()
+
+	oldKey := EncodeKey(req.OldKey)
+	newKey := EncodeKey(req.NewKey)
+
+	valRef, err := tx.Get(oldKey)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Get(newKey)
+	if err == nil {
+		return nil, store.ErrKeyAlreadyExists
+	}
+	if !errors.Is(err, store.ErrKeyNotFound) {
+		return nil, err
+	}
+
+	val, err := valRef.Resolve()
+	if err != nil {
+		return nil, err
+	}
+	if len(val) < 1 {
+		return nil, fmt.Errorf(
+			"%w: internal value consistency error - missing value prefix",
+			store.ErrCorruptedData,
+		)
+	}
+
+	var md *store.KVMetadata
+
+	if valRef.KVMetadata() != nil && valRef.KVMetadata().IsExpirable() {
+		expiresAt, err := valRef.KVMetadata().ExpirationTime()
+		if err != nil {
+			return nil, err
+		}
+
+		md = store.NewKVMetadata()
+
+		err = md.ExpiresAt(expiresAt)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if val[0] == ReferenceValuePrefix {
+		if len(val) < 1+8+1 {
+			return nil, fmt.Errorf(
+				"%w: internal value consistency error - invalid reference",
+				store.ErrCorruptedData,
+			)
+		}
+
+		if d.options.reverseReferenceIndex {
+			// the renamed reference keeps being found from the key it points to
+			referencedKey := TrimPrefix(val[1+8:])
+
+			for _, e := range []*store.EntrySpec{
+				EncodeReverseReference(req.NewKey, md, referencedKey),
+				deletedReverseReference(req.OldKey, referencedKey),
+			} {
+				err = tx.Set(e.Key, e.Metadata, e.Value)
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+	} else {
+		kvSchemas, err := d.kvSchemaRegistry()
+		if err != nil {
+			return nil, err
+		}
+
+		err = kvSchemas.validate(req.NewKey, TrimPrefix(val))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = tx.Delete(oldKey)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Set(newKey, md, val)
+	if err != nil {
+		return nil, err
+	}
+
+	link := EncodeRenameLink(req.NewKey, req.OldKey, valRef.Tx())
+
+	err = tx.Set(link.Key, link.Metadata, link.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	var hdr *store.TxHeader
+	if req.NoWait {
+		hdr, err = tx.AsyncCommit()
+	} else {
+		hdr, err = tx.Commit()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return schema.TxHeaderToProto(hdr), nil
+}
+
+type keyTx struct {
+	key  []byte
+	txID uint64
+}
+
+// historyFollowingRenames returns the entries of the key along with the ones of the keys renamed into it,
+// ordered by transaction. Entries of the renamed keys are returned with the key they were committed with
+func (d *db) historyFollowingRenames(req *schema.HistoryRequest, limit int, tx *store.Tx) (*schema.Entries, error) {
+	var keyTxs []keyTx
+
+	err := d.collectRenamedHistory(req.Key, math.MaxUint64, make(map[string]struct{}), &keyTxs, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(keyTxs) == 0 {
+		return nil, store.ErrKeyNotFound
+	}
+
+	sort.SliceStable(keyTxs, func(i, j int) bool {
+		if req.Desc {
+			return keyTxs[i].txID > keyTxs[j].txID
+		}
+		return keyTxs[i].txID < keyTxs[j].txID
+	})
+
+	list := &schema.Entries{}
+
+	if req.Offset >= uint64(len(keyTxs)) {
+		return list, nil
+	}
+
+	keyTxs = keyTxs[req.Offset:]
+
+	if len(keyTxs) > limit {
+		keyTxs = keyTxs[:limit]
+	}
+
+	for _, ktx := range keyTxs {
+		err = d.st.ReadTx(ktx.txID, tx)
+		if err != nil {
+			return nil, err
+		}
+
+		entry, err := d.historyEntry(ktx.key, EncodeKey(ktx.key), tx)
+		if err != nil {
+			return nil, err
+		}
+
+		list.Entries = append(list.Entries, entry)
+	}
+
+	return list, nil
+}
+
+// collectRenamedHistory appends the txs, up to maxTx, in which the key was updated and then does the same
+// with the keys renamed into it, up to the tx of the entry each rename took the value from
+func (d *db) collectRenamedHistory(key []byte, maxTx uint64, seen map[string]struct{}, keyTxs *[]keyTx, tx *store.Tx) error {
+	txIDs, err := d.keyHistory(EncodeKey(key))
+	if err != nil {
+		return err
+	}
+
+	for _, txID := range txIDs {
+		if txID > maxTx {
+			break
+		}
+
+		var id [8]byte
+		binary.BigEndian.PutUint64(id[:], txID)
+
+		// a key renamed back and forth would be visited more than once
+		_, ok := seen[string(id[:])+string(key)]
+		if ok {
+			continue
+		}
+		seen[string(id[:])+string(key)] = struct{}{}
+
+		*keyTxs = append(*keyTxs, keyTx{key: key, txID: txID})
+	}
+
+	linkKey := WrapWithPrefix(key, RenameLinkPrefix)
+
+	linkTxIDs, err := d.keyHistory(linkKey)
+	if err != nil {
+		return err
+	}
+
+	for _, linkTxID := range linkTxIDs {
+		if linkTxID > maxTx {
+			break
+		}
+
+		_, val, err := d.readMetadataAndValue(linkKey, linkTxID, tx)
+		if err != nil {
+			return err
+		}
+		if len(val) < 1+8+1 || val[0] != ReferenceValuePrefix {
+			return fmt.Errorf(
+				"%w: internal value consistency error - invalid rename link",
+				store.ErrCorruptedData,
+			)
+		}
+
+		// the entry the value was taken from is always older than the rename
+		atTx := binary.BigEndian.Uint64(TrimPrefix(val))
+
+		err = d.collectRenamedHistory(TrimPrefix(val[1+8:]), atTx, seen, keyTxs, tx)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// keyHistory returns all the txs in which the key was updated, in ascending order
+func (d *db) keyHistory(key []byte) ([]uint64, error) {
+	var txIDs []uint64
+
+	for offset := uint64(0); ; offset += MaxKeyScanLimit {
+		txs, err := d.st.History(key, offset, false, MaxKeyScanLimit)
+		if errors.Is(err, store.ErrKeyNotFound) || err == store.ErrOffsetOutOfRange {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		txIDs = append(txIDs, txs...)
+
+		if len(txs) < MaxKeyScanLimit {
+			break
+		}
+	}
+
+	return txIDs, nil
+}
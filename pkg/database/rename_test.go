@@ -0,0 +1,176 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRename(t *testing.T) {
+	db, closer := makeDb()
+	defer closer()
+
+	_, err := db.Rename(nil)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = db.Rename(&schema.RenameRequest{OldKey: []byte("key1")})
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = db.Rename(&schema.RenameRequest{OldKey: []byte("key1"), NewKey: []byte("key1")})
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = db.Rename(&schema.RenameRequest{OldKey: []byte("key1"), NewKey: []byte("key2")})
+	require.ErrorIs(t, err, store.ErrKeyNotFound)
+
+	expiresAt := time.Now().Add(time.Hour).Truncate(time.Second)
+
+	_, err = db.Set(&schema.SetRequest{KVs: []*schema.KeyValue{
+		{
+			Key:      []byte("key1"),
+			Value:    []byte("value1"),
+			Metadata: &schema.KVMetadata{Expiration: &schema.Expiration{ExpiresAt: expiresAt.Unix()}},
+		},
+		{Key: []byte("key3"), Value: []byte("value3")},
+	}})
+	require.NoError(t, err)
+
+	_, err = db.Rename(&schema.RenameRequest{OldKey: []byte("key1"), NewKey: []byte("key3")})
+	require.ErrorIs(t, err, store.ErrKeyAlreadyExists)
+
+	hdr, err := db.Rename(&schema.RenameRequest{OldKey: []byte("key1"), NewKey: []byte("key2")})
+	require.NoError(t, err)
+	require.Equal(t, int32(3), hdr.Nentries)
+
+	_, err = db.Get(context.Background(), &schema.KeyRequest{Key: []byte("key1"), SinceTx: hdr.Id})
+	require.ErrorIs(t, err, store.ErrKeyNotFound)
+
+	entry, err := db.Get(context.Background(), &schema.KeyRequest{Key: []byte("key2")})
+	require.NoError(t, err)
+	require.Equal(t, []byte("value1"), entry.Value)
+	require.Equal(t, hdr.Id, entry.Tx)
+	require.Equal(t, expiresAt.Unix(), entry.Metadata.Expiration.ExpiresAt)
+
+	t.Run("renamed references should keep pointing to the same key", func(t *testing.T) {
+		db.EnableReverseReferenceIndex(true)
+
+		_, err = db.SetReference(&schema.ReferenceRequest{Key: []byte("ref1"), ReferencedKey: []byte("key3")})
+		require.NoError(t, err)
+
+		hdr, err := db.Rename(&schema.RenameRequest{OldKey: []byte("ref1"), NewKey: []byte("ref2")})
+		require.NoError(t, err)
+		require.Equal(t, int32(5), hdr.Nentries)
+
+		entry, err := db.Get(context.Background(), &schema.KeyRequest{Key: []byte("ref2")})
+		require.NoError(t, err)
+		require.Equal(t, []byte("value3"), entry.Value)
+		require.Equal(t, []byte("ref2"), entry.ReferencedBy.Key)
+
+		entries, err := db.ResolveAll(&schema.ResolveAllRequest{Key: []byte("key3")})
+		require.NoError(t, err)
+		require.Len(t, entries.Entries, 1)
+		require.Equal(t, []byte("ref2"), entries.Entries[0].ReferencedBy.Key)
+	})
+}
+
+func TestHistoryFollowingRenames(t *testing.T) {
+	db, closer := makeDb()
+	defer closer()
+
+	var txs []uint64
+
+	set := func(key, value string) {
+		hdr, err := db.Set(&schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte(key), Value: []byte(value)}}})
+		require.NoError(t, err)
+		txs = append(txs, hdr.Id)
+	}
+
+	rename := func(oldKey, newKey string) {
+		hdr, err := db.Rename(&schema.RenameRequest{OldKey: []byte(oldKey), NewKey: []byte(newKey)})
+		require.NoError(t, err)
+		txs = append(txs, hdr.Id)
+	}
+
+	set("a", "v1")
+	set("a", "v2")
+	rename("a", "b")
+	set("b", "v3")
+	rename("b", "c")
+	set("a", "v4")
+
+	_, err := db.History(context.Background(), &schema.HistoryRequest{Key: []byte("c"), FollowRenames: true, StartTime: 1})
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = db.History(context.Background(), &schema.HistoryRequest{Key: []byte("d"), FollowRenames: true})
+	require.ErrorIs(t, err, store.ErrKeyNotFound)
+
+	entries, err := db.History(context.Background(), &schema.HistoryRequest{Key: []byte("c")})
+	require.NoError(t, err)
+	require.Len(t, entries.Entries, 1)
+
+	entries, err = db.History(context.Background(), &schema.HistoryRequest{Key: []byte("c"), FollowRenames: true})
+	require.NoError(t, err)
+	require.Len(t, entries.Entries, 5)
+
+	expected := []struct {
+		key   string
+		value string
+	}{
+		{"a", "v1"},
+		{"a", "v2"},
+		{"b", "v2"},
+		{"b", "v3"},
+		{"c", "v3"},
+	}
+
+	for i, e := range expected {
+		require.Equal(t, txs[i], entries.Entries[i].Tx)
+		require.Equal(t, []byte(e.key), entries.Entries[i].Key)
+		require.Equal(t, []byte(e.value), entries.Entries[i].Value)
+	}
+
+	entries, err = db.History(context.Background(), &schema.HistoryRequest{Key: []byte("c"), FollowRenames: true, Desc: true, Offset: 1, Limit: 2})
+	require.NoError(t, err)
+	require.Len(t, entries.Entries, 2)
+	require.Equal(t, []byte("b"), entries.Entries[0].Key)
+	require.Equal(t, []byte("v3"), entries.Entries[0].Value)
+	require.Equal(t, []byte("b"), entries.Entries[1].Key)
+	require.Equal(t, []byte("v2"), entries.Entries[1].Value)
+
+	entries, err = db.History(context.Background(), &schema.HistoryRequest{Key: []byte("c"), FollowRenames: true, Offset: 5})
+	require.NoError(t, err)
+	require.Empty(t, entries.Entries)
+
+	t.Run("keys renamed back should not be visited twice", func(t *testing.T) {
+		rename("c", "x")
+		rename("x", "c")
+
+		entries, err := db.History(context.Background(), &schema.HistoryRequest{Key: []byte("c"), FollowRenames: true})
+		require.NoError(t, err)
+		require.Len(t, entries.Entries, 8)
+		require.Equal(t, []byte("c"), entries.Entries[5].Key)
+		require.True(t, entries.Entries[5].Metadata.Deleted)
+		require.Equal(t, []byte("x"), entries.Entries[6].Key)
+		require.Equal(t, []byte("c"), entries.Entries[7].Key)
+		require.Equal(t, []byte("v3"), entries.Entries[7].Value)
+	})
+}
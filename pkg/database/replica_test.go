@@ -17,6 +17,7 @@ limitations under the License.
 package database
 
 import (
+	"context"
 	"os"
 	"strconv"
 	"testing"
@@ -76,7 +77,7 @@ func TestReadOnlyReplica(t *testing.T) {
 	_, _, err = replica.SQLExec(&schema.SQLExecRequest{Sql: "CREATE TABLE mytable(id INTEGER, title VARCHAR, PRIMARY KEY id)"}, nil)
 	require.Equal(t, ErrIsReplica, err)
 
-	_, err = replica.SQLQuery(&schema.SQLQueryRequest{Sql: "SELECT * FROM mytable"}, nil)
+	_, err = replica.SQLQuery(context.Background(), &schema.SQLQueryRequest{Sql: "SELECT * FROM mytable"}, nil)
 	require.Equal(t, ErrSQLNotReady, err)
 
 	_, err = replica.ListTables(nil)
@@ -116,7 +117,7 @@ func TestSwitchToReplica(t *testing.T) {
 	_, err = replica.DescribeTable("mytable", nil)
 	require.NoError(t, err)
 
-	_, err = replica.SQLQuery(&schema.SQLQueryRequest{Sql: "SELECT * FROM mytable"}, nil)
+	_, err = replica.SQLQuery(context.Background(), &schema.SQLQueryRequest{Sql: "SELECT * FROM mytable"}, nil)
 	require.NoError(t, err)
 
 	_, err = replica.VerifiableSQLGet(&schema.VerifiableSQLGetRequest{
@@ -0,0 +1,86 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package database
+
+import (
+	"errors"
+	"fmt"
+
+	"google.golang.org/protobuf/proto"
+)
+
+var ErrResultLimitExceeded = errors.New("result limit exceeded")
+
+// ResultLimitError is returned, together with the rows or entries read so far, when a SQL result or a scan
+// exceeds the limits of the database. It wraps ErrResultLimitExceeded
+type ResultLimitError struct {
+	// Limit is the exceeded limit, either "rows" or "bytes"
+	Limit string
+	Max   int
+	// Rows is the number of rows or entries of the partial result
+	Rows int
+}
+
+func (e *ResultLimitError) Error() string {
+	return fmt.Sprintf("%s: max %s (%d) reached, partial result of %d rows", ErrResultLimitExceeded.Error(), e.Limit, e.Max, e.Rows)
+}
+
+func (e *ResultLimitError) Unwrap() error {
+	return ErrResultLimitExceeded
+}
+
+// Partial tells whether any row was read before the limit was reached
+func (e *ResultLimitError) Partial() bool {
+	return e.Rows > 0
+}
+
+// resultLimiter accounts for the rows and bytes added to a result, rows are checked before they are added
+// so that the result never exceeds the limits
+type resultLimiter struct {
+	maxRows  int
+	maxBytes int
+
+	rows  int
+	bytes int
+}
+
+func (d *db) newResultLimiter() *resultLimiter {
+	return &resultLimiter{
+		maxRows:  d.options.maxResultRows,
+		maxBytes: d.options.maxResultBytes,
+	}
+}
+
+func (l *resultLimiter) add(m proto.Message) error {
+	if l.maxRows > 0 && l.rows+1 > l.maxRows {
+		return &ResultLimitError{Limit: "rows", Max: l.maxRows, Rows: l.rows}
+	}
+
+	if l.maxBytes > 0 {
+		size := proto.Size(m)
+
+		if l.bytes+size > l.maxBytes {
+			return &ResultLimitError{Limit: "bytes", Max: l.maxBytes, Rows: l.rows}
+		}
+
+		l.bytes += size
+	}
+
+	l.rows++
+
+	return nil
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResultLimits(t *testing.T) {
+	d, closer := makeDb()
+	db := d.(*db)
+	defer closer()
+
+	_, _, err := db.SQLExec(&schema.SQLExecRequest{Sql: "CREATE TABLE table1(id INTEGER, title VARCHAR, PRIMARY KEY id)"}, nil)
+	require.NoError(t, err)
+
+	for i := 0; i < 10; i++ {
+		_, _, err = db.SQLExec(&schema.SQLExecRequest{Sql: fmt.Sprintf("INSERT INTO table1(id, title) VALUES (%d, 'title%d')", i, i)}, nil)
+		require.NoError(t, err)
+
+		_, err = db.Set(&schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte(fmt.Sprintf("key%d", i)), Value: []byte("value")}}})
+		require.NoError(t, err)
+	}
+
+	t.Run("unlimited", func(t *testing.T) {
+		res, err := db.SQLQuery(context.Background(), &schema.SQLQueryRequest{Sql: "SELECT id, title FROM table1"}, nil)
+		require.NoError(t, err)
+		require.Len(t, res.Rows, 10)
+
+		entries, err := db.Scan(context.Background(), &schema.ScanRequest{Prefix: []byte("key")})
+		require.NoError(t, err)
+		require.Len(t, entries.Entries, 10)
+	})
+
+	t.Run("max rows", func(t *testing.T) {
+		db.options.WithMaxResultRows(4).WithMaxResultBytes(0)
+
+		res, err := db.SQLQuery(context.Background(), &schema.SQLQueryRequest{Sql: "SELECT id, title FROM table1"}, nil)
+		require.ErrorIs(t, err, ErrResultLimitExceeded)
+		require.Len(t, res.Rows, 4)
+
+		var limitErr *ResultLimitError
+		require.True(t, errors.As(err, &limitErr))
+		require.Equal(t, "rows", limitErr.Limit)
+		require.Equal(t, 4, limitErr.Rows)
+		require.True(t, limitErr.Partial())
+
+		entries, err := db.Scan(context.Background(), &schema.ScanRequest{Prefix: []byte("key")})
+		require.ErrorIs(t, err, ErrResultLimitExceeded)
+		require.Len(t, entries.Entries, 4)
+
+		// results within the limits are not affected
+		res, err = db.SQLQuery(context.Background(), &schema.SQLQueryRequest{Sql: "SELECT id FROM table1 LIMIT 4"}, nil)
+		require.NoError(t, err)
+		require.Len(t, res.Rows, 4)
+
+		entries, err = db.Scan(context.Background(), &schema.ScanRequest{Prefix: []byte("key"), Limit: 4})
+		require.NoError(t, err)
+		require.Len(t, entries.Entries, 4)
+	})
+
+	t.Run("max bytes", func(t *testing.T) {
+		db.options.WithMaxResultRows(0).WithMaxResultBytes(1)
+
+		res, err := db.SQLQuery(context.Background(), &schema.SQLQueryRequest{Sql: "SELECT id, title FROM table1"}, nil)
+		require.ErrorIs(t, err, ErrResultLimitExceeded)
+		require.Empty(t, res.Rows)
+
+		var limitErr *ResultLimitError
+		require.True(t, errors.As(err, &limitErr))
+		require.Equal(t, "bytes", limitErr.Limit)
+		require.False(t, limitErr.Partial())
+
+		_, err = db.Scan(context.Background(), &schema.ScanRequest{Prefix: []byte("key")})
+		require.ErrorIs(t, err, ErrResultLimitExceeded)
+	})
+}
@@ -16,12 +16,14 @@ limitations under the License.
 package database
 
 import (
+	"context"
+
 	"github.com/codenotary/immudb/embedded/store"
 	"github.com/codenotary/immudb/pkg/api/schema"
 )
 
 //Scan ...
-func (d *db) Scan(req *schema.ScanRequest) (*schema.Entries, error) {
+func (d *db) Scan(ctx context.Context, req *schema.ScanRequest) (*schema.Entries, error) {
 	d.mutex.RLock()
 	defer d.mutex.RUnlock()
 
@@ -41,7 +43,7 @@ func (d *db) Scan(req *schema.ScanRequest) (*schema.Entries, error) {
 	}
 
 	if !req.NoWait {
-		err := d.st.WaitForIndexingUpto(waitUntilTx, nil)
+		err := d.waitForIndexingUpto(ctx, waitUntilTx)
 		if err != nil {
 			return nil, err
 		}
@@ -82,7 +84,13 @@ func (d *db) Scan(req *schema.ScanRequest) (*schema.Entries, error) {
 
 	tx := d.st.NewTxHolder()
 
+	limiter := d.newResultLimiter()
+
 	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
 		key, valRef, err := r.Read()
 		if err == store.ErrNoMoreEntries {
 			break
@@ -100,6 +108,11 @@ func (d *db) Scan(req *schema.ScanRequest) (*schema.Entries, error) {
 			return nil, err
 		}
 
+		err = limiter.add(e)
+		if err != nil {
+			return &schema.Entries{Entries: entries}, err
+		}
+
 		entries = append(entries, e)
 		if i++; i == limit {
 			break
@@ -1,6 +1,7 @@
 package database
 
 import (
+	"context"
 	"testing"
 
 	"github.com/codenotary/immudb/embedded/store"
@@ -18,18 +19,18 @@ func TestStoreScan(t *testing.T) {
 	scanOptions := schema.ScanRequest{
 		Prefix: []byte(`z`),
 	}
-	list, err := db.Scan(&scanOptions)
+	list, err := db.Scan(context.Background(), &scanOptions)
 	require.NoError(t, err)
 	require.Empty(t, list.Entries)
 
 	meta, err := db.Set(&schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte(`abc`), Value: []byte(`item3`)}}})
 	require.NoError(t, err)
 
-	item, err := db.Get(&schema.KeyRequest{Key: []byte(`abc`), SinceTx: meta.Id})
+	item, err := db.Get(context.Background(), &schema.KeyRequest{Key: []byte(`abc`), SinceTx: meta.Id})
 	require.Equal(t, []byte(`abc`), item.Key)
 	require.NoError(t, err)
 
-	_, err = db.Scan(nil)
+	_, err = db.Scan(context.Background(), nil)
 	require.Equal(t, store.ErrIllegalArguments, err)
 
 	scanOptions = schema.ScanRequest{
@@ -39,7 +40,7 @@ func TestStoreScan(t *testing.T) {
 		Desc:    true,
 	}
 
-	_, err = db.Scan(&scanOptions)
+	_, err = db.Scan(context.Background(), &scanOptions)
 	require.Equal(t, ErrMaxKeyScanLimitExceeded, err)
 
 	scanOptions = schema.ScanRequest{
@@ -49,7 +50,7 @@ func TestStoreScan(t *testing.T) {
 		Desc:    true,
 	}
 
-	list, err = db.Scan(&scanOptions)
+	list, err = db.Scan(context.Background(), &scanOptions)
 	require.NoError(t, err)
 	require.Exactly(t, 2, len(list.Entries))
 	require.Equal(t, list.Entries[0].Key, []byte(`abc`))
@@ -64,7 +65,7 @@ func TestStoreScan(t *testing.T) {
 		Desc:    false,
 	}
 
-	list1, err1 := db.Scan(&scanOptions1)
+	list1, err1 := db.Scan(context.Background(), &scanOptions1)
 	require.NoError(t, err1)
 	require.Exactly(t, 3, len(list1.Entries))
 	require.Equal(t, list1.Entries[0].Key, []byte(`aaa`))
@@ -93,7 +94,7 @@ func TestStoreScanPrefix(t *testing.T) {
 		SinceTx: meta.Id,
 	}
 
-	list, err := db.Scan(&scanOptions)
+	list, err := db.Scan(context.Background(), &scanOptions)
 	require.NoError(t, err)
 	require.Exactly(t, 3, len(list.Entries))
 	require.Equal(t, list.Entries[0].Key, []byte(`prefix:suffix1`))
@@ -108,7 +109,7 @@ func TestStoreScanPrefix(t *testing.T) {
 		SinceTx: meta.Id,
 	}
 
-	list, err = db.Scan(&scanOptions)
+	list, err = db.Scan(context.Background(), &scanOptions)
 	require.NoError(t, err)
 	require.Exactly(t, 3, len(list.Entries))
 	require.Equal(t, list.Entries[0].Key, []byte(`prefix:suffix3`))
@@ -134,7 +135,7 @@ func TestStoreScanDesc(t *testing.T) {
 		SinceTx: meta.Id,
 	}
 
-	list, err := db.Scan(&scanOptions)
+	list, err := db.Scan(context.Background(), &scanOptions)
 	require.NoError(t, err)
 	require.Exactly(t, 3, len(list.Entries))
 	require.Equal(t, list.Entries[0].Key, []byte(`key1`))
@@ -149,7 +150,7 @@ func TestStoreScanDesc(t *testing.T) {
 		SinceTx: meta.Id,
 	}
 
-	list, err = db.Scan(&scanOptions)
+	list, err = db.Scan(context.Background(), &scanOptions)
 	require.NoError(t, err)
 	require.Exactly(t, 2, len(list.Entries))
 	require.Equal(t, list.Entries[0].Key, []byte(`key2`))
@@ -163,7 +164,7 @@ func TestStoreScanDesc(t *testing.T) {
 		SinceTx: meta.Id,
 	}
 
-	list, err = db.Scan(&scanOptions)
+	list, err = db.Scan(context.Background(), &scanOptions)
 	require.NoError(t, err)
 	require.Exactly(t, 1, len(list.Entries))
 	require.Equal(t, list.Entries[0].Key, []byte(`key1`))
@@ -176,7 +177,7 @@ func TestStoreScanDesc(t *testing.T) {
 		SinceTx: meta.Id,
 	}
 
-	list, err = db.Scan(&scanOptions)
+	list, err = db.Scan(context.Background(), &scanOptions)
 	require.NoError(t, err)
 	require.Len(t, list.Entries, 3)
 }
@@ -0,0 +1,220 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package database
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/codenotary/immudb/embedded/script"
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/api/schema"
+)
+
+// ExecScript runs a script within a single transaction. The script reads the state the transaction started from
+// along with its own writes, which are committed only when the script succeeds and no conflicting transaction
+// was committed in the meantime
+func (d *db) ExecScript(req *schema.ExecScriptRequest) (*schema.ExecScriptResult, error) {
+	if req == nil {
+		return nil, ErrIllegalArguments
+	}
+
+	s, err := script.Compile(req.Script)
+	if err != nil {
+		return nil, err
+	}
+
+	params, err := scriptParams(req.Params)
+	if err != nil {
+		return nil, err
+	}
+
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	if d.isReplica() {
+		return nil, ErrIsReplica
+	}
+
+	if d.isInMaintenance() {
+		return nil, ErrIsInMaintenance
+	}
+
+	if d.isDiskQuotaExceeded() {
+		return nil, ErrDiskQuotaExceeded
+	}
+
+	kvSchemas, err := d.kvSchemaRegistry()
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := d.st.NewTx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Cancel()
+
+	kv := &scriptKV{
+		d:         d,
+		tx:        tx,
+		txHolder:  d.st.NewTxHolder(),
+		kvSchemas: kvSchemas,
+	}
+
+	ret, err := s.Run(kv, params)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &schema.ExecScriptResult{
+		Result: scriptValue(ret),
+	}
+
+	if kv.writes == 0 {
+		return res, nil
+	}
+
+	var hdr *store.TxHeader
+	if req.NoWait {
+		hdr, err = tx.AsyncCommit()
+	} else {
+		hdr, err = tx.Commit()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	res.TxHeader = schema.TxHeaderToProto(hdr)
+
+	return res, nil
+}
+
+// scriptKV keeps every read and write of a script within its transaction
+type scriptKV struct {
+	d         *db
+	tx        *store.OngoingTx
+	txHolder  *store.Tx
+	kvSchemas *kvSchemaRegistry
+	writes    int
+}
+
+func (kv *scriptKV) Get(key []byte) ([]byte, error) {
+	valRef, err := kv.tx.Get(EncodeKey(key))
+	if errors.Is(err, store.ErrKeyNotFound) {
+		return nil, script.ErrKeyNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// keys deleted by the script itself are still found through the snapshot of the transaction
+	if valRef.KVMetadata() != nil && valRef.KVMetadata().Deleted() {
+		return nil, script.ErrKeyNotFound
+	}
+
+	entry, err := kv.d.getAt(EncodeKey(key), 0, 0, kv.tx, kv.txHolder)
+	if errors.Is(err, store.ErrKeyNotFound) {
+		return nil, script.ErrKeyNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return entry.Value, nil
+}
+
+func (kv *scriptKV) Set(key, value []byte) error {
+	err := kv.kvSchemas.validate(key, value)
+	if err != nil {
+		return err
+	}
+
+	e := EncodeEntrySpec(key, nil, value)
+
+	err = kv.tx.Set(e.Key, e.Metadata, e.Value)
+	if err != nil {
+		return err
+	}
+
+	kv.writes++
+
+	return nil
+}
+
+func (kv *scriptKV) Delete(key []byte) error {
+	err := kv.tx.Delete(EncodeKey(key))
+	if errors.Is(err, store.ErrKeyNotFound) {
+		return script.ErrKeyNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	kv.writes++
+
+	return nil
+}
+
+func scriptParams(params []*schema.NamedParam) (map[string]interface{}, error) {
+	values := make(map[string]interface{}, len(params))
+
+	for _, p := range params {
+		if p == nil || p.Value == nil {
+			return nil, ErrIllegalArguments
+		}
+
+		_, ok := values[p.Name]
+		if ok {
+			return nil, fmt.Errorf("%w: duplicated parameter '%s'", ErrIllegalArguments, p.Name)
+		}
+
+		switch v := p.Value.Value.(type) {
+		case *schema.SQLValue_Null:
+			values[p.Name] = nil
+		case *schema.SQLValue_N:
+			values[p.Name] = v.N
+		case *schema.SQLValue_S:
+			values[p.Name] = v.S
+		case *schema.SQLValue_B:
+			values[p.Name] = v.B
+		case *schema.SQLValue_Bs:
+			values[p.Name] = v.Bs
+		case *schema.SQLValue_Ts:
+			values[p.Name] = v.Ts
+		default:
+			return nil, fmt.Errorf("%w: unsupported type of parameter '%s'", ErrIllegalArguments, p.Name)
+		}
+	}
+
+	return values, nil
+}
+
+func scriptValue(v interface{}) *schema.SQLValue {
+	switch tv := v.(type) {
+	case bool:
+		return &schema.SQLValue{Value: &schema.SQLValue_B{B: tv}}
+	case int64:
+		return &schema.SQLValue{Value: &schema.SQLValue_N{N: tv}}
+	case string:
+		return &schema.SQLValue{Value: &schema.SQLValue_S{S: tv}}
+	case []byte:
+		return &schema.SQLValue{Value: &schema.SQLValue_Bs{Bs: tv}}
+	}
+
+	return &schema.SQLValue{Value: &schema.SQLValue_Null{}}
+}
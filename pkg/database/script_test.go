@@ -0,0 +1,133 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/codenotary/immudb/embedded/script"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/stretchr/testify/require"
+)
+
+const transferScript = `
+	local balance = tonumber(get(params.from))
+	if balance < params.amount then
+		error("insufficient funds")
+	end
+	set(params.from, tostring(balance - params.amount))
+	set(params.to, tostring(tonumber(get(params.to)) + params.amount))
+	return balance - params.amount
+`
+
+func transferParams(from, to string, amount int64) []*schema.NamedParam {
+	return []*schema.NamedParam{
+		{Name: "from", Value: &schema.SQLValue{Value: &schema.SQLValue_S{S: from}}},
+		{Name: "to", Value: &schema.SQLValue{Value: &schema.SQLValue_S{S: to}}},
+		{Name: "amount", Value: &schema.SQLValue{Value: &schema.SQLValue_N{N: amount}}},
+	}
+}
+
+func TestExecScript(t *testing.T) {
+	db, closer := makeDb()
+	defer closer()
+
+	_, err := db.ExecScript(nil)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = db.ExecScript(&schema.ExecScriptRequest{Script: "return ("})
+	require.ErrorIs(t, err, script.ErrSyntax)
+
+	_, err = db.ExecScript(&schema.ExecScriptRequest{
+		Script: "return params.p",
+		Params: []*schema.NamedParam{{Name: "p"}},
+	})
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = db.ExecScript(&schema.ExecScriptRequest{
+		Script: "return params.p",
+		Params: []*schema.NamedParam{
+			{Name: "p", Value: &schema.SQLValue{Value: &schema.SQLValue_N{N: 1}}},
+			{Name: "p", Value: &schema.SQLValue{Value: &schema.SQLValue_N{N: 2}}},
+		},
+	})
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = db.Set(&schema.SetRequest{KVs: []*schema.KeyValue{
+		{Key: []byte("acc1"), Value: []byte("100")},
+		{Key: []byte("acc2"), Value: []byte("0")},
+	}})
+	require.NoError(t, err)
+
+	res, err := db.ExecScript(&schema.ExecScriptRequest{Script: transferScript, Params: transferParams("acc1", "acc2", 60)})
+	require.NoError(t, err)
+	require.NotNil(t, res.TxHeader)
+	require.Equal(t, int32(2), res.TxHeader.Nentries)
+	require.Equal(t, int64(40), res.Result.GetN())
+
+	entry, err := db.Get(context.Background(), &schema.KeyRequest{Key: []byte("acc2"), SinceTx: res.TxHeader.Id})
+	require.NoError(t, err)
+	require.Equal(t, []byte("60"), entry.Value)
+
+	t.Run("nothing should be written when the script fails", func(t *testing.T) {
+		_, err := db.ExecScript(&schema.ExecScriptRequest{Script: transferScript, Params: transferParams("acc1", "acc2", 60)})
+		require.ErrorIs(t, err, script.ErrScriptFailed)
+
+		_, err = db.ExecScript(&schema.ExecScriptRequest{
+			Script: "set('acc1', '0'); return {}",
+		})
+		require.ErrorIs(t, err, script.ErrTypeMismatch)
+
+		entry, err := db.Get(context.Background(), &schema.KeyRequest{Key: []byte("acc1")})
+		require.NoError(t, err)
+		require.Equal(t, []byte("40"), entry.Value)
+	})
+
+	t.Run("no transaction should be committed by read-only scripts", func(t *testing.T) {
+		res, err := db.ExecScript(&schema.ExecScriptRequest{Script: "return get('acc1') .. '/' .. get('acc2')"})
+		require.NoError(t, err)
+		require.Nil(t, res.TxHeader)
+		require.Equal(t, []byte("40/60"), res.Result.GetBs())
+
+		res, err = db.ExecScript(&schema.ExecScriptRequest{Script: "local v = get('acc1')"})
+		require.NoError(t, err)
+		require.NotNil(t, res.Result.GetNull())
+	})
+
+	t.Run("scripts should read their own writes", func(t *testing.T) {
+		res, err := db.ExecScript(&schema.ExecScriptRequest{
+			Script: `
+				set('acc3', '1')
+				local created = exists('acc3')
+				del('acc3')
+				return created and not exists('acc3') and not del('acc3') and get('acc3') == nil
+			`,
+		})
+		require.NoError(t, err)
+		require.True(t, res.Result.GetB())
+	})
+
+	t.Run("references should be resolved", func(t *testing.T) {
+		_, err := db.SetReference(&schema.ReferenceRequest{Key: []byte("ref1"), ReferencedKey: []byte("acc1")})
+		require.NoError(t, err)
+
+		res, err := db.ExecScript(&schema.ExecScriptRequest{Script: "return get('ref1')"})
+		require.NoError(t, err)
+		require.Equal(t, []byte("40"), res.Result.GetBs())
+	})
+}
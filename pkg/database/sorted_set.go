@@ -16,7 +16,9 @@ limitations under the License.
 package database
 
 import (
+	"context"
 	"encoding/binary"
+	"errors"
 	"math"
 
 	"github.com/codenotary/immudb/embedded/store"
@@ -48,6 +50,14 @@ func (d *db) ZAdd(req *schema.ZAddRequest) (*schema.TxHeader, error) {
 		return nil, ErrIsReplica
 	}
 
+	if d.isInMaintenance() {
+		return nil, ErrIsInMaintenance
+	}
+
+	if d.isDiskQuotaExceeded() {
+		return nil, ErrDiskQuotaExceeded
+	}
+
 	lastTxID, _ := d.st.Alh()
 	err := d.st.WaitForIndexingUpto(lastTxID, nil)
 	if err != nil {
@@ -72,6 +82,7 @@ func (d *db) ZAdd(req *schema.ZAddRequest) (*schema.TxHeader, error) {
 	defer tx.Cancel()
 
 	e := EncodeZAdd(req.Set, req.Score, key, req.AtTx)
+	e.Metadata = expirationMetadata(req.Expiration)
 
 	err = tx.Set(e.Key, e.Metadata, e.Value)
 	if err != nil {
@@ -93,7 +104,7 @@ func (d *db) ZAdd(req *schema.ZAddRequest) (*schema.TxHeader, error) {
 }
 
 // ZScan ...
-func (d *db) ZScan(req *schema.ZScanRequest) (*schema.ZEntries, error) {
+func (d *db) ZScan(ctx context.Context, req *schema.ZScanRequest) (*schema.ZEntries, error) {
 	if req == nil || len(req.Set) == 0 {
 		return nil, store.ErrIllegalArguments
 	}
@@ -123,7 +134,7 @@ func (d *db) ZScan(req *schema.ZScanRequest) (*schema.ZEntries, error) {
 	}
 
 	if !req.NoWait {
-		err := d.st.WaitForIndexingUpto(waitUntilTx, nil)
+		err := d.waitForIndexingUpto(ctx, waitUntilTx)
 		if err != nil {
 			return nil, err
 		}
@@ -146,7 +157,8 @@ func (d *db) ZScan(req *schema.ZScanRequest) (*schema.ZEntries, error) {
 		seekKey = make([]byte, len(prefix)+scoreLen)
 		copy(seekKey, prefix)
 		// here we compose the offset if Min score filter is provided only if is not reversed order
-		if req.MinScore != nil && !req.Desc {
+		// negative scores are placed after the positive ones, so they are not skipped when the min score is negative
+		if req.MinScore != nil && !req.Desc && !math.Signbit(req.MinScore.Score) {
 			binary.BigEndian.PutUint64(seekKey[len(prefix):], math.Float64bits(req.MinScore.Score))
 		}
 		// here we compose the offset if Max score filter is provided only if is reversed order
@@ -159,7 +171,20 @@ func (d *db) ZScan(req *schema.ZScanRequest) (*schema.ZEntries, error) {
 				maxScore = req.MaxScore.Score
 			}
 
-			binary.BigEndian.PutUint64(seekKey[len(prefix):], math.Float64bits(maxScore))
+			maxScoreB := math.Float64bits(maxScore)
+
+			// entries scored with the max score are placed after the seek key,
+			// seeking from the next score includes them unless the bound is exclusive
+			if !req.MaxScoreExclusive && maxScoreB < math.MaxUint64 {
+				maxScoreB++
+			}
+
+			// negative scores are placed in reverse order, so lower ones are not skipped
+			if math.Signbit(maxScore) {
+				maxScoreB = math.MaxUint64
+			}
+
+			binary.BigEndian.PutUint64(seekKey[len(prefix):], maxScoreB)
 		}
 	} else {
 		seekKey = make([]byte, len(prefix)+scoreLen+keyLenLen+1+len(req.SeekKey)+txIDLen)
@@ -170,6 +195,8 @@ func (d *db) ZScan(req *schema.ZScanRequest) (*schema.ZEntries, error) {
 		binary.BigEndian.PutUint64(seekKey[len(prefix)+scoreLen+keyLenLen+1+len(req.SeekKey):], req.SeekAtTx)
 	}
 
+	rangeEndB, boundedRange := zScanRangeEnd(req)
+
 	r, err := snap.NewKeyReader(
 		&store.KeyReaderSpec{
 			SeekKey:       seekKey,
@@ -189,6 +216,10 @@ func (d *db) ZScan(req *schema.ZScanRequest) (*schema.ZEntries, error) {
 	tx := d.st.NewTxHolder()
 
 	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
 		zKey, _, err := r.Read()
 		if err == store.ErrNoMoreEntries {
 			break
@@ -202,11 +233,16 @@ func (d *db) ZScan(req *schema.ZScanRequest) (*schema.ZEntries, error) {
 		scoreB := binary.BigEndian.Uint64(zKey[scoreOff:])
 		score := math.Float64frombits(scoreB)
 
+		// no more entries within the score range are found once its end is reached
+		if boundedRange && ((!req.Desc && scoreB > rangeEndB) || (req.Desc && scoreB < rangeEndB)) {
+			break
+		}
+
 		// Guard to ensure that score match the filter range if filter is provided
-		if req.MinScore != nil && score < req.MinScore.Score {
+		if req.MinScore != nil && (score < req.MinScore.Score || (req.MinScoreExclusive && score == req.MinScore.Score)) {
 			continue
 		}
-		if req.MaxScore != nil && score > req.MaxScore.Score {
+		if req.MaxScore != nil && (score > req.MaxScore.Score || (req.MaxScoreExclusive && score == req.MaxScore.Score)) {
 			continue
 		}
 
@@ -217,8 +253,8 @@ func (d *db) ZScan(req *schema.ZScanRequest) (*schema.ZEntries, error) {
 		atTx := binary.BigEndian.Uint64(zKey[keyOff+len(key):])
 
 		e, err := d.getAt(key, atTx, 1, snap, tx)
-		if err == store.ErrKeyNotFound {
-			// ignore deleted ones (referenced key may have been deleted)
+		if errors.Is(err, store.ErrKeyNotFound) {
+			// ignore deleted ones (referenced key may have been deleted or expired)
 			continue
 		}
 		if err != nil {
@@ -246,6 +282,38 @@ func (d *db) ZScan(req *schema.ZScanRequest) (*schema.ZEntries, error) {
 	return list, nil
 }
 
+// zScanRangeEnd returns the encoded score after which no entry within the score range of the request
+// is found, following the scan order. Scores are indexed by their binary representation, so negative
+// scores are placed after the positive ones and in reverse order, thus the end of the range is only known
+// when all the scores within it have the same sign.
+func zScanRangeEnd(req *schema.ZScanRequest) (scoreB uint64, bounded bool) {
+	if !req.Desc {
+		if req.MinScore == nil {
+			return 0, false
+		}
+
+		if math.Signbit(req.MinScore.Score) {
+			return math.Float64bits(req.MinScore.Score), true
+		}
+
+		if req.MaxScore == nil {
+			return 0, false
+		}
+
+		return math.Float64bits(req.MaxScore.Score), true
+	}
+
+	if req.MaxScore != nil && math.Signbit(req.MaxScore.Score) {
+		return math.Float64bits(req.MaxScore.Score), true
+	}
+
+	if req.MinScore == nil || math.Signbit(req.MinScore.Score) {
+		return 0, false
+	}
+
+	return math.Float64bits(req.MinScore.Score), true
+}
+
 //VerifiableZAdd ...
 func (d *db) VerifiableZAdd(req *schema.VerifiableZAddRequest) (*schema.VerifiableTx, error) {
 	if req == nil {
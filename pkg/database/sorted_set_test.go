@@ -16,6 +16,8 @@ limitations under the License.
 package database
 
 import (
+	"context"
+	"fmt"
 	"math"
 	"testing"
 
@@ -82,7 +84,7 @@ func TestStoreIndexExists(t *testing.T) {
 		Limit: MaxKeyScanLimit + 1,
 	}
 
-	_, err = db.ZScan(zscanOpts)
+	_, err = db.ZScan(context.Background(), zscanOpts)
 	require.Equal(t, ErrMaxKeyScanLimitExceeded, err)
 
 	//try to retrieve directly the value or full scan to debug
@@ -91,7 +93,7 @@ func TestStoreIndexExists(t *testing.T) {
 		Set: []byte(`firstIndex`),
 	}
 
-	itemList1, err := db.ZScan(zscanOpts1)
+	itemList1, err := db.ZScan(context.Background(), zscanOpts1)
 	require.NoError(t, err)
 	require.Len(t, itemList1.Entries, 3)
 	require.Equal(t, []byte(`mySecondElementKey`), itemList1.Entries[0].Entry.Key)
@@ -104,7 +106,7 @@ func TestStoreIndexExists(t *testing.T) {
 		Desc:     true,
 	}
 
-	itemList2, err := db.ZScan(zscanOpts2)
+	itemList2, err := db.ZScan(context.Background(), zscanOpts2)
 	require.NoError(t, err)
 	require.Len(t, itemList2.Entries, 3)
 	require.Equal(t, []byte(`myFirstElementKey`), itemList2.Entries[0].Entry.Key)
@@ -182,7 +184,7 @@ func TestStoreIndexEqualKeys(t *testing.T) {
 		SinceTx: reference3.Id,
 	}
 
-	itemList1, err := db.ZScan(zscanOpts1)
+	itemList1, err := db.ZScan(context.Background(), zscanOpts1)
 	require.NoError(t, err)
 	require.Len(t, itemList1.Entries, 3)
 	require.Equal(t, []byte(`SignerId1`), itemList1.Entries[0].Entry.Key)
@@ -248,7 +250,7 @@ func TestStoreIndexEqualKeysEqualScores(t *testing.T) {
 		SinceTx: reference3.Id,
 	}
 
-	itemList1, err := db.ZScan(zscanOpts1)
+	itemList1, err := db.ZScan(context.Background(), zscanOpts1)
 
 	require.NoError(t, err)
 	require.Len(t, itemList1.Entries, 3)
@@ -358,7 +360,7 @@ func TestStore_ZScanPagination(t *testing.T) {
 		SinceTx:  meta.Id,
 	}
 
-	list0, err := db.ZScan(zScanOption0)
+	list0, err := db.ZScan(context.Background(), zScanOption0)
 	require.NoError(t, err)
 	require.Empty(t, list0.Entries)
 
@@ -372,7 +374,7 @@ func TestStore_ZScanPagination(t *testing.T) {
 		SinceTx:  meta.Id,
 	}
 
-	list1, err := db.ZScan(zScanOption1)
+	list1, err := db.ZScan(context.Background(), zScanOption1)
 	require.NoError(t, err)
 	require.Len(t, list1.Entries, 2)
 	require.Equal(t, list1.Entries[0].Entry.Key, []byte(`key3`))
@@ -392,7 +394,7 @@ func TestStore_ZScanPagination(t *testing.T) {
 		SinceTx:   meta.Id,
 	}
 
-	list, err := db.ZScan(zScanOption2)
+	list, err := db.ZScan(context.Background(), zScanOption2)
 	require.NoError(t, err)
 	require.Len(t, list.Entries, 2)
 	require.Equal(t, list.Entries[0].Entry.Key, []byte(`key5`))
@@ -482,7 +484,7 @@ func TestStore_ZScanReversePagination(t *testing.T) {
 		SinceTx:       meta.Id,
 	}
 
-	list1, err := db.ZScan(zScanOption1)
+	list1, err := db.ZScan(context.Background(), zScanOption1)
 	require.NoError(t, err)
 	require.Len(t, list1.Entries, 2)
 	require.Equal(t, list1.Entries[0].Entry.Key, []byte(`key6`))
@@ -501,7 +503,7 @@ func TestStore_ZScanReversePagination(t *testing.T) {
 		SinceTx:       meta.Id,
 	}
 
-	list2, err := db.ZScan(zScanOption2)
+	list2, err := db.ZScan(context.Background(), zScanOption2)
 	require.NoError(t, err)
 	require.Len(t, list2.Entries, 2)
 	require.Equal(t, list2.Entries[0].Entry.Key, []byte(`key5`))
@@ -518,13 +520,125 @@ func TestStore_ZScanReversePagination(t *testing.T) {
 		SinceTx:       meta.Id,
 	}
 
-	list3, err := db.ZScan(zScanOption3)
+	list3, err := db.ZScan(context.Background(), zScanOption3)
 	require.NoError(t, err)
 	require.Len(t, list3.Entries, 2)
 	require.Equal(t, list3.Entries[0].Entry.Key, []byte(`key4`))
 	require.Equal(t, list3.Entries[1].Entry.Key, []byte(`key3`))
 }
 
+func TestStore_ZScanScoreRanges(t *testing.T) {
+	db, closer := makeDb()
+	defer closer()
+
+	setName := []byte(`set1`)
+
+	scores := []float64{1, 2, 3, 4, 5, -1}
+	var meta *schema.TxHeader
+
+	for i, score := range scores {
+		key := []byte(fmt.Sprintf("key%d", i+1))
+
+		_, err := db.Set(&schema.SetRequest{KVs: []*schema.KeyValue{{Key: key, Value: []byte(`val`)}}})
+		require.NoError(t, err)
+
+		meta, err = db.ZAdd(&schema.ZAddRequest{Set: setName, Score: score, Key: key})
+		require.NoError(t, err)
+	}
+
+	zscanKeys := func(req *schema.ZScanRequest) []string {
+		req.Set = setName
+		req.SinceTx = meta.Id
+
+		list, err := db.ZScan(context.Background(), req)
+		require.NoError(t, err)
+
+		keys := make([]string, len(list.Entries))
+		for i, e := range list.Entries {
+			keys[i] = string(e.Key)
+		}
+		return keys
+	}
+
+	t.Run("bounds should be inclusive by default", func(t *testing.T) {
+		keys := zscanKeys(&schema.ZScanRequest{
+			MinScore: &schema.Score{Score: 2},
+			MaxScore: &schema.Score{Score: 4},
+		})
+		require.Equal(t, []string{"key2", "key3", "key4"}, keys)
+
+		keys = zscanKeys(&schema.ZScanRequest{
+			MinScore: &schema.Score{Score: 2},
+			MaxScore: &schema.Score{Score: 4},
+			Desc:     true,
+		})
+		require.Equal(t, []string{"key4", "key3", "key2"}, keys)
+	})
+
+	t.Run("bounds may be exclusive", func(t *testing.T) {
+		keys := zscanKeys(&schema.ZScanRequest{
+			MinScore:          &schema.Score{Score: 2},
+			MinScoreExclusive: true,
+			MaxScore:          &schema.Score{Score: 4},
+			MaxScoreExclusive: true,
+		})
+		require.Equal(t, []string{"key3"}, keys)
+
+		keys = zscanKeys(&schema.ZScanRequest{
+			MinScore:          &schema.Score{Score: 2},
+			MaxScore:          &schema.Score{Score: 4},
+			MaxScoreExclusive: true,
+			Desc:              true,
+		})
+		require.Equal(t, []string{"key3", "key2"}, keys)
+	})
+
+	t.Run("top entries since a score should be paginated by score", func(t *testing.T) {
+		keys := zscanKeys(&schema.ZScanRequest{
+			MaxScore:          &schema.Score{Score: 5},
+			MaxScoreExclusive: true,
+			Desc:              true,
+			Limit:             2,
+		})
+		require.Equal(t, []string{"key4", "key3"}, keys)
+
+		keys = zscanKeys(&schema.ZScanRequest{
+			MaxScore:          &schema.Score{Score: 3},
+			MaxScoreExclusive: true,
+			Desc:              true,
+			Limit:             2,
+		})
+		require.Equal(t, []string{"key2", "key1"}, keys)
+
+		keys = zscanKeys(&schema.ZScanRequest{
+			MinScore:          &schema.Score{Score: 3},
+			MinScoreExclusive: true,
+			Limit:             2,
+		})
+		require.Equal(t, []string{"key4", "key5"}, keys)
+	})
+
+	t.Run("negative scores should be found within the range", func(t *testing.T) {
+		keys := zscanKeys(&schema.ZScanRequest{
+			MaxScore: &schema.Score{Score: 1},
+		})
+		require.Equal(t, []string{"key1", "key6"}, keys)
+
+		keys = zscanKeys(&schema.ZScanRequest{
+			MinScore: &schema.Score{Score: -2},
+			MaxScore: &schema.Score{Score: 0},
+		})
+		require.Equal(t, []string{"key6"}, keys)
+
+		keys = zscanKeys(&schema.ZScanRequest{
+			MinScore: &schema.Score{Score: -2},
+			MaxScore: &schema.Score{Score: -1},
+			Desc:     true,
+		})
+		require.Equal(t, []string{"key6"}, keys)
+	})
+}
+
 func TestStore_ZScanInvalidSet(t *testing.T) {
 	db, closer := makeDb()
 	defer closer()
@@ -532,7 +646,7 @@ func TestStore_ZScanInvalidSet(t *testing.T) {
 	opt := &schema.ZScanRequest{
 		Set: nil,
 	}
-	_, err := db.ZScan(opt)
+	_, err := db.ZScan(context.Background(), opt)
 	require.Equal(t, store.ErrIllegalArguments, err)
 }
 
@@ -583,7 +697,7 @@ func TestStore_ZScanOnEqualKeysWithSameScoreAreReturnedOrderedByTS(t *testing.T)
 		SinceTx: meta.Id,
 	}
 
-	list, err := db.ZScan(ZScanRequest)
+	list, err := db.ZScan(context.Background(), ZScanRequest)
 	require.NoError(t, err)
 	// same key, sorted by internal timestamp
 	require.Exactly(t, []byte(`val1-A`), list.Entries[0].Entry.Value)
@@ -646,7 +760,7 @@ func TestStoreZScanOnZAddIndexReference(t *testing.T) {
 		SinceTx: reference3.Id,
 	}
 
-	itemList1, err := db.ZScan(zscanOpts1)
+	itemList1, err := db.ZScan(context.Background(), zscanOpts1)
 	require.NoError(t, err)
 	require.Len(t, itemList1.Entries, 3)
 	require.Equal(t, []byte(`SignerId1`), itemList1.Entries[0].Entry.Key)
@@ -711,7 +825,7 @@ func TestStoreVerifiableZAdd(t *testing.T) {
 		SinceTx: vtx.Tx.Header.Id,
 	}
 
-	itemList1, err := db.ZScan(zscanReq)
+	itemList1, err := db.ZScan(context.Background(), zscanReq)
 	require.NoError(t, err)
 	require.Len(t, itemList1.Entries, 1)
 	require.Equal(t, req.Key, itemList1.Entries[0].Entry.Key)
@@ -17,6 +17,8 @@ package database
 
 import (
 	"bytes"
+	"context"
+	"crypto/sha256"
 	"errors"
 	"fmt"
 	"strings"
@@ -345,6 +347,8 @@ func (d *db) SQLExecPrepared(stmts []sql.SQLStmt, namedParams []*schema.NamedPar
 		return nil, nil, ErrIllegalArguments
 	}
 
+	defer d.observeSQLStatement("exec", time.Now(), &err)
+
 	d.mutex.RLock()
 	defer d.mutex.RUnlock()
 
@@ -352,6 +356,14 @@ func (d *db) SQLExecPrepared(stmts []sql.SQLStmt, namedParams []*schema.NamedPar
 		return nil, nil, ErrIsReplica
 	}
 
+	if d.isInMaintenance() {
+		return nil, nil, ErrIsInMaintenance
+	}
+
+	if d.isDiskQuotaExceeded() {
+		return nil, nil, ErrDiskQuotaExceeded
+	}
+
 	params := make(map[string]interface{})
 
 	for _, p := range namedParams {
@@ -361,7 +373,61 @@ func (d *db) SQLExecPrepared(stmts []sql.SQLStmt, namedParams []*schema.NamedPar
 	return d.sqlEngine.ExecPreparedStmts(stmts, params, tx)
 }
 
-func (d *db) SQLQuery(req *schema.SQLQueryRequest, tx *sql.SQLTx) (*schema.SQLQueryResult, error) {
+// SetInSQLTx writes the entries within an ongoing SQL transaction, they are committed along with it
+func (d *db) SetInSQLTx(req *schema.SetRequest, tx *sql.SQLTx) error {
+	if req == nil || tx == nil {
+		return ErrIllegalArguments
+	}
+
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	if d.isReplica() {
+		return ErrIsReplica
+	}
+
+	if d.isInMaintenance() {
+		return ErrIsInMaintenance
+	}
+
+	kvSchemas, err := d.kvSchemaRegistry()
+	if err != nil {
+		return err
+	}
+
+	err = kvSchemas.validateKVs(req.KVs)
+	if err != nil {
+		return err
+	}
+
+	keys := make(map[[sha256.Size]byte]struct{}, len(req.KVs))
+
+	for _, kv := range req.KVs {
+		if len(kv.Key) == 0 {
+			return ErrIllegalArguments
+		}
+
+		kid := sha256.Sum256(kv.Key)
+		_, ok := keys[kid]
+		if ok {
+			return schema.ErrDuplicatedKeysNotSupported
+		}
+		keys[kid] = struct{}{}
+	}
+
+	for _, kv := range req.KVs {
+		e := EncodeEntrySpec(kv.Key, schema.KVMetadataFromProto(kv.Metadata), kv.Value)
+
+		err = tx.Set(e.Key, e.Metadata, e.Value)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (d *db) SQLQuery(ctx context.Context, req *schema.SQLQueryRequest, tx *sql.SQLTx) (*schema.SQLQueryResult, error) {
 	if req == nil {
 		return nil, ErrIllegalArguments
 	}
@@ -376,11 +442,13 @@ func (d *db) SQLQuery(req *schema.SQLQueryRequest, tx *sql.SQLTx) (*schema.SQLQu
 		return nil, sql.ErrExpectingDQLStmt
 	}
 
-	return d.SQLQueryPrepared(stmt, req.Params, tx)
+	return d.SQLQueryPrepared(ctx, stmt, req.Params, tx)
 }
 
-func (d *db) SQLQueryPrepared(stmt *sql.SelectStmt, namedParams []*schema.NamedParam, tx *sql.SQLTx) (*schema.SQLQueryResult, error) {
-	r, err := d.SQLQueryRowReader(stmt, tx)
+func (d *db) SQLQueryPrepared(ctx context.Context, stmt *sql.SelectStmt, namedParams []*schema.NamedParam, tx *sql.SQLTx) (res *schema.SQLQueryResult, err error) {
+	defer d.observeSQLStatement("query", time.Now(), &err)
+
+	r, err := d.SQLQueryRowReader(ctx, stmt, tx)
 	if err != nil {
 		return nil, err
 	}
@@ -412,7 +480,9 @@ func (d *db) SQLQueryPrepared(stmt *sql.SelectStmt, namedParams []*schema.NamedP
 		cols[i] = &schema.Column{Name: des.Selector(), Type: des.Type}
 	}
 
-	res := &schema.SQLQueryResult{Columns: cols}
+	res = &schema.SQLQueryResult{Columns: cols}
+
+	limiter := d.newResultLimiter()
 
 	for l := 0; ; l++ {
 		if l == MaxKeyScanLimit {
@@ -445,13 +515,18 @@ func (d *db) SQLQueryPrepared(stmt *sql.SelectStmt, namedParams []*schema.NamedP
 			}
 		}
 
+		err = limiter.add(rrow)
+		if err != nil {
+			return res, err
+		}
+
 		res.Rows = append(res.Rows, rrow)
 	}
 
 	return res, nil
 }
 
-func (d *db) SQLQueryRowReader(stmt *sql.SelectStmt, tx *sql.SQLTx) (sql.RowReader, error) {
+func (d *db) SQLQueryRowReader(ctx context.Context, stmt *sql.SelectStmt, tx *sql.SQLTx) (sql.RowReader, error) {
 	if stmt == nil {
 		return nil, ErrIllegalArguments
 	}
@@ -470,7 +545,7 @@ func (d *db) SQLQueryRowReader(stmt *sql.SelectStmt, tx *sql.SQLTx) (sql.RowRead
 		}
 	}
 
-	return d.sqlEngine.QueryPreparedStmt(stmt, nil, tx)
+	return d.sqlEngine.QueryPreparedStmtContext(ctx, stmt, nil, tx)
 }
 
 func (d *db) InferParameters(sql string, tx *sql.SQLTx) (map[string]sql.SQLValueType, error) {
@@ -526,3 +601,13 @@ func typedValueToRowValue(tv sql.TypedValue) *schema.SQLValue {
 	}
 	return nil
 }
+
+// observeSQLStatement records the duration of a statement of the given kind started at startedAt,
+// err points to the error it completed with
+func (d *db) observeSQLStatement(kind string, startedAt time.Time, err *error) {
+	metricsSQLStatementDuration.WithLabelValues(d.name, kind).Observe(time.Since(startedAt).Seconds())
+
+	if *err != nil {
+		metricsSQLStatementErrors.WithLabelValues(d.name, kind).Inc()
+	}
+}
@@ -16,6 +16,7 @@ limitations under the License.
 package database
 
 import (
+	"context"
 	"testing"
 
 	"github.com/codenotary/immudb/embedded/sql"
@@ -71,20 +72,20 @@ func TestSQLExecAndQuery(t *testing.T) {
 	params := make([]*schema.NamedParam, 1)
 	params[0] = &schema.NamedParam{Name: "active", Value: &schema.SQLValue{Value: &schema.SQLValue_B{B: true}}}
 
-	_, err = db.SQLQueryPrepared(nil, nil, nil)
+	_, err = db.SQLQueryPrepared(context.Background(), nil, nil, nil)
 	require.Equal(t, ErrIllegalArguments, err)
 
-	_, err = db.SQLQuery(nil, nil)
+	_, err = db.SQLQuery(context.Background(), nil, nil)
 	require.Equal(t, ErrIllegalArguments, err)
 
-	_, err = db.SQLQuery(&schema.SQLQueryRequest{Sql: "invalid sql statement"}, nil)
+	_, err = db.SQLQuery(context.Background(), &schema.SQLQueryRequest{Sql: "invalid sql statement"}, nil)
 	require.Error(t, err)
 
-	_, err = db.SQLQuery(&schema.SQLQueryRequest{Sql: "CREATE INDEX ON table1(title)"}, nil)
+	_, err = db.SQLQuery(context.Background(), &schema.SQLQueryRequest{Sql: "CREATE INDEX ON table1(title)"}, nil)
 	require.Equal(t, sql.ErrExpectingDQLStmt, err)
 
 	q := "SELECT t.id, t.id as id2, title, active, payload FROM table1 t WHERE id <= 3 AND active != @active"
-	res, err = db.SQLQuery(&schema.SQLQueryRequest{Sql: q, Params: params}, nil)
+	res, err = db.SQLQuery(context.Background(), &schema.SQLQueryRequest{Sql: q, Params: params}, nil)
 	require.NoError(t, err)
 	require.Len(t, res.Rows, 2)
 
@@ -172,3 +173,49 @@ func TestSQLExecAndQuery(t *testing.T) {
 	require.Equal(t, store.ErrKeyNotFound, err)
 
 }
+
+func TestSetInSQLTx(t *testing.T) {
+	db, closer := makeDb()
+	defer closer()
+
+	_, _, err := db.SQLExec(&schema.SQLExecRequest{Sql: "CREATE TABLE table1(id INTEGER, PRIMARY KEY id)"}, nil)
+	require.NoError(t, err)
+
+	err = db.SetInSQLTx(&schema.SetRequest{}, nil)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	tx, _, err := db.SQLExec(&schema.SQLExecRequest{Sql: "BEGIN TRANSACTION; INSERT INTO table1(id) VALUES (1);"}, nil)
+	require.NoError(t, err)
+
+	err = db.SetInSQLTx(&schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte("key1"), Value: []byte("value1")}, {Key: []byte("key1")}}}, tx)
+	require.ErrorIs(t, err, schema.ErrDuplicatedKeysNotSupported)
+
+	err = db.SetInSQLTx(&schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte("key1"), Value: []byte("value1")}}}, tx)
+	require.NoError(t, err)
+
+	_, ctxs, err := db.SQLExec(&schema.SQLExecRequest{Sql: "COMMIT;"}, tx)
+	require.NoError(t, err)
+	require.Len(t, ctxs, 1)
+
+	entry, err := db.Get(context.Background(), &schema.KeyRequest{Key: []byte("key1")})
+	require.NoError(t, err)
+	require.Equal(t, []byte("value1"), entry.Value)
+	require.Equal(t, ctxs[0].TxHeader().ID, entry.Tx)
+
+	t.Run("entries should be discarded along with a cancelled transaction", func(t *testing.T) {
+		tx, _, err := db.SQLExec(&schema.SQLExecRequest{Sql: "BEGIN TRANSACTION;"}, nil)
+		require.NoError(t, err)
+
+		err = db.SetInSQLTx(&schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte("key2"), Value: []byte("value2")}}}, tx)
+		require.NoError(t, err)
+
+		err = tx.Cancel()
+		require.NoError(t, err)
+
+		_, err = db.Get(context.Background(), &schema.KeyRequest{Key: []byte("key2")})
+		require.ErrorIs(t, err, store.ErrKeyNotFound)
+
+		err = db.SetInSQLTx(&schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte("key2"), Value: []byte("value2")}}}, tx)
+		require.ErrorIs(t, err, sql.ErrAlreadyClosed)
+	})
+}
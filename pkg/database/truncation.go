@@ -0,0 +1,173 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/codenotary/immudb/embedded/store"
+)
+
+// sqlCatalogPrefix prefixes the keys of the SQL catalog, which is read back every time the database is opened
+const sqlCatalogPrefix = "CTL."
+
+// Truncate discards the values of the txs committed more than retention ago, the values of the last tx are always kept.
+// Txs are kept, so they can still be proven, but reading a discarded value fails with store.ErrValueTruncated.
+// The SQL catalog is committed once again when any of its entries would be discarded.
+// It returns the id of the first tx whose values are kept, 0 when there was nothing to discard
+func (d *db) Truncate(ctx context.Context, retention time.Duration) (uint64, error) {
+	if retention <= 0 {
+		return 0, ErrIllegalArguments
+	}
+
+	if d.isReplica() {
+		return 0, ErrIsReplica
+	}
+
+	txID, err := d.st.FirstTxSince(time.Now().Add(-retention))
+	if errors.Is(err, store.ErrTxNotFound) {
+		txID = d.st.TxCount()
+	} else if err != nil {
+		return 0, err
+	}
+
+	if txID <= 1 {
+		return 0, nil
+	}
+
+	err = d.truncateUptoTx(ctx, txID)
+	if err != nil {
+		return 0, err
+	}
+
+	return txID, nil
+}
+
+func (d *db) truncateUptoTx(ctx context.Context, txID uint64) error {
+	err := d.preserveSQLCatalog(ctx, txID)
+	if err != nil {
+		return err
+	}
+
+	d.Logger.Infof("Truncating values of database '%s' committed before tx %d...", d.name, txID)
+
+	return d.st.TruncateUptoTx(txID)
+}
+
+// preserveSQLCatalog commits once again the entries of the SQL catalog committed before txID.
+// Entries modified in the meantime are not overwritten, the commit fails instead
+func (d *db) preserveSQLCatalog(ctx context.Context, txID uint64) error {
+	currTxID, _ := d.st.Alh()
+
+	err := d.waitForIndexingUpto(ctx, currTxID)
+	if err != nil {
+		return err
+	}
+
+	snap, err := d.st.SnapshotSince(currTxID)
+	if err != nil {
+		return err
+	}
+	defer snap.Close()
+
+	r, err := snap.NewKeyReader(&store.KeyReaderSpec{
+		Prefix: append([]byte{SQLPrefix}, sqlCatalogPrefix...),
+		Filter: store.IgnoreDeleted,
+	})
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	var tx *store.OngoingTx
+	var entries int
+
+	defer func() {
+		if tx != nil {
+			tx.Cancel()
+		}
+	}()
+
+	for {
+		key, valRef, err := r.Read()
+		if err == store.ErrNoMoreEntries {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		if valRef.Tx() >= txID {
+			continue
+		}
+
+		val, err := valRef.Resolve()
+		if err != nil {
+			return err
+		}
+
+		if tx == nil {
+			tx, err = d.st.NewWriteOnlyTx()
+			if err != nil {
+				return err
+			}
+
+			entries = 0
+		}
+
+		err = tx.Set(key, valRef.KVMetadata(), val)
+		if err != nil {
+			return err
+		}
+
+		err = tx.AddPrecondition(&store.PreconditionKeyNotModifiedAfterTx{Key: key, TxID: snap.Ts()})
+		if err != nil {
+			return err
+		}
+
+		entries++
+
+		if entries == d.st.MaxTxEntries() {
+			_, err = tx.Commit()
+			tx = nil
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if tx == nil {
+		return nil
+	}
+
+	_, err = tx.Commit()
+	tx = nil
+
+	return err
+}
+
+// TruncationProgress returns the percentage of the txs already read by an ongoing truncation
+func (d *db) TruncationProgress() float32 {
+	return d.st.TruncationProgress()
+}
+
+// CompactValueLogs reclaims the space taken by the values discarded by Truncate
+func (d *db) CompactValueLogs() error {
+	return d.st.CompactValueLogs()
+}
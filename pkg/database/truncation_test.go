@@ -0,0 +1,111 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package database
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/logger"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTruncate(t *testing.T) {
+	options := DefaultOption().WithDBRootPath("data_truncate").WithCorruptionChecker(false)
+	defer os.RemoveAll("data_truncate")
+
+	db, err := NewDB("db", options, logger.NewSimpleLogger("immudb ", os.Stderr))
+	require.NoError(t, err)
+
+	truncateUptoTx := db.(interface {
+		truncateUptoTx(ctx context.Context, txID uint64) error
+	}).truncateUptoTx
+
+	_, _, err = db.SQLExec(&schema.SQLExecRequest{Sql: `
+		CREATE TABLE table1(id INTEGER, title VARCHAR, PRIMARY KEY id);
+		INSERT INTO table1(id, title) VALUES (1, 'title1');
+	`}, nil)
+	require.NoError(t, err)
+
+	_, err = db.Set(&schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte("key1"), Value: []byte("value1")}}})
+	require.NoError(t, err)
+
+	hdr, err := db.Set(&schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte("key2"), Value: []byte("value2")}}})
+	require.NoError(t, err)
+
+	_, err = db.Truncate(context.Background(), 0)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	// all txs were committed within the retention period
+	txID, err := db.Truncate(context.Background(), time.Hour)
+	require.NoError(t, err)
+	require.Zero(t, txID)
+
+	err = truncateUptoTx(context.Background(), hdr.Id)
+	require.NoError(t, err)
+	require.Equal(t, float32(100), db.TruncationProgress())
+
+	_, err = db.Get(context.Background(), &schema.KeyRequest{Key: []byte("key1")})
+	require.ErrorIs(t, err, store.ErrValueTruncated)
+
+	entry, err := db.Get(context.Background(), &schema.KeyRequest{Key: []byte("key2")})
+	require.NoError(t, err)
+	require.Equal(t, []byte("value2"), entry.Value)
+
+	err = db.CompactValueLogs()
+	require.NoError(t, err)
+
+	err = db.Close()
+	require.NoError(t, err)
+
+	db, err = OpenDB("db", options, logger.NewSimpleLogger("immudb ", os.Stderr))
+	require.NoError(t, err)
+	defer db.Close()
+
+	// the SQL engine is loaded in background, which requires the SQL catalog to be kept
+	require.Eventually(t, func() bool {
+		_, err := db.SQLQuery(context.Background(), &schema.SQLQueryRequest{Sql: "SELECT title FROM table1 WHERE id = 3"}, nil)
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// rows committed before the truncation were discarded
+	_, _, err = db.SQLExec(&schema.SQLExecRequest{Sql: "INSERT INTO table1(id, title) VALUES (2, 'title2')"}, nil)
+	require.NoError(t, err)
+
+	res, err := db.SQLQuery(context.Background(), &schema.SQLQueryRequest{Sql: "SELECT title FROM table1 WHERE id = 2"}, nil)
+	require.NoError(t, err)
+	require.Len(t, res.Rows, 1)
+
+	_, err = db.SQLQuery(context.Background(), &schema.SQLQueryRequest{Sql: "SELECT title FROM table1 WHERE id = 1"}, nil)
+	require.ErrorIs(t, err, store.ErrValueTruncated)
+}
+
+func TestTruncateReplica(t *testing.T) {
+	options := DefaultOption().WithDBRootPath("data_truncate_replica").AsReplica(true)
+	defer os.RemoveAll("data_truncate_replica")
+
+	db, err := NewDB("db", options, logger.NewSimpleLogger("immudb ", os.Stderr))
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Truncate(context.Background(), time.Hour)
+	require.ErrorIs(t, err, ErrIsReplica)
+}
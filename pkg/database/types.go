@@ -22,6 +22,7 @@ import "sync"
 type DatabaseList interface {
 	Append(database DB)
 	GetByIndex(index int64) DB
+	Replace(index int64, database DB)
 	GetByName(string) (DB, error)
 	GetId(dbname string) int64
 	Length() int
@@ -56,6 +57,14 @@ func (d *databaseList) GetByIndex(index int64) DB {
 	return d.databases[index]
 }
 
+// Replace swaps the database at the given index, the new database must have the same name
+func (d *databaseList) Replace(index int64, database DB) {
+	d.Lock()
+	defer d.Unlock()
+
+	d.databases[index] = database
+}
+
 func (d *databaseList) GetByName(dbname string) (DB, error) {
 	d.RLock()
 	defer d.RUnlock()
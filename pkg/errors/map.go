@@ -36,6 +36,16 @@ func mapGRPcErrorCode(code Code) codes.Code {
 		return codes.Unimplemented
 	case CodInvalidDatabaseName:
 		return codes.NotFound
+	case CodNoDataFound, CodTxNotFound:
+		return codes.NotFound
+	case CodIntegrityConstraintViolation:
+		return codes.FailedPrecondition
+	case CodUniqueViolation:
+		return codes.AlreadyExists
+	case CodDataCorrupted, CodIndexCorrupted:
+		return codes.DataLoss
+	case CodDiskFull, CodProgramLimitExceeded:
+		return codes.ResourceExhausted
 	default:
 		return codes.Unknown
 	}
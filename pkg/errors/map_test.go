@@ -35,6 +35,20 @@ func Test_Map(t *testing.T) {
 	require.Equal(t, codes.Internal, st)
 	st = mapGRPcErrorCode(CodUndefinedFunction)
 	require.Equal(t, codes.Unimplemented, st)
+	st = mapGRPcErrorCode(CodNoDataFound)
+	require.Equal(t, codes.NotFound, st)
+	st = mapGRPcErrorCode(CodTxNotFound)
+	require.Equal(t, codes.NotFound, st)
+	st = mapGRPcErrorCode(CodIntegrityConstraintViolation)
+	require.Equal(t, codes.FailedPrecondition, st)
+	st = mapGRPcErrorCode(CodUniqueViolation)
+	require.Equal(t, codes.AlreadyExists, st)
+	st = mapGRPcErrorCode(CodDataCorrupted)
+	require.Equal(t, codes.DataLoss, st)
+	st = mapGRPcErrorCode(CodIndexCorrupted)
+	require.Equal(t, codes.DataLoss, st)
+	st = mapGRPcErrorCode(CodDiskFull)
+	require.Equal(t, codes.ResourceExhausted, st)
 	st = mapGRPcErrorCode(Code("Unknown"))
 	require.Equal(t, codes.Unknown, st)
 }
@@ -32,6 +32,14 @@ const (
 	CodSqlserverRejectedEstablishmentOfSqlSession    Code = "08001"
 	CodInvalidTransactionInitiation                  Code = "0B000"
 	CodInFailedSqlTransaction                        Code = "25P02"
+	CodNoDataFound                                   Code = "02000"
+	CodTxNotFound                                    Code = "02I01"
+	CodIntegrityConstraintViolation                  Code = "23000"
+	CodUniqueViolation                               Code = "23505"
+	CodDataCorrupted                                 Code = "XX001"
+	CodIndexCorrupted                                Code = "XX002"
+	CodDiskFull                                      Code = "53100"
+	CodProgramLimitExceeded                          Code = "54000"
 )
 
 var (
@@ -0,0 +1,109 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package integration
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	ic "github.com/codenotary/immudb/pkg/client"
+	"github.com/codenotary/immudb/pkg/client/tokenservice"
+	"github.com/codenotary/immudb/pkg/server"
+	"github.com/codenotary/immudb/pkg/server/servertest"
+	"github.com/codenotary/immudb/pkg/signer"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestImmuClient_ExportRootAndVerifyConsistency(t *testing.T) {
+	options := server.DefaultOptions().WithAuth(true).WithSigningKey("./../../test/signer/ec1.key")
+	bs := servertest.NewBufconnServer(options)
+
+	defer os.RemoveAll(options.Dir)
+	defer os.Remove(".state-")
+
+	err := bs.Start()
+	require.NoError(t, err)
+	defer bs.Stop()
+
+	opts := ic.DefaultOptions().WithDialOptions([]grpc.DialOption{grpc.WithContextDialer(bs.Dialer), grpc.WithInsecure()})
+	client, err := ic.NewImmuClient(opts.WithServerSigningPubKey("./../../test/signer/ec1.pub"))
+	require.NoError(t, err)
+	defer client.Disconnect()
+	client.WithTokenService(tokenservice.NewInmemoryTokenService())
+
+	lr, err := client.Login(context.TODO(), []byte(`immudb`), []byte(`immudb`))
+	require.NoError(t, err)
+	ctx := metadata.NewOutgoingContext(context.Background(), metadata.Pairs("authorization", lr.Token))
+
+	_, err = client.Set(ctx, []byte("key1"), []byte("value1"))
+	require.NoError(t, err)
+
+	exportedRoot, err := client.ExportRoot(ctx)
+	require.NoError(t, err)
+
+	pubKey, err := signer.ParsePublicKeyFile("./../../test/signer/ec1.pub")
+	require.NoError(t, err)
+
+	root, err := schema.VerifyExportedRoot(exportedRoot, pubKey)
+	require.NoError(t, err)
+	require.Equal(t, "defaultdb", root.Db)
+
+	// consistent with the current state when nothing was committed since the export
+	state, err := client.VerifyRootConsistency(ctx, exportedRoot)
+	require.NoError(t, err)
+	require.Equal(t, root.TxId, state.TxId)
+
+	for i := 0; i < 5; i++ {
+		_, err = client.Set(ctx, []byte("key2"), []byte("value2"))
+		require.NoError(t, err)
+	}
+
+	state, err = client.VerifyRootConsistency(ctx, exportedRoot)
+	require.NoError(t, err)
+	require.Equal(t, root.TxId+5, state.TxId)
+
+	_, err = client.VerifyRootConsistency(ctx, []byte{0})
+	require.ErrorIs(t, err, schema.ErrInvalidExportedRoot)
+
+	tampered := make([]byte, len(exportedRoot))
+	copy(tampered, exportedRoot)
+	tampered[1+4+len(root.Db)+8] ^= 1
+
+	_, err = client.VerifyRootConsistency(ctx, tampered)
+	require.ErrorIs(t, err, schema.ErrInvalidRootSignature)
+
+	// a properly signed root not matching the history of the database does not verify
+	sig, err := signer.NewSigner("./../../test/signer/ec1.key")
+	require.NoError(t, err)
+
+	root.TxHash[0] ^= 1
+
+	signature, publicKey, err := sig.Sign(root.ToBytes())
+	require.NoError(t, err)
+	root.Signature = &schema.Signature{Signature: signature, PublicKey: publicKey}
+
+	forgedRoot, err := root.ExportRoot()
+	require.NoError(t, err)
+
+	_, err = client.VerifyRootConsistency(ctx, forgedRoot)
+	require.ErrorIs(t, err, store.ErrCorruptedData)
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package integration
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/api/schemav2"
+	"github.com/codenotary/immudb/pkg/server"
+	"github.com/codenotary/immudb/pkg/server/servertest"
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestAPIv2(t *testing.T) {
+	options := server.DefaultOptions().WithAuth(true)
+	bs := servertest.NewBufconnServer(options)
+
+	defer os.RemoveAll(options.Dir)
+
+	err := bs.Start()
+	require.NoError(t, err)
+	defer bs.Stop()
+
+	conn, err := grpc.DialContext(context.Background(), "", grpc.WithContextDialer(bs.Dialer), grpc.WithInsecure())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	v1 := schema.NewImmuServiceClient(conn)
+	v2 := schemav2.NewImmuServiceClient(conn)
+
+	lr, err := v1.Login(context.Background(), &schema.LoginRequest{User: []byte(`immudb`), Password: []byte(`immudb`)})
+	require.NoError(t, err)
+	ctx := metadata.NewOutgoingContext(context.Background(), metadata.Pairs("authorization", lr.Token))
+
+	created, err := v2.CreateDatabase(ctx, &schemav2.CreateDatabaseRequest{
+		Name:     "db1",
+		Settings: &schema.DatabaseSettingsV2{MaxKeyLen: &schema.ConditionalUint32{Value: 32}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "db1", created.Name)
+	require.Equal(t, uint32(32), created.Settings.MaxKeyLen.Value)
+
+	_, err = v2.CreateDatabase(ctx, &schemav2.CreateDatabaseRequest{Name: "db1"})
+	require.Error(t, err)
+
+	updated, err := v2.UpdateDatabase(ctx, &schemav2.UpdateDatabaseRequest{
+		Database: "db1",
+		Settings: &schema.DatabaseSettingsV2{ExcludeCommitTime: &schema.ConditionalBool{Value: true}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "db1", updated.Database)
+	require.True(t, updated.Settings.ExcludeCommitTime.Value)
+	require.Equal(t, uint32(32), updated.Settings.MaxKeyLen.Value)
+
+	ur, err := v1.UseDatabase(ctx, &schema.Database{DatabaseName: "db1"})
+	require.NoError(t, err)
+	ctx = metadata.NewOutgoingContext(context.Background(), metadata.Pairs("authorization", ur.Token))
+
+	settings, err := v2.GetDatabaseSettings(ctx, &schemav2.DatabaseSettingsRequest{})
+	require.NoError(t, err)
+	require.Equal(t, "db1", settings.Database)
+	require.True(t, settings.Settings.ExcludeCommitTime.Value)
+
+	t.Run("deprecated methods should announce their replacement", func(t *testing.T) {
+		var header metadata.MD
+
+		_, err := v1.GetDatabaseSettingsV2(ctx, &empty.Empty{}, grpc.Header(&header))
+		require.NoError(t, err)
+		require.Equal(t, []string{"/immudb.v2.ImmuService/GetDatabaseSettings"}, header.Get(server.DEPRECATED_HEADER))
+
+		header = nil
+
+		_, err = v1.DatabaseList(ctx, &empty.Empty{}, grpc.Header(&header))
+		require.NoError(t, err)
+		require.Empty(t, header.Get(server.DEPRECATED_HEADER))
+	})
+}
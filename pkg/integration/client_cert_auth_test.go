@@ -0,0 +1,239 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package integration
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/auth"
+	ic "github.com/codenotary/immudb/pkg/client"
+	"github.com/codenotary/immudb/pkg/server"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pool *x509.CertPool
+}
+
+func newTestCA(t *testing.T) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "immudb test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+
+	return &testCA{cert: cert, key: key, pool: pool}
+}
+
+func (ca *testCA) issue(t *testing.T, template *x509.Certificate) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template.SerialNumber = big.NewInt(time.Now().UnixNano())
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(time.Hour)
+	template.KeyUsage = x509.KeyUsageDigitalSignature
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func (ca *testCA) clientCert(t *testing.T, commonName string, uris ...string) tls.Certificate {
+	template := &x509.Certificate{
+		Subject:     pkix.Name{CommonName: commonName},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+
+	for _, u := range uris {
+		uri, err := url.Parse(u)
+		require.NoError(t, err)
+		template.URIs = append(template.URIs, uri)
+	}
+
+	return ca.issue(t, template)
+}
+
+func TestClientCertAuth(t *testing.T) {
+	dir, err := ioutil.TempDir("", "client_cert_auth")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	ca := newTestCA(t)
+
+	serverCert := ca.issue(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "localhost"},
+		DNSNames:    []string{"localhost"},
+		IPAddresses: []net.IP{net.ParseIP("127.0.0.1")},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	})
+
+	serverOptions := server.DefaultOptions().
+		WithDir(filepath.Join(dir, "data")).
+		WithAddress("127.0.0.1").
+		WithPort(0).
+		WithMetricsServer(false).
+		WithWebServer(false).
+		WithPgsqlServer(false).
+		WithAuth(true).
+		WithTLS(&tls.Config{
+			Certificates: []tls.Certificate{serverCert},
+			ClientAuth:   tls.VerifyClientCertIfGiven,
+			ClientCAs:    ca.pool,
+		}).
+		WithClientCertUsers(map[string]string{
+			"spiffe://immudb.io/admin": auth.SysAdminUsername,
+			"App1.immudb.io":           "app1",
+			"app2.immudb.io":           "unknown",
+		})
+
+	s := server.DefaultServer().WithOptions(serverOptions).(*server.ImmuServer)
+
+	err = s.Initialize()
+	require.NoError(t, err)
+
+	go func() {
+		s.Start()
+	}()
+	defer s.Stop()
+
+	port := s.Listener.Addr().(*net.TCPAddr).Port
+
+	creds := func(cert ...tls.Certificate) grpc.DialOption {
+		return grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{
+			ServerName:   "localhost",
+			RootCAs:      ca.pool,
+			Certificates: cert,
+		}))
+	}
+
+	newClient := func(cert ...tls.Certificate) ic.ImmuClient {
+		return ic.NewClient().WithOptions(ic.DefaultOptions().
+			WithDir(dir).
+			WithAddress("127.0.0.1").
+			WithPort(port).
+			WithDialOptions([]grpc.DialOption{creds(cert...)}))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	t.Run("the certificate of the superadmin lets users be created without a password", func(t *testing.T) {
+		client := newClient(ca.clientCert(t, "admin", "spiffe://immudb.io/admin"))
+
+		err := client.OpenSession(ctx, nil, nil, "defaultdb")
+		require.NoError(t, err)
+		defer client.CloseSession(ctx)
+
+		err = client.CreateUser(ctx, []byte("app1"), []byte("App1@immudb"), auth.PermissionRW, "defaultdb")
+		require.NoError(t, err)
+	})
+
+	t.Run("users are authenticated by the common name of their certificate", func(t *testing.T) {
+		client := newClient(ca.clientCert(t, "app1.immudb.io"))
+
+		err := client.OpenSession(ctx, nil, nil, "defaultdb")
+		require.NoError(t, err)
+		defer client.CloseSession(ctx)
+
+		_, err = client.Set(ctx, []byte("key1"), []byte("value1"))
+		require.NoError(t, err)
+
+		entry, err := client.VerifiedGet(ctx, []byte("key1"))
+		require.NoError(t, err)
+		require.Equal(t, []byte("value1"), entry.Value)
+
+		// the user only has the permissions granted to it
+		err = client.CreateUser(ctx, []byte("app3"), []byte("App3@immudb"), auth.PermissionRW, "defaultdb")
+		require.Error(t, err)
+	})
+
+	t.Run("a database is selected without logging in", func(t *testing.T) {
+		conn, err := grpc.Dial("127.0.0.1:"+strconv.Itoa(port), creds(ca.clientCert(t, "app1.immudb.io")))
+		require.NoError(t, err)
+		defer conn.Close()
+
+		resp, err := schema.NewImmuServiceClient(conn).UseDatabase(ctx, &schema.Database{DatabaseName: "defaultdb"})
+		require.NoError(t, err)
+		require.NotEmpty(t, resp.Token)
+	})
+
+	t.Run("certificates not mapped to an existing user are not authenticated", func(t *testing.T) {
+		for _, cert := range []tls.Certificate{
+			ca.clientCert(t, "app2.immudb.io"),
+			ca.clientCert(t, "app3.immudb.io"),
+		} {
+			client := newClient(cert)
+
+			err := client.OpenSession(ctx, nil, nil, "defaultdb")
+			require.Error(t, err)
+		}
+
+		client := newClient()
+
+		err := client.OpenSession(ctx, nil, nil, "defaultdb")
+		require.Error(t, err)
+	})
+
+	t.Run("credentials are validated when given along with a certificate", func(t *testing.T) {
+		client := newClient(ca.clientCert(t, "app1.immudb.io"))
+
+		err := client.OpenSession(ctx, []byte("app1"), []byte("wrong"), "defaultdb")
+		require.Error(t, err)
+
+		err = client.OpenSession(ctx, []byte(auth.SysAdminUsername), []byte(auth.SysAdminPassword), "defaultdb")
+		require.NoError(t, err)
+		client.CloseSession(ctx)
+	})
+}
@@ -18,8 +18,10 @@ package integration
 import (
 	"context"
 	"errors"
+	"fmt"
 	"os"
 	"path"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -299,12 +301,12 @@ func TestImmuClientTampering(t *testing.T) {
 	}
 
 	_, err = client.Set(ctx, []byte{1}, []byte{1})
-	require.Equal(t, store.ErrCorruptedData, err)
+	require.ErrorIs(t, err, immuErrors.ErrCorruptedState)
 
 	_, err = client.SetAll(ctx, &schema.SetRequest{
 		KVs: []*schema.KeyValue{{Key: []byte{1}, Value: []byte{1}}},
 	})
-	require.Equal(t, store.ErrCorruptedData, err)
+	require.ErrorIs(t, err, immuErrors.ErrCorruptedState)
 
 	bs.Server.PostVerifiableSetFn = func(ctx context.Context,
 		req *schema.VerifiableSetRequest, res *schema.VerifiableTx, err error) (*schema.VerifiableTx, error) {
@@ -319,7 +321,24 @@ func TestImmuClientTampering(t *testing.T) {
 	}
 
 	_, err = client.VerifiedSet(ctx, []byte{1}, []byte{1})
-	require.Equal(t, store.ErrCorruptedData, err)
+	require.ErrorIs(t, err, immuErrors.ErrCorruptedState)
+
+	bs.Server.PostVerifiableSetFn = func(ctx context.Context,
+		req *schema.VerifiableSetRequest, res *schema.VerifiableTx, err error) (*schema.VerifiableTx, error) {
+
+		if err != nil {
+			return res, err
+		}
+
+		// the proof no longer links the tx to the state known by the client
+		res.DualProof.SourceTxHeader.EH[0] ^= 1
+
+		return res, nil
+	}
+
+	_, err = client.VerifiedSet(ctx, []byte{1}, []byte{1})
+	require.ErrorIs(t, err, immuErrors.ErrCorruptedState)
+	require.ErrorIs(t, err, store.ErrCorruptedData)
 
 	bs.Server.PostSetReferenceFn = func(ctx context.Context,
 		req *schema.ReferenceRequest, res *schema.TxHeader, err error) (*schema.TxHeader, error) {
@@ -334,7 +353,7 @@ func TestImmuClientTampering(t *testing.T) {
 	}
 
 	_, err = client.SetReference(ctx, []byte{2}, []byte{1})
-	require.Equal(t, store.ErrCorruptedData, err)
+	require.ErrorIs(t, err, immuErrors.ErrCorruptedState)
 
 	bs.Server.PostVerifiableSetReferenceFn = func(ctx context.Context,
 		req *schema.VerifiableReferenceRequest, res *schema.VerifiableTx, err error) (*schema.VerifiableTx, error) {
@@ -349,7 +368,7 @@ func TestImmuClientTampering(t *testing.T) {
 	}
 
 	_, err = client.VerifiedSetReference(ctx, []byte{2}, []byte{1})
-	require.Equal(t, store.ErrCorruptedData, err)
+	require.ErrorIs(t, err, immuErrors.ErrCorruptedState)
 
 	bs.Server.PostZAddFn = func(ctx context.Context,
 		req *schema.ZAddRequest, res *schema.TxHeader, err error) (*schema.TxHeader, error) {
@@ -364,7 +383,7 @@ func TestImmuClientTampering(t *testing.T) {
 	}
 
 	_, err = client.ZAdd(ctx, []byte{7}, 1, []byte{1})
-	require.Equal(t, store.ErrCorruptedData, err)
+	require.ErrorIs(t, err, immuErrors.ErrCorruptedState)
 
 	bs.Server.PostVerifiableZAddFn = func(ctx context.Context,
 		req *schema.VerifiableZAddRequest, res *schema.VerifiableTx, err error) (*schema.VerifiableTx, error) {
@@ -379,7 +398,7 @@ func TestImmuClientTampering(t *testing.T) {
 	}
 
 	_, err = client.VerifiedZAdd(ctx, []byte{7}, 1, []byte{1})
-	require.Equal(t, store.ErrCorruptedData, err)
+	require.ErrorIs(t, err, immuErrors.ErrCorruptedState)
 
 	bs.Server.PostExecAllFn = func(ctx context.Context,
 		req *schema.ExecAllRequest, res *schema.TxHeader, err error) (*schema.TxHeader, error) {
@@ -407,7 +426,7 @@ func TestImmuClientTampering(t *testing.T) {
 	}
 
 	_, err = client.ExecAll(ctx, aOps)
-	require.Equal(t, store.ErrCorruptedData, err)
+	require.ErrorIs(t, err, immuErrors.ErrCorruptedState)
 }
 
 func TestReplica(t *testing.T) {
@@ -595,6 +614,22 @@ func TestImmuClientDisconnect(t *testing.T) {
 	require.True(t, errors.Is(client.UpdateAuthConfig(ctx, auth.KindPassword), ic.ErrNotConnected))
 	require.True(t, errors.Is(client.UpdateMTLSConfig(ctx, false), ic.ErrNotConnected))
 	require.True(t, errors.Is(client.CompactIndex(ctx, &emptypb.Empty{}), ic.ErrNotConnected))
+
+	_, err = client.CompactIndexAsync(ctx)
+	require.True(t, errors.Is(err, ic.ErrNotConnected))
+
+	_, err = client.ListJobs(ctx, "")
+	require.True(t, errors.Is(err, ic.ErrNotConnected))
+
+	_, err = client.GetJob(ctx, "")
+	require.True(t, errors.Is(err, ic.ErrNotConnected))
+
+	_, err = client.CancelJob(ctx, "")
+	require.True(t, errors.Is(err, ic.ErrNotConnected))
+
+	_, err = client.WaitForJob(ctx, "", time.Millisecond, nil)
+	require.True(t, errors.Is(err, ic.ErrNotConnected))
+
 	require.True(t, errors.Is(client.FlushIndex(ctx, 100, true), ic.ErrNotConnected))
 
 	_, err = client.Login(context.TODO(), []byte("user"), []byte("passwd"))
@@ -947,6 +982,22 @@ func TestImmuClient_SetAll(t *testing.T) {
 	err = client.CompactIndex(ctx, &emptypb.Empty{})
 	require.NoError(t, err)
 
+	job, err := client.CompactIndexAsync(ctx)
+	require.NoError(t, err)
+
+	job, err = client.WaitForJob(ctx, job.Id, 10*time.Millisecond, nil)
+	require.NoError(t, err)
+	require.Equal(t, "defaultdb", job.Database)
+	require.True(t, job.Finished())
+
+	jobs, err := client.ListJobs(ctx, schema.JobKindCompaction)
+	require.NoError(t, err)
+	require.Len(t, jobs.Jobs, 1)
+	require.Equal(t, job.Id, jobs.Jobs[0].Id)
+
+	_, err = client.CancelJob(ctx, job.Id)
+	require.Error(t, err)
+
 	for _, kv := range setRequest.KVs {
 		i, err := client.Get(ctx, kv.Key)
 
@@ -965,6 +1016,208 @@ func TestImmuClient_SetAll(t *testing.T) {
 	require.True(t, errors.Is(err, ic.ErrNotConnected))
 }
 
+func TestImmuClient_AsyncSet(t *testing.T) {
+	options := server.DefaultOptions().WithAuth(true)
+	bs := servertest.NewBufconnServer(options)
+
+	defer os.RemoveAll(options.Dir)
+	defer os.Remove(".state-")
+
+	bs.Start()
+	defer bs.Stop()
+
+	client, err := ic.NewImmuClient(ic.DefaultOptions().
+		WithDialOptions([]grpc.DialOption{grpc.WithContextDialer(bs.Dialer), grpc.WithInsecure()}).
+		WithMaxPendingAsyncWrites(8))
+	require.NoError(t, err)
+	client.WithTokenService(tokenservice.NewInmemoryTokenService())
+	lr, err := client.Login(context.TODO(), []byte(`immudb`), []byte(`immudb`))
+	require.NoError(t, err)
+	md := metadata.Pairs("authorization", lr.Token)
+	ctx := metadata.NewOutgoingContext(context.Background(), md)
+
+	futures := make([]*ic.TxFuture, 100)
+
+	for i := range futures {
+		futures[i], err = client.AsyncSet(ctx, []byte(fmt.Sprintf("async-key-%d", i)), []byte(fmt.Sprintf("async-val-%d", i)))
+		require.NoError(t, err)
+	}
+
+	err = client.Flush(ctx)
+	require.NoError(t, err)
+
+	txs := make(map[uint64]struct{})
+
+	for i, f := range futures {
+		select {
+		case <-f.Done():
+		default:
+			require.Fail(t, "async write must be completed after flush")
+		}
+
+		hdr, err := f.Wait(ctx)
+		require.NoError(t, err)
+		require.Equal(t, int32(1), hdr.Nentries)
+
+		txs[hdr.Id] = struct{}{}
+
+		entry, err := client.Get(ctx, []byte(fmt.Sprintf("async-key-%d", i)))
+		require.NoError(t, err)
+		require.Equal(t, []byte(fmt.Sprintf("async-val-%d", i)), entry.Value)
+		require.Equal(t, hdr.Id, entry.Tx)
+	}
+
+	require.Len(t, txs, len(futures))
+
+	f, err := client.AsyncSet(ctx, nil, []byte("value"))
+	require.NoError(t, err)
+
+	_, err = f.Wait(ctx)
+	require.Error(t, err)
+
+	err = client.Flush(ctx)
+	require.Error(t, err)
+
+	// errors are only reported once
+	err = client.Flush(ctx)
+	require.NoError(t, err)
+
+	err = client.Disconnect()
+	require.NoError(t, err)
+
+	_, err = client.AsyncSet(ctx, []byte("key"), []byte("value"))
+	require.True(t, errors.Is(err, ic.ErrNotConnected))
+}
+
+func TestImmuClient_ValueCache(t *testing.T) {
+	options := server.DefaultOptions().WithAuth(true)
+	bs := servertest.NewBufconnServer(options)
+
+	defer os.RemoveAll(options.Dir)
+	defer os.Remove(".state-")
+
+	bs.Start()
+	defer bs.Stop()
+
+	var gets, calls int32
+
+	countGets := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if method == "/immudb.schema.ImmuService/Get" {
+			atomic.AddInt32(&gets, 1)
+		}
+		atomic.AddInt32(&calls, 1)
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+
+	client, err := ic.NewImmuClient(ic.DefaultOptions().
+		WithDialOptions([]grpc.DialOption{grpc.WithContextDialer(bs.Dialer), grpc.WithInsecure(), grpc.WithChainUnaryInterceptor(countGets)}).
+		WithValueCacheSize(2))
+	require.NoError(t, err)
+	defer client.Disconnect()
+	client.WithTokenService(tokenservice.NewInmemoryTokenService())
+	lr, err := client.Login(context.TODO(), []byte(`immudb`), []byte(`immudb`))
+	require.NoError(t, err)
+	ctx := metadata.NewOutgoingContext(context.Background(), metadata.Pairs("authorization", lr.Token))
+
+	// writes of another client must be seen
+	writer, err := ic.NewImmuClient(ic.DefaultOptions().
+		WithDialOptions([]grpc.DialOption{grpc.WithContextDialer(bs.Dialer), grpc.WithInsecure()}))
+	require.NoError(t, err)
+	defer writer.Disconnect()
+	writer.WithTokenService(tokenservice.NewInmemoryTokenService())
+	wlr, err := writer.Login(context.TODO(), []byte(`immudb`), []byte(`immudb`))
+	require.NoError(t, err)
+	wctx := metadata.NewOutgoingContext(context.Background(), metadata.Pairs("authorization", wlr.Token))
+
+	hdr1, err := writer.Set(wctx, []byte("key1"), []byte("value1"))
+	require.NoError(t, err)
+
+	entry, err := client.Get(ctx, []byte("key1"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("value1"), entry.Value)
+	require.Equal(t, int32(1), atomic.LoadInt32(&gets))
+
+	// cache hits make no call to the server
+	callsBeforeHits := atomic.LoadInt32(&calls)
+
+	for i := 0; i < 3; i++ {
+		entry, err := client.Get(ctx, []byte("key1"))
+		require.NoError(t, err)
+		require.Equal(t, []byte("value1"), entry.Value)
+	}
+	require.Equal(t, callsBeforeHits, atomic.LoadInt32(&calls))
+
+	// unrelated writes keep the cached value
+	_, err = writer.Set(wctx, []byte("key2"), []byte("value2"))
+	require.NoError(t, err)
+
+	_, err = client.CurrentState(ctx)
+	require.NoError(t, err)
+
+	entry, err = client.Get(ctx, []byte("key1"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("value1"), entry.Value)
+	require.Equal(t, int32(1), atomic.LoadInt32(&gets))
+
+	// writes of another client are seen once a later tx is known
+	_, err = writer.Set(wctx, []byte("key1"), []byte("value1b"))
+	require.NoError(t, err)
+
+	entry, err = client.Get(ctx, []byte("key1"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("value1"), entry.Value)
+
+	_, err = client.CurrentState(ctx)
+	require.NoError(t, err)
+
+	entry, err = client.Get(ctx, []byte("key1"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("value1b"), entry.Value)
+	require.Equal(t, int32(2), atomic.LoadInt32(&gets))
+
+	// values read at a given tx never change
+	entry, err = client.GetAt(ctx, []byte("key1"), hdr1.Id)
+	require.NoError(t, err)
+	require.Equal(t, []byte("value1"), entry.Value)
+
+	entry, err = client.GetAt(ctx, []byte("key1"), hdr1.Id)
+	require.NoError(t, err)
+	require.Equal(t, []byte("value1"), entry.Value)
+	require.Equal(t, int32(3), atomic.LoadInt32(&gets))
+
+	// the value of a reference changes when the referenced key is written
+	_, err = writer.SetReference(wctx, []byte("ref1"), []byte("key1"))
+	require.NoError(t, err)
+
+	entry, err = client.Get(ctx, []byte("ref1"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("value1b"), entry.Value)
+
+	_, err = writer.Set(wctx, []byte("key1"), []byte("value1c"))
+	require.NoError(t, err)
+
+	_, err = client.CurrentState(ctx)
+	require.NoError(t, err)
+
+	entry, err = client.Get(ctx, []byte("ref1"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("value1c"), entry.Value)
+
+	// own writes are seen right away
+	_, err = client.Set(ctx, []byte("key1"), []byte("value1d"))
+	require.NoError(t, err)
+
+	entry, err = client.Get(ctx, []byte("ref1"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("value1d"), entry.Value)
+
+	_, err = client.Delete(ctx, &schema.DeleteKeysRequest{Keys: [][]byte{[]byte("key1")}})
+	require.NoError(t, err)
+
+	_, err = client.Get(ctx, []byte("key1"))
+	require.Error(t, err)
+}
+
 func TestImmuClient_GetAll(t *testing.T) {
 	options := server.DefaultOptions().WithAuth(true)
 	bs := servertest.NewBufconnServer(options)
@@ -1048,6 +1301,23 @@ func TestImmuClient_Delete(t *testing.T) {
 	require.Error(t, err)
 	require.Contains(t, err.Error(), "key not found")
 
+	_, err = client.SetWithMetadata(ctx, []byte("nonIndexableKey"), []byte("nonIndexableValue"), schema.NewKVMetadata().AsNonIndexable())
+	require.NoError(t, err)
+
+	_, err = client.Get(ctx, []byte("nonIndexableKey"))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "key not found")
+
+	hdr, err := client.SetWithMetadata(ctx, []byte("ttlKey"), []byte("ttlValue"), schema.NewKVMetadata().WithTTL(time.Hour))
+	require.NoError(t, err)
+
+	i, err = client.Get(ctx, []byte("ttlKey"))
+	require.NoError(t, err)
+	require.Equal(t, hdr.Id, i.Tx)
+
+	_, expirable := i.Metadata.ExpirationTime()
+	require.True(t, expirable)
+
 	deleteRequest.Keys = append(deleteRequest.Keys, []byte("1,2,3"))
 	_, err = client.Delete(ctx, deleteRequest)
 	require.NoError(t, err)
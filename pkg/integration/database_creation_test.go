@@ -16,10 +16,12 @@ limitations under the License.
 package integration
 
 import (
+	"bytes"
 	"context"
 	"os"
 	"testing"
 
+	"github.com/codenotary/immudb/embedded/store"
 	"github.com/codenotary/immudb/pkg/api/schema"
 	immudb "github.com/codenotary/immudb/pkg/client"
 	"github.com/codenotary/immudb/pkg/server"
@@ -91,17 +93,19 @@ func TestCreateDatabaseV2(t *testing.T) {
 		ReplicationSettings: &schema.ReplicationSettings{
 			Replica: &schema.ConditionalBool{Value: false},
 		},
-		FileSize:                &schema.ConditionalUint32{Value: 1 << 20},
-		MaxKeyLen:               &schema.ConditionalUint32{Value: 32},
-		MaxValueLen:             &schema.ConditionalUint32{Value: 64},
-		MaxTxEntries:            &schema.ConditionalUint32{Value: 100},
-		ExcludeCommitTime:       &schema.ConditionalBool{Value: false},
-		MaxConcurrency:          &schema.ConditionalUint32{Value: 10},
-		MaxIOConcurrency:        &schema.ConditionalUint32{Value: 2},
-		TxLogCacheSize:          &schema.ConditionalUint32{Value: 2000},
-		VLogMaxOpenedFiles:      &schema.ConditionalUint32{Value: 8},
-		TxLogMaxOpenedFiles:     &schema.ConditionalUint32{Value: 4},
-		CommitLogMaxOpenedFiles: &schema.ConditionalUint32{Value: 2},
+		FileSize:                  &schema.ConditionalUint32{Value: 1 << 20},
+		MaxKeyLen:                 &schema.ConditionalUint32{Value: 32},
+		MaxValueLen:               &schema.ConditionalUint32{Value: 64},
+		MaxTxEntries:              &schema.ConditionalUint32{Value: 100},
+		ExcludeCommitTime:         &schema.ConditionalBool{Value: false},
+		MaxConcurrency:            &schema.ConditionalUint32{Value: 10},
+		MaxIOConcurrency:          &schema.ConditionalUint32{Value: 2},
+		TxLogCacheSize:            &schema.ConditionalUint32{Value: 2000},
+		VLogMaxOpenedFiles:        &schema.ConditionalUint32{Value: 8},
+		TxLogMaxOpenedFiles:       &schema.ConditionalUint32{Value: 4},
+		CommitLogMaxOpenedFiles:   &schema.ConditionalUint32{Value: 2},
+		ValueCompression:          &schema.ConditionalString{Value: server.S2ValueCompression},
+		ValueCompressionThreshold: &schema.ConditionalUint32{Value: 32},
 		IndexSettings: &schema.IndexSettings{
 			FlushThreshold:           &schema.ConditionalUint32{Value: 256},
 			SyncThreshold:            &schema.ConditionalUint32{Value: 512},
@@ -138,6 +142,8 @@ func TestCreateDatabaseV2(t *testing.T) {
 	require.Equal(t, dbSettings.VLogMaxOpenedFiles.Value, settings.VLogMaxOpenedFiles.Value)
 	require.Equal(t, dbSettings.TxLogMaxOpenedFiles.Value, settings.TxLogMaxOpenedFiles.Value)
 	require.Equal(t, dbSettings.CommitLogMaxOpenedFiles.Value, settings.CommitLogMaxOpenedFiles.Value)
+	require.Equal(t, dbSettings.ValueCompression.Value, settings.ValueCompression.Value)
+	require.Equal(t, dbSettings.ValueCompressionThreshold.Value, settings.ValueCompressionThreshold.Value)
 
 	require.Equal(t, dbSettings.IndexSettings.FlushThreshold.Value, settings.IndexSettings.FlushThreshold.Value)
 	require.Equal(t, dbSettings.IndexSettings.SyncThreshold.Value, settings.IndexSettings.SyncThreshold.Value)
@@ -152,3 +158,86 @@ func TestCreateDatabaseV2(t *testing.T) {
 	require.Equal(t, dbSettings.IndexSettings.HistoryLogMaxOpenedFiles.Value, settings.IndexSettings.HistoryLogMaxOpenedFiles.Value)
 	require.Equal(t, dbSettings.IndexSettings.CommitLogMaxOpenedFiles.Value, settings.IndexSettings.CommitLogMaxOpenedFiles.Value)
 }
+
+func TestDatabaseValueCompression(t *testing.T) {
+	options := server.DefaultOptions()
+	bs := servertest.NewBufconnServer(options)
+
+	defer os.RemoveAll(options.Dir)
+	defer os.Remove(".state-")
+
+	bs.Start()
+	defer bs.Stop()
+
+	clientOpts := immudb.DefaultOptions().WithDialOptions([]grpc.DialOption{grpc.WithContextDialer(bs.Dialer), grpc.WithInsecure()})
+	client := immudb.NewClient().WithOptions(clientOpts)
+
+	err := client.OpenSession(context.TODO(), []byte(`immudb`), []byte(`immudb`), "defaultdb")
+	require.NoError(t, err)
+	defer client.CloseSession(context.TODO())
+
+	_, err = client.CreateDatabaseV2(context.Background(), &schema.DatabaseSettingsV2{
+		DatabaseName:     "db1",
+		ValueCompression: &schema.ConditionalString{Value: "zip"},
+	})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "unsupported value compression algorithm")
+
+	_, err = client.CreateDatabaseV2(context.Background(), &schema.DatabaseSettingsV2{
+		DatabaseName:              "db1",
+		ValueCompression:          &schema.ConditionalString{Value: server.ZstdValueCompression},
+		ValueCompressionThreshold: &schema.ConditionalUint32{Value: 16},
+	})
+	require.NoError(t, err)
+
+	_, err = client.UseDatabase(context.Background(), &schema.Database{DatabaseName: "db1"})
+	require.NoError(t, err)
+
+	value := bytes.Repeat([]byte("immudb "), 100)
+
+	_, err = client.Set(context.Background(), []byte("key1"), value)
+	require.NoError(t, err)
+
+	entry, err := client.VerifiedGet(context.Background(), []byte("key1"))
+	require.NoError(t, err)
+	require.Equal(t, value, entry.Value)
+	require.Equal(t, int32(store.ZstdValueCompression), entry.Metadata.Compression)
+
+	_, err = client.VerifiedSet(context.Background(), []byte("key2"), value)
+	require.NoError(t, err)
+
+	// the value of the reference holds the compressible referenced key
+	longKey := bytes.Repeat([]byte("key"), 100)
+
+	_, err = client.Set(context.Background(), longKey, value)
+	require.NoError(t, err)
+
+	_, err = client.VerifiedSetReference(context.Background(), []byte("ref"), longKey)
+	require.NoError(t, err)
+
+	refEntry, err := client.VerifiedGet(context.Background(), []byte("ref"))
+	require.NoError(t, err)
+	require.Equal(t, value, refEntry.Value)
+	require.Equal(t, int32(store.ZstdValueCompression), refEntry.ReferencedBy.Metadata.Compression)
+
+	// compression may be changed on existing databases
+	res, err := client.UpdateDatabaseV2(context.Background(), &schema.DatabaseSettingsV2{
+		DatabaseName:     "db1",
+		ValueCompression: &schema.ConditionalString{Value: server.NoValueCompression},
+	})
+	require.NoError(t, err)
+	require.Equal(t, server.NoValueCompression, res.CurrentSettings.ValueCompression.Value)
+	require.Equal(t, uint32(16), res.CurrentSettings.ValueCompressionThreshold.Value)
+
+	tx, err := client.TxByIDWithSpec(context.Background(), &schema.TxRequest{
+		Tx: entry.Tx,
+		EntriesSpec: &schema.EntriesSpec{
+			KvEntriesSpec: &schema.EntryTypeSpec{Action: schema.EntryTypeAction_RAW_VALUE},
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, tx.Entries, 1)
+	// raw values hold the value type prefix
+	require.Equal(t, value, tx.Entries[0].Value[1:])
+	require.Equal(t, int32(len(value)+1), tx.Entries[0].VLen)
+}
@@ -27,6 +27,9 @@ import (
 	"github.com/codenotary/immudb/pkg/server/servertest"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
 func TestGRPCError(t *testing.T) {
@@ -52,3 +55,34 @@ func TestGRPCError(t *testing.T) {
 	require.Equal(t, int32(0), err.(errors.ImmuError).RetryDelay())
 	require.NotNil(t, err.(errors.ImmuError).Stack())
 }
+
+func TestTypedErrors(t *testing.T) {
+	options := server.DefaultOptions().WithAuth(true)
+	bs := servertest.NewBufconnServer(options)
+
+	defer os.RemoveAll(options.Dir)
+	defer os.Remove(".state-")
+
+	bs.Start()
+	defer bs.Stop()
+
+	cli, err := client.NewImmuClient(client.DefaultOptions().WithDialOptions([]grpc.DialOption{grpc.WithContextDialer(bs.Dialer), grpc.WithInsecure()}))
+	require.NoError(t, err)
+	defer cli.Disconnect()
+
+	lr, err := cli.Login(context.TODO(), []byte(`immudb`), []byte(`immudb`))
+	require.NoError(t, err)
+
+	md := metadata.Pairs("authorization", lr.Token)
+	ctx := metadata.NewOutgoingContext(context.Background(), md)
+
+	_, err = cli.Get(ctx, []byte("missing-key"))
+	require.ErrorIs(t, err, errors.ErrKeyNotFound)
+	require.NotErrorIs(t, err, errors.ErrTxNotFound)
+	require.Equal(t, errors.CodNoDataFound, err.(errors.ImmuError).Code())
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = cli.TxByID(ctx, 100)
+	require.ErrorIs(t, err, errors.ErrTxNotFound)
+	require.Equal(t, errors.CodTxNotFound, err.(errors.ImmuError).Code())
+}
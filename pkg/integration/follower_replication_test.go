@@ -264,3 +264,156 @@ func TestSystemDBAndDefaultDBReplication(t *testing.T) {
 	_, err = followerClient.Set(mctx, []byte("key2"), []byte("value2"))
 	require.Contains(t, err.Error(), "database is read-only because it's a replica")
 }
+
+func TestReplicationManagement(t *testing.T) {
+	//init master server
+	masterServerOpts := server.DefaultOptions().
+		WithMetricsServer(false).
+		WithWebServer(false).
+		WithPgsqlServer(false).
+		WithPort(0).
+		WithDir("master-mgmt-data")
+
+	masterServer := server.DefaultServer().WithOptions(masterServerOpts).(*server.ImmuServer)
+	defer os.RemoveAll(masterServerOpts.Dir)
+
+	err := masterServer.Initialize()
+	require.NoError(t, err)
+
+	//init follower server
+	followerServerOpts := server.DefaultOptions().
+		WithMetricsServer(false).
+		WithWebServer(false).
+		WithPgsqlServer(false).
+		WithPort(0).
+		WithDir("follower-mgmt-data")
+
+	followerServer := server.DefaultServer().WithOptions(followerServerOpts).(*server.ImmuServer)
+	defer os.RemoveAll(followerServerOpts.Dir)
+
+	err = followerServer.Initialize()
+	require.NoError(t, err)
+
+	go func() {
+		masterServer.Start()
+	}()
+
+	go func() {
+		followerServer.Start()
+	}()
+
+	time.Sleep(1 * time.Second)
+
+	defer func() {
+		masterServer.Stop()
+
+		time.Sleep(1 * time.Second)
+
+		followerServer.Stop()
+	}()
+
+	// init master client
+	masterPort := masterServer.Listener.Addr().(*net.TCPAddr).Port
+	masterClient, err := ic.NewImmuClient(ic.DefaultOptions().WithPort(masterPort))
+	require.NoError(t, err)
+	defer masterClient.Disconnect()
+
+	mlr, err := masterClient.Login(context.TODO(), []byte(`immudb`), []byte(`immudb`))
+	require.NoError(t, err)
+
+	mctx := metadata.NewOutgoingContext(context.Background(), metadata.Pairs("authorization", mlr.Token))
+
+	err = masterClient.CreateUser(mctx, []byte("follower"), []byte("follower1Pwd!"), auth.PermissionAdmin, "defaultdb")
+	require.NoError(t, err)
+
+	// init follower client
+	followerPort := followerServer.Listener.Addr().(*net.TCPAddr).Port
+	followerClient, err := ic.NewImmuClient(ic.DefaultOptions().WithPort(followerPort))
+	require.NoError(t, err)
+	defer followerClient.Disconnect()
+
+	flr, err := followerClient.Login(context.TODO(), []byte(`immudb`), []byte(`immudb`))
+	require.NoError(t, err)
+
+	fctx := metadata.NewOutgoingContext(context.Background(), metadata.Pairs("authorization", flr.Token))
+
+	_, err = followerClient.ReplicationStatus(fctx, "defaultdb")
+	require.Error(t, err)
+
+	err = followerClient.CreateDatabase(fctx, &schema.DatabaseSettings{
+		DatabaseName:     "replicateddb",
+		Replica:          true,
+		MasterDatabase:   "defaultdb",
+		MasterAddress:    "127.0.0.1",
+		MasterPort:       uint32(masterPort),
+		FollowerUsername: "follower",
+		FollowerPassword: "follower1Pwd!",
+	})
+	require.NoError(t, err)
+
+	hdr1, err := masterClient.Set(mctx, []byte("key1"), []byte("value1"))
+	require.NoError(t, err)
+
+	waitForTx := func(tx uint64) *schema.ReplicationStatusResponse {
+		for i := 0; i < 50; i++ {
+			st, err := followerClient.ReplicationStatus(fctx, "replicateddb")
+			require.NoError(t, err)
+
+			if st.LastTx >= tx {
+				return st
+			}
+
+			time.Sleep(100 * time.Millisecond)
+		}
+
+		require.Fail(t, "replica did not catch up")
+		return nil
+	}
+
+	st := waitForTx(hdr1.Id)
+	require.Equal(t, "replicateddb", st.Database)
+	require.True(t, st.Running)
+	require.False(t, st.Paused)
+
+	err = followerClient.PauseReplication(fctx, "replicateddb")
+	require.NoError(t, err)
+
+	err = followerClient.PauseReplication(fctx, "replicateddb")
+	require.Error(t, err)
+
+	hdr2, err := masterClient.Set(mctx, []byte("key2"), []byte("value2"))
+	require.NoError(t, err)
+
+	time.Sleep(500 * time.Millisecond)
+
+	st, err = followerClient.ReplicationStatus(fctx, "replicateddb")
+	require.NoError(t, err)
+	require.True(t, st.Paused)
+	require.Equal(t, hdr1.Id, st.LastTx)
+	require.Equal(t, hdr2.Id, st.MasterTx)
+	require.Equal(t, hdr2.Id-hdr1.Id, st.Lag)
+
+	err = followerClient.ResumeReplication(fctx, "replicateddb")
+	require.NoError(t, err)
+
+	st = waitForTx(hdr2.Id)
+	require.False(t, st.Paused)
+
+	err = followerClient.ResetReplication(fctx, "defaultdb")
+	require.Error(t, err)
+
+	err = followerClient.ResetReplication(fctx, "replicateddb")
+	require.NoError(t, err)
+
+	st = waitForTx(hdr2.Id)
+	require.Equal(t, hdr2.Id, st.LastTx)
+
+	fdb, err := followerClient.UseDatabase(fctx, &schema.Database{DatabaseName: "replicateddb"})
+	require.NoError(t, err)
+
+	fctx = metadata.NewOutgoingContext(context.Background(), metadata.Pairs("authorization", fdb.Token))
+
+	entry, err := followerClient.Get(fctx, []byte("key2"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("value2"), entry.Value)
+}
@@ -0,0 +1,211 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package integration
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	ic "github.com/codenotary/immudb/pkg/client"
+	"github.com/codenotary/immudb/pkg/client/tokenservice"
+	"github.com/codenotary/immudb/pkg/server"
+	"github.com/codenotary/immudb/pkg/server/servertest"
+	"github.com/codenotary/immudb/pkg/signer"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestImmuClient_ExportProofAndVerifyOffline(t *testing.T) {
+	options := server.DefaultOptions().WithAuth(true).WithSigningKey("./../../test/signer/ec1.key")
+	bs := servertest.NewBufconnServer(options)
+
+	defer os.RemoveAll(options.Dir)
+	defer os.Remove(".state-")
+
+	err := bs.Start()
+	require.NoError(t, err)
+	defer bs.Stop()
+
+	opts := ic.DefaultOptions().WithDialOptions([]grpc.DialOption{grpc.WithContextDialer(bs.Dialer), grpc.WithInsecure()})
+	client, err := ic.NewImmuClient(opts.WithServerSigningPubKey("./../../test/signer/ec1.pub"))
+	require.NoError(t, err)
+	defer client.Disconnect()
+	client.WithTokenService(tokenservice.NewInmemoryTokenService())
+
+	lr, err := client.Login(context.TODO(), []byte(`immudb`), []byte(`immudb`))
+	require.NoError(t, err)
+	ctx := metadata.NewOutgoingContext(context.Background(), metadata.Pairs("authorization", lr.Token))
+
+	hdr, err := client.Set(ctx, []byte("key1"), []byte("value1"))
+	require.NoError(t, err)
+
+	sourceState, err := client.CurrentState(ctx)
+	require.NoError(t, err)
+
+	for i := 0; i < 5; i++ {
+		_, err = client.Set(ctx, []byte("key2"), []byte("value2"))
+		require.NoError(t, err)
+	}
+
+	_, err = client.SetReference(ctx, []byte("ref1"), []byte("key1"))
+	require.NoError(t, err)
+
+	pubKey, err := signer.ParsePublicKeyFile("./../../test/signer/ec1.pub")
+	require.NoError(t, err)
+
+	requests := []*ic.ProofRequest{
+		{Type: schema.ProofType_TX_INCLUSION, Tx: hdr.Id},
+		{Type: schema.ProofType_ENTRY_INCLUSION, Key: []byte("key1")},
+		{Type: schema.ProofType_ENTRY_INCLUSION, Key: []byte("key2"), AtTx: hdr.Id + 2},
+		{Type: schema.ProofType_ENTRY_INCLUSION, Key: []byte("ref1")},
+		{Type: schema.ProofType_CONSISTENCY, SourceState: sourceState},
+	}
+
+	for _, req := range requests {
+		bundle, err := client.ExportProof(ctx, req)
+		require.NoError(t, err)
+		require.Equal(t, req.Type, bundle.Type)
+
+		bs, err := bundle.ToBytes()
+		require.NoError(t, err)
+
+		js, err := bundle.ToJSON()
+		require.NoError(t, err)
+
+		for _, encoded := range [][]byte{bs, js} {
+			decoded, err := schema.ParseProofBundle(encoded)
+			require.NoError(t, err)
+
+			err = ic.VerifyProofBundle(decoded, pubKey)
+			require.NoError(t, err)
+		}
+	}
+
+	_, err = client.ExportProof(ctx, &ic.ProofRequest{Type: schema.ProofType_TX_INCLUSION, Tx: 100})
+	require.ErrorIs(t, err, ic.ErrIllegalArguments)
+
+	bundle, err := client.ExportProof(ctx, &ic.ProofRequest{Type: schema.ProofType_ENTRY_INCLUSION, Key: []byte("key1")})
+	require.NoError(t, err)
+
+	bundle.Entry.Value = []byte("tampered")
+
+	err = ic.VerifyProofBundle(bundle, pubKey)
+	require.ErrorIs(t, err, store.ErrCorruptedData)
+
+	bundle, err = client.ExportProof(ctx, &ic.ProofRequest{Type: schema.ProofType_CONSISTENCY, SourceState: sourceState})
+	require.NoError(t, err)
+
+	bundle.SourceState.TxHash[0] ^= 1
+
+	err = ic.VerifyProofBundle(bundle, pubKey)
+	require.ErrorIs(t, err, store.ErrCorruptedData)
+
+	bundle.SourceState.TxHash[0] ^= 1
+	bundle.State.TxHash[0] ^= 1
+
+	err = ic.VerifyProofBundle(bundle, pubKey)
+	require.ErrorIs(t, err, store.ErrCorruptedData)
+}
+
+func TestImmuClient_VerifyProofBundleWithRoot(t *testing.T) {
+	options := server.DefaultOptions().WithAuth(true).WithSigningKey("./../../test/signer/ec1.key")
+	bs := servertest.NewBufconnServer(options)
+
+	defer os.RemoveAll(options.Dir)
+	defer os.Remove(".state-")
+
+	err := bs.Start()
+	require.NoError(t, err)
+	defer bs.Stop()
+
+	opts := ic.DefaultOptions().WithDialOptions([]grpc.DialOption{grpc.WithContextDialer(bs.Dialer), grpc.WithInsecure()})
+	client, err := ic.NewImmuClient(opts.WithServerSigningPubKey("./../../test/signer/ec1.pub"))
+	require.NoError(t, err)
+	defer client.Disconnect()
+	client.WithTokenService(tokenservice.NewInmemoryTokenService())
+
+	lr, err := client.Login(context.TODO(), []byte(`immudb`), []byte(`immudb`))
+	require.NoError(t, err)
+	ctx := metadata.NewOutgoingContext(context.Background(), metadata.Pairs("authorization", lr.Token))
+
+	_, err = client.Set(ctx, []byte("key1"), []byte("value1"))
+	require.NoError(t, err)
+
+	exportedRoot, err := client.ExportRoot(ctx)
+	require.NoError(t, err)
+
+	root, err := schema.ParseRoot(exportedRoot)
+	require.NoError(t, err)
+
+	_, err = client.Set(ctx, []byte("key2"), []byte("value2"))
+	require.NoError(t, err)
+
+	pubKey, err := signer.ParsePublicKeyFile("./../../test/signer/ec1.pub")
+	require.NoError(t, err)
+
+	requests := []*ic.ProofRequest{
+		{Type: schema.ProofType_TX_INCLUSION, Tx: root.TxId},
+		{Type: schema.ProofType_ENTRY_INCLUSION, Key: []byte("key2")},
+		{Type: schema.ProofType_CONSISTENCY, SourceState: root},
+	}
+
+	for _, req := range requests {
+		bundle, err := client.ExportProof(ctx, req)
+		require.NoError(t, err)
+
+		err = ic.VerifyProofBundleWithRoot(bundle, root, pubKey)
+		require.NoError(t, err)
+
+		err = ic.VerifyProofBundleWithRoot(bundle, root, nil)
+		require.NoError(t, err)
+	}
+
+	otherPubKey, err := signer.ParsePublicKeyFile("./../../test/signer/ec3.pub")
+	require.NoError(t, err)
+
+	bundle, err := client.ExportProof(ctx, &ic.ProofRequest{Type: schema.ProofType_CONSISTENCY, SourceState: root})
+	require.NoError(t, err)
+
+	err = ic.VerifyProofBundleWithRoot(bundle, root, otherPubKey)
+	require.ErrorIs(t, err, schema.ErrInvalidRootSignature)
+
+	err = ic.VerifyProofBundleWithRoot(bundle, &schema.ImmutableState{Db: root.Db, TxId: root.TxId, TxHash: root.TxHash}, nil)
+	require.ErrorIs(t, err, schema.ErrStateNotSigned)
+
+	forgedRoot := &schema.ImmutableState{
+		Db:        root.Db,
+		TxId:      bundle.State.TxId,
+		TxHash:    root.TxHash,
+		Signature: root.Signature,
+	}
+
+	err = ic.VerifyProofBundleWithRoot(bundle, forgedRoot, nil)
+	require.ErrorIs(t, err, schema.ErrInvalidRootSignature)
+
+	bundle.SourceState = &schema.ImmutableState{
+		Db:     root.Db,
+		TxId:   root.TxId,
+		TxHash: bundle.State.TxHash,
+	}
+
+	err = ic.VerifyProofBundleWithRoot(bundle, root, nil)
+	require.ErrorIs(t, err, store.ErrCorruptedData)
+}
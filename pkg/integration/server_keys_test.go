@@ -0,0 +1,105 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package integration
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	ic "github.com/codenotary/immudb/pkg/client"
+	"github.com/codenotary/immudb/pkg/client/tokenservice"
+	"github.com/codenotary/immudb/pkg/server"
+	"github.com/codenotary/immudb/pkg/server/servertest"
+	"github.com/codenotary/immudb/pkg/signer"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func newServerKeysClient(t *testing.T, dialer servertest.BuffDialer) (ic.ImmuClient, context.Context) {
+	opts := ic.DefaultOptions().WithDialOptions([]grpc.DialOption{grpc.WithContextDialer(dialer), grpc.WithInsecure()})
+	client, err := ic.NewImmuClient(opts)
+	require.NoError(t, err)
+	client.WithTokenService(tokenservice.NewInmemoryTokenService())
+
+	lr, err := client.Login(context.TODO(), []byte(`immudb`), []byte(`immudb`))
+	require.NoError(t, err)
+
+	return client, metadata.NewOutgoingContext(context.Background(), metadata.Pairs("authorization", lr.Token))
+}
+
+func TestImmuClient_GetServerKeysAfterKeyRotation(t *testing.T) {
+	options := server.DefaultOptions().WithAuth(true).WithSigningKey("./../../test/signer/ec1.key")
+	bs := servertest.NewBufconnServer(options)
+
+	defer os.RemoveAll(options.Dir)
+	defer os.Remove(".state-")
+
+	err := bs.Start()
+	require.NoError(t, err)
+
+	client, ctx := newServerKeysClient(t, bs.Dialer)
+
+	_, err = client.Set(ctx, []byte("key1"), []byte("value1"))
+	require.NoError(t, err)
+
+	oldState, err := client.CurrentState(ctx)
+	require.NoError(t, err)
+
+	keys, err := client.GetServerKeys(ctx)
+	require.NoError(t, err)
+	require.Len(t, keys.Keys, 1)
+	require.Equal(t, oldState.Signature.KeyId, keys.Keys[0].KeyId)
+
+	client.Disconnect()
+	bs.Stop()
+
+	// the signing key is rotated, the previous one is kept as retired
+	options.WithSigningKey("./../../test/signer/ec3.key").
+		WithRetiredSigningKeys([]string{"./../../test/signer/ec1.pub"})
+	bs = servertest.NewBufconnServer(options)
+
+	err = bs.Start()
+	require.NoError(t, err)
+	defer bs.Stop()
+
+	client, ctx = newServerKeysClient(t, bs.Dialer)
+	defer client.Disconnect()
+
+	state, err := client.CurrentState(ctx)
+	require.NoError(t, err)
+	require.Equal(t, oldState.TxId, state.TxId)
+	require.NotEqual(t, oldState.Signature.KeyId, state.Signature.KeyId)
+
+	keys, err = client.GetServerKeys(ctx)
+	require.NoError(t, err)
+	require.Len(t, keys.Keys, 2)
+	require.True(t, keys.Keys[0].Current)
+	require.Equal(t, state.Signature.KeyId, keys.Keys[0].KeyId)
+
+	// the state signed before the rotation is verified with the retired key matching its key id
+	require.False(t, keys.Keys[1].Current)
+	require.Equal(t, oldState.Signature.KeyId, keys.Keys[1].KeyId)
+
+	pk, err := signer.UnmarshalKey(keys.Keys[1].PublicKey)
+	require.NoError(t, err)
+
+	ok, err := oldState.CheckSignature(pk)
+	require.NoError(t, err)
+	require.True(t, ok)
+}
@@ -168,3 +168,60 @@ func TestSession_ExpireSessions(t *testing.T) {
 	}
 	wg.Wait()
 }
+
+func TestSession_KeepAlive(t *testing.T) {
+	sessOptions := &sessions.Options{
+		SessionGuardCheckInterval: time.Millisecond * 50,
+		MaxSessionInactivityTime:  time.Millisecond * 300,
+		MaxSessionAgeTime:         time.Minute,
+		Timeout:                   time.Millisecond * 100,
+	}
+	options := server.DefaultOptions().WithSessionOptions(sessOptions).WithWebServer(false).WithPgsqlServer(false)
+	bs := servertest.NewBufconnServer(options)
+
+	defer os.RemoveAll(options.Dir)
+	defer os.Remove(".state-")
+
+	bs.Start()
+	defer bs.Stop()
+
+	// the bufconn server does not run the sessions guard on its own
+	go bs.Server.Srv.SessManager.StartSessionsGuard()
+	defer bs.Server.Srv.SessManager.StopSessionsGuard()
+
+	newClient := func(heartBeatFrequency time.Duration) ic.ImmuClient {
+		return ic.NewClient().WithOptions(ic.DefaultOptions().
+			WithDialOptions([]grpc.DialOption{grpc.WithContextDialer(bs.Dialer), grpc.WithInsecure()}).
+			WithHeartBeatFrequency(heartBeatFrequency))
+	}
+
+	t.Run("idle sessions should be kept alive by the client", func(t *testing.T) {
+		client := newClient(time.Millisecond * 50)
+
+		// keep alive must not depend on the context the session was opened with
+		ctx, cancel := context.WithCancel(context.Background())
+		err := client.OpenSession(ctx, []byte(`immudb`), []byte(`immudb`), "defaultdb")
+		require.NoError(t, err)
+		cancel()
+
+		time.Sleep(time.Second)
+
+		_, err = client.Set(context.Background(), []byte("key1"), []byte("value1"))
+		require.NoError(t, err)
+
+		err = client.CloseSession(context.Background())
+		require.NoError(t, err)
+	})
+
+	t.Run("idle sessions should be reaped when keep alive is disabled", func(t *testing.T) {
+		client := newClient(0)
+
+		err := client.OpenSession(context.Background(), []byte(`immudb`), []byte(`immudb`), "defaultdb")
+		require.NoError(t, err)
+
+		time.Sleep(time.Second)
+
+		_, err = client.Set(context.Background(), []byte("key1"), []byte("value1"))
+		require.Error(t, err)
+	})
+}
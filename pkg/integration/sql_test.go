@@ -101,7 +101,7 @@ func TestImmuClient_SQL(t *testing.T) {
 		row.Values[1].Value = &schema.SQLValue_S{S: "tampered title"}
 
 		err = client.VerifyRow(ctx, row, "table1", []*schema.SQLValue{row.Values[0]})
-		require.Equal(t, sql.ErrCorruptedData, err)
+		require.ErrorIs(t, err, sql.ErrCorruptedData)
 	}
 
 	res, err = client.SQLQuery(ctx, "SELECT id, active FROM table1", nil, true)
@@ -20,11 +20,13 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"testing"
 
 	"github.com/codenotary/immudb/pkg/api/schema"
 	ic "github.com/codenotary/immudb/pkg/client"
+	"github.com/codenotary/immudb/pkg/database"
 	"github.com/codenotary/immudb/pkg/server"
 	"github.com/codenotary/immudb/pkg/server/servertest"
 	"github.com/codenotary/immudb/pkg/stream"
@@ -137,3 +139,143 @@ func TestImmuClient_StreamHistory(t *testing.T) {
 
 	require.Len(t, historyResp.Entries, 100)
 }
+
+func TestImmuClient_StreamZScanReader(t *testing.T) {
+	options := server.DefaultOptions().WithAuth(true)
+	bs := servertest.NewBufconnServer(options)
+
+	defer os.RemoveAll(options.Dir)
+	defer os.Remove(".state-")
+
+	bs.Start()
+	defer bs.Stop()
+
+	client, err := ic.NewImmuClient(ic.DefaultOptions().WithDialOptions(
+		[]grpc.DialOption{grpc.WithContextDialer(bs.Dialer), grpc.WithInsecure()},
+	))
+	require.NoError(t, err)
+	defer client.Disconnect()
+
+	lr, err := client.Login(context.TODO(), []byte(`immudb`), []byte(`immudb`))
+	require.NoError(t, err)
+
+	md := metadata.Pairs("authorization", lr.Token)
+	ctx := metadata.NewOutgoingContext(context.Background(), md)
+
+	// more entries than a single scan may return
+	entryCount := database.MaxKeyScanLimit + 500
+	setBytes := []byte("StreamZScanReaderTestSet")
+
+	var hdr *schema.TxHeader
+
+	for b := 0; b < entryCount; b += 500 {
+		ops := &schema.ExecAllRequest{}
+
+		for i := b; i < b+500; i++ {
+			k := []byte(fmt.Sprintf("key-%04d", i))
+
+			ops.Operations = append(ops.Operations,
+				&schema.Op{Operation: &schema.Op_Kv{Kv: &schema.KeyValue{Key: k, Value: []byte(fmt.Sprintf("val-%d", i))}}},
+				&schema.Op{Operation: &schema.Op_ZAdd{ZAdd: &schema.ZAddRequest{Set: setBytes, Score: float64(i), Key: k}}},
+			)
+		}
+
+		hdr, err = client.ExecAll(ctx, ops)
+		require.NoError(t, err)
+	}
+
+	t.Run("all entries are streamed", func(t *testing.T) {
+		zr, err := client.StreamZScanReader(ctx, &schema.ZScanRequest{Set: setBytes, SinceTx: hdr.Id})
+		require.NoError(t, err)
+		defer zr.Close()
+
+		for i := 0; i < entryCount; i++ {
+			entry, err := zr.Next()
+			require.NoError(t, err)
+			require.Equal(t, fmt.Sprintf("key-%04d", i), string(entry.Key))
+			require.Equal(t, float64(i), entry.Score)
+			require.Equal(t, fmt.Sprintf("val-%d", i), string(entry.Entry.Value))
+		}
+
+		_, err = zr.Next()
+		require.ErrorIs(t, err, io.EOF)
+	})
+
+	t.Run("limit spanning several pages is honoured", func(t *testing.T) {
+		zEntries, err := client.StreamZScan(ctx, &schema.ZScanRequest{
+			Set:     setBytes,
+			SinceTx: hdr.Id,
+			Limit:   database.MaxKeyScanLimit + 10,
+			Desc:    true,
+		})
+		require.NoError(t, err)
+		require.Len(t, zEntries.Entries, database.MaxKeyScanLimit+10)
+		require.Equal(t, fmt.Sprintf("key-%04d", entryCount-1), string(zEntries.Entries[0].Key))
+		require.Equal(t, fmt.Sprintf("key-%04d", entryCount-database.MaxKeyScanLimit-10), string(zEntries.Entries[database.MaxKeyScanLimit+9].Key))
+	})
+
+	t.Run("reader can be closed before reaching the end", func(t *testing.T) {
+		zr, err := client.StreamZScanReader(ctx, &schema.ZScanRequest{Set: setBytes, SinceTx: hdr.Id})
+		require.NoError(t, err)
+
+		_, err = zr.Next()
+		require.NoError(t, err)
+
+		err = zr.Close()
+		require.NoError(t, err)
+	})
+}
+
+func TestImmuClient_StreamHistoryReader(t *testing.T) {
+	options := server.DefaultOptions().WithAuth(true)
+	bs := servertest.NewBufconnServer(options)
+
+	defer os.RemoveAll(options.Dir)
+	defer os.Remove(".state-")
+
+	bs.Start()
+	defer bs.Stop()
+
+	client, err := ic.NewImmuClient(ic.DefaultOptions().WithDialOptions(
+		[]grpc.DialOption{grpc.WithContextDialer(bs.Dialer), grpc.WithInsecure()},
+	))
+	require.NoError(t, err)
+	defer client.Disconnect()
+
+	lr, err := client.Login(context.TODO(), []byte(`immudb`), []byte(`immudb`))
+	require.NoError(t, err)
+
+	md := metadata.Pairs("authorization", lr.Token)
+	ctx := metadata.NewOutgoingContext(context.Background(), md)
+
+	k := []byte("StreamHistoryReaderTestKey")
+
+	var hdr *schema.TxHeader
+
+	// more revisions than a single history request may return
+	revisions := database.MaxKeyScanLimit + 5
+
+	for i := 0; i < revisions; i++ {
+		hdr, err = client.Set(ctx, k, []byte(fmt.Sprintf("val-%d", i)))
+		require.NoError(t, err)
+	}
+
+	hr, err := client.StreamHistoryReader(ctx, &schema.HistoryRequest{Key: k, SinceTx: hdr.Id})
+	require.NoError(t, err)
+	defer hr.Close()
+
+	for i := 0; i < revisions; i++ {
+		entry, err := hr.Next()
+		require.NoError(t, err)
+		require.Equal(t, k, entry.Key)
+		require.Equal(t, fmt.Sprintf("val-%d", i), string(entry.Value))
+	}
+
+	_, err = hr.Next()
+	require.ErrorIs(t, err, io.EOF)
+
+	hEntries, err := client.StreamHistory(ctx, &schema.HistoryRequest{Key: k, SinceTx: hdr.Id, Offset: 2, Limit: 3, Desc: true})
+	require.NoError(t, err)
+	require.Len(t, hEntries.Entries, 3)
+	require.Equal(t, fmt.Sprintf("val-%d", revisions-3), string(hEntries.Entries[0].Value))
+}
@@ -114,6 +114,118 @@ func TestTransaction_Rollback(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestTransaction_KVAndSQL(t *testing.T) {
+	options := server.DefaultOptions()
+	bs := servertest.NewBufconnServer(options)
+
+	defer os.RemoveAll(options.Dir)
+	defer os.Remove(".state-")
+
+	bs.Start()
+	defer bs.Stop()
+
+	client := ic.NewClient().WithOptions(ic.DefaultOptions().WithDialOptions([]grpc.DialOption{grpc.WithContextDialer(bs.Dialer), grpc.WithInsecure()}))
+
+	err := client.OpenSession(context.TODO(), []byte(`immudb`), []byte(`immudb`), "defaultdb")
+	require.NoError(t, err)
+
+	tx, err := client.NewTx(context.TODO())
+	require.NoError(t, err)
+
+	err = tx.SQLExec(context.TODO(), "CREATE TABLE table1(id INTEGER, PRIMARY KEY id)", nil)
+	require.NoError(t, err)
+
+	err = tx.Set(context.TODO(), []byte("key1"), []byte("value1"))
+	require.NoError(t, err)
+
+	err = tx.SQLExec(context.TODO(), "INSERT INTO table1(id) VALUES (1)", nil)
+	require.NoError(t, err)
+
+	err = tx.SetAll(context.TODO(), &schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte("key2"), Value: []byte("value2")}}})
+	require.NoError(t, err)
+
+	txH, err := tx.Commit(context.TODO())
+	require.NoError(t, err)
+
+	for _, key := range []string{"key1", "key2"} {
+		entry, err := client.Get(context.TODO(), []byte(key))
+		require.NoError(t, err)
+		require.Equal(t, txH.Header.Id, entry.Tx)
+	}
+
+	res, err := client.SQLQuery(context.TODO(), "SELECT id FROM table1", nil, true)
+	require.NoError(t, err)
+	require.Len(t, res.Rows, 1)
+
+	tx, err = client.NewTx(context.TODO())
+	require.NoError(t, err)
+
+	err = tx.Set(context.TODO(), []byte("key3"), []byte("value3"))
+	require.NoError(t, err)
+
+	err = tx.SQLExec(context.TODO(), "INSERT INTO table1(id) VALUES (2)", nil)
+	require.NoError(t, err)
+
+	err = tx.Rollback(context.TODO())
+	require.NoError(t, err)
+
+	_, err = client.Get(context.TODO(), []byte("key3"))
+	require.Error(t, err)
+
+	res, err = client.SQLQuery(context.TODO(), "SELECT id FROM table1", nil, true)
+	require.NoError(t, err)
+	require.Len(t, res.Rows, 1)
+
+	err = client.CloseSession(context.TODO())
+	require.NoError(t, err)
+}
+
+func TestTransaction_Savepoints(t *testing.T) {
+	options := server.DefaultOptions()
+	bs := servertest.NewBufconnServer(options)
+
+	defer os.RemoveAll(options.Dir)
+	defer os.Remove(".state-")
+
+	bs.Start()
+	defer bs.Stop()
+
+	client := ic.NewClient().WithOptions(ic.DefaultOptions().WithDialOptions([]grpc.DialOption{grpc.WithContextDialer(bs.Dialer), grpc.WithInsecure()}))
+
+	err := client.OpenSession(context.TODO(), []byte(`immudb`), []byte(`immudb`), "defaultdb")
+	require.NoError(t, err)
+
+	_, err = client.SQLExec(context.TODO(), "CREATE TABLE table1(id INTEGER, PRIMARY KEY id)", nil)
+	require.NoError(t, err)
+
+	tx, err := client.NewTx(context.TODO())
+	require.NoError(t, err)
+
+	err = tx.SQLExec(context.TODO(), "INSERT INTO table1(id) VALUES (1); SAVEPOINT sp1;", nil)
+	require.NoError(t, err)
+
+	err = tx.SQLExec(context.TODO(), "INSERT INTO table1(id) VALUES (2), (1)", nil)
+	require.Error(t, err)
+
+	err = tx.SQLExec(context.TODO(), "INSERT INTO table1(id) VALUES (3)", nil)
+	require.Error(t, err)
+
+	err = tx.SQLExec(context.TODO(), "ROLLBACK TO SAVEPOINT sp1; INSERT INTO table1(id) VALUES (3);", nil)
+	require.NoError(t, err)
+
+	_, err = tx.Commit(context.TODO())
+	require.NoError(t, err)
+
+	res, err := client.SQLQuery(context.TODO(), "SELECT id FROM table1", nil, true)
+	require.NoError(t, err)
+	require.Len(t, res.Rows, 2)
+	require.Equal(t, int64(1), res.Rows[0].Values[0].GetN())
+	require.Equal(t, int64(3), res.Rows[1].Values[0].GetN())
+
+	err = client.CloseSession(context.TODO())
+	require.NoError(t, err)
+}
+
 func TestTransaction_MultipleReadWriteError(t *testing.T) {
 	options := server.DefaultOptions()
 	bs := servertest.NewBufconnServer(options)
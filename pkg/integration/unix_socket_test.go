@@ -0,0 +1,122 @@
+// +build linux darwin
+
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package integration
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	ic "github.com/codenotary/immudb/pkg/client"
+	"github.com/codenotary/immudb/pkg/server"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnixSocket(t *testing.T) {
+	dir, err := ioutil.TempDir("", "unix_socket")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	socket := filepath.Join(dir, "immudb.sock")
+
+	// a socket left behind by a server which was not gracefully stopped
+	stale, err := net.ListenUnix("unix", &net.UnixAddr{Name: socket, Net: "unix"})
+	require.NoError(t, err)
+	stale.SetUnlinkOnClose(false)
+	stale.Close()
+
+	serverOptions := server.DefaultOptions().
+		WithDir(filepath.Join(dir, "data")).
+		WithAddress(server.UnixSocketScheme + socket).
+		WithMetricsServer(false).
+		WithWebServer(false).
+		WithPgsqlServer(false).
+		WithAuth(true)
+	s := server.DefaultServer().WithOptions(serverOptions).(*server.ImmuServer)
+
+	err = s.Initialize()
+	require.NoError(t, err)
+
+	go func() {
+		s.Start()
+	}()
+
+	fi, err := os.Stat(socket)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(server.UnixSocketPerm), fi.Mode().Perm())
+
+	clientOptions := ic.DefaultOptions().
+		WithDir(dir).
+		WithAddress(ic.UnixSocketScheme + socket)
+
+	client := ic.NewClient().WithOptions(clientOptions)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	err = client.OpenSession(ctx, []byte(`immudb`), []byte(`immudb`), "defaultdb")
+	require.NoError(t, err)
+
+	_, err = client.Set(ctx, []byte("key1"), []byte("value1"))
+	require.NoError(t, err)
+
+	entry, err := client.VerifiedGet(ctx, []byte("key1"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("value1"), entry.Value)
+
+	err = client.CloseSession(ctx)
+	require.NoError(t, err)
+
+	err = s.Stop()
+	require.NoError(t, err)
+
+	_, err = os.Stat(socket)
+	require.True(t, os.IsNotExist(err))
+}
+
+func TestUnixSocketInUse(t *testing.T) {
+	dir, err := ioutil.TempDir("", "unix_socket")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	socket := filepath.Join(dir, "immudb.sock")
+
+	l, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+	defer l.Close()
+
+	serverOptions := server.DefaultOptions().
+		WithDir(filepath.Join(dir, "data")).
+		WithAddress(server.UnixSocketScheme + socket).
+		WithMetricsServer(false).
+		WithWebServer(false).
+		WithPgsqlServer(false)
+	s := server.DefaultServer().WithOptions(serverOptions).(*server.ImmuServer)
+
+	// the socket of a running server is never removed
+	err = s.Initialize()
+	require.Error(t, err)
+
+	_, err = os.Stat(socket)
+	require.NoError(t, err)
+}
@@ -0,0 +1,204 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package integration
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/alert"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	ic "github.com/codenotary/immudb/pkg/client"
+	"github.com/codenotary/immudb/pkg/server"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestWitness(t *testing.T) {
+	//init source server
+	srcServerOpts := server.DefaultOptions().
+		WithMetricsServer(false).
+		WithWebServer(false).
+		WithPgsqlServer(false).
+		WithPort(0).
+		WithDir("witness-src-data")
+
+	srcServer := server.DefaultServer().WithOptions(srcServerOpts).(*server.ImmuServer)
+	defer os.RemoveAll(srcServerOpts.Dir)
+
+	err := srcServer.Initialize()
+	require.NoError(t, err)
+
+	go func() {
+		srcServer.Start()
+	}()
+
+	time.Sleep(1 * time.Second)
+
+	defer srcServer.Stop()
+
+	srcPort := srcServer.Listener.Addr().(*net.TCPAddr).Port
+
+	alerts := make(chan *alert.Alert, 10)
+
+	alertReceiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var a alert.Alert
+		err := json.NewDecoder(r.Body).Decode(&a)
+		require.NoError(t, err)
+
+		alerts <- &a
+	}))
+	defer alertReceiver.Close()
+
+	//init witness server
+	witnessServerOpts := server.DefaultOptions().
+		WithMetricsServer(false).
+		WithWebServer(false).
+		WithPgsqlServer(false).
+		WithPort(0).
+		WithDir("witness-data").
+		WithWitnessOptions((&server.WitnessOptions{}).
+			WithDatabase("defaultdb").
+			WithSource("src").
+			WithSrcDatabase("defaultdb").
+			WithSrcAddress("127.0.0.1").
+			WithSrcPort(srcPort).
+			WithSrcUsername("immudb").
+			WithSrcPassword("immudb").
+			WithFetchInterval(100 * time.Millisecond)).
+		WithAlertOptions(alert.DefaultOptions().WithWebhookURL(alertReceiver.URL))
+
+	witnessServer := server.DefaultServer().WithOptions(witnessServerOpts).(*server.ImmuServer)
+	defer os.RemoveAll(witnessServerOpts.Dir)
+
+	err = witnessServer.Initialize()
+	require.NoError(t, err)
+
+	go func() {
+		witnessServer.Start()
+	}()
+
+	time.Sleep(1 * time.Second)
+
+	defer witnessServer.Stop()
+
+	// init source client
+	srcClient, err := ic.NewImmuClient(ic.DefaultOptions().WithPort(srcPort))
+	require.NoError(t, err)
+	defer srcClient.Disconnect()
+
+	slr, err := srcClient.Login(context.TODO(), []byte(`immudb`), []byte(`immudb`))
+	require.NoError(t, err)
+
+	sctx := metadata.NewOutgoingContext(context.Background(), metadata.Pairs("authorization", slr.Token))
+
+	// init witness client
+	witnessPort := witnessServer.Listener.Addr().(*net.TCPAddr).Port
+	witnessClient, err := ic.NewImmuClient(ic.DefaultOptions().WithPort(witnessPort))
+	require.NoError(t, err)
+	defer witnessClient.Disconnect()
+
+	wlr, err := witnessClient.Login(context.TODO(), []byte(`immudb`), []byte(`immudb`))
+	require.NoError(t, err)
+
+	wctx := metadata.NewOutgoingContext(context.Background(), metadata.Pairs("authorization", wlr.Token))
+
+	for i := 0; i < 3; i++ {
+		_, err = srcClient.Set(sctx, []byte("key"), []byte{byte(i)})
+		require.NoError(t, err)
+
+		srcState, err := srcClient.CurrentState(sctx)
+		require.NoError(t, err)
+
+		require.Eventually(t, func() bool {
+			roots, err := witnessClient.ExternalRoots(wctx, &schema.ExternalRootsRequest{Source: "src", Desc: true, Limit: 1})
+			return err == nil && len(roots.Roots) == 1 && roots.Roots[0].TreeSize == srcState.TxId
+		}, 10*time.Second, 100*time.Millisecond)
+	}
+
+	roots, err := witnessClient.ExternalRoots(wctx, &schema.ExternalRootsRequest{Source: "src"})
+	require.NoError(t, err)
+	require.NotEmpty(t, roots.Roots)
+
+	for i, root := range roots.Roots {
+		require.Equal(t, schema.ExternalRootType_IMMUDB, root.Type)
+		require.Len(t, root.RootHash, sha256.Size)
+		// the first observed root is trusted, the following ones are proven to be consistent with it
+		require.Equal(t, i > 0, root.Verified)
+	}
+
+	t.Run("roots registered through the API are not verified", func(t *testing.T) {
+		h := sha256.Sum256([]byte("sth"))
+
+		_, err := witnessClient.RegisterExternalRoot(wctx, &schema.ExternalRoot{
+			Source:   "ct",
+			Type:     schema.ExternalRootType_CT_LOG,
+			TreeSize: 10,
+			RootHash: h[:],
+			Verified: true,
+		})
+		require.NoError(t, err)
+
+		h = sha256.Sum256([]byte("forked sth"))
+
+		_, err = witnessClient.RegisterExternalRoot(wctx, &schema.ExternalRoot{
+			Source:   "ct",
+			Type:     schema.ExternalRootType_CT_LOG,
+			TreeSize: 10,
+			RootHash: h[:],
+		})
+		require.Error(t, err)
+
+		roots, err := witnessClient.ExternalRoots(wctx, &schema.ExternalRootsRequest{Source: "ct"})
+		require.NoError(t, err)
+		require.Len(t, roots.Roots, 1)
+		require.False(t, roots.Roots[0].Verified)
+	})
+
+	t.Run("inconsistent roots are alerted", func(t *testing.T) {
+		srcState, err := srcClient.CurrentState(sctx)
+		require.NoError(t, err)
+
+		// a forged root ahead of the source database makes its actual root look like a rollback
+		h := sha256.Sum256([]byte("forged"))
+
+		_, err = witnessClient.RegisterExternalRoot(wctx, &schema.ExternalRoot{
+			Source:   "src",
+			Type:     schema.ExternalRootType_IMMUDB,
+			TreeSize: srcState.TxId + 10,
+			RootHash: h[:],
+		})
+		require.NoError(t, err)
+
+		select {
+		case a := <-alerts:
+			require.Equal(t, alert.DetectorWitness, a.Detector)
+			require.Equal(t, "defaultdb", a.Database)
+			require.Equal(t, srcState.TxId+10, a.PrevRoot.TxID)
+			require.Equal(t, srcState.TxId, a.CurrRoot.TxID)
+		case <-time.After(10 * time.Second):
+			require.Fail(t, "no alert received")
+		}
+	})
+}
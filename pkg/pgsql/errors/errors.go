@@ -41,6 +41,9 @@ var ErrParametersValueSizeTooLarge = errors.New("provided parameters exceeded th
 var ErrNegativeParameterValueLen = errors.New("negative parameter length detected")
 var ErrMalformedMessage = errors.New("malformed message detected")
 var ErrMessageTooLarge = errors.New("payload message hit  allowed memory boundaries")
+var ErrCopyFailed = errors.New("COPY from stdin failed")
+var ErrMalformedCopyData = errors.New("malformed COPY data")
+var ErrCopyFormatNotSupported = errors.New("COPY format not supported")
 
 func MapPgError(err error) (er bm.ErrorResp) {
 	switch {
@@ -100,6 +103,16 @@ func MapPgError(err error) (er bm.ErrorResp) {
 			bm.Code(pgmeta.PgServerErrProtocolViolation),
 			bm.Message(err.Error()),
 		)
+	case errors.Is(err, ErrCopyFailed):
+		er = bm.ErrorResponse(bm.Severity(pgmeta.PgSeverityError),
+			bm.Code(pgmeta.QueryCanceled),
+			bm.Message(err.Error()),
+		)
+	case errors.Is(err, ErrMalformedCopyData):
+		er = bm.ErrorResponse(bm.Severity(pgmeta.PgSeverityError),
+			bm.Code(pgmeta.BadCopyFileFormat),
+			bm.Message(err.Error()),
+		)
 	default:
 		er = bm.ErrorResponse(bm.Severity(pgmeta.PgSeverityError),
 			bm.Message(err.Error()),
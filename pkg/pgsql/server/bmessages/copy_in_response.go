@@ -0,0 +1,43 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bmessages
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// CopyInResponse tells the client the server is ready to receive COPY data. Format is 0 for textual data and 1 for
+// binary, columns take the same format of the overall data.
+func CopyInResponse(format int8, colNumb int) []byte {
+	messageType := []byte(`G`)
+
+	overallFormat := []byte{byte(format)}
+
+	colNumbB := make([]byte, 2)
+	binary.BigEndian.PutUint16(colNumbB, uint16(colNumb))
+
+	colFormats := make([]byte, 2*colNumb)
+	for i := 0; i < colNumb; i++ {
+		binary.BigEndian.PutUint16(colFormats[i*2:], uint16(format))
+	}
+
+	messageLength := make([]byte, 4)
+	binary.BigEndian.PutUint32(messageLength, uint32(4+1+2+len(colFormats)))
+
+	return bytes.Join([][]byte{messageType, messageLength, overallFormat, colNumbB, colFormats}, nil)
+}
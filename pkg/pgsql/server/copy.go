@@ -0,0 +1,759 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/csv"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/codenotary/immudb/embedded/sql"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	pserr "github.com/codenotary/immudb/pkg/pgsql/errors"
+	bm "github.com/codenotary/immudb/pkg/pgsql/server/bmessages"
+	fm "github.com/codenotary/immudb/pkg/pgsql/server/fmessages"
+)
+
+// copyBatchSize is the number of rows inserted per transaction while loading COPY data.
+// immudb transactions are bounded in the number of entries, so rows can not be loaded within a single one. As a
+// consequence, a failing COPY keeps the batches committed before the failure.
+const copyBatchSize = 100
+
+const (
+	copyFormatText   = "text"
+	copyFormatCSV    = "csv"
+	copyFormatBinary = "binary"
+)
+
+var copyFromStdin = regexp.MustCompile(`(?is)^\s*copy\s+("?[\w.]+"?)\s*(?:\(([^)]*)\))?\s*from\s+stdin\b(.*?)[\s;]*$`)
+var copyQuotedOpt = regexp.MustCompile(`'[^']*'`)
+var copyFormatOpt = regexp.MustCompile(`(?i)\b(text|csv|binary)\b`)
+var copyHeaderOpt = regexp.MustCompile(`(?i)\bheader\b(?:\s+(true|on|1|false|off|0)\b)?`)
+var copyDelimiterOpt = regexp.MustCompile(`(?i)\bdelimiter\s+(?:as\s+)?'([^']*)'`)
+var copyNullOpt = regexp.MustCompile(`(?i)\bnull\s+(?:as\s+)?'([^']*)'`)
+
+var pgCopySignature = []byte("PGCOPY\n\377\r\n\000")
+
+type copyFromStmt struct {
+	table     string
+	cols      []string
+	format    string
+	header    bool
+	delimiter rune
+	null      string
+}
+
+// parseCopyFromStdin returns the COPY FROM STDIN statement contained in the query, or nil if it's not a COPY
+// statement. Both the current options syntax `WITH (FORMAT csv, HEADER)` and the legacy one `WITH CSV HEADER` are
+// accepted.
+func parseCopyFromStdin(statement string) (*copyFromStmt, error) {
+	m := copyFromStdin.FindStringSubmatch(statement)
+	if m == nil {
+		return nil, nil
+	}
+
+	cp := &copyFromStmt{
+		table:     unquoteIdentifier(m[1]),
+		format:    copyFormatText,
+		delimiter: '\t',
+		null:      `\N`,
+	}
+
+	if strings.TrimSpace(m[2]) != "" {
+		for _, col := range strings.Split(m[2], ",") {
+			cp.cols = append(cp.cols, unquoteIdentifier(col))
+		}
+	}
+
+	opts := m[3]
+	// quoted values are removed so to not be mistaken for option names
+	unquotedOpts := copyQuotedOpt.ReplaceAllString(opts, "''")
+
+	if f := copyFormatOpt.FindStringSubmatch(unquotedOpts); f != nil {
+		cp.format = strings.ToLower(f[1])
+	}
+
+	if cp.format == copyFormatCSV {
+		cp.delimiter = ','
+		cp.null = ""
+	}
+
+	if h := copyHeaderOpt.FindStringSubmatch(unquotedOpts); h != nil {
+		switch strings.ToLower(h[1]) {
+		case "", "true", "on", "1":
+			cp.header = true
+		}
+	}
+
+	if d := copyDelimiterOpt.FindStringSubmatch(opts); d != nil {
+		if len(d[1]) != 1 {
+			return nil, fmt.Errorf("%w: COPY delimiter must be a single one-byte character", pserr.ErrCopyFormatNotSupported)
+		}
+		cp.delimiter = rune(d[1][0])
+	}
+
+	if n := copyNullOpt.FindStringSubmatch(opts); n != nil {
+		cp.null = n[1]
+	}
+
+	if cp.format == copyFormatBinary && cp.header {
+		return nil, fmt.Errorf("%w: HEADER is not available in BINARY mode", pserr.ErrCopyFormatNotSupported)
+	}
+
+	return cp, nil
+}
+
+func unquoteIdentifier(id string) string {
+	return strings.Trim(strings.TrimSpace(id), `"`)
+}
+
+// copyFrom loads the rows sent by the client through the COPY sub-protocol into the table
+func (s *session) copyFrom(cp *copyFromStmt) error {
+	cols := cp.cols
+
+	if len(cols) == 0 {
+		res, err := s.database.DescribeTable(cp.table, nil)
+		if err != nil {
+			return err
+		}
+
+		for _, row := range res.Rows {
+			cols = append(cols, row.Values[0].GetS())
+		}
+	}
+
+	colTypes, err := s.copyColTypes(cp.table, cols)
+	if err != nil {
+		return err
+	}
+
+	var format int8
+	if cp.format == copyFormatBinary {
+		format = 1
+	}
+
+	if _, err = s.writeMessage(bm.CopyInResponse(format, len(cols))); err != nil {
+		return err
+	}
+
+	r := &copyInReader{s: s}
+
+	var dec copyDecoder
+
+	switch cp.format {
+	case copyFormatCSV:
+		dec = newCSVCopyDecoder(r, cp, colTypes)
+	case copyFormatBinary:
+		dec = newBinaryCopyDecoder(r, colTypes)
+	default:
+		dec = newTextCopyDecoder(r, cp, colTypes)
+	}
+
+	n, err := s.loadCopyRows(cp.table, cols, dec)
+	if err != nil {
+		// data sent after the failure is discarded up to the end of the COPY
+		r.drain()
+		return err
+	}
+
+	// anything following the end-of-data marker is ignored, but a COPY failure is still reported
+	if err = r.drain(); err != nil {
+		return err
+	}
+
+	_, err = s.writeMessage(bm.CommandComplete([]byte(fmt.Sprintf("COPY %d", n))))
+	return err
+}
+
+// copyColTypes returns the types of the columns as inferred from an insertion into the table
+func (s *session) copyColTypes(table string, cols []string) ([]sql.SQLValueType, error) {
+	stmt, err := parseCopyInsert(table, cols, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	params, err := s.database.InferParametersPrepared(stmt, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	colTypes := make([]sql.SQLValueType, len(cols))
+
+	for i := range cols {
+		t, ok := params[copyParamName(0, i)]
+		if !ok {
+			return nil, fmt.Errorf("%w: unable to infer the type of column %s", pserr.ErrCopyFormatNotSupported, cols[i])
+		}
+		colTypes[i] = t
+	}
+
+	return colTypes, nil
+}
+
+func (s *session) loadCopyRows(table string, cols []string, dec copyDecoder) (int, error) {
+	// the statement inserting a full batch is reused across batches
+	batchStmt, err := parseCopyInsert(table, cols, copyBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	n := 0
+	batch := make([][]interface{}, 0, copyBatchSize)
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+
+		stmt := batchStmt
+
+		if len(batch) < copyBatchSize {
+			stmt, err = parseCopyInsert(table, cols, len(batch))
+			if err != nil {
+				return err
+			}
+		}
+
+		params := make(map[string]interface{}, len(batch)*len(cols))
+
+		for i, row := range batch {
+			for j, val := range row {
+				params[copyParamName(i, j)] = val
+			}
+		}
+
+		namedParams, err := schema.EncodeParams(params)
+		if err != nil {
+			return err
+		}
+
+		_, _, err = s.database.SQLExecPrepared([]sql.SQLStmt{stmt}, namedParams, nil)
+		if err != nil {
+			return err
+		}
+
+		n += len(batch)
+		batch = batch[:0]
+
+		return nil
+	}
+
+	for {
+		row, err := dec.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return n, err
+		}
+
+		batch = append(batch, row)
+
+		if len(batch) == copyBatchSize {
+			if err = flush(); err != nil {
+				return n, err
+			}
+		}
+	}
+
+	return n, flush()
+}
+
+func copyParamName(row, col int) string {
+	return fmt.Sprintf("r%dc%d", row, col)
+}
+
+// parseCopyInsert builds the statement inserting rowCount rows into the table
+func parseCopyInsert(table string, cols []string, rowCount int) (sql.SQLStmt, error) {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "INSERT INTO %s(%s) VALUES ", table, strings.Join(cols, ", "))
+
+	for i := 0; i < rowCount; i++ {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+
+		sb.WriteString("(")
+		for j := range cols {
+			if j > 0 {
+				sb.WriteString(", ")
+			}
+			sb.WriteString("@" + copyParamName(i, j))
+		}
+		sb.WriteString(")")
+	}
+
+	stmts, err := sql.Parse(strings.NewReader(sb.String()))
+	if err != nil {
+		return nil, err
+	}
+
+	return stmts[0], nil
+}
+
+// copyInReader streams the data of the CopyData messages sent by the client until the COPY is completed
+type copyInReader struct {
+	s    *session
+	buf  []byte
+	done bool
+}
+
+func (r *copyInReader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		if r.done {
+			return 0, io.EOF
+		}
+
+		msg, _, err := r.s.nextMessage()
+		if err != nil {
+			r.done = true
+			return 0, err
+		}
+
+		switch m := msg.(type) {
+		case fm.CopyDataMsg:
+			r.buf = m.Data
+		case fm.CopyDoneMsg:
+			r.done = true
+		case fm.CopyFailMsg:
+			r.done = true
+			return 0, fmt.Errorf("%w: %s", pserr.ErrCopyFailed, m.Message)
+		case fm.FlushMsg, fm.SyncMsg:
+			// ignored while in copy-in mode
+		default:
+			r.done = true
+			return 0, fmt.Errorf("%w: unexpected message during COPY", pserr.ErrMalformedMessage)
+		}
+	}
+
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+
+	return n, nil
+}
+
+// drain discards the remaining data up to the end of the COPY
+func (r *copyInReader) drain() error {
+	_, err := io.Copy(ioutil.Discard, r)
+	return err
+}
+
+type copyDecoder interface {
+	// next returns the values of the next row, io.EOF once all rows were read
+	next() ([]interface{}, error)
+}
+
+type textCopyDecoder struct {
+	r         *bufio.Reader
+	delimiter byte
+	null      string
+	header    bool
+	colTypes  []sql.SQLValueType
+}
+
+func newTextCopyDecoder(r io.Reader, cp *copyFromStmt, colTypes []sql.SQLValueType) *textCopyDecoder {
+	return &textCopyDecoder{
+		r:         bufio.NewReader(r),
+		delimiter: byte(cp.delimiter),
+		null:      cp.null,
+		header:    cp.header,
+		colTypes:  colTypes,
+	}
+}
+
+func (d *textCopyDecoder) next() ([]interface{}, error) {
+	for {
+		line, err := d.r.ReadString('\n')
+		if err == io.EOF && line == "" {
+			return nil, io.EOF
+		}
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+
+		line = strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+
+		if line == `\.` {
+			return nil, io.EOF
+		}
+
+		if d.header {
+			d.header = false
+			continue
+		}
+
+		fields := splitCopyText(line, d.delimiter)
+		if len(fields) != len(d.colTypes) {
+			return nil, fmt.Errorf("%w: expected %d columns but %d were found", pserr.ErrMalformedCopyData, len(d.colTypes), len(fields))
+		}
+
+		row := make([]interface{}, len(fields))
+
+		for i, f := range fields {
+			if f == d.null {
+				continue
+			}
+
+			row[i], err = copyTextValue(d.colTypes[i], unescapeCopyText(f))
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		return row, nil
+	}
+}
+
+// splitCopyText splits the line into its fields, escaped delimiters are not taken as separators
+func splitCopyText(line string, delimiter byte) []string {
+	var fields []string
+
+	start := 0
+
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			i++
+		case delimiter:
+			fields = append(fields, line[start:i])
+			start = i + 1
+		}
+	}
+
+	return append(fields, line[start:])
+}
+
+// unescapeCopyText resolves the backslash sequences of the text format
+func unescapeCopyText(f string) string {
+	if !strings.Contains(f, `\`) {
+		return f
+	}
+
+	var sb strings.Builder
+
+	for i := 0; i < len(f); i++ {
+		c := f[i]
+
+		if c != '\\' || i == len(f)-1 {
+			sb.WriteByte(c)
+			continue
+		}
+
+		i++
+
+		switch f[i] {
+		case 'b':
+			sb.WriteByte('\b')
+		case 'f':
+			sb.WriteByte('\f')
+		case 'n':
+			sb.WriteByte('\n')
+		case 'r':
+			sb.WriteByte('\r')
+		case 't':
+			sb.WriteByte('\t')
+		case 'v':
+			sb.WriteByte('\v')
+		case 'x':
+			j := i + 1
+			for j < len(f) && j < i+3 && isHexDigit(f[j]) {
+				j++
+			}
+			if j == i+1 {
+				sb.WriteByte('x')
+				continue
+			}
+			v, _ := strconv.ParseUint(f[i+1:j], 16, 8)
+			sb.WriteByte(byte(v))
+			i = j - 1
+		case '0', '1', '2', '3', '4', '5', '6', '7':
+			j := i
+			for j < len(f) && j < i+3 && f[j] >= '0' && f[j] <= '7' {
+				j++
+			}
+			v, _ := strconv.ParseUint(f[i:j], 8, 16)
+			sb.WriteByte(byte(v))
+			i = j - 1
+		default:
+			sb.WriteByte(f[i])
+		}
+	}
+
+	return sb.String()
+}
+
+func isHexDigit(c byte) bool {
+	return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
+
+type csvCopyDecoder struct {
+	r        *csv.Reader
+	null     string
+	header   bool
+	colTypes []sql.SQLValueType
+}
+
+func newCSVCopyDecoder(r io.Reader, cp *copyFromStmt, colTypes []sql.SQLValueType) *csvCopyDecoder {
+	cr := csv.NewReader(r)
+	cr.Comma = cp.delimiter
+	cr.FieldsPerRecord = len(colTypes)
+	cr.ReuseRecord = true
+
+	return &csvCopyDecoder{
+		r:        cr,
+		null:     cp.null,
+		header:   cp.header,
+		colTypes: colTypes,
+	}
+}
+
+func (d *csvCopyDecoder) next() ([]interface{}, error) {
+	for {
+		record, err := d.r.Read()
+		if err != nil {
+			var perr *csv.ParseError
+			if errors.As(err, &perr) {
+				return nil, fmt.Errorf("%w: %s", pserr.ErrMalformedCopyData, perr.Error())
+			}
+			return nil, err
+		}
+
+		if len(record) == 1 && record[0] == `\.` {
+			return nil, io.EOF
+		}
+
+		if d.header {
+			d.header = false
+			continue
+		}
+
+		row := make([]interface{}, len(record))
+
+		for i, f := range record {
+			// quoted and unquoted values are not told apart, so a quoted value matching the null string is null as well
+			if f == d.null {
+				continue
+			}
+
+			row[i], err = copyTextValue(d.colTypes[i], f)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		return row, nil
+	}
+}
+
+type binaryCopyDecoder struct {
+	r        *bufio.Reader
+	started  bool
+	colTypes []sql.SQLValueType
+}
+
+func newBinaryCopyDecoder(r io.Reader, colTypes []sql.SQLValueType) *binaryCopyDecoder {
+	return &binaryCopyDecoder{
+		r:        bufio.NewReader(r),
+		colTypes: colTypes,
+	}
+}
+
+func (d *binaryCopyDecoder) readHeader() error {
+	sig := make([]byte, len(pgCopySignature))
+
+	if _, err := io.ReadFull(d.r, sig); err != nil {
+		return d.wrapErr(err)
+	}
+
+	if !bytes.Equal(sig, pgCopySignature) {
+		return fmt.Errorf("%w: COPY file signature not recognized", pserr.ErrMalformedCopyData)
+	}
+
+	// flags field
+	if _, err := d.readInt32(); err != nil {
+		return err
+	}
+
+	extLen, err := d.readInt32()
+	if err != nil {
+		return err
+	}
+	if extLen < 0 {
+		return fmt.Errorf("%w: invalid COPY file header", pserr.ErrMalformedCopyData)
+	}
+
+	if _, err := io.CopyN(ioutil.Discard, d.r, int64(extLen)); err != nil {
+		return d.wrapErr(err)
+	}
+
+	return nil
+}
+
+func (d *binaryCopyDecoder) next() ([]interface{}, error) {
+	if !d.started {
+		if err := d.readHeader(); err != nil {
+			return nil, err
+		}
+		d.started = true
+	}
+
+	b := make([]byte, 2)
+
+	if _, err := io.ReadFull(d.r, b); err != nil {
+		return nil, d.wrapErr(err)
+	}
+
+	fieldCount := int16(binary.BigEndian.Uint16(b))
+
+	// file trailer
+	if fieldCount == -1 {
+		return nil, io.EOF
+	}
+
+	if int(fieldCount) != len(d.colTypes) {
+		return nil, fmt.Errorf("%w: expected %d columns but %d were found", pserr.ErrMalformedCopyData, len(d.colTypes), fieldCount)
+	}
+
+	row := make([]interface{}, fieldCount)
+
+	for i := range row {
+		fieldLen, err := d.readInt32()
+		if err != nil {
+			return nil, err
+		}
+
+		if fieldLen == -1 {
+			continue
+		}
+
+		if fieldLen < 0 {
+			return nil, fmt.Errorf("%w: negative field length", pserr.ErrMalformedCopyData)
+		}
+
+		v := make([]byte, fieldLen)
+
+		if _, err := io.ReadFull(d.r, v); err != nil {
+			return nil, d.wrapErr(err)
+		}
+
+		row[i], err = copyBinaryValue(d.colTypes[i], v)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return row, nil
+}
+
+func (d *binaryCopyDecoder) readInt32() (int32, error) {
+	b := make([]byte, 4)
+
+	if _, err := io.ReadFull(d.r, b); err != nil {
+		return 0, d.wrapErr(err)
+	}
+
+	return int32(binary.BigEndian.Uint32(b)), nil
+}
+
+// wrapErr reports data ending before the file trailer as malformed
+func (d *binaryCopyDecoder) wrapErr(err error) error {
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return fmt.Errorf("%w: unexpected end of COPY data", pserr.ErrMalformedCopyData)
+	}
+	return err
+}
+
+var copyTimestampLayouts = []string{
+	"2006-01-02 15:04:05.999999",
+	"2006-01-02 15:04:05.999999Z07:00",
+	time.RFC3339Nano,
+	"2006-01-02",
+}
+
+func copyTextValue(t sql.SQLValueType, v string) (interface{}, error) {
+	switch t {
+	case sql.IntegerType:
+		i, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid INTEGER value '%s'", pserr.ErrMalformedCopyData, v)
+		}
+		return i, nil
+	case sql.BooleanType:
+		switch strings.ToLower(v) {
+		case "t", "true", "y", "yes", "on", "1":
+			return true, nil
+		case "f", "false", "n", "no", "off", "0":
+			return false, nil
+		}
+		return nil, fmt.Errorf("%w: invalid BOOLEAN value '%s'", pserr.ErrMalformedCopyData, v)
+	case sql.BLOBType:
+		// bytea hex format
+		d, err := hex.DecodeString(strings.TrimPrefix(v, `\x`))
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid BLOB value '%s'", pserr.ErrMalformedCopyData, v)
+		}
+		return d, nil
+	case sql.TimestampType:
+		for _, layout := range copyTimestampLayouts {
+			ts, err := time.Parse(layout, v)
+			if err == nil {
+				return ts, nil
+			}
+		}
+		return nil, fmt.Errorf("%w: invalid TIMESTAMP value '%s'", pserr.ErrMalformedCopyData, v)
+	}
+
+	return v, nil
+}
+
+func copyBinaryValue(t sql.SQLValueType, v []byte) (interface{}, error) {
+	switch t {
+	case sql.IntegerType:
+		i, err := getInt64(v)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", pserr.ErrMalformedCopyData, err.Error())
+		}
+		return i, nil
+	case sql.BooleanType:
+		if len(v) != 1 {
+			return nil, fmt.Errorf("%w: invalid BOOLEAN value", pserr.ErrMalformedCopyData)
+		}
+		return v[0] == 1, nil
+	case sql.BLOBType:
+		return v, nil
+	case sql.TimestampType:
+		// timestamps are exposed as int8 values
+		i, err := getInt64(v)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", pserr.ErrMalformedCopyData, err.Error())
+		}
+		return sql.TimeFromInt64(i), nil
+	}
+
+	return string(v), nil
+}
@@ -0,0 +1,141 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/codenotary/immudb/embedded/sql"
+	pserr "github.com/codenotary/immudb/pkg/pgsql/errors"
+	h "github.com/codenotary/immudb/pkg/pgsql/server/fmessages/fmessages_test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCopyFromStdin(t *testing.T) {
+	cp, err := parseCopyFromStdin("SELECT * FROM table1")
+	require.NoError(t, err)
+	require.Nil(t, cp)
+
+	cp, err = parseCopyFromStdin("COPY table1 FROM STDIN;")
+	require.NoError(t, err)
+	require.Equal(t, &copyFromStmt{table: "table1", format: copyFormatText, delimiter: '\t', null: `\N`}, cp)
+
+	cp, err = parseCopyFromStdin(`copy "table1" ( "id", "title" ) from stdin binary;`)
+	require.NoError(t, err)
+	require.Equal(t, "table1", cp.table)
+	require.Equal(t, []string{"id", "title"}, cp.cols)
+	require.Equal(t, copyFormatBinary, cp.format)
+
+	cp, err = parseCopyFromStdin("COPY table1 (id) FROM STDIN WITH (FORMAT csv, HEADER true, DELIMITER ';', NULL 'text')")
+	require.NoError(t, err)
+	require.Equal(t, copyFormatCSV, cp.format)
+	require.True(t, cp.header)
+	require.Equal(t, ';', cp.delimiter)
+	require.Equal(t, "text", cp.null)
+
+	cp, err = parseCopyFromStdin("COPY table1 FROM STDIN WITH CSV HEADER")
+	require.NoError(t, err)
+	require.Equal(t, copyFormatCSV, cp.format)
+	require.True(t, cp.header)
+	require.Equal(t, ',', cp.delimiter)
+	require.Equal(t, "", cp.null)
+
+	_, err = parseCopyFromStdin("COPY table1 FROM STDIN WITH (DELIMITER '::')")
+	require.ErrorIs(t, err, pserr.ErrCopyFormatNotSupported)
+
+	_, err = parseCopyFromStdin("COPY table1 FROM STDIN WITH (FORMAT binary, HEADER)")
+	require.ErrorIs(t, err, pserr.ErrCopyFormatNotSupported)
+}
+
+func TestTextCopyDecoder(t *testing.T) {
+	cp := &copyFromStmt{delimiter: '|', null: "NULL", header: true}
+	colTypes := []sql.SQLValueType{sql.IntegerType, sql.VarcharType, sql.TimestampType}
+
+	data := "id|title|ts\n1|a\\nb\\|c\\101|2022-01-02 03:04:05\r\n2|NULL|2022-01-02\n\\.\nignored\n"
+
+	dec := newTextCopyDecoder(bytes.NewBufferString(data), cp, colTypes)
+
+	row, err := dec.next()
+	require.NoError(t, err)
+	require.Equal(t, []interface{}{int64(1), "a\nb|cA", time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC)}, row)
+
+	row, err = dec.next()
+	require.NoError(t, err)
+	require.Equal(t, []interface{}{int64(2), nil, time.Date(2022, 1, 2, 0, 0, 0, 0, time.UTC)}, row)
+
+	_, err = dec.next()
+	require.Equal(t, io.EOF, err)
+
+	dec = newTextCopyDecoder(bytes.NewBufferString("1\n"), &copyFromStmt{delimiter: '\t'}, colTypes)
+	_, err = dec.next()
+	require.ErrorIs(t, err, pserr.ErrMalformedCopyData)
+
+	dec = newTextCopyDecoder(bytes.NewBufferString("a\tb\t2022-01-02\n"), &copyFromStmt{delimiter: '\t'}, colTypes)
+	_, err = dec.next()
+	require.ErrorIs(t, err, pserr.ErrMalformedCopyData)
+}
+
+func TestCSVCopyDecoder(t *testing.T) {
+	colTypes := []sql.SQLValueType{sql.IntegerType, sql.VarcharType, sql.BooleanType}
+
+	dec := newCSVCopyDecoder(bytes.NewBufferString("1,\"multi\nline\",true\n2,,off\n"), &copyFromStmt{delimiter: ','}, colTypes)
+
+	row, err := dec.next()
+	require.NoError(t, err)
+	require.Equal(t, []interface{}{int64(1), "multi\nline", true}, row)
+
+	row, err = dec.next()
+	require.NoError(t, err)
+	require.Equal(t, []interface{}{int64(2), nil, false}, row)
+
+	_, err = dec.next()
+	require.Equal(t, io.EOF, err)
+
+	dec = newCSVCopyDecoder(bytes.NewBufferString("1,a\n"), &copyFromStmt{delimiter: ','}, colTypes)
+	_, err = dec.next()
+	require.ErrorIs(t, err, pserr.ErrMalformedCopyData)
+}
+
+func TestBinaryCopyDecoder(t *testing.T) {
+	colTypes := []sql.SQLValueType{sql.IntegerType, sql.VarcharType, sql.BLOBType}
+
+	data := h.Join([][]byte{
+		pgCopySignature, h.I32(0), h.I32(0),
+		h.I16(3), h.I32(8), {0, 0, 0, 0, 0, 0, 0, 1}, h.I32(5), []byte("title"), h.I32(-1),
+		h.I16(-1),
+	})
+
+	dec := newBinaryCopyDecoder(bytes.NewBuffer(data), colTypes)
+
+	row, err := dec.next()
+	require.NoError(t, err)
+	require.Equal(t, []interface{}{int64(1), "title", nil}, row)
+
+	_, err = dec.next()
+	require.Equal(t, io.EOF, err)
+
+	dec = newBinaryCopyDecoder(bytes.NewBufferString("PGCOPY"), colTypes)
+	_, err = dec.next()
+	require.ErrorIs(t, err, pserr.ErrMalformedCopyData)
+
+	dec = newBinaryCopyDecoder(bytes.NewBuffer(h.Join([][]byte{pgCopySignature, h.I32(0), h.I32(0), h.I16(3), h.I32(8)})), colTypes)
+	_, err = dec.next()
+	require.ErrorIs(t, err, pserr.ErrMalformedCopyData)
+}
@@ -0,0 +1,219 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package server
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/codenotary/immudb/embedded/sql"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	pserr "github.com/codenotary/immudb/pkg/pgsql/errors"
+	bm "github.com/codenotary/immudb/pkg/pgsql/server/bmessages"
+)
+
+var declareCursor = regexp.MustCompile(`(?is)^\s*declare\s+(\w+)\s+(?:(?:binary|insensitive|asensitive|scroll|no\s+scroll)\s+)*cursor\s+(?:(?:with|without)\s+hold\s+)?for\s+(.+?)[\s;]*$`)
+var fetchCursor = regexp.MustCompile(`(?is)^\s*fetch\s+(?:(next|all|forward\s+all|forward\s+\d+|forward|\d+)\s+)?(?:(?:from|in)\s+)?(\w+)[\s;]*$`)
+var closeCursor = regexp.MustCompile(`(?is)^\s*close\s+(\w+)[\s;]*$`)
+
+// Cursors are portals created through SQL statements, so they share the namespace of the portals of the extended
+// query protocol as in postgres. Results are computed when the cursor is declared and then sent in batches.
+type declareCursorStmt struct {
+	name  string
+	query string
+}
+
+type fetchCursorStmt struct {
+	name string
+	// count is the maximum number of rows to be fetched, zero fetching all the remaining ones
+	count int
+}
+
+type closeCursorStmt struct {
+	name string
+}
+
+func parseCursorStmt(statement string) interface{} {
+	if m := declareCursor.FindStringSubmatch(statement); m != nil {
+		return &declareCursorStmt{name: m[1], query: m[2]}
+	}
+
+	if m := fetchCursor.FindStringSubmatch(statement); m != nil {
+		fetch := &fetchCursorStmt{name: m[2], count: 1}
+
+		direction := strings.Fields(strings.ToLower(m[1]))
+
+		switch {
+		case len(direction) == 0, direction[0] == "next", len(direction) == 1 && direction[0] == "forward":
+		case direction[len(direction)-1] == "all":
+			fetch.count = 0
+		default:
+			// only digits are matched, so the conversion can not fail
+			fetch.count, _ = strconv.Atoi(direction[len(direction)-1])
+		}
+
+		return fetch
+	}
+
+	if m := closeCursor.FindStringSubmatch(statement); m != nil {
+		return &closeCursorStmt{name: m[1]}
+	}
+
+	return nil
+}
+
+func (s *session) declareCursor(st *declareCursorStmt) error {
+	if _, ok := s.portals[st.name]; ok {
+		return fmt.Errorf("cursor %s already exists", st.name)
+	}
+
+	query, err := parseQuery(st.query)
+	if err != nil {
+		return err
+	}
+
+	p := &portal{
+		Name:      st.name,
+		Statement: &statement{Name: st.name, SQLStatement: st.query},
+	}
+
+	if err = s.runPortalQuery(p, query); err != nil {
+		return err
+	}
+
+	p.Statement.Results = p.columns
+
+	s.portals[st.name] = p
+
+	return nil
+}
+
+func (s *session) fetchCursor(st *fetchCursorStmt) error {
+	p, ok := s.portals[st.name]
+	if !ok {
+		return fmt.Errorf("cursor %s does not exist", st.name)
+	}
+
+	if !p.executed {
+		return fmt.Errorf("portal %s is not a cursor", st.name)
+	}
+
+	if _, err := s.writeMessage(bm.RowDescription(p.columns, nil)); err != nil {
+		return err
+	}
+
+	rows := p.nextRows(st.count)
+	if len(rows) == 0 {
+		return nil
+	}
+
+	_, err := s.writeMessage(bm.DataRow(rows, len(p.columns), nil))
+	return err
+}
+
+func (s *session) closeCursor(st *closeCursorStmt) error {
+	if _, ok := s.portals[st.name]; !ok {
+		return fmt.Errorf("cursor %s does not exist", st.name)
+	}
+
+	delete(s.portals, st.name)
+
+	return nil
+}
+
+// portalQuery returns the query of the portal if it's a single SELECT statement sent to the database, nil otherwise
+func (s *session) portalQuery(p *portal) *sql.SelectStmt {
+	statement := p.Statement.SQLStatement
+
+	if s.isInBlackList(statement) || s.isEmulableInternally(statement) != nil {
+		return nil
+	}
+
+	query, err := parseQuery(statement)
+	if err != nil {
+		return nil
+	}
+
+	return query
+}
+
+// executePortal sends up to maxRows rows of the portal, a zero maxRows sending all the remaining ones.
+// It returns whether rows are still to be sent, the portal being suspended until executed again.
+func (s *session) executePortal(p *portal, query *sql.SelectStmt, maxRows int32) (bool, error) {
+	if !p.executed {
+		if err := s.runPortalQuery(p, query); err != nil {
+			return false, err
+		}
+	}
+
+	rows := p.nextRows(int(maxRows))
+	if len(rows) > 0 {
+		if _, err := s.writeMessage(bm.DataRow(rows, len(p.columns), p.ResultColumnFormatCodes)); err != nil {
+			return false, err
+		}
+	}
+
+	return len(p.rows) > 0, nil
+}
+
+func (s *session) runPortalQuery(p *portal, query *sql.SelectStmt) error {
+	res, err := s.database.SQLQueryPrepared(context.Background(), query, p.Parameters, nil)
+	if err != nil {
+		return err
+	}
+
+	p.columns = res.Columns
+	p.rows = res.Rows
+	p.executed = true
+
+	return nil
+}
+
+// nextRows consumes up to n of the rows not yet sent, all of them if n is zero
+func (p *portal) nextRows(n int) []*schema.Row {
+	if n <= 0 || n > len(p.rows) {
+		n = len(p.rows)
+	}
+
+	rows := p.rows[:n]
+	p.rows = p.rows[n:]
+
+	return rows
+}
+
+func parseQuery(statement string) (*sql.SelectStmt, error) {
+	stmts, err := sql.Parse(strings.NewReader(statement))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(stmts) > 1 {
+		return nil, pserr.ErrMaxStmtNumberExceeded
+	}
+	if len(stmts) == 0 {
+		return nil, pserr.ErrNoStatementFound
+	}
+
+	query, ok := stmts[0].(*sql.SelectStmt)
+	if !ok {
+		return nil, sql.ErrExpectingDQLStmt
+	}
+
+	return query, nil
+}
@@ -0,0 +1,48 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCursorStmt(t *testing.T) {
+	var tests = []struct {
+		in  string
+		out interface{}
+	}{
+		{"SELECT * FROM table1", nil},
+		{"DECLARE c1 CURSOR FOR SELECT * FROM table1;", &declareCursorStmt{name: "c1", query: "SELECT * FROM table1"}},
+		{"declare c1 no scroll cursor without hold for select id from table1", &declareCursorStmt{name: "c1", query: "select id from table1"}},
+		{"FETCH c1", &fetchCursorStmt{name: "c1", count: 1}},
+		{"FETCH NEXT FROM c1", &fetchCursorStmt{name: "c1", count: 1}},
+		{"FETCH FORWARD IN c1", &fetchCursorStmt{name: "c1", count: 1}},
+		{"FETCH 10 FROM c1;", &fetchCursorStmt{name: "c1", count: 10}},
+		{"FETCH FORWARD 5 c1", &fetchCursorStmt{name: "c1", count: 5}},
+		{"FETCH ALL FROM c1", &fetchCursorStmt{name: "c1", count: 0}},
+		{"fetch forward all in c1", &fetchCursorStmt{name: "c1", count: 0}},
+		{"CLOSE c1", &closeCursorStmt{name: "c1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			require.Equal(t, tt.out, parseCursorStmt(tt.in))
+		})
+	}
+}
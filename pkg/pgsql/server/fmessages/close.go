@@ -0,0 +1,35 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package fmessages
+
+import pgserrors "github.com/codenotary/immudb/pkg/pgsql/errors"
+
+type CloseMsg struct {
+	// 'S' to close a prepared statement; or 'P' to close a portal.
+	CloseType string
+	// The name of the prepared statement or portal to close (an empty string selects the unnamed prepared statement or portal).
+	Name string
+}
+
+func ParseCloseMsg(msg []byte) (CloseMsg, error) {
+	if len(msg) < 2 {
+		return CloseMsg{}, pgserrors.ErrMalformedMessage
+	}
+	return CloseMsg{
+		CloseType: string(msg[0]),
+		Name:      string(msg[1 : len(msg)-1]),
+	}, nil
+}
@@ -0,0 +1,59 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package fmessages
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/codenotary/immudb/pkg/pgsql/errors"
+	h "github.com/codenotary/immudb/pkg/pgsql/server/fmessages/fmessages_test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCloseMsg(t *testing.T) {
+	var tests = []struct {
+		in  []byte
+		out CloseMsg
+		e   error
+	}{
+		{h.Join([][]byte{[]byte("P"), h.S("port")}),
+			CloseMsg{
+				CloseType: "P",
+				Name:      "port",
+			},
+			nil,
+		},
+		{h.Join([][]byte{[]byte("S"), h.S("")}),
+			CloseMsg{
+				CloseType: "S",
+			},
+			nil,
+		},
+		{[]byte("P"),
+			CloseMsg{},
+			errors.ErrMalformedMessage,
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("%d_close", i), func(t *testing.T) {
+			s, err := ParseCloseMsg(tt.in)
+			require.Equal(t, tt.out, s)
+			require.Equal(t, tt.e, err)
+		})
+	}
+}
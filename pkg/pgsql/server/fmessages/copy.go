@@ -0,0 +1,46 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fmessages
+
+// CopyDataMsg carries a chunk of the data stream sent by the client during COPY FROM STDIN
+type CopyDataMsg struct {
+	Data []byte
+}
+
+func ParseCopyDataMsg(payload []byte) (CopyDataMsg, error) {
+	return CopyDataMsg{Data: payload}, nil
+}
+
+// CopyDoneMsg signals the end of the COPY data stream
+type CopyDoneMsg struct{}
+
+func ParseCopyDoneMsg(payload []byte) (CopyDoneMsg, error) {
+	return CopyDoneMsg{}, nil
+}
+
+// CopyFailMsg is sent by the client to abort a COPY FROM STDIN
+type CopyFailMsg struct {
+	Message string
+}
+
+func ParseCopyFailMsg(payload []byte) (CopyFailMsg, error) {
+	msg := payload
+	if len(msg) > 0 && msg[len(msg)-1] == 0 {
+		msg = msg[:len(msg)-1] // A null-terminated string
+	}
+	return CopyFailMsg{Message: string(msg)}, nil
+}
@@ -22,11 +22,13 @@ import (
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"github.com/codenotary/immudb/pkg/auth"
 	"github.com/codenotary/immudb/pkg/database"
 	pserr "github.com/codenotary/immudb/pkg/pgsql/errors"
 	bm "github.com/codenotary/immudb/pkg/pgsql/server/bmessages"
 	fm "github.com/codenotary/immudb/pkg/pgsql/server/fmessages"
 	"github.com/codenotary/immudb/pkg/pgsql/server/pgmeta"
+	"github.com/codenotary/immudb/pkg/server/sessions"
 	"strings"
 )
 
@@ -52,7 +54,7 @@ func (s *session) InitializeSession() (err error) {
 
 	// SSL Request packet
 	if s.protocolVersion == "1234.5679" {
-		if s.tlsConfig == nil || len(s.tlsConfig.Certificates) == 0 {
+		if s.tlsConfig == nil || (len(s.tlsConfig.Certificates) == 0 && s.tlsConfig.GetCertificate == nil) {
 			if _, err = s.writeMessage([]byte(`N`)); err != nil {
 				return err
 			}
@@ -156,24 +158,40 @@ func (s *session) HandleStartup(dbList database.DatabaseList) (err error) {
 	if err != nil {
 		return err
 	}
-	if pw, ok := msg.(fm.PasswordMsg); ok {
-		if !ok || pw.GetSecret() == "" {
-			return pserr.ErrPwNotprovided
-		}
-		usr, err := s.getUser([]byte(s.username))
-		if err != nil {
-			if strings.Contains(err.Error(), "key not found") {
-				return pserr.ErrUsernameNotFound
-			}
+	// the database is only handed to authenticated users
+	pw, ok := msg.(fm.PasswordMsg)
+	if !ok || pw.GetSecret() == "" {
+		return pserr.ErrPwNotprovided
+	}
+	usr, err := s.getUser([]byte(s.username))
+	if err != nil {
+		if strings.Contains(err.Error(), "key not found") {
+			return pserr.ErrUsernameNotFound
 		}
-		if err := usr.ComparePasswords([]byte(pw.GetSecret())); err != nil {
-			return err
+		return err
+	}
+	if err := usr.ComparePasswords([]byte(pw.GetSecret())); err != nil {
+		return err
+	}
+	s.log.Debugf("authentication successful for %s", s.username)
+
+	if !usr.IsSysAdmin {
+		permission := usr.WhichPermission(s.database.GetName())
+
+		// restricted readers only get to see values redacted according to the rules of the database
+		if permission == auth.PermissionRestrictedR {
+			s.database = s.database.Redacted()
 		}
-		s.log.Debugf("authentication successful for %s", s.username)
-		if _, err := s.writeMessage(bm.AuthenticationOk()); err != nil {
-			return err
+
+		// the policies of the tables are enforced on the SQL queries of the users not administering the database
+		if permission != auth.PermissionAdmin {
+			s.database = s.database.WithSessionAttributes(sessions.PolicyAttributes(usr.Username, nil))
 		}
 	}
+
+	if _, err := s.writeMessage(bm.AuthenticationOk()); err != nil {
+		return err
+	}
 	if _, err := s.writeMessage(bm.ParameterStatus([]byte("standard_conforming_strings"), []byte("on"))); err != nil {
 		return err
 	}
@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"github.com/codenotary/immudb/pkg/pgsql/errors"
 	"github.com/codenotary/immudb/pkg/pgsql/server/pgmeta"
+	"io"
 	"math"
 	"net"
 )
@@ -69,7 +70,12 @@ func (r *messageReader) ReadRawMessage() (*rawMessage, error) {
 		return nil, errors.ErrMessageTooLarge
 	}
 	payload := make([]byte, pLen)
-	if _, err := r.conn.Read(payload); err != nil {
+	n, err := r.conn.Read(payload)
+	if err != nil {
+		return nil, err
+	}
+	// large payloads, as COPY data chunks, may not be available in a single read
+	if _, err := io.ReadFull(r.conn, payload[n:]); err != nil {
 		return nil, err
 	}
 
@@ -18,6 +18,8 @@ package server
 
 import (
 	"crypto/tls"
+	"net"
+
 	"github.com/codenotary/immudb/pkg/database"
 	"github.com/codenotary/immudb/pkg/logger"
 )
@@ -65,3 +67,11 @@ func SessFactory(sf SessionFactory) Option {
 		args.SessionFactory = sf
 	}
 }
+
+// ClientAddressCheck sets the check clients are rejected by before selecting a database, it's given the address
+// of the client and the name of the database
+func ClientAddressCheck(check func(addr net.Addr, database string) error) Option {
+	return func(args *srv) {
+		args.clientAddressCheck = check
+	}
+}
@@ -53,6 +53,8 @@ const PgServerErrProtocolViolation = "08P01"
 const PgServerErrConnectionFailure = "08006"
 const ProgramLimitExceeded = "54000"
 const DataException = "22000"
+const BadCopyFileFormat = "22P04"
+const QueryCanceled = "57014"
 
 var MTypes = map[byte]string{
 	'Q': "query",
@@ -70,6 +72,12 @@ var MTypes = map[byte]string{
 	't': "parameterDesctiption",
 	'B': "bind",
 	'H': "flush",
+	'G': "copyInResponse",
+	'd': "copyData",
+	'c': "copyDone",
+	'f': "copyFail",
+	's': "portalSuspended",
+	'3': "closeComplete",
 }
 
 var MaxMsgSize = 32 << 20 // 32MB
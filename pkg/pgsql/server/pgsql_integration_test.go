@@ -17,21 +17,27 @@ limitations under the License.
 package server_test
 
 import (
+	"bytes"
 	"context"
 	"crypto/tls"
 	"database/sql"
 	"encoding/hex"
 	"fmt"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/auth"
 	"github.com/codenotary/immudb/pkg/pgsql/errors"
 	"github.com/codenotary/immudb/pkg/pgsql/server/pgmeta"
 	"github.com/codenotary/immudb/pkg/server"
 	"github.com/codenotary/immudb/pkg/server/servertest"
+	"github.com/jackc/pgproto3/v2"
 	"github.com/jackc/pgx/v4"
 	_ "github.com/lib/pq"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
 	"io/ioutil"
 	"math/rand"
 	"os"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -830,3 +836,316 @@ func TestPgsqlServer_ExtendedQueryPGMultiFieldsPreparedMultiInsertError(t *testi
 	_, err = db.Exec(context.Background(), fmt.Sprintf("INSERT INTO %s (id, amount, total, title, content, isPresent) VALUES (?, ?, ?, ?, ?, ?); INSERT INTO %s (id, amount, total, title, content, isPresent) VALUES (?, ?, ?, ?, ?, ?)", table, table), 1, 1000, 6000, "title 1", fmt.Sprintf("%s", blobContent), true, 2, 2000, 12000, "title 2", fmt.Sprintf("%s", blobContent2), true)
 	require.Error(t, errors.ErrMaxStmtNumberExceeded)
 }
+
+func TestPgsqlServer_CopyFromStdin(t *testing.T) {
+	td, _ := ioutil.TempDir("", "_pgsql")
+	options := server.DefaultOptions().WithDir(td).WithPgsqlServer(true).WithPgsqlServerPort(0)
+	bs := servertest.NewBufconnServer(options)
+
+	bs.Start()
+	defer bs.Stop()
+
+	defer os.RemoveAll(td)
+	defer os.Remove(".state-")
+
+	bs.WaitForPgsqlListener()
+
+	db, err := pgx.Connect(context.Background(), fmt.Sprintf("host=localhost port=%d sslmode=disable user=immudb dbname=defaultdb password=immudb", bs.Server.Srv.PgsqlSrv.GetPort()))
+	require.NoError(t, err)
+	defer db.Close(context.Background())
+
+	table := getRandomTableName()
+	_, err = db.Exec(context.Background(), fmt.Sprintf("CREATE TABLE %s (id INTEGER, title VARCHAR, active BOOLEAN, content BLOB, PRIMARY KEY id)", table))
+	require.NoError(t, err)
+
+	var data bytes.Buffer
+	for i := 1; i <= 250; i++ {
+		fmt.Fprintf(&data, "%d\ttitle\\t%d\tt\t\\\\x0a0b\n", i, i)
+	}
+	data.WriteString("251\t\\N\tf\t\\N\n")
+
+	tag, err := db.PgConn().CopyFrom(context.Background(), &data, fmt.Sprintf("COPY %s (id, title, active, content) FROM STDIN", table))
+	require.NoError(t, err)
+	require.Equal(t, int64(251), tag.RowsAffected())
+
+	var count int64
+	err = db.QueryRow(context.Background(), fmt.Sprintf("SELECT COUNT(*) FROM %s", table)).Scan(&count)
+	require.NoError(t, err)
+	require.Equal(t, int64(251), count)
+
+	var title string
+	var active bool
+	err = db.QueryRow(context.Background(), fmt.Sprintf("SELECT title, active FROM %s WHERE id = 7", table)).Scan(&title, &active)
+	require.NoError(t, err)
+	require.Equal(t, "title\t7", title)
+	require.True(t, active)
+
+	csvData := strings.NewReader("id,title,active,content\n300,\"a, b\",false,\n301,c,true,0c0d\n")
+
+	tag, err = db.PgConn().CopyFrom(context.Background(), csvData, fmt.Sprintf("COPY %s FROM STDIN WITH (FORMAT csv, HEADER)", table))
+	require.NoError(t, err)
+	require.Equal(t, int64(2), tag.RowsAffected())
+
+	err = db.QueryRow(context.Background(), fmt.Sprintf("SELECT title FROM %s WHERE id = 300", table)).Scan(&title)
+	require.NoError(t, err)
+	require.Equal(t, "a, b", title)
+
+	_, err = db.PgConn().CopyFrom(context.Background(), strings.NewReader("400\tnot a bool\tmaybe\t\\N\n"), fmt.Sprintf("COPY %s FROM STDIN", table))
+	require.Error(t, err)
+
+	// the connection is still usable after a failed COPY
+	err = db.QueryRow(context.Background(), fmt.Sprintf("SELECT COUNT(*) FROM %s", table)).Scan(&count)
+	require.NoError(t, err)
+	require.Equal(t, int64(253), count)
+}
+
+func TestPgsqlServer_PortalRowBatching(t *testing.T) {
+	td, _ := ioutil.TempDir("", "_pgsql")
+	options := server.DefaultOptions().WithDir(td).WithPgsqlServer(true).WithPgsqlServerPort(0)
+	bs := servertest.NewBufconnServer(options)
+
+	bs.Start()
+	defer bs.Stop()
+
+	defer os.RemoveAll(td)
+	defer os.Remove(".state-")
+
+	bs.WaitForPgsqlListener()
+
+	db, err := pgx.Connect(context.Background(), fmt.Sprintf("host=localhost port=%d sslmode=disable user=immudb dbname=defaultdb password=immudb", bs.Server.Srv.PgsqlSrv.GetPort()))
+	require.NoError(t, err)
+	defer db.Close(context.Background())
+
+	table := getRandomTableName()
+	_, err = db.Exec(context.Background(), fmt.Sprintf("CREATE TABLE %s (id INTEGER, PRIMARY KEY id)", table))
+	require.NoError(t, err)
+
+	_, err = db.Exec(context.Background(), fmt.Sprintf("INSERT INTO %s (id) VALUES (1), (2), (3), (4), (5)", table))
+	require.NoError(t, err)
+
+	var buf []byte
+	buf = (&pgproto3.Parse{Query: fmt.Sprintf("SELECT id FROM %s", table)}).Encode(buf)
+	buf = (&pgproto3.Bind{}).Encode(buf)
+	buf = (&pgproto3.Execute{MaxRows: 2}).Encode(buf)
+	buf = (&pgproto3.Execute{MaxRows: 2}).Encode(buf)
+	buf = (&pgproto3.Execute{}).Encode(buf)
+	buf = (&pgproto3.Close{ObjectType: 'P'}).Encode(buf)
+	buf = (&pgproto3.Sync{}).Encode(buf)
+
+	err = db.PgConn().SendBytes(context.Background(), buf)
+	require.NoError(t, err)
+
+	var received []string
+
+	for {
+		msg, err := db.PgConn().ReceiveMessage(context.Background())
+		require.NoError(t, err)
+
+		received = append(received, fmt.Sprintf("%T", msg))
+
+		if _, ok := msg.(*pgproto3.ReadyForQuery); ok {
+			break
+		}
+	}
+
+	require.Equal(t, []string{
+		"*pgproto3.ParseComplete",
+		"*pgproto3.BindComplete",
+		"*pgproto3.DataRow", "*pgproto3.DataRow", "*pgproto3.PortalSuspended",
+		"*pgproto3.DataRow", "*pgproto3.DataRow", "*pgproto3.PortalSuspended",
+		"*pgproto3.DataRow", "*pgproto3.CommandComplete",
+		"*pgproto3.CloseComplete",
+		"*pgproto3.ReadyForQuery",
+	}, received)
+}
+
+func TestPgsqlServer_Cursors(t *testing.T) {
+	td, _ := ioutil.TempDir("", "_pgsql")
+	options := server.DefaultOptions().WithDir(td).WithPgsqlServer(true).WithPgsqlServerPort(0)
+	bs := servertest.NewBufconnServer(options)
+
+	bs.Start()
+	defer bs.Stop()
+
+	defer os.RemoveAll(td)
+	defer os.Remove(".state-")
+
+	bs.WaitForPgsqlListener()
+
+	db, err := pgx.Connect(context.Background(), fmt.Sprintf("host=localhost port=%d sslmode=disable user=immudb dbname=defaultdb password=immudb", bs.Server.Srv.PgsqlSrv.GetPort()))
+	require.NoError(t, err)
+	defer db.Close(context.Background())
+
+	table := getRandomTableName()
+	_, err = db.Exec(context.Background(), fmt.Sprintf("CREATE TABLE %s (id INTEGER, PRIMARY KEY id)", table))
+	require.NoError(t, err)
+
+	_, err = db.Exec(context.Background(), fmt.Sprintf("INSERT INTO %s (id) VALUES (1), (2), (3), (4), (5)", table))
+	require.NoError(t, err)
+
+	_, err = db.Exec(context.Background(), fmt.Sprintf("DECLARE c1 CURSOR FOR SELECT id FROM %s", table))
+	require.NoError(t, err)
+
+	_, err = db.Exec(context.Background(), fmt.Sprintf("DECLARE c1 CURSOR FOR SELECT id FROM %s", table))
+	require.Error(t, err)
+
+	res, err := db.PgConn().Exec(context.Background(), "FETCH 2 FROM c1").ReadAll()
+	require.NoError(t, err)
+	require.Len(t, res[0].Rows, 2)
+	require.Equal(t, "1", string(res[0].Rows[0][0]))
+
+	res, err = db.PgConn().Exec(context.Background(), "FETCH NEXT c1").ReadAll()
+	require.NoError(t, err)
+	require.Len(t, res[0].Rows, 1)
+	require.Equal(t, "3", string(res[0].Rows[0][0]))
+
+	res, err = db.PgConn().Exec(context.Background(), "FETCH ALL IN c1").ReadAll()
+	require.NoError(t, err)
+	require.Len(t, res[0].Rows, 2)
+
+	res, err = db.PgConn().Exec(context.Background(), "FETCH c1").ReadAll()
+	require.NoError(t, err)
+	require.Empty(t, res[0].Rows)
+
+	_, err = db.Exec(context.Background(), "CLOSE c1")
+	require.NoError(t, err)
+
+	_, err = db.PgConn().Exec(context.Background(), "FETCH c1").ReadAll()
+	require.Error(t, err)
+}
+
+func TestPgsqlServer_RestrictedReads(t *testing.T) {
+	td, _ := ioutil.TempDir("", "_pgsql")
+	options := server.DefaultOptions().WithDir(td).WithPgsqlServer(true).WithPgsqlServerPort(0)
+	bs := servertest.NewBufconnServer(options)
+
+	bs.Start()
+	defer bs.Stop()
+
+	defer os.RemoveAll(td)
+	defer os.Remove(".state-")
+
+	bs.WaitForPgsqlListener()
+
+	lr, err := bs.Server.Srv.Login(context.Background(), &schema.LoginRequest{
+		User:     []byte(auth.SysAdminUsername),
+		Password: []byte(auth.SysAdminPassword),
+	})
+	require.NoError(t, err)
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", lr.Token))
+
+	_, err = bs.Server.Srv.CreateUser(ctx, &schema.CreateUserRequest{
+		User:       []byte("auditor"),
+		Password:   []byte("Pa$$w0rd1"),
+		Permission: auth.PermissionRestrictedR,
+		Database:   server.DefaultDBName,
+	})
+	require.NoError(t, err)
+
+	db, err := sql.Open("postgres", fmt.Sprintf("host=localhost port=%d sslmode=disable user=immudb dbname=defaultdb password=immudb", bs.Server.Srv.PgsqlSrv.GetPort()))
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec("CREATE TABLE people (id INTEGER, ssn VARCHAR, PRIMARY KEY id)")
+	require.NoError(t, err)
+
+	_, err = db.Exec("INSERT INTO people (id, ssn) VALUES (1, '123456789')")
+	require.NoError(t, err)
+
+	_, err = bs.Server.Srv.SetRedactionRule(ctx, &schema.RedactionRule{
+		Table:        "people",
+		Column:       "ssn",
+		Mode:         schema.RedactionMode_REDACT_PARTIAL,
+		VisibleChars: 4,
+	})
+	require.NoError(t, err)
+
+	var ssn string
+	err = db.QueryRow("SELECT ssn FROM people").Scan(&ssn)
+	require.NoError(t, err)
+	require.Equal(t, "123456789", ssn)
+
+	auditorDB, err := sql.Open("postgres", fmt.Sprintf("host=localhost port=%d sslmode=disable user=auditor dbname=defaultdb password=Pa$$w0rd1", bs.Server.Srv.PgsqlSrv.GetPort()))
+	require.NoError(t, err)
+	defer auditorDB.Close()
+
+	err = auditorDB.QueryRow("SELECT ssn FROM people").Scan(&ssn)
+	require.NoError(t, err)
+	require.Equal(t, "*****6789", ssn)
+
+	err = auditorDB.QueryRow("SELECT ssn FROM people WHERE id = $1", 1).Scan(&ssn)
+	require.NoError(t, err)
+	require.Equal(t, "*****6789", ssn)
+}
+
+func TestPgsqlServer_Policies(t *testing.T) {
+	td, _ := ioutil.TempDir("", "_pgsql")
+	options := server.DefaultOptions().WithDir(td).WithPgsqlServer(true).WithPgsqlServerPort(0)
+	bs := servertest.NewBufconnServer(options)
+
+	bs.Start()
+	defer bs.Stop()
+
+	defer os.RemoveAll(td)
+	defer os.Remove(".state-")
+
+	bs.WaitForPgsqlListener()
+
+	lr, err := bs.Server.Srv.Login(context.Background(), &schema.LoginRequest{
+		User:     []byte(auth.SysAdminUsername),
+		Password: []byte(auth.SysAdminPassword),
+	})
+	require.NoError(t, err)
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", lr.Token))
+
+	_, err = bs.Server.Srv.CreateUser(ctx, &schema.CreateUserRequest{
+		User:       []byte("alice"),
+		Password:   []byte("Pa$$w0rd1"),
+		Permission: auth.PermissionRW,
+		Database:   server.DefaultDBName,
+	})
+	require.NoError(t, err)
+
+	db, err := sql.Open("postgres", fmt.Sprintf("host=localhost port=%d sslmode=disable user=immudb dbname=defaultdb password=immudb", bs.Server.Srv.PgsqlSrv.GetPort()))
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec("CREATE TABLE orders (id INTEGER AUTO_INCREMENT, owner VARCHAR, PRIMARY KEY id)")
+	require.NoError(t, err)
+
+	_, err = db.Exec("INSERT INTO orders (owner) VALUES ('alice'), ('bob'), ('carol')")
+	require.NoError(t, err)
+
+	_, err = db.Exec("CREATE POLICY own ON orders USING (owner = @user)")
+	require.NoError(t, err)
+
+	var count int
+	err = db.QueryRow("SELECT COUNT(*) FROM orders").Scan(&count)
+	require.NoError(t, err)
+	require.Equal(t, 3, count)
+
+	aliceDB, err := sql.Open("postgres", fmt.Sprintf("host=localhost port=%d sslmode=disable user=alice dbname=defaultdb password=Pa$$w0rd1", bs.Server.Srv.PgsqlSrv.GetPort()))
+	require.NoError(t, err)
+	defer aliceDB.Close()
+
+	rows, err := aliceDB.Query("SELECT owner FROM orders")
+	require.NoError(t, err)
+
+	var owners []string
+	for rows.Next() {
+		var owner string
+		require.NoError(t, rows.Scan(&owner))
+		owners = append(owners, owner)
+	}
+	require.NoError(t, rows.Err())
+	require.Equal(t, []string{"alice"}, owners)
+
+	var owner string
+	err = aliceDB.QueryRow("SELECT owner FROM orders WHERE id = $1", 2).Scan(&owner)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	_, err = aliceDB.Exec("DROP POLICY own ON orders")
+	require.Error(t, err)
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package server
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -66,6 +67,22 @@ func (s *session) QueriesMachine() (err error) {
 		case fm.TerminateMsg:
 			return s.mr.CloseConnection()
 		case fm.QueryMsg:
+			cp, err := parseCopyFromStdin(v.GetStatements())
+			if err != nil {
+				s.ErrorHandle(err)
+				continue
+			}
+			// COPY FROM STDIN writes its own command completion, reporting the number of loaded rows
+			if cp != nil {
+				if err = s.copyFrom(cp); err != nil {
+					s.ErrorHandle(err)
+					continue
+				}
+				if _, err = s.writeMessage(bm.ReadyForQuery()); err != nil {
+					s.ErrorHandle(err)
+				}
+				continue
+			}
 			if err = s.fetchAndWriteResults(v.GetStatements(), nil, nil, false); err != nil {
 				s.ErrorHandle(err)
 				continue
@@ -198,10 +215,34 @@ func (s *session) QueriesMachine() (err error) {
 				continue
 			}
 		case fm.Execute:
+			p, ok := s.portals[v.PortalName]
+			if !ok {
+				s.ErrorHandle(fmt.Errorf("portal %s not found", v.PortalName))
+				waitForSync = true
+				continue
+			}
+			// queries executed with a row limit are sent in batches, the portal being suspended in between
+			if query := s.portalQuery(p); query != nil && (v.MaxRows > 0 || p.executed) {
+				suspended, err := s.executePortal(p, query, v.MaxRows)
+				if err != nil {
+					s.ErrorHandle(err)
+					waitForSync = true
+					continue
+				}
+				completion := bm.CommandComplete([]byte(`ok`))
+				if suspended {
+					completion = bm.PortalSuspended()
+				}
+				if _, err = s.writeMessage(completion); err != nil {
+					s.ErrorHandle(err)
+					waitForSync = true
+				}
+				continue
+			}
 			//query execution
-			if err = s.fetchAndWriteResults(s.portals[v.PortalName].Statement.SQLStatement,
-				s.portals[v.PortalName].Parameters,
-				s.portals[v.PortalName].ResultColumnFormatCodes,
+			if err = s.fetchAndWriteResults(p.Statement.SQLStatement,
+				p.Parameters,
+				p.ResultColumnFormatCodes,
 				true); err != nil {
 				s.ErrorHandle(err)
 				waitForSync = true
@@ -211,6 +252,25 @@ func (s *session) QueriesMachine() (err error) {
 				s.ErrorHandle(err)
 				waitForSync = true
 			}
+		case fm.CloseMsg:
+			// closing a prepared statement closes the portals bound to it as well
+			if v.CloseType == "S" {
+				if st, ok := s.statements[v.Name]; ok {
+					for name, p := range s.portals {
+						if p.Statement == st {
+							delete(s.portals, name)
+						}
+					}
+					delete(s.statements, v.Name)
+				}
+			}
+			if v.CloseType == "P" {
+				delete(s.portals, v.Name)
+			}
+			if _, err = s.writeMessage(bm.CloseComplete()); err != nil {
+				s.ErrorHandle(err)
+				waitForSync = true
+			}
 		case fm.FlushMsg:
 			// there is no buffer to be flushed
 		default:
@@ -259,7 +319,7 @@ func (s *session) fetchAndWriteResults(statements string, parameters []*schema.N
 }
 
 func (s *session) query(st *sql.SelectStmt, parameters []*schema.NamedParam, resultColumnFormatCodes []int16, skipRowDesc bool) error {
-	res, err := s.database.SQLQueryPrepared(st, parameters, nil)
+	res, err := s.database.SQLQueryPrepared(context.Background(), st, parameters, nil)
 	if err != nil {
 		return err
 	}
@@ -292,6 +352,11 @@ type portal struct {
 	Statement               *statement
 	Parameters              []*schema.NamedParam
 	ResultColumnFormatCodes []int16
+
+	// results of a query executed with a row limit or declared as a cursor, rows are removed once sent
+	executed bool
+	columns  []*schema.Column
+	rows     []*schema.Row
 }
 
 type statement struct {
@@ -327,7 +392,7 @@ func (s *session) inferParamAndResultCols(statement string) ([]*schema.Column, [
 
 	sel, ok := stmt.(*sql.SelectStmt)
 	if ok {
-		rr, err := s.database.SQLQueryRowReader(sel, nil)
+		rr, err := s.database.SQLQueryRowReader(context.Background(), sel, nil)
 		if err != nil {
 			return nil, nil, err
 		}
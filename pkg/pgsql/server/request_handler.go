@@ -18,6 +18,8 @@ package server
 
 import (
 	"net"
+
+	"github.com/codenotary/immudb/pkg/database"
 )
 
 func (s *srv) handleRequest(conn net.Conn) (err error) {
@@ -28,8 +30,13 @@ func (s *srv) handleRequest(conn net.Conn) (err error) {
 	if err != nil {
 		return err
 	}
+	dbList := s.dbList
+	if s.clientAddressCheck != nil {
+		dbList = &checkedDatabaseList{DatabaseList: s.dbList, addr: conn.RemoteAddr(), check: s.clientAddressCheck}
+	}
+
 	// authentication
-	err = ss.HandleStartup(s.dbList)
+	err = ss.HandleStartup(dbList)
 	if err != nil {
 		return err
 	}
@@ -41,3 +48,19 @@ func (s *srv) handleRequest(conn net.Conn) (err error) {
 
 	return nil
 }
+
+// checkedDatabaseList only lets the client select the databases it's allowed to access by its address
+type checkedDatabaseList struct {
+	database.DatabaseList
+	addr  net.Addr
+	check func(addr net.Addr, database string) error
+}
+
+func (l *checkedDatabaseList) GetByName(name string) (database.DB, error) {
+	err := l.check(l.addr, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return l.DatabaseList.GetByName(name)
+}
@@ -2,6 +2,7 @@ package server
 
 import (
 	"errors"
+	"github.com/codenotary/immudb/pkg/database"
 	"github.com/stretchr/testify/require"
 	"net"
 	"testing"
@@ -31,3 +32,27 @@ func TestHandleRequestInitializeError(t *testing.T) {
 
 	require.Error(t, err)
 }
+
+func TestCheckedDatabaseList(t *testing.T) {
+	errNotAllowed := errors.New("not allowed")
+
+	addr := &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 50000}
+
+	dbList := &checkedDatabaseList{
+		DatabaseList: database.NewDatabaseList(),
+		addr:         addr,
+		check: func(a net.Addr, db string) error {
+			require.Equal(t, addr, a)
+			if db == "db1" {
+				return errNotAllowed
+			}
+			return nil
+		},
+	}
+
+	_, err := dbList.GetByName("db1")
+	require.ErrorIs(t, err, errNotAllowed)
+
+	_, err = dbList.GetByName("db2")
+	require.ErrorIs(t, err, database.ErrDatabaseNotExists)
+}
@@ -40,6 +40,8 @@ type srv struct {
 	dbList         database.DatabaseList
 	sysDb          database.DB
 	listener       net.Listener
+
+	clientAddressCheck func(addr net.Addr, database string) error
 }
 
 type Server interface {
@@ -17,6 +17,8 @@ limitations under the License.
 package server
 
 import (
+	"context"
+
 	"crypto/tls"
 	"encoding/json"
 	"github.com/codenotary/immudb/pkg/api/schema"
@@ -90,6 +92,7 @@ func (s *session) nextMessage() (interface{}, bool, error) {
 		msg.t == 'B' ||
 		msg.t == 'D' ||
 		msg.t == 'E' ||
+		msg.t == 'C' ||
 		msg.t == 'H' {
 		extQueryMode = true
 	}
@@ -116,6 +119,14 @@ func (s *session) parseRawMessage(msg *rawMessage) (interface{}, error) {
 		return fm.ParseExecuteMsg(msg.payload)
 	case 'H':
 		return fm.ParseFlushMsg(msg.payload)
+	case 'C':
+		return fm.ParseCloseMsg(msg.payload)
+	case 'd':
+		return fm.ParseCopyDataMsg(msg.payload)
+	case 'c':
+		return fm.ParseCopyDoneMsg(msg.payload)
+	case 'f':
+		return fm.ParseCopyFailMsg(msg.payload)
 	default:
 		return nil, errors.ErrUnknowMessageType
 	}
@@ -138,7 +149,7 @@ func (s *session) getUser(username []byte) (*auth.User, error) {
 	key[0] = 1
 	copy(key[1:], username)
 
-	item, err := s.sysDb.Get(&schema.KeyRequest{Key: key})
+	item, err := s.sysDb.Get(context.Background(), &schema.KeyRequest{Key: key})
 	if err != nil {
 		return nil, err
 	}
@@ -150,5 +161,8 @@ func (s *session) getUser(username []byte) (*auth.User, error) {
 		return nil, err
 	}
 
+	// the sysadmin is not granted permissions on each database
+	usr.IsSysAdmin = usr.Username == auth.SysAdminUsername
+
 	return &usr, nil
 }
@@ -22,7 +22,7 @@ import (
 )
 
 func (s *session) handshake() error {
-	if s.tlsConfig == nil || len(s.tlsConfig.Certificates) == 0 {
+	if s.tlsConfig == nil || (len(s.tlsConfig.Certificates) == 0 && s.tlsConfig.GetCertificate == nil) {
 		return pserr.ErrSSLNotSupported
 	}
 	tlsConn := tls.Server(s.mr.Connection(), s.tlsConfig)
@@ -22,14 +22,23 @@ func (s *session) isEmulableInternally(statement string) interface{} {
 	if selectVersion.MatchString(statement) {
 		return &version{}
 	}
+	if cursorStmt := parseCursorStmt(statement); cursorStmt != nil {
+		return cursorStmt
+	}
 	return nil
 }
 func (s *session) tryToHandleInternally(command interface{}) error {
-	switch command.(type) {
+	switch cmd := command.(type) {
 	case *version:
 		if err := s.writeVersionInfo(); err != nil {
 			return err
 		}
+	case *declareCursorStmt:
+		return s.declareCursor(cmd)
+	case *fetchCursorStmt:
+		return s.fetchCursor(cmd)
+	case *closeCursorStmt:
+		return s.closeCursor(cmd)
 	default:
 		return pserr.ErrMessageCannotBeHandledInternally
 	}
@@ -0,0 +1,34 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package replication
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	metricsReplicatedTxs = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "immudb_replication_replicated_txs_total",
+		Help: "Number of transactions replicated from the master since the immudb process was started",
+	}, []string{"db"})
+
+	metricsReplicationFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "immudb_replication_failures_total",
+		Help: "Number of failed attempts to fetch or replicate a transaction from the master",
+	}, []string{"db"})
+)
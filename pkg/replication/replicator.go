@@ -28,12 +28,17 @@ import (
 	"github.com/codenotary/immudb/pkg/database"
 	"github.com/codenotary/immudb/pkg/logger"
 	"github.com/codenotary/immudb/pkg/stream"
+	"github.com/golang/protobuf/ptypes/empty"
 	"google.golang.org/grpc/metadata"
 )
 
+const requestTimeout = 3 * time.Second
+
 var ErrIllegalArguments = errors.New("illegal arguments")
 var ErrAlreadyRunning = errors.New("already running")
 var ErrAlreadyStopped = errors.New("already stopped")
+var ErrAlreadyPaused = errors.New("already paused")
+var ErrNotPaused = errors.New("not paused")
 
 type TxReplicator struct {
 	db   database.DB
@@ -50,14 +55,31 @@ type TxReplicator struct {
 
 	delayer        Delayer
 	failedAttempts int
+	lastError      error
 
 	nextTx uint64
 
 	running bool
+	done    chan struct{}
+
+	paused      bool
+	resumeCh    chan struct{}
+	fetchCancel context.CancelFunc
 
 	mutex sync.Mutex
 }
 
+// Status describes the progress of a replicator
+type Status struct {
+	Running        bool
+	Paused         bool
+	Connected      bool
+	LastTx         uint64 // last transaction committed in the replica
+	MasterTx       uint64 // last transaction committed in the master, zero when not connected
+	FailedAttempts int
+	LastError      error
+}
+
 func NewTxReplicator(db database.DB, opts *Options, logger logger.Logger) (*TxReplicator, error) {
 	if db == nil || logger == nil || opts == nil || !opts.Valid() {
 		return nil, ErrIllegalArguments
@@ -94,8 +116,14 @@ func (txr *TxReplicator) Start() error {
 	txr.nextTx = st.TxId + 1
 
 	txr.running = true
+	txr.paused = false
+
+	done := make(chan struct{})
+	txr.done = done
 
 	go func() {
+		defer close(done)
+
 		defer func() {
 			if txr.client != nil {
 				txr.disconnect()
@@ -103,14 +131,23 @@ func (txr *TxReplicator) Start() error {
 		}()
 
 		for {
+			if resumeCh, paused := txr.pausedChan(); paused {
+				select {
+				case <-txr.mainContext.Done():
+					return
+				case <-resumeCh:
+				}
+
+				continue
+			}
+
 			if txr.client == nil {
 				err = txr.connect()
 				if err == nil {
-					txr.failedAttempts = 0
 					continue
 				}
 
-				txr.failedAttempts++
+				txr.recordFailure(err)
 
 				txr.logger.Infof("Failed to connect with '%s' for database '%s' (%d failed attempts). Reason: %v",
 					masterDB,
@@ -132,10 +169,14 @@ func (txr *TxReplicator) Start() error {
 			txr.logger.Debugf("Replicating transaction %d from '%s' to '%s'...", txr.nextTx, masterDB, txr.db.GetName())
 
 			bs, err := txr.fetchTX()
+			if _, paused := txr.pausedChan(); paused {
+				// the transaction will be fetched again once replication is resumed
+				continue
+			}
 			if err != nil {
 				txr.logger.Infof("Failed to export transaction %d from '%s' to '%s'. Reason: %v", txr.nextTx, masterDB, txr.db.GetName(), err)
 
-				txr.failedAttempts++
+				txr.recordFailure(err)
 				if txr.failedAttempts == 3 {
 					txr.disconnect()
 				}
@@ -159,7 +200,7 @@ func (txr *TxReplicator) Start() error {
 					masterDB,
 					err)
 
-				txr.failedAttempts++
+				txr.recordFailure(err)
 				if txr.failedAttempts == 3 {
 					txr.disconnect()
 				}
@@ -177,8 +218,7 @@ func (txr *TxReplicator) Start() error {
 
 			txr.logger.Debugf("Transaction %d from '%s' to '%s' successfully replicated", txr.nextTx, masterDB, txr.db.GetName())
 
-			txr.nextTx++
-			txr.failedAttempts = 0
+			txr.recordSuccess()
 		}
 	}()
 
@@ -221,6 +261,8 @@ func (txr *TxReplicator) connect() error {
 	txr.clientContext = metadata.NewOutgoingContext(txr.clientContext, metadata.Pairs("authorization", udr.GetToken()))
 
 	txr.client = client
+	txr.failedAttempts = 0
+	txr.lastError = nil
 
 	txr.logger.Infof("Connection to '%s':'%d' for database '%s' successfully established",
 		txr.opts.masterAddress,
@@ -245,7 +287,28 @@ func (txr *TxReplicator) disconnect() {
 }
 
 func (txr *TxReplicator) fetchTX() ([]byte, error) {
-	exportTxStream, err := txr.client.ExportTx(txr.clientContext, &schema.ExportTxRequest{Tx: txr.nextTx})
+	txr.mutex.Lock()
+
+	if txr.paused {
+		txr.mutex.Unlock()
+		return nil, context.Canceled
+	}
+
+	// fetching is canceled when replication gets paused while waiting for the master
+	ctx, cancel := context.WithCancel(txr.clientContext)
+	txr.fetchCancel = cancel
+
+	txr.mutex.Unlock()
+
+	defer func() {
+		txr.mutex.Lock()
+		txr.fetchCancel = nil
+		txr.mutex.Unlock()
+
+		cancel()
+	}()
+
+	exportTxStream, err := txr.client.ExportTx(ctx, &schema.ExportTxRequest{Tx: txr.nextTx})
 	if err != nil {
 		return nil, err
 	}
@@ -254,13 +317,129 @@ func (txr *TxReplicator) fetchTX() ([]byte, error) {
 	return receiver.ReadFully()
 }
 
-func (txr *TxReplicator) Stop() error {
+func (txr *TxReplicator) recordFailure(err error) {
+	txr.mutex.Lock()
+	defer txr.mutex.Unlock()
+
+	txr.failedAttempts++
+	txr.lastError = err
+
+	metricsReplicationFailures.WithLabelValues(txr.db.GetName()).Inc()
+}
+
+func (txr *TxReplicator) recordSuccess() {
+	txr.mutex.Lock()
+	defer txr.mutex.Unlock()
+
+	txr.nextTx++
+	txr.failedAttempts = 0
+	txr.lastError = nil
+
+	metricsReplicatedTxs.WithLabelValues(txr.db.GetName()).Inc()
+}
+
+func (txr *TxReplicator) pausedChan() (chan struct{}, bool) {
+	txr.mutex.Lock()
+	defer txr.mutex.Unlock()
+
+	return txr.resumeCh, txr.paused
+}
+
+// Pause suspends replication, a transaction already fetched from the master is still committed
+func (txr *TxReplicator) Pause() error {
 	txr.mutex.Lock()
 	defer txr.mutex.Unlock()
 
+	if !txr.running {
+		return ErrAlreadyStopped
+	}
+
+	if txr.paused {
+		return ErrAlreadyPaused
+	}
+
+	txr.paused = true
+	txr.resumeCh = make(chan struct{})
+
+	if txr.fetchCancel != nil {
+		txr.fetchCancel()
+	}
+
+	txr.logger.Infof("Replication of database '%s' paused", txr.db.GetName())
+
+	return nil
+}
+
+// Resume continues a paused replication from the last transaction committed in the replica
+func (txr *TxReplicator) Resume() error {
+	txr.mutex.Lock()
+	defer txr.mutex.Unlock()
+
+	if !txr.running {
+		return ErrAlreadyStopped
+	}
+
+	if !txr.paused {
+		return ErrNotPaused
+	}
+
+	txr.paused = false
+	close(txr.resumeCh)
+
+	txr.logger.Infof("Replication of database '%s' resumed", txr.db.GetName())
+
+	return nil
+}
+
+// Status returns the progress of the replication, the master is queried for its
+// last transaction when a connection is established
+func (txr *TxReplicator) Status(ctx context.Context) (*Status, error) {
+	st, err := txr.db.CurrentState()
+	if err != nil {
+		return nil, err
+	}
+
+	txr.mutex.Lock()
+
+	status := &Status{
+		Running:        txr.running,
+		Paused:         txr.paused,
+		Connected:      txr.client != nil,
+		LastTx:         st.TxId,
+		FailedAttempts: txr.failedAttempts,
+		LastError:      txr.lastError,
+	}
+
+	client := txr.client
+	clientContext := txr.clientContext
+
+	txr.mutex.Unlock()
+
+	if client == nil {
+		return status, nil
+	}
+
+	// the context of the request is used for cancellation while credentials are taken from the replicator
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+
+	md, _ := metadata.FromOutgoingContext(clientContext)
+
+	masterSt, err := client.GetServiceClient().CurrentState(metadata.NewOutgoingContext(ctx, md), &empty.Empty{})
+	if err == nil {
+		status.MasterTx = masterSt.TxId
+	}
+
+	return status, nil
+}
+
+func (txr *TxReplicator) Stop() error {
+	txr.mutex.Lock()
+
 	txr.logger.Infof("Stopping replication of database '%s'...", txr.db.GetName())
 
 	if !txr.running {
+		txr.mutex.Unlock()
 		return ErrAlreadyStopped
 	}
 
@@ -269,6 +448,14 @@ func (txr *TxReplicator) Stop() error {
 	}
 
 	txr.running = false
+	txr.paused = false
+
+	done := txr.done
+
+	txr.mutex.Unlock()
+
+	// wait for the replication loop to exit so the database is no longer written
+	<-done
 
 	txr.logger.Infof("Replication of database '%s' successfully stopped", txr.db.GetName())
 
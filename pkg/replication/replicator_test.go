@@ -17,6 +17,7 @@ limitations under the License.
 package replication
 
 import (
+	"context"
 	"os"
 	"testing"
 
@@ -56,6 +57,35 @@ func TestReplication(t *testing.T) {
 	err = txReplicator.Start()
 	require.ErrorIs(t, err, ErrAlreadyRunning)
 
+	err = txReplicator.Resume()
+	require.ErrorIs(t, err, ErrNotPaused)
+
+	err = txReplicator.Pause()
+	require.NoError(t, err)
+
+	err = txReplicator.Pause()
+	require.ErrorIs(t, err, ErrAlreadyPaused)
+
+	st, err := txReplicator.Status(context.Background())
+	require.NoError(t, err)
+	require.True(t, st.Running)
+	require.True(t, st.Paused)
+	require.Zero(t, st.LastTx)
+
+	err = txReplicator.Resume()
+	require.NoError(t, err)
+
 	err = txReplicator.Stop()
 	require.NoError(t, err)
+
+	err = txReplicator.Pause()
+	require.ErrorIs(t, err, ErrAlreadyStopped)
+
+	err = txReplicator.Resume()
+	require.ErrorIs(t, err, ErrAlreadyStopped)
+
+	st, err = txReplicator.Status(context.Background())
+	require.NoError(t, err)
+	require.False(t, st.Running)
+	require.False(t, st.Paused)
 }
@@ -0,0 +1,157 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/database"
+)
+
+// autoCompactionLatencyPercentile is the percentile of the commit latency compared with the latency budget
+const autoCompactionLatencyPercentile = 95
+
+// startAutoCompaction periodically checks the indexes of the databases with auto compaction enabled,
+// compacting them when garbage or the number of files cross the thresholds set in the database settings
+func (s *ImmuServer) startAutoCompaction() {
+	if s.Options.AutoCompactionInterval <= 0 {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	s.autoCompactionCancel = cancel
+	s.autoCompactionDone = done
+
+	go func() {
+		defer close(done)
+
+		for {
+			timer := time.NewTimer(s.Options.AutoCompactionInterval)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+
+			s.compactIndexesIfRequired()
+		}
+	}()
+}
+
+func (s *ImmuServer) stopAutoCompaction() {
+	if s.autoCompactionCancel == nil {
+		return
+	}
+
+	s.autoCompactionCancel()
+	<-s.autoCompactionDone
+
+	s.autoCompactionCancel = nil
+	s.autoCompactionDone = nil
+}
+
+func (s *ImmuServer) compactIndexesIfRequired() {
+	for i := 0; i < s.dbList.Length(); i++ {
+		db := s.dbList.GetByIndex(int64(i))
+
+		_, err := s.autoCompactIndex(db)
+		if err != nil {
+			s.Logger.Warningf("Unable to check index compaction of database '%s'. Reason: %v", db.GetName(), err)
+		}
+	}
+}
+
+// autoCompactIndex starts the compaction of the index of a database when required by its settings.
+// No job is returned when compaction is not required or when it's postponed because of the commit latency.
+func (s *ImmuServer) autoCompactIndex(db database.DB) (*schema.Job, error) {
+	opts, err := s.loadDBOptions(db.GetName(), false)
+	if err == store.ErrKeyNotFound {
+		// settings are not saved until changed, auto compaction is disabled by default
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.IndexOptions == nil || !opts.IndexOptions.AutoCompaction {
+		return nil, nil
+	}
+
+	reason, err := autoCompactionReason(db, opts.IndexOptions)
+	if err != nil || reason == "" {
+		return nil, err
+	}
+
+	latency, err := db.CommitLatency(autoCompactionLatencyPercentile)
+	if err != nil {
+		return nil, err
+	}
+
+	budget := time.Duration(opts.IndexOptions.AutoCompactionLatencyBudget) * time.Millisecond
+
+	// compaction is postponed while commits are already slower than the budget
+	if budget > 0 && latency > budget {
+		s.Logger.Infof("Index compaction of database '%s' postponed, p%d commit latency %v exceeds %v",
+			db.GetName(), autoCompactionLatencyPercentile, latency, budget)
+		return nil, nil
+	}
+
+	job, err := s.startIndexCompaction(db)
+	if err == ErrJobAlreadyInProgress {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.Logger.Infof("Index compaction of database '%s' started, %s", db.GetName(), reason)
+
+	return job, nil
+}
+
+// autoCompactionReason returns the threshold crossed by the index of the database,
+// an empty reason is returned when compaction is not required
+func autoCompactionReason(db database.DB, opts *indexOptions) (string, error) {
+	stats, err := db.IndexStats()
+	if err != nil {
+		return "", err
+	}
+
+	// the index can not be compacted until enough snapshots were flushed
+	if stats.SnapshotCount < uint64(opts.CompactionThld) {
+		return "", nil
+	}
+
+	garbageRatio := stats.GarbageRatio()
+
+	if opts.AutoCompactionGarbageThld > 0 && garbageRatio >= opts.AutoCompactionGarbageThld {
+		return fmt.Sprintf("garbage ratio %.2f reached threshold %.2f", garbageRatio, opts.AutoCompactionGarbageThld), nil
+	}
+
+	if opts.AutoCompactionFilesThld > 0 && stats.NodesLogFiles >= opts.AutoCompactionFilesThld {
+		return fmt.Sprintf("%d files reached threshold %d", stats.NodesLogFiles, opts.AutoCompactionFilesThld), nil
+	}
+
+	return "", nil
+}
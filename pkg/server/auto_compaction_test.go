@@ -0,0 +1,123 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/database"
+	"github.com/stretchr/testify/require"
+)
+
+type slowCommitsDB struct {
+	database.DB
+	latency time.Duration
+}
+
+func (db *slowCommitsDB) CommitLatency(p float64) (time.Duration, error) {
+	return db.latency, nil
+}
+
+func TestAutoCompaction(t *testing.T) {
+	s, ctx, closer := newTestServer(t, DefaultOptions().WithAutoCompactionInterval(0))
+	defer closer()
+
+	_, err := s.CreateDatabaseWithV2(ctx, &schema.DatabaseSettingsV2{
+		DatabaseName: "db1",
+		IndexSettings: &schema.IndexSettings{
+			AutoCompaction:            &schema.ConditionalBool{Value: true},
+			AutoCompactionGarbageThld: &schema.ConditionalFloat{Value: 0.9},
+			AutoCompactionFilesThld:   &schema.ConditionalUint32{Value: 0},
+		},
+	})
+	require.NoError(t, err)
+
+	db, err := s.dbList.GetByName("db1")
+	require.NoError(t, err)
+
+	// every flush replaces the nodes written by the previous one
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 10; j++ {
+			_, err = db.Set(&schema.SetRequest{KVs: []*schema.KeyValue{{
+				Key:   []byte(fmt.Sprintf("key%d", j)),
+				Value: []byte(fmt.Sprintf("value%d_%d", i, j)),
+			}}})
+			require.NoError(t, err)
+		}
+
+		err = db.FlushIndex(&schema.FlushIndexRequest{})
+		require.NoError(t, err)
+	}
+
+	stats, err := db.IndexStats()
+	require.NoError(t, err)
+	require.Greater(t, stats.GarbageRatio(), float32(0.5))
+
+	t.Run("compaction should not start below the thresholds", func(t *testing.T) {
+		job, err := s.autoCompactIndex(db)
+		require.NoError(t, err)
+		require.Nil(t, job)
+	})
+
+	_, err = s.UpdateDatabaseV2(ctx, &schema.DatabaseSettingsV2{
+		DatabaseName: "db1",
+		IndexSettings: &schema.IndexSettings{
+			AutoCompactionGarbageThld: &schema.ConditionalFloat{Value: 0.5},
+		},
+	})
+	require.NoError(t, err)
+
+	t.Run("compaction should be postponed while commits are slow", func(t *testing.T) {
+		job, err := s.autoCompactIndex(&slowCommitsDB{DB: db, latency: time.Second})
+		require.NoError(t, err)
+		require.Nil(t, job)
+	})
+
+	t.Run("compaction should not start on databases without auto compaction", func(t *testing.T) {
+		job, err := s.autoCompactIndex(s.dbList.GetByIndex(defaultDbIndex))
+		require.NoError(t, err)
+		require.Nil(t, job)
+	})
+
+	t.Run("compaction should be started in background", func(t *testing.T) {
+		s.Options.WithAutoCompactionInterval(10 * time.Millisecond)
+
+		s.startAutoCompaction()
+		defer s.stopAutoCompaction()
+
+		require.Eventually(t, func() bool {
+			jobs := s.jobs.list("db1", schema.JobKindCompaction)
+			return len(jobs) == 1 && jobs[0].Status == schema.JobStatusCompleted
+		}, 10*time.Second, 10*time.Millisecond)
+
+		stats, err := db.IndexStats()
+		require.NoError(t, err)
+		require.Equal(t, uint64(1), stats.SnapshotCount)
+		require.Zero(t, stats.GarbageRatio())
+	})
+
+	_, err = s.UpdateDatabaseV2(ctx, &schema.DatabaseSettingsV2{
+		DatabaseName: "db1",
+		IndexSettings: &schema.IndexSettings{
+			AutoCompactionGarbageThld: &schema.ConditionalFloat{Value: 1.5},
+		},
+	})
+	require.ErrorIs(t, err, ErrIllegalArguments)
+}
@@ -0,0 +1,121 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"crypto/x509"
+	"strings"
+
+	"github.com/codenotary/immudb/pkg/auth"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+// clientCertIdentities returns the names a client certificate identifies its owner with:
+// the common name followed by the DNS names, email addresses and URIs of its subject alternative names
+func clientCertIdentities(cert *x509.Certificate) []string {
+	var identities []string
+
+	if cert.Subject.CommonName != "" {
+		identities = append(identities, cert.Subject.CommonName)
+	}
+
+	identities = append(identities, cert.DNSNames...)
+	identities = append(identities, cert.EmailAddresses...)
+
+	for _, uri := range cert.URIs {
+		identities = append(identities, uri.String())
+	}
+
+	return identities
+}
+
+// verifiedClientCert returns the client certificate of the connection if it was verified during the TLS handshake
+func verifiedClientCert(ctx context.Context) (*x509.Certificate, bool) {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p == nil {
+		return nil, false
+	}
+
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(tlsInfo.State.VerifiedChains) == 0 || len(tlsInfo.State.PeerCertificates) == 0 {
+		return nil, false
+	}
+
+	return tlsInfo.State.PeerCertificates[0], true
+}
+
+// clientCertUser returns the active user mapped to the verified client certificate of the connection, if any
+func (s *ImmuServer) clientCertUser(ctx context.Context) (*auth.User, bool) {
+	if len(s.Options.ClientCertUsers) == 0 {
+		return nil, false
+	}
+
+	cert, ok := verifiedClientCert(ctx)
+	if !ok {
+		return nil, false
+	}
+
+	for _, identity := range clientCertIdentities(cert) {
+		username, mapped := s.clientCertUsername(identity)
+		if !mapped {
+			continue
+		}
+
+		u, err := s.getUser([]byte(username), true)
+		if err != nil {
+			s.Logger.Warningf("Client certificate '%s' is mapped to unknown user '%s'", identity, username)
+			return nil, false
+		}
+
+		if !u.Active {
+			return nil, false
+		}
+
+		if u.Username == auth.SysAdminUsername {
+			u.IsSysAdmin = true
+		}
+
+		return u, true
+	}
+
+	return nil, false
+}
+
+// clientCertUsername returns the user the identity is mapped to, identities are case insensitive
+// as names read from configuration files are lowercased
+func (s *ImmuServer) clientCertUsername(identity string) (string, bool) {
+	for id, username := range s.Options.ClientCertUsers {
+		if strings.EqualFold(id, identity) {
+			return username, true
+		}
+	}
+	return "", false
+}
+
+// authenticateUser validates the given credentials,
+// the user mapped to the client certificate is authenticated when none is given
+func (s *ImmuServer) authenticateUser(ctx context.Context, username []byte, password []byte) (*auth.User, error) {
+	if len(username) == 0 && len(password) == 0 {
+		if u, ok := s.clientCertUser(ctx); ok {
+			return u, nil
+		}
+	}
+
+	return s.getValidatedUser(username, password)
+}
@@ -0,0 +1,46 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package server
+
+import (
+	"context"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/database"
+	"github.com/golang/protobuf/ptypes/empty"
+)
+
+// CompactIndexAsync starts the compaction of the index of the selected database as a background job.
+// The returned job can be polled with GetJob, compaction jobs can not be canceled.
+func (s *ImmuServer) CompactIndexAsync(ctx context.Context, _ *empty.Empty) (*schema.Job, error) {
+	db, err := s.getDBFromCtx(ctx, "CompactIndexAsync")
+	if err != nil {
+		return nil, err
+	}
+
+	return s.startIndexCompaction(db)
+}
+
+// startIndexCompaction runs the compaction of the index of a database as a background job
+func (s *ImmuServer) startIndexCompaction(db database.DB) (*schema.Job, error) {
+	return s.jobs.start(schema.JobKindCompaction, db.GetName(), false, db.IndexCompactionProgress, func(ctx context.Context) error {
+		err := db.CompactIndex()
+		if err != nil {
+			s.Logger.Warningf("Index compaction of database '%s' failed: %v", db.GetName(), err)
+		}
+		return err
+	})
+}
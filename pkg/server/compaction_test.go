@@ -0,0 +1,105 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/codenotary/immudb/embedded/tbtree"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+func waitForJob(t *testing.T, s *ImmuServer, ctx context.Context, id string) *schema.Job {
+	for {
+		job, err := s.GetJob(ctx, &schema.JobRequest{Id: id})
+		require.NoError(t, err)
+		require.Equal(t, id, job.Id)
+
+		if job.Finished() {
+			return job
+		}
+
+		require.GreaterOrEqual(t, job.Progress, float32(0))
+		require.LessOrEqual(t, job.Progress, float32(100))
+
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestCompactIndexAsync(t *testing.T) {
+	s, ctx, closer := newTestServer(t, nil)
+	defer closer()
+
+	jobs, err := s.ListJobs(ctx, &schema.ListJobsRequest{Kind: schema.JobKindCompaction})
+	require.NoError(t, err)
+	require.Empty(t, jobs.Jobs)
+
+	for i := 0; i < 100; i++ {
+		_, err = s.Set(ctx, &schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte{byte(i)}, Value: []byte{byte(i)}}}})
+		require.NoError(t, err)
+	}
+
+	job, err := s.CompactIndexAsync(ctx, &emptypb.Empty{})
+	require.NoError(t, err)
+	require.NotEmpty(t, job.Id)
+	require.Equal(t, schema.JobKindCompaction, job.Kind)
+	require.Equal(t, DefaultDBName, job.Database)
+	require.False(t, job.Cancelable)
+	require.NotZero(t, job.StartedAt)
+
+	job = waitForJob(t, s, ctx, job.Id)
+	require.Equal(t, schema.JobStatusCompleted, job.Status)
+	require.Equal(t, float32(100), job.Progress)
+	require.Empty(t, job.Error)
+	require.NotZero(t, job.FinishedAt)
+
+	_, err = s.CancelJob(ctx, &schema.JobRequest{Id: job.Id})
+	require.ErrorIs(t, err, ErrJobAlreadyFinished)
+
+	job2, err := s.CompactIndexAsync(ctx, &emptypb.Empty{})
+	require.NoError(t, err)
+	require.NotEqual(t, job.Id, job2.Id)
+
+	job2 = waitForJob(t, s, ctx, job2.Id)
+	require.Equal(t, schema.JobStatusFailed, job2.Status)
+	require.Equal(t, tbtree.ErrCompactionThresholdNotReached.Error(), job2.Error)
+
+	jobs, err = s.ListJobs(ctx, &schema.ListJobsRequest{Kind: schema.JobKindCompaction})
+	require.NoError(t, err)
+	require.Len(t, jobs.Jobs, 2)
+
+	_, err = s.CreateDatabaseWith(ctx, &schema.DatabaseSettings{DatabaseName: "db1"})
+	require.NoError(t, err)
+
+	ur, err := s.UseDatabase(ctx, &schema.Database{DatabaseName: "db1"})
+	require.NoError(t, err)
+
+	db1Ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", ur.Token))
+
+	// jobs are only visible from the database they run on
+	_, err = s.GetJob(db1Ctx, &schema.JobRequest{Id: job2.Id})
+	require.ErrorIs(t, err, ErrJobNotFound)
+
+	jobs, err = s.ListJobs(db1Ctx, &schema.ListJobsRequest{})
+	require.NoError(t, err)
+	require.Empty(t, jobs.Jobs)
+}
@@ -0,0 +1,140 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/auth"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestAnonymousReads(t *testing.T) {
+	s, ctx, closer := newTestServer(t, nil)
+	defer closer()
+
+	settings, err := s.CreateDatabaseWithV2(ctx, &schema.DatabaseSettingsV2{
+		DatabaseName:   "publicdb",
+		AnonymousReads: &schema.ConditionalBool{Value: true},
+	})
+	require.NoError(t, err)
+	require.True(t, settings.AnonymousReads.Value)
+
+	_, err = s.CreateDatabaseWithV2(ctx, &schema.DatabaseSettingsV2{DatabaseName: "privatedb"})
+	require.NoError(t, err)
+
+	for _, db := range []string{"publicdb", "privatedb"} {
+		ur, err := s.UseDatabase(ctx, &schema.Database{DatabaseName: db})
+		require.NoError(t, err)
+
+		dbCtx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", ur.Token))
+
+		_, err = s.Set(dbCtx, &schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte("key1"), Value: []byte("value1")}}})
+		require.NoError(t, err)
+	}
+
+	anonymousCtx := func(db string) context.Context {
+		return metadata.NewIncomingContext(context.Background(), metadata.Pairs(auth.DatabaseHeader, db))
+	}
+
+	t.Run("databases allowing anonymous reads should be readable without logging in", func(t *testing.T) {
+		entry, err := s.Get(anonymousCtx("publicdb"), &schema.KeyRequest{Key: []byte("key1")})
+		require.NoError(t, err)
+		require.Equal(t, []byte("value1"), entry.Value)
+
+		state, err := s.CurrentState(anonymousCtx("publicdb"), nil)
+		require.NoError(t, err)
+		require.Equal(t, "publicdb", state.Db)
+
+		_, err = s.VerifiableTxById(anonymousCtx("publicdb"), &schema.VerifiableTxRequest{Tx: state.TxId, ProveSinceTx: 1})
+		require.NoError(t, err)
+	})
+
+	t.Run("writes should require authentication", func(t *testing.T) {
+		_, err := s.Set(anonymousCtx("publicdb"), &schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte("key2"), Value: []byte("value2")}}})
+		require.Error(t, err)
+
+		_, err = s.SQLExec(anonymousCtx("publicdb"), &schema.SQLExecRequest{Sql: "CREATE TABLE t1(id INTEGER, PRIMARY KEY id)"})
+		require.Error(t, err)
+	})
+
+	t.Run("other databases should not be readable without logging in", func(t *testing.T) {
+		_, err := s.Get(anonymousCtx("privatedb"), &schema.KeyRequest{Key: []byte("key1")})
+		require.Error(t, err)
+
+		_, err = s.Get(anonymousCtx(SystemDBName), &schema.KeyRequest{Key: []byte("key1")})
+		require.Error(t, err)
+
+		_, err = s.Get(anonymousCtx("unknowndb"), &schema.KeyRequest{Key: []byte("key1")})
+		require.Error(t, err)
+
+		_, err = s.Get(context.Background(), &schema.KeyRequest{Key: []byte("key1")})
+		require.Error(t, err)
+	})
+
+	t.Run("anonymous readers should only get redacted values", func(t *testing.T) {
+		ur, err := s.UseDatabase(ctx, &schema.Database{DatabaseName: "publicdb"})
+		require.NoError(t, err)
+
+		dbCtx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", ur.Token))
+
+		_, err = s.SetRedactionRule(dbCtx, &schema.RedactionRule{
+			Prefix:       []byte("card:"),
+			Mode:         schema.RedactionMode_REDACT_PARTIAL,
+			VisibleChars: 4,
+		})
+		require.NoError(t, err)
+
+		_, err = s.Set(dbCtx, &schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte("card:1"), Value: []byte("4111111111111111")}}})
+		require.NoError(t, err)
+
+		entry, err := s.Get(anonymousCtx("publicdb"), &schema.KeyRequest{Key: []byte("card:1")})
+		require.NoError(t, err)
+		require.Equal(t, []byte("************1111"), entry.Value)
+
+		entries, err := s.Scan(anonymousCtx("publicdb"), &schema.ScanRequest{Prefix: []byte("card:")})
+		require.NoError(t, err)
+		require.Len(t, entries.Entries, 1)
+		require.Equal(t, []byte("************1111"), entries.Entries[0].Value)
+
+		_, err = s.VerifiableGet(anonymousCtx("publicdb"), &schema.VerifiableGetRequest{
+			KeyRequest:   &schema.KeyRequest{Key: []byte("card:1")},
+			ProveSinceTx: 1,
+		})
+		require.Error(t, err)
+
+		entry, err = s.Get(dbCtx, &schema.KeyRequest{Key: []byte("card:1")})
+		require.NoError(t, err)
+		require.Equal(t, []byte("4111111111111111"), entry.Value)
+	})
+
+	t.Run("anonymous reads should be disabled on update", func(t *testing.T) {
+		res, err := s.UpdateDatabaseV2(ctx, &schema.DatabaseSettingsV2{
+			DatabaseName:   "publicdb",
+			AnonymousReads: &schema.ConditionalBool{Value: false},
+		})
+		require.NoError(t, err)
+		require.False(t, res.CurrentSettings.AnonymousReads.Value)
+		require.Empty(t, res.ReloadRequired)
+
+		_, err = s.Get(anonymousCtx("publicdb"), &schema.KeyRequest{Key: []byte("key1")})
+		require.Error(t, err)
+	})
+}
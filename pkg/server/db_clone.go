@@ -0,0 +1,169 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/database"
+)
+
+// CloneDatabase creates a new database holding the state of the source database up to the requested transaction.
+// Transactions are copied into the new database, so nothing is shared on disk with the source database.
+// The clone is created as a replica without master, thus it's read-only until its settings are updated.
+func (s *ImmuServer) CloneDatabase(ctx context.Context, req *schema.CloneDatabaseRequest) (*schema.CloneDatabaseResponse, error) {
+	if req == nil || req.SourceDatabase == "" {
+		return nil, ErrIllegalArguments
+	}
+
+	if s.Options.GetMaintenance() {
+		return nil, ErrNotAllowedInMaintenanceMode
+	}
+
+	if !s.Options.GetAuth() {
+		return nil, ErrAuthMustBeEnabled
+	}
+
+	_, user, err := s.getLoggedInUserdataFromCtx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not get loggedin user data")
+	}
+
+	if !user.IsSysAdmin {
+		return nil, ErrPermissionDenied
+	}
+
+	if req.SourceDatabase == SystemDBName {
+		return nil, ErrReservedDatabase
+	}
+
+	err = s.checkNewDatabaseName(req.DatabaseName)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.checkDatabaseNetworkRules(ctx, "CloneDatabase", req.SourceDatabase)
+	if err != nil {
+		return nil, err
+	}
+
+	srcDB, err := s.dbList.GetByName(req.SourceDatabase)
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := srcDB.CurrentState()
+	if err != nil {
+		return nil, err
+	}
+
+	asOfTx := req.AsOfTx
+	if asOfTx == 0 {
+		asOfTx = state.TxId
+	}
+
+	if asOfTx > state.TxId {
+		return nil, fmt.Errorf("%w: database '%s' has no transaction %d", ErrIllegalArguments, req.SourceDatabase, asOfTx)
+	}
+
+	srcOpts, err := s.loadDBOptions(req.SourceDatabase, false)
+	if err != nil {
+		return nil, err
+	}
+
+	dbOpts := *srcOpts
+	dbOpts.Database = req.DatabaseName
+	dbOpts.Replica = true
+	dbOpts.MasterDatabase = ""
+	dbOpts.MasterAddress = ""
+	dbOpts.MasterPort = 0
+	dbOpts.FollowerUsername = ""
+	dbOpts.FollowerPassword = ""
+	dbOpts.CreatedBy = user.Username
+	dbOpts.CreatedAt = time.Now()
+	dbOpts.UpdatedBy = ""
+	dbOpts.UpdatedAt = time.Time{}
+
+	s.Logger.Infof("Cloning database '%s' up to tx %d into database '%s'...", req.SourceDatabase, asOfTx, req.DatabaseName)
+
+	// the clone is owned by the tenant owning the source database
+	err = s.addTenantDatabase(dbOpts.Tenant, dbOpts.Database)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := database.NewDB(dbOpts.Database, s.databaseOptionsFrom(&dbOpts), s.Logger)
+	if err != nil {
+		s.removeTenantDatabase(dbOpts.Database)
+		return nil, err
+	}
+
+	err = cloneTxs(srcDB, db, asOfTx)
+	if err == nil {
+		err = s.saveDBOptions(&dbOpts)
+	}
+	if err != nil {
+		s.removeTenantDatabase(dbOpts.Database)
+		s.discardClone(db)
+		return nil, fmt.Errorf("database '%s' could not be cloned: %w", req.SourceDatabase, err)
+	}
+
+	s.dbList.Append(db)
+	s.multidbmode = true
+
+	s.Logger.Infof("Database '%s' successfully cloned into database '%s'", req.SourceDatabase, req.DatabaseName)
+
+	return &schema.CloneDatabaseResponse{
+		Settings: dbOpts.databaseSettings(),
+		TxId:     asOfTx,
+	}, nil
+}
+
+// cloneTxs replicates the transactions of the source database, up to the given one, into the clone
+func cloneTxs(srcDB, db database.DB, asOfTx uint64) error {
+	for txID := uint64(1); txID <= asOfTx; txID++ {
+		etx, err := srcDB.ExportTxByID(&schema.ExportTxRequest{Tx: txID})
+		if err != nil {
+			return err
+		}
+
+		_, err = db.ReplicateTx(etx)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// discardClone removes a clone which could not be completed
+func (s *ImmuServer) discardClone(db database.DB) {
+	err := db.Close()
+	if err != nil {
+		s.Logger.Warningf("Error closing database '%s'. Reason: %v", db.GetName(), err)
+	}
+
+	err = os.RemoveAll(filepath.Join(s.Options.Dir, db.GetName()))
+	if err != nil {
+		s.Logger.Warningf("Error removing data of database '%s'. Reason: %v", db.GetName(), err)
+	}
+}
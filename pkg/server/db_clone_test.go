@@ -0,0 +1,113 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/auth"
+	"github.com/codenotary/immudb/pkg/database"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCloneDatabase(t *testing.T) {
+	s, ctx, closer := newTestServer(t, nil)
+	defer closer()
+
+	_, err := s.CreateDatabaseWithV2(ctx, &schema.DatabaseSettingsV2{
+		DatabaseName:   "db1",
+		MaxConcurrency: &schema.ConditionalUint32{Value: 20},
+	})
+	require.NoError(t, err)
+
+	srcDB, err := s.dbList.GetByName("db1")
+	require.NoError(t, err)
+
+	var hdrs []*schema.TxHeader
+
+	for i := 0; i < 5; i++ {
+		hdr, err := srcDB.Set(&schema.SetRequest{KVs: []*schema.KeyValue{
+			{Key: []byte("key"), Value: []byte(fmt.Sprintf("value%d", i))},
+		}})
+		require.NoError(t, err)
+
+		hdrs = append(hdrs, hdr)
+	}
+
+	t.Run("databases should be cloned up to the requested tx", func(t *testing.T) {
+		res, err := s.CloneDatabase(ctx, &schema.CloneDatabaseRequest{
+			SourceDatabase: "db1",
+			DatabaseName:   "db1clone",
+			AsOfTx:         hdrs[2].Id,
+		})
+		require.NoError(t, err)
+		require.Equal(t, hdrs[2].Id, res.TxId)
+		require.Equal(t, "db1clone", res.Settings.DatabaseName)
+		require.True(t, res.Settings.ReplicationSettings.Replica.Value)
+		require.Equal(t, uint32(20), res.Settings.MaxConcurrency.Value)
+
+		clone, err := s.dbList.GetByName("db1clone")
+		require.NoError(t, err)
+		require.True(t, clone.IsReplica())
+
+		state, err := clone.CurrentState()
+		require.NoError(t, err)
+		require.Equal(t, hdrs[2].Id, state.TxId)
+		require.Equal(t, schema.TxHeaderFromProto(hdrs[2]).Alh(), schema.DigestFromProto(state.TxHash))
+
+		entry, err := clone.Get(context.Background(), &schema.KeyRequest{Key: []byte("key"), SinceTx: state.TxId})
+		require.NoError(t, err)
+		require.Equal(t, []byte("value2"), entry.Value)
+
+		_, err = clone.Set(&schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte("key"), Value: []byte("value")}}})
+		require.ErrorIs(t, err, database.ErrIsReplica)
+
+		settings, err := s.loadDBOptions("db1clone", false)
+		require.NoError(t, err)
+		require.Equal(t, auth.SysAdminUsername, settings.CreatedBy)
+	})
+
+	t.Run("databases should be cloned up to the latest tx by default", func(t *testing.T) {
+		res, err := s.CloneDatabase(ctx, &schema.CloneDatabaseRequest{SourceDatabase: "db1", DatabaseName: "db1latest"})
+		require.NoError(t, err)
+		require.Equal(t, hdrs[4].Id, res.TxId)
+	})
+
+	t.Run("invalid requests should fail", func(t *testing.T) {
+		_, err := s.CloneDatabase(ctx, nil)
+		require.ErrorIs(t, err, ErrIllegalArguments)
+
+		_, err = s.CloneDatabase(ctx, &schema.CloneDatabaseRequest{SourceDatabase: "db1", DatabaseName: "db1clone"})
+		require.Error(t, err)
+
+		_, err = s.CloneDatabase(ctx, &schema.CloneDatabaseRequest{SourceDatabase: "db2", DatabaseName: "db2clone"})
+		require.ErrorIs(t, err, database.ErrDatabaseNotExists)
+
+		_, err = s.CloneDatabase(ctx, &schema.CloneDatabaseRequest{SourceDatabase: SystemDBName, DatabaseName: "sysclone"})
+		require.ErrorIs(t, err, ErrReservedDatabase)
+
+		_, err = s.CloneDatabase(ctx, &schema.CloneDatabaseRequest{SourceDatabase: "db1", DatabaseName: "db1future", AsOfTx: hdrs[4].Id + 1})
+		require.ErrorIs(t, err, ErrIllegalArguments)
+		require.Negative(t, s.dbList.GetId("db1future"))
+
+		_, err = s.CloneDatabase(context.Background(), &schema.CloneDatabaseRequest{SourceDatabase: "db1", DatabaseName: "db1other"})
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,128 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/auth"
+	"github.com/codenotary/immudb/pkg/database"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestDatabaseDiskQuota(t *testing.T) {
+	s, ctx, closer := newTestServer(t, nil)
+	defer closer()
+	// the server is restarted by the subtests
+	defer func() { s.CloseDatabases() }()
+
+	_, err := s.CreateDatabaseWithV2(ctx, &schema.DatabaseSettingsV2{DatabaseName: "db1"})
+	require.NoError(t, err)
+
+	ur, err := s.UseDatabase(ctx, &schema.Database{DatabaseName: "db1"})
+	require.NoError(t, err)
+
+	dbCtx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", ur.Token))
+
+	_, err = s.Set(dbCtx, &schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte("key1"), Value: []byte("value1")}}})
+	require.NoError(t, err)
+
+	res, err := s.UpdateDatabaseV2(ctx, &schema.DatabaseSettingsV2{
+		DatabaseName: "db1",
+		MaxDiskUsage: &schema.ConditionalUint64{Value: 1},
+	})
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), res.CurrentSettings.MaxDiskUsage.Value)
+	require.Empty(t, res.ReloadRequired)
+
+	t.Run("writes should be rejected once the database exceeds its quota", func(t *testing.T) {
+		_, err := s.Set(dbCtx, &schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte("key2"), Value: []byte("value2")}}})
+		require.ErrorIs(t, err, database.ErrDiskQuotaExceeded)
+
+		mappedErr := mapServerError(err)
+		require.Equal(t, codes.ResourceExhausted, status.Code(mappedErr))
+
+		entry, err := s.Get(dbCtx, &schema.KeyRequest{Key: []byte("key1")})
+		require.NoError(t, err)
+		require.Equal(t, []byte("value1"), entry.Value)
+	})
+
+	t.Run("databases exceeding their quota should be reported", func(t *testing.T) {
+		exceeded := s.metricFuncComputeDBDiskQuotaExceeded()
+		require.Equal(t, float64(1), exceeded["db1"])
+		require.Equal(t, float64(0), exceeded[DefaultDBName])
+	})
+
+	t.Run("the quota should be kept when the database is reloaded", func(t *testing.T) {
+		err := s.CloseDatabases()
+		require.NoError(t, err)
+
+		s = DefaultServer().WithOptions(s.Options).(*ImmuServer)
+
+		err = s.Initialize()
+		require.NoError(t, err)
+
+		db, err := s.dbList.GetByName("db1")
+		require.NoError(t, err)
+		require.True(t, db.DiskQuotaExceeded())
+	})
+
+	t.Run("writes should be accepted once the quota is lifted", func(t *testing.T) {
+		lr, err := s.Login(context.Background(), &schema.LoginRequest{
+			User:     []byte(auth.SysAdminUsername),
+			Password: []byte(auth.SysAdminPassword),
+		})
+		require.NoError(t, err)
+
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", lr.Token))
+
+		_, err = s.UpdateDatabaseV2(ctx, &schema.DatabaseSettingsV2{
+			DatabaseName: "db1",
+			MaxDiskUsage: &schema.ConditionalUint64{Value: 0},
+		})
+		require.NoError(t, err)
+
+		ur, err := s.UseDatabase(ctx, &schema.Database{DatabaseName: "db1"})
+		require.NoError(t, err)
+
+		dbCtx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", ur.Token))
+
+		_, err = s.Set(dbCtx, &schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte("key2"), Value: []byte("value2")}}})
+		require.NoError(t, err)
+	})
+
+	t.Run("quotas beyond the supported range should be rejected", func(t *testing.T) {
+		lr, err := s.Login(context.Background(), &schema.LoginRequest{
+			User:     []byte(auth.SysAdminUsername),
+			Password: []byte(auth.SysAdminPassword),
+		})
+		require.NoError(t, err)
+
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", lr.Token))
+
+		_, err = s.UpdateDatabaseV2(ctx, &schema.DatabaseSettingsV2{
+			DatabaseName: "db1",
+			MaxDiskUsage: &schema.ConditionalUint64{Value: 1 << 63},
+		})
+		require.ErrorIs(t, err, ErrIllegalArguments)
+	})
+}
@@ -42,7 +42,7 @@ func (s *ImmuServer) CurrentState(ctx context.Context, _ *empty.Empty) (*schema.
 	state.Db = db.GetName()
 
 	if s.StateSigner != nil {
-		err = s.StateSigner.Sign(state)
+		err = s.signState(state)
 		if err != nil {
 			return nil, err
 		}
@@ -109,7 +109,7 @@ func (s *ImmuServer) Get(ctx context.Context, req *schema.KeyRequest) (*schema.E
 		return nil, err
 	}
 
-	return db.Get(req)
+	return db.Get(ctx, req)
 }
 
 // VerifiableGet ...
@@ -119,7 +119,7 @@ func (s *ImmuServer) VerifiableGet(ctx context.Context, req *schema.VerifiableGe
 		return nil, err
 	}
 
-	vEntry, err := db.VerifiableGet(req)
+	vEntry, err := db.VerifiableGet(ctx, req)
 	if err != nil {
 		return nil, err
 	}
@@ -152,7 +152,9 @@ func (s *ImmuServer) Scan(ctx context.Context, req *schema.ScanRequest) (*schema
 		return nil, err
 	}
 
-	return db.Scan(req)
+	defer s.logSlowQuery(ctx, db, slowQueryScan, scanStatement(req), nil, time.Now())
+
+	return db.Scan(ctx, req)
 }
 
 // Count ...
@@ -172,7 +174,7 @@ func (s *ImmuServer) TxById(ctx context.Context, req *schema.TxRequest) (*schema
 		return nil, err
 	}
 
-	return db.TxByID(req)
+	return db.TxByID(ctx, req)
 }
 
 // VerifiableTxByID ...
@@ -182,7 +184,7 @@ func (s *ImmuServer) VerifiableTxById(ctx context.Context, req *schema.Verifiabl
 		return nil, err
 	}
 
-	vtx, err := db.VerifiableTxByID(req)
+	vtx, err := db.VerifiableTxByID(ctx, req)
 	if err != nil {
 		return nil, err
 	}
@@ -215,7 +217,7 @@ func (s *ImmuServer) TxScan(ctx context.Context, req *schema.TxScanRequest) (*sc
 		return nil, err
 	}
 
-	return db.TxScan(req)
+	return db.TxScan(ctx, req)
 }
 
 // History ...
@@ -225,7 +227,7 @@ func (s *ImmuServer) History(ctx context.Context, req *schema.HistoryRequest) (*
 		return nil, err
 	}
 
-	return db.History(req)
+	return db.History(ctx, req)
 }
 
 // SetReference ...
@@ -242,6 +244,30 @@ func (s *ImmuServer) SetReference(ctx context.Context, req *schema.ReferenceRequ
 	return db.SetReference(req)
 }
 
+// ResolveAll returns the entries of the references currently pointing to a key
+func (s *ImmuServer) ResolveAll(ctx context.Context, req *schema.ResolveAllRequest) (*schema.Entries, error) {
+	db, err := s.getDBFromCtx(ctx, "ResolveAll")
+	if err != nil {
+		return nil, err
+	}
+
+	return db.ResolveAll(req)
+}
+
+// Rename moves the value of a key to a new one, the history of the old key stays linked to the new one
+func (s *ImmuServer) Rename(ctx context.Context, req *schema.RenameRequest) (*schema.TxHeader, error) {
+	if s.Options.GetMaintenance() {
+		return nil, ErrNotAllowedInMaintenanceMode
+	}
+
+	db, err := s.getDBFromCtx(ctx, "Rename")
+	if err != nil {
+		return nil, err
+	}
+
+	return db.Rename(req)
+}
+
 // VerifibleSetReference ...
 func (s *ImmuServer) VerifiableSetReference(ctx context.Context, req *schema.VerifiableReferenceRequest) (*schema.VerifiableTx, error) {
 	if s.Options.GetMaintenance() {
@@ -300,7 +326,7 @@ func (s *ImmuServer) ZScan(ctx context.Context, req *schema.ZScanRequest) (*sche
 		return nil, err
 	}
 
-	return db.ZScan(req)
+	return db.ZScan(ctx, req)
 }
 
 // VerifiableZAdd ...
@@ -372,7 +398,7 @@ func (s *ImmuServer) GetAll(ctx context.Context, req *schema.KeyListRequest) (*s
 		return nil, err
 	}
 
-	return db.GetAll(req)
+	return db.GetAll(ctx, req)
 }
 
 func (s *ImmuServer) Delete(ctx context.Context, req *schema.DeleteKeysRequest) (*schema.TxHeader, error) {
@@ -400,3 +426,129 @@ func (s *ImmuServer) ExecAll(ctx context.Context, req *schema.ExecAllRequest) (*
 
 	return db.ExecAll(req)
 }
+
+// ExecScript runs a script reading and conditionally writing keys within a single transaction,
+// scripts are only run when enabled on the server
+func (s *ImmuServer) ExecScript(ctx context.Context, req *schema.ExecScriptRequest) (*schema.ExecScriptResult, error) {
+	if s.Options.GetMaintenance() {
+		return nil, ErrNotAllowedInMaintenanceMode
+	}
+
+	if !s.Options.Scripting {
+		return nil, ErrScriptingDisabled
+	}
+
+	db, err := s.getDBFromCtx(ctx, "ExecScript")
+	if err != nil {
+		return nil, err
+	}
+
+	return db.ExecScript(req)
+}
+
+// RegisterExternalRoot appends a root of an external log.
+// Roots registered through the API are stored as reported, consistency with previously registered roots is not proven.
+func (s *ImmuServer) RegisterExternalRoot(ctx context.Context, req *schema.ExternalRoot) (*schema.TxHeader, error) {
+	if s.Options.GetMaintenance() {
+		return nil, ErrNotAllowedInMaintenanceMode
+	}
+
+	if req == nil {
+		return nil, store.ErrIllegalArguments
+	}
+
+	db, err := s.getDBFromCtx(ctx, "RegisterExternalRoot")
+	if err != nil {
+		return nil, err
+	}
+
+	req.Verified = false
+
+	return db.RegisterExternalRoot(req)
+}
+
+// ExternalRoots ...
+func (s *ImmuServer) ExternalRoots(ctx context.Context, req *schema.ExternalRootsRequest) (*schema.ExternalRootList, error) {
+	db, err := s.getDBFromCtx(ctx, "ExternalRoots")
+	if err != nil {
+		return nil, err
+	}
+
+	return db.ExternalRoots(req)
+}
+
+// SetKVSchema registers the schema the values written under a key prefix must conform to
+func (s *ImmuServer) SetKVSchema(ctx context.Context, req *schema.KVSchema) (*schema.TxHeader, error) {
+	if s.Options.GetMaintenance() {
+		return nil, ErrNotAllowedInMaintenanceMode
+	}
+
+	db, err := s.getDBFromCtx(ctx, "SetKVSchema")
+	if err != nil {
+		return nil, err
+	}
+
+	return db.SetKVSchema(req)
+}
+
+// DeleteKVSchema removes the schema registered for a key prefix
+func (s *ImmuServer) DeleteKVSchema(ctx context.Context, req *schema.KVSchemaRequest) (*schema.TxHeader, error) {
+	if s.Options.GetMaintenance() {
+		return nil, ErrNotAllowedInMaintenanceMode
+	}
+
+	db, err := s.getDBFromCtx(ctx, "DeleteKVSchema")
+	if err != nil {
+		return nil, err
+	}
+
+	return db.DeleteKVSchema(req)
+}
+
+// KVSchemas lists the schemas registered in the database
+func (s *ImmuServer) KVSchemas(ctx context.Context, _ *empty.Empty) (*schema.KVSchemaList, error) {
+	db, err := s.getDBFromCtx(ctx, "KVSchemas")
+	if err != nil {
+		return nil, err
+	}
+
+	return db.KVSchemas()
+}
+
+// SetRedactionRule registers how the values under a key prefix or of a SQL column are redacted for restricted readers
+func (s *ImmuServer) SetRedactionRule(ctx context.Context, req *schema.RedactionRule) (*schema.TxHeader, error) {
+	if s.Options.GetMaintenance() {
+		return nil, ErrNotAllowedInMaintenanceMode
+	}
+
+	db, err := s.getDBFromCtx(ctx, "SetRedactionRule")
+	if err != nil {
+		return nil, err
+	}
+
+	return db.SetRedactionRule(req)
+}
+
+// DeleteRedactionRule removes the redaction rule registered for a key prefix or a SQL column
+func (s *ImmuServer) DeleteRedactionRule(ctx context.Context, req *schema.RedactionRuleRequest) (*schema.TxHeader, error) {
+	if s.Options.GetMaintenance() {
+		return nil, ErrNotAllowedInMaintenanceMode
+	}
+
+	db, err := s.getDBFromCtx(ctx, "DeleteRedactionRule")
+	if err != nil {
+		return nil, err
+	}
+
+	return db.DeleteRedactionRule(req)
+}
+
+// RedactionRules lists the redaction rules registered in the database
+func (s *ImmuServer) RedactionRules(ctx context.Context, _ *empty.Empty) (*schema.RedactionRuleList, error) {
+	db, err := s.getDBFromCtx(ctx, "RedactionRules")
+	if err != nil {
+		return nil, err
+	}
+
+	return db.RedactionRules()
+}
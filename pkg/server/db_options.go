@@ -17,8 +17,10 @@ limitations under the License.
 package server
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"math"
 	"time"
 
 	"github.com/codenotary/immudb/embedded/store"
@@ -57,8 +59,26 @@ type dbOptions struct {
 	CommitLogMaxOpenedFiles int `json:"commitLogMaxOpenedFiles"`
 	WriteTxHeaderVersion    int `json:"writeTxHeaderVersion"`
 
+	ValueCompression          string `json:"valueCompression"`
+	ValueCompressionThreshold int    `json:"valueCompressionThreshold"`
+
+	AnonymousReads  bool `json:"anonymousReads"`
+	MaintenanceMode bool `json:"maintenanceMode"`
+
+	MaxDiskUsage int64 `json:"maxDiskUsage"` // bytes, 0 means unlimited
+
+	ReverseReferenceIndex bool `json:"reverseReferenceIndex"`
+
+	// background scrubs are disabled when set to 0
+	ScrubInterval    int64 `json:"scrubInterval"`    // ms
+	DelayDuringScrub int64 `json:"delayDuringScrub"` // ms
+
 	IndexOptions *indexOptions `json:"indexOptions"`
 
+	Template string `json:"template"`
+
+	Tenant string `json:"tenant"`
+
 	CreatedBy string    `json:"createdBy"`
 	CreatedAt time.Time `json:"createdAt"`
 	UpdatedBy string    `json:"updatedBy"`
@@ -79,11 +99,35 @@ type indexOptions struct {
 	NodesLogMaxOpenedFiles   int     `json:"nodesLogMaxOpenedFiles"`
 	HistoryLogMaxOpenedFiles int     `json:"historyLogMaxOpenedFiles"`
 	CommitLogMaxOpenedFiles  int     `json:"commitLogMaxOpenedFiles"`
+
+	// automatic compaction, triggers are disabled when set to 0
+	AutoCompaction              bool    `json:"autoCompaction"`
+	AutoCompactionGarbageThld   float32 `json:"autoCompactionGarbageThld"`
+	AutoCompactionFilesThld     int     `json:"autoCompactionFilesThld"`
+	AutoCompactionLatencyBudget int64   `json:"autoCompactionLatencyBudget"` // ms, p95 of the commit latency
 }
 
 const DefaultMaxValueLen = 1 << 25   //32Mb
 const DefaultStoreFileSize = 1 << 29 //512Mb
 
+const (
+	DefaultAutoCompactionGarbageThld   = 0.5
+	DefaultAutoCompactionFilesThld     = 100
+	DefaultAutoCompactionLatencyBudget = 100 // ms
+)
+
+const (
+	NoValueCompression   = "none"
+	ZstdValueCompression = "zstd"
+	S2ValueCompression   = "s2"
+)
+
+var valueCompressionAlgorithms = map[string]int{
+	NoValueCompression:   store.NoValueCompression,
+	ZstdValueCompression: store.ZstdValueCompression,
+	S2ValueCompression:   store.S2ValueCompression,
+}
+
 func (s *ImmuServer) defaultDBOptions(database string) *dbOptions {
 	return &dbOptions{
 		Database: database,
@@ -107,6 +151,11 @@ func (s *ImmuServer) defaultDBOptions(database string) *dbOptions {
 		CommitLogMaxOpenedFiles: store.DefaultCommitLogMaxOpenedFiles,
 		WriteTxHeaderVersion:    store.DefaultWriteTxHeaderVersion,
 
+		ValueCompression:          NoValueCompression,
+		ValueCompressionThreshold: store.DefaultValueCompressionThreshold,
+
+		DelayDuringScrub: store.DefaultDelayDuringScrub.Milliseconds(),
+
 		IndexOptions: s.defaultIndexOptions(),
 
 		CreatedAt: time.Now(),
@@ -128,14 +177,32 @@ func (s *ImmuServer) defaultIndexOptions() *indexOptions {
 		NodesLogMaxOpenedFiles:   tbtree.DefaultNodesLogMaxOpenedFiles,
 		HistoryLogMaxOpenedFiles: tbtree.DefaultHistoryLogMaxOpenedFiles,
 		CommitLogMaxOpenedFiles:  tbtree.DefaultCommitLogMaxOpenedFiles,
+
+		AutoCompaction:              false,
+		AutoCompactionGarbageThld:   DefaultAutoCompactionGarbageThld,
+		AutoCompactionFilesThld:     DefaultAutoCompactionFilesThld,
+		AutoCompactionLatencyBudget: DefaultAutoCompactionLatencyBudget,
 	}
 }
 
 func (s *ImmuServer) databaseOptionsFrom(opts *dbOptions) *database.Options {
-	return database.DefaultOption().
+	dbOpts := database.DefaultOption().
 		WithDBRootPath(s.Options.Dir).
 		WithStoreOptions(s.storeOptionsForDB(opts.Database, s.remoteStorage, opts.storeOptions())).
-		AsReplica(opts.Replica)
+		AsReplica(opts.Replica).
+		AsInMaintenance(opts.MaintenanceMode).
+		WithAnonymousReads(opts.AnonymousReads).
+		WithMaxDiskUsage(opts.MaxDiskUsage).
+		WithReverseReferenceIndex(opts.ReverseReferenceIndex)
+
+	// the system database is read by the server itself, which relies on complete results
+	if opts.Database != SystemDBName {
+		dbOpts.
+			WithMaxResultRows(s.Options.MaxResultRows).
+			WithMaxResultBytes(s.Options.MaxResultBytes)
+	}
+
+	return dbOpts
 }
 
 func (opts *dbOptions) storeOptions() *store.Options {
@@ -171,7 +238,11 @@ func (opts *dbOptions) storeOptions() *store.Options {
 		WithVLogMaxOpenedFiles(opts.VLogMaxOpenedFiles).
 		WithTxLogMaxOpenedFiles(opts.TxLogMaxOpenedFiles).
 		WithCommitLogMaxOpenedFiles(opts.CommitLogMaxOpenedFiles).
+		WithValueCompression(valueCompressionAlgorithms[opts.ValueCompression]).
+		WithValueCompressionThld(opts.ValueCompressionThreshold).
 		WithMaxLinearProofLen(0). // fixed no limitation, it may be customized in the future
+		WithScrubInterval(time.Millisecond * time.Duration(opts.ScrubInterval)).
+		WithDelayDuringScrub(time.Millisecond * time.Duration(opts.DelayDuringScrub)).
 		WithIndexOptions(indexOpts)
 
 	if opts.ExcludeCommitTime {
@@ -183,6 +254,29 @@ func (opts *dbOptions) storeOptions() *store.Options {
 	return stOpts
 }
 
+// reloadRequired returns the settings changed from prevOpts which are not applied to an opened database
+func (opts *dbOptions) reloadRequired(prevOpts *dbOptions) []string {
+	var settings []string
+
+	if opts.MaxConcurrency != prevOpts.MaxConcurrency {
+		settings = append(settings, "maxConcurrency")
+	}
+
+	if opts.MaxIOConcurrency != prevOpts.MaxIOConcurrency {
+		settings = append(settings, "maxIOConcurrency")
+	}
+
+	if opts.ScrubInterval != prevOpts.ScrubInterval {
+		settings = append(settings, "scrubInterval")
+	}
+
+	if opts.DelayDuringScrub != prevOpts.DelayDuringScrub {
+		settings = append(settings, "delayDuringScrub")
+	}
+
+	return settings
+}
+
 func (opts *dbOptions) databaseSettings() *schema.DatabaseSettingsV2 {
 
 	return &schema.DatabaseSettingsV2{
@@ -226,9 +320,31 @@ func (opts *dbOptions) databaseSettings() *schema.DatabaseSettingsV2 {
 			NodesLogMaxOpenedFiles:   &schema.ConditionalUint32{Value: uint32(opts.IndexOptions.NodesLogMaxOpenedFiles)},
 			HistoryLogMaxOpenedFiles: &schema.ConditionalUint32{Value: uint32(opts.IndexOptions.HistoryLogMaxOpenedFiles)},
 			CommitLogMaxOpenedFiles:  &schema.ConditionalUint32{Value: uint32(opts.IndexOptions.CommitLogMaxOpenedFiles)},
+
+			AutoCompaction:              &schema.ConditionalBool{Value: opts.IndexOptions.AutoCompaction},
+			AutoCompactionGarbageThld:   &schema.ConditionalFloat{Value: opts.IndexOptions.AutoCompactionGarbageThld},
+			AutoCompactionFilesThld:     &schema.ConditionalUint32{Value: uint32(opts.IndexOptions.AutoCompactionFilesThld)},
+			AutoCompactionLatencyBudget: &schema.ConditionalUint32{Value: uint32(opts.IndexOptions.AutoCompactionLatencyBudget)},
 		},
 
 		WriteTxHeaderVersion: &schema.ConditionalUint32{Value: uint32(opts.WriteTxHeaderVersion)},
+
+		ValueCompression:          &schema.ConditionalString{Value: opts.ValueCompression},
+		ValueCompressionThreshold: &schema.ConditionalUint32{Value: uint32(opts.ValueCompressionThreshold)},
+
+		AnonymousReads:  &schema.ConditionalBool{Value: opts.AnonymousReads},
+		MaintenanceMode: &schema.ConditionalBool{Value: opts.MaintenanceMode},
+
+		MaxDiskUsage: &schema.ConditionalUint64{Value: uint64(opts.MaxDiskUsage)},
+
+		ReverseReferenceIndex: &schema.ConditionalBool{Value: opts.ReverseReferenceIndex},
+
+		ScrubInterval:    &schema.ConditionalUint64{Value: uint64(opts.ScrubInterval)},
+		DelayDuringScrub: &schema.ConditionalUint32{Value: uint32(opts.DelayDuringScrub)},
+
+		Template: opts.Template,
+
+		Tenant: opts.Tenant,
 	}
 }
 
@@ -292,6 +408,14 @@ func (s *ImmuServer) overwriteWith(opts *dbOptions, settings *schema.DatabaseSet
 			return fmt.Errorf("%w: %s can not be changed after database creation ('%s')", ErrIllegalArguments, "max node size", opts.Database)
 		}
 
+		if settings.Template != "" {
+			return fmt.Errorf("%w: %s can not be changed after database creation ('%s')", ErrIllegalArguments, "template", opts.Database)
+		}
+
+		if settings.Tenant != "" {
+			return fmt.Errorf("%w: %s can not be changed after database creation ('%s')", ErrIllegalArguments, "tenant", opts.Database)
+		}
+
 		opts.UpdatedAt = time.Now()
 	}
 
@@ -365,6 +489,45 @@ func (s *ImmuServer) overwriteWith(opts *dbOptions, settings *schema.DatabaseSet
 		opts.WriteTxHeaderVersion = int(settings.WriteTxHeaderVersion.Value)
 	}
 
+	// values stored compressed can be read regardless of the current settings
+	// so compression settings can be changed at any time
+	if settings.ValueCompression != nil {
+		opts.ValueCompression = settings.ValueCompression.Value
+	}
+	if settings.ValueCompressionThreshold != nil {
+		opts.ValueCompressionThreshold = int(settings.ValueCompressionThreshold.Value)
+	}
+
+	if settings.AnonymousReads != nil {
+		opts.AnonymousReads = settings.AnonymousReads.Value
+	}
+
+	if settings.MaintenanceMode != nil {
+		opts.MaintenanceMode = settings.MaintenanceMode.Value
+	}
+
+	if settings.ReverseReferenceIndex != nil {
+		opts.ReverseReferenceIndex = settings.ReverseReferenceIndex.Value
+	}
+
+	// the quota is checked on every write, so it can be changed at any time
+	if settings.MaxDiskUsage != nil {
+		if settings.MaxDiskUsage.Value > math.MaxInt64 {
+			return fmt.Errorf("%w: invalid max disk usage %d", ErrIllegalArguments, settings.MaxDiskUsage.Value)
+		}
+		opts.MaxDiskUsage = int64(settings.MaxDiskUsage.Value)
+	}
+
+	if settings.ScrubInterval != nil {
+		if settings.ScrubInterval.Value > math.MaxInt64/uint64(time.Millisecond) {
+			return fmt.Errorf("%w: invalid scrub interval %d", ErrIllegalArguments, settings.ScrubInterval.Value)
+		}
+		opts.ScrubInterval = int64(settings.ScrubInterval.Value)
+	}
+	if settings.DelayDuringScrub != nil {
+		opts.DelayDuringScrub = int64(settings.DelayDuringScrub.Value)
+	}
+
 	// index options
 	if settings.IndexSettings != nil {
 		if opts.IndexOptions == nil {
@@ -410,6 +573,18 @@ func (s *ImmuServer) overwriteWith(opts *dbOptions, settings *schema.DatabaseSet
 		if settings.IndexSettings.CommitLogMaxOpenedFiles != nil {
 			opts.IndexOptions.CommitLogMaxOpenedFiles = int(settings.IndexSettings.CommitLogMaxOpenedFiles.Value)
 		}
+		if settings.IndexSettings.AutoCompaction != nil {
+			opts.IndexOptions.AutoCompaction = settings.IndexSettings.AutoCompaction.Value
+		}
+		if settings.IndexSettings.AutoCompactionGarbageThld != nil {
+			opts.IndexOptions.AutoCompactionGarbageThld = settings.IndexSettings.AutoCompactionGarbageThld.Value
+		}
+		if settings.IndexSettings.AutoCompactionFilesThld != nil {
+			opts.IndexOptions.AutoCompactionFilesThld = int(settings.IndexSettings.AutoCompactionFilesThld.Value)
+		}
+		if settings.IndexSettings.AutoCompactionLatencyBudget != nil {
+			opts.IndexOptions.AutoCompactionLatencyBudget = int64(settings.IndexSettings.AutoCompactionLatencyBudget.Value)
+		}
 	}
 
 	err := opts.Validate()
@@ -430,6 +605,15 @@ func (opts *dbOptions) Validate() error {
 		return fmt.Errorf("%w: invalid replication options for database '%s'", ErrIllegalArguments, opts.Database)
 	}
 
+	if _, ok := valueCompressionAlgorithms[opts.ValueCompression]; !ok {
+		return fmt.Errorf("%w: unsupported value compression algorithm '%s' for database '%s'", ErrIllegalArguments, opts.ValueCompression, opts.Database)
+	}
+
+	if opts.IndexOptions != nil &&
+		(opts.IndexOptions.AutoCompactionGarbageThld < 0 || opts.IndexOptions.AutoCompactionGarbageThld > 1) {
+		return fmt.Errorf("%w: invalid auto compaction garbage threshold for database '%s'", ErrIllegalArguments, opts.Database)
+	}
+
 	// TODO: Check store options if are valid
 
 	return nil
@@ -442,29 +626,28 @@ func (opts *dbOptions) isReplicatorRequired() bool {
 		opts.MasterPort > 0
 }
 
+func dbOptionsKey(database string) []byte {
+	key := make([]byte, 1+len(database))
+	key[0] = KeyPrefixDBSettings
+	copy(key[1:], []byte(database))
+	return key
+}
+
 func (s *ImmuServer) saveDBOptions(options *dbOptions) error {
 	serializedOptions, err := json.Marshal(options)
 	if err != nil {
 		return err
 	}
 
-	optionsKey := make([]byte, 1+len(options.Database))
-	optionsKey[0] = KeyPrefixDBSettings
-	copy(optionsKey[1:], []byte(options.Database))
-
-	_, err = s.sysDB.Set(&schema.SetRequest{KVs: []*schema.KeyValue{{Key: optionsKey, Value: serializedOptions}}})
+	_, err = s.sysDB.Set(&schema.SetRequest{KVs: []*schema.KeyValue{{Key: dbOptionsKey(options.Database), Value: serializedOptions}}})
 
 	return err
 }
 
 func (s *ImmuServer) loadDBOptions(database string, createIfNotExists bool) (*dbOptions, error) {
-	optionsKey := make([]byte, 1+len(database))
-	optionsKey[0] = KeyPrefixDBSettings
-	copy(optionsKey[1:], []byte(database))
-
 	options := s.defaultDBOptions(database)
 
-	e, err := s.sysDB.Get(&schema.KeyRequest{Key: optionsKey})
+	e, err := s.sysDB.Get(context.Background(), &schema.KeyRequest{Key: dbOptionsKey(database)})
 	if err == store.ErrKeyNotFound && createIfNotExists {
 		err = s.saveDBOptions(options)
 		if err != nil {
@@ -501,6 +684,14 @@ func (s *ImmuServer) logDBOptions(database string, opts *dbOptions) {
 	s.Logger.Infof("Option for %s TxLogMaxOpenedFiles: %v", database, opts.TxLogMaxOpenedFiles)
 	s.Logger.Infof("Option for %s CommitLogMaxOpenedFiles: %v", database, opts.CommitLogMaxOpenedFiles)
 	s.Logger.Infof("Option for %s WriteTxHeaderVersion: %v", database, opts.WriteTxHeaderVersion)
+	s.Logger.Infof("Option for %s ValueCompression: %v", database, opts.ValueCompression)
+	s.Logger.Infof("Option for %s ValueCompressionThreshold: %v", database, opts.ValueCompressionThreshold)
+	s.Logger.Infof("Option for %s AnonymousReads: %v", database, opts.AnonymousReads)
+	s.Logger.Infof("Option for %s MaintenanceMode: %v", database, opts.MaintenanceMode)
+	s.Logger.Infof("Option for %s MaxDiskUsage: %v", database, opts.MaxDiskUsage)
+	s.Logger.Infof("Option for %s ReverseReferenceIndex: %v", database, opts.ReverseReferenceIndex)
+	s.Logger.Infof("Option for %s ScrubInterval: %v", database, opts.ScrubInterval)
+	s.Logger.Infof("Option for %s DelayDuringScrub: %v", database, opts.DelayDuringScrub)
 	s.Logger.Infof("Option for %s IndexOptions.FlushThreshold: %v", database, opts.IndexOptions.FlushThreshold)
 	s.Logger.Infof("Option for %s IndexOptions.SyncThreshold: %v", database, opts.IndexOptions.SyncThreshold)
 	s.Logger.Infof("Option for %s IndexOptions.FlushBufferSize: %v", database, opts.IndexOptions.FlushBufferSize)
@@ -514,4 +705,8 @@ func (s *ImmuServer) logDBOptions(database string, opts *dbOptions) {
 	s.Logger.Infof("Option for %s IndexOptions.NodesLogMaxOpenedFiles: %v", database, opts.IndexOptions.NodesLogMaxOpenedFiles)
 	s.Logger.Infof("Option for %s IndexOptions.HistoryLogMaxOpenedFiles: %v", database, opts.IndexOptions.HistoryLogMaxOpenedFiles)
 	s.Logger.Infof("Option for %s IndexOptions.CommitLogMaxOpenedFiles: %v", database, opts.IndexOptions.CommitLogMaxOpenedFiles)
+	s.Logger.Infof("Option for %s IndexOptions.AutoCompaction: %v", database, opts.IndexOptions.AutoCompaction)
+	s.Logger.Infof("Option for %s IndexOptions.AutoCompactionGarbageThld: %v", database, opts.IndexOptions.AutoCompactionGarbageThld)
+	s.Logger.Infof("Option for %s IndexOptions.AutoCompactionFilesThld: %v", database, opts.IndexOptions.AutoCompactionFilesThld)
+	s.Logger.Infof("Option for %s IndexOptions.AutoCompactionLatencyBudget: %v", database, opts.IndexOptions.AutoCompactionLatencyBudget)
 }
@@ -0,0 +1,72 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/database"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestReverseReferenceIndex(t *testing.T) {
+	s, ctx, closer := newTestServer(t, nil)
+	defer closer()
+
+	settings, err := s.CreateDatabaseWithV2(ctx, &schema.DatabaseSettingsV2{
+		DatabaseName:          "refdb",
+		ReverseReferenceIndex: &schema.ConditionalBool{Value: true},
+	})
+	require.NoError(t, err)
+	require.True(t, settings.ReverseReferenceIndex.Value)
+
+	ur, err := s.UseDatabase(ctx, &schema.Database{DatabaseName: "refdb"})
+	require.NoError(t, err)
+
+	dbCtx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", ur.Token))
+
+	_, err = s.Set(dbCtx, &schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte("key1"), Value: []byte("value1")}}})
+	require.NoError(t, err)
+
+	hdr, err := s.SetReference(dbCtx, &schema.ReferenceRequest{Key: []byte("ref1"), ReferencedKey: []byte("key1")})
+	require.NoError(t, err)
+	require.Equal(t, int32(2), hdr.Nentries)
+
+	entries, err := s.ResolveAll(dbCtx, &schema.ResolveAllRequest{Key: []byte("key1")})
+	require.NoError(t, err)
+	require.Len(t, entries.Entries, 1)
+	require.Equal(t, []byte("ref1"), entries.Entries[0].ReferencedBy.Key)
+
+	t.Run("references should only be written when the index is enabled", func(t *testing.T) {
+		res, err := s.UpdateDatabaseV2(ctx, &schema.DatabaseSettingsV2{
+			DatabaseName:          "refdb",
+			ReverseReferenceIndex: &schema.ConditionalBool{Value: false},
+		})
+		require.NoError(t, err)
+		require.False(t, res.CurrentSettings.ReverseReferenceIndex.Value)
+
+		hdr, err := s.SetReference(dbCtx, &schema.ReferenceRequest{Key: []byte("ref2"), ReferencedKey: []byte("key1")})
+		require.NoError(t, err)
+		require.Equal(t, int32(1), hdr.Nentries)
+
+		_, err = s.ResolveAll(dbCtx, &schema.ResolveAllRequest{Key: []byte("key1")})
+		require.ErrorIs(t, err, database.ErrReverseReferenceIndexDisabled)
+	})
+}
@@ -0,0 +1,266 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/database"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// GetDatabaseSettingsHistory returns the versions of the settings of a database.
+// Every time the settings are updated, or rolled back, a new version is saved into the systemdb.
+func (s *ImmuServer) GetDatabaseSettingsHistory(ctx context.Context, req *schema.DatabaseSettingsHistoryRequest) (*schema.DatabaseSettingsHistory, error) {
+	if req == nil || req.Database == "" {
+		return nil, ErrIllegalArguments
+	}
+
+	err := s.checkDatabaseAdmin(ctx, req.Database)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.dbList.GetByName(req.Database)
+	if err != nil {
+		return nil, err
+	}
+
+	history, err := s.loadDBOptionsHistory(req.Database)
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make([]*schema.DatabaseSettingsVersion, len(history))
+
+	for i, opts := range history {
+		version := &schema.DatabaseSettingsVersion{
+			Version:   uint64(i + 1),
+			UpdatedBy: opts.UpdatedBy,
+			UpdatedAt: opts.UpdatedAt.Unix(),
+			Settings:  opts.databaseSettings(),
+		}
+
+		if opts.UpdatedAt.IsZero() {
+			version.UpdatedBy = opts.CreatedBy
+			version.UpdatedAt = opts.CreatedAt.Unix()
+		}
+
+		if i > 0 {
+			version.Changes, err = settingsChanges(versions[i-1].Settings, version.Settings)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		versions[i] = version
+	}
+
+	if req.Desc {
+		for i, j := 0, len(versions)-1; i < j; i, j = i+1, j-1 {
+			versions[i], versions[j] = versions[j], versions[i]
+		}
+	}
+
+	if req.Offset >= uint64(len(versions)) {
+		return &schema.DatabaseSettingsHistory{}, nil
+	}
+
+	versions = versions[req.Offset:]
+
+	if req.Limit > 0 && int(req.Limit) < len(versions) {
+		versions = versions[:req.Limit]
+	}
+
+	return &schema.DatabaseSettingsHistory{Versions: versions}, nil
+}
+
+// RollbackDatabaseSettings sets the settings of a database back to a previous version.
+// Rolling back saves a new version, settings are applied as when updated.
+func (s *ImmuServer) RollbackDatabaseSettings(ctx context.Context, req *schema.DatabaseSettingsRollbackRequest) (*schema.DatabaseSettingsUpdateResult, error) {
+	if req == nil || req.Version == 0 {
+		return nil, ErrIllegalArguments
+	}
+
+	db, user, err := s.checkDatabaseUpdate(ctx, req.Database)
+	if err != nil {
+		return nil, err
+	}
+
+	history, err := s.loadDBOptionsHistory(req.Database)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Version > uint64(len(history)) {
+		return nil, fmt.Errorf("%w: settings version %d of database '%s' not found", ErrIllegalArguments, req.Version, req.Database)
+	}
+
+	s.Logger.Infof("Rolling back settings of database '%s' to version %d...", db.GetName(), req.Version)
+
+	currOpts := history[len(history)-1]
+
+	dbOpts := *history[req.Version-1]
+	dbOpts.CreatedBy = currOpts.CreatedBy
+	dbOpts.CreatedAt = currOpts.CreatedAt
+	dbOpts.UpdatedBy = user.Username
+	dbOpts.UpdatedAt = time.Now()
+
+	res, err := s.applyDBOptions(db, &dbOpts, currOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	s.Logger.Infof("Settings of database '%s' successfully rolled back to version %d", db.GetName(), req.Version)
+
+	return res, nil
+}
+
+// loadDBOptionsHistory returns every version of the options of a database, from the oldest one
+func (s *ImmuServer) loadDBOptionsHistory(db string) ([]*dbOptions, error) {
+	var history []*dbOptions
+
+	for {
+		entries, err := s.sysDB.History(context.Background(), &schema.HistoryRequest{
+			Key:    dbOptionsKey(db),
+			Offset: uint64(len(history)),
+			Limit:  database.MaxKeyScanLimit,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, e := range entries.Entries {
+			opts := s.defaultDBOptions(db)
+
+			err = json.Unmarshal(e.Value, &opts)
+			if err != nil {
+				return nil, err
+			}
+
+			history = append(history, opts)
+		}
+
+		if len(entries.Entries) < database.MaxKeyScanLimit {
+			break
+		}
+	}
+
+	if len(history) == 0 {
+		return nil, database.ErrDatabaseNotExists
+	}
+
+	return history, nil
+}
+
+// settingsChanges returns the settings with different values, named as in the json representation of the settings
+func settingsChanges(prev, curr *schema.DatabaseSettingsV2) ([]*schema.DatabaseSettingChange, error) {
+	prevValues, err := settingsValues(prev)
+	if err != nil {
+		return nil, err
+	}
+
+	currValues, err := settingsValues(curr)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(currValues))
+	for name := range currValues {
+		names = append(names, name)
+	}
+	for name := range prevValues {
+		_, ok := currValues[name]
+		if !ok {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+
+	var changes []*schema.DatabaseSettingChange
+
+	for _, name := range names {
+		if prevValues[name] == currValues[name] {
+			continue
+		}
+
+		changes = append(changes, &schema.DatabaseSettingChange{
+			Setting:  name,
+			OldValue: prevValues[name],
+			NewValue: currValues[name],
+		})
+	}
+
+	return changes, nil
+}
+
+func settingsValues(settings *schema.DatabaseSettingsV2) (map[string]string, error) {
+	data, err := protojson.MarshalOptions{EmitUnpopulated: true}.Marshal(settings)
+	if err != nil {
+		return nil, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var fields map[string]interface{}
+
+	err = dec.Decode(&fields)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]string)
+	flattenSettingsValues(values, "", fields)
+
+	return values, nil
+}
+
+func flattenSettingsValues(values map[string]string, prefix string, fields map[string]interface{}) {
+	for k, v := range fields {
+		name := k
+
+		if prefix != "" {
+			name = prefix + "." + k
+
+			// conditional settings are reported by the name of the setting
+			if k == "value" {
+				name = prefix
+			}
+		}
+
+		m, ok := v.(map[string]interface{})
+		if ok {
+			flattenSettingsValues(values, name, m)
+			continue
+		}
+
+		if v == nil {
+			values[name] = ""
+			continue
+		}
+
+		values[name] = fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,132 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/auth"
+	"github.com/codenotary/immudb/pkg/database"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDatabaseSettingsHistory(t *testing.T) {
+	s, ctx, closer := newTestServer(t, nil)
+	defer closer()
+
+	_, err := s.CreateDatabaseWithV2(ctx, &schema.DatabaseSettingsV2{
+		DatabaseName:   "db1",
+		MaxConcurrency: &schema.ConditionalUint32{Value: 20},
+	})
+	require.NoError(t, err)
+
+	_, err = s.UpdateDatabaseV2(ctx, &schema.DatabaseSettingsV2{
+		DatabaseName:   "db1",
+		MaxConcurrency: &schema.ConditionalUint32{Value: 30},
+	})
+	require.NoError(t, err)
+
+	_, err = s.UpdateDatabaseV2(ctx, &schema.DatabaseSettingsV2{
+		DatabaseName:      "db1",
+		ExcludeCommitTime: &schema.ConditionalBool{Value: true},
+		IndexSettings: &schema.IndexSettings{
+			CacheSize: &schema.ConditionalUint32{Value: 200},
+		},
+	})
+	require.NoError(t, err)
+
+	t.Run("every update should be kept as a new version", func(t *testing.T) {
+		history, err := s.GetDatabaseSettingsHistory(ctx, &schema.DatabaseSettingsHistoryRequest{Database: "db1"})
+		require.NoError(t, err)
+		require.Len(t, history.Versions, 3)
+
+		v1 := history.Versions[0]
+		require.Equal(t, uint64(1), v1.Version)
+		require.Equal(t, auth.SysAdminUsername, v1.UpdatedBy)
+		require.NotZero(t, v1.UpdatedAt)
+		require.Equal(t, uint32(20), v1.Settings.MaxConcurrency.Value)
+		require.Empty(t, v1.Changes)
+
+		v2 := history.Versions[1]
+		require.Equal(t, uint64(2), v2.Version)
+		require.Equal(t, auth.SysAdminUsername, v2.UpdatedBy)
+		require.Equal(t, []*schema.DatabaseSettingChange{
+			{Setting: "maxConcurrency", OldValue: "20", NewValue: "30"},
+		}, v2.Changes)
+
+		v3 := history.Versions[2]
+		require.Equal(t, []*schema.DatabaseSettingChange{
+			{Setting: "excludeCommitTime", OldValue: "false", NewValue: "true"},
+			{Setting: "indexSettings.cacheSize", OldValue: "100000", NewValue: "200"},
+		}, v3.Changes)
+
+		history, err = s.GetDatabaseSettingsHistory(ctx, &schema.DatabaseSettingsHistoryRequest{
+			Database: "db1",
+			Offset:   1,
+			Limit:    1,
+			Desc:     true,
+		})
+		require.NoError(t, err)
+		require.Len(t, history.Versions, 1)
+		require.Equal(t, uint64(2), history.Versions[0].Version)
+
+		history, err = s.GetDatabaseSettingsHistory(ctx, &schema.DatabaseSettingsHistoryRequest{Database: "db1", Offset: 3})
+		require.NoError(t, err)
+		require.Empty(t, history.Versions)
+	})
+
+	t.Run("settings should be rolled back as a new version", func(t *testing.T) {
+		res, err := s.RollbackDatabaseSettings(ctx, &schema.DatabaseSettingsRollbackRequest{Database: "db1", Version: 1})
+		require.NoError(t, err)
+		require.Equal(t, uint32(20), res.CurrentSettings.MaxConcurrency.Value)
+		require.False(t, res.CurrentSettings.ExcludeCommitTime.Value)
+		require.Equal(t, []string{"maxConcurrency"}, res.ReloadRequired)
+
+		settings, err := s.loadDBOptions("db1", false)
+		require.NoError(t, err)
+		require.Equal(t, 20, settings.MaxConcurrency)
+		require.Equal(t, auth.SysAdminUsername, settings.UpdatedBy)
+
+		history, err := s.GetDatabaseSettingsHistory(ctx, &schema.DatabaseSettingsHistoryRequest{Database: "db1", Desc: true})
+		require.NoError(t, err)
+		require.Len(t, history.Versions, 4)
+		require.Equal(t, uint64(4), history.Versions[0].Version)
+		require.Len(t, history.Versions[0].Changes, 3)
+	})
+
+	t.Run("invalid requests should fail", func(t *testing.T) {
+		_, err := s.GetDatabaseSettingsHistory(ctx, nil)
+		require.ErrorIs(t, err, ErrIllegalArguments)
+
+		_, err = s.GetDatabaseSettingsHistory(ctx, &schema.DatabaseSettingsHistoryRequest{Database: "db2"})
+		require.ErrorIs(t, err, database.ErrDatabaseNotExists)
+
+		_, err = s.RollbackDatabaseSettings(ctx, &schema.DatabaseSettingsRollbackRequest{Database: "db1"})
+		require.ErrorIs(t, err, ErrIllegalArguments)
+
+		_, err = s.RollbackDatabaseSettings(ctx, &schema.DatabaseSettingsRollbackRequest{Database: "db1", Version: 5})
+		require.ErrorIs(t, err, ErrIllegalArguments)
+
+		_, err = s.RollbackDatabaseSettings(ctx, &schema.DatabaseSettingsRollbackRequest{Database: SystemDBName, Version: 1})
+		require.ErrorIs(t, err, ErrReservedDatabase)
+
+		_, err = s.GetDatabaseSettingsHistory(context.Background(), &schema.DatabaseSettingsHistoryRequest{Database: "db1"})
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,168 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// DatabaseSettingsConfig holds the settings applied to the databases created on this server.
+// Databases are created with the default settings, overridden by the settings of the template
+// named at creation (if any) and then by the settings included in the request.
+type DatabaseSettingsConfig struct {
+	Defaults  *schema.DatabaseSettingsV2
+	Templates map[string]*schema.DatabaseSettingsV2
+}
+
+// ReadDatabaseSettingsFile reads the default database settings and the database templates from a json file
+// where settings are written as accepted by the REST API, e.g.
+//
+//	{
+//	  "defaults": {"maxConcurrency": {"value": 100}},
+//	  "templates": {
+//	    "small": {"fileSize": {"value": 1048576}, "indexSettings": {"cacheSize": {"value": 1000}}}
+//	  }
+//	}
+func ReadDatabaseSettingsFile(path string) (*DatabaseSettingsConfig, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw struct {
+		Defaults  json.RawMessage            `json:"defaults"`
+		Templates map[string]json.RawMessage `json:"templates"`
+	}
+
+	err = json.Unmarshal(data, &raw)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &DatabaseSettingsConfig{
+		Templates: make(map[string]*schema.DatabaseSettingsV2, len(raw.Templates)),
+	}
+
+	if len(raw.Defaults) > 0 {
+		config.Defaults, err = unmarshalDatabaseSettings(raw.Defaults)
+		if err != nil {
+			return nil, fmt.Errorf("invalid default database settings: %w", err)
+		}
+	}
+
+	for name, t := range raw.Templates {
+		if name == "" {
+			return nil, fmt.Errorf("%w: database templates must be named", ErrIllegalArguments)
+		}
+
+		config.Templates[name], err = unmarshalDatabaseSettings(t)
+		if err != nil {
+			return nil, fmt.Errorf("invalid database template '%s': %w", name, err)
+		}
+	}
+
+	return config, nil
+}
+
+func unmarshalDatabaseSettings(data []byte) (*schema.DatabaseSettingsV2, error) {
+	settings := &schema.DatabaseSettingsV2{}
+
+	err := protojson.Unmarshal(data, settings)
+	if err != nil {
+		return nil, err
+	}
+
+	if settings.DatabaseName != "" || settings.Template != "" || settings.Tenant != "" {
+		return nil, fmt.Errorf("%w: database name, template and tenant can not be set", ErrIllegalArguments)
+	}
+
+	return settings, nil
+}
+
+// loadDatabaseSettings reads the settings file set in the server options, if any, checking settings are valid
+func (s *ImmuServer) loadDatabaseSettings() error {
+	if s.Options.DatabaseSettingsFile == "" {
+		return nil
+	}
+
+	config, err := ReadDatabaseSettingsFile(s.Options.DatabaseSettingsFile)
+	if err != nil {
+		return err
+	}
+
+	if config.Defaults != nil {
+		err = s.overwriteWith(s.defaultDBOptions(""), config.Defaults, false)
+		if err != nil {
+			return fmt.Errorf("invalid default database settings: %w", err)
+		}
+	}
+
+	for name, t := range config.Templates {
+		err = s.overwriteWith(s.defaultDBOptions(""), t, false)
+		if err != nil {
+			return fmt.Errorf("invalid database template '%s': %w", name, err)
+		}
+	}
+
+	s.dbSettings = config
+
+	return nil
+}
+
+// newDBOptions returns the options of a database to be created with the given settings,
+// on top of the server defaults and the template named in the settings
+func (s *ImmuServer) newDBOptions(settings *schema.DatabaseSettingsV2) (*dbOptions, error) {
+	opts := s.defaultDBOptions(settings.DatabaseName)
+
+	if s.dbSettings != nil && s.dbSettings.Defaults != nil {
+		err := s.overwriteWith(opts, s.dbSettings.Defaults, false)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if settings.Template != "" {
+		var template *schema.DatabaseSettingsV2
+
+		if s.dbSettings != nil {
+			template = s.dbSettings.Templates[settings.Template]
+		}
+
+		if template == nil {
+			return nil, fmt.Errorf("%w: database template '%s' not found", ErrIllegalArguments, settings.Template)
+		}
+
+		err := s.overwriteWith(opts, template, false)
+		if err != nil {
+			return nil, err
+		}
+
+		opts.Template = settings.Template
+	}
+
+	err := s.overwriteWith(opts, settings, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return opts, nil
+}
@@ -0,0 +1,161 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+)
+
+const testDatabaseSettings = `{
+	"defaults": {"maxConcurrency": {"value": 50}, "valueCompression": {"value": "zstd"}},
+	"templates": {
+		"small": {"fileSize": {"value": 1048576}, "indexSettings": {"cacheSize": {"value": 100}}},
+		"large": {"fileSize": {"value": 1073741824}, "maxConcurrency": {"value": 200}}
+	}
+}`
+
+func TestReadDatabaseSettingsFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "db_settings")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "settings.json")
+
+	_, err = ReadDatabaseSettingsFile(path)
+	require.Error(t, err)
+
+	for _, invalid := range []string{
+		`{"defaults": `,
+		`{"defaults": {"unknown": {"value": 1}}}`,
+		`{"defaults": {"databaseName": "db1"}}`,
+		`{"templates": {"small": {"template": "large"}}}`,
+		`{"templates": {"": {}}}`,
+	} {
+		err = ioutil.WriteFile(path, []byte(invalid), 0644)
+		require.NoError(t, err)
+
+		_, err = ReadDatabaseSettingsFile(path)
+		require.Error(t, err)
+	}
+
+	err = ioutil.WriteFile(path, []byte(testDatabaseSettings), 0644)
+	require.NoError(t, err)
+
+	config, err := ReadDatabaseSettingsFile(path)
+	require.NoError(t, err)
+	require.Equal(t, uint32(50), config.Defaults.MaxConcurrency.Value)
+	require.Len(t, config.Templates, 2)
+	require.Equal(t, uint32(1048576), config.Templates["small"].FileSize.Value)
+	require.Equal(t, uint32(100), config.Templates["small"].IndexSettings.CacheSize.Value)
+	require.Nil(t, config.Templates["small"].MaxConcurrency)
+}
+
+func TestCreateDatabaseFromTemplate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "db_templates")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	settingsFile := filepath.Join(dir, "settings.json")
+
+	t.Run("invalid settings should prevent the server from starting", func(t *testing.T) {
+		err := ioutil.WriteFile(settingsFile, []byte(`{"templates": {"small": {"valueCompression": {"value": "zip"}}}}`), 0644)
+		require.NoError(t, err)
+
+		serverOptions := DefaultOptions().
+			WithDir(filepath.Join(dir, "data")).
+			WithMetricsServer(false).
+			WithDatabaseSettingsFile(settingsFile)
+
+		s := DefaultServer().WithOptions(serverOptions).(*ImmuServer)
+
+		err = s.Initialize()
+		require.ErrorIs(t, err, ErrIllegalArguments)
+	})
+
+	err = ioutil.WriteFile(settingsFile, []byte(testDatabaseSettings), 0644)
+	require.NoError(t, err)
+
+	s, ctx, closer := newTestServer(t, DefaultOptions().WithDatabaseSettingsFile(settingsFile))
+	defer closer()
+
+	t.Run("databases should be created with the server defaults", func(t *testing.T) {
+		settings, err := s.CreateDatabaseWithV2(ctx, &schema.DatabaseSettingsV2{DatabaseName: "db1"})
+		require.NoError(t, err)
+		require.Empty(t, settings.Template)
+		require.Equal(t, uint32(50), settings.MaxConcurrency.Value)
+		require.Equal(t, ZstdValueCompression, settings.ValueCompression.Value)
+		require.Equal(t, uint32(DefaultStoreFileSize), settings.FileSize.Value)
+	})
+
+	t.Run("databases should be created from templates", func(t *testing.T) {
+		settings, err := s.CreateDatabaseWithV2(ctx, &schema.DatabaseSettingsV2{
+			DatabaseName: "db2",
+			Template:     "small",
+			IndexSettings: &schema.IndexSettings{
+				FlushThreshold: &schema.ConditionalUint32{Value: 1000},
+			},
+		})
+		require.NoError(t, err)
+		require.Equal(t, "small", settings.Template)
+		require.Equal(t, uint32(50), settings.MaxConcurrency.Value)
+		require.Equal(t, ZstdValueCompression, settings.ValueCompression.Value)
+		require.Equal(t, uint32(1048576), settings.FileSize.Value)
+		require.Equal(t, uint32(100), settings.IndexSettings.CacheSize.Value)
+		require.Equal(t, uint32(1000), settings.IndexSettings.FlushThreshold.Value)
+
+		// settings in the request take precedence over the template
+		settings, err = s.CreateDatabaseWithV2(ctx, &schema.DatabaseSettingsV2{
+			DatabaseName:   "db3",
+			Template:       "large",
+			MaxConcurrency: &schema.ConditionalUint32{Value: 10},
+		})
+		require.NoError(t, err)
+		require.Equal(t, "large", settings.Template)
+		require.Equal(t, uint32(10), settings.MaxConcurrency.Value)
+		require.Equal(t, uint32(1073741824), settings.FileSize.Value)
+
+		ur, err := s.UseDatabase(ctx, &schema.Database{DatabaseName: "db2"})
+		require.NoError(t, err)
+
+		db2Ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", ur.Token))
+
+		settings, err = s.GetDatabaseSettingsV2(db2Ctx, nil)
+		require.NoError(t, err)
+		require.Equal(t, "small", settings.Template)
+		require.Equal(t, uint32(1048576), settings.FileSize.Value)
+	})
+
+	t.Run("unknown templates should fail", func(t *testing.T) {
+		_, err := s.CreateDatabaseWithV2(ctx, &schema.DatabaseSettingsV2{DatabaseName: "db4", Template: "medium"})
+		require.ErrorIs(t, err, ErrIllegalArguments)
+
+		require.Negative(t, s.dbList.GetId("db4"))
+	})
+
+	t.Run("templates can not be changed after creation", func(t *testing.T) {
+		_, err := s.UpdateDatabaseV2(ctx, &schema.DatabaseSettingsV2{DatabaseName: "db1", Template: "small"})
+		require.ErrorIs(t, err, ErrIllegalArguments)
+	})
+}
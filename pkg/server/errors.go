@@ -17,6 +17,9 @@ limitations under the License.
 package server
 
 import (
+	"context"
+	stderrors "errors"
+
 	"github.com/codenotary/immudb/embedded/store"
 	"github.com/codenotary/immudb/pkg/auth"
 	"github.com/codenotary/immudb/pkg/database"
@@ -36,6 +39,8 @@ var (
 	ErrAuthMustBeEnabled           = status.Error(codes.InvalidArgument, "authentication must be on")
 	ErrAuthMustBeDisabled          = status.Error(codes.InvalidArgument, "authentication must be disabled when restoring systemdb")
 	ErrNotAllowedInMaintenanceMode = status.Error(codes.InvalidArgument, "operation not allowed in maintenance mode")
+	ErrScriptingDisabled           = status.Error(codes.FailedPrecondition, "server-side scripting is disabled")
+	ErrReadYourWritesTimeout       = status.Error(codes.DeadlineExceeded, "timeout waiting for the txs committed within the session to be indexed")
 	ErrReservedDatabase            = errors.New("database is reserved")
 	ErrPermissionDenied            = errors.New("permission denied")
 	ErrNotSupported                = errors.New("operation not supported")
@@ -43,6 +48,10 @@ var (
 	ErrReplicationInProgress       = errors.New("replication already in progress")
 	ErrReplicatorNotNeeded         = errors.New("replicator is not needed")
 	ErrReplicationNotInProgress    = errors.New("replication is not in progress")
+	ErrJobAlreadyInProgress        = errors.New("job already in progress")
+	ErrJobNotFound                 = errors.New("job not found").WithCode(errors.CodNoDataFound)
+	ErrJobNotCancelable            = errors.New("job can not be canceled")
+	ErrJobAlreadyFinished          = errors.New("job already finished")
 	ErrSessionAlreadyPresent       = errors.New("session already present").WithCode(errors.CodInternalError)
 	ErrSessionNotFound             = errors.New("session not found").WithCode(errors.CodSqlserverRejectedEstablishmentOfSqlSession)
 	ErrOngoingReadWriteTx          = sessions.ErrOngoingReadWriteTx
@@ -50,9 +59,45 @@ var (
 	ErrTxNotProperlyClosed         = errors.New("tx not properly closed")
 	ErrReadWriteTxNotOngoing       = errors.New("read write transaction not ongoing")
 	ErrTxReadConflict              = errors.New(store.ErrTxReadConflict.Error()).WithCode(errors.CodInFailedSqlTransaction)
+	ErrKeyNotFound                 = errors.New(store.ErrKeyNotFound.Error()).WithCode(errors.CodNoDataFound)
+	ErrExpiredEntry                = errors.New(store.ErrExpiredEntry.Error()).WithCode(errors.CodNoDataFound)
+	ErrValueTruncated              = errors.New(store.ErrValueTruncated.Error()).WithCode(errors.CodNoDataFound)
+	ErrTxNotFound                  = errors.New(store.ErrTxNotFound.Error()).WithCode(errors.CodTxNotFound)
+	ErrKeyAlreadyExists            = errors.New(store.ErrKeyAlreadyExists.Error()).WithCode(errors.CodUniqueViolation)
+	ErrCorruptedData               = errors.New(store.ErrCorruptedData.Error()).WithCode(errors.CodDataCorrupted)
+	ErrCorruptedTxData             = errors.New(store.ErrorCorruptedTxData.Error()).WithCode(errors.CodDataCorrupted)
+	ErrCorruptedIndex              = errors.New(store.ErrCorruptedIndex.Error()).WithCode(errors.CodIndexCorrupted)
+	ErrExternalRootNotFound        = errors.New(database.ErrExternalRootNotFound.Error()).WithCode(errors.CodNoDataFound)
+	ErrExternalRootOutdated        = errors.New(database.ErrExternalRootOutdated.Error()).WithCode(errors.CodIntegrityConstraintViolation)
+	ErrExternalRootConflict        = errors.New(database.ErrExternalRootConflict.Error()).WithCode(errors.CodIntegrityConstraintViolation)
+	ErrKVSchemaNotFound            = errors.New(database.ErrKVSchemaNotFound.Error()).WithCode(errors.CodNoDataFound)
+	ErrRedactionRuleNotFound       = errors.New(database.ErrRedactionRuleNotFound.Error()).WithCode(errors.CodNoDataFound)
+	ErrDiskQuotaExceeded           = errors.New(database.ErrDiskQuotaExceeded.Error()).WithCode(errors.CodDiskFull)
+	ErrStateSigningNotEnabled      = errors.New("state signing is not enabled, the server must be started with a signing key")
 )
 
 func mapServerError(err error) error {
+	// schema violations are wrapped with the details of the rejected value
+	if stderrors.Is(err, database.ErrKVSchemaViolation) {
+		return errors.New(err.Error()).WithCode(errors.CodInvalidParameterValue)
+	}
+
+	// failed preconditions are wrapped with the condition not satisfied
+	if stderrors.Is(err, store.ErrPreconditionFailed) {
+		return errors.New(err.Error()).WithCode(errors.CodIntegrityConstraintViolation)
+	}
+
+	// results exceeding the limits of the server tell how many rows were read before the limit was reached
+	var resultLimitErr *database.ResultLimitError
+	if stderrors.As(err, &resultLimitErr) {
+		return errors.New(err.Error()).WithCode(errors.CodProgramLimitExceeded)
+	}
+
+	// the deadline of the request expired, or the request was canceled by the client
+	if err == context.DeadlineExceeded || err == context.Canceled {
+		return status.FromContextError(err).Err()
+	}
+
 	switch err {
 	case store.ErrIllegalState:
 		return ErrIllegalState
@@ -60,6 +105,34 @@ func mapServerError(err error) error {
 		return ErrIllegalArguments
 	case store.ErrTxReadConflict:
 		return ErrTxReadConflict
+	case store.ErrKeyNotFound:
+		return ErrKeyNotFound
+	case store.ErrExpiredEntry:
+		return ErrExpiredEntry
+	case store.ErrValueTruncated:
+		return ErrValueTruncated
+	case store.ErrTxNotFound:
+		return ErrTxNotFound
+	case store.ErrKeyAlreadyExists:
+		return ErrKeyAlreadyExists
+	case store.ErrCorruptedData:
+		return ErrCorruptedData
+	case store.ErrorCorruptedTxData:
+		return ErrCorruptedTxData
+	case store.ErrCorruptedIndex:
+		return ErrCorruptedIndex
+	case database.ErrExternalRootNotFound:
+		return ErrExternalRootNotFound
+	case database.ErrExternalRootOutdated:
+		return ErrExternalRootOutdated
+	case database.ErrExternalRootConflict:
+		return ErrExternalRootConflict
+	case database.ErrKVSchemaNotFound:
+		return ErrKVSchemaNotFound
+	case database.ErrRedactionRuleNotFound:
+		return ErrRedactionRuleNotFound
+	case database.ErrDiskQuotaExceeded:
+		return ErrDiskQuotaExceeded
 	}
 	return err
 }
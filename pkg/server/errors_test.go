@@ -17,11 +17,17 @@ limitations under the License.
 package server
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"testing"
 
 	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/database"
+	immuerrors "github.com/codenotary/immudb/pkg/errors"
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 func TestMapServerError(t *testing.T) {
@@ -31,6 +37,33 @@ func TestMapServerError(t *testing.T) {
 	err = mapServerError(store.ErrIllegalArguments)
 	assert.Equal(t, ErrIllegalArguments, err)
 
+	err = mapServerError(store.ErrKeyNotFound)
+	assert.Equal(t, ErrKeyNotFound, err)
+
+	err = mapServerError(store.ErrTxNotFound)
+	assert.Equal(t, ErrTxNotFound, err)
+
+	err = mapServerError(store.ErrCorruptedData)
+	assert.Equal(t, ErrCorruptedData, err)
+
+	err = mapServerError(store.ErrValueTruncated)
+	assert.Equal(t, ErrValueTruncated, err)
+
+	err = mapServerError(fmt.Errorf("%w: KeyMustNotExist(6b657931)", store.ErrPreconditionFailed))
+	assert.Equal(t, immuerrors.CodIntegrityConstraintViolation, err.(immuerrors.Error).Code())
+	assert.Contains(t, err.Error(), "KeyMustNotExist(6b657931)")
+
+	err = mapServerError(&database.ResultLimitError{Limit: "rows", Max: 10, Rows: 10})
+	assert.Equal(t, immuerrors.CodProgramLimitExceeded, err.(immuerrors.Error).Code())
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.Contains(t, err.Error(), "partial result of 10 rows")
+
+	err = mapServerError(context.DeadlineExceeded)
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+
+	err = mapServerError(context.Canceled)
+	assert.Equal(t, codes.Canceled, status.Code(err))
+
 	someError := errors.New("some error")
 	err = mapServerError(someError)
 	assert.Equal(t, someError, err)
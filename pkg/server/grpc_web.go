@@ -0,0 +1,270 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"google.golang.org/grpc"
+)
+
+const (
+	grpcWebContentType     = "application/grpc-web"
+	grpcWebTextContentType = "application/grpc-web-text"
+
+	// grpcWebTrailerFrame flags the frame carrying the trailers at the end of the response body
+	grpcWebTrailerFrame = 0x80
+
+	// grpcWebAllowedHeaders are the request headers browsers may send from allowed origins
+	grpcWebAllowedHeaders = "content-type, x-grpc-web, x-user-agent, grpc-timeout, authorization, sessionid"
+)
+
+// grpcWebHandler serves the requests of the gRPC-Web protocol, as sent by browsers, through the gRPC server so the
+// whole API, server streaming included, is available without a translating proxy. Other requests are served by the
+// next handler.
+// Browsers may only call it from the origin of the web server itself or from one of the allowed origins, requests
+// of any other origin are rejected.
+type grpcWebHandler struct {
+	grpcSrv        *grpc.Server
+	allowedOrigins map[string]struct{}
+	next           http.Handler
+}
+
+func newGrpcWebHandler(grpcSrv *grpc.Server, allowedOrigins []string, next http.Handler) *grpcWebHandler {
+	h := &grpcWebHandler{
+		grpcSrv:        grpcSrv,
+		allowedOrigins: make(map[string]struct{}, len(allowedOrigins)),
+		next:           next,
+	}
+
+	for _, origin := range allowedOrigins {
+		h.allowedOrigins[strings.ToLower(strings.TrimSuffix(origin, "/"))] = struct{}{}
+	}
+
+	return h
+}
+
+func (h *grpcWebHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case isGrpcWebRequest(r):
+		h.serveGrpcWeb(w, r)
+	case isGrpcWebPreflight(r):
+		h.serveGrpcWebPreflight(w, r)
+	default:
+		h.next.ServeHTTP(w, r)
+	}
+}
+
+// isAllowedOrigin returns whether the request may be served, either because it's not a cross-origin one or because
+// its origin is an allowed one
+func (h *grpcWebHandler) isAllowedOrigin(r *http.Request) bool {
+	if !isCrossOrigin(r) {
+		return true
+	}
+
+	_, ok := h.allowedOrigins[strings.ToLower(r.Header.Get("Origin"))]
+
+	return ok
+}
+
+// isCrossOrigin returns whether the request was sent by a browser from an origin other than the one of the web server,
+// requests not sent by browsers carry no origin
+func isCrossOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return false
+	}
+
+	u, err := url.Parse(origin)
+
+	return err != nil || !strings.EqualFold(u.Host, r.Host)
+}
+
+func isGrpcWebRequest(r *http.Request) bool {
+	return r.Method == http.MethodPost && strings.HasPrefix(r.Header.Get("Content-Type"), grpcWebContentType)
+}
+
+// isGrpcWebPreflight returns whether the request is the CORS preflight of a gRPC-Web request, browsers sending it
+// before calling a server of a different origin
+func isGrpcWebPreflight(r *http.Request) bool {
+	return r.Method == http.MethodOptions &&
+		r.Header.Get("Access-Control-Request-Method") == http.MethodPost &&
+		strings.Contains(strings.ToLower(r.Header.Get("Access-Control-Request-Headers")), "x-grpc-web")
+}
+
+func (h *grpcWebHandler) serveGrpcWebPreflight(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Vary", "Origin")
+
+	if !h.isAllowedOrigin(r) {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	hdr := w.Header()
+	hdr.Set("Access-Control-Allow-Origin", r.Header.Get("Origin"))
+	hdr.Set("Access-Control-Allow-Methods", http.MethodPost)
+	hdr.Set("Access-Control-Allow-Headers", grpcWebAllowedHeaders)
+	hdr.Set("Access-Control-Max-Age", "600")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *grpcWebHandler) serveGrpcWeb(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Vary", "Origin")
+
+	if !h.isAllowedOrigin(r) {
+		http.Error(w, "origin not allowed", http.StatusForbidden)
+		return
+	}
+
+	contentType := r.Header.Get("Content-Type")
+	text := strings.HasPrefix(contentType, grpcWebTextContentType)
+
+	// the request is presented to the gRPC server as a regular HTTP/2 gRPC one
+	req := r.Clone(r.Context())
+	req.ProtoMajor = 2
+	req.ProtoMinor = 0
+	req.Proto = "HTTP/2"
+	req.Header.Del("Content-Length")
+
+	if text {
+		req.Header.Set("Content-Type", "application/grpc"+strings.TrimPrefix(contentType, grpcWebTextContentType))
+		req.Body = ioutil.NopCloser(base64.NewDecoder(base64.StdEncoding, r.Body))
+	} else {
+		req.Header.Set("Content-Type", "application/grpc"+strings.TrimPrefix(contentType, grpcWebContentType))
+	}
+
+	if isCrossOrigin(r) {
+		w.Header().Set("Access-Control-Allow-Origin", r.Header.Get("Origin"))
+		w.Header().Set("Access-Control-Expose-Headers", "grpc-status, grpc-message, grpc-status-details-bin")
+	}
+
+	ww := &grpcWebResponseWriter{
+		w:           w,
+		header:      make(http.Header),
+		contentType: contentType,
+		text:        text,
+	}
+
+	h.grpcSrv.ServeHTTP(ww, req)
+
+	ww.finish()
+}
+
+// grpcWebResponseWriter turns the response of the gRPC server into a gRPC-Web one, the trailers being sent as the
+// last frame of the body since browsers can not read HTTP trailers
+type grpcWebResponseWriter struct {
+	w           http.ResponseWriter
+	header      http.Header
+	contentType string
+	text        bool
+	wroteHeader bool
+}
+
+func (ww *grpcWebResponseWriter) Header() http.Header {
+	return ww.header
+}
+
+func (ww *grpcWebResponseWriter) WriteHeader(code int) {
+	if ww.wroteHeader {
+		return
+	}
+	ww.wroteHeader = true
+
+	h := ww.w.Header()
+
+	for k, vv := range ww.header {
+		if k == "Trailer" || strings.HasPrefix(k, http.TrailerPrefix) {
+			continue
+		}
+		h[k] = vv
+	}
+
+	h.Set("Content-Type", ww.contentType)
+
+	ww.w.WriteHeader(code)
+}
+
+func (ww *grpcWebResponseWriter) Write(b []byte) (int, error) {
+	if !ww.wroteHeader {
+		ww.WriteHeader(http.StatusOK)
+	}
+
+	if !ww.text {
+		return ww.w.Write(b)
+	}
+
+	// each write is encoded on its own, gRPC-Web clients accept concatenated base64 chunks
+	_, err := ww.w.Write([]byte(base64.StdEncoding.EncodeToString(b)))
+	if err != nil {
+		return 0, err
+	}
+
+	return len(b), nil
+}
+
+func (ww *grpcWebResponseWriter) Flush() {
+	if !ww.wroteHeader {
+		ww.WriteHeader(http.StatusOK)
+	}
+
+	if f, ok := ww.w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// finish writes the trailers set by the gRPC server, both the declared and the undeclared ones
+func (ww *grpcWebResponseWriter) finish() {
+	trailers := make(http.Header)
+
+	for _, names := range ww.header["Trailer"] {
+		for _, k := range strings.Split(names, ",") {
+			k = http.CanonicalHeaderKey(strings.TrimSpace(k))
+			if vv, ok := ww.header[k]; ok {
+				trailers[k] = vv
+			}
+		}
+	}
+
+	for k, vv := range ww.header {
+		if strings.HasPrefix(k, http.TrailerPrefix) {
+			trailers[http.CanonicalHeaderKey(strings.TrimPrefix(k, http.TrailerPrefix))] = vv
+		}
+	}
+
+	var buf bytes.Buffer
+
+	for k, vv := range trailers {
+		for _, v := range vv {
+			fmt.Fprintf(&buf, "%s: %s\r\n", strings.ToLower(k), v)
+		}
+	}
+
+	frame := make([]byte, 5+buf.Len())
+	frame[0] = grpcWebTrailerFrame
+	binary.BigEndian.PutUint32(frame[1:], uint32(buf.Len()))
+	copy(frame[5:], buf.Bytes())
+
+	ww.Write(frame)
+	ww.Flush()
+}
@@ -0,0 +1,216 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func grpcWebFrame(t *testing.T, msg proto.Message) []byte {
+	b, err := proto.Marshal(msg)
+	require.NoError(t, err)
+
+	frame := make([]byte, 5+len(b))
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(b)))
+	copy(frame[5:], b)
+
+	return frame
+}
+
+// readGrpcWebFrames returns the messages and the trailers of a gRPC-Web response body
+func readGrpcWebFrames(t *testing.T, body []byte) ([][]byte, string) {
+	var msgs [][]byte
+	var trailers string
+
+	for len(body) > 0 {
+		require.GreaterOrEqual(t, len(body), 5)
+
+		l := binary.BigEndian.Uint32(body[1:])
+		require.GreaterOrEqual(t, len(body), 5+int(l))
+
+		if body[0] == grpcWebTrailerFrame {
+			trailers = string(body[5 : 5+l])
+		} else {
+			msgs = append(msgs, body[5:5+l])
+		}
+
+		body = body[5+l:]
+	}
+
+	return msgs, trailers
+}
+
+func TestGrpcWebHandler(t *testing.T) {
+	grpcSrv := grpc.NewServer()
+	healthSrv := health.NewServer()
+	healthSrv.SetServingStatus("immudb", grpc_health_v1.HealthCheckResponse_SERVING)
+	grpc_health_v1.RegisterHealthServer(grpcSrv, healthSrv)
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+
+	h := newGrpcWebHandler(grpcSrv, []string{"http://localhost:3000"}, next)
+
+	t.Run("binary", func(t *testing.T) {
+		body := grpcWebFrame(t, &grpc_health_v1.HealthCheckRequest{Service: "immudb"})
+
+		req := httptest.NewRequest(http.MethodPost, "/grpc.health.v1.Health/Check", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/grpc-web+proto")
+		req.Header.Set("Origin", "http://localhost:3000")
+
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, "application/grpc-web+proto", rec.Header().Get("Content-Type"))
+		require.Equal(t, "http://localhost:3000", rec.Header().Get("Access-Control-Allow-Origin"))
+
+		msgs, trailers := readGrpcWebFrames(t, rec.Body.Bytes())
+		require.Len(t, msgs, 1)
+		require.Contains(t, trailers, "grpc-status: 0\r\n")
+
+		var res grpc_health_v1.HealthCheckResponse
+		require.NoError(t, proto.Unmarshal(msgs[0], &res))
+		require.Equal(t, grpc_health_v1.HealthCheckResponse_SERVING, res.Status)
+	})
+
+	t.Run("text", func(t *testing.T) {
+		body := base64.StdEncoding.EncodeToString(grpcWebFrame(t, &grpc_health_v1.HealthCheckRequest{Service: "unknown"}))
+
+		req := httptest.NewRequest(http.MethodPost, "/grpc.health.v1.Health/Check", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/grpc-web-text")
+
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, "application/grpc-web-text", rec.Header().Get("Content-Type"))
+
+		// chunks are encoded separately, but base64 quanta can be decoded on their own
+		var decoded []byte
+		enc := rec.Body.String()
+		require.Zero(t, len(enc)%4)
+		for i := 0; i < len(enc); i += 4 {
+			chunk, err := base64.StdEncoding.DecodeString(enc[i : i+4])
+			require.NoError(t, err)
+			decoded = append(decoded, chunk...)
+		}
+
+		msgs, trailers := readGrpcWebFrames(t, decoded)
+		require.Empty(t, msgs)
+		// NOT_FOUND
+		require.Contains(t, trailers, "grpc-status: 5\r\n")
+	})
+
+	t.Run("preflight", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodOptions, "/grpc.health.v1.Health/Check", nil)
+		req.Header.Set("Origin", "http://localhost:3000")
+		req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+		req.Header.Set("Access-Control-Request-Headers", "content-type,x-grpc-web,authorization")
+
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+
+		require.Equal(t, http.StatusNoContent, rec.Code)
+		require.Equal(t, "http://localhost:3000", rec.Header().Get("Access-Control-Allow-Origin"))
+		require.Equal(t, grpcWebAllowedHeaders, rec.Header().Get("Access-Control-Allow-Headers"))
+	})
+
+	t.Run("preflight of a disallowed origin", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodOptions, "/grpc.health.v1.Health/Check", nil)
+		req.Header.Set("Origin", "https://evil.example.com")
+		req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+		req.Header.Set("Access-Control-Request-Headers", "content-type,x-grpc-web,authorization")
+
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+
+		require.Equal(t, http.StatusForbidden, rec.Code)
+		require.Empty(t, rec.Header().Get("Access-Control-Allow-Origin"))
+		require.Empty(t, rec.Header().Get("Access-Control-Allow-Headers"))
+	})
+
+	t.Run("request of a disallowed origin", func(t *testing.T) {
+		body := grpcWebFrame(t, &grpc_health_v1.HealthCheckRequest{Service: "immudb"})
+
+		req := httptest.NewRequest(http.MethodPost, "/grpc.health.v1.Health/Check", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/grpc-web+proto")
+		req.Header.Set("Origin", "https://evil.example.com")
+
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+
+		require.Equal(t, http.StatusForbidden, rec.Code)
+		require.Empty(t, rec.Header().Get("Access-Control-Allow-Origin"))
+		require.NotEqual(t, "application/grpc-web+proto", rec.Header().Get("Content-Type"))
+	})
+
+	t.Run("request of the same origin", func(t *testing.T) {
+		body := grpcWebFrame(t, &grpc_health_v1.HealthCheckRequest{Service: "immudb"})
+
+		req := httptest.NewRequest(http.MethodPost, "http://immudb.example.com:8080/grpc.health.v1.Health/Check", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/grpc-web+proto")
+		req.Header.Set("Origin", "http://immudb.example.com:8080")
+
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Empty(t, rec.Header().Get("Access-Control-Allow-Origin"))
+
+		msgs, trailers := readGrpcWebFrames(t, rec.Body.Bytes())
+		require.Len(t, msgs, 1)
+		require.Contains(t, trailers, "grpc-status: 0\r\n")
+	})
+
+	t.Run("no origin should be allowed by default", func(t *testing.T) {
+		h := newGrpcWebHandler(grpcSrv, nil, next)
+
+		req := httptest.NewRequest(http.MethodOptions, "/grpc.health.v1.Health/Check", nil)
+		req.Header.Set("Origin", "http://localhost:3000")
+		req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+		req.Header.Set("Access-Control-Request-Headers", "content-type,x-grpc-web")
+
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+
+		require.Equal(t, http.StatusForbidden, rec.Code)
+		require.Empty(t, rec.Header().Get("Access-Control-Allow-Origin"))
+	})
+
+	t.Run("other requests", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+
+		require.Equal(t, http.StatusTeapot, rec.Code)
+	})
+}
@@ -0,0 +1,52 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+
+	"github.com/codenotary/immudb/pkg/database"
+)
+
+// getInclusionProof returns the audit path of an entry of the selected database up to its signed state,
+// it can be read by the same users as VerifiableGet
+func (s *ImmuServer) getInclusionProof(ctx context.Context, key []byte, atTx uint64) (*database.EntryInclusionProof, error) {
+	if len(key) == 0 {
+		return nil, ErrIllegalArguments
+	}
+
+	db, err := s.getDBFromCtx(ctx, "VerifiableGet")
+	if err != nil {
+		return nil, err
+	}
+
+	proof, err := db.GetInclusionProof(ctx, key, atTx)
+	if err != nil {
+		return nil, err
+	}
+
+	proof.State.Db = db.GetName()
+
+	if s.StateSigner != nil {
+		err = s.signState(proof.State)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return proof, nil
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/database"
+	"github.com/codenotary/immudb/pkg/signer"
+	"github.com/grpc-ecosystem/grpc-gateway/runtime"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestGetInclusionProof(t *testing.T) {
+	s, ctx, closer := newTestServer(t, DefaultOptions().WithSigningKey("./../../test/signer/ec1.key"))
+	defer closer()
+
+	hdr, err := s.Set(ctx, &schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte("key1"), Value: []byte("value1")}}})
+	require.NoError(t, err)
+
+	_, err = s.Set(ctx, &schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte("key1"), Value: []byte("value2")}}})
+	require.NoError(t, err)
+
+	_, err = s.getInclusionProof(ctx, nil, 0)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	checkProof := func(proof *database.EntryInclusionProof) {
+		require.Equal(t, []byte("value1"), proof.Entry.Value)
+		require.Equal(t, hdr.Id, proof.EntryTx)
+		require.Equal(t, DefaultDBName, proof.State.Db)
+		require.True(t, proof.Verify())
+
+		pk, err := signer.UnmarshalKey(proof.State.Signature.PublicKey)
+		require.NoError(t, err)
+
+		ok, err := proof.State.CheckSignature(pk)
+		require.NoError(t, err)
+		require.True(t, ok)
+	}
+
+	proof, err := s.getInclusionProof(ctx, []byte("key1"), hdr.Id)
+	require.NoError(t, err)
+	checkProof(proof)
+
+	// the console is called with the token of the sysadmin session
+	md, _ := metadata.FromIncomingContext(ctx)
+	token := md.Get("authorization")[0]
+
+	t.Run("console", func(t *testing.T) {
+		webMux := http.NewServeMux()
+		setupConsoleSQL(webMux, runtime.NewServeMux(), s)
+
+		call := func(method, query string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(method, "/api/console/inclusionproof?"+query, nil)
+			req.Header.Set("Authorization", token)
+
+			rec := httptest.NewRecorder()
+			webMux.ServeHTTP(rec, req)
+
+			return rec
+		}
+
+		rec := call(http.MethodPost, "key=key1")
+		require.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+
+		rec = call(http.MethodGet, "key=key1&atTx=x")
+		require.Equal(t, http.StatusBadRequest, rec.Code)
+
+		rec = call(http.MethodGet, "key=key1&atTx="+strconv.FormatUint(hdr.Id, 10))
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		var proof database.EntryInclusionProof
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &proof))
+		checkProof(&proof)
+	})
+}
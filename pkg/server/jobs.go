@@ -0,0 +1,300 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package server
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/rs/xid"
+)
+
+// defaultMaxFinishedJobs is the number of finished jobs kept so their outcome can still be queried
+const defaultMaxFinishedJobs = 100
+
+// jobRunFunc performs the work of a job, cancelable jobs are expected to return once ctx is done
+type jobRunFunc func(ctx context.Context) error
+
+// jobProgressFunc returns the percentage of the work already done by a running job
+type jobProgressFunc func() float32
+
+type job struct {
+	id         string
+	kind       string
+	database   string
+	startedAt  time.Time
+	finishedAt time.Time
+	status     string
+	err        error
+
+	progress jobProgressFunc
+
+	// cancel is nil when the job can not be canceled
+	cancel   context.CancelFunc
+	canceled bool
+
+	done chan struct{}
+}
+
+// jobManager keeps track of long-running operations (e.g. index compaction) executed in background.
+// At most one job of each kind runs on a database at any time.
+type jobManager struct {
+	jobs            map[string]*job
+	maxFinishedJobs int
+	mutex           sync.Mutex
+}
+
+func newJobManager(maxFinishedJobs int) *jobManager {
+	return &jobManager{
+		jobs:            make(map[string]*job),
+		maxFinishedJobs: maxFinishedJobs,
+	}
+}
+
+// start runs a new job in background. progress may be nil when the job can not report its progress.
+func (m *jobManager) start(kind, database string, cancelable bool, progress jobProgressFunc, run jobRunFunc) (*schema.Job, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for _, j := range m.jobs {
+		if j.kind == kind && j.database == database && j.status == schema.JobStatusRunning {
+			return nil, ErrJobAlreadyInProgress
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	j := &job{
+		id:        xid.New().String(),
+		kind:      kind,
+		database:  database,
+		startedAt: time.Now(),
+		status:    schema.JobStatusRunning,
+		progress:  progress,
+		done:      make(chan struct{}),
+	}
+
+	if cancelable {
+		j.cancel = cancel
+	}
+
+	m.jobs[j.id] = j
+
+	go m.run(ctx, cancel, j, run)
+
+	return j.toProto(), nil
+}
+
+func (m *jobManager) run(ctx context.Context, cancel context.CancelFunc, j *job, run jobRunFunc) {
+	err := run(ctx)
+	cancel()
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	j.finishedAt = time.Now()
+
+	switch {
+	case err == nil:
+		j.status = schema.JobStatusCompleted
+	case j.canceled && errors.Is(err, context.Canceled):
+		j.status = schema.JobStatusCanceled
+	default:
+		j.status = schema.JobStatusFailed
+		j.err = err
+	}
+
+	close(j.done)
+
+	m.prune()
+}
+
+// prune discards the oldest finished jobs exceeding maxFinishedJobs
+func (m *jobManager) prune() {
+	var finished []*job
+
+	for _, j := range m.jobs {
+		if j.status != schema.JobStatusRunning {
+			finished = append(finished, j)
+		}
+	}
+
+	if len(finished) <= m.maxFinishedJobs {
+		return
+	}
+
+	sort.Slice(finished, func(i, k int) bool {
+		return finished[i].finishedAt.Before(finished[k].finishedAt)
+	})
+
+	for _, j := range finished[:len(finished)-m.maxFinishedJobs] {
+		delete(m.jobs, j.id)
+	}
+}
+
+// get returns the job with the given id, only if it runs on the given database
+func (m *jobManager) get(id, database string) (*schema.Job, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	j, ok := m.jobs[id]
+	if !ok || j.database != database {
+		return nil, ErrJobNotFound
+	}
+
+	return j.toProto(), nil
+}
+
+// list returns the jobs of the given database, newest first. All kinds are included when kind is empty.
+func (m *jobManager) list(database, kind string) []*schema.Job {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	var jobs []*job
+
+	for _, j := range m.jobs {
+		if j.database == database && (kind == "" || j.kind == kind) {
+			jobs = append(jobs, j)
+		}
+	}
+
+	sort.Slice(jobs, func(i, k int) bool {
+		if jobs[i].startedAt.Equal(jobs[k].startedAt) {
+			return jobs[i].id > jobs[k].id
+		}
+		return jobs[i].startedAt.After(jobs[k].startedAt)
+	})
+
+	res := make([]*schema.Job, len(jobs))
+	for i, j := range jobs {
+		res[i] = j.toProto()
+	}
+
+	return res
+}
+
+// cancel requests the cancelation of a running job, it returns without waiting for the job to finish
+func (m *jobManager) cancel(id, database string) (*schema.Job, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	j, ok := m.jobs[id]
+	if !ok || j.database != database {
+		return nil, ErrJobNotFound
+	}
+
+	if j.status != schema.JobStatusRunning {
+		return nil, ErrJobAlreadyFinished
+	}
+
+	if j.cancel == nil {
+		return nil, ErrJobNotCancelable
+	}
+
+	j.canceled = true
+	j.cancel()
+
+	return j.toProto(), nil
+}
+
+// stop cancels all the running jobs which can be canceled
+func (m *jobManager) stop() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for _, j := range m.jobs {
+		if j.status == schema.JobStatusRunning && j.cancel != nil {
+			j.canceled = true
+			j.cancel()
+		}
+	}
+}
+
+func (j *job) toProto() *schema.Job {
+	res := &schema.Job{
+		Id:         j.id,
+		Kind:       j.kind,
+		Database:   j.database,
+		Status:     j.status,
+		StartedAt:  j.startedAt.Unix(),
+		Cancelable: j.cancel != nil,
+	}
+
+	switch j.status {
+	case schema.JobStatusRunning:
+		if j.progress != nil {
+			res.Progress = j.progress()
+		}
+	case schema.JobStatusCompleted:
+		res.Progress = 100
+	}
+
+	if j.status != schema.JobStatusRunning {
+		res.FinishedAt = j.finishedAt.Unix()
+	}
+
+	if j.err != nil {
+		res.Error = j.err.Error()
+	}
+
+	return res
+}
+
+// ListJobs returns the jobs run in background on the selected database, newest first
+func (s *ImmuServer) ListJobs(ctx context.Context, req *schema.ListJobsRequest) (*schema.JobList, error) {
+	if req == nil {
+		return nil, ErrIllegalArguments
+	}
+
+	db, err := s.getDBFromCtx(ctx, "ListJobs")
+	if err != nil {
+		return nil, err
+	}
+
+	return &schema.JobList{Jobs: s.jobs.list(db.GetName(), req.Kind)}, nil
+}
+
+// GetJob returns the state of a job run in background on the selected database
+func (s *ImmuServer) GetJob(ctx context.Context, req *schema.JobRequest) (*schema.Job, error) {
+	if req == nil {
+		return nil, ErrIllegalArguments
+	}
+
+	db, err := s.getDBFromCtx(ctx, "GetJob")
+	if err != nil {
+		return nil, err
+	}
+
+	return s.jobs.get(req.Id, db.GetName())
+}
+
+// CancelJob requests the cancelation of a job run in background on the selected database
+func (s *ImmuServer) CancelJob(ctx context.Context, req *schema.JobRequest) (*schema.Job, error) {
+	if req == nil {
+		return nil, ErrIllegalArguments
+	}
+
+	db, err := s.getDBFromCtx(ctx, "CancelJob")
+	if err != nil {
+		return nil, err
+	}
+
+	return s.jobs.cancel(req.Id, db.GetName())
+}
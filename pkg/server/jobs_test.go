@@ -0,0 +1,158 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/stretchr/testify/require"
+)
+
+func waitForJobManager(t *testing.T, m *jobManager, id, database string) *schema.Job {
+	for {
+		job, err := m.get(id, database)
+		require.NoError(t, err)
+
+		if job.Finished() {
+			return job
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestJobManager(t *testing.T) {
+	m := newJobManager(2)
+
+	release := make(chan struct{})
+
+	running, err := m.start("kind1", "db1", true, func() float32 { return 50 }, func(ctx context.Context) error {
+		select {
+		case <-release:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+	require.NoError(t, err)
+	require.Equal(t, schema.JobStatusRunning, running.Status)
+	require.Equal(t, float32(50), running.Progress)
+	require.True(t, running.Cancelable)
+
+	_, err = m.start("kind1", "db1", true, nil, func(ctx context.Context) error { return nil })
+	require.ErrorIs(t, err, ErrJobAlreadyInProgress)
+
+	t.Run("jobs of different kind or database can run concurrently", func(t *testing.T) {
+		job, err := m.start("kind2", "db1", false, nil, func(ctx context.Context) error {
+			return errors.New("injected error")
+		})
+		require.NoError(t, err)
+		require.False(t, job.Cancelable)
+
+		job = waitForJobManager(t, m, job.Id, "db1")
+		require.Equal(t, schema.JobStatusFailed, job.Status)
+		require.Equal(t, "injected error", job.Error)
+		require.Zero(t, job.Progress)
+
+		_, err = m.cancel(job.Id, "db1")
+		require.ErrorIs(t, err, ErrJobAlreadyFinished)
+
+		job, err = m.start("kind1", "db2", false, nil, func(ctx context.Context) error { return nil })
+		require.NoError(t, err)
+
+		job = waitForJobManager(t, m, job.Id, "db2")
+		require.Equal(t, schema.JobStatusCompleted, job.Status)
+
+		_, err = m.get(job.Id, "db1")
+		require.ErrorIs(t, err, ErrJobNotFound)
+
+		require.Len(t, m.list("db2", ""), 1)
+	})
+
+	t.Run("jobs are listed newest first", func(t *testing.T) {
+		jobs := m.list("db1", "")
+		require.Len(t, jobs, 2)
+		require.Equal(t, "kind2", jobs[0].Kind)
+		require.Equal(t, running.Id, jobs[1].Id)
+
+		jobs = m.list("db1", "kind1")
+		require.Len(t, jobs, 1)
+		require.Equal(t, running.Id, jobs[0].Id)
+	})
+
+	t.Run("running jobs can be canceled", func(t *testing.T) {
+		_, err := m.cancel("unknown", "db1")
+		require.ErrorIs(t, err, ErrJobNotFound)
+
+		_, err = m.cancel(running.Id, "db2")
+		require.ErrorIs(t, err, ErrJobNotFound)
+
+		job, err := m.cancel(running.Id, "db1")
+		require.NoError(t, err)
+		require.Equal(t, running.Id, job.Id)
+
+		job = waitForJobManager(t, m, running.Id, "db1")
+		require.Equal(t, schema.JobStatusCanceled, job.Status)
+		require.Empty(t, job.Error)
+		require.NotZero(t, job.FinishedAt)
+	})
+
+	t.Run("non-cancelable jobs can not be canceled", func(t *testing.T) {
+		job, err := m.start("kind3", "db1", false, nil, func(ctx context.Context) error {
+			<-release
+			return nil
+		})
+		require.NoError(t, err)
+
+		_, err = m.cancel(job.Id, "db1")
+		require.ErrorIs(t, err, ErrJobNotCancelable)
+
+		m.stop()
+
+		close(release)
+
+		job = waitForJobManager(t, m, job.Id, "db1")
+		require.Equal(t, schema.JobStatusCompleted, job.Status)
+		require.Equal(t, float32(100), job.Progress)
+	})
+
+	t.Run("only the latest finished jobs are kept", func(t *testing.T) {
+		jobs := m.list("db1", "")
+		require.Len(t, jobs, 2)
+		require.Equal(t, "kind3", jobs[0].Kind)
+		require.Equal(t, running.Id, jobs[1].Id)
+
+		require.Empty(t, m.list("db2", ""))
+	})
+}
+
+func TestJobsRPCEdgeCases(t *testing.T) {
+	s := DefaultServer()
+
+	_, err := s.ListJobs(context.Background(), nil)
+	require.Equal(t, ErrIllegalArguments, err)
+
+	_, err = s.GetJob(context.Background(), nil)
+	require.Equal(t, ErrIllegalArguments, err)
+
+	_, err = s.CancelJob(context.Background(), nil)
+	require.Equal(t, ErrIllegalArguments, err)
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/database"
+)
+
+// KeyspaceStats summarizes the keys of a database, the whole index is walked so it may take a while on large databases
+func (s *ImmuServer) KeyspaceStats(ctx context.Context, req *schema.KeyspaceStatsRequest) (*schema.KeyspaceStatsResponse, error) {
+	if req == nil {
+		return nil, ErrIllegalArguments
+	}
+
+	stats, err := s.keyspaceStats(ctx, req.Database, &database.KeyspaceStatsRequest{
+		PrefixLength: int(req.PrefixLength),
+		Outliers:     int(req.Outliers),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return keyspaceStatsToProto(stats), nil
+}
+
+// keyspaceStats summarizes the keys of a database, only its administrators can read them
+func (s *ImmuServer) keyspaceStats(ctx context.Context, dbName string, req *database.KeyspaceStatsRequest) (*database.KeyspaceStats, error) {
+	if dbName == "" || req == nil {
+		return nil, ErrIllegalArguments
+	}
+
+	err := s.checkDatabaseAdmin(ctx, dbName)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := s.dbList.GetByName(dbName)
+	if err != nil {
+		return nil, err
+	}
+
+	return db.KeyspaceStats(ctx, req)
+}
+
+func keyspaceStatsToProto(stats *database.KeyspaceStats) *schema.KeyspaceStatsResponse {
+	res := &schema.KeyspaceStatsResponse{
+		TxId:          stats.TxID,
+		Keyspaces:     keyspaceUsagesToProto(stats.Keyspaces),
+		Prefixes:      keyspaceUsagesToProto(stats.Prefixes),
+		OtherPrefixes: keyspaceUsageToProto(stats.OtherPrefixes),
+		KeySizes:      sizeDistributionToProto(stats.KeySizes),
+		ValueSizes:    sizeDistributionToProto(stats.ValueSizes),
+	}
+
+	for _, o := range stats.RevisionOutliers {
+		res.RevisionOutliers = append(res.RevisionOutliers, &schema.KeyRevisions{Key: o.Key, Revisions: o.Revisions})
+	}
+
+	return res
+}
+
+func keyspaceUsagesToProto(usages []*database.KeyspaceUsage) []*schema.KeyspaceUsage {
+	res := make([]*schema.KeyspaceUsage, len(usages))
+
+	for i, u := range usages {
+		res[i] = keyspaceUsageToProto(u)
+	}
+
+	return res
+}
+
+func keyspaceUsageToProto(u *database.KeyspaceUsage) *schema.KeyspaceUsage {
+	if u == nil {
+		return nil
+	}
+
+	return &schema.KeyspaceUsage{
+		Name:       u.Name,
+		Prefix:     u.Prefix,
+		Keys:       u.Keys,
+		KeyBytes:   u.KeyBytes,
+		ValueBytes: u.ValueBytes,
+	}
+}
+
+func sizeDistributionToProto(d *database.SizeDistribution) *schema.SizeDistribution {
+	if d == nil {
+		return nil
+	}
+
+	res := &schema.SizeDistribution{
+		Count: d.Count,
+		Min:   d.Min,
+		Max:   d.Max,
+		Sum:   d.Sum,
+	}
+
+	for _, b := range d.Buckets {
+		res.Buckets = append(res.Buckets, &schema.SizeBucket{UpperBound: b.UpperBound, Count: b.Count})
+	}
+
+	return res
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/auth"
+	"github.com/codenotary/immudb/pkg/database"
+	"github.com/grpc-ecosystem/grpc-gateway/runtime"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestKeyspaceStats(t *testing.T) {
+	s, ctx, closer := newTestServer(t, nil)
+	defer closer()
+
+	_, err := s.Set(ctx, &schema.SetRequest{KVs: []*schema.KeyValue{
+		{Key: []byte("user:1"), Value: []byte("value1")},
+		{Key: []byte("user:2"), Value: []byte("value2")},
+	}})
+	require.NoError(t, err)
+
+	_, err = s.keyspaceStats(ctx, "", &database.KeyspaceStatsRequest{})
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	stats, err := s.keyspaceStats(ctx, DefaultDBName, &database.KeyspaceStatsRequest{PrefixLength: 5})
+	require.NoError(t, err)
+	require.Len(t, stats.Prefixes, 1)
+	require.Equal(t, uint64(2), stats.Prefixes[0].Keys)
+
+	t.Run("grpc", func(t *testing.T) {
+		_, err := s.KeyspaceStats(ctx, nil)
+		require.ErrorIs(t, err, ErrIllegalArguments)
+
+		res, err := s.KeyspaceStats(ctx, &schema.KeyspaceStatsRequest{Database: DefaultDBName, PrefixLength: 5, Outliers: 1})
+		require.NoError(t, err)
+		require.Equal(t, stats.TxID, res.TxId)
+		require.Len(t, res.Prefixes, 1)
+		require.Equal(t, []byte("user:"), res.Prefixes[0].Prefix)
+		require.Equal(t, uint64(2), res.Prefixes[0].Keys)
+		require.Equal(t, uint64(2), res.KeySizes.Count)
+		require.Len(t, res.RevisionOutliers, 1)
+	})
+
+	// the console is called with the token of the sysadmin session
+	md, _ := metadata.FromIncomingContext(ctx)
+	token := md.Get("authorization")[0]
+
+	webMux := http.NewServeMux()
+	setupConsoleSQL(webMux, runtime.NewServeMux(), s)
+
+	get := func(path, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Authorization", token)
+
+		rec := httptest.NewRecorder()
+		webMux.ServeHTTP(rec, req)
+
+		return rec
+	}
+
+	t.Run("console", func(t *testing.T) {
+		rec := get("/api/console/keyspace?database="+DefaultDBName+"&prefixLength=5&outliers=1", token)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		var stats database.KeyspaceStats
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &stats))
+		require.Equal(t, []byte("user:"), stats.Prefixes[0].Prefix)
+		require.Len(t, stats.RevisionOutliers, 1)
+
+		rec = get("/api/console/keyspace?database="+DefaultDBName+"&prefixLength=x", token)
+		require.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("non admin users can not read the keyspace", func(t *testing.T) {
+		_, err = s.CreateUser(ctx, &schema.CreateUserRequest{
+			User:       []byte("user1"),
+			Password:   []byte("user1Password!"),
+			Permission: auth.PermissionRW,
+			Database:   DefaultDBName,
+		})
+		require.NoError(t, err)
+
+		lr, err := s.Login(context.Background(), &schema.LoginRequest{User: []byte("user1"), Password: []byte("user1Password!")})
+		require.NoError(t, err)
+
+		userCtx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", lr.Token))
+
+		_, err = s.keyspaceStats(userCtx, DefaultDBName, &database.KeyspaceStatsRequest{})
+		require.ErrorIs(t, err, ErrPermissionDenied)
+
+		_, err = s.KeyspaceStats(userCtx, &schema.KeyspaceStatsRequest{Database: DefaultDBName})
+		require.ErrorIs(t, err, ErrPermissionDenied)
+
+		rec := get("/api/console/keyspace?database="+DefaultDBName, lr.Token)
+		require.NotEqual(t, http.StatusOK, rec.Code)
+	})
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/database"
+	"github.com/codenotary/immudb/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+func TestKVSchemas(t *testing.T) {
+	s, ctx, closer := newTestServer(t, nil)
+	defer closer()
+
+	_, err := s.SetKVSchema(ctx, &schema.KVSchema{
+		Prefix:     []byte("user:"),
+		Definition: []byte(`{"type": "object", "required": ["name"]}`),
+	})
+	require.NoError(t, err)
+
+	schemas, err := s.KVSchemas(ctx, &emptypb.Empty{})
+	require.NoError(t, err)
+	require.Len(t, schemas.Schemas, 1)
+
+	_, err = s.Set(ctx, &schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte("user:1"), Value: []byte(`{"name": "alice"}`)}}})
+	require.NoError(t, err)
+
+	_, err = s.Set(ctx, &schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte("user:2"), Value: []byte(`{}`)}}})
+	require.ErrorIs(t, err, database.ErrKVSchemaViolation)
+
+	mappedErr := mapServerError(err)
+	require.Equal(t, errors.CodInvalidParameterValue, mappedErr.(errors.Error).Code())
+	require.Contains(t, mappedErr.Error(), "missing required property 'name'")
+
+	_, err = s.DeleteKVSchema(ctx, &schema.KVSchemaRequest{Prefix: []byte("user:")})
+	require.NoError(t, err)
+
+	_, err = s.DeleteKVSchema(ctx, &schema.KVSchemaRequest{Prefix: []byte("user:")})
+	require.ErrorIs(t, err, database.ErrKVSchemaNotFound)
+	require.Equal(t, ErrKVSchemaNotFound, mapServerError(err))
+
+	_, err = s.Set(ctx, &schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte("user:2"), Value: []byte(`{}`)}}})
+	require.NoError(t, err)
+
+	_, err = s.KVSchemas(context.Background(), &emptypb.Empty{})
+	require.Error(t, err)
+}
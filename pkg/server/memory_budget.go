@@ -0,0 +1,222 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/database"
+)
+
+// DefaultMemoryBudgetRebalanceInterval is how often the memory budget is divided again among the databases
+const DefaultMemoryBudgetRebalanceInterval = 30 * time.Second
+
+// the share of a database is divided among its caches and the SQL working memory,
+// entries are assumed to take the memory below to turn each part into a number of entries
+const (
+	indexCacheBudgetPercentage  = 60
+	txLogCacheBudgetPercentage  = 20
+	sqlDistinctBudgetPercentage = 20
+
+	txLogCacheEntryBytes = 4096
+	sqlDistinctRowBytes  = 256
+)
+
+// databases are always granted these limits, even if they exceed their share of the budget
+const (
+	minIndexCacheSize   = 100
+	minTxLogCacheSize   = 10
+	minSQLDistinctLimit = 10_000
+
+	maxSQLDistinctLimit = 1 << 20 // the default limit of the SQL engine
+)
+
+// memoryBudget divides a budget among the loaded databases, weighted by the number of requests each one
+// served recently, so the memory taken by all of them is bounded regardless of the number of databases
+type memoryBudget struct {
+	mutex    sync.Mutex
+	requests map[string]uint64  // requests served since the last rebalance
+	activity map[string]float64 // moving average of the requests served between rebalances
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func newMemoryBudget() *memoryBudget {
+	return &memoryBudget{
+		requests: make(map[string]uint64),
+		activity: make(map[string]float64),
+	}
+}
+
+// touch records a request served by the database
+func (b *memoryBudget) touch(db string) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.requests[db]++
+}
+
+// weights returns the weight of each database, idle databases weigh 1
+func (b *memoryBudget) weights(dbs []string) map[string]float64 {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	weights := make(map[string]float64, len(dbs))
+	activity := make(map[string]float64, len(dbs))
+
+	for _, db := range dbs {
+		activity[db] = b.activity[db]/2 + float64(b.requests[db])
+		weights[db] = 1 + activity[db]
+	}
+
+	// databases no longer loaded are forgotten
+	b.activity = activity
+	b.requests = make(map[string]uint64)
+
+	return weights
+}
+
+func clampLimit(v, min, max int64) int {
+	if v > max {
+		v = max
+	}
+	if v < min {
+		v = min
+	}
+	return int(v)
+}
+
+// memoryLimits turns the share of the budget of a database into the limits of its caches,
+// which never exceed the cache sizes set in the settings of the database
+func memoryLimits(share int64, opts *dbOptions) database.MemoryLimits {
+	indexOpts := opts.storeOptions().IndexOpts
+
+	return database.MemoryLimits{
+		IndexCacheSize:   clampLimit(share*indexCacheBudgetPercentage/100/int64(indexOpts.MaxNodeSize), minIndexCacheSize, int64(indexOpts.CacheSize)),
+		TxLogCacheSize:   clampLimit(share*txLogCacheBudgetPercentage/100/txLogCacheEntryBytes, minTxLogCacheSize, int64(opts.TxLogCacheSize)),
+		SQLDistinctLimit: clampLimit(share*sqlDistinctBudgetPercentage/100/sqlDistinctRowBytes, minSQLDistinctLimit, maxSQLDistinctLimit),
+	}
+}
+
+// touchMemoryBudget accounts a request served by the database to weight its share of the budget
+func (s *ImmuServer) touchMemoryBudget(db string) {
+	if s.memoryBudget == nil {
+		return
+	}
+
+	s.memoryBudget.touch(db)
+}
+
+// startMemoryBudget divides the memory budget among the loaded databases and keeps rebalancing it
+// as their activity changes, each database keeps the caches set in its settings when there is no budget
+func (s *ImmuServer) startMemoryBudget() {
+	if s.Options.MemoryBudget <= 0 {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	s.memoryBudget = newMemoryBudget()
+	s.memoryBudget.cancel = cancel
+	s.memoryBudget.done = done
+
+	s.rebalanceMemoryBudget()
+
+	go func() {
+		defer close(done)
+
+		for {
+			timer := time.NewTimer(s.Options.MemoryBudgetRebalanceInterval)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+
+			s.rebalanceMemoryBudget()
+		}
+	}()
+}
+
+func (s *ImmuServer) stopMemoryBudget() {
+	if s.memoryBudget == nil || s.memoryBudget.cancel == nil {
+		return
+	}
+
+	s.memoryBudget.cancel()
+	<-s.memoryBudget.done
+
+	s.memoryBudget.cancel = nil
+	s.memoryBudget.done = nil
+}
+
+// rebalanceMemoryBudget sets the memory limits of every loaded database to its share of the budget
+func (s *ImmuServer) rebalanceMemoryBudget() {
+	if s.memoryBudget == nil {
+		return
+	}
+
+	var dbs []database.DB
+	var names []string
+
+	for i := 0; i < s.dbList.Length(); i++ {
+		db := s.dbList.GetByIndex(int64(i))
+
+		dbs = append(dbs, db)
+		names = append(names, db.GetName())
+	}
+
+	weights := s.memoryBudget.weights(names)
+
+	var totalWeight float64
+	for _, w := range weights {
+		totalWeight += w
+	}
+
+	for _, db := range dbs {
+		opts, err := s.loadDBOptions(db.GetName(), false)
+		if err == store.ErrKeyNotFound {
+			// settings are not saved until changed
+			opts = s.defaultDBOptions(db.GetName())
+		} else if err != nil {
+			s.Logger.Warningf("Unable to load the settings of database '%s' to apply its memory budget: %v", db.GetName(), err)
+			continue
+		}
+
+		share := int64(float64(s.Options.MemoryBudget) * weights[db.GetName()] / totalWeight)
+
+		limits := memoryLimits(share, opts)
+
+		err = db.SetMemoryLimits(limits)
+		if err == store.ErrAlreadyClosed {
+			continue
+		}
+		if err != nil {
+			s.Logger.Warningf("Unable to apply the memory budget of database '%s': %v", db.GetName(), err)
+			continue
+		}
+
+		s.Logger.Debugf("Memory budget of database '%s': %d bytes {index cache = %d, tx log cache = %d, sql distinct limit = %d}",
+			db.GetName(), share, limits.IndexCacheSize, limits.TxLogCacheSize, limits.SQLDistinctLimit)
+	}
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/database"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryLimitsDB struct {
+	database.DB
+	limits chan database.MemoryLimits
+}
+
+func (db *memoryLimitsDB) SetMemoryLimits(limits database.MemoryLimits) error {
+	db.limits <- limits
+	return db.DB.SetMemoryLimits(limits)
+}
+
+func TestMemoryBudgetWeights(t *testing.T) {
+	b := newMemoryBudget()
+
+	for i := 0; i < 10; i++ {
+		b.touch("db1")
+	}
+	b.touch("db3")
+
+	weights := b.weights([]string{"db1", "db2"})
+	require.Equal(t, map[string]float64{"db1": 11, "db2": 1}, weights)
+
+	// activity decays when databases go idle and databases no longer loaded are forgotten
+	weights = b.weights([]string{"db1", "db2"})
+	require.Equal(t, map[string]float64{"db1": 6, "db2": 1}, weights)
+	require.NotContains(t, b.activity, "db3")
+}
+
+func TestMemoryLimits(t *testing.T) {
+	s := DefaultServer()
+	opts := s.defaultDBOptions("db1")
+
+	limits := memoryLimits(0, opts)
+	require.Equal(t, database.MemoryLimits{
+		IndexCacheSize:   minIndexCacheSize,
+		TxLogCacheSize:   minTxLogCacheSize,
+		SQLDistinctLimit: minSQLDistinctLimit,
+	}, limits)
+
+	// cache sizes set in the settings are never exceeded
+	limits = memoryLimits(1<<40, opts)
+	require.Equal(t, database.MemoryLimits{
+		IndexCacheSize:   opts.storeOptions().IndexOpts.CacheSize,
+		TxLogCacheSize:   opts.TxLogCacheSize,
+		SQLDistinctLimit: maxSQLDistinctLimit,
+	}, limits)
+}
+
+func TestMemoryBudget(t *testing.T) {
+	serverOptions := DefaultOptions().
+		WithMemoryBudget(8 << 20).
+		WithMemoryBudgetRebalanceInterval(time.Hour)
+
+	s, ctx, closer := newTestServer(t, serverOptions)
+	defer closer()
+	defer s.stopMemoryBudget()
+
+	require.NotNil(t, s.memoryBudget)
+
+	_, err := s.CreateDatabaseWithV2(ctx, &schema.DatabaseSettingsV2{DatabaseName: "db1"})
+	require.NoError(t, err)
+
+	db, err := s.dbList.GetByName("db1")
+	require.NoError(t, err)
+
+	limitedDB := &memoryLimitsDB{DB: db, limits: make(chan database.MemoryLimits, 1)}
+	s.dbList.Replace(s.dbList.GetId("db1"), limitedDB)
+
+	s.rebalanceMemoryBudget()
+	idleLimits := <-limitedDB.limits
+
+	for i := 0; i < 1000; i++ {
+		s.touchMemoryBudget("db1")
+	}
+
+	s.rebalanceMemoryBudget()
+	activeLimits := <-limitedDB.limits
+
+	require.Greater(t, activeLimits.IndexCacheSize, idleLimits.IndexCacheSize)
+	require.Greater(t, activeLimits.TxLogCacheSize, idleLimits.TxLogCacheSize)
+}
@@ -18,6 +18,7 @@ package server
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"expvar"
 	"net/http"
@@ -53,8 +54,16 @@ type MetricsCollection struct {
 	computeDBEntries func() map[string]float64
 	DBEntriesGauges  *prometheus.GaugeVec
 
+	computeDBDiskQuotaExceeded func() map[string]float64
+	DBDiskQuotaExceededGauges  *prometheus.GaugeVec
+
 	RPCsPerClientCounters        *prometheus.CounterVec
 	LastMessageAtPerClientGauges *prometheus.GaugeVec
+
+	computeTenantUsage    func() map[string]TenantUsage
+	TenantDatabasesGauges *prometheus.GaugeVec
+	TenantDiskUsageGauges *prometheus.GaugeVec
+	TenantSessionsGauges  *prometheus.GaugeVec
 }
 
 var metricsNamespace = "immudb"
@@ -93,6 +102,16 @@ func (mc *MetricsCollection) WithComputeDBEntries(f func() map[string]float64) {
 	mc.computeDBEntries = f
 }
 
+// WithComputeDBDiskQuotaExceeded ...
+func (mc *MetricsCollection) WithComputeDBDiskQuotaExceeded(f func() map[string]float64) {
+	mc.computeDBDiskQuotaExceeded = f
+}
+
+// WithComputeTenantUsage ...
+func (mc *MetricsCollection) WithComputeTenantUsage(f func() map[string]TenantUsage) {
+	mc.computeTenantUsage = f
+}
+
 // UpdateDBMetrics ...
 func (mc *MetricsCollection) UpdateDBMetrics() {
 	if mc.computeDBSizes != nil {
@@ -105,6 +124,18 @@ func (mc *MetricsCollection) UpdateDBMetrics() {
 			mc.DBEntriesGauges.WithLabelValues(db).Set(nbEntries)
 		}
 	}
+	if mc.computeDBDiskQuotaExceeded != nil {
+		for db, exceeded := range mc.computeDBDiskQuotaExceeded() {
+			mc.DBDiskQuotaExceededGauges.WithLabelValues(db).Set(exceeded)
+		}
+	}
+	if mc.computeTenantUsage != nil {
+		for tenant, usage := range mc.computeTenantUsage() {
+			mc.TenantDatabasesGauges.WithLabelValues(tenant).Set(float64(usage.Databases))
+			mc.TenantDiskUsageGauges.WithLabelValues(tenant).Set(float64(usage.DiskUsage))
+			mc.TenantSessionsGauges.WithLabelValues(tenant).Set(float64(usage.Sessions))
+		}
+	}
 }
 
 // Metrics immudb Prometheus metrics collection
@@ -133,6 +164,14 @@ var Metrics = MetricsCollection{
 		},
 		[]string{"db"},
 	),
+	DBDiskQuotaExceededGauges: promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "db_disk_quota_exceeded",
+			Help:      "Whether the database exceeds its disk usage quota (1) and writes are rejected or not (0).",
+		},
+		[]string{"db"},
+	),
 	LastMessageAtPerClientGauges: promauto.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Namespace: metricsNamespace,
@@ -141,13 +180,41 @@ var Metrics = MetricsCollection{
 		},
 		[]string{"ip"},
 	),
+	TenantDatabasesGauges: promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "tenant_number_of_databases",
+			Help:      "Number of databases owned by the tenant.",
+		},
+		[]string{"tenant"},
+	),
+	TenantDiskUsageGauges: promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "tenant_disk_usage_bytes",
+			Help:      "Disk space taken by the databases of the tenant in bytes.",
+		},
+		[]string{"tenant"},
+	),
+	TenantSessionsGauges: promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "tenant_number_of_sessions",
+			Help:      "Number of sessions opened by the users of the tenant.",
+		},
+		[]string{"tenant"},
+	),
 }
 
 // StartMetrics listens and servers the HTTP metrics server in a new goroutine.
 // The server is then returned and can be stopped using Close().
+// Metrics are served over TLS when tlsConfig is not nil, opts select which metrics are exported and how
+// they are protected, all of them are exported without authentication when opts is nil.
 func StartMetrics(
 	updateInterval time.Duration,
 	addr string,
+	opts *MetricsOptions,
+	tlsConfig *tls.Config,
 	l logger.Logger,
 	uptimeCounter func() float64,
 	computeDBSizes func() map[string]float64,
@@ -166,16 +233,27 @@ func StartMetrics(
 	}()
 
 	mux := http.NewServeMux()
-	mux.Handle("/metrics", corsHandler(promhttp.Handler()))
-	mux.Handle("/debug/vars", corsHandler(expvar.Handler()))
+	metricsHandler := promhttp.InstrumentMetricHandler(
+		prometheus.DefaultRegisterer,
+		promhttp.HandlerFor(newMetricsGatherer(prometheus.DefaultGatherer, opts), promhttp.HandlerOpts{}),
+	)
+
+	mux.Handle("/metrics", corsHandler(metricsAuthHandler(opts, metricsHandler)))
+	mux.Handle("/debug/vars", corsHandler(metricsAuthHandler(opts, expvar.Handler())))
 	mux.HandleFunc("/initz", corsHandlerFunc(ImmudbHealthHandlerFunc()))
 	mux.HandleFunc("/readyz", corsHandlerFunc(ImmudbHealthHandlerFunc()))
 	mux.HandleFunc("/livez", corsHandlerFunc(ImmudbHealthHandlerFunc()))
 	mux.HandleFunc("/version", corsHandlerFunc(ImmudbVersionHandlerFunc))
-	server := &http.Server{Addr: addr, Handler: mux}
+	server := &http.Server{Addr: addr, Handler: mux, TLSConfig: tlsConfig}
 
 	go func() {
-		if err := server.ListenAndServe(); err != nil {
+		var err error
+		if tlsConfig != nil {
+			err = server.ListenAndServeTLS("", "")
+		} else {
+			err = server.ListenAndServe()
+		}
+		if err != nil {
 			if err == http.ErrServerClosed {
 				l.Debugf("Metrics http server closed")
 			} else {
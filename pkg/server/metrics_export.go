@@ -0,0 +1,248 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+var ErrMetricsTLSNotConfigured = errors.New("mutual TLS on the metrics server requires the server certificate to be configured")
+
+// metricsGroupPrefixes maps the prefixes of metric names to their group, metrics not matching any prefix
+// belong to the server group
+var metricsGroupPrefixes = []struct {
+	prefix string
+	group  string
+}{
+	{"immudb_btree_", MetricsGroupIndex},
+	{"immudb_last_indexed_trx_id", MetricsGroupIndex},
+	{"immudb_commit_", MetricsGroupStore},
+	{"immudb_last_committed_trx_id", MetricsGroupStore},
+	{"immudb_scrub", MetricsGroupStore},
+	{"immudb_last_scrub_", MetricsGroupStore},
+	{"immudb_key_size_", MetricsGroupStore},
+	{"immudb_value_size_", MetricsGroupStore},
+	{"immudb_multiapp_", MetricsGroupStore},
+	{"immudb_remoteapp_", MetricsGroupStore},
+	{"immudb_replication_", MetricsGroupReplication},
+	{"immudb_sql_", MetricsGroupSQL},
+	{"go_", MetricsGroupRuntime},
+	{"process_", MetricsGroupRuntime},
+	{"promhttp_", MetricsGroupRuntime},
+}
+
+// databaseLabels are the labels identifying the database, or a structure of it, a series belongs to
+var databaseLabels = map[string]bool{
+	"db": true,
+	"id": true,
+}
+
+func metricsGroup(name string) string {
+	for _, p := range metricsGroupPrefixes {
+		if strings.HasPrefix(name, p.prefix) {
+			return p.group
+		}
+	}
+	return MetricsGroupServer
+}
+
+// metricsGatherer exports the metrics of the selected groups, optionally summing the series of all databases
+type metricsGatherer struct {
+	gatherer           prometheus.Gatherer
+	groups             map[string]bool
+	aggregateDatabases bool
+}
+
+func newMetricsGatherer(gatherer prometheus.Gatherer, opts *MetricsOptions) prometheus.Gatherer {
+	if opts == nil || (len(opts.Groups) == 0 && !opts.AggregateDatabases) {
+		return gatherer
+	}
+
+	g := &metricsGatherer{
+		gatherer:           gatherer,
+		aggregateDatabases: opts.AggregateDatabases,
+	}
+
+	if len(opts.Groups) > 0 {
+		g.groups = make(map[string]bool, len(opts.Groups))
+		for _, group := range opts.Groups {
+			g.groups[group] = true
+		}
+	}
+
+	return g
+}
+
+func (g *metricsGatherer) Gather() ([]*dto.MetricFamily, error) {
+	mfs, err := g.gatherer.Gather()
+
+	filtered := make([]*dto.MetricFamily, 0, len(mfs))
+
+	for _, mf := range mfs {
+		if g.groups != nil && !g.groups[metricsGroup(mf.GetName())] {
+			continue
+		}
+
+		if g.aggregateDatabases {
+			mf = aggregateDatabases(mf)
+		}
+
+		filtered = append(filtered, mf)
+	}
+
+	return filtered, err
+}
+
+// aggregateDatabases sums the series of mf differing only by their database labels. Histograms are summed bucket
+// by bucket while summaries lose their quantiles, as they can not be summed
+func aggregateDatabases(mf *dto.MetricFamily) *dto.MetricFamily {
+	aggregated := &dto.MetricFamily{
+		Name: mf.Name,
+		Help: mf.Help,
+		Type: mf.Type,
+	}
+
+	byLabels := make(map[string]*dto.Metric)
+
+	for _, m := range mf.Metric {
+		var labels []*dto.LabelPair
+		var key strings.Builder
+
+		for _, l := range m.Label {
+			if databaseLabels[l.GetName()] {
+				continue
+			}
+
+			labels = append(labels, l)
+			fmt.Fprintf(&key, "%s=%q,", l.GetName(), l.GetValue())
+		}
+
+		agg, ok := byLabels[key.String()]
+		if !ok {
+			agg = proto.Clone(m).(*dto.Metric)
+			agg.Label = labels
+			agg.TimestampMs = nil
+			if agg.Summary != nil {
+				agg.Summary.Quantile = nil
+			}
+
+			byLabels[key.String()] = agg
+			aggregated.Metric = append(aggregated.Metric, agg)
+			continue
+		}
+
+		sumMetrics(agg, m)
+	}
+
+	sort.SliceStable(aggregated.Metric, func(i, j int) bool {
+		return labelsKey(aggregated.Metric[i]) < labelsKey(aggregated.Metric[j])
+	})
+
+	return aggregated
+}
+
+func sumMetrics(agg, m *dto.Metric) {
+	switch {
+	case agg.Counter != nil && m.Counter != nil:
+		agg.Counter.Value = proto.Float64(agg.Counter.GetValue() + m.Counter.GetValue())
+	case agg.Gauge != nil && m.Gauge != nil:
+		agg.Gauge.Value = proto.Float64(agg.Gauge.GetValue() + m.Gauge.GetValue())
+	case agg.Untyped != nil && m.Untyped != nil:
+		agg.Untyped.Value = proto.Float64(agg.Untyped.GetValue() + m.Untyped.GetValue())
+	case agg.Summary != nil && m.Summary != nil:
+		agg.Summary.SampleCount = proto.Uint64(agg.Summary.GetSampleCount() + m.Summary.GetSampleCount())
+		agg.Summary.SampleSum = proto.Float64(agg.Summary.GetSampleSum() + m.Summary.GetSampleSum())
+	case agg.Histogram != nil && m.Histogram != nil:
+		agg.Histogram.SampleCount = proto.Uint64(agg.Histogram.GetSampleCount() + m.Histogram.GetSampleCount())
+		agg.Histogram.SampleSum = proto.Float64(agg.Histogram.GetSampleSum() + m.Histogram.GetSampleSum())
+
+		// series of the same histogram share their buckets
+		for i, b := range agg.Histogram.Bucket {
+			if i < len(m.Histogram.Bucket) && b.GetUpperBound() == m.Histogram.Bucket[i].GetUpperBound() {
+				b.CumulativeCount = proto.Uint64(b.GetCumulativeCount() + m.Histogram.Bucket[i].GetCumulativeCount())
+			}
+		}
+	}
+}
+
+func labelsKey(m *dto.Metric) string {
+	var key strings.Builder
+	for _, l := range m.Label {
+		fmt.Fprintf(&key, "%s=%q,", l.GetName(), l.GetValue())
+	}
+	return key.String()
+}
+
+// metricsAuthHandler requires the credentials in opts through basic auth, when a username is configured
+func metricsAuthHandler(opts *MetricsOptions, handler http.Handler) http.Handler {
+	if opts == nil || opts.Username == "" {
+		return handler
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, password, ok := r.BasicAuth()
+		if !ok ||
+			subtle.ConstantTimeCompare([]byte(username), []byte(opts.Username)) != 1 ||
+			subtle.ConstantTimeCompare([]byte(password), []byte(opts.Password)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Basic realm="immudb metrics"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// metricsTLSConfig returns the TLS configuration of the metrics server, nil when mutual TLS is not enabled.
+// The server certificate is the one of the gRPC server
+func metricsTLSConfig(opts *MetricsOptions, serverTLSConfig *tls.Config) (*tls.Config, error) {
+	if opts == nil || opts.ClientCAFile == "" {
+		return nil, nil
+	}
+
+	if !hasCertificate(serverTLSConfig) {
+		return nil, ErrMetricsTLSNotConfigured
+	}
+
+	bs, err := ioutil.ReadFile(opts.ClientCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read metrics client ca cert: %v", err)
+	}
+
+	certPool := x509.NewCertPool()
+	if !certPool.AppendCertsFromPEM(bs) {
+		return nil, fmt.Errorf("failed to append metrics client certs from %s", opts.ClientCAFile)
+	}
+
+	c := serverTLSConfig.Clone()
+	c.ClientCAs = certPool
+	c.ClientAuth = tls.RequireAndVerifyClientCert
+
+	return c, nil
+}
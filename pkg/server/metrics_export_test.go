@@ -0,0 +1,191 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/require"
+)
+
+func testMetricsRegistry() *prometheus.Registry {
+	reg := prometheus.NewRegistry()
+
+	dbSize := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "immudb_db_size_bytes"}, []string{"db"})
+	commitDuration := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "immudb_commit_stage_duration_seconds",
+		Buckets: []float64{1, 10},
+	}, []string{"db", "stage"})
+	cacheHit := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "immudb_btree_cache_hit"}, []string{"id"})
+
+	reg.MustRegister(dbSize, commitDuration, cacheHit)
+
+	dbSize.WithLabelValues("db1").Set(10)
+	dbSize.WithLabelValues("db2").Set(20)
+
+	commitDuration.WithLabelValues("db1", "values").Observe(0.5)
+	commitDuration.WithLabelValues("db2", "values").Observe(5)
+	commitDuration.WithLabelValues("db2", "commit_log").Observe(50)
+
+	cacheHit.WithLabelValues("data/db1/index").Add(3)
+
+	return reg
+}
+
+func gatheredFamilies(t *testing.T, g prometheus.Gatherer) map[string]*dto.MetricFamily {
+	mfs, err := g.Gather()
+	require.NoError(t, err)
+
+	byName := make(map[string]*dto.MetricFamily, len(mfs))
+	for _, mf := range mfs {
+		byName[mf.GetName()] = mf
+	}
+	return byName
+}
+
+func TestMetricsGroup(t *testing.T) {
+	require.Equal(t, MetricsGroupIndex, metricsGroup("immudb_btree_depth"))
+	require.Equal(t, MetricsGroupIndex, metricsGroup("immudb_last_indexed_trx_id"))
+	require.Equal(t, MetricsGroupStore, metricsGroup("immudb_commit_sync_batch_size"))
+	require.Equal(t, MetricsGroupStore, metricsGroup("immudb_scrubbed_txs_total"))
+	require.Equal(t, MetricsGroupReplication, metricsGroup("immudb_replication_failures_total"))
+	require.Equal(t, MetricsGroupSQL, metricsGroup("immudb_sql_statement_duration_seconds"))
+	require.Equal(t, MetricsGroupRuntime, metricsGroup("go_goroutines"))
+	require.Equal(t, MetricsGroupServer, metricsGroup("immudb_db_size_bytes"))
+}
+
+func TestMetricsGatherer(t *testing.T) {
+	reg := testMetricsRegistry()
+
+	t.Run("unfiltered", func(t *testing.T) {
+		require.Equal(t, prometheus.Gatherer(reg), newMetricsGatherer(reg, nil))
+		require.Equal(t, prometheus.Gatherer(reg), newMetricsGatherer(reg, &MetricsOptions{}))
+	})
+
+	t.Run("groups", func(t *testing.T) {
+		mfs := gatheredFamilies(t, newMetricsGatherer(reg, (&MetricsOptions{}).WithGroups([]string{MetricsGroupServer, MetricsGroupIndex})))
+		require.Len(t, mfs, 2)
+		require.Contains(t, mfs, "immudb_db_size_bytes")
+		require.Contains(t, mfs, "immudb_btree_cache_hit")
+	})
+
+	t.Run("aggregated databases", func(t *testing.T) {
+		mfs := gatheredFamilies(t, newMetricsGatherer(reg, (&MetricsOptions{}).WithAggregateDatabases(true)))
+		require.Len(t, mfs, 3)
+
+		dbSize := mfs["immudb_db_size_bytes"]
+		require.Len(t, dbSize.Metric, 1)
+		require.Empty(t, dbSize.Metric[0].Label)
+		require.Equal(t, 30.0, dbSize.Metric[0].Gauge.GetValue())
+
+		cacheHit := mfs["immudb_btree_cache_hit"]
+		require.Len(t, cacheHit.Metric, 1)
+		require.Equal(t, 3.0, cacheHit.Metric[0].Counter.GetValue())
+
+		// stages are kept apart while databases are summed
+		commitDuration := mfs["immudb_commit_stage_duration_seconds"]
+		require.Len(t, commitDuration.Metric, 2)
+
+		for _, m := range commitDuration.Metric {
+			require.Len(t, m.Label, 1)
+			require.Equal(t, "stage", m.Label[0].GetName())
+
+			h := m.Histogram
+
+			switch m.Label[0].GetValue() {
+			case "values":
+				require.Equal(t, uint64(2), h.GetSampleCount())
+				require.Equal(t, 5.5, h.GetSampleSum())
+				require.Equal(t, uint64(1), h.Bucket[0].GetCumulativeCount())
+				require.Equal(t, uint64(2), h.Bucket[1].GetCumulativeCount())
+			case "commit_log":
+				require.Equal(t, uint64(1), h.GetSampleCount())
+				require.Equal(t, uint64(0), h.Bucket[1].GetCumulativeCount())
+			default:
+				require.Fail(t, "unexpected stage")
+			}
+		}
+
+		// series of the registry are left untouched
+		mfs = gatheredFamilies(t, reg)
+		require.Len(t, mfs["immudb_db_size_bytes"].Metric, 2)
+	})
+}
+
+func TestMetricsAuthHandler(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	require.NotNil(t, metricsAuthHandler(nil, next))
+
+	h := metricsAuthHandler((&MetricsOptions{}).WithUsername("prometheus").WithPassword("secret"), next)
+
+	for _, c := range []struct {
+		username string
+		password string
+		code     int
+	}{
+		{"", "", http.StatusUnauthorized},
+		{"prometheus", "wrong", http.StatusUnauthorized},
+		{"other", "secret", http.StatusUnauthorized},
+		{"prometheus", "secret", http.StatusOK},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		if c.username != "" {
+			req.SetBasicAuth(c.username, c.password)
+		}
+
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+
+		require.Equal(t, c.code, rec.Code)
+		if c.code == http.StatusUnauthorized {
+			require.NotEmpty(t, rec.Header().Get("WWW-Authenticate"))
+		}
+	}
+}
+
+func TestMetricsTLSConfig(t *testing.T) {
+	c, err := metricsTLSConfig(nil, nil)
+	require.NoError(t, err)
+	require.Nil(t, c)
+
+	opts := (&MetricsOptions{}).WithClientCAFile("./../../test/mtls_certs/ca-chain.cert.pem")
+
+	_, err = metricsTLSConfig(opts, nil)
+	require.ErrorIs(t, err, ErrMetricsTLSNotConfigured)
+
+	cert, err := tls.LoadX509KeyPair("./../../test/mtls_certs/ca.cert.pem", "./../../test/mtls_certs/ca.key.pem")
+	require.NoError(t, err)
+
+	serverTLSConfig := &tls.Config{Certificates: []tls.Certificate{cert}}
+
+	c, err = metricsTLSConfig(opts, serverTLSConfig)
+	require.NoError(t, err)
+	require.Equal(t, tls.RequireAndVerifyClientCert, c.ClientAuth)
+	require.NotNil(t, c.ClientCAs)
+	require.Nil(t, serverTLSConfig.ClientCAs)
+
+	_, err = metricsTLSConfig((&MetricsOptions{}).WithClientCAFile("./missing.pem"), serverTLSConfig)
+	require.Error(t, err)
+}
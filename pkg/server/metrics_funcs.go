@@ -122,3 +122,23 @@ func (s *ImmuServer) metricFuncComputeDBEntries() (nbEntriesPerDB map[string]flo
 
 	return
 }
+
+// metricFuncComputeDBDiskQuotaExceeded reports the databases exceeding their disk usage quota, which reject writes
+func (s *ImmuServer) metricFuncComputeDBDiskQuotaExceeded() map[string]float64 {
+	exceeded := make(map[string]float64)
+
+	if s.dbList == nil {
+		return exceeded
+	}
+
+	for i := 0; i < s.dbList.Length(); i++ {
+		db := s.dbList.GetByIndex(int64(i))
+
+		exceeded[db.GetName()] = 0
+		if db.DiskQuotaExceeded() {
+			exceeded[db.GetName()] = 1
+		}
+	}
+
+	return exceeded
+}
@@ -27,6 +27,7 @@ import (
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc/peer"
@@ -36,6 +37,8 @@ func TestStartMetrics(t *testing.T) {
 	server := StartMetrics(
 		100*time.Millisecond,
 		"0.0.0.0:9999",
+		nil,
+		nil,
 		&mockLogger{},
 		func() float64 { return 0 },
 		func() map[string]float64 { return make(map[string]float64) },
@@ -55,6 +58,8 @@ func TestStartMetricsFail(t *testing.T) {
 	server := StartMetrics(
 		100*time.Millisecond,
 		"999.999.999.999:9999",
+		nil,
+		nil,
 		&mockLogger{},
 		func() float64 { return 0 },
 		func() map[string]float64 { return make(map[string]float64) },
@@ -141,6 +146,43 @@ func TestMetricsCollection_UpdateDBMetrics(t *testing.T) {
 	assert.IsType(t, MetricsCollection{}, mc)
 }
 
+func TestMetricsCollection_UpdateTenantMetrics(t *testing.T) {
+	newGaugeVec := func(name string) *prometheus.GaugeVec {
+		return prometheus.NewGaugeVec(prometheus.GaugeOpts{Namespace: metricsNamespace, Name: name}, []string{"tenant"})
+	}
+
+	mc := MetricsCollection{
+		TenantDatabasesGauges: newGaugeVec("tenant_number_of_databases"),
+		TenantDiskUsageGauges: newGaugeVec("tenant_disk_usage_bytes"),
+		TenantSessionsGauges:  newGaugeVec("tenant_number_of_sessions"),
+	}
+
+	mc.WithComputeTenantUsage(func() map[string]TenantUsage {
+		return map[string]TenantUsage{"acme": {Databases: 2, DiskUsage: 1024, Sessions: 3}}
+	})
+
+	mc.UpdateDBMetrics()
+
+	assert.Equal(t, float64(2), testutil.ToFloat64(mc.TenantDatabasesGauges.WithLabelValues("acme")))
+	assert.Equal(t, float64(1024), testutil.ToFloat64(mc.TenantDiskUsageGauges.WithLabelValues("acme")))
+	assert.Equal(t, float64(3), testutil.ToFloat64(mc.TenantSessionsGauges.WithLabelValues("acme")))
+}
+
+func TestMetricsCollection_UpdateDBDiskQuotaMetrics(t *testing.T) {
+	mc := MetricsCollection{
+		DBDiskQuotaExceededGauges: prometheus.NewGaugeVec(prometheus.GaugeOpts{Namespace: metricsNamespace, Name: "db_disk_quota_exceeded"}, []string{"db"}),
+	}
+
+	mc.WithComputeDBDiskQuotaExceeded(func() map[string]float64 {
+		return map[string]float64{"db1": 1, "db2": 0}
+	})
+
+	mc.UpdateDBMetrics()
+
+	assert.Equal(t, float64(1), testutil.ToFloat64(mc.DBDiskQuotaExceededGauges.WithLabelValues("db1")))
+	assert.Equal(t, float64(0), testutil.ToFloat64(mc.DBDiskQuotaExceededGauges.WithLabelValues("db2")))
+}
+
 func TestImmudbHealthHandlerFunc(t *testing.T) {
 	req, err := http.NewRequest("GET", "/initz", nil)
 	require.NoError(t, err)
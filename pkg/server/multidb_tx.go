@@ -0,0 +1,370 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/auth"
+	"github.com/codenotary/immudb/pkg/database"
+	"github.com/rs/xid"
+	"google.golang.org/protobuf/proto"
+)
+
+// multiDBTxRecord is kept in the systemdb for every multi-database transaction until all its parts are committed.
+// Once saved, the transaction is considered committed and its pending parts are committed on recovery.
+// Pending parts are also retried periodically while the server is running.
+// PreparedAt holds the last tx committed into each database when its part was prepared,
+// keys written after it are not overwritten when the part is recovered.
+type multiDBTxRecord struct {
+	ID         string            `json:"id"`
+	Request    []byte            `json:"request"`
+	PreparedAt map[string]uint64 `json:"preparedAt,omitempty"`
+}
+
+func multiDBTxKey(txID string) []byte {
+	key := make([]byte, 1+len(txID))
+	key[0] = KeyPrefixMultiDBTx
+	copy(key[1:], []byte(txID))
+	return key
+}
+
+// MultiDatabaseSet atomically writes into several databases of this server.
+// Each database commits its own tx, including an entry which records the multi-database transaction,
+// so the root of every database taking part is advanced.
+//
+// Parts are not durably prepared: preparing a part only validates it and stages it in memory.
+// The record of the transaction saved into the systemdb is the commit point, parts not committed
+// are prepared again from the record and committed in background or when the server is started again.
+// Recovered parts leave out the keys written into their database after they were first prepared.
+func (s *ImmuServer) MultiDatabaseSet(ctx context.Context, req *schema.MultiDatabaseSetRequest) (*schema.MultiDatabaseTxHeaders, error) {
+	if s.Options.GetMaintenance() {
+		return nil, ErrNotAllowedInMaintenanceMode
+	}
+
+	if req == nil || len(req.Writes) < 2 {
+		return nil, ErrIllegalArguments
+	}
+
+	databases := make([]string, len(req.Writes))
+
+	for i, w := range req.Writes {
+		for _, db := range databases[:i] {
+			if db == w.Database {
+				return nil, ErrIllegalArguments
+			}
+		}
+
+		databases[i] = w.Database
+	}
+
+	err := s.checkMultiDBTxPermissions(ctx, databases)
+	if err != nil {
+		return nil, err
+	}
+
+	s.multiDBTxMutex.Lock()
+	defer s.multiDBTxMutex.Unlock()
+
+	txID := xid.New().String()
+
+	prepared, err := s.prepareMultiDBTx(txID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	serializedReq, err := proto.Marshal(req)
+	if err != nil {
+		cancelMultiDBTx(prepared)
+		return nil, err
+	}
+
+	record := &multiDBTxRecord{
+		ID:         txID,
+		Request:    serializedReq,
+		PreparedAt: make(map[string]uint64, len(prepared)),
+	}
+
+	for i, p := range prepared {
+		record.PreparedAt[databases[i]] = p.PreparedAt()
+	}
+
+	// the transaction is committed once its record is saved
+	err = s.saveMultiDBTxRecord(record)
+	if err != nil {
+		cancelMultiDBTx(prepared)
+		return nil, err
+	}
+
+	hdrs := &schema.MultiDatabaseTxHeaders{
+		TransactionId: txID,
+		Headers:       make([]*schema.DatabaseTxHeader, len(prepared)),
+	}
+
+	for i, p := range prepared {
+		hdr, err := p.Commit()
+		if err != nil {
+			cancelMultiDBTx(prepared[i+1:])
+			return nil, fmt.Errorf("multi-database transaction '%s' will be completed in background: %w", txID, err)
+		}
+
+		hdrs.Headers[i] = &schema.DatabaseTxHeader{Database: databases[i], Header: hdr}
+	}
+
+	err = s.deleteMultiDBTxRecord(txID)
+	if err != nil {
+		s.Logger.Warningf("Multi-database transaction '%s' could not be marked as completed: %v", txID, err)
+	}
+
+	return hdrs, nil
+}
+
+func (s *ImmuServer) checkMultiDBTxPermissions(ctx context.Context, databases []string) error {
+	if !s.Options.GetAuth() {
+		return ErrAuthMustBeEnabled
+	}
+
+	_, user, err := s.getLoggedInUserdataFromCtx(ctx)
+	if err != nil {
+		return fmt.Errorf("could not get loggedin user data")
+	}
+
+	for _, db := range databases {
+		if db == SystemDBName {
+			return ErrPermissionDenied
+		}
+
+		if !user.IsSysAdmin && !auth.HasPermissionForMethod(user.WhichPermission(db), "MultiDatabaseSet") {
+			return ErrPermissionDenied
+		}
+
+		err = s.checkDatabaseNetworkRules(ctx, "MultiDatabaseSet", db)
+		if err != nil {
+			return err
+		}
+
+		err = s.checkTenantDiskQuota(db)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// prepareMultiDBTx prepares the part of the transaction to be committed into each database.
+// Nothing is written if any of them can not be prepared.
+func (s *ImmuServer) prepareMultiDBTx(txID string, req *schema.MultiDatabaseSetRequest) ([]*database.PreparedTx, error) {
+	databases := make([]string, len(req.Writes))
+	for i, w := range req.Writes {
+		databases[i] = w.Database
+	}
+
+	prepared := make([]*database.PreparedTx, 0, len(req.Writes))
+
+	for _, w := range req.Writes {
+		db, err := s.dbList.GetByName(w.Database)
+		if err != nil {
+			cancelMultiDBTx(prepared)
+			return nil, err
+		}
+
+		p, err := db.PrepareMultiDBTx(txID, databases, &schema.SetRequest{KVs: w.KVs, NoWait: req.NoWait})
+		if err != nil {
+			cancelMultiDBTx(prepared)
+			return nil, err
+		}
+
+		prepared = append(prepared, p)
+	}
+
+	return prepared, nil
+}
+
+func cancelMultiDBTx(prepared []*database.PreparedTx) {
+	for _, p := range prepared {
+		p.Cancel()
+	}
+}
+
+func (s *ImmuServer) saveMultiDBTxRecord(record *multiDBTxRecord) error {
+	serializedRecord, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.sysDB.Set(&schema.SetRequest{KVs: []*schema.KeyValue{{Key: multiDBTxKey(record.ID), Value: serializedRecord}}})
+
+	return err
+}
+
+// deleteMultiDBTxRecord marks the multi-database transaction as completed, records are only kept for pending ones
+func (s *ImmuServer) deleteMultiDBTxRecord(txID string) error {
+	_, err := s.sysDB.Delete(&schema.DeleteKeysRequest{Keys: [][]byte{multiDBTxKey(txID)}})
+	return err
+}
+
+// startMultiDBTxRetry periodically commits the pending parts of multi-database transactions,
+// so parts which could not be committed are completed without waiting for the server to be restarted
+func (s *ImmuServer) startMultiDBTxRetry() {
+	if s.Options.MultiDBTxRetryInterval <= 0 {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	s.multiDBTxRetryCancel = cancel
+	s.multiDBTxRetryDone = done
+
+	go func() {
+		defer close(done)
+
+		for {
+			timer := time.NewTimer(s.Options.MultiDBTxRetryInterval)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+
+			s.retryMultiDBTxs()
+		}
+	}()
+}
+
+func (s *ImmuServer) stopMultiDBTxRetry() {
+	if s.multiDBTxRetryCancel == nil {
+		return
+	}
+
+	s.multiDBTxRetryCancel()
+	<-s.multiDBTxRetryDone
+
+	s.multiDBTxRetryCancel = nil
+	s.multiDBTxRetryDone = nil
+}
+
+func (s *ImmuServer) retryMultiDBTxs() {
+	// records of ongoing transactions are not pending while the lock is held
+	s.multiDBTxMutex.Lock()
+	defer s.multiDBTxMutex.Unlock()
+
+	err := s.recoverMultiDBTxs()
+	if err != nil {
+		s.Logger.Warningf("Unable to retry multi-database transactions. Reason: %v", err)
+	}
+}
+
+// recoverMultiDBTxs commits the pending parts of the multi-database transactions
+// which were not completed before the server was stopped or when they were committed.
+// Transactions which can not be recovered are logged and their records kept, so they're retried later
+func (s *ImmuServer) recoverMultiDBTxs() error {
+	var seekKey []byte
+
+	for {
+		entries, err := s.sysDB.Scan(context.Background(), &schema.ScanRequest{
+			Prefix:  []byte{KeyPrefixMultiDBTx},
+			SeekKey: seekKey,
+			Limit:   database.MaxKeyScanLimit,
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, e := range entries.Entries {
+			var record multiDBTxRecord
+
+			err = json.Unmarshal(e.Value, &record)
+			if err != nil {
+				s.Logger.Errorf("Multi-database transaction record '%s' could not be read: %v", e.Key, err)
+				continue
+			}
+
+			s.Logger.Infof("Recovering multi-database transaction '%s'...", record.ID)
+
+			err = s.recoverMultiDBTx(&record)
+			if err != nil {
+				s.Logger.Errorf("Multi-database transaction '%s' could not be recovered: %v", record.ID, err)
+				continue
+			}
+
+			s.Logger.Infof("Multi-database transaction '%s' successfully recovered", record.ID)
+		}
+
+		if len(entries.Entries) < database.MaxKeyScanLimit {
+			return nil
+		}
+
+		seekKey = entries.Entries[len(entries.Entries)-1].Key
+	}
+}
+
+func (s *ImmuServer) recoverMultiDBTx(record *multiDBTxRecord) error {
+	var req schema.MultiDatabaseSetRequest
+
+	err := proto.Unmarshal(record.Request, &req)
+	if err != nil {
+		return err
+	}
+
+	databases := make([]string, len(req.Writes))
+	for i, w := range req.Writes {
+		databases[i] = w.Database
+	}
+
+	for _, w := range req.Writes {
+		db, err := s.dbList.GetByName(w.Database)
+		if err != nil {
+			return err
+		}
+
+		committed, err := db.MultiDBTxCommitted(record.ID)
+		if err != nil {
+			return err
+		}
+
+		if committed {
+			continue
+		}
+
+		var p *database.PreparedTx
+
+		preparedAt, ok := record.PreparedAt[w.Database]
+		if ok {
+			p, err = db.RecoverMultiDBTx(record.ID, databases, &schema.SetRequest{KVs: w.KVs}, preparedAt)
+		} else {
+			// records saved without the state of each database are replayed as they are
+			p, err = db.PrepareMultiDBTx(record.ID, databases, &schema.SetRequest{KVs: w.KVs})
+		}
+		if err != nil {
+			return err
+		}
+
+		_, err = p.Commit()
+		if err != nil {
+			return err
+		}
+	}
+
+	return s.deleteMultiDBTxRecord(record.ID)
+}
@@ -0,0 +1,413 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/auth"
+	"github.com/codenotary/immudb/pkg/database"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestMultiDatabaseSet(t *testing.T) {
+	s, ctx, closer := newTestServer(t, nil)
+	defer closer()
+	// the server is restarted by the subtests
+	defer func() { s.CloseDatabases() }()
+
+	serverOptions := s.Options
+
+	_, err := s.CreateDatabaseWith(ctx, &schema.DatabaseSettings{DatabaseName: "catalog"})
+	require.NoError(t, err)
+
+	_, err = s.CreateDatabaseWith(ctx, &schema.DatabaseSettings{DatabaseName: "tenant1"})
+	require.NoError(t, err)
+
+	write := func(database string, key, value string) *schema.DatabaseKVs {
+		return &schema.DatabaseKVs{
+			Database: database,
+			KVs:      []*schema.KeyValue{{Key: []byte(key), Value: []byte(value)}},
+		}
+	}
+
+	checkValue := func(database string, key, value string) {
+		db, err := s.dbList.GetByName(database)
+		require.NoError(t, err)
+
+		entry, err := db.Get(context.Background(), &schema.KeyRequest{Key: []byte(key)})
+		require.NoError(t, err)
+		require.Equal(t, []byte(value), entry.Value)
+	}
+
+	checkMissing := func(database string, key string) {
+		db, err := s.dbList.GetByName(database)
+		require.NoError(t, err)
+
+		_, err = db.Get(context.Background(), &schema.KeyRequest{Key: []byte(key)})
+		require.ErrorIs(t, err, store.ErrKeyNotFound)
+	}
+
+	t.Run("invalid requests should fail", func(t *testing.T) {
+		_, err := s.MultiDatabaseSet(ctx, nil)
+		require.ErrorIs(t, err, ErrIllegalArguments)
+
+		_, err = s.MultiDatabaseSet(ctx, &schema.MultiDatabaseSetRequest{
+			Writes: []*schema.DatabaseKVs{write("catalog", "key", "value")},
+		})
+		require.ErrorIs(t, err, ErrIllegalArguments)
+
+		_, err = s.MultiDatabaseSet(ctx, &schema.MultiDatabaseSetRequest{
+			Writes: []*schema.DatabaseKVs{write("catalog", "key", "value"), write("catalog", "key1", "value1")},
+		})
+		require.ErrorIs(t, err, ErrIllegalArguments)
+
+		_, err = s.MultiDatabaseSet(ctx, &schema.MultiDatabaseSetRequest{
+			Writes: []*schema.DatabaseKVs{write("catalog", "key", "value"), write(SystemDBName, "key", "value")},
+		})
+		require.ErrorIs(t, err, ErrPermissionDenied)
+
+		_, err = s.MultiDatabaseSet(context.Background(), &schema.MultiDatabaseSetRequest{
+			Writes: []*schema.DatabaseKVs{write("catalog", "key", "value"), write("tenant1", "key", "value")},
+		})
+		require.Error(t, err)
+	})
+
+	t.Run("nothing should be written if any database fails", func(t *testing.T) {
+		_, err := s.MultiDatabaseSet(ctx, &schema.MultiDatabaseSetRequest{
+			Writes: []*schema.DatabaseKVs{write("catalog", "key", "value"), write("tenant1", "", "value")},
+		})
+		require.ErrorIs(t, err, database.ErrIllegalArguments)
+
+		_, err = s.MultiDatabaseSet(ctx, &schema.MultiDatabaseSetRequest{
+			Writes: []*schema.DatabaseKVs{write("catalog", "key", "value"), write("missing", "key", "value")},
+		})
+		require.Error(t, err)
+
+		checkMissing("catalog", "key")
+	})
+
+	t.Run("parts exceeding the limits of a tx should be rejected before being committed", func(t *testing.T) {
+		catalog, err := s.dbList.GetByName("catalog")
+		require.NoError(t, err)
+
+		tenant1, err := s.dbList.GetByName("tenant1")
+		require.NoError(t, err)
+
+		maxTxEntries := store.DefaultMaxTxEntries
+
+		kvs := make([]*schema.KeyValue, maxTxEntries)
+		for i := range kvs {
+			kvs[i] = &schema.KeyValue{Key: []byte(fmt.Sprintf("bulk%04d", i)), Value: []byte("value")}
+		}
+
+		catalogState, err := catalog.CurrentState()
+		require.NoError(t, err)
+
+		tenant1State, err := tenant1.CurrentState()
+		require.NoError(t, err)
+
+		// the entry recording the transaction is added to every part
+		_, err = s.MultiDatabaseSet(ctx, &schema.MultiDatabaseSetRequest{
+			Writes: []*schema.DatabaseKVs{
+				{Database: "catalog", KVs: kvs},
+				{Database: "tenant1", KVs: kvs},
+			},
+		})
+		require.ErrorIs(t, err, store.ErrorMaxTxEntriesLimitExceeded)
+
+		state, err := catalog.CurrentState()
+		require.NoError(t, err)
+		require.Equal(t, catalogState.TxId, state.TxId)
+
+		state, err = tenant1.CurrentState()
+		require.NoError(t, err)
+		require.Equal(t, tenant1State.TxId, state.TxId)
+
+		checkMissing("catalog", "bulk0000")
+
+		entries, err := s.sysDB.Scan(context.Background(), &schema.ScanRequest{Prefix: []byte{KeyPrefixMultiDBTx}})
+		require.NoError(t, err)
+		require.Empty(t, entries.Entries)
+
+		hdrs, err := s.MultiDatabaseSet(ctx, &schema.MultiDatabaseSetRequest{
+			Writes: []*schema.DatabaseKVs{
+				{Database: "catalog", KVs: kvs[:maxTxEntries-1]},
+				{Database: "tenant1", KVs: kvs[:maxTxEntries-1]},
+			},
+		})
+		require.NoError(t, err)
+		require.Len(t, hdrs.Headers, 2)
+		require.Equal(t, int32(maxTxEntries), hdrs.Headers[0].Header.Nentries)
+
+		checkValue("tenant1", fmt.Sprintf("bulk%04d", maxTxEntries-2), "value")
+	})
+
+	t.Run("all databases should be written", func(t *testing.T) {
+		hdrs, err := s.MultiDatabaseSet(ctx, &schema.MultiDatabaseSetRequest{
+			Writes: []*schema.DatabaseKVs{write("catalog", "tenant1", "active"), write("tenant1", "key", "value")},
+		})
+		require.NoError(t, err)
+		require.NotEmpty(t, hdrs.TransactionId)
+		require.Len(t, hdrs.Headers, 2)
+		require.Equal(t, "catalog", hdrs.Headers[0].Database)
+		require.Equal(t, "tenant1", hdrs.Headers[1].Database)
+
+		for _, hdr := range hdrs.Headers {
+			db, err := s.dbList.GetByName(hdr.Database)
+			require.NoError(t, err)
+
+			committed, err := db.MultiDBTxCommitted(hdrs.TransactionId)
+			require.NoError(t, err)
+			require.True(t, committed)
+
+			state, err := db.CurrentState()
+			require.NoError(t, err)
+			require.Equal(t, hdr.Header.Id, state.TxId)
+		}
+
+		checkValue("catalog", "tenant1", "active")
+		checkValue("tenant1", "key", "value")
+
+		// records are only kept for pending txs
+		_, err = s.sysDB.Get(context.Background(), &schema.KeyRequest{Key: multiDBTxKey(hdrs.TransactionId)})
+		require.ErrorIs(t, err, store.ErrKeyNotFound)
+	})
+
+	t.Run("users should have write permission on every database", func(t *testing.T) {
+		_, err := s.CreateUser(ctx, &schema.CreateUserRequest{
+			User:       []byte("user1"),
+			Password:   []byte("Pa$$w0rd1"),
+			Permission: auth.PermissionRW,
+			Database:   "tenant1",
+		})
+		require.NoError(t, err)
+
+		login := func() context.Context {
+			ulr, err := s.Login(context.Background(), &schema.LoginRequest{
+				User:     []byte("user1"),
+				Password: []byte("Pa$$w0rd1"),
+			})
+			require.NoError(t, err)
+
+			return metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", ulr.Token))
+		}
+
+		userCtx := login()
+
+		req := &schema.MultiDatabaseSetRequest{
+			Writes: []*schema.DatabaseKVs{write("catalog", "tenant1", "suspended"), write("tenant1", "key", "value1")},
+		}
+
+		_, err = s.MultiDatabaseSet(userCtx, req)
+		require.ErrorIs(t, err, ErrPermissionDenied)
+
+		_, err = s.ChangePermission(ctx, &schema.ChangePermissionRequest{
+			Action:     schema.PermissionAction_GRANT,
+			Username:   "user1",
+			Database:   "catalog",
+			Permission: auth.PermissionRW,
+		})
+		require.NoError(t, err)
+
+		_, err = s.MultiDatabaseSet(login(), req)
+		require.NoError(t, err)
+
+		checkValue("catalog", "tenant1", "suspended")
+		checkValue("tenant1", "key", "value1")
+	})
+
+	t.Run("pending txs should be completed on recovery", func(t *testing.T) {
+		req := &schema.MultiDatabaseSetRequest{
+			Writes: []*schema.DatabaseKVs{write("catalog", "tenant2", "active"), write("tenant1", "key", "value2")},
+		}
+
+		prepared, err := s.prepareMultiDBTx("pending", req)
+		require.NoError(t, err)
+
+		serializedReq, err := proto.Marshal(req)
+		require.NoError(t, err)
+
+		err = s.saveMultiDBTxRecord(&multiDBTxRecord{
+			ID:         "pending",
+			Request:    serializedReq,
+			PreparedAt: map[string]uint64{"catalog": prepared[0].PreparedAt(), "tenant1": prepared[1].PreparedAt()},
+		})
+		require.NoError(t, err)
+
+		// only the first database committed its part before the server was stopped
+		_, err = prepared[0].Commit()
+		require.NoError(t, err)
+
+		cancelMultiDBTx(prepared[1:])
+
+		catalog, err := s.dbList.GetByName("catalog")
+		require.NoError(t, err)
+
+		catalogState, err := catalog.CurrentState()
+		require.NoError(t, err)
+
+		checkValue("tenant1", "key", "value1")
+
+		err = s.CloseDatabases()
+		require.NoError(t, err)
+
+		s = DefaultServer().WithOptions(serverOptions).(*ImmuServer)
+
+		err = s.Initialize()
+		require.NoError(t, err)
+
+		checkValue("catalog", "tenant2", "active")
+		checkValue("tenant1", "key", "value2")
+
+		catalog, err = s.dbList.GetByName("catalog")
+		require.NoError(t, err)
+
+		state, err := catalog.CurrentState()
+		require.NoError(t, err)
+		require.Equal(t, catalogState.TxId, state.TxId)
+
+		_, err = s.sysDB.Get(context.Background(), &schema.KeyRequest{Key: multiDBTxKey("pending")})
+		require.ErrorIs(t, err, store.ErrKeyNotFound)
+	})
+
+	t.Run("keys written before pending txs are recovered should not be overwritten", func(t *testing.T) {
+		tenant1, err := s.dbList.GetByName("tenant1")
+		require.NoError(t, err)
+
+		_, err = tenant1.Set(&schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte("key6"), Value: []byte("value1")}}})
+		require.NoError(t, err)
+
+		req := &schema.MultiDatabaseSetRequest{
+			Writes: []*schema.DatabaseKVs{
+				write("catalog", "tenant6", "suspended"),
+				{Database: "tenant1", KVs: []*schema.KeyValue{
+					{Key: []byte("key6"), Value: []byte("value2")},
+					{Key: []byte("key7"), Value: []byte("value2")},
+				}},
+			},
+		}
+
+		prepared, err := s.prepareMultiDBTx("overwriting", req)
+		require.NoError(t, err)
+
+		serializedReq, err := proto.Marshal(req)
+		require.NoError(t, err)
+
+		err = s.saveMultiDBTxRecord(&multiDBTxRecord{
+			ID:         "overwriting",
+			Request:    serializedReq,
+			PreparedAt: map[string]uint64{"catalog": prepared[0].PreparedAt(), "tenant1": prepared[1].PreparedAt()},
+		})
+		require.NoError(t, err)
+
+		_, err = prepared[0].Commit()
+		require.NoError(t, err)
+
+		// tenant1 failed to commit its part
+		cancelMultiDBTx(prepared[1:])
+
+		// written once the transaction failed and before it's recovered
+		_, err = tenant1.Set(&schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte("key6"), Value: []byte("value3")}}})
+		require.NoError(t, err)
+
+		s.retryMultiDBTxs()
+
+		checkValue("catalog", "tenant6", "suspended")
+		checkValue("tenant1", "key6", "value3")
+		checkValue("tenant1", "key7", "value2")
+
+		committed, err := tenant1.MultiDBTxCommitted("overwriting")
+		require.NoError(t, err)
+		require.True(t, committed)
+
+		_, err = s.sysDB.Get(context.Background(), &schema.KeyRequest{Key: multiDBTxKey("overwriting")})
+		require.ErrorIs(t, err, store.ErrKeyNotFound)
+	})
+
+	t.Run("pending txs should be retried in background", func(t *testing.T) {
+		serializedReq, err := proto.Marshal(&schema.MultiDatabaseSetRequest{
+			Writes: []*schema.DatabaseKVs{write("catalog", "tenant5", "active"), write("tenant1", "key", "value5")},
+		})
+		require.NoError(t, err)
+
+		err = s.saveMultiDBTxRecord(&multiDBTxRecord{ID: "retried", Request: serializedReq})
+		require.NoError(t, err)
+
+		s.retryMultiDBTxs()
+
+		checkValue("catalog", "tenant5", "active")
+		checkValue("tenant1", "key", "value5")
+
+		_, err = s.sysDB.Get(context.Background(), &schema.KeyRequest{Key: multiDBTxKey("retried")})
+		require.ErrorIs(t, err, store.ErrKeyNotFound)
+	})
+
+	t.Run("txs which can not be recovered should be kept without preventing the server from starting", func(t *testing.T) {
+		unrecoverableReq, err := proto.Marshal(&schema.MultiDatabaseSetRequest{
+			Writes: []*schema.DatabaseKVs{write("catalog", "tenant3", "active"), write("missingdb", "key", "value")},
+		})
+		require.NoError(t, err)
+
+		// more records than read at once are pending
+		for i := 0; i < database.MaxKeyScanLimit; i += 500 {
+			var kvs []*schema.KeyValue
+
+			for j := i; j < i+500; j++ {
+				record, err := json.Marshal(&multiDBTxRecord{ID: fmt.Sprintf("unrecoverable%04d", j), Request: unrecoverableReq})
+				require.NoError(t, err)
+
+				kvs = append(kvs, &schema.KeyValue{Key: multiDBTxKey(fmt.Sprintf("unrecoverable%04d", j)), Value: record})
+			}
+
+			_, err = s.sysDB.Set(&schema.SetRequest{KVs: kvs})
+			require.NoError(t, err)
+		}
+
+		serializedReq, err := proto.Marshal(&schema.MultiDatabaseSetRequest{
+			Writes: []*schema.DatabaseKVs{write("catalog", "tenant4", "active"), write("tenant1", "key", "value3")},
+		})
+		require.NoError(t, err)
+
+		err = s.saveMultiDBTxRecord(&multiDBTxRecord{ID: "zpending", Request: serializedReq})
+		require.NoError(t, err)
+
+		err = s.CloseDatabases()
+		require.NoError(t, err)
+
+		s = DefaultServer().WithOptions(serverOptions).(*ImmuServer)
+
+		err = s.Initialize()
+		require.NoError(t, err)
+
+		checkValue("catalog", "tenant4", "active")
+		checkValue("tenant1", "key", "value3")
+
+		_, err = s.sysDB.Get(context.Background(), &schema.KeyRequest{Key: multiDBTxKey("zpending")})
+		require.ErrorIs(t, err, store.ErrKeyNotFound)
+
+		_, err = s.sysDB.Get(context.Background(), &schema.KeyRequest{Key: multiDBTxKey("unrecoverable0000")})
+		require.NoError(t, err)
+	})
+}
@@ -0,0 +1,303 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/auth"
+	"github.com/codenotary/immudb/pkg/server/sessions"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// ErrClientAddressNotAllowed is returned to clients rejected by the network rules
+var ErrClientAddressNotAllowed = status.Error(codes.PermissionDenied, "client address is not allowed")
+
+// networkRules tells which clients are allowed to connect by their address
+type networkRules struct {
+	allowed   []*net.IPNet
+	denied    []*net.IPNet
+	databases map[string][]*net.IPNet
+}
+
+// ParseNetworks parses networks in CIDR notation, single addresses are accepted as well
+func ParseNetworks(networks []string) ([]*net.IPNet, error) {
+	var nets []*net.IPNet
+
+	for _, n := range networks {
+		n = strings.TrimSpace(n)
+		if n == "" {
+			continue
+		}
+
+		if !strings.Contains(n, "/") {
+			ip := net.ParseIP(n)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid network '%s'", n)
+			}
+
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip = ip.To4()
+				bits = 8 * net.IPv4len
+			}
+
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, ipNet, err := net.ParseCIDR(n)
+		if err != nil {
+			return nil, fmt.Errorf("invalid network '%s': %v", n, err)
+		}
+
+		nets = append(nets, ipNet)
+	}
+
+	return nets, nil
+}
+
+func newNetworkRules(opts *NetworkRulesOptions) (*networkRules, error) {
+	if !opts.enabled() {
+		return nil, nil
+	}
+
+	allowed, err := ParseNetworks(opts.AllowedNetworks)
+	if err != nil {
+		return nil, err
+	}
+
+	denied, err := ParseNetworks(opts.DeniedNetworks)
+	if err != nil {
+		return nil, err
+	}
+
+	databases := make(map[string][]*net.IPNet, len(opts.DatabaseAllowedNetworks))
+
+	for db, networks := range opts.DatabaseAllowedNetworks {
+		databases[db], err = ParseNetworks(networks)
+		if err != nil {
+			return nil, fmt.Errorf("database '%s': %v", db, err)
+		}
+	}
+
+	return &networkRules{
+		allowed:   allowed,
+		denied:    denied,
+		databases: databases,
+	}, nil
+}
+
+func containsIP(nets []*net.IPNet, ip net.IP) bool {
+	for _, n := range nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// check returns why the client address is rejected, denied networks take precedence over allowed ones
+func (r *networkRules) check(ip net.IP, database string) (string, bool) {
+	if containsIP(r.denied, ip) {
+		return "denied network", false
+	}
+
+	if len(r.allowed) > 0 && !containsIP(r.allowed, ip) {
+		return "not in the allowed networks", false
+	}
+
+	if database == "" {
+		return "", true
+	}
+
+	allowed, ok := r.databases[database]
+	if ok && !containsIP(allowed, ip) {
+		return fmt.Sprintf("not in the allowed networks of database '%s'", database), false
+	}
+
+	return "", true
+}
+
+// clientAddr returns the address of the client the request was received from, nil if unknown
+func clientAddr(ctx context.Context) net.Addr {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p == nil {
+		return nil
+	}
+
+	return p.Addr
+}
+
+// addrIP returns the IP of a client address, clients connected through a unix socket are on the loopback network
+func addrIP(addr net.Addr) (net.IP, string) {
+	if addr == nil {
+		return nil, "unknown"
+	}
+
+	if addr.Network() == "unix" {
+		return net.IPv4(127, 0, 0, 1), addr.String()
+	}
+
+	if tcpAddr, ok := addr.(*net.TCPAddr); ok {
+		return tcpAddr.IP, addr.String()
+	}
+
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return nil, addr.String()
+	}
+
+	return net.ParseIP(host), addr.String()
+}
+
+// targetDatabase returns the database the request is about, if it can be told before authenticating the client.
+// It's resolved the same way getDBFromCtx does, anonymous requests name it in the request metadata
+func (s *ImmuServer) targetDatabase(ctx context.Context, req interface{}) string {
+	switch r := req.(type) {
+	case *schema.OpenSessionRequest:
+		return r.DatabaseName
+	case *schema.Database:
+		return r.DatabaseName
+	}
+
+	if sessionID, err := sessions.GetSessionIDFromContext(ctx); err == nil {
+		if sess, err := s.SessManager.GetSession(sessionID); err == nil {
+			return sess.GetDatabase().GetName()
+		}
+		return ""
+	}
+
+	if jsUser, err := auth.GetLoggedInUser(ctx); err == nil {
+		if jsUser.DatabaseIndex == sysDBIndex {
+			return SystemDBName
+		}
+		if jsUser.DatabaseIndex >= 0 && jsUser.DatabaseIndex < int64(s.dbList.Length()) {
+			return s.dbList.GetByIndex(jsUser.DatabaseIndex).GetName()
+		}
+		return ""
+	}
+
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if dbNames := md.Get(auth.DatabaseHeader); len(dbNames) > 0 {
+			return dbNames[0]
+		}
+	}
+
+	return ""
+}
+
+// checkNetworkRules rejects clients not allowed by the network rules, every rejection is audit logged
+func (s *ImmuServer) checkNetworkRules(ctx context.Context, method string, req interface{}) error {
+	if s.networkRules == nil {
+		return nil
+	}
+
+	return s.checkClientAddress(clientAddr(ctx), method, s.targetDatabase(ctx, req))
+}
+
+// checkDatabaseNetworkRules rejects clients not allowed to access the database selected by the request,
+// it's called wherever a database is selected so requests not going through the gRPC interceptors are covered too
+func (s *ImmuServer) checkDatabaseNetworkRules(ctx context.Context, method string, database string) error {
+	if s.networkRules == nil {
+		return nil
+	}
+
+	return s.checkClientAddress(clientAddr(ctx), method, database)
+}
+
+// requestMethod returns the name of the gRPC method the request was made through, if any
+func requestMethod(ctx context.Context) string {
+	if method, ok := grpc.Method(ctx); ok {
+		return method
+	}
+	return "request"
+}
+
+// checkClientAddress rejects clients not allowed to access database, every rejection is audit logged
+func (s *ImmuServer) checkClientAddress(addr net.Addr, method string, database string) error {
+	if s.networkRules == nil {
+		return nil
+	}
+
+	ip, addrStr := addrIP(addr)
+	if ip == nil {
+		s.Logger.Warningf("audit: rejected %s from %s: unknown client address", method, addrStr)
+		return ErrClientAddressNotAllowed
+	}
+
+	if reason, ok := s.networkRules.check(ip, database); !ok {
+		s.Logger.Warningf("audit: rejected %s from %s: %s", method, addrStr, reason)
+		return ErrClientAddressNotAllowed
+	}
+
+	return nil
+}
+
+// NetworkRulesInterceptor rejects clients not allowed by the network rules before they get authenticated
+func (s *ImmuServer) NetworkRulesInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if err := s.checkNetworkRules(ctx, info.FullMethod, req); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// NetworkRulesStreamInterceptor rejects clients not allowed by the network rules before they get authenticated
+func (s *ImmuServer) NetworkRulesStreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := s.checkNetworkRules(ss.Context(), info.FullMethod, nil); err != nil {
+		return err
+	}
+	return handler(srv, ss)
+}
+
+// checkPgsqlClientAddress rejects the pgsql clients not allowed to access the database they select
+func (s *ImmuServer) checkPgsqlClientAddress(addr net.Addr, database string) error {
+	return s.checkClientAddress(addr, "pgsql", database)
+}
+
+// NetworkRulesHandler rejects the HTTP clients not allowed by the network rules. The address of the client is made
+// available to the handlers the same way gRPC does, so the rules of the databases selected through the REST API
+// and the web console are enforced as well
+func (s *ImmuServer) NetworkRulesHandler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var addr net.Addr
+
+		if tcpAddr, err := net.ResolveTCPAddr("tcp", r.RemoteAddr); err == nil {
+			addr = tcpAddr
+		}
+
+		if err := s.checkClientAddress(addr, r.Method+" "+r.URL.Path, ""); err != nil {
+			http.Error(w, "client address is not allowed", http.StatusForbidden)
+			return
+		}
+
+		if addr != nil {
+			r = r.WithContext(peer.NewContext(r.Context(), &peer.Peer{Addr: addr}))
+		}
+
+		h.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,228 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/auth"
+	"github.com/codenotary/immudb/pkg/logger"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+func TestParseNetworks(t *testing.T) {
+	nets, err := ParseNetworks([]string{"10.0.0.0/8", " 192.168.1.10 ", "", "2001:db8::/32", "::1"})
+	require.NoError(t, err)
+	require.Len(t, nets, 4)
+
+	require.True(t, containsIP(nets, net.ParseIP("10.1.2.3")))
+	require.True(t, containsIP(nets, net.ParseIP("192.168.1.10")))
+	require.False(t, containsIP(nets, net.ParseIP("192.168.1.11")))
+	require.True(t, containsIP(nets, net.ParseIP("2001:db8::1")))
+	require.True(t, containsIP(nets, net.ParseIP("::1")))
+
+	_, err = ParseNetworks([]string{"10.0.0.0/33"})
+	require.Error(t, err)
+
+	_, err = ParseNetworks([]string{"localhost"})
+	require.Error(t, err)
+}
+
+func TestNetworkRulesDisabled(t *testing.T) {
+	rules, err := newNetworkRules(nil)
+	require.NoError(t, err)
+	require.Nil(t, rules)
+
+	rules, err = newNetworkRules(&NetworkRulesOptions{})
+	require.NoError(t, err)
+	require.Nil(t, rules)
+
+	_, err = newNetworkRules((&NetworkRulesOptions{}).WithDatabaseAllowedNetworks(map[string][]string{"db1": {"invalid"}}))
+	require.Error(t, err)
+}
+
+func TestNetworkRulesInterceptor(t *testing.T) {
+	var logs bytes.Buffer
+
+	s := DefaultServer()
+	s.WithLogger(logger.NewSimpleLogger("immudb ", &logs))
+
+	var err error
+	s.networkRules, err = newNetworkRules((&NetworkRulesOptions{}).
+		WithAllowedNetworks([]string{"10.0.0.0/8", "127.0.0.1"}).
+		WithDeniedNetworks([]string{"10.0.1.0/24"}).
+		WithDatabaseAllowedNetworks(map[string][]string{"db1": {"10.0.2.0/24"}}))
+	require.NoError(t, err)
+
+	handled := false
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		handled = true
+		return nil, nil
+	}
+
+	call := func(addr net.Addr, req interface{}) error {
+		handled = false
+
+		ctx := context.Background()
+		if addr != nil {
+			ctx = peer.NewContext(ctx, &peer.Peer{Addr: addr})
+		}
+
+		_, err := s.NetworkRulesInterceptor(ctx, req, &grpc.UnaryServerInfo{FullMethod: "/immudb.schema.ImmuService/OpenSession"}, handler)
+		if err == nil {
+			require.True(t, handled)
+		} else {
+			require.False(t, handled)
+		}
+
+		return err
+	}
+
+	tcp := func(ip string) net.Addr {
+		return &net.TCPAddr{IP: net.ParseIP(ip), Port: 50000}
+	}
+
+	openSession := func(db string) interface{} {
+		return &schema.OpenSessionRequest{DatabaseName: db}
+	}
+
+	require.NoError(t, call(tcp("10.0.0.1"), openSession("defaultdb")))
+	require.NoError(t, call(&net.UnixAddr{Net: "unix"}, openSession("defaultdb")))
+
+	require.ErrorIs(t, call(tcp("192.168.1.1"), openSession("defaultdb")), ErrClientAddressNotAllowed)
+	require.Contains(t, logs.String(), "audit: rejected /immudb.schema.ImmuService/OpenSession from 192.168.1.1:50000: not in the allowed networks")
+
+	require.ErrorIs(t, call(tcp("10.0.1.1"), openSession("defaultdb")), ErrClientAddressNotAllowed)
+	require.Contains(t, logs.String(), "from 10.0.1.1:50000: denied network")
+
+	require.NoError(t, call(tcp("10.0.2.1"), openSession("db1")))
+	require.NoError(t, call(tcp("10.0.2.1"), &schema.Database{DatabaseName: "db1"}))
+
+	require.ErrorIs(t, call(tcp("10.0.0.1"), openSession("db1")), ErrClientAddressNotAllowed)
+	require.ErrorIs(t, call(tcp("10.0.0.1"), &schema.Database{DatabaseName: "db1"}), ErrClientAddressNotAllowed)
+	require.Contains(t, logs.String(), "from 10.0.0.1:50000: not in the allowed networks of database 'db1'")
+
+	require.ErrorIs(t, call(nil, openSession("defaultdb")), ErrClientAddressNotAllowed)
+	require.Contains(t, logs.String(), "from unknown: unknown client address")
+
+	// anonymous requests select the database through the request metadata
+	anonymousCall := func(addr net.Addr, db string) error {
+		ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: addr})
+		ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(auth.DatabaseHeader, db))
+
+		_, err := s.NetworkRulesInterceptor(ctx, &schema.KeyRequest{}, &grpc.UnaryServerInfo{FullMethod: "/immudb.schema.ImmuService/Get"}, handler)
+		return err
+	}
+
+	require.NoError(t, anonymousCall(tcp("10.0.2.1"), "db1"))
+	require.ErrorIs(t, anonymousCall(tcp("10.0.0.1"), "db1"), ErrClientAddressNotAllowed)
+}
+
+func TestNetworkRulesDatabaseSelection(t *testing.T) {
+	serverOptions := DefaultOptions().
+		WithPgsqlServer(false).
+		WithNetworkRulesOptions((&NetworkRulesOptions{}).
+			WithAllowedNetworks([]string{"10.0.0.0/8"}).
+			WithDatabaseAllowedNetworks(map[string][]string{"db1": {"10.0.2.0/24"}}))
+
+	s, ctx, closer := newTestServer(t, serverOptions)
+	defer closer()
+
+	from := func(ctx context.Context, ip string) context.Context {
+		return peer.NewContext(ctx, &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP(ip), Port: 50000}})
+	}
+
+	_, err := s.CreateDatabaseWithV2(from(ctx, "10.0.0.1"), &schema.DatabaseSettingsV2{
+		DatabaseName:   "db1",
+		AnonymousReads: &schema.ConditionalBool{Value: true},
+	})
+	require.NoError(t, err)
+
+	_, err = s.UseDatabase(from(ctx, "10.0.0.1"), &schema.Database{DatabaseName: "db1"})
+	require.ErrorIs(t, err, ErrClientAddressNotAllowed)
+
+	ur, err := s.UseDatabase(from(ctx, "10.0.2.1"), &schema.Database{DatabaseName: "db1"})
+	require.NoError(t, err)
+
+	dbCtx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", ur.Token))
+
+	_, err = s.Set(from(dbCtx, "10.0.2.1"), &schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte("key1"), Value: []byte("value1")}}})
+	require.NoError(t, err)
+
+	// tokens can not be used from other addresses
+	_, err = s.Get(from(dbCtx, "10.0.0.1"), &schema.KeyRequest{Key: []byte("key1")})
+	require.ErrorIs(t, err, ErrClientAddressNotAllowed)
+
+	_, err = s.KeyspaceStats(from(ctx, "10.0.0.1"), &schema.KeyspaceStatsRequest{Database: "db1"})
+	require.ErrorIs(t, err, ErrClientAddressNotAllowed)
+
+	anonymousCtx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(auth.DatabaseHeader, "db1"))
+
+	_, err = s.Get(from(anonymousCtx, "10.0.0.1"), &schema.KeyRequest{Key: []byte("key1")})
+	require.ErrorIs(t, err, ErrClientAddressNotAllowed)
+
+	entry, err := s.Get(from(anonymousCtx, "10.0.2.1"), &schema.KeyRequest{Key: []byte("key1")})
+	require.NoError(t, err)
+	require.Equal(t, []byte("value1"), entry.Value)
+
+	err = s.checkPgsqlClientAddress(&net.TCPAddr{IP: net.ParseIP("10.0.0.1")}, "db1")
+	require.ErrorIs(t, err, ErrClientAddressNotAllowed)
+
+	err = s.checkPgsqlClientAddress(&net.TCPAddr{IP: net.ParseIP("10.0.0.1")}, DefaultDBName)
+	require.NoError(t, err)
+}
+
+func TestNetworkRulesHandler(t *testing.T) {
+	s := DefaultServer()
+
+	var err error
+	s.networkRules, err = newNetworkRules((&NetworkRulesOptions{}).WithAllowedNetworks([]string{"10.0.0.0/8"}))
+	require.NoError(t, err)
+
+	var addr net.Addr
+
+	h := s.NetworkRulesHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		addr = clientAddr(r.Context())
+	}))
+
+	serve := func(remoteAddr string) int {
+		addr = nil
+
+		req := httptest.NewRequest(http.MethodGet, "/api/db/get/a2V5MQ==", nil)
+		req.RemoteAddr = remoteAddr
+
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+
+		return rec.Code
+	}
+
+	require.Equal(t, http.StatusForbidden, serve("192.168.1.1:50000"))
+	require.Nil(t, addr)
+
+	require.Equal(t, http.StatusOK, serve("10.0.0.1:50000"))
+	require.Equal(t, "10.0.0.1:50000", addr.String())
+}
@@ -22,51 +22,82 @@ import (
 	"net"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/codenotary/immudb/pkg/server/sessions"
 
 	"github.com/codenotary/immudb/pkg/stream"
 
+	"github.com/codenotary/immudb/pkg/alert"
 	"github.com/codenotary/immudb/pkg/auth"
 )
 
 const SystemDBName = "systemdb"
 const DefaultDBName = "defaultdb"
 
+// UnixSocketScheme prefixes addresses of unix domain sockets e.g. unix:///var/run/immudb.sock
+const UnixSocketScheme = "unix://"
+
+// localhost is where the HTTP and pgsql servers listen when the gRPC server listens on a unix socket
+const localhost = "127.0.0.1"
+
 // Options server options list
 type Options struct {
-	Dir                  string
-	Network              string
-	Address              string
-	Port                 int
-	MetricsPort          int
-	Config               string
-	Pidfile              string
-	Logfile              string
-	TLSConfig            *tls.Config
-	auth                 bool
-	MaxRecvMsgSize       int
-	NoHistograms         bool
-	Detached             bool
-	MetricsServer        bool
-	WebServer            bool
-	WebServerPort        int
-	DevMode              bool
-	AdminPassword        string `json:"-"`
-	systemAdminDBName    string
-	defaultDBName        string
-	listener             net.Listener
-	usingCustomListener  bool
-	maintenance          bool
-	SigningKey           string
-	synced               bool
-	RemoteStorageOptions *RemoteStorageOptions
-	StreamChunkSize      int
-	TokenExpiryTimeMin   int
-	PgsqlServer          bool
-	PgsqlServerPort      int
-	ReplicationOptions   *ReplicationOptions
-	SessionsOptions      *sessions.Options
+	Dir                           string
+	Network                       string
+	Address                       string
+	Port                          int
+	MetricsPort                   int
+	Config                        string
+	Pidfile                       string
+	Logfile                       string
+	TLSConfig                     *tls.Config
+	auth                          bool
+	MaxRecvMsgSize                int
+	NoHistograms                  bool
+	Detached                      bool
+	MetricsServer                 bool
+	WebServer                     bool
+	WebServerPort                 int
+	DevMode                       bool
+	AdminPassword                 string `json:"-"`
+	systemAdminDBName             string
+	defaultDBName                 string
+	listener                      net.Listener
+	usingCustomListener           bool
+	maintenance                   bool
+	SigningKey                    string
+	RetiredSigningKeys            []string
+	StateSigningInterval          time.Duration
+	DatabaseSettingsFile          string
+	TenantsFile                   string
+	AutoCompactionInterval        time.Duration
+	MultiDBTxRetryInterval        time.Duration
+	MemoryBudget                  int64
+	MemoryBudgetRebalanceInterval time.Duration
+	Scripting                     bool
+	GrpcReflection                bool
+	GrpcWeb                       bool
+	GrpcWebAllowedOrigins         []string
+	synced                        bool
+	RemoteStorageOptions          *RemoteStorageOptions
+	StreamChunkSize               int
+	TokenExpiryTimeMin            int
+	PgsqlServer                   bool
+	PgsqlServerPort               int
+	ReplicationOptions            *ReplicationOptions
+	WitnessOptions                []*WitnessOptions
+	AlertOptions                  *alert.Options
+	SessionsOptions               *sessions.Options
+	ACMEOptions                   *ACMEOptions
+	ClientCertUsers               map[string]string
+	NetworkRulesOptions           *NetworkRulesOptions
+	MetricsOptions                *MetricsOptions
+	SlowQueryThreshold            time.Duration
+	SlowQueryRecording            bool
+	MaxRequestDuration            time.Duration
+	MaxResultRows                 int
+	MaxResultBytes                int
 }
 
 type RemoteStorageOptions struct {
@@ -86,38 +117,105 @@ type ReplicationOptions struct {
 	FollowerPassword string
 }
 
+// ACMEOptions describes how certificates are obtained and renewed through ACME e.g. from Let's Encrypt,
+// it's enabled when at least one domain is configured
+type ACMEOptions struct {
+	Domains []string
+	Email   string
+	// CacheDir is where obtained certificates and the account key are kept across restarts
+	CacheDir string
+	// HTTPChallengeAddress is where http-01 challenges are answered, no challenge is answered over http when empty.
+	// tls-alpn-01 challenges are answered by any TLS listener reachable on port 443
+	HTTPChallengeAddress string
+	// DirectoryURL of the ACME CA, Let's Encrypt production directory when empty
+	DirectoryURL string
+}
+
+// Metric groups exported by the metrics server
+const (
+	MetricsGroupServer      = "server"
+	MetricsGroupStore       = "store"
+	MetricsGroupIndex       = "index"
+	MetricsGroupReplication = "replication"
+	MetricsGroupSQL         = "sql"
+	MetricsGroupRuntime     = "runtime"
+)
+
+// MetricsOptions describes how the metrics endpoint is protected and which metrics it exports
+type MetricsOptions struct {
+	// Username and Password are required through basic auth to read metrics when Username is set
+	Username string
+	Password string `json:"-"`
+	// ClientCAFile enables mutual TLS: metrics are served over TLS with the server certificate and clients must
+	// present a certificate signed by one of the CAs in the file
+	ClientCAFile string
+	// Groups are the groups of metrics exported, all of them when empty
+	Groups []string
+	// AggregateDatabases sums the series of all databases into one, dropping their database label, to bound
+	// the number of series on servers with many databases
+	AggregateDatabases bool
+}
+
+// NetworkRulesOptions describes which clients are allowed to connect by their address.
+// Networks are given in CIDR notation or as single addresses, clients connected through a unix socket
+// are on the loopback network
+type NetworkRulesOptions struct {
+	// AllowedNetworks are the only networks clients may connect from, any network when empty
+	AllowedNetworks []string
+	// DeniedNetworks are the networks clients may not connect from, even when they are within the allowed ones
+	DeniedNetworks []string
+	// DatabaseAllowedNetworks are the only networks clients may access a database from, by database name
+	DatabaseAllowedNetworks map[string][]string
+}
+
+// WitnessOptions describes an external immudb database whose roots are observed
+// and appended into a local database
+type WitnessOptions struct {
+	Database      string
+	Source        string
+	SrcDatabase   string
+	SrcAddress    string
+	SrcPort       int
+	SrcUsername   string
+	SrcPassword   string
+	FetchInterval time.Duration
+}
+
 // DefaultOptions returns default server options
 func DefaultOptions() *Options {
 	return &Options{
-		Dir:                  "./data",
-		Network:              "tcp",
-		Address:              "0.0.0.0",
-		Port:                 3322,
-		MetricsPort:          9497,
-		WebServerPort:        8080,
-		Config:               "configs/immudb.toml",
-		Pidfile:              "",
-		Logfile:              "",
-		TLSConfig:            nil,
-		auth:                 true,
-		MaxRecvMsgSize:       1024 * 1024 * 32, // 32Mb
-		NoHistograms:         false,
-		Detached:             false,
-		MetricsServer:        true,
-		WebServer:            true,
-		DevMode:              false,
-		AdminPassword:        auth.SysAdminPassword,
-		systemAdminDBName:    SystemDBName,
-		defaultDBName:        DefaultDBName,
-		usingCustomListener:  false,
-		maintenance:          false,
-		synced:               true,
-		RemoteStorageOptions: DefaultRemoteStorageOptions(),
-		StreamChunkSize:      stream.DefaultChunkSize,
-		TokenExpiryTimeMin:   1440,
-		PgsqlServer:          false,
-		PgsqlServerPort:      5432,
-		SessionsOptions:      sessions.DefaultOptions(),
+		Dir:                           "./data",
+		Network:                       "tcp",
+		Address:                       "0.0.0.0",
+		Port:                          3322,
+		MetricsPort:                   9497,
+		WebServerPort:                 8080,
+		Config:                        "configs/immudb.toml",
+		Pidfile:                       "",
+		Logfile:                       "",
+		TLSConfig:                     nil,
+		auth:                          true,
+		MaxRecvMsgSize:                1024 * 1024 * 32, // 32Mb
+		NoHistograms:                  false,
+		Detached:                      false,
+		MetricsServer:                 true,
+		WebServer:                     true,
+		DevMode:                       false,
+		AdminPassword:                 auth.SysAdminPassword,
+		systemAdminDBName:             SystemDBName,
+		defaultDBName:                 DefaultDBName,
+		usingCustomListener:           false,
+		maintenance:                   false,
+		synced:                        true,
+		RemoteStorageOptions:          DefaultRemoteStorageOptions(),
+		StreamChunkSize:               stream.DefaultChunkSize,
+		TokenExpiryTimeMin:            1440,
+		PgsqlServer:                   false,
+		PgsqlServerPort:               5432,
+		SessionsOptions:               sessions.DefaultOptions(),
+		AutoCompactionInterval:        time.Minute,
+		MultiDBTxRetryInterval:        time.Minute,
+		MemoryBudgetRebalanceInterval: DefaultMemoryBudgetRebalanceInterval,
 	}
 }
 
@@ -205,19 +303,47 @@ func (o *Options) WithDetached(detached bool) *Options {
 	return o
 }
 
+// UnixSocket returns the path of the unix socket the server listens on, if any
+func (o *Options) UnixSocket() (string, bool) {
+	if !strings.HasPrefix(o.Address, UnixSocketScheme) {
+		return "", false
+	}
+	return strings.TrimPrefix(o.Address, UnixSocketScheme), true
+}
+
 // Bind returns bind address
 func (o *Options) Bind() string {
+	if path, ok := o.UnixSocket(); ok {
+		return path
+	}
 	return o.Address + ":" + strconv.Itoa(o.Port)
 }
 
+// BindNetwork returns the network of the bind address
+func (o *Options) BindNetwork() string {
+	if _, ok := o.UnixSocket(); ok {
+		return "unix"
+	}
+	return o.Network
+}
+
+// tcpAddress returns the address TCP servers listen on,
+// only local clients are reached when the gRPC server listens on a unix socket
+func (o *Options) tcpAddress() string {
+	if _, ok := o.UnixSocket(); ok {
+		return localhost
+	}
+	return o.Address
+}
+
 // MetricsBind return metrics bind address
 func (o *Options) MetricsBind() string {
-	return o.Address + ":" + strconv.Itoa(o.MetricsPort)
+	return o.tcpAddress() + ":" + strconv.Itoa(o.MetricsPort)
 }
 
 // WebBind return bind address for the Web API/console
 func (o *Options) WebBind() string {
-	return o.Address + ":" + strconv.Itoa(o.WebServerPort)
+	return o.tcpAddress() + ":" + strconv.Itoa(o.WebServerPort)
 }
 
 // String print options
@@ -228,7 +354,11 @@ func (o *Options) String() string {
 	opts := make([]string, 0, 17)
 	opts = append(opts, "================ Config ================")
 	opts = append(opts, rightPad("Data dir", o.Dir))
-	opts = append(opts, rightPad("Address", fmt.Sprintf("%s:%d", o.Address, o.Port)))
+	if _, ok := o.UnixSocket(); ok {
+		opts = append(opts, rightPad("Address", o.Address))
+	} else {
+		opts = append(opts, rightPad("Address", fmt.Sprintf("%s:%d", o.Address, o.Port)))
+	}
 
 	repOpts := o.ReplicationOptions
 
@@ -237,7 +367,36 @@ func (o *Options) String() string {
 	}
 
 	if o.MetricsServer {
-		opts = append(opts, rightPad("Metrics address", fmt.Sprintf("%s:%d/metrics", o.Address, o.MetricsPort)))
+		opts = append(opts, rightPad("Metrics address", fmt.Sprintf("%s/metrics", o.MetricsBind())))
+		if o.MetricsOptions != nil {
+			if o.MetricsOptions.Username != "" {
+				opts = append(opts, rightPad("   basic auth user", o.MetricsOptions.Username))
+			}
+			if o.MetricsOptions.ClientCAFile != "" {
+				opts = append(opts, rightPad("   client CAs", o.MetricsOptions.ClientCAFile))
+			}
+			if len(o.MetricsOptions.Groups) > 0 {
+				opts = append(opts, rightPad("   groups", strings.Join(o.MetricsOptions.Groups, ", ")))
+			}
+			if o.MetricsOptions.AggregateDatabases {
+				opts = append(opts, rightPad("   databases", "aggregated"))
+			}
+		}
+	}
+	if o.MaxRequestDuration > 0 {
+		opts = append(opts, rightPad("Max request duration", o.MaxRequestDuration))
+	}
+	if o.MaxResultRows > 0 {
+		opts = append(opts, rightPad("Max result rows", o.MaxResultRows))
+	}
+	if o.MaxResultBytes > 0 {
+		opts = append(opts, rightPad("Max result bytes", o.MaxResultBytes))
+	}
+	if o.SlowQueryThreshold > 0 {
+		opts = append(opts, rightPad("Slow query threshold", o.SlowQueryThreshold))
+		if o.SlowQueryRecording {
+			opts = append(opts, rightPad("   recorded", o.SlowQueryRecording))
+		}
 	}
 	if o.Config != "" {
 		opts = append(opts, rightPad("Config file", o.Config))
@@ -256,6 +415,33 @@ func (o *Options) String() string {
 	opts = append(opts, rightPad("Synced mode", o.synced))
 	if o.SigningKey != "" {
 		opts = append(opts, rightPad("Signing key", o.SigningKey))
+		for _, k := range o.RetiredSigningKeys {
+			opts = append(opts, rightPad("   retired key", k))
+		}
+		if o.StateSigningInterval > 0 {
+			opts = append(opts, rightPad("   interval", o.StateSigningInterval))
+		}
+	}
+	if o.DatabaseSettingsFile != "" {
+		opts = append(opts, rightPad("Database settings", o.DatabaseSettingsFile))
+	}
+	if o.TenantsFile != "" {
+		opts = append(opts, rightPad("Tenants", o.TenantsFile))
+	}
+	if o.MemoryBudget > 0 {
+		opts = append(opts, rightPad("Memory budget", o.MemoryBudget))
+	}
+	if o.Scripting {
+		opts = append(opts, rightPad("Scripting", o.Scripting))
+	}
+	if o.GrpcReflection {
+		opts = append(opts, rightPad("gRPC reflection", o.GrpcReflection))
+	}
+	if o.WebServer && o.GrpcWeb {
+		opts = append(opts, rightPad("gRPC-Web", o.GrpcWeb))
+		if len(o.GrpcWebAllowedOrigins) > 0 {
+			opts = append(opts, rightPad("   allowed origins", strings.Join(o.GrpcWebAllowedOrigins, ", ")))
+		}
 	}
 	if o.RemoteStorageOptions.S3Storage {
 		opts = append(opts, "S3 storage")
@@ -266,6 +452,35 @@ func (o *Options) String() string {
 		}
 		opts = append(opts, rightPad("   prefix", o.RemoteStorageOptions.S3PathPrefix))
 	}
+	if o.ACMEOptions.enabled() {
+		opts = append(opts, rightPad("ACME domains", strings.Join(o.ACMEOptions.Domains, ", ")))
+		opts = append(opts, rightPad("   cache dir", o.ACMEOptions.CacheDir))
+	}
+	if o.NetworkRulesOptions.enabled() {
+		if len(o.NetworkRulesOptions.AllowedNetworks) > 0 {
+			opts = append(opts, rightPad("Allowed networks", strings.Join(o.NetworkRulesOptions.AllowedNetworks, ", ")))
+		}
+		if len(o.NetworkRulesOptions.DeniedNetworks) > 0 {
+			opts = append(opts, rightPad("Denied networks", strings.Join(o.NetworkRulesOptions.DeniedNetworks, ", ")))
+		}
+		for db, networks := range o.NetworkRulesOptions.DatabaseAllowedNetworks {
+			opts = append(opts, rightPad("   "+db, strings.Join(networks, ", ")))
+		}
+	}
+	if len(o.ClientCertUsers) > 0 {
+		opts = append(opts, rightPad("Cert auth users", len(o.ClientCertUsers)))
+	}
+	if o.AlertOptions != nil {
+		if o.AlertOptions.WebhookURL != "" {
+			opts = append(opts, rightPad("Alert webhook", o.AlertOptions.WebhookURL))
+		}
+		if o.AlertOptions.SMTPAddress != "" {
+			opts = append(opts, rightPad("Alert mail", strings.Join(o.AlertOptions.SMTPTo, ", ")))
+		}
+		if o.AlertOptions.PagerDutyRoutingKey != "" {
+			opts = append(opts, rightPad("Alert PagerDuty", o.AlertOptions.PagerDutyEventsURL))
+		}
+	}
 	if o.AdminPassword == auth.SysAdminPassword {
 		opts = append(opts, "----------------------------------------")
 		opts = append(opts, "Superadmin default credentials")
@@ -306,12 +521,12 @@ func (o *Options) WithAdminPassword(adminPassword string) *Options {
 	return o
 }
 
-//GetSystemAdminDBName returns the System database name
+// GetSystemAdminDBName returns the System database name
 func (o *Options) GetSystemAdminDBName() string {
 	return o.systemAdminDBName
 }
 
-//GetDefaultDBName returns the default database name
+// GetDefaultDBName returns the default database name
 func (o *Options) GetDefaultDBName() string {
 	return o.defaultDBName
 }
@@ -351,6 +566,87 @@ func (o *Options) WithSigningKey(signingKey string) *Options {
 	return o
 }
 
+// WithRetiredSigningKeys sets the public key files of the signing keys used before the current one.
+// Retired keys are served to clients so that states signed before a key rotation can still be verified
+func (o *Options) WithRetiredSigningKeys(retiredSigningKeys []string) *Options {
+	o.RetiredSigningKeys = retiredSigningKeys
+	return o
+}
+
+// WithStateSigningInterval sets how often the current state of every database is signed in background,
+// periodic signing is disabled when set to 0
+func (o *Options) WithStateSigningInterval(interval time.Duration) *Options {
+	o.StateSigningInterval = interval
+	return o
+}
+
+// WithDatabaseSettingsFile sets the file holding the default settings and the templates of the databases
+// created on this server
+func (o *Options) WithDatabaseSettingsFile(path string) *Options {
+	o.DatabaseSettingsFile = path
+	return o
+}
+
+// WithTenantsFile sets the file holding the tenants served by this server and their quotas,
+// tenants own databases and users and are limited in the resources they use
+func (o *Options) WithTenantsFile(path string) *Options {
+	o.TenantsFile = path
+	return o
+}
+
+// WithAutoCompactionInterval sets how often the indexes of the databases with automatic compaction enabled
+// are checked in background, automatic compaction is disabled on every database when set to 0
+func (o *Options) WithAutoCompactionInterval(interval time.Duration) *Options {
+	o.AutoCompactionInterval = interval
+	return o
+}
+
+// WithMultiDBTxRetryInterval sets how often the pending parts of multi-database transactions are retried in background,
+// they're only retried when the server starts if set to 0
+func (o *Options) WithMultiDBTxRetryInterval(interval time.Duration) *Options {
+	o.MultiDBTxRetryInterval = interval
+	return o
+}
+
+// WithMemoryBudget sets the memory in bytes the caches and the SQL working memory of all the databases may take,
+// it's divided among the loaded databases weighted by their activity. When set to 0, each database takes the caches set in its settings
+func (o *Options) WithMemoryBudget(memoryBudget int64) *Options {
+	o.MemoryBudget = memoryBudget
+	return o
+}
+
+// WithMemoryBudgetRebalanceInterval sets how often the memory budget is divided again among the databases
+func (o *Options) WithMemoryBudgetRebalanceInterval(interval time.Duration) *Options {
+	o.MemoryBudgetRebalanceInterval = interval
+	return o
+}
+
+// WithScripting enables or disables the server-side scripts run through ExecScript
+func (o *Options) WithScripting(enable bool) *Options {
+	o.Scripting = enable
+	return o
+}
+
+// WithGrpcReflection enables or disables the gRPC server reflection service
+func (o *Options) WithGrpcReflection(enable bool) *Options {
+	o.GrpcReflection = enable
+	return o
+}
+
+// WithGrpcWeb enables or disables serving the gRPC API through the gRPC-Web protocol on the web server,
+// so browsers can call it without a translating proxy
+func (o *Options) WithGrpcWeb(enable bool) *Options {
+	o.GrpcWeb = enable
+	return o
+}
+
+// WithGrpcWebAllowedOrigins sets the origins, other than the one of the web server, browsers may call the gRPC API
+// from through gRPC-Web e.g. https://app.example.com, requests of any other origin are rejected
+func (o *Options) WithGrpcWebAllowedOrigins(origins []string) *Options {
+	o.GrpcWebAllowedOrigins = origins
+	return o
+}
+
 // WithStreamChunkSize set the chunk size
 func (o *Options) WithStreamChunkSize(streamChunkSize int) *Options {
 	o.StreamChunkSize = streamChunkSize
@@ -385,11 +681,77 @@ func (o *Options) WithReplicationOptions(replicationOptions *ReplicationOptions)
 	return o
 }
 
+func (o *Options) WithWitnessOptions(witnessOptions ...*WitnessOptions) *Options {
+	o.WitnessOptions = witnessOptions
+	return o
+}
+
+// WithAlertOptions sets where witnesses deliver alerts when observing roots inconsistent with the registered ones
+func (o *Options) WithAlertOptions(alertOptions *alert.Options) *Options {
+	o.AlertOptions = alertOptions
+	return o
+}
+
 func (o *Options) WithSessionOptions(options *sessions.Options) *Options {
 	o.SessionsOptions = options
 	return o
 }
 
+// WithClientCertUsers sets the users clients are authenticated as by their certificate, without a password.
+// Certificates are identified by their common name or any of the DNS names, email addresses and URIs of their subject alternative names
+func (o *Options) WithClientCertUsers(clientCertUsers map[string]string) *Options {
+	o.ClientCertUsers = clientCertUsers
+	return o
+}
+
+// WithNetworkRulesOptions sets which clients are allowed to connect by their address
+func (o *Options) WithNetworkRulesOptions(networkRulesOptions *NetworkRulesOptions) *Options {
+	o.NetworkRulesOptions = networkRulesOptions
+	return o
+}
+
+// WithSlowQueryThreshold sets the duration after which SQL statements and scans are logged as slow, 0 disables the slow query log
+func (o *Options) WithSlowQueryThreshold(threshold time.Duration) *Options {
+	o.SlowQueryThreshold = threshold
+	return o
+}
+
+// WithSlowQueryRecording sets whether slow queries are recorded in the system database, besides being logged
+func (o *Options) WithSlowQueryRecording(recording bool) *Options {
+	o.SlowQueryRecording = recording
+	return o
+}
+
+// WithMaxRequestDuration sets the max execution time of unary requests, 0 means unlimited
+func (o *Options) WithMaxRequestDuration(maxRequestDuration time.Duration) *Options {
+	o.MaxRequestDuration = maxRequestDuration
+	return o
+}
+
+// WithMaxResultRows sets the max number of rows of a SQL result or entries of a scan, 0 means unlimited
+func (o *Options) WithMaxResultRows(maxResultRows int) *Options {
+	o.MaxResultRows = maxResultRows
+	return o
+}
+
+// WithMaxResultBytes sets the max size in bytes of a SQL result or a scan, 0 means unlimited
+func (o *Options) WithMaxResultBytes(maxResultBytes int) *Options {
+	o.MaxResultBytes = maxResultBytes
+	return o
+}
+
+// WithMetricsOptions sets how the metrics endpoint is protected and which metrics it exports
+func (o *Options) WithMetricsOptions(metricsOptions *MetricsOptions) *Options {
+	o.MetricsOptions = metricsOptions
+	return o
+}
+
+// WithACMEOptions sets how certificates are obtained through ACME instead of being read from files
+func (o *Options) WithACMEOptions(acmeOptions *ACMEOptions) *Options {
+	o.ACMEOptions = acmeOptions
+	return o
+}
+
 // RemoteStorageOptions
 
 func (opts *RemoteStorageOptions) WithS3Storage(S3Storage bool) *RemoteStorageOptions {
@@ -448,3 +810,132 @@ func (opts *ReplicationOptions) WithFollowerPassword(followerPassword string) *R
 	opts.FollowerPassword = followerPassword
 	return opts
 }
+
+// WitnessOptions
+
+func (opts *WitnessOptions) WithDatabase(database string) *WitnessOptions {
+	opts.Database = database
+	return opts
+}
+
+func (opts *WitnessOptions) WithSource(source string) *WitnessOptions {
+	opts.Source = source
+	return opts
+}
+
+func (opts *WitnessOptions) WithSrcDatabase(srcDatabase string) *WitnessOptions {
+	opts.SrcDatabase = srcDatabase
+	return opts
+}
+
+func (opts *WitnessOptions) WithSrcAddress(srcAddress string) *WitnessOptions {
+	opts.SrcAddress = srcAddress
+	return opts
+}
+
+func (opts *WitnessOptions) WithSrcPort(srcPort int) *WitnessOptions {
+	opts.SrcPort = srcPort
+	return opts
+}
+
+func (opts *WitnessOptions) WithSrcUsername(srcUsername string) *WitnessOptions {
+	opts.SrcUsername = srcUsername
+	return opts
+}
+
+func (opts *WitnessOptions) WithSrcPassword(srcPassword string) *WitnessOptions {
+	opts.SrcPassword = srcPassword
+	return opts
+}
+
+func (opts *WitnessOptions) WithFetchInterval(fetchInterval time.Duration) *WitnessOptions {
+	opts.FetchInterval = fetchInterval
+	return opts
+}
+
+// NetworkRulesOptions
+
+func (opts *NetworkRulesOptions) enabled() bool {
+	return opts != nil && (len(opts.AllowedNetworks) > 0 || len(opts.DeniedNetworks) > 0 || len(opts.DatabaseAllowedNetworks) > 0)
+}
+
+func (opts *NetworkRulesOptions) WithAllowedNetworks(allowedNetworks []string) *NetworkRulesOptions {
+	opts.AllowedNetworks = allowedNetworks
+	return opts
+}
+
+func (opts *NetworkRulesOptions) WithDeniedNetworks(deniedNetworks []string) *NetworkRulesOptions {
+	opts.DeniedNetworks = deniedNetworks
+	return opts
+}
+
+func (opts *NetworkRulesOptions) WithDatabaseAllowedNetworks(databaseAllowedNetworks map[string][]string) *NetworkRulesOptions {
+	opts.DatabaseAllowedNetworks = databaseAllowedNetworks
+	return opts
+}
+
+// MetricsOptions
+
+func (opts *MetricsOptions) WithUsername(username string) *MetricsOptions {
+	opts.Username = username
+	return opts
+}
+
+func (opts *MetricsOptions) WithPassword(password string) *MetricsOptions {
+	opts.Password = password
+	return opts
+}
+
+func (opts *MetricsOptions) WithClientCAFile(clientCAFile string) *MetricsOptions {
+	opts.ClientCAFile = clientCAFile
+	return opts
+}
+
+func (opts *MetricsOptions) WithGroups(groups []string) *MetricsOptions {
+	opts.Groups = groups
+	return opts
+}
+
+func (opts *MetricsOptions) WithAggregateDatabases(aggregateDatabases bool) *MetricsOptions {
+	opts.AggregateDatabases = aggregateDatabases
+	return opts
+}
+
+// ACMEOptions
+
+// DefaultACMEOptions returns default ACME options, no domain is configured thus ACME is disabled
+func DefaultACMEOptions() *ACMEOptions {
+	return &ACMEOptions{
+		CacheDir:             "./acme",
+		HTTPChallengeAddress: ":80",
+	}
+}
+
+func (opts *ACMEOptions) enabled() bool {
+	return opts != nil && len(opts.Domains) > 0
+}
+
+func (opts *ACMEOptions) WithDomains(domains []string) *ACMEOptions {
+	opts.Domains = domains
+	return opts
+}
+
+func (opts *ACMEOptions) WithEmail(email string) *ACMEOptions {
+	opts.Email = email
+	return opts
+}
+
+func (opts *ACMEOptions) WithCacheDir(cacheDir string) *ACMEOptions {
+	opts.CacheDir = cacheDir
+	return opts
+}
+
+func (opts *ACMEOptions) WithHTTPChallengeAddress(httpChallengeAddress string) *ACMEOptions {
+	opts.HTTPChallengeAddress = httpChallengeAddress
+	return opts
+}
+
+func (opts *ACMEOptions) WithDirectoryURL(directoryURL string) *ACMEOptions {
+	opts.DirectoryURL = directoryURL
+	return opts
+}
@@ -19,6 +19,7 @@ package server
 import (
 	"crypto/tls"
 	"testing"
+	"time"
 
 	"github.com/codenotary/immudb/pkg/auth"
 	"github.com/codenotary/immudb/pkg/stream"
@@ -50,11 +51,32 @@ func TestOptions(t *testing.T) {
 		op.WebBind() != "0.0.0.0:8080" ||
 		op.MetricsBind() != "0.0.0.0:9497" ||
 		op.PgsqlServer ||
-		op.PgsqlServerPort != 5432 {
+		op.PgsqlServerPort != 5432 ||
+		op.AutoCompactionInterval != time.Minute {
 		t.Errorf("database default options mismatch")
 	}
 }
 
+func TestOptionsUnixSocket(t *testing.T) {
+	op := DefaultOptions().WithAddress("unix:///var/run/immudb.sock")
+
+	path, ok := op.UnixSocket()
+	assert.True(t, ok)
+	assert.Equal(t, "/var/run/immudb.sock", path)
+	assert.Equal(t, "/var/run/immudb.sock", op.Bind())
+	assert.Equal(t, "unix", op.BindNetwork())
+	assert.Equal(t, "127.0.0.1:8080", op.WebBind())
+	assert.Equal(t, "127.0.0.1:9497", op.MetricsBind())
+	assert.Contains(t, op.String(), "unix:///var/run/immudb.sock")
+
+	op = DefaultOptions()
+
+	_, ok = op.UnixSocket()
+	assert.False(t, ok)
+	assert.Equal(t, "0.0.0.0:3322", op.Bind())
+	assert.Equal(t, "tcp", op.BindNetwork())
+}
+
 func TestSetOptions(t *testing.T) {
 	tlsConfig := &tls.Config{Certificates: []tls.Certificate{}}
 	op := DefaultOptions().WithDir("immudb_dir").WithNetwork("udp").
@@ -0,0 +1,128 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/codenotary/immudb/embedded/sql"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/auth"
+	"github.com/codenotary/immudb/pkg/database"
+	"github.com/codenotary/immudb/pkg/server/sessions"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestSQLPolicies(t *testing.T) {
+	s, ctx, closer := newTestServer(t, nil)
+	defer closer()
+
+	_, err := s.SQLExec(ctx, &schema.SQLExecRequest{Sql: `
+		CREATE TABLE orders (id INTEGER AUTO_INCREMENT, tenant VARCHAR, owner VARCHAR, PRIMARY KEY id);
+		INSERT INTO orders(tenant, owner) VALUES ('t1', 'alice'), ('t1', 'bob'), ('t2', 'carol');
+		CREATE POLICY own ON orders USING (owner = @user);
+	`})
+	require.NoError(t, err)
+
+	_, err = s.CreateUser(ctx, &schema.CreateUserRequest{
+		User:       []byte("alice"),
+		Password:   []byte("Pa$$w0rd1"),
+		Permission: auth.PermissionRW,
+		Database:   DefaultDBName,
+	})
+	require.NoError(t, err)
+
+	ulr, err := s.Login(context.Background(), &schema.LoginRequest{
+		User:     []byte("alice"),
+		Password: []byte("Pa$$w0rd1"),
+	})
+	require.NoError(t, err)
+
+	udb, err := s.UseDatabase(
+		metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", ulr.Token)),
+		&schema.Database{DatabaseName: DefaultDBName},
+	)
+	require.NoError(t, err)
+
+	userCtx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", udb.Token))
+
+	res, err := s.SQLQuery(userCtx, &schema.SQLQueryRequest{Sql: "SELECT owner FROM orders"})
+	require.NoError(t, err)
+	require.Len(t, res.Rows, 1)
+	require.Equal(t, "alice", res.Rows[0].Values[0].GetS())
+
+	res, err = s.SQLQuery(ctx, &schema.SQLQueryRequest{Sql: "SELECT owner FROM orders"})
+	require.NoError(t, err)
+	require.Len(t, res.Rows, 3)
+
+	_, err = s.SQLExec(userCtx, &schema.SQLExecRequest{Sql: "DROP POLICY own ON orders"})
+	require.ErrorIs(t, err, database.ErrPolicyManagementNotAllowed)
+
+	_, err = s.SQLExec(ctx, &schema.SQLExecRequest{Sql: "CREATE POLICY tenancy ON orders USING (tenant = @tenant)"})
+	require.NoError(t, err)
+
+	t.Run("session vars should be bound to policies", func(t *testing.T) {
+		_, err := s.OpenSession(context.Background(), &schema.OpenSessionRequest{
+			Username:     []byte("alice"),
+			Password:     []byte("Pa$$w0rd1"),
+			DatabaseName: DefaultDBName,
+			SessionVars:  map[string]string{"User": "bob"},
+		})
+		require.ErrorIs(t, err, sessions.ErrInvalidSessionVar)
+
+		sres, err := s.OpenSession(context.Background(), &schema.OpenSessionRequest{
+			Username:     []byte("alice"),
+			Password:     []byte("Pa$$w0rd1"),
+			DatabaseName: DefaultDBName,
+			SessionVars:  map[string]string{"Tenant": "t2"},
+		})
+		require.NoError(t, err)
+
+		sessCtx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("sessionid", sres.SessionID))
+
+		res, err := s.SQLQuery(sessCtx, &schema.SQLQueryRequest{Sql: "SELECT owner FROM orders ORDER BY id"})
+		require.NoError(t, err)
+		require.Len(t, res.Rows, 2)
+		require.Equal(t, "alice", res.Rows[0].Values[0].GetS())
+		require.Equal(t, "carol", res.Rows[1].Values[0].GetS())
+
+		tx, err := s.NewTx(sessCtx, &schema.NewTxRequest{Mode: schema.TxMode_ReadWrite})
+		require.NoError(t, err)
+
+		txCtx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(
+			"sessionid", sres.SessionID,
+			"transactionid", tx.TransactionID,
+		))
+
+		res, err = s.TxSQLQuery(txCtx, &schema.SQLQueryRequest{Sql: "SELECT owner FROM orders"})
+		require.NoError(t, err)
+		require.Len(t, res.Rows, 2)
+
+		_, err = s.TxSQLExec(txCtx, &schema.SQLExecRequest{Sql: "DROP POLICY tenancy ON orders"})
+		require.ErrorIs(t, err, database.ErrPolicyManagementNotAllowed)
+
+		_, err = s.Rollback(txCtx, nil)
+		require.NoError(t, err)
+	})
+
+	t.Run("missing session attributes should be reported", func(t *testing.T) {
+		_, err := s.SQLQuery(userCtx, &schema.SQLQueryRequest{Sql: "SELECT owner FROM orders"})
+		require.ErrorIs(t, err, sql.ErrMissingParameter)
+	})
+}
@@ -0,0 +1,122 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"time"
+
+	"github.com/codenotary/immudb/embedded/watchers"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/auth"
+	"github.com/codenotary/immudb/pkg/server/sessions"
+	"google.golang.org/grpc"
+)
+
+// ReadYourWritesInterceptor keeps track of the txs committed within sessions opened with readYourWrites,
+// and makes Get, GetAll and Scan requests of those sessions to wait for the last of them to be indexed.
+// Once a session has committed a tx, its reads don't wait for txs committed by other sessions
+func (s *ImmuServer) ReadYourWritesInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if auth.GetAuthTypeFromContext(ctx) != auth.SessionAuth || info.FullMethod == "/immudb.schema.ImmuService/OpenSession" {
+		return handler(ctx, req)
+	}
+
+	sess, err := s.SessManager.GetSessionFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	enabled, timeout := sess.GetReadYourWrites()
+	if !enabled {
+		return handler(ctx, req)
+	}
+
+	err = waitForSessionWrites(ctx, sess, req, timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := handler(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	sess.TrackCommittedTx(committedTxID(res))
+
+	return res, nil
+}
+
+func waitForSessionWrites(ctx context.Context, sess *sessions.Session, req interface{}, timeout time.Duration) error {
+	// reads of a given state are left as requested
+	var sinceTx *uint64
+
+	switch r := req.(type) {
+	case *schema.KeyRequest:
+		if r.AtTx == 0 && r.SinceTx == 0 {
+			sinceTx = &r.SinceTx
+		}
+	case *schema.KeyListRequest:
+		if r.SinceTx == 0 {
+			sinceTx = &r.SinceTx
+		}
+	case *schema.ScanRequest:
+		if r.SinceTx == 0 {
+			sinceTx = &r.SinceTx
+		}
+	}
+
+	lastTxID := sess.GetLastCommittedTxID()
+
+	if sinceTx == nil || lastTxID == 0 {
+		return nil
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	err := sess.GetDatabase().WaitForIndexingUpto(lastTxID, waitCtx.Done())
+	if err == watchers.ErrCancellationRequested {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return ErrReadYourWritesTimeout
+	}
+	if err != nil {
+		return err
+	}
+
+	*sinceTx = lastTxID
+
+	return nil
+}
+
+func committedTxID(res interface{}) uint64 {
+	switch r := res.(type) {
+	case *schema.TxHeader:
+		return r.GetId()
+	case *schema.VerifiableTx:
+		return r.GetTx().GetHeader().GetId()
+	case *schema.CommittedSQLTx:
+		return r.GetHeader().GetId()
+	case *schema.SQLExecResult:
+		if len(r.Txs) > 0 {
+			return r.Txs[len(r.Txs)-1].GetHeader().GetId()
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,124 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/auth"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestReadYourWritesInterceptor(t *testing.T) {
+	s, _, closer := newTestServer(t, nil)
+	defer closer()
+
+	openSession := func(readYourWrites bool) context.Context {
+		res, err := s.OpenSession(context.Background(), &schema.OpenSessionRequest{
+			Username:              []byte(auth.SysAdminUsername),
+			Password:              []byte(auth.SysAdminPassword),
+			DatabaseName:          DefaultDBName,
+			ReadYourWrites:        readYourWrites,
+			ReadYourWritesTimeout: 100,
+		})
+		require.NoError(t, err)
+
+		return metadata.NewIncomingContext(context.Background(), metadata.Pairs("sessionid", res.SessionID))
+	}
+
+	invoke := func(ctx context.Context, method string, req interface{}, handler grpc.UnaryHandler) (interface{}, error) {
+		return s.ReadYourWritesInterceptor(ctx, req, &grpc.UnaryServerInfo{FullMethod: "/immudb.schema.ImmuService/" + method}, handler)
+	}
+
+	set := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return s.Set(ctx, req.(*schema.SetRequest))
+	}
+
+	get := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return s.Get(ctx, req.(*schema.KeyRequest))
+	}
+
+	scan := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return s.Scan(ctx, req.(*schema.ScanRequest))
+	}
+
+	t.Run("reads should wait for the session writes to be indexed", func(t *testing.T) {
+		ctx := openSession(true)
+
+		sess, err := s.SessManager.GetSessionFromContext(ctx)
+		require.NoError(t, err)
+
+		enabled, timeout := sess.GetReadYourWrites()
+		require.True(t, enabled)
+		require.Equal(t, 100*time.Millisecond, timeout)
+
+		// no wait is needed before the session commits any tx
+		getReq := &schema.KeyRequest{Key: []byte("key1"), NoWait: true}
+		_, err = invoke(ctx, "Get", getReq, get)
+		require.Error(t, err)
+		require.Zero(t, getReq.SinceTx)
+
+		res, err := invoke(ctx, "Set", &schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte("key1"), Value: []byte("value1")}}}, set)
+		require.NoError(t, err)
+
+		txID := res.(*schema.TxHeader).Id
+		require.Equal(t, txID, sess.GetLastCommittedTxID())
+
+		getReq = &schema.KeyRequest{Key: []byte("key1"), NoWait: true}
+		entry, err := invoke(ctx, "Get", getReq, get)
+		require.NoError(t, err)
+		require.Equal(t, []byte("value1"), entry.(*schema.Entry).Value)
+		require.Equal(t, txID, getReq.SinceTx)
+
+		scanReq := &schema.ScanRequest{Prefix: []byte("key"), NoWait: true}
+		entries, err := invoke(ctx, "Scan", scanReq, scan)
+		require.NoError(t, err)
+		require.Len(t, entries.(*schema.Entries).Entries, 1)
+		require.Equal(t, txID, scanReq.SinceTx)
+
+		// reads of a given tx are left as requested
+		getReq = &schema.KeyRequest{Key: []byte("key1"), AtTx: txID}
+		_, err = invoke(ctx, "Get", getReq, get)
+		require.NoError(t, err)
+		require.Zero(t, getReq.SinceTx)
+
+		// waiting is bounded by the session timeout
+		sess.TrackCommittedTx(txID + 100)
+
+		_, err = invoke(ctx, "Get", &schema.KeyRequest{Key: []byte("key1")}, get)
+		require.ErrorIs(t, err, ErrReadYourWritesTimeout)
+	})
+
+	t.Run("writes should not be tracked without read your writes", func(t *testing.T) {
+		ctx := openSession(false)
+
+		sess, err := s.SessManager.GetSessionFromContext(ctx)
+		require.NoError(t, err)
+
+		enabled, _ := sess.GetReadYourWrites()
+		require.False(t, enabled)
+
+		_, err = invoke(ctx, "Set", &schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte("key2"), Value: []byte("value2")}}}, set)
+		require.NoError(t, err)
+		require.Zero(t, sess.GetLastCommittedTxID())
+	})
+}
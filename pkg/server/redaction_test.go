@@ -0,0 +1,119 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/auth"
+	"github.com/codenotary/immudb/pkg/database"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+func TestRestrictedReads(t *testing.T) {
+	s, ctx, closer := newTestServer(t, nil)
+	defer closer()
+
+	_, err := s.SetRedactionRule(ctx, &schema.RedactionRule{
+		Prefix:       []byte("card:"),
+		Mode:         schema.RedactionMode_REDACT_PARTIAL,
+		VisibleChars: 4,
+	})
+	require.NoError(t, err)
+
+	_, err = s.Set(ctx, &schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte("card:1"), Value: []byte("4111111111111111")}}})
+	require.NoError(t, err)
+
+	_, err = s.CreateUser(ctx, &schema.CreateUserRequest{
+		User:       []byte("auditor"),
+		Password:   []byte("Pa$$w0rd1"),
+		Permission: auth.PermissionRestrictedR,
+		Database:   DefaultDBName,
+	})
+	require.NoError(t, err)
+
+	ulr, err := s.Login(context.Background(), &schema.LoginRequest{
+		User:     []byte("auditor"),
+		Password: []byte("Pa$$w0rd1"),
+	})
+	require.NoError(t, err)
+
+	udb, err := s.UseDatabase(
+		metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", ulr.Token)),
+		&schema.Database{DatabaseName: DefaultDBName},
+	)
+	require.NoError(t, err)
+
+	userCtx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", udb.Token))
+
+	entry, err := s.Get(userCtx, &schema.KeyRequest{Key: []byte("card:1")})
+	require.NoError(t, err)
+	require.Equal(t, []byte("************1111"), entry.Value)
+
+	entry, err = s.Get(ctx, &schema.KeyRequest{Key: []byte("card:1")})
+	require.NoError(t, err)
+	require.Equal(t, []byte("4111111111111111"), entry.Value)
+
+	rules, err := s.RedactionRules(userCtx, &emptypb.Empty{})
+	require.NoError(t, err)
+	require.Len(t, rules.Rules, 1)
+
+	_, err = s.VerifiableGet(userCtx, &schema.VerifiableGetRequest{KeyRequest: &schema.KeyRequest{Key: []byte("card:1")}})
+	require.ErrorIs(t, err, ErrPermissionDenied)
+
+	_, err = s.TxScan(userCtx, &schema.TxScanRequest{InitialTx: 1})
+	require.ErrorIs(t, err, ErrPermissionDenied)
+
+	_, err = s.Set(userCtx, &schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte("card:2"), Value: []byte("4111")}}})
+	require.ErrorIs(t, err, ErrPermissionDenied)
+
+	_, err = s.SetRedactionRule(userCtx, &schema.RedactionRule{Prefix: []byte("card:"), Mode: schema.RedactionMode_REDACT_FULL})
+	require.ErrorIs(t, err, ErrPermissionDenied)
+
+	t.Run("restricted readers should not open transactions", func(t *testing.T) {
+		res, err := s.OpenSession(context.Background(), &schema.OpenSessionRequest{
+			Username:     []byte("auditor"),
+			Password:     []byte("Pa$$w0rd1"),
+			DatabaseName: DefaultDBName,
+		})
+		require.NoError(t, err)
+
+		sessCtx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("sessionid", res.SessionID))
+
+		entry, err := s.Get(sessCtx, &schema.KeyRequest{Key: []byte("card:1")})
+		require.NoError(t, err)
+		require.Equal(t, []byte("************1111"), entry.Value)
+
+		_, err = s.NewTx(sessCtx, &schema.NewTxRequest{Mode: schema.TxMode_ReadOnly})
+		require.ErrorIs(t, err, ErrPermissionDenied)
+	})
+
+	_, err = s.DeleteRedactionRule(ctx, &schema.RedactionRuleRequest{Prefix: []byte("card:")})
+	require.NoError(t, err)
+
+	_, err = s.DeleteRedactionRule(ctx, &schema.RedactionRuleRequest{Prefix: []byte("card:")})
+	require.ErrorIs(t, err, database.ErrRedactionRuleNotFound)
+	require.Equal(t, ErrRedactionRuleNotFound, mapServerError(err))
+
+	entry, err = s.Get(userCtx, &schema.KeyRequest{Key: []byte("card:1")})
+	require.NoError(t, err)
+	require.Equal(t, []byte("4111111111111111"), entry.Value)
+}
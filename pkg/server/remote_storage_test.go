@@ -347,6 +347,7 @@ func TestRemoteStorageUsedForNewDB(t *testing.T) {
 	s := DefaultServer()
 	err := s.Initialize()
 	require.NoError(t, err)
+	defer s.Listener.Close()
 
 	m := memory.Open()
 	s.remoteStorage = m
@@ -0,0 +1,202 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package server
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/auth"
+	"github.com/codenotary/immudb/pkg/database"
+	"github.com/codenotary/immudb/pkg/replication"
+	"github.com/golang/protobuf/ptypes/empty"
+)
+
+// ReplicationStatus returns the progress of the replication of a replica database
+func (s *ImmuServer) ReplicationStatus(ctx context.Context, req *schema.ReplicationRequest) (*schema.ReplicationStatusResponse, error) {
+	replicator, err := s.replicatorFor(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	st, err := replicator.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &schema.ReplicationStatusResponse{
+		Database:       req.Database,
+		Running:        st.Running,
+		Paused:         st.Paused,
+		Connected:      st.Connected,
+		LastTx:         st.LastTx,
+		MasterTx:       st.MasterTx,
+		FailedAttempts: uint32(st.FailedAttempts),
+	}
+
+	if st.MasterTx > st.LastTx {
+		res.Lag = st.MasterTx - st.LastTx
+	}
+
+	if st.LastError != nil {
+		res.LastError = st.LastError.Error()
+	}
+
+	return res, nil
+}
+
+// PauseReplication suspends the replication of a replica database, e.g. during maintenance windows
+func (s *ImmuServer) PauseReplication(ctx context.Context, req *schema.ReplicationRequest) (*empty.Empty, error) {
+	replicator, err := s.replicatorFor(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	err = replicator.Pause()
+	if err != nil {
+		return nil, err
+	}
+
+	return &empty.Empty{}, nil
+}
+
+// ResumeReplication continues a paused replication
+func (s *ImmuServer) ResumeReplication(ctx context.Context, req *schema.ReplicationRequest) (*empty.Empty, error) {
+	replicator, err := s.replicatorFor(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	err = replicator.Resume()
+	if err != nil {
+		return nil, err
+	}
+
+	return &empty.Empty{}, nil
+}
+
+// ResetReplication discards all the data of a replica database and replicates it again from the first transaction
+func (s *ImmuServer) ResetReplication(ctx context.Context, req *schema.ReplicationRequest) (*empty.Empty, error) {
+	if req == nil {
+		return nil, ErrIllegalArguments
+	}
+
+	if s.Options.GetMaintenance() {
+		return nil, ErrNotAllowedInMaintenanceMode
+	}
+
+	err := s.checkDatabaseAdmin(ctx, req.Database)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Database == s.Options.defaultDBName || req.Database == SystemDBName {
+		return nil, ErrReservedDatabase
+	}
+
+	if s.remoteStorage != nil {
+		return nil, fmt.Errorf("%w: replicas using remote storage can not be reset", ErrNotSupported)
+	}
+
+	id := s.dbList.GetId(req.Database)
+	if id < 0 {
+		return nil, database.ErrDatabaseNotExists
+	}
+
+	dbOpts, err := s.loadDBOptions(req.Database, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if !dbOpts.isReplicatorRequired() {
+		return nil, ErrReplicatorNotNeeded
+	}
+
+	s.Logger.Infof("Resetting replica database '%s'...", req.Database)
+
+	err = s.stopReplicationFor(req.Database)
+	if err != nil && err != ErrReplicationNotInProgress {
+		return nil, err
+	}
+
+	db := s.dbList.GetByIndex(id)
+
+	err = db.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.OS.RemoveAll(s.OS.Join(db.GetOptions().GetDBRootPath(), db.GetName()))
+	if err != nil {
+		return nil, err
+	}
+
+	db, err = database.NewDB(dbOpts.Database, s.databaseOptionsFrom(dbOpts), s.Logger)
+	if err != nil {
+		return nil, err
+	}
+
+	s.dbList.Replace(id, db)
+
+	err = s.startReplicationFor(db, dbOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	s.Logger.Infof("Replica database '%s' successfully reset", req.Database)
+
+	return &empty.Empty{}, nil
+}
+
+func (s *ImmuServer) replicatorFor(ctx context.Context, req *schema.ReplicationRequest) (*replication.TxReplicator, error) {
+	if req == nil {
+		return nil, ErrIllegalArguments
+	}
+
+	err := s.checkDatabaseAdmin(ctx, req.Database)
+	if err != nil {
+		return nil, err
+	}
+
+	s.replicationMutex.Lock()
+	defer s.replicationMutex.Unlock()
+
+	replicator, ok := s.replicators[req.Database]
+	if !ok {
+		return nil, ErrReplicationNotInProgress
+	}
+
+	return replicator, nil
+}
+
+// checkDatabaseAdmin checks the logged in user is a system admin or has admin permission on the database
+func (s *ImmuServer) checkDatabaseAdmin(ctx context.Context, dbname string) error {
+	if !s.Options.GetAuth() {
+		return ErrAuthMustBeEnabled
+	}
+
+	_, user, err := s.getLoggedInUserdataFromCtx(ctx)
+	if err != nil {
+		return fmt.Errorf("could not get loggedin user data")
+	}
+
+	if !user.IsSysAdmin && !user.HasPermission(dbname, auth.PermissionAdmin) {
+		return ErrPermissionDenied
+	}
+
+	return s.checkDatabaseNetworkRules(ctx, requestMethod(ctx), dbname)
+}
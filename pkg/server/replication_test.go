@@ -0,0 +1,104 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/database"
+	"github.com/codenotary/immudb/pkg/replication"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplicationManagementEdgeCases(t *testing.T) {
+	s, ctx, closer := newTestServer(t, nil)
+	defer closer()
+
+	_, err := s.ReplicationStatus(ctx, nil)
+	require.Equal(t, ErrIllegalArguments, err)
+
+	_, err = s.ResetReplication(ctx, nil)
+	require.Equal(t, ErrIllegalArguments, err)
+
+	_, err = s.PauseReplication(context.Background(), &schema.ReplicationRequest{Database: "defaultdb"})
+	require.Error(t, err)
+
+	_, err = s.ReplicationStatus(ctx, &schema.ReplicationRequest{Database: "defaultdb"})
+	require.Equal(t, ErrReplicationNotInProgress, err)
+
+	_, err = s.ResumeReplication(ctx, &schema.ReplicationRequest{Database: "defaultdb"})
+	require.Equal(t, ErrReplicationNotInProgress, err)
+
+	_, err = s.ResetReplication(ctx, &schema.ReplicationRequest{Database: "defaultdb"})
+	require.Equal(t, ErrReservedDatabase, err)
+
+	_, err = s.ResetReplication(ctx, &schema.ReplicationRequest{Database: "nodb"})
+	require.Equal(t, database.ErrDatabaseNotExists, err)
+
+	_, err = s.CreateDatabaseWith(ctx, &schema.DatabaseSettings{DatabaseName: "notreplica"})
+	require.NoError(t, err)
+
+	_, err = s.ResetReplication(ctx, &schema.ReplicationRequest{Database: "notreplica"})
+	require.Equal(t, ErrReplicatorNotNeeded, err)
+
+	_, err = s.CreateDatabaseWith(ctx, &schema.DatabaseSettings{
+		DatabaseName:   "replicadb",
+		Replica:        true,
+		MasterDatabase: "defaultdb",
+		MasterAddress:  "127.0.0.1",
+		MasterPort:     1,
+	})
+	require.NoError(t, err)
+
+	req := &schema.ReplicationRequest{Database: "replicadb"}
+
+	st, err := s.ReplicationStatus(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, "replicadb", st.Database)
+	require.True(t, st.Running)
+	require.False(t, st.Paused)
+
+	_, err = s.PauseReplication(ctx, req)
+	require.NoError(t, err)
+
+	_, err = s.PauseReplication(ctx, req)
+	require.ErrorIs(t, err, replication.ErrAlreadyPaused)
+
+	st, err = s.ReplicationStatus(ctx, req)
+	require.NoError(t, err)
+	require.True(t, st.Paused)
+
+	_, err = s.ResumeReplication(ctx, req)
+	require.NoError(t, err)
+
+	_, err = s.ResumeReplication(ctx, req)
+	require.ErrorIs(t, err, replication.ErrNotPaused)
+
+	_, err = s.ResetReplication(ctx, req)
+	require.NoError(t, err)
+
+	st, err = s.ReplicationStatus(ctx, req)
+	require.NoError(t, err)
+	require.True(t, st.Running)
+	require.Zero(t, st.LastTx)
+
+	db, err := s.dbList.GetByName("replicadb")
+	require.NoError(t, err)
+	require.True(t, db.IsReplica())
+}
@@ -0,0 +1,36 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+)
+
+// RequestDeadlineInterceptor bounds the execution time of unary requests to the max request duration of the server,
+// deadlines set by clients are kept when shorter. Streams are not bounded as replication and exports are long-lived
+func (s *ImmuServer) RequestDeadlineInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if s.Options.MaxRequestDuration <= 0 {
+		return handler(ctx, req)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.Options.MaxRequestDuration)
+	defer cancel()
+
+	return handler(ctx, req)
+}
@@ -0,0 +1,67 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+func TestRequestDeadlineInterceptor(t *testing.T) {
+	s := DefaultServer()
+
+	deadline := func(ctx context.Context, req interface{}) (interface{}, error) {
+		d, ok := ctx.Deadline()
+		if !ok {
+			return nil, nil
+		}
+		return time.Until(d), nil
+	}
+
+	res, err := s.RequestDeadlineInterceptor(context.Background(), nil, &grpc.UnaryServerInfo{}, deadline)
+	require.NoError(t, err)
+	require.Nil(t, res)
+
+	s.Options.WithMaxRequestDuration(time.Minute)
+
+	res, err = s.RequestDeadlineInterceptor(context.Background(), nil, &grpc.UnaryServerInfo{}, deadline)
+	require.NoError(t, err)
+	require.LessOrEqual(t, res.(time.Duration), time.Minute)
+	require.Greater(t, res.(time.Duration), 50*time.Second)
+
+	// shorter deadlines set by clients are kept
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	res, err = s.RequestDeadlineInterceptor(ctx, nil, &grpc.UnaryServerInfo{}, deadline)
+	require.NoError(t, err)
+	require.LessOrEqual(t, res.(time.Duration), time.Second)
+
+	blocking := func(ctx context.Context, req interface{}) (interface{}, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+
+	s.Options.WithMaxRequestDuration(time.Millisecond)
+
+	_, err = s.RequestDeadlineInterceptor(context.Background(), nil, &grpc.UnaryServerInfo{}, blocking)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
@@ -0,0 +1,45 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"testing"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecScript(t *testing.T) {
+	s, ctx, closer := newTestServer(t, nil)
+	defer closer()
+
+	req := &schema.ExecScriptRequest{Script: "set('k1', 'v1'); return get('k1')"}
+
+	_, err := s.ExecScript(ctx, req)
+	require.ErrorIs(t, err, ErrScriptingDisabled)
+
+	s.Options.Scripting = true
+
+	res, err := s.ExecScript(ctx, req)
+	require.NoError(t, err)
+	require.NotNil(t, res.TxHeader)
+	require.Equal(t, []byte("v1"), res.Result.GetBs())
+
+	entry, err := s.Get(ctx, &schema.KeyRequest{Key: []byte("k1"), SinceTx: res.TxHeader.Id})
+	require.NoError(t, err)
+	require.Equal(t, []byte("v1"), entry.Value)
+}
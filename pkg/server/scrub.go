@@ -0,0 +1,107 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/database"
+	"github.com/golang/protobuf/ptypes/empty"
+)
+
+// ScrubAsync starts the scrub of the selected database as a background job, scrub jobs can not be canceled.
+// The job fails if corrupted transactions are found, they are reported by ScrubStatus.
+func (s *ImmuServer) ScrubAsync(ctx context.Context, _ *empty.Empty) (*schema.Job, error) {
+	db, err := s.getDBFromCtx(ctx, "ScrubAsync")
+	if err != nil {
+		return nil, err
+	}
+
+	// the database may already be scrubbed in background, as set by its scrub interval
+	if db.ScrubStatus().Running {
+		return nil, store.ErrScrubInProgress
+	}
+
+	return s.jobs.start(schema.JobKindScrub, db.GetName(), false, scrubProgress(db), func(ctx context.Context) error {
+		status, err := db.Scrub()
+		if err != nil {
+			s.Logger.Warningf("Scrub of database '%s' failed: %v", db.GetName(), err)
+			return err
+		}
+
+		if status.MismatchCount > 0 {
+			s.Logger.Errorf("Scrub of database '%s' found %d corrupted transactions", db.GetName(), status.MismatchCount)
+			return ErrCorruptedData
+		}
+
+		return nil
+	})
+}
+
+// ScrubStatus returns the status of the ongoing scrub of the selected database or the last one
+func (s *ImmuServer) ScrubStatus(ctx context.Context, _ *empty.Empty) (*schema.ScrubStatusResponse, error) {
+	db, err := s.getDBFromCtx(ctx, "ScrubStatus")
+	if err != nil {
+		return nil, err
+	}
+
+	return scrubStatusToProto(db.GetName(), db.ScrubStatus()), nil
+}
+
+func scrubProgress(db database.DB) jobProgressFunc {
+	return func() float32 {
+		status := db.ScrubStatus()
+		if status.TargetTxID == 0 {
+			return 0
+		}
+		return float32(status.ScrubbedTxs) * 100 / float32(status.TargetTxID)
+	}
+}
+
+func scrubStatusToProto(dbName string, status store.ScrubStatus) *schema.ScrubStatusResponse {
+	res := &schema.ScrubStatusResponse{
+		Database:      dbName,
+		Running:       status.Running,
+		ScrubbedTxs:   status.ScrubbedTxs,
+		TargetTxId:    status.TargetTxID,
+		Mismatches:    make([]*schema.ScrubMismatch, len(status.Mismatches)),
+		MismatchCount: status.MismatchCount,
+	}
+
+	if !status.StartedAt.IsZero() {
+		res.StartedAt = status.StartedAt.Unix()
+	}
+
+	if !status.FinishedAt.IsZero() {
+		res.FinishedAt = status.FinishedAt.Unix()
+	}
+
+	for i, m := range status.Mismatches {
+		res.Mismatches[i] = &schema.ScrubMismatch{
+			TxId:   m.TxID,
+			Reason: m.Reason,
+		}
+	}
+
+	if status.Err != nil {
+		res.Error = status.Err.Error()
+	}
+
+	return res
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+func TestScrub(t *testing.T) {
+	s, ctx, closer := newTestServer(t, nil)
+	defer closer()
+
+	t.Run("scrubs should be started on demand", func(t *testing.T) {
+		status, err := s.ScrubStatus(ctx, &emptypb.Empty{})
+		require.NoError(t, err)
+		require.Equal(t, DefaultDBName, status.Database)
+		require.Zero(t, status.StartedAt)
+
+		for i := 0; i < 10; i++ {
+			_, err = s.Set(ctx, &schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte{byte(i)}, Value: []byte{byte(i)}}}})
+			require.NoError(t, err)
+		}
+
+		job, err := s.ScrubAsync(ctx, &emptypb.Empty{})
+		require.NoError(t, err)
+		require.Equal(t, schema.JobKindScrub, job.Kind)
+		require.Equal(t, DefaultDBName, job.Database)
+		require.False(t, job.Cancelable)
+
+		job = waitForJob(t, s, ctx, job.Id)
+		require.Equal(t, schema.JobStatusCompleted, job.Status)
+		require.Equal(t, float32(100), job.Progress)
+
+		status, err = s.ScrubStatus(ctx, &emptypb.Empty{})
+		require.NoError(t, err)
+		require.False(t, status.Running)
+		require.NotZero(t, status.StartedAt)
+		require.NotZero(t, status.FinishedAt)
+		require.Equal(t, status.TargetTxId, status.ScrubbedTxs)
+		require.Zero(t, status.MismatchCount)
+		require.Empty(t, status.Error)
+	})
+
+	t.Run("databases should be scrubbed in background as set in their settings", func(t *testing.T) {
+		settings, err := s.CreateDatabaseWithV2(ctx, &schema.DatabaseSettingsV2{
+			DatabaseName:     "db1",
+			ScrubInterval:    &schema.ConditionalUint64{Value: 10},
+			DelayDuringScrub: &schema.ConditionalUint32{Value: 1},
+		})
+		require.NoError(t, err)
+		require.Equal(t, uint64(10), settings.ScrubInterval.Value)
+		require.Equal(t, uint32(1), settings.DelayDuringScrub.Value)
+
+		ur, err := s.UseDatabase(ctx, &schema.Database{DatabaseName: "db1"})
+		require.NoError(t, err)
+
+		dbCtx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", ur.Token))
+
+		require.Eventually(t, func() bool {
+			status, err := s.ScrubStatus(dbCtx, &emptypb.Empty{})
+			require.NoError(t, err)
+			require.Equal(t, "db1", status.Database)
+			return status.FinishedAt > 0
+		}, 5*time.Second, 10*time.Millisecond)
+
+		res, err := s.UpdateDatabaseV2(ctx, &schema.DatabaseSettingsV2{
+			DatabaseName:  "db1",
+			ScrubInterval: &schema.ConditionalUint64{Value: 0},
+		})
+		require.NoError(t, err)
+		require.Zero(t, res.CurrentSettings.ScrubInterval.Value)
+		require.Equal(t, []string{"scrubInterval"}, res.ReloadRequired)
+	})
+}
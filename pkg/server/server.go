@@ -35,6 +35,7 @@ import (
 	"github.com/codenotary/immudb/embedded/store"
 	"github.com/codenotary/immudb/pkg/errors"
 	"github.com/codenotary/immudb/pkg/replication"
+	"github.com/codenotary/immudb/pkg/witness"
 
 	pgsqlsrv "github.com/codenotary/immudb/pkg/pgsql/server"
 
@@ -48,6 +49,7 @@ import (
 	"github.com/codenotary/immudb/cmd/helper"
 	"github.com/codenotary/immudb/cmd/version"
 	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/api/schemav2"
 	"github.com/codenotary/immudb/pkg/auth"
 	"github.com/golang/protobuf/ptypes/empty"
 	grpc_middleware "github.com/grpc-ecosystem/go-grpc-middleware"
@@ -55,6 +57,8 @@ import (
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
 	"google.golang.org/grpc/status"
 )
 
@@ -63,6 +67,10 @@ const (
 	KeyPrefixUser = iota + 1
 	//KeyPrefixDBSettings is used for entries related to database settings
 	KeyPrefixDBSettings
+	//KeyPrefixMultiDBTx is used for entries recording multi-database transactions
+	KeyPrefixMultiDBTx
+	//KeyPrefixSlowQuery is used for entries recording slow queries
+	KeyPrefixSlowQuery
 )
 
 var startedAt time.Time
@@ -114,6 +122,14 @@ func (s *ImmuServer) Initialize() error {
 		return logErr(s.Logger, "Unable to initialize remote storage: %v", err)
 	}
 
+	if err = s.loadDatabaseSettings(); err != nil {
+		return logErr(s.Logger, "Unable to load database settings: %v", err)
+	}
+
+	if err = s.loadTenants(); err != nil {
+		return logErr(s.Logger, "Unable to load tenants: %v", err)
+	}
+
 	if err = s.loadSystemDatabase(dataDir, remoteStorage, adminPassword); err != nil {
 		return logErr(s.Logger, "Unable to load system database: %v", err)
 	}
@@ -135,14 +151,26 @@ func (s *ImmuServer) Initialize() error {
 		if err = s.loadUserDatabases(dataDir, remoteStorage); err != nil {
 			return logErr(s.Logger, "Unable load databases: %v", err)
 		}
+
+		if err = s.recoverMultiDBTxs(); err != nil {
+			return logErr(s.Logger, "Unable to recover multi-database transactions: %v", err)
+		}
 	}
 
+	s.startWitnesses()
+
 	s.multidbmode = s.mandatoryAuth()
 	if !s.Options.GetAuth() && s.multidbmode {
 		return ErrAuthMustBeEnabled
 	}
 
 	grpcSrvOpts := []grpc.ServerOption{}
+	if s.Options.ACMEOptions.enabled() {
+		if err := s.setUpACME(); err != nil {
+			return logErr(s.Logger, "Unable to set up ACME: %v", err)
+		}
+	}
+
 	if s.Options.TLSConfig != nil {
 		grpcSrvOpts = []grpc.ServerOption{grpc.Creds(credentials.NewTLS(s.Options.TLSConfig))}
 	}
@@ -150,16 +178,28 @@ func (s *ImmuServer) Initialize() error {
 	if s.Options.SigningKey != "" {
 		if signer, err := signer.NewSigner(s.Options.SigningKey); err != nil {
 			return logErr(s.Logger, "Unable to configure the cryptographic signer: %v", err)
+		} else if retiredKeys, err := loadRetiredSigningKeys(s.Options.RetiredSigningKeys); err != nil {
+			return logErr(s.Logger, "Unable to load the retired signing keys: %v", err)
 		} else {
-			s.StateSigner = NewStateSigner(signer)
+			s.StateSigner = NewStateSigner(signer, retiredKeys...)
 		}
 	}
 
+	s.startStateSigning()
+
+	s.startAutoCompaction()
+
+	if !s.sysDB.IsReplica() {
+		s.startMultiDBTxRetry()
+	}
+
+	s.startMemoryBudget()
+
 	if s.Options.usingCustomListener {
 		s.Logger.Infof("Using custom listener")
 		s.Listener = s.Options.listener
 	} else {
-		s.Listener, err = net.Listen(s.Options.Network, s.Options.Bind())
+		s.Listener, err = s.listen()
 		if err != nil {
 			return logErr(s.Logger, "Immudb unable to listen: %v", err)
 		}
@@ -200,18 +240,29 @@ func (s *ImmuServer) Initialize() error {
 	}
 	//<===
 
+	s.networkRules, err = newNetworkRules(s.Options.NetworkRulesOptions)
+	if err != nil {
+		return logErr(s.Logger, "Invalid network rules: %v", err)
+	}
+
 	uuidContext := NewUUIDContext(s.UUID)
 
 	uis := []grpc.UnaryServerInterceptor{
 		ErrorMapper, // converts errors in gRPC ones. Need to be the first
+		// rejected clients are never authenticated
+		s.NetworkRulesInterceptor,
+		s.RequestDeadlineInterceptor,
 		s.KeepAliveSessionInterceptor,
+		s.DeprecationInterceptor, // headers must be set before the uuid one is sent
 		uuidContext.UUIDContextSetter,
 		grpc_prometheus.UnaryServerInterceptor,
 		auth.ServerUnaryInterceptor,
 		s.SessionAuthInterceptor,
+		s.ReadYourWritesInterceptor,
 	}
 	sss := []grpc.StreamServerInterceptor{
 		ErrorMapperStream, // converts errors in gRPC ones. Need to be the first
+		s.NetworkRulesStreamInterceptor,
 		s.KeepALiveSessionStreamInterceptor,
 		uuidContext.UUIDStreamContextSetter,
 		grpc_prometheus.StreamServerInterceptor,
@@ -226,14 +277,19 @@ func (s *ImmuServer) Initialize() error {
 
 	s.GrpcServer = grpc.NewServer(grpcSrvOpts...)
 	schema.RegisterImmuServiceServer(s.GrpcServer, s)
+	schemav2.RegisterImmuServiceServer(s.GrpcServer, s.V2())
 	grpc_prometheus.Register(s.GrpcServer)
 
+	if s.Options.GrpcReflection {
+		reflection.Register(s.GrpcServer)
+	}
+
 	s.SessManager, err = sessions.NewManager(s.Options.SessionsOptions)
 	if err != nil {
 		return err
 	}
 
-	s.PgsqlSrv = pgsqlsrv.New(pgsqlsrv.Address(s.Options.Address), pgsqlsrv.Port(s.Options.PgsqlServerPort), pgsqlsrv.DatabaseList(s.dbList), pgsqlsrv.SysDb(s.sysDB), pgsqlsrv.TlsConfig(s.Options.TLSConfig), pgsqlsrv.Logger(s.Logger))
+	s.PgsqlSrv = pgsqlsrv.New(pgsqlsrv.Address(s.Options.tcpAddress()), pgsqlsrv.Port(s.Options.PgsqlServerPort), pgsqlsrv.DatabaseList(s.dbList), pgsqlsrv.SysDb(s.sysDB), pgsqlsrv.TlsConfig(s.Options.TLSConfig), pgsqlsrv.Logger(s.Logger), pgsqlsrv.ClientAddressCheck(s.checkPgsqlClientAddress))
 	if s.Options.PgsqlServer {
 		if err = s.PgsqlSrv.Initialize(); err != nil {
 			return err
@@ -262,6 +318,15 @@ func (s *ImmuServer) Start() (err error) {
 		}()
 	}
 
+	if s.acmeManager != nil && s.Options.ACMEOptions.HTTPChallengeAddress != "" {
+		s.setUpACMEChallengeServer()
+		defer func() {
+			if err := s.acmeChallengeServer.Close(); err != nil {
+				s.Logger.Errorf("Failed to shutdown ACME challenge server: %s", err)
+			}
+		}()
+	}
+
 	s.installShutdownHandler()
 
 	go func() {
@@ -325,10 +390,65 @@ func (s *ImmuServer) setupPidFile() error {
 	return err
 }
 
+// UnixSocketPerm is the access mode of the unix socket the server listens on,
+// only the owner and the group of the immudb user are allowed to connect
+const UnixSocketPerm = 0660
+
+func (s *ImmuServer) listen() (net.Listener, error) {
+	path, ok := s.Options.UnixSocket()
+	if !ok {
+		return net.Listen(s.Options.BindNetwork(), s.Options.Bind())
+	}
+
+	l, err := net.Listen(s.Options.BindNetwork(), path)
+	if err != nil && isStaleUnixSocket(path) {
+		// the socket was left behind by a server which was not gracefully stopped
+		if err := os.Remove(path); err != nil {
+			return nil, err
+		}
+		l, err = net.Listen(s.Options.BindNetwork(), path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.Chmod(path, UnixSocketPerm); err != nil {
+		l.Close()
+		return nil, err
+	}
+
+	return l, nil
+}
+
+func isStaleUnixSocket(path string) bool {
+	fi, err := os.Stat(path)
+	if err != nil || fi.Mode()&os.ModeSocket == 0 {
+		return false
+	}
+
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		return true
+	}
+	conn.Close()
+
+	return false
+}
+
 func (s *ImmuServer) setUpMetricsServer() error {
+	Metrics.WithComputeDBDiskQuotaExceeded(s.metricFuncComputeDBDiskQuotaExceeded)
+	Metrics.WithComputeTenantUsage(s.tenantUsage)
+
+	tlsConfig, err := metricsTLSConfig(s.Options.MetricsOptions, s.Options.TLSConfig)
+	if err != nil {
+		return err
+	}
+
 	s.metricsServer = StartMetrics(
 		1*time.Minute,
 		s.Options.MetricsBind(),
+		s.Options.MetricsOptions,
+		tlsConfig,
 		s.Logger,
 		s.metricFuncServerUptimeCounter,
 		s.metricFuncComputeDBSizes,
@@ -338,10 +458,18 @@ func (s *ImmuServer) setUpMetricsServer() error {
 }
 
 func (s *ImmuServer) setUpWebServer() error {
+	var grpcWebSrv *grpc.Server
+	if s.Options.GrpcWeb {
+		grpcWebSrv = s.GrpcServer
+	}
+
 	server, err := StartWebServer(
 		s.Options.WebBind(),
 		s.Options.TLSConfig,
 		s,
+		s.V2(),
+		grpcWebSrv,
+		s.Options.GrpcWebAllowedOrigins,
 		s.Logger,
 	)
 	if err != nil {
@@ -530,6 +658,7 @@ func (s *ImmuServer) loadUserDatabases(dataDir string, remoteStorage remotestora
 		}
 
 		s.logDBOptions(dbname, dbOpts)
+		s.loadTenantDatabase(dbOpts.Tenant, dbname)
 
 		db, err := database.OpenDB(dbname, s.databaseOptionsFrom(dbOpts), s.Logger)
 		if err != nil {
@@ -537,6 +666,9 @@ func (s *ImmuServer) loadUserDatabases(dataDir string, remoteStorage remotestora
 		}
 
 		db.AsReplica(dbOpts.Replica)
+		db.AsInMaintenance(dbOpts.MaintenanceMode)
+		db.AllowAnonymousReads(dbOpts.AnonymousReads)
+		db.EnableReverseReferenceIndex(dbOpts.ReverseReferenceIndex)
 
 		if dbOpts.isReplicatorRequired() {
 			err = s.startReplicationFor(db, dbOpts)
@@ -621,6 +753,72 @@ func (s *ImmuServer) stopReplication() {
 	}
 }
 
+func (s *ImmuServer) startWitnesses() {
+	for _, wOpts := range s.Options.WitnessOptions {
+		err := s.startWitnessFor(wOpts)
+		if err != nil {
+			s.Logger.Errorf("Error starting witness of '%s' for database '%s'. Reason: %v", wOpts.Source, wOpts.Database, err)
+		}
+	}
+}
+
+func (s *ImmuServer) startWitnessFor(wOpts *WitnessOptions) error {
+	db, err := s.dbList.GetByName(wOpts.Database)
+	if err != nil {
+		return err
+	}
+
+	witnessOpts := witness.DefaultOptions().
+		WithSource(wOpts.Source).
+		WithSrcDatabase(wOpts.SrcDatabase).
+		WithSrcAddress(wOpts.SrcAddress).
+		WithSrcPort(wOpts.SrcPort).
+		WithSrcUsername(wOpts.SrcUsername).
+		WithSrcPassword(wOpts.SrcPassword)
+
+	if wOpts.FetchInterval > 0 {
+		witnessOpts.WithFetchInterval(wOpts.FetchInterval)
+	}
+
+	alertSinks, err := s.Options.AlertOptions.Sinks()
+	if err != nil {
+		return err
+	}
+	if len(alertSinks) > 0 {
+		witnessOpts.WithAlertSink(alertSinks)
+	}
+
+	fetcher, err := witness.NewImmudbFetcher(witnessOpts)
+	if err != nil {
+		return err
+	}
+
+	w, err := witness.NewWitness(db, fetcher, witnessOpts, s.Logger)
+	if err != nil {
+		return err
+	}
+
+	err = w.Start()
+	if err != nil {
+		return err
+	}
+
+	s.witnesses = append(s.witnesses, w)
+
+	return nil
+}
+
+func (s *ImmuServer) stopWitnesses() {
+	for _, w := range s.witnesses {
+		err := w.Stop()
+		if err != nil {
+			s.Logger.Warningf("Error stopping witness. Reason: %v", err)
+		}
+	}
+
+	s.witnesses = nil
+}
+
 // Stop stops the immudb server
 func (s *ImmuServer) Stop() error {
 	s.mux.Lock()
@@ -639,11 +837,28 @@ func (s *ImmuServer) Stop() error {
 
 	s.stopReplication()
 
+	s.stopWitnesses()
+
+	s.stopStateSigning()
+
+	s.stopAutoCompaction()
+
+	s.stopMultiDBTxRetry()
+
+	s.stopMemoryBudget()
+
+	s.jobs.stop()
+
 	return s.CloseDatabases()
 }
 
 //CloseDatabases closes all opened databases including the consinstency checker
 func (s *ImmuServer) CloseDatabases() error {
+	// no index is left to be compacted once databases get closed
+	s.stopAutoCompaction()
+
+	s.stopMultiDBTxRetry()
+
 	for i := 0; i < s.dbList.Length(); i++ {
 		val := s.dbList.GetByIndex(int64(i))
 		val.Close()
@@ -710,6 +925,50 @@ func (s *ImmuServer) Health(ctx context.Context, _ *empty.Empty) (*schema.Health
 	return &schema.HealthResponse{Status: true, Version: fmt.Sprintf("%s", Version.Version)}, nil
 }
 
+// apiVersions are the versions of the API served by the server
+var apiVersions = []string{"v1", "v2"}
+
+// ServerInfo returns the version of the server, the API versions it serves and its enabled features,
+// so clients can adapt to the server without parsing its version
+func (s *ImmuServer) ServerInfo(ctx context.Context, _ *schema.ServerInfoRequest) (*schema.ServerInfoResponse, error) {
+	return &schema.ServerInfoResponse{
+		Version:     Version.Version,
+		ApiVersions: apiVersions,
+		Features:    s.features(),
+	}, nil
+}
+
+func (s *ImmuServer) features() []string {
+	var features []string
+
+	if s.Options.ReplicationOptions != nil {
+		features = append(features, "replication")
+	}
+	if s.Options.RemoteStorageOptions != nil && s.Options.RemoteStorageOptions.S3Storage {
+		features = append(features, "s3")
+	}
+	if s.Options.PgsqlServer {
+		features = append(features, "pgsql")
+	}
+	if s.Options.WebServer {
+		features = append(features, "webconsole")
+	}
+	if s.Options.MetricsServer {
+		features = append(features, "metrics")
+	}
+	if s.Options.Scripting {
+		features = append(features, "scripting")
+	}
+	if s.Options.GrpcReflection {
+		features = append(features, "reflection")
+	}
+	if s.Options.WebServer && s.Options.GrpcWeb {
+		features = append(features, "grpc-web")
+	}
+
+	return features
+}
+
 func (s *ImmuServer) installShutdownHandler() {
 	c := make(chan os.Signal)
 	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
@@ -777,38 +1036,38 @@ func (s *ImmuServer) CreateDatabaseWithV2(ctx context.Context, req *schema.Datab
 		return nil, fmt.Errorf("logged In user does not have permissions for this operation")
 	}
 
-	if req.DatabaseName == SystemDBName {
-		return nil, fmt.Errorf("this database name is reserved")
-	}
-
-	if strings.ToLower(req.DatabaseName) != req.DatabaseName {
-		return nil, fmt.Errorf("provide a lowercase database name")
+	err = s.checkNewDatabaseName(req.DatabaseName)
+	if err != nil {
+		return nil, err
 	}
 
-	req.DatabaseName = strings.ToLower(req.DatabaseName)
-	if err = isValidDBName(req.DatabaseName); err != nil {
+	err = s.checkTenant(req.Tenant)
+	if err != nil {
 		return nil, err
 	}
 
-	//check if database exists
-	if s.dbList.GetId(req.GetDatabaseName()) >= 0 {
-		return nil, fmt.Errorf("database '%s' already exists", req.GetDatabaseName())
+	dbOpts, err := s.newDBOptions(req)
+	if err != nil {
+		return nil, err
 	}
 
-	dbOpts := s.defaultDBOptions(req.DatabaseName)
+	dbOpts.Tenant = req.Tenant
+	dbOpts.CreatedBy = user.Username
 
-	err = s.overwriteWith(dbOpts, req, false)
+	err = s.addTenantDatabase(dbOpts.Tenant, dbOpts.Database)
 	if err != nil {
 		return nil, err
 	}
 
 	err = s.saveDBOptions(dbOpts)
 	if err != nil {
+		s.removeTenantDatabase(dbOpts.Database)
 		return nil, err
 	}
 
 	db, err := database.NewDB(dbOpts.Database, s.databaseOptionsFrom(dbOpts), s.Logger)
 	if err != nil {
+		s.removeTenantDatabase(dbOpts.Database)
 		return nil, err
 	}
 
@@ -816,6 +1075,11 @@ func (s *ImmuServer) CreateDatabaseWithV2(ctx context.Context, req *schema.Datab
 	s.multidbmode = true
 
 	db.AsReplica(dbOpts.Replica)
+	db.AsInMaintenance(dbOpts.MaintenanceMode)
+	db.AllowAnonymousReads(dbOpts.AnonymousReads)
+	db.EnableReverseReferenceIndex(dbOpts.ReverseReferenceIndex)
+
+	s.rebalanceMemoryBudget()
 
 	err = s.startReplicationFor(db, dbOpts)
 	if err != nil && err != ErrReplicatorNotNeeded {
@@ -825,6 +1089,29 @@ func (s *ImmuServer) CreateDatabaseWithV2(ctx context.Context, req *schema.Datab
 	return dbOpts.databaseSettings(), nil
 }
 
+// checkNewDatabaseName checks a database can be created with the given name
+func (s *ImmuServer) checkNewDatabaseName(dbName string) error {
+	if dbName == SystemDBName {
+		return fmt.Errorf("this database name is reserved")
+	}
+
+	if strings.ToLower(dbName) != dbName {
+		return fmt.Errorf("provide a lowercase database name")
+	}
+
+	err := isValidDBName(dbName)
+	if err != nil {
+		return err
+	}
+
+	//check if database exists
+	if s.dbList.GetId(dbName) >= 0 {
+		return fmt.Errorf("database '%s' already exists", dbName)
+	}
+
+	return nil
+}
+
 // UpdateDatabase Updates database settings
 func (s *ImmuServer) UpdateDatabase(ctx context.Context, req *schema.DatabaseSettings) (*empty.Empty, error) {
 	if req == nil {
@@ -847,69 +1134,109 @@ func (s *ImmuServer) UpdateDatabaseV2(ctx context.Context, req *schema.DatabaseS
 		return nil, ErrIllegalArguments
 	}
 
+	db, user, err := s.checkDatabaseUpdate(ctx, req.DatabaseName)
+	if err != nil {
+		return nil, err
+	}
+
+	s.Logger.Infof("Updating database '%s'...", db.GetName())
+
+	dbOpts, err := s.loadDBOptions(req.DatabaseName, false)
+	if err != nil {
+		return nil, err
+	}
+
+	prevOpts := *dbOpts
+
+	err = s.overwriteWith(dbOpts, req, true)
+	if err != nil {
+		return nil, err
+	}
+
+	dbOpts.UpdatedBy = user.Username
+
+	res, err := s.applyDBOptions(db, dbOpts, &prevOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	s.Logger.Infof("Database '%s' successfully updated", db.GetName())
+
+	return res, nil
+}
+
+// checkDatabaseUpdate checks the settings of the database can be changed by the logged in user
+func (s *ImmuServer) checkDatabaseUpdate(ctx context.Context, dbName string) (database.DB, *auth.User, error) {
 	if s.Options.GetMaintenance() {
-		return nil, ErrNotAllowedInMaintenanceMode
+		return nil, nil, ErrNotAllowedInMaintenanceMode
 	}
 
 	if !s.Options.GetAuth() {
-		return nil, ErrAuthMustBeEnabled
+		return nil, nil, ErrAuthMustBeEnabled
 	}
 
-	if req.DatabaseName == s.Options.defaultDBName || req.DatabaseName == SystemDBName {
-		return nil, ErrReservedDatabase
+	if dbName == s.Options.defaultDBName || dbName == SystemDBName {
+		return nil, nil, ErrReservedDatabase
 	}
 
-	db, err := s.dbList.GetByName(req.DatabaseName)
+	db, err := s.dbList.GetByName(dbName)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	_, user, err := s.getLoggedInUserdataFromCtx(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("could not get loggedin user data")
+		return nil, nil, fmt.Errorf("could not get loggedin user data")
 	}
 
 	//if the requesting user has admin permission on this database
 	if (!user.IsSysAdmin) &&
-		(!user.HasPermission(req.DatabaseName, auth.PermissionAdmin)) {
-		return nil, fmt.Errorf("you do not have permission on this database")
+		(!user.HasPermission(dbName, auth.PermissionAdmin)) {
+		return nil, nil, fmt.Errorf("you do not have permission on this database")
 	}
 
-	s.Logger.Infof("Updating database '%s'...", db.GetName())
+	return db, user, nil
+}
 
-	err = s.stopReplicationFor(req.DatabaseName)
+// applyDBOptions saves the updated options of the database and applies those which don't require a reload
+func (s *ImmuServer) applyDBOptions(db database.DB, dbOpts *dbOptions, prevOpts *dbOptions) (*schema.DatabaseSettingsUpdateResult, error) {
+	err := s.stopReplicationFor(db.GetName())
 	if err != nil && err != ErrReplicationNotInProgress {
-		s.Logger.Errorf("Error stopping replication for database '%s'. Reason: %v", req.DatabaseName, err)
+		s.Logger.Errorf("Error stopping replication for database '%s'. Reason: %v", db.GetName(), err)
 	}
 
-	dbOpts, err := s.loadDBOptions(req.DatabaseName, false)
+	err = s.saveDBOptions(dbOpts)
 	if err != nil {
 		return nil, err
 	}
 
-	err = s.overwriteWith(dbOpts, req, true)
+	db.AsReplica(dbOpts.Replica)
+	db.AsInMaintenance(dbOpts.MaintenanceMode)
+	db.AllowAnonymousReads(dbOpts.AnonymousReads)
+	db.EnableReverseReferenceIndex(dbOpts.ReverseReferenceIndex)
+	db.SetMaxDiskUsage(dbOpts.MaxDiskUsage)
+
+	err = db.UpdateStoreOptions(dbOpts.storeOptions())
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("settings of database '%s' will only be applied once reloaded: %w", db.GetName(), err)
 	}
 
-	dbOpts.UpdatedBy = user.Username
+	// cache sizes set in the settings are the upper bound of the memory budget of the database
+	s.rebalanceMemoryBudget()
 
-	err = s.saveDBOptions(dbOpts)
-	if err != nil {
-		return nil, err
+	reloadRequired := dbOpts.reloadRequired(prevOpts)
+	if len(reloadRequired) > 0 {
+		s.Logger.Infof("Database '%s' must be reloaded to apply the updated settings: %v", db.GetName(), reloadRequired)
 	}
 
-	db.AsReplica(dbOpts.Replica)
-
 	err = s.startReplicationFor(db, dbOpts)
 	if err != nil && err != ErrReplicatorNotNeeded {
 		s.Logger.Errorf("Error starting replication for database '%s'. Reason: %v", db.GetName(), err)
 	}
 
-	s.Logger.Infof("Database '%s' successfully updated", db.GetName())
-
 	return &schema.DatabaseSettingsUpdateResult{
 		CurrentSettings: dbOpts.databaseSettings(),
+		ReloadRequired:  reloadRequired,
 	}, nil
 }
 
@@ -1005,6 +1332,10 @@ func (s *ImmuServer) DatabaseList(ctx context.Context, _ *empty.Empty) (*schema.
 				DatabaseName: val.GetName(),
 			}
 			dbList.Databases = append(dbList.Databases, db)
+
+			if val.IsInMaintenance() {
+				dbList.InMaintenance = append(dbList.InMaintenance, val.GetName())
+			}
 		}
 	} else {
 		for _, val := range loggedInuser.Permissions {
@@ -1012,6 +1343,11 @@ func (s *ImmuServer) DatabaseList(ctx context.Context, _ *empty.Empty) (*schema.
 				DatabaseName: val.Database,
 			}
 			dbList.Databases = append(dbList.Databases, db)
+
+			d, err := s.dbList.GetByName(val.Database)
+			if err == nil && d.IsInMaintenance() {
+				dbList.InMaintenance = append(dbList.InMaintenance, val.Database)
+			}
 		}
 	}
 
@@ -1062,11 +1398,17 @@ func (s *ImmuServer) UseDatabase(ctx context.Context, req *schema.Database) (*sc
 	if (!user.IsSysAdmin) &&
 		(!user.HasPermission(req.DatabaseName, auth.PermissionAdmin)) &&
 		(!user.HasPermission(req.DatabaseName, auth.PermissionR)) &&
-		(!user.HasPermission(req.DatabaseName, auth.PermissionRW)) {
+		(!user.HasPermission(req.DatabaseName, auth.PermissionRW)) &&
+		(!user.HasPermission(req.DatabaseName, auth.PermissionRestrictedR)) {
 
 		return nil, status.Errorf(codes.PermissionDenied, "Logged in user does not have permission on this database")
 	}
 
+	err = s.checkDatabaseNetworkRules(ctx, "UseDatabase", req.DatabaseName)
+	if err != nil {
+		return nil, err
+	}
+
 	token, err := auth.GenerateToken(*user, dbid, s.Options.TokenExpiryTimeMin)
 	if err != nil {
 		return nil, err
@@ -1094,7 +1436,14 @@ func (s *ImmuServer) UseDatabase(ctx context.Context, req *schema.Database) (*sc
 func (s *ImmuServer) getDBFromCtx(ctx context.Context, methodName string) (database.DB, error) {
 	//if auth is disabled and there is not user created databases returns defaultdb
 	if !s.Options.auth && !s.multidbmode && !s.Options.GetMaintenance() {
-		return s.dbList.GetByIndex(defaultDbIndex), nil
+		db := s.dbList.GetByIndex(defaultDbIndex)
+
+		err := s.checkDatabaseNetworkRules(ctx, methodName, db.GetName())
+		if err != nil {
+			return nil, err
+		}
+
+		return db, nil
 	}
 
 	if s.Options.GetMaintenance() && !auth.IsMaintenanceMethod(methodName) {
@@ -1103,6 +1452,24 @@ func (s *ImmuServer) getDBFromCtx(ctx context.Context, methodName string) (datab
 
 	ind, usr, err := s.getLoggedInUserdataFromCtx(ctx)
 	if err != nil {
+		if db, ok := s.anonymousReadDBFromCtx(ctx, methodName); ok {
+			// anonymous readers get to see values redacted as restricted readers do
+			redacted, err := db.HasRedactionRules()
+			if err != nil {
+				return nil, err
+			}
+			if redacted {
+				db = db.Redacted()
+			}
+
+			err = s.checkDatabaseNetworkRules(ctx, methodName, db.GetName())
+			if err != nil {
+				return nil, err
+			}
+
+			// anonymous readers have no session attributes to be granted access by policies
+			return db.WithSessionAttributes(map[string]interface{}{}), nil
+		}
 		if strings.HasPrefix(fmt.Sprintf("%s", err), "token has expired") {
 			return nil, status.Error(codes.PermissionDenied, err.Error())
 		}
@@ -1129,17 +1496,82 @@ func (s *ImmuServer) getDBFromCtx(ctx context.Context, methodName string) (datab
 		db = s.dbList.GetByIndex(ind)
 	}
 
+	err = s.checkDatabaseNetworkRules(ctx, methodName, db.GetName())
+	if err != nil {
+		return nil, err
+	}
+
+	s.touchMemoryBudget(db.GetName())
+
+	if auth.IsWriteMethod(methodName) {
+		err = s.checkTenantDiskQuota(db.GetName())
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	if usr.IsSysAdmin {
 		return db, nil
 	}
 
-	if ok := auth.HasPermissionForMethod(usr.WhichPermission(s.dbList.GetByIndex(ind).GetName()), methodName); !ok {
+	permission := usr.WhichPermission(s.dbList.GetByIndex(ind).GetName())
+
+	if ok := auth.HasPermissionForMethod(permission, methodName); !ok {
 		return nil, ErrPermissionDenied
 	}
 
+	// restricted readers only get to see values redacted according to the rules of the database
+	if permission == auth.PermissionRestrictedR {
+		db = db.Redacted()
+	}
+
+	// the policies of the tables are enforced on the SQL queries of the users not administering the database
+	if permission != auth.PermissionAdmin {
+		db = db.WithSessionAttributes(s.policyAttributesFromCtx(ctx, usr))
+	}
+
 	return db, nil
 }
 
+// policyAttributesFromCtx returns the session attributes the SQL policies are evaluated with,
+// the custom ones are only available when the request was made within a session
+func (s *ImmuServer) policyAttributesFromCtx(ctx context.Context, usr *auth.User) map[string]interface{} {
+	var vars map[string]string
+
+	if sessionID, err := sessions.GetSessionIDFromContext(ctx); err == nil {
+		if sess, err := s.SessManager.GetSession(sessionID); err == nil {
+			vars = sess.GetVars()
+		}
+	}
+
+	return sessions.PolicyAttributes(usr.Username, vars)
+}
+
+// anonymousReadDBFromCtx returns the database named in the request metadata when the request was made
+// without logging in, methodName only reads data and the database allows anonymous reads
+func (s *ImmuServer) anonymousReadDBFromCtx(ctx context.Context, methodName string) (database.DB, bool) {
+	if auth.GetAuthTypeFromContext(ctx) != auth.None || !auth.IsAnonymousReadMethod(methodName) {
+		return nil, false
+	}
+
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return nil, false
+	}
+
+	dbNames := md.Get(auth.DatabaseHeader)
+	if len(dbNames) == 0 || dbNames[0] == SystemDBName {
+		return nil, false
+	}
+
+	db, err := s.dbList.GetByName(dbNames[0])
+	if err != nil || !db.AnonymousReadsAllowed() {
+		return nil, false
+	}
+
+	return db, true
+}
+
 // isValidDBName checks if the provided database name meets the requirements
 func isValidDBName(dbName string) error {
 	if len(dbName) < 1 || len(dbName) > 128 {
@@ -1188,7 +1620,7 @@ func (s *ImmuServer) mandatoryAuth() bool {
 
 	if s.sysDB != nil {
 		//check if there is only sysadmin on systemdb and no other user
-		itemList, err := s.sysDB.Scan(&schema.ScanRequest{
+		itemList, err := s.sysDB.Scan(context.Background(), &schema.ScanRequest{
 			Prefix: []byte{KeyPrefixUser},
 		})
 
@@ -0,0 +1,47 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServerInfo(t *testing.T) {
+	serverOptions := DefaultOptions().
+		WithPgsqlServer(true).
+		WithPgsqlServerPort(0).
+		WithGrpcReflection(true)
+
+	s, _, closer := newTestServer(t, serverOptions)
+	defer closer()
+
+	// no authentication is required
+	info, err := s.ServerInfo(context.Background(), &schema.ServerInfoRequest{})
+	require.NoError(t, err)
+	require.Equal(t, Version.Version, info.Version)
+	require.Equal(t, []string{"v1", "v2"}, info.ApiVersions)
+	require.Contains(t, info.Features, "pgsql")
+	require.Contains(t, info.Features, "reflection")
+	require.NotContains(t, info.Features, "metrics")
+	require.NotContains(t, info.Features, "scripting")
+
+	require.Contains(t, s.GrpcServer.GetServiceInfo(), "grpc.reflection.v1alpha.ServerReflection")
+}
@@ -44,6 +44,7 @@ import (
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
 	"google.golang.org/protobuf/types/known/emptypb"
 )
 
@@ -68,6 +69,50 @@ var kvs = []*schema.KeyValue{
 	},
 }
 
+// newTestServer initializes a server storing its data into a temporary directory and logs in as sysadmin.
+// Options not set in opts, which may be nil, are the default ones, with the metrics server disabled.
+// It returns the server, the context of the sysadmin session and a func closing the server and removing its data.
+func newTestServer(t *testing.T, opts *Options) (*ImmuServer, context.Context, func()) {
+	dir, err := ioutil.TempDir("", "immudb_server")
+	require.NoError(t, err)
+
+	if opts == nil {
+		opts = DefaultOptions()
+	}
+
+	opts = opts.
+		WithDir(dir).
+		WithMetricsServer(false).
+		WithListener(bufconn.Listen(1024 * 1024)).
+		WithAdminPassword(auth.SysAdminPassword)
+
+	s := DefaultServer().WithOptions(opts).(*ImmuServer)
+
+	closer := func() {
+		s.CloseDatabases()
+		os.RemoveAll(dir)
+	}
+
+	err = s.Initialize()
+	if err != nil {
+		closer()
+	}
+	require.NoError(t, err)
+
+	lr, err := s.Login(context.Background(), &schema.LoginRequest{
+		User:     []byte(auth.SysAdminUsername),
+		Password: []byte(auth.SysAdminPassword),
+	})
+	if err != nil {
+		closer()
+	}
+	require.NoError(t, err)
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", lr.Token))
+
+	return s, ctx, closer
+}
+
 func TestLogErr(t *testing.T) {
 	logger := logger.NewSimpleLogger("immudb ", os.Stderr)
 
@@ -374,6 +419,59 @@ func TestServerUpdateDatabase(t *testing.T) {
 	require.Equal(t, false, dbOpts.Replica)
 }
 
+func TestServerUpdateDatabaseV2WithoutReloading(t *testing.T) {
+	s, ctx, closer := newTestServer(t, nil)
+	defer closer()
+
+	_, err := s.CreateDatabaseWithV2(ctx, &schema.DatabaseSettingsV2{DatabaseName: "db1"})
+	require.NoError(t, err)
+
+	ur, err := s.UseDatabase(ctx, &schema.Database{DatabaseName: "db1"})
+	require.NoError(t, err)
+
+	dbCtx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", ur.Token))
+
+	hdr, err := s.Set(dbCtx, &schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte("key1"), Value: []byte("value1")}}})
+	require.NoError(t, err)
+	require.NotZero(t, hdr.Ts)
+
+	res, err := s.UpdateDatabaseV2(ctx, &schema.DatabaseSettingsV2{
+		DatabaseName:      "db1",
+		ExcludeCommitTime: &schema.ConditionalBool{Value: true},
+		TxLogCacheSize:    &schema.ConditionalUint32{Value: 10},
+		IndexSettings: &schema.IndexSettings{
+			CacheSize:      &schema.ConditionalUint32{Value: 200},
+			FlushThreshold: &schema.ConditionalUint32{Value: 1000},
+		},
+	})
+	require.NoError(t, err)
+	require.Empty(t, res.ReloadRequired)
+	require.Equal(t, uint32(200), res.CurrentSettings.IndexSettings.CacheSize.Value)
+
+	hdr, err = s.Set(dbCtx, &schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte("key2"), Value: []byte("value2")}}})
+	require.NoError(t, err)
+	require.Zero(t, hdr.Ts)
+
+	res, err = s.UpdateDatabaseV2(ctx, &schema.DatabaseSettingsV2{
+		DatabaseName:   "db1",
+		MaxConcurrency: &schema.ConditionalUint32{Value: 10},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"maxConcurrency"}, res.ReloadRequired)
+	require.Equal(t, uint32(10), res.CurrentSettings.MaxConcurrency.Value)
+
+	res, err = s.UpdateDatabaseV2(ctx, &schema.DatabaseSettingsV2{
+		DatabaseName:   "db1",
+		MaxConcurrency: &schema.ConditionalUint32{Value: 10},
+	})
+	require.NoError(t, err)
+	require.Empty(t, res.ReloadRequired)
+
+	entry, err := s.Get(dbCtx, &schema.KeyRequest{Key: []byte("key1")})
+	require.NoError(t, err)
+	require.Equal(t, []byte("value1"), entry.Value)
+}
+
 func TestServerLoaduserDatabase(t *testing.T) {
 	serverOptions := DefaultOptions().WithMetricsServer(false).WithAdminPassword(auth.SysAdminPassword)
 	s := DefaultServer().WithOptions(serverOptions).(*ImmuServer)
@@ -0,0 +1,109 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/api/schemav2"
+	"github.com/golang/protobuf/ptypes/empty"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// DEPRECATED_HEADER is sent in the response of deprecated methods, along with the method replacing them
+const DEPRECATED_HEADER = "immudb-deprecated"
+
+// immuServiceV2 implements the version 2 of the API as an adapter over the version 1 implemented by the ImmuServer
+type immuServiceV2 struct {
+	s *ImmuServer
+}
+
+// V2 returns the implementation of the version 2 of the API
+func (s *ImmuServer) V2() schemav2.ImmuServiceServer {
+	return &immuServiceV2{s: s}
+}
+
+func (v *immuServiceV2) CreateDatabase(ctx context.Context, req *schemav2.CreateDatabaseRequest) (*schemav2.CreateDatabaseResponse, error) {
+	if req == nil {
+		return nil, ErrIllegalArguments
+	}
+
+	settings := &schema.DatabaseSettingsV2{}
+	if req.Settings != nil {
+		settings = req.Settings
+	}
+	settings.DatabaseName = req.Name
+
+	res, err := v.s.CreateDatabaseWithV2(ctx, settings)
+	if err != nil {
+		return nil, err
+	}
+
+	return &schemav2.CreateDatabaseResponse{Name: res.DatabaseName, Settings: res}, nil
+}
+
+func (v *immuServiceV2) UpdateDatabase(ctx context.Context, req *schemav2.UpdateDatabaseRequest) (*schemav2.UpdateDatabaseResponse, error) {
+	if req == nil {
+		return nil, ErrIllegalArguments
+	}
+
+	settings := &schema.DatabaseSettingsV2{}
+	if req.Settings != nil {
+		settings = req.Settings
+	}
+	settings.DatabaseName = req.Database
+
+	res, err := v.s.UpdateDatabaseV2(ctx, settings)
+	if err != nil {
+		return nil, err
+	}
+
+	return &schemav2.UpdateDatabaseResponse{
+		Database:       res.CurrentSettings.DatabaseName,
+		Settings:       res.CurrentSettings,
+		ReloadRequired: res.ReloadRequired,
+	}, nil
+}
+
+func (v *immuServiceV2) GetDatabaseSettings(ctx context.Context, _ *schemav2.DatabaseSettingsRequest) (*schemav2.DatabaseSettingsResponse, error) {
+	res, err := v.s.GetDatabaseSettingsV2(ctx, &empty.Empty{})
+	if err != nil {
+		return nil, err
+	}
+
+	return &schemav2.DatabaseSettingsResponse{Database: res.DatabaseName, Settings: res}, nil
+}
+
+// DeprecationInterceptor announces the replacement of deprecated methods to clients in the
+// DEPRECATED_HEADER response header and logs the first use of each of them
+func (s *ImmuServer) DeprecationInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	replacement, deprecated := schemav2.Replacements[info.FullMethod]
+	if !deprecated {
+		return handler(ctx, req)
+	}
+
+	if _, logged := s.deprecationsLogged.LoadOrStore(info.FullMethod, true); !logged {
+		s.Logger.Warningf("deprecated method %s was used, it's replaced by %s", info.FullMethod, replacement)
+	}
+
+	// headers can't be set when there is no transport stream, as when the method is invoked through the REST gateway
+	grpc.SetHeader(ctx, metadata.Pairs(DEPRECATED_HEADER, replacement))
+
+	return handler(ctx, req)
+}
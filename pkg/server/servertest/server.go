@@ -23,6 +23,7 @@ import (
 	"sync"
 
 	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/api/schemav2"
 	"github.com/codenotary/immudb/pkg/auth"
 	grpc_middleware "github.com/grpc-ecosystem/go-grpc-middleware"
 	"google.golang.org/grpc"
@@ -55,7 +56,7 @@ func NewBufconnServer(options *server.Options) *bufconnServer {
 		Lis:     bufconn.Listen(bufSize),
 		Options: options,
 		GrpcServer: grpc.NewServer(
-			grpc.UnaryInterceptor(grpc_middleware.ChainUnaryServer(server.ErrorMapper, immuserver.KeepAliveSessionInterceptor, auth.ServerUnaryInterceptor, immuserver.SessionAuthInterceptor)),
+			grpc.UnaryInterceptor(grpc_middleware.ChainUnaryServer(server.ErrorMapper, immuserver.KeepAliveSessionInterceptor, immuserver.DeprecationInterceptor, auth.ServerUnaryInterceptor, immuserver.SessionAuthInterceptor)),
 			grpc.StreamInterceptor(grpc_middleware.ChainStreamServer(server.ErrorMapperStream, immuserver.KeepALiveSessionStreamInterceptor, auth.ServerStreamInterceptor)),
 		),
 		immuServer: immuserver,
@@ -68,8 +69,11 @@ func (bs *bufconnServer) Start() error {
 	bs.m.Lock()
 	defer bs.m.Unlock()
 
+	// only the listener is captured, so clients left open don't retain the server once stopped
+	lis := bs.Lis
+
 	bs.Dialer = func(ctx context.Context, s string) (net.Conn, error) {
-		return bs.Lis.Dial()
+		return lis.Dial()
 	}
 
 	bs.Server = &ServerMock{Srv: bs.immuServer}
@@ -83,6 +87,7 @@ func (bs *bufconnServer) Start() error {
 	bs.pgsqlwg.Done()
 
 	schema.RegisterImmuServiceServer(bs.GrpcServer, bs.Server)
+	schemav2.RegisterImmuServiceServer(bs.GrpcServer, bs.Server.Srv.V2())
 
 	go func() {
 		if err := bs.GrpcServer.Serve(bs.Lis); err != nil {
@@ -45,6 +45,10 @@ func (s *ServerMock) TxSQLExec(ctx context.Context, request *schema.SQLExecReque
 	return s.Srv.TxSQLExec(ctx, request)
 }
 
+func (s *ServerMock) TxSet(ctx context.Context, request *schema.SetRequest) (*empty.Empty, error) {
+	return s.Srv.TxSet(ctx, request)
+}
+
 func (s *ServerMock) TxSQLQuery(ctx context.Context, request *schema.SQLQueryRequest) (*schema.SQLQueryResult, error) {
 	return s.Srv.TxSQLQuery(ctx, request)
 }
@@ -184,6 +188,10 @@ func (s *ServerMock) ExecAll(ctx context.Context, req *schema.ExecAllRequest) (*
 	return s.PostExecAllFn(ctx, req, rsp, err)
 }
 
+func (s *ServerMock) ExecScript(ctx context.Context, req *schema.ExecScriptRequest) (*schema.ExecScriptResult, error) {
+	return s.Srv.ExecScript(ctx, req)
+}
+
 func (s *ServerMock) Scan(ctx context.Context, req *schema.ScanRequest) (*schema.Entries, error) {
 	return s.Srv.Scan(ctx, req)
 }
@@ -216,6 +224,10 @@ func (s *ServerMock) Health(ctx context.Context, req *empty.Empty) (*schema.Heal
 	return s.Srv.Health(ctx, req)
 }
 
+func (s *ServerMock) ServerInfo(ctx context.Context, req *schema.ServerInfoRequest) (*schema.ServerInfoResponse, error) {
+	return s.Srv.ServerInfo(ctx, req)
+}
+
 func (s *ServerMock) CurrentState(ctx context.Context, req *empty.Empty) (*schema.ImmutableState, error) {
 	return s.Srv.CurrentState(ctx, req)
 }
@@ -238,6 +250,14 @@ func (s *ServerMock) VerifiableSetReference(ctx context.Context, req *schema.Ver
 	return s.PostVerifiableSetReferenceFn(ctx, req, rsp, err)
 }
 
+func (s *ServerMock) ResolveAll(ctx context.Context, req *schema.ResolveAllRequest) (*schema.Entries, error) {
+	return s.Srv.ResolveAll(ctx, req)
+}
+
+func (s *ServerMock) Rename(ctx context.Context, req *schema.RenameRequest) (*schema.TxHeader, error) {
+	return s.Srv.Rename(ctx, req)
+}
+
 func (s *ServerMock) ZAdd(ctx context.Context, req *schema.ZAddRequest) (*schema.TxHeader, error) {
 	if s.PostZAddFn == nil {
 		return s.Srv.ZAdd(ctx, req)
@@ -300,6 +320,18 @@ func (s *ServerMock) GetDatabaseSettingsV2(ctx context.Context, req *empty.Empty
 	return s.Srv.GetDatabaseSettingsV2(ctx, req)
 }
 
+func (s *ServerMock) CloneDatabase(ctx context.Context, req *schema.CloneDatabaseRequest) (*schema.CloneDatabaseResponse, error) {
+	return s.Srv.CloneDatabase(ctx, req)
+}
+
+func (s *ServerMock) GetDatabaseSettingsHistory(ctx context.Context, req *schema.DatabaseSettingsHistoryRequest) (*schema.DatabaseSettingsHistory, error) {
+	return s.Srv.GetDatabaseSettingsHistory(ctx, req)
+}
+
+func (s *ServerMock) RollbackDatabaseSettings(ctx context.Context, req *schema.DatabaseSettingsRollbackRequest) (*schema.DatabaseSettingsUpdateResult, error) {
+	return s.Srv.RollbackDatabaseSettings(ctx, req)
+}
+
 func (s *ServerMock) FlushIndex(ctx context.Context, req *schema.FlushIndexRequest) (*empty.Empty, error) {
 	return s.Srv.FlushIndex(ctx, req)
 }
@@ -347,3 +379,87 @@ func (s *ServerMock) DescribeTable(ctx context.Context, req *schema.Table) (*sch
 func (s *ServerMock) VerifiableSQLGet(ctx context.Context, req *schema.VerifiableSQLGetRequest) (*schema.VerifiableSQLEntry, error) {
 	return s.Srv.VerifiableSQLGet(ctx, req)
 }
+
+func (s *ServerMock) RegisterExternalRoot(ctx context.Context, req *schema.ExternalRoot) (*schema.TxHeader, error) {
+	return s.Srv.RegisterExternalRoot(ctx, req)
+}
+
+func (s *ServerMock) ExternalRoots(ctx context.Context, req *schema.ExternalRootsRequest) (*schema.ExternalRootList, error) {
+	return s.Srv.ExternalRoots(ctx, req)
+}
+
+func (s *ServerMock) SetKVSchema(ctx context.Context, req *schema.KVSchema) (*schema.TxHeader, error) {
+	return s.Srv.SetKVSchema(ctx, req)
+}
+
+func (s *ServerMock) DeleteKVSchema(ctx context.Context, req *schema.KVSchemaRequest) (*schema.TxHeader, error) {
+	return s.Srv.DeleteKVSchema(ctx, req)
+}
+
+func (s *ServerMock) KVSchemas(ctx context.Context, req *empty.Empty) (*schema.KVSchemaList, error) {
+	return s.Srv.KVSchemas(ctx, req)
+}
+
+func (s *ServerMock) SetRedactionRule(ctx context.Context, req *schema.RedactionRule) (*schema.TxHeader, error) {
+	return s.Srv.SetRedactionRule(ctx, req)
+}
+
+func (s *ServerMock) DeleteRedactionRule(ctx context.Context, req *schema.RedactionRuleRequest) (*schema.TxHeader, error) {
+	return s.Srv.DeleteRedactionRule(ctx, req)
+}
+
+func (s *ServerMock) RedactionRules(ctx context.Context, req *empty.Empty) (*schema.RedactionRuleList, error) {
+	return s.Srv.RedactionRules(ctx, req)
+}
+
+func (s *ServerMock) MultiDatabaseSet(ctx context.Context, req *schema.MultiDatabaseSetRequest) (*schema.MultiDatabaseTxHeaders, error) {
+	return s.Srv.MultiDatabaseSet(ctx, req)
+}
+
+func (s *ServerMock) ReplicationStatus(ctx context.Context, req *schema.ReplicationRequest) (*schema.ReplicationStatusResponse, error) {
+	return s.Srv.ReplicationStatus(ctx, req)
+}
+
+func (s *ServerMock) PauseReplication(ctx context.Context, req *schema.ReplicationRequest) (*empty.Empty, error) {
+	return s.Srv.PauseReplication(ctx, req)
+}
+
+func (s *ServerMock) ResumeReplication(ctx context.Context, req *schema.ReplicationRequest) (*empty.Empty, error) {
+	return s.Srv.ResumeReplication(ctx, req)
+}
+
+func (s *ServerMock) ResetReplication(ctx context.Context, req *schema.ReplicationRequest) (*empty.Empty, error) {
+	return s.Srv.ResetReplication(ctx, req)
+}
+
+func (s *ServerMock) KeyspaceStats(ctx context.Context, req *schema.KeyspaceStatsRequest) (*schema.KeyspaceStatsResponse, error) {
+	return s.Srv.KeyspaceStats(ctx, req)
+}
+
+func (s *ServerMock) CompactIndexAsync(ctx context.Context, req *empty.Empty) (*schema.Job, error) {
+	return s.Srv.CompactIndexAsync(ctx, req)
+}
+
+func (s *ServerMock) ScrubAsync(ctx context.Context, req *empty.Empty) (*schema.Job, error) {
+	return s.Srv.ScrubAsync(ctx, req)
+}
+
+func (s *ServerMock) ScrubStatus(ctx context.Context, req *empty.Empty) (*schema.ScrubStatusResponse, error) {
+	return s.Srv.ScrubStatus(ctx, req)
+}
+
+func (s *ServerMock) ListJobs(ctx context.Context, req *schema.ListJobsRequest) (*schema.JobList, error) {
+	return s.Srv.ListJobs(ctx, req)
+}
+
+func (s *ServerMock) GetJob(ctx context.Context, req *schema.JobRequest) (*schema.Job, error) {
+	return s.Srv.GetJob(ctx, req)
+}
+
+func (s *ServerMock) CancelJob(ctx context.Context, req *schema.JobRequest) (*schema.Job, error) {
+	return s.Srv.CancelJob(ctx, req)
+}
+
+func (s *ServerMock) GetServerKeys(ctx context.Context, req *empty.Empty) (*schema.ServerKeys, error) {
+	return s.Srv.GetServerKeys(ctx, req)
+}
@@ -16,9 +16,19 @@ limitations under the License.
 
 package server
 
+// ServiceNotifier reports the state of the server to the service manager supervising it
+type ServiceNotifier interface {
+	// Ready reports the server completed its startup
+	Ready()
+	// Stopping reports the server began its shutdown, it may be called more than once
+	Stopping()
+}
+
 // Service ...
 type Service struct {
 	ImmuServerIf
+	// Notifier is optional, it's set when the service manager expects to be notified
+	Notifier ServiceNotifier
 }
 
 // Start - non-blocking start service
@@ -28,10 +38,24 @@ func (s Service) Start() {
 
 // Stop - non-blocking stop service
 func (s Service) Stop() {
+	if s.Notifier != nil {
+		s.Notifier.Stopping()
+	}
+
 	s.ImmuServerIf.Stop()
 }
 
 // Run - blocking run service
 func (s Service) Run() {
+	if s.Notifier != nil {
+		// listeners are bound while the server is initialized,
+		// connections are served as soon as it starts
+		s.Notifier.Ready()
+	}
+
 	s.ImmuServerIf.Start()
+
+	if s.Notifier != nil {
+		s.Notifier.Stopping()
+	}
 }
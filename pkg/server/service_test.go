@@ -46,3 +46,44 @@ func TestService(t *testing.T) {
 	time.Sleep(1 * time.Second)
 	srvc.Stop()
 }
+
+type recordingNotifier struct {
+	states chan string
+}
+
+func (n *recordingNotifier) Ready() {
+	n.states <- "ready"
+}
+
+func (n *recordingNotifier) Stopping() {
+	n.states <- "stopping"
+}
+
+func TestServiceNotifier(t *testing.T) {
+	bufSize := 1024 * 1024
+	l := bufconn.Listen(bufSize)
+	datadir := "rome_notifier"
+	defer func() {
+		os.RemoveAll(datadir)
+	}()
+	options := DefaultOptions().WithAuth(true).WithDir(datadir).WithListener(l).WithPort(22222).WithMetricsServer(false)
+
+	server := DefaultServer().WithOptions(options).(*ImmuServer)
+
+	err := server.Initialize()
+	require.NoError(t, err)
+
+	notifier := &recordingNotifier{states: make(chan string, 3)}
+
+	srvc := &Service{
+		ImmuServerIf: server,
+		Notifier:     notifier,
+	}
+
+	srvc.Start()
+	require.Equal(t, "ready", <-notifier.states)
+
+	srvc.Stop()
+	require.Equal(t, "stopping", <-notifier.states)
+	require.Equal(t, "stopping", <-notifier.states)
+}
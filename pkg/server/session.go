@@ -3,6 +3,8 @@ package server
 import (
 	"context"
 	"fmt"
+	"time"
+
 	"github.com/codenotary/immudb/pkg/api/schema"
 	"github.com/codenotary/immudb/pkg/auth"
 	"github.com/codenotary/immudb/pkg/errors"
@@ -20,7 +22,7 @@ func (s *ImmuServer) OpenSession(ctx context.Context, r *schema.OpenSessionReque
 		return nil, errors.New(ErrAuthDisabled).WithCode(errors.CodProtocolViolation)
 	}
 
-	u, err := s.getValidatedUser(r.Username, r.Password)
+	u, err := s.authenticateUser(ctx, r.Username, r.Password)
 	if err != nil {
 		return nil, errors.Wrap(err, ErrInvalidUsernameOrPassword)
 	}
@@ -43,16 +45,38 @@ func (s *ImmuServer) OpenSession(ctx context.Context, r *schema.OpenSessionReque
 	if (!u.IsSysAdmin) &&
 		(!u.HasPermission(r.DatabaseName, auth.PermissionAdmin)) &&
 		(!u.HasPermission(r.DatabaseName, auth.PermissionR)) &&
-		(!u.HasPermission(r.DatabaseName, auth.PermissionRW)) {
+		(!u.HasPermission(r.DatabaseName, auth.PermissionRW)) &&
+		(!u.HasPermission(r.DatabaseName, auth.PermissionRestrictedR)) {
 
 		return nil, status.Errorf(codes.PermissionDenied, "Logged in user does not have permission on this database")
 	}
 
-	session, err := s.SessManager.NewSession(u, s.dbList.GetByIndex(databaseID))
+	err = s.checkDatabaseNetworkRules(ctx, "OpenSession", r.DatabaseName)
+	if err != nil {
+		return nil, err
+	}
+
+	vars, err := sessions.NormalizeVars(r.SessionVars)
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := s.newSession(u, s.dbList.GetByIndex(databaseID))
 	if err != nil {
 		return nil, err
 	}
 
+	session.SetVars(vars)
+
+	if r.ReadYourWrites {
+		timeout := time.Duration(r.ReadYourWritesTimeout) * time.Millisecond
+		if timeout == 0 {
+			timeout = sessions.DefaultReadYourWritesTimeout
+		}
+
+		session.SetReadYourWrites(true, timeout)
+	}
+
 	return &schema.OpenSessionResponse{
 		SessionID:  session.GetID(),
 		ServerUUID: s.UUID.String(),
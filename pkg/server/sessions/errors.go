@@ -35,3 +35,4 @@ var ErrGuardNotRunning = errors.New("session guard not running")
 var ErrMaxSessionsReached = errors.New("max sessions reached")
 var ErrWriteOnlyTXNotAllowed = errors.New("write only transaction not allowed")
 var ErrReadOnlyTXNotAllowed = errors.New("read only transaction not allowed")
+var ErrInvalidSessionVar = errors.New("invalid or reserved session variable name").WithCode(errors.CodInvalidParameterValue)
@@ -17,6 +17,8 @@ limitations under the License.
 package transactions
 
 import (
+	"context"
+
 	"github.com/codenotary/immudb/embedded/sql"
 	"github.com/codenotary/immudb/pkg/api/schema"
 	"github.com/codenotary/immudb/pkg/database"
@@ -39,7 +41,8 @@ type Transaction interface {
 	Commit() ([]*sql.SQLTx, error)
 	GetSessionID() string
 	SQLExec(request *schema.SQLExecRequest) error
-	SQLQuery(request *schema.SQLQueryRequest) (*schema.SQLQueryResult, error)
+	SQLQuery(ctx context.Context, request *schema.SQLQueryRequest) (*schema.SQLQueryResult, error)
+	Set(request *schema.SetRequest) error
 }
 
 func NewTransaction(sqlTx *sql.SQLTx, transactionID string, mode schema.TxMode, db database.DB, sessionID string) *transaction {
@@ -98,8 +101,14 @@ func (tx *transaction) SQLExec(request *schema.SQLExecRequest) (err error) {
 	return err
 }
 
-func (tx *transaction) SQLQuery(request *schema.SQLQueryRequest) (*schema.SQLQueryResult, error) {
+func (tx *transaction) SQLQuery(ctx context.Context, request *schema.SQLQueryRequest) (*schema.SQLQueryResult, error) {
+	tx.mutex.Lock()
+	defer tx.mutex.Unlock()
+	return tx.db.SQLQuery(ctx, request, tx.sqlTx)
+}
+
+func (tx *transaction) Set(request *schema.SetRequest) error {
 	tx.mutex.Lock()
 	defer tx.mutex.Unlock()
-	return tx.db.SQLQuery(request, tx.sqlTx)
+	return tx.db.SetInSQLTx(request, tx.sqlTx)
 }
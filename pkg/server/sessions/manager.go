@@ -54,6 +54,7 @@ type Manager interface {
 	StopSessionsGuard() error
 	GetSession(sessionID string) (*Session, error)
 	SessionCount() int
+	TenantSessionCount(tenant string) int
 	GetTransactionFromContext(ctx context.Context) (transactions.Transaction, error)
 	GetSessionFromContext(ctx context.Context) (*Session, error)
 	DeleteTransaction(transactions.Transaction) error
@@ -161,6 +162,21 @@ func (sm *manager) SessionCount() int {
 	return len(sm.sessions)
 }
 
+// TenantSessionCount returns the number of sessions opened by the users of the tenant
+func (sm *manager) TenantSessionCount(tenant string) int {
+	sm.sessionMux.RLock()
+	defer sm.sessionMux.RUnlock()
+
+	count := 0
+	for _, sess := range sm.sessions {
+		if sess.GetUser().Tenant == tenant {
+			count++
+		}
+	}
+
+	return count
+}
+
 func (sm *manager) StartSessionsGuard() error {
 	sm.sessionMux.Lock()
 	if sm.running {
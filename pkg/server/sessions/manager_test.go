@@ -46,6 +46,20 @@ func TestSessionGuard(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestManager_TenantSessionCount(t *testing.T) {
+	m, err := NewManager(DefaultOptions())
+	require.NoError(t, err)
+
+	for _, tenant := range []string{"acme", "acme", "globex", ""} {
+		_, err := m.NewSession(&auth.User{Username: "user", Tenant: tenant}, nil)
+		require.NoError(t, err)
+	}
+
+	require.Equal(t, 2, m.TenantSessionCount("acme"))
+	require.Equal(t, 1, m.TenantSessionCount("globex"))
+	require.Equal(t, 0, m.TenantSessionCount("initech"))
+}
+
 const SGUARD_CHECK_INTERVAL = time.Millisecond * 250
 const MAX_SESSION_INACTIVE = time.Millisecond * 2000
 const MAX_SESSION_AGE = time.Millisecond * 3000
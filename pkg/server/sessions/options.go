@@ -18,6 +18,9 @@ package sessions
 
 import "time"
 
+// DefaultReadYourWritesTimeout is the max time reads wait for the session writes to be indexed, when not specified
+const DefaultReadYourWritesTimeout = 5 * time.Second
+
 type Options struct {
 	SessionGuardCheckInterval time.Duration
 	// MaxSessionInactivityTime is a duration for the amount of time after which an idle session would be closed by the server
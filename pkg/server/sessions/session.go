@@ -18,6 +18,7 @@ package sessions
 
 import (
 	"context"
+	"strings"
 	"sync"
 	"time"
 
@@ -50,6 +51,12 @@ type Session struct {
 	readWriteTxOngoing bool
 	transactions       map[string]transactions.Transaction
 	log                logger.Logger
+	// when readYourWrites is set, reads wait for the last tx committed within the session to be indexed
+	readYourWrites        bool
+	readYourWritesTimeout time.Duration
+	lastCommittedTxID     uint64
+	// vars are the custom session attributes the SQL policies are evaluated with
+	vars map[string]string
 }
 
 func NewSession(sessionID string, user *auth.User, db database.DB, log logger.Logger) *Session {
@@ -78,7 +85,10 @@ func (s *Session) NewTransaction(mode schema.TxMode) (transactions.Transaction,
 		return nil, ErrReadOnlyTXNotAllowed
 	}
 
-	sqlTx, _, err := s.database.SQLExec(&schema.SQLExecRequest{Sql: "BEGIN TRANSACTION;"}, nil)
+	// policies are enforced on the queries made within the transaction
+	db := s.database.WithSessionAttributes(s.policyAttributes())
+
+	sqlTx, _, err := db.SQLExec(&schema.SQLExecRequest{Sql: "BEGIN TRANSACTION;"}, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -91,7 +101,7 @@ func (s *Session) NewTransaction(mode schema.TxMode) (transactions.Transaction,
 	}
 
 	transactionID := xid.New().String()
-	tx := transactions.NewTransaction(sqlTx, transactionID, mode, s.database, s.id)
+	tx := transactions.NewTransaction(sqlTx, transactionID, mode, db, s.id)
 	s.transactions[transactionID] = tx
 
 	return tx, nil
@@ -249,3 +259,91 @@ func (s *Session) SetReadWriteTxOngoing(b bool) {
 	defer s.mux.Unlock()
 	s.readWriteTxOngoing = b
 }
+
+func (s *Session) SetReadYourWrites(enabled bool, timeout time.Duration) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.readYourWrites = enabled
+	s.readYourWritesTimeout = timeout
+}
+
+// GetReadYourWrites returns whether reads wait for the txs committed within the session to be indexed, and up to how long
+func (s *Session) GetReadYourWrites() (bool, time.Duration) {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+	return s.readYourWrites, s.readYourWritesTimeout
+}
+
+// TrackCommittedTx records a tx committed within the session
+func (s *Session) TrackCommittedTx(txID uint64) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	if txID > s.lastCommittedTxID {
+		s.lastCommittedTxID = txID
+	}
+}
+
+func (s *Session) GetLastCommittedTxID() uint64 {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+	return s.lastCommittedTxID
+}
+
+// UserAttribute is the session attribute the name of the user is bound to
+const UserAttribute = "user"
+
+// NormalizeVars lowercases the names of the custom session attributes, as SQL parameters are case insensitive
+func NormalizeVars(vars map[string]string) (map[string]string, error) {
+	nvars := make(map[string]string, len(vars))
+
+	for name, value := range vars {
+		nname := strings.ToLower(name)
+
+		if nname == "" || nname == UserAttribute {
+			return nil, ErrInvalidSessionVar
+		}
+
+		_, exists := nvars[nname]
+		if exists {
+			return nil, ErrInvalidSessionVar
+		}
+
+		nvars[nname] = value
+	}
+
+	return nvars, nil
+}
+
+func (s *Session) SetVars(vars map[string]string) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.vars = vars
+}
+
+func (s *Session) GetVars() map[string]string {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+	return s.vars
+}
+
+// PolicyAttributes returns the session attributes the SQL policies are evaluated with
+func PolicyAttributes(username string, vars map[string]string) map[string]interface{} {
+	attrs := make(map[string]interface{}, len(vars)+1)
+
+	for name, value := range vars {
+		attrs[name] = value
+	}
+
+	attrs[UserAttribute] = username
+
+	return attrs
+}
+
+// not thread safe, policies are not enforced for the administrators of the database
+func (s *Session) policyAttributes() map[string]interface{} {
+	if s.user.IsSysAdmin || s.user.WhichPermission(s.database.GetName()) == auth.PermissionAdmin {
+		return nil
+	}
+
+	return PolicyAttributes(s.user.Username, s.vars)
+}
@@ -0,0 +1,257 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/database"
+)
+
+// kinds of the operations recorded by the slow query log
+const (
+	slowQuerySQLExec  = "sql exec"
+	slowQuerySQLQuery = "sql query"
+	slowQueryScan     = "scan"
+)
+
+// slowQueryRecord describes an operation which took longer than the slow query threshold.
+// Parameter values are never recorded, only their names and types
+type slowQueryRecord struct {
+	Time      time.Time     `json:"time"`
+	Duration  time.Duration `json:"duration"`
+	Database  string        `json:"database"`
+	User      string        `json:"user,omitempty"`
+	Kind      string        `json:"kind"`
+	Statement string        `json:"statement"`
+	Params    []string      `json:"params,omitempty"`
+	Plan      []string      `json:"plan,omitempty"`
+}
+
+func slowQueryKey(t time.Time) []byte {
+	key := make([]byte, 1+8)
+	key[0] = KeyPrefixSlowQuery
+	binary.BigEndian.PutUint64(key[1:], uint64(t.UnixNano()))
+	return key
+}
+
+// logSlowQuery logs the operation started at startedAt when it took longer than the slow query threshold,
+// recording it in the system database as well if enabled. It's meant to be deferred right before the operation.
+// Queries are explained, logged and recorded in the background, so that the request is not delayed any further
+func (s *ImmuServer) logSlowQuery(ctx context.Context, db database.DB, kind, statement string, params []*schema.NamedParam, startedAt time.Time) {
+	elapsed := time.Since(startedAt)
+
+	if s.Options.SlowQueryThreshold <= 0 || elapsed < s.Options.SlowQueryThreshold || db == nil {
+		return
+	}
+
+	record := &slowQueryRecord{
+		Time:      startedAt,
+		Duration:  elapsed,
+		Database:  db.GetName(),
+		Kind:      kind,
+		Statement: statement,
+		Params:    redactedParams(params),
+	}
+
+	if _, user, err := s.getLoggedInUserdataFromCtx(ctx); err == nil && user != nil {
+		record.User = user.Username
+	}
+
+	go func() {
+		if kind == slowQuerySQLQuery {
+			// the request context is done by the time the query is explained
+			record.Plan = queryPlan(context.Background(), db, statement, params)
+		}
+
+		s.recordSlowQuery(record)
+	}()
+}
+
+func (s *ImmuServer) recordSlowQuery(record *slowQueryRecord) {
+	s.Logger.Warningf("Slow %s on database '%s' by user '%s' took %s: %s params: [%s] plan: [%s]",
+		record.Kind,
+		record.Database,
+		record.User,
+		record.Duration,
+		record.Statement,
+		strings.Join(record.Params, ", "),
+		strings.Join(record.Plan, "; "))
+
+	if !s.Options.SlowQueryRecording || s.sysDB == nil {
+		return
+	}
+
+	serializedRecord, err := json.Marshal(record)
+	if err != nil {
+		s.Logger.Errorf("Slow query could not be recorded. Reason: %v", err)
+		return
+	}
+
+	_, err = s.sysDB.Set(&schema.SetRequest{KVs: []*schema.KeyValue{{Key: slowQueryKey(record.Time), Value: serializedRecord}}})
+	if err != nil {
+		s.Logger.Errorf("Slow query could not be recorded. Reason: %v", err)
+	}
+}
+
+// slowQueries returns up to limit slow queries recorded in the system database, newest first,
+// skipping the offset most recent ones. A zero limit means up to database.MaxKeyScanLimit queries.
+// Only the system administrator can read them, as they span all databases
+func (s *ImmuServer) slowQueries(ctx context.Context, offset, limit uint64) ([]*slowQueryRecord, error) {
+	if !s.Options.GetAuth() {
+		return nil, ErrAuthMustBeEnabled
+	}
+
+	_, user, err := s.getLoggedInUserdataFromCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.IsSysAdmin {
+		return nil, ErrPermissionDenied
+	}
+
+	if limit > database.MaxKeyScanLimit {
+		return nil, database.ErrMaxKeyScanLimitExceeded
+	}
+
+	if limit == 0 {
+		limit = database.MaxKeyScanLimit
+	}
+
+	var records []*slowQueryRecord
+	var seekKey []byte
+
+	// skipped records are read page by page, the key the page is seeked from was already read
+	for uint64(len(records)) < limit {
+		entries, err := s.sysDB.Scan(ctx, &schema.ScanRequest{
+			Prefix:  []byte{KeyPrefixSlowQuery},
+			SeekKey: seekKey,
+			Desc:    true,
+			Limit:   database.MaxKeyScanLimit,
+			NoWait:  true,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, e := range entries.Entries {
+			if uint64(len(records)) == limit {
+				break
+			}
+
+			if seekKey != nil && bytes.Equal(e.Key, seekKey) {
+				continue
+			}
+
+			if offset > 0 {
+				offset--
+				continue
+			}
+
+			var record slowQueryRecord
+
+			err = json.Unmarshal(e.Value, &record)
+			if err != nil {
+				return nil, err
+			}
+
+			records = append(records, &record)
+		}
+
+		if len(entries.Entries) < database.MaxKeyScanLimit {
+			break
+		}
+
+		seekKey = entries.Entries[len(entries.Entries)-1].Key
+	}
+
+	return records, nil
+}
+
+// redactedParams describes params by name and type only, their values may hold sensitive data
+func redactedParams(params []*schema.NamedParam) []string {
+	if len(params) == 0 {
+		return nil
+	}
+
+	redacted := make([]string, len(params))
+
+	for i, p := range params {
+		var t string
+
+		switch p.GetValue().GetValue().(type) {
+		case *schema.SQLValue_N:
+			t = "INTEGER"
+		case *schema.SQLValue_S:
+			t = "VARCHAR"
+		case *schema.SQLValue_B:
+			t = "BOOLEAN"
+		case *schema.SQLValue_Bs:
+			t = "BLOB"
+		case *schema.SQLValue_Ts:
+			t = "TIMESTAMP"
+		default:
+			t = "NULL"
+		}
+
+		redacted[i] = fmt.Sprintf("@%s %s", p.GetName(), t)
+	}
+
+	return redacted
+}
+
+// queryPlan returns the operators of the plan of a query, no plan is returned when the query can not be explained
+func queryPlan(ctx context.Context, db database.DB, query string, params []*schema.NamedParam) []string {
+	res, err := db.SQLQuery(ctx, &schema.SQLQueryRequest{Sql: "EXPLAIN " + query, Params: params}, nil)
+	if err != nil {
+		return nil
+	}
+
+	plan := make([]string, len(res.Rows))
+
+	for i, row := range res.Rows {
+		if len(row.Values) < 3 {
+			return nil
+		}
+
+		plan[i] = strings.TrimSpace(row.Values[0].GetS())
+
+		if details := row.Values[1].GetS(); details != "" {
+			plan[i] += " " + details
+		}
+
+		if _, isNull := row.Values[2].GetValue().(*schema.SQLValue_Null); !isNull {
+			plan[i] += fmt.Sprintf(" (est. rows: %d)", row.Values[2].GetN())
+		}
+	}
+
+	return plan
+}
+
+// scanStatement describes a scan by its shape, the prefix and the key to seek from are left out
+// as they may hold sensitive data, only the length of the prefix is given
+func scanStatement(req *schema.ScanRequest) string {
+	return fmt.Sprintf("prefix: %d bytes, desc: %v, limit: %d, sinceTx: %d", len(req.GetPrefix()), req.GetDesc(), req.GetLimit(), req.GetSinceTx())
+}
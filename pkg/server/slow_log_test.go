@@ -0,0 +1,185 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/auth"
+	"github.com/codenotary/immudb/pkg/database"
+	"github.com/grpc-ecosystem/grpc-gateway/runtime"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestSlowQueryLog(t *testing.T) {
+	serverOptions := DefaultOptions().
+		WithMetricsServer(false).
+		WithAdminPassword(auth.SysAdminPassword).
+		WithSlowQueryThreshold(time.Nanosecond).
+		WithSlowQueryRecording(true)
+
+	s := DefaultServer().WithOptions(serverOptions).(*ImmuServer)
+	defer os.RemoveAll(s.Options.Dir)
+
+	s.Initialize()
+
+	lr, err := s.Login(context.Background(), &schema.LoginRequest{
+		User:     []byte(auth.SysAdminUsername),
+		Password: []byte(auth.SysAdminPassword),
+	})
+	require.NoError(t, err)
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", lr.Token))
+
+	_, err = s.SQLExec(ctx, &schema.SQLExecRequest{Sql: "CREATE TABLE table1 (id INTEGER, title VARCHAR, PRIMARY KEY id)"})
+	require.NoError(t, err)
+
+	_, err = s.SQLQuery(ctx, &schema.SQLQueryRequest{
+		Sql:    "SELECT id, title FROM table1 WHERE title = @title",
+		Params: []*schema.NamedParam{{Name: "title", Value: &schema.SQLValue{Value: &schema.SQLValue_S{S: "secret"}}}},
+	})
+	require.NoError(t, err)
+
+	_, err = s.Scan(ctx, &schema.ScanRequest{Prefix: []byte("key"), Limit: 10, NoWait: true})
+	require.NoError(t, err)
+
+	var records []*slowQueryRecord
+
+	// slow queries are recorded in the background
+	require.Eventually(t, func() bool {
+		records, err = s.slowQueries(ctx, 0, 0)
+		require.NoError(t, err)
+		return len(records) == 3
+	}, 10*time.Second, 10*time.Millisecond)
+
+	for _, r := range records {
+		require.Equal(t, auth.SysAdminUsername, r.User)
+		require.Equal(t, DefaultDBName, r.Database)
+		require.Greater(t, r.Duration, time.Duration(0))
+	}
+
+	// newest first
+	require.Equal(t, slowQueryScan, records[0].Kind)
+	require.Contains(t, records[0].Statement, "prefix: 3 bytes")
+	require.NotContains(t, records[0].Statement, "key")
+
+	require.Equal(t, slowQuerySQLQuery, records[1].Kind)
+	require.Equal(t, []string{"@title VARCHAR"}, records[1].Params)
+	require.NotEmpty(t, records[1].Plan)
+
+	require.Equal(t, slowQuerySQLExec, records[2].Kind)
+	require.Empty(t, records[2].Plan)
+
+	page, err := s.slowQueries(ctx, 1, 1)
+	require.NoError(t, err)
+	require.Len(t, page, 1)
+	require.Equal(t, records[1], page[0])
+
+	_, err = s.slowQueries(ctx, 0, database.MaxKeyScanLimit+1)
+	require.ErrorIs(t, err, database.ErrMaxKeyScanLimitExceeded)
+
+	serialized, err := json.Marshal(records)
+	require.NoError(t, err)
+	require.NotContains(t, string(serialized), "secret")
+
+	t.Run("console", func(t *testing.T) {
+		webMux := http.NewServeMux()
+		setupConsoleSQL(webMux, runtime.NewServeMux(), s)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/console/slowqueries?offset=1&limit=2", strings.NewReader(""))
+		req.Header.Set("Authorization", lr.Token)
+
+		rec := httptest.NewRecorder()
+		webMux.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		var consoleRecords []*slowQueryRecord
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &consoleRecords))
+		require.Len(t, consoleRecords, 2)
+		require.Equal(t, slowQuerySQLQuery, consoleRecords[0].Kind)
+
+		req = httptest.NewRequest(http.MethodGet, "/api/console/slowqueries?limit=-1", strings.NewReader(""))
+		req.Header.Set("Authorization", lr.Token)
+
+		rec = httptest.NewRecorder()
+		webMux.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("older slow queries are read past the scan limit", func(t *testing.T) {
+		kvs := make([]*schema.KeyValue, database.MaxKeyScanLimit+5)
+
+		for i := range kvs {
+			record, err := json.Marshal(&slowQueryRecord{Time: time.Unix(int64(i), 0), Kind: slowQueryScan})
+			require.NoError(t, err)
+
+			kvs[i] = &schema.KeyValue{Key: slowQueryKey(time.Unix(int64(i), 0)), Value: record}
+		}
+
+		_, err := s.sysDB.Set(&schema.SetRequest{KVs: kvs})
+		require.NoError(t, err)
+
+		all, err := s.slowQueries(ctx, 0, 0)
+		require.NoError(t, err)
+		require.Len(t, all, database.MaxKeyScanLimit)
+		require.Equal(t, records, all[:len(records)])
+
+		oldest, err := s.slowQueries(ctx, database.MaxKeyScanLimit, 10)
+		require.NoError(t, err)
+		require.Len(t, oldest, len(records)+5)
+		require.Equal(t, time.Unix(0, 0).Unix(), oldest[len(oldest)-1].Time.Unix())
+	})
+
+	t.Run("non admin users can not read slow queries", func(t *testing.T) {
+		_, err = s.CreateUser(ctx, &schema.CreateUserRequest{
+			User:       []byte("user1"),
+			Password:   []byte("user1Password!"),
+			Permission: auth.PermissionAdmin,
+			Database:   DefaultDBName,
+		})
+		require.NoError(t, err)
+
+		lr, err := s.Login(context.Background(), &schema.LoginRequest{User: []byte("user1"), Password: []byte("user1Password!")})
+		require.NoError(t, err)
+
+		userCtx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", lr.Token))
+
+		_, err = s.slowQueries(userCtx, 0, 0)
+		require.ErrorIs(t, err, ErrPermissionDenied)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		s.Options.WithSlowQueryThreshold(0)
+
+		_, err = s.SQLQuery(ctx, &schema.SQLQueryRequest{Sql: "SELECT id FROM table1"})
+		require.NoError(t, err)
+
+		after, err := s.slowQueries(ctx, 0, 0)
+		require.NoError(t, err)
+		require.Len(t, after, database.MaxKeyScanLimit)
+		require.Equal(t, records, after[:len(records)])
+	})
+}
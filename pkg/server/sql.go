@@ -17,6 +17,7 @@ package server
 
 import (
 	"context"
+	"time"
 
 	"github.com/codenotary/immudb/pkg/api/schema"
 	"github.com/golang/protobuf/ptypes/empty"
@@ -64,6 +65,8 @@ func (s *ImmuServer) SQLExec(ctx context.Context, req *schema.SQLExecRequest) (*
 		return nil, err
 	}
 
+	defer s.logSlowQuery(ctx, db, slowQuerySQLExec, req.GetSql(), req.GetParams(), time.Now())
+
 	tx, ctxs, err := db.SQLExec(req, nil)
 	if err != nil {
 		return nil, err
@@ -107,7 +110,9 @@ func (s *ImmuServer) SQLQuery(ctx context.Context, req *schema.SQLQueryRequest)
 		return nil, err
 	}
 
-	return db.SQLQuery(req, nil)
+	defer s.logSlowQuery(ctx, db, slowQuerySQLQuery, req.GetSql(), req.GetParams(), time.Now())
+
+	return db.SQLQuery(ctx, req, nil)
 }
 
 func (s *ImmuServer) ListTables(ctx context.Context, _ *empty.Empty) (*schema.SQLQueryResult, error) {
@@ -24,15 +24,22 @@ import (
 
 type StateSigner interface {
 	Sign(state *schema.ImmutableState) error
+	Keys() []*schema.ServerKey
 }
 
 type stateSigner struct {
 	Signer signer.Signer
+
+	// retiredKeys are the public keys of the signers used before the current one
+	retiredKeys [][]byte
 }
 
-func NewStateSigner(signer signer.Signer) *stateSigner {
+// NewStateSigner returns a state signer. Signatures include the id of the signing key so that,
+// after a key rotation, states signed with any of the retired keys can still be verified
+func NewStateSigner(signer signer.Signer, retiredKeys ...[]byte) *stateSigner {
 	return &stateSigner{
-		Signer: signer,
+		Signer:      signer,
+		retiredKeys: retiredKeys,
 	}
 }
 
@@ -46,7 +53,30 @@ func (sts *stateSigner) Sign(state *schema.ImmutableState) error {
 	state.Signature = &schema.Signature{
 		Signature: signature,
 		PublicKey: publicKey,
+		KeyId:     signer.KeyID(publicKey),
 	}
 
 	return err
 }
+
+// Keys returns the current signing public key followed by the retired ones
+func (sts *stateSigner) Keys() []*schema.ServerKey {
+	current := sts.Signer.PublicKey()
+
+	keys := []*schema.ServerKey{
+		{
+			KeyId:     signer.KeyID(current),
+			PublicKey: current,
+			Current:   true,
+		},
+	}
+
+	for _, k := range sts.retiredKeys {
+		keys = append(keys, &schema.ServerKey{
+			KeyId:     signer.KeyID(k),
+			PublicKey: k,
+		})
+	}
+
+	return keys
+}
@@ -46,3 +46,22 @@ func TestStateSigner_Err(t *testing.T) {
 	err := stSigner.Sign(nil)
 	assert.Error(t, store.ErrIllegalArguments, err)
 }
+
+func TestStateSigner_Keys(t *testing.T) {
+	s, _ := signer.NewSigner("./../../test/signer/ec3.key")
+	retired, _ := signer.NewSigner("./../../test/signer/ec1.key")
+
+	stSigner := NewStateSigner(s, retired.PublicKey())
+
+	state := &schema.ImmutableState{}
+	err := stSigner.Sign(state)
+	assert.NoError(t, err)
+	assert.Equal(t, signer.KeyID(s.PublicKey()), state.Signature.KeyId)
+
+	keys := stSigner.Keys()
+	assert.Len(t, keys, 2)
+	assert.Equal(t, state.Signature.KeyId, keys[0].KeyId)
+	assert.True(t, keys[0].Current)
+	assert.Equal(t, signer.KeyID(retired.PublicKey()), keys[1].KeyId)
+	assert.False(t, keys[1].Current)
+}
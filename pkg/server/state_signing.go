@@ -0,0 +1,163 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/database"
+	"github.com/codenotary/immudb/pkg/signer"
+	"github.com/golang/protobuf/ptypes/empty"
+)
+
+// GetServerKeys returns the public keys used by the server to sign states, including the ones
+// retired after a key rotation. Keys are identified by the key id included in every signature
+func (s *ImmuServer) GetServerKeys(ctx context.Context, _ *empty.Empty) (*schema.ServerKeys, error) {
+	if s.StateSigner == nil {
+		return nil, ErrStateSigningNotEnabled
+	}
+
+	return &schema.ServerKeys{Keys: s.StateSigner.Keys()}, nil
+}
+
+// signState signs the provided state, the signature of the latest signed state
+// of the database is reused when the state did not change since it was signed
+func (s *ImmuServer) signState(state *schema.ImmutableState) error {
+	s.signedStatesMutex.RLock()
+	signed, ok := s.signedStates[state.Db]
+	s.signedStatesMutex.RUnlock()
+
+	if ok && signed.TxId == state.TxId && bytes.Equal(signed.TxHash, state.TxHash) {
+		state.Signature = signed.Signature
+		return nil
+	}
+
+	err := s.StateSigner.Sign(state)
+	if err != nil {
+		return err
+	}
+
+	s.signedStatesMutex.Lock()
+	defer s.signedStatesMutex.Unlock()
+
+	if s.signedStates == nil {
+		s.signedStates = make(map[string]*schema.ImmutableState)
+	}
+
+	signed, ok = s.signedStates[state.Db]
+	if !ok || signed.TxId <= state.TxId {
+		s.signedStates[state.Db] = &schema.ImmutableState{
+			Db:        state.Db,
+			TxId:      state.TxId,
+			TxHash:    state.TxHash,
+			Signature: state.Signature,
+		}
+	}
+
+	return nil
+}
+
+// signDatabaseStates signs the current state of every loaded database, so a fresh signed state
+// is available even for databases not receiving requests
+func (s *ImmuServer) signDatabaseStates() {
+	dbs := make([]database.DB, 0, s.dbList.Length()+1)
+
+	if s.sysDB != nil {
+		dbs = append(dbs, s.sysDB)
+	}
+
+	for i := 0; i < s.dbList.Length(); i++ {
+		dbs = append(dbs, s.dbList.GetByIndex(int64(i)))
+	}
+
+	for _, db := range dbs {
+		state, err := db.CurrentState()
+		if err != nil {
+			s.Logger.Warningf("Unable to sign the state of database '%s'. Reason: %v", db.GetName(), err)
+			continue
+		}
+
+		state.Db = db.GetName()
+
+		err = s.signState(state)
+		if err != nil {
+			s.Logger.Warningf("Unable to sign the state of database '%s'. Reason: %v", db.GetName(), err)
+		}
+	}
+}
+
+func (s *ImmuServer) startStateSigning() {
+	if s.StateSigner == nil || s.Options.StateSigningInterval <= 0 {
+		return
+	}
+
+	s.Logger.Infof("Signing database states every %v", s.Options.StateSigningInterval)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	s.stateSigningCancel = cancel
+	s.stateSigningDone = done
+
+	go func() {
+		defer close(done)
+
+		for {
+			s.signDatabaseStates()
+
+			timer := time.NewTimer(s.Options.StateSigningInterval)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+		}
+	}()
+}
+
+func (s *ImmuServer) stopStateSigning() {
+	if s.stateSigningCancel == nil {
+		return
+	}
+
+	s.stateSigningCancel()
+	<-s.stateSigningDone
+
+	s.stateSigningCancel = nil
+	s.stateSigningDone = nil
+}
+
+// loadRetiredSigningKeys reads the public key files of the signing keys used before the current one
+func loadRetiredSigningKeys(paths []string) ([][]byte, error) {
+	keys := make([][]byte, 0, len(paths))
+
+	for _, path := range paths {
+		pk, err := signer.ParsePublicKeyFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+
+		keys = append(keys, signer.MarshalKey(pk))
+	}
+
+	return keys, nil
+}
@@ -0,0 +1,156 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/signer"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+func newSigningServer(t *testing.T, interval time.Duration) *ImmuServer {
+	dir, err := ioutil.TempDir("", "state_signing")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	s := DefaultServer()
+	s.WithOptions(s.Options.
+		WithDir(dir).
+		WithAuth(false).
+		WithSigningKey("./../../test/signer/ec3.key").
+		WithRetiredSigningKeys([]string{"./../../test/signer/ec1.pub"}).
+		WithStateSigningInterval(interval))
+
+	sig, err := signer.NewSigner(s.Options.SigningKey)
+	require.NoError(t, err)
+
+	retiredKeys, err := loadRetiredSigningKeys(s.Options.RetiredSigningKeys)
+	require.NoError(t, err)
+
+	s.WithStateSigner(NewStateSigner(sig, retiredKeys...))
+
+	err = s.loadSystemDatabase(dir, nil, s.Options.AdminPassword)
+	require.NoError(t, err)
+
+	err = s.loadDefaultDatabase(dir, nil)
+	require.NoError(t, err)
+
+	t.Cleanup(func() { s.CloseDatabases() })
+
+	return s
+}
+
+func TestGetServerKeys(t *testing.T) {
+	s := DefaultServer()
+
+	_, err := s.GetServerKeys(context.Background(), &emptypb.Empty{})
+	require.ErrorIs(t, err, ErrStateSigningNotEnabled)
+
+	s = newSigningServer(t, 0)
+
+	keys, err := s.GetServerKeys(context.Background(), &emptypb.Empty{})
+	require.NoError(t, err)
+	require.Len(t, keys.Keys, 2)
+
+	current, err := signer.ParsePublicKeyFile("./../../test/signer/ec3.pub")
+	require.NoError(t, err)
+
+	retired, err := signer.ParsePublicKeyFile("./../../test/signer/ec1.pub")
+	require.NoError(t, err)
+
+	require.True(t, keys.Keys[0].Current)
+	require.Equal(t, signer.MarshalKey(current), keys.Keys[0].PublicKey)
+	require.Equal(t, signer.KeyID(keys.Keys[0].PublicKey), keys.Keys[0].KeyId)
+
+	require.False(t, keys.Keys[1].Current)
+	require.Equal(t, signer.MarshalKey(retired), keys.Keys[1].PublicKey)
+	require.Equal(t, signer.KeyID(keys.Keys[1].PublicKey), keys.Keys[1].KeyId)
+
+	state, err := s.CurrentState(context.Background(), &emptypb.Empty{})
+	require.NoError(t, err)
+	require.Equal(t, keys.Keys[0].KeyId, state.Signature.KeyId)
+}
+
+func TestCurrentStateSignatureReuse(t *testing.T) {
+	s := newSigningServer(t, 0)
+	ctx := context.Background()
+
+	state1, err := s.CurrentState(ctx, &emptypb.Empty{})
+	require.NoError(t, err)
+
+	state2, err := s.CurrentState(ctx, &emptypb.Empty{})
+	require.NoError(t, err)
+	require.Equal(t, state1.Signature.Signature, state2.Signature.Signature)
+
+	_, err = s.Set(ctx, &schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte("key1"), Value: []byte("value1")}}})
+	require.NoError(t, err)
+
+	state3, err := s.CurrentState(ctx, &emptypb.Empty{})
+	require.NoError(t, err)
+	require.Greater(t, state3.TxId, state1.TxId)
+	require.NotEqual(t, state1.Signature.Signature, state3.Signature.Signature)
+
+	pk, err := signer.UnmarshalKey(state3.Signature.PublicKey)
+	require.NoError(t, err)
+
+	ok, err := state3.CheckSignature(pk)
+	require.NoError(t, err)
+	require.True(t, ok)
+}
+
+func TestPeriodicStateSigning(t *testing.T) {
+	s := newSigningServer(t, 10*time.Millisecond)
+	ctx := context.Background()
+
+	s.startStateSigning()
+	defer s.stopStateSigning()
+
+	_, err := s.Set(ctx, &schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte("key1"), Value: []byte("value1")}}})
+	require.NoError(t, err)
+
+	state, err := s.dbList.GetByIndex(defaultDbIndex).CurrentState()
+	require.NoError(t, err)
+
+	for {
+		s.signedStatesMutex.RLock()
+		signed, ok := s.signedStates[DefaultDBName]
+		_, sysOk := s.signedStates[SystemDBName]
+		s.signedStatesMutex.RUnlock()
+
+		if ok && sysOk && signed.TxId == state.TxId {
+			pk, err := signer.UnmarshalKey(signed.Signature.PublicKey)
+			require.NoError(t, err)
+
+			ok, err := signed.CheckSignature(pk)
+			require.NoError(t, err)
+			require.True(t, ok)
+			break
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+
+	s.stopStateSigning()
+	require.Nil(t, s.stateSigningCancel)
+}
@@ -19,6 +19,7 @@ package server
 import (
 	"bytes"
 
+	"github.com/codenotary/immudb/embedded/watchers"
 	"github.com/codenotary/immudb/pkg/api/schema"
 )
 
@@ -32,7 +33,11 @@ func (s *ImmuServer) ExportTx(req *schema.ExportTxRequest, txsServer schema.Immu
 		return err
 	}
 
-	err = db.WaitForTx(req.Tx, nil)
+	// the replica may be waiting for a tx yet to be committed, it stops waiting once it's gone
+	err = db.WaitForTx(req.Tx, txsServer.Context().Done())
+	if err == watchers.ErrCancellationRequested {
+		return txsServer.Context().Err()
+	}
 	if err != nil {
 		return err
 	}
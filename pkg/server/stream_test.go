@@ -2,9 +2,12 @@ package server
 
 import (
 	"context"
+	"fmt"
 	"testing"
 
 	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/database"
+	"github.com/codenotary/immudb/pkg/stream"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc"
 )
@@ -30,6 +33,82 @@ func TestImmuServer_StreamGetDbError(t *testing.T) {
 	require.Error(t, err)
 }
 
+func TestImmuServer_StreamHistoryWithConcurrentWrites(t *testing.T) {
+	s, ctx, closer := newTestServer(t, nil)
+	defer closer()
+
+	// more revisions than a single history page
+	revisions := database.MaxKeyScanLimit + 5
+
+	for i := 0; i < revisions; i++ {
+		_, err := s.Set(ctx, &schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte("key"), Value: []byte(fmt.Sprintf("val-%d", i))}}})
+		require.NoError(t, err)
+	}
+
+	for _, desc := range []bool{false, true} {
+		var values []string
+
+		// the history is streamed as it was when the call was made
+		expected := revisions
+
+		s.StreamServiceFactory = &historyStreamFactoryMock{
+			ServiceFactory: stream.NewStreamServiceFactory(s.Options.StreamChunkSize),
+			send: func(kv *stream.KeyValue) error {
+				value, err := stream.ReadValue(kv.Value.Content, s.Options.StreamChunkSize)
+				require.NoError(t, err)
+
+				values = append(values, string(value))
+
+				// new revisions are committed while the first page is being sent
+				if len(values) == 1 {
+					for i := 0; i < 3; i++ {
+						_, err = s.Set(ctx, &schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte("key"), Value: []byte(fmt.Sprintf("val-%d", revisions))}}})
+						require.NoError(t, err)
+
+						revisions++
+					}
+				}
+
+				return nil
+			},
+		}
+
+		err := s.StreamHistory(&schema.HistoryRequest{Key: []byte("key"), Desc: desc}, &historyStreamServerMock{ctx: ctx})
+		require.NoError(t, err)
+		require.Len(t, values, expected)
+
+		for i, v := range values {
+			if desc {
+				require.Equal(t, fmt.Sprintf("val-%d", expected-1-i), v)
+			} else {
+				require.Equal(t, fmt.Sprintf("val-%d", i), v)
+			}
+		}
+	}
+}
+
+type historyStreamServerMock struct {
+	StreamServerMock
+	ctx context.Context
+}
+
+func (s *historyStreamServerMock) Context() context.Context {
+	return s.ctx
+}
+
+type historyStreamFactoryMock struct {
+	stream.ServiceFactory
+	send func(kv *stream.KeyValue) error
+}
+
+func (f *historyStreamFactoryMock) NewKvStreamSender(str stream.MsgSender) stream.KvStreamSender {
+	return f
+}
+
+func (f *historyStreamFactoryMock) Send(kv *stream.KeyValue) error {
+	return f.send(kv)
+}
+
 type StreamServerMock struct {
 	grpc.ServerStream
 }
@@ -25,6 +25,7 @@ import (
 
 	"github.com/codenotary/immudb/embedded/store"
 	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/database"
 	"github.com/codenotary/immudb/pkg/stream"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -40,7 +41,7 @@ func (s *ImmuServer) StreamGet(kr *schema.KeyRequest, str schema.ImmuService_Str
 
 	kvsr := s.StreamServiceFactory.NewKvStreamSender(s.StreamServiceFactory.NewMsgSender(str))
 
-	entry, err := db.Get(kr)
+	entry, err := db.Get(str.Context(), kr)
 	if err != nil {
 		return err
 	}
@@ -125,7 +126,7 @@ func (s *ImmuServer) StreamVerifiableGet(req *schema.VerifiableGetRequest, str s
 
 	vess := s.StreamServiceFactory.NewVEntryStreamSender(s.StreamServiceFactory.NewMsgSender(str))
 
-	vEntry, err := db.VerifiableGet(req)
+	vEntry, err := db.VerifiableGet(str.Context(), req)
 	if err != nil {
 		return err
 	}
@@ -297,7 +298,7 @@ func (s *ImmuServer) StreamScan(req *schema.ScanRequest, str schema.ImmuService_
 		return err
 	}
 
-	r, err := db.Scan(req)
+	r, err := db.Scan(str.Context(), req)
 	if err != nil {
 		return err
 	}
@@ -325,96 +326,179 @@ func (s *ImmuServer) StreamScan(req *schema.ScanRequest, str schema.ImmuService_
 	return nil
 }
 
-// StreamZScan ...
+// StreamZScan sends sorted set entries to the client as they are scanned.
+// Entries are read in pages of at most database.MaxKeyScanLimit entries, so the whole set can be
+// consumed in a single call while keeping memory usage bounded. A zero limit streams all the entries.
 func (s *ImmuServer) StreamZScan(request *schema.ZScanRequest, server schema.ImmuService_StreamZScanServer) error {
 	db, err := s.getDBFromCtx(server.Context(), "ZScan")
 	if err != nil {
 		return err
 	}
 
-	r, err := db.ZScan(request)
-	if err != nil {
-		return err
+	if request == nil {
+		return ErrIllegalArguments
 	}
 
 	zss := s.StreamServiceFactory.NewZStreamSender(s.StreamServiceFactory.NewMsgSender(server))
 
-	for _, e := range r.Entries {
-		scoreBs, err := stream.NumberToBytes(e.Score)
-		if err != nil {
-			s.Logger.Errorf(
-				"StreamZScan error: could not convert score %f to bytes: %v", e.Score, err)
+	pageReq := proto.Clone(request).(*schema.ZScanRequest)
+	sent := uint64(0)
+
+	for {
+		pageReq.Limit = database.MaxKeyScanLimit
+		if request.Limit > 0 && request.Limit-sent < pageReq.Limit {
+			pageReq.Limit = request.Limit - sent
 		}
 
-		atTxBs, err := stream.NumberToBytes(e.AtTx)
+		r, err := db.ZScan(server.Context(), pageReq)
 		if err != nil {
-			s.Logger.Errorf(
-				"StreamZScan error: could not convert atTx %d to bytes: %v", e.AtTx, err)
+			return err
 		}
 
-		ze := &stream.ZEntry{
-			Set: &stream.ValueSize{
-				Content: bufio.NewReader(bytes.NewBuffer(e.Set)),
-				Size:    len(e.Set),
-			},
-			Key: &stream.ValueSize{
-				Content: bufio.NewReader(bytes.NewBuffer(e.Key)),
-				Size:    len(e.Key),
-			},
-			Score: &stream.ValueSize{
-				Content: bufio.NewReader(bytes.NewBuffer(scoreBs)),
-				Size:    len(scoreBs),
-			},
-			AtTx: &stream.ValueSize{
-				Content: bufio.NewReader(bytes.NewBuffer(atTxBs)),
-				Size:    len(atTxBs),
-			},
-			Value: &stream.ValueSize{
-				Content: bufio.NewReader(bytes.NewBuffer(e.Entry.Value)),
-				Size:    len(e.Entry.Value),
-			},
+		for _, e := range r.Entries {
+			scoreBs, err := stream.NumberToBytes(e.Score)
+			if err != nil {
+				s.Logger.Errorf(
+					"StreamZScan error: could not convert score %f to bytes: %v", e.Score, err)
+			}
+
+			atTxBs, err := stream.NumberToBytes(e.AtTx)
+			if err != nil {
+				s.Logger.Errorf(
+					"StreamZScan error: could not convert atTx %d to bytes: %v", e.AtTx, err)
+			}
+
+			ze := &stream.ZEntry{
+				Set: &stream.ValueSize{
+					Content: bufio.NewReader(bytes.NewBuffer(e.Set)),
+					Size:    len(e.Set),
+				},
+				Key: &stream.ValueSize{
+					Content: bufio.NewReader(bytes.NewBuffer(e.Key)),
+					Size:    len(e.Key),
+				},
+				Score: &stream.ValueSize{
+					Content: bufio.NewReader(bytes.NewBuffer(scoreBs)),
+					Size:    len(scoreBs),
+				},
+				AtTx: &stream.ValueSize{
+					Content: bufio.NewReader(bytes.NewBuffer(atTxBs)),
+					Size:    len(atTxBs),
+				},
+				Value: &stream.ValueSize{
+					Content: bufio.NewReader(bytes.NewBuffer(e.Entry.Value)),
+					Size:    len(e.Entry.Value),
+				},
+			}
+
+			err = zss.Send(ze)
+			if err != nil {
+				return err
+			}
 		}
 
-		err = zss.Send(ze)
-		if err != nil {
-			return err
+		sent += uint64(len(r.Entries))
+
+		if uint64(len(r.Entries)) < pageReq.Limit || sent == request.Limit {
+			return nil
 		}
+
+		// next page starts right after the last sent entry,
+		// entries indexed so far were already waited for
+		last := r.Entries[len(r.Entries)-1]
+
+		pageReq.SeekKey = last.Key
+		pageReq.SeekScore = last.Score
+		pageReq.SeekAtTx = last.AtTx
+		pageReq.InclusiveSeek = false
+		pageReq.NoWait = true
 	}
-	return nil
 }
 
+// StreamHistory sends the history of a key to the client as it is read.
+// Entries are read in pages of at most database.MaxKeyScanLimit entries, so long histories can be
+// consumed in a single call while keeping memory usage bounded. A zero limit streams the whole history.
+// The history is sent as of the tx committed when the call is made, every page resumes right after
+// the last sent entry, so entries committed while it's being sent are neither sent nor shift the pages.
 func (s *ImmuServer) StreamHistory(request *schema.HistoryRequest, server schema.ImmuService_StreamHistoryServer) error {
 	db, err := s.getDBFromCtx(server.Context(), "History")
 	if err != nil {
 		return err
 	}
 
-	r, err := db.History(request)
+	if request == nil || request.Limit < 0 {
+		return ErrIllegalArguments
+	}
+
+	state, err := db.CurrentState()
 	if err != nil {
 		return err
 	}
 
 	kvsr := s.StreamServiceFactory.NewKvStreamSender(s.StreamServiceFactory.NewMsgSender(server))
 
-	for _, e := range r.Entries {
-		kv := &stream.KeyValue{
-			Key: &stream.ValueSize{
-				Content: bufio.NewReader(bytes.NewBuffer(e.Key)),
-				Size:    len(e.Key),
-			},
-			Value: &stream.ValueSize{
-				Content: bufio.NewReader(bytes.NewBuffer(e.Value)),
-				Size:    len(e.Value),
-			},
-		}
+	pageReq := proto.Clone(request).(*schema.HistoryRequest)
+	if pageReq.SinceTx == 0 {
+		// the history is read as of the current tx, which is only waited for by the first page
+		pageReq.SinceTx = state.TxId
+	}
 
-		err = kvsr.Send(kv)
+	sent := int32(0)
+	lastTx := uint64(0)
+
+	for {
+		pageReq.Limit = database.MaxKeyScanLimit
+
+		r, err := db.History(server.Context(), pageReq)
 		if err != nil {
 			return err
 		}
-	}
 
-	return nil
+		for _, e := range r.Entries {
+			if e.Tx > state.TxId {
+				// committed after the call was made, entries are sorted by tx
+				if request.Desc {
+					continue
+				}
+				return nil
+			}
+
+			// entries committed in the meantime shift the pages of the history in descending order,
+			// so the entries already sent are read again
+			if lastTx > 0 && ((request.Desc && e.Tx >= lastTx) || (!request.Desc && e.Tx <= lastTx)) {
+				continue
+			}
+
+			kv := &stream.KeyValue{
+				Key: &stream.ValueSize{
+					Content: bufio.NewReader(bytes.NewBuffer(e.Key)),
+					Size:    len(e.Key),
+				},
+				Value: &stream.ValueSize{
+					Content: bufio.NewReader(bytes.NewBuffer(e.Value)),
+					Size:    len(e.Value),
+				},
+			}
+
+			err = kvsr.Send(kv)
+			if err != nil {
+				return err
+			}
+
+			lastTx = e.Tx
+			sent++
+
+			if sent == request.Limit {
+				return nil
+			}
+		}
+
+		if int32(len(r.Entries)) < pageReq.Limit {
+			return nil
+		}
+
+		pageReq.Offset += uint64(len(r.Entries))
+	}
 }
 
 func (s *ImmuServer) StreamExecAll(str schema.ImmuService_StreamExecAllServer) error {
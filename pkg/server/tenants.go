@@ -0,0 +1,311 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sync"
+
+	"github.com/codenotary/immudb/pkg/auth"
+	"github.com/codenotary/immudb/pkg/database"
+	"github.com/codenotary/immudb/pkg/server/sessions"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// TenantQuotas limits the resources used by a tenant, limits set to 0 are not enforced
+type TenantQuotas struct {
+	MaxDatabases int   `json:"maxDatabases"`
+	MaxDiskUsage int64 `json:"maxDiskUsage"` // bytes
+	MaxSessions  int   `json:"maxSessions"`
+}
+
+// TenantUsage holds the resources currently used by a tenant
+type TenantUsage struct {
+	Databases int
+	DiskUsage int64
+	Sessions  int
+}
+
+// ReadTenantsFile reads the tenants served by this server along with their quotas from a json file, e.g.
+//
+//	{
+//	  "tenants": {
+//	    "acme": {"maxDatabases": 5, "maxDiskUsage": 10737418240, "maxSessions": 20},
+//	    "globex": {"maxSessions": 5}
+//	  }
+//	}
+func ReadTenantsFile(path string) (map[string]*TenantQuotas, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var config struct {
+		Tenants map[string]*TenantQuotas `json:"tenants"`
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+
+	err = dec.Decode(&config)
+	if err != nil {
+		return nil, err
+	}
+
+	for name, quotas := range config.Tenants {
+		if !auth.IsValidUsername(name) {
+			return nil, fmt.Errorf("%w: tenant name '%s' can only contain letters, digits and underscores", ErrIllegalArguments, name)
+		}
+
+		if quotas == nil {
+			config.Tenants[name] = &TenantQuotas{}
+			continue
+		}
+
+		if quotas.MaxDatabases < 0 || quotas.MaxDiskUsage < 0 || quotas.MaxSessions < 0 {
+			return nil, fmt.Errorf("%w: quotas of tenant '%s' must not be negative", ErrIllegalArguments, name)
+		}
+	}
+
+	return config.Tenants, nil
+}
+
+// tenancy keeps track of the databases owned by each tenant to enforce their quotas
+type tenancy struct {
+	quotas map[string]*TenantQuotas
+
+	mutex     sync.Mutex
+	databases map[string]string // database name -> tenant
+
+	sessionsMutex sync.Mutex
+}
+
+func newTenancy(quotas map[string]*TenantQuotas) *tenancy {
+	return &tenancy{
+		quotas:    quotas,
+		databases: make(map[string]string),
+	}
+}
+
+func (t *tenancy) databasesOf(tenant string) []string {
+	var dbs []string
+	for db, owner := range t.databases {
+		if owner == tenant {
+			dbs = append(dbs, db)
+		}
+	}
+	return dbs
+}
+
+func tenantQuotaExceeded(tenant string, format string, args ...interface{}) error {
+	return status.Errorf(codes.ResourceExhausted, "quota of tenant '%s' exceeded: %s", tenant, fmt.Sprintf(format, args...))
+}
+
+// loadTenants reads the tenants file set in the server options, if any
+func (s *ImmuServer) loadTenants() error {
+	if s.Options.TenantsFile == "" {
+		return nil
+	}
+
+	quotas, err := ReadTenantsFile(s.Options.TenantsFile)
+	if err != nil {
+		return err
+	}
+
+	s.tenants = newTenancy(quotas)
+
+	return nil
+}
+
+// checkTenant checks the tenant is served by this server, databases not owned by any tenant can always be created
+func (s *ImmuServer) checkTenant(tenant string) error {
+	if tenant == "" {
+		return nil
+	}
+
+	if s.tenants == nil {
+		return fmt.Errorf("%w: tenants are not enabled on this server", ErrIllegalArguments)
+	}
+
+	if _, ok := s.tenants.quotas[tenant]; !ok {
+		return fmt.Errorf("%w: tenant '%s' not found", ErrIllegalArguments, tenant)
+	}
+
+	return nil
+}
+
+// tenantOf returns the tenant owning the database, empty if it's not owned by any tenant
+func (s *ImmuServer) tenantOf(db string) string {
+	if s.tenants == nil {
+		return ""
+	}
+
+	s.tenants.mutex.Lock()
+	defer s.tenants.mutex.Unlock()
+
+	return s.tenants.databases[db]
+}
+
+// addTenantDatabase records the database as owned by the tenant, failing if the tenant already owns as many databases as allowed
+func (s *ImmuServer) addTenantDatabase(tenant string, db string) error {
+	if s.tenants == nil || tenant == "" {
+		return nil
+	}
+
+	s.tenants.mutex.Lock()
+	defer s.tenants.mutex.Unlock()
+
+	quotas, ok := s.tenants.quotas[tenant]
+	if ok && quotas.MaxDatabases > 0 && len(s.tenants.databasesOf(tenant)) >= quotas.MaxDatabases {
+		return tenantQuotaExceeded(tenant, "max number of databases (%d) reached", quotas.MaxDatabases)
+	}
+
+	s.tenants.databases[db] = tenant
+
+	return nil
+}
+
+// removeTenantDatabase releases the quota taken by a database which could not be created
+func (s *ImmuServer) removeTenantDatabase(db string) {
+	if s.tenants == nil {
+		return
+	}
+
+	s.tenants.mutex.Lock()
+	defer s.tenants.mutex.Unlock()
+
+	delete(s.tenants.databases, db)
+}
+
+// loadTenantDatabase records the owner of a database loaded on startup, quotas are not enforced on existing databases
+func (s *ImmuServer) loadTenantDatabase(tenant string, db string) {
+	if tenant == "" {
+		return
+	}
+
+	if s.tenants == nil {
+		s.Logger.Warningf("Database '%s' is owned by tenant '%s' but tenants are not enabled, its quotas are not enforced", db, tenant)
+		return
+	}
+
+	if _, ok := s.tenants.quotas[tenant]; !ok {
+		s.Logger.Warningf("Database '%s' is owned by unknown tenant '%s', its quotas are not enforced", db, tenant)
+	}
+
+	s.tenants.mutex.Lock()
+	defer s.tenants.mutex.Unlock()
+
+	s.tenants.databases[db] = tenant
+}
+
+// tenantDiskUsage returns the disk space taken by the databases of the tenant, as cached by each database
+func (s *ImmuServer) tenantDiskUsage(tenant string) int64 {
+	s.tenants.mutex.Lock()
+	dbs := s.tenants.databasesOf(tenant)
+	s.tenants.mutex.Unlock()
+
+	var usage int64
+
+	for _, name := range dbs {
+		db, err := s.dbList.GetByName(name)
+		if err != nil {
+			// the database is still being created
+			continue
+		}
+
+		size, err := db.DiskUsage()
+		if err != nil {
+			s.Logger.Warningf("Disk usage of database '%s' could not be computed: %v", name, err)
+			continue
+		}
+
+		usage += size
+	}
+
+	return usage
+}
+
+// checkTenantDiskQuota rejects writes into the databases of a tenant which already uses as much disk space as allowed
+func (s *ImmuServer) checkTenantDiskQuota(db string) error {
+	tenant := s.tenantOf(db)
+	if tenant == "" {
+		return nil
+	}
+
+	quotas, ok := s.tenants.quotas[tenant]
+	if !ok || quotas.MaxDiskUsage == 0 {
+		return nil
+	}
+
+	if s.tenantDiskUsage(tenant) >= quotas.MaxDiskUsage {
+		return tenantQuotaExceeded(tenant, "max disk usage (%d bytes) reached", quotas.MaxDiskUsage)
+	}
+
+	return nil
+}
+
+// newSession opens a session for the user, failing if its tenant already has as many sessions as allowed
+func (s *ImmuServer) newSession(u *auth.User, db database.DB) (*sessions.Session, error) {
+	if s.tenants == nil || u.Tenant == "" {
+		return s.SessManager.NewSession(u, db)
+	}
+
+	quotas, ok := s.tenants.quotas[u.Tenant]
+	if !ok || quotas.MaxSessions == 0 {
+		return s.SessManager.NewSession(u, db)
+	}
+
+	// sessions of a tenant are only opened here, so they can't be opened
+	// concurrently between counting them and opening a new one
+	s.tenants.sessionsMutex.Lock()
+	defer s.tenants.sessionsMutex.Unlock()
+
+	if s.SessManager.TenantSessionCount(u.Tenant) >= quotas.MaxSessions {
+		return nil, tenantQuotaExceeded(u.Tenant, "max number of sessions (%d) reached", quotas.MaxSessions)
+	}
+
+	return s.SessManager.NewSession(u, db)
+}
+
+// tenantUsage returns the resources currently used by every tenant
+func (s *ImmuServer) tenantUsage() map[string]TenantUsage {
+	usage := make(map[string]TenantUsage)
+
+	if s.tenants == nil {
+		return usage
+	}
+
+	for tenant := range s.tenants.quotas {
+		u := TenantUsage{DiskUsage: s.tenantDiskUsage(tenant)}
+
+		if s.SessManager != nil {
+			u.Sessions = s.SessManager.TenantSessionCount(tenant)
+		}
+
+		s.tenants.mutex.Lock()
+		u.Databases = len(s.tenants.databasesOf(tenant))
+		s.tenants.mutex.Unlock()
+
+		usage[tenant] = u
+	}
+
+	return usage
+}
@@ -0,0 +1,244 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/auth"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const testTenants = `{
+	"tenants": {
+		"acme": {"maxDatabases": 2, "maxSessions": 1},
+		"globex": {"maxDiskUsage": 1},
+		"initech": null
+	}
+}`
+
+func TestReadTenantsFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tenants")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "tenants.json")
+
+	_, err = ReadTenantsFile(path)
+	require.Error(t, err)
+
+	for _, invalid := range []string{
+		`{"tenants": `,
+		`{"tenants": {"acme": {"maxDatabase": 1}}}`,
+		`{"tenants": {"acme": {"maxSessions": -1}}}`,
+		`{"tenants": {"acme corp": {}}}`,
+		`{"tenants": {"": {}}}`,
+	} {
+		err = ioutil.WriteFile(path, []byte(invalid), 0644)
+		require.NoError(t, err)
+
+		_, err = ReadTenantsFile(path)
+		require.Error(t, err)
+	}
+
+	err = ioutil.WriteFile(path, []byte(testTenants), 0644)
+	require.NoError(t, err)
+
+	tenants, err := ReadTenantsFile(path)
+	require.NoError(t, err)
+	require.Len(t, tenants, 3)
+	require.Equal(t, &TenantQuotas{MaxDatabases: 2, MaxSessions: 1}, tenants["acme"])
+	require.Equal(t, &TenantQuotas{MaxDiskUsage: 1}, tenants["globex"])
+	require.Equal(t, &TenantQuotas{}, tenants["initech"])
+}
+
+func requireQuotaExceeded(t *testing.T, err error) {
+	require.Error(t, err)
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+}
+
+func TestTenants(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tenants")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	tenantsFile := filepath.Join(dir, "tenants.json")
+
+	err = ioutil.WriteFile(tenantsFile, []byte(testTenants), 0644)
+	require.NoError(t, err)
+
+	serverOptions := DefaultOptions().WithTenantsFile(tenantsFile)
+
+	s, ctx, closer := newTestServer(t, serverOptions)
+	defer closer()
+
+	t.Run("databases should only be owned by known tenants", func(t *testing.T) {
+		_, err := s.CreateDatabaseWithV2(ctx, &schema.DatabaseSettingsV2{DatabaseName: "db0", Tenant: "unknown"})
+		require.ErrorIs(t, err, ErrIllegalArguments)
+	})
+
+	t.Run("tenants should not own more databases than allowed", func(t *testing.T) {
+		settings, err := s.CreateDatabaseWithV2(ctx, &schema.DatabaseSettingsV2{DatabaseName: "acmedb1", Tenant: "acme"})
+		require.NoError(t, err)
+		require.Equal(t, "acme", settings.Tenant)
+
+		_, err = s.CreateDatabaseWithV2(ctx, &schema.DatabaseSettingsV2{DatabaseName: "acmedb2", Tenant: "acme"})
+		require.NoError(t, err)
+
+		_, err = s.CreateDatabaseWithV2(ctx, &schema.DatabaseSettingsV2{DatabaseName: "acmedb3", Tenant: "acme"})
+		requireQuotaExceeded(t, err)
+
+		_, err = s.CloneDatabase(ctx, &schema.CloneDatabaseRequest{SourceDatabase: "acmedb1", DatabaseName: "acmedb4"})
+		requireQuotaExceeded(t, err)
+
+		require.Equal(t, int64(-1), s.dbList.GetId("acmedb3"))
+		require.Equal(t, int64(-1), s.dbList.GetId("acmedb4"))
+	})
+
+	t.Run("the owner of a database should not be changed", func(t *testing.T) {
+		_, err := s.UpdateDatabaseV2(ctx, &schema.DatabaseSettingsV2{DatabaseName: "acmedb1", Tenant: "globex"})
+		require.ErrorIs(t, err, ErrIllegalArguments)
+	})
+
+	t.Run("users should be owned by the tenant of their database", func(t *testing.T) {
+		_, err := s.CreateUser(ctx, &schema.CreateUserRequest{
+			User:       []byte("wile"),
+			Password:   []byte("Pa$$w0rd1"),
+			Permission: auth.PermissionRW,
+			Database:   "acmedb1",
+		})
+		require.NoError(t, err)
+
+		u, err := s.getUser([]byte("wile"), true)
+		require.NoError(t, err)
+		require.Equal(t, "acme", u.Tenant)
+
+		_, err = s.ChangePermission(ctx, &schema.ChangePermissionRequest{
+			Action:     schema.PermissionAction_GRANT,
+			Username:   "wile",
+			Database:   "acmedb2",
+			Permission: auth.PermissionR,
+		})
+		require.NoError(t, err)
+
+		_, err = s.ChangePermission(ctx, &schema.ChangePermissionRequest{
+			Action:     schema.PermissionAction_GRANT,
+			Username:   "wile",
+			Database:   DefaultDBName,
+			Permission: auth.PermissionR,
+		})
+		require.Equal(t, codes.PermissionDenied, status.Code(err))
+	})
+
+	t.Run("users of a tenant should not open more sessions than allowed", func(t *testing.T) {
+		open := func() (*schema.OpenSessionResponse, error) {
+			return s.OpenSession(context.Background(), &schema.OpenSessionRequest{
+				Username:     []byte("wile"),
+				Password:     []byte("Pa$$w0rd1"),
+				DatabaseName: "acmedb1",
+			})
+		}
+
+		sres, err := open()
+		require.NoError(t, err)
+
+		_, err = open()
+		requireQuotaExceeded(t, err)
+
+		// sessions of the sysadmin are not accounted to any tenant
+		_, err = s.OpenSession(context.Background(), &schema.OpenSessionRequest{
+			Username:     []byte(auth.SysAdminUsername),
+			Password:     []byte(auth.SysAdminPassword),
+			DatabaseName: "acmedb1",
+		})
+		require.NoError(t, err)
+
+		_, err = s.CloseSession(metadata.NewIncomingContext(context.Background(), metadata.Pairs("sessionid", sres.SessionID)), nil)
+		require.NoError(t, err)
+
+		_, err = open()
+		require.NoError(t, err)
+	})
+
+	t.Run("writes should be rejected once the tenant uses as much disk space as allowed", func(t *testing.T) {
+		_, err := s.CreateDatabaseWithV2(ctx, &schema.DatabaseSettingsV2{DatabaseName: "globexdb", Tenant: "globex"})
+		require.NoError(t, err)
+
+		udr, err := s.UseDatabase(ctx, &schema.Database{DatabaseName: "globexdb"})
+		require.NoError(t, err)
+
+		dbCtx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", udr.Token))
+
+		_, err = s.Set(dbCtx, &schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte("key1"), Value: []byte("value1")}}})
+		requireQuotaExceeded(t, err)
+
+		_, err = s.MultiDatabaseSet(ctx, &schema.MultiDatabaseSetRequest{Writes: []*schema.DatabaseKVs{
+			{Database: DefaultDBName, KVs: []*schema.KeyValue{{Key: []byte("key1"), Value: []byte("value1")}}},
+			{Database: "globexdb", KVs: []*schema.KeyValue{{Key: []byte("key1"), Value: []byte("value1")}}},
+		}})
+		requireQuotaExceeded(t, err)
+
+		// data can still be read
+		_, err = s.CurrentState(dbCtx, nil)
+		require.NoError(t, err)
+	})
+
+	t.Run("the usage of every tenant should be reported", func(t *testing.T) {
+		usage := s.tenantUsage()
+		require.Len(t, usage, 3)
+		require.Equal(t, 2, usage["acme"].Databases)
+		require.Equal(t, 1, usage["acme"].Sessions)
+		require.Positive(t, usage["acme"].DiskUsage)
+		require.Equal(t, 1, usage["globex"].Databases)
+		require.Equal(t, TenantUsage{}, usage["initech"])
+	})
+
+	err = s.CloseDatabases()
+	require.NoError(t, err)
+
+	t.Run("the owners of the databases should be loaded on restart", func(t *testing.T) {
+		s := DefaultServer().WithOptions(serverOptions).(*ImmuServer)
+
+		err := s.Initialize()
+		require.NoError(t, err)
+		defer s.CloseDatabases()
+
+		require.Equal(t, "acme", s.tenantOf("acmedb1"))
+		require.Equal(t, "acme", s.tenantOf("acmedb2"))
+		require.Equal(t, "globex", s.tenantOf("globexdb"))
+		require.Equal(t, "", s.tenantOf(DefaultDBName))
+
+		lr, err := s.Login(context.Background(), &schema.LoginRequest{
+			User:     []byte(auth.SysAdminUsername),
+			Password: []byte(auth.SysAdminPassword),
+		})
+		require.NoError(t, err)
+
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", lr.Token))
+
+		_, err = s.CreateDatabaseWithV2(ctx, &schema.DatabaseSettingsV2{DatabaseName: "acmedb3", Tenant: "acme"})
+		requireQuotaExceeded(t, err)
+	})
+}
@@ -0,0 +1,200 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"crypto/tls"
+	"errors"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/logger"
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// DefaultCertCheckInterval is how often certificate files are checked for changes
+const DefaultCertCheckInterval = 5 * time.Second
+
+// CertReloader serves the certificate read from the configured files and reloads it when the files change,
+// thus renewed certificates are served without restarting the server
+type CertReloader struct {
+	certFile string
+	keyFile  string
+	log      logger.Logger
+
+	checkInterval time.Duration
+
+	mutex       sync.Mutex
+	cert        *tls.Certificate
+	certModTime time.Time
+	keyModTime  time.Time
+	lastCheck   time.Time
+}
+
+// NewCertReloader reads the certificate and the private key, it fails when they can not be loaded
+func NewCertReloader(certFile, keyFile string, log logger.Logger) (*CertReloader, error) {
+	r := &CertReloader{
+		certFile:      certFile,
+		keyFile:       keyFile,
+		log:           log,
+		checkInterval: DefaultCertCheckInterval,
+	}
+
+	certModTime, keyModTime, err := r.modTimes()
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.load(certModTime, keyModTime)
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// WithCheckInterval sets how often certificate files are checked for changes
+func (r *CertReloader) WithCheckInterval(checkInterval time.Duration) *CertReloader {
+	r.checkInterval = checkInterval
+	return r
+}
+
+// GetCertificate implements tls.Config.GetCertificate, files are checked while handshaking at most once per check interval.
+// The certificate being served is kept when the changed files can not be loaded e.g. while they are being replaced
+func (r *CertReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	now := time.Now()
+	if now.Sub(r.lastCheck) < r.checkInterval {
+		return r.cert, nil
+	}
+	r.lastCheck = now
+
+	certModTime, keyModTime, err := r.modTimes()
+	if err != nil {
+		r.log.Warningf("Unable to check certificate files for changes: %v", err)
+		return r.cert, nil
+	}
+
+	if certModTime.Equal(r.certModTime) && keyModTime.Equal(r.keyModTime) {
+		return r.cert, nil
+	}
+
+	err = r.load(certModTime, keyModTime)
+	if err != nil {
+		r.log.Warningf("Unable to reload certificate '%s', the previous one is still served: %v", r.certFile, err)
+		return r.cert, nil
+	}
+
+	r.log.Infof("Certificate '%s' reloaded", r.certFile)
+
+	return r.cert, nil
+}
+
+func (r *CertReloader) modTimes() (certModTime time.Time, keyModTime time.Time, err error) {
+	certInfo, err := os.Stat(r.certFile)
+	if err != nil {
+		return
+	}
+
+	keyInfo, err := os.Stat(r.keyFile)
+	if err != nil {
+		return
+	}
+
+	return certInfo.ModTime(), keyInfo.ModTime(), nil
+}
+
+func (r *CertReloader) load(certModTime, keyModTime time.Time) error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return err
+	}
+
+	r.cert = &cert
+	r.certModTime = certModTime
+	r.keyModTime = keyModTime
+
+	return nil
+}
+
+// hasCertificate returns true when connections are served over TLS with the given configuration
+func hasCertificate(tlsConfig *tls.Config) bool {
+	return tlsConfig != nil && (len(tlsConfig.Certificates) > 0 || tlsConfig.GetCertificate != nil)
+}
+
+var ErrACMEWithCertificate = errors.New("certificates are either obtained through ACME or read from files")
+
+// setUpACME makes certificates be obtained and renewed through ACME,
+// client authentication settings of the configured TLS settings are kept
+func (s *ImmuServer) setUpACME() error {
+	acmeOpts := s.Options.ACMEOptions
+
+	tlsConfig := &tls.Config{}
+	if s.Options.TLSConfig != nil {
+		if hasCertificate(s.Options.TLSConfig) {
+			return ErrACMEWithCertificate
+		}
+		tlsConfig = s.Options.TLSConfig.Clone()
+	}
+
+	s.acmeManager = &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		Cache:      autocert.DirCache(acmeOpts.CacheDir),
+		HostPolicy: autocert.HostWhitelist(acmeOpts.Domains...),
+		Email:      acmeOpts.Email,
+	}
+
+	if acmeOpts.DirectoryURL != "" {
+		s.acmeManager.Client = &acme.Client{DirectoryURL: acmeOpts.DirectoryURL}
+	}
+
+	tlsConfig.GetCertificate = func(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+		// clients connecting by IP address don't tell the server name
+		if hello.ServerName == "" {
+			h := *hello
+			h.ServerName = acmeOpts.Domains[0]
+			hello = &h
+		}
+		return s.acmeManager.GetCertificate(hello)
+	}
+	tlsConfig.NextProtos = append(tlsConfig.NextProtos, acme.ALPNProto)
+
+	s.Options.TLSConfig = tlsConfig
+
+	return nil
+}
+
+func (s *ImmuServer) setUpACMEChallengeServer() {
+	s.acmeChallengeServer = &http.Server{
+		Addr:    s.Options.ACMEOptions.HTTPChallengeAddress,
+		Handler: s.acmeManager.HTTPHandler(nil),
+	}
+
+	go func() {
+		s.Logger.Infof("ACME http-01 challenges are answered on %s", s.acmeChallengeServer.Addr)
+
+		err := s.acmeChallengeServer.ListenAndServe()
+		if err != http.ErrServerClosed {
+			s.Logger.Errorf("ACME challenge server error: %v", err)
+		}
+	}()
+}
@@ -0,0 +1,221 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/logger"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/acme"
+)
+
+// selfSignedCert returns the PEM encoded certificate and private key for the given domain
+func selfSignedCert(t *testing.T, domain string) (certPEM, keyPEM []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: domain},
+		DNSNames:     []string{domain},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(90 * 24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM = pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+
+	return certPEM, keyPEM
+}
+
+func writeCert(t *testing.T, dir, domain string, modTime time.Time) (certFile, keyFile string) {
+	certPEM, keyPEM := selfSignedCert(t, domain)
+
+	certFile = filepath.Join(dir, "cert.pem")
+	keyFile = filepath.Join(dir, "key.pem")
+
+	require.NoError(t, ioutil.WriteFile(certFile, certPEM, 0600))
+	require.NoError(t, ioutil.WriteFile(keyFile, keyPEM, 0600))
+
+	require.NoError(t, os.Chtimes(certFile, modTime, modTime))
+	require.NoError(t, os.Chtimes(keyFile, modTime, modTime))
+
+	return certFile, keyFile
+}
+
+func servedDomain(t *testing.T, r *CertReloader) string {
+	cert, err := r.GetCertificate(&tls.ClientHelloInfo{})
+	require.NoError(t, err)
+
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	require.NoError(t, err)
+
+	return leaf.Subject.CommonName
+}
+
+func TestCertReloader(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cert_reloader")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	log := logger.NewSimpleLogger("immudb ", os.Stderr)
+
+	_, err = NewCertReloader(filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem"), log)
+	require.Error(t, err)
+
+	now := time.Now()
+
+	certFile, keyFile := writeCert(t, dir, "first.immudb.io", now.Add(-time.Minute))
+
+	r, err := NewCertReloader(certFile, keyFile, log)
+	require.NoError(t, err)
+	r.WithCheckInterval(0)
+
+	require.Equal(t, "first.immudb.io", servedDomain(t, r))
+
+	writeCert(t, dir, "second.immudb.io", now)
+	require.Equal(t, "second.immudb.io", servedDomain(t, r))
+
+	t.Run("the previous certificate is served while the new one can not be loaded", func(t *testing.T) {
+		require.NoError(t, ioutil.WriteFile(certFile, []byte("partially written"), 0600))
+		require.NoError(t, os.Chtimes(certFile, now.Add(time.Minute), now.Add(time.Minute)))
+		require.Equal(t, "second.immudb.io", servedDomain(t, r))
+
+		require.NoError(t, os.Remove(keyFile))
+		require.Equal(t, "second.immudb.io", servedDomain(t, r))
+
+		writeCert(t, dir, "third.immudb.io", now.Add(2*time.Minute))
+		require.Equal(t, "third.immudb.io", servedDomain(t, r))
+	})
+
+	t.Run("files are not checked before the check interval elapses", func(t *testing.T) {
+		r.WithCheckInterval(time.Hour)
+		r.GetCertificate(&tls.ClientHelloInfo{})
+
+		writeCert(t, dir, "fourth.immudb.io", now.Add(3*time.Minute))
+		require.Equal(t, "third.immudb.io", servedDomain(t, r))
+	})
+}
+
+func TestACME(t *testing.T) {
+	dir, err := ioutil.TempDir("", "acme")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// certificates are cached along with their private key, the cached one is served without reaching the CA
+	certPEM, keyPEM := selfSignedCert(t, "immudb.io")
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "immudb.io"), append(keyPEM, certPEM...), 0600))
+
+	options := DefaultOptions().
+		WithACMEOptions(DefaultACMEOptions().
+			WithDomains([]string{"immudb.io"}).
+			WithCacheDir(dir).
+			WithHTTPChallengeAddress("127.0.0.1:18080").
+			WithDirectoryURL("http://127.0.0.1:1/directory"))
+
+	s := DefaultServer().WithOptions(options).(*ImmuServer)
+
+	err = s.setUpACME()
+	require.NoError(t, err)
+	require.True(t, hasCertificate(s.Options.TLSConfig))
+	require.Contains(t, s.Options.TLSConfig.NextProtos, acme.ALPNProto)
+
+	hello := &tls.ClientHelloInfo{
+		CipherSuites:    []uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256},
+		SupportedCurves: []tls.CurveID{tls.CurveP256},
+	}
+
+	cert, err := s.Options.TLSConfig.GetCertificate(hello)
+	require.NoError(t, err)
+	require.Equal(t, "immudb.io", cert.Leaf.Subject.CommonName)
+
+	hello.ServerName = "other.immudb.io"
+	_, err = s.Options.TLSConfig.GetCertificate(hello)
+	require.Error(t, err)
+
+	t.Run("http-01 challenges are answered while the server runs", func(t *testing.T) {
+		s.setUpACMEChallengeServer()
+		defer s.acmeChallengeServer.Close()
+
+		get := func(host string) int {
+			req, err := http.NewRequest(http.MethodGet, "http://127.0.0.1:18080/.well-known/acme-challenge/token", nil)
+			require.NoError(t, err)
+			req.Host = host
+
+			var resp *http.Response
+			require.Eventually(t, func() bool {
+				resp, err = http.DefaultClient.Do(req)
+				return err == nil
+			}, 5*time.Second, 10*time.Millisecond)
+			resp.Body.Close()
+
+			return resp.StatusCode
+		}
+
+		// unknown tokens are not answered
+		require.Equal(t, http.StatusNotFound, get("immudb.io"))
+
+		// only challenges of the configured domains are answered
+		require.Equal(t, http.StatusForbidden, get("other.immudb.io"))
+	})
+}
+
+func TestACMEWithCertificate(t *testing.T) {
+	options := DefaultOptions().
+		WithTLS(&tls.Config{Certificates: []tls.Certificate{{}}}).
+		WithACMEOptions(DefaultACMEOptions().WithDomains([]string{"immudb.io"}))
+
+	s := DefaultServer().WithOptions(options).(*ImmuServer)
+
+	err := s.setUpACME()
+	require.ErrorIs(t, err, ErrACMEWithCertificate)
+}
+
+func TestACMEKeepsClientAuth(t *testing.T) {
+	options := DefaultOptions().
+		WithTLS(&tls.Config{ClientAuth: tls.RequireAndVerifyClientCert}).
+		WithACMEOptions(DefaultACMEOptions().WithDomains([]string{"immudb.io"}))
+
+	s := DefaultServer().WithOptions(options).(*ImmuServer)
+
+	err := s.setUpACME()
+	require.NoError(t, err)
+	require.Equal(t, tls.RequireAndVerifyClientCert, s.Options.TLSConfig.ClientAuth)
+	require.NotNil(t, s.Options.TLSConfig.GetCertificate)
+}
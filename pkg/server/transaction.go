@@ -19,6 +19,7 @@ package server
 import (
 	"context"
 	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/auth"
 	"github.com/golang/protobuf/ptypes/empty"
 )
 
@@ -36,6 +37,18 @@ func (s *ImmuServer) NewTx(ctx context.Context, request *schema.NewTxRequest) (*
 		return nil, err
 	}
 
+	// values read within transactions are not redacted, so restricted readers are not allowed to open them
+	if sess.GetUser().WhichPermission(sess.GetDatabase().GetName()) == auth.PermissionRestrictedR {
+		return nil, ErrPermissionDenied
+	}
+
+	if request.Mode == schema.TxMode_ReadWrite {
+		err = s.checkTenantDiskQuota(sess.GetDatabase().GetName())
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	tx, err := sess.NewTransaction(request.Mode)
 	if err != nil {
 		return nil, err
@@ -119,5 +132,37 @@ func (s *ImmuServer) TxSQLQuery(ctx context.Context, request *schema.SQLQueryReq
 		return nil, err
 	}
 
-	return tx.SQLQuery(request)
+	return tx.SQLQuery(ctx, request)
+}
+
+// TxSet writes key-value entries within the ongoing transaction, they are committed along with its SQL changes
+func (s *ImmuServer) TxSet(ctx context.Context, request *schema.SetRequest) (*empty.Empty, error) {
+	if request == nil {
+		return nil, ErrIllegalArguments
+	}
+
+	if s.Options.GetMaintenance() {
+		return new(empty.Empty), ErrNotAllowedInMaintenanceMode
+	}
+
+	sess, err := s.SessManager.GetSessionFromContext(ctx)
+	if err != nil {
+		return new(empty.Empty), err
+	}
+
+	user := sess.GetUser()
+	if !user.IsSysAdmin && !auth.HasPermissionForMethod(user.WhichPermission(sess.GetDatabase().GetName()), "Set") {
+		return new(empty.Empty), ErrPermissionDenied
+	}
+
+	tx, err := s.SessManager.GetTransactionFromContext(ctx)
+	if err != nil {
+		return new(empty.Empty), err
+	}
+
+	if tx.GetMode() != schema.TxMode_ReadWrite {
+		return new(empty.Empty), ErrReadWriteTxNotOngoing
+	}
+
+	return new(empty.Empty), tx.Set(request)
 }
@@ -17,6 +17,7 @@ limitations under the License.
 package server
 
 import (
+	"context"
 	"github.com/codenotary/immudb/pkg/server/sessions"
 	"math"
 	"net"
@@ -25,12 +26,15 @@ import (
 	"sync"
 
 	"github.com/codenotary/immudb/embedded/remotestorage"
+	"github.com/codenotary/immudb/pkg/api/schema"
 	pgsqlsrv "github.com/codenotary/immudb/pkg/pgsql/server"
 	"github.com/codenotary/immudb/pkg/replication"
 	"github.com/codenotary/immudb/pkg/stream"
+	"github.com/codenotary/immudb/pkg/witness"
 
 	"github.com/codenotary/immudb/pkg/database"
 	"github.com/rs/xid"
+	"golang.org/x/crypto/acme/autocert"
 
 	"google.golang.org/grpc"
 
@@ -58,6 +62,22 @@ type ImmuServer struct {
 	replicators      map[string]*replication.TxReplicator
 	replicationMutex sync.Mutex
 
+	multiDBTxMutex sync.Mutex
+
+	dbSettings *DatabaseSettingsConfig
+
+	jobs *jobManager
+
+	autoCompactionCancel context.CancelFunc
+	autoCompactionDone   chan struct{}
+
+	multiDBTxRetryCancel context.CancelFunc
+	multiDBTxRetryDone   chan struct{}
+
+	memoryBudget *memoryBudget
+
+	witnesses []*witness.Witness
+
 	Logger      logger.Logger
 	Options     *Options
 	Listener    net.Listener
@@ -71,15 +91,25 @@ type ImmuServer struct {
 	sysDB                database.DB
 	metricsServer        *http.Server
 	webServer            *http.Server
+	acmeManager          *autocert.Manager
+	acmeChallengeServer  *http.Server
+	networkRules         *networkRules
+	tenants              *tenancy
 	mux                  sync.Mutex
 	pgsqlMux             sync.Mutex
 	StateSigner          StateSigner
+	signedStates         map[string]*schema.ImmutableState
+	signedStatesMutex    sync.RWMutex
+	stateSigningCancel   context.CancelFunc
+	stateSigningDone     chan struct{}
 	StreamServiceFactory stream.ServiceFactory
 	PgsqlSrv             pgsqlsrv.Server
 
 	remoteStorage remotestorage.Storage
 
 	SessManager sessions.Manager
+
+	deprecationsLogged sync.Map
 }
 
 // DefaultServer ...
@@ -88,6 +118,7 @@ func DefaultServer() *ImmuServer {
 		OS:                   immuos.NewStandardOS(),
 		dbList:               database.NewDatabaseList(),
 		replicators:          make(map[string]*replication.TxReplicator),
+		jobs:                 newJobManager(defaultMaxFinishedJobs),
 		Logger:               logger.NewSimpleLogger("immudb ", os.Stderr),
 		Options:              DefaultOptions(),
 		quit:                 make(chan struct{}),
@@ -22,7 +22,7 @@ func (s *ImmuServer) Login(ctx context.Context, r *schema.LoginRequest) (*schema
 		return nil, errors.New(ErrAuthDisabled).WithCode(errors.CodProtocolViolation)
 	}
 
-	u, err := s.getValidatedUser(r.User, r.Password)
+	u, err := s.authenticateUser(ctx, r.User, r.Password)
 	if err != nil {
 		return nil, errors.Wrap(err, ErrInvalidUsernameOrPassword)
 	}
@@ -117,7 +117,7 @@ func (s *ImmuServer) CreateUser(ctx context.Context, r *schema.CreateUserRequest
 
 	//check permission is a known value
 	if (r.Permission == auth.PermissionNone) ||
-		(r.Permission > auth.PermissionRW && r.Permission < auth.PermissionAdmin) {
+		(r.Permission > auth.PermissionRestrictedR && r.Permission < auth.PermissionAdmin) {
 		return nil, fmt.Errorf("unrecognized permission")
 	}
 
@@ -165,7 +165,7 @@ func (s *ImmuServer) ListUsers(ctx context.Context, req *empty.Empty) (*schema.U
 		}
 	}
 
-	itemList, err := s.sysDB.Scan(&schema.ScanRequest{
+	itemList, err := s.sysDB.Scan(ctx, &schema.ScanRequest{
 		Prefix: []byte{KeyPrefixUser},
 		NoWait: true,
 	})
@@ -365,7 +365,7 @@ func (s *ImmuServer) ChangePermission(ctx context.Context, r *schema.ChangePermi
 			return nil, status.Errorf(codes.InvalidArgument, "action not recognized")
 		}
 		if (r.Permission == auth.PermissionNone) ||
-			((r.Permission > auth.PermissionRW) &&
+			((r.Permission > auth.PermissionRestrictedR) &&
 				(r.Permission < auth.PermissionAdmin)) {
 			return nil, status.Errorf(codes.InvalidArgument, "unrecognized permission")
 		}
@@ -407,6 +407,11 @@ func (s *ImmuServer) ChangePermission(ctx context.Context, r *schema.ChangePermi
 		}
 	}
 
+	//users of a tenant can only be granted permissions on the databases of the same tenant
+	if r.Action == schema.PermissionAction_GRANT && targetUser.Tenant != s.tenantOf(r.Database) {
+		return nil, status.Errorf(codes.PermissionDenied, "user %s and database %s are not owned by the same tenant", r.Username, r.Database)
+	}
+
 	if r.Action == schema.PermissionAction_REVOKE {
 		targetUser.RevokePermission(r.Database)
 	} else {
@@ -513,12 +518,14 @@ func (s *ImmuServer) insertNewUser(username []byte, plainPassword []byte, permis
 	userdata.Permissions = append(userdata.Permissions, auth.Permission{Permission: permission, Database: database})
 	userdata.CreatedBy = createdBy
 	userdata.CreatedAt = time.Now()
+	// users are owned by the tenant owning the database they are created on
+	userdata.Tenant = s.tenantOf(database)
 
 	if permission == auth.PermissionSysAdmin {
 		userdata.IsSysAdmin = true
 	}
 
-	if (permission > auth.PermissionRW) && (permission < auth.PermissionAdmin) {
+	if (permission > auth.PermissionRestrictedR) && (permission < auth.PermissionAdmin) {
 		return nil, nil, fmt.Errorf("unknown permission")
 	}
 
@@ -547,7 +554,7 @@ func (s *ImmuServer) getUser(username []byte, includeDeactivated bool) (*auth.Us
 	key[0] = KeyPrefixUser
 	copy(key[1:], username)
 
-	item, err := s.sysDB.Get(&schema.KeyRequest{Key: key})
+	item, err := s.sysDB.Get(context.Background(), &schema.KeyRequest{Key: key})
 	if err != nil {
 		return nil, err
 	}
@@ -609,6 +616,16 @@ func (s *ImmuServer) getLoggedInUserdataFromCtx(ctx context.Context) (int64, *au
 		return s.dbList.GetId(sess.GetDatabase().GetName()), sess.GetUser(), nil
 	}
 	jsUser, err := auth.GetLoggedInUser(ctx)
+	if err == auth.ErrNotLoggedIn {
+		// clients authenticated by their certificate don't need to login,
+		// the database is selected as for a user which just logged in
+		if u, ok := s.clientCertUser(ctx); ok {
+			if s.multidbmode {
+				return -1, u, nil
+			}
+			return defaultDbIndex, u, nil
+		}
+	}
 	if err != nil {
 		return -1, nil, err
 	}
@@ -0,0 +1,162 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package server
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/embedded/tbtree"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/database"
+)
+
+// Phases of a vacuum, run in this order: values are truncated first, as the SQL catalog may be committed once again
+// and the index compaction then includes it, the space of discarded values is reclaimed last
+const (
+	vacuumPhaseTruncation = iota
+	vacuumPhaseIndexCompaction
+	vacuumPhaseValueLogCompaction
+	vacuumPhases
+)
+
+var vacuumPhaseNames = [vacuumPhases]string{"truncation", "index compaction", "value log compaction"}
+
+// vacuum runs the maintenance operations of a database as a single job, reporting the progress of all of them
+type vacuum struct {
+	db        database.DB
+	retention time.Duration
+
+	phase int
+	mutex sync.Mutex
+}
+
+// progress weights every phase the same, the truncation phase is complete when no retention was given
+func (v *vacuum) progress() float32 {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+
+	var p float32
+
+	switch v.phase {
+	case vacuumPhaseTruncation:
+		p = v.db.TruncationProgress()
+	case vacuumPhaseIndexCompaction:
+		p = v.db.IndexCompactionProgress()
+	}
+
+	return (float32(v.phase)*100 + p) / vacuumPhases
+}
+
+func (v *vacuum) run(ctx context.Context, s *ImmuServer) error {
+	for phase := 0; phase < vacuumPhases; phase++ {
+		// phases are not interrupted, cancelation takes effect in between
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		v.mutex.Lock()
+		v.phase = phase
+		v.mutex.Unlock()
+
+		s.Logger.Infof("Vacuum of database '%s': %s...", v.db.GetName(), vacuumPhaseNames[phase])
+
+		var err error
+
+		switch phase {
+		case vacuumPhaseTruncation:
+			if v.retention == 0 {
+				continue
+			}
+			_, err = v.db.Truncate(ctx, v.retention)
+		case vacuumPhaseIndexCompaction:
+			err = v.db.CompactIndex()
+		case vacuumPhaseValueLogCompaction:
+			err = v.db.CompactValueLogs()
+		}
+		// there may be nothing to compact, and compactions are not supported along with remote storage
+		if err == tbtree.ErrCompactionThresholdNotReached || err == store.ErrCompactionUnsupported {
+			s.Logger.Infof("Vacuum of database '%s': %s skipped, %v", v.db.GetName(), vacuumPhaseNames[phase], err)
+			continue
+		}
+		if err != nil {
+			s.Logger.Warningf("Vacuum of database '%s' failed during %s: %v", v.db.GetName(), vacuumPhaseNames[phase], err)
+			return err
+		}
+	}
+
+	s.Logger.Infof("Vacuum of database '%s' completed", v.db.GetName())
+
+	return nil
+}
+
+// vacuumDatabase starts a background job discarding the values committed more than retention ago, compacting the
+// index and reclaiming the space of discarded values. No value is discarded when retention is 0.
+// Only the administrators of the database can vacuum it, the job can be canceled in between phases
+func (s *ImmuServer) vacuumDatabase(ctx context.Context, dbName string, retention time.Duration) (*schema.Job, error) {
+	if dbName == "" || retention < 0 {
+		return nil, ErrIllegalArguments
+	}
+
+	if dbName == SystemDBName {
+		return nil, ErrReservedDatabase
+	}
+
+	err := s.checkDatabaseAdmin(ctx, dbName)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := s.dbList.GetByName(dbName)
+	if err != nil {
+		return nil, err
+	}
+
+	if db.IsReplica() {
+		return nil, database.ErrIsReplica
+	}
+
+	v := &vacuum{db: db, retention: retention}
+
+	return s.jobs.start(schema.JobKindVacuum, dbName, true, v.progress, func(ctx context.Context) error {
+		return v.run(ctx, s)
+	})
+}
+
+// vacuumJob returns the state of a vacuum job, only the administrators of the database can read it
+func (s *ImmuServer) vacuumJob(ctx context.Context, dbName, id string) (*schema.Job, error) {
+	if dbName == "" || id == "" {
+		return nil, ErrIllegalArguments
+	}
+
+	err := s.checkDatabaseAdmin(ctx, dbName)
+	if err != nil {
+		return nil, err
+	}
+
+	job, err := s.jobs.get(id, dbName)
+	if err != nil {
+		return nil, err
+	}
+
+	if job.Kind != schema.JobKindVacuum {
+		return nil, ErrJobNotFound
+	}
+
+	return job, nil
+}
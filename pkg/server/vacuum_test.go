@@ -0,0 +1,139 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/auth"
+	"github.com/grpc-ecosystem/grpc-gateway/runtime"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+func TestVacuumDatabase(t *testing.T) {
+	serverOptions := DefaultOptions().
+		WithMetricsServer(false).
+		WithAdminPassword(auth.SysAdminPassword)
+
+	s := DefaultServer().WithOptions(serverOptions).(*ImmuServer)
+	defer os.RemoveAll(s.Options.Dir)
+
+	s.Initialize()
+
+	lr, err := s.Login(context.Background(), &schema.LoginRequest{
+		User:     []byte(auth.SysAdminUsername),
+		Password: []byte(auth.SysAdminPassword),
+	})
+	require.NoError(t, err)
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", lr.Token))
+
+	_, err = s.SQLExec(ctx, &schema.SQLExecRequest{Sql: `
+		CREATE TABLE table1(id INTEGER, PRIMARY KEY id);
+		INSERT INTO table1(id) VALUES (1), (2);
+	`})
+	require.NoError(t, err)
+
+	_, err = s.vacuumDatabase(ctx, "", 0)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = s.vacuumDatabase(ctx, DefaultDBName, -time.Hour)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = s.vacuumDatabase(ctx, SystemDBName, time.Hour)
+	require.ErrorIs(t, err, ErrReservedDatabase)
+
+	job, err := s.vacuumDatabase(ctx, DefaultDBName, time.Hour)
+	require.NoError(t, err)
+	require.Equal(t, schema.JobKindVacuum, job.Kind)
+	require.True(t, job.Cancelable)
+
+	job = waitForJobManager(t, s.jobs, job.Id, DefaultDBName)
+	require.Equal(t, schema.JobStatusCompleted, job.Status, job.Error)
+	require.Equal(t, float32(100), job.Progress)
+
+	// all the txs were committed within the retention period
+	res, err := s.SQLQuery(ctx, &schema.SQLQueryRequest{Sql: "SELECT id FROM table1"})
+	require.NoError(t, err)
+	require.Len(t, res.Rows, 2)
+
+	_, err = s.vacuumJob(ctx, DefaultDBName, "missing")
+	require.ErrorIs(t, err, ErrJobNotFound)
+
+	webMux := http.NewServeMux()
+	setupConsoleSQL(webMux, runtime.NewServeMux(), s)
+
+	call := func(method, path, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", token)
+
+		rec := httptest.NewRecorder()
+		webMux.ServeHTTP(rec, req)
+
+		return rec
+	}
+
+	t.Run("console", func(t *testing.T) {
+		rec := call(http.MethodPost, "/api/console/vacuum?database="+DefaultDBName+"&retention=x", lr.Token)
+		require.Equal(t, http.StatusBadRequest, rec.Code)
+
+		rec = call(http.MethodPost, "/api/console/vacuum?database="+DefaultDBName, lr.Token)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		var job schema.Job
+		require.NoError(t, protojson.Unmarshal(rec.Body.Bytes(), &job))
+		require.Equal(t, schema.JobKindVacuum, job.Kind)
+
+		require.Eventually(t, func() bool {
+			rec := call(http.MethodGet, "/api/console/vacuum?database="+DefaultDBName+"&id="+job.Id, lr.Token)
+			require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+			require.NoError(t, protojson.Unmarshal(rec.Body.Bytes(), &job))
+			return job.Finished()
+		}, 10*time.Second, 10*time.Millisecond)
+
+		require.Equal(t, schema.JobStatusCompleted, job.Status, job.Error)
+	})
+
+	t.Run("non admin users can not vacuum the database", func(t *testing.T) {
+		_, err = s.CreateUser(ctx, &schema.CreateUserRequest{
+			User:       []byte("user1"),
+			Password:   []byte("user1Password!"),
+			Permission: auth.PermissionRW,
+			Database:   DefaultDBName,
+		})
+		require.NoError(t, err)
+
+		lr, err := s.Login(context.Background(), &schema.LoginRequest{User: []byte("user1"), Password: []byte("user1Password!")})
+		require.NoError(t, err)
+
+		userCtx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", lr.Token))
+
+		_, err = s.vacuumDatabase(userCtx, DefaultDBName, 0)
+		require.ErrorIs(t, err, ErrPermissionDenied)
+
+		rec := call(http.MethodPost, "/api/console/vacuum?database="+DefaultDBName, lr.Token)
+		require.NotEqual(t, http.StatusOK, rec.Code)
+	})
+}
@@ -0,0 +1,487 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/codenotary/immudb/embedded/sql"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/database"
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/grpc-ecosystem/grpc-gateway/runtime"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	rowProved       = "proved"
+	rowUnverifiable = "unverifiable"
+	rowFailed       = "failed"
+)
+
+// maxConsoleProvedRows is the max number of rows of a query result proofs are returned for
+const maxConsoleProvedRows = 100
+
+// consoleSQLHandler serves the endpoints used by the SQL editor of the web console: query results come with the
+// proof of each row and the signed state of the database the query was run on. Proofs are checked by the console
+// against a state it already trusts, as a server can't vouch for itself
+type consoleSQLHandler struct {
+	s   schema.ImmuServiceServer
+	mux *runtime.ServeMux
+}
+
+// consoleRowVerification holds the proof of a row, proofs are built from the tx of the state trusted by the console
+// so that the row is proven to be included in a tx consistent with it
+type consoleRowVerification struct {
+	Status string          `json:"status"`
+	TxID   uint64          `json:"txId,omitempty"`
+	Proof  json.RawMessage `json:"proof,omitempty"`
+	Error  string          `json:"error,omitempty"`
+}
+
+type consoleSQLQueryResponse struct {
+	Result       json.RawMessage           `json:"result"`
+	Verification []*consoleRowVerification `json:"verification"`
+	State        json.RawMessage           `json:"state"`
+}
+
+// slowQueriesSource is implemented by servers recording slow queries
+type slowQueriesSource interface {
+	slowQueries(ctx context.Context, offset, limit uint64) ([]*slowQueryRecord, error)
+}
+
+// keyspaceStatsSource is implemented by servers summarizing the keys of their databases
+type keyspaceStatsSource interface {
+	keyspaceStats(ctx context.Context, dbName string, req *database.KeyspaceStatsRequest) (*database.KeyspaceStats, error)
+}
+
+// vacuumSource is implemented by servers running vacuum jobs on their databases
+type vacuumSource interface {
+	vacuumDatabase(ctx context.Context, dbName string, retention time.Duration) (*schema.Job, error)
+	vacuumJob(ctx context.Context, dbName, id string) (*schema.Job, error)
+}
+
+// inclusionProofSource is implemented by servers providing the audit paths of entries
+type inclusionProofSource interface {
+	getInclusionProof(ctx context.Context, key []byte, atTx uint64) (*database.EntryInclusionProof, error)
+}
+
+type consoleError struct {
+	Error string `json:"error"`
+}
+
+// setupConsoleSQL registers the SQL editor endpoints under /api/console. Authentication metadata is read from the
+// request the same way the REST API does through mux
+func setupConsoleSQL(webMux *http.ServeMux, mux *runtime.ServeMux, s schema.ImmuServiceServer) {
+	h := &consoleSQLHandler{s: s, mux: mux}
+
+	webMux.HandleFunc("/api/console/state", h.serveState)
+	webMux.HandleFunc("/api/console/sqlquery", h.serveSQLQuery)
+
+	if _, ok := s.(slowQueriesSource); ok {
+		webMux.HandleFunc("/api/console/slowqueries", h.serveSlowQueries)
+	}
+
+	if _, ok := s.(keyspaceStatsSource); ok {
+		webMux.HandleFunc("/api/console/keyspace", h.serveKeyspaceStats)
+	}
+
+	if _, ok := s.(vacuumSource); ok {
+		webMux.HandleFunc("/api/console/vacuum", h.serveVacuum)
+	}
+
+	if _, ok := s.(inclusionProofSource); ok {
+		webMux.HandleFunc("/api/console/inclusionproof", h.serveInclusionProof)
+	}
+}
+
+func (h *consoleSQLHandler) serveState(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONResponse(w, r, http.StatusMethodNotAllowed, &consoleError{Error: "method not allowed"})
+		return
+	}
+
+	ctx, err := runtime.AnnotateIncomingContext(r.Context(), h.mux, r)
+	if err != nil {
+		writeConsoleError(w, r, err)
+		return
+	}
+
+	state, err := h.s.CurrentState(ctx, &empty.Empty{})
+	if err != nil {
+		writeConsoleError(w, r, err)
+		return
+	}
+
+	writeConsoleProto(w, r, state)
+}
+
+func (h *consoleSQLHandler) serveSQLQuery(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSONResponse(w, r, http.StatusMethodNotAllowed, &consoleError{Error: "method not allowed"})
+		return
+	}
+
+	ctx, err := runtime.AnnotateIncomingContext(r.Context(), h.mux, r)
+	if err != nil {
+		writeConsoleError(w, r, err)
+		return
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		writeJSONResponse(w, r, http.StatusBadRequest, &consoleError{Error: err.Error()})
+		return
+	}
+
+	req := &schema.SQLQueryRequest{}
+
+	err = protojson.Unmarshal(body, req)
+	if err != nil {
+		writeJSONResponse(w, r, http.StatusBadRequest, &consoleError{Error: err.Error()})
+		return
+	}
+
+	// proofs are built from the tx of the state trusted by the console, if any
+	var proveSinceTx uint64
+
+	if s := r.URL.Query().Get("proveSinceTx"); s != "" {
+		proveSinceTx, err = strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			writeJSONResponse(w, r, http.StatusBadRequest, &consoleError{Error: "invalid proveSinceTx"})
+			return
+		}
+	}
+
+	// the state is taken before running the query so that it's not older than any proven row
+	state, err := h.s.CurrentState(ctx, &empty.Empty{})
+	if err != nil {
+		writeConsoleError(w, r, err)
+		return
+	}
+
+	res, err := h.s.SQLQuery(ctx, req)
+	if err != nil {
+		writeConsoleError(w, r, err)
+		return
+	}
+
+	resp := &consoleSQLQueryResponse{
+		Verification: h.proveRows(ctx, res, proveSinceTx),
+	}
+
+	resp.Result, err = protojson.Marshal(res)
+	if err != nil {
+		writeConsoleError(w, r, err)
+		return
+	}
+
+	resp.State, err = protojson.Marshal(state)
+	if err != nil {
+		writeConsoleError(w, r, err)
+		return
+	}
+
+	writeJSONResponse(w, r, http.StatusOK, resp)
+}
+
+// serveSlowQueries returns the recorded slow queries, newest first, offset and limit parameters are optional
+func (h *consoleSQLHandler) serveSlowQueries(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONResponse(w, r, http.StatusMethodNotAllowed, &consoleError{Error: "method not allowed"})
+		return
+	}
+
+	ctx, err := runtime.AnnotateIncomingContext(r.Context(), h.mux, r)
+	if err != nil {
+		writeConsoleError(w, r, err)
+		return
+	}
+
+	var offset, limit uint64
+
+	for param, v := range map[string]*uint64{"offset": &offset, "limit": &limit} {
+		s := r.URL.Query().Get(param)
+		if s == "" {
+			continue
+		}
+
+		*v, err = strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			writeJSONResponse(w, r, http.StatusBadRequest, &consoleError{Error: "invalid " + param})
+			return
+		}
+	}
+
+	records, err := h.s.(slowQueriesSource).slowQueries(ctx, offset, limit)
+	if err != nil {
+		writeConsoleError(w, r, err)
+		return
+	}
+
+	writeJSONResponse(w, r, http.StatusOK, records)
+}
+
+// serveKeyspaceStats summarizes the keys of the database given by the database query parameter,
+// prefixLength and outliers parameters are optional
+func (h *consoleSQLHandler) serveKeyspaceStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONResponse(w, r, http.StatusMethodNotAllowed, &consoleError{Error: "method not allowed"})
+		return
+	}
+
+	ctx, err := runtime.AnnotateIncomingContext(r.Context(), h.mux, r)
+	if err != nil {
+		writeConsoleError(w, r, err)
+		return
+	}
+
+	req := &database.KeyspaceStatsRequest{}
+
+	for param, v := range map[string]*int{"prefixLength": &req.PrefixLength, "outliers": &req.Outliers} {
+		s := r.URL.Query().Get(param)
+		if s == "" {
+			continue
+		}
+
+		*v, err = strconv.Atoi(s)
+		if err != nil {
+			writeJSONResponse(w, r, http.StatusBadRequest, &consoleError{Error: "invalid " + param})
+			return
+		}
+	}
+
+	stats, err := h.s.(keyspaceStatsSource).keyspaceStats(ctx, r.URL.Query().Get("database"), req)
+	if err != nil {
+		writeConsoleError(w, r, err)
+		return
+	}
+
+	writeJSONResponse(w, r, http.StatusOK, stats)
+}
+
+// serveVacuum starts a vacuum job on the database given by the database query parameter when posted, the optional
+// retention parameter is a duration (e.g. 720h). The state of the job given by the id parameter is returned otherwise
+func (h *consoleSQLHandler) serveVacuum(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		writeJSONResponse(w, r, http.StatusMethodNotAllowed, &consoleError{Error: "method not allowed"})
+		return
+	}
+
+	ctx, err := runtime.AnnotateIncomingContext(r.Context(), h.mux, r)
+	if err != nil {
+		writeConsoleError(w, r, err)
+		return
+	}
+
+	dbName := r.URL.Query().Get("database")
+
+	var job *schema.Job
+
+	if r.Method == http.MethodGet {
+		job, err = h.s.(vacuumSource).vacuumJob(ctx, dbName, r.URL.Query().Get("id"))
+	} else {
+		var retention time.Duration
+
+		if s := r.URL.Query().Get("retention"); s != "" {
+			retention, err = time.ParseDuration(s)
+			if err != nil {
+				writeJSONResponse(w, r, http.StatusBadRequest, &consoleError{Error: "invalid retention"})
+				return
+			}
+		}
+
+		job, err = h.s.(vacuumSource).vacuumDatabase(ctx, dbName, retention)
+	}
+	if err != nil {
+		writeConsoleError(w, r, err)
+		return
+	}
+
+	writeConsoleProto(w, r, job)
+}
+
+// serveInclusionProof returns the audit path of the entry of the key query parameter in the selected database,
+// as committed at the optional atTx parameter or its latest one
+func (h *consoleSQLHandler) serveInclusionProof(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONResponse(w, r, http.StatusMethodNotAllowed, &consoleError{Error: "method not allowed"})
+		return
+	}
+
+	ctx, err := runtime.AnnotateIncomingContext(r.Context(), h.mux, r)
+	if err != nil {
+		writeConsoleError(w, r, err)
+		return
+	}
+
+	var atTx uint64
+
+	if s := r.URL.Query().Get("atTx"); s != "" {
+		atTx, err = strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			writeJSONResponse(w, r, http.StatusBadRequest, &consoleError{Error: "invalid atTx"})
+			return
+		}
+	}
+
+	proof, err := h.s.(inclusionProofSource).getInclusionProof(ctx, []byte(r.URL.Query().Get("key")), atTx)
+	if err != nil {
+		writeConsoleError(w, r, err)
+		return
+	}
+
+	writeJSONResponse(w, r, http.StatusOK, proof)
+}
+
+// proveRows returns the proof of each row in res, up to maxConsoleProvedRows of them. Only rows selected from a single
+// table and including all of its primary key columns can be verified, as the primary key is needed to retrieve the
+// proofs
+func (h *consoleSQLHandler) proveRows(ctx context.Context, res *schema.SQLQueryResult, proveSinceTx uint64) []*consoleRowVerification {
+	verification := make([]*consoleRowVerification, len(res.Rows))
+
+	table, pkPos, reason := h.primaryKeyPositions(ctx, res.Columns)
+
+	for i, row := range res.Rows {
+		if pkPos == nil {
+			verification[i] = &consoleRowVerification{Status: rowUnverifiable, Error: reason}
+			continue
+		}
+
+		if i >= maxConsoleProvedRows {
+			verification[i] = &consoleRowVerification{
+				Status: rowUnverifiable,
+				Error:  "only the first " + strconv.Itoa(maxConsoleProvedRows) + " rows are proven",
+			}
+			continue
+		}
+
+		verification[i] = h.proveRow(ctx, row, table, pkPos, proveSinceTx)
+	}
+
+	return verification
+}
+
+func (h *consoleSQLHandler) proveRow(ctx context.Context, row *schema.Row, table string, pkPos []int, proveSinceTx uint64) *consoleRowVerification {
+	pkVals := make([]*schema.SQLValue, len(pkPos))
+
+	for i, pos := range pkPos {
+		pkVals[i] = row.Values[pos]
+	}
+
+	vEntry, err := h.s.VerifiableSQLGet(ctx, &schema.VerifiableSQLGetRequest{
+		SqlGetRequest: &schema.SQLGetRequest{Table: table, PkValues: pkVals},
+		ProveSinceTx:  proveSinceTx,
+	})
+	if err != nil {
+		return &consoleRowVerification{Status: rowFailed, Error: err.Error()}
+	}
+
+	// columns not mapped to the table, e.g. aliased ones, can not be compared with the stored row
+	for _, col := range row.Columns {
+		if _, ok := vEntry.ColIdsByName[col]; !ok {
+			return &consoleRowVerification{Status: rowUnverifiable, Error: "column " + col + " does not belong to table " + table}
+		}
+	}
+
+	proof, err := protojson.Marshal(vEntry)
+	if err != nil {
+		return &consoleRowVerification{Status: rowFailed, TxID: vEntry.SqlEntry.Tx, Error: err.Error()}
+	}
+
+	return &consoleRowVerification{Status: rowProved, TxID: vEntry.SqlEntry.Tx, Proof: proof}
+}
+
+// primaryKeyPositions returns the table the columns were selected from and the position of its primary key columns
+// in the query result. When rows can not be verified, a nil slice is returned together with the reason
+func (h *consoleSQLHandler) primaryKeyPositions(ctx context.Context, cols []*schema.Column) (table string, pkPos []int, reason string) {
+	var db string
+
+	colPos := make(map[string]int, len(cols))
+
+	for i, col := range cols {
+		// selectors of plain columns are formatted as (db.table.col)
+		if !strings.HasPrefix(col.Name, "(") || !strings.HasSuffix(col.Name, ")") {
+			return "", nil, "only plain columns can be verified"
+		}
+
+		parts := strings.Split(col.Name[1:len(col.Name)-1], ".")
+		if len(parts) != 3 {
+			return "", nil, "only plain columns can be verified"
+		}
+
+		if i > 0 && (parts[0] != db || parts[1] != table) {
+			return "", nil, "only rows selected from a single table can be verified"
+		}
+
+		db, table = parts[0], parts[1]
+		colPos[col.Name] = i
+	}
+
+	if table == "" {
+		return "", nil, "no columns selected"
+	}
+
+	desc, err := h.s.DescribeTable(ctx, &schema.Table{TableName: table})
+	if err != nil {
+		return "", nil, err.Error()
+	}
+
+	// primary key columns are described in table order, which is the order of the primary key unless it was declared
+	// with a different column ordering, in which case rows won't be found and are reported as failed
+	for _, row := range desc.Rows {
+		if row.Values[3].GetS() != "PRIMARY KEY" {
+			continue
+		}
+
+		pos, ok := colPos[sql.EncodeSelector("", db, table, row.Values[0].GetS())]
+		if !ok {
+			return "", nil, "primary key column " + row.Values[0].GetS() + " not selected"
+		}
+
+		pkPos = append(pkPos, pos)
+	}
+
+	if len(pkPos) == 0 {
+		return "", nil, "table " + table + " has no primary key"
+	}
+
+	return table, pkPos, ""
+}
+
+func writeConsoleProto(w http.ResponseWriter, r *http.Request, m proto.Message) {
+	data, err := protojson.Marshal(m)
+	if err != nil {
+		writeConsoleError(w, r, err)
+		return
+	}
+
+	writeJSONResponse(w, r, http.StatusOK, json.RawMessage(data))
+}
+
+func writeConsoleError(w http.ResponseWriter, r *http.Request, err error) {
+	st := status.Convert(err)
+	writeJSONResponse(w, r, runtime.HTTPStatusFromCode(st.Code()), &consoleError{Error: st.Message()})
+}
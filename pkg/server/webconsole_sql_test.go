@@ -0,0 +1,201 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/auth"
+	"github.com/codenotary/immudb/pkg/database"
+	"github.com/codenotary/immudb/pkg/signer"
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/grpc-ecosystem/grpc-gateway/runtime"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+func TestConsoleSQL(t *testing.T) {
+	serverOptions := DefaultOptions().
+		WithMetricsServer(false).
+		WithAdminPassword(auth.SysAdminPassword).
+		WithSigningKey("./../../test/signer/ec1.key")
+
+	s := DefaultServer().WithOptions(serverOptions).(*ImmuServer)
+	defer os.RemoveAll(s.Options.Dir)
+
+	s.Initialize()
+
+	lr, err := s.Login(context.Background(), &schema.LoginRequest{
+		User:     []byte(auth.SysAdminUsername),
+		Password: []byte(auth.SysAdminPassword),
+	})
+	require.NoError(t, err)
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", lr.Token))
+
+	_, err = s.SQLExec(ctx, &schema.SQLExecRequest{Sql: "CREATE TABLE table1 (id INTEGER, title VARCHAR, PRIMARY KEY id)"})
+	require.NoError(t, err)
+
+	_, err = s.SQLExec(ctx, &schema.SQLExecRequest{Sql: "INSERT INTO table1 (id, title) VALUES (1, 'title1'), (2, 'title2')"})
+	require.NoError(t, err)
+
+	// state already trusted by the console
+	trustedState, err := s.CurrentState(ctx, &empty.Empty{})
+	require.NoError(t, err)
+
+	// the state moves past the rows, which are then proven through a dual proof
+	_, err = s.SQLExec(ctx, &schema.SQLExecRequest{Sql: "CREATE TABLE table2 (id INTEGER, PRIMARY KEY id)"})
+	require.NoError(t, err)
+
+	webMux := http.NewServeMux()
+	setupConsoleSQL(webMux, runtime.NewServeMux(), s)
+
+	do := func(method, path, body string, authorized bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if authorized {
+			req.Header.Set("Authorization", lr.Token)
+		}
+
+		rec := httptest.NewRecorder()
+		webMux.ServeHTTP(rec, req)
+
+		return rec
+	}
+
+	query := func(sql string) ([]*consoleRowVerification, *schema.SQLQueryResult) {
+		path := "/api/console/sqlquery?proveSinceTx=" + strconv.FormatUint(trustedState.TxId, 10)
+
+		rec := do(http.MethodPost, path, `{"sql": "`+sql+`"}`, true)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		var resp consoleSQLQueryResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+
+		state := &schema.ImmutableState{}
+		require.NoError(t, protojson.Unmarshal(resp.State, state))
+		require.GreaterOrEqual(t, state.TxId, trustedState.TxId)
+
+		res := &schema.SQLQueryResult{}
+		require.NoError(t, protojson.Unmarshal(resp.Result, res))
+		require.Len(t, resp.Verification, len(res.Rows))
+
+		return resp.Verification, res
+	}
+
+	// verify checks the proof of a row as the console does, against the state it trusts
+	verify := func(row *schema.Row, v *consoleRowVerification) error {
+		vEntry := &schema.VerifiableSQLEntry{}
+		require.NoError(t, protojson.Unmarshal(v.Proof, vEntry))
+
+		_, _, err := schema.VerifySQLRow(row, []*schema.SQLValue{row.Values[0]}, vEntry, trustedState, []byte{database.SQLPrefix})
+		return err
+	}
+
+	t.Run("state", func(t *testing.T) {
+		rec := do(http.MethodGet, "/api/console/state", "", true)
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		state := &schema.ImmutableState{}
+		require.NoError(t, protojson.Unmarshal(rec.Body.Bytes(), state))
+		require.NotNil(t, state.Signature)
+
+		key, err := signer.UnmarshalKey(state.Signature.PublicKey)
+		require.NoError(t, err)
+
+		ok, err := state.CheckSignature(key)
+		require.NoError(t, err)
+		require.True(t, ok)
+	})
+
+	t.Run("unauthorized", func(t *testing.T) {
+		rec := do(http.MethodGet, "/api/console/state", "", false)
+		require.NotEqual(t, http.StatusOK, rec.Code)
+	})
+
+	t.Run("method not allowed", func(t *testing.T) {
+		rec := do(http.MethodGet, "/api/console/sqlquery", "", true)
+		require.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	})
+
+	t.Run("invalid query", func(t *testing.T) {
+		rec := do(http.MethodPost, "/api/console/sqlquery", `{"sql": "SELECT * FROM missing"}`, true)
+		require.NotEqual(t, http.StatusOK, rec.Code)
+	})
+
+	t.Run("proved rows", func(t *testing.T) {
+		verification, res := query("SELECT id, title FROM table1")
+		require.Len(t, verification, 2)
+
+		for i, v := range verification {
+			require.Equal(t, rowProved, v.Status, v.Error)
+			require.LessOrEqual(t, v.TxID, trustedState.TxId)
+			require.NoError(t, verify(res.Rows[i], v))
+		}
+
+		// rows altered by the server don't match their proofs
+		res.Rows[0].Values[1] = &schema.SQLValue{Value: &schema.SQLValue_S{S: "tampered"}}
+		require.Error(t, verify(res.Rows[0], verification[0]))
+	})
+
+	t.Run("invalid proveSinceTx", func(t *testing.T) {
+		rec := do(http.MethodPost, "/api/console/sqlquery?proveSinceTx=x", `{"sql": "SELECT id FROM table1"}`, true)
+		require.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("proofs are returned for a limited number of rows", func(t *testing.T) {
+		var values []string
+		for i := 0; i <= maxConsoleProvedRows; i++ {
+			values = append(values, "("+strconv.Itoa(i)+")")
+		}
+
+		_, err := s.SQLExec(ctx, &schema.SQLExecRequest{Sql: "INSERT INTO table2 (id) VALUES " + strings.Join(values, ", ")})
+		require.NoError(t, err)
+
+		verification, _ := query("SELECT id FROM table2")
+		require.Len(t, verification, maxConsoleProvedRows+1)
+
+		for _, v := range verification[:maxConsoleProvedRows] {
+			require.Equal(t, rowProved, v.Status, v.Error)
+		}
+		require.Equal(t, rowUnverifiable, verification[maxConsoleProvedRows].Status)
+		require.Empty(t, verification[maxConsoleProvedRows].Proof)
+	})
+
+	t.Run("primary key not selected", func(t *testing.T) {
+		verification, _ := query("SELECT title FROM table1")
+		require.Len(t, verification, 2)
+
+		for _, v := range verification {
+			require.Equal(t, rowUnverifiable, v.Status)
+		}
+	})
+
+	t.Run("aggregated columns", func(t *testing.T) {
+		verification, _ := query("SELECT COUNT(*) FROM table1")
+		require.Len(t, verification, 1)
+		require.Equal(t, rowUnverifiable, verification[0].Status)
+	})
+}
@@ -4,33 +4,60 @@ import (
 	"context"
 	"crypto/tls"
 	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/api/schemav2"
 	"github.com/codenotary/immudb/pkg/logger"
 	"github.com/codenotary/immudb/webconsole"
 	"github.com/grpc-ecosystem/grpc-gateway/runtime"
+	"google.golang.org/grpc"
 	"net/http"
 )
 
-func StartWebServer(addr string, tlsConfig *tls.Config, s schema.ImmuServiceServer, l logger.Logger) (*http.Server, error) {
+// networkRulesSource is implemented by servers rejecting clients by their address
+type networkRulesSource interface {
+	NetworkRulesHandler(h http.Handler) http.Handler
+}
+
+// StartWebServer serves the REST API and the web console, and the gRPC API to browsers through gRPC-Web if grpcWebSrv
+// is not nil. Browsers may call the gRPC API from other origins only if they are among grpcWebAllowedOrigins
+func StartWebServer(addr string, tlsConfig *tls.Config, s schema.ImmuServiceServer, v2 schemav2.ImmuServiceServer, grpcWebSrv *grpc.Server, grpcWebAllowedOrigins []string, l logger.Logger) (*http.Server, error) {
 	proxyMux := runtime.NewServeMux()
 	err := schema.RegisterImmuServiceHandlerServer(context.Background(), proxyMux, s)
 	if err != nil {
 		return nil, err
 	}
 
+	// paths of the version 2 of the API are all prefixed by /v2
+	err = schemav2.RegisterImmuServiceHandlerServer(context.Background(), proxyMux, v2)
+	if err != nil {
+		return nil, err
+	}
+
 	webMux := http.NewServeMux()
 	webMux.Handle("/api/", http.StripPrefix("/api", proxyMux))
 
+	setupConsoleSQL(webMux, proxyMux, s)
+
 	err = webconsole.SetupWebconsole(webMux, l, addr)
 	if err != nil {
 		return nil, err
 	}
 
-	httpServer := &http.Server{Addr: addr, Handler: webMux}
+	var handler http.Handler = webMux
+	if grpcWebSrv != nil {
+		handler = newGrpcWebHandler(grpcWebSrv, grpcWebAllowedOrigins, webMux)
+	}
+
+	// the REST API and the web console call the server directly, skipping the gRPC interceptors
+	if nr, ok := s.(networkRulesSource); ok {
+		handler = nr.NetworkRulesHandler(handler)
+	}
+
+	httpServer := &http.Server{Addr: addr, Handler: handler}
 	httpServer.TLSConfig = tlsConfig
 
 	go func() {
 		var err error
-		if tlsConfig != nil && len(tlsConfig.Certificates) > 0 {
+		if hasCertificate(tlsConfig) {
 			l.Infof("Web API server enabled on %s/api (https)", addr)
 			err = httpServer.ListenAndServeTLS("", "")
 		} else {
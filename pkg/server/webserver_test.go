@@ -38,6 +38,9 @@ func TestStartWebServerHTTP(t *testing.T) {
 		"0.0.0.0:8080",
 		tlsConfig,
 		server,
+		server.V2(),
+		nil,
+		nil,
 		&mockLogger{})
 	require.NoError(t, err)
 	defer webServer.Close()
@@ -79,6 +82,9 @@ EKTcWGekdmdDPsHloRNtsiCa697B2O9IFA==
 		"0.0.0.0:8080",
 		tlsConfig,
 		server,
+		server.V2(),
+		nil,
+		nil,
 		&mockLogger{})
 	require.NoError(t, err)
 	defer webServer.Close()
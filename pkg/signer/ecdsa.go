@@ -73,10 +73,18 @@ func (sig signer) Sign(payload []byte) ([]byte, []byte, error) {
 	}
 	sigToMarshal := ecdsaSignature{R: r, S: s}
 	m, _ := asn1.Marshal(sigToMarshal)
-	pk := sig.privateKey.PublicKey
-	p := elliptic.Marshal(pk.Curve, pk.X, pk.Y)
 
-	return m, p, nil
+	return m, sig.PublicKey(), nil
+}
+
+// PublicKey returns the marshalled public key of the signer
+func (sig signer) PublicKey() []byte {
+	return MarshalKey(&sig.privateKey.PublicKey)
+}
+
+// MarshalKey converts a public key into the format returned by Sign
+func MarshalKey(publicKey *ecdsa.PublicKey) []byte {
+	return elliptic.Marshal(publicKey.Curve, publicKey.X, publicKey.Y)
 }
 
 func UnmarshalKey(publicKey []byte) (*ecdsa.PublicKey, error) {
@@ -131,3 +131,28 @@ func TestUnmarshalKey_Error(t *testing.T) {
 	require.Nil(t, ecdsaPK)
 	require.ErrorIs(t, err, ErrInvalidPublicKey)
 }
+
+func TestSignature_PublicKey(t *testing.T) {
+	s, err := NewSigner("./../../test/signer/ec3.key")
+	require.NoError(t, err)
+
+	_, publicKey, err := s.Sign([]byte(`myhash`))
+	require.NoError(t, err)
+	require.Equal(t, publicKey, s.PublicKey())
+
+	pk, err := ParsePublicKeyFile("./../../test/signer/ec3.pub")
+	require.NoError(t, err)
+	require.Equal(t, publicKey, MarshalKey(pk))
+}
+
+func TestKeyID(t *testing.T) {
+	s1, err := NewSigner("./../../test/signer/ec1.key")
+	require.NoError(t, err)
+
+	s3, err := NewSigner("./../../test/signer/ec3.key")
+	require.NoError(t, err)
+
+	require.Len(t, KeyID(s1.PublicKey()), 16)
+	require.Equal(t, KeyID(s1.PublicKey()), KeyID(s1.PublicKey()))
+	require.NotEqual(t, KeyID(s1.PublicKey()), KeyID(s3.PublicKey()))
+}
@@ -16,6 +16,19 @@ limitations under the License.
 
 package signer
 
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
 type Signer interface {
 	Sign(payload []byte) (signature []byte, publicKey []byte, err error)
+	PublicKey() []byte
+}
+
+// KeyID returns a short identifier of a marshalled public key, embedded into signatures
+// so verifiers can select the key a payload was signed with once signing keys are rotated
+func KeyID(publicKey []byte) string {
+	h := sha256.Sum256(publicKey)
+	return hex.EncodeToString(h[:8])
 }
@@ -38,11 +38,25 @@ func (c *Conn) GetImmuClient() client.ImmuClient {
 }
 
 func (c *Conn) Prepare(query string) (driver.Stmt, error) {
-	return nil, ErrNotImplemented
+	return c.PrepareContext(context.TODO(), query)
 }
 
+// PrepareContext returns a statement bound to this connection.
+// Statements are not prepared server-side, the query is sent along with its arguments on each execution.
 func (c *Conn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
-	return nil, ErrNotImplemented
+	if !c.immuClient.IsConnected() {
+		return nil, driver.ErrBadConn
+	}
+
+	return &Stmt{conn: c, query: query}, nil
+}
+
+func (c *Conn) Ping(ctx context.Context) error {
+	if !c.immuClient.IsConnected() {
+		return driver.ErrBadConn
+	}
+
+	return c.immuClient.HealthCheck(ctx)
 }
 
 func (c *Conn) Close() error {
@@ -54,6 +68,10 @@ func (c *Conn) ExecContext(ctx context.Context, query string, argsV []driver.Nam
 		return nil, driver.ErrBadConn
 	}
 
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	vals, err := namedValuesToSqlMap(argsV)
 	if err != nil {
 		return nil, err
@@ -81,6 +99,10 @@ func (c *Conn) QueryContext(ctx context.Context, query string, argsV []driver.Na
 	if !c.immuClient.IsConnected() {
 		return nil, driver.ErrBadConn
 	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	queryResult := &schema.SQLQueryResult{}
 
 	vals, err := namedValuesToSqlMap(argsV)
@@ -93,7 +115,7 @@ func (c *Conn) QueryContext(ctx context.Context, query string, argsV []driver.Na
 		if err != nil {
 			return nil, err
 		}
-		return &Rows{rows: queryResult.Rows}, nil
+		return &Rows{columns: queryResult.Columns, rows: queryResult.Rows}, nil
 	}
 
 	queryResult, err = c.immuClient.SQLQuery(ctx, query, vals, true)
@@ -101,7 +123,7 @@ func (c *Conn) QueryContext(ctx context.Context, query string, argsV []driver.Na
 		return nil, err
 	}
 
-	return &Rows{rows: queryResult.Rows}, nil
+	return &Rows{columns: queryResult.Columns, rows: queryResult.Rows}, nil
 }
 
 func (c *Conn) CheckNamedValue(nv *driver.NamedValue) error {
@@ -113,5 +135,5 @@ func (c *Conn) ResetSession(ctx context.Context) error {
 	if !c.immuClient.IsConnected() {
 		return driver.ErrBadConn
 	}
-	return ErrNotImplemented
+	return nil
 }
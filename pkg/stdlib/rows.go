@@ -32,12 +32,19 @@ import (
 )
 
 type Rows struct {
-	index uint64
-	rows  []*schema.Row
+	index   uint64
+	columns []*schema.Column
+	rows    []*schema.Row
 }
 
 func (r *Rows) Columns() []string {
 	names := make([]string, 0)
+	if len(r.columns) > 0 {
+		for _, c := range r.columns {
+			names = append(names, columnName(c.Name))
+		}
+		return names
+	}
 	if len(r.rows) > 0 {
 		for _, n := range r.rows[0].Columns {
 			name := n[strings.LastIndex(n, ".")+1 : len(n)-1]
@@ -47,6 +54,20 @@ func (r *Rows) Columns() []string {
 	return names
 }
 
+// columnName returns the column name out of a selector in the form (db.table.column)
+func columnName(selector string) string {
+	name := strings.TrimSuffix(strings.TrimPrefix(selector, "("), ")")
+	return name[strings.LastIndex(name, ".")+1:]
+}
+
+// columnType returns the column type as reported by the server, if available
+func (r *Rows) columnType(index int) (sql.SQLValueType, bool) {
+	if len(r.columns) == 0 || index < 0 || index >= len(r.columns) {
+		return "", false
+	}
+	return r.columns[index].Type, true
+}
+
 // ColumnTypeDatabaseTypeName
 // 	IntegerType   SQLValueType = "INTEGER"
 //	BooleanType   SQLValueType = "BOOLEAN"
@@ -55,6 +76,11 @@ func (r *Rows) Columns() []string {
 //	TimestampType SQLValueType = "TIMESTAMP"
 //	AnyType       SQLValueType = "ANY"
 func (r *Rows) ColumnTypeDatabaseTypeName(index int) string {
+	if len(r.columns) > 0 {
+		colType, _ := r.columnType(index)
+		return colType
+	}
+
 	if len(r.rows) <= 0 || len(r.rows[0].Values)-1 < index {
 		return ""
 	}
@@ -92,6 +118,22 @@ func (r *Rows) ColumnTypeDatabaseTypeName(index int) string {
 
 // ColumnTypeLength If length is not limited other than system limits, it should return math.MaxInt64
 func (r *Rows) ColumnTypeLength(index int) (int64, bool) {
+	if len(r.columns) > 0 {
+		colType, ok := r.columnType(index)
+		if !ok {
+			return 0, false
+		}
+
+		switch colType {
+		case sql.IntegerType:
+			return 8, false
+		case sql.BooleanType:
+			return 1, false
+		default:
+			return math.MaxInt64, true
+		}
+	}
+
 	if len(r.rows) <= 0 || len(r.rows[0].Values)-1 < index {
 		return 0, false
 	}
@@ -136,6 +178,26 @@ func (r *Rows) ColumnTypePrecisionScale(index int) (precision, scale int64, ok b
 
 // ColumnTypeScanType returns the value type that can be used to scan types into.
 func (r *Rows) ColumnTypeScanType(index int) reflect.Type {
+	if len(r.columns) > 0 {
+		colType, ok := r.columnType(index)
+		if !ok {
+			return nil
+		}
+
+		switch colType {
+		case sql.IntegerType:
+			return reflect.TypeOf(int64(0))
+		case sql.BooleanType:
+			return reflect.TypeOf(true)
+		case sql.BLOBType:
+			return reflect.TypeOf([]byte{})
+		case sql.TimestampType:
+			return reflect.TypeOf(time.Time{})
+		default:
+			return reflect.TypeOf("")
+		}
+	}
+
 	if len(r.rows) <= 0 || len(r.rows[0].Values)-1 < index {
 		return nil
 	}
@@ -212,7 +274,11 @@ func namedValuesToSqlMap(argsV []driver.NamedValue) (map[string]interface{}, err
 	vals := make(map[string]interface{})
 
 	for id, nv := range args {
-		key := "param" + strconv.Itoa(id+1)
+		// named arguments (e.g. sql.Named("id", 1)) are bound to @id, the rest to positional parameters
+		key := argsV[id].Name
+		if key == "" {
+			key = "param" + strconv.Itoa(id+1)
+		}
 		vals[key] = nv
 	}
 
@@ -17,6 +17,7 @@ limitations under the License.
 package stdlib
 
 import (
+	"database/sql/driver"
 	"fmt"
 	"math"
 	"reflect"
@@ -434,3 +435,47 @@ func TestRows_convertToPlainVals(t *testing.T) {
 		})
 	}
 }
+
+func TestRows_ColumnsMetadata(t *testing.T) {
+	r := Rows{
+		columns: []*schema.Column{
+			{Name: "(defaultdb.table1.id)", Type: sql.IntegerType},
+			{Name: "(defaultdb.table1.ts)", Type: sql.TimestampType},
+			{Name: "(defaultdb.table1.content)", Type: sql.BLOBType},
+			{Name: "(defaultdb.table1.active)", Type: sql.BooleanType},
+			{Name: "TABLE", Type: sql.VarcharType},
+		},
+	}
+
+	require.Equal(t, []string{"id", "ts", "content", "active", "TABLE"}, r.Columns())
+
+	require.Equal(t, "TIMESTAMP", r.ColumnTypeDatabaseTypeName(1))
+	require.Equal(t, "", r.ColumnTypeDatabaseTypeName(5))
+
+	require.Equal(t, reflect.TypeOf(int64(0)), r.ColumnTypeScanType(0))
+	require.Equal(t, reflect.TypeOf(time.Time{}), r.ColumnTypeScanType(1))
+	require.Equal(t, reflect.TypeOf([]byte{}), r.ColumnTypeScanType(2))
+	require.Equal(t, reflect.TypeOf(true), r.ColumnTypeScanType(3))
+	require.Equal(t, reflect.TypeOf(""), r.ColumnTypeScanType(4))
+	require.Nil(t, r.ColumnTypeScanType(5))
+
+	l, ok := r.ColumnTypeLength(0)
+	require.Equal(t, int64(8), l)
+	require.False(t, ok)
+
+	l, ok = r.ColumnTypeLength(4)
+	require.Equal(t, int64(math.MaxInt64), l)
+	require.True(t, ok)
+
+	_, ok = r.ColumnTypeLength(5)
+	require.False(t, ok)
+}
+
+func TestNamedValuesToSqlMap(t *testing.T) {
+	vals, err := namedValuesToSqlMap([]driver.NamedValue{
+		{Ordinal: 1, Value: int64(1)},
+		{Ordinal: 2, Name: "title", Value: "immudb"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{"param1": int64(1), "title": "immudb"}, vals)
+}
@@ -482,3 +482,133 @@ func TestConn_QueryContextErr(t *testing.T) {
 	err = immuConn.Ping(context.TODO())
 	require.NoError(t, err)
 }*/
+
+func TestNamedParamsAndTypes(t *testing.T) {
+	options := server.DefaultOptions().WithAuth(true)
+	bs := servertest.NewBufconnServer(options)
+
+	bs.Start()
+	defer bs.Stop()
+
+	defer os.RemoveAll(options.Dir)
+	defer os.Remove(".state-")
+
+	opts := client.DefaultOptions()
+	opts.Username = "immudb"
+	opts.Password = "immudb"
+	opts.Database = "defaultdb"
+
+	opts.WithDialOptions([]grpc.DialOption{grpc.WithContextDialer(bs.Dialer), grpc.WithInsecure()})
+
+	db := OpenDB(opts)
+	defer db.Close()
+
+	err := db.PingContext(context.TODO())
+	require.NoError(t, err)
+
+	table := getRandomTableName()
+	_, err = db.ExecContext(context.TODO(), fmt.Sprintf("CREATE TABLE %s(id INTEGER, ts TIMESTAMP, content BLOB, active BOOLEAN, PRIMARY KEY id)", table))
+	require.NoError(t, err)
+
+	ts := time.Date(2021, 12, 1, 10, 30, 0, 0, time.UTC)
+
+	_, err = db.ExecContext(context.TODO(),
+		fmt.Sprintf("INSERT INTO %s (id, ts, content, active) VALUES (@id, @ts, @content, @active)", table),
+		sql.Named("id", 1),
+		sql.Named("ts", ts),
+		sql.Named("content", []byte{0xca, 0xfe}),
+		sql.Named("active", true),
+	)
+	require.NoError(t, err)
+
+	stmt, err := db.PrepareContext(context.TODO(), fmt.Sprintf("INSERT INTO %s (id, ts, content, active) VALUES (?, ?, ?, ?)", table))
+	require.NoError(t, err)
+
+	_, err = stmt.Exec(2, nil, nil, false)
+	require.NoError(t, err)
+
+	err = stmt.Close()
+	require.NoError(t, err)
+
+	rows, err := db.QueryContext(context.TODO(), fmt.Sprintf("SELECT id, ts, content, active FROM %s WHERE id = @id", table), sql.Named("id", 1))
+	require.NoError(t, err)
+	defer rows.Close()
+
+	cols, err := rows.Columns()
+	require.NoError(t, err)
+	require.Equal(t, []string{"id", "ts", "content", "active"}, cols)
+
+	colTypes, err := rows.ColumnTypes()
+	require.NoError(t, err)
+	require.Equal(t, "INTEGER", colTypes[0].DatabaseTypeName())
+	require.Equal(t, "TIMESTAMP", colTypes[1].DatabaseTypeName())
+	require.Equal(t, "BLOB", colTypes[2].DatabaseTypeName())
+	require.Equal(t, "BOOLEAN", colTypes[3].DatabaseTypeName())
+
+	var id int64
+	var rts time.Time
+	var content []byte
+	var active bool
+
+	require.True(t, rows.Next())
+	err = rows.Scan(&id, &rts, &content, &active)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), id)
+	require.True(t, ts.Equal(rts))
+	require.Equal(t, []byte{0xca, 0xfe}, content)
+	require.True(t, active)
+	require.False(t, rows.Next())
+
+	t.Run("null values should be scanned into nullable types", func(t *testing.T) {
+		var nts sql.NullTime
+		var nactive sql.NullBool
+
+		err = db.QueryRowContext(context.TODO(), fmt.Sprintf("SELECT ts, active FROM %s WHERE id = 2", table)).Scan(&nts, &nactive)
+		require.NoError(t, err)
+		require.False(t, nts.Valid)
+		require.True(t, nactive.Valid)
+		require.False(t, nactive.Bool)
+	})
+
+	t.Run("column metadata should be available on empty results", func(t *testing.T) {
+		rows, err := db.QueryContext(context.TODO(), fmt.Sprintf("SELECT id, active FROM %s WHERE id > 100", table))
+		require.NoError(t, err)
+		defer rows.Close()
+
+		cols, err := rows.Columns()
+		require.NoError(t, err)
+		require.Equal(t, []string{"id", "active"}, cols)
+		require.False(t, rows.Next())
+	})
+
+	t.Run("cancelled context should abort execution", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := db.ExecContext(ctx, fmt.Sprintf("INSERT INTO %s (id) VALUES (3)", table))
+		require.ErrorIs(t, err, context.Canceled)
+
+		_, err = db.QueryContext(ctx, fmt.Sprintf("SELECT id FROM %s", table))
+		require.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("rolled back transactions should not persist changes", func(t *testing.T) {
+		tx, err := db.BeginTx(context.TODO(), nil)
+		require.NoError(t, err)
+
+		_, err = tx.ExecContext(context.TODO(), fmt.Sprintf("INSERT INTO %s (id, active) VALUES (@id, @active)", table), sql.Named("id", 10), sql.Named("active", true))
+		require.NoError(t, err)
+
+		var count int64
+		err = tx.QueryRowContext(context.TODO(), fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE id = 10", table)).Scan(&count)
+		require.NoError(t, err)
+		require.Equal(t, int64(1), count)
+
+		err = tx.Rollback()
+		require.NoError(t, err)
+
+		err = db.QueryRowContext(context.TODO(), fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE id = 10", table)).Scan(&count)
+		require.NoError(t, err)
+		require.Equal(t, int64(0), count)
+	})
+}
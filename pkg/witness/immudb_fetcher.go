@@ -0,0 +1,197 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package witness
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/client"
+	"github.com/golang/protobuf/ptypes/empty"
+	"google.golang.org/grpc/metadata"
+)
+
+type immudbFetcher struct {
+	opts *Options
+
+	client        client.ImmuClient
+	clientContext context.Context
+
+	mutex sync.Mutex
+}
+
+// NewImmudbFetcher returns a RootFetcher observing the state of a remote immudb database.
+// Consistency between observed states is checked by means of the dual proof provided by the remote server.
+func NewImmudbFetcher(opts *Options) (RootFetcher, error) {
+	if opts == nil || !opts.Valid() {
+		return nil, ErrIllegalArguments
+	}
+
+	return &immudbFetcher{opts: opts}, nil
+}
+
+func (f *immudbFetcher) FetchRoot(ctx context.Context, prev *schema.ExternalRoot) (*schema.ExternalRoot, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.client == nil {
+		err := f.connect(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	root, err := f.fetchRoot(prev)
+	if err != nil {
+		// reconnect on next attempt
+		f.disconnect()
+		return nil, err
+	}
+
+	return root, nil
+}
+
+func (f *immudbFetcher) fetchRoot(prev *schema.ExternalRoot) (*schema.ExternalRoot, error) {
+	state, err := f.client.GetServiceClient().CurrentState(f.clientContext, &empty.Empty{})
+	if err != nil {
+		return nil, err
+	}
+
+	if state.TxId == 0 {
+		if prev != nil {
+			return nil, &InconsistentRootError{
+				Prev:   prev,
+				Reason: fmt.Sprintf("database is empty but a root at size %d was previously observed", prev.TreeSize),
+			}
+		}
+		return nil, nil
+	}
+
+	root := &schema.ExternalRoot{
+		Source:    f.opts.source,
+		Type:      schema.ExternalRootType_IMMUDB,
+		TreeSize:  state.TxId,
+		RootHash:  state.TxHash,
+		Signature: state.Signature,
+	}
+
+	if prev == nil {
+		return root, nil
+	}
+
+	if state.TxId < prev.TreeSize {
+		return nil, &InconsistentRootError{
+			Prev:   prev,
+			Root:   root,
+			Reason: fmt.Sprintf("size %d is smaller than previously observed size %d", state.TxId, prev.TreeSize),
+		}
+	}
+
+	if state.TxId == prev.TreeSize {
+		if !bytes.Equal(state.TxHash, prev.RootHash) {
+			return nil, &InconsistentRootError{
+				Prev:   prev,
+				Root:   root,
+				Reason: fmt.Sprintf("hash mismatch at size %d", state.TxId),
+			}
+		}
+
+		root.Verified = true
+
+		return root, nil
+	}
+
+	vtx, err := f.client.GetServiceClient().VerifiableTxById(f.clientContext, &schema.VerifiableTxRequest{
+		Tx:           state.TxId,
+		ProveSinceTx: prev.TreeSize,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	verifies := store.VerifyDualProof(
+		schema.DualProofFromProto(vtx.DualProof),
+		prev.TreeSize,
+		state.TxId,
+		schema.DigestFromProto(prev.RootHash),
+		schema.DigestFromProto(state.TxHash),
+	)
+	if !verifies {
+		return nil, &InconsistentRootError{
+			Prev:   prev,
+			Root:   root,
+			Proof:  vtx.DualProof,
+			Reason: fmt.Sprintf("consistency proof between sizes %d and %d does not verify", prev.TreeSize, state.TxId),
+		}
+	}
+
+	root.Verified = true
+
+	return root, nil
+}
+
+func (f *immudbFetcher) connect(ctx context.Context) error {
+	opts := client.DefaultOptions().WithAddress(f.opts.srcAddress).WithPort(f.opts.srcPort)
+	client, err := client.NewImmuClient(opts)
+	if err != nil {
+		return err
+	}
+
+	login, err := client.Login(ctx, []byte(f.opts.srcUsername), []byte(f.opts.srcPassword))
+	if err != nil {
+		client.Disconnect()
+		return err
+	}
+
+	f.clientContext = metadata.NewOutgoingContext(ctx, metadata.Pairs("authorization", login.GetToken()))
+
+	udr, err := client.UseDatabase(f.clientContext, &schema.Database{DatabaseName: f.opts.srcDatabase})
+	if err != nil {
+		client.Disconnect()
+		return err
+	}
+
+	f.clientContext = metadata.NewOutgoingContext(f.clientContext, metadata.Pairs("authorization", udr.GetToken()))
+
+	f.client = client
+
+	return nil
+}
+
+func (f *immudbFetcher) disconnect() {
+	if f.client == nil {
+		return
+	}
+
+	f.client.Logout(f.clientContext)
+	f.client.Disconnect()
+
+	f.client = nil
+}
+
+func (f *immudbFetcher) Close() error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.disconnect()
+
+	return nil
+}
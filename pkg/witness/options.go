@@ -0,0 +1,99 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package witness
+
+import (
+	"time"
+
+	"github.com/codenotary/immudb/pkg/alert"
+)
+
+const DefaultFetchInterval = time.Minute
+
+type Options struct {
+	source string
+
+	srcDatabase string
+	srcAddress  string
+	srcPort     int
+	srcUsername string
+	srcPassword string
+
+	fetchInterval time.Duration
+
+	alertSink alert.Sink
+}
+
+func DefaultOptions() *Options {
+	return &Options{
+		fetchInterval: DefaultFetchInterval,
+	}
+}
+
+func (opts *Options) Valid() bool {
+	return opts != nil &&
+		opts.source != "" &&
+		opts.fetchInterval > 0
+}
+
+// WithSource sets the name under which observed roots are registered
+func (o *Options) WithSource(source string) *Options {
+	o.source = source
+	return o
+}
+
+// WithSrcDatabase sets the observed database name
+func (o *Options) WithSrcDatabase(srcDatabase string) *Options {
+	o.srcDatabase = srcDatabase
+	return o
+}
+
+// WithSrcAddress sets the observed database address
+func (o *Options) WithSrcAddress(srcAddress string) *Options {
+	o.srcAddress = srcAddress
+	return o
+}
+
+// WithSrcPort sets the observed database port
+func (o *Options) WithSrcPort(srcPort int) *Options {
+	o.srcPort = srcPort
+	return o
+}
+
+// WithSrcUsername sets username used to fetch roots
+func (o *Options) WithSrcUsername(srcUsername string) *Options {
+	o.srcUsername = srcUsername
+	return o
+}
+
+// WithSrcPassword sets password used to fetch roots
+func (o *Options) WithSrcPassword(srcPassword string) *Options {
+	o.srcPassword = srcPassword
+	return o
+}
+
+// WithFetchInterval sets the time between two consecutive root observations
+func (o *Options) WithFetchInterval(fetchInterval time.Duration) *Options {
+	o.fetchInterval = fetchInterval
+	return o
+}
+
+// WithAlertSink sets where inconsistent roots are reported, besides being logged
+func (o *Options) WithAlertSink(alertSink alert.Sink) *Options {
+	o.alertSink = alertSink
+	return o
+}
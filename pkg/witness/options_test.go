@@ -0,0 +1,55 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package witness
+
+import (
+	"testing"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/alert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOptions(t *testing.T) {
+	opts := &Options{}
+	require.False(t, opts.Valid())
+
+	opts.WithSource("remote").
+		WithSrcDatabase("defaultdb").
+		WithSrcAddress("127.0.0.1").
+		WithSrcPort(3322).
+		WithSrcUsername("immudbUsr").
+		WithSrcPassword("immdubPwd").
+		WithFetchInterval(time.Second).
+		WithAlertSink(alert.Sinks{})
+
+	require.Equal(t, "remote", opts.source)
+	require.Equal(t, "defaultdb", opts.srcDatabase)
+	require.Equal(t, "127.0.0.1", opts.srcAddress)
+	require.Equal(t, 3322, opts.srcPort)
+	require.Equal(t, "immudbUsr", opts.srcUsername)
+	require.Equal(t, "immdubPwd", opts.srcPassword)
+	require.Equal(t, time.Second, opts.fetchInterval)
+	require.Equal(t, alert.Sinks{}, opts.alertSink)
+
+	require.True(t, opts.Valid())
+
+	defaultOpts := DefaultOptions()
+	require.NotNil(t, defaultOpts)
+	require.False(t, defaultOpts.Valid())
+	require.True(t, defaultOpts.WithSource("remote").Valid())
+}
@@ -0,0 +1,226 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package witness
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/alert"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/database"
+	"github.com/codenotary/immudb/pkg/logger"
+)
+
+var ErrIllegalArguments = errors.New("illegal arguments")
+var ErrAlreadyRunning = errors.New("already running")
+var ErrAlreadyStopped = errors.New("already stopped")
+var ErrInconsistentRoot = errors.New("observed root is not consistent with the previously registered one")
+
+// InconsistentRootError holds the roots found to be inconsistent, it wraps ErrInconsistentRoot
+type InconsistentRootError struct {
+	Prev   *schema.ExternalRoot
+	Root   *schema.ExternalRoot
+	Proof  *schema.DualProof
+	Reason string
+}
+
+func (e *InconsistentRootError) Error() string {
+	return ErrInconsistentRoot.Error() + ": " + e.Reason
+}
+
+func (e *InconsistentRootError) Unwrap() error {
+	return ErrInconsistentRoot
+}
+
+// RootFetcher retrieves the current root of an external log.
+// When a previously registered root is provided, the returned root must be proven to be consistent with it.
+// A nil root is returned when the external log has no root to be observed yet.
+type RootFetcher interface {
+	FetchRoot(ctx context.Context, prev *schema.ExternalRoot) (*schema.ExternalRoot, error)
+	Close() error
+}
+
+// Witness periodically fetches the root of an external log and appends it into the local database,
+// making the database a verifiable witness of the external log history
+type Witness struct {
+	db      database.DB
+	fetcher RootFetcher
+	opts    *Options
+
+	logger logger.Logger
+
+	mainContext context.Context
+	cancelFunc  context.CancelFunc
+	done        chan struct{}
+
+	running bool
+
+	mutex sync.Mutex
+}
+
+func NewWitness(db database.DB, fetcher RootFetcher, opts *Options, logger logger.Logger) (*Witness, error) {
+	if db == nil || fetcher == nil || logger == nil || opts == nil || !opts.Valid() {
+		return nil, ErrIllegalArguments
+	}
+
+	return &Witness{
+		db:      db,
+		fetcher: fetcher,
+		opts:    opts,
+		logger:  logger,
+	}, nil
+}
+
+func (w *Witness) Start() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.running {
+		return ErrAlreadyRunning
+	}
+
+	w.logger.Infof("Initializing witness of '%s' into '%s'...", w.opts.source, w.db.GetName())
+
+	w.mainContext, w.cancelFunc = context.WithCancel(context.Background())
+	w.done = make(chan struct{})
+
+	w.running = true
+
+	go func() {
+		defer close(w.done)
+
+		for {
+			err := w.observe(w.mainContext)
+			if errors.Is(err, ErrInconsistentRoot) {
+				w.logger.Errorf("Inconsistent root observed from '%s'. Reason: %v", w.opts.source, err)
+				w.alert(w.mainContext, err)
+			} else if err != nil {
+				w.logger.Warningf("Failed to observe root of '%s' into '%s'. Reason: %v", w.opts.source, w.db.GetName(), err)
+			}
+
+			timer := time.NewTimer(w.opts.fetchInterval)
+			select {
+			case <-w.mainContext.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+		}
+	}()
+
+	w.logger.Infof("Witness of '%s' into '%s' successfully initialized", w.opts.source, w.db.GetName())
+
+	return nil
+}
+
+// observe fetches the current root of the external log and registers it if it was not yet observed
+func (w *Witness) observe(ctx context.Context) error {
+	prev, err := w.db.LatestExternalRoot(w.opts.source)
+	if err == database.ErrExternalRootNotFound {
+		prev = nil
+	} else if err != nil {
+		return err
+	}
+
+	root, err := w.fetcher.FetchRoot(ctx, prev)
+	if err != nil {
+		return err
+	}
+
+	if root == nil {
+		w.logger.Debugf("No root of '%s' available yet", w.opts.source)
+		return nil
+	}
+
+	if prev != nil && root.TreeSize == prev.TreeSize && bytes.Equal(root.RootHash, prev.RootHash) {
+		w.logger.Debugf("Root of '%s' at size %d already registered", w.opts.source, root.TreeSize)
+		return nil
+	}
+
+	root.Source = w.opts.source
+
+	_, err = w.db.RegisterExternalRoot(root)
+	if err != nil {
+		return err
+	}
+
+	w.logger.Debugf("Root of '%s' at size %d successfully registered into '%s'", w.opts.source, root.TreeSize, w.db.GetName())
+
+	return nil
+}
+
+// alert delivers the inconsistency to the configured alert sink, if any
+func (w *Witness) alert(ctx context.Context, err error) {
+	if w.opts.alertSink == nil {
+		return
+	}
+
+	a := &alert.Alert{
+		Detector:      alert.DetectorWitness,
+		ServerAddress: fmt.Sprintf("%s:%d", w.opts.srcAddress, w.opts.srcPort),
+		Database:      w.opts.srcDatabase,
+		DetectedAt:    time.Now(),
+		Message:       err.Error(),
+	}
+
+	var inconsistency *InconsistentRootError
+	if errors.As(err, &inconsistency) {
+		a.PrevRoot = alert.RootFromExternalRoot(inconsistency.Prev)
+		a.CurrRoot = alert.RootFromExternalRoot(inconsistency.Root)
+
+		proof, perr := alert.EncodeProof(inconsistency.Proof)
+		if perr != nil {
+			w.logger.Warningf("Failed to encode consistency proof of '%s'. Reason: %v", w.opts.source, perr)
+		}
+		a.Proof = proof
+	}
+
+	serr := w.opts.alertSink.Send(ctx, a)
+	if serr != nil {
+		w.logger.Errorf("Failed to deliver alert about '%s'. Reason: %v", w.opts.source, serr)
+	}
+}
+
+func (w *Witness) Stop() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	w.logger.Infof("Stopping witness of '%s' into '%s'...", w.opts.source, w.db.GetName())
+
+	if !w.running {
+		return ErrAlreadyStopped
+	}
+
+	w.cancelFunc()
+	<-w.done
+
+	w.running = false
+
+	err := w.fetcher.Close()
+	if err != nil {
+		w.logger.Warningf("Error closing fetcher of '%s'. Reason: %v", w.opts.source, err)
+	}
+
+	w.logger.Infof("Witness of '%s' into '%s' successfully stopped", w.opts.source, w.db.GetName())
+
+	return nil
+}
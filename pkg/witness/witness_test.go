@@ -0,0 +1,220 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package witness
+
+import (
+	"context"
+	"crypto/sha256"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/alert"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/database"
+	"github.com/codenotary/immudb/pkg/logger"
+	"github.com/stretchr/testify/require"
+)
+
+type fetcherMock struct {
+	roots []*schema.ExternalRoot
+	err   error
+	prevs []*schema.ExternalRoot
+}
+
+func (f *fetcherMock) FetchRoot(ctx context.Context, prev *schema.ExternalRoot) (*schema.ExternalRoot, error) {
+	f.prevs = append(f.prevs, prev)
+
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	if len(f.roots) == 0 {
+		return nil, nil
+	}
+
+	root := f.roots[0]
+	f.roots = f.roots[1:]
+
+	return root, nil
+}
+
+func (f *fetcherMock) Close() error {
+	return nil
+}
+
+type alertSinkMock struct {
+	alerts chan *alert.Alert
+}
+
+func (s *alertSinkMock) Send(ctx context.Context, a *alert.Alert) error {
+	s.alerts <- a
+	return nil
+}
+
+func rootAt(treeSize uint64) *schema.ExternalRoot {
+	h := sha256.Sum256([]byte{byte(treeSize)})
+
+	return &schema.ExternalRoot{
+		Type:     schema.ExternalRootType_IMMUDB,
+		TreeSize: treeSize,
+		RootHash: h[:],
+	}
+}
+
+func TestWitness(t *testing.T) {
+	logger := logger.NewSimpleLogger("logger", os.Stdout)
+
+	db, err := database.NewDB("witness_defaultdb", database.DefaultOption(), logger)
+	require.NoError(t, err)
+
+	defer os.RemoveAll(db.GetOptions().GetDBRootPath())
+
+	opts := DefaultOptions().WithSource("remote").WithFetchInterval(10 * time.Millisecond)
+
+	_, err = NewWitness(nil, nil, nil, nil)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = NewWitness(db, &fetcherMock{}, DefaultOptions(), logger)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	t.Run("observed roots should be registered", func(t *testing.T) {
+		fetcher := &fetcherMock{}
+
+		w, err := NewWitness(db, fetcher, opts, logger)
+		require.NoError(t, err)
+
+		// nothing observed yet
+		err = w.observe(context.Background())
+		require.NoError(t, err)
+		require.Nil(t, fetcher.prevs[0])
+
+		_, err = db.LatestExternalRoot("remote")
+		require.ErrorIs(t, err, database.ErrExternalRootNotFound)
+
+		fetcher.roots = []*schema.ExternalRoot{rootAt(1), rootAt(1), rootAt(3)}
+
+		for i := 0; i < 3; i++ {
+			err = w.observe(context.Background())
+			require.NoError(t, err)
+		}
+
+		require.Equal(t, uint64(1), fetcher.prevs[2].TreeSize)
+		require.Equal(t, uint64(1), fetcher.prevs[3].TreeSize)
+
+		roots, err := db.ExternalRoots(&schema.ExternalRootsRequest{Source: "remote"})
+		require.NoError(t, err)
+		require.Len(t, roots.Roots, 2)
+		require.Equal(t, "remote", roots.Roots[0].Source)
+		require.Equal(t, uint64(3), roots.Roots[1].TreeSize)
+	})
+
+	t.Run("fetching errors should not register any root", func(t *testing.T) {
+		fetcher := &fetcherMock{err: ErrInconsistentRoot}
+
+		w, err := NewWitness(db, fetcher, opts, logger)
+		require.NoError(t, err)
+
+		err = w.observe(context.Background())
+		require.ErrorIs(t, err, ErrInconsistentRoot)
+
+		latest, err := db.LatestExternalRoot("remote")
+		require.NoError(t, err)
+		require.Equal(t, uint64(3), latest.TreeSize)
+	})
+
+	t.Run("inconsistent roots should be alerted", func(t *testing.T) {
+		fetcher := &fetcherMock{err: &InconsistentRootError{
+			Prev:   rootAt(3),
+			Root:   rootAt(3),
+			Reason: "hash mismatch at size 3",
+		}}
+
+		sink := &alertSinkMock{alerts: make(chan *alert.Alert, 1)}
+
+		alertOpts := DefaultOptions().
+			WithSource("remote").
+			WithSrcDatabase("defaultdb").
+			WithSrcAddress("127.0.0.1").
+			WithSrcPort(3322).
+			WithFetchInterval(time.Second).
+			WithAlertSink(sink)
+
+		w, err := NewWitness(db, fetcher, alertOpts, logger)
+		require.NoError(t, err)
+
+		err = w.Start()
+		require.NoError(t, err)
+
+		a := <-sink.alerts
+
+		err = w.Stop()
+		require.NoError(t, err)
+
+		require.Equal(t, alert.DetectorWitness, a.Detector)
+		require.Equal(t, "127.0.0.1:3322", a.ServerAddress)
+		require.Equal(t, "defaultdb", a.Database)
+		require.Equal(t, ErrInconsistentRoot.Error()+": hash mismatch at size 3", a.Message)
+		require.Equal(t, uint64(3), a.PrevRoot.TxID)
+		require.Equal(t, a.PrevRoot, a.CurrRoot)
+	})
+
+	t.Run("roots older than the registered ones should be rejected", func(t *testing.T) {
+		fetcher := &fetcherMock{roots: []*schema.ExternalRoot{rootAt(2)}}
+
+		w, err := NewWitness(db, fetcher, opts, logger)
+		require.NoError(t, err)
+
+		err = w.observe(context.Background())
+		require.ErrorIs(t, err, database.ErrExternalRootOutdated)
+	})
+
+	t.Run("roots should be periodically observed", func(t *testing.T) {
+		fetcher := &fetcherMock{roots: []*schema.ExternalRoot{rootAt(4), rootAt(5)}}
+
+		w, err := NewWitness(db, fetcher, opts, logger)
+		require.NoError(t, err)
+
+		err = w.Stop()
+		require.ErrorIs(t, err, ErrAlreadyStopped)
+
+		err = w.Start()
+		require.NoError(t, err)
+
+		err = w.Start()
+		require.ErrorIs(t, err, ErrAlreadyRunning)
+
+		require.Eventually(t, func() bool {
+			latest, err := db.LatestExternalRoot("remote")
+			return err == nil && latest.TreeSize == 5
+		}, 5*time.Second, 10*time.Millisecond)
+
+		err = w.Stop()
+		require.NoError(t, err)
+	})
+}
+
+func TestImmudbFetcher(t *testing.T) {
+	_, err := NewImmudbFetcher(nil)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	fetcher, err := NewImmudbFetcher(DefaultOptions().WithSource("remote"))
+	require.NoError(t, err)
+
+	err = fetcher.Close()
+	require.NoError(t, err)
+}